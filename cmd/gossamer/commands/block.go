@@ -0,0 +1,95 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/spf13/cobra"
+)
+
+// BlockCmd is the command to inspect a block stored in the node database
+var BlockCmd = &cobra.Command{
+	Use:   "block <number|hash>",
+	Short: "Inspect a block stored in the node database",
+	Long: `The block command opens the node database read-only and prints the
+header fields, the number of extrinsics and whether the block is finalised,
+for the block with the given decimal number or 0x prefixed hash.
+The node must not be running, since it locks its database.
+Examples:
+	gossamer block 42 --base-path ~/.gossamer/westend
+	gossamer block 0x91b171bb158e2d3848fa23a9f1c25182fb8e20313b2c1eb49219da7a70ce90c3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return execBlock(cmd, args[0])
+	},
+}
+
+// execBlock executes the block command
+func execBlock(cmd *cobra.Command, numberOrHash string) (err error) {
+	if basePath == "" {
+		basePath = config.BasePath
+	}
+
+	if basePath == "" {
+		return fmt.Errorf("basepath must be specified")
+	}
+
+	basePath = utils.ExpandDir(basePath)
+
+	db, err := utils.LoadReadOnlyBadgerDB(filepath.Join(basePath, utils.DefaultDatabaseDir))
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer func() {
+		closeErr := db.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("closing database: %w", closeErr)
+		}
+	}()
+
+	blockDatabase := state.NewReadOnlyBlockDatabase(db)
+
+	var summary state.BlockSummary
+	if strings.HasPrefix(numberOrHash, "0x") {
+		hash, err := common.HexToHash(numberOrHash)
+		if err != nil {
+			return fmt.Errorf("parsing block hash: %w", err)
+		}
+		summary, err = state.InspectBlockByHash(blockDatabase, hash)
+		if err != nil {
+			return fmt.Errorf("inspecting block: %w", err)
+		}
+	} else {
+		number, err := strconv.ParseUint(numberOrHash, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing block number: %w", err)
+		}
+		summary, err = state.InspectBlockByNumber(blockDatabase, uint(number))
+		if err != nil {
+			return fmt.Errorf("inspecting block: %w", err)
+		}
+	}
+
+	printBlockSummary(cmd.OutOrStdout(), summary)
+	return nil
+}
+
+func printBlockSummary(w io.Writer, summary state.BlockSummary) {
+	fmt.Fprintf(w, "hash: %s\n", summary.Hash)
+	fmt.Fprintf(w, "number: %d\n", summary.Header.Number)
+	fmt.Fprintf(w, "parent hash: %s\n", summary.Header.ParentHash)
+	fmt.Fprintf(w, "state root: %s\n", summary.Header.StateRoot)
+	fmt.Fprintf(w, "extrinsics root: %s\n", summary.Header.ExtrinsicsRoot)
+	fmt.Fprintf(w, "digest items: %d\n", len(summary.Header.Digest.Types))
+	fmt.Fprintf(w, "extrinsics: %d\n", summary.ExtrinsicsCount)
+	fmt.Fprintf(w, "finalised: %t\n", summary.Finalised)
+}
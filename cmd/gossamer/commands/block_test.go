@@ -0,0 +1,182 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/telemetry"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedBlocks initialises a node database at the base path given and adds
+// a chain of blocks with the given length on top of the genesis block,
+// finalising the blocks up to the finalised number given.
+// It returns the headers of the blocks added.
+func seedBlocks(t *testing.T, basepath string, length, finalisedNumber uint) (headers []*types.Header) {
+	t.Helper()
+
+	rootCmd, err := NewRootCommand()
+	require.NoError(t, err)
+	rootCmd.AddCommand(InitCmd)
+
+	rootCmd.SetArgs([]string{InitCmd.Name(), "--base-path", basepath, "--chain", testChainSpec})
+	err = rootCmd.Execute()
+	require.NoError(t, err)
+
+	stateService := state.NewService(state.Config{
+		Path:      basepath,
+		Telemetry: telemetry.NewNoopMailer(),
+	})
+	err = stateService.SetupBase()
+	require.NoError(t, err)
+	err = stateService.Start()
+	require.NoError(t, err)
+
+	parent := stateService.Block.GenesisHash()
+	for number := uint(1); number <= length; number++ {
+		digest := types.NewDigest()
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, uint64(number)).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		err = digest.Add(*preRuntimeDigest)
+		require.NoError(t, err)
+
+		extrinsics := make([]types.Extrinsic, number)
+		for i := range extrinsics {
+			extrinsics[i] = types.Extrinsic{byte(number), byte(i)}
+		}
+
+		header := types.Header{
+			ParentHash:     parent,
+			Number:         number,
+			StateRoot:      common.Hash{byte(number)},
+			ExtrinsicsRoot: common.Hash{0xee, byte(number)},
+			Digest:         digest,
+		}
+		block := types.NewBlock(header, *types.NewBody(extrinsics))
+		err = stateService.Block.AddBlockWithArrivalTime(&block, time.Unix(0, int64(number)))
+		require.NoError(t, err)
+
+		headers = append(headers, &block.Header)
+		parent = block.Header.Hash()
+	}
+
+	if finalisedNumber > 0 {
+		err = stateService.Block.SetFinalisedHash(headers[finalisedNumber-1].Hash(), 1, 0)
+		require.NoError(t, err)
+	}
+
+	err = stateService.Stop()
+	require.NoError(t, err)
+
+	return headers
+}
+
+// TestBlock test "gossamer block <number|hash> --base-path=basepath"
+func TestBlock(t *testing.T) {
+	basepath := t.TempDir()
+	headers := seedBlocks(t, basepath, 3, 1)
+
+	expectedOutput := func(header *types.Header, extrinsics int, finalised bool) string {
+		return fmt.Sprintf("hash: %s\n"+
+			"number: %d\n"+
+			"parent hash: %s\n"+
+			"state root: %s\n"+
+			"extrinsics root: %s\n"+
+			"digest items: 1\n"+
+			"extrinsics: %d\n"+
+			"finalised: %t\n",
+			header.Hash(), header.Number, header.ParentHash, header.StateRoot,
+			header.ExtrinsicsRoot, extrinsics, finalised)
+	}
+
+	testCases := map[string]struct {
+		numberOrHash   string
+		expectedOutput string
+		errMessage     string
+	}{
+		"finalised_by_number": {
+			numberOrHash:   "1",
+			expectedOutput: expectedOutput(headers[0], 1, true),
+		},
+		"finalised_by_hash": {
+			numberOrHash:   headers[0].Hash().String(),
+			expectedOutput: expectedOutput(headers[0], 1, true),
+		},
+		"unfinalised_by_number": {
+			numberOrHash:   "2",
+			expectedOutput: expectedOutput(headers[1], 2, false),
+		},
+		"unfinalised_by_hash": {
+			numberOrHash:   headers[2].Hash().String(),
+			expectedOutput: expectedOutput(headers[2], 3, false),
+		},
+		"number_not_found": {
+			numberOrHash: "4",
+			errMessage: "inspecting block: getting block hash for block number 4: " +
+				"Key not found",
+		},
+		"hash_not_found": {
+			numberOrHash: common.Hash{1}.String(),
+			errMessage: "inspecting block: block " + common.Hash{1}.String() +
+				" not found in database",
+		},
+		"invalid_number": {
+			numberOrHash: "one",
+			errMessage:   "parsing block number: strconv.ParseUint: parsing \"one\": invalid syntax",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rootCmd, err := NewRootCommand()
+			require.NoError(t, err)
+			rootCmd.AddCommand(BlockCmd)
+
+			output := bytes.NewBuffer(nil)
+			rootCmd.SetOut(output)
+			rootCmd.SetArgs([]string{BlockCmd.Name(), testCase.numberOrHash, "--base-path", basepath})
+			err = rootCmd.Execute()
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedOutput, output.String())
+		})
+	}
+}
+
+// TestBlockDatabaseLocked test "gossamer block" errors if the database is locked
+func TestBlockDatabaseLocked(t *testing.T) {
+	basepath := t.TempDir()
+	seedBlocks(t, basepath, 1, 0)
+
+	db, err := utils.SetupDatabase(basepath, false)
+	require.NoError(t, err)
+	defer func() {
+		err := db.Close()
+		require.NoError(t, err)
+	}()
+
+	rootCmd, err := NewRootCommand()
+	require.NoError(t, err)
+	rootCmd.AddCommand(BlockCmd)
+
+	rootCmd.SetArgs([]string{BlockCmd.Name(), "1", "--base-path", basepath})
+	err = rootCmd.Execute()
+	assert.ErrorIs(t, err, utils.ErrDatabaseLocked)
+	assert.EqualError(t, err, "opening database: database is locked by another process: "+
+		filepath.Join(basepath, utils.DefaultDatabaseDir))
+}
@@ -21,6 +21,10 @@ func init() {
 	InitCmd.Flags().Bool("force",
 		false,
 		"force reinitialization of node")
+	InitCmd.Flags().StringToString("genesis-storage-override",
+		nil,
+		`Override a genesis storage key with a value, both hex encoded, e.g. 0x01=0x02.
+This flag can be passed multiple times to override multiple keys.`)
 }
 
 // InitCmd is the command to initialise the node
@@ -43,10 +47,17 @@ func execInit(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to get --force: %s", err)
 	}
 
+	genesisStorageOverrides, err := cmd.Flags().GetStringToString("genesis-storage-override")
+	if err != nil {
+		return fmt.Errorf("failed to get --genesis-storage-override: %s", err)
+	}
+	config.GenesisStorageOverrides = genesisStorageOverrides
+
 	if dot.IsNodeInitialised(config.BasePath) {
 		// prompt user to confirm reinitialization
 		if force || confirmMessage("Are you sure you want to reinitialise the node? [Y/n]") {
 			logger.Info("reinitialising node at base path " + config.BasePath + "...")
+			config.ForceInit = true
 		} else {
 			logger.Warn("exiting without reinitialising the node at base path " + config.BasePath + "...")
 			return nil // exit if reinitialization is not confirmed
@@ -0,0 +1,111 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	KeystoreCmd.PersistentFlags().String("keystore-path", "",
+		"path to the directory containing the keystore, defaults to the base path")
+	KeystoreCmd.PersistentFlags().String("password", "",
+		"passphrase used to encrypt or decrypt the keystore export, prompted for if not given")
+	KeystoreCmd.AddCommand(keystoreExportCmd, keystoreImportCmd)
+}
+
+// KeystoreCmd is the command to export and import the gossamer keystore
+var KeystoreCmd = &cobra.Command{
+	Use:   "keystore",
+	Short: "Export and import the node keystore",
+	Long: `The keystore command is used to move the keys of the gossamer keystore between nodes.
+The keys are exported to a single file encrypted with a passphrase, and each key
+stays encrypted with its own password.
+Examples:
+
+To export the keystore:
+	gossamer keystore export keystore-export.json --keystore-path=path/to/location
+To import the keystore:
+	gossamer keystore import keystore-export.json --keystore-path=path/to/location`,
+}
+
+var keystoreExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export all the keys of the keystore to an encrypted file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportKeystore(cmd, args[0])
+	},
+}
+
+var keystoreImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the keys of an encrypted keystore export file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importKeystore(cmd, args[0])
+	},
+}
+
+// exportKeystore exports the keys of the keystore to the file given
+func exportKeystore(cmd *cobra.Command, file string) error {
+	keystorePath, password, err := getKeystoreFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	keys, err := keystore.ExportKeystore(keystorePath, file, password)
+	if err != nil {
+		return fmt.Errorf("exporting keystore: %w", err)
+	}
+
+	logger.Infof("exported %d keys to %s", keys, file)
+	return nil
+}
+
+// importKeystore imports the keys of the keystore export file given
+func importKeystore(cmd *cobra.Command, file string) error {
+	keystorePath, password, err := getKeystoreFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	keys, err := keystore.ImportKeystore(file, keystorePath, password)
+	if err != nil {
+		return fmt.Errorf("importing keystore: %w", err)
+	}
+
+	logger.Infof("imported %d keys from %s", keys, file)
+	return nil
+}
+
+// getKeystoreFlags returns the keystore path and the export passphrase,
+// prompting for the passphrase if it is not given with the password flag.
+func getKeystoreFlags(cmd *cobra.Command) (keystorePath string, password []byte, err error) {
+	keystorePath, err = cmd.Flags().GetString("keystore-path")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get keystore-path: %s", err)
+	}
+	if keystorePath == "" {
+		keystorePath = basePath
+	}
+	if keystorePath == "" {
+		return "", nil, fmt.Errorf("keystore-path cannot be empty")
+	}
+
+	passwordFlag, err := cmd.Flags().GetString("password")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get password: %s", err)
+	}
+
+	password = []byte(passwordFlag)
+	if len(password) == 0 {
+		password = getPassword("Enter the passphrase of the keystore export:")
+	}
+
+	return keystorePath, password, nil
+}
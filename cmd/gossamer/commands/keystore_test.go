@@ -0,0 +1,52 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKeystoreExportImport test "gossamer keystore export" and "gossamer keystore import"
+func TestKeystoreExportImport(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	exportFile := filepath.Join(t.TempDir(), "keystore-export.json")
+
+	rootCmd, err := NewRootCommand()
+	require.NoError(t, err)
+	rootCmd.AddCommand(AccountCmd, KeystoreCmd)
+
+	rootCmd.SetArgs([]string{"account", "generate", fmt.Sprintf("--keystore-path=%s", sourceDir)})
+	err = rootCmd.Execute()
+	require.NoError(t, err)
+
+	rootCmd.SetArgs([]string{"keystore", "export", exportFile,
+		fmt.Sprintf("--keystore-path=%s", sourceDir), "--password=VerySecurePassword"})
+	err = rootCmd.Execute()
+	require.NoError(t, err)
+
+	rootCmd.SetArgs([]string{"keystore", "import", exportFile,
+		fmt.Sprintf("--keystore-path=%s", targetDir), "--password=WrongPassword"})
+	err = rootCmd.Execute()
+	assert.ErrorIs(t, err, keystore.ErrExportAuthentication)
+
+	rootCmd.SetArgs([]string{"keystore", "import", exportFile,
+		fmt.Sprintf("--keystore-path=%s", targetDir), "--password=VerySecurePassword"})
+	err = rootCmd.Execute()
+	require.NoError(t, err)
+
+	sourceKeys, err := utils.KeystoreFiles(sourceDir)
+	require.NoError(t, err)
+	targetKeys, err := utils.KeystoreFiles(targetDir)
+	require.NoError(t, err)
+	assert.Len(t, targetKeys, 1)
+	assert.Equal(t, sourceKeys, targetKeys)
+}
@@ -29,7 +29,6 @@
 	logLevel string
 
 	// Base Config
-	pruning       string
 	telemetryURLs string
 
 	// Core Config
@@ -222,6 +221,13 @@ func addBaseConfigFlags(cmd *cobra.Command) error {
 		"id"); err != nil {
 		return fmt.Errorf("failed to add --id flag: %s", err)
 	}
+	if err := addStringFlagBindViper(cmd,
+		"log-format",
+		config.BaseConfig.LogFormat,
+		"Log format, one of console or json",
+		"log-format"); err != nil {
+		return fmt.Errorf("failed to add --log-format flag: %s", err)
+	}
 	if err := addBoolFlagBindViper(cmd,
 		"no-telemetry",
 		config.BaseConfig.NoTelemetry,
@@ -243,10 +249,14 @@ func addBaseConfigFlags(cmd *cobra.Command) error {
 		"retain-blocks"); err != nil {
 		return fmt.Errorf("failed to add --retain-blocks flag: %s", err)
 	}
-	cmd.Flags().StringVar(&pruning,
+	if err := addStringFlagBindViper(cmd,
 		"state-pruning",
 		string(config.BaseConfig.Pruning),
-		"State trie online pruning")
+		"State trie online pruning mode, one of archive or pruned "+
+			"to keep only the state of the last retain-blocks finalised blocks",
+		"pruning"); err != nil {
+		return fmt.Errorf("failed to add --state-pruning flag: %s", err)
+	}
 	if err := addBoolFlagBindViper(cmd,
 		"prometheus-external",
 		config.BaseConfig.PrometheusExternal,
@@ -360,6 +370,24 @@ func addNetworkFlags(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to add --max-peers flag: %s", err)
 	}
 
+	if err := addIntFlagBindViper(cmd,
+		"in-peers",
+		config.Network.InPeers,
+		"Maximum number of incoming connections with non reserved peers, "+
+			"defaults to max-peers - min-peers if 0",
+		"network.in-peers"); err != nil {
+		return fmt.Errorf("failed to add --in-peers flag: %s", err)
+	}
+
+	if err := addIntFlagBindViper(cmd,
+		"out-peers",
+		config.Network.OutPeers,
+		"Maximum number of outgoing connections with non reserved peers, "+
+			"defaults to max-peers / 2 if 0",
+		"network.out-peers"); err != nil {
+		return fmt.Errorf("failed to add --out-peers flag: %s", err)
+	}
+
 	if err := addStringSliceFlagBindViper(cmd,
 		"persistent-peers",
 		config.Network.PersistentPeers,
@@ -408,6 +436,62 @@ func addNetworkFlags(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to add --listen-addr flag: %s", err)
 	}
 
+	if err := addStringSliceFlagBindViper(cmd,
+		"listen-addrs",
+		config.Network.ListenAddresses,
+		"Comma separated list of additional multiaddresses to listen on, for example a QUIC multiaddress",
+		"network.listen-addrs"); err != nil {
+		return fmt.Errorf("failed to add --listen-addrs flag: %s", err)
+	}
+
+	if err := addStringSliceFlagBindViper(cmd,
+		"external-addrs",
+		config.Network.ExternalAddresses,
+		"Comma separated list of multiaddresses advertised to peers, overriding the observed addresses",
+		"network.external-addrs"); err != nil {
+		return fmt.Errorf("failed to add --external-addrs flag: %s", err)
+	}
+
+	if err := addUintFlagBindViper(cmd,
+		"max-block-response-blocks",
+		config.Network.MaxBlockResponseBlocks,
+		"Maximum number of blocks in each block response served, defaults to 128 if 0",
+		"network.max-block-response-blocks"); err != nil {
+		return fmt.Errorf("failed to add --max-block-response-blocks flag: %s", err)
+	}
+
+	if err := addUintFlagBindViper(cmd,
+		"max-block-response-bytes",
+		config.Network.MaxBlockResponseBytes,
+		"Maximum encoded size in bytes of each block response served, defaults to 8 MiB if 0 and capped to 16 MiB",
+		"network.max-block-response-bytes"); err != nil {
+		return fmt.Errorf("failed to add --max-block-response-bytes flag: %s", err)
+	}
+
+	if err := addUintFlagBindViper(cmd,
+		"max-in-flight-block-requests",
+		config.Network.MaxInFlightBlockRequests,
+		"Maximum number of block requests in flight while syncing, defaults to 12 if 0",
+		"network.max-in-flight-block-requests"); err != nil {
+		return fmt.Errorf("failed to add --max-in-flight-block-requests flag: %s", err)
+	}
+
+	if err := addIntFlagBindViper(cmd,
+		"min-bootnodes",
+		config.Network.MinBootnodes,
+		"Number of bootnodes to connect to before starting, defaults to 1 if 0",
+		"network.min-bootnodes"); err != nil {
+		return fmt.Errorf("failed to add --min-bootnodes flag: %s", err)
+	}
+
+	if err := addDurationFlagBindViper(cmd,
+		"bootnode-dial-timeout",
+		config.Network.BootnodeDialTimeout,
+		"Timeout to connect to each bootnode on start, defaults to 3s if 0",
+		"network.bootnode-dial-timeout"); err != nil {
+		return fmt.Errorf("failed to add --bootnode-dial-timeout flag: %s", err)
+	}
+
 	return nil
 }
 
@@ -534,6 +618,54 @@ func addCoreFlags(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to add --grandpa-interval flag: %s", err)
 	}
 
+	if err := addBoolFlagBindViper(cmd,
+		"validate-tries",
+		config.Core.ValidateTries,
+		"Validate the state trie structure of each imported block (debugging, slow)",
+		"core.validate-tries"); err != nil {
+		return fmt.Errorf("failed to add --validate-tries flag: %s", err)
+	}
+
+	if err := addStringFlagBindViper(cmd,
+		"sync",
+		config.Core.SyncMode,
+		"Sync mode, one of 'full', 'fast' or 'warp' to download the state at a recent finalised block",
+		"core.sync"); err != nil {
+		return fmt.Errorf("failed to add --sync flag: %s", err)
+	}
+
+	if err := addBoolFlagBindViper(cmd,
+		"validate-block-announces",
+		config.Core.ValidateBlockAnnounces,
+		"Verify the BABE seal of announced block headers before relaying them",
+		"core.validate-block-announces"); err != nil {
+		return fmt.Errorf("failed to add --validate-block-announces flag: %s", err)
+	}
+
+	if err := addDurationFlagBindViper(cmd,
+		"slot-duration",
+		config.Core.SlotDuration,
+		"Expected BABE slot duration, checked against the runtime on start (0 to use the runtime one)",
+		"core.slot-duration"); err != nil {
+		return fmt.Errorf("failed to add --slot-duration flag: %s", err)
+	}
+
+	if err := addUintFlagBindViper(cmd,
+		"epoch-length",
+		config.Core.EpochLength,
+		"Expected BABE epoch length in slots, checked against the runtime on start (0 to use the runtime one)",
+		"core.epoch-length"); err != nil {
+		return fmt.Errorf("failed to add --epoch-length flag: %s", err)
+	}
+
+	if err := addDurationFlagBindViper(cmd,
+		"untrusted-call-timeout",
+		config.Core.UntrustedCallTimeout,
+		"Time limit of the runtime calls made for untrusted data, such as transactions from the network",
+		"core.untrusted-call-timeout"); err != nil {
+		return fmt.Errorf("failed to add --untrusted-call-timeout flag: %s", err)
+	}
+
 	return nil
 }
 
@@ -545,6 +677,66 @@ func addStateFlags(cmd *cobra.Command) error {
 		"state.rewind"); err != nil {
 		return fmt.Errorf("failed to add --rewind flag: %s", err)
 	}
+	if err := addUintFlagBindViper(cmd,
+		"trie-node-cache-size", config.State.TrieNodeCacheSize,
+		"Size in MiB of the in-memory cache of decoded state trie nodes, 0 to disable it",
+		"state.trie-node-cache-size"); err != nil {
+		return fmt.Errorf("failed to add --trie-node-cache-size flag: %s", err)
+	}
+	if err := addBoolFlagBindViper(cmd,
+		"headers-only", config.State.HeadersOnly,
+		"Do not store the bodies and state of blocks, only their headers",
+		"state.headers-only"); err != nil {
+		return fmt.Errorf("failed to add --headers-only flag: %s", err)
+	}
+	if err := addUintFlagBindViper(cmd,
+		"retain-bodies", config.State.RetainBodies,
+		"Number of bodies of the last finalised blocks kept in the pruned mode, 0 to keep all block bodies",
+		"state.retain-bodies"); err != nil {
+		return fmt.Errorf("failed to add --retain-bodies flag: %s", err)
+	}
+	if err := addUintFlagBindViper(cmd,
+		"storage-metrics-log-threshold", config.State.StorageMetricsLogThreshold,
+		"Number of storage keys changed by a block from which a summary of its storage changes is logged, 0 to disable it",
+		"state.storage-metrics-log-threshold"); err != nil {
+		return fmt.Errorf("failed to add --storage-metrics-log-threshold flag: %s", err)
+	}
+	if err := addUintFlagBindViper(cmd,
+		"db-block-cache-size", config.State.DBBlockCacheSize,
+		"Size in MiB of the cache of the database table blocks read from disk",
+		"state.db-block-cache-size"); err != nil {
+		return fmt.Errorf("failed to add --db-block-cache-size flag: %s", err)
+	}
+	if err := addUintFlagBindViper(cmd,
+		"db-index-cache-size", config.State.DBIndexCacheSize,
+		"Size in MiB of the cache of the database table indices, 0 to keep all the indices in memory",
+		"state.db-index-cache-size"); err != nil {
+		return fmt.Errorf("failed to add --db-index-cache-size flag: %s", err)
+	}
+	if err := addUintFlagBindViper(cmd,
+		"db-value-log-file-size", config.State.DBValueLogFileSize,
+		"Maximum size in MiB of each database value log file",
+		"state.db-value-log-file-size"); err != nil {
+		return fmt.Errorf("failed to add --db-value-log-file-size flag: %s", err)
+	}
+	if err := addUintFlagBindViper(cmd,
+		"db-num-compactors", config.State.DBNumCompactors,
+		"Number of concurrent database compactions, 0 or at least 2",
+		"state.db-num-compactors"); err != nil {
+		return fmt.Errorf("failed to add --db-num-compactors flag: %s", err)
+	}
+	if err := addDurationFlagBindViper(cmd,
+		"db-value-log-gc-interval", config.State.DBValueLogGCInterval,
+		"Interval at which the database value log garbage collection runs, 0 to disable it",
+		"state.db-value-log-gc-interval"); err != nil {
+		return fmt.Errorf("failed to add --db-value-log-gc-interval flag: %s", err)
+	}
+	if err := addFloat64FlagBindViper(cmd,
+		"db-value-log-gc-discard-ratio", config.State.DBValueLogGCDiscardRatio,
+		"Fraction of a database value log file which must be discardable for the garbage collection to rewrite it",
+		"state.db-value-log-gc-discard-ratio"); err != nil {
+		return fmt.Errorf("failed to add --db-value-log-gc-discard-ratio flag: %s", err)
+	}
 
 	return nil
 }
@@ -107,6 +107,18 @@ func addUint16FlagBindViper(
 	return viper.BindPFlag(viperBindName, cmd.PersistentFlags().Lookup(name))
 }
 
+// addFloat64FlagBindViper adds a float64 flag to the given command and binds it to the given viper name
+func addFloat64FlagBindViper(
+	cmd *cobra.Command,
+	name string,
+	defaultValue float64,
+	usage string,
+	viperBindName string,
+) error {
+	cmd.PersistentFlags().Float64(name, defaultValue, usage)
+	return viper.BindPFlag(viperBindName, cmd.PersistentFlags().Lookup(name))
+}
+
 // addDurationFlagBindViper adds a duration flag to the given command and binds it to the given viper name
 func addDurationFlagBindViper(
 	cmd *cobra.Command,
@@ -21,10 +21,12 @@ func main() {
 	rootCmd.AddCommand(
 		commands.InitCmd,
 		commands.AccountCmd,
+		commands.KeystoreCmd,
 		commands.ImportRuntimeCmd,
 		commands.BuildSpecCmd,
 		commands.PruneStateCmd,
 		commands.ImportStateCmd,
+		commands.BlockCmd,
 		commands.VersionCmd,
 	)
 	configureCobraCmd("GSSMR")
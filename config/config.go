@@ -9,6 +9,7 @@
 	"time"
 
 	"github.com/ChainSafe/gossamer/dot/state/pruner"
+	"github.com/ChainSafe/gossamer/internal/database"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/genesis"
 	"github.com/ChainSafe/gossamer/lib/os"
@@ -24,6 +25,8 @@
 	defaultBasePath = "~/.gossamer/gssmr"
 	// DefaultLogLevel is the default log level
 	DefaultLogLevel = "info"
+	// DefaultLogFormat is the default log format
+	DefaultLogFormat = "console"
 	// DefaultPrometheusPort is the default prometheus port
 	DefaultPrometheusPort = uint32(9876)
 	// DefaultRetainBlocks is the default number of blocks to retain
@@ -38,6 +41,28 @@
 	DefaultRole = common.AuthorityRole
 	// DefaultWasmInterpreter is the default wasm interpreter
 	DefaultWasmInterpreter = wasmer.Name
+	// DefaultSyncMode is the default sync mode
+	DefaultSyncMode = "full"
+	// DefaultUntrustedCallTimeout is the default time limit of untrusted runtime calls
+	DefaultUntrustedCallTimeout = wasmer.DefaultUntrustedCallTimeout
+
+	// DefaultTrieNodeCacheSize is the default size in MiB of the trie node cache
+	DefaultTrieNodeCacheSize = 64
+	// DefaultRetainBodies is the default number of finalised block bodies
+	// kept in the pruned mode
+	DefaultRetainBodies = 4096
+	// DefaultDBBlockCacheSize is the default size in MiB of the database block cache
+	DefaultDBBlockCacheSize = database.DefaultBlockCacheSize >> 20
+	// DefaultDBIndexCacheSize is the default size in MiB of the database index cache
+	DefaultDBIndexCacheSize = database.DefaultIndexCacheSize >> 20
+	// DefaultDBValueLogFileSize is the default size in MiB of the database value log files
+	DefaultDBValueLogFileSize = database.DefaultValueLogFileSize >> 20
+	// DefaultDBNumCompactors is the default number of concurrent database compactions
+	DefaultDBNumCompactors = database.DefaultNumCompactors
+	// DefaultDBValueLogGCInterval is the default interval of the database value log garbage collection
+	DefaultDBValueLogGCInterval = database.DefaultValueLogGCInterval
+	// DefaultDBValueLogGCDiscardRatio is the default discard ratio of the database value log garbage collection
+	DefaultDBValueLogGCDiscardRatio = database.DefaultValueLogGCDiscardRatio
 
 	// DefaultNetworkPort is the default network port
 	DefaultNetworkPort = 7001
@@ -131,12 +156,22 @@ type BaseConfig struct {
 	BasePath           string                      `mapstructure:"base-path,omitempty"`
 	ChainSpec          string                      `mapstructure:"chain-spec,omitempty"`
 	LogLevel           string                      `mapstructure:"log-level,omitempty"`
+	LogFormat          string                      `mapstructure:"log-format,omitempty"`
 	PrometheusPort     uint32                      `mapstructure:"prometheus-port,omitempty"`
 	RetainBlocks       uint32                      `mapstructure:"retain-blocks,omitempty"`
 	Pruning            pruner.Mode                 `mapstructure:"pruning,omitempty"`
 	PrometheusExternal bool                        `mapstructure:"prometheus-external,omitempty"`
 	NoTelemetry        bool                        `mapstructure:"no-telemetry"`
 	TelemetryURLs      []genesis.TelemetryEndpoint `mapstructure:"telemetry-urls,omitempty"`
+	// GenesisStorageOverrides maps hex encoded storage keys to hex encoded
+	// values overriding the chain-spec genesis storage when initialising
+	// the node. It is only set by the init command flags and is not part
+	// of the config file.
+	GenesisStorageOverrides map[string]string `mapstructure:"-"`
+	// ForceInit clears an already initialised database when initialising
+	// the node. It is only set by the init command and is not part of the
+	// config file.
+	ForceInit bool `mapstructure:"-"`
 }
 
 // SystemConfig represents the system configuration
@@ -174,26 +209,96 @@ type NetworkConfig struct {
 	NoMDNS            bool          `mapstructure:"no-mdns"`
 	MinPeers          int           `mapstructure:"min-peers"`
 	MaxPeers          int           `mapstructure:"max-peers"`
+	InPeers           int           `mapstructure:"in-peers"`
+	OutPeers          int           `mapstructure:"out-peers"`
 	PersistentPeers   []string      `mapstructure:"persistent-peers"`
 	DiscoveryInterval time.Duration `mapstructure:"discovery-interval"`
 	PublicIP          string        `mapstructure:"public-ip"`
 	PublicDNS         string        `mapstructure:"public-dns"`
 	NodeKey           string        `mapstructure:"node-key"`
 	ListenAddress     string        `mapstructure:"listen-addr"`
+	// ListenAddresses are additional multiaddresses to listen on,
+	// for example a QUIC multiaddress.
+	ListenAddresses []string `mapstructure:"listen-addrs"`
+	// ExternalAddresses are the multiaddresses advertised to peers,
+	// overriding the observed addresses, for nodes behind a NAT.
+	ExternalAddresses []string `mapstructure:"external-addrs"`
+	// MaxBlockResponseBlocks and MaxBlockResponseBytes bound each block
+	// response served, and are the defaults if set to 0. MaxBlockResponseBytes
+	// is capped to the maximum block response size accepted by the network.
+	MaxBlockResponseBlocks uint `mapstructure:"max-block-response-blocks"`
+	MaxBlockResponseBytes  uint `mapstructure:"max-block-response-bytes"`
+	// MaxInFlightBlockRequests is the maximum number of block requests
+	// in flight while syncing, and is the default if set to 0.
+	MaxInFlightBlockRequests uint `mapstructure:"max-in-flight-block-requests"`
+	// MinBootnodes is the number of bootnodes to connect to on start,
+	// and BootnodeDialTimeout the timeout to connect to each of them.
+	// They are the defaults if set to 0.
+	MinBootnodes        int           `mapstructure:"min-bootnodes"`
+	BootnodeDialTimeout time.Duration `mapstructure:"bootnode-dial-timeout"`
 }
 
 // CoreConfig is to marshal/unmarshal toml core config vars
 type CoreConfig struct {
-	Role             common.NetworkRole `mapstructure:"role,omitempty"`
-	BabeAuthority    bool               `mapstructure:"babe-authority"`
-	GrandpaAuthority bool               `mapstructure:"grandpa-authority"`
-	WasmInterpreter  string             `mapstructure:"wasm-interpreter,omitempty"`
-	GrandpaInterval  time.Duration      `mapstructure:"grandpa-interval,omitempty"`
+	Role                   common.NetworkRole `mapstructure:"role,omitempty"`
+	BabeAuthority          bool               `mapstructure:"babe-authority"`
+	GrandpaAuthority       bool               `mapstructure:"grandpa-authority"`
+	WasmInterpreter        string             `mapstructure:"wasm-interpreter,omitempty"`
+	GrandpaInterval        time.Duration      `mapstructure:"grandpa-interval,omitempty"`
+	ValidateTries          bool               `mapstructure:"validate-tries,omitempty"`
+	SyncMode               string             `mapstructure:"sync,omitempty"`
+	ValidateBlockAnnounces bool               `mapstructure:"validate-block-announces"`
+	// SlotDuration and EpochLength are the BABE slot duration and epoch length
+	// expected by the node, checked at startup against the BABE configuration
+	// of the runtime. The runtime values are used if they are set to 0.
+	SlotDuration time.Duration `mapstructure:"slot-duration,omitempty"`
+	EpochLength  uint          `mapstructure:"epoch-length,omitempty"`
+	// UntrustedCallTimeout is the time limit of the runtime calls made for
+	// untrusted data, such as validating transactions from the network.
+	UntrustedCallTimeout time.Duration `mapstructure:"untrusted-call-timeout,omitempty"`
 }
 
 // StateConfig contains the configuration for the state.
 type StateConfig struct {
-	Rewind uint `mapstructure:"rewind,omitempty"`
+	Rewind            uint `mapstructure:"rewind,omitempty"`
+	TrieNodeCacheSize uint `mapstructure:"trie-node-cache-size"`
+	// HeadersOnly can be set to true to not store the bodies and the state
+	// tries of finalised blocks, for nodes only following the chain head.
+	HeadersOnly bool `mapstructure:"headers-only"`
+	// RetainBodies is the number of bodies of the last finalised blocks kept
+	// in the pruned mode, the bodies of older finalised blocks being pruned
+	// while keeping their headers, and 0 to keep all block bodies.
+	RetainBodies uint `mapstructure:"retain-bodies"`
+	// StorageMetricsLogThreshold is the number of storage keys changed by a block
+	// from which a summary of its storage changes is logged, and 0 disables it.
+	StorageMetricsLogThreshold uint `mapstructure:"storage-metrics-log-threshold"`
+	// DBBlockCacheSize is the size in MiB of the cache of the database table blocks.
+	DBBlockCacheSize uint `mapstructure:"db-block-cache-size"`
+	// DBIndexCacheSize is the size in MiB of the cache of the database table
+	// indices, and 0 to keep all the indices in memory.
+	DBIndexCacheSize uint `mapstructure:"db-index-cache-size"`
+	// DBValueLogFileSize is the maximum size in MiB of each database value log file.
+	DBValueLogFileSize uint `mapstructure:"db-value-log-file-size"`
+	// DBNumCompactors is the number of concurrent database compactions.
+	DBNumCompactors uint `mapstructure:"db-num-compactors"`
+	// DBValueLogGCInterval is the interval at which the database value log
+	// garbage collection runs, and 0 disables it.
+	DBValueLogGCInterval time.Duration `mapstructure:"db-value-log-gc-interval"`
+	// DBValueLogGCDiscardRatio is the fraction of a database value log file
+	// which must be discardable for the garbage collection to rewrite it.
+	DBValueLogGCDiscardRatio float64 `mapstructure:"db-value-log-gc-discard-ratio"`
+}
+
+// DatabaseOptions returns the database options from the state configuration.
+func (s *StateConfig) DatabaseOptions() database.Options {
+	return database.Options{
+		BlockCacheSize:         int64(s.DBBlockCacheSize) << 20,
+		IndexCacheSize:         int64(s.DBIndexCacheSize) << 20,
+		ValueLogFileSize:       int64(s.DBValueLogFileSize) << 20,
+		NumCompactors:          int(s.DBNumCompactors),
+		ValueLogGCInterval:     s.DBValueLogGCInterval,
+		ValueLogGCDiscardRatio: s.DBValueLogGCDiscardRatio,
+	}
 }
 
 // RPCConfig is to marshal/unmarshal toml RPC config vars
@@ -240,6 +345,12 @@ func (b *BaseConfig) ValidateBasic() error {
 			uint32Max,
 		)
 	}
+	if !b.Pruning.IsValid() {
+		return fmt.Errorf("invalid pruning mode: %s", b.Pruning)
+	}
+	if b.Pruning == pruner.Pruned && b.RetainBlocks == 0 {
+		return fmt.Errorf("retain-blocks cannot be zero in the %s pruning mode", pruner.Pruned)
+	}
 
 	return nil
 }
@@ -287,6 +398,16 @@ func (c *CoreConfig) ValidateBasic() error {
 
 // ValidateBasic does the basic validation on StateConfig
 func (s *StateConfig) ValidateBasic() error {
+	if s.DBValueLogFileSize >= 2048 {
+		return fmt.Errorf("db-value-log-file-size must be less than 2048 MiB")
+	}
+	if s.DBNumCompactors == 1 {
+		return fmt.Errorf("db-num-compactors cannot be 1")
+	}
+	if s.DBValueLogGCDiscardRatio < 0 || s.DBValueLogGCDiscardRatio >= 1 {
+		return fmt.Errorf("db-value-log-gc-discard-ratio must be between 0 and 1")
+	}
+
 	return nil
 }
 
@@ -335,6 +456,7 @@ func DefaultConfig() *Config {
 			BasePath:           defaultBasePath,
 			ChainSpec:          "",
 			LogLevel:           DefaultLogLevel,
+			LogFormat:          DefaultLogFormat,
 			PrometheusPort:     DefaultPrometheusPort,
 			RetainBlocks:       DefaultRetainBlocks,
 			Pruning:            DefaultPruning,
@@ -359,11 +481,14 @@ func DefaultConfig() *Config {
 			Unlock: "",
 		},
 		Core: &CoreConfig{
-			Role:             DefaultRole,
-			BabeAuthority:    true,
-			GrandpaAuthority: true,
-			WasmInterpreter:  DefaultWasmInterpreter,
-			GrandpaInterval:  DefaultDiscoveryInterval,
+			Role:                   DefaultRole,
+			BabeAuthority:          true,
+			GrandpaAuthority:       true,
+			WasmInterpreter:        DefaultWasmInterpreter,
+			GrandpaInterval:        DefaultDiscoveryInterval,
+			SyncMode:               DefaultSyncMode,
+			ValidateBlockAnnounces: true,
+			UntrustedCallTimeout:   DefaultUntrustedCallTimeout,
 		},
 		Network: &NetworkConfig{
 			Port:              DefaultNetworkPort,
@@ -381,7 +506,15 @@ func DefaultConfig() *Config {
 			ListenAddress:     "",
 		},
 		State: &StateConfig{
-			Rewind: 0,
+			Rewind:                   0,
+			TrieNodeCacheSize:        DefaultTrieNodeCacheSize,
+			RetainBodies:             DefaultRetainBodies,
+			DBBlockCacheSize:         DefaultDBBlockCacheSize,
+			DBIndexCacheSize:         DefaultDBIndexCacheSize,
+			DBValueLogFileSize:       DefaultDBValueLogFileSize,
+			DBNumCompactors:          DefaultDBNumCompactors,
+			DBValueLogGCInterval:     DefaultDBValueLogGCInterval,
+			DBValueLogGCDiscardRatio: DefaultDBValueLogGCDiscardRatio,
 		},
 		RPC: &RPCConfig{
 			RPCExternal:       false,
@@ -416,6 +549,7 @@ func DefaultConfigFromSpec(nodeSpec *genesis.Genesis) *Config {
 			BasePath:           defaultBasePath,
 			ChainSpec:          "",
 			LogLevel:           DefaultLogLevel,
+			LogFormat:          DefaultLogFormat,
 			PrometheusPort:     uint32(9876),
 			RetainBlocks:       DefaultRetainBlocks,
 			Pruning:            DefaultPruning,
@@ -440,11 +574,14 @@ func DefaultConfigFromSpec(nodeSpec *genesis.Genesis) *Config {
 			Unlock: "",
 		},
 		Core: &CoreConfig{
-			Role:             DefaultRole,
-			BabeAuthority:    true,
-			GrandpaAuthority: true,
-			WasmInterpreter:  DefaultWasmInterpreter,
-			GrandpaInterval:  DefaultDiscoveryInterval,
+			Role:                   DefaultRole,
+			BabeAuthority:          true,
+			GrandpaAuthority:       true,
+			WasmInterpreter:        DefaultWasmInterpreter,
+			GrandpaInterval:        DefaultDiscoveryInterval,
+			SyncMode:               DefaultSyncMode,
+			ValidateBlockAnnounces: true,
+			UntrustedCallTimeout:   DefaultUntrustedCallTimeout,
 		},
 		Network: &NetworkConfig{
 			Port:              DefaultNetworkPort,
@@ -462,7 +599,15 @@ func DefaultConfigFromSpec(nodeSpec *genesis.Genesis) *Config {
 			ListenAddress:     "",
 		},
 		State: &StateConfig{
-			Rewind: 0,
+			Rewind:                   0,
+			TrieNodeCacheSize:        DefaultTrieNodeCacheSize,
+			RetainBodies:             DefaultRetainBodies,
+			DBBlockCacheSize:         DefaultDBBlockCacheSize,
+			DBIndexCacheSize:         DefaultDBIndexCacheSize,
+			DBValueLogFileSize:       DefaultDBValueLogFileSize,
+			DBNumCompactors:          DefaultDBNumCompactors,
+			DBValueLogGCInterval:     DefaultDBValueLogGCInterval,
+			DBValueLogGCDiscardRatio: DefaultDBValueLogGCDiscardRatio,
 		},
 		RPC: &RPCConfig{
 			RPCExternal:       false,
@@ -497,12 +642,16 @@ func Copy(c *Config) Config {
 			BasePath:           c.BaseConfig.BasePath,
 			ChainSpec:          c.BaseConfig.ChainSpec,
 			LogLevel:           c.BaseConfig.LogLevel,
+			LogFormat:          c.BaseConfig.LogFormat,
 			PrometheusPort:     c.PrometheusPort,
 			RetainBlocks:       c.RetainBlocks,
 			Pruning:            c.Pruning,
 			PrometheusExternal: c.PrometheusExternal,
 			NoTelemetry:        c.NoTelemetry,
 			TelemetryURLs:      c.TelemetryURLs,
+
+			GenesisStorageOverrides: c.GenesisStorageOverrides,
+			ForceInit:               c.ForceInit,
 		},
 		Log: &LogConfig{
 			Core:    c.Log.Core,
@@ -521,29 +670,54 @@ func Copy(c *Config) Config {
 			Unlock: c.Account.Unlock,
 		},
 		Core: &CoreConfig{
-			Role:             c.Core.Role,
-			BabeAuthority:    c.Core.BabeAuthority,
-			GrandpaAuthority: c.Core.GrandpaAuthority,
-			WasmInterpreter:  c.Core.WasmInterpreter,
-			GrandpaInterval:  c.Core.GrandpaInterval,
+			Role:                   c.Core.Role,
+			BabeAuthority:          c.Core.BabeAuthority,
+			GrandpaAuthority:       c.Core.GrandpaAuthority,
+			WasmInterpreter:        c.Core.WasmInterpreter,
+			GrandpaInterval:        c.Core.GrandpaInterval,
+			ValidateTries:          c.Core.ValidateTries,
+			SyncMode:               c.Core.SyncMode,
+			ValidateBlockAnnounces: c.Core.ValidateBlockAnnounces,
+			SlotDuration:           c.Core.SlotDuration,
+			EpochLength:            c.Core.EpochLength,
+			UntrustedCallTimeout:   c.Core.UntrustedCallTimeout,
 		},
 		Network: &NetworkConfig{
-			Port:              c.Network.Port,
-			Bootnodes:         c.Network.Bootnodes,
-			ProtocolID:        c.Network.ProtocolID,
-			NoBootstrap:       c.Network.NoBootstrap,
-			NoMDNS:            c.Network.NoMDNS,
-			MinPeers:          c.Network.MinPeers,
-			MaxPeers:          c.Network.MaxPeers,
-			PersistentPeers:   c.Network.PersistentPeers,
-			DiscoveryInterval: c.Network.DiscoveryInterval,
-			PublicIP:          c.Network.PublicIP,
-			PublicDNS:         c.Network.PublicDNS,
-			NodeKey:           c.Network.NodeKey,
-			ListenAddress:     c.Network.ListenAddress,
+			Port:                     c.Network.Port,
+			Bootnodes:                c.Network.Bootnodes,
+			ProtocolID:               c.Network.ProtocolID,
+			NoBootstrap:              c.Network.NoBootstrap,
+			NoMDNS:                   c.Network.NoMDNS,
+			MinPeers:                 c.Network.MinPeers,
+			MaxPeers:                 c.Network.MaxPeers,
+			InPeers:                  c.Network.InPeers,
+			OutPeers:                 c.Network.OutPeers,
+			PersistentPeers:          c.Network.PersistentPeers,
+			DiscoveryInterval:        c.Network.DiscoveryInterval,
+			PublicIP:                 c.Network.PublicIP,
+			PublicDNS:                c.Network.PublicDNS,
+			NodeKey:                  c.Network.NodeKey,
+			ListenAddress:            c.Network.ListenAddress,
+			ListenAddresses:          c.Network.ListenAddresses,
+			ExternalAddresses:        c.Network.ExternalAddresses,
+			MaxBlockResponseBlocks:   c.Network.MaxBlockResponseBlocks,
+			MaxBlockResponseBytes:    c.Network.MaxBlockResponseBytes,
+			MaxInFlightBlockRequests: c.Network.MaxInFlightBlockRequests,
+			MinBootnodes:             c.Network.MinBootnodes,
+			BootnodeDialTimeout:      c.Network.BootnodeDialTimeout,
 		},
 		State: &StateConfig{
-			Rewind: c.State.Rewind,
+			Rewind:                     c.State.Rewind,
+			TrieNodeCacheSize:          c.State.TrieNodeCacheSize,
+			HeadersOnly:                c.State.HeadersOnly,
+			RetainBodies:               c.State.RetainBodies,
+			StorageMetricsLogThreshold: c.State.StorageMetricsLogThreshold,
+			DBBlockCacheSize:           c.State.DBBlockCacheSize,
+			DBIndexCacheSize:           c.State.DBIndexCacheSize,
+			DBValueLogFileSize:         c.State.DBValueLogFileSize,
+			DBNumCompactors:            c.State.DBNumCompactors,
+			DBValueLogGCInterval:       c.State.DBValueLogGCInterval,
+			DBValueLogGCDiscardRatio:   c.State.DBValueLogGCDiscardRatio,
 		},
 		RPC: &RPCConfig{
 			UnsafeRPC:         c.RPC.UnsafeRPC,
@@ -0,0 +1,78 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StateConfig_DatabaseOptions(t *testing.T) {
+	t.Parallel()
+
+	stateConfig := &StateConfig{
+		DBBlockCacheSize:         1,
+		DBIndexCacheSize:         2,
+		DBValueLogFileSize:       3,
+		DBNumCompactors:          4,
+		DBValueLogGCInterval:     time.Minute,
+		DBValueLogGCDiscardRatio: 0.7,
+	}
+
+	expected := database.Options{
+		BlockCacheSize:         1 << 20,
+		IndexCacheSize:         2 << 20,
+		ValueLogFileSize:       3 << 20,
+		NumCompactors:          4,
+		ValueLogGCInterval:     time.Minute,
+		ValueLogGCDiscardRatio: 0.7,
+	}
+	assert.Equal(t, expected, stateConfig.DatabaseOptions())
+
+	defaultOptions := DefaultConfig().State.DatabaseOptions()
+	assert.Equal(t, database.DefaultOptions(), defaultOptions)
+}
+
+func Test_StateConfig_ValidateBasic(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stateConfig StateConfig
+		errMessage  string
+	}{
+		"default": {
+			stateConfig: *DefaultConfig().State,
+		},
+		"value_log_file_size_too_large": {
+			stateConfig: StateConfig{DBValueLogFileSize: 2048},
+			errMessage:  "db-value-log-file-size must be less than 2048 MiB",
+		},
+		"single_compactor": {
+			stateConfig: StateConfig{DBNumCompactors: 1},
+			errMessage:  "db-num-compactors cannot be 1",
+		},
+		"discard_ratio_too_large": {
+			stateConfig: StateConfig{DBValueLogGCDiscardRatio: 1},
+			errMessage:  "db-value-log-gc-discard-ratio must be between 0 and 1",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.stateConfig.ValidateBasic()
+
+			if testCase.errMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
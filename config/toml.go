@@ -82,6 +82,11 @@ func WriteConfigFile(basePath string, config *Config) error {
 # Defaults to "info"
 log-level = "{{ .BaseConfig.LogLevel }}"
 
+# Log format
+# One of: console, json
+# Defaults to "console"
+log-format = "{{ .BaseConfig.LogFormat }}"
+
 # Listen address for the prometheus server
 # Defaults to "localhost:9876"
 prometheus-port = {{ .BaseConfig.PrometheusPort }}
@@ -90,7 +95,9 @@ func WriteConfigFile(basePath string, config *Config) error {
 # Defaults to 512
 retain-blocks = {{ .BaseConfig.RetainBlocks }}
 
-# State trie online pruning mode
+# State trie online pruning mode, one of "archive" to keep the state of
+# all blocks, or "pruned" to only keep the state of the last retain-blocks
+# finalised blocks and of the non finalised blocks
 # Defaults to "archive"
 pruning = "{{ .BaseConfig.Pruning }}"
 
@@ -198,6 +205,14 @@ func WriteConfigFile(basePath string, config *Config) error {
 # Defaults to 50
 max-peers = {{ .Network.MaxPeers }}
 
+# Maximum number of incoming connections with non reserved peers
+# Defaults to max-peers - min-peers if set to 0
+in-peers = {{ .Network.InPeers }}
+
+# Maximum number of outgoing connections with non reserved peers
+# Defaults to max-peers / 2 if set to 0
+out-peers = {{ .Network.OutPeers }}
+
 # Comma separated list of peers to always keep connected to
 persistent-peers = "{{ StringsJoin .Network.PersistentPeers ", " }}"
 
@@ -217,6 +232,34 @@ func WriteConfigFile(basePath string, config *Config) error {
 # Multiaddress to listen on
 listen-addr = "{{ .Network.ListenAddress }}"
 
+# Comma separated list of additional multiaddresses to listen on, for example a QUIC multiaddress
+listen-addrs = "{{ StringsJoin .Network.ListenAddresses ", " }}"
+
+# Comma separated list of multiaddresses advertised to peers, overriding the observed addresses
+external-addrs = "{{ StringsJoin .Network.ExternalAddresses ", " }}"
+
+# Maximum number of blocks in each block response served
+# Defaults to 128 if set to 0
+max-block-response-blocks = {{ .Network.MaxBlockResponseBlocks }}
+
+# Maximum encoded size in bytes of each block response served
+# Defaults to 8388608 (8 MiB) if set to 0
+max-block-response-bytes = {{ .Network.MaxBlockResponseBytes }}
+
+# Maximum number of block requests in flight while syncing, the requests
+# to each peer being further limited by a window adapting to its responses
+# Defaults to 12 if set to 0
+max-in-flight-block-requests = {{ .Network.MaxInFlightBlockRequests }}
+
+# Number of bootnodes to connect to before starting, the unreachable ones being skipped
+# Defaults to 1 if set to 0
+min-bootnodes = {{ .Network.MinBootnodes }}
+
+# Timeout to connect to each bootnode on start
+# Format: "10s", "1m", "1h"
+# Defaults to 3s if set to 0
+bootnode-dial-timeout = "{{ .Network.BootnodeDialTimeout }}"
+
 #######################################################
 ###             Core Configuration Options          ###
 #######################################################
@@ -242,6 +285,33 @@ func WriteConfigFile(basePath string, config *Config) error {
 # Grandpa interval
 grandpa-interval = "{{ .Core.GrandpaInterval }}"
 
+# Validate the structure of the state trie of each imported block.
+# This is a debugging option slowing down block import.
+# Defaults to false
+validate-tries = {{ .Core.ValidateTries }}
+
+# Sync mode, one of "full" or "fast".
+# Fast sync downloads the state at a recent finalised block
+# instead of executing all the blocks up to it.
+# Defaults to "full"
+sync = "{{ .Core.SyncMode }}"
+
+# Verify the BABE seal of announced block headers with a known
+# parent before relaying the block announcements.
+# Defaults to true
+validate-block-announces = {{ .Core.ValidateBlockAnnounces }}
+
+# BABE slot duration and epoch length in slots expected by the node.
+# The node fails to start if they differ from the runtime BABE configuration.
+# Defaults to 0 to use the runtime values
+slot-duration = "{{ .Core.SlotDuration }}"
+epoch-length = {{ .Core.EpochLength }}
+
+# Time limit of the runtime calls made for untrusted data, such as
+# validating transactions from the network or RPC state calls.
+# Defaults to "2s"
+untrusted-call-timeout = "{{ .Core.UntrustedCallTimeout }}"
+
 #######################################################
 ###            State Configuration Options          ###
 #######################################################
@@ -250,6 +320,51 @@ func WriteConfigFile(basePath string, config *Config) error {
 # Defaults to 0
 rewind = {{ .State.Rewind }}
 
+# Size in MiB of the in-memory cache of decoded state trie nodes, 0 to disable it
+# Defaults to 64
+trie-node-cache-size = {{ .State.TrieNodeCacheSize }}
+
+# Do not store the bodies and state of blocks, only their headers
+# Defaults to false
+headers-only = {{ .State.HeadersOnly }}
+
+# Number of bodies of the last finalised blocks kept in the pruned mode,
+# the bodies of older finalised blocks being pruned while keeping their
+# headers, 0 to keep all block bodies
+# Defaults to 4096
+retain-bodies = {{ .State.RetainBodies }}
+
+# Number of storage keys changed by a block from which a summary
+# of its storage changes is logged, 0 to disable it
+# Defaults to 0
+storage-metrics-log-threshold = {{ .State.StorageMetricsLogThreshold }}
+
+# Size in MiB of the cache of the database table blocks read from disk
+# Defaults to 256
+db-block-cache-size = {{ .State.DBBlockCacheSize }}
+
+# Size in MiB of the cache of the database table indices,
+# 0 to keep all the indices in memory
+# Defaults to 0
+db-index-cache-size = {{ .State.DBIndexCacheSize }}
+
+# Maximum size in MiB of each database value log file
+# Defaults to 1024
+db-value-log-file-size = {{ .State.DBValueLogFileSize }}
+
+# Number of concurrent database compactions, 0 or at least 2
+# Defaults to 4
+db-num-compactors = {{ .State.DBNumCompactors }}
+
+# Interval at which the database value log garbage collection runs, 0 to disable it
+# Defaults to "10m0s"
+db-value-log-gc-interval = "{{ .State.DBValueLogGCInterval }}"
+
+# Fraction of a database value log file which must be
+# discardable for the garbage collection to rewrite it
+# Defaults to 0.5
+db-value-log-gc-discard-ratio = {{ .State.DBValueLogGCDiscardRatio }}
+
 #######################################################
 ###              RPC Configuration Options          ###
 #######################################################
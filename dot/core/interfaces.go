@@ -11,6 +11,7 @@
 
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto"
@@ -29,17 +30,18 @@ type BlockState interface {
 	BestBlockHeader() (*types.Header, error)
 	AddBlock(*types.Block) error
 	GetBlockStateRoot(bhash common.Hash) (common.Hash, error)
-	RangeInMemory(start, end common.Hash) ([]common.Hash, error)
 	GetBlockBody(hash common.Hash) (*types.Body, error)
+	GetHeader(bhash common.Hash) (*types.Header, error)
 	HandleRuntimeChanges(newState *rtstorage.TrieState, in runtime.Instance, bHash common.Hash) error
 	GetRuntime(blockHash common.Hash) (instance runtime.Instance, err error)
 	StoreRuntime(blockHash common.Hash, runtime runtime.Instance)
-	LowestCommonAncestor(a, b common.Hash) (common.Hash, error)
+	SubscribeReorgs() (events <-chan state.ReorgEvent, unsubscribe func())
 }
 
 // StorageState interface for storage state methods
 type StorageState interface {
 	TrieState(root *common.Hash) (*rtstorage.TrieState, error)
+	QueryTrieState(root *common.Hash) (*rtstorage.TrieState, error)
 	StoreTrie(*rtstorage.TrieState, *types.Header) error
 	GetStateRootFromBlock(bhash *common.Hash) (*common.Hash, error)
 	GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error)
@@ -51,6 +53,7 @@ type TransactionState interface {
 	Push(vt *transaction.ValidTransaction) (common.Hash, error)
 	AddToPool(vt *transaction.ValidTransaction) common.Hash
 	RemoveExtrinsic(ext types.Extrinsic)
+	RemoveInvalidExtrinsic(ext types.Extrinsic)
 	RemoveExtrinsicFromPool(ext types.Extrinsic)
 	PendingInPool() []*transaction.ValidTransaction
 	Exists(ext types.Extrinsic) bool
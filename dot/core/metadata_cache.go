@@ -0,0 +1,55 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// maxCachedMetadata is the maximum number of runtime metadata
+// cached, after which the oldest one is evicted.
+const maxCachedMetadata = 4
+
+// metadataCache is a thread safe cache of the SCALE encoded runtime metadata
+// keyed by runtime code hash, since the metadata of a runtime only changes
+// on runtime upgrades. Its zero value is ready to use.
+type metadataCache struct {
+	mutex              sync.Mutex
+	codeHashToMetadata map[common.Hash][]byte
+	// codeHashes are the code hashes of the metadata cached, in insertion order.
+	codeHashes []common.Hash
+}
+
+// get returns the metadata cached for the given runtime code hash,
+// and false if there is none. The metadata returned must not be modified.
+func (c *metadataCache) get(codeHash common.Hash) (metadata []byte, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	metadata, ok = c.codeHashToMetadata[codeHash]
+	return metadata, ok
+}
+
+// set caches the metadata for the given runtime code hash,
+// evicting the oldest metadata cached if the cache is full.
+func (c *metadataCache) set(codeHash common.Hash, metadata []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.codeHashToMetadata == nil {
+		c.codeHashToMetadata = make(map[common.Hash][]byte, maxCachedMetadata)
+	}
+
+	_, has := c.codeHashToMetadata[codeHash]
+	if !has {
+		c.codeHashes = append(c.codeHashes, codeHash)
+	}
+	c.codeHashToMetadata[codeHash] = metadata
+
+	if len(c.codeHashes) > maxCachedMetadata {
+		delete(c.codeHashToMetadata, c.codeHashes[0])
+		c.codeHashes = c.codeHashes[1:]
+	}
+}
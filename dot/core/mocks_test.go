@@ -10,6 +10,7 @@
 
 	network "github.com/ChainSafe/gossamer/dot/network"
 	peerset "github.com/ChainSafe/gossamer/dot/peerset"
+	state "github.com/ChainSafe/gossamer/dot/state"
 	types "github.com/ChainSafe/gossamer/dot/types"
 	common "github.com/ChainSafe/gossamer/lib/common"
 	runtime "github.com/ChainSafe/gossamer/lib/runtime"
@@ -115,6 +116,21 @@ func (mr *MockBlockStateMockRecorder) GetBlockStateRoot(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockStateRoot", reflect.TypeOf((*MockBlockState)(nil).GetBlockStateRoot), arg0)
 }
 
+// GetHeader mocks base method.
+func (m *MockBlockState) GetHeader(arg0 common.Hash) (*types.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHeader", arg0)
+	ret0, _ := ret[0].(*types.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHeader indicates an expected call of GetHeader.
+func (mr *MockBlockStateMockRecorder) GetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHeader", reflect.TypeOf((*MockBlockState)(nil).GetHeader), arg0)
+}
+
 // GetRuntime mocks base method.
 func (m *MockBlockState) GetRuntime(arg0 common.Hash) (runtime.Instance, error) {
 	m.ctrl.T.Helper()
@@ -144,46 +160,31 @@ func (mr *MockBlockStateMockRecorder) HandleRuntimeChanges(arg0, arg1, arg2 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleRuntimeChanges", reflect.TypeOf((*MockBlockState)(nil).HandleRuntimeChanges), arg0, arg1, arg2)
 }
 
-// LowestCommonAncestor mocks base method.
-func (m *MockBlockState) LowestCommonAncestor(arg0, arg1 common.Hash) (common.Hash, error) {
+// StoreRuntime mocks base method.
+func (m *MockBlockState) StoreRuntime(arg0 common.Hash, arg1 runtime.Instance) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LowestCommonAncestor", arg0, arg1)
-	ret0, _ := ret[0].(common.Hash)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	m.ctrl.Call(m, "StoreRuntime", arg0, arg1)
 }
 
-// LowestCommonAncestor indicates an expected call of LowestCommonAncestor.
-func (mr *MockBlockStateMockRecorder) LowestCommonAncestor(arg0, arg1 interface{}) *gomock.Call {
+// StoreRuntime indicates an expected call of StoreRuntime.
+func (mr *MockBlockStateMockRecorder) StoreRuntime(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LowestCommonAncestor", reflect.TypeOf((*MockBlockState)(nil).LowestCommonAncestor), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRuntime", reflect.TypeOf((*MockBlockState)(nil).StoreRuntime), arg0, arg1)
 }
 
-// RangeInMemory mocks base method.
-func (m *MockBlockState) RangeInMemory(arg0, arg1 common.Hash) ([]common.Hash, error) {
+// SubscribeReorgs mocks base method.
+func (m *MockBlockState) SubscribeReorgs() (<-chan state.ReorgEvent, func()) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RangeInMemory", arg0, arg1)
-	ret0, _ := ret[0].([]common.Hash)
-	ret1, _ := ret[1].(error)
+	ret := m.ctrl.Call(m, "SubscribeReorgs")
+	ret0, _ := ret[0].(<-chan state.ReorgEvent)
+	ret1, _ := ret[1].(func())
 	return ret0, ret1
 }
 
-// RangeInMemory indicates an expected call of RangeInMemory.
-func (mr *MockBlockStateMockRecorder) RangeInMemory(arg0, arg1 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RangeInMemory", reflect.TypeOf((*MockBlockState)(nil).RangeInMemory), arg0, arg1)
-}
-
-// StoreRuntime mocks base method.
-func (m *MockBlockState) StoreRuntime(arg0 common.Hash, arg1 runtime.Instance) {
-	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "StoreRuntime", arg0, arg1)
-}
-
-// StoreRuntime indicates an expected call of StoreRuntime.
-func (mr *MockBlockStateMockRecorder) StoreRuntime(arg0, arg1 interface{}) *gomock.Call {
+// SubscribeReorgs indicates an expected call of SubscribeReorgs.
+func (mr *MockBlockStateMockRecorder) SubscribeReorgs() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRuntime", reflect.TypeOf((*MockBlockState)(nil).StoreRuntime), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeReorgs", reflect.TypeOf((*MockBlockState)(nil).SubscribeReorgs))
 }
 
 // MockStorageState is a mock of StorageState interface.
@@ -251,6 +252,21 @@ func (mr *MockStorageStateMockRecorder) Lock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockStorageState)(nil).Lock))
 }
 
+// QueryTrieState mocks base method.
+func (m *MockStorageState) QueryTrieState(arg0 *common.Hash) (*storage.TrieState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryTrieState", arg0)
+	ret0, _ := ret[0].(*storage.TrieState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryTrieState indicates an expected call of QueryTrieState.
+func (mr *MockStorageStateMockRecorder) QueryTrieState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryTrieState", reflect.TypeOf((*MockStorageState)(nil).QueryTrieState), arg0)
+}
+
 // StoreTrie mocks base method.
 func (m *MockStorageState) StoreTrie(arg0 *storage.TrieState, arg1 *types.Header) error {
 	m.ctrl.T.Helper()
@@ -396,6 +412,18 @@ func (mr *MockTransactionStateMockRecorder) RemoveExtrinsicFromPool(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExtrinsicFromPool", reflect.TypeOf((*MockTransactionState)(nil).RemoveExtrinsicFromPool), arg0)
 }
 
+// RemoveInvalidExtrinsic mocks base method.
+func (m *MockTransactionState) RemoveInvalidExtrinsic(arg0 types.Extrinsic) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RemoveInvalidExtrinsic", arg0)
+}
+
+// RemoveInvalidExtrinsic indicates an expected call of RemoveInvalidExtrinsic.
+func (mr *MockTransactionStateMockRecorder) RemoveInvalidExtrinsic(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveInvalidExtrinsic", reflect.TypeOf((*MockTransactionState)(nil).RemoveInvalidExtrinsic), arg0)
+}
+
 // MockNetwork is a mock of Network interface.
 type MockNetwork struct {
 	ctrl     *gomock.Controller
@@ -11,6 +11,7 @@
 	"sync"
 
 	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/lib/blocktree"
@@ -20,6 +21,7 @@
 	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
 	"github.com/ChainSafe/gossamer/lib/transaction"
+	"github.com/ChainSafe/gossamer/pkg/scale"
 
 	cscale "github.com/centrifuge/go-substrate-rpc-client/v4/scale"
 	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
@@ -41,6 +43,11 @@ type Service struct {
 	blockAddCh chan *types.Block // for asynchronous block handling
 	sync.Mutex                   // lock for channel
 
+	// reorgs receives the reorgs of the best chain once the
+	// service is started, and unsubscribeReorgs closes it.
+	reorgs            <-chan state.ReorgEvent
+	unsubscribeReorgs func()
+
 	// Service interfaces
 	blockState       BlockState
 	storageState     StorageState
@@ -55,6 +62,13 @@ type Service struct {
 	// Keystore
 	keys          *keystore.GlobalKeystore
 	onBlockImport BlockImportDigestHandler
+
+	// validateTries is true to validate the state trie
+	// structure before storing it on block import.
+	validateTries bool
+
+	// metadata caches the runtime metadata by runtime code hash.
+	metadata metadataCache
 }
 
 // Config holds the configuration for the core Service.
@@ -73,6 +87,10 @@ type Config struct {
 	OnBlockImport        BlockImportDigestHandler
 
 	GrandpaState GrandpaState
+
+	// ValidateTries can be set to true to validate the structure of the
+	// state trie of each block handled. This is slow and meant for debugging.
+	ValidateTries bool
 }
 
 // NewService returns a new core service that connects the runtime, BABE
@@ -96,6 +114,7 @@ func NewService(cfg *Config) (*Service, error) {
 		codeSubstitutedState: cfg.CodeSubstitutedState,
 		onBlockImport:        cfg.OnBlockImport,
 		grandpaState:         cfg.GrandpaState,
+		validateTries:        cfg.ValidateTries,
 	}
 
 	return srv, nil
@@ -103,6 +122,7 @@ func NewService(cfg *Config) (*Service, error) {
 
 // Start starts the core service
 func (s *Service) Start() error {
+	s.reorgs, s.unsubscribeReorgs = s.blockState.SubscribeReorgs()
 	go s.handleBlocksAsync()
 	return nil
 }
@@ -114,6 +134,9 @@ func (s *Service) Stop() error {
 
 	s.cancel()
 	close(s.blockAddCh)
+	if s.unsubscribeReorgs != nil {
+		s.unsubscribeReorgs()
+	}
 	return nil
 }
 
@@ -197,6 +220,14 @@ func (s *Service) handleBlock(block *types.Block, state *rtstorage.TrieState) er
 		return ErrNilBlockHandlerParameter
 	}
 
+	if s.validateTries {
+		err := state.Trie().Validate()
+		if err != nil {
+			return fmt.Errorf("validating state trie for block %s: %w",
+				block.Header.Hash(), err)
+		}
+	}
+
 	// store updates state trie nodes in database
 	err := s.storageState.StoreTrie(state, &block.Header)
 	if err != nil {
@@ -282,10 +313,11 @@ func (s *Service) handleCodeSubstitution(hash common.Hash,
 	// this needs to create a new runtime instance, otherwise it will update
 	// the blocks that reference the current runtime version to use the code substition
 	cfg := wasmer.Config{
-		Storage:     state,
-		Keystore:    rt.Keystore(),
-		NodeStorage: rt.NodeStorage(),
-		Network:     rt.NetworkService(),
+		Storage:              state,
+		Keystore:             rt.Keystore(),
+		NodeStorage:          rt.NodeStorage(),
+		Network:              rt.NetworkService(),
+		UntrustedCallTimeout: rt.UntrustedCallTimeout(),
 	}
 
 	if rt.Validator() {
@@ -307,7 +339,8 @@ func (s *Service) handleCodeSubstitution(hash common.Hash,
 }
 
 // handleBlocksAsync handles a block asynchronously; the handling performed by this function
-// does not need to be completed before the next block can be imported.
+// does not need to be completed before the next block can be imported. It also handles the
+// reorgs of the best chain, such that the transaction pool is maintained by a single goroutine.
 func (s *Service) handleBlocksAsync() {
 	for {
 		select {
@@ -321,14 +354,18 @@ func (s *Service) handleBlocksAsync() {
 			}
 
 			bestBlockHash := s.blockState.BestBlockHash()
-			if err := s.handleChainReorg(bestBlockHash, block.Header.Hash()); err != nil {
+			if err := s.maintainTransactionPool(block, bestBlockHash); err != nil {
 				// TODO remove once gossamer is in stable state
-				panic(fmt.Errorf("failed to re-add transactions to chain upon re-org: %s", err))
+				panic(fmt.Errorf("failed to maintain txn pool after re-org: %s", err))
+			}
+		case reorg, ok := <-s.reorgs:
+			if !ok {
+				return
 			}
 
-			if err := s.maintainTransactionPool(block, bestBlockHash); err != nil {
+			if err := s.handleReorg(reorg); err != nil {
 				// TODO remove once gossamer is in stable state
-				panic(fmt.Errorf("failed to maintain txn pool after re-org: %s", err))
+				panic(fmt.Errorf("failed to re-add transactions to chain upon re-org: %s", err))
 			}
 		case <-s.ctx.Done():
 			return
@@ -336,57 +373,69 @@ func (s *Service) handleBlocksAsync() {
 	}
 }
 
-// handleChainReorg checks if there is a chain re-org (ie. new chain head is on a different chain than the
-// previous chain head). If there is a re-org, it moves the transactions that were included on the previous
-// chain back into the transaction pool.
-func (s *Service) handleChainReorg(best, curr common.Hash) error {
-	ancestor, err := s.blockState.LowestCommonAncestor(best, curr)
-	if err != nil {
-		return err
-	}
-
-	// if the highest common ancestor of the previous chain head and current chain head is the previous chain head,
-	// then the current chain head is the descendant of the previous and thus are on the same chain
-	if ancestor == best || ancestor == curr {
+// handleReorg moves the transactions included in the blocks retracted from the best chain
+// by the reorg given back into the transaction pool, unless they are included in the blocks
+// enacted, once they are re-validated at the state of the new best block.
+// The transactions no longer valid, such as the expired ones, are removed from the
+// transaction state and their watchers are notified they are invalid.
+func (s *Service) handleReorg(reorg state.ReorgEvent) error {
+	if len(reorg.Retracted) == 0 {
 		return nil
 	}
 
-	subchain, err := s.blockState.RangeInMemory(ancestor, best)
-	if err != nil {
-		return err
+	bestBlockHash := reorg.CommonAncestor
+	if len(reorg.Enacted) > 0 {
+		bestBlockHash = reorg.Enacted[len(reorg.Enacted)-1]
 	}
 
-	// subchain contains the ancestor as well so we need to remove it.
-	if len(subchain) > 0 {
-		subchain = subchain[1:]
-	} else {
-		return nil
+	enactedExtrinsics := make(map[common.Hash]struct{})
+	for _, hash := range reorg.Enacted {
+		body, err := s.blockState.GetBlockBody(hash)
+		if err != nil {
+			return fmt.Errorf("getting body of enacted block %s: %w", hash, err)
+		}
+
+		for _, ext := range *body {
+			enactedExtrinsics[ext.Hash()] = struct{}{}
+		}
 	}
 
-	// Check transaction validation on the best block.
-	bestBlockHash := s.blockState.BestBlockHash()
 	rt, err := s.blockState.GetRuntime(bestBlockHash)
 	if err != nil {
-		return err
+		return fmt.Errorf("getting runtime: %w", err)
+	}
+
+	stateRoot, err := s.storageState.GetStateRootFromBlock(&bestBlockHash)
+	if err != nil {
+		return fmt.Errorf("getting state root from block hash: %w", err)
 	}
 
-	if rt == nil {
-		return ErrNilRuntime
+	trieState, err := s.storageState.TrieState(stateRoot)
+	if err != nil {
+		return fmt.Errorf("getting trie state: %w", err)
 	}
+	rt.SetContextStorage(trieState)
 
-	// for each block in the previous chain, re-add its extrinsics back into the pool
-	for _, hash := range subchain {
+	// The retracted blocks are walked from the common ancestor up, such that
+	// the transactions of a same sender are re-added in their nonce order.
+	for i := len(reorg.Retracted) - 1; i >= 0; i-- {
+		hash := reorg.Retracted[i]
 		body, err := s.blockState.GetBlockBody(hash)
-		if err != nil || body == nil {
+		if err != nil {
+			// the retracted block may be pruned meanwhile
+			logger.Debugf("cannot get body of retracted block %s: %s", hash, err)
 			continue
 		}
 
 		for _, ext := range *body {
-			logger.Tracef("validating transaction on re-org chain for extrinsic %s", ext)
+			if _, enacted := enactedExtrinsics[ext.Hash()]; enacted {
+				continue
+			}
+
 			decExt := &ctypes.Extrinsic{}
 			decoder := cscale.NewDecoder(bytes.NewReader(ext))
 			if err = decoder.Decode(&decExt); err != nil {
-				return fmt.Errorf("decoding extrinsic: %s", err)
+				return fmt.Errorf("decoding extrinsic: %w", err)
 			}
 
 			// Inherent are not signed.
@@ -394,15 +443,16 @@ func (s *Service) handleChainReorg(best, curr common.Hash) error {
 				continue
 			}
 
-			externalExt, err := s.buildExternalTransaction(rt, ext)
+			externalExt, err := s.buildExternalTransactionAt(rt, ext, &bestBlockHash)
 			if err != nil {
-				return fmt.Errorf("building external transaction: %s", err)
+				return fmt.Errorf("building external transaction: %w", err)
 			}
 
 			transactionValidity, err := rt.ValidateTransaction(externalExt)
 			if err != nil {
-				logger.Debugf("failed to validate transaction for extrinsic %s: %s skipping in chain reorg", ext, err)
-				s.transactionState.RemoveExtrinsic(ext)
+				logger.Debugf("failed to validate transaction for extrinsic %s of retracted block %s: %s",
+					ext, hash, err)
+				s.transactionState.RemoveInvalidExtrinsic(ext)
 				continue
 			}
 			vtx := transaction.NewValidTransaction(ext, transactionValidity)
@@ -560,13 +610,101 @@ func (s *Service) HandleSubmittedExtrinsic(ext types.Extrinsic) error {
 	return nil
 }
 
-// GetMetadata calls runtime Metadata_metadata function
+// GetMetadata calls runtime Metadata_metadata function at the state of the
+// given block hash, or of the best block if the hash is nil. The metadata is
+// cached by runtime code hash since it only changes on runtime upgrades, so
+// the metadata returned must not be modified.
 func (s *Service) GetMetadata(bhash *common.Hash) (metadata []byte, err error) {
 	rt, err := prepareRuntime(bhash, s.storageState, s.blockState)
 	if err != nil {
 		return nil, fmt.Errorf("setting up runtime: %w", err)
 	}
-	return rt.Metadata()
+
+	codeHash := rt.GetCodeHash()
+	metadata, ok := s.metadata.get(codeHash)
+	if ok {
+		return metadata, nil
+	}
+
+	metadata, err = rt.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	// The metadata is a view of the runtime memory, which is
+	// overwritten by the next call to the shared runtime.
+	metadata = append([]byte(nil), metadata...)
+
+	s.metadata.set(codeHash, metadata)
+	return metadata, nil
+}
+
+// CallRuntime calls the runtime exported function with the given name and
+// SCALE encoded arguments at the state of the given block hash, or of the best
+// block if the block hash is nil, and returns the raw SCALE encoded result.
+// It returns an error wrapping wasmer.ErrExportFunctionNotFound if the
+// runtime does not export a function with this name. Since the call and its
+// arguments are untrusted, the call is limited by the untrusted call timeout.
+func (s *Service) CallRuntime(bhash *common.Hash, function string, data []byte) (result []byte, err error) {
+	rt, err := prepareRuntime(bhash, s.storageState, s.blockState)
+	if err != nil {
+		return nil, fmt.Errorf("setting up runtime: %w", err)
+	}
+	return rt.ExecUntrusted(function, data)
+}
+
+// DryRunExtrinsic applies the extrinsic given in a child block of the block with
+// the given hash, or of the best block if the hash is nil, and returns the SCALE
+// encoded result of the runtime apply extrinsic call together with the SCALE
+// encoded vector of the event records deposited by the extrinsic. The child block
+// is initialised on a copy of the state of the block, which is discarded whatever
+// the outcome, such that the state of the block is never modified.
+func (s *Service) DryRunExtrinsic(bhash *common.Hash, ext types.Extrinsic) (
+	result, events []byte, err error) {
+	var blockHash common.Hash
+	if bhash != nil {
+		blockHash = *bhash
+	} else {
+		blockHash = s.blockState.BestBlockHash()
+	}
+
+	parentHeader, err := s.blockState.GetHeader(blockHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting header: %w", err)
+	}
+
+	rt, trieState, err := prepareRuntimeWithTrieState(&blockHash, s.storageState, s.blockState)
+	if err != nil {
+		return nil, nil, fmt.Errorf("setting up runtime: %w", err)
+	}
+
+	header := &types.Header{
+		ParentHash: blockHash,
+		Number:     parentHeader.Number + 1,
+		Digest:     types.NewDigest(),
+	}
+	err = rt.InitializeBlock(header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("initialising block: %w", err)
+	}
+
+	// The events of the block are cleared when it is initialised,
+	// and the events deposited by its initialisation are not returned.
+	eventsBefore := trieState.Get(common.SystemEventsKey)
+
+	result, err = rt.ApplyExtrinsic(ext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("applying extrinsic: %w", err)
+	}
+	// The result is a view of the runtime memory, which is
+	// overwritten by the next call to the shared runtime.
+	result = append([]byte(nil), result...)
+
+	events, err = appendedEvents(eventsBefore, trieState.Get(common.SystemEventsKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting events deposited: %w", err)
+	}
+
+	return result, events, nil
 }
 
 // GetReadProofAt will return an array with the proofs for the keys passed as params
@@ -593,6 +731,13 @@ func (s *Service) GetReadProofAt(block common.Hash, keys [][]byte) (
 // buildExternalTransaction builds an external transaction based on the current transaction queue API version
 // See https://github.com/paritytech/substrate/blob/polkadot-v0.9.25/primitives/transaction-pool/src/runtime_api.rs#L25-L55
 func (s *Service) buildExternalTransaction(rt runtime.Instance, ext types.Extrinsic) (types.Extrinsic, error) {
+	return s.buildExternalTransactionAt(rt, ext, nil)
+}
+
+// buildExternalTransactionAt builds an external transaction to be validated at the
+// block with the given hash, or at the best block if the block hash is nil.
+func (s *Service) buildExternalTransactionAt(rt runtime.Instance, ext types.Extrinsic,
+	blockHash *common.Hash) (types.Extrinsic, error) {
 	runtimeVersion, err := rt.Version()
 	if err != nil {
 		return nil, err
@@ -604,7 +749,11 @@ func (s *Service) buildExternalTransaction(rt runtime.Instance, ext types.Extrin
 	var extrinsicParts [][]byte
 	switch txQueueVersion {
 	case 3:
-		extrinsicParts = [][]byte{{byte(types.TxnExternal)}, ext, s.blockState.BestBlockHash().ToBytes()}
+		if blockHash == nil {
+			bestBlockHash := s.blockState.BestBlockHash()
+			blockHash = &bestBlockHash
+		}
+		extrinsicParts = [][]byte{{byte(types.TxnExternal)}, ext, blockHash.ToBytes()}
 	case 2:
 		extrinsicParts = [][]byte{{byte(types.TxnExternal)}, ext}
 	default:
@@ -615,17 +764,29 @@ func (s *Service) buildExternalTransaction(rt runtime.Instance, ext types.Extrin
 
 func prepareRuntime(blockHash *common.Hash, storageState StorageState,
 	blockState BlockState) (instance runtime.Instance, err error) {
+	instance, _, err = prepareRuntimeWithTrieState(blockHash, storageState, blockState)
+	return instance, err
+}
+
+// prepareRuntimeWithTrieState returns the runtime of the block with the given
+// hash, or of the best block if the hash is nil, using a copy of the trie of the
+// block as its storage. The trie state returned is this copy, which is discarded.
+// Since the runtime calls are made for untrusted queries, the trie of the block
+// is not cached with the tries used to import blocks if it is loaded from the
+// database.
+func prepareRuntimeWithTrieState(blockHash *common.Hash, storageState StorageState,
+	blockState BlockState) (instance runtime.Instance, trieState *rtstorage.TrieState, err error) {
 	var stateRootHash *common.Hash
 	if blockHash != nil {
 		stateRootHash, err = storageState.GetStateRootFromBlock(blockHash)
 		if err != nil {
-			return nil, fmt.Errorf("getting state root from block hash: %w", err)
+			return nil, nil, fmt.Errorf("getting state root from block hash: %w", err)
 		}
 	}
 
-	trieState, err := storageState.TrieState(stateRootHash)
+	trieState, err = storageState.QueryTrieState(stateRootHash)
 	if err != nil {
-		return nil, fmt.Errorf("getting trie state: %w", err)
+		return nil, nil, fmt.Errorf("getting trie state: %w", err)
 	}
 
 	var blockHashValue common.Hash
@@ -636,9 +797,52 @@ func prepareRuntime(blockHash *common.Hash, storageState StorageState,
 	}
 	instance, err = blockState.GetRuntime(blockHashValue)
 	if err != nil {
-		return nil, fmt.Errorf("getting runtime: %w", err)
+		return nil, nil, fmt.Errorf("getting runtime: %w", err)
 	}
 
 	instance.SetContextStorage(trieState)
-	return instance, nil
+	return instance, trieState, nil
+}
+
+// appendedEvents returns the SCALE encoded vector of the event records
+// appended to the events given before, where both events before and
+// after are SCALE encoded vectors of event records.
+func appendedEvents(before, after []byte) (appended []byte, err error) {
+	beforeCount, beforeRecords, err := splitEventRecords(before)
+	if err != nil {
+		return nil, fmt.Errorf("splitting events before: %w", err)
+	}
+
+	afterCount, afterRecords, err := splitEventRecords(after)
+	if err != nil {
+		return nil, fmt.Errorf("splitting events after: %w", err)
+	}
+
+	if afterCount < beforeCount || !bytes.HasPrefix(afterRecords, beforeRecords) {
+		return nil, fmt.Errorf("%w: %d events before and %d events after",
+			errEventsNotAppended, beforeCount, afterCount)
+	}
+
+	appended, err = scale.Marshal(afterCount - beforeCount)
+	if err != nil {
+		return nil, fmt.Errorf("encoding events count: %w", err)
+	}
+	return append(appended, afterRecords[len(beforeRecords):]...), nil
+}
+
+// splitEventRecords splits the SCALE encoded vector of event records given
+// into its number of records and the concatenation of the encoded records.
+// An empty encoding is considered to contain no event record.
+func splitEventRecords(encoded []byte) (count uint, records []byte, err error) {
+	if len(encoded) == 0 {
+		return 0, nil, nil
+	}
+
+	reader := bytes.NewReader(encoded)
+	err = scale.NewDecoder(reader).Decode(&count)
+	if err != nil {
+		return 0, nil, fmt.Errorf("decoding events count: %w", err)
+	}
+
+	return count, encoded[len(encoded)-reader.Len():], nil
 }
@@ -15,18 +15,18 @@
 
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/state"
-	"github.com/ChainSafe/gossamer/dot/sync"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/babe/inherents"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
-	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/ChainSafe/gossamer/lib/trie"
 	"github.com/ChainSafe/gossamer/lib/utils"
 	"github.com/ChainSafe/gossamer/pkg/scale"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
@@ -196,160 +196,73 @@ func TestService_HasKey_UnknownType(t *testing.T) {
 	require.False(t, res)
 }
 
-func TestHandleChainReorg_NoReorg(t *testing.T) {
-	s := NewTestService(t, nil)
-	state.AddBlocksToState(t, s.blockState.(*state.BlockState), 4, false)
-
-	head, err := s.blockState.BestBlockHeader()
-	require.NoError(t, err)
-
-	err = s.handleChainReorg(head.ParentHash, head.Hash())
-	require.NoError(t, err)
-}
-
-func TestHandleChainReorg_WithReorg_Trans(t *testing.T) {
-	t.Skip() // TODO: tx fails to validate in handleChainReorg() with "Invalid transaction" (#1026)
-	s := NewTestService(t, nil)
-	bs := s.blockState
-
-	parent, err := bs.BestBlockHeader()
-	require.NoError(t, err)
-
-	bestBlockHash := s.blockState.BestBlockHash()
-	rt, err := s.blockState.GetRuntime(bestBlockHash)
-	require.NoError(t, err)
-
-	block1 := sync.BuildBlock(t, rt, parent, nil)
-	bs.StoreRuntime(block1.Header.Hash(), rt)
-	err = bs.AddBlock(block1)
-	require.NoError(t, err)
-
-	block2 := sync.BuildBlock(t, rt, &block1.Header, nil)
-	bs.StoreRuntime(block2.Header.Hash(), rt)
-	err = bs.AddBlock(block2)
-	require.NoError(t, err)
+func newTestForkBlock(t *testing.T, parent *types.Header, slot uint64,
+	extrinsics []types.Extrinsic) *types.Block {
+	t.Helper()
 
-	block3 := sync.BuildBlock(t, rt, &block2.Header, nil)
-	bs.StoreRuntime(block3.Header.Hash(), rt)
-	err = bs.AddBlock(block3)
+	preRuntimeDigest, err := types.NewBabePrimaryPreDigest(0, slot, [32]byte{}, [64]byte{}).ToPreRuntimeDigest()
 	require.NoError(t, err)
-
-	block4 := sync.BuildBlock(t, rt, &block3.Header, nil)
-	bs.StoreRuntime(block4.Header.Hash(), rt)
-	err = bs.AddBlock(block4)
-	require.NoError(t, err)
-
-	block5 := sync.BuildBlock(t, rt, &block4.Header, nil)
-	bs.StoreRuntime(block5.Header.Hash(), rt)
-	err = bs.AddBlock(block5)
-	require.NoError(t, err)
-
-	block31 := sync.BuildBlock(t, rt, &block2.Header, nil)
-	bs.StoreRuntime(block31.Header.Hash(), rt)
-	err = bs.AddBlock(block31)
-	require.NoError(t, err)
-
-	nonce := uint64(0)
-
-	// Add extrinsic to block `block41`
-	ext := createExtrinsic(t, rt, bs.(*state.BlockState).GenesisHash(), nonce)
-
-	block41 := sync.BuildBlock(t, rt, &block31.Header, ext)
-	bs.StoreRuntime(block41.Header.Hash(), rt)
-	err = bs.AddBlock(block41)
-	require.NoError(t, err)
-
-	err = s.handleChainReorg(block41.Header.Hash(), block5.Header.Hash())
+	digest := types.NewDigest()
+	err = digest.Add(*preRuntimeDigest)
 	require.NoError(t, err)
 
-	pending := s.transactionState.(*state.TransactionState).Pending()
-	require.Equal(t, 1, len(pending))
-}
-
-func TestHandleChainReorg_WithReorg_NoTransactions(t *testing.T) {
-	s := NewTestService(t, nil)
-	const height = 5
-	const branch = 3
-	branches := map[uint]int{branch: 1}
-	state.AddBlocksToStateWithFixedBranches(t, s.blockState.(*state.BlockState), height, branches)
-
-	leaves := s.blockState.(*state.BlockState).Leaves()
-	require.Equal(t, 2, len(leaves))
-
-	head := s.blockState.BestBlockHash()
-	var other common.Hash
-	if leaves[0] == head {
-		other = leaves[1]
-	} else {
-		other = leaves[0]
+	return &types.Block{
+		Header: types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			StateRoot:  parent.StateRoot,
+			Digest:     digest,
+		},
+		Body: types.Body(extrinsics),
 	}
-
-	err := s.handleChainReorg(other, head)
-	require.NoError(t, err)
 }
 
-func TestHandleChainReorg_WithReorg_Transactions(t *testing.T) {
-	t.Skip() // need to update this test to use a valid transaction
+func TestService_handleReorg_ReaddsRetractedTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
 
-	cfg := &Config{
-		Runtime: wasmer.NewTestInstance(t, runtime.WESTEND_RUNTIME_v0929),
-	}
+	ext, _, _ := generateExtrinsic(t)
+	validity := &transaction.Validity{Propagate: true}
 
-	s := NewTestService(t, cfg)
-	const height = 5
-	const branch = 3
-	state.AddBlocksToState(t, s.blockState.(*state.BlockState), height, false)
+	rt := NewMockInstance(ctrl)
+	rt.EXPECT().SetContextStorage(gomock.Any()).AnyTimes()
+	rt.EXPECT().Version().Return(runtime.Version{
+		APIItems: []runtime.APIItem{{
+			Name: common.MustBlake2b8([]byte("TaggedTransactionQueue")),
+			Ver:  3,
+		}},
+	}, nil).AnyTimes()
+	rt.EXPECT().ValidateTransaction(gomock.Any()).Return(validity, nil).AnyTimes()
 
-	// create extrinsic
-	enc, err := scale.Marshal([]byte("nootwashere"))
-	require.NoError(t, err)
-	// we prefix with []byte{2} here since that's the enum index for the old IncludeDataExt extrinsic
-	tx := append([]byte{2}, enc...)
-
-	bestBlockHash := s.blockState.BestBlockHash()
-	rt, err := s.blockState.GetRuntime(bestBlockHash)
+	s := NewTestService(t, &Config{Runtime: rt})
+	err := s.Start()
 	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := s.Stop()
+		require.NoError(t, err)
+	})
 
-	validity, err := rt.ValidateTransaction(tx)
+	genesisHeader, err := s.blockState.BestBlockHeader()
 	require.NoError(t, err)
 
-	// get common ancestor
-	ancestor, err := s.blockState.(*state.BlockState).GetBlockByNumber(branch - 1)
+	// The block including the extrinsic is retracted by the longer fork.
+	retractedBlock := newTestForkBlock(t, genesisHeader, 1, []types.Extrinsic{ext})
+	err = s.blockState.AddBlock(retractedBlock)
 	require.NoError(t, err)
 
-	// build "re-org" chain
-
-	digest := types.NewDigest()
-	block := &types.Block{
-		Header: types.Header{
-			ParentHash: ancestor.Header.Hash(),
-			Number:     ancestor.Header.Number + 1,
-			Digest:     digest,
-		},
-		Body: types.Body([]types.Extrinsic{tx}),
-	}
-
-	s.blockState.StoreRuntime(block.Header.Hash(), rt)
-	err = s.blockState.AddBlock(block)
+	forkBlock1 := newTestForkBlock(t, genesisHeader, 2, []types.Extrinsic{})
+	err = s.blockState.AddBlock(forkBlock1)
 	require.NoError(t, err)
-
-	leaves := s.blockState.(*state.BlockState).Leaves()
-	require.Equal(t, 2, len(leaves))
-
-	head := s.blockState.BestBlockHash()
-	var other common.Hash
-	if leaves[0] == head {
-		other = leaves[1]
-	} else {
-		other = leaves[0]
-	}
-
-	err = s.handleChainReorg(other, head)
+	forkBlock2 := newTestForkBlock(t, &forkBlock1.Header, 3, []types.Extrinsic{})
+	err = s.blockState.AddBlock(forkBlock2)
 	require.NoError(t, err)
+	require.Equal(t, forkBlock2.Header.Hash(), s.blockState.BestBlockHash())
 
-	pending := s.transactionState.(*state.TransactionState).Pending()
-	require.Equal(t, 1, len(pending))
-	require.Equal(t, transaction.NewValidTransaction(tx, validity), pending[0])
+	expectedPending := []*transaction.ValidTransaction{transaction.NewValidTransaction(ext, validity)}
+	transactionState := s.transactionState.(*state.TransactionState)
+	require.Eventually(t, func() bool {
+		return len(transactionState.Pending()) == len(expectedPending)
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, expectedPending, transactionState.Pending())
 }
 
 func TestMaintainTransactionPoolLatestTxnQueue_EmptyBlock(t *testing.T) {
@@ -507,6 +420,25 @@ func TestService_GetMetadata(t *testing.T) {
 	res, err := s.GetMetadata(nil)
 	require.NoError(t, err)
 	require.Greater(t, len(res), 10000)
+
+	var encodedMetadata []byte
+	err = scale.Unmarshal(res, &encodedMetadata)
+	require.NoError(t, err)
+	metadata := &ctypes.Metadata{}
+	err = codec.Decode(encodedMetadata, metadata)
+	require.NoError(t, err)
+	require.Equal(t, uint32(ctypes.MagicNumber), metadata.MagicNumber)
+
+	// the metadata is cached for the runtime code hash of the best block
+	rt, err := s.blockState.GetRuntime(s.blockState.BestBlockHash())
+	require.NoError(t, err)
+	cached, ok := s.metadata.get(rt.GetCodeHash())
+	require.True(t, ok)
+	require.Equal(t, res, cached)
+
+	secondRes, err := s.GetMetadata(nil)
+	require.NoError(t, err)
+	require.Equal(t, res, secondRes)
 }
 
 func TestService_HandleRuntimeChanges(t *testing.T) {
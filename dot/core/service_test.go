@@ -8,10 +8,13 @@
 	"context"
 	"encoding/hex"
 	"errors"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/ChainSafe/gossamer/dot/network"
 	testdata "github.com/ChainSafe/gossamer/dot/rpc/modules/test_data"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/blocktree"
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -234,6 +237,7 @@ func Test_Service_handleCodeSubstitution(t *testing.T) {
 				storedRuntime.EXPECT().Keystore().Return(nil)
 				storedRuntime.EXPECT().NodeStorage().Return(runtime.NodeStorage{})
 				storedRuntime.EXPECT().NetworkService().Return(nil)
+				storedRuntime.EXPECT().UntrustedCallTimeout().Return(time.Second)
 				storedRuntime.EXPECT().Validator().Return(false)
 
 				blockState := NewMockBlockState(ctrl)
@@ -261,6 +265,7 @@ func Test_Service_handleCodeSubstitution(t *testing.T) {
 				storedRuntime.EXPECT().Keystore().Return(nil)
 				storedRuntime.EXPECT().NodeStorage().Return(runtime.NodeStorage{})
 				storedRuntime.EXPECT().NetworkService().Return(nil)
+				storedRuntime.EXPECT().UntrustedCallTimeout().Return(time.Second)
 				storedRuntime.EXPECT().Validator().Return(true)
 
 				blockState := NewMockBlockState(ctrl)
@@ -290,6 +295,7 @@ func Test_Service_handleCodeSubstitution(t *testing.T) {
 				storedRuntime.EXPECT().Keystore().Return(nil)
 				storedRuntime.EXPECT().NodeStorage().Return(runtime.NodeStorage{})
 				storedRuntime.EXPECT().NetworkService().Return(nil)
+				storedRuntime.EXPECT().UntrustedCallTimeout().Return(time.Second)
 				storedRuntime.EXPECT().Validator().Return(true)
 
 				blockState := NewMockBlockState(ctrl)
@@ -366,6 +372,23 @@ func Test_Service_handleBlock(t *testing.T) {
 		execTest(t, service, &block, trieState, errTestDummyError)
 	})
 
+	t.Run("validate_tries_error", func(t *testing.T) {
+		t.Parallel()
+		// Leaf without storage value
+		invalidTrie := trie.NewTrie(&trie.Node{PartialKey: []byte{1, 2}})
+		trieState := rtstorage.NewTrieState(invalidTrie)
+
+		testHeader := types.NewEmptyHeader()
+		block := types.NewBlock(*testHeader, *types.NewBody([]types.Extrinsic{[]byte{21}}))
+		block.Header.Number = 21
+
+		service := &Service{validateTries: true}
+		err := service.handleBlock(&block, trieState)
+		assert.ErrorIs(t, err, trie.ErrLeafWithoutValue)
+		assert.EqualError(t, err, "validating state trie for block "+
+			block.Header.Hash().String()+": leaf has no storage value: at path 0x12")
+	})
+
 	t.Run("addBlock_quit_error", func(t *testing.T) {
 		t.Parallel()
 		trieState := rtstorage.NewTrieState(nil)
@@ -742,214 +765,218 @@ func Test_Service_handleBlocksAsync(t *testing.T) {
 		service.handleBlocksAsync()
 	})
 
-	t.Run("handleChainReorg_error", func(t *testing.T) {
+	t.Run("reorgs_channel_not_ok", func(t *testing.T) {
 		t.Parallel()
+		reorgs := make(chan state.ReorgEvent)
+		close(reorgs)
+		service := &Service{
+			reorgs: reorgs,
+			ctx:    context.Background(),
+		}
+		service.handleBlocksAsync()
+	})
 
-		testHeader := types.NewEmptyHeader()
-		block := types.NewBlock(*testHeader, *types.NewBody([]types.Extrinsic{[]byte{21}}))
-		block.Header.Number = 21
+	t.Run("handleReorg_error", func(t *testing.T) {
+		t.Parallel()
 
 		ctrl := gomock.NewController(t)
 		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{})
-		mockBlockState.EXPECT().LowestCommonAncestor(common.Hash{}, block.Header.Hash()).
-			Return(common.Hash{}, errTestDummyError)
+		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(nil, errTestDummyError)
 
-		blockAddChan := make(chan *types.Block)
+		reorgs := make(chan state.ReorgEvent)
 		go func() {
-			blockAddChan <- &block
-			close(blockAddChan)
+			reorgs <- state.ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+			}
+			close(reorgs)
 		}()
 		service := &Service{
 			blockState: mockBlockState,
-			blockAddCh: blockAddChan,
+			reorgs:     reorgs,
 			ctx:        context.Background(),
 		}
 
-		assert.PanicsWithError(t, "failed to re-add transactions to chain upon re-org: test dummy error",
-			service.handleBlocksAsync)
+		assert.PanicsWithError(t, "failed to re-add transactions to chain upon re-org: "+
+			"getting runtime: test dummy error", service.handleBlocksAsync)
 	})
 }
 
-func TestService_handleChainReorg(t *testing.T) {
+func TestService_handleReorg(t *testing.T) {
 	t.Parallel()
-	execTest := func(t *testing.T, s *Service, prevHash common.Hash, currHash common.Hash, expErr error) {
-		err := s.handleChainReorg(prevHash, currHash)
-		if expErr != nil {
-			assert.EqualError(t, err, expErr.Error())
-		}
-	}
 
-	testPrevHash := common.MustHexToHash("0x01")
-	testCurrentHash := common.MustHexToHash("0x02")
-	testAncestorHash := common.MustHexToHash("0x03")
-	testSubChain := []common.Hash{testPrevHash, testCurrentHash, testAncestorHash}
+	commonAncestorHash := common.Hash{1}
+	retractedHashes := []common.Hash{{3}, {2}}
+	enactedHashes := []common.Hash{{4}, {5}}
+	bestBlockHash := enactedHashes[1]
+	stateRoot := common.Hash{6}
+	reorg := state.ReorgEvent{
+		CommonAncestor: commonAncestorHash,
+		Retracted:      retractedHashes,
+		Enacted:        enactedHashes,
+	}
 
-	// A valid extrinsic is needed since it will be validated in handleChainReorg
-	ext, externExt, body := generateExtrinsic(t)
+	// A valid extrinsic is needed since it will be validated in handleReorg
+	ext, _, body := generateExtrinsic(t)
+	// The transactions are validated at the new best block, or at the
+	// common ancestor if no block is enacted.
+	externalExtAt := func(blockHash common.Hash) types.Extrinsic {
+		return types.Extrinsic(bytes.Join([][]byte{
+			{byte(types.TxnExternal)}, ext, blockHash.ToBytes(),
+		}, nil))
+	}
 	testValidity := &transaction.Validity{Propagate: true}
 	vtx := transaction.NewValidTransaction(ext, testValidity)
+	unsignedExt := types.Extrinsic{0x0c, 0x04, 0x00, 0x00}
+	otherExt := types.Extrinsic{0x0c, 0x04, 0x00, 0x01}
+	emptyBody := types.NewBody([]types.Extrinsic{})
 
-	t.Run("highest_common_ancestor_err", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(common.Hash{}, errDummyErr)
-
-		service := &Service{
-			blockState: mockBlockState,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, errDummyErr)
-	})
-
-	t.Run("highest_common_ancestor_err", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(common.Hash{}, errDummyErr)
-
-		service := &Service{
-			blockState: mockBlockState,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, errDummyErr)
-	})
-
-	t.Run("ancestor_eq_priv", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(testPrevHash, nil)
-
-		service := &Service{
-			blockState: mockBlockState,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, nil)
-	})
-
-	t.Run("subchain_err", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(testAncestorHash, nil)
-		mockBlockState.EXPECT().RangeInMemory(testAncestorHash, testPrevHash).Return([]common.Hash{}, errDummyErr)
-
-		service := &Service{
-			blockState: mockBlockState,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, errDummyErr)
-	})
-
-	t.Run("empty_subchain", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(testAncestorHash, nil)
-		mockBlockState.EXPECT().RangeInMemory(testAncestorHash, testPrevHash).Return([]common.Hash{}, nil)
-
-		service := &Service{
-			blockState: mockBlockState,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, nil)
-	})
-
-	t.Run("get_runtime_err", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(testAncestorHash, nil)
-		mockBlockState.EXPECT().RangeInMemory(testAncestorHash, testPrevHash).Return(testSubChain, nil)
-		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{1})
-		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(nil, errDummyErr)
-
-		service := &Service{
-			blockState: mockBlockState,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, errDummyErr)
-	})
-
-	t.Run("invalid_transaction", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-
-		runtimeMockErr := NewMockInstance(ctrl)
-		runtimeMockErr.EXPECT().ValidateTransaction(externExt).Return(nil, errTestDummyError)
-		runtimeMockErr.EXPECT().Version().Return(runtime.Version{
-			SpecName:         []byte("polkadot"),
-			ImplName:         []byte("parity-polkadot"),
-			AuthoringVersion: authoringVersion,
-			SpecVersion:      specVersion,
-			ImplVersion:      implVersion,
-			APIItems: []runtime.APIItem{{
-				Name: common.MustBlake2b8([]byte("TaggedTransactionQueue")),
-				Ver:  3,
-			}},
-			TransactionVersion: transactionVersion,
-			StateVersion:       stateVersion,
-		}, nil)
-
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(testAncestorHash, nil)
-		mockBlockState.EXPECT().RangeInMemory(testAncestorHash, testPrevHash).Return(testSubChain, nil)
-		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{1})
-		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMockErr, nil)
-		mockBlockState.EXPECT().GetBlockBody(testCurrentHash).Return(nil, errDummyErr)
-		mockBlockState.EXPECT().GetBlockBody(testAncestorHash).Return(body, nil)
-		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{})
-		mockTxnState := NewMockTransactionState(ctrl)
-		mockTxnState.EXPECT().RemoveExtrinsic(ext)
+	runtimeVersion := runtime.Version{
+		SpecName:         []byte("polkadot"),
+		ImplName:         []byte("parity-polkadot"),
+		AuthoringVersion: authoringVersion,
+		SpecVersion:      specVersion,
+		ImplVersion:      implVersion,
+		APIItems: []runtime.APIItem{{
+			Name: common.MustBlake2b8([]byte("TaggedTransactionQueue")),
+			Ver:  3,
+		}},
+		TransactionVersion: transactionVersion,
+		StateVersion:       stateVersion,
+	}
 
-		service := &Service{
-			blockState:       mockBlockState,
-			transactionState: mockTxnState,
-		}
+	testCases := map[string]struct {
+		reorg        state.ReorgEvent
+		buildService func(ctrl *gomock.Controller) *Service
+		errWrapped   error
+		errMessage   string
+	}{
+		"no_retracted_block": {
+			reorg: state.ReorgEvent{
+				CommonAncestor: commonAncestorHash,
+				Enacted:        enactedHashes,
+			},
+			buildService: func(ctrl *gomock.Controller) *Service {
+				return &Service{}
+			},
+		},
+		"enacted_block_body_error": {
+			reorg: reorg,
+			buildService: func(ctrl *gomock.Controller) *Service {
+				blockState := NewMockBlockState(ctrl)
+				blockState.EXPECT().GetBlockBody(enactedHashes[0]).Return(nil, errTestDummyError)
+				return &Service{blockState: blockState}
+			},
+			errWrapped: errTestDummyError,
+			errMessage: "getting body of enacted block " + enactedHashes[0].String() +
+				": test dummy error",
+		},
+		"get_runtime_error": {
+			reorg: state.ReorgEvent{
+				CommonAncestor: commonAncestorHash,
+				Retracted:      retractedHashes,
+			},
+			buildService: func(ctrl *gomock.Controller) *Service {
+				blockState := NewMockBlockState(ctrl)
+				blockState.EXPECT().GetRuntime(commonAncestorHash).Return(nil, errTestDummyError)
+				return &Service{blockState: blockState}
+			},
+			errWrapped: errTestDummyError,
+			errMessage: "getting runtime: test dummy error",
+		},
+		"trie_state_error": {
+			reorg: reorg,
+			buildService: func(ctrl *gomock.Controller) *Service {
+				blockState := NewMockBlockState(ctrl)
+				blockState.EXPECT().GetBlockBody(enactedHashes[0]).Return(emptyBody, nil)
+				blockState.EXPECT().GetBlockBody(enactedHashes[1]).Return(emptyBody, nil)
+				blockState.EXPECT().GetRuntime(bestBlockHash).Return(NewMockInstance(ctrl), nil)
+				storageState := NewMockStorageState(ctrl)
+				storageState.EXPECT().GetStateRootFromBlock(&bestBlockHash).Return(&stateRoot, nil)
+				storageState.EXPECT().TrieState(&stateRoot).Return(nil, errTestDummyError)
+				return &Service{blockState: blockState, storageState: storageState}
+			},
+			errWrapped: errTestDummyError,
+			errMessage: "getting trie state: test dummy error",
+		},
+		"transactions_re_added": {
+			reorg: reorg,
+			buildService: func(ctrl *gomock.Controller) *Service {
+				blockState := NewMockBlockState(ctrl)
+				blockState.EXPECT().GetBlockBody(enactedHashes[0]).
+					Return(types.NewBody([]types.Extrinsic{otherExt}), nil)
+				blockState.EXPECT().GetBlockBody(enactedHashes[1]).Return(emptyBody, nil)
+				rt := NewMockInstance(ctrl)
+				blockState.EXPECT().GetRuntime(bestBlockHash).Return(rt, nil)
+				storageState := NewMockStorageState(ctrl)
+				storageState.EXPECT().GetStateRootFromBlock(&bestBlockHash).Return(&stateRoot, nil)
+				trieState := &rtstorage.TrieState{}
+				storageState.EXPECT().TrieState(&stateRoot).Return(trieState, nil)
+				rt.EXPECT().SetContextStorage(trieState)
+
+				// The retracted blocks are walked from the common ancestor up,
+				// and the body of the first one is pruned already.
+				bodyPruned := blockState.EXPECT().GetBlockBody(retractedHashes[1]).
+					Return(nil, errTestDummyError)
+				blockState.EXPECT().GetBlockBody(retractedHashes[0]).
+					Return(types.NewBody([]types.Extrinsic{unsignedExt, otherExt, (*body)[0]}), nil).
+					After(bodyPruned)
+				rt.EXPECT().Version().Return(runtimeVersion, nil)
+				rt.EXPECT().ValidateTransaction(externalExtAt(bestBlockHash)).Return(testValidity, nil)
+				transactionState := NewMockTransactionState(ctrl)
+				transactionState.EXPECT().AddToPool(vtx).Return(common.Hash{})
+				return &Service{
+					blockState:       blockState,
+					storageState:     storageState,
+					transactionState: transactionState,
+				}
+			},
+		},
+		"invalid_transaction_removed": {
+			reorg: state.ReorgEvent{
+				CommonAncestor: commonAncestorHash,
+				Retracted:      retractedHashes[:1],
+			},
+			buildService: func(ctrl *gomock.Controller) *Service {
+				blockState := NewMockBlockState(ctrl)
+				rt := NewMockInstance(ctrl)
+				blockState.EXPECT().GetRuntime(commonAncestorHash).Return(rt, nil)
+				storageState := NewMockStorageState(ctrl)
+				storageState.EXPECT().GetStateRootFromBlock(&commonAncestorHash).Return(&stateRoot, nil)
+				trieState := &rtstorage.TrieState{}
+				storageState.EXPECT().TrieState(&stateRoot).Return(trieState, nil)
+				rt.EXPECT().SetContextStorage(trieState)
+				blockState.EXPECT().GetBlockBody(retractedHashes[0]).Return(body, nil)
+				rt.EXPECT().Version().Return(runtimeVersion, nil)
+				rt.EXPECT().ValidateTransaction(externalExtAt(commonAncestorHash)).
+					Return(nil, errTestDummyError)
+				transactionState := NewMockTransactionState(ctrl)
+				transactionState.EXPECT().RemoveInvalidExtrinsic(ext)
+				return &Service{
+					blockState:       blockState,
+					storageState:     storageState,
+					transactionState: transactionState,
+				}
+			},
+		},
+	}
 
-		execTest(t, service, testPrevHash, testCurrentHash, nil)
-	})
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
 
-	t.Run("happy_path", func(t *testing.T) {
-		t.Parallel()
-		ctrl := gomock.NewController(t)
-		runtimeMockOk := NewMockInstance(ctrl)
-		runtimeMockOk.EXPECT().ValidateTransaction(externExt).Return(testValidity, nil)
-		runtimeMockOk.EXPECT().Version().Return(runtime.Version{
-			SpecName:         []byte("polkadot"),
-			ImplName:         []byte("parity-polkadot"),
-			AuthoringVersion: authoringVersion,
-			SpecVersion:      specVersion,
-			ImplVersion:      implVersion,
-			APIItems: []runtime.APIItem{{
-				Name: common.MustBlake2b8([]byte("TaggedTransactionQueue")),
-				Ver:  3,
-			}},
-			TransactionVersion: transactionVersion,
-			StateVersion:       stateVersion,
-		}, nil)
+			service := testCase.buildService(ctrl)
 
-		mockBlockState := NewMockBlockState(ctrl)
-		mockBlockState.EXPECT().LowestCommonAncestor(testPrevHash, testCurrentHash).
-			Return(testAncestorHash, nil)
-		mockBlockState.EXPECT().RangeInMemory(testAncestorHash, testPrevHash).Return(testSubChain, nil)
-		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{1})
-		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMockOk, nil)
-		mockBlockState.EXPECT().GetBlockBody(testCurrentHash).Return(nil, errDummyErr)
-		mockBlockState.EXPECT().GetBlockBody(testAncestorHash).Return(body, nil)
-		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{})
-		mockTxnStateOk := NewMockTransactionState(ctrl)
-		mockTxnStateOk.EXPECT().AddToPool(vtx).Return(common.Hash{})
+			err := service.handleReorg(testCase.reorg)
 
-		service := &Service{
-			blockState:       mockBlockState,
-			transactionState: mockTxnStateOk,
-		}
-		execTest(t, service, testPrevHash, testCurrentHash, nil)
-	})
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
 }
 
 func TestServiceInsertKey(t *testing.T) {
@@ -1149,7 +1176,7 @@ func TestServiceGetRuntimeVersion(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		mockStorageState := NewMockStorageState(ctrl)
 		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{}).Return(&common.Hash{}, nil)
-		mockStorageState.EXPECT().TrieState(&common.Hash{}).Return(nil, errDummyErr)
+		mockStorageState.EXPECT().QueryTrieState(&common.Hash{}).Return(nil, errDummyErr)
 		service := &Service{
 			storageState: mockStorageState,
 		}
@@ -1162,7 +1189,7 @@ func TestServiceGetRuntimeVersion(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		mockStorageState := NewMockStorageState(ctrl)
 		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{}).Return(&common.Hash{}, nil)
-		mockStorageState.EXPECT().TrieState(&common.Hash{}).Return(ts, nil).MaxTimes(2)
+		mockStorageState.EXPECT().QueryTrieState(&common.Hash{}).Return(ts, nil).MaxTimes(2)
 
 		mockBlockState := NewMockBlockState(ctrl)
 		mockBlockState.EXPECT().GetRuntime(common.Hash{}).Return(nil, errDummyErr)
@@ -1179,7 +1206,7 @@ func TestServiceGetRuntimeVersion(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		mockStorageState := NewMockStorageState(ctrl)
 		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{}).Return(&common.Hash{}, nil).MaxTimes(2)
-		mockStorageState.EXPECT().TrieState(&common.Hash{}).Return(ts, nil).MaxTimes(2)
+		mockStorageState.EXPECT().QueryTrieState(&common.Hash{}).Return(ts, nil).MaxTimes(2)
 
 		runtimeMock := NewMockInstance(ctrl)
 		mockBlockState := NewMockBlockState(ctrl)
@@ -1373,7 +1400,7 @@ func TestServiceGetMetadata(t *testing.T) {
 		t.Parallel()
 		ctrl := gomock.NewController(t)
 		mockStorageState := NewMockStorageState(ctrl)
-		mockStorageState.EXPECT().TrieState(nil).Return(nil, errDummyErr)
+		mockStorageState.EXPECT().QueryTrieState(nil).Return(nil, errDummyErr)
 		service := &Service{
 			storageState: mockStorageState,
 		}
@@ -1385,7 +1412,7 @@ func TestServiceGetMetadata(t *testing.T) {
 		t.Parallel()
 		ctrl := gomock.NewController(t)
 		mockStorageState := NewMockStorageState(ctrl)
-		mockStorageState.EXPECT().TrieState(nil).Return(&rtstorage.TrieState{}, nil)
+		mockStorageState.EXPECT().QueryTrieState(nil).Return(&rtstorage.TrieState{}, nil)
 		mockBlockState := NewMockBlockState(ctrl)
 		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{1})
 		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(nil, errDummyErr)
@@ -1401,12 +1428,13 @@ func TestServiceGetMetadata(t *testing.T) {
 		t.Parallel()
 		ctrl := gomock.NewController(t)
 		mockStorageState := NewMockStorageState(ctrl)
-		mockStorageState.EXPECT().TrieState(nil).Return(&rtstorage.TrieState{}, nil)
+		mockStorageState.EXPECT().QueryTrieState(nil).Return(&rtstorage.TrieState{}, nil)
 		runtimeMockOk := NewMockInstance(ctrl)
 		mockBlockState := NewMockBlockState(ctrl)
 		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{1})
 		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMockOk, nil)
 		runtimeMockOk.EXPECT().SetContextStorage(&rtstorage.TrieState{})
+		runtimeMockOk.EXPECT().GetCodeHash().Return(common.Hash{2})
 		runtimeMockOk.EXPECT().Metadata().Return([]byte{1, 2, 3}, nil)
 		service := &Service{
 			storageState: mockStorageState,
@@ -1415,6 +1443,295 @@ func TestServiceGetMetadata(t *testing.T) {
 		const expectedErrMessage = "setting up runtime: getting state root from block hash: dummy error for testing"
 		execTest(t, service, nil, []byte{1, 2, 3}, nil, expectedErrMessage)
 	})
+
+	t.Run("cached_for_code_hash", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{3}).
+			Return(&common.Hash{4}, nil).Times(2)
+		mockStorageState.EXPECT().QueryTrieState(&common.Hash{4}).
+			Return(&rtstorage.TrieState{}, nil).Times(2)
+		runtimeMock := NewMockInstance(ctrl)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().GetRuntime(common.Hash{3}).Return(runtimeMock, nil).Times(2)
+		runtimeMock.EXPECT().SetContextStorage(&rtstorage.TrieState{}).Times(2)
+		runtimeMock.EXPECT().GetCodeHash().Return(common.Hash{2}).Times(2)
+		// the metadata is only obtained from the runtime once
+		runtimeMock.EXPECT().Metadata().Return([]byte{1, 2, 3}, nil)
+		service := &Service{
+			storageState: mockStorageState,
+			blockState:   mockBlockState,
+		}
+
+		execTest(t, service, &common.Hash{3}, []byte{1, 2, 3}, nil, "")
+		execTest(t, service, &common.Hash{3}, []byte{1, 2, 3}, nil, "")
+	})
+}
+
+func Test_metadataCache(t *testing.T) {
+	t.Parallel()
+
+	var cache metadataCache
+
+	_, ok := cache.get(common.Hash{1})
+	assert.False(t, ok)
+
+	for i := byte(1); i <= maxCachedMetadata+1; i++ {
+		cache.set(common.Hash{i}, []byte{i})
+	}
+
+	// the oldest metadata is evicted
+	_, ok = cache.get(common.Hash{1})
+	assert.False(t, ok)
+	for i := byte(2); i <= maxCachedMetadata+1; i++ {
+		metadata, ok := cache.get(common.Hash{i})
+		assert.True(t, ok)
+		assert.Equal(t, []byte{i}, metadata)
+	}
+}
+
+func TestService_CallRuntime(t *testing.T) {
+	t.Parallel()
+
+	t.Run("setup_runtime_error", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{1}).Return(nil, errDummyErr)
+		service := &Service{
+			storageState: mockStorageState,
+		}
+
+		result, err := service.CallRuntime(&common.Hash{1}, "Core_version", nil)
+
+		assert.ErrorIs(t, err, errDummyErr)
+		assert.EqualError(t, err, "setting up runtime: getting state root from block hash: dummy error for testing")
+		assert.Nil(t, result)
+	})
+
+	t.Run("at_block_state", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		stateRoot := common.Hash{2}
+		trieState := &rtstorage.TrieState{}
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{1}).Return(&stateRoot, nil)
+		mockStorageState.EXPECT().QueryTrieState(&stateRoot).Return(trieState, nil)
+		runtimeMock := NewMockInstance(ctrl)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMock, nil)
+		runtimeMock.EXPECT().SetContextStorage(trieState)
+		runtimeMock.EXPECT().ExecUntrusted("Core_version", []byte{3}).Return([]byte{4}, nil)
+		service := &Service{
+			storageState: mockStorageState,
+			blockState:   mockBlockState,
+		}
+
+		result, err := service.CallRuntime(&common.Hash{1}, "Core_version", []byte{3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{4}, result)
+	})
+}
+
+func TestService_DryRunExtrinsic(t *testing.T) {
+	t.Parallel()
+
+	parentHeader := &types.Header{Number: 1}
+	childHeader := &types.Header{
+		ParentHash: common.Hash{1},
+		Number:     2,
+		Digest:     types.NewDigest(),
+	}
+
+	t.Run("get_header_error", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().GetHeader(common.Hash{1}).Return(nil, errDummyErr)
+		service := &Service{
+			blockState: mockBlockState,
+		}
+
+		result, events, err := service.DryRunExtrinsic(&common.Hash{1}, types.Extrinsic{1})
+
+		assert.ErrorIs(t, err, errDummyErr)
+		assert.EqualError(t, err, "getting header: dummy error for testing")
+		assert.Nil(t, result)
+		assert.Nil(t, events)
+	})
+
+	t.Run("setup_runtime_error", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().GetHeader(common.Hash{1}).Return(parentHeader, nil)
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{1}).Return(nil, errDummyErr)
+		service := &Service{
+			storageState: mockStorageState,
+			blockState:   mockBlockState,
+		}
+
+		result, events, err := service.DryRunExtrinsic(&common.Hash{1}, types.Extrinsic{1})
+
+		assert.ErrorIs(t, err, errDummyErr)
+		assert.EqualError(t, err, "setting up runtime: getting state root from block hash: dummy error for testing")
+		assert.Nil(t, result)
+		assert.Nil(t, events)
+	})
+
+	t.Run("initialize_block_error", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		stateRoot := common.Hash{2}
+		trieState := rtstorage.NewTrieState(trie.NewEmptyTrie())
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{1}).Return(&stateRoot, nil)
+		mockStorageState.EXPECT().QueryTrieState(&stateRoot).Return(trieState, nil)
+		runtimeMock := NewMockInstance(ctrl)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{1})
+		mockBlockState.EXPECT().GetHeader(common.Hash{1}).Return(parentHeader, nil)
+		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMock, nil)
+		runtimeMock.EXPECT().SetContextStorage(trieState)
+		runtimeMock.EXPECT().InitializeBlock(childHeader).Return(errDummyErr)
+		service := &Service{
+			storageState: mockStorageState,
+			blockState:   mockBlockState,
+		}
+
+		result, events, err := service.DryRunExtrinsic(nil, types.Extrinsic{1})
+
+		assert.ErrorIs(t, err, errDummyErr)
+		assert.EqualError(t, err, "initialising block: dummy error for testing")
+		assert.Nil(t, result)
+		assert.Nil(t, events)
+	})
+
+	t.Run("apply_extrinsic_error", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		stateRoot := common.Hash{2}
+		trieState := rtstorage.NewTrieState(trie.NewEmptyTrie())
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{1}).Return(&stateRoot, nil)
+		mockStorageState.EXPECT().QueryTrieState(&stateRoot).Return(trieState, nil)
+		runtimeMock := NewMockInstance(ctrl)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().GetHeader(common.Hash{1}).Return(parentHeader, nil)
+		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMock, nil)
+		runtimeMock.EXPECT().SetContextStorage(trieState)
+		runtimeMock.EXPECT().InitializeBlock(childHeader).Return(nil)
+		runtimeMock.EXPECT().ApplyExtrinsic(types.Extrinsic{1}).Return(nil, errDummyErr)
+		service := &Service{
+			storageState: mockStorageState,
+			blockState:   mockBlockState,
+		}
+
+		result, events, err := service.DryRunExtrinsic(&common.Hash{1}, types.Extrinsic{1})
+
+		assert.ErrorIs(t, err, errDummyErr)
+		assert.EqualError(t, err, "applying extrinsic: dummy error for testing")
+		assert.Nil(t, result)
+		assert.Nil(t, events)
+	})
+
+	t.Run("events_deposited", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		stateRoot := common.Hash{2}
+		// The events of the parent block are replaced by the
+		// single event record deposited by the block initialisation.
+		trieState := rtstorage.NewTrieState(trie.NewEmptyTrie())
+		err := trieState.Put(common.SystemEventsKey, []byte{8, 0x1, 0x2})
+		require.NoError(t, err)
+		mockStorageState := NewMockStorageState(ctrl)
+		mockStorageState.EXPECT().GetStateRootFromBlock(&common.Hash{1}).Return(&stateRoot, nil)
+		mockStorageState.EXPECT().QueryTrieState(&stateRoot).Return(trieState, nil)
+		runtimeMock := NewMockInstance(ctrl)
+		mockBlockState := NewMockBlockState(ctrl)
+		mockBlockState.EXPECT().GetHeader(common.Hash{1}).Return(parentHeader, nil)
+		mockBlockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeMock, nil)
+		runtimeMock.EXPECT().SetContextStorage(trieState)
+		runtimeMock.EXPECT().InitializeBlock(childHeader).
+			DoAndReturn(func(*types.Header) error {
+				err := trieState.Put(common.SystemEventsKey, []byte{4, 0xa})
+				require.NoError(t, err)
+				return nil
+			})
+		runtimeMock.EXPECT().ApplyExtrinsic(types.Extrinsic{1}).
+			DoAndReturn(func(types.Extrinsic) ([]byte, error) {
+				err := trieState.Put(common.SystemEventsKey, []byte{12, 0xa, 0xb, 0xc})
+				require.NoError(t, err)
+				return []byte{0, 0}, nil
+			})
+		service := &Service{
+			storageState: mockStorageState,
+			blockState:   mockBlockState,
+		}
+
+		result, events, err := service.DryRunExtrinsic(&common.Hash{1}, types.Extrinsic{1})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 0}, result)
+		assert.Equal(t, []byte{8, 0xb, 0xc}, events)
+	})
+}
+
+func Test_appendedEvents(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		before     []byte
+		after      []byte
+		appended   []byte
+		errWrapped error
+		errMessage string
+	}{
+		"no_events": {
+			appended: []byte{0},
+		},
+		"no_events_before": {
+			after:    []byte{8, 0xa, 0xb},
+			appended: []byte{8, 0xa, 0xb},
+		},
+		"events_appended": {
+			before:   []byte{4, 0xa},
+			after:    []byte{12, 0xa, 0xb, 0xc},
+			appended: []byte{8, 0xb, 0xc},
+		},
+		"events_replaced": {
+			before:     []byte{4, 0xa},
+			after:      []byte{4, 0xb},
+			errWrapped: errEventsNotAppended,
+			errMessage: "events are not appended: 1 events before and 1 events after",
+		},
+		"malformed_events_after": {
+			after:      []byte{0xff},
+			errWrapped: io.EOF,
+			errMessage: "splitting events after: decoding events count: reading bytes: EOF",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			appended, err := appendedEvents(testCase.before, testCase.after)
+
+			if testCase.errMessage != "" {
+				assert.ErrorIs(t, err, testCase.errWrapped)
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, appended)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.appended, appended)
+		})
+	}
 }
 
 func TestService_GetReadProofAt(t *testing.T) {
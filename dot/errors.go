@@ -14,3 +14,13 @@
 var ErrInvalidKeystoreType = errors.New("invalid keystore type")
 
 var ErrWasmInterpreterName = errors.New("unknown wasm interpreter name")
+
+// ErrInvalidGenesisStorageOverride is returned when a genesis storage
+// override key or value is not a valid 0x prefixed hex string.
+var ErrInvalidGenesisStorageOverride = errors.New("invalid genesis storage override")
+
+var errStateNotInitialised = errors.New("state is not initialised")
+
+// ErrBABEConfigurationMismatch is returned on start when the configured BABE
+// slot duration or epoch length differs from the runtime BABE configuration.
+var ErrBABEConfigurationMismatch = errors.New("BABE configuration does not match runtime")
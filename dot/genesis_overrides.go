@@ -0,0 +1,49 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package dot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// applyGenesisStorageOverrides puts the overrides given, mapping hex encoded
+// storage keys to hex encoded storage values, in the genesis trie given, such
+// that the genesis state root computed from the trie reflects them.
+// All the overrides are checked before any is applied, and it returns an
+// error wrapping ErrInvalidGenesisStorageOverride if any is not valid hex.
+func applyGenesisStorageOverrides(t *trie.Trie, overrides map[string]string) (err error) {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	keyValues := make([][2][]byte, len(keys))
+	for i, key := range keys {
+		keyValues[i][0], err = common.HexToBytes(key)
+		if err != nil {
+			return fmt.Errorf("%w: key: %s", ErrInvalidGenesisStorageOverride, err)
+		}
+
+		keyValues[i][1], err = common.HexToBytes(overrides[key])
+		if err != nil {
+			return fmt.Errorf("%w: value for key %s: %s",
+				ErrInvalidGenesisStorageOverride, key, err)
+		}
+	}
+
+	for _, keyValue := range keyValues {
+		err = t.Put(keyValue[0], keyValue[1])
+		if err != nil {
+			return fmt.Errorf("putting override for key 0x%x in genesis trie: %w", keyValue[0], err)
+		}
+		logger.Infof("overriding genesis storage key 0x%x", keyValue[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package dot
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyGenesisStorageOverrides(t *testing.T) {
+	t.Parallel()
+
+	genesisTop := map[string]string{
+		"0x01": "0x01",
+		"0x02": "0x02",
+	}
+
+	testCases := map[string]struct {
+		overrides      map[string]string
+		expectedValues map[string][]byte
+		rootChanged    bool
+		errWrapped     error
+		errMessage     string
+	}{
+		"no_override": {
+			expectedValues: map[string][]byte{
+				"\x01": {1},
+				"\x02": {2},
+			},
+		},
+		"override_existing_and_new_keys": {
+			overrides: map[string]string{
+				"0x01": "0xff",
+				"0x03": "0x03",
+			},
+			expectedValues: map[string][]byte{
+				"\x01": {0xff},
+				"\x02": {2},
+				"\x03": {3},
+			},
+			rootChanged: true,
+		},
+		"key_without_hex_prefix": {
+			overrides: map[string]string{
+				"0x03": "0x03",
+				"01":   "0xff",
+			},
+			expectedValues: map[string][]byte{
+				"\x01": {1},
+				"\x02": {2},
+			},
+			errWrapped: ErrInvalidGenesisStorageOverride,
+			errMessage: "invalid genesis storage override: key: " +
+				"could not byteify non 0x prefixed string: 01",
+		},
+		"invalid_hex_value": {
+			overrides: map[string]string{
+				"0x01": "0xzz",
+			},
+			expectedValues: map[string][]byte{
+				"\x01": {1},
+				"\x02": {2},
+			},
+			errWrapped: ErrInvalidGenesisStorageOverride,
+			errMessage: "invalid genesis storage override: value for key 0x01: " +
+				"encoding/hex: invalid byte: U+007A 'z': 0xzz",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			genesisTrie, err := trie.LoadFromMap(genesisTop)
+			require.NoError(t, err)
+			header, err := genesisTrie.GenesisBlock()
+			require.NoError(t, err)
+
+			err = applyGenesisStorageOverrides(&genesisTrie, testCase.overrides)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+
+			for key, expectedValue := range testCase.expectedValues {
+				assert.Equal(t, expectedValue, genesisTrie.Get([]byte(key)))
+			}
+
+			overriddenHeader, err := genesisTrie.GenesisBlock()
+			require.NoError(t, err)
+			if testCase.rootChanged {
+				assert.NotEqual(t, header.StateRoot, overriddenHeader.StateRoot)
+				assert.NotEqual(t, header.Hash(), overriddenHeader.Hash())
+			} else {
+				assert.Equal(t, header.StateRoot, overriddenHeader.StateRoot)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@
 import (
 	"encoding/json"
 
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/services"
 )
@@ -25,9 +27,12 @@ type ServiceRegisterer interface {
 	Get(srvc interface{}) services.Service
 }
 
-// BlockJustificationVerifier has a verification method for block justifications.
+// BlockJustificationVerifier has verification methods for block justifications
+// and for the authority set changes of warp sync proofs.
 type BlockJustificationVerifier interface {
 	VerifyBlockJustification(common.Hash, []byte) error
+	VerifyWarpSyncProof(proof *network.WarpSyncProof, setID uint64, authorities []types.GrandpaVoter) (
+		nextSetID uint64, nextAuthorities []types.GrandpaVoter, err error)
 }
 
 // Telemetry is the telemetry client to send telemetry messages.
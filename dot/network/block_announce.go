@@ -202,17 +202,20 @@ func (s *Service) validateBlockAnnounceHandshake(from peer.ID, hs Handshake) err
 
 // handleBlockAnnounceMessage handles BlockAnnounce messages
 // if some more blocks are required to sync the announced block, the node will open a sync stream
-// with its peer and send a BlockRequest message
+// with its peer and send a BlockRequest message. The announcement is only relayed if the
+// block is already known or if the announced header is validated by the syncer.
 func (s *Service) handleBlockAnnounceMessage(from peer.ID, msg NotificationsMessage) (propagate bool, err error) {
 	bam, ok := msg.(*BlockAnnounceMessage)
 	if !ok {
 		return false, errors.New("invalid message")
 	}
 
-	err = s.syncer.HandleBlockAnnounce(from, bam)
+	propagate, err = s.syncer.HandleBlockAnnounce(from, bam)
 	if errors.Is(err, blocktree.ErrBlockExists) {
 		return true, nil
+	} else if err != nil {
+		return false, err
 	}
 
-	return false, err
+	return propagate, nil
 }
@@ -6,6 +6,7 @@
 package network
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ChainSafe/gossamer/dot/types"
@@ -132,6 +133,7 @@ func TestHandleBlockAnnounceMessage(t *testing.T) {
 
 	testCases := map[string]struct {
 		propagate  bool
+		errMessage string
 		mockSyncer func(*testing.T, peer.ID, *BlockAnnounceMessage) Syncer
 	}{
 		"block_already_exists": {
@@ -140,7 +142,7 @@ func TestHandleBlockAnnounceMessage(t *testing.T) {
 				syncer := NewMockSyncer(ctrl)
 				syncer.EXPECT().
 					HandleBlockAnnounce(peer, blockAnnounceMessage).
-					Return(blocktree.ErrBlockExists)
+					Return(false, blocktree.ErrBlockExists)
 				return syncer
 			},
 			propagate: true,
@@ -148,6 +150,29 @@ func TestHandleBlockAnnounceMessage(t *testing.T) {
 		"block_does_not_exists": {
 			propagate: false,
 		},
+		"validated_announce_relayed": {
+			mockSyncer: func(t *testing.T, peer peer.ID, blockAnnounceMessage *BlockAnnounceMessage) Syncer {
+				ctrl := gomock.NewController(t)
+				syncer := NewMockSyncer(ctrl)
+				syncer.EXPECT().
+					HandleBlockAnnounce(peer, blockAnnounceMessage).
+					Return(true, nil)
+				return syncer
+			},
+			propagate: true,
+		},
+		"invalid_announce_not_relayed": {
+			mockSyncer: func(t *testing.T, peer peer.ID, blockAnnounceMessage *BlockAnnounceMessage) Syncer {
+				ctrl := gomock.NewController(t)
+				syncer := NewMockSyncer(ctrl)
+				syncer.EXPECT().
+					HandleBlockAnnounce(peer, blockAnnounceMessage).
+					Return(false, errors.New("invalid block announce"))
+				return syncer
+			},
+			errMessage: "invalid block announce",
+			propagate:  false,
+		},
 	}
 
 	for tname, tt := range testCases {
@@ -176,7 +201,11 @@ func TestHandleBlockAnnounceMessage(t *testing.T) {
 			service := createTestService(t, config)
 			gotPropagate, err := service.handleBlockAnnounceMessage(peerID, msg)
 
-			require.NoError(t, err)
+			if tt.errMessage != "" {
+				require.EqualError(t, err, tt.errMessage)
+			} else {
+				require.NoError(t, err)
+			}
 			require.Equal(t, tt.propagate, gotPropagate)
 		})
 	}
@@ -0,0 +1,180 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// recentPeersFilename is the name of the file in the base path storing
+// the bootstrap peers which were reachable when the node last started.
+const recentPeersFilename = "recent_peers.json"
+
+type bootnodeDialResult struct {
+	addrInfo peer.AddrInfo
+	err      error
+}
+
+// dialBootnodes dials the peers reachable when the node last started and the
+// bootnodes concurrently, each with the bootnode dial timeout, and returns as
+// soon as the minimum number of bootnodes connected or all the dials are done.
+// The reachable peers are added to the peer set and stored as the recent peers
+// once all the dials are done, and the unreachable ones are logged and skipped.
+func (h *host) dialBootnodes() {
+	recentPeers, err := loadRecentPeers(h.basePath)
+	if err != nil {
+		logger.Warnf("failed to load recent peers: %s", err)
+	}
+
+	candidates := bootstrapCandidates(recentPeers, h.bootnodes, h.id())
+	if len(candidates) == 0 {
+		return
+	}
+
+	results := make(chan bootnodeDialResult, len(candidates))
+	for _, addrInfo := range candidates {
+		h.p2pHost.Peerstore().AddAddrs(addrInfo.ID, addrInfo.Addrs, peerstore.PermanentAddrTTL)
+		go func(addrInfo peer.AddrInfo) {
+			ctx, cancel := context.WithTimeout(h.ctx, h.bootnodeDialTimeout)
+			defer cancel()
+			results <- bootnodeDialResult{
+				addrInfo: addrInfo,
+				err:      h.p2pHost.Connect(ctx, addrInfo),
+			}
+		}(addrInfo)
+	}
+
+	minConnected := make(chan struct{})
+	go h.collectBootnodeDials(candidates, results, minConnected)
+	<-minConnected
+}
+
+// collectBootnodeDials collects the results of the bootnode dials, closing
+// minConnected once the minimum number of bootnodes connected or all the
+// dials are done.
+func (h *host) collectBootnodeDials(candidates []peer.AddrInfo,
+	results <-chan bootnodeDialResult, minConnected chan<- struct{}) {
+	signalled := false
+	signal := func() {
+		if !signalled {
+			signalled = true
+			close(minConnected)
+		}
+	}
+
+	var reachable []peer.AddrInfo
+	for range candidates {
+		result := <-results
+		if result.err != nil {
+			logger.Warnf("skipping unreachable bootnode %s: %s", result.addrInfo, result.err)
+			continue
+		}
+
+		logger.Debugf("bootstrapping to peer %s", result.addrInfo.ID)
+		h.cm.peerSetHandler.AddPeer(0, result.addrInfo.ID)
+		reachable = append(reachable, result.addrInfo)
+		if len(reachable) >= h.minBootnodes {
+			signal()
+		}
+	}
+	signal()
+
+	if h.ctx.Err() != nil {
+		return
+	}
+
+	if len(reachable) == 0 {
+		// keep on trying all the candidates through the peer set,
+		// since the node may have no network connectivity yet.
+		logger.Warn("no bootnode is reachable")
+		for _, addrInfo := range candidates {
+			h.cm.peerSetHandler.AddPeer(0, addrInfo.ID)
+		}
+		return
+	}
+
+	err := storeRecentPeers(h.basePath, reachable)
+	if err != nil {
+		logger.Warnf("failed to store recent peers: %s", err)
+	}
+}
+
+// bootstrapCandidates returns the recent peers followed by the bootnodes,
+// without duplicate peers and without the local peer.
+func bootstrapCandidates(recentPeers, bootnodes []peer.AddrInfo, self peer.ID) (candidates []peer.AddrInfo) {
+	seen := make(map[peer.ID]struct{}, len(recentPeers)+len(bootnodes))
+	for _, addrInfos := range [][]peer.AddrInfo{recentPeers, bootnodes} {
+		for _, addrInfo := range addrInfos {
+			_, has := seen[addrInfo.ID]
+			if has || addrInfo.ID == self {
+				continue
+			}
+			seen[addrInfo.ID] = struct{}{}
+			candidates = append(candidates, addrInfo)
+		}
+	}
+	return candidates
+}
+
+// loadRecentPeers loads the recent peers stored in the base path given,
+// and returns no peer if none is stored.
+func loadRecentPeers(basePath string) (recentPeers []peer.AddrInfo, err error) {
+	data, err := os.ReadFile(filepath.Join(basePath, recentPeersFilename))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var addresses []string
+	err = json.Unmarshal(data, &addresses)
+	if err != nil {
+		return nil, fmt.Errorf("decoding recent peers: %w", err)
+	}
+
+	addrs, err := parseMultiaddrs(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	return peer.AddrInfosFromP2pAddrs(addrs...)
+}
+
+// storeRecentPeers stores the recent peers given in the base path given.
+func storeRecentPeers(basePath string, recentPeers []peer.AddrInfo) error {
+	var addresses []string
+	for _, addrInfo := range recentPeers {
+		addrs, err := peer.AddrInfoToP2pAddrs(&addrInfo)
+		if err != nil {
+			return fmt.Errorf("encoding recent peer %s: %w", addrInfo.ID, err)
+		}
+		addresses = append(addresses, multiaddrsToStrings(addrs)...)
+	}
+
+	data, err := json.Marshal(addresses)
+	if err != nil {
+		return fmt.Errorf("encoding recent peers: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(basePath, recentPeersFilename), data, 0600)
+}
+
+func multiaddrsToStrings(addrs []ma.Multiaddr) (addresses []string) {
+	addresses = make([]string, len(addrs))
+	for i, addr := range addrs {
+		addresses[i] = addr.String()
+	}
+	return addresses
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package network
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bootstrapCandidates(t *testing.T) {
+	t.Parallel()
+
+	self := peer.AddrInfo{ID: "self"}
+	a := peer.AddrInfo{ID: "a"}
+	b := peer.AddrInfo{ID: "b"}
+	c := peer.AddrInfo{ID: "c"}
+
+	candidates := bootstrapCandidates(
+		[]peer.AddrInfo{b, self},
+		[]peer.AddrInfo{a, b, c},
+		self.ID,
+	)
+
+	// the recent peers are dialed first
+	assert.Equal(t, []peer.AddrInfo{b, a, c}, candidates)
+}
+
+func Test_storeRecentPeers_loadRecentPeers(t *testing.T) {
+	t.Parallel()
+
+	basePath := t.TempDir()
+
+	recentPeers, err := loadRecentPeers(basePath)
+	require.NoError(t, err)
+	assert.Empty(t, recentPeers)
+
+	addrInfo, err := peer.AddrInfoFromString(
+		"/ip4/104.131.131.82/tcp/4001/p2p/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ")
+	require.NoError(t, err)
+	addrInfo.Addrs = append(addrInfo.Addrs, ma.StringCast("/ip4/104.131.131.82/udp/4001/quic-v1"))
+
+	err = storeRecentPeers(basePath, []peer.AddrInfo{*addrInfo})
+	require.NoError(t, err)
+
+	recentPeers, err = loadRecentPeers(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, []peer.AddrInfo{*addrInfo}, recentPeers)
+}
@@ -11,6 +11,7 @@
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
+	ma "github.com/multiformats/go-multiaddr"
 
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/internal/metrics"
@@ -45,6 +46,12 @@
 	// DefaultDiscoveryInterval is the default interval for searching for DHT peers
 	DefaultDiscoveryInterval = time.Minute * 5
 
+	// DefaultMinBootnodes is the default number of bootnodes to connect to on start
+	DefaultMinBootnodes = 1
+
+	// DefaultBootnodeDialTimeout is the default timeout to connect to each bootnode on start
+	DefaultBootnodeDialTimeout = time.Second * 3
+
 	defaultTxnBatchSize = 100
 )
 
@@ -82,13 +89,32 @@ type Config struct {
 	ProtocolID string
 	// NoBootstrap disables bootstrapping
 	NoBootstrap bool
+	// MinBootnodes is the number of bootnodes to connect to before the service
+	// starts, and defaults to DefaultMinBootnodes if it is zero.
+	MinBootnodes int
+	// BootnodeDialTimeout is the timeout to connect to each bootnode on start, and
+	// defaults to DefaultBootnodeDialTimeout if it is zero.
+	BootnodeDialTimeout time.Duration
 	// NoMDNS disables MDNS discovery
 	NoMDNS bool
 	// ListenAddress is the multiaddress to listen on
 	ListenAddress string
+	// ListenAddresses are additional multiaddresses to listen on, for
+	// example a QUIC multiaddress in addition to the TCP listen address.
+	ListenAddresses []string
+	// ExternalAddresses are the multiaddresses advertised to peers in identify
+	// responses and DHT records. If set, they override the addresses observed
+	// by the node, which are private addresses if the node is behind a NAT.
+	ExternalAddresses []string
 
 	MinPeers int
 	MaxPeers int
+	// InPeers is the maximum number of incoming connections with peers other
+	// than the reserved peers, and defaults to MaxPeers-MinPeers if it is zero.
+	InPeers int
+	// OutPeers is the maximum number of outgoing connections with peers other
+	// than the reserved peers, and defaults to MaxPeers/2 if it is zero.
+	OutPeers int
 
 	DiscoveryInterval time.Duration
 
@@ -142,11 +168,30 @@ func (c *Config) build() error {
 		return err
 	}
 
+	// check multiaddresses syntax
+	_, err = c.listenMultiaddrs()
+	if err != nil {
+		return err
+	}
+
+	_, err = parseMultiaddrs(c.ExternalAddresses)
+	if err != nil {
+		return fmt.Errorf("parsing external addresses: %w", err)
+	}
+
 	// check bootnoode configuration
 	if !c.NoBootstrap && len(c.Bootnodes) == 0 {
 		c.logger.Warn("Bootstrap is enabled but no bootstrap nodes are defined")
 	}
 
+	if c.MinBootnodes == 0 {
+		c.MinBootnodes = DefaultMinBootnodes
+	}
+
+	if c.BootnodeDialTimeout == 0 {
+		c.BootnodeDialTimeout = DefaultBootnodeDialTimeout
+	}
+
 	// set telemetryInterval to default
 	if c.telemetryInterval.Microseconds() == 0 {
 		c.telemetryInterval = time.Second * 5
@@ -155,6 +200,42 @@ func (c *Config) build() error {
 	return nil
 }
 
+// listenMultiaddrs returns the multiaddresses to listen on, which are the
+// listen address, or the TCP address with the configured port if it is not
+// set, followed by the additional listen addresses.
+func (c *Config) listenMultiaddrs() (addrs []ma.Multiaddr, err error) {
+	listenAddress := fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", c.Port)
+	if c.ListenAddress != "" {
+		listenAddress = c.ListenAddress
+	}
+
+	listenAddresses := append([]string{listenAddress}, c.ListenAddresses...)
+	addrs, err = parseMultiaddrs(listenAddresses)
+	if err != nil {
+		return nil, fmt.Errorf("parsing listen addresses: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if !isSupportedTransport(addr) {
+			return nil, fmt.Errorf("%w: listen address %s must use tcp or quic-v1",
+				errUnsupportedTransport, addr)
+		}
+	}
+	return addrs, nil
+}
+
+// isSupportedTransport returns true if the multiaddress given uses the TCP
+// or QUIC transport, which are the transports the host can listen on.
+func isSupportedTransport(addr ma.Multiaddr) bool {
+	for _, proto := range addr.Protocols() {
+		switch proto.Code {
+		case ma.P_TCP, ma.P_QUIC_V1:
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) checkState() (err error) {
 	// set NoStatus to true if we don't need BlockState
 	if c.BlockState == nil {
@@ -84,3 +84,65 @@ func TestBuild(t *testing.T) {
 	require.Equal(t, false, cfg.NoBootstrap)
 	require.Equal(t, false, cfg.NoMDNS)
 }
+
+func TestBuild_multiaddrs(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		listenAddress     string
+		listenAddresses   []string
+		externalAddresses []string
+		errWrapped        error
+		errMessage        string
+	}{
+		"valid_multiaddrs": {
+			listenAddress:     "/ip4/0.0.0.0/tcp/7001",
+			listenAddresses:   []string{"/ip4/0.0.0.0/udp/7001/quic-v1"},
+			externalAddresses: []string{"/ip4/1.2.3.4/tcp/7001", "/dns/example.com/tcp/7001"},
+		},
+		"invalid_listen_address": {
+			listenAddress: "/ip4/0.0.0.0/tcp",
+			errWrapped:    errInvalidMultiaddr,
+			errMessage:    "parsing listen addresses: invalid multiaddress: \"/ip4/0.0.0.0/tcp\": ",
+		},
+		"invalid_additional_listen_address": {
+			listenAddresses: []string{"/ip4/0.0.0.0/udp/7001/quic-v1", "0.0.0.0:7001"},
+			errWrapped:      errInvalidMultiaddr,
+			errMessage:      "parsing listen addresses: invalid multiaddress: \"0.0.0.0:7001\": ",
+		},
+		"unsupported_listen_transport": {
+			listenAddresses: []string{"/ip4/0.0.0.0/udp/7001"},
+			errWrapped:      errUnsupportedTransport,
+			errMessage:      "unsupported transport: listen address /ip4/0.0.0.0/udp/7001 must use tcp or quic-v1",
+		},
+		"invalid_external_address": {
+			externalAddresses: []string{"/ip4/1.2.3/tcp/7001"},
+			errWrapped:        errInvalidMultiaddr,
+			errMessage:        "parsing external addresses: invalid multiaddress: \"/ip4/1.2.3/tcp/7001\": ",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				logger:            log.New(log.SetWriter(io.Discard)),
+				BlockState:        &state.BlockState{},
+				BasePath:          t.TempDir(),
+				RandSeed:          1,
+				ListenAddress:     testCase.listenAddress,
+				ListenAddresses:   testCase.listenAddresses,
+				ExternalAddresses: testCase.externalAddresses,
+			}
+
+			err := cfg.build()
+
+			require.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				require.ErrorContains(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
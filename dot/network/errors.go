@@ -14,8 +14,12 @@
 	errHandshakeTimeout              = errors.New("handshake timeout reached")
 	errBlockRequestFromNumberInvalid = errors.New("block request message From number is not valid")
 	errInvalidStartingBlockType      = errors.New("invalid StartingBlock in messsage")
+	errStateRequestBlockHashInvalid  = errors.New("state request message block hash is not valid")
+	errStateRootInvalid              = errors.New("state response state root is not valid")
 	errInboundHanshakeExists         = errors.New("an inbound handshake already exists for given peer")
 	errInvalidRole                   = errors.New("invalid role")
+	errInvalidMultiaddr              = errors.New("invalid multiaddress")
+	errUnsupportedTransport          = errors.New("unsupported transport")
 	ErrFailedToReadEntireMessage     = errors.New("failed to read entire message")
 	ErrNilStream                     = errors.New("nil stream")
 	ErrInvalidLEB128EncodedData      = errors.New("invalid LEB128 encoded data")
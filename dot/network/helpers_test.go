@@ -249,12 +249,16 @@ func createTestService(t *testing.T, cfg *Config) (srvc *Service) {
 		syncer.EXPECT().
 			HandleBlockAnnounce(
 				gomock.AssignableToTypeOf(peer.ID("")), gomock.Any()).
-			Return(nil).AnyTimes()
+			Return(false, nil).AnyTimes()
 
 		syncer.EXPECT().
 			CreateBlockResponse(gomock.Any()).
 			Return(newTestBlockResponseMessage(t), nil).AnyTimes()
 
+		syncer.EXPECT().
+			HandlePeerDisconnected(gomock.AssignableToTypeOf(peer.ID(""))).
+			AnyTimes()
+
 		syncer.EXPECT().IsSynced().Return(false).AnyTimes()
 		cfg.Syncer = syncer
 	}
@@ -9,7 +9,6 @@
 	"log"
 	"net"
 	"path"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +27,8 @@
 	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoreds"
 	rm "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	rmObs "github.com/libp2p/go-libp2p/p2p/host/resource-manager/obs"
+	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -76,34 +77,48 @@ type host struct {
 	discovery       *discovery
 	bootnodes       []peer.AddrInfo
 	persistentPeers []peer.AddrInfo
-	protocolID      protocol.ID
-	cm              *ConnManager
-	ds              *badger.Datastore
-	messageCache    *messageCache
-	bwc             *metrics.BandwidthCounter
-	closeSync       sync.Once
+	basePath        string
+
+	minBootnodes        int
+	bootnodeDialTimeout time.Duration
+
+	protocolID   protocol.ID
+	cm           *ConnManager
+	ds           *badger.Datastore
+	messageCache *messageCache
+	bwc          *metrics.BandwidthCounter
+	closeSync    sync.Once
 }
 
-func newHost(ctx context.Context, cfg *Config) (*host, error) {
-	// create multiaddress (without p2p identity)
-	listenAddress := fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", cfg.Port)
-	if cfg.ListenAddress != "" {
-		listenAddress = cfg.ListenAddress
-	}
-	addr, err := ma.NewMultiaddr(listenAddress)
+// publicAddr returns the multiaddress made of the ip or dns prefix given, followed
+// by the transport of the listen address given, for example /ip4/1.2.3.4/udp/7001/quic-v1
+// for the prefix /ip4/1.2.3.4 and the listen address /ip4/0.0.0.0/udp/7001/quic-v1.
+func publicAddr(prefix string, listenAddr ma.Multiaddr) (ma.Multiaddr, error) {
+	hostAddr, err := ma.NewMultiaddr(prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	portString, err := addr.ValueForProtocol(ma.P_TCP)
+	_, transport := ma.SplitFirst(listenAddr)
+	if transport == nil {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedTransport, listenAddr)
+	}
+
+	return hostAddr.Encapsulate(transport), nil
+}
+
+func newHost(ctx context.Context, cfg *Config) (*host, error) {
+	// create multiaddresses (without p2p identity)
+	listenAddrs, err := cfg.listenMultiaddrs()
 	if err != nil {
 		return nil, err
 	}
 
-	port, err := strconv.ParseUint(portString, 10, 64)
+	configuredExternalAddrs, err := parseMultiaddrs(cfg.ExternalAddresses)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("parsing external addresses: %w", err)
 	}
+
 	var externalAddr ma.Multiaddr
 
 	switch {
@@ -113,23 +128,25 @@ func newHost(ctx context.Context, cfg *Config) (*host, error) {
 			return nil, fmt.Errorf("invalid public ip: %s", cfg.PublicIP)
 		}
 		logger.Debugf("using config PublicIP: %s", ip)
-		externalAddr, err = ma.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%d", ip, port))
+		externalAddr, err = publicAddr(fmt.Sprintf("/ip4/%s", ip), listenAddrs[0])
 		if err != nil {
 			return nil, err
 		}
 	case strings.TrimSpace(cfg.PublicDNS) != "":
 		logger.Debugf("using config PublicDNS: %s", cfg.PublicDNS)
-		externalAddr, err = ma.NewMultiaddr(fmt.Sprintf("/dns/%s/tcp/%d", cfg.PublicDNS, port))
+		externalAddr, err = publicAddr(fmt.Sprintf("/dns/%s", cfg.PublicDNS), listenAddrs[0])
 		if err != nil {
 			return nil, err
 		}
+	case len(configuredExternalAddrs) > 0:
+		logger.Debugf("using config ExternalAddresses: %v", configuredExternalAddrs)
 	default:
 		ip, err := pubip.Get()
 		if err != nil {
 			logger.Errorf("failed to get public IP error: %v", err)
 		} else {
 			logger.Debugf("got public IP address %s", ip)
-			externalAddr, err = ma.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%d", ip, port))
+			externalAddr, err = publicAddr(fmt.Sprintf("/ip4/%s", ip), listenAddrs[0])
 			if err != nil {
 				return nil, err
 			}
@@ -148,12 +165,12 @@ func newHost(ctx context.Context, cfg *Config) (*host, error) {
 		return nil, fmt.Errorf("failed to parse persistent peers: %w", err)
 	}
 
-	// We have tried to set maxInPeers and maxOutPeers such that number of peer
+	// By default, maxInPeers and maxOutPeers are set such that number of peer
 	// connections remain between min peers and max peers
 	const reservedOnly = false
 	peerCfgSet := peerset.NewConfigSet(
-		uint32(cfg.MaxPeers-cfg.MinPeers),
-		uint32(cfg.MaxPeers/2),
+		uint32(cfg.InPeers),
+		uint32(cfg.OutPeers),
 		reservedOnly,
 		peerSetSlotAllocTime,
 	)
@@ -202,13 +219,24 @@ func newHost(ctx context.Context, cfg *Config) (*host, error) {
 	// set libp2p host options
 	opts := []libp2p.Option{
 		libp2p.ResourceManager(manager),
-		libp2p.ListenAddrs(addr),
+		libp2p.ListenAddrs(listenAddrs...),
+		libp2p.Transport(tcp.NewTCPTransport),
+		libp2p.Transport(libp2pquic.NewTransport),
 		libp2p.DisableRelay(),
 		libp2p.Identity(cfg.privateKey),
 		libp2p.NATPortMap(),
 		libp2p.Peerstore(ps),
 		libp2p.ConnectionManager(cm),
 		libp2p.AddrsFactory(func(as []ma.Multiaddr) []ma.Multiaddr {
+			// the configured external addresses override the observed addresses
+			if len(configuredExternalAddrs) > 0 {
+				addrs := append([]ma.Multiaddr{}, configuredExternalAddrs...)
+				if externalAddr == nil {
+					return addrs
+				}
+				return append(addrs, externalAddr)
+			}
+
 			addrs := []ma.Multiaddr{}
 			for _, addr := range as {
 				if !privateIPs.AddrBlocked(addr) {
@@ -254,8 +282,12 @@ func newHost(ctx context.Context, cfg *Config) (*host, error) {
 		cm:              cm,
 		ds:              ds,
 		persistentPeers: pps,
-		messageCache:    msgCache,
-		bwc:             bwc,
+		basePath:        cfg.BasePath,
+
+		minBootnodes:        cfg.MinBootnodes,
+		bootnodeDialTimeout: cfg.BootnodeDialTimeout,
+		messageCache:        msgCache,
+		bwc:                 bwc,
 	}
 
 	cm.host = host
@@ -308,18 +340,15 @@ func (h *host) connect(p peer.AddrInfo) (err error) {
 	return err
 }
 
-// bootstrap connects the host to the configured bootnodes
+// bootstrap adds the persistent peers as reserved peers and connects
+// the host to the configured bootnodes
 func (h *host) bootstrap() {
 	for _, info := range h.persistentPeers {
 		h.p2pHost.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
 		h.cm.peerSetHandler.AddReservedPeer(0, info.ID)
 	}
 
-	for _, addrInfo := range h.bootnodes {
-		logger.Debugf("bootstrapping to peer %s", addrInfo.ID)
-		h.p2pHost.Peerstore().AddAddrs(addrInfo.ID, addrInfo.Addrs, peerstore.PermanentAddrTTL)
-		h.cm.peerSetHandler.AddPeer(0, addrInfo.ID)
-	}
+	h.dialBootnodes()
 }
 
 // send creates a new outbound stream with the given peer and writes the message. It also returns
@@ -6,12 +6,16 @@
 package network
 
 import (
+	"crypto/rand"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/ChainSafe/gossamer/dot/peerset"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	ma "github.com/multiformats/go-multiaddr"
@@ -104,6 +108,39 @@ func TestExternalAddrsPublicDNS(t *testing.T) {
 
 }
 
+func TestExternalAddrsConfigured(t *testing.T) {
+	t.Parallel()
+
+	port := availablePort(t)
+	config := &Config{
+		BasePath: t.TempDir(),
+		Port:     port,
+		ListenAddresses: []string{
+			fmt.Sprintf("/ip4/127.0.0.1/udp/%d/quic-v1", port),
+		},
+		ExternalAddresses: []string{
+			"/ip4/10.0.5.2/tcp/30333",
+			"/dns/alice.example.com/udp/30333/quic-v1",
+		},
+		NoBootstrap: true,
+		NoMDNS:      true,
+	}
+
+	node := createTestService(t, config)
+
+	// the configured external addresses are advertised instead of the observed addresses
+	expected := []ma.Multiaddr{
+		mustNewMultiAddr("/ip4/10.0.5.2/tcp/30333"),
+		mustNewMultiAddr("/dns/alice.example.com/udp/30333/quic-v1"),
+	}
+	assert.Equal(t, expected, addrInfo(node.host).Addrs)
+
+	// the node listens on both the TCP and QUIC addresses
+	listenAddrs := node.host.p2pHost.Network().ListenAddresses()
+	assert.Contains(t, listenAddrs, mustNewMultiAddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port)))
+	assert.Contains(t, listenAddrs, mustNewMultiAddr(fmt.Sprintf("/ip4/127.0.0.1/udp/%d/quic-v1", port)))
+}
+
 // test host connect method
 func TestConnect(t *testing.T) {
 	t.Parallel()
@@ -183,6 +220,60 @@ func TestBootstrap(t *testing.T) {
 	}
 }
 
+func TestBootstrap_unreachableBootnodes(t *testing.T) {
+	t.Parallel()
+
+	configA := &Config{
+		BasePath:    t.TempDir(),
+		Port:        availablePort(t),
+		NoBootstrap: true,
+		NoMDNS:      true,
+	}
+
+	nodeA := createTestService(t, configA)
+	nodeA.noGossip = true
+
+	newPeerID := func() peer.ID {
+		privateKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		peerID, err := peer.IDFromPrivateKey(privateKey)
+		require.NoError(t, err)
+		return peerID
+	}
+
+	// the first bootnode refuses connections and the second one never answers
+	refusingBootnode := fmt.Sprintf("/ip4/127.0.0.1/tcp/%d/p2p/%s", availablePort(t), newPeerID())
+	silentBootnode := fmt.Sprintf("/ip4/192.0.2.1/tcp/30333/p2p/%s", newPeerID())
+
+	configB := &Config{
+		BasePath:            t.TempDir(),
+		Port:                availablePort(t),
+		Bootnodes:           []string{refusingBootnode, silentBootnode, nodeA.host.multiaddrs()[0].String()},
+		NoMDNS:              true,
+		MinBootnodes:        1,
+		BootnodeDialTimeout: 5 * time.Second,
+	}
+
+	start := time.Now()
+	nodeB := createTestService(t, configB)
+	nodeB.noGossip = true
+
+	// the node starts once node A connects, without waiting for the silent bootnode
+	assert.Less(t, time.Since(start), 4*time.Second)
+	assert.Equal(t, network.Connected, nodeB.host.p2pHost.Network().Connectedness(nodeA.host.id()))
+
+	// only node A is stored as a recent peer once all the dials are done
+	require.Eventually(t, func() bool {
+		recentPeers, err := loadRecentPeers(configB.BasePath)
+		require.NoError(t, err)
+		return len(recentPeers) > 0
+	}, 10*time.Second, 100*time.Millisecond)
+	recentPeers, err := loadRecentPeers(configB.BasePath)
+	require.NoError(t, err)
+	require.Len(t, recentPeers, 1)
+	assert.Equal(t, nodeA.host.id(), recentPeers[0].ID)
+}
+
 // test host send method
 func TestSend(t *testing.T) {
 	t.Parallel()
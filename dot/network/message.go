@@ -172,6 +172,40 @@ func (bm *BlockRequestMessage) Decode(in []byte) error {
 // BlockResponseMessage is sent in response to a BlockRequestMessage
 type BlockResponseMessage struct {
 	BlockData []*types.BlockData
+	// encoding is the protobuf encoding of the message if it is
+	// already known, in which case it is returned by Encode.
+	encoding []byte
+}
+
+// NewEncodedBlockResponseMessage returns a BlockResponseMessage with the block data
+// given and its encoding, which is the concatenation of the encodings of the block
+// data returned by EncodeRawBlockData, such that the message is not encoded again.
+func NewEncodedBlockResponseMessage(blockData []*types.BlockData, encoding []byte) *BlockResponseMessage {
+	return &BlockResponseMessage{
+		BlockData: blockData,
+		encoding:  encoding,
+	}
+}
+
+// RawBlockData is the block data of a block response
+// with its header and extrinsics SCALE encoded already.
+type RawBlockData struct {
+	Hash          common.Hash
+	Header        []byte
+	Body          [][]byte
+	Receipt       *[]byte
+	MessageQueue  *[]byte
+	Justification *[]byte
+}
+
+// EncodeRawBlockData returns the protobuf encoding of a BlockResponseMessage containing
+// only the raw block data given, without encoding its header and extrinsics again.
+// Since the block data of the message is a repeated protobuf field, the encoding of
+// a message is the concatenation of the encodings of each of its block data.
+func EncodeRawBlockData(raw RawBlockData) (encoding []byte, err error) {
+	return proto.Marshal(&pb.BlockResponse{
+		Blocks: []*pb.BlockData{rawBlockDataToProtobuf(raw)},
+	})
 }
 
 // String formats a BlockResponseMessage as a string
@@ -185,6 +219,10 @@ func (bm *BlockResponseMessage) String() string {
 
 // Encode returns the protobuf encoded BlockResponseMessage
 func (bm *BlockResponseMessage) Encode() ([]byte, error) {
+	if bm.encoding != nil {
+		return bm.encoding, nil
+	}
+
 	var (
 		err error
 	)
@@ -212,6 +250,7 @@ func (bm *BlockResponseMessage) Decode(in []byte) (err error) {
 	}
 
 	bm.BlockData = make([]*types.BlockData, len(msg.Blocks))
+	bm.encoding = nil
 
 	for i, bd := range msg.Blocks {
 		block, err := protobufToBlockData(bd)
@@ -226,8 +265,11 @@ func (bm *BlockResponseMessage) Decode(in []byte) (err error) {
 
 // blockDataToProtobuf converts a gossamer BlockData to a protobuf-defined BlockData
 func blockDataToProtobuf(bd *types.BlockData) (*pb.BlockData, error) {
-	p := &pb.BlockData{
-		Hash: bd.Hash[:],
+	raw := RawBlockData{
+		Hash:          bd.Hash,
+		Receipt:       bd.Receipt,
+		MessageQueue:  bd.MessageQueue,
+		Justification: bd.Justification,
 	}
 
 	if bd.Header != nil {
@@ -235,7 +277,7 @@ func blockDataToProtobuf(bd *types.BlockData) (*pb.BlockData, error) {
 		if err != nil {
 			return nil, err
 		}
-		p.Header = header
+		raw.Header = header
 	}
 
 	if bd.Body != nil {
@@ -245,25 +287,36 @@ func blockDataToProtobuf(bd *types.BlockData) (*pb.BlockData, error) {
 			return nil, err
 		}
 
-		p.Body = types.ExtrinsicsArrayToBytesArray(exts)
+		raw.Body = types.ExtrinsicsArrayToBytesArray(exts)
+	}
+
+	return rawBlockDataToProtobuf(raw), nil
+}
+
+// rawBlockDataToProtobuf converts a RawBlockData to a protobuf-defined BlockData
+func rawBlockDataToProtobuf(raw RawBlockData) *pb.BlockData {
+	p := &pb.BlockData{
+		Hash:   raw.Hash[:],
+		Header: raw.Header,
+		Body:   raw.Body,
 	}
 
-	if bd.Receipt != nil {
-		p.Receipt = *bd.Receipt
+	if raw.Receipt != nil {
+		p.Receipt = *raw.Receipt
 	}
 
-	if bd.MessageQueue != nil {
-		p.MessageQueue = *bd.MessageQueue
+	if raw.MessageQueue != nil {
+		p.MessageQueue = *raw.MessageQueue
 	}
 
-	if bd.Justification != nil {
-		p.Justification = *bd.Justification
-		if len(*bd.Justification) == 0 {
+	if raw.Justification != nil {
+		p.Justification = *raw.Justification
+		if len(*raw.Justification) == 0 {
 			p.IsEmptyJustification = true
 		}
 	}
 
-	return p, nil
+	return p
 }
 
 func protobufToBlockData(pbd *pb.BlockData) (*types.BlockData, error) {
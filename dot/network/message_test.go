@@ -11,6 +11,7 @@
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
+	"github.com/ChainSafe/gossamer/pkg/scale"
 
 	"github.com/stretchr/testify/require"
 )
@@ -256,6 +257,58 @@ func TestEncodeBlockResponseMessage_WithAll(t *testing.T) {
 	require.Equal(t, bm, act)
 }
 
+func TestNewEncodedBlockResponseMessage(t *testing.T) {
+	t.Parallel()
+
+	blockData := []*types.BlockData{
+		{
+			Hash:   common.Hash{1},
+			Header: types.NewHeader(common.Hash{2}, common.Hash{3}, common.Hash{4}, 1, types.NewDigest()),
+		},
+		{
+			Hash:          common.Hash{5},
+			Body:          types.NewBody(types.BytesArrayToExtrinsics([][]byte{{1, 2}})),
+			Justification: &[]byte{3},
+		},
+	}
+
+	expected, err := (&BlockResponseMessage{BlockData: blockData}).Encode()
+	require.NoError(t, err)
+
+	header, err := scale.Marshal(*blockData[0].Header)
+	require.NoError(t, err)
+	rawBlockData := []RawBlockData{
+		{
+			Hash:   common.Hash{1},
+			Header: header,
+		},
+		{
+			Hash:          common.Hash{5},
+			Body:          [][]byte{{8, 1, 2}},
+			Justification: &[]byte{3},
+		},
+	}
+
+	var encoding []byte
+	for _, raw := range rawBlockData {
+		encoded, err := EncodeRawBlockData(raw)
+		require.NoError(t, err)
+		encoding = append(encoding, encoded...)
+	}
+	require.Equal(t, expected, encoding)
+
+	bm := NewEncodedBlockResponseMessage(blockData, encoding)
+	enc, err := bm.Encode()
+	require.NoError(t, err)
+	require.Equal(t, expected, enc)
+
+	decoded := new(BlockResponseMessage)
+	err = decoded.Decode(enc)
+	require.NoError(t, err)
+	require.Len(t, decoded.BlockData, len(blockData))
+	require.Equal(t, blockData[1].Hash, decoded.BlockData[1].Hash)
+}
+
 func TestEncodeBlockAnnounceMessage(t *testing.T) {
 	/* this value is a concatenation of:
 	 *  ParentHash: Hash: 0x4545454545454545454545454545454545454545454545454545454545454545
@@ -181,7 +181,7 @@ func (s *Service) createNotificationsMessageHandler(
 			return nil
 		}
 
-		s.broadcastExcluding(info, peer, msg)
+		s.broadcastExcluding(info, msg, peer)
 		return nil
 	}
 }
@@ -396,10 +396,10 @@ func (s *Service) sendHandshake(peer peer.ID, hs Handshake, info *notificationsP
 	return hsData.stream, nil
 }
 
-// broadcastExcluding sends a message to each connected peer except the given peer,
+// broadcastExcluding sends a message to each connected peer except the given peers,
 // and peers that have previously sent us the message or who we have already sent the message to.
 // used for notifications sub-protocols to gossip a message
-func (s *Service) broadcastExcluding(info *notificationsProtocol, excluding peer.ID, msg NotificationsMessage) {
+func (s *Service) broadcastExcluding(info *notificationsProtocol, msg NotificationsMessage, excluding ...peer.ID) {
 	logger.Tracef("broadcasting message from notifications sub-protocol %s", info.protocolID)
 
 	hs, err := info.getHandshake()
@@ -410,7 +410,7 @@ func (s *Service) broadcastExcluding(info *notificationsProtocol, excluding peer
 
 	peers := s.host.peers()
 	for _, peer := range peers {
-		if peer == excluding {
+		if isExcluded(peer, excluding) {
 			continue
 		}
 
@@ -420,6 +420,15 @@ func (s *Service) broadcastExcluding(info *notificationsProtocol, excluding peer
 	}
 }
 
+func isExcluded(peerID peer.ID, excluding []peer.ID) bool {
+	for _, excluded := range excluding {
+		if peerID == excluded {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Service) readHandshake(stream network.Stream, decoder HandshakeDecoder, maxSize uint64,
 ) <-chan *handshakeReader {
 	hsC := make(chan *handshakeReader)
@@ -31,6 +31,8 @@
 
 	// the following are sub-protocols used by the node
 	SyncID          = "/sync/2"
+	StateSyncID     = "/state/2"
+	WarpSyncID      = "/sync/warp"
 	lightID         = "/light/2"
 	blockAnnounceID = "/block-announces/1"
 	transactionsID  = "/transactions/1"
@@ -126,6 +128,8 @@ type Service struct {
 	blockState         BlockState
 	syncer             Syncer
 	transactionHandler TransactionHandler
+	warpSyncProvider   WarpSyncProvider
+	stateProvider      StateProvider
 
 	// Configuration options
 	noBootstrap bool
@@ -170,6 +174,14 @@ func NewService(cfg *Config) (*Service, error) {
 		cfg.MaxPeers = DefaultMaxPeerCount
 	}
 
+	if cfg.InPeers == 0 {
+		cfg.InPeers = cfg.MaxPeers - cfg.MinPeers
+	}
+
+	if cfg.OutPeers == 0 {
+		cfg.OutPeers = cfg.MaxPeers / 2
+	}
+
 	if cfg.DiscoveryInterval > 0 {
 		connectToPeersTimeout = cfg.DiscoveryInterval
 	}
@@ -235,6 +247,18 @@ func (s *Service) SetTransactionHandler(handler TransactionHandler) {
 	s.transactionHandler = handler
 }
 
+// SetWarpSyncProvider sets the WarpSyncProvider used by the network service.
+// Warp sync proofs are only served to peers if it is set before the service starts.
+func (s *Service) SetWarpSyncProvider(provider WarpSyncProvider) {
+	s.warpSyncProvider = provider
+}
+
+// SetStateProvider sets the StateProvider used by the network service.
+// States are only served to peers if it is set before the service starts.
+func (s *Service) SetStateProvider(provider StateProvider) {
+	s.stateProvider = provider
+}
+
 // Start starts the network service
 func (s *Service) Start() error {
 	if s.syncer == nil {
@@ -251,6 +275,12 @@ func (s *Service) Start() error {
 
 	s.host.registerStreamHandler(s.host.protocolID+SyncID, s.handleSyncStream)
 	s.host.registerStreamHandler(s.host.protocolID+lightID, s.handleLightStream)
+	if s.warpSyncProvider != nil {
+		s.host.registerStreamHandler(s.host.protocolID+WarpSyncID, s.handleWarpSyncStream)
+	}
+	if s.stateProvider != nil {
+		s.host.registerStreamHandler(s.host.protocolID+StateSyncID, s.handleStateStream)
+	}
 
 	// register block announce protocol
 	err := s.RegisterNotificationsProtocol(
@@ -306,6 +336,7 @@ func (s *Service) Start() error {
 			prtl.peersData.deleteInboundHandshakeData(peerID)
 			prtl.peersData.deleteOutboundHandshakeData(peerID)
 		}
+		s.syncer.HandlePeerDisconnected(peerID)
 	}
 
 	// log listening addresses to console
@@ -528,6 +559,12 @@ func (s *Service) IsStopped() bool {
 
 // GossipMessage gossips a notifications protocol message to our peers
 func (s *Service) GossipMessage(msg NotificationsMessage) {
+	s.GossipMessageExcluding(msg)
+}
+
+// GossipMessageExcluding gossips a notifications protocol
+// message to our peers, except to the peers given.
+func (s *Service) GossipMessageExcluding(msg NotificationsMessage, excluding ...peer.ID) {
 	if s.host == nil || msg == nil || s.IsStopped() {
 		return
 	}
@@ -544,7 +581,7 @@ func (s *Service) GossipMessage(msg NotificationsMessage) {
 			continue
 		}
 
-		s.broadcastExcluding(prtl, peer.ID(""), msg)
+		s.broadcastExcluding(prtl, msg, excluding...)
 		return
 	}
 
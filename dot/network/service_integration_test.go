@@ -7,10 +7,13 @@
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ChainSafe/gossamer/dot/types"
@@ -92,6 +95,58 @@ func TestBroadcastMessages(t *testing.T) {
 	require.NotNil(t, handler.messages[nodeA.host.id()])
 }
 
+func TestBroadcastMessages_QUIC(t *testing.T) {
+	t.Parallel()
+
+	configA := &Config{
+		BasePath:      t.TempDir(),
+		ListenAddress: fmt.Sprintf("/ip4/127.0.0.1/udp/%d/quic-v1", availablePort(t)),
+		NoBootstrap:   true,
+		NoMDNS:        true,
+	}
+
+	nodeA := createTestService(t, configA)
+	nodeA.noGossip = true
+
+	configB := &Config{
+		BasePath:      t.TempDir(),
+		ListenAddress: fmt.Sprintf("/ip4/127.0.0.1/udp/%d/quic-v1", availablePort(t)),
+		NoBootstrap:   true,
+		NoMDNS:        true,
+	}
+
+	nodeB := createTestService(t, configB)
+	nodeB.noGossip = true
+	handler := newTestStreamHandler(testBlockAnnounceHandshakeDecoder)
+	nodeB.host.registerStreamHandler(nodeB.host.protocolID+blockAnnounceID, handler.handleStream)
+
+	addrInfoB := peer.AddrInfo{
+		ID:    nodeB.host.id(),
+		Addrs: nodeB.host.p2pHost.Network().ListenAddresses(),
+	}
+	err := nodeA.host.connect(addrInfoB)
+	// retry connect if "failed to dial" error
+	if failedToDial(err) {
+		time.Sleep(TestBackoffTimeout)
+		err = nodeA.host.connect(addrInfoB)
+	}
+	require.NoError(t, err)
+
+	conns := nodeA.host.p2pHost.Network().ConnsToPeer(nodeB.host.id())
+	require.NotEmpty(t, conns)
+	_, err = conns[0].RemoteMultiaddr().ValueForProtocol(ma.P_QUIC_V1)
+	require.NoError(t, err)
+
+	anounceMessage := &BlockAnnounceMessage{
+		Number: 128 * 7,
+		Digest: types.NewDigest(),
+	}
+
+	nodeA.GossipMessage(anounceMessage)
+	time.Sleep(time.Second * 2)
+	require.NotNil(t, handler.messages[nodeA.host.id()])
+}
+
 func TestBroadcastDuplicateMessage(t *testing.T) {
 	t.Parallel()
 
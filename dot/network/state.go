@@ -25,8 +25,11 @@ type Syncer interface {
 	HandleBlockAnnounceHandshake(from peer.ID, msg *BlockAnnounceHandshake) error
 
 	// HandleBlockAnnounce is called upon receipt of a BlockAnnounceMessage to process it.
-	// If a request needs to be sent to the peer to retrieve the full block, this function will return it.
-	HandleBlockAnnounce(from peer.ID, msg *BlockAnnounceMessage) error
+	// It returns true if the announced header is validated and the announcement can be relayed.
+	HandleBlockAnnounce(from peer.ID, msg *BlockAnnounceMessage) (gossip bool, err error)
+
+	// HandlePeerDisconnected is called when the connection to the given peer is closed.
+	HandlePeerDisconnected(who peer.ID)
 
 	// IsSynced exposes the internal synced state
 	IsSynced() bool
@@ -41,6 +44,18 @@ type TransactionHandler interface {
 	TransactionsCount() int
 }
 
+// WarpSyncProvider is the interface used by the warp sync sub-protocol
+type WarpSyncProvider interface {
+	// GenerateWarpSyncProof is called upon receipt of a WarpProofRequestMessage to create the response
+	GenerateWarpSyncProof(begin common.Hash) (proof *WarpSyncProof, err error)
+}
+
+// StateProvider is the interface used by the state sync sub-protocol
+type StateProvider interface {
+	// CreateStateResponse is called upon receipt of a StateRequestMessage to create the response
+	CreateStateResponse(req *StateRequestMessage) (*StateResponseMessage, error)
+}
+
 // PeerSetHandler is the interface used by the connection manager to handle peerset.
 type PeerSetHandler interface {
 	Start(context.Context)
@@ -0,0 +1,233 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/ChainSafe/gossamer/dot/network/proto"
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var (
+	StateRequestTimeout = time.Second * 30
+)
+
+// maxStateRequestSize is the maximum size of a state request, as in Substrate.
+const maxStateRequestSize = 1024 * 1024
+
+var _ Message = (*StateRequestMessage)(nil)
+
+// StateRequestMessage is sent to request the storage entries
+// of the state of a block from a peer.
+type StateRequestMessage struct {
+	// Block is the hash of the block to request the state of.
+	Block common.Hash
+	// Start is the key to start from, excluding the key itself.
+	// It contains a single top trie key, or the child storage key of
+	// a child trie followed by a key of this child trie, and is empty
+	// to start from the beginning of the state.
+	Start [][]byte
+	// NoProof is true to request the storage entries instead of a proof.
+	NoProof bool
+}
+
+// String formats a StateRequestMessage as a string
+func (sr *StateRequestMessage) String() string {
+	return fmt.Sprintf("StateRequestMessage Block=%s Start=0x%x NoProof=%t",
+		sr.Block, sr.Start, sr.NoProof)
+}
+
+// Encode returns the protobuf encoded StateRequestMessage
+func (sr *StateRequestMessage) Encode() ([]byte, error) {
+	msg := &pb.StateRequest{
+		Block:   sr.Block.ToBytes(),
+		Start:   sr.Start,
+		NoProof: sr.NoProof,
+	}
+
+	return proto.Marshal(msg)
+}
+
+// Decode decodes the protobuf encoded input to a StateRequestMessage
+func (sr *StateRequestMessage) Decode(in []byte) error {
+	msg := &pb.StateRequest{}
+	err := proto.Unmarshal(in, msg)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Block) != common.HashLength {
+		return fmt.Errorf("%w: expected %d bytes, got %d bytes",
+			errStateRequestBlockHashInvalid, common.HashLength, len(msg.Block))
+	}
+
+	sr.Block = common.BytesToHash(msg.Block)
+	sr.Start = msg.Start
+	sr.NoProof = msg.NoProof
+
+	return nil
+}
+
+var _ ResponseMessage = (*StateResponseMessage)(nil)
+
+// StateResponseMessage is sent in response to a StateRequestMessage
+type StateResponseMessage struct {
+	// Entries contains the storage entries of the top trie and of
+	// child tries, and is only populated if no proof was requested.
+	Entries []KeyValueStateEntry
+	// Proof contains the proof nodes if a proof was requested.
+	Proof []byte
+}
+
+// KeyValueStateEntry contains storage entries of a trie
+type KeyValueStateEntry struct {
+	// StateRoot is the root hash of the child trie the entries are from,
+	// and is the zero hash for entries of the top trie.
+	StateRoot common.Hash
+	Entries   []StateEntry
+	// Complete is true if there are no more entries to request for this trie.
+	Complete bool
+}
+
+// StateEntry is a storage key and its value
+type StateEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// String formats a StateResponseMessage as a string
+func (sr *StateResponseMessage) String() string {
+	if sr == nil {
+		return "StateResponseMessage=nil"
+	}
+
+	entries := 0
+	for _, keyValueStateEntry := range sr.Entries {
+		entries += len(keyValueStateEntry.Entries)
+	}
+
+	return fmt.Sprintf("StateResponseMessage Tries=%d Entries=%d ProofSize=%d",
+		len(sr.Entries), entries, len(sr.Proof))
+}
+
+// Encode returns the protobuf encoded StateResponseMessage
+func (sr *StateResponseMessage) Encode() ([]byte, error) {
+	msg := &pb.StateResponse{
+		Entries: make([]*pb.KeyValueStateEntry, len(sr.Entries)),
+		Proof:   sr.Proof,
+	}
+
+	for i, keyValueStateEntry := range sr.Entries {
+		pbEntry := &pb.KeyValueStateEntry{
+			Entries:  make([]*pb.StateEntry, len(keyValueStateEntry.Entries)),
+			Complete: keyValueStateEntry.Complete,
+		}
+
+		if keyValueStateEntry.StateRoot != (common.Hash{}) {
+			pbEntry.StateRoot = keyValueStateEntry.StateRoot.ToBytes()
+		}
+
+		for j, entry := range keyValueStateEntry.Entries {
+			pbEntry.Entries[j] = &pb.StateEntry{
+				Key:   entry.Key,
+				Value: entry.Value,
+			}
+		}
+
+		msg.Entries[i] = pbEntry
+	}
+
+	return proto.Marshal(msg)
+}
+
+// Decode decodes the protobuf encoded input to a StateResponseMessage
+func (sr *StateResponseMessage) Decode(in []byte) (err error) {
+	msg := &pb.StateResponse{}
+	err = proto.Unmarshal(in, msg)
+	if err != nil {
+		return err
+	}
+
+	sr.Entries = make([]KeyValueStateEntry, len(msg.Entries))
+	for i, pbEntry := range msg.Entries {
+		var stateRoot common.Hash
+		switch len(pbEntry.StateRoot) {
+		case 0:
+		case common.HashLength:
+			stateRoot = common.BytesToHash(pbEntry.StateRoot)
+		default:
+			return fmt.Errorf("%w: expected 0 or %d bytes, got %d bytes",
+				errStateRootInvalid, common.HashLength, len(pbEntry.StateRoot))
+		}
+
+		entries := make([]StateEntry, len(pbEntry.Entries))
+		for j, entry := range pbEntry.Entries {
+			entries[j] = StateEntry{
+				Key:   entry.Key,
+				Value: entry.Value,
+			}
+		}
+
+		sr.Entries[i] = KeyValueStateEntry{
+			StateRoot: stateRoot,
+			Entries:   entries,
+			Complete:  pbEntry.Complete,
+		}
+	}
+	sr.Proof = msg.Proof
+
+	return nil
+}
+
+// handleStateStream handles streams with the <protocol-id>/state/2 protocol ID
+func (s *Service) handleStateStream(stream libp2pnetwork.Stream) {
+	if stream == nil {
+		return
+	}
+
+	s.readStream(stream, decodeStateRequest, s.handleStateRequest, maxStateRequestSize)
+}
+
+func decodeStateRequest(in []byte, _ peer.ID, _ bool) (Message, error) {
+	msg := new(StateRequestMessage)
+	err := msg.Decode(in)
+	return msg, err
+}
+
+// handleStateRequest handles inbound state streams, on which
+// the only messages received are StateRequestMessages.
+func (s *Service) handleStateRequest(stream libp2pnetwork.Stream, msg Message) error {
+	defer func() {
+		err := stream.Close()
+		if err != nil {
+			logger.Warnf("failed to close stream: %s", err)
+		}
+	}()
+
+	req, ok := msg.(*StateRequestMessage)
+	if !ok {
+		return nil
+	}
+
+	resp, err := s.stateProvider.CreateStateResponse(req)
+	if err != nil {
+		logger.Debugf("cannot create state response for request: %s", err)
+		return nil
+	}
+
+	err = s.host.writeToStream(stream, resp)
+	if err != nil {
+		logger.Debugf("failed to send StateResponse message to peer %s: %s", stream.Conn().RemotePeer(), err)
+		return err
+	}
+
+	return nil
+}
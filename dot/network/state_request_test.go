@@ -0,0 +1,101 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package network
+
+import (
+	"testing"
+
+	pb "github.com/ChainSafe/gossamer/dot/network/proto"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_StateRequestMessage_Encode_Decode(t *testing.T) {
+	t.Parallel()
+
+	request := &StateRequestMessage{
+		Block:   common.Hash{1, 2},
+		Start:   [][]byte{{3}, {4, 5}},
+		NoProof: true,
+	}
+
+	encoded, err := request.Encode()
+	require.NoError(t, err)
+
+	expected := common.MustHexToBytes("0x0a200102000000000000000000000000000000" +
+		"000000000000000000000000000000120103120204051801")
+	assert.Equal(t, expected, encoded)
+
+	decoded := new(StateRequestMessage)
+	err = decoded.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, request, decoded)
+}
+
+func Test_StateRequestMessage_Decode_invalidBlockHash(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := proto.Marshal(&pb.StateRequest{Block: []byte{1}})
+	require.NoError(t, err)
+
+	decoded := new(StateRequestMessage)
+	err = decoded.Decode(encoded)
+	assert.ErrorIs(t, err, errStateRequestBlockHashInvalid)
+	assert.EqualError(t, err, "state request message block hash is not valid: "+
+		"expected 32 bytes, got 1 bytes")
+}
+
+func Test_StateResponseMessage_Encode_Decode(t *testing.T) {
+	t.Parallel()
+
+	response := &StateResponseMessage{
+		Entries: []KeyValueStateEntry{
+			{
+				Entries: []StateEntry{
+					{Key: []byte{1}, Value: []byte{2}},
+					{Key: []byte{3}, Value: []byte{4}},
+				},
+			},
+			{
+				StateRoot: common.Hash{5},
+				Entries: []StateEntry{
+					{Key: []byte{6}, Value: []byte{7}},
+				},
+				Complete: true,
+			},
+		},
+	}
+
+	encoded, err := response.Encode()
+	require.NoError(t, err)
+
+	decoded := new(StateResponseMessage)
+	err = decoded.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, response, decoded)
+
+	// The top trie state root is encoded as empty bytes.
+	pbResponse := &pb.StateResponse{}
+	err = proto.Unmarshal(encoded, pbResponse)
+	require.NoError(t, err)
+	assert.Empty(t, pbResponse.Entries[0].StateRoot)
+	assert.Equal(t, common.Hash{5}.ToBytes(), pbResponse.Entries[1].StateRoot)
+}
+
+func Test_StateResponseMessage_Decode_invalidStateRoot(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := proto.Marshal(&pb.StateResponse{
+		Entries: []*pb.KeyValueStateEntry{{StateRoot: []byte{1}}},
+	})
+	require.NoError(t, err)
+
+	decoded := new(StateResponseMessage)
+	err = decoded.Decode(encoded)
+	assert.ErrorIs(t, err, errStateRootInvalid)
+	assert.EqualError(t, err, "state response state root is not valid: "+
+		"expected 0 or 32 bytes, got 1 bytes")
+}
@@ -125,7 +125,7 @@ func (s *Service) startTxnBatchProcessing(txnBatchCh chan *batchMessage, slotDur
 						continue
 					}
 					if !hasSeen {
-						s.broadcastExcluding(s.notificationsProtocols[transactionMsgType], txnMsg.peer, txnMsg.msg)
+						s.broadcastExcluding(s.notificationsProtocols[transactionMsgType], txnMsg.msg, txnMsg.peer)
 					}
 				}
 			}
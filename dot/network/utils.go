@@ -22,6 +22,10 @@
 const (
 	// maxBlockRequestSize              uint64 = 1024 * 1024      // 1mb
 	MaxBlockResponseSize uint64 = 1024 * 1024 * 16 // 16mb
+	// MaxStateResponseSize is maximum size for a state response message.
+	MaxStateResponseSize uint64 = 1024 * 1024 * 16 // 16mb
+	// MaxWarpSyncProofSize is maximum size for a warp sync proof response message.
+	MaxWarpSyncProofSize uint64 = 1024 * 1024 * 8 // 8mb
 	// MaxGrandpaNotificationSize is maximum size for a grandpa notification message.
 	MaxGrandpaNotificationSize       uint64 = 1024 * 1024      // 1mb
 	maxTransactionsNotificationSize  uint64 = 1024 * 1024 * 16 // 16mb
@@ -59,6 +63,18 @@ func stringsToAddrInfos(peers []string) ([]peer.AddrInfo, error) {
 	return pinfos, nil
 }
 
+// parseMultiaddrs converts multiaddress strings to multiaddresses
+func parseMultiaddrs(addresses []string) (addrs []multiaddr.Multiaddr, err error) {
+	addrs = make([]multiaddr.Multiaddr, len(addresses))
+	for i, address := range addresses {
+		addrs[i], err = multiaddr.NewMultiaddr(address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", errInvalidMultiaddr, address, err)
+		}
+	}
+	return addrs, nil
+}
+
 // generateKey generates an ed25519 private key and writes it to the data directory
 // If the seed is zero, we use real cryptographic randomness. Otherwise, we use a
 // deterministic randomness source to make keys the same across multiple runs.
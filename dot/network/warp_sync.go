@@ -0,0 +1,180 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var (
+	WarpSyncProofRequestTimeout = time.Second * 30
+)
+
+// maxWarpProofRequestSize is the maximum size of a warp proof request,
+// which only contains the begin block hash.
+const maxWarpProofRequestSize = common.HashLength
+
+var _ Message = (*WarpProofRequestMessage)(nil)
+
+// WarpProofRequestMessage is sent to request a warp sync proof from a peer.
+type WarpProofRequestMessage struct {
+	// Begin is the hash of the finalised block to start the proof from,
+	// which is the genesis block hash or the block hash of the last
+	// fragment verified.
+	Begin common.Hash
+}
+
+// String formats a WarpProofRequestMessage as a string
+func (wr *WarpProofRequestMessage) String() string {
+	return fmt.Sprintf("WarpProofRequestMessage Begin=%s", wr.Begin)
+}
+
+// Encode returns the SCALE encoded WarpProofRequestMessage
+func (wr *WarpProofRequestMessage) Encode() ([]byte, error) {
+	return scale.Marshal(*wr)
+}
+
+// Decode decodes the SCALE encoded input to a WarpProofRequestMessage
+func (wr *WarpProofRequestMessage) Decode(in []byte) error {
+	return scale.Unmarshal(in, wr)
+}
+
+// WarpSyncFragment proves a GRANDPA authority set change with the header
+// of the last block of the authority set, which contains the authority set
+// change digest, and the justification of this block by the authority set.
+type WarpSyncFragment struct {
+	Header        types.Header
+	Justification types.GrandpaJustification
+}
+
+var _ ResponseMessage = (*WarpSyncProof)(nil)
+
+// WarpSyncProof is sent in response to a WarpProofRequestMessage and
+// contains the chain of authority set changes after the begin block.
+type WarpSyncProof struct {
+	Fragments []WarpSyncFragment
+	// IsFinished is true if the last fragment is the latest finalised
+	// block, and is false if more fragments can be requested starting
+	// from the last fragment block.
+	IsFinished bool
+}
+
+// String formats a WarpSyncProof as a string
+func (wp *WarpSyncProof) String() string {
+	if wp == nil {
+		return "WarpSyncProof=nil"
+	}
+
+	return fmt.Sprintf("WarpSyncProof Fragments=%d IsFinished=%t",
+		len(wp.Fragments), wp.IsFinished)
+}
+
+// Encode returns the SCALE encoded WarpSyncProof
+func (wp *WarpSyncProof) Encode() ([]byte, error) {
+	return scale.Marshal(*wp)
+}
+
+// Decode decodes the SCALE encoded input to a WarpSyncProof.
+// Each fragment header is decoded one at a time, since the
+// digest of each header has to be initialised before decoding.
+func (wp *WarpSyncProof) Decode(in []byte) (err error) {
+	reader := bytes.NewReader(in)
+	decoder := scale.NewDecoder(reader)
+
+	var length uint
+	err = decoder.Decode(&length)
+	if err != nil {
+		return fmt.Errorf("decoding number of fragments: %w", err)
+	}
+
+	// Each fragment is at least one byte long, which
+	// bounds the number of fragments to allocate.
+	if length > uint(reader.Len()) {
+		return fmt.Errorf("%w: %d fragments for %d bytes left",
+			ErrFailedToReadEntireMessage, length, reader.Len())
+	}
+
+	fragments := make([]WarpSyncFragment, length)
+	for i := range fragments {
+		header := types.NewEmptyHeader()
+		err = decoder.Decode(header)
+		if err != nil {
+			return fmt.Errorf("decoding header of fragment %d: %w", i, err)
+		}
+
+		justification, err := types.DecodeGrandpaJustification(reader)
+		if err != nil {
+			return fmt.Errorf("decoding justification of fragment %d: %w", i, err)
+		}
+
+		fragments[i] = WarpSyncFragment{
+			Header:        *header,
+			Justification: *justification,
+		}
+	}
+
+	var isFinished bool
+	err = decoder.Decode(&isFinished)
+	if err != nil {
+		return fmt.Errorf("decoding is finished: %w", err)
+	}
+
+	wp.Fragments = fragments
+	wp.IsFinished = isFinished
+	return nil
+}
+
+// handleWarpSyncStream handles streams with the <protocol-id>/sync/warp protocol ID
+func (s *Service) handleWarpSyncStream(stream libp2pnetwork.Stream) {
+	if stream == nil {
+		return
+	}
+
+	s.readStream(stream, decodeWarpProofRequest, s.handleWarpProofRequest, maxWarpProofRequestSize)
+}
+
+func decodeWarpProofRequest(in []byte, _ peer.ID, _ bool) (Message, error) {
+	msg := new(WarpProofRequestMessage)
+	err := msg.Decode(in)
+	return msg, err
+}
+
+// handleWarpProofRequest handles inbound warp sync streams, on which
+// the only messages received are WarpProofRequestMessages.
+func (s *Service) handleWarpProofRequest(stream libp2pnetwork.Stream, msg Message) error {
+	defer func() {
+		err := stream.Close()
+		if err != nil {
+			logger.Warnf("failed to close stream: %s", err)
+		}
+	}()
+
+	req, ok := msg.(*WarpProofRequestMessage)
+	if !ok {
+		return nil
+	}
+
+	proof, err := s.warpSyncProvider.GenerateWarpSyncProof(req.Begin)
+	if err != nil {
+		logger.Debugf("cannot create warp sync proof from block %s: %s", req.Begin, err)
+		return nil
+	}
+
+	err = s.host.writeToStream(stream, proof)
+	if err != nil {
+		logger.Debugf("failed to send WarpSyncProof message to peer %s: %s", stream.Conn().RemotePeer(), err)
+		return err
+	}
+
+	return nil
+}
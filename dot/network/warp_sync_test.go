@@ -0,0 +1,130 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package network
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WarpProofRequestMessage_Encode_Decode(t *testing.T) {
+	t.Parallel()
+
+	request := &WarpProofRequestMessage{Begin: common.Hash{1, 2}}
+
+	encoded, err := request.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, common.Hash{1, 2}.ToBytes(), encoded)
+
+	decoded := new(WarpProofRequestMessage)
+	err = decoded.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, request, decoded)
+}
+
+func Test_decodeWarpProofRequest(t *testing.T) {
+	t.Parallel()
+
+	request := &WarpProofRequestMessage{Begin: common.Hash{1, 2}}
+	encoded, err := request.Encode()
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(encoded), maxWarpProofRequestSize)
+
+	msg, err := decodeWarpProofRequest(encoded, peer.ID("peer"), true)
+	require.NoError(t, err)
+	assert.Equal(t, request, msg)
+}
+
+func Test_WarpSyncProof_Encode_Decode(t *testing.T) {
+	t.Parallel()
+
+	digest := types.NewDigest()
+	err := digest.Add(types.PreRuntimeDigest{
+		ConsensusEngineID: types.BabeEngineID,
+		Data:              []byte{1, 2},
+	})
+	require.NoError(t, err)
+
+	proof := &WarpSyncProof{
+		Fragments: []WarpSyncFragment{
+			{
+				Header: types.Header{
+					ParentHash: common.Hash{1},
+					Number:     2,
+					Digest:     digest,
+				},
+				Justification: types.GrandpaJustification{
+					Round: 3,
+					Commit: types.GrandpaCommit{
+						Hash:   common.Hash{4},
+						Number: 2,
+						Precommits: []types.GrandpaSignedVote{{
+							Vote:        types.GrandpaVote{Hash: common.Hash{5}, Number: 3},
+							Signature:   [64]byte{6},
+							AuthorityID: [32]byte{7},
+						}},
+					},
+					VotesAncestries: []types.Header{{
+						ParentHash: common.Hash{4},
+						Number:     3,
+						Digest:     digest,
+					}},
+				},
+			},
+			{
+				Header: types.Header{
+					ParentHash: common.Hash{8},
+					Number:     9,
+					Digest:     types.NewDigest(),
+				},
+				Justification: types.GrandpaJustification{
+					Round:  10,
+					Commit: types.GrandpaCommit{Hash: common.Hash{11}, Number: 9},
+				},
+			},
+		},
+		IsFinished: true,
+	}
+
+	encoded, err := proof.Encode()
+	require.NoError(t, err)
+
+	// the justifications are encoded inline as in Substrate,
+	// and not as length prefixed byte arrays.
+	expected := []byte{2 << 2}
+	for _, fragment := range proof.Fragments {
+		encodedHeader, err := scale.Marshal(fragment.Header)
+		require.NoError(t, err)
+		encodedJustification, err := scale.Marshal(fragment.Justification)
+		require.NoError(t, err)
+		expected = append(expected, encodedHeader...)
+		expected = append(expected, encodedJustification...)
+	}
+	expected = append(expected, 1)
+	assert.Equal(t, expected, encoded)
+
+	decoded := new(WarpSyncProof)
+	err = decoded.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, proof, decoded)
+}
+
+func Test_WarpSyncProof_Decode_tooManyFragments(t *testing.T) {
+	t.Parallel()
+
+	// 100 fragments, compact encoded, with a single byte left
+	encoded := []byte{0x91, 0x01, 0x00}
+
+	decoded := new(WarpSyncProof)
+	err := decoded.Decode(encoded)
+	assert.ErrorIs(t, err, ErrFailedToReadEntireMessage)
+	assert.EqualError(t, err, "failed to read entire message: "+
+		"100 fragments for 1 bytes left")
+}
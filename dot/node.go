@@ -10,6 +10,7 @@
 	"os/signal"
 	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -40,6 +41,13 @@
 
 var logger = log.NewFromGlobal(log.AddContext("pkg", "dot"))
 
+// servicesStopTimeout is the maximum duration to gracefully stop all
+// the node services, after which the node is force closed.
+const servicesStopTimeout = time.Minute
+
+// maxOffchainWorkers is the maximum number of offchain workers running at once.
+const maxOffchainWorkers = 4
+
 // Node is a container for all the components of a node.
 type Node struct {
 	Name            string
@@ -108,9 +116,11 @@ func (*nodeBuilder) isNodeInitialised(basepath string) error {
 		}
 	}()
 
-	_, err = state.NewBaseState(db).LoadGenesisData()
+	initialised, err := state.IsInitialised(db)
 	if err != nil {
-		return fmt.Errorf("cannot load genesis data in base state: %w", err)
+		return fmt.Errorf("cannot check state initialisation: %w", err)
+	} else if !initialised {
+		return errStateNotInitialised
 	}
 
 	return nil
@@ -142,7 +152,7 @@ func (*nodeBuilder) initNode(config *cfg.Config) error {
 
 	if !gen.IsRaw() {
 		// genesis is human-readable, convert to raw
-		err = gen.ToRaw()
+		gen, err = genesis.BuildRawFromHuman(gen, wasmer.NewGenesisBuilder)
 		if err != nil {
 			return fmt.Errorf("failed to convert genesis-spec to raw genesis: %w", err)
 		}
@@ -154,6 +164,12 @@ func (*nodeBuilder) initNode(config *cfg.Config) error {
 		return fmt.Errorf("failed to create trie from genesis: %w", err)
 	}
 
+	// overrides are applied before computing the genesis state root.
+	err = applyGenesisStorageOverrides(&t, config.GenesisStorageOverrides)
+	if err != nil {
+		return fmt.Errorf("applying genesis storage overrides: %w", err)
+	}
+
 	// create genesis block from trie
 	header, err := t.GenesisBlock()
 	if err != nil {
@@ -179,6 +195,7 @@ func (*nodeBuilder) initNode(config *cfg.Config) error {
 		},
 		Telemetry: telemetryMailer,
 		Metrics:   metrics.NewIntervalConfig(config.PrometheusExternal),
+		ForceInit: config.ForceInit,
 	}
 
 	// create new state service
@@ -229,7 +246,8 @@ func LoadGlobalNodeName(basepath string) (nodename string, err error) {
 // NewNode creates a node based on the given Config and key store.
 func NewNode(config *cfg.Config, ks *keystore.GlobalKeystore) (*Node, error) {
 	serviceRegistryLogger := logger.New(log.AddContext("pkg", "services"))
-	return newNode(config, ks, &nodeBuilder{}, services.NewServiceRegistry(serviceRegistryLogger))
+	serviceRegistry := services.NewServiceRegistry(serviceRegistryLogger, servicesStopTimeout)
+	return newNode(config, ks, &nodeBuilder{}, serviceRegistry)
 }
 
 func newNode(config *cfg.Config,
@@ -257,6 +275,12 @@ func newNode(config *cfg.Config,
 
 	logger.Patch(log.SetLevel(globalLogLevel))
 
+	loggingOptions, err := newLoggingOptions(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot configure logging: %w", err)
+	}
+	log.Patch(loggingOptions...)
+
 	logger.Infof(
 		"🕸️ initialising node services with global configuration name %s, id %s and base path %s...",
 		config.Name, config.ID, config.BasePath)
@@ -350,19 +374,16 @@ func newNode(config *cfg.Config,
 	if err != nil {
 		return nil, err
 	}
-	nodeSrvcs = append(nodeSrvcs, dh)
 
 	coreSrvc, err := builder.createCoreService(config, ks, stateSrvc, networkSrvc, dh)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create core service: %s", err)
 	}
-	nodeSrvcs = append(nodeSrvcs, coreSrvc)
 
 	fg, err := builder.createGRANDPAService(config, stateSrvc, ks.Gran, networkSrvc, telemetryMailer)
 	if err != nil {
 		return nil, err
 	}
-	nodeSrvcs = append(nodeSrvcs, fg)
 
 	syncer, err := builder.newSyncService(config, stateSrvc, fg, ver, coreSrvc, networkSrvc, telemetryMailer)
 	if err != nil {
@@ -372,6 +393,8 @@ func newNode(config *cfg.Config,
 	if networkSrvc != nil {
 		networkSrvc.SetSyncer(syncer)
 		networkSrvc.SetTransactionHandler(coreSrvc)
+		networkSrvc.SetWarpSyncProvider(fg)
+		networkSrvc.SetStateProvider(syncer)
 	}
 	nodeSrvcs = append(nodeSrvcs, syncer)
 
@@ -381,6 +404,16 @@ func newNode(config *cfg.Config,
 	}
 	nodeSrvcs = append(nodeSrvcs, bp)
 
+	// resubmit the equivocation reports until they are included on-chain
+	nodeSrvcs = append(nodeSrvcs, babe.NewEquivocationReporter(stateSrvc.Block, stateSrvc.Slot))
+
+	// offchain workers only run when the node is an authority, as in Substrate by default
+	if config.Core.Role == common.AuthorityRole {
+		offchainWorkers := runtime.NewOffchainWorkerScheduler(stateSrvc.Block, stateSrvc.Storage,
+			wasmer.NewOffchainWorkerInstance, maxOffchainWorkers)
+		nodeSrvcs = append(nodeSrvcs, offchainWorkers)
+	}
+
 	// check if rpc service is enabled
 	if enabled := config.RPC.IsRPCEnabled() || config.RPC.IsWSEnabled(); enabled {
 		var rpcSrvc *rpc.HTTPServer
@@ -404,7 +437,13 @@ func newNode(config *cfg.Config,
 		logger.Debug("rpc service disabled by default")
 	}
 
-	// close state service last
+	// Services are stopped in the order they are registered. The services
+	// producing block imports, the network first, are stopped before the
+	// services handling block imports, and the in progress block import
+	// completes when the sync service stops.
+	nodeSrvcs = append(nodeSrvcs, dh, coreSrvc, fg)
+
+	// close state service last, flushing the database
 	nodeSrvcs = append(nodeSrvcs, stateSrvc)
 
 	node := &Node{
@@ -482,17 +521,24 @@ func (n *Node) Start() error {
 	n.ServiceRegistry.StartAll()
 
 	n.wg.Add(1)
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	// stopped is closed once the node is stopped, such that the signal
+	// goroutine exits if the node is stopped without a signal.
+	stopped := make(chan struct{})
 	go func() {
-		sigc := make(chan os.Signal, 1)
-		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
 		defer signal.Stop(sigc)
-		<-sigc
-		logger.Info("signal interrupt, shutting down...")
-		n.Stop()
+		select {
+		case <-sigc:
+			logger.Info("signal interrupt, shutting down...")
+			n.Stop()
+		case <-stopped:
+		}
 	}()
 
 	close(n.started)
 	n.wg.Wait()
+	close(stopped)
 	return nil
 }
 
@@ -513,31 +559,136 @@ func (n *nodeBuilder) loadRuntime(config *cfg.Config, ns *runtime.NodeStorage,
 	stateSrvc *state.Service, ks *keystore.GlobalKeystore,
 	net *network.Service) error {
 	blocks := stateSrvc.Block.GetNonFinalisedBlocks()
-	runtimeCode := make(map[string]runtime.Instance)
-	for i := range blocks {
-		hash := &blocks[i]
-		code, err := stateSrvc.Storage.GetStorageByBlockHash(hash, []byte(":code"))
+	codeHashToRuntime := make(map[common.Hash]runtime.Instance)
+	for _, hash := range blocks {
+		header, err := stateSrvc.Block.GetHeader(hash)
 		if err != nil {
-			return err
+			return fmt.Errorf("getting header for block %s: %w", hash, err)
 		}
 
-		codeHash, err := common.Blake2bHash(code)
+		// the runtime code is read from the state of each block, such that
+		// blocks after a runtime upgrade use the upgraded runtime.
+		runtimeCode, err := stateSrvc.Storage.GetRuntimeCode(&header.StateRoot)
 		if err != nil {
-			return err
+			return fmt.Errorf("getting runtime code for block %s: %w", hash, err)
 		}
 
-		if rt, ok := runtimeCode[codeHash.String()]; ok {
-			stateSrvc.Block.StoreRuntime(*hash, rt)
+		if rt, ok := codeHashToRuntime[runtimeCode.CodeHash]; ok {
+			stateSrvc.Block.StoreRuntime(hash, rt)
 			continue
 		}
 
-		rt, err := createRuntime(config, *ns, stateSrvc, ks, net, code)
+		rt, err := createRuntime(config, *ns, stateSrvc, ks, net, header, runtimeCode)
 		if err != nil {
 			return err
 		}
 
-		runtimeCode[codeHash.String()] = rt
+		codeHashToRuntime[runtimeCode.CodeHash] = rt
+	}
+
+	err := checkBABEConfiguration(config.Core, stateSrvc)
+	if err != nil {
+		return fmt.Errorf("checking BABE configuration: %w", err)
+	}
+
+	return nil
+}
+
+// checkBABEConfiguration checks the epoch length and slot duration configured
+// and stored against the BABE configuration reported by the runtime of the best block.
+func checkBABEConfiguration(coreConfig *cfg.CoreConfig, stateSrvc *state.Service) error {
+	bestHeader, err := stateSrvc.Block.BestBlockHeader()
+	if err != nil {
+		return fmt.Errorf("getting best block header: %w", err)
+	}
+
+	rt, err := stateSrvc.Block.GetRuntime(bestHeader.Hash())
+	if err != nil {
+		return fmt.Errorf("getting runtime of best block: %w", err)
+	}
+
+	trieState, err := stateSrvc.Storage.TrieState(&bestHeader.StateRoot)
+	if err != nil {
+		return fmt.Errorf("getting trie state of best block: %w", err)
+	}
+
+	rt.SetContextStorage(trieState)
+	babeConfig, err := rt.BabeConfiguration()
+	if err != nil {
+		return fmt.Errorf("getting BABE configuration from runtime: %w", err)
+	}
+
+	err = validateBABEConfiguration(coreConfig, babeConfig)
+	if err != nil {
+		return err
 	}
 
+	return stateSrvc.Epoch.CheckBABEConfiguration(babeConfig)
+}
+
+// validateBABEConfiguration returns an error listing the differences between
+// the slot duration and epoch length configured and the ones of the runtime
+// BABE configuration given. Values configured to 0 are not checked.
+func validateBABEConfiguration(coreConfig *cfg.CoreConfig, babeConfig *types.BabeConfiguration) error {
+	var differences []string
+
+	runtimeSlotDuration := time.Duration(babeConfig.SlotDuration) * time.Millisecond
+	if coreConfig.SlotDuration != 0 && coreConfig.SlotDuration != runtimeSlotDuration {
+		differences = append(differences, fmt.Sprintf("slot duration is %s in configuration and %s in runtime",
+			coreConfig.SlotDuration, runtimeSlotDuration))
+	}
+
+	if coreConfig.EpochLength != 0 && uint64(coreConfig.EpochLength) != babeConfig.EpochLength {
+		differences = append(differences, fmt.Sprintf("epoch length is %d in configuration and %d in runtime",
+			coreConfig.EpochLength, babeConfig.EpochLength))
+	}
+
+	if len(differences) > 0 {
+		return fmt.Errorf("%w: %s", ErrBABEConfigurationMismatch, strings.Join(differences, ", "))
+	}
 	return nil
 }
+
+// newLoggingOptions returns the global logger options to set the log
+// format and the per module log levels from the configuration given.
+// Modules are matched against the "pkg" context of each logger.
+func newLoggingOptions(config *cfg.Config) (options []log.Option, err error) {
+	format := log.FormatConsole
+	if config.LogFormat != "" {
+		format, err = log.ParseFormat(config.LogFormat)
+		if err != nil {
+			return nil, fmt.Errorf("parsing log format: %w", err)
+		}
+	}
+
+	moduleToLevelString := map[string]string{
+		"core":           config.Log.Core,
+		"digest":         config.Log.Digest,
+		"sync":           config.Log.Sync,
+		"network":        config.Log.Network,
+		"rpc":            config.Log.RPC,
+		"state":          config.Log.State,
+		"runtime":        config.Log.Runtime,
+		"babe":           config.Log.Babe,
+		"grandpa":        config.Log.Grandpa,
+		"runtime/wasmer": config.Log.Wasmer,
+	}
+
+	moduleToLevel := make(map[string]log.Level, len(moduleToLevelString))
+	for module, levelString := range moduleToLevelString {
+		if levelString == "" {
+			continue
+		}
+
+		level, err := log.ParseLevel(levelString)
+		if err != nil {
+			return nil, fmt.Errorf("parsing log level for module %s: %w", module, err)
+		}
+		moduleToLevel[module] = level
+	}
+
+	return []log.Option{
+		log.SetFormat(format),
+		log.SetModuleLevels(moduleToLevel),
+	}, nil
+}
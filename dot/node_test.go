@@ -4,10 +4,12 @@
 package dot
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@
 
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/internal/metrics"
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -216,7 +219,7 @@ type fields struct {
 			name: "base_case",
 			fields: fields{
 				Name:     "Node",
-				Services: services.NewServiceRegistry(serviceRegistryLogger),
+				Services: services.NewServiceRegistry(serviceRegistryLogger, 0),
 				started:  make(chan struct{}),
 			},
 			err: nil,
@@ -244,3 +247,199 @@ type fields struct {
 		})
 	}
 }
+
+// testImportService imports a block on start, and waits
+// for the block import in progress to complete on stop.
+type testImportService struct {
+	importStarted   chan struct{}
+	releaseImport   chan struct{}
+	importCommitted chan struct{}
+}
+
+func (s *testImportService) Start() error {
+	go func() {
+		close(s.importStarted)
+		<-s.releaseImport
+		close(s.importCommitted)
+	}()
+	return nil
+}
+
+func (s *testImportService) Stop() error {
+	<-s.importCommitted
+	return nil
+}
+
+// testDatabaseService records if the block import
+// was committed when the database is closed.
+type testDatabaseService struct {
+	importCommitted           chan struct{}
+	importCommittedBeforeStop bool
+}
+
+func (*testDatabaseService) Start() error { return nil }
+
+func (s *testDatabaseService) Stop() error {
+	select {
+	case <-s.importCommitted:
+		s.importCommittedBeforeStop = true
+	default:
+	}
+	return nil
+}
+
+func TestNode_Start_shutdownSignalMidImport(t *testing.T) {
+	serviceRegistryLogger := logger.New(log.AddContext("pkg", "services"))
+	importService := &testImportService{
+		importStarted:   make(chan struct{}),
+		releaseImport:   make(chan struct{}),
+		importCommitted: make(chan struct{}),
+	}
+	databaseService := &testDatabaseService{
+		importCommitted: importService.importCommitted,
+	}
+	serviceRegistry := services.NewServiceRegistry(serviceRegistryLogger, time.Minute)
+	serviceRegistry.RegisterService(importService)
+	serviceRegistry.RegisterService(databaseService)
+
+	n := &Node{
+		Name:            "Node",
+		ServiceRegistry: serviceRegistry,
+		started:         make(chan struct{}),
+	}
+
+	startErr := make(chan error)
+	go func() {
+		startErr <- n.Start()
+	}()
+
+	<-n.started
+	<-importService.importStarted
+
+	err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	require.NoError(t, err)
+
+	// The node must not exit until the block import in progress completes.
+	select {
+	case err := <-startErr:
+		t.Fatalf("node exited before the block import completed: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(importService.releaseImport)
+	err = <-startErr
+	require.NoError(t, err)
+	assert.True(t, databaseService.importCommittedBeforeStop)
+}
+
+func Test_newLoggingOptions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid_format", func(t *testing.T) {
+		t.Parallel()
+
+		config := cfg.DefaultConfig()
+		config.LogFormat = "xml"
+
+		options, err := newLoggingOptions(config)
+
+		assert.ErrorIs(t, err, log.ErrFormatNotRecognised)
+		assert.EqualError(t, err, "parsing log format: format is not recognised: xml")
+		assert.Nil(t, options)
+	})
+
+	t.Run("invalid_module_level", func(t *testing.T) {
+		t.Parallel()
+
+		config := cfg.DefaultConfig()
+		config.Log.Sync = "loud"
+
+		options, err := newLoggingOptions(config)
+
+		assert.ErrorIs(t, err, log.ErrLevelNotRecognised)
+		assert.EqualError(t, err, "parsing log level for module sync: level is not recognised: loud")
+		assert.Nil(t, options)
+	})
+
+	t.Run("json_and_module_levels", func(t *testing.T) {
+		t.Parallel()
+
+		config := cfg.DefaultConfig()
+		config.LogFormat = "json"
+		config.Log.Network = "warn"
+		config.Log.Sync = "debug"
+
+		options, err := newLoggingOptions(config)
+		require.NoError(t, err)
+
+		buffer := bytes.NewBuffer(nil)
+		parent := log.New(log.SetWriter(buffer), log.SetLevel(log.Info))
+		networkLogger := parent.New(log.AddContext("pkg", "network"))
+		syncLogger := parent.New(log.AddContext("pkg", "sync"))
+		parent.Patch(options...)
+
+		networkLogger.Info("filtered")
+		syncLogger.Debug("passed")
+
+		assert.Regexp(t, `^{"time":"[^"]+","level":"DEBUG","message":"passed","pkg":"sync"}\n$`,
+			buffer.String())
+	})
+}
+
+func Test_validateBABEConfiguration(t *testing.T) {
+	t.Parallel()
+
+	babeConfig := &types.BabeConfiguration{
+		SlotDuration: 6000,
+		EpochLength:  600,
+	}
+
+	testCases := map[string]struct {
+		coreConfig *cfg.CoreConfig
+		errWrapped error
+		errMessage string
+	}{
+		"values_omitted": {
+			coreConfig: &cfg.CoreConfig{},
+		},
+		"values_matching": {
+			coreConfig: &cfg.CoreConfig{
+				SlotDuration: 6 * time.Second,
+				EpochLength:  600,
+			},
+		},
+		"slot_duration_mismatch": {
+			coreConfig: &cfg.CoreConfig{
+				SlotDuration: 2 * time.Second,
+				EpochLength:  600,
+			},
+			errWrapped: ErrBABEConfigurationMismatch,
+			errMessage: "BABE configuration does not match runtime: " +
+				"slot duration is 2s in configuration and 6s in runtime",
+		},
+		"both_mismatch": {
+			coreConfig: &cfg.CoreConfig{
+				SlotDuration: 2 * time.Second,
+				EpochLength:  200,
+			},
+			errWrapped: ErrBABEConfigurationMismatch,
+			errMessage: "BABE configuration does not match runtime: " +
+				"slot duration is 2s in configuration and 6s in runtime, " +
+				"epoch length is 200 in configuration and 600 in runtime",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateBABEConfiguration(testCase.coreConfig, babeConfig)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
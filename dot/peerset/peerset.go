@@ -339,7 +339,7 @@ func (ps *PeerSet) reportPeer(change ReputationChange, peers ...peer.ID) error {
 		}
 
 		if rep >= BannedThresholdValue {
-			return nil
+			continue
 		}
 
 		setLen := ps.peerState.getSetLength()
@@ -437,9 +437,72 @@ func (ps *PeerSet) allocSlots(setIdx int) error {
 
 		logger.Debugf("Sent connect message to peer %s", peerID)
 	}
+
+	// once the outgoing slots are full, the connected peers are replaced
+	// by the not connected peers having a higher reputation.
+	for !peerState.hasFreeOutgoingSlot(setIdx) {
+		peerID := peerState.highestNotConnectedPeer(setIdx)
+		if peerID == "" {
+			break
+		}
+
+		n, err := peerState.getNode(peerID)
+		if err != nil {
+			return fmt.Errorf("cannot get node: %w", err)
+		}
+
+		if n.reputation < BannedThresholdValue {
+			break
+		}
+
+		evicted, err := ps.evictLowestReputationPeer(setIdx, outgoing, n.reputation)
+		if err != nil {
+			return fmt.Errorf("cannot evict outgoing peer: %w", err)
+		} else if !evicted {
+			break
+		}
+
+		if err = peerState.tryOutgoing(setIdx, peerID); err != nil {
+			logger.Errorf("could not set peer %s as outgoing connection: %s", peerID.Pretty(), err)
+			break
+		}
+
+		ps.resultMsgCh <- Message{
+			Status: Connect,
+			setID:  uint64(setIdx),
+			PeerID: peerID,
+		}
+	}
 	return nil
 }
 
+// evictLowestReputationPeer disconnects the slot occupying peer with the lowest
+// reputation amongst the peers connected with the membership state given, if its
+// reputation is lower than the reputation given. Reserved peers do not occupy
+// slots, so they are never evicted. It returns true if a peer is evicted.
+func (ps *PeerSet) evictLowestReputationPeer(setIdx int, state MembershipState,
+	reputation Reputation) (evicted bool, err error) {
+	peerID, lowestReputation := ps.peerState.lowestReputationSlotPeer(setIdx, state)
+	if peerID == "" || lowestReputation >= reputation {
+		return false, nil
+	}
+
+	err = ps.peerState.disconnect(setIdx, peerID)
+	if err != nil {
+		return false, fmt.Errorf("cannot disconnect: %w", err)
+	}
+
+	ps.resultMsgCh <- Message{
+		Status: Drop,
+		setID:  uint64(setIdx),
+		PeerID: peerID,
+	}
+
+	logger.Debugf("evicted peer %s with reputation %d to free a slot for a peer with reputation %d",
+		peerID, lowestReputation, reputation)
+	return true, nil
+}
+
 func (ps *PeerSet) addReservedPeers(setID int, peers ...peer.ID) error {
 	ps.reservedLock.Lock()
 	defer ps.reservedLock.Unlock()
@@ -635,6 +698,15 @@ func (ps *PeerSet) incoming(setID int, peers ...peer.ID) error {
 			message.Status = Reject
 		} else {
 			err := state.tryAcceptIncoming(setID, pid)
+			if errors.Is(err, ErrIncomingSlotsUnavailable) {
+				evicted, evictErr := ps.evictLowestReputationPeer(setID, ingoing, nodeReputation)
+				if evictErr != nil {
+					return fmt.Errorf("cannot evict incoming peer: %w", evictErr)
+				} else if evicted {
+					err = state.tryAcceptIncoming(setID, pid)
+				}
+			}
+
 			if err != nil {
 				if errors.Is(err, ErrIncomingSlotsUnavailable) {
 					logger.Debugf("cannot accept incoming peer %s: %s", pid, err)
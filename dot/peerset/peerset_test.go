@@ -261,6 +261,33 @@ func TestReAllocAfterBanned(t *testing.T) {
 	checkMessageStatus(t, <-ps.resultMsgCh, Connect)
 }
 
+func TestReportPeers(t *testing.T) {
+	const testSetID = 0
+
+	t.Parallel()
+	handler := newTestPeerSet(t, 0, 2, []peer.ID{discovered1, discovered2}, nil, false)
+
+	ps := handler.peerSet
+	require.Len(t, ps.resultMsgCh, 2)
+	for len(ps.resultMsgCh) != 0 {
+		checkMessageStatus(t, <-ps.resultMsgCh, Connect)
+	}
+
+	ps.peerState.Lock()
+	ps.peerState.nodes[discovered1].reputation = BannedThresholdValue
+	ps.peerState.Unlock()
+
+	// the first peer reported stays above the banned threshold,
+	// and the second peer reported is still dropped.
+	handler.ReportPeer(newReputationChange(BannedThresholdValue/10, ""), discovered2, discovered1)
+	time.Sleep(100 * time.Millisecond)
+
+	require.Equal(t, Message{Status: Drop, setID: testSetID, PeerID: discovered1}, <-ps.resultMsgCh)
+	require.Len(t, ps.resultMsgCh, 0)
+	checkNodePeerMembershipState(t, ps.peerState, discovered1, testSetID, notConnected)
+	checkNodePeerMembershipState(t, ps.peerState, discovered2, testSetID, outgoing)
+}
+
 func TestRemovePeer(t *testing.T) {
 	const testSetID = 0
 
@@ -314,6 +341,85 @@ func TestSetReservePeer(t *testing.T) {
 	}
 }
 
+func TestIncomingEviction(t *testing.T) {
+	const testSetID = 0
+
+	t.Parallel()
+	handler := newTestPeerSet(t, 1, 0, nil, []peer.ID{reservedPeer}, false)
+
+	ps := handler.peerSet
+	// the reserved peer cannot be connected since there is no outgoing slot
+	// so it connects with an incoming connection.
+	checkMessageStatus(t, <-ps.resultMsgCh, Connect)
+	err := ps.peerState.disconnect(testSetID, reservedPeer)
+	require.NoError(t, err)
+	handler.Incoming(testSetID, reservedPeer)
+	checkMessageStatus(t, <-ps.resultMsgCh, Accept)
+
+	handler.Incoming(testSetID, incomingPeer)
+	checkMessageStatus(t, <-ps.resultMsgCh, Accept)
+	checkPeerStateSetNumIn(t, ps.peerState, testSetID, 1)
+
+	// the reserved peer has the lowest reputation but does not occupy a slot,
+	// so only the incoming peer is evicted for a peer with a higher reputation.
+	handler.ReportPeer(newReputationChange(BadMessageValue, BadMessageReason), reservedPeer)
+	handler.ReportPeer(newReputationChange(DuplicateGossipValue*10, DuplicateGossipReason), incomingPeer)
+
+	handler.Incoming(testSetID, incoming2)
+	require.Equal(t, Message{Status: Drop, setID: testSetID, PeerID: incomingPeer}, <-ps.resultMsgCh)
+	checkMessageStatus(t, <-ps.resultMsgCh, Accept)
+
+	checkNodePeerMembershipState(t, ps.peerState, reservedPeer, testSetID, ingoing)
+	checkNodePeerMembershipState(t, ps.peerState, incomingPeer, testSetID, notConnected)
+	checkNodePeerMembershipState(t, ps.peerState, incoming2, testSetID, ingoing)
+	checkPeerStateSetNumIn(t, ps.peerState, testSetID, 1)
+
+	// a peer with the same reputation as the connected peer is rejected.
+	handler.Incoming(testSetID, incoming3)
+	checkMessageStatus(t, <-ps.resultMsgCh, Reject)
+	checkNodePeerMembershipState(t, ps.peerState, reservedPeer, testSetID, ingoing)
+	checkNodePeerMembershipState(t, ps.peerState, incoming2, testSetID, ingoing)
+	checkPeerStateSetNumIn(t, ps.peerState, testSetID, 1)
+}
+
+func TestOutgoingEviction(t *testing.T) {
+	const testSetID = 0
+
+	t.Parallel()
+	handler := newTestPeerSet(t, 0, 1, []peer.ID{bootNode}, []peer.ID{reservedPeer}, false)
+
+	ps := handler.peerSet
+	require.Len(t, ps.resultMsgCh, 2)
+	for len(ps.resultMsgCh) != 0 {
+		checkMessageStatus(t, <-ps.resultMsgCh, Connect)
+	}
+	checkPeerStateSetNumOut(t, ps.peerState, testSetID, 1)
+
+	// the outgoing slot is full, so a discovered peer with
+	// the same reputation as the boot node is not connected.
+	handler.AddPeer(testSetID, discovered1)
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, ps.resultMsgCh, 0)
+	checkNodePeerMembershipState(t, ps.peerState, discovered1, testSetID, notConnected)
+
+	handler.ReportPeer(newReputationChange(BadMessageValue, BadMessageReason), reservedPeer)
+	handler.ReportPeer(newReputationChange(BadMessageValue, BadMessageReason), bootNode)
+	time.Sleep(100 * time.Millisecond)
+	handler.AddPeer(testSetID, discovered2)
+
+	require.Equal(t, Message{Status: Drop, setID: testSetID, PeerID: bootNode}, <-ps.resultMsgCh)
+	msg := <-ps.resultMsgCh
+	require.Equal(t, Connect, msg.Status)
+	require.Contains(t, []peer.ID{discovered1, discovered2}, msg.PeerID)
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, ps.resultMsgCh, 0)
+
+	checkNodePeerMembershipState(t, ps.peerState, reservedPeer, testSetID, outgoing)
+	checkNodePeerMembershipState(t, ps.peerState, bootNode, testSetID, notConnected)
+	checkNodePeerMembershipState(t, ps.peerState, msg.PeerID, testSetID, outgoing)
+	checkPeerStateSetNumOut(t, ps.peerState, testSetID, 1)
+}
+
 func getNodePeer(ps *PeersState, pid peer.ID) (node, bool) {
 	ps.RLock()
 	defer ps.RUnlock()
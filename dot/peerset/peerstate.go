@@ -270,6 +270,33 @@ func (ps *PeersState) highestNotConnectedPeer(set int) (highestPeerID peer.ID) {
 	return highestPeerID
 }
 
+// lowestReputationSlotPeer returns the peer with the lowest reputation amongst
+// the slot occupying peers connected with the membership state given, or an
+// empty peer ID if there is no such peer.
+func (ps *PeersState) lowestReputationSlotPeer(set int, state MembershipState) (
+	lowestPeerID peer.ID, lowestReputation Reputation) {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	lowestReputation = math.MaxInt32
+	for peerID, node := range ps.nodes {
+		if node.state[set] != state {
+			continue
+		}
+
+		if _, isNoSlotNode := ps.sets[set].noSlotNodes[peerID]; isNoSlotNode {
+			continue
+		}
+
+		if lowestPeerID == "" || node.reputation < lowestReputation {
+			lowestReputation = node.reputation
+			lowestPeerID = peerID
+		}
+	}
+
+	return lowestPeerID, lowestReputation
+}
+
 func (ps *PeersState) hasFreeOutgoingSlot(set int) bool {
 	return ps.sets[set].numOut < ps.sets[set].maxOut
 }
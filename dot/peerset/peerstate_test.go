@@ -197,3 +197,38 @@ func TestHighestNotConnectedPeer(t *testing.T) {
 
 	require.Equal(t, peer1, state.highestNotConnectedPeer(0))
 }
+
+func TestLowestReputationSlotPeer(t *testing.T) {
+	t.Parallel()
+
+	state := newTestPeerState(t, 25, 25)
+
+	peerID, _ := state.lowestReputationSlotPeer(0, ingoing)
+	require.Equal(t, peer.ID(""), peerID)
+
+	for _, peerID := range []peer.ID{peer1, peer2, reservedPeer} {
+		state.discover(0, peerID)
+		err := state.tryAcceptIncoming(0, peerID)
+		require.NoError(t, err)
+	}
+	state.discover(0, discovered1)
+	err := state.tryOutgoing(0, discovered1)
+	require.NoError(t, err)
+	err = state.addNoSlotNode(0, reservedPeer)
+	require.NoError(t, err)
+
+	state.nodes[peer1].reputation = 10
+	state.nodes[peer2].reputation = -10
+	state.nodes[reservedPeer].reputation = -100
+	state.nodes[discovered1].reputation = -1000
+
+	// the reserved peer does not occupy a slot and
+	// the discovered peer is an outgoing peer.
+	peerID, reputation := state.lowestReputationSlotPeer(0, ingoing)
+	require.Equal(t, peer2, peerID)
+	require.Equal(t, Reputation(-10), reputation)
+
+	peerID, reputation = state.lowestReputationSlotPeer(0, outgoing)
+	require.Equal(t, discovered1, peerID)
+	require.Equal(t, Reputation(-1000), reputation)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ChainSafe/gossamer/internal/log"
+)
+
+// maxBlocksBehindReady is the maximum number of blocks the best block
+// can be behind the highest known block for the node to be ready.
+const maxBlocksBehindReady = 5
+
+// HealthResponse is the response of the health endpoint.
+type HealthResponse struct {
+	IsSyncing       bool `json:"isSyncing"`
+	BestNumber      uint `json:"bestNumber"`
+	FinalisedNumber uint `json:"finalizedNumber"`
+	Peers           int  `json:"peers"`
+	Ready           bool `json:"ready"`
+}
+
+// healthHandler serves the health of the node for liveness and readiness
+// probes. It only reads the network, sync and block states and never calls
+// the runtime, so it is cheap to query frequently.
+type healthHandler struct {
+	logger     *log.Logger
+	blockAPI   BlockAPI
+	networkAPI NetworkAPI
+	syncAPI    SyncAPI
+}
+
+func newHealthHandler(logger *log.Logger, blockAPI BlockAPI, networkAPI NetworkAPI,
+	syncAPI SyncAPI) *healthHandler {
+	return &healthHandler{
+		logger:     logger,
+		blockAPI:   blockAPI,
+		networkAPI: networkAPI,
+		syncAPI:    syncAPI,
+	}
+}
+
+// ServeHTTP writes the health of the node as JSON, with the status code
+// 200 if the node is ready and 503 otherwise.
+func (h *healthHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	response, err := h.health()
+	if err != nil {
+		h.logger.Warnf("failed to get node health: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if !response.Ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		h.logger.Debugf("failed to write health response: %s", err)
+	}
+}
+
+func (h *healthHandler) health() (response HealthResponse, err error) {
+	networkHealth := h.networkAPI.Health()
+	response.IsSyncing = networkHealth.IsSyncing
+	response.Peers = networkHealth.Peers
+
+	bestHeader, err := h.blockAPI.GetHeader(h.blockAPI.BestBlockHash())
+	if err != nil {
+		return response, fmt.Errorf("getting best block header: %w", err)
+	}
+	response.BestNumber = bestHeader.Number
+
+	finalisedHash, err := h.blockAPI.GetHighestFinalisedHash()
+	if err != nil {
+		return response, fmt.Errorf("getting highest finalised hash: %w", err)
+	}
+
+	finalisedHeader, err := h.blockAPI.GetHeader(finalisedHash)
+	if err != nil {
+		return response, fmt.Errorf("getting highest finalised header: %w", err)
+	}
+	response.FinalisedNumber = finalisedHeader.Number
+
+	hasPeers := networkHealth.Peers > 0 || !networkHealth.ShouldHavePeers
+	atTip := response.BestNumber+maxBlocksBehindReady >= h.syncAPI.HighestBlock()
+	response.Ready = hasPeers && !networkHealth.IsSyncing && atTip
+
+	return response, nil
+}
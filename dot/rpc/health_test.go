@@ -0,0 +1,124 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/rpc/modules/mocks"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_healthHandler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	blockAPI := mocks.NewMockBlockAPI(ctrl)
+	networkAPI := mocks.NewMockNetworkAPI(ctrl)
+	syncAPI := NewMockSyncAPI(ctrl)
+	handler := newHealthHandler(log.New(log.SetWriter(io.Discard)), blockAPI, networkAPI, syncAPI)
+
+	bestHash, finalisedHash := common.Hash{1}, common.Hash{2}
+	expectBlocks := func(bestNumber, finalisedNumber uint) {
+		blockAPI.EXPECT().BestBlockHash().Return(bestHash)
+		blockAPI.EXPECT().GetHeader(bestHash).Return(&types.Header{Number: bestNumber}, nil)
+		blockAPI.EXPECT().GetHighestFinalisedHash().Return(finalisedHash, nil)
+		blockAPI.EXPECT().GetHeader(finalisedHash).Return(&types.Header{Number: finalisedNumber}, nil)
+	}
+
+	steps := []struct {
+		name               string
+		health             common.Health
+		bestNumber         uint
+		finalisedNumber    uint
+		highestBlock       uint
+		expectedStatusCode int
+		expectedResponse   HealthResponse
+	}{
+		{
+			name:               "syncing without peers",
+			health:             common.Health{IsSyncing: true, ShouldHavePeers: true},
+			highestBlock:       0,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   HealthResponse{IsSyncing: true},
+		},
+		{
+			name:               "syncing far behind the tip",
+			health:             common.Health{Peers: 3, IsSyncing: true, ShouldHavePeers: true},
+			bestNumber:         100,
+			finalisedNumber:    98,
+			highestBlock:       1000,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse: HealthResponse{
+				IsSyncing:       true,
+				BestNumber:      100,
+				FinalisedNumber: 98,
+				Peers:           3,
+			},
+		},
+		{
+			name:               "near the tip",
+			health:             common.Health{Peers: 3, ShouldHavePeers: true},
+			bestNumber:         998,
+			finalisedNumber:    996,
+			highestBlock:       1000,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: HealthResponse{
+				BestNumber:      998,
+				FinalisedNumber: 996,
+				Peers:           3,
+				Ready:           true,
+			},
+		},
+		{
+			name:               "peers lost",
+			health:             common.Health{ShouldHavePeers: true},
+			bestNumber:         1000,
+			finalisedNumber:    998,
+			highestBlock:       1000,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse: HealthResponse{
+				BestNumber:      1000,
+				FinalisedNumber: 998,
+			},
+		},
+		{
+			name:               "no peers expected",
+			health:             common.Health{},
+			bestNumber:         1000,
+			finalisedNumber:    998,
+			highestBlock:       1000,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: HealthResponse{
+				BestNumber:      1000,
+				FinalisedNumber: 998,
+				Ready:           true,
+			},
+		},
+	}
+
+	for _, step := range steps {
+		networkAPI.EXPECT().Health().Return(step.health)
+		expectBlocks(step.bestNumber, step.finalisedNumber)
+		syncAPI.EXPECT().HighestBlock().Return(step.highestBlock)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.Equal(t, step.expectedStatusCode, recorder.Code, step.name)
+		var response HealthResponse
+		err := json.NewDecoder(recorder.Body).Decode(&response)
+		require.NoError(t, err, step.name)
+		assert.Equal(t, step.expectedResponse, response, step.name)
+	}
+}
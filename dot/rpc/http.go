@@ -143,6 +143,8 @@ func (h *HTTPServer) Start() error {
 	h.logger.Infof("Starting HTTP Server on host %s and port %d...", h.serverConfig.Host, h.serverConfig.RPCPort)
 	r := mux.NewRouter()
 	r.Handle("/", h.rpcServer)
+	r.Handle("/health", newHealthHandler(h.logger, h.serverConfig.BlockAPI,
+		h.serverConfig.NetworkAPI, h.serverConfig.SyncAPI))
 
 	validate := validator.New()
 	// Add custom validator for `common.Hash`
@@ -27,6 +27,7 @@ type StorageAPI interface {
 	Entries(root *common.Hash) (map[string][]byte, error)
 	GetStateRootFromBlock(bhash *common.Hash) (*common.Hash, error)
 	GetKeysWithPrefix(root *common.Hash, prefix []byte) ([][]byte, error)
+	GetKeysPaged(root *common.Hash, prefix, startAfter []byte, limit uint) ([][]byte, error)
 	RegisterStorageObserver(observer state.Observer)
 	UnregisterStorageObserver(observer state.Observer)
 }
@@ -37,6 +38,7 @@ type BlockAPI interface {
 	BestBlockHash() common.Hash
 	GetBlockByHash(hash common.Hash) (*types.Block, error)
 	GetHashByNumber(blockNumber uint) (common.Hash, error)
+	GetNonCanonicalHashesByNumber(blockNumber uint) ([]common.Hash, error)
 	GetFinalisedHash(uint64, uint64) (common.Hash, error)
 	GetHighestFinalisedHash() (common.Hash, error)
 	HasJustification(hash common.Hash) (bool, error)
@@ -76,6 +78,8 @@ type BlockProducerAPI interface {
 type TransactionStateAPI interface {
 	AddToPool(*transaction.ValidTransaction) common.Hash
 	Pending() []*transaction.ValidTransaction
+	PendingInQueue() []*transaction.ValidTransaction
+	RemoveExtrinsics(hashes []common.Hash) (removed []common.Hash)
 	GetStatusNotifierChannel(ext types.Extrinsic) chan transaction.Status
 	FreeStatusNotifierChannel(ch chan transaction.Status)
 }
@@ -87,6 +91,8 @@ type CoreAPI interface {
 	GetRuntimeVersion(bhash *common.Hash) (runtime.Version, error)
 	HandleSubmittedExtrinsic(types.Extrinsic) error
 	GetMetadata(bhash *common.Hash) ([]byte, error)
+	CallRuntime(bhash *common.Hash, function string, data []byte) ([]byte, error)
+	DryRunExtrinsic(bhash *common.Hash, ext types.Extrinsic) (result, events []byte, err error)
 	DecodeSessionKeys(enc []byte) ([]byte, error)
 	GetReadProofAt(block common.Hash, keys [][]byte) (common.Hash, [][]byte, error)
 }
@@ -6,3 +6,4 @@
 //go:generate mockgen -destination=mocks_test.go -package=$GOPACKAGE . API,TransactionStateAPI
 //go:generate mockgen -destination=mock_telemetry_test.go -package $GOPACKAGE . Telemetry
 //go:generate mockgen -destination=mock_network_test.go -package $GOPACKAGE github.com/ChainSafe/gossamer/dot/core Network
+//go:generate mockgen -destination=mock_sync_api_test.go -package $GOPACKAGE . SyncAPI
@@ -138,3 +138,31 @@ func (mr *MockTransactionStateAPIMockRecorder) Pending() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockTransactionStateAPI)(nil).Pending))
 }
+
+// PendingInQueue mocks base method.
+func (m *MockTransactionStateAPI) PendingInQueue() []*transaction.ValidTransaction {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingInQueue")
+	ret0, _ := ret[0].([]*transaction.ValidTransaction)
+	return ret0
+}
+
+// PendingInQueue indicates an expected call of PendingInQueue.
+func (mr *MockTransactionStateAPIMockRecorder) PendingInQueue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingInQueue", reflect.TypeOf((*MockTransactionStateAPI)(nil).PendingInQueue))
+}
+
+// RemoveExtrinsics mocks base method.
+func (m *MockTransactionStateAPI) RemoveExtrinsics(arg0 []common.Hash) []common.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExtrinsics", arg0)
+	ret0, _ := ret[0].([]common.Hash)
+	return ret0
+}
+
+// RemoveExtrinsics indicates an expected call of RemoveExtrinsics.
+func (mr *MockTransactionStateAPIMockRecorder) RemoveExtrinsics(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExtrinsics", reflect.TypeOf((*MockTransactionStateAPI)(nil).RemoveExtrinsics), arg0)
+}
@@ -5,6 +5,7 @@
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -41,10 +42,11 @@ type Extrinsic struct {
 	Data string
 }
 
-// ExtrinsicOrHash is a type for Hash and Extrinsic array of bytes
+// ExtrinsicOrHash identifies an extrinsic either by its hash, or by the
+// extrinsic itself hex encoded, in which case the hash is ignored.
 type ExtrinsicOrHash struct {
-	Hash      common.Hash
-	Extrinsic []byte
+	Hash      common.Hash `json:"hash"`
+	Extrinsic string      `json:"extrinsic"`
 }
 
 // ExtrinsicOrHashRequest is a array of ExtrinsicOrHash
@@ -179,9 +181,9 @@ func (am *AuthorModule) HasKey(r *http.Request, req *[]string, res *bool) error
 	return err
 }
 
-// PendingExtrinsics Returns all pending extrinsics
+// PendingExtrinsics Returns all pending extrinsics ready to be included in a block
 func (am *AuthorModule) PendingExtrinsics(r *http.Request, req *EmptyRequest, res *PendingExtrinsicsResponse) error {
-	pending := am.txStateAPI.Pending()
+	pending := am.txStateAPI.PendingInQueue()
 	resp := make([]string, len(pending))
 	for idx, tx := range pending {
 		resp[idx] = common.BytesToHex(tx.Extrinsic)
@@ -191,8 +193,26 @@ func (am *AuthorModule) PendingExtrinsics(r *http.Request, req *EmptyRequest, re
 	return nil
 }
 
-// RemoveExtrinsic Remove given extrinsic from the pool and temporarily ban it to prevent reimporting
-func (am *AuthorModule) RemoveExtrinsic(r *http.Request, _ *ExtrinsicOrHashRequest, _ *RemoveExtrinsicsResponse) error {
+// RemoveExtrinsic removes the given extrinsics from the pool, as well as the extrinsics
+// depending on them, and returns the hashes of the extrinsics actually removed.
+func (am *AuthorModule) RemoveExtrinsic(r *http.Request, req *ExtrinsicOrHashRequest,
+	res *RemoveExtrinsicsResponse) error {
+	hashes := make([]common.Hash, len(*req))
+	for i, extrinsicOrHash := range *req {
+		if extrinsicOrHash.Extrinsic == "" {
+			hashes[i] = extrinsicOrHash.Hash
+			continue
+		}
+
+		extBytes, err := common.HexToBytes(extrinsicOrHash.Extrinsic)
+		if err != nil {
+			return fmt.Errorf("decoding extrinsic at index %d: %w", i, err)
+		}
+		hashes[i] = types.Extrinsic(extBytes).Hash()
+	}
+
+	removed := am.txStateAPI.RemoveExtrinsics(hashes)
+	*res = append(RemoveExtrinsicsResponse{}, removed...)
 	return nil
 }
 
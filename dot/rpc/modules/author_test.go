@@ -232,10 +232,10 @@ func TestAuthorModule_PendingExtrinsics(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	emptyMockTransactionStateAPI := mocks.NewMockTransactionStateAPI(ctrl)
-	emptyMockTransactionStateAPI.EXPECT().Pending().Return([]*transaction.ValidTransaction{})
+	emptyMockTransactionStateAPI.EXPECT().PendingInQueue().Return([]*transaction.ValidTransaction{})
 
 	mockTransactionStateAPI := mocks.NewMockTransactionStateAPI(ctrl)
-	mockTransactionStateAPI.EXPECT().Pending().Return([]*transaction.ValidTransaction{
+	mockTransactionStateAPI.EXPECT().PendingInQueue().Return([]*transaction.ValidTransaction{
 		{
 			Extrinsic: types.NewExtrinsic([]byte("someExtrinsic")),
 		},
@@ -299,6 +299,73 @@ type args struct {
 	}
 }
 
+func TestAuthorModule_RemoveExtrinsic(t *testing.T) {
+	t.Parallel()
+
+	extrinsic := types.NewExtrinsic([]byte("someExtrinsic"))
+	hash := common.Hash{1}
+
+	testCases := map[string]struct {
+		req               ExtrinsicOrHashRequest
+		txStateAPIBuilder func(ctrl *gomock.Controller) TransactionStateAPI
+		expectedRes       RemoveExtrinsicsResponse
+		errWrapped        error
+		errMessage        string
+	}{
+		"invalid_extrinsic_hex": {
+			req: ExtrinsicOrHashRequest{{Extrinsic: "zz"}},
+			txStateAPIBuilder: func(ctrl *gomock.Controller) TransactionStateAPI {
+				return mocks.NewMockTransactionStateAPI(ctrl)
+			},
+			errWrapped: common.ErrNoPrefix,
+			errMessage: "decoding extrinsic at index 0: could not byteify non 0x prefixed string: zz",
+		},
+		"nothing_removed": {
+			req: ExtrinsicOrHashRequest{{Hash: hash}},
+			txStateAPIBuilder: func(ctrl *gomock.Controller) TransactionStateAPI {
+				txStateAPI := mocks.NewMockTransactionStateAPI(ctrl)
+				txStateAPI.EXPECT().RemoveExtrinsics([]common.Hash{hash}).Return(nil)
+				return txStateAPI
+			},
+			expectedRes: RemoveExtrinsicsResponse{},
+		},
+		"hash_and_extrinsic": {
+			req: ExtrinsicOrHashRequest{
+				{Hash: hash},
+				{Extrinsic: common.BytesToHex(extrinsic)},
+			},
+			txStateAPIBuilder: func(ctrl *gomock.Controller) TransactionStateAPI {
+				txStateAPI := mocks.NewMockTransactionStateAPI(ctrl)
+				txStateAPI.EXPECT().RemoveExtrinsics([]common.Hash{hash, extrinsic.Hash()}).
+					Return([]common.Hash{extrinsic.Hash(), {2}})
+				return txStateAPI
+			},
+			expectedRes: RemoveExtrinsicsResponse{extrinsic.Hash(), {2}},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			authorModule := &AuthorModule{
+				txStateAPI: testCase.txStateAPIBuilder(ctrl),
+			}
+
+			var res RemoveExtrinsicsResponse
+			err := authorModule.RemoveExtrinsic(nil, &testCase.req, &res)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.expectedRes, res)
+		})
+	}
+}
+
 func TestAuthorModule_InsertKey(t *testing.T) {
 	kp1, err := sr25519.NewKeypairFromSeed(
 		common.MustHexToBytes("0x6246ddf254e0b4b4e7dffefc8adf69d212b98ac2b579c362b473fec8c40b4c0a"))
@@ -4,11 +4,13 @@
 package modules
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
 
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/pkg/scale"
@@ -19,9 +21,24 @@ type ChainHashRequest struct {
 	Bhash *common.Hash
 }
 
-// ChainBlockNumberRequest interface can accept string, float64 or []
+// maxBlockHashNumbers is the maximum number of block numbers in a
+// block number array or range of a chain_getBlockHash request.
+const maxBlockHashNumbers = 1024
+
+var (
+	errBlockNumberRange     = errors.New("invalid block number range")
+	errBlockNumberRangeSize = errors.New("block number range is too large")
+	errBlockNumberArraySize = errors.New("block number array is too large")
+)
+
+// ChainBlockNumberRequest interface can accept string, float64, [] or
+// a block number range object {"from": <number>, "to": <number>},
+// where both the from and to block numbers are included.
+// IncludeForks can be set to true to also get the hashes of the
+// blocks not on the canonical chain for each block number.
 type ChainBlockNumberRequest struct {
-	Block interface{}
+	Block        interface{}
+	IncludeForks bool
 }
 
 // ChainFinalizedHeadRequest ...
@@ -97,7 +114,11 @@ func (cm *ChainModule) GetBlock(r *http.Request, req *ChainHashRequest, res *Cha
 }
 
 // GetBlockHash Get hash of the 'n-th' block in the canon chain. If no parameters are provided,
-// the latest block hash gets returned.
+// the latest block hash gets returned. An array of hashes is returned for an array or a range
+// of block numbers, and the hash is null for block numbers above the best block number.
+// An array or a range contains at most maxBlockHashNumbers block numbers.
+// If forks are included, the hashes of each block number are returned with the canonical
+// hash first, followed by the hashes of the forks.
 func (cm *ChainModule) GetBlockHash(r *http.Request, req *ChainBlockNumberRequest, res *ChainHashResponse) error {
 	// if request is empty, return highest hash
 	if req.Block == nil {
@@ -105,15 +126,36 @@ func (cm *ChainModule) GetBlockHash(r *http.Request, req *ChainBlockNumberReques
 		return nil
 	}
 
-	val, err := cm.unwindRequest(req.Block)
-	// if result only returns 1 value, just use that (instead of array)
-	if len(val) == 1 {
-		*res = val[0]
-	} else {
-		*res = val
+	if req.IncludeForks {
+		val, err := cm.unwindRequestWithForks(req.Block)
+		if len(val) == 1 {
+			*res = val[0]
+		} else {
+			*res = val
+		}
+		return err
 	}
 
-	return err
+	switch req.Block.(type) {
+	case []interface{}, map[string]interface{}:
+		val, err := cm.unwindRequest(req.Block)
+		if err != nil {
+			return err
+		}
+		*res = val
+		return nil
+	default:
+		hash, err := cm.lookupHashByInterface(req.Block)
+		if err != nil {
+			return err
+		}
+		if hash == nil {
+			*res = nil
+		} else {
+			*res = *hash
+		}
+		return nil
+	}
 }
 
 // GetFinalizedHead returns the most recently finalised block hash
@@ -177,17 +219,38 @@ func (cm *ChainModule) hashLookup(req *ChainHashRequest) common.Hash {
 	return *req.Bhash
 }
 
-// unwindRequest takes request interface slice and makes call for each element
-func (cm *ChainModule) unwindRequest(req interface{}) ([]string, error) {
-	res := make([]string, 0)
+// unwindRequest takes request interface slice or block number range and makes
+// call for each element. The hash is nil for block numbers above the best block.
+func (cm *ChainModule) unwindRequest(req interface{}) ([]*string, error) {
+	res := make([]*string, 0)
 	switch x := (req).(type) {
 	case []interface{}:
+		if len(x) > maxBlockHashNumbers {
+			return nil, fmt.Errorf("%w: %d block numbers exceed the maximum of %d",
+				errBlockNumberArraySize, len(x), maxBlockHashNumbers)
+		}
 		for _, v := range x {
 			u, err := cm.unwindRequest(v)
 			if err != nil {
 				return nil, err
 			}
 			res = append(res, u...)
+			if len(res) > maxBlockHashNumbers {
+				return nil, fmt.Errorf("%w: more than %d block numbers",
+					errBlockNumberArraySize, maxBlockHashNumbers)
+			}
+		}
+	case map[string]interface{}:
+		from, to, err := parseBlockNumberRange(x)
+		if err != nil {
+			return nil, err
+		}
+		for num := from; num <= to; num++ {
+			h, err := cm.lookupHashByNumber(num)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, h)
 		}
 	case interface{}:
 		h, err := cm.lookupHashByInterface(x)
@@ -199,37 +262,144 @@ func (cm *ChainModule) unwindRequest(req interface{}) ([]string, error) {
 	return res, nil
 }
 
+// unwindRequestWithForks takes request interface slice and gets the canonical
+// and non canonical hashes for each element
+func (cm *ChainModule) unwindRequestWithForks(req interface{}) ([][]string, error) {
+	res := make([][]string, 0)
+	switch x := (req).(type) {
+	case []interface{}:
+		if len(x) > maxBlockHashNumbers {
+			return nil, fmt.Errorf("%w: %d block numbers exceed the maximum of %d",
+				errBlockNumberArraySize, len(x), maxBlockHashNumbers)
+		}
+		for _, v := range x {
+			u, err := cm.unwindRequestWithForks(v)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, u...)
+			if len(res) > maxBlockHashNumbers {
+				return nil, fmt.Errorf("%w: more than %d block numbers",
+					errBlockNumberArraySize, maxBlockHashNumbers)
+			}
+		}
+	case interface{}:
+		h, err := cm.lookupHashesByInterface(x)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, h)
+	}
+	return res, nil
+}
+
 // lookupHashByInterface parses given interface to determine block number, then
 // finds hash for that block number
-func (cm *ChainModule) lookupHashByInterface(i interface{}) (string, error) {
-	var num uint
+func (cm *ChainModule) lookupHashByInterface(i interface{}) (*string, error) {
+	num, err := parseBlockNumber(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return cm.lookupHashByNumber(num)
+}
+
+// lookupHashByNumber finds the canonical hash for the given block number,
+// and returns nil if there is no canonical block at this number, that is
+// if the block number is above the best block number.
+func (cm *ChainModule) lookupHashByNumber(num uint) (*string, error) {
+	h, err := cm.blockAPI.GetHashByNumber(num)
+	if errors.Is(err, state.ErrNoCanonicalAtHeight) {
+		return nil, nil //nolint:nilnil
+	} else if err != nil {
+		return nil, err
+	}
+
+	hash := h.String()
+	return &hash, nil
+}
+
+// lookupHashesByInterface parses given interface to determine block number, then
+// finds the canonical hash followed by the non canonical hashes for that block number
+func (cm *ChainModule) lookupHashesByInterface(i interface{}) ([]string, error) {
+	num, err := parseBlockNumber(i)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := cm.blockAPI.GetHashByNumber(num)
+	if err != nil {
+		return nil, err
+	}
+
+	forkHashes, err := cm.blockAPI.GetNonCanonicalHashesByNumber(num)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, 0, 1+len(forkHashes))
+	hashes = append(hashes, h.String())
+	for _, forkHash := range forkHashes {
+		hashes = append(hashes, forkHash.String())
+	}
+	return hashes, nil
+}
+
+// parseBlockNumber parses the given interface as a block number
+func parseBlockNumber(i interface{}) (uint, error) {
 	switch x := i.(type) {
 	case float64:
-		num = uint(x)
+		return uint(x), nil
 	case string:
 		// remove leading 0x (if there is one)
 		re, err := regexp.Compile(`0x`)
 		if err != nil {
-			return "", err
+			return 0, err
 		}
 		x = re.ReplaceAllString(x, "")
 
 		xUint64, err := strconv.ParseUint(x, 10, 64)
 		if err != nil {
-			return "", err
+			return 0, err
 		}
-		num = uint(xUint64)
+		return uint(xUint64), nil
 
 	default:
-		return "", fmt.Errorf("unknown request number type: %T", x)
+		return 0, fmt.Errorf("unknown request number type: %T", x)
 	}
+}
 
-	h, err := cm.blockAPI.GetHashByNumber(num)
+// parseBlockNumberRange parses the from and to block numbers of the given
+// block number range, where both block numbers are included in the range.
+func parseBlockNumberRange(blockRange map[string]interface{}) (from, to uint, err error) {
+	fromValue, ok := blockRange["from"]
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: missing from block number", errBlockNumberRange)
+	}
+	toValue, ok := blockRange["to"]
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: missing to block number", errBlockNumberRange)
+	}
+
+	from, err = parseBlockNumber(fromValue)
 	if err != nil {
-		return "", err
+		return 0, 0, fmt.Errorf("parsing from block number: %w", err)
+	}
+	to, err = parseBlockNumber(toValue)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing to block number: %w", err)
+	}
+
+	if from > to {
+		return 0, 0, fmt.Errorf("%w: from block number %d is greater than to block number %d",
+			errBlockNumberRange, from, to)
+	}
+	if to-from >= maxBlockHashNumbers {
+		return 0, 0, fmt.Errorf("%w: %d block numbers exceed the maximum of %d",
+			errBlockNumberRangeSize, to-from+1, maxBlockHashNumbers)
 	}
 
-	return h.String(), nil
+	return from, to, nil
 }
 
 // HeaderToJSON converts types.Header to ChainBlockHeaderResponse
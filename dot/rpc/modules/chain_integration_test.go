@@ -221,7 +221,7 @@ func TestChainGetBlockHash_Latest(t *testing.T) {
 
 	resString := string("")
 	res := ChainHashResponse(resString)
-	req := ChainBlockNumberRequest{nil}
+	req := ChainBlockNumberRequest{Block: nil}
 
 	err := svc.GetBlockHash(nil, &req, &res)
 	require.NoError(t, err)
@@ -236,7 +236,7 @@ func TestChainGetBlockHash_ByNumber(t *testing.T) {
 
 	resString := string("")
 	res := ChainHashResponse(resString)
-	req := ChainBlockNumberRequest{"1"}
+	req := ChainBlockNumberRequest{Block: "1"}
 
 	err := svc.GetBlockHash(nil, &req, &res)
 	require.NoError(t, err)
@@ -252,7 +252,7 @@ func TestChainGetBlockHash_ByHex(t *testing.T) {
 
 	resString := string("")
 	res := ChainHashResponse(resString)
-	req := ChainBlockNumberRequest{"0x01"}
+	req := ChainBlockNumberRequest{Block: "0x01"}
 
 	err := svc.GetBlockHash(nil, &req, &res)
 	require.NoError(t, err)
@@ -272,7 +272,7 @@ func TestChainGetBlockHash_Array(t *testing.T) {
 	nums := make([]interface{}, 2)
 	nums[0] = float64(0)     // as number
 	nums[1] = string("0x01") // as hex string
-	req := ChainBlockNumberRequest{nums}
+	req := ChainBlockNumberRequest{Block: nums}
 
 	err := svc.GetBlockHash(nil, &req, &res)
 	require.NoError(t, err)
@@ -281,11 +281,33 @@ func TestChainGetBlockHash_Array(t *testing.T) {
 	require.NoError(t, err)
 	expected1, err := state.Block.GetBlockByNumber(1)
 	require.NoError(t, err)
-	expected := []string{expected0.Header.Hash().String(), expected1.Header.Hash().String()}
+	hash0, hash1 := expected0.Header.Hash().String(), expected1.Header.Hash().String()
+	expected := []*string{&hash0, &hash1}
 
 	require.Equal(t, expected, res)
 }
 
+func TestChainGetBlockHash_RangeSpanningTip(t *testing.T) {
+	state := newTestStateService(t)
+	svc := NewChainModule(state.Block)
+
+	bestBlockHeader, err := state.Block.BestBlockHeader()
+	require.NoError(t, err)
+
+	var res ChainHashResponse
+	req := ChainBlockNumberRequest{Block: map[string]interface{}{
+		"from": float64(bestBlockHeader.Number),
+		"to":   float64(bestBlockHeader.Number + 2),
+	}}
+
+	err = svc.GetBlockHash(nil, &req, &res)
+	require.NoError(t, err)
+
+	bestBlockHash := bestBlockHeader.Hash().String()
+	expected := []*string{&bestBlockHash, nil, nil}
+	require.Equal(t, expected, res)
+}
+
 func TestChainGetFinalizedHead(t *testing.T) {
 	state := newTestStateService(t)
 	svc := NewChainModule(state.Block)
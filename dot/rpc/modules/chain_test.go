@@ -9,6 +9,7 @@
 	"testing"
 
 	"github.com/ChainSafe/gossamer/dot/rpc/modules/mocks"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/golang/mock/gomock"
@@ -132,7 +133,30 @@ func TestChainModule_GetBlockHash(t *testing.T) {
 	mockBlockAPIErr.EXPECT().GetHashByNumber(uint(21)).
 		Return(common.Hash{}, errors.New("GetBlockHash Error"))
 
+	forkHash := common.NewHash([]byte{0x03, 0x04})
+	mockBlockAPIForks := mocks.NewMockBlockAPI(ctrl)
+	mockBlockAPIForks.EXPECT().GetHashByNumber(uint(21)).
+		Return(testHash, nil).Times(3)
+	mockBlockAPIForks.EXPECT().GetNonCanonicalHashesByNumber(uint(21)).
+		Return([]common.Hash{forkHash}, nil).Times(3)
+
+	mockBlockAPIForksErr := mocks.NewMockBlockAPI(ctrl)
+	mockBlockAPIForksErr.EXPECT().GetHashByNumber(uint(21)).
+		Return(testHash, nil)
+	mockBlockAPIForksErr.EXPECT().GetNonCanonicalHashesByNumber(uint(21)).
+		Return(nil, errors.New("GetNonCanonicalHashesByNumber Error"))
+
+	mockBlockAPIRange := mocks.NewMockBlockAPI(ctrl)
+	mockBlockAPIRange.EXPECT().GetHashByNumber(uint(21)).
+		Return(testHash, nil).Times(2)
+	mockBlockAPIRange.EXPECT().GetHashByNumber(uint(22)).
+		Return(common.Hash{}, state.ErrNoCanonicalAtHeight).Times(3)
+	mockBlockAPIRange.EXPECT().GetHashByNumber(uint(23)).
+		Return(common.Hash{}, state.ErrNoCanonicalAtHeight)
+
 	expRes := ChainHashResponse(testHash.String())
+	expForksRes := []string{testHash.String(), forkHash.String()}
+	testHashString := testHash.String()
 	type fields struct {
 		blockAPI BlockAPI
 	}
@@ -163,7 +187,7 @@ type args struct {
 				mockBlockAPI,
 			},
 			args: args{
-				req: &ChainBlockNumberRequest{"21"},
+				req: &ChainBlockNumberRequest{Block: "21"},
 			},
 			exp: expRes,
 		},
@@ -173,7 +197,7 @@ type args struct {
 				mockBlockAPI,
 			},
 			args: args{
-				req: &ChainBlockNumberRequest{float64(21)},
+				req: &ChainBlockNumberRequest{Block: float64(21)},
 			},
 			exp: expRes,
 		},
@@ -183,9 +207,8 @@ type args struct {
 				mockBlockAPI,
 			},
 			args: args{
-				req: &ChainBlockNumberRequest{uintptr(1)},
+				req: &ChainBlockNumberRequest{Block: uintptr(1)},
 			},
-			exp:    []string(nil),
 			expErr: errors.New("unknown request number type: uintptr"),
 		},
 		{
@@ -194,9 +217,8 @@ type args struct {
 				mockBlockAPI,
 			},
 			args: args{
-				req: &ChainBlockNumberRequest{i},
+				req: &ChainBlockNumberRequest{Block: i},
 			},
-			exp:    []string(nil),
 			expErr: errors.New(`strconv.ParseUint: parsing "a": invalid syntax`),
 		},
 		{
@@ -205,11 +227,130 @@ type args struct {
 				mockBlockAPIErr,
 			},
 			args: args{
-				req: &ChainBlockNumberRequest{"21"},
+				req: &ChainBlockNumberRequest{Block: "21"},
 			},
-			exp:    []string(nil),
 			expErr: errors.New("GetBlockHash Error"),
 		},
+		{
+			name: "GetBlockHash_beyond_best_block",
+			fields: fields{
+				mockBlockAPIRange,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: float64(22)},
+			},
+		},
+		{
+			name: "GetBlockHash_slice_req_OK",
+			fields: fields{
+				mockBlockAPIRange,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: []interface{}{"21", float64(22)}},
+			},
+			exp: []*string{&testHashString, nil},
+		},
+		{
+			name: "GetBlockHash_range_spanning_tip",
+			fields: fields{
+				mockBlockAPIRange,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: map[string]interface{}{
+					"from": "21",
+					"to":   float64(23),
+				}},
+			},
+			exp: []*string{&testHashString, nil, nil},
+		},
+		{
+			name: "GetBlockHash_range_from_greater_than_to",
+			fields: fields{
+				mockBlockAPIRange,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: map[string]interface{}{
+					"from": float64(23),
+					"to":   float64(22),
+				}},
+			},
+			expErr: errors.New("invalid block number range: " +
+				"from block number 23 is greater than to block number 22"),
+		},
+		{
+			name: "GetBlockHash_range_too_large",
+			fields: fields{
+				mockBlockAPIRange,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: map[string]interface{}{
+					"from": float64(0),
+					"to":   float64(2000),
+				}},
+			},
+			expErr: errors.New("block number range is too large: " +
+				"2001 block numbers exceed the maximum of 1024"),
+		},
+		{
+			name: "GetBlockHash_slice_too_large",
+			fields: fields{
+				mockBlockAPIRange,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: make([]interface{}, 2000)},
+			},
+			expErr: errors.New("block number array is too large: " +
+				"2000 block numbers exceed the maximum of 1024"),
+		},
+		{
+			name: "GetBlockHash_include_forks_slice_too_large",
+			fields: fields{
+				mockBlockAPIForks,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{
+					Block:        make([]interface{}, 2000),
+					IncludeForks: true,
+				},
+			},
+			exp: [][]string(nil),
+			expErr: errors.New("block number array is too large: " +
+				"2000 block numbers exceed the maximum of 1024"),
+		},
+		{
+			name: "GetBlockHash_include_forks_OK",
+			fields: fields{
+				mockBlockAPIForks,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: "21", IncludeForks: true},
+			},
+			exp: expForksRes,
+		},
+		{
+			name: "GetBlockHash_include_forks_slice_OK",
+			fields: fields{
+				mockBlockAPIForks,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{
+					Block:        []interface{}{"21", float64(21)},
+					IncludeForks: true,
+				},
+			},
+			exp: [][]string{expForksRes, expForksRes},
+		},
+		{
+			name: "GetBlockHash_include_forks_Err",
+			fields: fields{
+				mockBlockAPIForksErr,
+			},
+			args: args{
+				req: &ChainBlockNumberRequest{Block: "21", IncludeForks: true},
+			},
+			exp:    [][]string(nil),
+			expErr: errors.New("GetNonCanonicalHashesByNumber Error"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,169 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package modules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/extrinsic"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	cscale "github.com/centrifuge/go-substrate-rpc-client/v4/scale"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+var errApplyExtrinsicResultMalformed = errors.New("apply extrinsic result is malformed")
+
+// dispatchErrorNames are the names of the sp_runtime::DispatchError variants,
+// at the index of each variant.
+var dispatchErrorNames = [...]string{
+	"Other", "CannotLookup", "BadOrigin", "Module", "ConsumerRemaining", "NoProviders",
+	"TooManyConsumers", "Token", "Arithmetic", "Transactional", "Exhausted", "Corruption",
+	"Unavailable",
+}
+
+// dispatchSubErrorNames are the names of the variants of the errors nested
+// in the sp_runtime::DispatchError variants, at the index of each variant.
+var dispatchSubErrorNames = map[string][]string{
+	"Token": {"NoFunds", "WouldDie", "BelowMinimum", "CannotCreate", "UnknownAsset",
+		"Frozen", "Unsupported"},
+	"Arithmetic":    {"Underflow", "Overflow", "DivisionByZero"},
+	"Transactional": {"LimitReached", "NoLayer"},
+}
+
+// describeApplyExtrinsicResult decodes the SCALE encoded apply extrinsic result
+// given, which is a Result<Result<(), DispatchError>, TransactionValidityError>,
+// and returns true if the extrinsic is dispatched successfully, or a message
+// describing why it is not valid or why its dispatch failed otherwise.
+func describeApplyExtrinsicResult(metadata *ctypes.Metadata, result []byte) (
+	success bool, errMessage string, err error) {
+	if len(result) < 2 {
+		return false, "", fmt.Errorf("%w: %d bytes", errApplyExtrinsicResultMalformed, len(result))
+	}
+
+	switch result[0] {
+	case 0:
+		switch result[1] {
+		case 0:
+			return true, "", nil
+		case 1:
+			errMessage, err = describeDispatchError(metadata, result[2:])
+			if err != nil {
+				return false, "", fmt.Errorf("describing dispatch error: %w", err)
+			}
+			return false, "dispatch error: " + errMessage, nil
+		default:
+			return false, "", fmt.Errorf("%w: invalid dispatch outcome result byte: %d",
+				errApplyExtrinsicResultMalformed, result[1])
+		}
+	case 1:
+		transactionValidityError := runtime.NewTransactionValidityError()
+		err = scale.Unmarshal(result[1:], transactionValidityError)
+		if err != nil {
+			return false, "", fmt.Errorf("%w: decoding transaction validity error: %s",
+				errApplyExtrinsicResultMalformed, err)
+		}
+		return false, "transaction validity error: " + transactionValidityError.Error(), nil
+	default:
+		return false, "", fmt.Errorf("%w: invalid result byte: %d",
+			errApplyExtrinsicResultMalformed, result[0])
+	}
+}
+
+// describeDispatchError returns the name of the SCALE encoded dispatch error
+// given, naming a module error with the pallet and error names from the metadata
+// given, for example Module(Balances.InsufficientBalance).
+func describeDispatchError(metadata *ctypes.Metadata, encoded []byte) (description string, err error) {
+	if len(encoded) == 0 || int(encoded[0]) >= len(dispatchErrorNames) {
+		return "", fmt.Errorf("%w: invalid dispatch error: 0x%x",
+			errApplyExtrinsicResultMalformed, encoded)
+	}
+	name := dispatchErrorNames[encoded[0]]
+
+	if name == "Module" {
+		var moduleError ctypes.ModuleError
+		err = cscale.NewDecoder(bytes.NewReader(encoded[1:])).Decode(&moduleError)
+		if err != nil {
+			return "", fmt.Errorf("%w: decoding module error: %s", errApplyExtrinsicResultMalformed, err)
+		}
+
+		palletName, errorName := moduleErrorNames(metadata, moduleError)
+		return fmt.Sprintf("Module(%s.%s)", palletName, errorName), nil
+	}
+
+	subErrorNames, ok := dispatchSubErrorNames[name]
+	if !ok {
+		return name, nil
+	}
+
+	if len(encoded) < 2 || int(encoded[1]) >= len(subErrorNames) {
+		return "", fmt.Errorf("%w: invalid %s dispatch error: 0x%x",
+			errApplyExtrinsicResultMalformed, name, encoded[1:])
+	}
+	return fmt.Sprintf("%s(%s)", name, subErrorNames[encoded[1]]), nil
+}
+
+// moduleErrorNames returns the pallet and error names of the module error given,
+// falling back on their indexes if they are not found in the metadata.
+func moduleErrorNames(metadata *ctypes.Metadata, moduleError ctypes.ModuleError) (
+	palletName, errorName string) {
+	palletName = fmt.Sprint(moduleError.Index)
+	if metadata.Version == 14 {
+		for _, pallet := range metadata.AsMetadataV14.Pallets {
+			if pallet.Index == moduleError.Index {
+				palletName = string(pallet.Name)
+				break
+			}
+		}
+	}
+
+	errorName = fmt.Sprint(moduleError.Error[0])
+	metadataError, err := metadata.FindError(moduleError.Index, moduleError.Error)
+	if err == nil {
+		errorName = metadataError.Name
+	}
+
+	return palletName, errorName
+}
+
+// decodeDryRunEvents decodes the SCALE encoded vector of event records given
+// using the runtime metadata given, preserving their order.
+func decodeDryRunEvents(metadata *ctypes.Metadata, events []byte) (dryRunEvents []DryRunEvent, err error) {
+	dryRunEvents = []DryRunEvent{}
+	if len(events) == 0 {
+		return dryRunEvents, nil
+	}
+
+	records, err := extrinsic.DecodeEvents(metadata, events)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		dryRunEvents = append(dryRunEvents, DryRunEvent{
+			Phase:  describePhase(record.Phase),
+			Pallet: record.Pallet,
+			Name:   record.Name,
+			Data:   record.Fields,
+		})
+	}
+	return dryRunEvents, nil
+}
+
+// describePhase returns the name of the phase given, followed by
+// its values in parentheses if it has any, for example ApplyExtrinsic(1).
+func describePhase(phase extrinsic.Variant) string {
+	if len(phase.Fields) == 0 {
+		return phase.Name
+	}
+
+	values := make([]string, len(phase.Fields))
+	for i, field := range phase.Fields {
+		values[i] = fmt.Sprint(field.Value)
+	}
+	return fmt.Sprintf("%s(%s)", phase.Name, strings.Join(values, ", "))
+}
@@ -57,6 +57,21 @@ func (mr *MockStorageAPIMockRecorder) Entries(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Entries", reflect.TypeOf((*MockStorageAPI)(nil).Entries), arg0)
 }
 
+// GetKeysPaged mocks base method.
+func (m *MockStorageAPI) GetKeysPaged(arg0 *common.Hash, arg1, arg2 []byte, arg3 uint) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeysPaged", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeysPaged indicates an expected call of GetKeysPaged.
+func (mr *MockStorageAPIMockRecorder) GetKeysPaged(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysPaged", reflect.TypeOf((*MockStorageAPI)(nil).GetKeysPaged), arg0, arg1, arg2, arg3)
+}
+
 // GetKeysWithPrefix mocks base method.
 func (m *MockStorageAPI) GetKeysWithPrefix(arg0 *common.Hash, arg1 []byte) ([][]byte, error) {
 	m.ctrl.T.Helper()
@@ -350,6 +365,21 @@ func (mr *MockBlockAPIMockRecorder) GetJustification(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJustification", reflect.TypeOf((*MockBlockAPI)(nil).GetJustification), arg0)
 }
 
+// GetNonCanonicalHashesByNumber mocks base method.
+func (m *MockBlockAPI) GetNonCanonicalHashesByNumber(arg0 uint) ([]common.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonCanonicalHashesByNumber", arg0)
+	ret0, _ := ret[0].([]common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonCanonicalHashesByNumber indicates an expected call of GetNonCanonicalHashesByNumber.
+func (mr *MockBlockAPIMockRecorder) GetNonCanonicalHashesByNumber(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonCanonicalHashesByNumber", reflect.TypeOf((*MockBlockAPI)(nil).GetNonCanonicalHashesByNumber), arg0)
+}
+
 // GetRuntime mocks base method.
 func (m *MockBlockAPI) GetRuntime(arg0 common.Hash) (runtime.Instance, error) {
 	m.ctrl.T.Helper()
@@ -697,6 +727,34 @@ func (mr *MockTransactionStateAPIMockRecorder) Pending() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockTransactionStateAPI)(nil).Pending))
 }
 
+// PendingInQueue mocks base method.
+func (m *MockTransactionStateAPI) PendingInQueue() []*transaction.ValidTransaction {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingInQueue")
+	ret0, _ := ret[0].([]*transaction.ValidTransaction)
+	return ret0
+}
+
+// PendingInQueue indicates an expected call of PendingInQueue.
+func (mr *MockTransactionStateAPIMockRecorder) PendingInQueue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingInQueue", reflect.TypeOf((*MockTransactionStateAPI)(nil).PendingInQueue))
+}
+
+// RemoveExtrinsics mocks base method.
+func (m *MockTransactionStateAPI) RemoveExtrinsics(arg0 []common.Hash) []common.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExtrinsics", arg0)
+	ret0, _ := ret[0].([]common.Hash)
+	return ret0
+}
+
+// RemoveExtrinsics indicates an expected call of RemoveExtrinsics.
+func (mr *MockTransactionStateAPIMockRecorder) RemoveExtrinsics(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExtrinsics", reflect.TypeOf((*MockTransactionStateAPI)(nil).RemoveExtrinsics), arg0)
+}
+
 // MockCoreAPI is a mock of CoreAPI interface.
 type MockCoreAPI struct {
 	ctrl     *gomock.Controller
@@ -720,6 +778,21 @@ func (m *MockCoreAPI) EXPECT() *MockCoreAPIMockRecorder {
 	return m.recorder
 }
 
+// CallRuntime mocks base method.
+func (m *MockCoreAPI) CallRuntime(arg0 *common.Hash, arg1 string, arg2 []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallRuntime", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallRuntime indicates an expected call of CallRuntime.
+func (mr *MockCoreAPIMockRecorder) CallRuntime(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallRuntime", reflect.TypeOf((*MockCoreAPI)(nil).CallRuntime), arg0, arg1, arg2)
+}
+
 // DecodeSessionKeys mocks base method.
 func (m *MockCoreAPI) DecodeSessionKeys(arg0 []byte) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -735,6 +808,22 @@ func (mr *MockCoreAPIMockRecorder) DecodeSessionKeys(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecodeSessionKeys", reflect.TypeOf((*MockCoreAPI)(nil).DecodeSessionKeys), arg0)
 }
 
+// DryRunExtrinsic mocks base method.
+func (m *MockCoreAPI) DryRunExtrinsic(arg0 *common.Hash, arg1 types.Extrinsic) ([]byte, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DryRunExtrinsic", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DryRunExtrinsic indicates an expected call of DryRunExtrinsic.
+func (mr *MockCoreAPIMockRecorder) DryRunExtrinsic(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DryRunExtrinsic", reflect.TypeOf((*MockCoreAPI)(nil).DryRunExtrinsic), arg0, arg1)
+}
+
 // GetMetadata mocks base method.
 func (m *MockCoreAPI) GetMetadata(arg0 *common.Hash) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -54,6 +54,21 @@ func (mr *MockStorageAPIMockRecorder) Entries(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Entries", reflect.TypeOf((*MockStorageAPI)(nil).Entries), arg0)
 }
 
+// GetKeysPaged mocks base method.
+func (m *MockStorageAPI) GetKeysPaged(arg0 *common.Hash, arg1, arg2 []byte, arg3 uint) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeysPaged", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeysPaged indicates an expected call of GetKeysPaged.
+func (mr *MockStorageAPIMockRecorder) GetKeysPaged(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysPaged", reflect.TypeOf((*MockStorageAPI)(nil).GetKeysPaged), arg0, arg1, arg2, arg3)
+}
+
 // GetKeysWithPrefix mocks base method.
 func (m *MockStorageAPI) GetKeysWithPrefix(arg0 *common.Hash, arg1 []byte) ([][]byte, error) {
 	m.ctrl.T.Helper()
@@ -347,6 +362,21 @@ func (mr *MockBlockAPIMockRecorder) GetJustification(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJustification", reflect.TypeOf((*MockBlockAPI)(nil).GetJustification), arg0)
 }
 
+// GetNonCanonicalHashesByNumber mocks base method.
+func (m *MockBlockAPI) GetNonCanonicalHashesByNumber(arg0 uint) ([]common.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonCanonicalHashesByNumber", arg0)
+	ret0, _ := ret[0].([]common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonCanonicalHashesByNumber indicates an expected call of GetNonCanonicalHashesByNumber.
+func (mr *MockBlockAPIMockRecorder) GetNonCanonicalHashesByNumber(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonCanonicalHashesByNumber", reflect.TypeOf((*MockBlockAPI)(nil).GetNonCanonicalHashesByNumber), arg0)
+}
+
 // GetRuntime mocks base method.
 func (m *MockBlockAPI) GetRuntime(arg0 common.Hash) (runtime.Instance, error) {
 	m.ctrl.T.Helper()
@@ -4,9 +4,13 @@
 package modules
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
 
 	"github.com/ChainSafe/gossamer/lib/common"
+	cscale "github.com/centrifuge/go-substrate-rpc-client/v4/scale"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
 )
 
 // PaymentQueryInfoRequest represents the request to get the fee of an extrinsic in a given block
@@ -36,8 +40,20 @@ func NewPaymentModule(blockAPI BlockAPI) *PaymentModule {
 	}
 }
 
-// QueryInfo query the known data about the fee of an extrinsic at the given block
+// QueryInfo query the known data about the fee of an extrinsic at the given block.
+// The extrinsic is checked to decode before calling the runtime, such that an
+// error wrapping ErrInvalidExtrinsic is returned for a malformed extrinsic.
 func (p *PaymentModule) QueryInfo(_ *http.Request, req *PaymentQueryInfoRequest, res *PaymentQueryInfoResponse) error {
+	ext, err := common.HexToBytes(req.Ext)
+	if err != nil {
+		return fmt.Errorf("%w: decoding hex: %s", ErrInvalidExtrinsic, err)
+	}
+
+	err = validateExtrinsic(ext)
+	if err != nil {
+		return err
+	}
+
 	var hash common.Hash
 	if req.Hash == nil {
 		hash = p.blockAPI.BestBlockHash()
@@ -50,14 +66,9 @@ func (p *PaymentModule) QueryInfo(_ *http.Request, req *PaymentQueryInfoRequest,
 		return err
 	}
 
-	ext, err := common.HexToBytes(req.Ext)
-	if err != nil {
-		return err
-	}
-
 	encQueryInfo, err := r.PaymentQueryInfo(ext)
 	if err != nil {
-		return err
+		return fmt.Errorf("querying runtime for extrinsic info: %w", err)
 	}
 
 	if encQueryInfo != nil {
@@ -70,3 +81,27 @@ func (p *PaymentModule) QueryInfo(_ *http.Request, req *PaymentQueryInfoRequest,
 
 	return nil
 }
+
+// validateExtrinsic returns an error wrapping ErrInvalidExtrinsic if the SCALE
+// encoded extrinsic given does not decode, or if its length prefix does not
+// match the length of the encoded extrinsic following it.
+func validateExtrinsic(ext []byte) error {
+	reader := bytes.NewReader(ext)
+	length, err := cscale.NewDecoder(reader).DecodeUintCompact()
+	if err != nil {
+		return fmt.Errorf("%w: decoding length prefix: %s", ErrInvalidExtrinsic, err)
+	}
+
+	if length.Uint64() != uint64(reader.Len()) {
+		return fmt.Errorf("%w: length prefix is %d bytes but %d bytes follow it",
+			ErrInvalidExtrinsic, length.Uint64(), reader.Len())
+	}
+
+	var decoded ctypes.Extrinsic
+	err = cscale.NewDecoder(bytes.NewReader(ext)).Decode(&decoded)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidExtrinsic, err)
+	}
+
+	return nil
+}
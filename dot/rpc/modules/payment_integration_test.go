@@ -12,10 +12,12 @@
 	"github.com/ChainSafe/gossamer/dot/rpc/modules/mocks"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
 	mocksruntime "github.com/ChainSafe/gossamer/lib/runtime/mocks"
 )
 
@@ -51,7 +53,7 @@ func TestPaymentQueryInfo(t *testing.T) {
 		}
 
 		var req PaymentQueryInfoRequest
-		req.Ext = "0x0001"
+		req.Ext = "0x0c040000"
 		req.Hash = nil
 
 		var res PaymentQueryInfoResponse
@@ -75,7 +77,7 @@ func TestPaymentQueryInfo(t *testing.T) {
 		}
 
 		var req PaymentQueryInfoRequest
-		req.Ext = "0x0011"
+		req.Ext = "0x0c040000"
 		req.Hash = nil
 
 		var res PaymentQueryInfoResponse
@@ -100,7 +102,7 @@ func TestPaymentQueryInfo(t *testing.T) {
 
 		mockedHash := common.NewHash([]byte{0x01, 0x02})
 		var req PaymentQueryInfoRequest
-		req.Ext = "0x0000"
+		req.Ext = "0x0c040000"
 		req.Hash = &mockedHash
 
 		var res PaymentQueryInfoResponse
@@ -125,7 +127,7 @@ func TestPaymentQueryInfo(t *testing.T) {
 
 		mockedHash := common.NewHash([]byte{0x01, 0x02})
 		var req PaymentQueryInfoRequest
-		req.Ext = "0x0020"
+		req.Ext = "0x0c040000"
 		req.Hash = &mockedHash
 
 		var res PaymentQueryInfoResponse
@@ -134,4 +136,49 @@ func TestPaymentQueryInfo(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, res, PaymentQueryInfoResponse{})
 	})
+
+	t.Run("With_the_runtime_fixture", func(t *testing.T) {
+		rt, err := state.Block.GetRuntime(bestBlockHash)
+		require.NoError(t, err)
+
+		genesisHash := state.Block.GenesisHash()
+		extHex := runtime.NewTestExtrinsic(t, rt, genesisHash, genesisHash, 0,
+			signature.TestKeyringPairAlice, "System.remark", []byte{0xab, 0xcd})
+
+		mod := NewPaymentModule(state.Block)
+		req := PaymentQueryInfoRequest{
+			Ext:  extHex,
+			Hash: &bestBlockHash,
+		}
+		var res PaymentQueryInfoResponse
+		err = mod.QueryInfo(nil, &req, &res)
+
+		require.NoError(t, err)
+		// Fee computed by the westend local runtime for this extrinsic length.
+		expected := PaymentQueryInfoResponse{
+			Weight:     3505824,
+			Class:      0,
+			PartialFee: "11939839815",
+		}
+		require.Equal(t, expected, res)
+
+		info, err := rt.PaymentQueryInfo(common.MustHexToBytes(extHex))
+		require.NoError(t, err)
+		require.Equal(t, expected.PartialFee, info.PartialFee.String())
+	})
+
+	t.Run("With_an_invalid_extrinsic", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		// The runtime is not called for an extrinsic not decoding.
+		blockAPIMock := mocks.NewMockBlockAPI(ctrl)
+		mod := NewPaymentModule(blockAPIMock)
+
+		req := PaymentQueryInfoRequest{Ext: "0x0c840000"}
+		var res PaymentQueryInfoResponse
+		err := mod.QueryInfo(nil, &req, &res)
+
+		require.ErrorIs(t, err, ErrInvalidExtrinsic)
+		require.Equal(t, PaymentQueryInfoResponse{}, res)
+	})
 }
@@ -36,8 +36,8 @@ func TestPaymentModule_QueryInfo(t *testing.T) {
 	blockErrorAPIMock1 := mocks.NewMockBlockAPI(ctrl)
 	blockErrorAPIMock2 := mocks.NewMockBlockAPI(ctrl)
 
-	blockAPIMock.EXPECT().BestBlockHash().Return(testHash).Times(2)
-	blockAPIMock.EXPECT().GetRuntime(testHash).Return(runtimeMock, nil).Times(3)
+	blockAPIMock.EXPECT().BestBlockHash().Return(testHash)
+	blockAPIMock.EXPECT().GetRuntime(testHash).Return(runtimeMock, nil).Times(2)
 
 	blockAPIMock2.EXPECT().GetRuntime(testHash).Return(runtimeMock2, nil)
 
@@ -45,13 +45,13 @@ func TestPaymentModule_QueryInfo(t *testing.T) {
 
 	blockErrorAPIMock2.EXPECT().GetRuntime(testHash).Return(nil, errors.New("GetRuntime error"))
 
-	runtimeMock.EXPECT().PaymentQueryInfo(common.MustHexToBytes("0x0000")).Return(nil, nil).Times(2)
-	runtimeMock2.EXPECT().PaymentQueryInfo(common.MustHexToBytes("0x0000")).Return(&types.RuntimeDispatchInfo{
+	runtimeMock.EXPECT().PaymentQueryInfo(common.MustHexToBytes("0x0c040000")).Return(nil, nil).Times(2)
+	runtimeMock2.EXPECT().PaymentQueryInfo(common.MustHexToBytes("0x0c040000")).Return(&types.RuntimeDispatchInfo{
 		Weight:     uint64(21),
 		Class:      21,
 		PartialFee: u,
 	}, nil)
-	runtimeErrorMock.EXPECT().PaymentQueryInfo(common.MustHexToBytes("0x0000")).
+	runtimeErrorMock.EXPECT().PaymentQueryInfo(common.MustHexToBytes("0x0c040000")).
 		Return(nil, errors.New("PaymentQueryInfo error"))
 
 	paymentModule := NewPaymentModule(blockAPIMock)
@@ -76,7 +76,7 @@ type args struct {
 			},
 			args: args{
 				req: &PaymentQueryInfoRequest{
-					Ext:  "0x0000",
+					Ext:  "0x0c040000",
 					Hash: &testHash,
 				},
 			},
@@ -89,7 +89,7 @@ type args struct {
 			},
 			args: args{
 				req: &PaymentQueryInfoRequest{
-					Ext:  "0x0000",
+					Ext:  "0x0c040000",
 					Hash: &testHash,
 				},
 			},
@@ -102,7 +102,7 @@ type args struct {
 			},
 		},
 		{
-			name: "Nil_Hash",
+			name: "Invalid_Hex",
 			fields: fields{
 				paymentModule.blockAPI,
 			},
@@ -111,16 +111,42 @@ type args struct {
 					Ext: "0x0",
 				},
 			},
-			expErr: errors.New("encoding/hex: odd length hex string: 0x0"),
+			expErr: errors.New("invalid extrinsic: decoding hex: encoding/hex: odd length hex string: 0x0"),
+		},
+		{
+			name: "Invalid_Length_Prefix",
+			fields: fields{
+				paymentModule.blockAPI,
+			},
+			args: args{
+				req: &PaymentQueryInfoRequest{
+					Ext: "0x10040000",
+				},
+			},
+			expErr: errors.New("invalid extrinsic: length prefix is 4 bytes but 3 bytes follow it"),
 		},
 		{
-			name: "Invalid_Ext",
+			name: "Undecodable_Ext",
+			fields: fields{
+				paymentModule.blockAPI,
+			},
+			args: args{
+				req: &PaymentQueryInfoRequest{
+					Ext: "0x0c840000",
+				},
+			},
+			expErr: errors.New("invalid extrinsic: " +
+				"type *types.ExtrinsicSignatureV4 does not support Decodeable interface " +
+				"and could not be decoded field by field, error: expected more bytes, but could not decode any more"),
+		},
+		{
+			name: "Nil_Hash",
 			fields: fields{
 				paymentModule.blockAPI,
 			},
 			args: args{
 				req: &PaymentQueryInfoRequest{
-					Ext: "0x0000",
+					Ext: "0x0c040000",
 				},
 			},
 			exp: PaymentQueryInfoResponse{},
@@ -132,11 +158,11 @@ type args struct {
 			},
 			args: args{
 				req: &PaymentQueryInfoRequest{
-					Ext:  "0x0000",
+					Ext:  "0x0c040000",
 					Hash: &testHash,
 				},
 			},
-			expErr: errors.New("PaymentQueryInfo error"),
+			expErr: errors.New("querying runtime for extrinsic info: PaymentQueryInfo error"),
 		},
 		{
 			name: "GetRuntime_error",
@@ -145,7 +171,7 @@ type args struct {
 			},
 			args: args{
 				req: &PaymentQueryInfoRequest{
-					Ext:  "0x0000",
+					Ext:  "0x0c040000",
 					Hash: &testHash,
 				},
 			},
@@ -12,6 +12,7 @@
 	UnsafeMethods = []string{
 		"system_addReservedPeer",
 		"system_removeReservedPeer",
+		"system_dryRun",
 		"author_submitExtrinsic",
 		"author_removeExtrinsic",
 		"author_insertKey",
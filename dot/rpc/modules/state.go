@@ -5,10 +5,12 @@
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"strings"
 
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	"github.com/ChainSafe/gossamer/pkg/scale"
@@ -192,46 +194,28 @@ func NewStateModule(net NetworkAPI, storage StorageAPI, core CoreAPI, blockAPI B
 	}
 }
 
-// GetPairs returns the keys with prefix, leave empty to get all the keys.
+// GetPairs returns the key value pairs with keys having the given prefix,
+// in lexicographic order of their keys. The prefix can be left empty to get
+// all the pairs. If the block hash is nil, the best block state is used.
 func (sm *StateModule) GetPairs(_ *http.Request, req *StatePairRequest, res *StatePairResponse) error {
-	var (
-		stateRootHash *common.Hash
-		err           error
-	)
-
-	if req.Bhash != nil {
-		stateRootHash, err = sm.storageAPI.GetStateRootFromBlock(req.Bhash)
-		if err != nil {
-			return err
-		}
+	stateRootHash, err := sm.storageAPI.GetStateRootFromBlock(req.Bhash)
+	if err != nil {
+		return err
 	}
 
-	if req.Prefix == nil || *req.Prefix == "" || *req.Prefix == "0x" {
-		pairs, err := sm.storageAPI.Entries(stateRootHash)
+	var prefix []byte
+	if req.Prefix != nil && *req.Prefix != "" {
+		prefix, err = common.HexToBytes(*req.Prefix)
 		if err != nil {
-			return err
+			return fmt.Errorf("cannot convert hex prefix %s to bytes: %w", *req.Prefix, err)
 		}
-
-		for k, v := range pairs {
-			*res = append(*res, []string{common.BytesToHex([]byte(k)), common.BytesToHex(v)})
-		}
-		return nil
 	}
 
-	reqBytes, err := common.HexToBytes(*req.Prefix)
-	if err != nil {
-		return fmt.Errorf("cannot convert hex prefix %s to bytes: %w", *req.Prefix, err)
-	}
-	keys, err := sm.storageAPI.GetKeysWithPrefix(stateRootHash, reqBytes)
+	keys, err := sm.storageAPI.GetKeysPaged(stateRootHash, prefix, nil, math.MaxUint)
 	if err != nil {
 		return err
 	}
 
-	if len(keys) == 0 {
-		*res = []interface{}{}
-		return nil
-	}
-
 	*res = make([]interface{}, len(keys))
 	for i, key := range keys {
 		val, err := sm.storageAPI.GetStorage(stateRootHash, key)
@@ -245,35 +229,29 @@ func (sm *StateModule) GetPairs(_ *http.Request, req *StatePairRequest, res *Sta
 	return nil
 }
 
-// Call makes a call to the runtime.
+// Call calls the runtime exported function with the method name given, with
+// the hex encoded SCALE arguments given, at the state of the given block or of
+// the best block if no block hash is given, and returns the hex encoded result.
 func (sm *StateModule) Call(_ *http.Request, req *StateCallRequest, res *StateCallResponse) error {
-	var blockHash common.Hash
-	if req.Block == nil {
-		blockHash = sm.blockAPI.BestBlockHash()
-	} else {
-		blockHash = *req.Block
-	}
-
-	rt, err := sm.blockAPI.GetRuntime(blockHash)
-	if err != nil {
-		return fmt.Errorf("get runtime: %w", err)
-	}
-
 	request, err := common.HexToBytes(req.Params)
 	if err != nil {
 		return fmt.Errorf("convert hex to bytes: %w", err)
 	}
 
-	response, err := rt.Exec(req.Method, request)
+	response, err := sm.coreAPI.CallRuntime(req.Block, req.Method, request)
 	if err != nil {
-		return fmt.Errorf("runtime exec: %w", err)
+		return fmt.Errorf("calling runtime function %s: %w", req.Method, err)
 	}
 
 	*res = StateCallResponse(common.BytesToHex(response))
 	return nil
 }
 
-// GetKeysPaged Returns the keys with prefix with pagination support.
+// GetKeysPaged returns up to the requested quantity of keys having
+// the given prefix and being strictly after the given after key, in
+// lexicographic order. An after key after all the keys having the prefix
+// results in no key returned. If the block hash is nil, the best block
+// state is used.
 func (sm *StateModule) GetKeysPaged(_ *http.Request, req *StateStorageKeyRequest, res *StateStorageKeysResponse) error {
 	if req.Prefix == "" {
 		req.Prefix = "0x"
@@ -282,24 +260,30 @@ func (sm *StateModule) GetKeysPaged(_ *http.Request, req *StateStorageKeyRequest
 	if err != nil {
 		return err
 	}
-	keys, err := sm.storageAPI.GetKeysWithPrefix(req.Block, hPrefix)
-	if err != nil {
-		return fmt.Errorf("cannot get keys with prefix %s: %w", hPrefix, err)
-	}
-	resCount := uint32(0)
-	for _, k := range keys {
-		fKey := fmt.Sprintf("0x%x", k)
-		if strings.Compare(fKey, req.AfterKey) == 1 {
-			// sm.storageAPI.Keys sorts keys in lexicographical order, so we know that keys where strings.Compare = 1
-			//  are after the requested after key.
-			if resCount >= req.Qty {
-				break
-			}
-			*res = append(*res, fKey)
-			resCount++
+
+	var afterKey []byte
+	if req.AfterKey != "" {
+		afterKey, err = common.HexToBytes(req.AfterKey)
+		if err != nil {
+			return fmt.Errorf("cannot convert hex after key %s to bytes: %w", req.AfterKey, err)
 		}
 	}
-	return err
+
+	stateRootHash, err := sm.storageAPI.GetStateRootFromBlock(req.Block)
+	if err != nil {
+		return fmt.Errorf("cannot get state root from block: %w", err)
+	}
+
+	keys, err := sm.storageAPI.GetKeysPaged(stateRootHash, hPrefix, afterKey, uint(req.Qty))
+	if err != nil {
+		return fmt.Errorf("cannot get keys with prefix %s: %w", req.Prefix, err)
+	}
+
+	*res = make(StateStorageKeysResponse, len(keys))
+	for i, key := range keys {
+		(*res)[i] = common.BytesToHex(key)
+	}
+	return nil
 }
 
 // GetMetadata calls runtime Metadata_metadata function
@@ -372,7 +356,7 @@ func (sm *StateModule) GetStorage(
 
 	if req.Bhash != nil {
 		item, err = sm.storageAPI.GetStorageByBlockHash(req.Bhash, reqBytes)
-		if err != nil {
+		if err != nil && !errors.Is(err, state.ErrStorageKeyNotFound) {
 			return err
 		}
 	} else {
@@ -402,7 +386,7 @@ func (sm *StateModule) GetStorageHash(
 
 	if req.Bhash != nil {
 		item, err = sm.storageAPI.GetStorageByBlockHash(req.Bhash, reqBytes)
-		if err != nil {
+		if err != nil && !errors.Is(err, state.ErrStorageKeyNotFound) {
 			return err
 		}
 	} else {
@@ -434,7 +418,7 @@ func (sm *StateModule) GetStorageSize(
 
 	if req.Bhash != nil {
 		item, err = sm.storageAPI.GetStorageByBlockHash(req.Bhash, reqBytes)
-		if err != nil {
+		if err != nil && !errors.Is(err, state.ErrStorageKeyNotFound) {
 			return err
 		}
 	} else {
@@ -488,7 +472,7 @@ func (sm *StateModule) QueryStorage(
 
 		for j, key := range req.Keys {
 			value, err := sm.storageAPI.GetStorageByBlockHash(&blockHash, common.MustHexToBytes(key))
-			if err != nil {
+			if err != nil && !errors.Is(err, state.ErrStorageKeyNotFound) {
 				return fmt.Errorf("getting value by block hash: %w", err)
 			}
 			var hexValue *string
@@ -520,7 +504,8 @@ func (sm *StateModule) QueryStorage(
 }
 
 // QueryStorageAt queries historical storage entries (by key) at the block hash given or
-// the best block if the given block hash is nil
+// the best block if the given block hash is nil. The value of each key is returned in
+// the order of the keys requested, and is null if the key is absent.
 func (sm *StateModule) QueryStorageAt(
 	_ *http.Request, request *StateStorageQueryAtRequest, response *[]StorageChangeSetResponse) error {
 	atBlockHash := request.At
@@ -531,8 +516,13 @@ func (sm *StateModule) QueryStorageAt(
 	changes := make([][2]*string, len(request.Keys))
 
 	for i, key := range request.Keys {
-		value, err := sm.storageAPI.GetStorageByBlockHash(&atBlockHash, common.MustHexToBytes(key))
+		keyBytes, err := common.HexToBytes(key)
 		if err != nil {
+			return fmt.Errorf("decoding key %s: %w", key, err)
+		}
+
+		value, err := sm.storageAPI.GetStorageByBlockHash(&atBlockHash, keyBytes)
+		if err != nil && !errors.Is(err, state.ErrStorageKeyNotFound) {
 			return fmt.Errorf("getting value by block hash: %w", err)
 		}
 		var hexValue *string
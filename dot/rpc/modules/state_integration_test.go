@@ -14,9 +14,13 @@
 	"strings"
 	"testing"
 
+	"github.com/ChainSafe/gossamer/dot/core"
 	"github.com/ChainSafe/gossamer/dot/rpc/modules/mocks"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -99,6 +103,59 @@ func TestStateModule_GetRuntimeVersion(t *testing.T) {
 
 }
 
+func TestStateModule_Call(t *testing.T) {
+	state := newTestStateService(t)
+	bestBlockHash := state.Block.BestBlockHash()
+	hash := &bestBlockHash
+
+	// The runtime of the westend local genesis is used
+	// since it does not need to be downloaded.
+	rt, err := state.Block.GetRuntime(bestBlockHash)
+	require.NoError(t, err)
+	coreService, err := core.NewService(&core.Config{
+		Runtime:              rt,
+		Keystore:             keystore.NewGlobalKeystore(),
+		TransactionState:     state.Transaction,
+		BlockState:           state.Block,
+		StorageState:         state.Storage,
+		Network:              NewMockNetwork(gomock.NewController(t)),
+		CodeSubstitutedState: state.Base,
+	})
+	require.NoError(t, err)
+	sm := NewStateModule(nil, state.Storage, coreService, nil)
+
+	t.Run("Core_version_matches_runtime_version", func(t *testing.T) {
+		req := &StateCallRequest{
+			Method: "Core_version",
+			Params: "0x",
+			Block:  hash,
+		}
+		var res StateCallResponse
+		err := sm.Call(nil, req, &res)
+		require.NoError(t, err)
+
+		version, err := runtime.DecodeVersion(common.MustHexToBytes(string(res)))
+		require.NoError(t, err)
+
+		var expected StateRuntimeVersionResponse
+		err = sm.GetRuntimeVersion(nil, &StateRuntimeVersionRequest{Bhash: hash}, &expected)
+		require.NoError(t, err)
+		assert.Equal(t, expected, NewStateRuntimeVersionResponse(version))
+	})
+
+	t.Run("export_not_found", func(t *testing.T) {
+		req := &StateCallRequest{
+			Method: "Core_unknown",
+			Params: "0x",
+		}
+		var res StateCallResponse
+		err := sm.Call(nil, req, &res)
+		assert.ErrorIs(t, err, wasmer.ErrExportFunctionNotFound)
+		assert.EqualError(t, err, "calling runtime function Core_unknown: export function not found: Core_unknown")
+		assert.Empty(t, res)
+	})
+}
+
 func TestStateModule_GetPairs(t *testing.T) {
 	sm, hash, _ := setupStateModule(t)
 
@@ -435,7 +492,7 @@ func TestStateModule_GetMetadata(t *testing.T) {
 }
 
 func TestStateModule_GetKeysPaged(t *testing.T) {
-	sm, _, stateRootHash := setupStateModule(t)
+	sm, hash, _ := setupStateModule(t)
 
 	testCases := []struct {
 		name     string
@@ -450,7 +507,7 @@ func TestStateModule_GetKeysPaged(t *testing.T) {
 		{name: "allKeysTestBlockHash",
 			params: StateStorageKeyRequest{
 				Qty:   10,
-				Block: stateRootHash,
+				Block: hash,
 			}, expected: []string{"0x3a6b657931", "0x3a6b657932"}},
 		{name: "prefixMatchAll",
 			params: StateStorageKeyRequest{
@@ -476,6 +533,17 @@ func TestStateModule_GetKeysPaged(t *testing.T) {
 				Qty:      10,
 				AfterKey: "0x3a6b657931",
 			}, expected: []string{"0x3a6b657932"}},
+		{name: "afterKeyOutOfRange",
+			params: StateStorageKeyRequest{
+				Qty:      10,
+				AfterKey: "0xff",
+			}, expected: nil},
+		{name: "afterKeyBeforePrefix",
+			params: StateStorageKeyRequest{
+				Prefix:   "0x3a6b657932",
+				Qty:      10,
+				AfterKey: "0x00",
+			}, expected: []string{"0x3a6b657932"}},
 	}
 
 	for _, test := range testCases {
@@ -495,6 +563,31 @@ func TestStateModule_GetKeysPaged(t *testing.T) {
 	}
 }
 
+func TestStateModule_GetKeysPaged_chunks(t *testing.T) {
+	sm, hash, _ := setupStateModule(t)
+
+	var keys []string
+	req := StateStorageKeyRequest{
+		Prefix: "0x3a",
+		Qty:    1,
+		Block:  hash,
+	}
+	for {
+		var res StateStorageKeysResponse
+		err := sm.GetKeysPaged(nil, &req, &res)
+		require.NoError(t, err)
+		if len(res) == 0 {
+			break
+		}
+		require.Len(t, res, 1)
+		keys = append(keys, res...)
+		req.AfterKey = res[0]
+	}
+
+	expected := []string{"0x3a6b657931", "0x3a6b657932"}
+	require.Equal(t, expected, keys)
+}
+
 func TestGetReadProof_WhenCoreAPIReturnsError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -16,7 +16,11 @@
 package modules
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"testing"
 
@@ -24,6 +28,7 @@
 
 	"github.com/ChainSafe/gossamer/dot/rpc/modules/mocks"
 	testdata "github.com/ChainSafe/gossamer/dot/rpc/modules/test_data"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/blocktree"
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -31,6 +36,7 @@
 	"github.com/ChainSafe/gossamer/pkg/scale"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStateModuleGetPairs(t *testing.T) {
@@ -38,45 +44,40 @@ func TestStateModuleGetPairs(t *testing.T) {
 
 	str := "0x01"
 	hash := common.MustHexToHash("0x3aa96b0149b6ca3688878bdbd19464448624136398e3ce45b9e755d3ab61355a")
-	m := make(map[string][]byte)
-	m["a"] = []byte{21, 22}
-	m["b"] = []byte{23, 24}
 
 	mockStorageAPI := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPI.EXPECT().GetStateRootFromBlock(&hash).Return(&hash, nil)
-	mockStorageAPI.EXPECT().GetKeysWithPrefix(&hash, common.MustHexToBytes(str)).Return([][]byte{{1}, {1}}, nil)
-	mockStorageAPI.EXPECT().GetStorage(&hash, []byte{1}).Return([]byte{21}, nil).Times(2)
+	mockStorageAPI.EXPECT().GetKeysPaged(&hash, common.MustHexToBytes(str), nil, uint(math.MaxUint)).
+		Return([][]byte{{1}, {1, 2}}, nil)
+	mockStorageAPI.EXPECT().GetStorage(&hash, []byte{1}).Return([]byte{21}, nil)
+	mockStorageAPI.EXPECT().GetStorage(&hash, []byte{1, 2}).Return([]byte{22}, nil)
 
 	mockStorageAPINil := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPINil.EXPECT().GetStateRootFromBlock(&hash).Return(&hash, nil)
-	mockStorageAPINil.EXPECT().Entries(&hash).Return(m, nil)
+	mockStorageAPINil.EXPECT().GetKeysPaged(&hash, nil, nil, uint(math.MaxUint)).
+		Return([][]byte{{'a'}, {'b'}}, nil)
+	mockStorageAPINil.EXPECT().GetStorage(&hash, []byte{'a'}).Return([]byte{21, 22}, nil)
+	mockStorageAPINil.EXPECT().GetStorage(&hash, []byte{'b'}).Return([]byte{23, 24}, nil)
 
 	mockStorageAPIGetKeysEmpty := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPIGetKeysEmpty.EXPECT().GetStateRootFromBlock(&hash).Return(&hash, nil)
-	mockStorageAPIGetKeysEmpty.EXPECT().GetKeysWithPrefix(&hash, common.MustHexToBytes(str)).Return([][]byte{}, nil)
+	mockStorageAPIGetKeysEmpty.EXPECT().GetKeysPaged(&hash, common.MustHexToBytes(str), nil, uint(math.MaxUint)).
+		Return([][]byte{}, nil)
 
 	mockStorageAPIGetKeysErr := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPIGetKeysErr.EXPECT().GetStateRootFromBlock(&hash).Return(&hash, nil)
-	mockStorageAPIGetKeysErr.EXPECT().GetKeysWithPrefix(&hash, common.MustHexToBytes(str)).
-		Return(nil, errors.New("GetKeysWithPrefix Err"))
-
-	mockStorageAPIEntriesErr := mocks.NewMockStorageAPI(ctrl)
-	mockStorageAPIEntriesErr.EXPECT().GetStateRootFromBlock(&hash).Return(&hash, nil)
-	mockStorageAPIEntriesErr.EXPECT().Entries(&hash).Return(nil, errors.New("entries Err"))
+	mockStorageAPIGetKeysErr.EXPECT().GetKeysPaged(&hash, common.MustHexToBytes(str), nil, uint(math.MaxUint)).
+		Return(nil, errors.New("GetKeysPaged Err"))
 
 	mockStorageAPIErr := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPIErr.EXPECT().GetStateRootFromBlock(&hash).Return(nil, errors.New("GetStateRootFromBlock Err"))
 
 	mockStorageAPIGetStorageErr := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPIGetStorageErr.EXPECT().GetStateRootFromBlock(&hash).Return(&hash, nil)
-	mockStorageAPIGetStorageErr.EXPECT().GetKeysWithPrefix(&hash, common.MustHexToBytes(str)).
+	mockStorageAPIGetStorageErr.EXPECT().GetKeysPaged(&hash, common.MustHexToBytes(str), nil, uint(math.MaxUint)).
 		Return([][]byte{{2}, {2}}, nil)
 	mockStorageAPIGetStorageErr.EXPECT().GetStorage(&hash, []byte{2}).Return(nil, errors.New("GetStorage Err"))
 
-	var expRes StatePairResponse
-	for k, v := range m {
-		expRes = append(expRes, []string{common.BytesToHex([]byte(k)), common.BytesToHex(v)})
-	}
 	type fields struct {
 		networkAPI NetworkAPI
 		storageAPI StorageAPI
@@ -101,6 +102,7 @@ type args struct {
 					Bhash: &hash,
 				},
 			},
+			exp:    StatePairResponse{},
 			expErr: errors.New("GetStateRootFromBlock Err"),
 		},
 		{
@@ -111,18 +113,7 @@ type args struct {
 					Bhash: &hash,
 				},
 			},
-			exp: expRes,
-		},
-		{
-			name:   "Nil Prefix Err",
-			fields: fields{nil, mockStorageAPIEntriesErr, nil},
-			args: args{
-				req: &StatePairRequest{
-					Bhash: &hash,
-				},
-			},
-			exp:    []interface{}{},
-			expErr: errors.New("entries Err"),
+			exp: StatePairResponse{[]string{"0x61", "0x1516"}, []string{"0x62", "0x1718"}},
 		},
 		{
 			name:   "OK Case",
@@ -133,10 +124,10 @@ type args struct {
 					Bhash:  &hash,
 				},
 			},
-			exp: StatePairResponse{[]string{"0x01", "0x15"}, []string{"0x01", "0x15"}},
+			exp: StatePairResponse{[]string{"0x01", "0x15"}, []string{"0x0102", "0x16"}},
 		},
 		{
-			name:   "GetKeysWithPrefix Error",
+			name:   "GetKeysPaged Error",
 			fields: fields{nil, mockStorageAPIGetKeysErr, nil},
 			args: args{
 				req: &StatePairRequest{
@@ -144,7 +135,8 @@ type args struct {
 					Bhash:  &hash,
 				},
 			},
-			expErr: errors.New("GetKeysWithPrefix Err"),
+			exp:    StatePairResponse{},
+			expErr: errors.New("GetKeysPaged Err"),
 		},
 		{
 			name:   "GetStorage Error",
@@ -159,7 +151,7 @@ type args struct {
 			expErr: errors.New("GetStorage Err"),
 		},
 		{
-			name:   "GetKeysWithPrefix Empty",
+			name:   "GetKeysPaged Empty",
 			fields: fields{nil, mockStorageAPIGetKeysEmpty, nil},
 			args: args{
 				req: &StatePairRequest{
@@ -184,7 +176,7 @@ type args struct {
 			} else {
 				assert.NoError(t, err)
 			}
-			assert.ElementsMatch(t, tt.exp, res)
+			assert.Equal(t, tt.exp, res)
 		})
 	}
 }
@@ -192,17 +184,26 @@ type args struct {
 func TestStateModuleGetKeysPaged(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
+	hash := common.MustHexToHash("0x3aa96b0149b6ca3688878bdbd19464448624136398e3ce45b9e755d3ab61355a")
+
 	mockStorageAPI := mocks.NewMockStorageAPI(ctrl)
-	mockStorageAPI.EXPECT().GetKeysWithPrefix((*common.Hash)(nil), common.MustHexToBytes("0x")).
-		Return([][]byte{{1}, {2}}, nil)
+	mockStorageAPI.EXPECT().GetStateRootFromBlock((*common.Hash)(nil)).Return(&hash, nil)
+	mockStorageAPI.EXPECT().GetKeysPaged(&hash, []byte{}, []byte{1}, uint(10)).
+		Return([][]byte{{1, 1}, {2}}, nil)
 
-	mockStorageAPI2 := mocks.NewMockStorageAPI(ctrl)
-	mockStorageAPI2.EXPECT().GetKeysWithPrefix((*common.Hash)(nil), common.MustHexToBytes("0x")).
-		Return([][]byte{{1, 1, 1}, {1, 1, 1}}, nil)
+	mockStorageAPIEmpty := mocks.NewMockStorageAPI(ctrl)
+	mockStorageAPIEmpty.EXPECT().GetStateRootFromBlock((*common.Hash)(nil)).Return(&hash, nil)
+	mockStorageAPIEmpty.EXPECT().GetKeysPaged(&hash, []byte{}, []byte{0xff}, uint(10)).
+		Return(nil, nil)
+
+	mockStorageAPIStateRootErr := mocks.NewMockStorageAPI(ctrl)
+	mockStorageAPIStateRootErr.EXPECT().GetStateRootFromBlock((*common.Hash)(nil)).
+		Return(nil, errors.New("GetStateRootFromBlock Err"))
 
 	mockStorageAPIErr := mocks.NewMockStorageAPI(ctrl)
-	mockStorageAPIErr.EXPECT().GetKeysWithPrefix((*common.Hash)(nil), common.MustHexToBytes("0x")).
-		Return(nil, errors.New("GetKeysWithPrefix Err"))
+	mockStorageAPIErr.EXPECT().GetStateRootFromBlock((*common.Hash)(nil)).Return(&hash, nil)
+	mockStorageAPIErr.EXPECT().GetKeysPaged(&hash, []byte{}, []byte(nil), uint(10)).
+		Return(nil, errors.New("GetKeysPaged Err"))
 
 	type fields struct {
 		networkAPI NetworkAPI
@@ -225,35 +226,45 @@ type args struct {
 			fields: fields{nil, mockStorageAPI, nil},
 			args: args{
 				req: &StateStorageKeyRequest{
+					Qty:      10,
 					AfterKey: "0x01",
 				},
 			},
-			exp: StateStorageKeysResponse(nil),
+			exp: StateStorageKeysResponse{"0x0101", "0x02"},
 		},
 		{
-			name:   "ResCount break",
-			fields: fields{nil, mockStorageAPI2, nil},
+			name:   "After key out of range",
+			fields: fields{nil, mockStorageAPIEmpty, nil},
 			args: args{
 				req: &StateStorageKeyRequest{
-					Qty:      1,
-					AfterKey: "0x01",
+					Qty:      10,
+					AfterKey: "0xff",
 				},
 			},
-			exp: StateStorageKeysResponse{"0x010101"},
+			exp: StateStorageKeysResponse{},
 		},
 		{
-			name:   "GetKeysWithPrefix Error",
+			name:   "GetStateRootFromBlock Error",
+			fields: fields{nil, mockStorageAPIStateRootErr, nil},
+			args: args{
+				req: &StateStorageKeyRequest{
+					Qty: 10,
+				},
+			},
+			expErr: errors.New("cannot get state root from block: GetStateRootFromBlock Err"),
+		},
+		{
+			name:   "GetKeysPaged Error",
 			fields: fields{nil, mockStorageAPIErr, nil},
 			args: args{
 				req: &StateStorageKeyRequest{
-					AfterKey: "0x01",
+					Qty: 10,
 				},
 			},
-			expErr: errors.New("cannot get keys with prefix : GetKeysWithPrefix Err"),
+			expErr: errors.New("cannot get keys with prefix 0x: GetKeysPaged Err"),
 		},
 		{
-			name:   "Request Prefix Error",
-			fields: fields{nil, mockStorageAPI, nil},
+			name: "Request Prefix Error",
 			args: args{
 				req: &StateStorageKeyRequest{
 					Prefix:   "a",
@@ -262,6 +273,16 @@ type args struct {
 			},
 			expErr: errors.New("could not byteify non 0x prefixed string: a"),
 		},
+		{
+			name: "Request After Key Error",
+			args: args{
+				req: &StateStorageKeyRequest{
+					AfterKey: "a",
+				},
+			},
+			expErr: errors.New("cannot convert hex after key a to bytes: " +
+				"could not byteify non 0x prefixed string: a"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -286,26 +307,74 @@ type args struct {
 // TODO: Improve runtime tests
 // https://github.com/ChainSafe/gossamer/issues/3234
 func TestCall(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	testHash := common.NewHash([]byte{0x01, 0x02})
-	rt := wasmer.NewTestInstance(t, runtime.WESTEND_RUNTIME_v0929)
+	t.Parallel()
 
-	mockNetworkAPI := mocks.NewMockNetworkAPI(ctrl)
-	mockStorageAPI := mocks.NewMockStorageAPI(ctrl)
-	mockBlockAPI := mocks.NewMockBlockAPI(ctrl)
-	mockBlockAPI.EXPECT().BestBlockHash().Return(testHash)
-	mockBlockAPI.EXPECT().GetRuntime(testHash).Return(rt, nil)
+	blockHash := common.Hash{1}
+
+	testCases := map[string]struct {
+		coreAPIBuilder func(ctrl *gomock.Controller) CoreAPI
+		req            *StateCallRequest
+		res            StateCallResponse
+		errWrapped     error
+		errMessage     string
+	}{
+		"invalid_params_hex": {
+			coreAPIBuilder: func(ctrl *gomock.Controller) CoreAPI { return nil },
+			req: &StateCallRequest{
+				Method: "Core_version",
+				Params: "0x1",
+			},
+			errWrapped: hex.ErrLength,
+			errMessage: "convert hex to bytes: encoding/hex: odd length hex string: 0x1",
+		},
+		"export_not_found": {
+			coreAPIBuilder: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().CallRuntime((*common.Hash)(nil), "Core_unknown", []byte{}).
+					Return(nil, fmt.Errorf("%w: Core_unknown", wasmer.ErrExportFunctionNotFound))
+				return coreAPI
+			},
+			req: &StateCallRequest{
+				Method: "Core_unknown",
+				Params: "0x",
+			},
+			errWrapped: wasmer.ErrExportFunctionNotFound,
+			errMessage: "calling runtime function Core_unknown: export function not found: Core_unknown",
+		},
+		"at_block": {
+			coreAPIBuilder: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().CallRuntime(&blockHash, "Core_version", []byte{1, 2}).
+					Return([]byte{3, 4}, nil)
+				return coreAPI
+			},
+			req: &StateCallRequest{
+				Method: "Core_version",
+				Params: "0x0102",
+				Block:  &blockHash,
+			},
+			res: "0x0304",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			sm := NewStateModule(nil, nil, testCase.coreAPIBuilder(ctrl), nil)
 
-	sm := NewStateModule(mockNetworkAPI, mockStorageAPI, nil, mockBlockAPI)
+			var res StateCallResponse
+			err := sm.Call(nil, testCase.req, &res)
 
-	req := &StateCallRequest{
-		Method: "Core_version",
-		Params: "0x",
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.res, res)
+		})
 	}
-	var res StateCallResponse
-	err := sm.Call(nil, req, &res)
-	assert.NoError(t, err)
-	assert.NotEmpty(t, res)
 }
 
 func TestStateModuleGetMetadata(t *testing.T) {
@@ -1232,6 +1301,49 @@ type fields struct {
 				},
 			},
 		},
+		"absent_keys": {
+			fields: fields{
+				storageAPIBuilder: func(ctrl *gomock.Controller) *MockStorageAPI {
+					mockStorageAPI := NewMockStorageAPI(ctrl)
+					mockStorageAPI.EXPECT().GetStorageByBlockHash(&common.Hash{2}, []byte{1}).
+						Return(nil, state.ErrStorageKeyNotFound)
+					mockStorageAPI.EXPECT().GetStorageByBlockHash(&common.Hash{2}, []byte{2}).
+						Return([]byte{2, 2}, nil)
+					return mockStorageAPI
+				},
+				blockAPIBuilder: func(ctrl *gomock.Controller) *MockBlockAPI {
+					return NewMockBlockAPI(ctrl)
+				}},
+			request: &StateStorageQueryAtRequest{
+				Keys: []string{"0x01", "0x02"},
+				At:   common.Hash{2},
+			},
+			expectedResponse: []StorageChangeSetResponse{
+				{
+					Block: &common.Hash{2},
+					Changes: [][2]*string{
+						{stringPtr("0x01"), nil},
+						makeChange("0x02", "0x0202"),
+					},
+				},
+			},
+		},
+		"invalid_key": {
+			fields: fields{
+				storageAPIBuilder: func(ctrl *gomock.Controller) *MockStorageAPI {
+					return NewMockStorageAPI(ctrl)
+				},
+				blockAPIBuilder: func(ctrl *gomock.Controller) *MockBlockAPI {
+					return NewMockBlockAPI(ctrl)
+				}},
+			request: &StateStorageQueryAtRequest{
+				Keys: []string{"010203"},
+				At:   common.Hash{2},
+			},
+			expectedResponse: []StorageChangeSetResponse{},
+			expectedError: errors.New("decoding key 010203: " +
+				"could not byteify non 0x prefixed string: 010203"),
+		},
 		"missing_start_block/multi_keys": {
 			fields: fields{
 				storageAPIBuilder: func(ctrl *gomock.Controller) *MockStorageAPI {
@@ -1281,3 +1393,29 @@ type fields struct {
 		})
 	}
 }
+
+func TestStateModuleQueryStorageAt_jsonResponse(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	blockHash := common.Hash{2}
+	storageAPI := NewMockStorageAPI(ctrl)
+	storageAPI.EXPECT().GetStorageByBlockHash(&blockHash, []byte{1}).Return([]byte{1, 1}, nil)
+	storageAPI.EXPECT().GetStorageByBlockHash(&blockHash, []byte{2}).Return(nil, state.ErrStorageKeyNotFound)
+	blockAPI := NewMockBlockAPI(ctrl)
+	blockAPI.EXPECT().BestBlockHash().Return(blockHash)
+
+	sm := &StateModule{
+		storageAPI: storageAPI,
+		blockAPI:   blockAPI,
+	}
+	var response []StorageChangeSetResponse
+	err := sm.QueryStorageAt(nil, &StateStorageQueryAtRequest{Keys: []string{"0x01", "0x02"}}, &response)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+	const expected = `[{"block":"0x0200000000000000000000000000000000000000000000000000000000000000",` +
+		`"changes":[["0x01","0x0101"],["0x02",null]]}]`
+	assert.JSONEq(t, expected, string(encoded))
+}
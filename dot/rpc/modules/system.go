@@ -6,6 +6,7 @@
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 	"net/http"
 	"strings"
@@ -67,6 +68,31 @@ type SyncStateResponse struct {
 	StartingBlock uint32 `json:"startingBlock"`
 }
 
+// SystemDryRunRequest holds the request fields of the system_dryRun RPC method
+type SystemDryRunRequest struct {
+	// hex SCALE encoded extrinsic
+	Extrinsic string
+	// hex optional block hash indicating the state
+	Hash *common.Hash
+}
+
+// SystemDryRunResponse holds the outcome of an extrinsic applied on a copy of the
+// state of a block, where Result is the hex SCALE encoded apply extrinsic result.
+type SystemDryRunResponse struct {
+	Result  string        `json:"result"`
+	Success bool          `json:"success"`
+	Error   string        `json:"error,omitempty"`
+	Events  []DryRunEvent `json:"events"`
+}
+
+// DryRunEvent is an event deposited by an extrinsic applied by system_dryRun
+type DryRunEvent struct {
+	Phase  string      `json:"phase"`
+	Pallet string      `json:"pallet"`
+	Name   string      `json:"name"`
+	Data   interface{} `json:"data"`
+}
+
 // NewSystemModule creates a new API instance
 func NewSystemModule(net NetworkAPI, sys SystemAPI, core CoreAPI,
 	storage StorageAPI, txAPI TransactionStateAPI, blockAPI BlockAPI,
@@ -159,17 +185,24 @@ func (sm *SystemModule) NodeRoles(r *http.Request, req *EmptyRequest, res *[]int
 	return nil
 }
 
-// AccountNextIndex Returns the next valid index (aka. nonce) for given account.
+// AccountNextIndex Returns the next valid index (aka. nonce) for given account,
+// which is its nonce stored at the best block plus the number of transactions
+// signed by the account pending in the transaction pool.
 func (sm *SystemModule) AccountNextIndex(r *http.Request, req *StringRequest, res *U64Response) error {
 	if req == nil || req.String == "" {
 		return errors.New("account address must be valid")
 	}
 	addressPubKey := crypto.PublicAddressToByteArray(common.Address(req.String))
 
-	// check pending transactions for extrinsics singed by addressPubKey
+	nonce, err := sm.accountNonce(addressPubKey)
+	if err != nil {
+		return err
+	}
+
+	// count the pending transactions signed by addressPubKey, ignoring
+	// the ones with a nonce already used in the best block.
 	pending := sm.txStateAPI.Pending()
-	nonce := uint64(0)
-	found := false
+	pendingCount := uint64(0)
 	for _, v := range pending {
 		var ext ctypes.Extrinsic
 		err := codec.Decode(v.Extrinsic, &ext)
@@ -177,54 +210,119 @@ func (sm *SystemModule) AccountNextIndex(r *http.Request, req *StringRequest, re
 			return err
 		}
 
+		if !ext.IsSigned() {
+			continue
+		}
+
 		extSigner := [32]byte(ext.Signature.Signer.AsID)
-		if bytes.Equal(extSigner[:], addressPubKey) {
-			found = true
-			sigNonce := big.Int(ext.Signature.Nonce)
-			if sigNonce.Uint64() > nonce {
-				nonce = sigNonce.Uint64()
-			}
+		if !bytes.Equal(extSigner[:], addressPubKey) {
+			continue
 		}
-	}
 
-	if found {
-		*res = U64Response(nonce)
-		return nil
+		sigNonce := big.Int(ext.Signature.Nonce)
+		if sigNonce.Uint64() >= nonce {
+			pendingCount++
+		}
 	}
 
-	// no extrinsic signed by request found in pending transactions, so look in storage
+	*res = U64Response(nonce + pendingCount)
+	return nil
+}
+
+// accountNonce returns the nonce of the account stored at the
+// best block, which is 0 if there is no account stored.
+func (sm *SystemModule) accountNonce(addressPubKey []byte) (nonce uint64, err error) {
 	// get metadata to build storage storageKey
-	rawMeta, err := sm.coreAPI.GetMetadata(nil)
+	metadata, err := sm.metadata(nil)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	storageKey, err := ctypes.CreateStorageKey(metadata, "System", "Account", addressPubKey, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	accountRaw, err := sm.storageAPI.GetStorage(nil, storageKey)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(accountRaw) == 0 {
+		return 0, nil
+	}
+
+	var accountInfo ctypes.AccountInfo
+	err = codec.Decode(accountRaw, &accountInfo)
+	if err != nil {
+		return 0, fmt.Errorf("decoding account info: %w", err)
+	}
+
+	return uint64(accountInfo.Nonce), nil
+}
+
+// metadata returns the decoded runtime metadata at the state of the
+// block with the given hash, or of the best block if the hash is nil.
+func (sm *SystemModule) metadata(hash *common.Hash) (metadata *ctypes.Metadata, err error) {
+	rawMeta, err := sm.coreAPI.GetMetadata(hash)
+	if err != nil {
+		return nil, err
 	}
 	var sdMeta []byte
 	err = scale.Unmarshal(rawMeta, &sdMeta)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var metadata ctypes.Metadata
-	err = codec.Decode(sdMeta, &metadata)
+	metadata = new(ctypes.Metadata)
+	err = codec.Decode(sdMeta, metadata)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return metadata, nil
+}
 
-	storageKey, err := ctypes.CreateStorageKey(&metadata, "System", "Account", addressPubKey, nil)
+// DryRun applies the extrinsic given on a copy of the state of the block with
+// the given hash, or of the best block if the hash is nil, and returns the outcome
+// of the extrinsic together with the events it deposited. The copy of the state is
+// discarded whatever the outcome, such that the extrinsic is never committed.
+// An error wrapping ErrInvalidExtrinsic is returned for a malformed extrinsic.
+func (sm *SystemModule) DryRun(_ *http.Request, req *SystemDryRunRequest, res *SystemDryRunResponse) error {
+	ext, err := common.HexToBytes(req.Extrinsic)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: decoding hex: %s", ErrInvalidExtrinsic, err)
 	}
 
-	accountRaw, err := sm.storageAPI.GetStorage(nil, storageKey)
+	err = validateExtrinsic(ext)
 	if err != nil {
 		return err
 	}
-	var accountInfo ctypes.AccountInfo
-	err = codec.Decode(accountRaw, &accountInfo)
+
+	result, events, err := sm.coreAPI.DryRunExtrinsic(req.Hash, ext)
 	if err != nil {
-		return err
+		return fmt.Errorf("dry running extrinsic: %w", err)
+	}
+
+	metadata, err := sm.metadata(req.Hash)
+	if err != nil {
+		return fmt.Errorf("getting metadata: %w", err)
 	}
 
-	*res = U64Response(accountInfo.Nonce)
+	success, errMessage, err := describeApplyExtrinsicResult(metadata, result)
+	if err != nil {
+		return fmt.Errorf("describing apply extrinsic result: %w", err)
+	}
+
+	dryRunEvents, err := decodeDryRunEvents(metadata, events)
+	if err != nil {
+		return fmt.Errorf("decoding events: %w", err)
+	}
+
+	*res = SystemDryRunResponse{
+		Result:  common.BytesToHex(result),
+		Success: success,
+		Error:   errMessage,
+		Events:  dryRunEvents,
+	}
 	return nil
 }
 
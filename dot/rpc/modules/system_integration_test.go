@@ -14,6 +14,9 @@
 	"time"
 
 	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 	"github.com/golang/mock/gomock"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
@@ -552,3 +555,133 @@ func TestAddReservedPeer(t *testing.T) {
 		require.Error(t, sysModule.RemoveReservedPeer(nil, &StringRequest{String: "    "}, nil))
 	})
 }
+
+func TestSystemModule_DryRun_Transfer(t *testing.T) {
+	state := newTestStateService(t)
+	genesisHash := state.Block.GenesisHash()
+	genesisHeader, err := state.Block.GetHeader(genesisHash)
+	require.NoError(t, err)
+	genesisStateRoot, err := state.Storage.GetStateRootFromBlock(&genesisHash)
+	require.NoError(t, err)
+
+	// The runtime of the westend local genesis stored for the
+	// test blocks is used since it does not need to be downloaded.
+	rt, err := state.Block.GetRuntime(state.Block.BestBlockHash())
+	require.NoError(t, err)
+
+	// The test blocks of the state service have an empty state, so
+	// a block is built on the genesis state to dry run extrinsics at.
+	trieState, err := state.Storage.TrieState(genesisStateRoot)
+	require.NoError(t, err)
+	rt.SetContextStorage(trieState)
+	block := runtime.InitializeRuntimeToTest(t, rt, genesisHeader)
+	block.Header.StateRoot = trieState.MustRoot()
+	err = state.Storage.StoreTrie(trieState, &block.Header)
+	require.NoError(t, err)
+	err = state.Block.AddBlock(block)
+	require.NoError(t, err)
+	blockHash := block.Header.Hash()
+	state.Block.StoreRuntime(blockHash, rt)
+
+	coreService, err := core.NewService(&core.Config{
+		Runtime:              rt,
+		Keystore:             keystore.NewGlobalKeystore(),
+		TransactionState:     state.Transaction,
+		BlockState:           state.Block,
+		StorageState:         state.Storage,
+		Network:              NewMockNetwork(gomock.NewController(t)),
+		CodeSubstitutedState: state.Base,
+	})
+	require.NoError(t, err)
+	sm := NewSystemModule(nil, nil, coreService, state.Storage,
+		state.Transaction, state.Block, nil)
+
+	metadata, err := sm.metadata(&blockHash)
+	require.NoError(t, err)
+	version, err := rt.Version()
+	require.NoError(t, err)
+	bob, err := ctypes.NewMultiAddressFromHexAccountID(
+		"0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48")
+	require.NoError(t, err)
+	aliceAccountKey, err := ctypes.CreateStorageKey(metadata, "System", "Account",
+		signature.TestKeyringPairAlice.PublicKey, nil)
+	require.NoError(t, err)
+
+	newTransfer := func(t *testing.T, amount ctypes.UCompact) string {
+		t.Helper()
+		call, err := ctypes.NewCall(metadata, "Balances.transfer", bob, amount)
+		require.NoError(t, err)
+		extrinsic := ctypes.NewExtrinsic(call)
+		err = extrinsic.Sign(signature.TestKeyringPairAlice, ctypes.SignatureOptions{
+			BlockHash:          ctypes.Hash(genesisHash),
+			Era:                ctypes.ExtrinsicEra{IsImmortalEra: true},
+			GenesisHash:        ctypes.Hash(genesisHash),
+			Nonce:              ctypes.NewUCompactFromUInt(0),
+			SpecVersion:        ctypes.U32(version.SpecVersion),
+			Tip:                ctypes.NewUCompactFromUInt(0),
+			TransactionVersion: ctypes.U32(version.TransactionVersion),
+		})
+		require.NoError(t, err)
+		encoded, err := codec.EncodeToHex(extrinsic)
+		require.NoError(t, err)
+		return encoded
+	}
+
+	testCases := map[string]struct {
+		amount      ctypes.UCompact
+		result      string
+		success     bool
+		errMessage  string
+		eventsNames []string
+	}{
+		"successful_transfer": {
+			amount:  ctypes.NewUCompactFromUInt(1000000),
+			result:  "0x0000",
+			success: true,
+			eventsNames: []string{"Balances.Withdraw", "Balances.Transfer", "Balances.Deposit",
+				"TransactionPayment.TransactionFeePaid", "System.ExtrinsicSuccess"},
+		},
+		"insufficient_balance": {
+			amount:     ctypes.NewUCompactFromUInt(^uint64(0)),
+			result:     "0x0001030402000000",
+			errMessage: "dispatch error: Module(Balances.InsufficientBalance)",
+			eventsNames: []string{"Balances.Withdraw", "Balances.Deposit",
+				"TransactionPayment.TransactionFeePaid", "System.ExtrinsicFailed"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			aliceAccountBefore, err := state.Storage.GetStorage(&block.Header.StateRoot, aliceAccountKey)
+			require.NoError(t, err)
+
+			req := &SystemDryRunRequest{
+				Extrinsic: newTransfer(t, testCase.amount),
+				Hash:      &blockHash,
+			}
+			var res SystemDryRunResponse
+			err = sm.DryRun(nil, req, &res)
+			require.NoError(t, err)
+
+			require.Equal(t, testCase.result, res.Result)
+			require.Equal(t, testCase.success, res.Success)
+			require.Equal(t, testCase.errMessage, res.Error)
+			eventsNames := make([]string, len(res.Events))
+			for i, event := range res.Events {
+				// The extrinsic is the first one applied in the child block.
+				require.Equal(t, "ApplyExtrinsic(0)", event.Phase)
+				eventsNames[i] = event.Pallet + "." + event.Name
+			}
+			require.Equal(t, testCase.eventsNames, eventsNames)
+
+			// The state of the block is not modified by the dry run.
+			stateRoot, err := state.Storage.GetStateRootFromBlock(&blockHash)
+			require.NoError(t, err)
+			require.Equal(t, block.Header.StateRoot, *stateRoot)
+			aliceAccountAfter, err := state.Storage.GetStorage(stateRoot, aliceAccountKey)
+			require.NoError(t, err)
+			require.Equal(t, aliceAccountBefore, aliceAccountAfter)
+		})
+	}
+}
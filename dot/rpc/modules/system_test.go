@@ -241,12 +241,23 @@ func TestSystemModule_AccountNextIndex(t *testing.T) {
 		Validity:  new(transaction.Validity),
 	}
 
+	aliceStorageKey := common.MustHexToBytes("0x26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886" +
+		"371da9de1e86a9a8c739864cf3cc5ec2bea59fd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+	accountInfo := func(nonce byte) []byte {
+		encoded := make([]byte, 80)
+		encoded[0] = nonce
+		return encoded
+	}
+
 	mockTxStateAPI := mocks.NewMockTransactionStateAPI(ctrl)
-	mockTxStateAPI.EXPECT().Pending().Return(v).Times(5)
+	mockTxStateAPI.EXPECT().Pending().Return(v).Times(4)
+
+	mockTxStateAPINoPending := mocks.NewMockTransactionStateAPI(ctrl)
+	mockTxStateAPINoPending.EXPECT().Pending().Return(nil)
 
 	mockCoreAPI := mocks.NewMockCoreAPI(ctrl)
 	mockCoreAPI.EXPECT().GetMetadata((*common.Hash)(nil)).
-		Return(common.MustHexToBytes(testdata.NewTestMetadata()), nil).Times(2)
+		Return(common.MustHexToBytes(testdata.NewTestMetadata()), nil).Times(7)
 
 	mockCoreAPIErr := mocks.NewMockCoreAPI(ctrl)
 	mockCoreAPIErr.EXPECT().GetMetadata((*common.Hash)(nil)).
@@ -261,6 +272,21 @@ func TestSystemModule_AccountNextIndex(t *testing.T) {
 		Return(common.MustHexToBytes("0x0300000000000000000000000000000000000000000000000000000000000000000000"+
 			"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"), nil)
 
+	mockStorageAPI.EXPECT().GetStorage((*common.Hash)(nil), aliceStorageKey).
+		Return(accountInfo(3), nil)
+
+	mockStorageAPIStaleNonce := mocks.NewMockStorageAPI(ctrl)
+	mockStorageAPIStaleNonce.EXPECT().GetStorage((*common.Hash)(nil), aliceStorageKey).
+		Return(accountInfo(5), nil)
+
+	mockStorageAPINoAccount := mocks.NewMockStorageAPI(ctrl)
+	mockStorageAPINoAccount.EXPECT().GetStorage((*common.Hash)(nil), aliceStorageKey).
+		Return(nil, nil).Times(2)
+
+	mockStorageAPIMalformed := mocks.NewMockStorageAPI(ctrl)
+	mockStorageAPIMalformed.EXPECT().GetStorage((*common.Hash)(nil), aliceStorageKey).
+		Return([]byte{1}, nil)
+
 	mockStorageAPIErr := mocks.NewMockStorageAPI(ctrl)
 	mockStorageAPIErr.EXPECT().GetStorage((*common.Hash)(nil), storageKeyHex).Return(nil, errors.New("getStorage error"))
 
@@ -289,6 +315,39 @@ type args struct {
 			},
 			exp: U64Response(4),
 		},
+		{
+			name:      "pending_transaction_nonce_already_used",
+			sysModule: NewSystemModule(nil, nil, mockCoreAPI, mockStorageAPIStaleNonce, mockTxStateAPI, nil, nil),
+			args: args{
+				req: &StringRequest{String: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+			},
+			exp: U64Response(5),
+		},
+		{
+			name:      "no_account_with_pending_transaction",
+			sysModule: NewSystemModule(nil, nil, mockCoreAPI, mockStorageAPINoAccount, mockTxStateAPI, nil, nil),
+			args: args{
+				req: &StringRequest{String: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+			},
+			exp: U64Response(1),
+		},
+		{
+			name:      "no_account_and_no_pending_transaction",
+			sysModule: NewSystemModule(nil, nil, mockCoreAPI, mockStorageAPINoAccount, mockTxStateAPINoPending, nil, nil),
+			args: args{
+				req: &StringRequest{String: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+			},
+			exp: U64Response(0),
+		},
+		{
+			name:      "malformed_account_info",
+			sysModule: NewSystemModule(nil, nil, mockCoreAPI, mockStorageAPIMalformed, mockTxStateAPI, nil, nil),
+			args: args{
+				req: &StringRequest{String: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+			},
+			expErr: errors.New("decoding account info: type *types.AccountInfo does not support " +
+				"Decodeable interface and could not be decoded field by field, error: unexpected EOF"),
+		},
 		{
 			name:      "not_found_in_pending_transactions",
 			sysModule: NewSystemModule(nil, nil, mockCoreAPI, mockStorageAPI, mockTxStateAPI, nil, nil),
@@ -337,6 +396,126 @@ type args struct {
 	}
 }
 
+func TestSystemModule_DryRun(t *testing.T) {
+	t.Parallel()
+
+	blockHash := common.Hash{1}
+	extrinsic := common.MustHexToBytes("0x0c040000")
+	errTest := errors.New("test error")
+	metadata := common.MustHexToBytes(testdata.NewTestMetadata())
+
+	testCases := map[string]struct {
+		req         *SystemDryRunRequest
+		coreAPIMock func(ctrl *gomock.Controller) CoreAPI
+		res         SystemDryRunResponse
+		errWrapped  error
+		errMessage  string
+	}{
+		"invalid_hex": {
+			req:         &SystemDryRunRequest{Extrinsic: "0xzz"},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI { return nil },
+			errWrapped:  ErrInvalidExtrinsic,
+			errMessage:  "invalid extrinsic: decoding hex: encoding/hex: invalid byte: U+007A 'z': 0xzz",
+		},
+		"invalid_extrinsic": {
+			req:         &SystemDryRunRequest{Extrinsic: "0x1004"},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI { return nil },
+			errWrapped:  ErrInvalidExtrinsic,
+			errMessage:  "invalid extrinsic: length prefix is 4 bytes but 1 bytes follow it",
+		},
+		"dry_run_error": {
+			req: &SystemDryRunRequest{Extrinsic: "0x0c040000", Hash: &blockHash},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().DryRunExtrinsic(&blockHash, types.Extrinsic(extrinsic)).
+					Return(nil, nil, errTest)
+				return coreAPI
+			},
+			errWrapped: errTest,
+			errMessage: "dry running extrinsic: test error",
+		},
+		"malformed_result": {
+			req: &SystemDryRunRequest{Extrinsic: "0x0c040000"},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().DryRunExtrinsic((*common.Hash)(nil), types.Extrinsic(extrinsic)).
+					Return([]byte{2, 0}, []byte{0}, nil)
+				coreAPI.EXPECT().GetMetadata((*common.Hash)(nil)).Return(metadata, nil)
+				return coreAPI
+			},
+			errWrapped: errApplyExtrinsicResultMalformed,
+			errMessage: "describing apply extrinsic result: " +
+				"apply extrinsic result is malformed: invalid result byte: 2",
+		},
+		"success": {
+			req: &SystemDryRunRequest{Extrinsic: "0x0c040000", Hash: &blockHash},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().DryRunExtrinsic(&blockHash, types.Extrinsic(extrinsic)).
+					Return([]byte{0, 0}, []byte{0}, nil)
+				coreAPI.EXPECT().GetMetadata(&blockHash).Return(metadata, nil)
+				return coreAPI
+			},
+			res: SystemDryRunResponse{
+				Result:  "0x0000",
+				Success: true,
+				Events:  []DryRunEvent{},
+			},
+		},
+		"dispatch_error": {
+			req: &SystemDryRunRequest{Extrinsic: "0x0c040000", Hash: &blockHash},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().DryRunExtrinsic(&blockHash, types.Extrinsic(extrinsic)).
+					Return([]byte{0, 1, 7, 0}, nil, nil)
+				coreAPI.EXPECT().GetMetadata(&blockHash).Return(metadata, nil)
+				return coreAPI
+			},
+			res: SystemDryRunResponse{
+				Result: "0x00010700",
+				Error:  "dispatch error: Token(NoFunds)",
+				Events: []DryRunEvent{},
+			},
+		},
+		"transaction_validity_error": {
+			req: &SystemDryRunRequest{Extrinsic: "0x0c040000", Hash: &blockHash},
+			coreAPIMock: func(ctrl *gomock.Controller) CoreAPI {
+				coreAPI := mocks.NewMockCoreAPI(ctrl)
+				coreAPI.EXPECT().DryRunExtrinsic(&blockHash, types.Extrinsic(extrinsic)).
+					Return([]byte{1, 0, 3}, nil, nil)
+				coreAPI.EXPECT().GetMetadata(&blockHash).Return(metadata, nil)
+				return coreAPI
+			},
+			res: SystemDryRunResponse{
+				Result: "0x010003",
+				Error:  "transaction validity error: outdated transaction",
+				Events: []DryRunEvent{},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			sm := &SystemModule{
+				coreAPI: testCase.coreAPIMock(ctrl),
+			}
+
+			var res SystemDryRunResponse
+			err := sm.DryRun(nil, testCase.req, &res)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.res, res)
+		})
+	}
+}
+
 func TestSystemModule_SyncState(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -19,6 +19,8 @@ type StorageAPI interface {
 
 // BlockAPI is the interface for the block state
 type BlockAPI interface {
+	GetHeader(hash common.Hash) (*types.Header, error)
+	GetHighestFinalisedHash() (common.Hash, error)
 	GetJustification(hash common.Hash) ([]byte, error)
 	GetImportedBlockNotifierChannel() chan *types.Block
 	FreeImportedBlockNotifierChannel(ch chan *types.Block)
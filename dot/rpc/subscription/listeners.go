@@ -171,6 +171,9 @@ type BlockFinalizedListener struct {
 	done          chan struct{}
 	cancel        chan struct{}
 	cancelTimeout time.Duration
+	// lastFinalised is the last finalised header sent, used to also send the
+	// headers finalised in between when multiple blocks are finalised at once.
+	lastFinalised *types.Header
 }
 
 // Listen implementation of Listen interface to listen for importedChan changes
@@ -193,20 +196,64 @@ func (l *BlockFinalizedListener) Listen() {
 				if info == nil {
 					continue
 				}
-				head, err := modules.HeaderToJSON(info.Header)
+
+				headers, err := l.finalisedHeaders(&info.Header)
 				if err != nil {
-					logger.Errorf("failed to convert header to JSON: %s", err)
+					logger.Errorf("failed to get headers finalised before block %s: %s", info.Header.Hash(), err)
+					headers = []*types.Header{&info.Header}
+				}
+
+				for _, header := range headers {
+					l.sendHeader(header)
 				}
-				res := newSubcriptionBaseResponseJSON()
-				res.Method = chainFinalizedHeadMethod
-				res.Params.Result = head
-				res.Params.SubscriptionID = l.subID
-				l.wsconn.safeSend(res)
 			}
 		}
 	}()
 }
 
+// sendHeader sends the finalised header given to the subscriber.
+func (l *BlockFinalizedListener) sendHeader(header *types.Header) {
+	head, err := modules.HeaderToJSON(*header)
+	if err != nil {
+		logger.Errorf("failed to convert header to JSON: %s", err)
+	}
+	res := newSubcriptionBaseResponseJSON()
+	res.Method = chainFinalizedHeadMethod
+	res.Params.Result = head
+	res.Params.SubscriptionID = l.subID
+	l.wsconn.safeSend(res)
+}
+
+// finalisedHeaders returns the headers finalised up to the given finalised
+// header since the last finalised header sent, in ascending block number order.
+// It returns no header if the finalised header given was already sent, which
+// can happen since finalisation notifications can be received out of order.
+func (l *BlockFinalizedListener) finalisedHeaders(finalised *types.Header) (headers []*types.Header, err error) {
+	lastFinalised := l.lastFinalised
+	l.lastFinalised = finalised
+
+	if lastFinalised == nil {
+		return []*types.Header{finalised}, nil
+	}
+
+	if finalised.Number <= lastFinalised.Number {
+		l.lastFinalised = lastFinalised
+		return nil, nil
+	}
+
+	headers = make([]*types.Header, finalised.Number-lastFinalised.Number)
+	headers[len(headers)-1] = finalised
+	for i := len(headers) - 2; i >= 0; i-- {
+		parentHash := headers[i+1].ParentHash
+		headers[i], err = l.wsconn.BlockAPI.GetHeader(parentHash)
+		if err != nil {
+			return nil, fmt.Errorf("getting header %s: %w", parentHash, err)
+		}
+	}
+
+	return headers, nil
+}
+
 // Stop to cancel the running goroutines to this listener
 func (l *BlockFinalizedListener) Stop() error {
 	return cancelWithTimeout(l.cancel, l.done, l.cancelTimeout)
@@ -172,6 +172,70 @@ func TestBlockFinalizedListener_Listen(t *testing.T) {
 
 	require.Equal(t, string(expectedResponseBytes)+"\n", string(msg))
 }
+
+func TestBlockFinalizedListener_Listen_multipleBlocksFinalised(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	wsconn, ws, cancel := setupWSConn(t)
+	defer cancel()
+
+	headers := make([]*types.Header, 7)
+	headers[0] = types.NewEmptyHeader()
+	for i := 1; i < len(headers); i++ {
+		headers[i] = &types.Header{
+			ParentHash: headers[i-1].Hash(),
+			Number:     uint(i),
+		}
+	}
+
+	BlockAPI := mocks.NewMockBlockAPI(ctrl)
+	for _, header := range headers[2:4] {
+		BlockAPI.EXPECT().GetHeader(header.Hash()).Return(header, nil)
+	}
+	BlockAPI.EXPECT().FreeFinalisedNotifierChannel(gomock.Any())
+	wsconn.BlockAPI = BlockAPI
+
+	notifyChan := make(chan *types.FinalisationInfo)
+	bfl := BlockFinalizedListener{
+		channel:       notifyChan,
+		wsconn:        wsconn,
+		subID:         1,
+		cancel:        make(chan struct{}),
+		done:          make(chan struct{}),
+		cancelTimeout: time.Second * 5,
+		lastFinalised: headers[1],
+	}
+
+	bfl.Listen()
+	defer func() {
+		require.NoError(t, bfl.Stop())
+	}()
+
+	// blocks 2 to 4 are finalised at once, then the notification for
+	// block 3 is received late, and block 5 is finalised.
+	for _, header := range []*types.Header{headers[4], headers[3], headers[5]} {
+		notifyChan <- &types.FinalisationInfo{
+			Header: *header,
+		}
+	}
+
+	for _, header := range headers[2:6] {
+		_, msg, err := ws.ReadMessage()
+		require.NoError(t, err)
+
+		head, err := modules.HeaderToJSON(*header)
+		require.NoError(t, err)
+		expectedResponse := newSubcriptionBaseResponseJSON()
+		expectedResponse.Method = chainFinalizedHeadMethod
+		expectedResponse.Params.Result = head
+		expectedResponse.Params.SubscriptionID = 1
+
+		expectedResponseBytes, err := json.Marshal(expectedResponse)
+		require.NoError(t, err)
+		require.Equal(t, string(expectedResponseBytes)+"\n", string(msg))
+	}
+}
+
 func TestExtrinsicSubmitListener_Listen(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
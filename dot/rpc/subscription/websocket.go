@@ -244,6 +244,17 @@ func (c *WSConn) initBlockFinalizedListener(reqID float64, _ interface{}) (Liste
 		return nil, fmt.Errorf("error BlockAPI not set")
 	}
 
+	// The highest finalised header is read before registering the notifier
+	// channel, such that the headers finalised in between are sent with the
+	// first header finalised notified, instead of being dropped.
+	finalisedHash, err := c.BlockAPI.GetHighestFinalisedHash()
+	if err == nil {
+		blockFinalizedListener.lastFinalised, err = c.BlockAPI.GetHeader(finalisedHash)
+	}
+	if err != nil {
+		logger.Warnf("failed to get highest finalised header: %s", err)
+	}
+
 	blockFinalizedListener.channel = c.BlockAPI.GetFinalisedNotifierChannel()
 
 	c.mu.Lock()
@@ -256,6 +267,11 @@ func (c *WSConn) initBlockFinalizedListener(reqID float64, _ interface{}) (Liste
 	initRes := NewSubscriptionResponseJSON(blockFinalizedListener.subID, reqID)
 	c.safeSend(initRes)
 
+	// the highest finalised header at subscription time is sent first
+	if blockFinalizedListener.lastFinalised != nil {
+		blockFinalizedListener.sendHeader(blockFinalizedListener.lastFinalised)
+	}
+
 	return blockFinalizedListener, nil
 }
 
@@ -201,7 +201,12 @@ func TestWSConn_HandleConn(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","error":{"code":null,"message":"error BlockAPI not set"},"id":1}`+"\n"), msg)
 
-	wsconn.BlockAPI = modules.NewMockAnyBlockAPI(ctrl)
+	finalisedHeader := &types.Header{Number: 1, Digest: types.NewDigest()}
+	finalisedBlockAPI := mocks.NewMockBlockAPI(ctrl)
+	finalisedBlockAPI.EXPECT().GetHighestFinalisedHash().Return(common.Hash{1}, nil)
+	finalisedBlockAPI.EXPECT().GetHeader(common.Hash{1}).Return(finalisedHeader, nil)
+	finalisedBlockAPI.EXPECT().GetFinalisedNotifierChannel().Return(make(chan *types.FinalisationInfo))
+	wsconn.BlockAPI = finalisedBlockAPI
 
 	res, err = wsconn.initBlockFinalizedListener(1, nil)
 	require.NoError(t, err)
@@ -211,6 +216,17 @@ func TestWSConn_HandleConn(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":7,"id":1}`+"\n"), msg)
 
+	// the highest finalised header at subscription time is sent first
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	expectedHead := `{"jsonrpc":"2.0","method":"chain_finalizedHead","params":{"result":{` +
+		`"parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000",` +
+		`"number":"0x01",` +
+		`"stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000",` +
+		`"extrinsicsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000",` +
+		`"digest":{"logs":null}},"subscription":7}}` + "\n"
+	require.Equal(t, expectedHead, string(msg))
+
 	// test initExtrinsicWatch
 	wsconn.CoreAPI = modules.NewMockAnyAPI(ctrl)
 	wsconn.BlockAPI = nil
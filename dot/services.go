@@ -17,9 +17,11 @@
 	"github.com/ChainSafe/gossamer/dot/rpc"
 	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/state/pruner"
 	"github.com/ChainSafe/gossamer/dot/sync"
 	"github.com/ChainSafe/gossamer/dot/system"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/database"
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/internal/metrics"
 	"github.com/ChainSafe/gossamer/internal/pprof"
@@ -33,6 +35,9 @@
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
 	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 )
 
 // BlockProducer to produce blocks
@@ -55,7 +60,7 @@ type rpcServiceSettings struct {
 	syncer        *sync.Service
 }
 
-func newInMemoryDB() (*chaindb.BadgerDB, error) {
+func newInMemoryDB() (*database.BadgerDB, error) {
 	return utils.SetupDatabase("", true)
 }
 
@@ -68,9 +73,16 @@ func (nodeBuilder) createStateService(config *cfg.Config) (*state.Service, error
 		return nil, err
 	}
 	stateConfig := state.Config{
-		Path:     config.BasePath,
-		LogLevel: stateLogLevel,
-		Metrics:  metrics.NewIntervalConfig(config.PrometheusExternal),
+		Path:                       config.BasePath,
+		LogLevel:                   stateLogLevel,
+		Metrics:                    metrics.NewIntervalConfig(config.PrometheusExternal),
+		TrieNodeCacheSize:          uint64(config.State.TrieNodeCacheSize) << 20,
+		HeadersOnly:                config.State.HeadersOnly,
+		StorageMetricsLogThreshold: config.State.StorageMetricsLogThreshold,
+		DatabaseOptions:            config.State.DatabaseOptions(),
+	}
+	if config.Pruning == pruner.Pruned {
+		stateConfig.RetainedBodies = config.State.RetainBodies
 	}
 
 	stateSrvc := state.NewService(stateConfig)
@@ -114,11 +126,16 @@ func (nodeBuilder) createRuntimeStorage(st *state.Service) (*runtime.NodeStorage
 	}, nil
 }
 
+// createRuntime creates the runtime instance for the block header given,
+// using the runtime code given from the state of this block, and stores
+// it in the block state for this block.
 func createRuntime(config *cfg.Config, ns runtime.NodeStorage, st *state.Service,
-	ks *keystore.GlobalKeystore, net *network.Service, code []byte) (
-	rt runtime.Instance, err error) {
+	ks *keystore.GlobalKeystore, net *network.Service, header *types.Header,
+	runtimeCode state.RuntimeCode) (rt runtime.Instance, err error) {
 	logger.Info("creating runtime with interpreter " + config.Core.WasmInterpreter + "...")
 
+	code := runtimeCode.Code
+
 	// check if code substitute is in use, if so replace code
 	codeSubHash := st.Base.LoadCodeSubstitutedBlockHash()
 
@@ -133,12 +150,7 @@ func createRuntime(config *cfg.Config, ns runtime.NodeStorage, st *state.Service
 		code = common.MustHexToBytes(codeString)
 	}
 
-	ts, err := st.Storage.TrieState(nil)
-	if err != nil {
-		return nil, err
-	}
-
-	codeHash, err := st.Storage.LoadCodeHash(nil)
+	ts, err := st.Storage.TrieState(&header.StateRoot)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +168,9 @@ func createRuntime(config *cfg.Config, ns runtime.NodeStorage, st *state.Service
 			NodeStorage: ns,
 			Network:     net,
 			Role:        config.Core.Role,
-			CodeHash:    codeHash,
+			CodeHash:    runtimeCode.CodeHash,
+
+			UntrustedCallTimeout: config.Core.UntrustedCallTimeout,
 		}
 
 		// create runtime executor
@@ -168,7 +182,7 @@ func createRuntime(config *cfg.Config, ns runtime.NodeStorage, st *state.Service
 		return nil, fmt.Errorf("%w: %s", ErrWasmInterpreterName, config.Core.WasmInterpreter)
 	}
 
-	st.Block.StoreRuntime(st.Block.BestBlockHash(), rt)
+	st.Block.StoreRuntime(header.Hash(), rt)
 	return rt, nil
 }
 
@@ -234,6 +248,11 @@ func (nodeBuilder) createBABEServiceWithBuilder(config *cfg.Config, st *state.Se
 		bcfg.Keypair = kps[0].(*sr25519.Keypair)
 	}
 
+	bcfg.MaxBlockWeight, err = maxBlockWeight(st)
+	if err != nil {
+		logger.Warnf("using default maximum block weight %d: %s", babe.DefaultMaxBlockWeight, err)
+	}
+
 	bs, err := newBabeService.NewServiceIFace(bcfg)
 	if err != nil {
 		logger.Errorf("failed to initialise BABE service: %s", err)
@@ -242,6 +261,34 @@ func (nodeBuilder) createBABEServiceWithBuilder(config *cfg.Config, st *state.Se
 	return bs, nil
 }
 
+// maxBlockWeight returns the maximum block weight read
+// from the metadata of the runtime of the best block.
+func maxBlockWeight(st *state.Service) (weight uint64, err error) {
+	rt, err := st.Block.GetRuntime(st.Block.BestBlockHash())
+	if err != nil {
+		return 0, fmt.Errorf("getting runtime: %w", err)
+	}
+
+	encodedMetadata, err := rt.Metadata()
+	if err != nil {
+		return 0, fmt.Errorf("getting runtime metadata: %w", err)
+	}
+
+	var rawMetadata []byte
+	err = scale.Unmarshal(encodedMetadata, &rawMetadata)
+	if err != nil {
+		return 0, fmt.Errorf("decoding runtime metadata: %w", err)
+	}
+
+	metadata := new(ctypes.Metadata)
+	err = codec.Decode(rawMetadata, metadata)
+	if err != nil {
+		return 0, fmt.Errorf("decoding runtime metadata: %w", err)
+	}
+
+	return babe.MaxBlockWeight(metadata)
+}
+
 // Core Service
 
 // createCoreService creates the core service from the provided core configuration
@@ -278,6 +325,7 @@ func (nodeBuilder) createCoreService(config *cfg.Config, ks *keystore.GlobalKeys
 		CodeSubstitutedState: st.Base,
 		OnBlockImport:        digest.NewBlockImportHandler(st.Epoch),
 		GrandpaState:         st.Grandpa,
+		ValidateTries:        config.Core.ValidateTries,
 	}
 
 	// create new core service
@@ -322,6 +370,8 @@ func (nodeBuilder) createNetworkService(config *cfg.Config, stateSrvc *state.Ser
 		NoMDNS:            config.Network.NoMDNS,
 		MinPeers:          config.Network.MinPeers,
 		MaxPeers:          config.Network.MaxPeers,
+		InPeers:           config.Network.InPeers,
+		OutPeers:          config.Network.OutPeers,
 		PersistentPeers:   config.Network.PersistentPeers,
 		DiscoveryInterval: config.Network.DiscoveryInterval,
 		SlotDuration:      slotDuration,
@@ -331,6 +381,11 @@ func (nodeBuilder) createNetworkService(config *cfg.Config, stateSrvc *state.Ser
 		Metrics:           metrics.NewIntervalConfig(config.PrometheusExternal),
 		NodeKey:           config.Network.NodeKey,
 		ListenAddress:     config.Network.ListenAddress,
+		ListenAddresses:   config.Network.ListenAddresses,
+		ExternalAddresses: config.Network.ExternalAddresses,
+
+		MinBootnodes:        config.Network.MinBootnodes,
+		BootnodeDialTimeout: config.Network.BootnodeDialTimeout,
 	}
 
 	networkSrvc, err := network.NewService(&networkConfig)
@@ -362,7 +417,9 @@ func (nodeBuilder) createRPCService(params rpcServiceSettings) (*rpc.HTTPServer,
 		return nil, fmt.Errorf("failed to load genesis data: %s", err)
 	}
 
-	syncStateSrvc, err := modules.NewStateSync(genesisData, params.state.Storage)
+	// the RPC state queries must never modify the storage state used to import blocks.
+	storageAPI := state.NewReadOnlyStorageState(params.state.Storage)
+	syncStateSrvc, err := modules.NewStateSync(genesisData, storageAPI)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sync state service: %s", err)
 	}
@@ -374,7 +431,7 @@ func (nodeBuilder) createRPCService(params rpcServiceSettings) (*rpc.HTTPServer,
 	rpcConfig := &rpc.HTTPServerConfig{
 		LogLvl:              rpcLogLevel,
 		BlockAPI:            params.state.Block,
-		StorageAPI:          params.state.Storage,
+		StorageAPI:          storageAPI,
 		NetworkAPI:          params.network,
 		CoreAPI:             params.core,
 		NodeStorage:         params.nodeStorage,
@@ -477,26 +534,59 @@ func (nodeBuilder) newSyncService(config *cfg.Config, st *state.Service, fg Bloc
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse sync log level: %w", err)
 	}
+	syncMode := sync.FullSync
+	if config.Core.SyncMode != "" {
+		syncMode, err = sync.ParseMode(config.Core.SyncMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sync mode: %w", err)
+		}
+	}
+
 	syncCfg := &sync.Config{
-		LogLvl:             syncLogLevel,
-		Network:            net,
-		BlockState:         st.Block,
-		StorageState:       st.Storage,
-		TransactionState:   st.Transaction,
-		FinalityGadget:     fg,
-		BabeVerifier:       verifier,
-		BlockImportHandler: cs,
-		MinPeers:           config.Network.MinPeers,
-		MaxPeers:           config.Network.MaxPeers,
-		SlotDuration:       slotDuration,
-		Telemetry:          telemetryMailer,
-		BadBlocks:          genesisData.BadBlocks,
+		LogLvl:                   syncLogLevel,
+		Network:                  net,
+		BlockState:               st.Block,
+		StorageState:             st.Storage,
+		TransactionState:         st.Transaction,
+		FinalityGadget:           fg,
+		BabeVerifier:             verifier,
+		WarpSyncVerifier:         fg,
+		GrandpaState:             st.Grandpa,
+		BlockImportHandler:       cs,
+		BlockImportDigestHandler: digest.NewBlockImportHandler(st.Epoch),
+		MinPeers:                 config.Network.MinPeers,
+		MaxPeers:                 config.Network.MaxPeers,
+		SlotDuration:             slotDuration,
+		Telemetry:                telemetryMailer,
+		BadBlocks:                genesisData.BadBlocks,
+		Mode:                     syncMode,
+		ValidateBlockAnnounces:   config.Core.ValidateBlockAnnounces,
+		MaxBlockResponseBlocks:   config.Network.MaxBlockResponseBlocks,
+		MaxBlockResponseBytes:    config.Network.MaxBlockResponseBytes,
+		MaxInFlightBlockRequests: config.Network.MaxInFlightBlockRequests,
 	}
 
 	blockReqRes := net.GetRequestResponseProtocol(network.SyncID, network.BlockRequestTimeout,
 		network.MaxBlockResponseSize)
 
-	return sync.NewService(syncCfg, blockReqRes)
+	var stateReqRes, warpReqRes network.RequestMaker
+	if syncMode == sync.FastSync || syncMode == sync.WarpSync {
+		stateReqRes = net.GetRequestResponseProtocol(network.StateSyncID, network.StateRequestTimeout,
+			network.MaxStateResponseSize)
+	}
+
+	if syncMode == sync.WarpSync {
+		syncCfg.GenesisHash = st.Block.GenesisHash()
+		syncCfg.GenesisAuthorities, err = st.Grandpa.GetAuthorities(0)
+		if err != nil {
+			return nil, fmt.Errorf("getting genesis grandpa authorities: %w", err)
+		}
+
+		warpReqRes = net.GetRequestResponseProtocol(network.WarpSyncID, network.WarpSyncProofRequestTimeout,
+			network.MaxWarpSyncProofSize)
+	}
+
+	return sync.NewService(syncCfg, blockReqRes, stateReqRes, warpReqRes)
 }
 
 func (nodeBuilder) createDigestHandler(st *state.Service) (*digest.Handler, error) {
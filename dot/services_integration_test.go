@@ -102,6 +102,7 @@ type args struct {
 					gomock.AssignableToTypeOf(&babe.ServiceConfig{})).
 					DoAndReturn(
 						func(cfg *babe.ServiceConfig) (*babe.Service, error) {
+							assert.Equal(t, uint64(1_500_000_000_000), cfg.MaxBlockWeight)
 							return &babe.Service{}, nil
 						})
 			}
@@ -344,10 +345,12 @@ type args struct {
 
 			ctrl := gomock.NewController(t)
 			stateSrvc := newStateService(t, ctrl)
-			code, err := stateSrvc.Storage.LoadCode(nil)
+			header, err := stateSrvc.Block.BestBlockHeader()
+			require.NoError(t, err)
+			runtimeCode, err := stateSrvc.Storage.GetRuntimeCode(&header.StateRoot)
 			require.NoError(t, err)
 
-			got, err := createRuntime(tt.args.config, tt.args.ns, stateSrvc, nil, nil, code)
+			got, err := createRuntime(tt.args.config, tt.args.ns, stateSrvc, nil, nil, header, runtimeCode)
 			assert.ErrorIs(t, err, tt.err)
 			if tt.expectedType == nil {
 				assert.Nil(t, got)
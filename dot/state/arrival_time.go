@@ -0,0 +1,166 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// ErrArrivalTimeNotFound is returned when no arrival time is stored for
+// a block, for example for blocks produced by the node itself or loaded
+// from a snapshot, in which case the slot time can be used instead.
+var ErrArrivalTimeNotFound = errors.New("arrival time not found")
+
+var errArrivalTimeEncodingLength = errors.New("arrival time encoding length is invalid")
+
+// arrivalTimeNumberPrefix + encodedBlockNum -> hashes of the blocks with an arrival
+// time at this block number, for them to be deleted once this number is finalised.
+var arrivalTimeNumberPrefix = []byte("arn")
+
+// arrivalTimeEncodingLength is the length of an encoded arrival
+// time, which is its number of nanoseconds since the Unix epoch.
+const arrivalTimeEncodingLength = 8
+
+// StoreArrivalTime stores the arrival time given for the block hash given
+// in the database. Only the wall clock reading of the time is stored, since
+// its monotonic clock reading is meaningless once the node restarts.
+func StoreArrivalTime(db Putter, hash common.Hash, arrivalTime time.Time) error {
+	encoded := make([]byte, arrivalTimeEncodingLength)
+	binary.LittleEndian.PutUint64(encoded, uint64(arrivalTime.UnixNano()))
+
+	err := db.Put(arrivalTimeKey(hash), encoded)
+	if err != nil {
+		return fmt.Errorf("putting arrival time in database: %w", err)
+	}
+	return nil
+}
+
+// LoadArrivalTime loads the arrival time of the block hash given from
+// the database. It returns an error wrapping ErrArrivalTimeNotFound if
+// no arrival time is stored for this block hash.
+func LoadArrivalTime(db Getter, hash common.Hash) (arrivalTime time.Time, err error) {
+	encoded, err := db.Get(arrivalTimeKey(hash))
+	if err != nil {
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return time.Time{}, fmt.Errorf("%w: for block hash %s", ErrArrivalTimeNotFound, hash)
+		}
+		return time.Time{}, fmt.Errorf("getting arrival time from database: %w", err)
+	}
+
+	if len(encoded) != arrivalTimeEncodingLength {
+		return time.Time{}, fmt.Errorf("%w: %d bytes instead of %d bytes",
+			errArrivalTimeEncodingLength, len(encoded), arrivalTimeEncodingLength)
+	}
+
+	nanoseconds := int64(binary.LittleEndian.Uint64(encoded))
+	return time.Unix(0, nanoseconds), nil
+}
+
+// SetArrivalTime stores the arrival time given for a block received from
+// the network, such that it persists across restarts until the block number
+// is finalised, whether the block is imported or not. It does nothing if an
+// arrival time is already stored for the block, if the block header is already
+// known or if the block number is already finalised, such that the arrival
+// time stored is the time at which the block was first received.
+func (bs *BlockState) SetArrivalTime(hash common.Hash, number uint, arrivalTime time.Time) error {
+	has, err := bs.HasHeader(hash)
+	if err != nil {
+		return fmt.Errorf("checking header exists: %w", err)
+	} else if has {
+		return nil
+	}
+
+	bs.arrivalTimesLock.Lock()
+	defer bs.arrivalTimesLock.Unlock()
+
+	finalisedHeader, err := bs.GetHighestFinalisedHeader()
+	if err != nil {
+		return fmt.Errorf("getting highest finalised header: %w", err)
+	} else if number <= finalisedHeader.Number {
+		return nil
+	}
+
+	has, err = bs.db.Has(arrivalTimeKey(hash))
+	if err != nil {
+		return fmt.Errorf("checking arrival time exists: %w", err)
+	} else if has {
+		return nil
+	}
+
+	hashes, err := bs.loadArrivalTimeHashes(number)
+	if err != nil {
+		return err
+	}
+
+	encodedHashes, err := scale.Marshal(append(hashes, hash))
+	if err != nil {
+		return fmt.Errorf("encoding block hashes with arrival time: %w", err)
+	}
+
+	batch := bs.db.NewBatch()
+	defer batch.Reset()
+
+	err = StoreArrivalTime(batch, hash, arrivalTime)
+	if err != nil {
+		return err
+	}
+
+	err = batch.Put(arrivalTimeNumberKey(number), encodedHashes)
+	if err != nil {
+		return fmt.Errorf("putting block hashes with arrival time: %w", err)
+	}
+
+	return batch.Flush()
+}
+
+// deleteArrivalTimes adds to the batch given the deletion of the arrival
+// times of the blocks at the block number given, which is finalised.
+// The arrivalTimesLock must be held until the batch is written.
+func (bs *BlockState) deleteArrivalTimes(batch Deleter, number uint) (err error) {
+	hashes, err := bs.loadArrivalTimeHashes(number)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		err = batch.Del(arrivalTimeKey(hash))
+		if err != nil {
+			return fmt.Errorf("deleting arrival time: %w", err)
+		}
+	}
+
+	err = batch.Del(arrivalTimeNumberKey(number))
+	if err != nil {
+		return fmt.Errorf("deleting block hashes with arrival time: %w", err)
+	}
+	return nil
+}
+
+// loadArrivalTimeHashes returns the hashes of the blocks
+// with an arrival time at the block number given.
+func (bs *BlockState) loadArrivalTimeHashes(number uint) (hashes []common.Hash, err error) {
+	encodedHashes, err := bs.db.Get(arrivalTimeNumberKey(number))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting block hashes with arrival time: %w", err)
+	}
+
+	err = scale.Unmarshal(encodedHashes, &hashes)
+	if err != nil {
+		return nil, fmt.Errorf("decoding block hashes with arrival time: %w", err)
+	}
+	return hashes, nil
+}
+
+func arrivalTimeNumberKey(number uint) []byte {
+	return append(arrivalTimeNumberPrefix, encodeBlockNumber(uint64(number))...)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StoreArrivalTime_LoadArrivalTime(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	hash := common.Hash{1}
+
+	_, err := LoadArrivalTime(db, hash)
+	assert.ErrorIs(t, err, ErrArrivalTimeNotFound)
+	assert.EqualError(t, err, "arrival time not found: for block hash "+
+		"0x0100000000000000000000000000000000000000000000000000000000000000")
+
+	// time.Now contains a monotonic clock reading which is not stored.
+	arrivalTime := time.Now()
+	err = StoreArrivalTime(db, hash, arrivalTime)
+	require.NoError(t, err)
+
+	loadedArrivalTime, err := LoadArrivalTime(db, hash)
+	require.NoError(t, err)
+	assert.True(t, arrivalTime.Equal(loadedArrivalTime))
+	assert.Equal(t, arrivalTime.Round(0).String(), loadedArrivalTime.String())
+
+	err = db.Put(arrivalTimeKey(hash), []byte{1, 2})
+	require.NoError(t, err)
+	_, err = LoadArrivalTime(db, hash)
+	assert.ErrorIs(t, err, errArrivalTimeEncodingLength)
+	assert.EqualError(t, err, "arrival time encoding length is invalid: 2 bytes instead of 8 bytes")
+}
+
+func Test_BlockState_SetArrivalTime(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	header := &types.Header{
+		ParentHash: genesisHeader.Hash(),
+		Number:     1,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	hash := header.Hash()
+
+	_, err = bs.GetArrivalTime(hash)
+	assert.ErrorIs(t, err, ErrArrivalTimeNotFound)
+
+	// The block is received from the network but not yet imported,
+	// and is received again later.
+	firstArrivalTime := time.Unix(1, 0)
+	err = bs.SetArrivalTime(hash, header.Number, firstArrivalTime)
+	require.NoError(t, err)
+	err = bs.SetArrivalTime(hash, header.Number, time.Unix(2, 0))
+	require.NoError(t, err)
+
+	arrivalTime, err := LoadArrivalTime(bs.db, hash)
+	require.NoError(t, err)
+	assert.Equal(t, firstArrivalTime, arrivalTime)
+
+	// A sibling block is received from the network but never imported.
+	sibling := &types.Header{
+		ParentHash:     genesisHeader.Hash(),
+		Number:         1,
+		ExtrinsicsRoot: common.Hash{1},
+		Digest:         createPrimaryBABEDigest(t),
+	}
+	err = bs.SetArrivalTime(sibling.Hash(), sibling.Number, time.Unix(2, 0))
+	require.NoError(t, err)
+
+	// The block is imported with its arrival time.
+	err = bs.AddBlock(&types.Block{
+		Header: *header,
+		Body:   types.Body{},
+	})
+	require.NoError(t, err)
+	arrivalTime, err = bs.GetArrivalTime(hash)
+	require.NoError(t, err)
+	assert.Equal(t, firstArrivalTime, arrivalTime)
+
+	// The arrival times are no longer needed once the block number is finalised.
+	err = bs.SetFinalisedHash(hash, 1, 0)
+	require.NoError(t, err)
+	for _, hash := range []common.Hash{hash, sibling.Hash()} {
+		_, err = LoadArrivalTime(bs.db, hash)
+		assert.ErrorIs(t, err, ErrArrivalTimeNotFound)
+	}
+	has, err := bs.db.Has(arrivalTimeNumberKey(1))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// The arrival time of a known block is not stored.
+	err = bs.SetArrivalTime(hash, header.Number, time.Unix(3, 0))
+	require.NoError(t, err)
+	_, err = LoadArrivalTime(bs.db, hash)
+	assert.ErrorIs(t, err, ErrArrivalTimeNotFound)
+
+	// The arrival time of a block at a finalised block number is not stored.
+	err = bs.SetArrivalTime(common.Hash{2}, 1, time.Unix(3, 0))
+	require.NoError(t, err)
+	_, err = LoadArrivalTime(bs.db, common.Hash{2})
+	assert.ErrorIs(t, err, ErrArrivalTimeNotFound)
+}
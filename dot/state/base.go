@@ -41,12 +41,16 @@ func (s *BaseState) LoadNodeGlobalName() (string, error) {
 
 // StoreGenesisData stores the given genesis data at the known GenesisDataKey.
 func (s *BaseState) StoreGenesisData(gen *genesis.Data) error {
+	return storeGenesisData(s.db, gen)
+}
+
+func storeGenesisData(db Putter, gen *genesis.Data) error {
 	enc, err := json.Marshal(gen)
 	if err != nil {
 		return fmt.Errorf("cannot scale encode genesis data: %s", err)
 	}
 
-	return s.db.Put(common.GenesisDataKey, enc)
+	return db.Put(common.GenesisDataKey, enc)
 }
 
 // LoadGenesisData retrieves the genesis data stored at the known GenesisDataKey.
@@ -111,18 +115,11 @@ func (s *BaseState) loadSkipToEpoch() (uint64, error) {
 }
 
 func (s *BaseState) storeFirstSlot(slot uint64) error {
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, slot)
-	return s.db.Put(firstSlotKey, buf)
+	return StoreFirstSlot(s.db, slot)
 }
 
 func (s *BaseState) loadFirstSlot() (uint64, error) {
-	data, err := s.db.Get(firstSlotKey)
-	if err != nil {
-		return 0, err
-	}
-
-	return binary.LittleEndian.Uint64(data), nil
+	return LoadFirstSlot(s.db)
 }
 
 func (s *BaseState) storeEpochLength(l uint64) error {
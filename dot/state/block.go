@@ -16,7 +16,6 @@
 	"github.com/ChainSafe/gossamer/lib/blocktree"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/runtime"
-	"github.com/ChainSafe/gossamer/pkg/scale"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/exp/slices"
@@ -30,17 +29,28 @@
 )
 
 var (
-	headerPrefix        = []byte("hdr") // headerPrefix + hash -> header
-	blockBodyPrefix     = []byte("blb") // blockBodyPrefix + hash -> body
-	headerHashPrefix    = []byte("hsh") // headerHashPrefix + encodedBlockNum -> hash
-	arrivalTimePrefix   = []byte("arr") // arrivalTimePrefix || hash -> arrivalTime
-	receiptPrefix       = []byte("rcp") // receiptPrefix + hash -> receipt
-	messageQueuePrefix  = []byte("mqp") // messageQueuePrefix + hash -> message queue
-	justificationPrefix = []byte("jcp") // justificationPrefix + hash -> justification
+	headerPrefix           = []byte("hdr") // headerPrefix + hash -> header
+	blockBodyPrefix        = []byte("blb") // blockBodyPrefix + hash -> body
+	headerHashPrefix       = []byte("hsh") // headerHashPrefix + encodedBlockNum -> hash
+	arrivalTimePrefix      = []byte("arr") // arrivalTimePrefix || hash -> arrivalTime
+	receiptPrefix          = []byte("rcp") // receiptPrefix + hash -> receipt
+	messageQueuePrefix     = []byte("mqp") // messageQueuePrefix + hash -> message queue
+	justificationPrefix    = []byte("jcp") // justificationPrefix + hash -> justification
+	unfinalisedBlockPrefix = []byte("ufb") // unfinalisedBlockPrefix + hash -> unfinalised block
+	forkChoiceWeightPrefix = []byte("fcw") // forkChoiceWeightPrefix + hash -> fork choice weight
+	headerOnlyPrefix       = []byte("hob") // headerOnlyPrefix + hash -> empty, for blocks without body
+	blockTreeKey           = []byte("btr") // blockTreeKey -> block tree snapshot
 
 	errNilBlockTree = errors.New("blocktree is nil")
 	errNilBlockBody = errors.New("block body is nil")
 
+	// ErrBodiesNotStored is returned when getting the body of a finalised
+	// block which is not stored since the block state only stores headers.
+	ErrBodiesNotStored = errors.New("block bodies are not stored")
+	// ErrBlockBodyPruned is returned when getting the body of a finalised
+	// block which is pruned since it is too far below the finalised head.
+	ErrBlockBodyPruned = errors.New("block body is pruned")
+
 	syncedBlocksGauge = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "gossamer_network_syncer",
 		Name:      "blocks_synced_total",
@@ -56,10 +66,13 @@ type BlockState struct {
 	dbPath    string
 	db        BlockStateDatabase
 	sync.RWMutex
-	genesisHash       common.Hash
-	lastFinalised     common.Hash
-	unfinalisedBlocks *hashToBlockMap
-	tries             *Tries
+	genesisHash   common.Hash
+	lastFinalised common.Hash
+	// indexedBestBlockHash is the best block hash
+	// the block number index is up to date with.
+	indexedBestBlockHash common.Hash
+	unfinalisedBlocks    *hashToBlockMap
+	tries                *Tries
 
 	// block notifiers
 	imported                       map[chan *types.Block]struct{}
@@ -68,12 +81,38 @@ type BlockState struct {
 	importedLock                   sync.RWMutex
 	runtimeUpdateSubscriptionsLock sync.RWMutex
 	runtimeUpdateSubscriptions     map[uint32]chan<- runtime.Version
+	importedBlockNotifier          *ImportedBlockNotifier
+	reorgNotifier                  *notifier[ReorgEvent]
+	finalisedNotifier              *notifier[*types.Header]
+	hooks                          *blockHooks
+
+	// justifications contains the justifications of unfinalised blocks,
+	// which are written to the database when their block is finalised.
+	justifications map[common.Hash][]byte
+
+	// arrivalTimesLock serialises the updates of the arrival times
+	// of the blocks received from the network with their deletion
+	// on finalisation.
+	arrivalTimesLock sync.Mutex
+
+	// newRuntimeInstance instantiates the runtime code given
+	// on runtime upgrades, and is mocked in tests.
+	newRuntimeInstance func(code []byte, cfg wasmer.Config) (runtime.Instance, error)
+
+	// headersOnly is true if the bodies of blocks are not written to the
+	// database, such that the bodies of unfinalised blocks are only kept
+	// in memory until their block is finalised.
+	headersOnly bool
+	// retainedBodies is the number of bodies of the last finalised blocks
+	// kept in the database, the bodies of the canonical blocks below them
+	// being pruned. It is 0 if block bodies are never pruned.
+	retainedBodies uint
 
 	telemetry Telemetry
 }
 
 // NewBlockState will create a new BlockState backed by the database located at basePath
-func NewBlockState(db *chaindb.BadgerDB, trs *Tries, telemetry Telemetry) (*BlockState, error) {
+func NewBlockState(db chaindb.Database, trs *Tries, telemetry Telemetry) (*BlockState, error) {
 	bs := &BlockState{
 		dbPath:                     db.Path(),
 		baseState:                  NewBaseState(db),
@@ -83,7 +122,13 @@ func NewBlockState(db *chaindb.BadgerDB, trs *Tries, telemetry Telemetry) (*Bloc
 		imported:                   make(map[chan *types.Block]struct{}),
 		finalised:                  make(map[chan *types.FinalisationInfo]struct{}),
 		runtimeUpdateSubscriptions: make(map[uint32]chan<- runtime.Version),
+		importedBlockNotifier:      newImportedBlockNotifier(defaultBufferSize),
+		reorgNotifier:              newReorgNotifier(),
+		finalisedNotifier:          newFinalisedNotifier(),
+		hooks:                      newBlockHooks(),
+		justifications:             make(map[common.Hash][]byte),
 		telemetry:                  telemetry,
+		newRuntimeInstance:         newWasmerInstance,
 	}
 
 	gh, err := bs.db.Get(headerHashKey(0))
@@ -100,12 +145,25 @@ func NewBlockState(db *chaindb.BadgerDB, trs *Tries, telemetry Telemetry) (*Bloc
 	bs.genesisHash = genesisHash
 	bs.lastFinalised = header.Hash()
 	bs.bt = blocktree.NewBlockTreeFromRoot(header)
+
+	// the block number index is reset to the highest finalised block,
+	// and then updated with the unfinalised blocks restored.
+	err = bs.resetBlockNumberIndex(header)
+	if err != nil {
+		return nil, fmt.Errorf("resetting block number index: %w", err)
+	}
+
+	err = bs.loadBlockTree()
+	if err != nil {
+		return nil, fmt.Errorf("loading block tree: %w", err)
+	}
+
 	return bs, nil
 }
 
 // NewBlockStateFromGenesis initialises a BlockState from a genesis header,
 // saving it to the database located at basePath
-func NewBlockStateFromGenesis(db *chaindb.BadgerDB, trs *Tries, header *types.Header,
+func NewBlockStateFromGenesis(db chaindb.Database, trs *Tries, header *types.Header,
 	telemetryMailer Telemetry) (*BlockState, error) {
 	bs := &BlockState{
 		bt:                         blocktree.NewBlockTreeFromRoot(header),
@@ -116,12 +174,23 @@ func NewBlockStateFromGenesis(db *chaindb.BadgerDB, trs *Tries, header *types.He
 		imported:                   make(map[chan *types.Block]struct{}),
 		finalised:                  make(map[chan *types.FinalisationInfo]struct{}),
 		runtimeUpdateSubscriptions: make(map[uint32]chan<- runtime.Version),
+		importedBlockNotifier:      newImportedBlockNotifier(defaultBufferSize),
+		reorgNotifier:              newReorgNotifier(),
+		finalisedNotifier:          newFinalisedNotifier(),
+		hooks:                      newBlockHooks(),
+		justifications:             make(map[common.Hash][]byte),
 		genesisHash:                header.Hash(),
 		lastFinalised:              header.Hash(),
 		telemetry:                  telemetryMailer,
+		newRuntimeInstance:         newWasmerInstance,
+	}
+
+	weight, err := newForkChoiceWeight(bs.db, header, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("creating genesis fork choice weight: %w", err)
 	}
 
-	if err := bs.setArrivalTime(header.Hash(), time.Now()); err != nil {
+	if err := StoreForkChoiceWeight(bs.db, header.Hash(), weight); err != nil {
 		return nil, err
 	}
 
@@ -129,8 +198,17 @@ func NewBlockStateFromGenesis(db *chaindb.BadgerDB, trs *Tries, header *types.He
 		return nil, err
 	}
 
-	if err := bs.db.Put(headerHashKey(uint64(header.Number)), header.Hash().ToBytes()); err != nil {
-		return nil, err
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	if err := stateRootIndex.add(header); err != nil {
+		return nil, fmt.Errorf("adding genesis block to state root index: %w", err)
+	}
+
+	if err := stateRootIndex.put(bs.db); err != nil {
+		return nil, fmt.Errorf("writing state root index: %w", err)
+	}
+
+	if err := bs.resetBlockNumberIndex(header); err != nil {
+		return nil, fmt.Errorf("resetting block number index: %w", err)
 	}
 
 	if err := bs.SetBlockBody(header.Hash(), types.NewBody([]types.Extrinsic{})); err != nil {
@@ -191,12 +269,12 @@ func (bs *BlockState) HasHeader(hash common.Hash) (bool, error) {
 		return true, nil
 	}
 
-	return bs.db.Has(headerKey(hash))
+	return HasHeader(bs.db, hash)
 }
 
 // HasHeaderInDatabase returns true if the database contains a header with the given hash
 func (bs *BlockState) HasHeaderInDatabase(hash common.Hash) (bool, error) {
-	return bs.db.Has(headerKey(hash))
+	return HasHeader(bs.db, hash)
 }
 
 // GetHeader returns a BlockHeader for a given hash
@@ -214,13 +292,7 @@ func (bs *BlockState) GetHeader(hash common.Hash) (header *types.Header, err err
 		return nil, chaindb.ErrKeyNotFound
 	}
 
-	data, err := bs.db.Get(headerKey(hash))
-	if err != nil {
-		return nil, err
-	}
-
-	result := types.NewEmptyHeader()
-	err = scale.Unmarshal(data, result)
+	result, err := LoadHeader(bs.db, hash)
 	if err != nil {
 		return nil, err
 	}
@@ -233,18 +305,14 @@ func (bs *BlockState) GetHeader(hash common.Hash) (header *types.Header, err err
 	return result, nil
 }
 
-// GetHashByNumber returns the block hash on our best chain with the given number
+// GetHashByNumber returns the block hash on our best chain with the given number.
+// It returns an error wrapping ErrNoCanonicalAtHeight if no block with this number
+// is on our best chain, even if fork blocks with this number are known.
 func (bs *BlockState) GetHashByNumber(num uint) (common.Hash, error) {
-	hash, err := bs.bt.GetHashByNumber(num)
-	if err == nil {
-		return hash, nil
-	} else if !errors.Is(err, blocktree.ErrNumLowerThanRoot) {
-		return common.Hash{}, fmt.Errorf("failed to get hash from blocktree: %w", err)
-	}
-
-	// if error is ErrNumLowerThanRoot, number has already been finalised, so check db
 	bh, err := bs.db.Get(headerHashKey(uint64(num)))
-	if err != nil {
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return common.Hash{}, fmt.Errorf("%w: for block number %d", ErrNoCanonicalAtHeight, num)
+	} else if err != nil {
 		return common.Hash{}, fmt.Errorf("cannot get block %d: %w", num, err)
 	}
 
@@ -385,7 +453,12 @@ func (bs *BlockState) GetBlockByHash(hash common.Hash) (*types.Block, error) {
 
 	block := bs.unfinalisedBlocks.getBlock(hash)
 	if block != nil {
-		return block, nil
+		headerOnly, err := bs.isHeaderOnly(hash)
+		if err != nil {
+			return nil, err
+		} else if !headerOnly {
+			return block, nil
+		}
 	}
 
 	header, err := bs.GetHeader(hash)
@@ -403,12 +476,8 @@ func (bs *BlockState) GetBlockByHash(hash common.Hash) (*types.Block, error) {
 
 // SetHeader will set the header into DB
 func (bs *BlockState) SetHeader(header *types.Header) error {
-	bh, err := scale.Marshal(*header)
-	if err != nil {
-		return err
-	}
-
-	return bs.db.Put(headerKey(header.Hash()), bh)
+	_, err := StoreHeader(bs.db, header)
+	return err
 }
 
 // HasBlockBody returns true if the db contains the block body
@@ -416,95 +485,269 @@ func (bs *BlockState) HasBlockBody(hash common.Hash) (bool, error) {
 	bs.RLock()
 	defer bs.RUnlock()
 
+	headerOnly, err := bs.isHeaderOnly(hash)
+	if err != nil {
+		return false, err
+	} else if headerOnly {
+		return false, nil
+	}
+
 	if bs.unfinalisedBlocks.getBlock(hash) != nil {
 		return true, nil
 	}
 
-	return bs.db.Has(blockBodyKey(hash))
+	return HasBlockBody(bs.db, hash)
 }
 
 // GetBlockBody will return Body for a given hash
 func (bs *BlockState) GetBlockBody(hash common.Hash) (body *types.Body, err error) {
+	headerOnly, err := bs.isHeaderOnly(hash)
+	if err != nil {
+		return nil, err
+	} else if headerOnly {
+		return nil, fmt.Errorf("%w: for header only block hash %s", ErrBodiesNotStored, hash)
+	}
+
 	body = bs.unfinalisedBlocks.getBlockBody(hash)
 	if body != nil {
 		return body, nil
 	}
 
-	data, err := bs.db.Get(blockBodyKey(hash))
+	record, err := bs.loadBlockBody(hash)
 	if err != nil {
 		return nil, err
 	}
 
-	return types.NewBodyFromBytes(data)
+	return record.Decode()
+}
+
+// loadBlockBody loads the block body record stored in the database for the
+// given block hash. It returns an error wrapping ErrBodiesNotStored if the
+// block state only stores headers or the block is header only, and no block
+// body is stored for the hash.
+// It returns an error wrapping both ErrBlockBodyPruned and ErrBodiesNotStored
+// if the block body is pruned.
+func (bs *BlockState) loadBlockBody(hash common.Hash) (record BlockBodyRecord, err error) {
+	record, err = LoadBlockBody(bs.db, hash)
+	if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return record, err
+	}
+
+	if bs.headersOnly {
+		return nil, fmt.Errorf("%w: for block hash %s", ErrBodiesNotStored, hash)
+	}
+
+	headerOnly, headerOnlyErr := bs.isHeaderOnly(hash)
+	if headerOnlyErr != nil {
+		return nil, headerOnlyErr
+	} else if headerOnly {
+		return nil, fmt.Errorf("%w: for header only block hash %s", ErrBodiesNotStored, hash)
+	}
+
+	if bs.retainedBodies > 0 {
+		pruned, number, prunedErr := bs.isBodyPruned(hash)
+		if prunedErr != nil {
+			return nil, fmt.Errorf("checking if block body is pruned: %w", prunedErr)
+		} else if pruned {
+			return nil, fmt.Errorf("%w: %w: for block number %d and hash %s",
+				ErrBlockBodyPruned, ErrBodiesNotStored, number, hash)
+		}
+	}
+
+	return record, err
 }
 
-// SetBlockBody will add a block body to the db
+// SetBlockBody will add a block body to the db,
+// unless the block state only stores headers.
 func (bs *BlockState) SetBlockBody(hash common.Hash, body *types.Body) error {
-	encodedBody, err := scale.Marshal(*body)
+	if bs.headersOnly {
+		return nil
+	}
+	return StoreBlockBody(bs.db, hash, body)
+}
+
+// isHeaderOnly returns true if the block with the given hash was
+// added to the block tree without its body.
+func (bs *BlockState) isHeaderOnly(hash common.Hash) (headerOnly bool, err error) {
+	headerOnly, err = bs.db.Has(prefixKey(hash, headerOnlyPrefix))
 	if err != nil {
-		return err
+		return false, fmt.Errorf("checking if block is header only: %w", err)
 	}
+	return headerOnly, nil
+}
 
-	return bs.db.Put(blockBodyKey(hash), encodedBody)
+// HeadersOnly returns true if the block state does not store block bodies.
+func (bs *BlockState) HeadersOnly() bool {
+	return bs.headersOnly
 }
 
-// CompareAndSetBlockData will compare empty fields and set all elements in a block data to db
+// CompareAndSetBlockData will compare empty fields and set all elements in a block data to db.
+// The receipt and the message queue of the block data are stored if they are present, which
+// includes empty ones, and if they are not already stored for the block hash.
 func (bs *BlockState) CompareAndSetBlockData(bd *types.BlockData) error {
-	hasReceipt, _ := bs.HasReceipt(bd.Hash)
-	if bd.Receipt != nil && !hasReceipt {
-		err := bs.SetReceipt(bd.Hash, *bd.Receipt)
+	if bd.Receipt != nil {
+		hasReceipt, err := bs.HasReceipt(bd.Hash)
 		if err != nil {
-			return err
+			return fmt.Errorf("checking receipt exists: %w", err)
+		}
+
+		if !hasReceipt {
+			err = bs.SetReceipt(bd.Hash, *bd.Receipt)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	hasMessageQueue, _ := bs.HasMessageQueue(bd.Hash)
-	if bd.MessageQueue != nil && !hasMessageQueue {
-		err := bs.SetMessageQueue(bd.Hash, *bd.MessageQueue)
+	if bd.MessageQueue != nil {
+		hasMessageQueue, err := bs.HasMessageQueue(bd.Hash)
 		if err != nil {
-			return err
+			return fmt.Errorf("checking message queue exists: %w", err)
+		}
+
+		if !hasMessageQueue {
+			err = bs.SetMessageQueue(bd.Hash, *bd.MessageQueue)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-// AddBlock adds a block to the blocktree and the DB with arrival time as current unix time
+// AddBlock adds a block to the blocktree and the DB with the arrival time recorded
+// when the block was received from the network, or the current time otherwise.
 func (bs *BlockState) AddBlock(block *types.Block) error {
 	bs.Lock()
-	defer bs.Unlock()
-	return bs.AddBlockWithArrivalTime(block, time.Now())
+	defer bs.unlockAndCallHooks()
+
+	arrivalTime, err := LoadArrivalTime(bs.db, block.Header.Hash())
+	if errors.Is(err, ErrArrivalTimeNotFound) {
+		arrivalTime = time.Now()
+	} else if err != nil {
+		return fmt.Errorf("loading arrival time: %w", err)
+	}
+
+	return bs.addBlockWithArrivalTime(block, arrivalTime)
 }
 
 // AddBlockWithArrivalTime adds a block to the blocktree and the DB with the given arrival time
 func (bs *BlockState) AddBlockWithArrivalTime(block *types.Block, arrivalTime time.Time) error {
+	defer bs.hooks.callQueued()
+	return bs.addBlockWithArrivalTime(block, arrivalTime)
+}
+
+func (bs *BlockState) addBlockWithArrivalTime(block *types.Block, arrivalTime time.Time) error {
 	if block.Body == nil {
 		return errNilBlockBody
 	}
 
+	weight, err := newForkChoiceWeight(bs.db, &block.Header, arrivalTime)
+	if err != nil {
+		return fmt.Errorf("creating fork choice weight: %w", err)
+	}
+
 	// add block to blocktree
 	if err := bs.bt.AddBlock(&block.Header, arrivalTime); err != nil {
 		return err
 	}
 
 	bs.unfinalisedBlocks.store(block)
+	blockHash := block.Header.Hash()
+
+	if err := StoreForkChoiceWeight(bs.db, blockHash, weight); err != nil {
+		return err
+	}
+
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	if err := stateRootIndex.add(&block.Header); err != nil {
+		return fmt.Errorf("adding block to state root index: %w", err)
+	}
+
+	if err := stateRootIndex.put(bs.db); err != nil {
+		return fmt.Errorf("writing state root index: %w", err)
+	}
+
+	if err := bs.updateBlockNumberIndex([]*types.Header{&block.Header}, nil); err != nil {
+		return fmt.Errorf("updating block number index: %w", err)
+	}
+
 	go bs.notifyImported(block)
+	bs.importedBlockNotifier.notify(ImportedBlock{
+		Hash:      blockHash,
+		Number:    block.Header.Number,
+		IsNewBest: bs.bt.BestBlockHash() == blockHash,
+	})
+	bs.hooks.queue(func() { bs.hooks.callImported(&block.Header) })
 	return nil
 }
 
 // AddBlockToBlockTree adds the given block to the blocktree. It does not write it to the database.
-// TODO: remove this func and usage from sync (after sync refactor?)
+// TODO: remove this func (after sync refactor?)
 func (bs *BlockState) AddBlockToBlockTree(block *types.Block) error {
 	bs.Lock()
-	defer bs.Unlock()
+	defer bs.unlockAndCallHooks()
 
-	arrivalTime, err := bs.GetArrivalTime(block.Header.Hash())
+	blockHash := block.Header.Hash()
+	arrivalTime, err := bs.GetArrivalTime(blockHash)
 	if err != nil {
 		arrivalTime = time.Now()
 	}
 
+	weight, err := newForkChoiceWeight(bs.db, &block.Header, arrivalTime)
+	if err != nil {
+		return fmt.Errorf("creating fork choice weight: %w", err)
+	}
+
 	bs.unfinalisedBlocks.store(block)
-	return bs.bt.AddBlock(&block.Header, arrivalTime)
+	err = bs.bt.AddBlock(&block.Header, arrivalTime)
+	if err != nil {
+		return err
+	}
+
+	err = StoreForkChoiceWeight(bs.db, blockHash, weight)
+	if err != nil {
+		return err
+	}
+
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	err = stateRootIndex.add(&block.Header)
+	if err != nil {
+		return fmt.Errorf("adding block to state root index: %w", err)
+	}
+
+	err = stateRootIndex.put(bs.db)
+	if err != nil {
+		return fmt.Errorf("writing state root index: %w", err)
+	}
+
+	err = bs.updateBlockNumberIndex([]*types.Header{&block.Header}, nil)
+	if err != nil {
+		return fmt.Errorf("updating block number index: %w", err)
+	}
+
+	return nil
+}
+
+// AddHeaderToBlockTree adds the given header to the blocktree, for a block whose
+// body is not downloaded, as when fast syncing. The block is marked as header only,
+// such that its body is not written to the database when it is finalised, nor
+// served as an empty body.
+func (bs *BlockState) AddHeaderToBlockTree(header *types.Header) error {
+	err := bs.AddBlockToBlockTree(&types.Block{
+		Header: *header,
+		Body:   types.Body{},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = bs.db.Put(prefixKey(header.Hash(), headerOnlyPrefix), []byte{})
+	if err != nil {
+		return fmt.Errorf("marking block as header only: %w", err)
+	}
+	return nil
 }
 
 // GetAllBlocksAtNumber returns all unfinalised blocks with the given number
@@ -617,15 +860,9 @@ func (bs *BlockState) GetSlotForBlock(hash common.Hash) (uint64, error) {
 var ErrEmptyHeader = errors.New("empty header")
 
 func (bs *BlockState) loadHeaderFromDatabase(hash common.Hash) (header *types.Header, err error) {
-	startHeaderData, err := bs.db.Get(headerKey(hash))
+	header, err = LoadHeader(bs.db, hash)
 	if err != nil {
-		return nil, fmt.Errorf("querying database: %w", err)
-	}
-
-	header = types.NewEmptyHeader()
-	err = scale.Unmarshal(startHeaderData, header)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshaling start header: %w", err)
+		return nil, err
 	}
 
 	if header.Empty() {
@@ -804,29 +1041,22 @@ func (bs *BlockState) BlocktreeAsString() string {
 	return bs.bt.String()
 }
 
-// GetArrivalTime returns the arrival time in nanoseconds since the Unix epoch of a block given its hash
+// GetArrivalTime returns the arrival time of a block given its hash, from the
+// block tree or from the database for blocks not yet added to the block tree.
+// It returns an error wrapping ErrArrivalTimeNotFound if no arrival time is known.
 func (bs *BlockState) GetArrivalTime(hash common.Hash) (time.Time, error) {
 	at, err := bs.bt.GetArrivalTime(hash)
 	if err == nil {
 		return at, nil
 	}
 
-	arrivalTime, err := bs.db.Get(arrivalTimeKey(hash))
-	if err != nil {
-		return time.Time{}, err
-	}
-
-	ns := binary.LittleEndian.Uint64(arrivalTime)
-	return time.Unix(0, int64(ns)), nil
-}
-
-func (bs *BlockState) setArrivalTime(hash common.Hash, arrivalTime time.Time) error {
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, uint64(arrivalTime.UnixNano()))
-	return bs.db.Put(arrivalTimeKey(hash), buf)
+	return LoadArrivalTime(bs.db, hash)
 }
 
-// HandleRuntimeChanges handles the update in runtime.
+// HandleRuntimeChanges detects a change of the runtime code in the state of
+// the block given, in which case it stores a runtime instance with the new
+// code for the block, such that its descendants are imported with the new
+// runtime. Blocks on other forks keep using the runtime of their ancestors.
 func (bs *BlockState) HandleRuntimeChanges(newState *rtstorage.TrieState,
 	parentRuntimeInstance runtime.Instance, bHash common.Hash) error {
 	currCodeHash, err := newState.LoadCodeHash()
@@ -875,21 +1105,30 @@ func (bs *BlockState) HandleRuntimeChanges(newState *rtstorage.TrieState,
 			bHash, parentCodeHash, previousVersion.SpecVersion, currCodeHash, newVersion.SpecVersion)
 	}
 
-	rtCfg := wasmer.Config{
-		Storage:     newState,
-		Keystore:    parentRuntimeInstance.Keystore(),
-		NodeStorage: parentRuntimeInstance.NodeStorage(),
-		Network:     parentRuntimeInstance.NetworkService(),
-		CodeHash:    currCodeHash,
-	}
+	// the code may already be instantiated for a runtime upgrade
+	// on another fork, for example if a reorg undid the upgrade
+	// and it is then applied again on the new best chain.
+	instance := bs.bt.GetRuntimeByCodeHash(currCodeHash)
+	if instance != nil {
+		logger.Debugf("reusing runtime instance with code hash %s for block %s", currCodeHash, bHash)
+	} else {
+		rtCfg := wasmer.Config{
+			Storage:              newState,
+			Keystore:             parentRuntimeInstance.Keystore(),
+			NodeStorage:          parentRuntimeInstance.NodeStorage(),
+			Network:              parentRuntimeInstance.NetworkService(),
+			CodeHash:             currCodeHash,
+			UntrustedCallTimeout: parentRuntimeInstance.UntrustedCallTimeout(),
+		}
 
-	if parentRuntimeInstance.Validator() {
-		rtCfg.Role = 4
-	}
+		if parentRuntimeInstance.Validator() {
+			rtCfg.Role = 4
+		}
 
-	instance, err := wasmer.NewInstance(code, rtCfg)
-	if err != nil {
-		return err
+		instance, err = bs.newRuntimeInstance(code, rtCfg)
+		if err != nil {
+			return err
+		}
 	}
 
 	bs.StoreRuntime(bHash, instance)
@@ -899,7 +1138,7 @@ func (bs *BlockState) HandleRuntimeChanges(newState *rtstorage.TrieState,
 		return fmt.Errorf("failed to update code substituted block hash: %w", err)
 	}
 
-	newVersion, err := parentRuntimeInstance.Version()
+	newVersion, err := instance.Version()
 	if err != nil {
 		return err
 	}
@@ -907,6 +1146,14 @@ func (bs *BlockState) HandleRuntimeChanges(newState *rtstorage.TrieState,
 	return nil
 }
 
+func newWasmerInstance(code []byte, cfg wasmer.Config) (runtime.Instance, error) {
+	instance, err := wasmer.NewInstance(code, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
 // GetRuntime gets the runtime instance pointer for the block hash given.
 func (bs *BlockState) GetRuntime(blockHash common.Hash) (instance runtime.Instance, err error) {
 	// we search primarily in the blocktree so we ensure the
@@ -0,0 +1,75 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"github.com/ChainSafe/chaindb"
+)
+
+// blockStateBatch is a database batch for the block state, tracking the
+// keys put and deleted in the batch such that checking if a key exists
+// reflects the batch writes not yet flushed to the database.
+// It is not safe for concurrent use.
+type blockStateBatch struct {
+	batch chaindb.Batch
+	db    Haser
+	// pending maps the keys written in the batch to true
+	// if they are put and to false if they are deleted.
+	pending map[string]bool
+}
+
+func newBlockStateBatch(db BlockStateDatabase) *blockStateBatch {
+	return &blockStateBatch{
+		batch:   db.NewBatch(),
+		db:      db,
+		pending: make(map[string]bool),
+	}
+}
+
+// Put puts the value given at the key given in the batch.
+func (b *blockStateBatch) Put(key, value []byte) error {
+	err := b.batch.Put(key, value)
+	if err != nil {
+		return err
+	}
+	b.pending[string(key)] = true
+	return nil
+}
+
+// Del deletes the key given in the batch.
+func (b *blockStateBatch) Del(key []byte) error {
+	err := b.batch.Del(key)
+	if err != nil {
+		return err
+	}
+	b.pending[string(key)] = false
+	return nil
+}
+
+// Has returns true if the key given is put in the batch,
+// false if it is deleted in the batch, and otherwise
+// whether the key exists in the database.
+func (b *blockStateBatch) Has(key []byte) (has bool, err error) {
+	has, pending := b.pending[string(key)]
+	if pending {
+		return has, nil
+	}
+	return b.db.Has(key)
+}
+
+// Flush writes the batch to the database.
+func (b *blockStateBatch) Flush() error {
+	err := b.batch.Flush()
+	if err != nil {
+		return err
+	}
+	b.pending = make(map[string]bool)
+	return nil
+}
+
+// Reset discards the writes of the batch.
+func (b *blockStateBatch) Reset() {
+	b.batch.Reset()
+	b.pending = make(map[string]bool)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_blockStateBatch(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	storedHeader := newTestHeader(t)
+	storedHash, err := StoreHeader(db, storedHeader)
+	require.NoError(t, err)
+
+	batch := newBlockStateBatch(db)
+	defer batch.Reset()
+
+	pendingHeader := newTestHeader(t)
+	pendingHeader.Number++
+	pendingHash, err := StoreHeader(batch, pendingHeader)
+	require.NoError(t, err)
+	err = StoreBlockBody(batch, pendingHash, types.NewBody([]types.Extrinsic{{1}}))
+	require.NoError(t, err)
+	err = batch.Del(headerKey(storedHash))
+	require.NoError(t, err)
+
+	// The batch reflects its writes not yet flushed.
+	components, err := BlockExists(batch, pendingHash)
+	require.NoError(t, err)
+	assert.Equal(t, HeaderComponent|BodyComponent, components)
+	has, err := HasHeader(batch, storedHash)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// The database does not.
+	components, err = BlockExists(db, pendingHash)
+	require.NoError(t, err)
+	assert.Equal(t, BlockComponents(0), components)
+	has, err = HasHeader(db, storedHash)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	err = batch.Flush()
+	require.NoError(t, err)
+
+	for _, haser := range []Haser{batch, db} {
+		has, err = HasHeader(haser, pendingHash)
+		require.NoError(t, err)
+		assert.True(t, has)
+		has, err = HasHeader(haser, storedHash)
+		require.NoError(t, err)
+		assert.False(t, has)
+	}
+
+	// Writes discarded are no longer reflected.
+	err = StoreJustification(batch, pendingHash, []byte{1})
+	require.NoError(t, err)
+	batch.Reset()
+	has, err = HasJustification(batch, pendingHash)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
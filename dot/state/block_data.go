@@ -14,75 +14,73 @@ func prefixKey(hash common.Hash, prefix []byte) []byte {
 
 // HasReceipt returns if the db contains a receipt at the given hash
 func (bs *BlockState) HasReceipt(hash common.Hash) (bool, error) {
-	return bs.db.Has(prefixKey(hash, receiptPrefix))
+	return HasReceipt(bs.db, hash)
 }
 
 // SetReceipt sets a Receipt in the database
 func (bs *BlockState) SetReceipt(hash common.Hash, data []byte) error {
-	err := bs.db.Put(prefixKey(hash, receiptPrefix), data)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return StoreReceipt(bs.db, hash, data)
 }
 
-// GetReceipt retrieves a Receipt from the database
+// GetReceipt retrieves a Receipt from the database. It returns an error
+// wrapping ErrReceiptNotFound if there is no receipt for the block hash.
 func (bs *BlockState) GetReceipt(hash common.Hash) ([]byte, error) {
-	data, err := bs.db.Get(prefixKey(hash, receiptPrefix))
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return LoadReceipt(bs.db, hash)
 }
 
 // HasMessageQueue returns if the db contains a MessageQueue at the given hash
 func (bs *BlockState) HasMessageQueue(hash common.Hash) (bool, error) {
-	return bs.db.Has(prefixKey(hash, messageQueuePrefix))
+	return HasMessageQueue(bs.db, hash)
 }
 
 // SetMessageQueue sets a MessageQueue in the database
 func (bs *BlockState) SetMessageQueue(hash common.Hash, data []byte) error {
-	err := bs.db.Put(prefixKey(hash, messageQueuePrefix), data)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return StoreMessageQueue(bs.db, hash, data)
 }
 
-// GetMessageQueue retrieves a MessageQueue from the database
+// GetMessageQueue retrieves a MessageQueue from the database. It returns an error
+// wrapping ErrMessageQueueNotFound if there is no message queue for the block hash.
 func (bs *BlockState) GetMessageQueue(hash common.Hash) ([]byte, error) {
-	data, err := bs.db.Get(prefixKey(hash, messageQueuePrefix))
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return LoadMessageQueue(bs.db, hash)
 }
 
 // HasJustification returns if the db contains a Justification at the given hash
 func (bs *BlockState) HasJustification(hash common.Hash) (bool, error) {
-	return bs.db.Has(prefixKey(hash, justificationPrefix))
+	bs.RLock()
+	_, pending := bs.justifications[hash]
+	bs.RUnlock()
+	if pending {
+		return true, nil
+	}
+
+	return HasJustification(bs.db, hash)
 }
 
-// SetJustification sets a Justification in the database
+// SetJustification sets a Justification for the given block hash. The
+// justification of an unfinalised block is kept in memory, and written to
+// the database in the same batch as the block once the block is finalised.
 func (bs *BlockState) SetJustification(hash common.Hash, data []byte) error {
-	err := bs.db.Put(prefixKey(hash, justificationPrefix), data)
-	if err != nil {
-		return err
+	bs.Lock()
+	defer bs.Unlock()
+
+	if bs.unfinalisedBlocks.getBlock(hash) != nil {
+		bs.justifications[hash] = data
+		return nil
 	}
 
-	return nil
+	return StoreJustification(bs.db, hash, data)
 }
 
-// GetJustification retrieves a Justification from the database
+// GetJustification retrieves a Justification for the given block hash.
+// It returns an error wrapping ErrJustificationNotFound if there is no
+// justification for the block hash.
 func (bs *BlockState) GetJustification(hash common.Hash) ([]byte, error) {
-	data, err := bs.db.Get(prefixKey(hash, justificationPrefix))
-	if err != nil {
-		return nil, err
+	bs.RLock()
+	justification, pending := bs.justifications[hash]
+	bs.RUnlock()
+	if pending {
+		return justification, nil
 	}
 
-	return data, nil
+	return LoadJustification(bs.db, hash)
 }
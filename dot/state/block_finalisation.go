@@ -65,6 +65,13 @@ func (bs *BlockState) GetFinalisedHash(round, setID uint64) (common.Hash, error)
 }
 
 func (bs *BlockState) setHighestRoundAndSetID(round, setID uint64) error {
+	return bs.putHighestRoundAndSetID(bs.db, round, setID)
+}
+
+// putHighestRoundAndSetID puts the highest round and set ID in the
+// database writer given, checking the set ID is not lower than the
+// highest set ID stored in the database.
+func (bs *BlockState) putHighestRoundAndSetID(db Putter, round, setID uint64) error {
 	_, highestSetID, err := bs.GetHighestRoundAndSetID()
 	if err != nil {
 		return err
@@ -74,7 +81,7 @@ func (bs *BlockState) setHighestRoundAndSetID(round, setID uint64) error {
 		return fmt.Errorf("%w: %d should be greater or equal %d", errSetIDLowerThanHighest, setID, highestSetID)
 	}
 
-	return bs.db.Put(highestRoundAndSetIDKey, roundAndSetIDToBytes(round, setID))
+	return db.Put(highestRoundAndSetIDKey, roundAndSetIDToBytes(round, setID))
 }
 
 // GetHighestRoundAndSetID gets the highest round and setID that have been finalised
@@ -91,12 +98,7 @@ func (bs *BlockState) GetHighestRoundAndSetID() (uint64, uint64, error) {
 
 // GetHighestFinalisedHash returns the highest finalised block hash
 func (bs *BlockState) GetHighestFinalisedHash() (common.Hash, error) {
-	round, setID, err := bs.GetHighestRoundAndSetID()
-	if err != nil {
-		return common.Hash{}, err
-	}
-
-	return bs.GetFinalisedHash(round, setID)
+	return GetHighestFinalisedHash(bs.db)
 }
 
 // GetHighestFinalisedHeader returns the highest finalised block header
@@ -117,7 +119,7 @@ func (bs *BlockState) GetHighestFinalisedHeader() (*types.Header, error) {
 // SetFinalisedHash sets the latest finalised block hash
 func (bs *BlockState) SetFinalisedHash(hash common.Hash, round, setID uint64) error {
 	bs.Lock()
-	defer bs.Unlock()
+	defer bs.unlockAndCallHooks()
 
 	has, err := bs.HasHeader(hash)
 	if err != nil {
@@ -127,34 +129,104 @@ func (bs *BlockState) SetFinalisedHash(hash common.Hash, round, setID uint64) er
 		return fmt.Errorf("cannot finalise unknown block %s", hash)
 	}
 
-	if err := bs.handleFinalisedBlock(hash); err != nil {
+	err = bs.checkFinalisationAdvances(hash)
+	if err != nil {
+		logger.Warnf("rejecting finalisation of block %s: %s", hash, err)
+		return err
+	}
+
+	// the finalised subchain and the finalisation bookkeeping
+	// are written to the database in a single batch.
+	batch := newBlockStateBatch(bs.db)
+	defer batch.Reset()
+
+	bs.arrivalTimesLock.Lock()
+	defer bs.arrivalTimesLock.Unlock()
+
+	finalisedHashes, err := bs.handleFinalisedBlock(batch, hash)
+	if err != nil {
 		return fmt.Errorf("failed to set finalised subchain in db on finalisation: %w", err)
 	}
 
-	if err := bs.db.Put(finalisedHashKey(round, setID), hash[:]); err != nil {
+	if err := batch.Put(finalisedHashKey(round, setID), hash[:]); err != nil {
 		return fmt.Errorf("failed to set finalised hash key: %w", err)
 	}
 
-	if err := bs.setHighestRoundAndSetID(round, setID); err != nil {
+	if err := bs.putHighestRoundAndSetID(batch, round, setID); err != nil {
 		return fmt.Errorf("failed to set highest round and set ID: %w", err)
 	}
 
+	if err := putHighestFinalisedHash(batch, hash); err != nil {
+		return err
+	}
+
+	if err := batch.Flush(); err != nil {
+		return fmt.Errorf("writing finalisation to database: %w", err)
+	}
+
+	// the headers of the finalised blocks are only kept in memory until
+	// they are cleaned, so they are collected for the finalisation hooks.
+	var finalisedHeaders []*types.Header
+	if bs.hooks.hasFinalisation() {
+		finalisedHeaders = make([]*types.Header, 0, len(finalisedHashes))
+		for _, finalisedHash := range finalisedHashes {
+			finalisedHeaders = append(finalisedHeaders, &bs.unfinalisedBlocks.getBlock(finalisedHash).Header)
+		}
+	}
+
+	bs.cleanFinalisedBlocks(finalisedHashes)
+
 	if round > 0 {
 		bs.notifyFinalized(hash, round, setID)
 	}
 
+	header, err := bs.GetHeader(hash)
+	if err != nil {
+		return fmt.Errorf("failed to get finalised header, hash: %s, error: %s", hash, err)
+	}
+
 	pruned := bs.bt.Prune(hash)
+	// The pruned blocks at or below the finalised block number are kept in the
+	// block number index as non canonical, and deleted by the fork pruning.
+	prunedHeaders := make([]*types.Header, 0, len(pruned))
+	prunedHashes := make([]common.Hash, 0, len(pruned))
+	forkHeaders := make([]*types.Header, 0, len(pruned))
 	for _, hash := range pruned {
 		blockHeader := bs.unfinalisedBlocks.delete(hash)
 		if blockHeader == nil {
 			continue
 		}
+		forkHeaders = append(forkHeaders, blockHeader)
+		if blockHeader.Number > header.Number {
+			prunedHeaders = append(prunedHeaders, blockHeader)
+			prunedHashes = append(prunedHashes, hash)
+		}
+		delete(bs.justifications, hash)
 
 		bs.tries.delete(blockHeader.StateRoot)
 
 		logger.Tracef("pruned block number %d with hash %s", blockHeader.Number, hash)
 	}
 
+	if err := bs.deletePrunedForkBlocks(prunedHashes); err != nil {
+		return fmt.Errorf("deleting data of pruned blocks: %w", err)
+	}
+
+	// pruning may change the best block if it was not a descendant of the finalised block.
+	if err := bs.updateBlockNumberIndex(nil, prunedHeaders); err != nil {
+		return fmt.Errorf("updating block number index: %w", err)
+	}
+
+	if err := bs.pruneForks(header.Number); err != nil {
+		return fmt.Errorf("pruning forks: %w", err)
+	}
+
+	// the block bodies left are pruned on the next finalisation,
+	// so failing to prune them must not fail the finalisation.
+	if err := bs.pruneBodies(header.Number); err != nil {
+		logger.Errorf("pruning block bodies: %s", err)
+	}
+
 	// if nothing was previously finalised, set the first slot of the network to the
 	// slot number of block 1, which is now being set as final
 	if bs.lastFinalised == bs.genesisHash && hash != bs.genesisHash {
@@ -163,11 +235,6 @@ func (bs *BlockState) SetFinalisedHash(hash common.Hash, round, setID uint64) er
 		}
 	}
 
-	header, err := bs.GetHeader(hash)
-	if err != nil {
-		return fmt.Errorf("failed to get finalised header, hash: %s, error: %s", hash, err)
-	}
-
 	bs.telemetry.SendMessage(
 		telemetry.NewNotifyFinalized(
 			header.Hash(),
@@ -185,6 +252,11 @@ func (bs *BlockState) SetFinalisedHash(hash common.Hash, round, setID uint64) er
 	}
 
 	bs.lastFinalised = hash
+	bs.finalisedNotifier.notify(header)
+	bs.hooks.queue(func() {
+		bs.hooks.callFinalised(header)
+		bs.hooks.callFinalisation(finalisedHeaders, forkHeaders)
+	})
 	return nil
 }
 
@@ -202,17 +274,31 @@ func (bs *BlockState) deleteFromTries(lastFinalised common.Hash) error {
 	return nil
 }
 
-func (bs *BlockState) handleFinalisedBlock(curr common.Hash) error {
+// handleFinalisedBlock writes the blocks from the last finalised block
+// excluded to the block given included to the database batch given,
+// together with their justification if there is one, and deletes their
+// arrival time, as well as the fork choice weights of the blocks finalised
+// before the block given. Their bodies are not written if the block state only
+// stores headers, or if the block is header only. It returns the hashes of the blocks written, to remove
+// them from memory once the batch is flushed.
+func (bs *BlockState) handleFinalisedBlock(batch PutDeleter, curr common.Hash) (
+	finalisedHashes []common.Hash, err error) {
 	if curr == bs.lastFinalised {
-		return nil
+		return nil, nil
 	}
 
 	subchain, err := bs.RangeInMemory(bs.lastFinalised, curr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	batch := bs.db.NewBatch()
+	// only the fork choice weight of the block given is kept, since the fork
+	// choice weights of its descendants are computed from it.
+	for _, hash := range subchain[:len(subchain)-1] {
+		if err = batch.Del(prefixKey(hash, forkChoiceWeightPrefix)); err != nil {
+			return nil, err
+		}
+	}
 
 	// root of subchain is previously finalised block, which has already been stored in the db
 	for _, hash := range subchain[1:] {
@@ -222,30 +308,51 @@ func (bs *BlockState) handleFinalisedBlock(curr common.Hash) error {
 
 		block := bs.unfinalisedBlocks.getBlock(hash)
 		if block == nil {
-			return fmt.Errorf("failed to find block in unfinalised block map, block=%s", hash)
+			return nil, fmt.Errorf("failed to find block in unfinalised block map, block=%s", hash)
 		}
 
-		if err = bs.SetHeader(&block.Header); err != nil {
-			return err
+		if _, err = StoreHeader(batch, &block.Header); err != nil {
+			return nil, err
 		}
 
-		if err = bs.SetBlockBody(hash, &block.Body); err != nil {
-			return err
+		headerOnly, err := bs.isHeaderOnly(hash)
+		if err != nil {
+			return nil, err
 		}
 
-		arrivalTime, err := bs.bt.GetArrivalTime(hash)
-		if err != nil {
-			return err
+		if !bs.headersOnly && !headerOnly {
+			if err = StoreBlockBody(batch, hash, &block.Body); err != nil {
+				return nil, err
+			}
+		}
+
+		// the arrival times are only needed until the block number is finalised
+		if err = batch.Del(arrivalTimeKey(hash)); err != nil {
+			return nil, err
 		}
 
-		if err = bs.setArrivalTime(hash, arrivalTime); err != nil {
-			return err
+		if err = bs.deleteArrivalTimes(batch, block.Header.Number); err != nil {
+			return nil, err
 		}
 
-		if err = batch.Put(headerHashKey(uint64(block.Header.Number)), hash.ToBytes()); err != nil {
-			return err
+		justification, ok := bs.justifications[hash]
+		if ok {
+			if err = StoreJustification(batch, hash, justification); err != nil {
+				return nil, err
+			}
 		}
 
+		finalisedHashes = append(finalisedHashes, hash)
+	}
+	return finalisedHashes, nil
+}
+
+// cleanFinalisedBlocks removes the finalised blocks given from memory,
+// once they are written to the database.
+func (bs *BlockState) cleanFinalisedBlocks(finalisedHashes []common.Hash) {
+	for _, hash := range finalisedHashes {
+		delete(bs.justifications, hash)
+
 		// delete from the unfinalisedBlockMap and delete reference to in-memory trie
 		blockHeader := bs.unfinalisedBlocks.delete(hash)
 		if blockHeader == nil {
@@ -256,18 +363,18 @@ func (bs *BlockState) handleFinalisedBlock(curr common.Hash) error {
 
 		logger.Tracef("cleaned out finalised block from memory; block number %d with hash %s", blockHeader.Number, hash)
 	}
-	return batch.Flush()
 }
 
+// setFirstSlotOnFinalisation stores the slot number of block 1 as the first slot.
+// If block 1 is not in the database, the first slot already stored is kept.
 func (bs *BlockState) setFirstSlotOnFinalisation() error {
-	header, err := bs.GetHeaderByNumber(1)
+	slot, ok, err := bs.blockOneSlot()
 	if err != nil {
 		return err
 	}
 
-	slot, err := types.GetSlotFromHeader(header)
-	if err != nil {
-		return err
+	if !ok {
+		return nil
 	}
 
 	return bs.baseState.storeFirstSlot(slot)
@@ -4,12 +4,15 @@
 package state
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/trie"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -156,3 +159,64 @@ func TestSetFinalisedHash_setFirstSlotOnFinalisation(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, firstSlot, res)
 }
+
+func TestBlockState_SetJustification_finalisation(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	chain := addTestChain(t, bs, genesisHeader, 2, common.Hash{0xa}, time.Unix(1, 0))
+	hash := chain[1].Hash()
+	justification := []byte{1, 2, 3}
+
+	_, err = bs.GetJustification(hash)
+	assert.ErrorIs(t, err, ErrJustificationNotFound)
+
+	// The justification of an unfinalised block is only kept in memory.
+	err = bs.SetJustification(hash, justification)
+	require.NoError(t, err)
+
+	has, err := bs.HasJustification(hash)
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = HasJustification(bs.db, hash)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// A failed finalisation writes neither the blocks nor the justification.
+	db := &flushHookDatabase{
+		BlockStateDatabase: bs.db,
+		flushErr:           errors.New("test error"),
+	}
+	bs.db = db
+	err = bs.SetFinalisedHash(hash, 1, 0)
+	assert.EqualError(t, err, "writing finalisation to database: test error")
+
+	has, err = HasHeader(db.BlockStateDatabase, hash)
+	require.NoError(t, err)
+	assert.False(t, has)
+	has, err = HasJustification(db.BlockStateDatabase, hash)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// The justification is written with the block once it is finalised.
+	db.flushErr = nil
+	err = bs.SetFinalisedHash(hash, 1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, bs.justifications)
+
+	stored, err := LoadJustification(bs.db, hash)
+	require.NoError(t, err)
+	assert.Equal(t, justification, stored)
+
+	// Setting the same justification again is idempotent.
+	err = bs.SetJustification(hash, justification)
+	require.NoError(t, err)
+	stored, err = bs.GetJustification(hash)
+	require.NoError(t, err)
+	assert.Equal(t, justification, stored)
+
+	_, err = bs.GetJustification(chain[0].Hash())
+	assert.ErrorIs(t, err, ErrJustificationNotFound)
+}
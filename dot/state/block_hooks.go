@@ -0,0 +1,235 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// OnBestBlockChange registers a hook called with the header of the new best
+// block each time the best block changes, once the block number index of the
+// new best chain is written to the database.
+//
+// Hooks are called synchronously, in registration order, once the block
+// state is unlocked after the update, so they may call methods of the block
+// state. They should return quickly, since the hooks of the next updates are
+// called after them. A hook panicking is recovered and logged, and does not
+// prevent the other hooks from being called.
+// The header given is shared between hooks and must not be modified.
+func (bs *BlockState) OnBestBlockChange(hook func(header *types.Header)) {
+	bs.hooks.addBestBlock(hook)
+}
+
+// OnFinalized registers a hook called with the header of each finalised
+// block, after the block is marked as final, that is once its finalisation
+// is written to the database and it is the last finalised block. Since
+// finalisation may prune the best block, the best block hooks for this
+// change are called before the finalised hooks.
+//
+// Hooks are called synchronously, in registration order, once the block
+// state is unlocked after the update, so they may call methods of the block
+// state. They should return quickly, since the hooks of the next updates are
+// called after them. A hook panicking is recovered and logged, and does not
+// prevent the other hooks from being called.
+// The header given is shared between hooks and must not be modified.
+func (bs *BlockState) OnFinalized(hook func(header *types.Header)) {
+	bs.hooks.addFinalised(hook)
+}
+
+// onFinalisation registers a hook called on each finalisation with the
+// headers of the blocks newly finalised, in ascending number order, and the
+// headers of the blocks pruned from forks by the finalisation. It is used
+// by the storage state to prune the state of these blocks, and is called
+// after the finalised hooks in the same way.
+func (bs *BlockState) onFinalisation(hook func(finalised, pruned []*types.Header)) {
+	bs.hooks.addFinalisation(hook)
+}
+
+// onImport registers a hook called with the header of each block imported,
+// once the block is written to the database. It is used by the storage
+// state to notify the storage changes of the block, and is called in
+// the same way as the best block hooks.
+func (bs *BlockState) onImport(hook func(header *types.Header)) {
+	bs.hooks.addImported(hook)
+}
+
+// setIndexedBestBlockHash sets the best block hash the block number index
+// is up to date with, once the index is written to the database, and queues
+// the call of the best block hooks if the best block changed.
+func (bs *BlockState) setIndexedBestBlockHash(bestBlockHash common.Hash) {
+	changed := bestBlockHash != bs.indexedBestBlockHash
+	bs.indexedBestBlockHash = bestBlockHash
+	if !changed || !bs.hooks.hasBestBlock() {
+		return
+	}
+
+	header, err := bs.GetHeader(bestBlockHash)
+	if err != nil {
+		logger.Errorf("failed to get best block header for hash %s: %s", bestBlockHash, err)
+		return
+	}
+	bs.hooks.queue(func() { bs.hooks.callBestBlock(header) })
+}
+
+// unlockAndCallHooks unlocks the block state and calls the hooks
+// queued while it was locked, such that hooks can call its methods.
+func (bs *BlockState) unlockAndCallHooks() {
+	bs.Unlock()
+	bs.hooks.callQueued()
+}
+
+// blockHooks contains the hooks registered by the users
+// of the block state to be called on chain progress.
+type blockHooks struct {
+	mutex        sync.RWMutex
+	bestBlock    []func(header *types.Header)
+	imported     []func(header *types.Header)
+	finalised    []func(header *types.Header)
+	finalisation []func(finalised, pruned []*types.Header)
+
+	// queued contains the hook calls queued while the block state
+	// is locked, in the order of the updates they are called for.
+	queued      []func()
+	queuedMutex sync.Mutex
+	// callingMutex is held while the queued hook calls are
+	// made, such that they are made in the order queued.
+	callingMutex sync.Mutex
+}
+
+func newBlockHooks() *blockHooks {
+	return &blockHooks{}
+}
+
+func (h *blockHooks) addBestBlock(hook func(header *types.Header)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.bestBlock = append(h.bestBlock, hook)
+}
+
+func (h *blockHooks) addImported(hook func(header *types.Header)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.imported = append(h.imported, hook)
+}
+
+func (h *blockHooks) addFinalised(hook func(header *types.Header)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.finalised = append(h.finalised, hook)
+}
+
+func (h *blockHooks) addFinalisation(hook func(finalised, pruned []*types.Header)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.finalisation = append(h.finalisation, hook)
+}
+
+func (h *blockHooks) hasBestBlock() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.bestBlock) > 0
+}
+
+func (h *blockHooks) callBestBlock(header *types.Header) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, hook := range h.bestBlock {
+		callHook("best block", hook, header)
+	}
+}
+
+func (h *blockHooks) callImported(header *types.Header) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, hook := range h.imported {
+		callHook("imported", hook, header)
+	}
+}
+
+func (h *blockHooks) callFinalised(header *types.Header) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, hook := range h.finalised {
+		callHook("finalised", hook, header)
+	}
+}
+
+func (h *blockHooks) hasFinalisation() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.finalisation) > 0
+}
+
+func (h *blockHooks) callFinalisation(finalised, pruned []*types.Header) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, hook := range h.finalisation {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("finalisation hook panicked: %v", r)
+				}
+			}()
+			hook(finalised, pruned)
+		}()
+	}
+}
+
+// queue queues the hook call given, to be made by callQueued
+// once the block state is unlocked.
+func (h *blockHooks) queue(call func()) {
+	h.queuedMutex.Lock()
+	defer h.queuedMutex.Unlock()
+	h.queued = append(h.queued, call)
+}
+
+func (h *blockHooks) takeQueued() (calls []func()) {
+	h.queuedMutex.Lock()
+	defer h.queuedMutex.Unlock()
+	calls = h.queued
+	h.queued = nil
+	return calls
+}
+
+func (h *blockHooks) hasQueued() bool {
+	h.queuedMutex.Lock()
+	defer h.queuedMutex.Unlock()
+	return len(h.queued) > 0
+}
+
+// callQueued makes the hook calls queued, in the order they were queued.
+// If the calls are already being made, by another goroutine or further up
+// the call stack by a hook updating the block state, it returns and the
+// calls it queued are made by the caller making the calls.
+func (h *blockHooks) callQueued() {
+	for h.callingMutex.TryLock() {
+		for calls := h.takeQueued(); len(calls) > 0; calls = h.takeQueued() {
+			for _, call := range calls {
+				call()
+			}
+		}
+		h.callingMutex.Unlock()
+
+		// calls queued before the mutex is unlocked
+		// may not have been made by their caller.
+		if !h.hasQueued() {
+			return
+		}
+	}
+}
+
+// callHook calls the hook given with the header given,
+// recovering and logging any panic of the hook.
+func callHook(name string, hook func(header *types.Header), header *types.Header) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("%s hook panicked for block number %d with hash %s: %v",
+				name, header.Number, header.Hash(), r)
+		}
+	}()
+	hook(header)
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockState_OnBestBlockChange(t *testing.T) {
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	var bestBlockHashes []common.Hash
+	bs.OnBestBlockChange(func(header *types.Header) {
+		// the hook is called once the best block is committed.
+		assert.Equal(t, header.Hash(), bs.indexedBestBlockHash)
+		bestBlockHashes = append(bestBlockHashes, header.Hash())
+	})
+
+	chain, _ := AddBlocksToState(t, bs, 3, false)
+
+	expectedHashes := make([]common.Hash, len(chain))
+	for i, header := range chain {
+		expectedHashes[i] = header.Hash()
+	}
+	assert.Equal(t, expectedHashes, bestBlockHashes)
+}
+
+func TestBlockState_OnFinalized(t *testing.T) {
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	var finalisedHashes []common.Hash
+	bs.OnFinalized(func(header *types.Header) {
+		// the hook is called once the block is marked as final.
+		assert.Equal(t, header.Hash(), bs.lastFinalised)
+		finalisedHashes = append(finalisedHashes, header.Hash())
+	})
+
+	chain, _ := AddBlocksToState(t, bs, 3, false)
+
+	for _, header := range chain[1:] {
+		err := bs.SetFinalisedHash(header.Hash(), 1, 0)
+		require.NoError(t, err)
+	}
+
+	expectedHashes := []common.Hash{chain[1].Hash(), chain[2].Hash()}
+	assert.Equal(t, expectedHashes, finalisedHashes)
+}
+
+func TestBlockState_hookPanicIsolated(t *testing.T) {
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	panicking := func(*types.Header) { panic("embedder bug") }
+	bs.OnBestBlockChange(panicking)
+	bs.OnFinalized(panicking)
+
+	var bestBlockCalls, finalisedCalls int
+	bs.OnBestBlockChange(func(*types.Header) { bestBlockCalls++ })
+	bs.OnFinalized(func(*types.Header) { finalisedCalls++ })
+
+	chain, _ := AddBlocksToState(t, bs, 2, false)
+	assert.Equal(t, 2, bestBlockCalls)
+	assert.Equal(t, chain[1].Hash(), bs.BestBlockHash())
+
+	err := bs.SetFinalisedHash(chain[1].Hash(), 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, finalisedCalls)
+
+	finalisedHash, err := bs.GetHighestFinalisedHash()
+	require.NoError(t, err)
+	assert.Equal(t, chain[1].Hash(), finalisedHash)
+}
+
+func TestBlockState_hookCallsBlockState(t *testing.T) {
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	var bestBlockHashes, finalisedHashes []common.Hash
+	bs.OnBestBlockChange(func(*types.Header) {
+		// the hook is called once the block state is unlocked.
+		bestBlockHashes = append(bestBlockHashes, bs.BestBlockHash())
+	})
+	bs.OnFinalized(func(*types.Header) {
+		finalisedHash, err := bs.GetHighestFinalisedHash()
+		require.NoError(t, err)
+		finalisedHashes = append(finalisedHashes, finalisedHash)
+	})
+
+	chain, _ := AddBlocksToState(t, bs, 2, false)
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: chain[1].Hash(),
+			Number:     3,
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: types.Body{},
+	}
+	err := bs.AddBlock(block)
+	require.NoError(t, err)
+
+	err = bs.SetFinalisedHash(chain[1].Hash(), 1, 0)
+	require.NoError(t, err)
+
+	expectedBestBlockHashes := []common.Hash{chain[0].Hash(), chain[1].Hash(), block.Header.Hash()}
+	assert.Equal(t, expectedBestBlockHashes, bestBlockHashes)
+	assert.Equal(t, []common.Hash{chain[1].Hash()}, finalisedHashes)
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// ImportBlocks adds the blocks given to the blocktree and the database, in the
+// order given such that a block can be the child of a block preceding it, for
+// example for the blocks of a block response received when syncing.
+// The fork choice weights, the block number index changes and the best block
+// hash of all the blocks are written to the database in a single batch,
+// instead of committing them for each block as AddBlock does.
+// All the blocks are checked before adding any of them, such that if one
+// block cannot be added, no block is added, and readers never observe only
+// some of the blocks added. If the database batch cannot be written, the
+// blocks are removed from the blocktree and from the unfinalised blocks.
+func (bs *BlockState) ImportBlocks(blocks []*types.Block) (err error) {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	bs.Lock()
+	defer bs.unlockAndCallHooks()
+
+	arrivalTime := time.Now()
+	batch := bs.db.NewBatch()
+	defer batch.Reset()
+
+	headers := make([]*types.Header, len(blocks))
+	// weights contains the fork choice weights of the blocks
+	// given, since they are not yet in the database.
+	weights := make(map[common.Hash]ForkChoiceWeight, len(blocks))
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	for i, block := range blocks {
+		header := &block.Header
+		hash := header.Hash()
+		if block.Body == nil {
+			return fmt.Errorf("%w: for block hash %s", errNilBlockBody, hash)
+		}
+
+		var weight ForkChoiceWeight
+		parentWeight, ok := weights[header.ParentHash]
+		if ok {
+			weight, err = childForkChoiceWeight(parentWeight, header, arrivalTime)
+		} else {
+			weight, err = newForkChoiceWeight(bs.db, header, arrivalTime)
+		}
+		if err != nil {
+			return fmt.Errorf("creating fork choice weight for block hash %s: %w", hash, err)
+		}
+		weights[hash] = weight
+
+		err = StoreForkChoiceWeight(batch, hash, weight)
+		if err != nil {
+			return err
+		}
+
+		err = stateRootIndex.add(header)
+		if err != nil {
+			return fmt.Errorf("adding block hash %s to state root index: %w", hash, err)
+		}
+
+		headers[i] = header
+	}
+
+	err = stateRootIndex.put(batch)
+	if err != nil {
+		return fmt.Errorf("writing state root index: %w", err)
+	}
+
+	// The blocks are stored in memory before their headers are added to the
+	// blocktree, such that their headers can be read once they are observed.
+	stored := make([]common.Hash, 0, len(blocks))
+	for _, block := range blocks {
+		hash := block.Header.Hash()
+		if bs.unfinalisedBlocks.getBlock(hash) != nil {
+			continue
+		}
+		bs.unfinalisedBlocks.store(block)
+		stored = append(stored, hash)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, hash := range stored {
+			bs.unfinalisedBlocks.delete(hash)
+		}
+	}()
+
+	err = bs.bt.AddBlocks(headers, arrivalTime)
+	if err != nil {
+		return fmt.Errorf("adding blocks to blocktree: %w", err)
+	}
+
+	// The blocks are removed from the blocktree if they cannot be written
+	// to the database, such that the blocktree and the database stay
+	// consistent, and the blocks can be imported again.
+	defer func() {
+		if err == nil {
+			return
+		}
+		hashes := make([]common.Hash, len(headers))
+		for i, header := range headers {
+			hashes[i] = header.Hash()
+		}
+		removeErr := bs.bt.RemoveBlocks(hashes)
+		if removeErr != nil {
+			logger.Errorf("failed to remove blocks from blocktree: %s", removeErr)
+		}
+	}()
+
+	bestBlockHash, reorg, err := bs.putBlockNumberIndex(batch, headers, nil)
+	if err != nil {
+		return fmt.Errorf("updating block number index: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("writing blocks to database: %w", err)
+	}
+	bs.setIndexedBestBlockHash(bestBlockHash)
+	bs.reorgNotifier.notify(reorg)
+
+	for _, block := range blocks {
+		go bs.notifyImported(block)
+		blockHash := block.Header.Hash()
+		bs.importedBlockNotifier.notify(ImportedBlock{
+			Hash:      blockHash,
+			Number:    block.Header.Number,
+			IsNewBest: bestBlockHash == blockHash,
+		})
+		header := &block.Header
+		bs.hooks.queue(func() { bs.hooks.callImported(header) })
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBlocks returns a chain of blocks with the given length
+// descending from the parent header given. The extrinsics root
+// given differentiates the blocks from other chains.
+func newTestBlocks(t testing.TB, parent *types.Header, length int,
+	extrinsicsRoot common.Hash) (blocks []*types.Block) {
+	t.Helper()
+
+	digest := createPrimaryBABEDigest(t)
+	blocks = make([]*types.Block, length)
+	for i := range blocks {
+		blocks[i] = &types.Block{
+			Header: types.Header{
+				ParentHash:     parent.Hash(),
+				Number:         parent.Number + 1,
+				ExtrinsicsRoot: extrinsicsRoot,
+				Digest:         digest,
+			},
+			Body: types.Body{},
+		}
+		parent = &blocks[i].Header
+	}
+	return blocks
+}
+
+func Test_BlockState_ImportBlocks(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	chain := newTestBlocks(t, genesisHeader, 3, common.Hash{1})
+	fork := newTestBlocks(t, &chain[0].Header, 1, common.Hash{2})
+	blocks := append(append([]*types.Block{}, chain...), fork...)
+
+	err = bs.ImportBlocks(blocks)
+	require.NoError(t, err)
+
+	assert.Equal(t, chain[2].Header.Hash(), bs.BestBlockHash())
+	for i, block := range chain {
+		hash := block.Header.Hash()
+		canonicalHash, err := bs.GetHashByNumber(block.Header.Number)
+		require.NoError(t, err)
+		assert.Equal(t, hash, canonicalHash)
+
+		body, err := bs.GetBlockBody(hash)
+		require.NoError(t, err)
+		assert.Equal(t, &block.Body, body)
+
+		weight, err := LoadForkChoiceWeight(bs.db, hash)
+		require.NoError(t, err)
+		assert.Equal(t, uint(i+1), weight.PrimaryCount)
+	}
+
+	forkHash := fork[0].Header.Hash()
+	nonCanonicalHashes, err := bs.GetNonCanonicalHashesByNumber(2)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{forkHash}, nonCanonicalHashes)
+	weight, err := LoadForkChoiceWeight(bs.db, forkHash)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), weight.PrimaryCount)
+
+	assert.Equal(t, bs.BestBlockHash(), bs.indexedBestBlockHash)
+
+	// The blocks following the best block are imported with a
+	// child of a block imported in the previous batch as well.
+	next := newTestBlocks(t, &chain[2].Header, 2, common.Hash{1})
+	forkChild := newTestBlocks(t, &fork[0].Header, 1, common.Hash{2})
+	err = bs.ImportBlocks(append(next, forkChild...))
+	require.NoError(t, err)
+	assert.Equal(t, next[1].Header.Hash(), bs.BestBlockHash())
+	weight, err = LoadForkChoiceWeight(bs.db, next[1].Header.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), weight.PrimaryCount)
+}
+
+func Test_BlockState_ImportBlocks_rollback(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+	chain := newTestBlocks(t, genesisHeader, 3, common.Hash{1})
+
+	testCases := map[string]struct {
+		invalidBlock *types.Block
+		errWrapped   error
+	}{
+		"unknown_parent": {
+			invalidBlock: newTestBlocks(t, &types.Header{Number: 3}, 1, common.Hash{3})[0],
+			errWrapped:   blocktree.ErrParentNotFound,
+		},
+		"already_in_batch": {
+			invalidBlock: chain[1],
+			errWrapped:   blocktree.ErrBlockExists,
+		},
+		"nil_body": {
+			invalidBlock: &types.Block{Header: *types.NewEmptyHeader()},
+			errWrapped:   errNilBlockBody,
+		},
+	}
+
+	for name, testCase := range testCases {
+		blocks := append(append([]*types.Block{}, chain...), testCase.invalidBlock)
+
+		err := bs.ImportBlocks(blocks)
+
+		assert.ErrorIsf(t, err, testCase.errWrapped, "test case %s", name)
+		assert.Equalf(t, genesisHeader.Hash(), bs.BestBlockHash(), "test case %s", name)
+		for _, block := range chain {
+			hash := block.Header.Hash()
+			has, err := bs.HasHeader(hash)
+			require.NoError(t, err)
+			assert.Falsef(t, has, "test case %s", name)
+			_, err = LoadForkChoiceWeight(bs.db, hash)
+			assert.ErrorIsf(t, err, chaindb.ErrKeyNotFound, "test case %s", name)
+			_, err = bs.GetHashByNumber(block.Header.Number)
+			assert.Errorf(t, err, "test case %s", name)
+		}
+	}
+}
+
+func Benchmark_BlockState_ImportBlocks(b *testing.B) {
+	const blocksCount = 10000
+	const batchSize = 128
+
+	// newBenchmarkBlockState creates a block state using an on disk
+	// database, since the benefit of batching is fewer disk writes.
+	newBenchmarkBlockState := func(b *testing.B) *BlockState {
+		db, err := utils.SetupDatabase(b.TempDir(), false)
+		require.NoError(b, err)
+		b.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		telemetryMock := NewMockTelemetry(gomock.NewController(b))
+		telemetryMock.EXPECT().SendMessage(gomock.Any()).AnyTimes()
+		genesisHeader := &types.Header{
+			StateRoot: testGenesisHeader.StateRoot,
+			Digest:    types.NewDigest(),
+		}
+		bs, err := NewBlockStateFromGenesis(db, newTriesEmpty(), genesisHeader, telemetryMock)
+		require.NoError(b, err)
+		return bs
+	}
+
+	genesisHeader := &types.Header{
+		StateRoot: testGenesisHeader.StateRoot,
+		Digest:    types.NewDigest(),
+	}
+	blocks := newTestBlocks(b, genesisHeader, blocksCount, common.Hash{})
+
+	b.Run("AddBlock", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			bs := newBenchmarkBlockState(b)
+			b.StartTimer()
+
+			for _, block := range blocks {
+				err := bs.AddBlock(block)
+				require.NoError(b, err)
+			}
+		}
+	})
+
+	b.Run("ImportBlocks", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			bs := newBenchmarkBlockState(b)
+			b.StartTimer()
+
+			for start := 0; start < len(blocks); start += batchSize {
+				end := start + batchSize
+				if end > len(blocks) {
+					end = len(blocks)
+				}
+				err := bs.ImportBlocks(blocks[start:end])
+				require.NoError(b, err)
+			}
+		}
+	})
+}
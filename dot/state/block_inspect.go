@@ -0,0 +1,115 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// BlockSummary is the summary of a block stored in the database.
+type BlockSummary struct {
+	Hash            common.Hash
+	Header          *types.Header
+	ExtrinsicsCount int
+	Finalised       bool
+}
+
+// NewReadOnlyBlockDatabase returns the block database reading
+// from the badger database given, which can be opened read-only.
+func NewReadOnlyBlockDatabase(db *badger.DB) Getter {
+	return &readOnlyTable{
+		db:     db,
+		prefix: []byte(blockPrefix),
+	}
+}
+
+// readOnlyTable gets values at keys with its prefix from a badger database.
+type readOnlyTable struct {
+	db     *badger.DB
+	prefix []byte
+}
+
+func (r *readOnlyTable) Get(key []byte) (value []byte, err error) {
+	prefixedKey := append(append([]byte(nil), r.prefix...), key...)
+	err = r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(prefixedKey)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, chaindb.ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// InspectBlockByNumber returns the summary of the block with the given
+// number on the canonical chain, using the block database given.
+func InspectBlockByNumber(db Getter, number uint) (summary BlockSummary, err error) {
+	hash, err := db.Get(headerHashKey(uint64(number)))
+	if err != nil {
+		return summary, fmt.Errorf("getting block hash for block number %d: %w", number, err)
+	}
+
+	return InspectBlockByHash(db, common.NewHash(hash))
+}
+
+// InspectBlockByHash returns the summary of the block with the
+// given block hash, using the block database given. The block can
+// be finalised, or unfinalised and stored in the block tree snapshot.
+func InspectBlockByHash(db Getter, hash common.Hash) (summary BlockSummary, err error) {
+	summary.Hash = hash
+
+	var body *types.Body
+	summary.Header, err = LoadHeader(db, hash)
+	switch {
+	case errors.Is(err, chaindb.ErrKeyNotFound):
+		block, err := loadUnfinalisedBlock(db, hash)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return summary, fmt.Errorf("block %s not found in database", hash)
+		} else if err != nil {
+			return summary, fmt.Errorf("loading unfinalised block: %w", err)
+		}
+		summary.Header = &block.Header
+		body = &block.Body
+	case err != nil:
+		return summary, fmt.Errorf("loading header: %w", err)
+	default:
+		record, err := LoadBlockBody(db, hash)
+		if err != nil {
+			return summary, fmt.Errorf("loading block body: %w", err)
+		}
+
+		body, err = record.Decode()
+		if err != nil {
+			return summary, fmt.Errorf("decoding block body: %w", err)
+		}
+	}
+	summary.ExtrinsicsCount = len(*body)
+
+	finalisedHeader, err := GetHighestFinalisedHeader(db)
+	if err != nil {
+		return summary, fmt.Errorf("loading highest finalised header: %w", err)
+	}
+
+	if summary.Header.Number <= finalisedHeader.Number {
+		canonicalHash, err := db.Get(headerHashKey(uint64(summary.Header.Number)))
+		if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+			return summary, fmt.Errorf("getting canonical block hash: %w", err)
+		}
+		summary.Finalised = common.NewHash(canonicalHash) == hash
+	}
+
+	return summary, nil
+}
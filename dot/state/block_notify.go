@@ -25,6 +25,11 @@ func (bs *BlockState) GetImportedBlockNotifierChannel() chan *types.Block {
 	return ch
 }
 
+// ImportedBlockNotifier returns the notifier of imported block events.
+func (bs *BlockState) ImportedBlockNotifier() *ImportedBlockNotifier {
+	return bs.importedBlockNotifier
+}
+
 // GetFinalisedNotifierChannel function to retrieve a finalised block notifier channel
 func (bs *BlockState) GetFinalisedNotifierChannel() chan *types.FinalisationInfo {
 	bs.finalisedLock.Lock()
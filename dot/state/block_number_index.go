@@ -0,0 +1,408 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"golang.org/x/exp/slices"
+)
+
+// ErrNoCanonicalAtHeight is returned when no block with a given
+// number is on the best chain, which is the case for numbers above
+// the best block number even if fork blocks have these numbers.
+var ErrNoCanonicalAtHeight = errors.New("no canonical block at height")
+
+var (
+	// nonCanonicalHashesPrefix + encodedBlockNum -> SCALE encoded hashes
+	nonCanonicalHashesPrefix = []byte("nch")
+)
+
+// nonCanonicalHashesKey = nonCanonicalHashesPrefix + num (uint64 big endian)
+func nonCanonicalHashesKey(number uint64) []byte {
+	return append(nonCanonicalHashesPrefix, encodeBlockNumber(number)...)
+}
+
+// GetNonCanonicalHashesByNumber returns the hashes of the blocks
+// with the given number which are not on our best chain.
+func (bs *BlockState) GetNonCanonicalHashesByNumber(blockNumber uint) (hashes []common.Hash, err error) {
+	index := newBlockNumberIndexBatch(bs.db)
+	return index.nonCanonicalHashes(blockNumber)
+}
+
+// GetAllHashesByNumber returns the hashes of all the known blocks with the given
+// number, starting with the hash of the block on our best chain if there is one,
+// followed by the hashes of the blocks which are not on our best chain.
+func (bs *BlockState) GetAllHashesByNumber(blockNumber uint) (hashes []common.Hash, err error) {
+	index := newBlockNumberIndexBatch(bs.db)
+	canonicalHash, canonical, err := index.canonicalHash(blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getting canonical hash: %w", err)
+	}
+
+	nonCanonicalHashes, err := index.nonCanonicalHashes(blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getting non canonical hashes: %w", err)
+	}
+
+	hashes = make([]common.Hash, 0, 1+len(nonCanonicalHashes))
+	if canonical {
+		hashes = append(hashes, canonicalHash)
+	}
+	for _, hash := range nonCanonicalHashes {
+		// The index may be written to between the two reads above,
+		// so the canonical hash may also be read as non canonical.
+		if canonical && hash == canonicalHash {
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+// updateBlockNumberIndex updates the block number to hash index if the best
+// block of the block tree changed since the last update. The canonical hashes
+// of the reorganised range, the non canonical hashes and the new best block
+// hash are all written in a single database batch, so the index is never
+// left partially updated.
+// The added headers are recorded as non canonical for their block number if
+// they are not on the new best chain, and the pruned headers are removed from
+// the non canonical hashes of their block number.
+func (bs *BlockState) updateBlockNumberIndex(added, pruned []*types.Header) (err error) {
+	batch := bs.db.NewBatch()
+	defer batch.Reset()
+
+	bestBlockHash, reorg, err := bs.putBlockNumberIndex(batch, added, pruned)
+	if err != nil {
+		return err
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("writing block number index: %w", err)
+	}
+
+	bs.setIndexedBestBlockHash(bestBlockHash)
+	bs.reorgNotifier.notify(reorg)
+	return nil
+}
+
+// putBlockNumberIndex writes the block number index changes described in
+// updateBlockNumberIndex to the database batch given, and returns the best
+// block hash the index is up to date with once the batch is flushed, as well
+// as the change of best chain since the last update, which should be sent
+// to the reorg notifier once the batch is flushed.
+func (bs *BlockState) putBlockNumberIndex(batch PutDeleter, added, pruned []*types.Header) (
+	bestBlockHash common.Hash, reorg ReorgEvent, err error) {
+	index := newBlockNumberIndexBatch(bs.db)
+
+	bestBlockHash = bs.bt.BestBlockHash()
+	if bestBlockHash != bs.indexedBestBlockHash {
+		reorg, err = bs.reorganiseBlockNumberIndex(index, bestBlockHash)
+		if err != nil {
+			return bestBlockHash, reorg, fmt.Errorf("reorganising block number index: %w", err)
+		}
+	}
+
+	for _, header := range added {
+		hash := header.Hash()
+		canonicalHash, canonical, err := index.canonicalHash(header.Number)
+		if err != nil {
+			return bestBlockHash, reorg, fmt.Errorf("getting canonical hash: %w", err)
+		} else if canonical && canonicalHash == hash {
+			continue
+		}
+
+		err = index.addNonCanonicalHash(header.Number, hash)
+		if err != nil {
+			return bestBlockHash, reorg, fmt.Errorf("adding non canonical hash: %w", err)
+		}
+	}
+
+	for _, header := range pruned {
+		err = index.removeNonCanonicalHash(header.Number, header.Hash())
+		if err != nil {
+			return bestBlockHash, reorg, fmt.Errorf("removing non canonical hash: %w", err)
+		}
+	}
+
+	err = index.put(batch)
+	if err != nil {
+		return bestBlockHash, reorg, fmt.Errorf("writing block number index: %w", err)
+	}
+
+	return bestBlockHash, reorg, nil
+}
+
+// reorganiseBlockNumberIndex sets the chain ending with the given best block
+// hash as the canonical chain in the index batch given. The previously
+// canonical hashes of the reorganised range are moved to the non canonical
+// hashes of their block number. It returns the change of best chain, where
+// the retracted hashes are the previously canonical hashes replaced.
+func (bs *BlockState) reorganiseBlockNumberIndex(index *blockNumberIndexBatch,
+	bestBlockHash common.Hash) (reorg ReorgEvent, err error) {
+	header, err := bs.GetHeader(bestBlockHash)
+	if err != nil {
+		return reorg, fmt.Errorf("getting best block header: %w", err)
+	}
+
+	// The previous best chain may be longer than the new best chain,
+	// so canonical hashes above the new best block are no longer canonical.
+	var retractedAbove []common.Hash
+	for number := header.Number + 1; ; number++ {
+		canonicalHash, canonical, err := index.canonicalHash(number)
+		if err != nil {
+			return reorg, fmt.Errorf("getting canonical hash: %w", err)
+		} else if !canonical {
+			break
+		}
+
+		index.deleteCanonicalHash(number)
+		err = index.addNonCanonicalHash(number, canonicalHash)
+		if err != nil {
+			return reorg, fmt.Errorf("adding non canonical hash: %w", err)
+		}
+		retractedAbove = append(retractedAbove, canonicalHash)
+	}
+
+	// Retracted hashes are ordered from the previous best block down
+	// and enacted hashes are reversed once the walk down is done.
+	reverseHashes(retractedAbove)
+	reorg.Retracted = retractedAbove
+	defer func() {
+		reverseHashes(reorg.Enacted)
+	}()
+
+	// Walk down the new best chain until reaching
+	// a block already canonical in the index.
+	for {
+		hash := header.Hash()
+		canonicalHash, canonical, err := index.canonicalHash(header.Number)
+		if err != nil {
+			return reorg, fmt.Errorf("getting canonical hash: %w", err)
+		} else if canonical && canonicalHash == hash {
+			reorg.CommonAncestor = hash
+			return reorg, nil
+		} else if canonical {
+			err = index.addNonCanonicalHash(header.Number, canonicalHash)
+			if err != nil {
+				return reorg, fmt.Errorf("adding non canonical hash: %w", err)
+			}
+			reorg.Retracted = append(reorg.Retracted, canonicalHash)
+		}
+		index.setCanonicalHash(header.Number, hash)
+		err = index.removeNonCanonicalHash(header.Number, hash)
+		if err != nil {
+			return reorg, fmt.Errorf("removing non canonical hash: %w", err)
+		}
+		reorg.Enacted = append(reorg.Enacted, hash)
+
+		if header.Number == 0 {
+			return reorg, nil
+		}
+
+		// Check the parent hash against the index before loading
+		// the parent header, which may only be in the database.
+		parentCanonicalHash, parentCanonical, err := index.canonicalHash(header.Number - 1)
+		if err != nil {
+			return reorg, fmt.Errorf("getting canonical hash: %w", err)
+		} else if parentCanonical && parentCanonicalHash == header.ParentHash {
+			reorg.CommonAncestor = header.ParentHash
+			return reorg, nil
+		}
+
+		header, err = bs.GetHeader(header.ParentHash)
+		if err != nil {
+			return reorg, fmt.Errorf("getting parent header: %w", err)
+		}
+	}
+}
+
+// resetBlockNumberIndex sets the block given as the best block of the index,
+// and removes all the canonical and non canonical hashes above it, since the
+// blocks above it are no longer in the block tree.
+func (bs *BlockState) resetBlockNumberIndex(bestBlockHeader *types.Header) (err error) {
+	index := newBlockNumberIndexBatch(bs.db)
+
+	for number := bestBlockHeader.Number + 1; ; number++ {
+		_, canonical, err := index.canonicalHash(number)
+		if err != nil {
+			return fmt.Errorf("getting canonical hash: %w", err)
+		}
+
+		nonCanonicalHashes, err := index.nonCanonicalHashes(number)
+		if err != nil {
+			return fmt.Errorf("getting non canonical hashes: %w", err)
+		}
+
+		if !canonical && len(nonCanonicalHashes) == 0 {
+			break
+		}
+
+		index.deleteCanonicalHash(number)
+		index.nonCanonical[number] = nil
+	}
+
+	bestBlockHash := bestBlockHeader.Hash()
+	index.setCanonicalHash(bestBlockHeader.Number, bestBlockHash)
+	err = index.flush()
+	if err != nil {
+		return fmt.Errorf("writing block number index: %w", err)
+	}
+
+	bs.setIndexedBestBlockHash(bestBlockHash)
+	return nil
+}
+
+// blockNumberIndexBatch accumulates changes to the block number
+// to hash index, such that they can be written to the database
+// in a single batch. Reads return the pending changes if any.
+type blockNumberIndexBatch struct {
+	db GetNewBatcher
+	// canonical maps block numbers to their pending canonical hash,
+	// where a nil hash means the canonical hash is deleted.
+	canonical map[uint]*common.Hash
+	// nonCanonical maps block numbers to their pending
+	// non canonical hashes.
+	nonCanonical map[uint][]common.Hash
+}
+
+func newBlockNumberIndexBatch(db GetNewBatcher) *blockNumberIndexBatch {
+	return &blockNumberIndexBatch{
+		db:           db,
+		canonical:    make(map[uint]*common.Hash),
+		nonCanonical: make(map[uint][]common.Hash),
+	}
+}
+
+func (b *blockNumberIndexBatch) canonicalHash(number uint) (
+	hash common.Hash, ok bool, err error) {
+	pendingHash, pending := b.canonical[number]
+	if pending {
+		if pendingHash == nil {
+			return hash, false, nil
+		}
+		return *pendingHash, true, nil
+	}
+
+	encodedHash, err := b.db.Get(headerHashKey(uint64(number)))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return hash, false, nil
+	} else if err != nil {
+		return hash, false, fmt.Errorf("getting hash for block number %d: %w", number, err)
+	}
+
+	return common.NewHash(encodedHash), true, nil
+}
+
+func (b *blockNumberIndexBatch) setCanonicalHash(number uint, hash common.Hash) {
+	b.canonical[number] = &hash
+}
+
+func (b *blockNumberIndexBatch) deleteCanonicalHash(number uint) {
+	b.canonical[number] = nil
+}
+
+func (b *blockNumberIndexBatch) nonCanonicalHashes(number uint) (
+	hashes []common.Hash, err error) {
+	hashes, pending := b.nonCanonical[number]
+	if pending {
+		return hashes, nil
+	}
+
+	encodedHashes, err := b.db.Get(nonCanonicalHashesKey(uint64(number)))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting non canonical hashes for block number %d: %w", number, err)
+	}
+
+	err = scale.Unmarshal(encodedHashes, &hashes)
+	if err != nil {
+		return nil, fmt.Errorf("decoding non canonical hashes for block number %d: %w", number, err)
+	}
+
+	return hashes, nil
+}
+
+func (b *blockNumberIndexBatch) addNonCanonicalHash(number uint, hash common.Hash) (err error) {
+	hashes, err := b.nonCanonicalHashes(number)
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(hashes, hash) {
+		return nil
+	}
+
+	b.nonCanonical[number] = append(slices.Clone(hashes), hash)
+	return nil
+}
+
+func (b *blockNumberIndexBatch) removeNonCanonicalHash(number uint, hash common.Hash) (err error) {
+	hashes, err := b.nonCanonicalHashes(number)
+	if err != nil {
+		return err
+	}
+
+	i := slices.Index(hashes, hash)
+	if i == -1 {
+		return nil
+	}
+
+	b.nonCanonical[number] = slices.Delete(slices.Clone(hashes), i, i+1)
+	return nil
+}
+
+// flush writes the pending changes to the database in a single batch.
+func (b *blockNumberIndexBatch) flush() (err error) {
+	batch := b.db.NewBatch()
+	err = b.put(batch)
+	if err != nil {
+		batch.Reset()
+		return err
+	}
+	return batch.Flush()
+}
+
+// put writes the pending changes to the database batch given.
+func (b *blockNumberIndexBatch) put(batch PutDeleter) (err error) {
+
+	for number, hash := range b.canonical {
+		key := headerHashKey(uint64(number))
+		if hash == nil {
+			err = batch.Del(key)
+		} else {
+			err = batch.Put(key, hash.ToBytes())
+		}
+		if err != nil {
+			return fmt.Errorf("writing canonical hash for block number %d: %w", number, err)
+		}
+	}
+
+	for number, hashes := range b.nonCanonical {
+		key := nonCanonicalHashesKey(uint64(number))
+		if len(hashes) == 0 {
+			err = batch.Del(key)
+		} else {
+			var encodedHashes []byte
+			encodedHashes, err = scale.Marshal(hashes)
+			if err != nil {
+				return fmt.Errorf("encoding non canonical hashes for block number %d: %w", number, err)
+			}
+			err = batch.Put(key, encodedHashes)
+		}
+		if err != nil {
+			return fmt.Errorf("writing non canonical hashes for block number %d: %w", number, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flushHookDatabase calls its hook before a batch is
+// flushed, and can be set to fail flushing batches.
+type flushHookDatabase struct {
+	BlockStateDatabase
+	beforeFlush func()
+	flushErr    error
+}
+
+func (d *flushHookDatabase) NewBatch() chaindb.Batch {
+	return &flushHookBatch{
+		Batch: d.BlockStateDatabase.NewBatch(),
+		db:    d,
+	}
+}
+
+type flushHookBatch struct {
+	chaindb.Batch
+	db *flushHookDatabase
+}
+
+func (b *flushHookBatch) Flush() error {
+	if b.db.beforeFlush != nil {
+		b.db.beforeFlush()
+	}
+
+	if b.db.flushErr != nil {
+		return b.db.flushErr
+	}
+
+	return b.Batch.Flush()
+}
+
+// addTestChain adds a chain of blocks with the given length on top of the
+// parent header given, where each block has the extrinsics root given to
+// differentiate chains. Each block arrives at the given arrival time.
+func addTestChain(t *testing.T, bs *BlockState, parent *types.Header, length int,
+	extrinsicsRoot common.Hash, arrivalTime time.Time) (headers []*types.Header) {
+	t.Helper()
+
+	for i := 0; i < length; i++ {
+		header := &types.Header{
+			ParentHash:     parent.Hash(),
+			Number:         parent.Number + 1,
+			ExtrinsicsRoot: extrinsicsRoot,
+			Digest:         createPrimaryBABEDigest(t),
+		}
+		block := &types.Block{Header: *header, Body: types.Body{}}
+		err := bs.AddBlockWithArrivalTime(block, arrivalTime)
+		require.NoError(t, err)
+
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func headersToHashes(headers []*types.Header) (hashes []common.Hash) {
+	hashes = make([]common.Hash, len(headers))
+	for i, header := range headers {
+		hashes[i] = header.Hash()
+	}
+	return hashes
+}
+
+// indexedHashes returns the canonical hashes and the non canonical
+// hashes of the index for block numbers 1 to the number given.
+func indexedHashes(t *testing.T, bs *BlockState, maxNumber uint) (
+	canonical, nonCanonical []common.Hash) {
+	t.Helper()
+
+	for number := uint(1); number <= maxNumber; number++ {
+		hash, err := bs.GetHashByNumber(number)
+		if err == nil {
+			canonical = append(canonical, hash)
+		} else {
+			require.ErrorIs(t, err, ErrNoCanonicalAtHeight)
+		}
+
+		hashes, err := bs.GetNonCanonicalHashesByNumber(number)
+		require.NoError(t, err)
+		nonCanonical = append(nonCanonical, hashes...)
+	}
+	return canonical, nonCanonical
+}
+
+func Test_BlockState_blockNumberIndex_reorg(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	arrivalTime := time.Unix(1, 0)
+
+	chainA := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xa}, arrivalTime)
+	// chain B arrives later, so chain A stays the best chain.
+	chainB := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xb}, arrivalTime.Add(time.Second))
+	require.Equal(t, chainA[2].Hash(), bs.BestBlockHash())
+
+	canonical, nonCanonical := indexedHashes(t, bs, 4)
+	assert.Equal(t, headersToHashes(chainA), canonical)
+	assert.Equal(t, headersToHashes(chainB), nonCanonical)
+
+	// Wrap the database to check the index is rewritten in a single
+	// batch, and is not modified until the batch is flushed.
+	db := &flushHookDatabase{BlockStateDatabase: bs.db}
+	bs.db = db
+	flushes := 0
+	db.beforeFlush = func() {
+		flushes++
+		canonical, nonCanonical := indexedHashes(t, bs, 4)
+		assert.Equal(t, headersToHashes(chainA), canonical)
+		assert.Equal(t, headersToHashes(chainB), nonCanonical)
+	}
+
+	// Extending chain B with a fourth block makes chain B the best chain,
+	// which reorganises the 3 blocks of chain A.
+	chainB = append(chainB, addTestChain(t, bs, chainB[2], 1, common.Hash{0xb}, arrivalTime)...)
+	require.Equal(t, chainB[3].Hash(), bs.BestBlockHash())
+	assert.Equal(t, 1, flushes)
+
+	canonical, nonCanonical = indexedHashes(t, bs, 4)
+	assert.Equal(t, headersToHashes(chainB), canonical)
+	assert.Equal(t, headersToHashes(chainA), nonCanonical)
+
+	assert.Equal(t, chainB[3].Hash(), bs.indexedBestBlockHash)
+}
+
+func Test_BlockState_blockNumberIndex_flushError(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	arrivalTime := time.Unix(1, 0)
+
+	chainA := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xa}, arrivalTime)
+	chainB := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xb}, arrivalTime.Add(time.Second))
+
+	db := &flushHookDatabase{
+		BlockStateDatabase: bs.db,
+		flushErr:           errors.New("test error"),
+	}
+	bs.db = db
+
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: chainB[2].Hash(),
+			Number:     4,
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: types.Body{},
+	}
+	err = bs.AddBlockWithArrivalTime(block, arrivalTime)
+	assert.EqualError(t, err, "updating block number index: "+
+		"writing block number index: test error")
+
+	// The index is left untouched by the failed reorg.
+	canonical, nonCanonical := indexedHashes(t, bs, 4)
+	assert.Equal(t, headersToHashes(chainA), canonical)
+	assert.Equal(t, headersToHashes(chainB), nonCanonical)
+
+	// The reorg is written by the next index update.
+	db.flushErr = nil
+	chainB = append(chainB, &block.Header)
+	chainB = append(chainB, addTestChain(t, bs, chainB[3], 1, common.Hash{0xb}, arrivalTime)...)
+
+	canonical, nonCanonical = indexedHashes(t, bs, 5)
+	assert.Equal(t, headersToHashes(chainB), canonical)
+	assert.Equal(t, headersToHashes(chainA), nonCanonical)
+}
+
+func Test_BlockState_blockNumberIndex_finalisation(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	arrivalTime := time.Unix(1, 0)
+
+	chainA := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xa}, arrivalTime)
+	chainB := addTestChain(t, bs, genesisHeader, 2, common.Hash{0xb}, arrivalTime.Add(time.Second))
+	chainC := addTestChain(t, bs, chainA[0], 2, common.Hash{0xc}, arrivalTime.Add(time.Second))
+	require.Equal(t, chainA[2].Hash(), bs.BestBlockHash())
+
+	canonical, nonCanonical := indexedHashes(t, bs, 3)
+	assert.Equal(t, headersToHashes(chainA), canonical)
+	assert.ElementsMatch(t, append(headersToHashes(chainB), headersToHashes(chainC)...), nonCanonical)
+
+	// Finalising the first block of chain A prunes chain B.
+	err = bs.SetFinalisedHash(chainA[0].Hash(), 1, 0)
+	require.NoError(t, err)
+
+	canonical, nonCanonical = indexedHashes(t, bs, 3)
+	assert.Equal(t, headersToHashes(chainA), canonical)
+	assert.Equal(t, headersToHashes(chainC), nonCanonical)
+
+	// Finalising the last block of chain C prunes chain A above
+	// its first block, and makes chain C the best chain.
+	err = bs.SetFinalisedHash(chainC[1].Hash(), 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, chainC[1].Hash(), bs.BestBlockHash())
+
+	canonical, nonCanonical = indexedHashes(t, bs, 3)
+	assert.Equal(t, []common.Hash{chainA[0].Hash(), chainC[0].Hash(), chainC[1].Hash()}, canonical)
+	assert.Empty(t, nonCanonical)
+}
+
+func Test_NewBlockState_resetsBlockNumberIndex(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(gomock.Any()).AnyTimes()
+	db := NewInMemoryDB(t)
+	genesisHeader := testGenesisHeader
+	bs, err := NewBlockStateFromGenesis(db, newTriesEmpty(), genesisHeader, telemetryMock)
+	require.NoError(t, err)
+	arrivalTime := time.Unix(1, 0)
+
+	chainA := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xa}, arrivalTime)
+	addTestChain(t, bs, chainA[0], 3, common.Hash{0xb}, arrivalTime.Add(time.Second))
+	err = bs.SetFinalisedHash(chainA[0].Hash(), 1, 0)
+	require.NoError(t, err)
+
+	// Unfinalised blocks are not persisted, so the index
+	// only contains the finalised chain once reloaded.
+	reloaded, err := NewBlockState(db, newTriesEmpty(), telemetryMock)
+	require.NoError(t, err)
+
+	canonical, nonCanonical := indexedHashes(t, reloaded, 4)
+	assert.Equal(t, []common.Hash{chainA[0].Hash()}, canonical)
+	assert.Empty(t, nonCanonical)
+}
+
+func Test_BlockState_GetHashByNumber_reorgBoundaries(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	arrivalTime := time.Unix(1, 0)
+
+	// Chain A is the best chain, and chains B and S fork from chain A
+	// at block 1. Chain S is made of blocks authored in secondary slots,
+	// so it does not become the best chain although it is the longest.
+	chainA := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xa}, arrivalTime)
+	chainB := addTestChain(t, bs, chainA[0], 2, common.Hash{0xb}, arrivalTime.Add(time.Second))
+	chainS := make([]*types.Header, 3)
+	parent := chainA[0]
+	for i := range chainS {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, uint64(i)).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		digest := types.NewDigest()
+		err = digest.Add(*preRuntimeDigest)
+		require.NoError(t, err)
+		chainS[i] = &types.Header{
+			ParentHash:     parent.Hash(),
+			Number:         parent.Number + 1,
+			ExtrinsicsRoot: common.Hash{0xc},
+			Digest:         digest,
+		}
+		err = bs.AddBlockWithArrivalTime(&types.Block{Header: *chainS[i], Body: types.Body{}}, arrivalTime)
+		require.NoError(t, err)
+		parent = chainS[i]
+	}
+	require.Equal(t, chainA[2].Hash(), bs.BestBlockHash())
+
+	type heightHashes struct {
+		canonical *common.Hash
+		all       []common.Hash
+	}
+	assertHeights := func(t *testing.T, expected []heightHashes) {
+		t.Helper()
+		for i, expectedHashes := range expected {
+			number := uint(i + 1)
+
+			hash, err := bs.GetHashByNumber(number)
+			if expectedHashes.canonical == nil {
+				assert.ErrorIs(t, err, ErrNoCanonicalAtHeight)
+				assert.EqualError(t, err, fmt.Sprintf(
+					"no canonical block at height: for block number %d", number))
+			} else {
+				assert.NoError(t, err)
+				assert.Equalf(t, *expectedHashes.canonical, hash, "block number %d", number)
+			}
+
+			hashes, err := bs.GetAllHashesByNumber(number)
+			require.NoError(t, err)
+			assert.Equalf(t, expectedHashes.all, hashes, "block number %d", number)
+		}
+	}
+	hashOf := func(header *types.Header) *common.Hash {
+		hash := header.Hash()
+		return &hash
+	}
+	hashesOf := func(headers ...*types.Header) []common.Hash {
+		return headersToHashes(headers)
+	}
+
+	// Block 4 only exists on chain S, so it has no canonical block.
+	assertHeights(t, []heightHashes{
+		{canonical: hashOf(chainA[0]), all: hashesOf(chainA[0])},
+		{canonical: hashOf(chainA[1]), all: hashesOf(chainA[1], chainB[0], chainS[0])},
+		{canonical: hashOf(chainA[2]), all: hashesOf(chainA[2], chainB[1], chainS[1])},
+		{all: hashesOf(chainS[2])},
+		{all: []common.Hash{}},
+	})
+
+	// Extending chain B makes it the best chain, so the canonical hashes
+	// move from chain A to chain B above the common ancestor block 1.
+	chainB = append(chainB, addTestChain(t, bs, chainB[1], 1, common.Hash{0xb}, arrivalTime)...)
+	require.Equal(t, chainB[2].Hash(), bs.BestBlockHash())
+
+	assertHeights(t, []heightHashes{
+		{canonical: hashOf(chainA[0]), all: hashesOf(chainA[0])},
+		{canonical: hashOf(chainB[0]), all: hashesOf(chainB[0], chainS[0], chainA[1])},
+		{canonical: hashOf(chainB[1]), all: hashesOf(chainB[1], chainS[1], chainA[2])},
+		{canonical: hashOf(chainB[2]), all: hashesOf(chainB[2], chainS[2])},
+		{all: []common.Hash{}},
+	})
+}
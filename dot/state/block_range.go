@@ -0,0 +1,251 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// ErrBlockRangeStartNotFound is returned when the starting block
+// of a block range is not known, or is above the best block number.
+var ErrBlockRangeStartNotFound = errors.New("block range start not found")
+
+var errInvalidDirection = errors.New("invalid direction")
+
+// blocksInRangeMaxSize is the maximum total size in bytes of the headers,
+// bodies and justifications returned for a block range, which is the maximum
+// size of a network block response. Since the components of a block are not
+// larger than its encoding in a response, callers capping the encoded size
+// of a response below it still get enough blocks to fill the response.
+const blocksInRangeMaxSize = 16 * 1024 * 1024
+
+// Direction is the direction in which a block range is walked.
+type Direction byte
+
+const (
+	// Ascending walks a block range from parent to child.
+	Ascending Direction = iota
+	// Descending walks a block range from child to parent.
+	Descending
+)
+
+// BlockID identifies the starting block of a block range,
+// by its hash if Hash is not nil, or by its number on the
+// canonical chain otherwise.
+type BlockID struct {
+	Hash   *common.Hash
+	Number uint
+}
+
+// RawBlockData contains the SCALE encoded components of a block,
+// such that they can be framed in a network block response without
+// being decoded and re-encoded.
+type RawBlockData struct {
+	Hash   common.Hash
+	Header []byte
+	// Body contains the SCALE encoded extrinsics of the block body,
+	// and is nil if the body is not requested, or if the block state
+	// only stores headers and the body of the block is not stored.
+	Body [][]byte
+	// Justification is nil if the justification is not
+	// requested or if the block has no justification.
+	Justification *[]byte
+}
+
+// size returns the size in bytes of the block components.
+func (r *RawBlockData) size() (size int) {
+	size = len(r.Header)
+	for _, extrinsic := range r.Body {
+		size += len(extrinsic)
+	}
+	if r.Justification != nil {
+		size += len(*r.Justification)
+	}
+	return size
+}
+
+// GetBlocksInRange returns up to max blocks starting from the block given
+// and walking in the direction given. Ascending blocks are walked on the
+// canonical chain up to the best block, and descending blocks are walked
+// using the parent hashes down to the genesis block, such that descending
+// from a fork block returns the fork blocks.
+// Ascending from a block out of the canonical chain only returns this block,
+// and the walk also stops if the canonical chain is reorganised meanwhile.
+// The body and justification of each block are only loaded if requested, and
+// blocks stop being added once their total size reaches the maximum size of
+// a range, although the starting block is always returned.
+// It returns an error wrapping ErrBlockRangeStartNotFound if the starting
+// block is not known or is above the best block number.
+func (bs *BlockState) GetBlocksInRange(from BlockID, direction Direction, max uint32,
+	withBody, withJustification bool) (blocks []RawBlockData, err error) {
+	return bs.getBlocksInRange(from, direction, max, withBody, withJustification, blocksInRangeMaxSize)
+}
+
+func (bs *BlockState) getBlocksInRange(from BlockID, direction Direction, max uint32,
+	withBody, withJustification bool, maxSize int) (blocks []RawBlockData, err error) {
+	if direction != Ascending && direction != Descending {
+		return nil, fmt.Errorf("%w: %d", errInvalidDirection, direction)
+	}
+
+	bestBlockNumber, err := bs.BestBlockNumber()
+	if err != nil {
+		return nil, fmt.Errorf("getting best block number: %w", err)
+	}
+
+	hash, number, err := bs.resolveBlockRangeStart(from, bestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := uint(max)
+	if capacity > bestBlockNumber+1 {
+		capacity = bestBlockNumber + 1
+	}
+	blocks = make([]RawBlockData, 0, capacity)
+	totalSize := 0
+	for len(blocks) < int(max) {
+		block, err := bs.getRawBlockData(hash, withBody, withJustification)
+		if err != nil {
+			return nil, fmt.Errorf("getting block data for block hash %s: %w", hash, err)
+		}
+
+		if len(blocks) > 0 {
+			previousHash := blocks[len(blocks)-1].Hash
+			if direction == Ascending && common.NewHash(block.Header[:common.HashLength]) != previousHash {
+				// The canonical chain no longer descends from the previous block.
+				break
+			}
+
+			totalSize += block.size()
+			if totalSize > maxSize {
+				break
+			}
+		} else {
+			totalSize = block.size()
+		}
+		blocks = append(blocks, block)
+
+		if direction == Descending {
+			if number == 0 {
+				break
+			}
+			hash = common.NewHash(block.Header[:common.HashLength])
+			number--
+			continue
+		}
+
+		if number >= bestBlockNumber {
+			break
+		}
+		number++
+		hash, err = bs.GetHashByNumber(number)
+		if errors.Is(err, ErrNoCanonicalAtHeight) {
+			// The best block was reverted meanwhile.
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("getting canonical block hash: %w", err)
+		}
+	}
+
+	return blocks, nil
+}
+
+// resolveBlockRangeStart returns the hash and number of the starting
+// block of a block range.
+func (bs *BlockState) resolveBlockRangeStart(from BlockID, bestBlockNumber uint) (
+	hash common.Hash, number uint, err error) {
+	if from.Hash == nil {
+		if from.Number > bestBlockNumber {
+			return hash, 0, fmt.Errorf("%w: block number %d is above best block number %d",
+				ErrBlockRangeStartNotFound, from.Number, bestBlockNumber)
+		}
+
+		hash, err = bs.GetHashByNumber(from.Number)
+		if errors.Is(err, ErrNoCanonicalAtHeight) {
+			return hash, 0, fmt.Errorf("%w: for block number %d", ErrBlockRangeStartNotFound, from.Number)
+		} else if err != nil {
+			return hash, 0, fmt.Errorf("getting canonical block hash: %w", err)
+		}
+		return hash, from.Number, nil
+	}
+
+	header, err := bs.GetHeader(*from.Hash)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return hash, 0, fmt.Errorf("%w: for block hash %s", ErrBlockRangeStartNotFound, *from.Hash)
+	} else if err != nil {
+		return hash, 0, fmt.Errorf("getting header: %w", err)
+	}
+
+	return *from.Hash, header.Number, nil
+}
+
+// getRawBlockData returns the encoded components of the block with the
+// given hash, from the unfinalised blocks or from the database where
+// they are stored SCALE encoded already.
+func (bs *BlockState) getRawBlockData(hash common.Hash, withBody, withJustification bool) (
+	block RawBlockData, err error) {
+	block.Hash = hash
+
+	unfinalisedBlock := bs.unfinalisedBlocks.getBlock(hash)
+	if unfinalisedBlock != nil {
+		block.Header, err = scale.Marshal(unfinalisedBlock.Header)
+		if err != nil {
+			return block, fmt.Errorf("encoding header: %w", err)
+		}
+
+		headerOnly, err := bs.isHeaderOnly(hash)
+		if err != nil {
+			return block, err
+		}
+
+		if withBody && !headerOnly {
+			extrinsics, err := unfinalisedBlock.Body.AsEncodedExtrinsics()
+			if err != nil {
+				return block, fmt.Errorf("encoding extrinsics: %w", err)
+			}
+			block.Body = types.ExtrinsicsArrayToBytesArray(extrinsics)
+		}
+	} else {
+		block.Header, err = bs.db.Get(headerKey(hash))
+		if err != nil {
+			return block, fmt.Errorf("getting header from database: %w", err)
+		}
+
+		if withBody {
+			record, err := bs.loadBlockBody(hash)
+			switch {
+			case errors.Is(err, ErrBodiesNotStored):
+				// the block range is still served without the block body.
+			case err != nil:
+				return block, err
+			default:
+				block.Body, err = record.EncodedExtrinsics()
+				if err != nil {
+					return block, err
+				}
+			}
+		}
+	}
+
+	if len(block.Header) < common.HashLength {
+		return block, fmt.Errorf("%w: %d bytes", ErrHeaderRecordMalformed, len(block.Header))
+	}
+
+	if withJustification {
+		justification, err := bs.GetJustification(hash)
+		if err == nil {
+			block.Justification = &justification
+		} else if !errors.Is(err, ErrJustificationNotFound) {
+			return block, fmt.Errorf("getting justification: %w", err)
+		}
+	}
+
+	return block, nil
+}
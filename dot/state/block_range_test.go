@@ -0,0 +1,228 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBlockRangeState returns a block state with a canonical chain of
+// five blocks, of which the first two are finalised, and a fork block
+// descending from the third canonical block. Every block has a body with
+// one extrinsic of the given size. It returns the genesis header, the
+// canonical chain blocks and the fork block.
+func newTestBlockRangeState(t *testing.T, extrinsicSize int) (bs *BlockState,
+	genesisHeader *types.Header, chain []*types.Block, fork *types.Block) {
+	t.Helper()
+
+	bs = newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	chain = newTestBlocks(t, genesisHeader, 5, common.Hash{1})
+	fork = newTestBlocks(t, &chain[2].Header, 1, common.Hash{2})[0]
+	for i, block := range append(append([]*types.Block{}, chain...), fork) {
+		extrinsic := make(types.Extrinsic, extrinsicSize)
+		extrinsic[0] = byte(i)
+		block.Body = *types.NewBody([]types.Extrinsic{extrinsic})
+		err = bs.AddBlock(block)
+		require.NoError(t, err)
+	}
+	require.Equal(t, chain[4].Header.Hash(), bs.BestBlockHash())
+
+	err = bs.SetFinalisedHash(chain[1].Header.Hash(), 1, 0)
+	require.NoError(t, err)
+
+	return bs, genesisHeader, chain, fork
+}
+
+func blocksToHashes(blocks []*types.Block) (hashes []common.Hash) {
+	hashes = make([]common.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Header.Hash()
+	}
+	return hashes
+}
+
+func rawBlocksToHashes(blocks []RawBlockData) (hashes []common.Hash) {
+	hashes = make([]common.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash
+	}
+	return hashes
+}
+
+func Test_BlockState_GetBlocksInRange(t *testing.T) {
+	t.Parallel()
+
+	bs, genesisHeader, chain, fork := newTestBlockRangeState(t, 1)
+	genesisHash := genesisHeader.Hash()
+	chainHashes := blocksToHashes(chain)
+	forkHash := fork.Header.Hash()
+	unknownHash := common.Hash{9}
+
+	testCases := map[string]struct {
+		from       BlockID
+		direction  Direction
+		max        uint32
+		hashes     []common.Hash
+		errWrapped error
+		errMessage string
+	}{
+		"ascending_stops_at_best_block": {
+			from:      BlockID{Number: 0},
+			direction: Ascending,
+			max:       128,
+			hashes:    append([]common.Hash{genesisHash}, chainHashes...),
+		},
+		"ascending_by_number": {
+			from:      BlockID{Number: 2},
+			direction: Ascending,
+			max:       2,
+			hashes:    chainHashes[1:3],
+		},
+		"ascending_by_hash": {
+			from:      BlockID{Hash: &chainHashes[3]},
+			direction: Ascending,
+			max:       128,
+			hashes:    chainHashes[3:],
+		},
+		"ascending_from_fork_block": {
+			from:      BlockID{Hash: &forkHash},
+			direction: Ascending,
+			max:       128,
+			hashes:    []common.Hash{forkHash},
+		},
+		"descending_stops_at_genesis": {
+			from:      BlockID{Number: 5},
+			direction: Descending,
+			max:       128,
+			hashes: []common.Hash{chainHashes[4], chainHashes[3],
+				chainHashes[2], chainHashes[1], chainHashes[0], genesisHash},
+		},
+		"descending_from_fork_block": {
+			from:      BlockID{Hash: &forkHash},
+			direction: Descending,
+			max:       3,
+			hashes:    []common.Hash{forkHash, chainHashes[2], chainHashes[1]},
+		},
+		"zero_max": {
+			from:      BlockID{Number: 1},
+			direction: Ascending,
+			hashes:    []common.Hash{},
+		},
+		"number_above_best_block": {
+			from:       BlockID{Number: 6},
+			direction:  Descending,
+			max:        1,
+			errWrapped: ErrBlockRangeStartNotFound,
+			errMessage: "block range start not found: block number 6 is above best block number 5",
+		},
+		"unknown_hash": {
+			from:       BlockID{Hash: &unknownHash},
+			direction:  Ascending,
+			max:        1,
+			errWrapped: ErrBlockRangeStartNotFound,
+			errMessage: "block range start not found: for block hash " +
+				"0x0900000000000000000000000000000000000000000000000000000000000000",
+		},
+		"invalid_direction": {
+			from:       BlockID{Number: 1},
+			direction:  Direction(2),
+			max:        1,
+			errWrapped: errInvalidDirection,
+			errMessage: "invalid direction: 2",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			blocks, err := bs.GetBlocksInRange(testCase.from, testCase.direction,
+				testCase.max, false, false)
+
+			if testCase.errWrapped != nil {
+				assert.ErrorIs(t, err, testCase.errWrapped)
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, blocks)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.hashes, rawBlocksToHashes(blocks))
+		})
+	}
+}
+
+func Test_BlockState_GetBlocksInRange_components(t *testing.T) {
+	t.Parallel()
+
+	bs, genesisHeader, chain, _ := newTestBlockRangeState(t, 1)
+
+	// The first block is finalised and its justification is in the database,
+	// and the third block is unfinalised and its justification is pending.
+	err := bs.SetJustification(chain[0].Header.Hash(), []byte{1})
+	require.NoError(t, err)
+	err = bs.SetJustification(chain[2].Header.Hash(), []byte{3})
+	require.NoError(t, err)
+
+	blocks, err := bs.GetBlocksInRange(BlockID{Number: 0}, Ascending, 4, true, true)
+	require.NoError(t, err)
+
+	expectedHeaders := []*types.Header{genesisHeader}
+	expectedBodies := []*types.Body{types.NewBody([]types.Extrinsic{})}
+	for _, block := range chain[:3] {
+		expectedHeaders = append(expectedHeaders, &block.Header)
+		expectedBodies = append(expectedBodies, &block.Body)
+	}
+	expectedJustifications := []*[]byte{nil, {1}, nil, {3}}
+
+	require.Len(t, blocks, len(expectedHeaders))
+	for i, block := range blocks {
+		header, err := scale.Marshal(*expectedHeaders[i])
+		require.NoError(t, err)
+		assert.Equalf(t, header, block.Header, "block %d", i)
+
+		extrinsics, err := expectedBodies[i].AsEncodedExtrinsics()
+		require.NoError(t, err)
+		assert.Equalf(t, types.ExtrinsicsArrayToBytesArray(extrinsics), block.Body, "block %d", i)
+
+		assert.Equalf(t, expectedJustifications[i], block.Justification, "block %d", i)
+	}
+
+	blocks, err = bs.GetBlocksInRange(BlockID{Number: 1}, Ascending, 1, false, false)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.Nil(t, blocks[0].Body)
+	assert.Nil(t, blocks[0].Justification)
+}
+
+func Test_BlockState_getBlocksInRange_maxSize(t *testing.T) {
+	t.Parallel()
+
+	const extrinsicSize = 1000
+	bs, _, chain, _ := newTestBlockRangeState(t, extrinsicSize)
+	chainHashes := blocksToHashes(chain)
+
+	blocks, err := bs.getBlocksInRange(BlockID{Number: 1}, Ascending, 128, true, false, 2*extrinsicSize)
+	require.NoError(t, err)
+	// The extrinsic and the header of each block do not fit twice.
+	assert.Equal(t, chainHashes[:1], rawBlocksToHashes(blocks))
+
+	blocks, err = bs.getBlocksInRange(BlockID{Number: 1}, Ascending, 128, true, false, 3*extrinsicSize)
+	require.NoError(t, err)
+	assert.Equal(t, chainHashes[:2], rawBlocksToHashes(blocks))
+
+	// The starting block is returned even if it is larger than the maximum size.
+	blocks, err = bs.getBlocksInRange(BlockID{Number: 5}, Descending, 128, true, false, 1)
+	require.NoError(t, err)
+	assert.Equal(t, chainHashes[4:], rawBlocksToHashes(blocks))
+}
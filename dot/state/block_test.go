@@ -4,6 +4,7 @@
 package state
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 	"time"
@@ -12,10 +13,14 @@
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/blocktree"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
 	"github.com/ChainSafe/gossamer/lib/trie"
 	"github.com/ChainSafe/gossamer/pkg/scale"
 	"github.com/golang/mock/gomock"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -526,7 +531,6 @@ func TestGetHashByNumber(t *testing.T) {
 }
 
 func TestAddBlock_WithReOrg(t *testing.T) {
-	t.Skip() // TODO: this should be fixed after state refactor PR
 	bs := newTestBlockState(t, newTriesEmpty())
 
 	header1a := &types.Header{
@@ -644,7 +648,7 @@ func TestAddBlockToBlockTree(t *testing.T) {
 		ParentHash: testGenesisHeader.Hash(),
 	}
 
-	err := bs.setArrivalTime(header.Hash(), time.Now())
+	err := bs.SetArrivalTime(header.Hash(), header.Number, time.Now())
 	require.NoError(t, err)
 
 	err = bs.AddBlockToBlockTree(&types.Block{
@@ -653,6 +657,48 @@ func TestAddBlockToBlockTree(t *testing.T) {
 	})
 	require.NoError(t, err)
 	require.Equal(t, bs.BestBlockHash(), header.Hash())
+
+	weight, err := LoadForkChoiceWeight(bs.db, header.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), weight.PrimaryCount)
+}
+
+func TestAddHeaderToBlockTree(t *testing.T) {
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	header := &types.Header{
+		Number:     1,
+		Digest:     createPrimaryBABEDigest(t),
+		ParentHash: testGenesisHeader.Hash(),
+	}
+	hash := header.Hash()
+
+	err := bs.AddHeaderToBlockTree(header)
+	require.NoError(t, err)
+	require.Equal(t, hash, bs.BestBlockHash())
+
+	checkHeaderOnly := func() {
+		t.Helper()
+		has, err := bs.HasBlockBody(hash)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		_, err = bs.GetBlockBody(hash)
+		assert.ErrorIs(t, err, ErrBodiesNotStored)
+
+		_, err = bs.GetBlockByHash(hash)
+		assert.ErrorIs(t, err, ErrBodiesNotStored)
+	}
+	checkHeaderOnly()
+
+	// the body is not written to the database once the block is finalised
+	err = bs.SetFinalisedHash(hash, 1, 0)
+	require.NoError(t, err)
+	checkHeaderOnly()
+
+	has, err := HasBlockBody(bs.db, hash)
+	require.NoError(t, err)
+	assert.False(t, has)
 }
 
 func TestNumberIsFinalised(t *testing.T) {
@@ -833,7 +879,7 @@ func TestRange(t *testing.T) {
 			blocksToPersistAtDisk: 0,
 			wantErr:               loadHeaderFromDiskErr,
 			stringErr: "retrieving end hash from database: " +
-				"querying database: [mocked] cannot read, database closed ex",
+				"getting header from database: [mocked] cannot read, database closed ex",
 			newBlockState: func(t *testing.T, ctrl *gomock.Controller,
 				genesisHeader *types.Header) *BlockState {
 				telemetryMock := NewMockTelemetry(ctrl)
@@ -842,11 +888,22 @@ func TestRange(t *testing.T) {
 				db := NewInMemoryDB(t)
 				blockState, err := NewBlockStateFromGenesis(db, newTriesEmpty(), genesisHeader, telemetryMock)
 
+				blockStateDB := blockState.db
 				mockedDb := NewMockBlockStateDatabase(ctrl)
 				// cannot assert the exact hash type since the block header
-				// hash is generate by the running test case
+				// hash is generate by the running test case, and the block
+				// number index is read and written when adding blocks.
 				mockedDb.EXPECT().Get(gomock.AssignableToTypeOf([]byte{})).
-					Return(nil, loadHeaderFromDiskErr)
+					DoAndReturn(func(key []byte) ([]byte, error) {
+						if bytes.HasPrefix(key, headerPrefix) {
+							return nil, loadHeaderFromDiskErr
+						}
+						return blockStateDB.Get(key)
+					}).MinTimes(1)
+				mockedDb.EXPECT().NewBatch().DoAndReturn(blockStateDB.NewBatch).AnyTimes()
+				// the fork choice weight is written when adding blocks.
+				mockedDb.EXPECT().Put(gomock.AssignableToTypeOf([]byte{}), gomock.AssignableToTypeOf([]byte{})).
+					DoAndReturn(blockStateDB.Put).AnyTimes()
 				blockState.db = mockedDb
 
 				require.NoError(t, err)
@@ -966,7 +1023,7 @@ func TestRange(t *testing.T) {
 			blocksToPersistAtDisk: 64,
 			wantErr:               chaindb.ErrKeyNotFound,
 			stringErr: "range start should be in database: " +
-				"querying database: Key not found",
+				"getting header from database: Key not found",
 			newBlockState: func(t *testing.T, ctrl *gomock.Controller,
 				genesisHeader *types.Header) *BlockState {
 				telemetryMock := NewMockTelemetry(ctrl)
@@ -1119,3 +1176,212 @@ func Test_GetRuntime_StoreRuntime(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, runtimeInstance, sameRuntimeOnDiffHash)
 }
+
+func Test_BlockState_HandleRuntimeChanges(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	oldCode := []byte("old runtime code")
+	newCode := []byte("new runtime code")
+	newCodeHash := common.MustBlake2bHash(newCode)
+	newTrieState := func(code []byte) *rtstorage.TrieState {
+		trieState := rtstorage.NewTrieState(trie.NewEmptyTrie())
+		err := trieState.Put(common.CodeKey, code)
+		require.NoError(t, err)
+		return trieState
+	}
+
+	genesisRuntime := NewMockInstance(ctrl)
+	genesisRuntime.EXPECT().GetCodeHash().Return(common.MustBlake2bHash(oldCode)).AnyTimes()
+	genesisRuntime.EXPECT().Keystore().AnyTimes()
+	genesisRuntime.EXPECT().NodeStorage().AnyTimes()
+	genesisRuntime.EXPECT().NetworkService().AnyTimes()
+	genesisRuntime.EXPECT().UntrustedCallTimeout().AnyTimes()
+	genesisRuntime.EXPECT().Validator().AnyTimes()
+	bs.StoreRuntime(genesisHeader.Hash(), genesisRuntime)
+
+	upgradedRuntime := NewMockInstance(ctrl)
+	upgradedRuntime.EXPECT().GetCodeHash().Return(newCodeHash).AnyTimes()
+	upgradedRuntime.EXPECT().Version().Return(runtime.Version{SpecVersion: 2}, nil).AnyTimes()
+	instantiations := 0
+	bs.newRuntimeInstance = func(code []byte, cfg wasmer.Config) (runtime.Instance, error) {
+		instantiations++
+		assert.Equal(t, newCode, code)
+		assert.Equal(t, newCodeHash, cfg.CodeHash)
+		return upgradedRuntime, nil
+	}
+
+	runtimeUpdates := make(chan runtime.Version)
+	_, err = bs.RegisterRuntimeUpdatedChannel(runtimeUpdates)
+	require.NoError(t, err)
+
+	// importBlocks imports the chain given with the runtime code
+	// given, as done for each block imported by the core service.
+	importBlocks := func(chain []*types.Header, code []byte) {
+		for _, header := range chain {
+			parentRuntime, err := bs.GetRuntime(header.ParentHash)
+			require.NoError(t, err)
+			err = bs.HandleRuntimeChanges(newTrieState(code), parentRuntime, header.Hash())
+			require.NoError(t, err)
+		}
+	}
+
+	// The upgrade block is validated with the parent runtime, and
+	// the block following it is validated with the new runtime.
+	upgradeChain := addTestChain(t, bs, genesisHeader, 2, common.Hash{1}, time.Unix(1, 0))
+	importBlocks(upgradeChain, newCode)
+	assert.Equal(t, uint32(2), (<-runtimeUpdates).SpecVersion)
+	assert.Equal(t, 1, instantiations)
+	for _, header := range upgradeChain {
+		instance, err := bs.GetRuntime(header.Hash())
+		require.NoError(t, err)
+		assert.Same(t, upgradedRuntime, instance)
+	}
+
+	// A reorg to a longer chain without the upgrade reverts to the prior runtime.
+	forkChain := addTestChain(t, bs, genesisHeader, 3, common.Hash{2}, time.Unix(1, 0))
+	importBlocks(forkChain, oldCode)
+	require.Equal(t, forkChain[2].Hash(), bs.BestBlockHash())
+	instance, err := bs.GetRuntime(bs.BestBlockHash())
+	require.NoError(t, err)
+	assert.Same(t, genesisRuntime, instance)
+
+	// The upgrade applied again on the new best chain reuses the runtime
+	// instance already instantiated for the code hash.
+	forkUpgrade := addTestChain(t, bs, forkChain[2], 1, common.Hash{2}, time.Unix(1, 0))
+	importBlocks(forkUpgrade, newCode)
+	assert.Equal(t, uint32(2), (<-runtimeUpdates).SpecVersion)
+	assert.Equal(t, 1, instantiations)
+	instance, err = bs.GetRuntime(forkUpgrade[0].Hash())
+	require.NoError(t, err)
+	assert.Same(t, upgradedRuntime, instance)
+
+	// Finalising an ancestor of the upgrade block keeps the upgraded
+	// runtime, even though it was first stored for a pruned fork.
+	err = bs.SetFinalisedHash(forkChain[0].Hash(), 1, 0)
+	require.NoError(t, err)
+	instance, err = bs.GetRuntime(forkUpgrade[0].Hash())
+	require.NoError(t, err)
+	assert.Same(t, upgradedRuntime, instance)
+	instance, err = bs.GetRuntime(forkChain[2].Hash())
+	require.NoError(t, err)
+	assert.Same(t, genesisRuntime, instance)
+}
+
+// writtenBytesDatabase counts the bytes of the keys and
+// values put in the database, directly or in batches.
+type writtenBytesDatabase struct {
+	BlockStateDatabase
+	written int
+}
+
+func (d *writtenBytesDatabase) Put(key, value []byte) error {
+	d.written += len(key) + len(value)
+	return d.BlockStateDatabase.Put(key, value)
+}
+
+func (d *writtenBytesDatabase) NewBatch() chaindb.Batch {
+	return &writtenBytesBatch{
+		Batch: d.BlockStateDatabase.NewBatch(),
+		db:    d,
+	}
+}
+
+type writtenBytesBatch struct {
+	chaindb.Batch
+	db *writtenBytesDatabase
+}
+
+func (b *writtenBytesBatch) Put(key, value []byte) error {
+	b.db.written += len(key) + len(value)
+	return b.Batch.Put(key, value)
+}
+
+func TestBlockState_headersOnly(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blocks   = 300
+		bodySize = 16 * 1024
+	)
+	body := *types.NewBody([]types.Extrinsic{make([]byte, bodySize)})
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	bs.headersOnly = true
+	db := &writtenBytesDatabase{BlockStateDatabase: bs.db}
+	bs.db = db
+
+	parent, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	headers := make([]*types.Header, blocks)
+	for i := range headers {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			Digest:     createPrimaryBABEDigest(t),
+		}
+		hash := header.Hash()
+		written := db.written
+
+		err = bs.AddBlock(&types.Block{Header: *header, Body: body})
+		require.NoError(t, err)
+
+		// The body of an unfinalised block is kept in memory.
+		storedBody, err := bs.GetBlockBody(hash)
+		require.NoError(t, err)
+		assert.Equal(t, &body, storedBody)
+
+		err = bs.SetJustification(hash, []byte{1})
+		require.NoError(t, err)
+		err = bs.SetFinalisedHash(hash, uint64(i+1), 0)
+		require.NoError(t, err)
+
+		// The disk usage grows by the header and the finality
+		// records of each block, but not by the block body.
+		assert.Less(t, db.written-written, bodySize/4)
+
+		headers[i] = header
+		parent = header
+	}
+
+	for _, header := range headers {
+		hash := header.Hash()
+		storedHeader, err := bs.GetHeader(hash)
+		require.NoError(t, err)
+		assert.Equal(t, header.Number, storedHeader.Number)
+
+		canonicalHash, err := bs.GetHashByNumber(header.Number)
+		require.NoError(t, err)
+		assert.Equal(t, hash, canonicalHash)
+
+		justification, err := bs.GetJustification(hash)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1}, justification)
+
+		has, err := bs.HasBlockBody(hash)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		_, err = bs.GetBlockBody(hash)
+		assert.ErrorIs(t, err, ErrBodiesNotStored)
+	}
+
+	err = bs.SetBlockBody(headers[0].Hash(), &body)
+	require.NoError(t, err)
+	has, err := HasBlockBody(bs.db, headers[0].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// A block range is still served without the block bodies.
+	lastHash := headers[blocks-1].Hash()
+	rawBlocks, err := bs.GetBlocksInRange(BlockID{Hash: &lastHash}, Descending, 2, true, false)
+	require.NoError(t, err)
+	require.Len(t, rawBlocks, 2)
+	for _, rawBlock := range rawBlocks {
+		assert.Nil(t, rawBlock.Body)
+	}
+}
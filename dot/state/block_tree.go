@@ -0,0 +1,195 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// StoreBlockTree writes a snapshot of the block tree to the database,
+// together with the unfinalised blocks of the snapshot, such that the block
+// tree can be restored when the block state is created on restart.
+// The unfinalised blocks of the previous snapshot no longer in the block
+// tree are deleted from the database.
+func (bs *BlockState) StoreBlockTree() (err error) {
+	snapshot := bs.bt.Snapshot()
+	previousSnapshot, err := bs.loadBlockTreeSnapshot()
+	if err != nil {
+		return fmt.Errorf("loading previous block tree snapshot: %w", err)
+	}
+
+	batch := bs.db.NewBatch()
+	defer batch.Reset()
+
+	snapshotHashes := make(map[common.Hash]struct{}, len(snapshot.Nodes))
+	for _, snapshotNode := range snapshot.Nodes {
+		snapshotHashes[snapshotNode.Hash] = struct{}{}
+
+		block := bs.unfinalisedBlocks.getBlock(snapshotNode.Hash)
+		if block == nil {
+			// The root block is finalised and stored in the database,
+			// and blocks finalised or pruned since the snapshot was
+			// taken are discarded when the block tree is restored.
+			continue
+		}
+
+		key := prefixKey(snapshotNode.Hash, unfinalisedBlockPrefix)
+		has, err := bs.db.Has(key)
+		if err != nil {
+			return fmt.Errorf("checking unfinalised block exists in database: %w", err)
+		} else if has {
+			continue
+		}
+
+		encodedBlock, err := scale.Marshal(*block)
+		if err != nil {
+			return fmt.Errorf("encoding block: %w", err)
+		}
+
+		err = batch.Put(key, encodedBlock)
+		if err != nil {
+			return fmt.Errorf("putting unfinalised block in database batch: %w", err)
+		}
+	}
+
+	for _, snapshotNode := range previousSnapshot.Nodes {
+		_, ok := snapshotHashes[snapshotNode.Hash]
+		if ok {
+			continue
+		}
+
+		err = batch.Del(prefixKey(snapshotNode.Hash, unfinalisedBlockPrefix))
+		if err != nil {
+			return fmt.Errorf("deleting unfinalised block in database batch: %w", err)
+		}
+	}
+
+	encodedSnapshot, err := scale.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("encoding block tree snapshot: %w", err)
+	}
+
+	err = batch.Put(blockTreeKey, encodedSnapshot)
+	if err != nil {
+		return fmt.Errorf("putting block tree snapshot in database batch: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("writing block tree snapshot to database: %w", err)
+	}
+
+	return nil
+}
+
+// loadBlockTree restores the block tree from the snapshot stored in the
+// database, if any. The unfinalised blocks restored are added to the
+// unfinalised blocks map and to the block number index. Blocks of the
+// snapshot without an unfinalised block stored in the database, or not
+// descending from the highest finalised block, are discarded.
+func (bs *BlockState) loadBlockTree() (err error) {
+	snapshot, err := bs.loadBlockTreeSnapshot()
+	if err != nil {
+		return fmt.Errorf("loading block tree snapshot: %w", err)
+	}
+
+	var restoredHeaders []*types.Header
+	exists := func(hash common.Hash) (exists bool, err error) {
+		block, err := loadUnfinalisedBlock(bs.db, hash)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return false, nil
+		} else if err != nil {
+			logger.Warnf("discarding block %s from block tree snapshot: %s", hash, err)
+			return false, nil
+		}
+
+		bs.unfinalisedBlocks.store(block)
+		restoredHeaders = append(restoredHeaders, &block.Header)
+		return true, nil
+	}
+
+	discarded, err := bs.bt.Restore(snapshot, exists)
+	if err != nil {
+		return fmt.Errorf("restoring block tree: %w", err)
+	}
+
+	if len(discarded) > 0 {
+		logger.Debugf("discarded %d blocks from the block tree snapshot", len(discarded))
+	}
+
+	if len(restoredHeaders) == 0 {
+		return nil
+	}
+
+	bestBlockHash := bs.bt.BestBlockHash()
+	if bestBlockHash != snapshot.BestBlockHash {
+		logger.Debugf("best block %s of the block tree snapshot differs from the restored best block %s",
+			snapshot.BestBlockHash, bestBlockHash)
+	}
+
+	persistedBestBlockHash, err := BestBlockFromLeaves(bs.db, bs.bt.Leaves())
+	if err != nil {
+		logger.Warnf("selecting best block from fork choice weights: %s", err)
+	} else if persistedBestBlockHash != bestBlockHash {
+		logger.Warnf("best block %s selected from fork choice weights differs from the restored best block %s",
+			persistedBestBlockHash, bestBlockHash)
+	}
+
+	err = bs.updateBlockNumberIndex(restoredHeaders, nil)
+	if err != nil {
+		return fmt.Errorf("updating block number index: %w", err)
+	}
+
+	logger.Infof("restored %d unfinalised blocks with best block %s",
+		len(restoredHeaders), bestBlockHash)
+	return nil
+}
+
+// loadBlockTreeSnapshot loads the block tree snapshot from the database,
+// and returns an empty snapshot if no snapshot is stored.
+func (bs *BlockState) loadBlockTreeSnapshot() (snapshot blocktree.Snapshot, err error) {
+	encodedSnapshot, err := bs.db.Get(blockTreeKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return snapshot, nil
+	} else if err != nil {
+		return snapshot, fmt.Errorf("getting block tree snapshot from database: %w", err)
+	}
+
+	err = scale.Unmarshal(encodedSnapshot, &snapshot)
+	if err != nil {
+		return snapshot, fmt.Errorf("decoding block tree snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// loadUnfinalisedBlock loads and decodes the unfinalised block stored in the
+// database for the given block hash, and checks its header hash matches the
+// block hash.
+func loadUnfinalisedBlock(db Getter, hash common.Hash) (block *types.Block, err error) {
+	encodedBlock, err := db.Get(prefixKey(hash, unfinalisedBlockPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("getting unfinalised block from database: %w", err)
+	}
+
+	decodedBlock := types.NewEmptyBlock()
+	err = scale.Unmarshal(encodedBlock, &decodedBlock)
+	if err != nil {
+		return nil, fmt.Errorf("decoding block: %w", err)
+	}
+
+	headerHash := decodedBlock.Header.Hash()
+	if headerHash != hash {
+		return nil, fmt.Errorf("block header hash %s does not match block hash %s", headerHash, hash)
+	}
+
+	return &decodedBlock, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// bodyPruningBatchSize is the maximum number of block bodies
+	// deleted in a single database batch.
+	bodyPruningBatchSize = 1024
+	// bodyPruningMaxNumbers is the maximum number of block numbers pruned
+	// on each finalisation, such that a large backlog of block bodies to
+	// prune, for example when enabling body pruning on an existing
+	// database, is pruned over several finalisations.
+	bodyPruningMaxNumbers = 1 << 12
+)
+
+var (
+	// bodyPrunedNumberKey -> block number up to which block bodies are pruned
+	bodyPrunedNumberKey = []byte("bpn")
+
+	prunedBlockBodiesCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_block",
+		Name:      "pruned_block_bodies_total",
+		Help:      "total number of finalised block bodies deleted from the database",
+	})
+)
+
+// pruneBodies deletes from the database the bodies of the canonical blocks
+// more than the number of retained bodies below the finalised number given,
+// keeping their headers, justifications and other block data. It does
+// nothing if the number of retained bodies is 0. The block bodies are
+// deleted in batches, each batch also storing the block number pruned up
+// to, such that pruning resumes from the last batch written if it is
+// interrupted. The genesis block body is never pruned. At most
+// bodyPruningMaxNumbers block numbers are pruned per call, since it runs
+// on each finalisation.
+func (bs *BlockState) pruneBodies(finalisedNumber uint) (err error) {
+	if bs.retainedBodies == 0 || finalisedNumber <= bs.retainedBodies {
+		return nil
+	}
+
+	prunedNumber, err := bs.loadBodyPrunedNumber()
+	if err != nil {
+		return fmt.Errorf("loading body pruned number: %w", err)
+	}
+
+	lastNumber := finalisedNumber - bs.retainedBodies
+	if lastNumber > prunedNumber+bodyPruningMaxNumbers {
+		lastNumber = prunedNumber + bodyPruningMaxNumbers
+	}
+
+	batch := bs.db.NewBatch()
+	defer func() {
+		batch.Reset()
+	}()
+
+	var batchPrunedBodies int
+	for number := prunedNumber + 1; number <= lastNumber; number++ {
+		hash, err := bs.GetHashByNumber(number)
+		switch {
+		case errors.Is(err, ErrNoCanonicalAtHeight):
+			// no block is stored at this number, for example if it was skipped by warp sync.
+			logger.Tracef("no block body to prune at block number %d", number)
+		case err != nil:
+			return fmt.Errorf("getting canonical hash: %w", err)
+		default:
+			err = batch.Del(blockBodyKey(hash))
+			if err != nil {
+				return fmt.Errorf("deleting block body: %w", err)
+			}
+			batchPrunedBodies++
+		}
+
+		if batchPrunedBodies < bodyPruningBatchSize && number != lastNumber {
+			continue
+		}
+
+		err = batch.Put(bodyPrunedNumberKey, encodeBlockNumber(uint64(number)))
+		if err != nil {
+			return fmt.Errorf("putting body pruned number in database batch: %w", err)
+		}
+
+		err = batch.Flush()
+		if err != nil {
+			return fmt.Errorf("writing body pruning batch: %w", err)
+		}
+
+		logger.Debugf("pruned %d block bodies up to block number %d", batchPrunedBodies, number)
+		prunedBlockBodiesCounter.Add(float64(batchPrunedBodies))
+		batchPrunedBodies = 0
+		batch.Reset()
+		batch = bs.db.NewBatch()
+	}
+
+	return nil
+}
+
+// loadBodyPrunedNumber returns the block number up to which block
+// bodies are pruned, and 0 if no block body was ever pruned.
+func (bs *BlockState) loadBodyPrunedNumber() (number uint, err error) {
+	encodedNumber, err := bs.db.Get(bodyPrunedNumberKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("getting body pruned number from database: %w", err)
+	}
+
+	return uint(binary.BigEndian.Uint64(encodedNumber)), nil
+}
+
+// isBodyPruned returns true if the block with the given hash is stored
+// with its header and its block number is at or below the block number
+// block bodies are pruned up to.
+func (bs *BlockState) isBodyPruned(hash common.Hash) (pruned bool, number uint, err error) {
+	prunedNumber, err := bs.loadBodyPrunedNumber()
+	if err != nil {
+		return false, 0, fmt.Errorf("loading body pruned number: %w", err)
+	}
+
+	if prunedNumber == 0 {
+		return false, 0, nil
+	}
+
+	header, err := LoadHeader(bs.db, hash)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return false, 0, nil
+	} else if err != nil {
+		return false, 0, fmt.Errorf("loading header: %w", err)
+	}
+
+	return header.Number <= prunedNumber, header.Number, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BlockState_pruneBodies(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	bs.retainedBodies = 3
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	chain := addTestChain(t, bs, genesisHeader, 10, common.Hash{0xa}, time.Unix(1, 0))
+
+	// Finalising within the window does not prune any block body.
+	err = bs.SetFinalisedHash(chain[2].Hash(), 1, 0)
+	require.NoError(t, err)
+	prunedNumber, err := bs.loadBodyPrunedNumber()
+	require.NoError(t, err)
+	assert.Zero(t, prunedNumber)
+
+	// Block numbers 1 to 5 are below the window once block 8 is finalised.
+	err = bs.SetFinalisedHash(chain[7].Hash(), 2, 0)
+	require.NoError(t, err)
+	prunedNumber, err = bs.loadBodyPrunedNumber()
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), prunedNumber)
+
+	for _, header := range chain[:5] {
+		hash := header.Hash()
+
+		body, err := bs.GetBlockBody(hash)
+		assert.ErrorIs(t, err, ErrBlockBodyPruned)
+		assert.ErrorIs(t, err, ErrBodiesNotStored)
+		assert.Nil(t, body)
+
+		has, err := bs.HasBlockBody(hash)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		storedHeader, err := bs.GetHeader(hash)
+		require.NoError(t, err)
+		assert.Equal(t, hash, storedHeader.Hash())
+
+		canonicalHash, err := bs.GetHashByNumber(header.Number)
+		require.NoError(t, err)
+		assert.Equal(t, hash, canonicalHash)
+	}
+
+	for _, header := range chain[5:] {
+		body, err := bs.GetBlockBody(header.Hash())
+		require.NoErrorf(t, err, "block number %d", header.Number)
+		assert.NotNil(t, body)
+	}
+
+	// Block ranges are still served without the pruned block bodies.
+	blocks, err := bs.GetBlocksInRange(BlockID{Number: 4}, Ascending, 4, true, false)
+	require.NoError(t, err)
+	require.Len(t, blocks, 4)
+	assert.Nil(t, blocks[0].Body)
+	assert.Nil(t, blocks[1].Body)
+}
+
+func Test_BlockState_pruneBodies_missingCanonicalBlock(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	bs.retainedBodies = 1
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	chain := addTestChain(t, bs, genesisHeader, 4, common.Hash{0xa}, time.Unix(1, 0))
+	err = bs.SetFinalisedHash(chain[1].Hash(), 1, 0)
+	require.NoError(t, err)
+
+	// the block number 2 is missing from the block number index,
+	// as for block numbers skipped by warp sync.
+	err = bs.db.Del(headerHashKey(2))
+	require.NoError(t, err)
+
+	err = bs.SetFinalisedHash(chain[3].Hash(), 2, 0)
+	require.NoError(t, err)
+
+	prunedNumber, err := bs.loadBodyPrunedNumber()
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), prunedNumber)
+
+	has, err := bs.HasBlockBody(chain[2].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+	assert.Equal(t, chain[3].Hash(), bs.lastFinalised)
+}
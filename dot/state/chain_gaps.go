@@ -0,0 +1,187 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// chainGapsScanBatchSize is the number of block numbers
+// scanned at a time by FindChainGaps.
+const chainGapsScanBatchSize = 1024
+
+// GapKind is the kind of data missing for the blocks of a chain gap.
+type GapKind uint8
+
+const (
+	// HeaderGap is the kind of gap where the canonical block
+	// hash or the header of the canonical block is missing.
+	HeaderGap GapKind = iota
+	// BodyGap is the kind of gap where the header of the
+	// canonical block is stored but its body is missing.
+	BodyGap
+)
+
+func (k GapKind) String() string {
+	switch k {
+	case HeaderGap:
+		return "header missing"
+	case BodyGap:
+		return "body missing"
+	default:
+		panic(fmt.Sprintf("unknown gap kind %d", k))
+	}
+}
+
+// Gap is a range of consecutive block numbers of the canonical
+// chain for which the same kind of data is missing in the database.
+type Gap struct {
+	Kind GapKind
+	// Start is the first block number of the gap.
+	Start uint
+	// End is the last block number of the gap, included in the gap.
+	End uint
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("%s from block %d to block %d", g.Kind, g.Start, g.End)
+}
+
+// FindChainGaps returns the gaps of the canonical chain stored in the block
+// database given, for the block numbers from `from` to `to` included, in
+// increasing block number order. Only finalised blocks are stored in the
+// database, so the range should not go above the highest finalised block.
+func FindChainGaps(db GetHaser, from, to uint) (gaps []Gap, err error) {
+	scanner := NewChainGapScanner(db, from, to)
+	for !scanner.Done() {
+		scannedGaps, err := scanner.Scan(chainGapsScanBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, gap := range scannedGaps {
+			gaps = appendGap(gaps, gap)
+		}
+	}
+	return gaps, nil
+}
+
+// ChainGapScanner scans the canonical chain stored in a block database for
+// gaps, a batch of block numbers at a time, such that the scan can be resumed
+// from its cursor.
+type ChainGapScanner struct {
+	db GetHaser
+	// cursor is the next block number to scan.
+	cursor uint
+	to     uint
+	done   bool
+}
+
+// NewChainGapScanner returns a chain gap scanner scanning the block database
+// given for the block numbers from the cursor given to `to` included.
+func NewChainGapScanner(db GetHaser, cursor, to uint) *ChainGapScanner {
+	return &ChainGapScanner{
+		db:     db,
+		cursor: cursor,
+		to:     to,
+		done:   cursor > to,
+	}
+}
+
+// Cursor returns the next block number to scan, which can be given to
+// NewChainGapScanner to resume the scan.
+func (s *ChainGapScanner) Cursor() uint {
+	return s.cursor
+}
+
+// Done returns true once all the block numbers are scanned.
+func (s *ChainGapScanner) Done() bool {
+	return s.done
+}
+
+// Scan scans up to the maximum number of block numbers given from the cursor,
+// advances the cursor and returns the gaps found. A gap spanning over the end
+// of the scanned block numbers is continued by the first gap returned by the
+// next scan.
+func (s *ChainGapScanner) Scan(maxBlocks uint) (gaps []Gap, err error) {
+	for scanned := uint(0); scanned < maxBlocks && !s.done; scanned++ {
+		number := s.cursor
+		kind, missing, err := missingBlockData(s.db, number)
+		if err != nil {
+			return gaps, fmt.Errorf("for block number %d: %w", number, err)
+		}
+
+		if missing {
+			gaps = appendGap(gaps, Gap{Kind: kind, Start: number, End: number})
+		}
+
+		if number == s.to {
+			s.done = true
+		} else {
+			s.cursor++
+		}
+	}
+	return gaps, nil
+}
+
+// missingBlockData returns the kind of data missing in the database
+// for the canonical block with the given number, if any is missing.
+func missingBlockData(db GetHaser, number uint) (kind GapKind, missing bool, err error) {
+	encodedHash, err := db.Get(headerHashKey(uint64(number)))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return HeaderGap, true, nil
+	} else if err != nil {
+		return 0, false, fmt.Errorf("getting canonical block hash: %w", err)
+	}
+	hash := common.NewHash(encodedHash)
+
+	has, err := HasHeader(db, hash)
+	if err != nil {
+		return 0, false, fmt.Errorf("checking header exists: %w", err)
+	} else if !has {
+		return HeaderGap, true, nil
+	}
+
+	has, err = HasBlockBody(db, hash)
+	if err != nil {
+		return 0, false, fmt.Errorf("checking block body exists: %w", err)
+	} else if !has {
+		return BodyGap, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// appendGap appends the gap given to the gaps given, merging it with
+// the last gap if it is of the same kind and follows the last gap.
+func appendGap(gaps []Gap, gap Gap) []Gap {
+	if len(gaps) > 0 {
+		last := &gaps[len(gaps)-1]
+		if last.Kind == gap.Kind && last.End+1 == gap.Start {
+			last.End = gap.End
+			return gaps
+		}
+	}
+	return append(gaps, gap)
+}
+
+// FindChainGaps returns the gaps of the finalised canonical chain stored in
+// the database, for the block numbers from `from` to `to` included, with `to`
+// lowered to the highest finalised block number.
+func (bs *BlockState) FindChainGaps(from, to uint) (gaps []Gap, err error) {
+	finalisedHeader, err := bs.GetHighestFinalisedHeader()
+	if err != nil {
+		return nil, fmt.Errorf("getting highest finalised header: %w", err)
+	}
+
+	if to > finalisedHeader.Number {
+		to = finalisedHeader.Number
+	}
+
+	return FindChainGaps(bs.db, from, to)
+}
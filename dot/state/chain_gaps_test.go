@@ -0,0 +1,183 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGappedChainDB returns a block database storing the canonical chain
+// of blocks 0 to 12, with the holes:
+//   - blocks 3 and 4 have no canonical hash and no header respectively
+//   - blocks 5 and 6 have no body
+//   - block 7 has no header and no body
+//   - blocks 11 and 12 have no canonical hash
+func newTestGappedChainDB(t *testing.T) (db chaindb.Database) {
+	t.Helper()
+
+	db = chaindb.NewTable(NewInMemoryDB(t), blockPrefix)
+	parentHash := common.Hash{}
+	for number := uint(0); number <= 12; number++ {
+		header := types.NewHeader(parentHash, common.Hash{}, common.Hash{}, number, types.NewDigest())
+		hash := header.Hash()
+		parentHash = hash
+
+		if number != 3 && number < 11 {
+			err := db.Put(headerHashKey(uint64(number)), hash.ToBytes())
+			require.NoError(t, err)
+		}
+
+		if number != 4 && number != 7 {
+			_, err := StoreHeader(db, header)
+			require.NoError(t, err)
+		}
+
+		if number < 5 || number > 7 {
+			err := StoreBlockBody(db, hash, types.NewBody(nil))
+			require.NoError(t, err)
+		}
+	}
+
+	return db
+}
+
+func Test_FindChainGaps(t *testing.T) {
+	t.Parallel()
+
+	db := newTestGappedChainDB(t)
+
+	testCases := map[string]struct {
+		from, to uint
+		gaps     []Gap
+	}{
+		"full_range": {
+			from: 0,
+			to:   12,
+			gaps: []Gap{
+				{Kind: HeaderGap, Start: 3, End: 4},
+				{Kind: BodyGap, Start: 5, End: 6},
+				{Kind: HeaderGap, Start: 7, End: 7},
+				{Kind: HeaderGap, Start: 11, End: 12},
+			},
+		},
+		"range_inside_gap": {
+			from: 5,
+			to:   5,
+			gaps: []Gap{{Kind: BodyGap, Start: 5, End: 5}},
+		},
+		"range_without_gap": {
+			from: 8,
+			to:   10,
+		},
+		"range_above_stored_chain": {
+			from: 12,
+			to:   20,
+			gaps: []Gap{{Kind: HeaderGap, Start: 12, End: 20}},
+		},
+		"empty_range": {
+			from: 2,
+			to:   1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gaps, err := FindChainGaps(db, testCase.from, testCase.to)
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.gaps, gaps)
+		})
+	}
+}
+
+func Test_ChainGapScanner_resume(t *testing.T) {
+	t.Parallel()
+
+	db := newTestGappedChainDB(t)
+
+	scanner := NewChainGapScanner(db, 2, 12)
+	gaps, err := scanner.Scan(3)
+	require.NoError(t, err)
+	assert.Equal(t, []Gap{{Kind: HeaderGap, Start: 3, End: 4}}, gaps)
+	assert.Equal(t, uint(5), scanner.Cursor())
+	assert.False(t, scanner.Done())
+
+	// The scan is resumed from the cursor with a new scanner,
+	// and the gap spanning over the end of the scan continues.
+	scanner = NewChainGapScanner(db, scanner.Cursor(), 12)
+	gaps, err = scanner.Scan(1)
+	require.NoError(t, err)
+	assert.Equal(t, []Gap{{Kind: BodyGap, Start: 5, End: 5}}, gaps)
+
+	gaps, err = scanner.Scan(100)
+	require.NoError(t, err)
+	expectedGaps := []Gap{
+		{Kind: BodyGap, Start: 6, End: 6},
+		{Kind: HeaderGap, Start: 7, End: 7},
+		{Kind: HeaderGap, Start: 11, End: 12},
+	}
+	assert.Equal(t, expectedGaps, gaps)
+	assert.Equal(t, uint(12), scanner.Cursor())
+	assert.True(t, scanner.Done())
+
+	gaps, err = scanner.Scan(100)
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+}
+
+func Test_ChainGapScanner_Scan_error(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	db := NewMockBlockStateDatabase(ctrl)
+	db.EXPECT().Get(headerHashKey(1)).Return(nil, errors.New("test error"))
+
+	scanner := NewChainGapScanner(db, 1, 2)
+	gaps, err := scanner.Scan(2)
+
+	assert.EqualError(t, err, "for block number 1: getting canonical block hash: test error")
+	assert.Empty(t, gaps)
+	assert.Equal(t, uint(1), scanner.Cursor())
+}
+
+func Test_BlockState_FindChainGaps(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+
+	chain := addTestChain(t, bs, genesisHeader, 5, common.Hash{0xa}, time.Unix(1, 0))
+	err = bs.SetFinalisedHash(chain[2].Hash(), 1, 1)
+	require.NoError(t, err)
+
+	// Prune the body of the finalised block 2.
+	err = bs.db.Del(blockBodyKey(chain[1].Hash()))
+	require.NoError(t, err)
+
+	// The unfinalised blocks 4 and 5 are not stored in the database
+	// and the range is lowered to the finalised block 3.
+	gaps, err := bs.FindChainGaps(0, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []Gap{{Kind: BodyGap, Start: 2, End: 2}}, gaps)
+}
+
+func Test_Gap_String(t *testing.T) {
+	t.Parallel()
+
+	gap := Gap{Kind: BodyGap, Start: 5, End: 6}
+	assert.Equal(t, "body missing from block 5 to block 6", gap.String())
+}
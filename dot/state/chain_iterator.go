@@ -0,0 +1,118 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// ErrParentHeaderNotFound is returned by the chain iterator when the
+// header of the parent of a block is not found, which happens if the
+// parent block is pruned.
+var ErrParentHeaderNotFound = errors.New("parent header not found")
+
+// chainIteratorCacheSize is the maximum number of headers
+// last returned by a chain iterator that it keeps.
+const chainIteratorCacheSize = 16
+
+// ChainIterator walks the chain backwards from a starting block, returning
+// the header of each block followed by the header of its parent.
+type ChainIterator struct {
+	headerGetter HeaderGetter
+	start        common.Hash
+	// previous is the header last returned, and is nil
+	// if no header is returned yet.
+	previous   *types.Header
+	done       bool
+	stopHash   *common.Hash
+	stopNumber *uint
+	recent     []*types.Header
+}
+
+// NewChainIterator returns a chain iterator walking back the parent hashes of
+// the headers given by the header getter, starting from the block with the
+// given hash. The walk can cross the finalised blocks boundary if the header
+// getter is the block state. Headers are only loaded as the iterator advances.
+func NewChainIterator(headerGetter HeaderGetter, start common.Hash) *ChainIterator {
+	return &ChainIterator{
+		headerGetter: headerGetter,
+		start:        start,
+		recent:       make([]*types.Header, 0, chainIteratorCacheSize),
+	}
+}
+
+// StopAtHash makes the iterator stop after returning the header of the
+// block with the given hash. If this block is not an ancestor of the
+// starting block, the iterator only stops after the genesis header.
+func (ci *ChainIterator) StopAtHash(hash common.Hash) *ChainIterator {
+	ci.stopHash = &hash
+	return ci
+}
+
+// StopAtNumber makes the iterator stop after returning the header
+// of the block with the given number.
+func (ci *ChainIterator) StopAtNumber(number uint) *ChainIterator {
+	ci.stopNumber = &number
+	return ci
+}
+
+// Next returns the header of the next block, starting with the header of
+// the starting block. It returns a nil header and a nil error once the
+// iterator is done, which is after the genesis header or the stop block
+// header is returned.
+// It returns an error wrapping ErrParentHeaderNotFound if the parent
+// header of the header last returned is not found.
+func (ci *ChainIterator) Next() (header *types.Header, err error) {
+	if ci.done {
+		return nil, nil
+	}
+
+	if ci.previous == nil {
+		header, err = ci.headerGetter.GetHeader(ci.start)
+		if err != nil {
+			return nil, fmt.Errorf("getting start header: %w", err)
+		}
+	} else {
+		header, err = ci.headerGetter.GetHeader(ci.previous.ParentHash)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return nil, fmt.Errorf("%w: for block hash %s, parent of block %s",
+				ErrParentHeaderNotFound, ci.previous.ParentHash, ci.previous.Hash())
+		} else if err != nil {
+			return nil, fmt.Errorf("getting parent header of block %s: %w",
+				ci.previous.Hash(), err)
+		}
+	}
+
+	if ci.stopNumber != nil && header.Number < *ci.stopNumber {
+		// The starting block is below the stop block number.
+		ci.done = true
+		return nil, nil
+	}
+
+	ci.previous = header
+	if len(ci.recent) == chainIteratorCacheSize {
+		copy(ci.recent, ci.recent[1:])
+		ci.recent = ci.recent[:chainIteratorCacheSize-1]
+	}
+	ci.recent = append(ci.recent, header)
+
+	ci.done = header.Number == 0 ||
+		(ci.stopNumber != nil && header.Number == *ci.stopNumber) ||
+		(ci.stopHash != nil && header.Hash() == *ci.stopHash)
+
+	return header, nil
+}
+
+// Recent returns the headers last returned by the iterator, up to
+// the chainIteratorCacheSize headers, in the order they were returned.
+func (ci *ChainIterator) Recent() (headers []*types.Header) {
+	headers = make([]*types.Header, len(ci.recent))
+	copy(headers, ci.recent)
+	return headers
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walkChainIterator returns the hashes of the headers returned by the
+// iterator given until it is done or returns an error.
+func walkChainIterator(iterator *ChainIterator) (hashes []common.Hash, err error) {
+	for {
+		header, err := iterator.Next()
+		if err != nil {
+			return hashes, err
+		} else if header == nil {
+			return hashes, nil
+		}
+		hashes = append(hashes, header.Hash())
+	}
+}
+
+func Test_ChainIterator(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+
+	// Blocks 1 to 3 are finalised and their headers are in the
+	// database, and blocks 4 and 5 are unfinalised.
+	chain := addTestChain(t, bs, genesisHeader, 5, common.Hash{0xa}, time.Unix(1, 0))
+	err = bs.SetFinalisedHash(chain[2].Hash(), 1, 1)
+	require.NoError(t, err)
+	allHashes := append([]common.Hash{genesisHeader.Hash()}, headersToHashes(chain)...)
+	reversedHashes := make([]common.Hash, len(allHashes))
+	for i, hash := range allHashes {
+		reversedHashes[len(allHashes)-1-i] = hash
+	}
+
+	stopNumber := uint(3)
+
+	testCases := map[string]struct {
+		start      common.Hash
+		stopHash   *common.Hash
+		stopNumber *uint
+		hashes     []common.Hash
+		errWrapped error
+		errMessage string
+	}{
+		"crossing_finalised_boundary": {
+			start:  chain[4].Hash(),
+			hashes: reversedHashes,
+		},
+		"stop_at_hash": {
+			start:    chain[4].Hash(),
+			stopHash: &allHashes[2],
+			hashes:   reversedHashes[:4],
+		},
+		"stop_at_number": {
+			start:      chain[4].Hash(),
+			stopNumber: &stopNumber,
+			hashes:     reversedHashes[:3],
+		},
+		"start_at_stop_hash": {
+			start:    chain[1].Hash(),
+			stopHash: &allHashes[2],
+			hashes:   reversedHashes[3:4],
+		},
+		"start_below_stop_number": {
+			start:      chain[1].Hash(),
+			stopNumber: &stopNumber,
+		},
+		"start_not_found": {
+			start:      common.Hash{1},
+			errWrapped: chaindb.ErrKeyNotFound,
+			errMessage: "getting start header: Key not found",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			iterator := NewChainIterator(bs, testCase.start)
+			if testCase.stopHash != nil {
+				iterator.StopAtHash(*testCase.stopHash)
+			}
+			if testCase.stopNumber != nil {
+				iterator.StopAtNumber(*testCase.stopNumber)
+			}
+
+			hashes, err := walkChainIterator(iterator)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.hashes, hashes)
+
+			// The iterator stays done once it is done.
+			if err == nil {
+				header, err := iterator.Next()
+				assert.NoError(t, err)
+				assert.Nil(t, header)
+			}
+		})
+	}
+}
+
+func Test_ChainIterator_prunedGap(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+
+	chain := addTestChain(t, bs, genesisHeader, 4, common.Hash{0xa}, time.Unix(1, 0))
+	err = bs.SetFinalisedHash(chain[2].Hash(), 1, 1)
+	require.NoError(t, err)
+
+	// Prune the header of the finalised block 2.
+	err = bs.db.Del(headerKey(chain[1].Hash()))
+	require.NoError(t, err)
+
+	iterator := NewChainIterator(bs, chain[3].Hash())
+	hashes, err := walkChainIterator(iterator)
+
+	assert.ErrorIs(t, err, ErrParentHeaderNotFound)
+	assert.EqualError(t, err, "parent header not found: for block hash "+
+		chain[1].Hash().String()+", parent of block "+chain[2].Hash().String())
+	assert.Equal(t, headersToHashes([]*types.Header{chain[3], chain[2]}), hashes)
+}
+
+func Test_ChainIterator_Recent(t *testing.T) {
+	t.Parallel()
+
+	const length = chainIteratorCacheSize + 4
+	headers := make([]*types.Header, length)
+	headerGetter := make(headerMap, length)
+	parentHash := common.Hash{}
+	for i := range headers {
+		headers[i] = &types.Header{
+			ParentHash: parentHash,
+			Number:     uint(i),
+			Digest:     types.NewDigest(),
+		}
+		parentHash = headers[i].Hash()
+		headerGetter[parentHash] = headers[i]
+	}
+
+	iterator := NewChainIterator(headerGetter, headers[length-1].Hash())
+	assert.Empty(t, iterator.Recent())
+
+	header, err := iterator.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Header{header}, iterator.Recent())
+
+	hashes, err := walkChainIterator(iterator)
+	require.NoError(t, err)
+	require.Len(t, hashes, length-1)
+
+	expectedRecent := make([]*types.Header, chainIteratorCacheSize)
+	for i := range expectedRecent {
+		expectedRecent[i] = headers[chainIteratorCacheSize-1-i]
+	}
+	assert.Equal(t, expectedRecent, iterator.Recent())
+}
@@ -0,0 +1,361 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+var (
+	// ErrHeaderRecordMalformed is returned when a header record
+	// stored in the database cannot be decoded entirely.
+	ErrHeaderRecordMalformed = errors.New("header record is malformed")
+	// ErrBlockBodyRecordMalformed is returned when a block body
+	// record stored in the database cannot be decoded entirely.
+	ErrBlockBodyRecordMalformed = errors.New("block body record is malformed")
+	// ErrJustificationNotFound is returned when no justification
+	// is stored in the database for a block hash.
+	ErrJustificationNotFound = errors.New("justification not found")
+	// ErrReceiptNotFound is returned when no receipt
+	// is stored in the database for a block hash.
+	ErrReceiptNotFound = errors.New("receipt not found")
+	// ErrMessageQueueNotFound is returned when no message queue
+	// is stored in the database for a block hash.
+	ErrMessageQueueNotFound = errors.New("message queue not found")
+)
+
+// StoreHeader SCALE encodes the header given and stores it in the database
+// at the header key for its block hash. The block hash is computed from the
+// encoded header, and the cached hash of the header is not used, such that
+// the key always matches the stored header.
+// It returns the block hash of the header stored.
+func StoreHeader(db Putter, header *types.Header) (hash common.Hash, err error) {
+	encoding, err := scale.Marshal(*header)
+	if err != nil {
+		return hash, fmt.Errorf("encoding header: %w", err)
+	}
+
+	hash, err = common.Blake2bHash(encoding)
+	if err != nil {
+		return hash, fmt.Errorf("hashing header: %w", err)
+	}
+
+	err = db.Put(headerKey(hash), encoding)
+	if err != nil {
+		return hash, fmt.Errorf("putting header for block hash %s in database: %w", hash, err)
+	}
+
+	return hash, nil
+}
+
+// LoadHeader loads and decodes the header stored in the database for the
+// given block hash. It returns an error wrapping ErrHeaderRecordMalformed
+// if the record is truncated or has trailing data.
+func LoadHeader(db Getter, hash common.Hash) (header *types.Header, err error) {
+	encoding, err := db.Get(headerKey(hash))
+	if err != nil {
+		return nil, fmt.Errorf("getting header from database: %w", err)
+	}
+
+	header = types.NewEmptyHeader()
+	err = decodeRecord(encoding, header, ErrHeaderRecordMalformed)
+	if err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+// HasHeader returns true if the database contains
+// a header record for the given block hash.
+func HasHeader(db Haser, hash common.Hash) (has bool, err error) {
+	return db.Has(headerKey(hash))
+}
+
+// StoreBlockBody SCALE encodes the extrinsics of the block body given
+// and stores them in the database at the block body key for the block
+// hash given.
+func StoreBlockBody(db Putter, hash common.Hash, body *types.Body) (err error) {
+	encoding, err := scale.Marshal(*body)
+	if err != nil {
+		return fmt.Errorf("encoding block body: %w", err)
+	}
+
+	err = db.Put(blockBodyKey(hash), encoding)
+	if err != nil {
+		return fmt.Errorf("putting block body for block hash %s in database: %w", hash, err)
+	}
+
+	return nil
+}
+
+// BlockBodyRecord is a block body record loaded from the database,
+// which is the SCALE encoding of the extrinsics of the block body.
+// It is only decoded when Decode is called, such that it can be
+// used verbatim, for example to serve network block responses.
+type BlockBodyRecord []byte
+
+// Decode decodes the block body record. It returns an error wrapping
+// ErrBlockBodyRecordMalformed if the record is truncated or has
+// trailing data.
+func (r BlockBodyRecord) Decode() (body *types.Body, err error) {
+	var extrinsics [][]byte
+	err = decodeRecord(r, &extrinsics, ErrBlockBodyRecordMalformed)
+	if err != nil {
+		return nil, err
+	}
+
+	body = types.NewBody(types.BytesArrayToExtrinsics(extrinsics))
+	return body, nil
+}
+
+// EncodedExtrinsics splits the block body record into the SCALE encoded
+// extrinsics it contains, without decoding them. The extrinsics returned
+// share their memory with the record. It returns an error wrapping
+// ErrBlockBodyRecordMalformed if the record is truncated or has trailing data.
+func (r BlockBodyRecord) EncodedExtrinsics() (extrinsics [][]byte, err error) {
+	reader := bytes.NewReader(r)
+	decoder := scale.NewDecoder(reader)
+	var count uint
+	err = decoder.Decode(&count)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decoding extrinsics count: %s",
+			ErrBlockBodyRecordMalformed, err)
+	}
+
+	if uint64(count) > uint64(reader.Len()) {
+		return nil, fmt.Errorf("%w: %d extrinsics for %d bytes",
+			ErrBlockBodyRecordMalformed, count, reader.Len())
+	}
+
+	extrinsics = make([][]byte, count)
+	for i := range extrinsics {
+		start := len(r) - reader.Len()
+		var length uint
+		err = decoder.Decode(&length)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decoding length of extrinsic %d: %s",
+				ErrBlockBodyRecordMalformed, i, err)
+		}
+
+		if uint64(length) > uint64(reader.Len()) {
+			return nil, fmt.Errorf("%w: extrinsic %d is %d bytes but %d bytes are left",
+				ErrBlockBodyRecordMalformed, i, length, reader.Len())
+		}
+
+		end := len(r) - reader.Len() + int(length)
+		extrinsics[i] = r[start:end:end]
+		_, err = reader.Seek(int64(length), io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("skipping extrinsic %d: %w", i, err)
+		}
+	}
+
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("%w: %d bytes left after extrinsics",
+			ErrBlockBodyRecordMalformed, reader.Len())
+	}
+
+	return extrinsics, nil
+}
+
+// LoadBlockBody loads the block body record stored in the database
+// for the given block hash, without decoding it.
+func LoadBlockBody(db Getter, hash common.Hash) (record BlockBodyRecord, err error) {
+	record, err = db.Get(blockBodyKey(hash))
+	if err != nil {
+		return nil, fmt.Errorf("getting block body from database: %w", err)
+	}
+
+	return record, nil
+}
+
+// decodeRecord decodes the SCALE encoded database record given into the value
+// given. It returns an error wrapping the malformed error given if the record
+// is truncated or has trailing data.
+func decodeRecord[T any](record []byte, value *T, errMalformed error) (err error) {
+	reader := bytes.NewReader(record)
+	err = scale.NewDecoder(reader).Decode(value)
+	if err != nil {
+		return fmt.Errorf("%w: decoding %d bytes: %s", errMalformed, len(record), err)
+	}
+
+	if reader.Len() > 0 {
+		return fmt.Errorf("%w: %d bytes left after decoding", errMalformed, reader.Len())
+	}
+
+	// The SCALE decoder does not fail on a short read of the last byte
+	// slice, so check the decoded value re-encodes to the same record.
+	reEncoding, err := scale.Marshal(*value)
+	if err != nil {
+		return fmt.Errorf("encoding decoded record: %w", err)
+	}
+
+	if !bytes.Equal(reEncoding, record) {
+		return fmt.Errorf("%w: decoded record re-encodes to %d bytes instead of %d bytes",
+			errMalformed, len(reEncoding), len(record))
+	}
+
+	return nil
+}
+
+// HasBlockBody returns true if the database contains
+// a block body record for the given block hash.
+func HasBlockBody(db Haser, hash common.Hash) (has bool, err error) {
+	return db.Has(blockBodyKey(hash))
+}
+
+// StoreJustification stores the justification given in the database
+// for the block hash given. Storing the same justification again for
+// the same block hash leaves the database unchanged.
+func StoreJustification(db Putter, hash common.Hash, justification []byte) (err error) {
+	err = db.Put(prefixKey(hash, justificationPrefix), justification)
+	if err != nil {
+		return fmt.Errorf("putting justification for block hash %s in database: %w", hash, err)
+	}
+
+	return nil
+}
+
+// LoadJustification loads the justification stored in the database for
+// the given block hash. It returns an error wrapping ErrJustificationNotFound
+// if no justification is stored for the block hash.
+func LoadJustification(db Getter, hash common.Hash) (justification []byte, err error) {
+	justification, err = db.Get(prefixKey(hash, justificationPrefix))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: for block hash %s", ErrJustificationNotFound, hash)
+	} else if err != nil {
+		return nil, fmt.Errorf("getting justification from database: %w", err)
+	}
+
+	return justification, nil
+}
+
+// HasJustification returns true if the database contains
+// a justification for the given block hash.
+func HasJustification(db Haser, hash common.Hash) (has bool, err error) {
+	return db.Has(prefixKey(hash, justificationPrefix))
+}
+
+// StoreReceipt stores the receipt given in the database for the block hash
+// given. The receipt is opaque and stored as is, and may be empty.
+func StoreReceipt(db Putter, hash common.Hash, receipt []byte) (err error) {
+	err = db.Put(prefixKey(hash, receiptPrefix), receipt)
+	if err != nil {
+		return fmt.Errorf("putting receipt for block hash %s in database: %w", hash, err)
+	}
+
+	return nil
+}
+
+// LoadReceipt loads the receipt stored in the database for the given block
+// hash, where an empty receipt stored is returned as an empty non-nil slice.
+// It returns an error wrapping ErrReceiptNotFound if no receipt is stored
+// for the block hash.
+func LoadReceipt(db Getter, hash common.Hash) (receipt []byte, err error) {
+	receipt, err = db.Get(prefixKey(hash, receiptPrefix))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: for block hash %s", ErrReceiptNotFound, hash)
+	} else if err != nil {
+		return nil, fmt.Errorf("getting receipt from database: %w", err)
+	}
+
+	if receipt == nil {
+		receipt = []byte{}
+	}
+	return receipt, nil
+}
+
+// HasReceipt returns true if the database contains
+// a receipt for the given block hash.
+func HasReceipt(db Haser, hash common.Hash) (has bool, err error) {
+	return db.Has(prefixKey(hash, receiptPrefix))
+}
+
+// StoreMessageQueue stores the message queue given in the database for the block
+// hash given. The message queue is opaque and stored as is, and may be empty.
+func StoreMessageQueue(db Putter, hash common.Hash, messageQueue []byte) (err error) {
+	err = db.Put(prefixKey(hash, messageQueuePrefix), messageQueue)
+	if err != nil {
+		return fmt.Errorf("putting message queue for block hash %s in database: %w", hash, err)
+	}
+
+	return nil
+}
+
+// LoadMessageQueue loads the message queue stored in the database for the given
+// block hash, where an empty message queue stored is returned as an empty non-nil
+// slice. It returns an error wrapping ErrMessageQueueNotFound if no message queue
+// is stored for the block hash.
+func LoadMessageQueue(db Getter, hash common.Hash) (messageQueue []byte, err error) {
+	messageQueue, err = db.Get(prefixKey(hash, messageQueuePrefix))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: for block hash %s", ErrMessageQueueNotFound, hash)
+	} else if err != nil {
+		return nil, fmt.Errorf("getting message queue from database: %w", err)
+	}
+
+	if messageQueue == nil {
+		messageQueue = []byte{}
+	}
+	return messageQueue, nil
+}
+
+// HasMessageQueue returns true if the database contains
+// a message queue for the given block hash.
+func HasMessageQueue(db Haser, hash common.Hash) (has bool, err error) {
+	return db.Has(prefixKey(hash, messageQueuePrefix))
+}
+
+// BlockComponents is a bitmask of the components of a block
+// stored in the database.
+type BlockComponents uint8
+
+const (
+	// HeaderComponent is set if the block header is stored.
+	HeaderComponent BlockComponents = 1 << iota
+	// BodyComponent is set if the block body is stored.
+	BodyComponent
+	// JustificationComponent is set if a justification is stored for the block.
+	JustificationComponent
+)
+
+// Has returns true if all the components given are set.
+func (c BlockComponents) Has(components BlockComponents) bool {
+	return c&components == components
+}
+
+// BlockExists returns the bitmask of the components stored in the database
+// for the given block hash, checking each component exists without reading
+// or decoding its record.
+func BlockExists(db Haser, hash common.Hash) (components BlockComponents, err error) {
+	checks := []struct {
+		component BlockComponents
+		has       func(db Haser, hash common.Hash) (bool, error)
+	}{
+		{component: HeaderComponent, has: HasHeader},
+		{component: BodyComponent, has: HasBlockBody},
+		{component: JustificationComponent, has: HasJustification},
+	}
+
+	for _, check := range checks {
+		has, err := check.has(db, hash)
+		if err != nil {
+			return 0, fmt.Errorf("checking block component exists: %w", err)
+		}
+		if has {
+			components |= check.component
+		}
+	}
+
+	return components, nil
+}
@@ -0,0 +1,669 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHeader(t *testing.T) (header *types.Header) {
+	t.Helper()
+
+	digest := types.NewDigest()
+	err := digest.Add(types.PreRuntimeDigest{
+		ConsensusEngineID: types.BabeEngineID,
+		Data:              []byte{1, 2, 3},
+	})
+	require.NoError(t, err)
+
+	return types.NewHeader(common.Hash{1}, common.Hash{2}, common.Hash{3}, 21, digest)
+}
+
+func Test_StoreHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("hash_computed_from_encoding", func(t *testing.T) {
+		t.Parallel()
+
+		db := NewInMemoryDB(t)
+		header := newTestHeader(t)
+		expectedHash := header.Hash()
+		// Modify the header after its hash got cached,
+		// so its cached hash no longer matches its contents.
+		header.Number++
+		encoding, err := scale.Marshal(*header)
+		require.NoError(t, err)
+		contentHash, err := common.Blake2bHash(encoding)
+		require.NoError(t, err)
+
+		hash, err := StoreHeader(db, header)
+
+		require.NoError(t, err)
+		assert.Equal(t, contentHash, hash)
+		assert.NotEqual(t, expectedHash, hash)
+
+		has, err := HasHeader(db, expectedHash)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		stored, err := db.Get(headerKey(contentHash))
+		require.NoError(t, err)
+		assert.Equal(t, encoding, stored)
+	})
+
+	t.Run("put_error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		db := NewMockBlockStateDatabase(ctrl)
+		header := newTestHeader(t)
+		db.EXPECT().Put(headerKey(header.Hash()), gomock.Any()).
+			Return(errors.New("test error"))
+
+		_, err := StoreHeader(db, header)
+
+		assert.EqualError(t, err, "putting header for block hash "+
+			header.Hash().String()+" in database: test error")
+	})
+}
+
+func Test_LoadHeader(t *testing.T) {
+	t.Parallel()
+
+	header := newTestHeader(t)
+	encoding, err := scale.Marshal(*header)
+	require.NoError(t, err)
+	hash := header.Hash()
+
+	testCases := map[string]struct {
+		record     []byte
+		header     *types.Header
+		errWrapped error
+		errMessage string
+	}{
+		"header_not_found": {
+			errWrapped: chaindb.ErrKeyNotFound,
+			errMessage: "getting header from database: Key not found",
+		},
+		"valid_record": {
+			record: encoding,
+			header: header,
+		},
+		"empty_record": {
+			record:     []byte{},
+			errWrapped: ErrHeaderRecordMalformed,
+			errMessage: "header record is malformed: decoding 0 bytes: " +
+				"decoding struct: unmarshalling field at index 0: EOF",
+		},
+		"truncated_record": {
+			record:     encoding[:len(encoding)-1],
+			errWrapped: ErrHeaderRecordMalformed,
+			errMessage: "header record is malformed: " +
+				"decoded record re-encodes to 107 bytes instead of 106 bytes",
+		},
+		"truncated_record_before_digest": {
+			record:     encoding[:40],
+			errWrapped: ErrHeaderRecordMalformed,
+			errMessage: "header record is malformed: decoding 40 bytes: " +
+				"decoding struct: unmarshalling field at index 2: EOF",
+		},
+		"trailing_data": {
+			record:     append(append([]byte{}, encoding...), 1, 2),
+			errWrapped: ErrHeaderRecordMalformed,
+			errMessage: "header record is malformed: 2 bytes left after decoding",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := NewInMemoryDB(t)
+			if testCase.record != nil {
+				err := db.Put(headerKey(hash), testCase.record)
+				require.NoError(t, err)
+			}
+
+			loaded, err := LoadHeader(db, hash)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, loaded)
+				return
+			}
+			assert.Equal(t, testCase.header.Hash(), loaded.Hash())
+			assert.Equal(t, testCase.header, loaded)
+		})
+	}
+}
+
+func Test_StoreHeader_LoadHeader_HasHeader(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	header := newTestHeader(t)
+
+	has, err := HasHeader(db, header.Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	hash, err := StoreHeader(db, header)
+	require.NoError(t, err)
+	assert.Equal(t, header.Hash(), hash)
+
+	has, err = HasHeader(db, hash)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	loaded, err := LoadHeader(db, hash)
+	require.NoError(t, err)
+	assert.Equal(t, hash, loaded.Hash())
+	assert.Equal(t, header, loaded)
+}
+
+// extrinsicsRoot computes the extrinsics root of the block body given,
+// as the root hash of the trie of its SCALE encoded extrinsics keyed
+// by their compact encoded index.
+func extrinsicsRoot(t *testing.T, body *types.Body) (root common.Hash) {
+	t.Helper()
+
+	encodedExtrinsics, err := body.AsEncodedExtrinsics()
+	require.NoError(t, err)
+
+	extrinsicsTrie := trie.NewEmptyTrie()
+	for i, encodedExtrinsic := range encodedExtrinsics {
+		key, err := scale.Marshal(big.NewInt(int64(i)))
+		require.NoError(t, err)
+		err = extrinsicsTrie.Put(key, encodedExtrinsic)
+		require.NoError(t, err)
+	}
+
+	root, err = extrinsicsTrie.Hash()
+	require.NoError(t, err)
+	return root
+}
+
+func Test_StoreBlockBody_LoadBlockBody_HasBlockBody(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		body *types.Body
+	}{
+		"empty_body": {
+			body: types.NewBody([]types.Extrinsic{}),
+		},
+		"single_large_extrinsic": {
+			body: types.NewBody([]types.Extrinsic{
+				bytes.Repeat([]byte{0xab}, 1<<20+1),
+			}),
+		},
+		"multiple_extrinsics": {
+			body: types.NewBody([]types.Extrinsic{{1}, {}, {2, 3}}),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// The in memory database limits values to 1MiB,
+			// so an on disk database is used instead.
+			db, err := utils.SetupDatabase(t.TempDir(), false)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				err := db.Close()
+				require.NoError(t, err)
+			})
+			header := newTestHeader(t)
+			header.ExtrinsicsRoot = extrinsicsRoot(t, testCase.body)
+			hash := header.Hash()
+
+			has, err := HasBlockBody(db, hash)
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			err = StoreBlockBody(db, hash, testCase.body)
+			require.NoError(t, err)
+
+			has, err = HasBlockBody(db, hash)
+			require.NoError(t, err)
+			assert.True(t, has)
+
+			record, err := LoadBlockBody(db, hash)
+			require.NoError(t, err)
+			expectedRecord, err := scale.Marshal(*testCase.body)
+			require.NoError(t, err)
+			assert.Equal(t, BlockBodyRecord(expectedRecord), record)
+
+			body, err := record.Decode()
+			require.NoError(t, err)
+			assert.Equal(t, testCase.body, body)
+			assert.Equal(t, header.ExtrinsicsRoot, extrinsicsRoot(t, body))
+		})
+	}
+}
+
+func Test_StoreBlockBody_putError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	db := NewMockBlockStateDatabase(ctrl)
+	hash := common.Hash{1}
+	db.EXPECT().Put(blockBodyKey(hash), []byte{0}).
+		Return(errors.New("test error"))
+
+	err := StoreBlockBody(db, hash, types.NewBody([]types.Extrinsic{}))
+
+	assert.EqualError(t, err, "putting block body for block hash "+
+		hash.String()+" in database: test error")
+}
+
+func Test_LoadBlockBody_notFound(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+
+	record, err := LoadBlockBody(db, common.Hash{1})
+
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+	assert.EqualError(t, err, "getting block body from database: Key not found")
+	assert.Nil(t, record)
+}
+
+func Test_BlockBodyRecord_Decode(t *testing.T) {
+	t.Parallel()
+
+	body := types.NewBody([]types.Extrinsic{{1, 2}, {3, 4, 5}})
+	encoding, err := scale.Marshal(*body)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		record     BlockBodyRecord
+		body       *types.Body
+		errWrapped error
+		errMessage string
+	}{
+		"valid_record": {
+			record: encoding,
+			body:   body,
+		},
+		"empty_record": {
+			record:     BlockBodyRecord{},
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: decoding 0 bytes: reading byte: EOF",
+		},
+		"truncated_record": {
+			record:     encoding[:len(encoding)-1],
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: " +
+				"decoded record re-encodes to 8 bytes instead of 7 bytes",
+		},
+		"trailing_data": {
+			record:     append(append(BlockBodyRecord{}, encoding...), 1, 2),
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: 2 bytes left after decoding",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decoded, err := testCase.record.Decode()
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, decoded)
+				return
+			}
+			assert.Equal(t, testCase.body, decoded)
+		})
+	}
+}
+
+func Test_BlockBodyRecord_EncodedExtrinsics(t *testing.T) {
+	t.Parallel()
+
+	body := types.NewBody([]types.Extrinsic{{1, 2}, {3, 4, 5}})
+	encoding, err := scale.Marshal(*body)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		record     BlockBodyRecord
+		extrinsics [][]byte
+		errWrapped error
+		errMessage string
+	}{
+		"valid_record": {
+			record:     encoding,
+			extrinsics: [][]byte{{8, 1, 2}, {12, 3, 4, 5}},
+		},
+		"no_extrinsic": {
+			record:     BlockBodyRecord{0},
+			extrinsics: [][]byte{},
+		},
+		"empty_record": {
+			record:     BlockBodyRecord{},
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: decoding extrinsics count: reading byte: EOF",
+		},
+		"count_too_large": {
+			record:     BlockBodyRecord{8, 0},
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: 2 extrinsics for 1 bytes",
+		},
+		"truncated_record": {
+			record:     encoding[:len(encoding)-1],
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: extrinsic 1 is 3 bytes but 2 bytes are left",
+		},
+		"trailing_data": {
+			record:     append(append(BlockBodyRecord{}, encoding...), 1, 2),
+			errWrapped: ErrBlockBodyRecordMalformed,
+			errMessage: "block body record is malformed: 2 bytes left after extrinsics",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			extrinsics, err := testCase.record.EncodedExtrinsics()
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, extrinsics)
+				return
+			}
+			assert.Equal(t, testCase.extrinsics, extrinsics)
+
+			expectedExtrinsics, err := body.AsEncodedExtrinsics()
+			require.NoError(t, err)
+			if len(extrinsics) > 0 {
+				assert.Equal(t, types.ExtrinsicsArrayToBytesArray(expectedExtrinsics), extrinsics)
+			}
+		})
+	}
+}
+
+func Test_StoreJustification_LoadJustification_HasJustification(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	hash := common.Hash{1}
+	justification := []byte{1, 2, 3}
+
+	has, err := HasJustification(db, hash)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	loaded, err := LoadJustification(db, hash)
+	assert.ErrorIs(t, err, ErrJustificationNotFound)
+	assert.EqualError(t, err, "justification not found: for block hash "+hash.String())
+	assert.Nil(t, loaded)
+
+	// Storing a duplicate justification is idempotent.
+	for i := 0; i < 2; i++ {
+		err = StoreJustification(db, hash, justification)
+		require.NoError(t, err)
+
+		has, err = HasJustification(db, hash)
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		loaded, err = LoadJustification(db, hash)
+		require.NoError(t, err)
+		assert.Equal(t, justification, loaded)
+	}
+}
+
+func Test_LoadJustification_getError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	db := NewMockBlockStateDatabase(ctrl)
+	hash := common.Hash{1}
+	db.EXPECT().Get(prefixKey(hash, justificationPrefix)).
+		Return(nil, errors.New("test error"))
+
+	_, err := LoadJustification(db, hash)
+
+	assert.NotErrorIs(t, err, ErrJustificationNotFound)
+	assert.EqualError(t, err, "getting justification from database: test error")
+}
+
+func Test_StoreReceipt_LoadReceipt_HasReceipt(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		receipt []byte
+	}{
+		"empty_receipt":     {receipt: []byte{}},
+		"non_empty_receipt": {receipt: []byte{1, 2, 3}},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := NewInMemoryDB(t)
+			hash := common.Hash{1}
+
+			has, err := HasReceipt(db, hash)
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			loaded, err := LoadReceipt(db, hash)
+			assert.ErrorIs(t, err, ErrReceiptNotFound)
+			assert.EqualError(t, err, "receipt not found: for block hash "+hash.String())
+			assert.Nil(t, loaded)
+
+			err = StoreReceipt(db, hash, testCase.receipt)
+			require.NoError(t, err)
+
+			has, err = HasReceipt(db, hash)
+			require.NoError(t, err)
+			assert.True(t, has)
+
+			loaded, err = LoadReceipt(db, hash)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.receipt, loaded)
+		})
+	}
+}
+
+func Test_StoreMessageQueue_LoadMessageQueue_HasMessageQueue(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		messageQueue []byte
+	}{
+		"empty_message_queue":     {messageQueue: []byte{}},
+		"non_empty_message_queue": {messageQueue: []byte{1, 2, 3}},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := NewInMemoryDB(t)
+			hash := common.Hash{1}
+
+			has, err := HasMessageQueue(db, hash)
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			loaded, err := LoadMessageQueue(db, hash)
+			assert.ErrorIs(t, err, ErrMessageQueueNotFound)
+			assert.EqualError(t, err, "message queue not found: for block hash "+hash.String())
+			assert.Nil(t, loaded)
+
+			err = StoreMessageQueue(db, hash, testCase.messageQueue)
+			require.NoError(t, err)
+
+			has, err = HasMessageQueue(db, hash)
+			require.NoError(t, err)
+			assert.True(t, has)
+
+			loaded, err = LoadMessageQueue(db, hash)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.messageQueue, loaded)
+		})
+	}
+}
+
+func Test_LoadReceipt_LoadMessageQueue_getError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	db := NewMockBlockStateDatabase(ctrl)
+	hash := common.Hash{1}
+	db.EXPECT().Get(prefixKey(hash, receiptPrefix)).
+		Return(nil, errors.New("test error"))
+	db.EXPECT().Get(prefixKey(hash, messageQueuePrefix)).
+		Return(nil, errors.New("test error"))
+
+	_, err := LoadReceipt(db, hash)
+	assert.NotErrorIs(t, err, ErrReceiptNotFound)
+	assert.EqualError(t, err, "getting receipt from database: test error")
+
+	_, err = LoadMessageQueue(db, hash)
+	assert.NotErrorIs(t, err, ErrMessageQueueNotFound)
+	assert.EqualError(t, err, "getting message queue from database: test error")
+}
+
+func Test_BlockExists(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	header := newTestHeader(t)
+	hash := header.Hash()
+
+	components, err := BlockExists(db, hash)
+	require.NoError(t, err)
+	assert.Equal(t, BlockComponents(0), components)
+
+	_, err = StoreHeader(db, header)
+	require.NoError(t, err)
+	err = StoreJustification(db, hash, []byte{1})
+	require.NoError(t, err)
+
+	components, err = BlockExists(db, hash)
+	require.NoError(t, err)
+	assert.Equal(t, HeaderComponent|JustificationComponent, components)
+	assert.True(t, components.Has(HeaderComponent|JustificationComponent))
+	assert.False(t, components.Has(HeaderComponent|BodyComponent))
+
+	err = StoreBlockBody(db, hash, types.NewBody([]types.Extrinsic{{1}}))
+	require.NoError(t, err)
+
+	components, err = BlockExists(db, hash)
+	require.NoError(t, err)
+	assert.True(t, components.Has(HeaderComponent|BodyComponent|JustificationComponent))
+}
+
+func Test_BlockExists_hasError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	db := NewMockBlockStateDatabase(ctrl)
+	hash := common.Hash{1}
+	db.EXPECT().Has(headerKey(hash)).Return(true, nil)
+	db.EXPECT().Has(blockBodyKey(hash)).Return(false, errors.New("test error"))
+
+	_, err := BlockExists(db, hash)
+
+	assert.EqualError(t, err, "checking block component exists: test error")
+}
+
+func Benchmark_Has_Load(b *testing.B) {
+	db, err := chaindb.NewBadgerDB(&chaindb.Config{
+		DataDir:  b.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(b, err)
+	b.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	digest := types.NewDigest()
+	err = digest.Add(types.PreRuntimeDigest{
+		ConsensusEngineID: types.BabeEngineID,
+		Data:              []byte{1, 2, 3},
+	})
+	require.NoError(b, err)
+	header := types.NewHeader(common.Hash{1}, common.Hash{2}, common.Hash{3}, 21, digest)
+	hash, err := StoreHeader(db, header)
+	require.NoError(b, err)
+	extrinsics := make([]types.Extrinsic, 100)
+	for i := range extrinsics {
+		extrinsics[i] = bytes.Repeat([]byte{byte(i)}, 200)
+	}
+	err = StoreBlockBody(db, hash, types.NewBody(extrinsics))
+	require.NoError(b, err)
+
+	b.Run("HasHeader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := HasHeader(db, hash)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("LoadHeader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := LoadHeader(db, hash)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("HasBlockBody", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := HasBlockBody(db, hash)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("LoadBlockBody", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			record, err := LoadBlockBody(db, hash)
+			require.NoError(b, err)
+			_, err = record.Decode()
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("BlockExists", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := BlockExists(db, hash)
+			require.NoError(b, err)
+		}
+	})
+}
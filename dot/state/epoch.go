@@ -7,6 +7,7 @@
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -23,8 +24,14 @@
 	errEpochNotInDatabase = errors.New("epoch data not found in the database")
 	errHashNotPersisted   = errors.New("hash with next epoch not found in database")
 	errNoPreRuntimeDigest = errors.New("header does not contain pre-runtime digest")
+	errTimeBeforeNetwork  = errors.New("given time is before network start")
+	errEpochLengthZero    = errors.New("epoch length is zero")
 )
 
+// maxClockSkewSlots is the number of slots a local time can be behind
+// the network start and still be considered in the first epoch.
+const maxClockSkewSlots = 1
+
 var (
 	epochPrefix         = "epoch"
 	epochLengthKey      = []byte("epochlength")
@@ -35,6 +42,8 @@
 	configDataPrefix    = []byte("configinfo")
 	latestConfigDataKey = []byte("lcfginfo")
 	skipToKey           = []byte("skipto")
+	epochDataPrunedKey  = []byte("prunedepoch")
+	epochStartsKey      = []byte("epochstarts")
 )
 
 func epochDataKey(epoch uint64) []byte {
@@ -49,14 +58,25 @@ func configDataKey(epoch uint64) []byte {
 	return append(configDataPrefix, buf...)
 }
 
+// epochStart is the first slot of an epoch.
+type epochStart struct {
+	Epoch uint64
+	Slot  uint64
+}
+
 // EpochState tracks information related to each epoch
 type EpochState struct {
-	db          GetPutter
+	db          EpochStateDatabase
 	baseState   *BaseState
 	blockState  *BlockState
 	epochLength uint64 // measured in slots
 	skipToEpoch uint64
 
+	epochStartsLock sync.RWMutex
+	// epochStarts are the start slots of the epochs after the genesis epoch,
+	// ordered by epoch, as stored when their epoch change digest is processed.
+	epochStarts []epochStart
+
 	nextEpochDataLock sync.RWMutex
 	// nextEpochData follows the format map[epoch]map[block hash]next epoch data
 	nextEpochData nextEpochMap[types.NextEpochData]
@@ -64,14 +84,18 @@ type EpochState struct {
 	nextConfigDataLock sync.RWMutex
 	// nextConfigData follows the format map[epoch]map[block hash]next config data
 	nextConfigData nextEpochMap[types.NextConfigDataV1]
+
+	// vrfRandomnessLock protects the VRF randomness records of the
+	// primary blocks, read and written for each epoch as a whole.
+	vrfRandomnessLock sync.RWMutex
 }
 
 // NewEpochStateFromGenesis returns a new EpochState given information for the first epoch, fetched from the runtime
-func NewEpochStateFromGenesis(db *chaindb.BadgerDB, blockState *BlockState,
+func NewEpochStateFromGenesis(db chaindb.Database, blockState *BlockState,
 	genesisConfig *types.BabeConfiguration) (*EpochState, error) {
 	baseState := NewBaseState(db)
 
-	err := baseState.storeFirstSlot(1) // this may change once the first block is imported
+	err := baseState.storeFirstSlot(genesisFirstSlot) // this changes once block 1 is imported
 	if err != nil {
 		return nil, err
 	}
@@ -129,11 +153,15 @@ func NewEpochStateFromGenesis(db *chaindb.BadgerDB, blockState *BlockState,
 		return nil, err
 	}
 
+	if blockState != nil {
+		blockState.onFinalisation(s.pruneForkVRFRandomness)
+	}
+
 	return s, nil
 }
 
 // NewEpochState returns a new EpochState
-func NewEpochState(db *chaindb.BadgerDB, blockState *BlockState) (*EpochState, error) {
+func NewEpochState(db chaindb.Database, blockState *BlockState) (*EpochState, error) {
 	baseState := NewBaseState(db)
 
 	epochLength, err := baseState.loadEpochLength()
@@ -146,15 +174,43 @@ func NewEpochState(db *chaindb.BadgerDB, blockState *BlockState) (*EpochState, e
 		return nil, err
 	}
 
-	return &EpochState{
+	epochDB := chaindb.NewTable(db, epochPrefix)
+	epochStarts, err := loadEpochStarts(epochDB)
+	if err != nil {
+		return nil, fmt.Errorf("loading epoch start slots: %w", err)
+	}
+
+	s := &EpochState{
 		baseState:      baseState,
 		blockState:     blockState,
-		db:             chaindb.NewTable(db, epochPrefix),
+		db:             epochDB,
 		epochLength:    epochLength,
 		skipToEpoch:    skipToEpoch,
+		epochStarts:    epochStarts,
 		nextEpochData:  make(nextEpochMap[types.NextEpochData]),
 		nextConfigData: make(nextEpochMap[types.NextConfigDataV1]),
-	}, nil
+	}
+
+	if blockState != nil {
+		blockState.onFinalisation(s.pruneForkVRFRandomness)
+	}
+
+	return s, nil
+}
+
+func loadEpochStarts(db EpochStateDatabase) (epochStarts []epochStart, err error) {
+	enc, err := db.Get(epochStartsKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = scale.Unmarshal(enc, &epochStarts)
+	if err != nil {
+		return nil, fmt.Errorf("decoding epoch start slots: %w", err)
+	}
+	return epochStarts, nil
 }
 
 // GetEpochLength returns the length of an epoch in slots
@@ -172,6 +228,64 @@ func (s *EpochState) GetSlotDuration() (time.Duration, error) {
 	return time.ParseDuration(fmt.Sprintf("%dms", d))
 }
 
+// StoreEpochLength stores the length of an epoch in slots, used for all the epoch computations.
+func (s *EpochState) StoreEpochLength(epochLength uint64) error {
+	if epochLength == 0 {
+		return errEpochLengthZero
+	}
+
+	s.epochStartsLock.Lock()
+	defer s.epochStartsLock.Unlock()
+
+	err := s.baseState.storeEpochLength(epochLength)
+	if err != nil {
+		return err
+	}
+
+	s.epochLength = epochLength
+	return nil
+}
+
+// StoreSlotDuration stores the duration of a slot in milliseconds.
+func (s *EpochState) StoreSlotDuration(slotDuration uint64) error {
+	return s.baseState.storeSlotDuration(slotDuration)
+}
+
+// CheckBABEConfiguration checks the epoch length and slot duration stored, which are
+// set from the genesis runtime, against the BABE configuration reported by a later
+// runtime. If they differ, a warning is logged and the runtime values are stored.
+func (s *EpochState) CheckBABEConfiguration(babeConfig *types.BabeConfiguration) error {
+	epochLength, err := s.GetEpochLength()
+	if err != nil {
+		return fmt.Errorf("getting epoch length: %w", err)
+	}
+
+	if epochLength != babeConfig.EpochLength {
+		logger.Warnf("runtime epoch length %d differs from stored epoch length %d, using the runtime one",
+			babeConfig.EpochLength, epochLength)
+		err = s.StoreEpochLength(babeConfig.EpochLength)
+		if err != nil {
+			return fmt.Errorf("storing epoch length: %w", err)
+		}
+	}
+
+	slotDuration, err := s.baseState.loadSlotDuration()
+	if err != nil {
+		return fmt.Errorf("getting slot duration: %w", err)
+	}
+
+	if slotDuration != babeConfig.SlotDuration {
+		logger.Warnf("runtime slot duration %dms differs from stored slot duration %dms, using the runtime one",
+			babeConfig.SlotDuration, slotDuration)
+		err = s.StoreSlotDuration(babeConfig.SlotDuration)
+		if err != nil {
+			return fmt.Errorf("storing slot duration: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // SetCurrentEpoch sets the current epoch
 func (s *EpochState) SetCurrentEpoch(epoch uint64) error {
 	buf := make([]byte, 8)
@@ -190,16 +304,12 @@ func (s *EpochState) GetCurrentEpoch() (uint64, error) {
 }
 
 // GetEpochForBlock checks the pre-runtime digest to determine what epoch the block was formed in.
+// Blocks with a slot before the first slot of the network are in the genesis epoch.
 func (s *EpochState) GetEpochForBlock(header *types.Header) (uint64, error) {
 	if header == nil {
 		return 0, errors.New("header is nil")
 	}
 
-	firstSlot, err := s.baseState.loadFirstSlot()
-	if err != nil {
-		return 0, err
-	}
-
 	for _, d := range header.Digest.Types {
 		digestValue, err := d.Value()
 		if err != nil {
@@ -225,17 +335,15 @@ func (s *EpochState) GetEpochForBlock(header *types.Header) (uint64, error) {
 			slotNumber = d.SlotNumber
 		}
 
-		if slotNumber < firstSlot {
-			return 0, nil
-		}
-
-		return (slotNumber - firstSlot) / s.epochLength, nil
+		return s.epochForSlot(slotNumber)
 	}
 
 	return 0, errNoPreRuntimeDigest
 }
 
-// SetEpochData sets the epoch data for a given epoch
+// SetEpochData sets the epoch data for a given epoch. The epoch data
+// can be set in any epoch order, since the data of a future epoch is
+// announced in block digests before the epoch starts.
 func (s *EpochState) SetEpochData(epoch uint64, info *types.EpochData) error {
 	raw := info.ToEpochDataRaw()
 
@@ -249,8 +357,91 @@ func (s *EpochState) SetEpochData(epoch uint64, info *types.EpochData) error {
 
 // GetEpochData returns the epoch data for a given epoch persisted in database
 // otherwise will try to get the data from the in-memory map using the header
-// if the header params is nil then it will search only in database
+// if the header params is nil then it will search only in database.
+// Until finalised, epoch data may differ across forks, and the epoch data
+// announced by an ancestor of the header is returned.
+// If the chain stalled for longer than an epoch, no epoch data was announced
+// for the epochs skipped, and the epoch data announced for the epoch after
+// the epoch of the parent block is returned, since BABE keeps on using it
+// after skipped epochs. The header must then be either the header of the
+// block in the epoch given or the header of its parent block.
 func (s *EpochState) GetEpochData(epoch uint64, header *types.Header) (*types.EpochData, error) {
+	epochData, err := s.getEpochData(epoch, header)
+	if err == nil || header == nil {
+		return epochData, err
+	}
+
+	notFound := errors.Is(err, errEpochNotInDatabase) ||
+		errors.Is(err, ErrEpochNotInMemory) ||
+		errors.Is(err, errHashNotInMemory)
+	if !notFound {
+		return nil, err
+	}
+
+	skipped, parentEpoch, skippedErr := s.isSkippedEpoch(epoch, header)
+	if skippedErr != nil {
+		return nil, fmt.Errorf("checking if epoch %d was skipped: %w", epoch, skippedErr)
+	} else if !skipped {
+		return nil, err
+	}
+
+	logger.Debugf("no epoch data for skipped epoch %d, using epoch data of epoch %d", epoch, parentEpoch+1)
+	return s.getEpochData(parentEpoch+1, header)
+}
+
+// GetEpochDataForBlock returns the epoch data used by the block with the header
+// given in the epoch given. Until the block announcing it is finalised, the
+// epoch data of an epoch is one of the candidates announced on each fork, and
+// the candidate announced by an ancestor of the header is returned. Once the
+// announcing block is finalised, the other candidates are deleted and the
+// epoch data is read from the database.
+func (s *EpochState) GetEpochDataForBlock(epoch uint64, header *types.Header) (*types.EpochData, error) {
+	if header == nil {
+		return nil, errors.New("header is nil")
+	}
+
+	return s.GetEpochData(epoch, header)
+}
+
+// isSkippedEpoch returns true if the epochs after the epoch of the parent block
+// of the block in the epoch given were skipped, where the header given is either
+// the header of the block or the header of its parent block. It also returns the
+// epoch of the parent block.
+func (s *EpochState) isSkippedEpoch(epoch uint64, header *types.Header) (
+	skipped bool, parentEpoch uint64, err error) {
+	if header.Number == 0 {
+		// no epoch can be skipped before the first block
+		return false, 0, nil
+	}
+
+	headerEpoch, err := s.GetEpochForBlock(header)
+	if err != nil {
+		return false, 0, fmt.Errorf("getting epoch of block %s: %w", header.Hash(), err)
+	}
+
+	parentEpoch = headerEpoch
+	if headerEpoch >= epoch {
+		parent, err := s.blockState.GetHeader(header.ParentHash)
+		if err != nil {
+			return false, 0, fmt.Errorf("getting parent header: %w", err)
+		}
+
+		if parent.Number == 0 {
+			return false, 0, nil
+		}
+
+		parentEpoch, err = s.GetEpochForBlock(parent)
+		if err != nil {
+			return false, 0, fmt.Errorf("getting epoch of block %s: %w", parent.Hash(), err)
+		}
+	}
+
+	return parentEpoch+1 < epoch, parentEpoch, nil
+}
+
+// getEpochData returns the epoch data announced for the given epoch, from
+// the database or else from the in-memory map using the header if not nil.
+func (s *EpochState) getEpochData(epoch uint64, header *types.Header) (*types.EpochData, error) {
 	epochData, err := s.getEpochDataFromDatabase(epoch)
 	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
 		return nil, fmt.Errorf("failed to retrieve epoch data from database: %w", err)
@@ -280,6 +471,68 @@ func (s *EpochState) GetEpochData(epoch uint64, header *types.Header) (*types.Ep
 	return epochData, nil
 }
 
+// HasEpochData returns true if the epoch data for the given epoch is persisted in database.
+func (s *EpochState) HasEpochData(epoch uint64) (has bool, err error) {
+	return s.db.Has(epochDataKey(epoch))
+}
+
+// PruneEpochData deletes the epoch data persisted in database for the epochs
+// before the given finalised epoch, which are no longer needed to verify blocks.
+// The epoch data of the latest epoch at or before the finalised epoch is kept,
+// since it is used by the finalised epoch if the finalised epoch was skipped.
+func (s *EpochState) PruneEpochData(finalisedEpoch uint64) error {
+	firstUnprunedEpoch, err := s.loadFirstUnprunedEpoch()
+	if err != nil {
+		return fmt.Errorf("loading first unpruned epoch: %w", err)
+	}
+
+	if finalisedEpoch <= firstUnprunedEpoch {
+		return nil
+	}
+
+	pruneBefore := finalisedEpoch
+	for ; pruneBefore > firstUnprunedEpoch; pruneBefore-- {
+		has, err := s.HasEpochData(pruneBefore)
+		if err != nil {
+			return fmt.Errorf("checking epoch data for epoch %d: %w", pruneBefore, err)
+		}
+		if has {
+			break
+		}
+	}
+
+	if pruneBefore == firstUnprunedEpoch {
+		return nil
+	}
+
+	for epoch := firstUnprunedEpoch; epoch < pruneBefore; epoch++ {
+		err = s.db.Del(epochDataKey(epoch))
+		if err != nil {
+			return fmt.Errorf("deleting epoch data for epoch %d: %w", epoch, err)
+		}
+	}
+
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, pruneBefore)
+	err = s.db.Put(epochDataPrunedKey, buf)
+	if err != nil {
+		return fmt.Errorf("storing first unpruned epoch: %w", err)
+	}
+	return nil
+}
+
+// loadFirstUnprunedEpoch returns the first epoch whose epoch data was
+// not pruned, which is epoch 0 if the epoch data was never pruned.
+func (s *EpochState) loadFirstUnprunedEpoch() (epoch uint64, err error) {
+	data, err := s.db.Get(epochDataPrunedKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
 // getEpochDataFromDatabase returns the epoch data for a given epoch persisted in database
 func (s *EpochState) getEpochDataFromDatabase(epoch uint64) (*types.EpochData, error) {
 	enc, err := s.db.Get(epochDataKey(epoch))
@@ -313,12 +566,33 @@ func (s *EpochState) SetConfigData(epoch uint64, info *types.ConfigData) error {
 		return err
 	}
 
-	// this assumes the most recently set config data is the highest on the chain
-	if err = s.setLatestConfigData(epoch); err != nil {
+	err = s.db.Put(configDataKey(epoch), enc)
+	if err != nil {
 		return err
 	}
 
-	return s.db.Put(configDataKey(epoch), enc)
+	// config data can be set out of order, so only move the latest config data epoch forward
+	latestEpoch, hasLatest, err := s.loadLatestConfigDataEpoch()
+	if err != nil {
+		return fmt.Errorf("loading latest config data epoch: %w", err)
+	}
+
+	if hasLatest && latestEpoch > epoch {
+		return nil
+	}
+	return s.setLatestConfigData(epoch)
+}
+
+// loadLatestConfigDataEpoch returns the highest epoch having config data
+// persisted in database, and false if no config data was persisted.
+func (s *EpochState) loadLatestConfigDataEpoch() (epoch uint64, has bool, err error) {
+	data, err := s.db.Get(latestConfigDataKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return binary.LittleEndian.Uint64(data), true, nil
 }
 
 func (s *EpochState) setLatestConfigData(epoch uint64) error {
@@ -333,19 +607,32 @@ func (s *EpochState) setLatestConfigData(epoch uint64) error {
 // - The supplied configuration data are intended to be used from the next epoch onwards.
 // If the header params is nil then it will search only in the database.
 func (s *EpochState) GetConfigData(epoch uint64, header *types.Header) (configData *types.ConfigData, err error) {
+	latestEpoch, hasLatest, err := s.loadLatestConfigDataEpoch()
+	if err != nil {
+		return nil, fmt.Errorf("loading latest config data epoch: %w", err)
+	}
+
 	for tryEpoch := int(epoch); tryEpoch >= 0; tryEpoch-- {
-		configData, err = s.getConfigDataFromDatabase(uint64(tryEpoch))
-		if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
-			return nil, fmt.Errorf("failed to retrieve config epoch from database: %w", err)
-		}
+		// no config data is persisted in database after the latest config data epoch
+		persisted := !hasLatest || uint64(tryEpoch) <= latestEpoch
+		if persisted {
+			configData, err = s.getConfigDataFromDatabase(uint64(tryEpoch))
+			if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+				return nil, fmt.Errorf("failed to retrieve config epoch from database: %w", err)
+			}
 
-		if configData != nil {
-			return configData, nil
+			if configData != nil {
+				return configData, nil
+			}
 		}
 
 		// there is no config data for the `tryEpoch` on database and we don't have a
-		// header to lookup in the memory map, so let's go retrieve the previous epoch
+		// header to lookup in the memory map, so let's go retrieve the previous epoch,
+		// skipping directly to the latest config data epoch.
 		if header == nil {
+			if !persisted {
+				tryEpoch = int(latestEpoch) + 1
+			}
 			continue
 		}
 
@@ -401,6 +688,7 @@ func (s *EpochState) HandleBABEDigest(header *types.Header, digest scale.Varying
 		nextEpoch := currEpoch + 1
 		s.storeBABENextEpochData(nextEpoch, headerHash, val)
 		logger.Debugf("stored BABENextEpochData data: %v for hash: %s to epoch: %d", digest, headerHash, nextEpoch)
+		s.checkNextEpochRandomness(header, currEpoch, val.Randomness)
 		return nil
 
 	case types.BABEOnDisabled:
@@ -477,9 +765,22 @@ func (s *EpochState) GetLatestConfigData() (*types.ConfigData, error) {
 	return s.GetConfigData(epoch, nil)
 }
 
-// GetStartSlotForEpoch returns the first slot in the given epoch.
-// If 0 is passed as the epoch, it returns the start slot for the current epoch.
+// GetStartSlotForEpoch returns the first slot in the given epoch. The genesis epoch
+// starts at the slot of the first block, and each other epoch starts at its stored
+// start slot, or epoch length slots after the start of the previous epoch.
 func (s *EpochState) GetStartSlotForEpoch(epoch uint64) (uint64, error) {
+	s.epochStartsLock.RLock()
+	defer s.epochStartsLock.RUnlock()
+
+	// last stored epoch start at or before the epoch
+	i := sort.Search(len(s.epochStarts), func(i int) bool {
+		return s.epochStarts[i].Epoch > epoch
+	})
+	if i > 0 {
+		start := s.epochStarts[i-1]
+		return start.Slot + (epoch-start.Epoch)*s.epochLength, nil
+	}
+
 	firstSlot, err := s.baseState.loadFirstSlot()
 	if err != nil {
 		return 0, err
@@ -488,7 +789,80 @@ func (s *EpochState) GetStartSlotForEpoch(epoch uint64) (uint64, error) {
 	return s.epochLength*epoch + firstSlot, nil
 }
 
-// GetEpochFromTime returns the epoch for a given time
+// epochForSlot returns the epoch the given slot is in, using the last
+// epoch start at or before the slot.
+func (s *EpochState) epochForSlot(slot uint64) (uint64, error) {
+	s.epochStartsLock.RLock()
+	defer s.epochStartsLock.RUnlock()
+
+	i := sort.Search(len(s.epochStarts), func(i int) bool {
+		return s.epochStarts[i].Slot > slot
+	})
+	if i > 0 {
+		start := s.epochStarts[i-1]
+		return start.Epoch + (slot-start.Slot)/s.epochLength, nil
+	}
+
+	firstSlot, err := s.baseState.loadFirstSlot()
+	if err != nil {
+		return 0, err
+	}
+
+	if slot < firstSlot {
+		return 0, nil
+	}
+
+	return (slot - firstSlot) / s.epochLength, nil
+}
+
+// storeEpochStartSlot stores the first slot of the given epoch, which must
+// be after the start of the previous stored epoch and before the start of
+// the next stored epoch.
+func (s *EpochState) storeEpochStartSlot(epoch, slot uint64) error {
+	s.epochStartsLock.Lock()
+	defer s.epochStartsLock.Unlock()
+
+	i := sort.Search(len(s.epochStarts), func(i int) bool {
+		return s.epochStarts[i].Epoch >= epoch
+	})
+	if i < len(s.epochStarts) && s.epochStarts[i].Epoch == epoch {
+		if s.epochStarts[i].Slot == slot {
+			return nil
+		}
+		return fmt.Errorf("epoch %d already starts at slot %d", epoch, s.epochStarts[i].Slot)
+	}
+
+	if i > 0 && s.epochStarts[i-1].Slot >= slot {
+		return fmt.Errorf("epoch %d start slot %d is not after epoch %d start slot %d",
+			epoch, slot, s.epochStarts[i-1].Epoch, s.epochStarts[i-1].Slot)
+	}
+	if i < len(s.epochStarts) && s.epochStarts[i].Slot <= slot {
+		return fmt.Errorf("epoch %d start slot %d is not before epoch %d start slot %d",
+			epoch, slot, s.epochStarts[i].Epoch, s.epochStarts[i].Slot)
+	}
+
+	epochStarts := make([]epochStart, 0, len(s.epochStarts)+1)
+	epochStarts = append(epochStarts, s.epochStarts[:i]...)
+	epochStarts = append(epochStarts, epochStart{Epoch: epoch, Slot: slot})
+	epochStarts = append(epochStarts, s.epochStarts[i:]...)
+
+	enc, err := scale.Marshal(epochStarts)
+	if err != nil {
+		return fmt.Errorf("encoding epoch start slots: %w", err)
+	}
+
+	err = s.db.Put(epochStartsKey, enc)
+	if err != nil {
+		return err
+	}
+
+	s.epochStarts = epochStarts
+	return nil
+}
+
+// GetEpochFromTime returns the epoch for a given time. A time at most
+// maxClockSkewSlots before the network start is considered in the genesis
+// epoch, to tolerate the clock of this node being behind the network.
 func (s *EpochState) GetEpochFromTime(t time.Time) (uint64, error) {
 	slotDuration, err := s.GetSlotDuration()
 	if err != nil {
@@ -502,11 +876,11 @@ func (s *EpochState) GetEpochFromTime(t time.Time) (uint64, error) {
 
 	slot := uint64(t.UnixNano()) / uint64(slotDuration.Nanoseconds())
 
-	if slot < firstSlot {
-		return 0, errors.New("given time is before network start")
+	if slot+maxClockSkewSlots < firstSlot {
+		return 0, errTimeBeforeNetwork
 	}
 
-	return (slot - firstSlot) / s.epochLength, nil
+	return s.epochForSlot(slot)
 }
 
 // SetFirstSlot sets the first slot number of the network
@@ -584,6 +958,28 @@ func (s *EpochState) FinalizeBABENextEpochData(finalizedHeader *types.Header) er
 		}
 
 		nextEpoch = finalizedBlockEpoch + 1
+
+		err = s.PruneEpochData(finalizedBlockEpoch)
+		if err != nil {
+			return fmt.Errorf("pruning epoch data before epoch %d: %w", finalizedBlockEpoch, err)
+		}
+
+		err = s.pruneVRFRandomness(finalizedBlockEpoch)
+		if err != nil {
+			return fmt.Errorf("pruning VRF randomness before epoch %d: %w", finalizedBlockEpoch, err)
+		}
+
+		// the next epoch start is stored once finalised, since the genesis
+		// epoch start may change until the first block is finalised.
+		finalizedEpochStart, err := s.GetStartSlotForEpoch(finalizedBlockEpoch)
+		if err != nil {
+			return fmt.Errorf("getting start slot for epoch %d: %w", finalizedBlockEpoch, err)
+		}
+
+		err = s.storeEpochStartSlot(nextEpoch, finalizedEpochStart+s.epochLength)
+		if err != nil {
+			return fmt.Errorf("storing start slot for epoch %d: %w", nextEpoch, err)
+		}
 	}
 
 	epochInDatabase, err := s.getEpochDataFromDatabase(nextEpoch)
@@ -0,0 +1,291 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// vrfRandomnessPrefix is the prefix of the keys of the VRF
+// randomness of the primary blocks of each epoch.
+var vrfRandomnessPrefix = []byte("vrfrand")
+
+// vrfRandomnessPrunedKey is the key of the first epoch
+// whose VRF randomness records are not pruned.
+var vrfRandomnessPrunedKey = []byte("vrfpruned")
+
+func vrfRandomnessKey(epoch uint64) []byte {
+	key := make([]byte, 0, len(vrfRandomnessPrefix)+8)
+	key = append(key, vrfRandomnessPrefix...)
+	return binary.LittleEndian.AppendUint64(key, epoch)
+}
+
+// vrfRandomnessRecord is the VRF randomness of a primary block.
+type vrfRandomnessRecord struct {
+	Hash       common.Hash
+	Randomness [types.RandomnessLength]byte
+}
+
+// StoreVRFRandomness stores the VRF randomness of the primary block with the hash
+// given in the epoch given, for it to be accumulated in the randomness of the epoch
+// after next. It is stored by block hash so the randomness accumulated on a chain
+// only ever contains the VRF randomness of the blocks of this chain.
+func (s *EpochState) StoreVRFRandomness(epoch uint64, hash common.Hash,
+	vrfRandomness [types.RandomnessLength]byte) error {
+	s.vrfRandomnessLock.Lock()
+	defer s.vrfRandomnessLock.Unlock()
+
+	records, err := s.loadVRFRandomness(epoch)
+	if err != nil {
+		return fmt.Errorf("loading VRF randomness of epoch %d: %w", epoch, err)
+	}
+
+	for _, record := range records {
+		if record.Hash == hash {
+			return nil
+		}
+	}
+
+	records = append(records, vrfRandomnessRecord{Hash: hash, Randomness: vrfRandomness})
+	return s.storeVRFRandomnessRecords(epoch, records)
+}
+
+// ComputeNextRandomness computes the randomness of the epoch after the epoch
+// following the epoch given, once the epoch given ended, as in the BABE pallet:
+// it is the Blake2b-256 hash of the randomness of the epoch following the epoch
+// given, of the little endian encoded index of the epoch after it, and of the VRF
+// randomness of the primary blocks of the epoch given, in block order.
+// The VRF randomness accumulated is the one of the primary blocks of the epoch
+// given which are ancestors of the header given or the header itself, such that
+// a reorg rolls the accumulator back to the blocks of the new chain.
+func (s *EpochState) ComputeNextRandomness(epoch uint64, header *types.Header) (
+	randomness [types.RandomnessLength]byte, err error) {
+	nextEpochData, err := s.GetEpochData(epoch+1, header)
+	if err != nil {
+		return randomness, fmt.Errorf("getting epoch data for epoch %d: %w", epoch+1, err)
+	}
+
+	vrfRandomness, err := s.accumulatedVRFRandomness(epoch, header)
+	if err != nil {
+		return randomness, fmt.Errorf("getting VRF randomness of epoch %d: %w", epoch, err)
+	}
+
+	data := make([]byte, 0, types.RandomnessLength+8+len(vrfRandomness)*types.RandomnessLength)
+	data = append(data, nextEpochData.Randomness[:]...)
+	data = binary.LittleEndian.AppendUint64(data, epoch+2)
+	for _, blockVRFRandomness := range vrfRandomness {
+		data = append(data, blockVRFRandomness[:]...)
+	}
+
+	return common.Blake2bHash(data)
+}
+
+// checkNextEpochRandomness cross-checks the randomness announced for the
+// epoch after the epoch of the header given, in its next epoch data digest,
+// against the randomness computed from the VRF randomness stored for the
+// epoch before, and logs a warning if they differ. The check is skipped for
+// the genesis epoch and if the previous epoch was skipped.
+func (s *EpochState) checkNextEpochRandomness(header *types.Header, epoch uint64,
+	announced [types.RandomnessLength]byte) {
+	if epoch == 0 || header.Number == 0 {
+		return
+	}
+
+	parent, err := s.blockState.GetHeader(header.ParentHash)
+	if err != nil {
+		logger.Debugf("cannot check randomness of epoch %d: getting parent header: %s", epoch+1, err)
+		return
+	}
+
+	parentEpoch, err := s.GetEpochForBlock(parent)
+	if err != nil {
+		logger.Debugf("cannot check randomness of epoch %d: getting parent epoch: %s", epoch+1, err)
+		return
+	} else if parentEpoch+1 != epoch {
+		return
+	}
+
+	computed, err := s.ComputeNextRandomness(parentEpoch, parent)
+	if err != nil {
+		logger.Debugf("cannot check randomness of epoch %d: %s", epoch+1, err)
+		return
+	}
+
+	if computed != announced {
+		logger.Warnf("randomness 0x%x announced for epoch %d by block %s differs from computed randomness 0x%x",
+			announced, epoch+1, header.Hash(), computed)
+	}
+}
+
+// accumulatedVRFRandomness returns the VRF randomness of the primary blocks of the
+// epoch given on the chain ending with the header given, ordered by block number.
+func (s *EpochState) accumulatedVRFRandomness(epoch uint64, header *types.Header) (
+	vrfRandomness [][types.RandomnessLength]byte, err error) {
+	s.vrfRandomnessLock.RLock()
+	records, err := s.loadVRFRandomness(epoch)
+	s.vrfRandomnessLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	blockRandomness := make(map[common.Hash][types.RandomnessLength]byte, len(records))
+	for _, record := range records {
+		blockRandomness[record.Hash] = record.Randomness
+	}
+
+	for header.Number > 0 {
+		blockEpoch, err := s.GetEpochForBlock(header)
+		if err != nil {
+			return nil, fmt.Errorf("getting epoch of block %s: %w", header.Hash(), err)
+		}
+
+		if blockEpoch < epoch {
+			break
+		}
+
+		if blockEpoch == epoch {
+			randomness, ok := blockRandomness[header.Hash()]
+			if ok {
+				vrfRandomness = append(vrfRandomness, randomness)
+			}
+		}
+
+		header, err = s.blockState.GetHeader(header.ParentHash)
+		if err != nil {
+			return nil, fmt.Errorf("getting parent header: %w", err)
+		}
+	}
+
+	for i, j := 0, len(vrfRandomness)-1; i < j; i, j = i+1, j-1 {
+		vrfRandomness[i], vrfRandomness[j] = vrfRandomness[j], vrfRandomness[i]
+	}
+
+	return vrfRandomness, nil
+}
+
+// pruneVRFRandomness deletes the VRF randomness records of the epochs before the
+// finalised epoch given, which are no longer needed since the randomness of the
+// epochs up to the epoch after the finalised epoch is final.
+func (s *EpochState) pruneVRFRandomness(finalisedEpoch uint64) error {
+	s.vrfRandomnessLock.Lock()
+	defer s.vrfRandomnessLock.Unlock()
+
+	var firstUnprunedEpoch uint64
+	encoded, err := s.db.Get(vrfRandomnessPrunedKey)
+	switch {
+	case errors.Is(err, chaindb.ErrKeyNotFound):
+	case err != nil:
+		return fmt.Errorf("getting first unpruned epoch: %w", err)
+	default:
+		firstUnprunedEpoch = binary.LittleEndian.Uint64(encoded)
+	}
+
+	if finalisedEpoch <= firstUnprunedEpoch {
+		return nil
+	}
+
+	for epoch := firstUnprunedEpoch; epoch < finalisedEpoch; epoch++ {
+		err = s.db.Del(vrfRandomnessKey(epoch))
+		if err != nil {
+			return fmt.Errorf("deleting VRF randomness of epoch %d: %w", epoch, err)
+		}
+	}
+
+	err = s.db.Put(vrfRandomnessPrunedKey, binary.LittleEndian.AppendUint64(nil, finalisedEpoch))
+	if err != nil {
+		return fmt.Errorf("storing first unpruned epoch: %w", err)
+	}
+	return nil
+}
+
+// pruneForkVRFRandomness deletes the VRF randomness records of the blocks
+// pruned from forks. It is registered as a finalisation hook of the block
+// state, so errors are logged.
+func (s *EpochState) pruneForkVRFRandomness(_, pruned []*types.Header) {
+	prunedHashes := make(map[uint64]map[common.Hash]struct{})
+	for _, header := range pruned {
+		epoch, err := s.GetEpochForBlock(header)
+		if err != nil {
+			logger.Debugf("getting epoch of pruned block %s: %s", header.Hash(), err)
+			continue
+		}
+
+		if prunedHashes[epoch] == nil {
+			prunedHashes[epoch] = make(map[common.Hash]struct{})
+		}
+		prunedHashes[epoch][header.Hash()] = struct{}{}
+	}
+
+	s.vrfRandomnessLock.Lock()
+	defer s.vrfRandomnessLock.Unlock()
+
+	for epoch, hashes := range prunedHashes {
+		err := s.deleteVRFRandomness(epoch, hashes)
+		if err != nil {
+			logger.Errorf("deleting VRF randomness of pruned blocks of epoch %d: %s", epoch, err)
+		}
+	}
+}
+
+// deleteVRFRandomness deletes the VRF randomness records of the blocks with
+// the hashes given in the epoch given. It must be called with the lock held.
+func (s *EpochState) deleteVRFRandomness(epoch uint64, hashes map[common.Hash]struct{}) error {
+	records, err := s.loadVRFRandomness(epoch)
+	if err != nil {
+		return err
+	}
+
+	kept := records[:0]
+	for _, record := range records {
+		if _, ok := hashes[record.Hash]; !ok {
+			kept = append(kept, record)
+		}
+	}
+
+	if len(kept) == len(records) {
+		return nil
+	} else if len(kept) == 0 {
+		return s.db.Del(vrfRandomnessKey(epoch))
+	}
+	return s.storeVRFRandomnessRecords(epoch, kept)
+}
+
+// loadVRFRandomness returns the VRF randomness records of the epoch given,
+// in the order they are stored. It must be called with the lock held.
+func (s *EpochState) loadVRFRandomness(epoch uint64) (records []vrfRandomnessRecord, err error) {
+	encoded, err := s.db.Get(vrfRandomnessKey(epoch))
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting from database: %w", err)
+	}
+
+	err = scale.Unmarshal(encoded, &records)
+	if err != nil {
+		return nil, fmt.Errorf("decoding VRF randomness records: %w", err)
+	}
+	return records, nil
+}
+
+// storeVRFRandomnessRecords stores the VRF randomness records of the
+// epoch given. It must be called with the lock held.
+func (s *EpochState) storeVRFRandomnessRecords(epoch uint64, records []vrfRandomnessRecord) error {
+	encoded, err := scale.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("encoding VRF randomness records: %w", err)
+	}
+
+	err = s.db.Put(vrfRandomnessKey(epoch), encoded)
+	if err != nil {
+		return fmt.Errorf("putting VRF randomness in database: %w", err)
+	}
+	return nil
+}
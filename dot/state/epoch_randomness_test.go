@@ -0,0 +1,131 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpochState_ComputeNextRandomness(t *testing.T) {
+	db := NewInMemoryDB(t)
+	blockState := newTestBlockState(t, newTriesEmpty())
+	s, err := NewEpochStateFromGenesis(db, blockState, genesisBABEConfig)
+	require.NoError(t, err)
+
+	// the epoch length is 200 slots and the first slot is 1
+	newBlock := func(parent *types.Header, slot uint64, vrfRandomness *[types.RandomnessLength]byte) *types.Header {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, slot).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		digest := types.NewDigest()
+		require.NoError(t, digest.Add(*preRuntimeDigest))
+
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			Digest:     digest,
+		}
+		err = blockState.AddBlock(&types.Block{Header: *header, Body: types.Body{}})
+		require.NoError(t, err)
+
+		if vrfRandomness != nil {
+			epoch, err := s.GetEpochForBlock(header)
+			require.NoError(t, err)
+			err = s.StoreVRFRandomness(epoch, header.Hash(), *vrfRandomness)
+			require.NoError(t, err)
+		}
+		return header
+	}
+
+	announce := func(header *types.Header, randomness byte) {
+		nextEpochData := types.NewBabeConsensusDigest()
+		err := nextEpochData.Set(types.NextEpochData{Randomness: [32]byte{randomness}})
+		require.NoError(t, err)
+		err = s.HandleBABEDigest(header, nextEpochData)
+		require.NoError(t, err)
+	}
+
+	block1 := newBlock(&testGenesisHeader, 1, &[32]byte{0x10})
+	announce(block1, 0x1)
+
+	// two forks in epoch 1, announcing the same randomness for epoch 2
+	blockA2 := newBlock(block1, 201, &[32]byte{0xa2})
+	announce(blockA2, 0xa)
+	blockA3 := newBlock(blockA2, 202, &[32]byte{0xa3})
+	blockA4 := newBlock(blockA3, 401, &[32]byte{0xa4})
+
+	blockB2 := newBlock(block1, 203, &[32]byte{0xb2})
+	announce(blockB2, 0xa)
+	blockB3 := newBlock(blockB2, 204, nil)
+
+	// The expected values are computed independently of this implementation,
+	// with the compute_randomness function of the BABE pallet:
+	// Blake2b-256(randomness of epoch 2 ++ LE(3) ++ VRF randomness of epoch 1).
+	expectedA := common.MustHexToHash("0x3386d0c32fae5e55d69fa9caf70e37fb152deeca5b6fcd9140120a63aa7ab606")
+	expectedB := common.MustHexToHash("0xa47d01947f2c129cba7573e3734a62b2e078a5e4861d652d8a1ecd4f4e0e8e29")
+	expectedEmpty := common.MustHexToHash("0x412b79347dece5a2c76fea2ed3befffe7a68d8fad3268d8cc10c4f640eb40330")
+
+	// blocks of epoch 0 and epoch 2 are not accumulated
+	randomness, err := s.ComputeNextRandomness(1, blockA4)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(expectedA), randomness)
+
+	randomness, err = s.ComputeNextRandomness(1, blockA3)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(expectedA), randomness)
+
+	// the accumulator of fork B only contains the VRF randomness of its primary blocks
+	randomness, err = s.ComputeNextRandomness(1, blockB3)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(expectedB), randomness)
+
+	// an epoch without primary blocks only hashes the randomness and epoch index
+	blockC2 := newBlock(block1, 205, nil)
+	announce(blockC2, 0xa)
+	randomness, err = s.ComputeNextRandomness(1, blockC2)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(expectedEmpty), randomness)
+
+	// finalising block A2 prunes the VRF randomness of the epochs
+	// before its epoch and of the blocks pruned from forks.
+	err = blockState.SetFinalisedHash(blockA2.Hash(), 1, 0)
+	require.NoError(t, err)
+	err = s.FinalizeBABENextEpochData(blockA2)
+	require.NoError(t, err)
+
+	records, err := s.loadVRFRandomness(0)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	records, err = s.loadVRFRandomness(1)
+	require.NoError(t, err)
+	assert.Equal(t, []vrfRandomnessRecord{
+		{Hash: blockA2.Hash(), Randomness: [32]byte{0xa2}},
+		{Hash: blockA3.Hash(), Randomness: [32]byte{0xa3}},
+	}, records)
+
+	// the accumulated VRF randomness is persisted across restarts
+	restarted, err := NewEpochState(db, blockState)
+	require.NoError(t, err)
+	randomness, err = restarted.ComputeNextRandomness(1, blockA4)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(expectedA), randomness)
+
+	// storing the VRF randomness of a block again does not accumulate it twice
+	err = restarted.StoreVRFRandomness(1, blockA3.Hash(), [32]byte{0xa3})
+	require.NoError(t, err)
+	randomness, err = restarted.ComputeNextRandomness(1, blockA4)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(expectedA), randomness)
+
+	// once a block of epoch 2 is final, the VRF randomness of epoch 1 is pruned
+	err = restarted.pruneVRFRandomness(2)
+	require.NoError(t, err)
+	records, err = restarted.loadVRFRandomness(1)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -83,6 +83,80 @@ func TestEpochState_EpochData(t *testing.T) {
 	}
 }
 
+func TestEpochState_EpochDataPersistence(t *testing.T) {
+	db := NewInMemoryDB(t)
+	blockState := newTestBlockState(t, newTriesEmpty())
+	s, err := NewEpochStateFromGenesis(db, blockState, genesisBABEConfig)
+	require.NoError(t, err)
+
+	keyring, err := keystore.NewSr25519Keyring()
+	require.NoError(t, err)
+
+	epochsData := map[uint64]*types.EpochData{
+		1: {
+			Authorities: []types.Authority{
+				{Key: keyring.Alice().Public().(*sr25519.PublicKey), Weight: 1},
+				{Key: keyring.Bob().Public().(*sr25519.PublicKey), Weight: 2},
+			},
+			Randomness: [32]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+				17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32},
+		},
+		2: {
+			Authorities: []types.Authority{
+				{Key: keyring.Charlie().Public().(*sr25519.PublicKey), Weight: 3},
+			},
+			Randomness: [32]byte{0xff, 31: 0xee},
+		},
+	}
+
+	// the data of a future epoch can be stored before the data of the epoch before it
+	for _, epoch := range []uint64{2, 1} {
+		has, err := s.HasEpochData(epoch)
+		require.NoError(t, err)
+		require.False(t, has)
+
+		err = s.SetEpochData(epoch, epochsData[epoch])
+		require.NoError(t, err)
+
+		has, err = s.HasEpochData(epoch)
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	// epoch data survive a restart
+	s, err = NewEpochState(db, blockState)
+	require.NoError(t, err)
+
+	for epoch, expected := range epochsData {
+		data, err := s.GetEpochData(epoch, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected.Randomness, data.Randomness)
+		require.Len(t, data.Authorities, len(expected.Authorities))
+		for i, authority := range data.Authorities {
+			require.Equal(t, expected.Authorities[i].Weight, authority.Weight)
+			require.Equal(t, expected.Authorities[i].Key.Encode(), authority.Key.Encode())
+		}
+	}
+
+	// the epoch data before the finalised epoch is pruned
+	err = s.PruneEpochData(2)
+	require.NoError(t, err)
+	for epoch, expected := range map[uint64]bool{0: false, 1: false, 2: true} {
+		has, err := s.HasEpochData(epoch)
+		require.NoError(t, err)
+		require.Equal(t, expected, has)
+	}
+	_, err = s.GetEpochData(1, nil)
+	require.ErrorIs(t, err, errEpochNotInDatabase)
+
+	// pruning again before the same epoch is a no-op
+	err = s.PruneEpochData(2)
+	require.NoError(t, err)
+	has, err := s.HasEpochData(2)
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
 func TestEpochState_GetStartSlotForEpoch(t *testing.T) {
 	s := newEpochStateFromGenesis(t)
 
@@ -134,6 +208,59 @@ func TestEpochState_ConfigData(t *testing.T) {
 	require.Equal(t, data, ret)
 }
 
+func TestEpochState_ConfigDataChange(t *testing.T) {
+	s := newEpochStateFromGenesis(t)
+
+	genesisConfigData := &types.ConfigData{
+		C1:             genesisBABEConfig.C1,
+		C2:             genesisBABEConfig.C2,
+		SecondarySlots: genesisBABEConfig.SecondarySlots,
+	}
+
+	// a config change announced in epoch 1 applies from epoch 2
+	changedConfigData := &types.ConfigData{
+		C1:             1,
+		C2:             2,
+		SecondarySlots: 2,
+	}
+	err := s.SetConfigData(2, changedConfigData)
+	require.NoError(t, err)
+
+	testCases := map[uint64]*types.ConfigData{
+		0:         genesisConfigData,
+		1:         genesisConfigData,
+		2:         changedConfigData,
+		3:         changedConfigData,
+		1_000_000: changedConfigData,
+	}
+	for epoch, expected := range testCases {
+		configData, err := s.GetConfigData(epoch, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, configData, "epoch %d", epoch)
+	}
+
+	// config data set for an older epoch does not change the latest config data
+	olderConfigData := &types.ConfigData{
+		C1:             3,
+		C2:             4,
+		SecondarySlots: 1,
+	}
+	err = s.SetConfigData(1, olderConfigData)
+	require.NoError(t, err)
+
+	configData, err := s.GetConfigData(1, nil)
+	require.NoError(t, err)
+	require.Equal(t, olderConfigData, configData)
+
+	configData, err = s.GetConfigData(1_000_000, nil)
+	require.NoError(t, err)
+	require.Equal(t, changedConfigData, configData)
+
+	configData, err = s.GetLatestConfigData()
+	require.NoError(t, err)
+	require.Equal(t, changedConfigData, configData)
+}
+
 func TestEpochState_GetEpochForBlock(t *testing.T) {
 	s := newEpochStateFromGenesis(t)
 
@@ -181,6 +308,54 @@ func TestEpochState_SetAndGetSlotDuration(t *testing.T) {
 	require.Equal(t, expected, ret)
 }
 
+func TestEpochState_CheckBABEConfiguration(t *testing.T) {
+	s := newEpochStateFromGenesis(t)
+
+	// the configuration reported by the runtime is the stored one
+	err := s.CheckBABEConfiguration(genesisBABEConfig)
+	require.NoError(t, err)
+	epochLength, err := s.GetEpochLength()
+	require.NoError(t, err)
+	require.Equal(t, genesisBABEConfig.EpochLength, epochLength)
+
+	// the configuration reported by the runtime is preferred to the stored one
+	runtimeConfig := *genesisBABEConfig
+	runtimeConfig.EpochLength = 50
+	runtimeConfig.SlotDuration = 2000
+	err = s.CheckBABEConfiguration(&runtimeConfig)
+	require.NoError(t, err)
+
+	epochLength, err = s.GetEpochLength()
+	require.NoError(t, err)
+	require.Equal(t, uint64(50), epochLength)
+	slotDuration, err := s.GetSlotDuration()
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, slotDuration)
+
+	// the epoch computations use the stored configuration, with the first slot 1
+	start, err := s.GetStartSlotForEpoch(3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(151), start)
+
+	for slot, expectedEpoch := range map[uint64]uint64{1: 0, 50: 0, 51: 1, 120: 2, 151: 3} {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, slot).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		digest := types.NewDigest()
+		require.NoError(t, digest.Add(*preRuntimeDigest))
+
+		epoch, err := s.GetEpochForBlock(&types.Header{Number: 1, Digest: digest})
+		require.NoError(t, err)
+		require.Equal(t, expectedEpoch, epoch, "epoch of slot %d", slot)
+	}
+
+	epoch, err := s.GetEpochFromTime(time.UnixMilli(151 * 2000))
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), epoch)
+
+	err = s.StoreEpochLength(0)
+	require.ErrorIs(t, err, errEpochLengthZero)
+}
+
 func TestEpochState_GetEpochFromTime(t *testing.T) {
 	s := newEpochStateFromGenesis(t)
 	s.blockState = newTestBlockState(t, newTriesEmpty())
@@ -223,6 +398,261 @@ func TestEpochState_GetEpochFromTime(t *testing.T) {
 	require.Equal(t, uint64(99), epoch)
 }
 
+func TestEpochState_irregularEpochStarts(t *testing.T) {
+	db := NewInMemoryDB(t)
+	blockState := newTestBlockState(t, newTriesEmpty())
+	s, err := NewEpochStateFromGenesis(db, blockState, genesisBABEConfig)
+	require.NoError(t, err)
+
+	// the genesis epoch starts at the slot of the first block
+	const firstSlot = 1000
+	err = s.SetFirstSlot(firstSlot)
+	require.NoError(t, err)
+
+	// epochs 2 and 5 start later than epoch length slots after the previous epoch
+	require.NoError(t, s.storeEpochStartSlot(5, 2200))
+	require.NoError(t, s.storeEpochStartSlot(2, 1450))
+	require.NoError(t, s.storeEpochStartSlot(2, 1450))
+	require.Error(t, s.storeEpochStartSlot(2, 1460))
+	require.Error(t, s.storeEpochStartSlot(3, 1450))
+	require.Error(t, s.storeEpochStartSlot(6, 2100))
+
+	newHeader := func(slot uint64) *types.Header {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, slot).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		digest := types.NewDigest()
+		require.NoError(t, digest.Add(*preRuntimeDigest))
+		return &types.Header{Digest: digest}
+	}
+
+	slotDuration := time.Millisecond * time.Duration(genesisBABEConfig.SlotDuration)
+	slotTime := func(slot uint64) time.Time {
+		return time.Unix(0, int64(slot)*slotDuration.Nanoseconds())
+	}
+
+	epochStartSlots := []uint64{1000, 1200, 1450, 1650, 1850, 2200, 2400}
+	slotEpochs := map[uint64]uint64{
+		999:  0,
+		1000: 0,
+		1199: 0,
+		1200: 1,
+		1449: 1,
+		1450: 2,
+		1650: 3,
+		2199: 4,
+		2200: 5,
+		2400: 6,
+		5000: 19,
+	}
+
+	assertEpochs := func(t *testing.T, s *EpochState) {
+		t.Helper()
+
+		for epoch, expected := range epochStartSlots {
+			start, err := s.GetStartSlotForEpoch(uint64(epoch))
+			require.NoError(t, err)
+			require.Equal(t, expected, start, "start slot of epoch %d", epoch)
+		}
+
+		for slot, expected := range slotEpochs {
+			epoch, err := s.GetEpochForBlock(newHeader(slot))
+			require.NoError(t, err)
+			require.Equal(t, expected, epoch, "epoch of block at slot %d", slot)
+
+			epoch, err = s.GetEpochFromTime(slotTime(slot))
+			require.NoError(t, err)
+			require.Equal(t, expected, epoch, "epoch of time at slot %d", slot)
+		}
+
+		// the end of the slot before an epoch start is still in the previous epoch
+		epoch, err := s.GetEpochFromTime(slotTime(1450).Add(-time.Nanosecond))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), epoch)
+
+		// a clock more than one slot behind the network start is not tolerated
+		_, err = s.GetEpochFromTime(slotTime(firstSlot - 2))
+		require.ErrorIs(t, err, errTimeBeforeNetwork)
+	}
+
+	assertEpochs(t, s)
+
+	// epoch start slots survive a restart
+	s, err = NewEpochState(db, blockState)
+	require.NoError(t, err)
+	assertEpochs(t, s)
+}
+
+func TestEpochState_skippedEpochs(t *testing.T) {
+	s := newEpochStateFromGenesis(t)
+
+	parent := &testGenesisHeader
+	newBlock := func(slot uint64) *types.Header {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, slot).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		digest := types.NewDigest()
+		require.NoError(t, digest.Add(*preRuntimeDigest))
+
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			Digest:     digest,
+		}
+		parent = header
+		return header
+	}
+
+	importBlock := func(header *types.Header, randomness byte) {
+		err := s.blockState.AddBlock(&types.Block{Header: *header, Body: types.Body{}})
+		require.NoError(t, err)
+
+		nextEpochData := types.NewBabeConsensusDigest()
+		err = nextEpochData.Set(types.NextEpochData{Randomness: [32]byte{randomness}})
+		require.NoError(t, err)
+		err = s.HandleBABEDigest(header, nextEpochData)
+		require.NoError(t, err)
+	}
+
+	finaliseBlock := func(header *types.Header) {
+		err := s.blockState.SetFinalisedHash(header.Hash(), 0, 0)
+		require.NoError(t, err)
+		err = s.FinalizeBABENextEpochData(header)
+		require.NoError(t, err)
+	}
+
+	requireRandomness := func(epoch uint64, header *types.Header, randomness byte) {
+		t.Helper()
+		epochData, err := s.GetEpochData(epoch, header)
+		require.NoError(t, err)
+		require.Equal(t, [32]byte{randomness}, epochData.Randomness, "randomness of epoch %d", epoch)
+	}
+
+	// epoch e is from slot 200e+1 to slot 200e+200
+	block1 := newBlock(1)
+	importBlock(block1, 1)
+	block2 := newBlock(250)
+	importBlock(block2, 2)
+	finaliseBlock(block1)
+	finaliseBlock(block2)
+
+	// no block is produced for 2.5 epochs, so no epoch data is announced for epoch 3
+	block3 := newBlock(750)
+	epoch, err := s.GetEpochForBlock(block3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), epoch)
+
+	// the block after the stall is verified with the epoch data of the last announced epoch
+	requireRandomness(3, block3, 2)
+	importBlock(block3, 4)
+
+	// the epoch data announced by the block after the stall is for the epoch after it
+	block4 := newBlock(801)
+	epoch, err = s.GetEpochForBlock(block4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), epoch)
+	requireRandomness(4, block4, 4)
+
+	finaliseBlock(block3)
+	requireRandomness(3, block3, 2)
+	requireRandomness(4, nil, 4)
+
+	// the fallback only applies to skipped epochs
+	_, err = s.GetEpochData(3, nil)
+	require.ErrorIs(t, err, errEpochNotInDatabase)
+	_, err = s.GetEpochData(5, block4)
+	require.ErrorIs(t, err, ErrEpochNotInMemory)
+
+	start, err := s.GetStartSlotForEpoch(4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(801), start)
+
+	// the epoch data used by the skipped epoch is not pruned
+	for e, expected := range map[uint64]bool{0: false, 1: false, 2: true, 3: false, 4: true} {
+		has, err := s.HasEpochData(e)
+		require.NoError(t, err)
+		require.Equal(t, expected, has, "epoch %d", e)
+	}
+}
+
+func TestEpochState_GetEpochDataForBlock(t *testing.T) {
+	s := newEpochStateFromGenesis(t)
+	keyring, err := keystore.NewSr25519Keyring()
+	require.NoError(t, err)
+
+	newBlock := func(parent *types.Header, slot uint64) *types.Header {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, slot).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		digest := types.NewDigest()
+		require.NoError(t, digest.Add(*preRuntimeDigest))
+
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			Digest:     digest,
+		}
+		err = s.blockState.AddBlock(&types.Block{Header: *header, Body: types.Body{}})
+		require.NoError(t, err)
+		return header
+	}
+
+	announce := func(header *types.Header, authority *sr25519.Keypair, randomness byte) {
+		nextEpochData := types.NewBabeConsensusDigest()
+		err := nextEpochData.Set(types.NextEpochData{
+			Authorities: []types.AuthorityRaw{
+				{Key: authority.Public().(*sr25519.PublicKey).AsBytes(), Weight: 1},
+			},
+			Randomness: [32]byte{randomness},
+		})
+		require.NoError(t, err)
+		err = s.HandleBABEDigest(header, nextEpochData)
+		require.NoError(t, err)
+	}
+
+	finaliseBlock := func(header *types.Header) {
+		err := s.blockState.SetFinalisedHash(header.Hash(), 0, 0)
+		require.NoError(t, err)
+		err = s.FinalizeBABENextEpochData(header)
+		require.NoError(t, err)
+	}
+
+	requireRandomness := func(epoch uint64, header *types.Header, randomness byte) {
+		t.Helper()
+		epochData, err := s.GetEpochDataForBlock(epoch, header)
+		require.NoError(t, err)
+		require.Equal(t, [32]byte{randomness}, epochData.Randomness, "randomness of epoch %d", epoch)
+	}
+
+	block1 := newBlock(&testGenesisHeader, 1)
+	announce(block1, keyring.KeyAlice, 1)
+	finaliseBlock(block1)
+
+	// two forks in epoch 1 announce different authorities for epoch 2
+	blockA2 := newBlock(block1, 201)
+	announce(blockA2, keyring.KeyAlice, 0xa)
+	blockB2 := newBlock(block1, 202)
+	announce(blockB2, keyring.KeyBob, 0xb)
+	blockA3 := newBlock(blockA2, 401)
+	blockB3 := newBlock(blockB2, 402)
+
+	// each block of epoch 2 uses the epoch data announced by its ancestor
+	requireRandomness(2, blockA3, 0xa)
+	requireRandomness(2, blockB3, 0xb)
+	epochDataB, err := s.GetEpochDataForBlock(2, blockB3)
+	require.NoError(t, err)
+	require.Len(t, epochDataB.Authorities, 1)
+	require.Equal(t, keyring.KeyBob.Public().Encode(), epochDataB.Authorities[0].Key.Encode())
+
+	// finalising fork A keeps its epoch data and deletes the candidates of fork B
+	finaliseBlock(blockA2)
+	require.NotContains(t, s.nextEpochData, uint64(2))
+	requireRandomness(2, blockA3, 0xa)
+	epochData, err := s.GetEpochData(2, nil)
+	require.NoError(t, err)
+	require.Equal(t, [32]byte{0xa}, epochData.Randomness)
+
+	_, err = s.GetEpochDataForBlock(2, nil)
+	require.EqualError(t, err, "header is nil")
+}
+
 type inMemoryBABEData[T any] struct {
 	epoch    uint64
 	hashes   []common.Hash
@@ -0,0 +1,297 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// ErrEquivocationNotFound is returned when no equivocation
+// is stored for a slot and authority index.
+var ErrEquivocationNotFound = errors.New("equivocation not found")
+
+var errEquivocationSignerNotFound = errors.New("signer of equivocating header not found")
+
+var (
+	equivocationPrefix = []byte("equivocation")
+	equivocationsKey   = []byte("equivocations")
+)
+
+// equivocationID identifies the equivocation of an authority in a slot.
+type equivocationID struct {
+	Slot           uint64
+	AuthorityIndex uint32
+}
+
+// equivocationRecord is the database record of an equivocation.
+type equivocationRecord struct {
+	AuthorityIndex uint32
+	Proof          types.BabeEquivocationProof
+	Reported       bool
+}
+
+func equivocationKey(id equivocationID) []byte {
+	key := make([]byte, 0, len(equivocationPrefix)+8+4)
+	key = append(key, equivocationPrefix...)
+	key = binary.LittleEndian.AppendUint64(key, id.Slot)
+	return binary.LittleEndian.AppendUint32(key, id.AuthorityIndex)
+}
+
+// StoreEquivocation stores the two headers produced by the authority at the index given
+// in the slot given, and marks the equivocation as unreported. The offender is the signer
+// of the first header, as recorded when it was checked by CheckEquivocation. It returns
+// true if the equivocation is new, and false if an equivocation of the same authority in
+// the same slot is already stored, in which case it is left unchanged.
+func (s *SlotState) StoreEquivocation(slot uint64, authorityIndex uint32,
+	firstHeader, secondHeader *types.Header) (isNew bool, err error) {
+	s.equivocationsLock.Lock()
+	defer s.equivocationsLock.Unlock()
+
+	id := equivocationID{Slot: slot, AuthorityIndex: authorityIndex}
+	has, err := s.db.Has(equivocationKey(id))
+	if err != nil {
+		return false, fmt.Errorf("checking equivocation in database: %w", err)
+	} else if has {
+		return false, nil
+	}
+
+	offender, err := s.getSigner(slot, firstHeader)
+	if err != nil {
+		return false, err
+	}
+
+	record := equivocationRecord{
+		AuthorityIndex: authorityIndex,
+		Proof: types.BabeEquivocationProof{
+			Offender:     offender,
+			Slot:         slot,
+			FirstHeader:  *firstHeader,
+			SecondHeader: *secondHeader,
+		},
+	}
+	encodedRecord, err := scale.Marshal(record)
+	if err != nil {
+		return false, fmt.Errorf("encoding equivocation: %w", err)
+	}
+
+	ids, err := s.loadEquivocationIDs()
+	if err != nil {
+		return false, err
+	}
+
+	encodedIDs, err := scale.Marshal(append(ids, id))
+	if err != nil {
+		return false, fmt.Errorf("encoding equivocations: %w", err)
+	}
+
+	batch := s.db.NewBatch()
+	err = batch.Put(equivocationKey(id), encodedRecord)
+	if err != nil {
+		return false, fmt.Errorf("putting equivocation in batch: %w", err)
+	}
+
+	err = batch.Put(equivocationsKey, encodedIDs)
+	if err != nil {
+		return false, fmt.Errorf("putting equivocations in batch: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return false, fmt.Errorf("flushing batch: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetUnreportedEquivocations returns the proofs of the equivocations stored
+// whose report is not yet included on-chain, in the order they were stored.
+func (s *SlotState) GetUnreportedEquivocations() (proofs []types.BabeEquivocationProof, err error) {
+	s.equivocationsLock.Lock()
+	defer s.equivocationsLock.Unlock()
+
+	ids, err := s.loadEquivocationIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		record, err := s.getEquivocationRecord(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !record.Reported {
+			proofs = append(proofs, record.Proof)
+		}
+	}
+
+	return proofs, nil
+}
+
+// SetEquivocationReported marks the equivocation of the authority at the index
+// given in the slot given as reported, once its report extrinsic is included
+// on-chain, so it is no longer returned by GetUnreportedEquivocations.
+func (s *SlotState) SetEquivocationReported(slot uint64, authorityIndex uint32) error {
+	s.equivocationsLock.Lock()
+	defer s.equivocationsLock.Unlock()
+
+	id := equivocationID{Slot: slot, AuthorityIndex: authorityIndex}
+	record, err := s.getEquivocationRecord(id)
+	if err != nil {
+		return err
+	}
+
+	if record.Reported {
+		return nil
+	}
+	record.Reported = true
+
+	encodedRecord, err := scale.Marshal(*record)
+	if err != nil {
+		return fmt.Errorf("encoding equivocation: %w", err)
+	}
+
+	err = s.db.Put(equivocationKey(id), encodedRecord)
+	if err != nil {
+		return fmt.Errorf("putting equivocation in database: %w", err)
+	}
+
+	return nil
+}
+
+// pruneEquivocations deletes the equivocations stored for the slots before the
+// slot given, which are out of the equivocation detection window. Equivocations
+// not yet reported are given up on.
+func (s *SlotState) pruneEquivocations(firstSlot uint64) error {
+	s.equivocationsLock.Lock()
+	defer s.equivocationsLock.Unlock()
+
+	ids, err := s.loadEquivocationIDs()
+	if err != nil {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	kept := make([]equivocationID, 0, len(ids))
+	for _, id := range ids {
+		if id.Slot >= firstSlot {
+			kept = append(kept, id)
+			continue
+		}
+
+		record, err := s.getEquivocationRecord(id)
+		if err != nil {
+			return err
+		}
+
+		if !record.Reported {
+			logger.Warnf("pruning equivocation of authority %d in slot %d which is not reported on-chain",
+				id.AuthorityIndex, id.Slot)
+		}
+
+		err = batch.Del(equivocationKey(id))
+		if err != nil {
+			return fmt.Errorf("deleting equivocation in batch: %w", err)
+		}
+	}
+
+	if len(kept) == len(ids) {
+		return nil
+	}
+
+	encodedIDs, err := scale.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("encoding equivocations: %w", err)
+	}
+
+	err = batch.Put(equivocationsKey, encodedIDs)
+	if err != nil {
+		return fmt.Errorf("putting equivocations in batch: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("flushing batch: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SlotState) getEquivocationRecord(id equivocationID) (record *equivocationRecord, err error) {
+	encoded, err := s.db.Get(equivocationKey(id))
+	if err != nil {
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return nil, fmt.Errorf("%w: for authority %d in slot %d",
+				ErrEquivocationNotFound, id.AuthorityIndex, id.Slot)
+		}
+		return nil, fmt.Errorf("getting equivocation from database: %w", err)
+	}
+
+	// the headers should be created empty so their
+	// digests can be decoded correctly
+	record = &equivocationRecord{
+		Proof: types.BabeEquivocationProof{
+			FirstHeader:  *types.NewEmptyHeader(),
+			SecondHeader: *types.NewEmptyHeader(),
+		},
+	}
+	err = scale.Unmarshal(encoded, record)
+	if err != nil {
+		return nil, fmt.Errorf("decoding equivocation: %w", err)
+	}
+
+	return record, nil
+}
+
+// getSigner returns the signer of the header given
+// stored by CheckEquivocation for the slot given.
+func (s *SlotState) getSigner(slot uint64, header *types.Header) (signer types.AuthorityID, err error) {
+	slotEncoded := make([]byte, 8)
+	binary.LittleEndian.PutUint64(slotEncoded, slot)
+	slotKey := bytes.Join([][]byte{slotHeaderMapKey, slotEncoded}, nil)
+
+	encodedHeadersWithSigners, err := s.db.Get(slotKey)
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return signer, fmt.Errorf("getting key slot header map key %d: %w", slot, err)
+	}
+
+	headersWithSigners, err := decodeHeadersWithSigners(encodedHeadersWithSigners)
+	if err != nil {
+		return signer, err
+	}
+
+	headerHash := header.Hash()
+	for _, headerAndSigner := range headersWithSigners {
+		if headerAndSigner.Header.Hash() == headerHash {
+			return headerAndSigner.Signer, nil
+		}
+	}
+
+	return signer, fmt.Errorf("%w: for block hash %s in slot %d",
+		errEquivocationSignerNotFound, headerHash, slot)
+}
+
+func (s *SlotState) loadEquivocationIDs() (ids []equivocationID, err error) {
+	encoded, err := s.db.Get(equivocationsKey)
+	if err != nil {
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting equivocations from database: %w", err)
+	}
+
+	err = scale.Unmarshal(encoded, &ids)
+	if err != nil {
+		return nil, fmt.Errorf("decoding equivocations: %w", err)
+	}
+
+	return ids, nil
+}
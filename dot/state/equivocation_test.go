@@ -0,0 +1,148 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotState_StoreEquivocation(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	db, err := utils.SetupDatabase(dataDir, false)
+	require.NoError(t, err)
+	slotState := NewSlotState(db)
+
+	kr, err := keystore.NewSr25519Keyring()
+	require.NoError(t, err)
+	aliceAuthorityID := types.AuthorityID(kr.KeyAlice.Public().(*sr25519.PublicKey).AsBytes())
+
+	const slot = uint64(2)
+	header1 := createHeader(t, 1)
+	header2 := createHeader(t, 1)
+
+	equivocationProof, err := slotState.CheckEquivocation(slot, slot, header1, aliceAuthorityID)
+	require.NoError(t, err)
+	require.Nil(t, equivocationProof)
+	equivocationProof, err = slotState.CheckEquivocation(slot, slot, header2, aliceAuthorityID)
+	require.NoError(t, err)
+	require.NotNil(t, equivocationProof)
+
+	const authorityIndex = uint32(3)
+	isNew, err := slotState.StoreEquivocation(slot, authorityIndex,
+		&equivocationProof.FirstHeader, &equivocationProof.SecondHeader)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+
+	record, err := slotState.getEquivocationRecord(equivocationID{Slot: slot, AuthorityIndex: authorityIndex})
+	require.NoError(t, err)
+	assert.Equal(t, authorityIndex, record.AuthorityIndex)
+	assert.Equal(t, *equivocationProof, record.Proof)
+
+	requireUnreported := func(s *SlotState, want []*types.Header) {
+		t.Helper()
+		proofs, err := s.GetUnreportedEquivocations()
+		require.NoError(t, err)
+		require.Len(t, proofs, len(want)/2)
+		for i, proof := range proofs {
+			assert.Equal(t, slot, proof.Slot)
+			assert.Equal(t, aliceAuthorityID, proof.Offender)
+			assert.Equal(t, want[2*i].Hash(), proof.FirstHeader.Hash())
+			assert.Equal(t, want[2*i+1].Hash(), proof.SecondHeader.Hash())
+		}
+	}
+	requireUnreported(slotState, []*types.Header{header1, header2})
+
+	// detecting the same pair again after reopening the
+	// database does not store it again
+	err = db.Close()
+	require.NoError(t, err)
+	db, err = utils.SetupDatabase(dataDir, false)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := db.Close()
+		require.NoError(t, err)
+	})
+
+	reopened := NewSlotState(db)
+	equivocationProof, err = reopened.CheckEquivocation(slot, slot, header2, aliceAuthorityID)
+	require.NoError(t, err)
+	require.NotNil(t, equivocationProof)
+	isNew, err = reopened.StoreEquivocation(slot, authorityIndex,
+		&equivocationProof.FirstHeader, &equivocationProof.SecondHeader)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	requireUnreported(reopened, []*types.Header{header1, header2})
+
+	// a reported equivocation is no longer returned, nor stored again
+	err = reopened.SetEquivocationReported(slot, authorityIndex)
+	require.NoError(t, err)
+	err = reopened.SetEquivocationReported(slot, authorityIndex)
+	require.NoError(t, err)
+	requireUnreported(reopened, nil)
+
+	isNew, err = reopened.StoreEquivocation(slot, authorityIndex,
+		&equivocationProof.FirstHeader, &equivocationProof.SecondHeader)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	requireUnreported(reopened, nil)
+
+	err = reopened.SetEquivocationReported(slot+1, authorityIndex)
+	assert.ErrorIs(t, err, ErrEquivocationNotFound)
+
+	// the offender is the signer of the first header checked in the slot
+	_, err = reopened.StoreEquivocation(slot+1, authorityIndex, header1, header2)
+	assert.ErrorIs(t, err, errEquivocationSignerNotFound)
+}
+
+func TestSlotState_pruneEquivocations(t *testing.T) {
+	t.Parallel()
+
+	slotState := NewSlotState(NewInMemoryDB(t))
+
+	kr, err := keystore.NewSr25519Keyring()
+	require.NoError(t, err)
+	aliceAuthorityID := types.AuthorityID(kr.KeyAlice.Public().(*sr25519.PublicKey).AsBytes())
+
+	storeEquivocation := func(slot uint64) {
+		t.Helper()
+		header1 := createHeader(t, 1)
+		header2 := createHeader(t, 1)
+		_, err := slotState.CheckEquivocation(slot, slot, header1, aliceAuthorityID)
+		require.NoError(t, err)
+		equivocationProof, err := slotState.CheckEquivocation(slot, slot, header2, aliceAuthorityID)
+		require.NoError(t, err)
+		require.NotNil(t, equivocationProof)
+		_, err = slotState.StoreEquivocation(slot, 0,
+			&equivocationProof.FirstHeader, &equivocationProof.SecondHeader)
+		require.NoError(t, err)
+	}
+
+	storeEquivocation(1)
+	storeEquivocation(2)
+
+	proofs, err := slotState.GetUnreportedEquivocations()
+	require.NoError(t, err)
+	require.Len(t, proofs, 2)
+
+	// checking a header at a slot reaching the pruning bound prunes
+	// the equivocations of the slots out of the detection window
+	storeEquivocation(1 + pruningBound)
+
+	proofs, err = slotState.GetUnreportedEquivocations()
+	require.NoError(t, err)
+	require.Len(t, proofs, 1)
+	assert.Equal(t, 1+pruningBound, proofs[0].Slot)
+
+	_, err = slotState.getEquivocationRecord(equivocationID{Slot: 1, AuthorityIndex: 0})
+	assert.ErrorIs(t, err, ErrEquivocationNotFound)
+}
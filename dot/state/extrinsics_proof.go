@@ -0,0 +1,138 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/lib/trie/proof"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// ErrExtrinsicIndexOutOfRange is returned when generating an extrinsic
+// proof for an index not smaller than the number of extrinsics of the block.
+var ErrExtrinsicIndexOutOfRange = errors.New("extrinsic index out of range")
+
+var errExtrinsicsRootMismatch = errors.New("extrinsics root does not match block body")
+
+// GenerateExtrinsicProof returns the encoded trie nodes proving the extrinsic at
+// the index given is included in the block with the hash given, against the
+// extrinsics root of the block header. As in Substrate, the extrinsics trie
+// maps the compact encoded index of each extrinsic to the SCALE encoded extrinsic.
+func (bs *BlockState) GenerateExtrinsicProof(hash common.Hash, index uint) (
+	encodedProofNodes [][]byte, err error) {
+	header, err := bs.GetHeader(hash)
+	if err != nil {
+		return nil, fmt.Errorf("getting header: %w", err)
+	}
+
+	body, err := bs.GetBlockBody(hash)
+	if err != nil {
+		return nil, fmt.Errorf("getting block body: %w", err)
+	}
+
+	if index >= uint(len(*body)) {
+		return nil, fmt.Errorf("%w: index %d for %d extrinsics in block %s",
+			ErrExtrinsicIndexOutOfRange, index, len(*body), hash)
+	}
+
+	extrinsicsTrie, err := newExtrinsicsTrie(body)
+	if err != nil {
+		return nil, fmt.Errorf("creating extrinsics trie: %w", err)
+	}
+
+	root, err := extrinsicsTrie.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("hashing extrinsics trie: %w", err)
+	}
+
+	if root != header.ExtrinsicsRoot {
+		return nil, fmt.Errorf("%w: root %s of body differs from header extrinsics root %s",
+			errExtrinsicsRootMismatch, root, header.ExtrinsicsRoot)
+	}
+
+	database := make(memoryDatabase)
+	err = extrinsicsTrie.WriteDirtyTo(database)
+	if err != nil {
+		return nil, fmt.Errorf("writing extrinsics trie: %w", err)
+	}
+
+	key, err := extrinsicTrieKey(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return proof.Generate(root[:], [][]byte{key}, database)
+}
+
+// VerifyExtrinsicProof verifies the encoded proof nodes given prove the extrinsic
+// given is at the index given in the block with the extrinsics root given.
+func VerifyExtrinsicProof(encodedProofNodes [][]byte, extrinsicsRoot common.Hash,
+	index uint, extrinsic types.Extrinsic) error {
+	key, err := extrinsicTrieKey(index)
+	if err != nil {
+		return err
+	}
+
+	encodedExtrinsic, err := scale.Marshal(extrinsic)
+	if err != nil {
+		return fmt.Errorf("encoding extrinsic: %w", err)
+	}
+
+	return proof.Verify(encodedProofNodes, extrinsicsRoot[:], key, encodedExtrinsic)
+}
+
+// newExtrinsicsTrie returns the trie of the SCALE encoded extrinsics
+// of the body given, keyed by their compact encoded index.
+func newExtrinsicsTrie(body *types.Body) (extrinsicsTrie *trie.Trie, err error) {
+	encodedExtrinsics, err := body.AsEncodedExtrinsics()
+	if err != nil {
+		return nil, fmt.Errorf("encoding extrinsics: %w", err)
+	}
+
+	extrinsicsTrie = trie.NewEmptyTrie()
+	for i, encodedExtrinsic := range encodedExtrinsics {
+		key, err := extrinsicTrieKey(uint(i))
+		if err != nil {
+			return nil, err
+		}
+
+		err = extrinsicsTrie.Put(key, encodedExtrinsic)
+		if err != nil {
+			return nil, fmt.Errorf("putting extrinsic %d in trie: %w", i, err)
+		}
+	}
+
+	return extrinsicsTrie, nil
+}
+
+func extrinsicTrieKey(index uint) (key []byte, err error) {
+	key, err = scale.Marshal(new(big.Int).SetUint64(uint64(index)))
+	if err != nil {
+		return nil, fmt.Errorf("encoding extrinsic index %d: %w", index, err)
+	}
+	return key, nil
+}
+
+// memoryDatabase is an in-memory database for the encoded nodes of a trie.
+type memoryDatabase map[string][]byte
+
+func (db memoryDatabase) Get(key []byte) (value []byte, err error) {
+	value, ok := db[string(key)]
+	if !ok {
+		return nil, chaindb.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (db memoryDatabase) Put(key, value []byte) error {
+	db[string(key)] = append([]byte(nil), value...)
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockState_GenerateExtrinsicProof(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	extrinsics := []types.Extrinsic{
+		{1, 2, 3},
+		make([]byte, 40),
+		{4, 5},
+	}
+	body := types.NewBody(extrinsics)
+	header := types.Header{
+		Number:         1,
+		ParentHash:     testGenesisHeader.Hash(),
+		ExtrinsicsRoot: extrinsicsRoot(t, body),
+		Digest:         types.NewDigest(),
+	}
+	err := bs.AddBlock(&types.Block{Header: header, Body: *body})
+	require.NoError(t, err)
+
+	for index, extrinsic := range extrinsics {
+		encodedProofNodes, err := bs.GenerateExtrinsicProof(header.Hash(), uint(index))
+		require.NoError(t, err)
+
+		err = VerifyExtrinsicProof(encodedProofNodes, header.ExtrinsicsRoot, uint(index), extrinsic)
+		assert.NoError(t, err)
+
+		// the proof does not verify for another extrinsic
+		err = VerifyExtrinsicProof(encodedProofNodes, header.ExtrinsicsRoot, uint(index), types.Extrinsic{9})
+		assert.Error(t, err)
+	}
+
+	_, err = bs.GenerateExtrinsicProof(header.Hash(), uint(len(extrinsics)))
+	assert.ErrorIs(t, err, ErrExtrinsicIndexOutOfRange)
+	assert.EqualError(t, err, "extrinsic index out of range: index 3 for 3 extrinsics in block "+
+		header.Hash().String())
+}
+
+func TestBlockState_GenerateExtrinsicProof_polkadotBlock1(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	// body and extrinsics root of the polkadot block 1, from polkadot.js
+	encodedBody := []byte{8, 40, 4, 3, 0, 11, 80, 149, 160, 81, 114, 1, 16, 4, 20, 0, 0}
+	polkadotExtrinsicsRoot := common.MustHexToHash("0x9a87f6af64ef97aff2d31bebfdd59f8fe2ef6019278b634b2515a38f1c4c2420")
+
+	var encodedExtrinsics [][]byte
+	err := scale.Unmarshal(encodedBody, &encodedExtrinsics)
+	require.NoError(t, err)
+	extrinsics := types.BytesArrayToExtrinsics(encodedExtrinsics)
+
+	header := types.Header{
+		Number:         1,
+		ParentHash:     testGenesisHeader.Hash(),
+		ExtrinsicsRoot: polkadotExtrinsicsRoot,
+		Digest:         types.NewDigest(),
+	}
+	err = bs.AddBlock(&types.Block{Header: header, Body: *types.NewBody(extrinsics)})
+	require.NoError(t, err)
+
+	for index, extrinsic := range extrinsics {
+		encodedProofNodes, err := bs.GenerateExtrinsicProof(header.Hash(), uint(index))
+		require.NoError(t, err)
+
+		err = VerifyExtrinsicProof(encodedProofNodes, polkadotExtrinsicsRoot, uint(index), extrinsic)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"github.com/ChainSafe/gossamer/dot/types"
+)
+
+// SubscribeFinalizedBlocks returns a channel receiving the header of each
+// finalised block, once the database batch of the finalisation is written,
+// and a function to unsubscribe which closes the channel.
+// Headers are received in increasing block number order. Sending headers
+// never blocks finalisation: if headers are not received fast enough, the
+// finalisations not yet received are collapsed into the latest one.
+// The headers received are shared between subscriptions and must not be modified.
+func (bs *BlockState) SubscribeFinalizedBlocks() (headers <-chan *types.Header, unsubscribe func()) {
+	return bs.finalisedNotifier.subscribe()
+}
+
+// newFinalisedNotifier returns a notifier taking in the header
+// of each block finalised and sending it to its subscriptions.
+func newFinalisedNotifier() *notifier[*types.Header] {
+	return newNotifier(coalesceFinalised)
+}
+
+// coalesceFinalised replaces the pending header with the header given, unless
+// the header given is not above the pending header or the header sent last,
+// such that headers are sent in increasing number order.
+func coalesceFinalised(pending, sent **types.Header, header *types.Header) (newPending **types.Header) {
+	if pending != nil && header.Number <= (*pending).Number ||
+		sent != nil && header.Number <= (*sent).Number {
+		return pending
+	}
+	return &header
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiveFinalised receives a finalised header from the channel given,
+// failing the test if no header is received within a second.
+func receiveFinalised(t *testing.T, headers <-chan *types.Header) *types.Header {
+	t.Helper()
+
+	select {
+	case header, ok := <-headers:
+		require.True(t, ok, "headers channel closed")
+		return header
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for finalised header")
+		return nil
+	}
+}
+
+func Test_BlockState_SubscribeFinalizedBlocks(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+	chain := addTestChain(t, bs, genesisHeader, 6, common.Hash{0xa}, time.Unix(1, 0))
+
+	headers, unsubscribe := bs.SubscribeFinalizedBlocks()
+	defer unsubscribe()
+
+	err = bs.SetFinalisedHash(chain[0].Hash(), 1, 1)
+	require.NoError(t, err)
+	header := receiveFinalised(t, headers)
+	assert.Equal(t, chain[0].Hash(), header.Hash())
+
+	// Finality jumps from block 1 to block 5, and only
+	// the header of block 5 is received.
+	err = bs.SetFinalisedHash(chain[4].Hash(), 2, 1)
+	require.NoError(t, err)
+	header = receiveFinalised(t, headers)
+	assert.Equal(t, chain[4].Hash(), header.Hash())
+
+	select {
+	case header := <-headers:
+		t.Fatalf("unexpected finalised header received for block %d", header.Number)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unsubscribe()
+	_, ok := <-headers
+	assert.False(t, ok)
+
+	// Finalising after unsubscribing does not block.
+	err = bs.SetFinalisedHash(chain[5].Hash(), 3, 1)
+	require.NoError(t, err)
+}
+
+func Test_BlockState_SubscribeFinalizedBlocks_slowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+	chain := addTestChain(t, bs, genesisHeader, 10, common.Hash{0xa}, time.Unix(1, 0))
+
+	headers, unsubscribe := bs.SubscribeFinalizedBlocks()
+	defer unsubscribe()
+
+	// Blocks are finalised without receiving headers, which must not
+	// block finalisation, with finality jumping several blocks at once.
+	for i, finalisedIndex := range []int{0, 3, 4, 7, 9} {
+		err = bs.SetFinalisedHash(chain[finalisedIndex].Hash(), uint64(i+1), 1)
+		require.NoError(t, err)
+	}
+
+	// Headers are received in increasing block number order, with the
+	// finalisations not yet received collapsed into the latest one.
+	var previousNumber uint
+	for previousNumber != 10 {
+		header := receiveFinalised(t, headers)
+		assert.Greater(t, header.Number, previousNumber)
+		assert.Equal(t, chain[header.Number-1].Hash(), header.Hash())
+		previousNumber = header.Number
+	}
+}
+
+func Test_subscription_push_finalised(t *testing.T) {
+	t.Parallel()
+
+	subscription := &subscription[*types.Header]{
+		coalesce: coalesceFinalised,
+		ready:    make(chan struct{}, 1),
+	}
+
+	subscription.push(&types.Header{Number: 2})
+	assert.Equal(t, &types.Header{Number: 2}, *subscription.pending)
+	assert.Len(t, subscription.ready, 1)
+
+	// Headers not above the pending header are ignored.
+	subscription.push(&types.Header{Number: 1})
+	assert.Equal(t, &types.Header{Number: 2}, *subscription.pending)
+
+	// The pending header is replaced with the header of the block
+	// finalised last, collapsing the finalisations in between.
+	subscription.push(&types.Header{Number: 5})
+	assert.Equal(t, &types.Header{Number: 5}, *subscription.pending)
+
+	// Headers not above the header taken last are ignored.
+	subscription.sent = subscription.pending
+	subscription.pending = nil
+	subscription.push(&types.Header{Number: 5})
+	assert.Nil(t, subscription.pending)
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+)
+
+// ErrFirstSlotNotFound is returned when the first slot of the chain
+// is not stored in the database.
+var ErrFirstSlotNotFound = errors.New("first slot not found")
+
+var (
+	errFirstSlotEncodingLength = errors.New("first slot encoding length is invalid")
+	errFirstSlotMismatch       = errors.New("first slot does not match block 1 slot")
+)
+
+// firstSlotEncodingLength is the length of an encoded first slot number.
+const firstSlotEncodingLength = 8
+
+// genesisFirstSlot is the first slot stored at genesis, before
+// block 1 is imported and its slot number is known.
+const genesisFirstSlot = 1
+
+// StoreFirstSlot stores the slot number of block 1 of the chain in the database.
+// It is used for all slot to epoch computations, so it remains available once
+// block 1 is pruned or when block 1 was never synced.
+func StoreFirstSlot(db Putter, slot uint64) error {
+	encoded := make([]byte, firstSlotEncodingLength)
+	binary.LittleEndian.PutUint64(encoded, slot)
+
+	err := db.Put(firstSlotKey, encoded)
+	if err != nil {
+		return fmt.Errorf("putting first slot in database: %w", err)
+	}
+	return nil
+}
+
+// LoadFirstSlot loads the slot number of block 1 of the chain from the database.
+// It returns an error wrapping ErrFirstSlotNotFound if it is not stored.
+func LoadFirstSlot(db Getter) (slot uint64, err error) {
+	encoded, err := db.Get(firstSlotKey)
+	if err != nil {
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return 0, ErrFirstSlotNotFound
+		}
+		return 0, fmt.Errorf("getting first slot from database: %w", err)
+	}
+
+	if len(encoded) != firstSlotEncodingLength {
+		return 0, fmt.Errorf("%w: %d bytes instead of %d bytes",
+			errFirstSlotEncodingLength, len(encoded), firstSlotEncodingLength)
+	}
+
+	return binary.LittleEndian.Uint64(encoded), nil
+}
+
+// checkFirstSlot returns an error if the first slot given does not
+// match the slot number of the block 1 header given.
+func checkFirstSlot(firstSlot uint64, blockOne *types.Header) error {
+	slot, err := types.GetSlotFromHeader(blockOne)
+	if err != nil {
+		return fmt.Errorf("getting slot from block 1 header: %w", err)
+	}
+
+	if slot != firstSlot {
+		return fmt.Errorf("%w: first slot is %d and block 1 slot is %d",
+			errFirstSlotMismatch, firstSlot, slot)
+	}
+	return nil
+}
+
+// blockOneSlot returns the slot number of the canonical block 1 and true,
+// or false if block 1 is not in the database, for example because the node
+// imported a state past it.
+func (bs *BlockState) blockOneSlot() (slot uint64, ok bool, err error) {
+	header, err := bs.GetHeaderByNumber(1)
+	if err != nil {
+		if errors.Is(err, ErrNoCanonicalAtHeight) || errors.Is(err, chaindb.ErrKeyNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("getting block 1 header: %w", err)
+	}
+
+	slot, err = types.GetSlotFromHeader(header)
+	if err != nil {
+		return 0, false, fmt.Errorf("getting slot from block 1 header: %w", err)
+	}
+
+	return slot, true, nil
+}
+
+// migrateFirstSlot backfills the first slot of databases where block 1 is
+// finalised from the digest of block 1, and corrects it if it differs from
+// it. Without block 1 in the database, the stored first slot is kept.
+func migrateFirstSlot(db GetPutDeleter, blockState *BlockState) error {
+	finalised, err := blockState.GetHighestFinalisedHeader()
+	if err != nil {
+		return fmt.Errorf("getting highest finalised header: %w", err)
+	}
+
+	if finalised.Number == 0 {
+		return nil
+	}
+
+	storedSlot, err := LoadFirstSlot(db)
+	stored := err == nil
+	if err != nil && !errors.Is(err, ErrFirstSlotNotFound) {
+		return fmt.Errorf("loading first slot: %w", err)
+	}
+
+	slot, ok, err := blockState.blockOneSlot()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !ok && !stored:
+		return fmt.Errorf("%w: and block 1 is not in the database", ErrFirstSlotNotFound)
+	case !ok, stored && storedSlot == slot:
+		return nil
+	case stored:
+		logger.Warnf("correcting first slot from %d to block 1 slot %d", storedSlot, slot)
+	default:
+		logger.Infof("backfilling first slot as block 1 slot %d", slot)
+	}
+
+	return StoreFirstSlot(db, slot)
+}
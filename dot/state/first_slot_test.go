@@ -0,0 +1,116 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StoreFirstSlot_LoadFirstSlot(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+
+	_, err := LoadFirstSlot(db)
+	assert.ErrorIs(t, err, ErrFirstSlotNotFound)
+
+	err = StoreFirstSlot(db, 42069)
+	require.NoError(t, err)
+
+	slot, err := LoadFirstSlot(db)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42069), slot)
+
+	err = db.Put(firstSlotKey, []byte{1, 2})
+	require.NoError(t, err)
+	_, err = LoadFirstSlot(db)
+	assert.ErrorIs(t, err, errFirstSlotEncodingLength)
+	assert.EqualError(t, err, "first slot encoding length is invalid: 2 bytes instead of 8 bytes")
+}
+
+func Test_migrateFirstSlot(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	db := bs.baseState.db
+	const firstSlot = uint64(42069)
+
+	// nothing is finalised yet, so the genesis first slot is kept
+	err := migrateFirstSlot(db, bs)
+	require.NoError(t, err)
+	slot, err := LoadFirstSlot(db)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(genesisFirstSlot), slot)
+
+	digest := types.NewDigest()
+	preDigest, err := types.NewBabeSecondaryPlainPreDigest(0, firstSlot).ToPreRuntimeDigest()
+	require.NoError(t, err)
+	err = digest.Add(*preDigest)
+	require.NoError(t, err)
+	header1 := types.Header{
+		Number:     1,
+		Digest:     digest,
+		ParentHash: testGenesisHeader.Hash(),
+	}
+	err = bs.AddBlock(&types.Block{Header: header1, Body: types.Body{}})
+	require.NoError(t, err)
+	err = bs.SetFinalisedHash(header1.Hash(), 1, 1)
+	require.NoError(t, err)
+
+	// the first slot is backfilled from block 1
+	err = db.Del(firstSlotKey)
+	require.NoError(t, err)
+	err = migrateFirstSlot(db, bs)
+	require.NoError(t, err)
+	slot, err = LoadFirstSlot(db)
+	require.NoError(t, err)
+	assert.Equal(t, firstSlot, slot)
+
+	// the first slot is corrected from block 1
+	err = StoreFirstSlot(db, firstSlot+1)
+	require.NoError(t, err)
+	err = migrateFirstSlot(db, bs)
+	require.NoError(t, err)
+	slot, err = LoadFirstSlot(db)
+	require.NoError(t, err)
+	assert.Equal(t, firstSlot, slot)
+
+	// without block 1, the stored first slot is kept
+	err = bs.db.Del(headerHashKey(1))
+	require.NoError(t, err)
+	err = StoreFirstSlot(db, firstSlot+1)
+	require.NoError(t, err)
+	err = migrateFirstSlot(db, bs)
+	require.NoError(t, err)
+	slot, err = LoadFirstSlot(db)
+	require.NoError(t, err)
+	assert.Equal(t, firstSlot+1, slot)
+
+	err = db.Del(firstSlotKey)
+	require.NoError(t, err)
+	err = migrateFirstSlot(db, bs)
+	assert.ErrorIs(t, err, ErrFirstSlotNotFound)
+}
+
+func Test_checkFirstSlot(t *testing.T) {
+	t.Parallel()
+
+	digest := types.NewDigest()
+	preDigest, err := types.NewBabeSecondaryPlainPreDigest(0, 100).ToPreRuntimeDigest()
+	require.NoError(t, err)
+	err = digest.Add(*preDigest)
+	require.NoError(t, err)
+	header := &types.Header{Number: 1, Digest: digest}
+
+	err = checkFirstSlot(100, header)
+	require.NoError(t, err)
+
+	err = checkFirstSlot(101, header)
+	assert.ErrorIs(t, err, errFirstSlotMismatch)
+	assert.EqualError(t, err, "first slot does not match block 1 slot: first slot is 101 and block 1 slot is 100")
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// ErrNoLeaves is returned when selecting the best block from no leaves.
+var ErrNoLeaves = errors.New("no leaves to select the best block from")
+
+// ForkChoiceWeight is the fork choice data of a block stored in the
+// database, such that the best block can be selected from the leaves
+// of the block tree without the block tree in memory.
+type ForkChoiceWeight struct {
+	Number uint
+	// IsPrimary is true if the block was authored in a BABE primary slot.
+	IsPrimary bool
+	// PrimaryCount is the number of blocks authored in a primary slot
+	// on the chain from the genesis block excluded to the block included.
+	PrimaryCount uint
+	// ArrivalTime is the arrival time of the block in nanoseconds since the Unix epoch.
+	ArrivalTime int64
+}
+
+// heavierThan returns true if the block with the weight and hash given is
+// preferred over the other block by the fork choice rule of the block tree:
+// the chain with the most primary blocks, then the highest block number,
+// then the earliest arrival time and finally the lowest block hash.
+func (w ForkChoiceWeight) heavierThan(hash common.Hash,
+	other ForkChoiceWeight, otherHash common.Hash) bool {
+	switch {
+	case w.PrimaryCount != other.PrimaryCount:
+		return w.PrimaryCount > other.PrimaryCount
+	case w.Number != other.Number:
+		return w.Number > other.Number
+	case w.ArrivalTime != other.ArrivalTime:
+		return w.ArrivalTime < other.ArrivalTime
+	default:
+		return bytes.Compare(hash[:], otherHash[:]) < 0
+	}
+}
+
+// newForkChoiceWeight returns the fork choice weight of the block with
+// the header and arrival time given, using the fork choice weight of its
+// parent block stored in the database.
+func newForkChoiceWeight(db Getter, header *types.Header, arrivalTime time.Time) (
+	weight ForkChoiceWeight, err error) {
+	if header.Number == 0 {
+		return childForkChoiceWeight(ForkChoiceWeight{}, header, arrivalTime)
+	}
+
+	// If the parent block was stored before fork choice weights were,
+	// primary blocks are counted from the parent block. This is consistent
+	// for the children of the finalised block, from which all leaves descend.
+	parentWeight, err := LoadForkChoiceWeight(db, header.ParentHash)
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return weight, fmt.Errorf("loading parent fork choice weight: %w", err)
+	}
+
+	return childForkChoiceWeight(parentWeight, header, arrivalTime)
+}
+
+// childForkChoiceWeight returns the fork choice weight of the block with
+// the header and arrival time given, from the fork choice weight of its
+// parent block.
+func childForkChoiceWeight(parentWeight ForkChoiceWeight, header *types.Header,
+	arrivalTime time.Time) (weight ForkChoiceWeight, err error) {
+	weight = ForkChoiceWeight{
+		Number:      header.Number,
+		ArrivalTime: arrivalTime.UnixNano(),
+	}
+
+	if header.Number == 0 {
+		return weight, nil
+	}
+
+	weight.IsPrimary, err = types.IsPrimary(header)
+	if err != nil {
+		return weight, fmt.Errorf("checking if block is primary: %w", err)
+	}
+
+	weight.PrimaryCount = parentWeight.PrimaryCount
+	if weight.IsPrimary {
+		weight.PrimaryCount++
+	}
+
+	return weight, nil
+}
+
+// StoreForkChoiceWeight SCALE encodes the fork choice weight given and
+// stores it in the database at the fork choice weight key for the block hash.
+func StoreForkChoiceWeight(db Putter, hash common.Hash, weight ForkChoiceWeight) (err error) {
+	encoding, err := scale.Marshal(weight)
+	if err != nil {
+		return fmt.Errorf("encoding fork choice weight: %w", err)
+	}
+
+	err = db.Put(prefixKey(hash, forkChoiceWeightPrefix), encoding)
+	if err != nil {
+		return fmt.Errorf("putting fork choice weight for block hash %s in database: %w", hash, err)
+	}
+
+	return nil
+}
+
+// LoadForkChoiceWeight loads and decodes the fork choice weight
+// stored in the database for the given block hash.
+func LoadForkChoiceWeight(db Getter, hash common.Hash) (weight ForkChoiceWeight, err error) {
+	encoding, err := db.Get(prefixKey(hash, forkChoiceWeightPrefix))
+	if err != nil {
+		return weight, fmt.Errorf("getting fork choice weight from database: %w", err)
+	}
+
+	err = scale.Unmarshal(encoding, &weight)
+	if err != nil {
+		return weight, fmt.Errorf("decoding fork choice weight: %w", err)
+	}
+
+	return weight, nil
+}
+
+// BestBlockFromLeaves returns the best block hash amongst the leaves given,
+// using only the fork choice weights stored in the database. The leaves must
+// all descend from the same finalised block, such that the block selected is
+// the best block of the block tree with these leaves.
+func BestBlockFromLeaves(db Getter, leaves []common.Hash) (best common.Hash, err error) {
+	if len(leaves) == 0 {
+		return best, ErrNoLeaves
+	}
+
+	var bestWeight ForkChoiceWeight
+	for i, leaf := range leaves {
+		weight, err := LoadForkChoiceWeight(db, leaf)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("loading fork choice weight for leaf %s: %w", leaf, err)
+		}
+
+		if i == 0 || weight.heavierThan(leaf, bestWeight, best) {
+			best = leaf
+			bestWeight = weight
+		}
+	}
+
+	return best, nil
+}
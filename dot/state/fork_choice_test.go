@@ -0,0 +1,171 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addForkChoiceTestChain adds a chain of blocks with the given length on top
+// of the parent header given, authored in primary slots if primary is true
+// and in secondary slots otherwise.
+func addForkChoiceTestChain(t *testing.T, bs *BlockState, parent *types.Header, length int,
+	primary bool, arrivalTime time.Time) (headers []*types.Header) {
+	t.Helper()
+
+	for i := 0; i < length; i++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			Digest:     createSecondaryBABEDigest(t),
+		}
+		if primary {
+			header.Digest = createPrimaryBABEDigest(t)
+		}
+		block := &types.Block{Header: *header, Body: types.Body{}}
+		err := bs.AddBlockWithArrivalTime(block, arrivalTime)
+		require.NoError(t, err)
+
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func Test_BestBlockFromLeaves(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		weights    map[common.Hash]ForkChoiceWeight
+		leaves     []common.Hash
+		best       common.Hash
+		errWrapped error
+		errMessage string
+	}{
+		"no_leaves": {
+			errWrapped: ErrNoLeaves,
+			errMessage: "no leaves to select the best block from",
+		},
+		"weight_not_found": {
+			weights: map[common.Hash]ForkChoiceWeight{
+				{1}: {Number: 1},
+			},
+			leaves:     []common.Hash{{1}, {2}},
+			errWrapped: chaindb.ErrKeyNotFound,
+			errMessage: "loading fork choice weight for leaf " +
+				"0x0200000000000000000000000000000000000000000000000000000000000000: " +
+				"getting fork choice weight from database: Key not found",
+		},
+		"most_primaries": {
+			weights: map[common.Hash]ForkChoiceWeight{
+				{1}: {Number: 5, PrimaryCount: 1},
+				{2}: {Number: 3, PrimaryCount: 2},
+				{3}: {Number: 4, PrimaryCount: 2},
+			},
+			leaves: []common.Hash{{1}, {2}, {3}},
+			best:   common.Hash{3},
+		},
+		"highest_number": {
+			weights: map[common.Hash]ForkChoiceWeight{
+				{1}: {Number: 3, PrimaryCount: 1},
+				{2}: {Number: 4, PrimaryCount: 1},
+			},
+			leaves: []common.Hash{{1}, {2}},
+			best:   common.Hash{2},
+		},
+		"earliest_arrival_time": {
+			weights: map[common.Hash]ForkChoiceWeight{
+				{1}: {Number: 3, ArrivalTime: 2},
+				{2}: {Number: 3, ArrivalTime: 1},
+			},
+			leaves: []common.Hash{{1}, {2}},
+			best:   common.Hash{2},
+		},
+		"lowest_hash": {
+			weights: map[common.Hash]ForkChoiceWeight{
+				{1}: {Number: 3, ArrivalTime: 1},
+				{2}: {Number: 3, ArrivalTime: 1},
+			},
+			leaves: []common.Hash{{2}, {1}},
+			best:   common.Hash{1},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := NewInMemoryDB(t)
+			for hash, weight := range testCase.weights {
+				err := StoreForkChoiceWeight(db, hash, weight)
+				require.NoError(t, err)
+			}
+
+			best, err := BestBlockFromLeaves(db, testCase.leaves)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.best, best)
+		})
+	}
+}
+
+func Test_BlockState_forkChoiceWeights(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	// The longer chain is authored in secondary slots,
+	// so the chain with more primary blocks is the best chain.
+	arrivalTime := time.Unix(1, 0)
+	secondaryChain := addForkChoiceTestChain(t, bs, genesisHeader, 3, false, arrivalTime)
+	primaryChain := addForkChoiceTestChain(t, bs, genesisHeader, 2, true, arrivalTime)
+	require.Equal(t, primaryChain[1].Hash(), bs.BestBlockHash())
+
+	weight, err := LoadForkChoiceWeight(bs.db, primaryChain[1].Hash())
+	require.NoError(t, err)
+	expectedWeight := ForkChoiceWeight{
+		Number:       2,
+		IsPrimary:    true,
+		PrimaryCount: 2,
+		ArrivalTime:  arrivalTime.UnixNano(),
+	}
+	assert.Equal(t, expectedWeight, weight)
+
+	best, err := BestBlockFromLeaves(bs.db, bs.Leaves())
+	require.NoError(t, err)
+	assert.Equal(t, bs.BestBlockHash(), best)
+
+	// The fork choice weights of the pruned blocks are deleted on finalisation.
+	err = bs.SetFinalisedHash(primaryChain[0].Hash(), 1, 0)
+	require.NoError(t, err)
+	for _, header := range secondaryChain {
+		_, err = LoadForkChoiceWeight(bs.db, header.Hash())
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+	}
+	_, err = LoadForkChoiceWeight(bs.db, primaryChain[0].Hash())
+	assert.NoError(t, err)
+
+	// Only the fork choice weight of the last finalised block is kept.
+	err = bs.SetFinalisedHash(primaryChain[1].Hash(), 2, 0)
+	require.NoError(t, err)
+	for _, hash := range []common.Hash{genesisHeader.Hash(), primaryChain[0].Hash()} {
+		_, err = LoadForkChoiceWeight(bs.db, hash)
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+	}
+	_, err = LoadForkChoiceWeight(bs.db, primaryChain[1].Hash())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// forkPruningBatchSize is the maximum number of fork blocks
+	// deleted in a single database batch.
+	forkPruningBatchSize = 256
+	// forkPruningMaxNumbers is the maximum number of block numbers walked
+	// on each finalisation, such that a large backlog of block numbers to
+	// prune is pruned over several finalisations.
+	forkPruningMaxNumbers = 1 << 14
+)
+
+var (
+	// forkPrunedNumberKey -> block number up to which fork blocks are pruned
+	forkPrunedNumberKey = []byte("fpn")
+
+	prunedForkBlocksCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_block",
+		Name:      "pruned_fork_blocks_total",
+		Help:      "total number of fork blocks deleted from the database",
+	})
+)
+
+// pruneForks deletes from the database the data of the blocks not on the
+// canonical chain, for the block numbers of the block number index above the
+// block number pruned up to, and at or below the finalised number given.
+// The canonical chain is never modified. The fork blocks are deleted in
+// batches, each batch also storing the block number pruned up to, such that
+// pruning resumes from the last batch written if it is interrupted.
+func (bs *BlockState) pruneForks(finalisedNumber uint) (err error) {
+	prunedNumber, err := bs.loadForkPrunedNumber()
+	if err != nil {
+		return fmt.Errorf("loading fork pruned number: %w", err)
+	}
+
+	lastNumber := finalisedNumber
+	if lastNumber > prunedNumber+forkPruningMaxNumbers {
+		lastNumber = prunedNumber + forkPruningMaxNumbers
+	}
+
+	index := newBlockNumberIndexBatch(bs.db)
+	batch := bs.db.NewBatch()
+	defer func() {
+		batch.Reset()
+	}()
+
+	var batchPrunedBlocks int
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	for number := prunedNumber + 1; number <= lastNumber; number++ {
+		nonCanonicalHashes, err := index.nonCanonicalHashes(number)
+		if err != nil {
+			return fmt.Errorf("getting non canonical hashes: %w", err)
+		}
+
+		if len(nonCanonicalHashes) > 0 {
+			canonicalHash, canonical, err := index.canonicalHash(number)
+			if err != nil {
+				return fmt.Errorf("getting canonical hash: %w", err)
+			}
+
+			for _, hash := range nonCanonicalHashes {
+				if canonical && hash == canonicalHash {
+					continue
+				}
+
+				err = deleteBlockData(batch, hash)
+				if err != nil {
+					return fmt.Errorf("deleting fork block data: %w", err)
+				}
+
+				err = stateRootIndex.remove(hash)
+				if err != nil {
+					return fmt.Errorf("removing fork block from state root index: %w", err)
+				}
+				batchPrunedBlocks++
+			}
+
+			err = batch.Del(nonCanonicalHashesKey(uint64(number)))
+			if err != nil {
+				return fmt.Errorf("deleting non canonical hashes: %w", err)
+			}
+		}
+
+		if batchPrunedBlocks < forkPruningBatchSize && number != lastNumber {
+			continue
+		}
+
+		err = stateRootIndex.put(batch)
+		if err != nil {
+			return fmt.Errorf("writing state root index: %w", err)
+		}
+
+		err = batch.Put(forkPrunedNumberKey, encodeBlockNumber(uint64(number)))
+		if err != nil {
+			return fmt.Errorf("putting fork pruned number in database batch: %w", err)
+		}
+
+		err = batch.Flush()
+		if err != nil {
+			return fmt.Errorf("writing fork pruning batch: %w", err)
+		}
+
+		if batchPrunedBlocks > 0 {
+			logger.Debugf("pruned %d fork blocks up to block number %d", batchPrunedBlocks, number)
+		}
+		prunedForkBlocksCounter.Add(float64(batchPrunedBlocks))
+		batchPrunedBlocks = 0
+		batch.Reset()
+		batch = bs.db.NewBatch()
+		stateRootIndex = newStateRootIndexBatch(bs.db)
+	}
+
+	return nil
+}
+
+// loadForkPrunedNumber returns the block number up to which fork
+// blocks are pruned, and 0 if no fork block was ever pruned.
+func (bs *BlockState) loadForkPrunedNumber() (number uint, err error) {
+	encodedNumber, err := bs.db.Get(forkPrunedNumberKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("getting fork pruned number from database: %w", err)
+	}
+
+	return uint(binary.BigEndian.Uint64(encodedNumber)), nil
+}
+
+// deletePrunedForkBlocks deletes all the data stored for the given
+// hashes of fork blocks pruned from the block tree from the database.
+func (bs *BlockState) deletePrunedForkBlocks(hashes []common.Hash) (err error) {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	batch := bs.db.NewBatch()
+	defer batch.Reset()
+
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	for _, hash := range hashes {
+		err = deleteBlockData(batch, hash)
+		if err != nil {
+			return err
+		}
+
+		err = stateRootIndex.remove(hash)
+		if err != nil {
+			return fmt.Errorf("removing block hash %s from state root index: %w", hash, err)
+		}
+	}
+
+	err = stateRootIndex.put(batch)
+	if err != nil {
+		return fmt.Errorf("writing state root index: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("writing database batch: %w", err)
+	}
+
+	prunedForkBlocksCounter.Add(float64(len(hashes)))
+	return nil
+}
+
+// deleteBlockData adds the deletions of all the data stored
+// for the given block hash to the database batch given.
+func deleteBlockData(batch Deleter, hash common.Hash) (err error) {
+	keys := [][]byte{
+		headerKey(hash),
+		blockBodyKey(hash),
+		arrivalTimeKey(hash),
+		prefixKey(hash, justificationPrefix),
+		prefixKey(hash, receiptPrefix),
+		prefixKey(hash, messageQueuePrefix),
+		prefixKey(hash, forkChoiceWeightPrefix),
+		prefixKey(hash, unfinalisedBlockPrefix),
+		prefixKey(hash, headerOnlyPrefix),
+	}
+
+	for _, key := range keys {
+		err = batch.Del(key)
+		if err != nil {
+			return fmt.Errorf("deleting key 0x%x: %w", key, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeForkBlockData stores data in the database for the block hashes
+// given, such as stored by previous versions or for blocks out of the
+// block tree, and returns the keys of the data stored.
+func storeForkBlockData(t *testing.T, db Putter, headers []*types.Header) (keys [][]byte) {
+	t.Helper()
+
+	for _, header := range headers {
+		hash, err := StoreHeader(db, header)
+		require.NoError(t, err)
+		err = StoreBlockBody(db, hash, types.NewBody([]types.Extrinsic{{1}}))
+		require.NoError(t, err)
+		err = StoreJustification(db, hash, []byte{2})
+		require.NoError(t, err)
+		err = StoreArrivalTime(db, hash, time.Unix(1, 0))
+		require.NoError(t, err)
+		err = db.Put(prefixKey(hash, receiptPrefix), []byte{3})
+		require.NoError(t, err)
+		err = db.Put(prefixKey(hash, messageQueuePrefix), []byte{4})
+		require.NoError(t, err)
+
+		keys = append(keys,
+			headerKey(hash),
+			blockBodyKey(hash),
+			prefixKey(hash, justificationPrefix),
+			arrivalTimeKey(hash),
+			prefixKey(hash, receiptPrefix),
+			prefixKey(hash, messageQueuePrefix),
+			prefixKey(hash, forkChoiceWeightPrefix),
+		)
+	}
+	return keys
+}
+
+func Test_BlockState_pruneForks(t *testing.T) {
+	// Not parallel since it checks the pruned fork blocks counter.
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	arrivalTime := time.Unix(1, 0)
+	canonicalChain := addTestChain(t, bs, genesisHeader, 5, common.Hash{0xa}, arrivalTime)
+	forkA := addTestChain(t, bs, genesisHeader, 3, common.Hash{0xb}, arrivalTime)
+	forkB := addTestChain(t, bs, canonicalChain[1], 2, common.Hash{0xc}, arrivalTime)
+	// Fork C is above the finalised block number once block 3 is finalised.
+	forkC := addTestChain(t, bs, canonicalChain[1], 3, common.Hash{0xd}, arrivalTime.Add(time.Second))
+	require.Equal(t, canonicalChain[4].Hash(), bs.BestBlockHash())
+
+	forkHeaders := append(append(append([]*types.Header{}, forkA...), forkB...), forkC...)
+	forkKeys := storeForkBlockData(t, bs.db, forkHeaders)
+
+	prunedBefore := testutil.ToFloat64(prunedForkBlocksCounter)
+
+	finalised := canonicalChain[2]
+	err = bs.SetFinalisedHash(finalised.Hash(), 1, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(len(forkHeaders)), testutil.ToFloat64(prunedForkBlocksCounter)-prunedBefore)
+	for _, key := range forkKeys {
+		has, err := bs.db.Has(key)
+		require.NoError(t, err)
+		assert.Falsef(t, has, "key 0x%x", key)
+	}
+
+	for number := uint(1); number <= 5; number++ {
+		hashes, err := bs.GetNonCanonicalHashesByNumber(number)
+		require.NoError(t, err)
+		assert.Emptyf(t, hashes, "block number %d", number)
+	}
+
+	prunedNumber, err := bs.loadForkPrunedNumber()
+	require.NoError(t, err)
+	assert.Equal(t, finalised.Number, prunedNumber)
+
+	// The canonical chain is kept entirely.
+	for _, header := range canonicalChain[:3] {
+		hash := header.Hash()
+		storedHeader, err := LoadHeader(bs.db, hash)
+		require.NoError(t, err)
+		assert.Equal(t, hash, storedHeader.Hash())
+		has, err := HasBlockBody(bs.db, hash)
+		require.NoError(t, err)
+		assert.True(t, has)
+		_, err = LoadForkChoiceWeight(bs.db, hash)
+		assert.NoError(t, err)
+	}
+	for _, header := range canonicalChain {
+		canonicalHash, err := bs.GetHashByNumber(header.Number)
+		require.NoError(t, err)
+		assert.Equal(t, header.Hash(), canonicalHash)
+	}
+	has, err := bs.HasHeader(canonicalChain[4].Hash())
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func Test_BlockState_pruneForks_resume(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+
+	// Non canonical hashes are left at block numbers pruned up to, at block
+	// numbers to prune and above the maximum block numbers walked.
+	lastNumber := uint(forkPruningMaxNumbers + 10)
+	nonCanonicalNumbers := []uint{2, 3, 6, forkPruningMaxNumbers + 3, lastNumber}
+	index := newBlockNumberIndexBatch(bs.db)
+	for _, number := range nonCanonicalNumbers {
+		err := index.addNonCanonicalHash(number, common.Hash{byte(number)})
+		require.NoError(t, err)
+	}
+	err := index.flush()
+	require.NoError(t, err)
+
+	// Pruning was interrupted after pruning up to block number 3, and
+	// the non canonical hashes below were left for the test purpose.
+	err = bs.db.Put(forkPrunedNumberKey, encodeBlockNumber(3))
+	require.NoError(t, err)
+
+	nonCanonicalHashes := func() (numbers []uint) {
+		for _, number := range nonCanonicalNumbers {
+			hashes, err := bs.GetNonCanonicalHashesByNumber(number)
+			require.NoError(t, err)
+			if len(hashes) > 0 {
+				numbers = append(numbers, number)
+			}
+		}
+		return numbers
+	}
+
+	err = bs.pruneForks(lastNumber)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{2, 3, lastNumber}, nonCanonicalHashes())
+	prunedNumber, err := bs.loadForkPrunedNumber()
+	require.NoError(t, err)
+	assert.Equal(t, uint(3+forkPruningMaxNumbers), prunedNumber)
+
+	err = bs.pruneForks(lastNumber)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{2, 3}, nonCanonicalHashes())
+	prunedNumber, err = bs.loadForkPrunedNumber()
+	require.NoError(t, err)
+	assert.Equal(t, lastNumber, prunedNumber)
+
+	_, err = bs.db.Get(nonCanonicalHashesKey(uint64(lastNumber)))
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+}
@@ -46,7 +46,7 @@ type GrandpaState struct {
 }
 
 // NewGrandpaStateFromGenesis returns a new GrandpaState given the grandpa genesis authorities
-func NewGrandpaStateFromGenesis(db *chaindb.BadgerDB, bs *BlockState,
+func NewGrandpaStateFromGenesis(db chaindb.Database, bs *BlockState,
 	genesisAuthorities []types.GrandpaVoter, telemetry Telemetry) (*GrandpaState, error) {
 	grandpaDB := chaindb.NewTable(db, grandpaPrefix)
 	s := &GrandpaState{
@@ -77,7 +77,7 @@ func NewGrandpaStateFromGenesis(db *chaindb.BadgerDB, bs *BlockState,
 }
 
 // NewGrandpaState returns a new GrandpaState
-func NewGrandpaState(db *chaindb.BadgerDB, bs *BlockState, telemetry Telemetry) *GrandpaState {
+func NewGrandpaState(db chaindb.Database, bs *BlockState, telemetry Telemetry) *GrandpaState {
 	return &GrandpaState{
 		db:                   chaindb.NewTable(db, grandpaPrefix),
 		blockState:           bs,
@@ -0,0 +1,97 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// highestFinalisedHashKey is the key of the highest finalised block hash,
+// written in the same database batch as the rest of the finalisation.
+var highestFinalisedHashKey = []byte("hfh")
+
+var errFinalisedNotDescendant = errors.New(
+	"finalised block is not a descendant of the highest finalised block")
+
+// GetHighestFinalisedHash returns the highest finalised block hash from the
+// block database given. For databases written without the highest finalised
+// hash record, it falls back on the hash finalised at the highest round and
+// set ID.
+func GetHighestFinalisedHash(db Getter) (hash common.Hash, err error) {
+	encodedHash, err := db.Get(highestFinalisedHashKey)
+	if err == nil {
+		return common.NewHash(encodedHash), nil
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return common.Hash{}, fmt.Errorf("getting highest finalised hash from database: %w", err)
+	}
+
+	roundAndSetID, err := db.Get(highestRoundAndSetIDKey)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("getting highest round and set id: %w", err)
+	}
+
+	round := binary.LittleEndian.Uint64(roundAndSetID[:8])
+	setID := binary.LittleEndian.Uint64(roundAndSetID[8:16])
+	encodedHash, err = db.Get(finalisedHashKey(round, setID))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("getting finalised hash: %w", err)
+	}
+
+	return common.NewHash(encodedHash), nil
+}
+
+// GetHighestFinalisedHeader returns the highest finalised
+// block header from the block database given.
+func GetHighestFinalisedHeader(db Getter) (header *types.Header, err error) {
+	hash, err := GetHighestFinalisedHash(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadHeader(db, hash)
+}
+
+func putHighestFinalisedHash(db Putter, hash common.Hash) error {
+	err := db.Put(highestFinalisedHashKey, hash.ToBytes())
+	if err != nil {
+		return fmt.Errorf("putting highest finalised hash in database: %w", err)
+	}
+	return nil
+}
+
+// checkFinalisationAdvances returns an error wrapping errFinalisedNotDescendant
+// if the block hash given is neither the highest finalised block hash nor one
+// of its descendants, such that finality never moves backwards or to another
+// fork, for example on finality notifications received out of order.
+func (bs *BlockState) checkFinalisationAdvances(hash common.Hash) error {
+	highestFinalisedHash, err := GetHighestFinalisedHash(bs.db)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		// nothing is finalised yet
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("getting highest finalised hash: %w", err)
+	}
+
+	isDescendant, err := bs.bt.IsDescendantOf(highestFinalisedHash, hash)
+	if errors.Is(err, blocktree.ErrEndNodeNotFound) {
+		// the block is not in the block tree, so it is finalised
+		// already or on a fork pruned on a previous finalisation.
+		isDescendant = false
+	} else if err != nil {
+		return fmt.Errorf("checking block is descendant of highest finalised block: %w", err)
+	}
+
+	if !isDescendant {
+		return fmt.Errorf("%w: block hash %s and highest finalised block hash %s",
+			errFinalisedNotDescendant, hash, highestFinalisedHash)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetHighestFinalisedHash(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	header := newTestHeader(t)
+	hash, err := StoreHeader(db, header)
+	require.NoError(t, err)
+
+	_, err = GetHighestFinalisedHash(db)
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+
+	// Databases written without the highest finalised
+	// hash record use the highest round and set ID.
+	err = db.Put(highestRoundAndSetIDKey, roundAndSetIDToBytes(2, 1))
+	require.NoError(t, err)
+	err = db.Put(finalisedHashKey(2, 1), hash.ToBytes())
+	require.NoError(t, err)
+
+	highestFinalisedHash, err := GetHighestFinalisedHash(db)
+	require.NoError(t, err)
+	assert.Equal(t, hash, highestFinalisedHash)
+
+	err = putHighestFinalisedHash(db, common.Hash{1})
+	require.NoError(t, err)
+	highestFinalisedHash, err = GetHighestFinalisedHash(db)
+	require.NoError(t, err)
+	assert.Equal(t, common.Hash{1}, highestFinalisedHash)
+	_, err = GetHighestFinalisedHeader(db)
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+
+	err = putHighestFinalisedHash(db, hash)
+	require.NoError(t, err)
+	highestFinalisedHeader, err := GetHighestFinalisedHeader(db)
+	require.NoError(t, err)
+	assert.Equal(t, hash, highestFinalisedHeader.Hash())
+}
+
+func Test_BlockState_SetFinalisedHash_outOfOrder(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	chain := addTestChain(t, bs, genesisHeader, 5, common.Hash{1}, time.Unix(1, 0))
+	fork := addTestChain(t, bs, chain[0], 3, common.Hash{2}, time.Unix(1, 0))
+
+	assertHighestFinalised := func(t *testing.T, expectedNumber uint) {
+		t.Helper()
+		header, err := GetHighestFinalisedHeader(bs.db)
+		require.NoError(t, err)
+		assert.Equal(t, expectedNumber, header.Number)
+		hash, err := bs.GetHighestFinalisedHash()
+		require.NoError(t, err)
+		assert.Equal(t, header.Hash(), hash)
+	}
+
+	err = bs.SetFinalisedHash(chain[2].Hash(), 2, 0)
+	require.NoError(t, err)
+	assertHighestFinalised(t, 3)
+
+	// The finality notification for block 2 is received after the one for block 3.
+	err = bs.SetFinalisedHash(chain[1].Hash(), 1, 0)
+	assert.ErrorIs(t, err, errFinalisedNotDescendant)
+	assertHighestFinalised(t, 3)
+
+	// The fork block 3 is pruned from the block tree and from the database
+	// on finalisation, and its header is stored again for the test purpose.
+	_, err = StoreHeader(bs.db, fork[1])
+	require.NoError(t, err)
+	err = bs.SetFinalisedHash(fork[1].Hash(), 3, 0)
+	assert.ErrorIs(t, err, errFinalisedNotDescendant)
+	assertHighestFinalised(t, 3)
+	_, err = bs.GetFinalisedHash(3, 0)
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+
+	// Finalising the highest finalised block again in a later round is allowed.
+	err = bs.SetFinalisedHash(chain[2].Hash(), 3, 0)
+	require.NoError(t, err)
+	assertHighestFinalised(t, 3)
+
+	err = bs.SetFinalisedHash(chain[4].Hash(), 4, 0)
+	require.NoError(t, err)
+	assertHighestFinalised(t, 5)
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// maxHistoricalTries is the maximum number of lazy tries of historical
+// state roots kept in memory, after which the least recently used
+// one is evicted.
+const maxHistoricalTries = 16
+
+// historicalTries is a thread safe cache of lazy tries for state roots
+// whose trie is not in memory, such as the state roots of old blocks
+// queried over RPC. Each lazy trie only holds the nodes loaded from
+// the database on the paths to the keys queried.
+type historicalTries struct {
+	mutex      sync.Mutex
+	rootToTrie map[common.Hash]*trie.LazyTrie
+	// roots are the roots of the cached tries, ordered from
+	// the least recently used to the most recently used.
+	roots   []common.Hash
+	maxSize int
+}
+
+func newHistoricalTries(maxSize int) *historicalTries {
+	return &historicalTries{
+		rootToTrie: make(map[common.Hash]*trie.LazyTrie, maxSize),
+		roots:      make([]common.Hash, 0, maxSize),
+		maxSize:    maxSize,
+	}
+}
+
+// get returns the lazy trie cached for the given root, or nil if
+// it is not cached, and marks it as the most recently used.
+func (h *historicalTries) get(root common.Hash) (lazyTrie *trie.LazyTrie) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	lazyTrie, ok := h.rootToTrie[root]
+	if !ok {
+		return nil
+	}
+
+	h.removeRoot(root)
+	h.roots = append(h.roots, root)
+	return lazyTrie
+}
+
+// set caches the lazy trie given at the given root, evicting
+// the least recently used trie if the cache is full.
+func (h *historicalTries) set(root common.Hash, lazyTrie *trie.LazyTrie) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	_, has := h.rootToTrie[root]
+	if has {
+		h.removeRoot(root)
+	} else if len(h.roots) == h.maxSize {
+		evicted := h.roots[0]
+		h.roots = h.roots[1:]
+		delete(h.rootToTrie, evicted)
+	}
+
+	h.rootToTrie[root] = lazyTrie
+	h.roots = append(h.roots, root)
+}
+
+// delete removes the lazy trie cached for the given root, if any.
+func (h *historicalTries) delete(root common.Hash) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.rootToTrie, root)
+	h.removeRoot(root)
+}
+
+// removeRoot removes the root given from the ordered roots,
+// and must be called with the mutex locked.
+func (h *historicalTries) removeRoot(root common.Hash) {
+	for i, cachedRoot := range h.roots {
+		if cachedRoot == root {
+			h.roots = append(h.roots[:i], h.roots[i+1:]...)
+			return
+		}
+	}
+}
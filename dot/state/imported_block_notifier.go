@@ -0,0 +1,107 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// ImportedBlock is the event sent to subscribers when a block is imported.
+type ImportedBlock struct {
+	Hash   common.Hash
+	Number uint
+	// IsNewBest is true if the block imported is the new best block.
+	IsNewBest bool
+}
+
+// ImportedBlockNotifier sends an event to each of its subscribers for
+// each block imported. Sending an event never blocks: if the buffer of
+// a subscription is full, its oldest event is dropped to make room for
+// the new event.
+type ImportedBlockNotifier struct {
+	bufferSize    int
+	mutex         sync.Mutex
+	subscriptions map[*ImportedBlockSubscription]struct{}
+}
+
+func newImportedBlockNotifier(bufferSize int) *ImportedBlockNotifier {
+	return &ImportedBlockNotifier{
+		bufferSize:    bufferSize,
+		subscriptions: make(map[*ImportedBlockSubscription]struct{}),
+	}
+}
+
+// ImportedBlockSubscription is a subscription to imported block events.
+type ImportedBlockSubscription struct {
+	events  chan ImportedBlock
+	dropped atomic.Uint64
+}
+
+// Events returns the channel of imported block events of the subscription,
+// which is closed when the subscription is unsubscribed.
+func (s *ImportedBlockSubscription) Events() <-chan ImportedBlock {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because
+// the subscription events were not received fast enough.
+func (s *ImportedBlockSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Subscribe returns a new subscription to imported block events.
+func (n *ImportedBlockNotifier) Subscribe() *ImportedBlockSubscription {
+	subscription := &ImportedBlockSubscription{
+		events: make(chan ImportedBlock, n.bufferSize),
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// Unsubscribe removes the subscription given and closes its events channel.
+func (n *ImportedBlockNotifier) Unsubscribe(subscription *ImportedBlockSubscription) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	_, ok := n.subscriptions[subscription]
+	if !ok {
+		return
+	}
+	delete(n.subscriptions, subscription)
+	close(subscription.events)
+}
+
+func (n *ImportedBlockNotifier) notify(event ImportedBlock) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for subscription := range n.subscriptions {
+		subscription.send(event)
+	}
+}
+
+// send sends the event given to the subscription, dropping its oldest
+// events until there is room for the event in its buffer. It is only
+// called with the notifier mutex locked so there is a single sender.
+func (s *ImportedBlockSubscription) send(event ImportedBlock) {
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
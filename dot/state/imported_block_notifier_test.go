@@ -0,0 +1,120 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveImportedBlocks(t *testing.T, subscription *ImportedBlockSubscription,
+	count int) (events []ImportedBlock) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		select {
+		case event := <-subscription.Events():
+			events = append(events, event)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for imported block event %d", i)
+		}
+	}
+	return events
+}
+
+func Test_ImportedBlockNotifier_multipleSubscribers(t *testing.T) {
+	t.Parallel()
+
+	notifier := newImportedBlockNotifier(4)
+	subscriptionA := notifier.Subscribe()
+	subscriptionB := notifier.Subscribe()
+
+	events := []ImportedBlock{
+		{Hash: common.Hash{1}, Number: 1, IsNewBest: true},
+		{Hash: common.Hash{2}, Number: 2},
+	}
+	for _, event := range events {
+		notifier.notify(event)
+	}
+
+	assert.Equal(t, events, receiveImportedBlocks(t, subscriptionA, len(events)))
+	assert.Equal(t, events, receiveImportedBlocks(t, subscriptionB, len(events)))
+	assert.Zero(t, subscriptionA.Dropped())
+	assert.Zero(t, subscriptionB.Dropped())
+
+	notifier.Unsubscribe(subscriptionA)
+	_, ok := <-subscriptionA.Events()
+	assert.False(t, ok)
+
+	event := ImportedBlock{Hash: common.Hash{3}, Number: 3}
+	notifier.notify(event)
+	assert.Equal(t, []ImportedBlock{event}, receiveImportedBlocks(t, subscriptionB, 1))
+
+	// Unsubscribing twice is a no-op.
+	notifier.Unsubscribe(subscriptionA)
+}
+
+func Test_ImportedBlockNotifier_slowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	const bufferSize = 2
+	notifier := newImportedBlockNotifier(bufferSize)
+	slowSubscription := notifier.Subscribe()
+	subscription := notifier.Subscribe()
+
+	const eventsCount = 5
+	events := make([]ImportedBlock, eventsCount)
+	for i := range events {
+		events[i] = ImportedBlock{Hash: common.Hash{byte(i)}, Number: uint(i)}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, event := range events {
+			notifier.notify(event)
+			// The other subscriber keeps up with the importer.
+			<-subscription.Events()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("importer is stalled by the slow subscriber")
+	}
+	assert.Zero(t, subscription.Dropped())
+
+	// The slow subscriber only receives the most recent events,
+	// and its oldest events are dropped.
+	assert.Equal(t, events[eventsCount-bufferSize:],
+		receiveImportedBlocks(t, slowSubscription, bufferSize))
+	assert.Equal(t, uint64(eventsCount-bufferSize), slowSubscription.Dropped())
+}
+
+func Test_BlockState_ImportedBlockNotifier(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	subscription := bs.ImportedBlockNotifier().Subscribe()
+	arrivalTime := time.Unix(1, 0)
+
+	chainA := addTestChain(t, bs, genesisHeader, 2, common.Hash{0xa}, arrivalTime)
+	// Chain B arrives later, so its block at the same number
+	// as the best block of chain A is not the new best block.
+	chainB := addTestChain(t, bs, chainA[0], 1, common.Hash{0xb}, arrivalTime.Add(time.Second))
+
+	expected := []ImportedBlock{
+		{Hash: chainA[0].Hash(), Number: 1, IsNewBest: true},
+		{Hash: chainA[1].Hash(), Number: 2, IsNewBest: true},
+		{Hash: chainB[0].Hash(), Number: 2},
+	}
+	assert.Equal(t, expected, receiveImportedBlocks(t, subscription, len(expected)))
+}
@@ -4,6 +4,8 @@
 package state
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"path/filepath"
 
@@ -18,10 +20,109 @@
 	"github.com/ChainSafe/gossamer/lib/utils"
 )
 
+// initialisedKey -> whether the state initialisation is complete. It is written
+// as not complete before initialising the state, and as complete once done.
+var initialisedKey = []byte("initialised")
+
+// ErrAlreadyInitialised is returned when initialising the state
+// of a database already initialised without forcing it.
+var ErrAlreadyInitialised = errors.New("state is already initialised")
+
+// IsInitialised returns true if the state initialisation of the database given
+// completed. A database initialised before the initialisation marker existed is
+// considered initialised if it contains the genesis data.
+func IsInitialised(db Getter) (initialised bool, err error) {
+	marker, err := db.Get(initialisedKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		_, err = db.Get(common.GenesisDataKey)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("getting genesis data: %w", err)
+		}
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("getting initialisation marker: %w", err)
+	}
+
+	return bytes.Equal(marker, []byte{1}), nil
+}
+
+// InitialisationDatabase is the database interface to initialise the state.
+type InitialisationDatabase interface {
+	Putter
+	NewBatcher
+}
+
+// InitialiseState marks the state of the empty database given as being initialised,
+// and then writes the genesis trie nodes, the genesis data, the genesis header, the
+// best block hash and the latest storage hash in a single batch. The state is only
+// considered initialised once markInitialised is called, such that an interrupted
+// initialisation is detected and started over.
+func InitialiseState(db InitialisationDatabase, gen *genesis.Genesis, header *types.Header, t *trie.Trie) error {
+	err := db.Put(initialisedKey, []byte{0})
+	if err != nil {
+		return fmt.Errorf("marking state initialisation as started: %w", err)
+	}
+
+	batch := db.NewBatch()
+	defer batch.Reset()
+
+	err = t.WriteDirtyTo(&tablePutter{putter: batch, prefix: storagePrefix})
+	if err != nil {
+		return fmt.Errorf("writing genesis trie: %w", err)
+	}
+
+	err = storeGenesisData(batch, gen.GenesisData())
+	if err != nil {
+		return fmt.Errorf("writing genesis data: %w", err)
+	}
+
+	// the block state created from the genesis header writes the
+	// genesis header again, together with its block number index.
+	_, err = StoreHeader(&tablePutter{putter: batch, prefix: blockPrefix}, header)
+	if err != nil {
+		return fmt.Errorf("writing genesis header: %w", err)
+	}
+
+	err = batch.Put(common.BestBlockHashKey, header.Hash().ToBytes())
+	if err != nil {
+		return fmt.Errorf("writing best block hash: %w", err)
+	}
+
+	err = batch.Put(common.LatestStorageHashKey, header.StateRoot.ToBytes())
+	if err != nil {
+		return fmt.Errorf("writing latest storage hash: %w", err)
+	}
+
+	return batch.Flush()
+}
+
+// markInitialised marks the state initialisation as complete,
+// and must be the last write of the initialisation.
+func markInitialised(db Putter) error {
+	return db.Put(initialisedKey, []byte{1})
+}
+
+// tablePutter puts values at keys prefixed with its prefix, such that
+// values of several database tables can be written in the same batch.
+type tablePutter struct {
+	putter Putter
+	prefix string
+}
+
+func (t *tablePutter) Put(key, value []byte) error {
+	return t.putter.Put(append([]byte(t.prefix), key...), value)
+}
+
 // Initialise initialises the genesis state of the DB using the given storage trie.
 // The trie should be loaded with the genesis storage state.
 // This only needs to be called during genesis initialisation of the node;
 // it is not called during normal startup.
+// It returns ErrAlreadyInitialised if the database is already initialised,
+// unless the service is configured to force the initialisation, in which
+// case the database is cleared. A database whose initialisation was interrupted
+// is always cleared.
 func (s *Service) Initialise(gen *genesis.Genesis, header *types.Header, t *trie.Trie) error {
 	// get data directory from service
 	basepath, err := filepath.Abs(s.dbPath)
@@ -30,19 +131,35 @@ func (s *Service) Initialise(gen *genesis.Genesis, header *types.Header, t *trie
 	}
 
 	// initialise database using data directory
-	db, err := utils.SetupDatabase(basepath, s.isMemDB)
+	db, err := utils.SetupDatabaseWithOptions(basepath, s.isMemDB, s.dbOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create database: %s", err)
 	}
 
 	s.db = db
 
+	initialised, err := IsInitialised(db)
+	if err != nil {
+		return fmt.Errorf("checking if state is initialised: %w", err)
+	}
+
+	if initialised && !s.forceInit {
+		if !s.isMemDB {
+			closeErr := db.Close()
+			if closeErr != nil {
+				logger.Errorf("failed to close database: %s", closeErr)
+			}
+		}
+		return fmt.Errorf("%w: at base path %s", ErrAlreadyInitialised, basepath)
+	}
+
 	if err = db.ClearAll(); err != nil {
 		return fmt.Errorf("failed to clear database: %s", err)
 	}
 
-	if err = t.WriteDirty(chaindb.NewTable(db, storagePrefix)); err != nil {
-		return fmt.Errorf("failed to write genesis trie to database: %w", err)
+	// write initial genesis values to database
+	if err = InitialiseState(db, gen, header, t); err != nil {
+		return fmt.Errorf("failed to write genesis values to database: %w", err)
 	}
 
 	s.Base = NewBaseState(db)
@@ -58,11 +175,6 @@ func (s *Service) Initialise(gen *genesis.Genesis, header *types.Header, t *trie
 	}
 	rt.Stop()
 
-	// write initial genesis values to database
-	if err = s.storeInitialValues(gen.GenesisData(), t); err != nil {
-		return fmt.Errorf("failed to write genesis values to database: %s", err)
-	}
-
 	tries := NewTries()
 	tries.SetTrie(t)
 
@@ -73,7 +185,7 @@ func (s *Service) Initialise(gen *genesis.Genesis, header *types.Header, t *trie
 	}
 
 	// create storage state from genesis trie
-	storageState, err := NewStorageState(db, blockState, tries)
+	storageState, err := NewStorageState(db, blockState, tries, 0, s.PrunerCfg)
 	if err != nil {
 		return fmt.Errorf("failed to create storage state from trie: %s", err)
 	}
@@ -83,7 +195,7 @@ func (s *Service) Initialise(gen *genesis.Genesis, header *types.Header, t *trie
 		return fmt.Errorf("failed to create epoch state: %s", err)
 	}
 
-	grandpaAuths, err := loadGrandpaAuthorities(t)
+	grandpaAuths, err := LoadGrandpaAuthorities(t)
 	if err != nil {
 		return fmt.Errorf("failed to load grandpa authorities: %w", err)
 	}
@@ -93,6 +205,10 @@ func (s *Service) Initialise(gen *genesis.Genesis, header *types.Header, t *trie
 		return fmt.Errorf("failed to create grandpa state: %s", err)
 	}
 
+	if err = markInitialised(db); err != nil {
+		return fmt.Errorf("marking state as initialised: %w", err)
+	}
+
 	// check database type
 	if s.isMemDB {
 		// append storage state and block state to state service
@@ -124,31 +240,6 @@ func (s *Service) loadBabeConfigurationFromRuntime(r BabeConfigurer) (*types.Bab
 	return babeCfg, nil
 }
 
-func loadGrandpaAuthorities(t *trie.Trie) ([]types.GrandpaVoter, error) {
-	key := common.MustHexToBytes(genesis.GrandpaAuthoritiesKeyHex)
-	authsRaw := t.Get(key)
-	if authsRaw == nil {
-		return []types.GrandpaVoter{}, nil
-	}
-
-	return types.DecodeGrandpaVoters(authsRaw[1:])
-}
-
-// storeInitialValues writes initial genesis values to the state database
-func (s *Service) storeInitialValues(data *genesis.Data, t *trie.Trie) error {
-	// write genesis trie to database
-	if err := t.WriteDirty(chaindb.NewTable(s.db, storagePrefix)); err != nil {
-		return fmt.Errorf("failed to write trie to database: %s", err)
-	}
-
-	// write genesis data to state database
-	if err := s.Base.StoreGenesisData(data); err != nil {
-		return fmt.Errorf("failed to write genesis data to database: %s", err)
-	}
-
-	return nil
-}
-
 // CreateGenesisRuntime creates runtime instance form genesis
 func (s *Service) CreateGenesisRuntime(t *trie.Trie, gen *genesis.Genesis) (runtime.Instance, error) {
 	// load genesis state into database
@@ -0,0 +1,135 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/internal/database"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestWriteFailed = errors.New("test write failed")
+
+// failingDatabase is a database failing its write number failAt,
+// counting from 1, to simulate an interrupted process.
+// Batch writes and batch flushes are counted as writes.
+type failingDatabase struct {
+	*database.BadgerDB
+	writes int
+	failAt int
+}
+
+func (d *failingDatabase) write() error {
+	d.writes++
+	if d.writes == d.failAt {
+		return errTestWriteFailed
+	}
+	return nil
+}
+
+func (d *failingDatabase) Put(key, value []byte) error {
+	err := d.write()
+	if err != nil {
+		return err
+	}
+	return d.BadgerDB.Put(key, value)
+}
+
+func (d *failingDatabase) NewBatch() chaindb.Batch {
+	return &failingBatch{Batch: d.BadgerDB.NewBatch(), db: d}
+}
+
+type failingBatch struct {
+	chaindb.Batch
+	db *failingDatabase
+}
+
+func (b *failingBatch) Put(key, value []byte) error {
+	err := b.db.write()
+	if err != nil {
+		return err
+	}
+	return b.Batch.Put(key, value)
+}
+
+func (b *failingBatch) Flush() error {
+	err := b.db.write()
+	if err != nil {
+		return err
+	}
+	return b.Batch.Flush()
+}
+
+func TestInitialiseState(t *testing.T) {
+	t.Parallel()
+
+	gen, genTrie, genesisHeader := newWestendDevGenesisWithTrieAndHeader(t)
+
+	db := &failingDatabase{BadgerDB: NewInMemoryDB(t)}
+	err := InitialiseState(db, &gen, &genesisHeader, genTrie.DeepCopy())
+	require.NoError(t, err)
+	numberOfWrites := db.writes
+
+	// the state is only initialised once marked as initialised.
+	initialised, err := IsInitialised(db)
+	require.NoError(t, err)
+	assert.False(t, initialised)
+
+	err = markInitialised(db)
+	require.NoError(t, err)
+	initialised, err = IsInitialised(db)
+	require.NoError(t, err)
+	assert.True(t, initialised)
+
+	storedHeader, err := LoadHeader(chaindb.NewTable(db, blockPrefix), genesisHeader.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, &genesisHeader, storedHeader)
+	latestStorageHash, err := db.Get(common.LatestStorageHashKey)
+	require.NoError(t, err)
+	assert.Equal(t, genesisHeader.StateRoot.ToBytes(), latestStorageHash)
+
+	failurePoints := map[string]int{
+		"initialisation_marker": 1,
+		"first_batch_write":     2,
+		"middle_batch_write":    numberOfWrites / 2,
+		"last_batch_write":      numberOfWrites - 1,
+		"batch_flush":           numberOfWrites,
+	}
+	for name, failAt := range failurePoints {
+		db := &failingDatabase{BadgerDB: NewInMemoryDB(t), failAt: failAt}
+		err := InitialiseState(db, &gen, &genesisHeader, genTrie.DeepCopy())
+		require.ErrorIs(t, err, errTestWriteFailed, name)
+
+		initialised, err := IsInitialised(db)
+		require.NoError(t, err, name)
+		assert.False(t, initialised, name)
+
+		// nothing of the batch is written.
+		_, err = db.Get(common.GenesisDataKey)
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound, name)
+		_, err = db.Get(append([]byte(storagePrefix), genesisHeader.StateRoot.ToBytes()...))
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound, name)
+	}
+}
+
+func TestIsInitialised_legacyDatabase(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	initialised, err := IsInitialised(db)
+	require.NoError(t, err)
+	assert.False(t, initialised)
+
+	// databases initialised without the initialisation marker contain the genesis data.
+	err = db.Put(common.GenesisDataKey, []byte("{}"))
+	require.NoError(t, err)
+	initialised, err = IsInitialised(db)
+	require.NoError(t, err)
+	assert.True(t, initialised)
+}
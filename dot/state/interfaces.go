@@ -8,6 +8,7 @@
 
 	"github.com/ChainSafe/chaindb"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 )
 
 // GetPutDeleter has methods to get, put and delete key values.
@@ -16,6 +17,12 @@ type GetPutDeleter interface {
 	Deleter
 }
 
+// PutDeleter has methods to put and delete key values.
+type PutDeleter interface {
+	Putter
+	Deleter
+}
+
 // BlockStateDatabase is the database interface for the block state.
 type BlockStateDatabase interface {
 	GetPutDeleter
@@ -23,6 +30,12 @@ type BlockStateDatabase interface {
 	NewBatcher
 }
 
+// EpochStateDatabase is the database interface for the epoch state.
+type EpochStateDatabase interface {
+	GetPutDeleter
+	Haser
+}
+
 // GetPutter has methods to get and put key values.
 type GetPutter interface {
 	Getter
@@ -36,6 +49,13 @@ type GetNewBatcher interface {
 	NewBatcher
 }
 
+// GetHaser has methods to get values and check
+// if values exist at given keys.
+type GetHaser interface {
+	Getter
+	Haser
+}
+
 // Getter gets a value corresponding to the given key.
 type Getter interface {
 	Get(key []byte) (value []byte, err error)
@@ -56,6 +76,11 @@ type Haser interface {
 	Has(key []byte) (has bool, err error)
 }
 
+// HeaderGetter gets the block header corresponding to the given block hash.
+type HeaderGetter interface {
+	GetHeader(hash common.Hash) (header *types.Header, err error)
+}
+
 // NewBatcher creates a new database batch.
 type NewBatcher interface {
 	NewBatch() chaindb.Batch
@@ -0,0 +1,135 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"sync"
+)
+
+// coalesceFunc returns the value pending to be sent to a subscription once
+// the value given is pushed to it, given the value pending, which is nil if
+// there is none, and the value sent last, which is nil if none was sent yet.
+// It returns nil if no value is pending.
+type coalesceFunc[T any] func(pending, sent *T, value T) (newPending *T)
+
+// notifier sends the values it is notified of to each of its subscriptions.
+// Notifying never blocks: if values are not received fast enough by a
+// subscriber, the values not yet received are coalesced into a single
+// pending value using the coalesce function of the notifier.
+type notifier[T any] struct {
+	mutex         sync.Mutex
+	subscriptions map[*subscription[T]]struct{}
+	coalesce      coalesceFunc[T]
+}
+
+func newNotifier[T any](coalesce coalesceFunc[T]) *notifier[T] {
+	return &notifier[T]{
+		subscriptions: make(map[*subscription[T]]struct{}),
+		coalesce:      coalesce,
+	}
+}
+
+// subscribe creates a subscription and launches its goroutine
+// sending its values, which is stopped by the unsubscribe function.
+func (n *notifier[T]) subscribe() (values <-chan T, unsubscribe func()) {
+	subscription := &subscription[T]{
+		values:   make(chan T),
+		coalesce: n.coalesce,
+		ready:    make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	n.mutex.Lock()
+	n.subscriptions[subscription] = struct{}{}
+	n.mutex.Unlock()
+
+	go subscription.run()
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			n.mutex.Lock()
+			delete(n.subscriptions, subscription)
+			n.mutex.Unlock()
+
+			close(subscription.stop)
+			<-subscription.done
+			close(subscription.values)
+		})
+	}
+	return subscription.values, unsubscribe
+}
+
+// notify pushes the value given to each of the subscriptions.
+func (n *notifier[T]) notify(value T) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for subscription := range n.subscriptions {
+		subscription.push(value)
+	}
+}
+
+type subscription[T any] struct {
+	values   chan T
+	coalesce coalesceFunc[T]
+	// pendingMutex protects pending, which is the value not yet taken
+	// by the subscription goroutine, and sent, which is the value
+	// taken last by the subscription goroutine.
+	pendingMutex sync.Mutex
+	pending      *T
+	sent         *T
+	// ready is signalled when pending is set.
+	ready chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// push coalesces the value given with the pending value of the subscription.
+func (s *subscription[T]) push(value T) {
+	s.pendingMutex.Lock()
+	defer s.pendingMutex.Unlock()
+
+	s.pending = s.coalesce(s.pending, s.sent, value)
+	if s.pending == nil {
+		return
+	}
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the pending value to the values channel each
+// time one is set, until the subscription is stopped.
+func (s *subscription[T]) run() {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.ready:
+		}
+
+		s.pendingMutex.Lock()
+		value := s.pending
+		s.pending = nil
+		if value != nil {
+			s.sent = value
+		}
+		s.pendingMutex.Unlock()
+
+		if value == nil {
+			continue
+		}
+
+		select {
+		case s.values <- *value:
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -9,6 +9,7 @@
 	"os"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/state/pruner"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/trie"
 	"github.com/ChainSafe/gossamer/lib/utils"
@@ -33,7 +34,7 @@ type OfflinePruner struct {
 
 // NewOfflinePruner creates an instance of OfflinePruner.
 func NewOfflinePruner(inputDBPath string,
-	retainBlockNum uint32) (pruner *OfflinePruner, err error) {
+	retainBlockNum uint32) (offlinePruner *OfflinePruner, err error) {
 	db, err := utils.LoadChainDB(inputDBPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load DB %w", err)
@@ -75,7 +76,7 @@ func NewOfflinePruner(inputDBPath string,
 	}
 
 	// load storage state
-	storageState, err := NewStorageState(db, blockState, tries)
+	storageState, err := NewStorageState(db, blockState, tries, 0, pruner.Config{Mode: pruner.Archive})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new storage state %w", err)
 	}
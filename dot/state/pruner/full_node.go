@@ -0,0 +1,418 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pruner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+var logger = log.NewFromGlobal(
+	log.AddContext("pkg", "pruner"),
+)
+
+var (
+	// refCountPrefix + node hash -> number of references to the trie node
+	refCountPrefix = []byte("rc")
+	// insertedNodeHashesPrefix + block hash -> hashes of the trie nodes
+	// inserted by the non finalised block
+	insertedNodeHashesPrefix = []byte("inh")
+	// finalisedBlockPrefix + block number -> block hash and state root
+	// of the finalised block whose state is not pruned yet
+	finalisedBlockPrefix = []byte("pfb")
+	// nextPruneNumberKey -> block number of the next finalised state to prune
+	nextPruneNumberKey = []byte("pnn")
+)
+
+// ErrRetainedBlocksZero is returned when creating a full node
+// pruner with no finalised block state to retain.
+var ErrRetainedBlocksZero = errors.New("retained blocks cannot be zero")
+
+// Database is the database interface for the full node pruner,
+// which must be the database containing the state trie nodes.
+type Database interface {
+	Get(key []byte) (value []byte, err error)
+	NewBatch() chaindb.Batch
+}
+
+// DeletedNodeHashesStore stores the hashes of the state trie
+// nodes deleted by each block imported.
+type DeletedNodeHashesStore interface {
+	GetDeletedNodeHashes(blockHash common.Hash) (deletedNodeHashes map[common.Hash]struct{}, err error)
+	DeleteDeletedNodeHashes(blockHash common.Hash) (err error)
+}
+
+// FullNode prunes the state of the finalised blocks falling out of the
+// retention window of the last finalised blocks, and the state of the
+// blocks pruned from forks on finalisation.
+//
+// Each trie node stored is reference counted: each block inserting a
+// node increments its count, and the count is decremented when the node
+// is deleted by a finalised block whose parent state is pruned, or when the
+// block inserting it is pruned from a fork. A node is removed from the
+// database once its count reaches zero. Trie nodes stored without a
+// reference count, such as the nodes of the genesis state, have an
+// implicit count of one.
+type FullNode struct {
+	mutex             sync.Mutex
+	db                Database
+	deletedNodeHashes DeletedNodeHashesStore
+	retainedBlocks    uint32
+	// nextPruneNumber is the block number of the next finalised state
+	// to prune, and is nil until the first finalisation is recorded.
+	nextPruneNumber *uint
+}
+
+// NewFullNode creates a full node pruner keeping the state of the given
+// number of last finalised blocks, which must be at least one.
+func NewFullNode(db Database, deletedNodeHashes DeletedNodeHashesStore,
+	retainedBlocks uint32) (pruner *FullNode, err error) {
+	if retainedBlocks == 0 {
+		return nil, ErrRetainedBlocksZero
+	}
+
+	pruner = &FullNode{
+		db:                db,
+		deletedNodeHashes: deletedNodeHashes,
+		retainedBlocks:    retainedBlocks,
+	}
+
+	encoded, err := db.Get(nextPruneNumberKey)
+	switch {
+	case errors.Is(err, chaindb.ErrKeyNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("getting next prune number: %w", err)
+	default:
+		nextPruneNumber := uint(binary.BigEndian.Uint64(encoded))
+		pruner.nextPruneNumber = &nextPruneNumber
+	}
+
+	return pruner, nil
+}
+
+// StoreJournalRecord increments the reference count of the trie nodes
+// inserted by the block with the given hash, and records them to release
+// them if the block is pruned from a fork. It must be called before the
+// inserted nodes are written to the database. The deleted node hashes are
+// ignored since they are stored by the storage state.
+func (p *FullNode) StoreJournalRecord(_, insertedNodeHashes map[common.Hash]struct{},
+	blockHash common.Hash, _ int64) (err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	insertedKey := prefixedKey(insertedNodeHashesPrefix, blockHash[:])
+	_, err = p.db.Get(insertedKey)
+	if err == nil {
+		// the state of the block is already recorded
+		return nil
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return fmt.Errorf("getting inserted node hashes: %w", err)
+	}
+
+	nodeHashes := make([]common.Hash, 0, len(insertedNodeHashes))
+	for nodeHash := range insertedNodeHashes {
+		nodeHashes = append(nodeHashes, nodeHash)
+	}
+
+	encoded, err := scale.Marshal(nodeHashes)
+	if err != nil {
+		return fmt.Errorf("encoding inserted node hashes: %w", err)
+	}
+
+	batch := p.db.NewBatch()
+	defer batch.Reset()
+
+	for _, nodeHash := range nodeHashes {
+		err = p.incrementRefCount(batch, nodeHash)
+		if err != nil {
+			return fmt.Errorf("incrementing reference count of node %s: %w", nodeHash, err)
+		}
+	}
+
+	err = batch.Put(insertedKey, encoded)
+	if err != nil {
+		return fmt.Errorf("putting inserted node hashes in batch: %w", err)
+	}
+
+	return batch.Flush()
+}
+
+// Finalise records the newly finalised blocks given in ascending number
+// order, releases the trie nodes inserted by the blocks pruned from forks
+// given, and prunes the state of the finalised blocks falling out of the
+// retention window. It returns the state roots of the finalised blocks
+// whose state is pruned.
+func (p *FullNode) Finalise(finalised, pruned []*types.Header) (prunedRoots []common.Hash, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(finalised) > 0 {
+		err = p.recordFinalised(finalised)
+		if err != nil {
+			return nil, fmt.Errorf("recording finalised blocks: %w", err)
+		}
+	}
+
+	for _, header := range pruned {
+		err = p.pruneFork(header.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("pruning state of fork block %s: %w", header.Hash(), err)
+		}
+	}
+
+	if len(finalised) == 0 || p.nextPruneNumber == nil {
+		return nil, nil
+	}
+
+	lastFinalisedNumber := finalised[len(finalised)-1].Number
+	for *p.nextPruneNumber+uint(p.retainedBlocks) <= lastFinalisedNumber {
+		prunedRoot, err := p.pruneFinalised(*p.nextPruneNumber)
+		if err != nil {
+			return prunedRoots, fmt.Errorf("pruning state of finalised block number %d: %w",
+				*p.nextPruneNumber, err)
+		}
+		if prunedRoot != nil {
+			prunedRoots = append(prunedRoots, *prunedRoot)
+		}
+		*p.nextPruneNumber++
+	}
+
+	return prunedRoots, nil
+}
+
+// recordFinalised records the hash and state root of the finalised blocks
+// given, and removes their inserted node hashes which are no longer needed.
+// The first time blocks are finalised, the state of the parent of the first
+// block is set to be the next state to prune.
+func (p *FullNode) recordFinalised(finalised []*types.Header) (err error) {
+	batch := p.db.NewBatch()
+	defer batch.Reset()
+
+	for _, header := range finalised {
+		blockHash := header.Hash()
+		err = batch.Put(finalisedBlockKey(header.Number), prefixedKey(blockHash[:], header.StateRoot[:]))
+		if err != nil {
+			return fmt.Errorf("putting finalised block in batch: %w", err)
+		}
+
+		err = batch.Del(prefixedKey(insertedNodeHashesPrefix, blockHash[:]))
+		if err != nil {
+			return fmt.Errorf("deleting inserted node hashes in batch: %w", err)
+		}
+	}
+
+	var nextPruneNumber uint
+	if p.nextPruneNumber == nil {
+		if finalised[0].Number > 0 {
+			nextPruneNumber = finalised[0].Number - 1
+		}
+		err = batch.Put(nextPruneNumberKey, encodeNumber(nextPruneNumber))
+		if err != nil {
+			return fmt.Errorf("putting next prune number in batch: %w", err)
+		}
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return err
+	}
+
+	if p.nextPruneNumber == nil {
+		p.nextPruneNumber = &nextPruneNumber
+	}
+	return nil
+}
+
+// pruneFork releases the trie nodes inserted by the fork block with the given hash.
+func (p *FullNode) pruneFork(blockHash common.Hash) (err error) {
+	insertedKey := prefixedKey(insertedNodeHashesPrefix, blockHash[:])
+	encoded, err := p.db.Get(insertedKey)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		// the state of the block was not recorded
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("getting inserted node hashes: %w", err)
+	}
+
+	var nodeHashes []common.Hash
+	err = scale.Unmarshal(encoded, &nodeHashes)
+	if err != nil {
+		return fmt.Errorf("decoding inserted node hashes: %w", err)
+	}
+
+	batch := p.db.NewBatch()
+	defer batch.Reset()
+
+	for _, nodeHash := range nodeHashes {
+		err = p.decrementRefCount(batch, nodeHash)
+		if err != nil {
+			return fmt.Errorf("decrementing reference count of node %s: %w", nodeHash, err)
+		}
+	}
+
+	err = batch.Del(insertedKey)
+	if err != nil {
+		return fmt.Errorf("deleting inserted node hashes in batch: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = p.deletedNodeHashes.DeleteDeletedNodeHashes(blockHash)
+	if err != nil {
+		return fmt.Errorf("deleting deleted node hashes: %w", err)
+	}
+	return nil
+}
+
+// pruneFinalised prunes the state of the finalised block with the given
+// number, by releasing the trie nodes deleted by its finalised child block.
+// It returns the state root of the block pruned, which is nil if the block
+// was finalised before its state was tracked by the pruner.
+func (p *FullNode) pruneFinalised(number uint) (prunedRoot *common.Hash, err error) {
+	childHash, _, err := p.getFinalisedBlock(number + 1)
+	if err != nil {
+		return nil, fmt.Errorf("getting finalised child block: %w", err)
+	}
+
+	deletedNodeHashes, err := p.deletedNodeHashes.GetDeletedNodeHashes(childHash)
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, fmt.Errorf("getting deleted node hashes: %w", err)
+	}
+
+	batch := p.db.NewBatch()
+	defer batch.Reset()
+
+	for nodeHash := range deletedNodeHashes {
+		err = p.decrementRefCount(batch, nodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("decrementing reference count of node %s: %w", nodeHash, err)
+		}
+	}
+
+	_, stateRoot, err := p.getFinalisedBlock(number)
+	switch {
+	case errors.Is(err, chaindb.ErrKeyNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("getting finalised block: %w", err)
+	default:
+		prunedRoot = &stateRoot
+	}
+
+	err = batch.Del(finalisedBlockKey(number))
+	if err != nil {
+		return nil, fmt.Errorf("deleting finalised block in batch: %w", err)
+	}
+
+	err = batch.Put(nextPruneNumberKey, encodeNumber(number+1))
+	if err != nil {
+		return nil, fmt.Errorf("putting next prune number in batch: %w", err)
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	// the deleted node hashes are no longer needed once the
+	// state of the parent block is pruned.
+	err = p.deletedNodeHashes.DeleteDeletedNodeHashes(childHash)
+	if err != nil {
+		return nil, fmt.Errorf("deleting deleted node hashes: %w", err)
+	}
+
+	logger.Tracef("pruned state of finalised block number %d", number)
+	return prunedRoot, nil
+}
+
+func (p *FullNode) getFinalisedBlock(number uint) (blockHash, stateRoot common.Hash, err error) {
+	encoded, err := p.db.Get(finalisedBlockKey(number))
+	if err != nil {
+		return blockHash, stateRoot, err
+	}
+
+	copy(blockHash[:], encoded[:common.HashLength])
+	copy(stateRoot[:], encoded[common.HashLength:])
+	return blockHash, stateRoot, nil
+}
+
+// refCount returns the reference count of the trie node with the given hash.
+func (p *FullNode) refCount(nodeHash common.Hash) (count uint32, err error) {
+	encoded, err := p.db.Get(prefixedKey(refCountPrefix, nodeHash[:]))
+	if err == nil {
+		return binary.LittleEndian.Uint32(encoded), nil
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, fmt.Errorf("getting reference count: %w", err)
+	}
+
+	// trie nodes stored without reference count are referenced once.
+	_, err = p.db.Get(nodeHash[:])
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("getting node: %w", err)
+	}
+	return 1, nil
+}
+
+func (p *FullNode) incrementRefCount(batch chaindb.Batch, nodeHash common.Hash) (err error) {
+	count, err := p.refCount(nodeHash)
+	if err != nil {
+		return err
+	}
+
+	encoded := make([]byte, 4)
+	binary.LittleEndian.PutUint32(encoded, count+1)
+	return batch.Put(prefixedKey(refCountPrefix, nodeHash[:]), encoded)
+}
+
+// decrementRefCount decrements the reference count of the trie node
+// with the given hash, and deletes the node if it is no longer referenced.
+func (p *FullNode) decrementRefCount(batch chaindb.Batch, nodeHash common.Hash) (err error) {
+	count, err := p.refCount(nodeHash)
+	if err != nil {
+		return err
+	}
+
+	refCountKey := prefixedKey(refCountPrefix, nodeHash[:])
+	if count > 1 {
+		encoded := make([]byte, 4)
+		binary.LittleEndian.PutUint32(encoded, count-1)
+		return batch.Put(refCountKey, encoded)
+	}
+
+	err = batch.Del(refCountKey)
+	if err != nil {
+		return err
+	}
+	return batch.Del(nodeHash[:])
+}
+
+func finalisedBlockKey(number uint) []byte {
+	return prefixedKey(finalisedBlockPrefix, encodeNumber(number))
+}
+
+// prefixedKey returns a new slice with the prefix followed by the key,
+// such that the prefix given is never modified.
+func prefixedKey(prefix, key []byte) []byte {
+	prefixed := make([]byte, 0, len(prefix)+len(key))
+	prefixed = append(prefixed, prefix...)
+	return append(prefixed, key...)
+}
+
+func encodeNumber(number uint) []byte {
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, uint64(number))
+	return encoded
+}
@@ -4,12 +4,16 @@
 package pruner
 
 import (
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
 const (
 	// Archive pruner mode.
 	Archive = Mode("archive")
+	// Pruned pruner mode, keeping only the state of the
+	// last finalised blocks and of the non finalised blocks.
+	Pruned = Mode("pruned")
 )
 
 // Mode online pruning mode of historical state tries
@@ -18,7 +22,7 @@
 // IsValid checks whether the pruning mode is valid
 func (p Mode) IsValid() bool {
 	switch p {
-	case Archive:
+	case Archive, Pruned:
 		return true
 	default:
 		return false
@@ -27,7 +31,9 @@ func (p Mode) IsValid() bool {
 
 // Config holds state trie pruning mode and retained blocks
 type Config struct {
-	Mode           Mode
+	Mode Mode
+	// RetainedBlocks is the number of last finalised blocks
+	// whose state is kept in the pruned mode.
 	RetainedBlocks uint32
 }
 
@@ -35,6 +41,7 @@ type Config struct {
 type Pruner interface {
 	StoreJournalRecord(deletedNodeHashes, insertedNodeHashes map[common.Hash]struct{},
 		blockHash common.Hash, blockNum int64) error
+	Finalise(finalised, pruned []*types.Header) (prunedRoots []common.Hash, err error)
 }
 
 // ArchiveNode is a no-op since we don't prune nodes in archive mode.
@@ -45,3 +52,8 @@ func (*ArchiveNode) StoreJournalRecord(_, _ map[common.Hash]struct{},
 	_ common.Hash, _ int64) error {
 	return nil
 }
+
+// Finalise for archive node doesn't do anything.
+func (*ArchiveNode) Finalise(_, _ []*types.Header) (prunedRoots []common.Hash, err error) {
+	return nil, nil
+}
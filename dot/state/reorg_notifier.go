@@ -0,0 +1,114 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// ReorgEvent is the event sent to subscribers when the best chain
+// switches from a branch of the block tree to another branch.
+type ReorgEvent struct {
+	// CommonAncestor is the hash of the highest block
+	// common to the previous and the new best chains.
+	CommonAncestor common.Hash
+	// Retracted contains the hashes of the blocks no longer on the best
+	// chain, ordered from the previous best block down to the common
+	// ancestor excluded.
+	Retracted []common.Hash
+	// Enacted contains the hashes of the blocks now on the best chain,
+	// ordered from the common ancestor excluded up to the new best block.
+	Enacted []common.Hash
+}
+
+// SubscribeReorgs returns a channel receiving an event each time the best
+// chain switches branches, once the database batch of the reorg is written,
+// and a function to unsubscribe which closes the channel.
+// Sending events never blocks block import: if events are not received
+// fast enough, the reorgs not yet received are coalesced into a single
+// event from the best chain of the last event received, such that events
+// are never received out of order.
+func (bs *BlockState) SubscribeReorgs() (events <-chan ReorgEvent, unsubscribe func()) {
+	return bs.reorgNotifier.subscribe()
+}
+
+// newReorgNotifier returns a notifier taking in each change of the best
+// chain and sending the changes switching branches to its subscriptions.
+// It must be notified of every change of the best chain and in order,
+// such that the pending reorgs of subscriptions can be coalesced with them.
+func newReorgNotifier() *notifier[ReorgEvent] {
+	return newNotifier(coalesceReorg)
+}
+
+// coalesceReorg coalesces the change of best chain given, which is either a
+// reorg or an extension of the best chain if it has no retracted hash, with
+// the pending reorg. A change extending the best chain is ignored if there is
+// no pending reorg, since the subscriber only needs it to follow a reorg it
+// did not receive yet.
+func coalesceReorg(pending, _ *ReorgEvent, change ReorgEvent) (newPending *ReorgEvent) {
+	if pending == nil {
+		if len(change.Retracted) == 0 {
+			return nil
+		}
+		copied := copyReorgEvent(change)
+		return &copied
+	}
+
+	coalesced := coalesceReorgEvents(*pending, change)
+	if len(coalesced.Retracted) == 0 {
+		// The reorgs pending were reverted, so the best chain
+		// is only extended from the best chain last received.
+		return nil
+	}
+	return &coalesced
+}
+
+// coalesceReorgEvents returns the change of best chain from the best chain
+// before the first change given to the best chain after the second change
+// given. The second change must start from the best block the first change
+// ends with.
+func coalesceReorgEvents(first, second ReorgEvent) (coalesced ReorgEvent) {
+	if len(second.Retracted) <= len(first.Enacted) {
+		// The second change only retracts blocks enacted by the first change.
+		keptEnacted := first.Enacted[:len(first.Enacted)-len(second.Retracted)]
+		coalesced = ReorgEvent{
+			CommonAncestor: first.CommonAncestor,
+			Retracted:      append([]common.Hash{}, first.Retracted...),
+			Enacted:        append(append([]common.Hash{}, keptEnacted...), second.Enacted...),
+		}
+	} else {
+		// The second change retracts all the blocks enacted by the first
+		// change, and blocks from the first common ancestor down.
+		retractedBelow := second.Retracted[len(first.Enacted):]
+		coalesced = ReorgEvent{
+			CommonAncestor: second.CommonAncestor,
+			Retracted:      append(append([]common.Hash{}, first.Retracted...), retractedBelow...),
+			Enacted:        append([]common.Hash{}, second.Enacted...),
+		}
+	}
+
+	// Blocks both retracted and enacted again are common to both chains.
+	for len(coalesced.Retracted) > 0 && len(coalesced.Enacted) > 0 &&
+		coalesced.Retracted[len(coalesced.Retracted)-1] == coalesced.Enacted[0] {
+		coalesced.CommonAncestor = coalesced.Enacted[0]
+		coalesced.Retracted = coalesced.Retracted[:len(coalesced.Retracted)-1]
+		coalesced.Enacted = coalesced.Enacted[1:]
+	}
+
+	return coalesced
+}
+
+func copyReorgEvent(event ReorgEvent) (copied ReorgEvent) {
+	return ReorgEvent{
+		CommonAncestor: event.CommonAncestor,
+		Retracted:      append([]common.Hash{}, event.Retracted...),
+		Enacted:        append([]common.Hash{}, event.Enacted...),
+	}
+}
+
+func reverseHashes(hashes []common.Hash) {
+	for i, j := 0, len(hashes)-1; i < j; i, j = i+1, j-1 {
+		hashes[i], hashes[j] = hashes[j], hashes[i]
+	}
+}
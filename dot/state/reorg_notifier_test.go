@@ -0,0 +1,256 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiveReorg receives a reorg event from the channel given,
+// failing the test if no event is received within a second.
+func receiveReorg(t *testing.T, events <-chan ReorgEvent) ReorgEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "events channel closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for reorg event")
+		return ReorgEvent{}
+	}
+}
+
+func Test_BlockState_SubscribeReorgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		canonicalLength int
+		forkLength      int
+	}{
+		"2_blocks_reorg": {
+			canonicalLength: 2,
+			forkLength:      3,
+		},
+		"50_blocks_reorg": {
+			canonicalLength: 50,
+			forkLength:      51,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			bs := newTestBlockState(t, newTriesEmpty())
+			genesisHeader, err := bs.BestBlockHeader()
+			require.NoError(t, err)
+
+			events, unsubscribe := bs.SubscribeReorgs()
+			defer unsubscribe()
+
+			arrivalTime := time.Unix(1, 0)
+			canonicalChain := addTestChain(t, bs, genesisHeader, testCase.canonicalLength,
+				common.Hash{0xa}, arrivalTime)
+			// The fork arrives later so it only becomes the
+			// best chain once it has more primary blocks.
+			fork := addTestChain(t, bs, genesisHeader, testCase.forkLength,
+				common.Hash{0xb}, arrivalTime.Add(time.Second))
+			require.Equal(t, fork[len(fork)-1].Hash(), bs.BestBlockHash())
+
+			retracted := headersToHashes(canonicalChain)
+			reverseHashes(retracted)
+			expected := ReorgEvent{
+				CommonAncestor: genesisHeader.Hash(),
+				Retracted:      retracted,
+				Enacted:        headersToHashes(fork),
+			}
+			event := receiveReorg(t, events)
+			assert.Equal(t, expected, event)
+
+			// Extending the best chain is not a reorg.
+			addTestChain(t, bs, fork[len(fork)-1], 1, common.Hash{0xb}, arrivalTime)
+			unsubscribe()
+			_, ok := <-events
+			assert.False(t, ok)
+		})
+	}
+}
+
+func Test_BlockState_SubscribeReorgs_slowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	events, unsubscribe := bs.SubscribeReorgs()
+	defer unsubscribe()
+
+	// Reorgs are done without receiving events, which must not
+	// block block import, with each fork longer than the previous one.
+	arrivalTime := time.Unix(1, 0)
+	var bestChain []*types.Header
+	for i := 1; i <= 10; i++ {
+		bestChain = addTestChain(t, bs, genesisHeader, i, common.Hash{byte(i)}, arrivalTime)
+	}
+	bestBlockHash := bs.BestBlockHash()
+	require.Equal(t, bestChain[len(bestChain)-1].Hash(), bestBlockHash)
+
+	// Events are received in order, with the reorgs not yet
+	// received coalesced into the last event received.
+	var event ReorgEvent
+	for {
+		event = receiveReorg(t, events)
+		if event.Enacted[len(event.Enacted)-1] == bestBlockHash {
+			break
+		}
+	}
+	assert.Equal(t, genesisHeader.Hash(), event.CommonAncestor)
+	assert.Equal(t, headersToHashes(bestChain), event.Enacted)
+}
+
+func Test_subscription_push_reorg(t *testing.T) {
+	t.Parallel()
+
+	subscription := &subscription[ReorgEvent]{
+		coalesce: coalesceReorg,
+		ready:    make(chan struct{}, 1),
+	}
+
+	// Extensions of the best chain without pending reorg are ignored.
+	subscription.push(ReorgEvent{CommonAncestor: common.Hash{1}, Enacted: []common.Hash{{2}}})
+	assert.Nil(t, subscription.pending)
+	assert.Len(t, subscription.ready, 0)
+
+	// Chain 1 <- 2 is replaced with 1 <- 3 <- 4.
+	subscription.push(ReorgEvent{
+		CommonAncestor: common.Hash{1},
+		Retracted:      []common.Hash{{2}},
+		Enacted:        []common.Hash{{3}, {4}},
+	})
+	// The best chain is extended with 5.
+	subscription.push(ReorgEvent{CommonAncestor: common.Hash{4}, Enacted: []common.Hash{{5}}})
+	// Chain 4 <- 5 is replaced with 4 <- 6.
+	subscription.push(ReorgEvent{
+		CommonAncestor: common.Hash{4},
+		Retracted:      []common.Hash{{5}},
+		Enacted:        []common.Hash{{6}},
+	})
+
+	expected := &ReorgEvent{
+		CommonAncestor: common.Hash{1},
+		Retracted:      []common.Hash{{2}},
+		Enacted:        []common.Hash{{3}, {4}, {6}},
+	}
+	assert.Equal(t, expected, subscription.pending)
+	assert.Len(t, subscription.ready, 1)
+
+	// Reverting to chain 1 <- 2 leaves no reorg pending.
+	subscription.push(ReorgEvent{
+		CommonAncestor: common.Hash{1},
+		Retracted:      []common.Hash{{6}, {4}, {3}},
+		Enacted:        []common.Hash{{2}},
+	})
+	assert.Nil(t, subscription.pending)
+}
+
+func Test_coalesceReorgEvents(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		first     ReorgEvent
+		second    ReorgEvent
+		coalesced ReorgEvent
+	}{
+		"extension": {
+			first: ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+				Enacted:        []common.Hash{{3}},
+			},
+			second: ReorgEvent{
+				CommonAncestor: common.Hash{3},
+				Enacted:        []common.Hash{{4}},
+			},
+			coalesced: ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+				Enacted:        []common.Hash{{3}, {4}},
+			},
+		},
+		"reorg_above_first_common_ancestor": {
+			first: ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+				Enacted:        []common.Hash{{3}, {4}},
+			},
+			second: ReorgEvent{
+				CommonAncestor: common.Hash{3},
+				Retracted:      []common.Hash{{4}},
+				Enacted:        []common.Hash{{5}, {6}},
+			},
+			coalesced: ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+				Enacted:        []common.Hash{{3}, {5}, {6}},
+			},
+		},
+		"reorg_below_first_common_ancestor": {
+			// Chain 0 <- 1 <- 2 is replaced with 0 <- 1 <- 3,
+			// which is replaced with 0 <- 4.
+			first: ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+				Enacted:        []common.Hash{{3}},
+			},
+			second: ReorgEvent{
+				CommonAncestor: common.Hash{0},
+				Retracted:      []common.Hash{{3}, {1}},
+				Enacted:        []common.Hash{{4}},
+			},
+			coalesced: ReorgEvent{
+				CommonAncestor: common.Hash{0},
+				Retracted:      []common.Hash{{2}, {1}},
+				Enacted:        []common.Hash{{4}},
+			},
+		},
+		"reorg_back_to_common_chain": {
+			// Chain 0 <- 1 <- 2 is replaced with 0 <- 3,
+			// which is replaced with 0 <- 1 <- 4.
+			first: ReorgEvent{
+				CommonAncestor: common.Hash{0},
+				Retracted:      []common.Hash{{2}, {1}},
+				Enacted:        []common.Hash{{3}},
+			},
+			second: ReorgEvent{
+				CommonAncestor: common.Hash{0},
+				Retracted:      []common.Hash{{3}},
+				Enacted:        []common.Hash{{1}, {4}},
+			},
+			coalesced: ReorgEvent{
+				CommonAncestor: common.Hash{1},
+				Retracted:      []common.Hash{{2}},
+				Enacted:        []common.Hash{{4}},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			coalesced := coalesceReorgEvents(testCase.first, testCase.second)
+			assert.Equal(t, testCase.coalesced, coalesced)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// RegisterRuntimeUpdateHook registers a hook called with the runtime code
+// stored at the :code key and its blake2b hash each time the runtime code of
+// the best block changes, once the new best block is committed. This is the
+// case when a block writing a new runtime code becomes the best block, and
+// when the best chain switches to a branch with a different runtime code,
+// such as a reorg reverting a runtime upgrade. The hook is called once for
+// each change of the best block changing the runtime code, even if the best
+// chain switches across several blocks changing it.
+//
+// Hooks are called synchronously, in registration order, while the block
+// state is locked for the update, so they must return quickly and must not
+// call methods of the block state. A hook panicking is recovered and logged,
+// and does not prevent the other hooks from being called.
+// The code given is shared between hooks and must not be modified.
+func (s *StorageState) RegisterRuntimeUpdateHook(hook func(codeHash common.Hash, code []byte)) {
+	first := s.runtimeUpdates.add(hook)
+	if !first {
+		return
+	}
+
+	s.blockState.OnBestBlockChange(s.handleBestBlockRuntimeCode)
+
+	// The runtime code of the current best block is the reference
+	// the runtime code of the next best blocks is compared with.
+	code, err := s.GetStorage(nil, codeKey)
+	if err != nil {
+		logger.Warnf("failed to get the runtime code of the best block: %s", err)
+		return
+	}
+	s.runtimeUpdates.initialise(code)
+}
+
+// handleBestBlockRuntimeCode is the best block hook calling the runtime
+// update hooks if the runtime code of the new best block is different
+// from the runtime code of the previous best block.
+func (s *StorageState) handleBestBlockRuntimeCode(header *types.Header) {
+	code, err := s.GetStorage(&header.StateRoot, codeKey)
+	if err != nil {
+		logger.Errorf("failed to get the runtime code of block number %d with hash %s: %s",
+			header.Number, header.Hash(), err)
+		return
+	}
+	s.runtimeUpdates.update(code)
+}
+
+// runtimeUpdateHooks contains the runtime update hooks
+// and the runtime code of the best block.
+type runtimeUpdateHooks struct {
+	mutex sync.Mutex
+	hooks []func(codeHash common.Hash, code []byte)
+	// code is the runtime code of the best block, and is nil
+	// until the runtime code of a best block is known.
+	code []byte
+}
+
+func newRuntimeUpdateHooks() *runtimeUpdateHooks {
+	return &runtimeUpdateHooks{}
+}
+
+// add adds the hook given and returns true if it is the first hook added.
+func (r *runtimeUpdateHooks) add(hook func(codeHash common.Hash, code []byte)) (first bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.hooks = append(r.hooks, hook)
+	return len(r.hooks) == 1
+}
+
+// initialise sets the runtime code of the best block if it is not already
+// set by a change of best block, without calling the hooks.
+func (r *runtimeUpdateHooks) initialise(code []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.code == nil && len(code) > 0 {
+		r.code = copyBytes(code)
+	}
+}
+
+// update sets the runtime code of the new best block, and calls the hooks
+// if it is different from the runtime code of the previous best block.
+// The hooks are not called if the runtime code of the previous best block
+// is not known, or if the new best block has no runtime code.
+func (r *runtimeUpdateHooks) update(code []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(code) == 0 || bytes.Equal(code, r.code) {
+		return
+	}
+
+	previousCode := r.code
+	r.code = copyBytes(code)
+	if previousCode == nil {
+		return
+	}
+
+	codeHash, err := common.Blake2bHash(r.code)
+	if err != nil {
+		logger.Errorf("failed to hash the runtime code: %s", err)
+		return
+	}
+
+	for _, hook := range r.hooks {
+		callRuntimeUpdateHook(hook, codeHash, r.code)
+	}
+}
+
+// callRuntimeUpdateHook calls the hook given with the runtime
+// code given, recovering and logging any panic of the hook.
+func callRuntimeUpdateHook(hook func(codeHash common.Hash, code []byte), codeHash common.Hash, code []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("runtime update hook panicked for code hash %s: %v", codeHash, r)
+		}
+	}()
+	hook(codeHash, code)
+}
+
+func copyBytes(b []byte) (copied []byte) {
+	copied = make([]byte, len(b))
+	copy(copied, b)
+	return copied
+}
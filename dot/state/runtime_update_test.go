@@ -0,0 +1,59 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageState_RegisterRuntimeUpdateHook(t *testing.T) {
+	storage := newTestStorageState(t)
+
+	oldCode, newCode := []byte("old runtime code"), []byte("new runtime code")
+	block1 := addTestStorageBlock(t, storage, testGenesisHeader, map[string][]byte{
+		string(codeKey): oldCode,
+	})
+
+	type runtimeUpdate struct {
+		codeHash common.Hash
+		code     []byte
+	}
+	var updates []runtimeUpdate
+	storage.RegisterRuntimeUpdateHook(func(codeHash common.Hash, code []byte) {
+		updates = append(updates, runtimeUpdate{codeHash: codeHash, code: code})
+	})
+	oldCodeUpdate := runtimeUpdate{codeHash: common.MustBlake2bHash(oldCode), code: oldCode}
+	newCodeUpdate := runtimeUpdate{codeHash: common.MustBlake2bHash(newCode), code: newCode}
+
+	// The best chain is extended without changing the runtime code.
+	block2 := addTestStorageBlock(t, storage, block1, map[string][]byte{"key": {1}})
+	assert.Empty(t, updates)
+
+	// On-chain runtime upgrade.
+	block3 := addTestStorageBlock(t, storage, block2, map[string][]byte{
+		string(codeKey): newCode,
+	})
+	assert.Equal(t, []runtimeUpdate{newCodeUpdate}, updates)
+
+	block4 := addTestStorageBlock(t, storage, block3, map[string][]byte{"key": {2}})
+	assert.Equal(t, []runtimeUpdate{newCodeUpdate}, updates)
+
+	// A fork without the runtime upgrade becomes the best chain, reverting it.
+	forkBlock3 := addTestStorageBlock(t, storage, block2, map[string][]byte{"key": {3}})
+	forkBlock4 := addTestStorageBlock(t, storage, forkBlock3, map[string][]byte{"key": {4}})
+	assert.Equal(t, []runtimeUpdate{newCodeUpdate}, updates)
+	forkBlock5 := addTestStorageBlock(t, storage, forkBlock4, map[string][]byte{"key": {5}})
+	assert.Equal(t, forkBlock5.Hash(), storage.blockState.BestBlockHash())
+	assert.Equal(t, []runtimeUpdate{newCodeUpdate, oldCodeUpdate}, updates)
+
+	// The chain with the runtime upgrade becomes the best chain again,
+	// the hook being called once although several blocks are enacted.
+	block5 := addTestStorageBlock(t, storage, block4, map[string][]byte{"key": {6}})
+	block6 := addTestStorageBlock(t, storage, block5, map[string][]byte{"key": {7}})
+	assert.Equal(t, block6.Hash(), storage.blockState.BestBlockHash())
+	assert.Equal(t, []runtimeUpdate{newCodeUpdate, oldCodeUpdate, newCodeUpdate}, updates)
+}
@@ -4,11 +4,14 @@
 package state
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/ChainSafe/gossamer/dot/state/pruner"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/database"
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/internal/metrics"
 	"github.com/ChainSafe/gossamer/lib/blocktree"
@@ -22,11 +25,16 @@
 	log.AddContext("pkg", "state"),
 )
 
+// blockTreeStoreInterval is the interval at which the block tree
+// is stored to the database, such that it can be restored on restart.
+const blockTreeStoreInterval = time.Minute
+
 // Service is the struct that holds storage, block and network states
 type Service struct {
 	dbPath      string
 	logLvl      log.Level
-	db          *chaindb.BadgerDB
+	db          chaindb.Database
+	dbOptions   database.Options
 	isMemDB     bool // set to true if using an in-memory database; only used for testing.
 	Base        *BaseState
 	Storage     *StorageState
@@ -36,9 +44,26 @@ type Service struct {
 	Grandpa     *GrandpaState
 	Slot        *SlotState
 	closeCh     chan interface{}
+	// blockTreeStorerDone is closed once the goroutine periodically
+	// storing the block tree exits, and is nil if it is not started.
+	blockTreeStorerDone chan struct{}
 
 	PrunerCfg pruner.Config
 	Telemetry Telemetry
+	// trieNodeCacheSize is the maximum size in bytes of the
+	// decoded trie nodes cached by the storage state.
+	trieNodeCacheSize uint64
+	// headersOnly is true if the bodies of finalised blocks are not stored.
+	headersOnly bool
+	// retainedBodies is the number of bodies of the last finalised
+	// blocks kept, and 0 if block bodies are never pruned.
+	retainedBodies uint
+	// storageMetricsLogThreshold is the number of storage keys changed by a block
+	// from which a summary of its storage changes is logged, and 0 disables it.
+	storageMetricsLogThreshold uint
+	// forceInit is true if an already initialised database
+	// is cleared when initialising the state.
+	forceInit bool
 
 	// Below are for testing only.
 	BabeThresholdNumerator   uint64
@@ -47,27 +72,59 @@ type Service struct {
 
 // Config is the default configuration used by state service.
 type Config struct {
-	Path      string
-	LogLevel  log.Level
-	PrunerCfg pruner.Config
-	Telemetry Telemetry
-	Metrics   metrics.IntervalConfig
+	Path     string
+	LogLevel log.Level
+	// DatabaseOptions are the tuning options of the database,
+	// and the default options are used if it is left to its zero value.
+	DatabaseOptions database.Options
+	PrunerCfg       pruner.Config
+	Telemetry       Telemetry
+	Metrics         metrics.IntervalConfig
+	// TrieNodeCacheSize is the maximum size in bytes of the decoded
+	// trie nodes cached in memory, and 0 disables the cache.
+	TrieNodeCacheSize uint64
+	// HeadersOnly can be set to true to not store the bodies and the state
+	// tries of blocks, such that only their headers, justifications and
+	// finality records are kept in the database. The state tries are only
+	// kept in memory, so the state is downloaded again on restart.
+	HeadersOnly bool
+	// RetainedBodies is the number of bodies of the last finalised blocks
+	// kept in the database, the bodies of older finalised blocks being
+	// pruned while keeping their headers. It is 0 to never prune them.
+	RetainedBodies uint
+	// StorageMetricsLogThreshold is the number of storage keys changed by a block
+	// from which a summary of its storage changes is logged, and 0 disables it.
+	StorageMetricsLogThreshold uint
+	// ForceInit can be set to true to clear an already initialised
+	// database when initialising the state.
+	ForceInit bool
 }
 
 // NewService create a new instance of Service
 func NewService(config Config) *Service {
 	logger.Patch(log.SetLevel(config.LogLevel))
 
+	dbOptions := config.DatabaseOptions
+	if dbOptions == (database.Options{}) {
+		dbOptions = database.DefaultOptions()
+	}
+
 	return &Service{
-		dbPath:    config.Path,
-		logLvl:    config.LogLevel,
-		db:        nil,
-		isMemDB:   false,
-		Storage:   nil,
-		Block:     nil,
-		closeCh:   make(chan interface{}),
-		PrunerCfg: config.PrunerCfg,
-		Telemetry: config.Telemetry,
+		dbPath:                     config.Path,
+		logLvl:                     config.LogLevel,
+		dbOptions:                  dbOptions,
+		db:                         nil,
+		isMemDB:                    false,
+		Storage:                    nil,
+		Block:                      nil,
+		closeCh:                    make(chan interface{}),
+		PrunerCfg:                  config.PrunerCfg,
+		Telemetry:                  config.Telemetry,
+		trieNodeCacheSize:          config.TrieNodeCacheSize,
+		headersOnly:                config.HeadersOnly,
+		retainedBodies:             config.RetainedBodies,
+		storageMetricsLogThreshold: config.StorageMetricsLogThreshold,
+		forceInit:                  config.ForceInit,
 	}
 }
 
@@ -79,7 +136,7 @@ func (s *Service) UseMemDB() {
 }
 
 // DB returns the Service's database
-func (s *Service) DB() *chaindb.BadgerDB {
+func (s *Service) DB() chaindb.Database {
 	return s.db
 }
 
@@ -96,7 +153,7 @@ func (s *Service) SetupBase() error {
 	}
 
 	// initialise database
-	db, err := utils.SetupDatabase(basepath, false)
+	db, err := utils.SetupDatabaseWithOptions(basepath, false, s.dbOptions)
 	if err != nil {
 		return err
 	}
@@ -121,6 +178,13 @@ func (s *Service) Start() (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to create block state: %w", err)
 	}
+	s.Block.headersOnly = s.headersOnly
+	s.Block.retainedBodies = s.retainedBodies
+
+	err = migrateFirstSlot(s.db, s.Block)
+	if err != nil {
+		return fmt.Errorf("migrating first slot: %w", err)
+	}
 
 	// retrieve latest header
 	bestHeader, err := s.Block.GetHighestFinalisedHeader()
@@ -132,10 +196,11 @@ func (s *Service) Start() (err error) {
 	logger.Debugf("start with latest state root: %s", stateRoot)
 
 	// create storage state
-	s.Storage, err = NewStorageState(s.db, s.Block, tries)
+	s.Storage, err = NewStorageState(s.db, s.Block, tries, s.trieNodeCacheSize, s.PrunerCfg)
 	if err != nil {
 		return fmt.Errorf("failed to create storage state: %w", err)
 	}
+	s.Storage.metrics.logThreshold = s.storageMetricsLogThreshold
 
 	// load current storage state trie into memory
 	_, err = s.Storage.LoadFromDB(stateRoot)
@@ -159,9 +224,33 @@ func (s *Service) Start() (err error) {
 		s.Block.BestBlockHash(), num, s.Block.genesisHash.String())
 
 	s.Slot = NewSlotState(s.db)
+
+	s.blockTreeStorerDone = make(chan struct{})
+	go s.storeBlockTreePeriodically(blockTreeStoreInterval)
 	return nil
 }
 
+// storeBlockTreePeriodically stores the block tree at each interval
+// given until the service is stopped.
+func (s *Service) storeBlockTreePeriodically(interval time.Duration) {
+	defer close(s.blockTreeStorerDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			err := s.Block.StoreBlockTree()
+			if err != nil {
+				logger.Errorf("storing block tree: %s", err)
+			}
+		}
+	}
+}
+
 // Rewind rewinds the chain to the given block number.
 // If the given number of blocks is greater than the chain height, it will rewind to genesis.
 func (s *Service) Rewind(toBlock uint) error {
@@ -180,6 +269,11 @@ func (s *Service) Rewind(toBlock uint) error {
 	}
 
 	s.Block.bt = blocktree.NewBlockTreeFromRoot(&root.Header)
+	err = s.Block.resetBlockNumberIndex(&root.Header)
+	if err != nil {
+		return fmt.Errorf("resetting block number index: %w", err)
+	}
+	s.Block.hooks.callQueued()
 
 	header, err := s.Block.BestBlockHeader()
 	if err != nil {
@@ -203,6 +297,13 @@ func (s *Service) Rewind(toBlock uint) error {
 
 	s.Block.lastFinalised = header.Hash()
 
+	// rewinding moves the highest finalised block backwards,
+	// which is otherwise rejected on finalisation.
+	err = putHighestFinalisedHash(s.Block.db, header.Hash())
+	if err != nil {
+		return err
+	}
+
 	// TODO: this is broken, it needs to set the latest finalised header after
 	// rewinding to some block number, but there is no reverse lookup function
 	// for block -> (round, setID) where it was finalised (#1859)
@@ -238,22 +339,40 @@ func (s *Service) Rewind(toBlock uint) error {
 	return nil
 }
 
-// Stop closes each state database
-func (s *Service) Stop() error {
+// Stop closes each state database. The database is always flushed and
+// closed, even if storing the block tree fails, and all the errors
+// encountered are returned joined together.
+func (s *Service) Stop() (err error) {
 	close(s.closeCh)
 
+	if s.blockTreeStorerDone != nil {
+		<-s.blockTreeStorerDone
+	}
+
+	var errs []error
+	err = s.Block.StoreBlockTree()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("storing block tree: %w", err))
+	}
+
 	hash, err := s.Block.GetHighestFinalisedHash()
 	if err != nil {
-		return err
+		errs = append(errs, fmt.Errorf("getting highest finalised hash: %w", err))
+	} else {
+		logger.Debugf("stop with best finalised hash %s", hash)
 	}
 
-	logger.Debugf("stop with best finalised hash %s", hash)
+	err = s.db.Flush()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("flushing database: %w", err))
+	}
 
-	if err = s.db.Flush(); err != nil {
-		return err
+	err = s.db.Close()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("closing database: %w", err))
 	}
 
-	return s.db.Close()
+	return errors.Join(errs...)
 }
 
 // Import imports the given state corresponding to the given header and sets the head of the chain
@@ -262,7 +381,7 @@ func (s *Service) Import(header *types.Header, t *trie.Trie, firstSlot uint64) e
 	var err error
 	// initialise database using data directory
 	if !s.isMemDB {
-		s.db, err = utils.SetupDatabase(s.dbPath, s.isMemDB)
+		s.db, err = utils.SetupDatabaseWithOptions(s.dbPath, s.isMemDB, s.dbOptions)
 		if err != nil {
 			return fmt.Errorf("failed to create database: %w", err)
 		}
@@ -283,6 +402,12 @@ func (s *Service) Import(header *types.Header, t *trie.Trie, firstSlot uint64) e
 
 	s.Base = NewBaseState(s.db)
 
+	if header.Number == 1 {
+		if err = checkFirstSlot(firstSlot, header); err != nil {
+			return err
+		}
+	}
+
 	if err = s.Base.storeFirstSlot(firstSlot); err != nil {
 		return err
 	}
@@ -327,6 +452,9 @@ func (s *Service) Import(header *types.Header, t *trie.Trie, firstSlot uint64) e
 	if err := block.setHighestRoundAndSetID(0, 0); err != nil {
 		return err
 	}
+	if err := putHighestFinalisedHash(block.db, hash); err != nil {
+		return err
+	}
 
 	logger.Debugf(
 		"Import best block hash %s with latest state root %s",
@@ -16,9 +16,11 @@
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/lib/utils"
 	"github.com/golang/mock/gomock"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -91,6 +93,10 @@ func TestService_Initialise(t *testing.T) {
 	genesisHeaderPtr := types.NewHeader(common.NewHash([]byte{77}),
 		genTrie.MustHash(), trie.EmptyHash, 0, types.NewDigest())
 
+	err = state.Initialise(&genData, genesisHeaderPtr, genTrieCopy)
+	require.ErrorIs(t, err, ErrAlreadyInitialised)
+
+	state.forceInit = true
 	err = state.Initialise(&genData, genesisHeaderPtr, genTrieCopy)
 	require.NoError(t, err)
 
@@ -167,6 +173,155 @@ func TestService_BlockTree(t *testing.T) {
 	require.Equal(t, stateA.Block.BestBlockHash(), stateB.Block.BestBlockHash())
 }
 
+func TestService_BlockTree_restart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(gomock.Any()).AnyTimes()
+
+	config := Config{
+		Path:      t.TempDir(),
+		LogLevel:  log.Info,
+		Telemetry: telemetryMock,
+	}
+
+	stateA := NewService(config)
+
+	genData, genTrie, genesisHeader := newWestendDevGenesisWithTrieAndHeader(t)
+	err := stateA.Initialise(&genData, &genesisHeader, &genTrie)
+	require.NoError(t, err)
+
+	err = stateA.SetupBase()
+	require.NoError(t, err)
+
+	err = stateA.Start()
+	require.NoError(t, err)
+
+	// Chains A and B have the same length, and chain A arrived first,
+	// so chain A is the best chain only if the arrival times are restored.
+	arrivalTime := time.Unix(1, 0)
+	chainA := addTestChain(t, stateA.Block, &genesisHeader, 3, common.Hash{0xb}, arrivalTime)
+	chainB := addTestChain(t, stateA.Block, &genesisHeader, 3, common.Hash{0xc}, arrivalTime.Add(time.Second))
+	// Chain C is dangling once its first block is deleted from the database.
+	chainC := addTestChain(t, stateA.Block, chainA[0], 1, common.Hash{0xd}, arrivalTime)
+	require.Equal(t, chainA[2].Hash(), stateA.Block.BestBlockHash())
+	leaves := stateA.Block.Leaves()
+
+	err = stateA.Stop()
+	require.NoError(t, err)
+
+	stateB := NewService(config)
+
+	err = stateB.SetupBase()
+	require.NoError(t, err)
+
+	err = stateB.Start()
+	require.NoError(t, err)
+
+	assert.Equal(t, chainA[2].Hash(), stateB.Block.BestBlockHash())
+	assert.ElementsMatch(t, leaves, stateB.Block.Leaves())
+	header, err := stateB.Block.GetHeader(chainB[2].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, chainB[2], header)
+	hash, err := stateB.Block.GetHashByNumber(chainA[2].Number)
+	require.NoError(t, err)
+	assert.Equal(t, chainA[2].Hash(), hash)
+
+	err = stateB.Stop()
+	require.NoError(t, err)
+
+	db, err := utils.SetupDatabase(config.Path, false)
+	require.NoError(t, err)
+	err = chaindb.NewTable(db, blockPrefix).Del(prefixKey(chainC[0].Hash(), unfinalisedBlockPrefix))
+	require.NoError(t, err)
+	err = db.Close()
+	require.NoError(t, err)
+
+	stateC := NewService(config)
+
+	err = stateC.SetupBase()
+	require.NoError(t, err)
+
+	err = stateC.Start()
+	require.NoError(t, err)
+
+	assert.Equal(t, chainA[2].Hash(), stateC.Block.BestBlockHash())
+	assert.ElementsMatch(t, []common.Hash{chainA[2].Hash(), chainB[2].Hash()}, stateC.Block.Leaves())
+	has, err := stateC.Block.HasHeader(chainC[0].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	err = stateC.Stop()
+	require.NoError(t, err)
+}
+
+func TestService_BestBlock_restart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(gomock.Any()).AnyTimes()
+
+	config := Config{
+		Path:      t.TempDir(),
+		LogLevel:  log.Info,
+		Telemetry: telemetryMock,
+	}
+
+	stateA := NewService(config)
+
+	genData, genTrie, genesisHeader := newWestendDevGenesisWithTrieAndHeader(t)
+	err := stateA.Initialise(&genData, &genesisHeader, &genTrie)
+	require.NoError(t, err)
+
+	err = stateA.SetupBase()
+	require.NoError(t, err)
+
+	err = stateA.Start()
+	require.NoError(t, err)
+
+	// The longer chain is authored in secondary slots and arrived first,
+	// so the chain with more primary blocks is the best chain only if the
+	// primary slot flags are restored.
+	arrivalTime := time.Unix(1, 0)
+	secondaryChain := addForkChoiceTestChain(t, stateA.Block, &genesisHeader, 4, false, arrivalTime)
+	primaryChain := addForkChoiceTestChain(t, stateA.Block, &genesisHeader, 2, true, arrivalTime.Add(time.Second))
+	bestBlockHash := primaryChain[1].Hash()
+	require.Equal(t, bestBlockHash, stateA.Block.BestBlockHash())
+	leaves := []common.Hash{secondaryChain[3].Hash(), primaryChain[1].Hash()}
+
+	// Simulate a crash after the block tree is stored periodically,
+	// without the block tree being stored on shutdown.
+	err = stateA.Block.StoreBlockTree()
+	require.NoError(t, err)
+	close(stateA.closeCh)
+	<-stateA.blockTreeStorerDone
+	err = stateA.db.Close()
+	require.NoError(t, err)
+
+	db, err := utils.SetupDatabase(config.Path, false)
+	require.NoError(t, err)
+	best, err := BestBlockFromLeaves(chaindb.NewTable(db, blockPrefix), leaves)
+	require.NoError(t, err)
+	assert.Equal(t, bestBlockHash, best)
+	err = db.Close()
+	require.NoError(t, err)
+
+	stateB := NewService(config)
+
+	err = stateB.SetupBase()
+	require.NoError(t, err)
+
+	err = stateB.Start()
+	require.NoError(t, err)
+
+	assert.Equal(t, bestBlockHash, stateB.Block.BestBlockHash())
+	assert.ElementsMatch(t, leaves, stateB.Block.Leaves())
+	hash, err := stateB.Block.GetHashByNumber(primaryChain[1].Number)
+	require.NoError(t, err)
+	assert.Equal(t, bestBlockHash, hash)
+
+	err = stateB.Stop()
+	require.NoError(t, err)
+}
+
 func TestService_StorageTriePruning(t *testing.T) {
 	t.Skip() // Unskip once https://github.com/ChainSafe/gossamer/pull/2831 is done
 
@@ -0,0 +1,35 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewService_databaseOptions(t *testing.T) {
+	t.Parallel()
+
+	service := NewService(Config{Path: t.TempDir()})
+	assert.Equal(t, database.DefaultOptions(), service.dbOptions)
+
+	options := database.DefaultOptions()
+	options.BlockCacheSize = 1 << 20
+	options.ValueLogFileSize = 1 << 20
+	options.ValueLogGCInterval = time.Millisecond
+	service = NewService(Config{
+		Path:            t.TempDir(),
+		DatabaseOptions: options,
+	})
+	assert.Equal(t, options, service.dbOptions)
+
+	err := service.SetupBase()
+	require.NoError(t, err)
+	err = service.db.Close()
+	require.NoError(t, err)
+}
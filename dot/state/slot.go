@@ -8,6 +8,7 @@
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/ChainSafe/chaindb"
 	"github.com/ChainSafe/gossamer/dot/types"
@@ -29,9 +30,13 @@
 
 type SlotState struct {
 	db chaindb.Database
+
+	// equivocationsLock protects the equivocations stored
+	// and the list of their identifiers.
+	equivocationsLock sync.Mutex
 }
 
-func NewSlotState(db *chaindb.BadgerDB) *SlotState {
+func NewSlotState(db chaindb.Database) *SlotState {
 	slotStateDB := chaindb.NewTable(db, slotTablePrefix)
 
 	return &SlotState{
@@ -61,30 +66,9 @@ func (s *SlotState) CheckEquivocation(slotNow, slot uint64, header *types.Header
 		return nil, fmt.Errorf("getting key slot header map key %d: %w", slot, err)
 	}
 
-	headersWithSigners := make([]headerAndSigner, 0)
-	if len(encodedHeadersWithSigners) > 0 {
-		encodedSliceHeadersWithSigners := make([][]byte, 0)
-
-		err = scale.Unmarshal(encodedHeadersWithSigners, &encodedSliceHeadersWithSigners)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshaling encoded headers with signers: %w", err)
-		}
-
-		for _, encodedHeaderAndSigner := range encodedSliceHeadersWithSigners {
-			// each header and signer instance should have an empty header
-			// so we will be able to scale decode the whole byte stream with
-			// the digests correctly in place
-			decodedHeaderAndSigner := headerAndSigner{
-				Header: types.NewEmptyHeader(),
-			}
-
-			err := scale.Unmarshal(encodedHeaderAndSigner, &decodedHeaderAndSigner)
-			if err != nil {
-				return nil, fmt.Errorf("unmarshaling header with signer: %w", err)
-			}
-
-			headersWithSigners = append(headersWithSigners, decodedHeaderAndSigner)
-		}
+	headersWithSigners, err := decodeHeadersWithSigners(encodedHeadersWithSigners)
+	if err != nil {
+		return nil, err
 	}
 
 	firstSavedSlot := slot
@@ -182,9 +166,48 @@ func (s *SlotState) CheckEquivocation(slotNow, slot uint64, header *types.Header
 		return nil, fmt.Errorf("failed to flush batch operations: %w", err)
 	}
 
+	if newFirstSavedSlot > firstSavedSlot {
+		err = s.pruneEquivocations(newFirstSavedSlot)
+		if err != nil {
+			return nil, fmt.Errorf("pruning equivocations: %w", err)
+		}
+	}
+
 	return nil, nil //nolint:nilnil
 }
 
+// decodeHeadersWithSigners decodes the headers and their signers
+// stored for a slot, returning an empty slice if encoded is empty.
+func decodeHeadersWithSigners(encoded []byte) (headersWithSigners []headerAndSigner, err error) {
+	headersWithSigners = make([]headerAndSigner, 0)
+	if len(encoded) > 0 {
+		encodedSliceHeadersWithSigners := make([][]byte, 0)
+
+		err = scale.Unmarshal(encoded, &encodedSliceHeadersWithSigners)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling encoded headers with signers: %w", err)
+		}
+
+		for _, encodedHeaderAndSigner := range encodedSliceHeadersWithSigners {
+			// each header and signer instance should have an empty header
+			// so we will be able to scale decode the whole byte stream with
+			// the digests correctly in place
+			decodedHeaderAndSigner := headerAndSigner{
+				Header: types.NewEmptyHeader(),
+			}
+
+			err := scale.Unmarshal(encodedHeaderAndSigner, &decodedHeaderAndSigner)
+			if err != nil {
+				return nil, fmt.Errorf("unmarshaling header with signer: %w", err)
+			}
+
+			headersWithSigners = append(headersWithSigners, decodedHeaderAndSigner)
+		}
+	}
+
+	return headersWithSigners, nil
+}
+
 func saturatingSub(a, b uint64) uint64 {
 	if a > b {
 		return a - b
@@ -0,0 +1,264 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+var errStateRootIndexMalformed = errors.New("state root index is malformed")
+
+var (
+	// blockStateRootPrefix + block hash -> state root of the block
+	blockStateRootPrefix = []byte("bsr")
+	// stateRootBlocksPrefix + state root -> hashes of the first and last blocks with this state root
+	// stateRootBlocksPrefix + state root + block hash -> hashes of the previous and next blocks
+	// with this state root, where a zero hash means there is no such block.
+	stateRootBlocksPrefix = []byte("srb")
+)
+
+// GetStateRootFromBlock returns the state root stored in the database
+// for the given block hash. The mapping is written when the block is
+// imported, and is removed only when the block is pruned as a fork block.
+func GetStateRootFromBlock(db Getter, hash common.Hash) (stateRoot common.Hash, err error) {
+	encodedRoot, err := db.Get(prefixKey(hash, blockStateRootPrefix))
+	if err != nil {
+		return stateRoot, fmt.Errorf("getting state root for block hash %s from database: %w", hash, err)
+	}
+
+	return common.NewHash(encodedRoot), nil
+}
+
+// GetBlocksWithStateRoot returns the hashes of the blocks stored in the
+// database with the given state root, in import order. Several blocks can
+// share the same state root, for example consecutive blocks not modifying
+// the storage. It returns no hash if no block has this state root.
+func GetBlocksWithStateRoot(db Getter, stateRoot common.Hash) (hashes []common.Hash, err error) {
+	index := newStateRootIndexBatch(db)
+	return index.blockHashes(stateRoot)
+}
+
+func stateRootBlocksKey(stateRoot common.Hash) []byte {
+	return prefixKey(stateRoot, stateRootBlocksPrefix)
+}
+
+func stateRootBlockKey(stateRoot, hash common.Hash) []byte {
+	return append(stateRootBlocksKey(stateRoot), hash.ToBytes()...)
+}
+
+// stateRootIndexBatch accumulates changes to the block hash to state root
+// mapping and to its reverse mapping, such that they can be written to
+// the database in a single batch. Reads return the pending changes if any.
+// The mapping of a block never changes on reorgs since it only depends on
+// the block header, so blocks are only added on import and removed on pruning.
+// The blocks with the same state root are stored as a doubly linked list
+// with an entry per block, such that adding and removing a block only
+// writes a constant number of entries.
+type stateRootIndexBatch struct {
+	db Getter
+	// pending maps database keys to their pending value,
+	// where a nil value means the key is deleted.
+	pending map[string][]byte
+}
+
+func newStateRootIndexBatch(db Getter) *stateRootIndexBatch {
+	return &stateRootIndexBatch{
+		db:      db,
+		pending: make(map[string][]byte),
+	}
+}
+
+// get returns the value at the key given, and false if there is no value.
+func (b *stateRootIndexBatch) get(key []byte) (value []byte, ok bool, err error) {
+	value, pending := b.pending[string(key)]
+	if pending {
+		return value, value != nil, nil
+	}
+
+	value, err = b.db.Get(key)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// getHashes returns the two hashes stored at the key given,
+// and false if there is no value.
+func (b *stateRootIndexBatch) getHashes(key []byte) (
+	first, second common.Hash, ok bool, err error) {
+	value, ok, err := b.get(key)
+	if err != nil || !ok {
+		return first, second, ok, err
+	} else if len(value) != 2*common.HashLength {
+		return first, second, false, fmt.Errorf("%w: %d bytes", errStateRootIndexMalformed, len(value))
+	}
+
+	return common.NewHash(value[:common.HashLength]), common.NewHash(value[common.HashLength:]), true, nil
+}
+
+func (b *stateRootIndexBatch) setHashes(key []byte, first, second common.Hash) {
+	b.pending[string(key)] = append(first.ToBytes(), second.ToBytes()...)
+}
+
+// stateRoot returns the state root for the given block hash,
+// and false if there is no state root for the block hash.
+func (b *stateRootIndexBatch) stateRoot(hash common.Hash) (
+	stateRoot common.Hash, ok bool, err error) {
+	encodedRoot, ok, err := b.get(prefixKey(hash, blockStateRootPrefix))
+	if err != nil {
+		return stateRoot, false, fmt.Errorf("getting state root for block hash %s: %w", hash, err)
+	} else if !ok {
+		return stateRoot, false, nil
+	}
+
+	return common.NewHash(encodedRoot), true, nil
+}
+
+func (b *stateRootIndexBatch) blockHashes(stateRoot common.Hash) (
+	hashes []common.Hash, err error) {
+	hash, _, ok, err := b.getHashes(stateRootBlocksKey(stateRoot))
+	if err != nil {
+		return nil, fmt.Errorf("getting block hashes for state root %s: %w", stateRoot, err)
+	} else if !ok {
+		return nil, nil
+	}
+
+	for hash != (common.Hash{}) {
+		hashes = append(hashes, hash)
+
+		_, next, ok, err := b.getHashes(stateRootBlockKey(stateRoot, hash))
+		if err != nil {
+			return nil, fmt.Errorf("getting block hash after %s for state root %s: %w", hash, stateRoot, err)
+		} else if !ok {
+			return nil, fmt.Errorf("%w: block hash %s not found for state root %s",
+				errStateRootIndexMalformed, hash, stateRoot)
+		}
+		hash = next
+	}
+
+	return hashes, nil
+}
+
+// add adds the mapping between the block hash and the state root of the header given.
+func (b *stateRootIndexBatch) add(header *types.Header) (err error) {
+	hash := header.Hash()
+	stateRoot := header.StateRoot
+
+	_, ok, err := b.stateRoot(hash)
+	if err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	b.pending[string(prefixKey(hash, blockStateRootPrefix))] = stateRoot.ToBytes()
+
+	first, last, ok, err := b.getHashes(stateRootBlocksKey(stateRoot))
+	if err != nil {
+		return fmt.Errorf("getting block hashes for state root %s: %w", stateRoot, err)
+	} else if !ok {
+		first = hash
+	} else {
+		lastPrevious, _, _, err := b.getHashes(stateRootBlockKey(stateRoot, last))
+		if err != nil {
+			return fmt.Errorf("getting block hash before %s for state root %s: %w", last, stateRoot, err)
+		}
+		b.setHashes(stateRootBlockKey(stateRoot, last), lastPrevious, hash)
+	}
+
+	b.setHashes(stateRootBlockKey(stateRoot, hash), last, common.Hash{})
+	b.setHashes(stateRootBlocksKey(stateRoot), first, hash)
+	return nil
+}
+
+// remove removes the mapping between the block hash given and its state root, if any.
+func (b *stateRootIndexBatch) remove(hash common.Hash) (err error) {
+	stateRoot, ok, err := b.stateRoot(hash)
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+	b.pending[string(prefixKey(hash, blockStateRootPrefix))] = nil
+
+	blockKey := stateRootBlockKey(stateRoot, hash)
+	previous, next, ok, err := b.getHashes(blockKey)
+	if err != nil {
+		return fmt.Errorf("getting block hashes around %s for state root %s: %w", hash, stateRoot, err)
+	} else if !ok {
+		return nil
+	}
+	b.pending[string(blockKey)] = nil
+
+	first, last, _, err := b.getHashes(stateRootBlocksKey(stateRoot))
+	if err != nil {
+		return fmt.Errorf("getting block hashes for state root %s: %w", stateRoot, err)
+	}
+
+	if previous == (common.Hash{}) {
+		first = next
+	} else {
+		err = b.setNeighbour(stateRoot, previous, false, next)
+		if err != nil {
+			return err
+		}
+	}
+
+	if next == (common.Hash{}) {
+		last = previous
+	} else {
+		err = b.setNeighbour(stateRoot, next, true, previous)
+		if err != nil {
+			return err
+		}
+	}
+
+	if first == (common.Hash{}) {
+		b.pending[string(stateRootBlocksKey(stateRoot))] = nil
+		return nil
+	}
+	b.setHashes(stateRootBlocksKey(stateRoot), first, last)
+	return nil
+}
+
+// setNeighbour sets the previous block hash, or the next block hash if previous
+// is false, of the block with the hash and state root given to the neighbour given.
+func (b *stateRootIndexBatch) setNeighbour(stateRoot, hash common.Hash,
+	previous bool, neighbour common.Hash) (err error) {
+	key := stateRootBlockKey(stateRoot, hash)
+	previousHash, nextHash, _, err := b.getHashes(key)
+	if err != nil {
+		return fmt.Errorf("getting block hashes around %s for state root %s: %w", hash, stateRoot, err)
+	}
+
+	if previous {
+		previousHash = neighbour
+	} else {
+		nextHash = neighbour
+	}
+	b.setHashes(key, previousHash, nextHash)
+	return nil
+}
+
+// put writes the pending changes to the database batch given.
+func (b *stateRootIndexBatch) put(batch PutDeleter) (err error) {
+	for key, value := range b.pending {
+		if value == nil {
+			err = batch.Del([]byte(key))
+		} else {
+			err = batch.Put([]byte(key), value)
+		}
+		if err != nil {
+			return fmt.Errorf("writing state root index: %w", err)
+		}
+	}
+
+	return nil
+}
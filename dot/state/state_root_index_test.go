@@ -0,0 +1,167 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
+)
+
+// newTestBlocksWithStateRoots returns a chain of blocks descending from the
+// parent header given, with a block for each of the state roots given.
+func newTestBlocksWithStateRoots(t *testing.T, parent *types.Header, extrinsicsRoot common.Hash,
+	stateRoots ...common.Hash) (blocks []*types.Block) {
+	t.Helper()
+
+	blocks = make([]*types.Block, len(stateRoots))
+	for i, stateRoot := range stateRoots {
+		blocks[i] = &types.Block{
+			Header: types.Header{
+				ParentHash:     parent.Hash(),
+				Number:         parent.Number + 1,
+				StateRoot:      stateRoot,
+				ExtrinsicsRoot: extrinsicsRoot,
+				Digest:         createPrimaryBABEDigest(t),
+			},
+			Body: types.Body{},
+		}
+		parent = &blocks[i].Header
+	}
+	return blocks
+}
+
+func Test_StateRootIndex(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.BestBlockHeader()
+	require.NoError(t, err)
+
+	genesisStateRoot, err := GetStateRootFromBlock(bs.db, genesisHeader.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, genesisHeader.StateRoot, genesisStateRoot)
+
+	// Blocks 2 and 3 make no storage changes and share the state root of block 1.
+	rootA, rootB, rootC := common.Hash{0xa}, common.Hash{0xb}, common.Hash{0xc}
+	chain := newTestBlocksWithStateRoots(t, genesisHeader, common.Hash{1}, rootA, rootA, rootA, rootB)
+	err = bs.ImportBlocks(chain)
+	require.NoError(t, err)
+
+	// A fork block is added to the block tree only, as when syncing.
+	treeFork := newTestBlocksWithStateRoots(t, &chain[0].Header, common.Hash{3}, rootA)
+	err = bs.AddBlockToBlockTree(treeFork[0])
+	require.NoError(t, err)
+
+	// The fork blocks are added one by one, the first one sharing
+	// the state root of its parent and the second one not.
+	fork := newTestBlocksWithStateRoots(t, &chain[0].Header, common.Hash{2}, rootA, rootC)
+	for _, block := range fork {
+		err = bs.AddBlockWithArrivalTime(block, time.Unix(1, 0))
+		require.NoError(t, err)
+	}
+
+	storageState := &StorageState{blockState: bs}
+	for _, block := range append(append(append([]*types.Block{}, chain...), treeFork...), fork...) {
+		hash := block.Header.Hash()
+		stateRoot, err := GetStateRootFromBlock(bs.db, hash)
+		require.NoError(t, err)
+		assert.Equal(t, block.Header.StateRoot, stateRoot)
+
+		storageStateRoot, err := storageState.GetStateRootFromBlock(&hash)
+		require.NoError(t, err)
+		assert.Equal(t, block.Header.StateRoot, *storageStateRoot)
+	}
+
+	hashes, err := GetBlocksWithStateRoot(bs.db, rootA)
+	require.NoError(t, err)
+	expectedHashes := []common.Hash{
+		chain[0].Header.Hash(), chain[1].Header.Hash(), chain[2].Header.Hash(),
+		treeFork[0].Header.Hash(), fork[0].Header.Hash(),
+	}
+	assert.Equal(t, expectedHashes, hashes)
+
+	hashes, err = GetBlocksWithStateRoot(bs.db, rootC)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{fork[1].Header.Hash()}, hashes)
+
+	hashes, err = GetBlocksWithStateRoot(bs.db, common.Hash{0xff})
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	// The fork blocks are pruned on finalisation.
+	err = bs.SetFinalisedHash(chain[3].Header.Hash(), 1, 0)
+	require.NoError(t, err)
+
+	hashes, err = GetBlocksWithStateRoot(bs.db, rootA)
+	require.NoError(t, err)
+	assert.Equal(t, expectedHashes[:3], hashes)
+
+	hashes, err = GetBlocksWithStateRoot(bs.db, rootB)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{chain[3].Header.Hash()}, hashes)
+
+	hashes, err = GetBlocksWithStateRoot(bs.db, rootC)
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+	has, err := bs.db.Has(prefixKey(rootC, stateRootBlocksPrefix))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	for _, block := range append(treeFork, fork...) {
+		_, err = GetStateRootFromBlock(bs.db, block.Header.Hash())
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+		has, err = bs.db.Has(stateRootBlockKey(block.Header.StateRoot, block.Header.Hash()))
+		require.NoError(t, err)
+		assert.False(t, has)
+	}
+}
+
+func Test_stateRootIndexBatch_remove(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	stateRoot := common.Hash{0xa}
+	headers := make([]*types.Header, 3)
+	hashes := make([]common.Hash, len(headers))
+	index := newStateRootIndexBatch(db)
+	for i := range headers {
+		headers[i] = &types.Header{Number: uint(i + 1), StateRoot: stateRoot}
+		hashes[i] = headers[i].Hash()
+		err := index.add(headers[i])
+		require.NoError(t, err)
+	}
+	err := index.put(db)
+	require.NoError(t, err)
+
+	// each removal is read back from the database
+	for _, hashToRemove := range []common.Hash{hashes[1], hashes[2], hashes[0]} {
+		index = newStateRootIndexBatch(db)
+		err = index.remove(hashToRemove)
+		require.NoError(t, err)
+		err = index.put(db)
+		require.NoError(t, err)
+
+		i := slices.Index(hashes, hashToRemove)
+		hashes = slices.Delete(hashes, i, i+1)
+
+		blockHashes, err := GetBlocksWithStateRoot(db, stateRoot)
+		require.NoError(t, err)
+		if len(hashes) == 0 {
+			assert.Empty(t, blockHashes)
+		} else {
+			assert.Equal(t, hashes, blockHashes)
+		}
+	}
+
+	has, err := db.Has(stateRootBlocksKey(stateRoot))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
@@ -20,10 +20,26 @@
 // storagePrefix storage key prefix.
 var storagePrefix = "storage"
 var codeKey = common.CodeKey
+var heapPagesKey = common.HeapPagesKey
 
 // ErrTrieDoesNotExist is returned when attempting to interact with a trie that is not stored in the StorageState
 var ErrTrieDoesNotExist = errors.New("trie with given root does not exist")
 
+// ErrStatePruned is returned when querying the state at a state root
+// whose trie nodes are no longer stored in the database.
+var ErrStatePruned = errors.New("state is pruned")
+
+// ErrBlockUnknown is returned when querying the state
+// of a block whose hash is not known.
+var ErrBlockUnknown = errors.New("block is unknown")
+
+// ErrStorageKeyNotFound is returned when querying a
+// storage key absent from the state queried.
+var ErrStorageKeyNotFound = errors.New("storage key not found")
+
+// ErrRuntimeCodeNotFound is returned when the runtime code is not set in a state trie
+var ErrRuntimeCodeNotFound = errors.New("runtime code not found")
+
 func errTrieDoesNotExist(hash common.Hash) error {
 	return fmt.Errorf("%w: %s", ErrTrieDoesNotExist, hash)
 }
@@ -32,37 +48,82 @@ func errTrieDoesNotExist(hash common.Hash) error {
 type StorageState struct {
 	blockState *BlockState
 	tries      *Tries
+	// historicalTries caches lazy tries for the most recently
+	// queried state roots whose trie is not in memory.
+	historicalTries *historicalTries
+	// changeSets caches the storage changes of the blocks
+	// most recently imported or queried.
+	changeSets *changeSetCache
 
 	db GetNewBatcher
 	sync.RWMutex
 
 	// change notifiers
-	observerListMutex sync.RWMutex
-	observerList      []Observer
-	pruner            pruner.Pruner
+	observersMutex sync.Mutex
+	// observers maps each storage observer registered
+	// to the function unsubscribing it from the changes.
+	observers       map[Observer]func()
+	changesNotifier *storageChangesNotifier
+	pruner          pruner.Pruner
+	runtimeUpdates  *runtimeUpdateHooks
+
+	metrics *storageMetrics
 }
 
 // NewStorageState creates a new StorageState backed by the given block state
-// and database located at basePath.
-func NewStorageState(db *chaindb.BadgerDB, blockState *BlockState,
-	tries *Tries) (*StorageState, error) {
-	storageTable := chaindb.NewTable(db, storagePrefix)
-
-	return &StorageState{
-		blockState:   blockState,
-		tries:        tries,
-		db:           storageTable,
-		observerList: []Observer{},
-		pruner:       &pruner.ArchiveNode{},
-	}, nil
+// and database located at basePath. Decoded trie nodes read from the database
+// are cached up to approximately trieNodeCacheSize bytes, and are not cached
+// if trieNodeCacheSize is 0. In the pruned mode of the pruner config given,
+// the state of the finalised blocks falling out of the retention window and
+// of the blocks pruned from forks is pruned on each finalisation.
+func NewStorageState(db chaindb.Database, blockState *BlockState,
+	tries *Tries, trieNodeCacheSize uint64, prunerConfig pruner.Config) (*StorageState, error) {
+	var storageTable GetNewBatcher = chaindb.NewTable(db, storagePrefix)
+	if trieNodeCacheSize > 0 {
+		storageTable = newTrieNodeCacheDatabase(storageTable, newTrieNodeCache(trieNodeCacheSize))
+	}
+
+	s := &StorageState{
+		blockState:      blockState,
+		tries:           tries,
+		historicalTries: newHistoricalTries(maxHistoricalTries),
+		changeSets:      newChangeSetCache(maxCachedChangeSets),
+		db:              storageTable,
+		observers:       make(map[Observer]func()),
+		pruner:          &pruner.ArchiveNode{},
+		runtimeUpdates:  newRuntimeUpdateHooks(),
+		metrics:         newStorageMetrics(0),
+	}
+
+	s.changesNotifier = newStorageChangesNotifier(defaultBufferSize, s.blockStorageChanges)
+	blockState.onImport(s.changesNotifier.queue)
+
+	if prunerConfig.Mode == pruner.Pruned {
+		fullNode, err := pruner.NewFullNode(storageTable, s, prunerConfig.RetainedBlocks)
+		if err != nil {
+			return nil, fmt.Errorf("creating full node pruner: %w", err)
+		}
+		s.pruner = fullNode
+		blockState.onFinalisation(s.pruneFinalisation)
+	}
+
+	return s, nil
 }
 
-// StoreTrie stores the given trie in the StorageState and writes it to the database
+// StoreTrie stores the given trie in the StorageState and writes it to the database,
+// unless the block state only stores headers.
 func (s *StorageState) StoreTrie(ts *rtstorage.TrieState, header *types.Header) error {
 	root := ts.MustRoot()
 
-	s.tries.softSet(root, ts.Trie())
+	// The state tries are not written to the database in headers only mode,
+	// and are only kept in memory for the blocks following them to be
+	// executed, until their block is finalised or pruned.
+	if s.blockState.HeadersOnly() {
+		s.tries.softSet(root, ts.Trie())
+		return nil
+	}
 
+	var trieNodesPersisted uint
 	if header != nil {
 		insertedNodeHashes, deletedNodeHashes, err := ts.GetChangedNodeHashes()
 		if err != nil {
@@ -73,20 +134,57 @@ func (s *StorageState) StoreTrie(ts *rtstorage.TrieState, header *types.Header)
 		if err != nil {
 			return fmt.Errorf("storing journal record: %w", err)
 		}
-	}
 
-	logger.Tracef("cached trie in storage state: %s", root)
+		// the deleted node hashes are only recorded for the full node pruner,
+		// which removes them once it prunes the block.
+		if _, pruned := s.pruner.(*pruner.FullNode); pruned {
+			err = s.storeDeletedNodeHashes(header.Hash(), deletedNodeHashes)
+			if err != nil {
+				return fmt.Errorf("storing deleted node hashes for block hash %s: %w", header.Hash(), err)
+			}
+		}
+
+		trieNodesPersisted, err = s.countNewNodeHashes(insertedNodeHashes)
+		if err != nil {
+			return fmt.Errorf("counting new trie nodes for block hash %s: %w", header.Hash(), err)
+		}
+	}
 
 	if err := ts.Trie().WriteDirty(s.db); err != nil {
 		logger.Warnf("failed to write trie with root %s to database: %s", root, err)
 		return err
 	}
 
-	go s.notifyAll(root)
+	// the trie is only cached once it is written to the database,
+	// and is kept in memory until its block is finalised or pruned.
+	s.tries.softSet(root, ts.Trie())
+	logger.Tracef("cached trie in storage state: %s", root)
+
+	if header != nil {
+		s.metrics.record(header, ts.ChangeStats(), trieNodesPersisted)
+	}
+
 	return nil
 }
 
-// TrieState returns the TrieState for a given state root.
+// countNewNodeHashes returns the number of node hashes given which
+// are not in the database, which is the number of nodes persisted
+// when writing these nodes to the database.
+func (s *StorageState) countNewNodeHashes(nodeHashes map[common.Hash]struct{}) (count uint, err error) {
+	for nodeHash := range nodeHashes {
+		_, err = s.db.Get(nodeHash.ToBytes())
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			count++
+		} else if err != nil {
+			return 0, fmt.Errorf("getting node hash %s: %w", nodeHash, err)
+		}
+	}
+	return count, nil
+}
+
+// TrieState returns the TrieState for a given state root, which is a snapshot
+// of the trie at the root such that modifying it leaves the trie at the root
+// unchanged. The trie is loaded from the database if it is not in memory.
 // If no state root is provided, it returns the TrieState for the current chain head.
 func (s *StorageState) TrieState(root *common.Hash) (*rtstorage.TrieState, error) {
 	if root == nil {
@@ -104,8 +202,6 @@ func (s *StorageState) TrieState(root *common.Hash) (*rtstorage.TrieState, error
 		if err != nil {
 			return nil, err
 		}
-
-		s.tries.softSet(*root, t)
 	} else if t.MustHash() != *root {
 		panic("trie does not have expected root")
 	}
@@ -117,15 +213,73 @@ func (s *StorageState) TrieState(root *common.Hash) (*rtstorage.TrieState, error
 	return next, nil
 }
 
-// LoadFromDB loads an encoded trie from the DB where the key is `root`
+// QueryTrieState returns the TrieState for a given state root, or for the current
+// chain head if no state root is provided, to be used by the runtime calls made
+// for queries, such as the RPC ones, and discarded once the call is made.
+// As for TrieState, it is a snapshot of the trie at the root, but the trie is not
+// cached in memory with the tries used to import blocks if it is loaded from the
+// database.
+func (s *StorageState) QueryTrieState(root *common.Hash) (*rtstorage.TrieState, error) {
+	t, err := s.readTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return rtstorage.NewTrieState(t.Snapshot()), nil
+}
+
+// LoadFromDB loads an encoded trie from the DB where the key is `root`.
+// The trie loaded is cached in memory with the most recently loaded tries.
+// An error wrapping ErrStatePruned is returned if the trie nodes are no
+// longer stored in the database.
 func (s *StorageState) LoadFromDB(root common.Hash) (*trie.Trie, error) {
+	t, err := s.loadFromDB(root)
+	if err != nil {
+		return nil, err
+	}
+
+	s.tries.setLoaded(t.MustHash(), t)
+	return t, nil
+}
+
+// loadFromDB loads the trie with the given root from the database,
+// without caching it in memory.
+func (s *StorageState) loadFromDB(root common.Hash) (*trie.Trie, error) {
 	t := trie.NewEmptyTrie()
 	err := t.Load(s.db, root)
-	if err != nil {
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: at state root %s: %w", ErrStatePruned, root, err)
+	} else if err != nil {
 		return nil, err
 	}
 
-	s.tries.softSet(t.MustHash(), t)
+	return t, nil
+}
+
+// readTrie returns the trie with the given root, or with the best block state
+// root if root is nil, from memory or else from the database. Contrary to
+// loadTrie, the trie loaded from the database is not cached in memory, such
+// that the tries used to import blocks are left unchanged. The trie returned
+// must not be modified.
+func (s *StorageState) readTrie(root *common.Hash) (*trie.Trie, error) {
+	if root == nil {
+		sr, err := s.blockState.BestBlockStateRoot()
+		if err != nil {
+			return nil, err
+		}
+		root = &sr
+	}
+
+	t := s.tries.get(*root)
+	if t != nil {
+		return t, nil
+	}
+
+	t, err := s.loadFromDB(*root)
+	if err != nil {
+		return nil, fmt.Errorf("trie does not exist at root %s: %w", *root, err)
+	}
+
 	return t, nil
 }
 
@@ -159,7 +313,10 @@ func (s *StorageState) ExistsStorage(root *common.Hash, key []byte) (bool, error
 }
 
 // GetStorage gets the object from the trie using the given key and storage hash
-// If no hash is provided, the current chain head is used
+// If no hash is provided, the current chain head is used.
+// If the trie is not in memory, only the nodes on the path to the key are loaded
+// from the database, and an error wrapping ErrStatePruned is returned if they
+// are no longer stored.
 func (s *StorageState) GetStorage(root *common.Hash, key []byte) ([]byte, error) {
 	if root == nil {
 		sr, err := s.blockState.BestBlockStateRoot()
@@ -175,40 +332,138 @@ func (s *StorageState) GetStorage(root *common.Hash, key []byte) ([]byte, error)
 		return val, nil
 	}
 
-	return trie.GetFromDB(s.db, *root, key)
+	return s.getHistoricalStorage(*root, key)
 }
 
-// GetStorageByBlockHash returns the value at the given key at the given block hash
-func (s *StorageState) GetStorageByBlockHash(bhash *common.Hash, key []byte) ([]byte, error) {
-	var (
-		root common.Hash
-		err  error
-	)
-
-	if bhash != nil {
-		header, err := s.blockState.GetHeader(*bhash)
+// getHistoricalStorage returns the value at the given key in the trie
+// with the given root, lazily loading the nodes on the path to the key
+// from the database. The lazy trie is cached with the tries of the most
+// recently queried historical state roots.
+func (s *StorageState) getHistoricalStorage(root common.Hash, key []byte) (value []byte, err error) {
+	lazyTrie := s.historicalTries.get(root)
+	if lazyTrie == nil {
+		lazyTrie, err = trie.NewLazyTrie(s.db, root)
 		if err != nil {
-			return nil, err
+			return nil, historicalStateError(root, err)
 		}
+		s.historicalTries.set(root, lazyTrie)
+	}
+
+	value, err = lazyTrie.Get(key)
+	if err != nil {
+		s.historicalTries.delete(root)
+		return nil, historicalStateError(root, err)
+	}
+
+	return value, nil
+}
+
+func historicalStateError(root common.Hash, err error) error {
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return fmt.Errorf("%w: at state root %s: %s", ErrStatePruned, root, err)
+	}
+	return fmt.Errorf("getting storage at state root %s: %w", root, err)
+}
+
+// GetStorageByBlockHash returns the value at the given key in the state of the
+// block with the given hash, or of the best block if the hash is nil.
+// If the state trie is not in memory, only the nodes on the path to the key
+// are loaded from the database.
+// It returns an error wrapping ErrBlockUnknown if the block is not known,
+// ErrStatePruned if the state of the block is pruned, or ErrStorageKeyNotFound
+// if the key is absent from the state.
+func (s *StorageState) GetStorageByBlockHash(blockHash *common.Hash, key []byte) (value []byte, err error) {
+	root, err := s.stateRootByBlockHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = s.GetStorage(&root, key)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return nil, fmt.Errorf("%w: 0x%x at state root %s", ErrStorageKeyNotFound, key, root)
+	}
+
+	return value, nil
+}
 
-		root = header.StateRoot
+// GetStorageFromChildByBlockHash returns the value at the given key in the child
+// trie located at the given key to child, in the state of the block with the
+// given hash, or of the best block if the hash is nil.
+// If the state trie is not in memory, only the nodes on the paths to the
+// child trie root hash and to the key in the child trie are loaded from the
+// database. It returns the same errors as GetStorageByBlockHash, with an error
+// wrapping ErrStorageKeyNotFound if the child trie does not exist.
+func (s *StorageState) GetStorageFromChildByBlockHash(blockHash *common.Hash,
+	keyToChild, key []byte) (value []byte, err error) {
+	root, err := s.stateRootByBlockHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	childStorageKey := trie.ChildStorageKey(keyToChild)
+	encodedChildRoot, err := s.GetStorage(&root, childStorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("getting child trie root hash: %w", err)
+	} else if encodedChildRoot == nil {
+		return nil, fmt.Errorf("%w: child trie at key 0x%x at state root %s",
+			ErrStorageKeyNotFound, keyToChild, root)
+	}
+
+	t := s.tries.get(root)
+	if t != nil {
+		value, err = t.GetFromChild(keyToChild, key)
 	} else {
-		root, err = s.StorageRoot()
-		if err != nil {
-			return nil, err
-		}
+		value, err = s.getHistoricalStorage(common.BytesToHash(encodedChildRoot), key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting child trie storage: %w", err)
+	} else if value == nil {
+		return nil, fmt.Errorf("%w: 0x%x in child trie at key 0x%x at state root %s",
+			ErrStorageKeyNotFound, key, keyToChild, root)
+	}
+
+	return value, nil
+}
+
+// stateRootByBlockHash returns the state root of the block with the given
+// hash, or of the best block if the hash is nil. It returns an error
+// wrapping ErrBlockUnknown if the block is not known.
+func (s *StorageState) stateRootByBlockHash(blockHash *common.Hash) (root common.Hash, err error) {
+	if blockHash == nil {
+		bestBlockHash := s.blockState.BestBlockHash()
+		blockHash = &bestBlockHash
+	}
+
+	header, err := s.blockState.GetHeader(*blockHash)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return root, fmt.Errorf("%w: %s", ErrBlockUnknown, *blockHash)
+	} else if err != nil {
+		return root, fmt.Errorf("getting header: %w", err)
 	}
 
-	return s.GetStorage(&root, key)
+	return header.StateRoot, nil
 }
 
-// GetStateRootFromBlock returns the state root hash of a given block hash
+// GetStateRootFromBlock returns the state root hash of a given block hash,
+// read from the state root index of the blocks, or of the best block if the
+// block hash is nil.
 func (s *StorageState) GetStateRootFromBlock(bhash *common.Hash) (*common.Hash, error) {
 	if bhash == nil {
 		b := s.blockState.BestBlockHash()
 		bhash = &b
 	}
 
+	stateRoot, err := GetStateRootFromBlock(s.blockState.db, *bhash)
+	if err == nil {
+		return &stateRoot, nil
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	// blocks stored before the state root index was
+	// introduced are only found through their header.
 	header, err := s.blockState.GetHeader(*bhash)
 	if err != nil {
 		return nil, err
@@ -243,6 +498,20 @@ func (s *StorageState) GetKeysWithPrefix(root *common.Hash, prefix []byte) ([][]
 	return tr.GetKeysWithPrefix(prefix), nil
 }
 
+// GetKeysPaged returns up to limit keys matching the given prefix and
+// being strictly after the startAfter key, in lexicographic order, for the
+// given state root (or best block state root if root is nil).
+// The startAfter key can be left nil to start from the first key.
+func (s *StorageState) GetKeysPaged(root *common.Hash, prefix, startAfter []byte,
+	limit uint) (keys [][]byte, err error) {
+	tr, err := s.loadTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.GetKeysPaged(prefix, startAfter, limit), nil
+}
+
 // GetStorageChild returns a child trie, if it exists
 func (s *StorageState) GetStorageChild(root *common.Hash, keyToChild []byte) (*trie.Trie, error) {
 	tr, err := s.loadTrie(root)
@@ -268,6 +537,57 @@ func (s *StorageState) LoadCode(hash *common.Hash) ([]byte, error) {
 	return s.GetStorage(hash, codeKey)
 }
 
+// RuntimeCode is the runtime code stored in a state trie.
+type RuntimeCode struct {
+	// Code is the runtime Wasm code stored at the :code key.
+	Code []byte
+	// CodeHash is the blake2b hash of the runtime code.
+	CodeHash common.Hash
+	// HeapPages is the number of heap pages stored at the :heappages key,
+	// or DefaultHeapPages if the key is not set.
+	HeapPages uint64
+}
+
+// GetRuntimeCode returns the runtime code stored at the :code key of the state
+// trie with the given state root, or of the best block state root if root is nil,
+// such that the runtime set by the last runtime upgrade is returned. The number of
+// heap pages stored at the :heappages key is returned with it, defaulting to
+// DefaultHeapPages if the key is not set.
+func (s *StorageState) GetRuntimeCode(root *common.Hash) (runtimeCode RuntimeCode, err error) {
+	code, err := s.GetStorage(root, codeKey)
+	if err != nil {
+		return runtimeCode, fmt.Errorf("getting runtime code: %w", err)
+	} else if len(code) == 0 {
+		return runtimeCode, ErrRuntimeCodeNotFound
+	}
+
+	codeHash, err := common.Blake2bHash(code)
+	if err != nil {
+		return runtimeCode, fmt.Errorf("hashing runtime code: %w", err)
+	}
+
+	encodedHeapPages, err := s.GetStorage(root, heapPagesKey)
+	if err != nil {
+		return runtimeCode, fmt.Errorf("getting heap pages: %w", err)
+	}
+
+	heapPages, err := decodeHeapPages(encodedHeapPages)
+	if err != nil {
+		return runtimeCode, err
+	}
+
+	runtimeCode = RuntimeCode{
+		Code:      code,
+		CodeHash:  codeHash,
+		HeapPages: DefaultHeapPages,
+	}
+	if heapPages != nil {
+		runtimeCode.HeapPages = *heapPages
+	}
+
+	return runtimeCode, nil
+}
+
 // LoadCodeHash returns the hash of the runtime code (located at :code)
 func (s *StorageState) LoadCodeHash(hash *common.Hash) (common.Hash, error) {
 	code, err := s.LoadCode(hash)
@@ -0,0 +1,308 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// StorageChangeFilter selects the storage keys a storage
+// changes subscription receives the changes of.
+// The zero value selects all the storage keys.
+type StorageChangeFilter struct {
+	// Keys are the exact storage keys selected.
+	Keys [][]byte
+	// Prefixes are the storage key prefixes selected.
+	Prefixes [][]byte
+}
+
+func (f StorageChangeFilter) all() bool {
+	return len(f.Keys) == 0 && len(f.Prefixes) == 0
+}
+
+// StorageChanges contains the storage changes of a block
+// selected by the filter of a subscription.
+type StorageChanges struct {
+	BlockHash common.Hash
+	// Changes are the key value pairs changed by the block, sorted
+	// by key. The value is nil if the key was deleted.
+	Changes []KeyValue
+	// Dropped is true if the changes of one or more blocks before this
+	// block were dropped, since they were not received fast enough.
+	Dropped bool
+}
+
+// SubscribeStorageChanges returns a channel receiving the changes of the storage
+// keys selected by the filter given for each block, once the block is imported,
+// and a function to unsubscribe which closes the channel. Blocks without any
+// selected change are not sent. The changes are computed in the background, such
+// that block import is never slowed down by subscriptions: if changes are not
+// received fast enough, the changes of new blocks are dropped until there is
+// room again, and the next changes received have their Dropped field set to true.
+// The changes received are shared between subscriptions and must not be modified.
+func (s *StorageState) SubscribeStorageChanges(filter StorageChangeFilter) (
+	changes <-chan StorageChanges, unsubscribe func()) {
+	return s.changesNotifier.subscribe(filter)
+}
+
+// blockStorageChanges returns the changes between the state trie of the
+// parent block of the block with the header given and its state trie.
+func (s *StorageState) blockStorageChanges(header *types.Header) (changes []KeyValue, err error) {
+	parentHeader, err := s.blockState.GetHeader(header.ParentHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting parent header: %w", err)
+	}
+
+	return s.blockChangeSet(header.Hash(), parentHeader.StateRoot, header.StateRoot)
+}
+
+// storageChanges returns the key value pairs changed between the
+// tries with the old and new roots given, sorted by key.
+func storageChanges(db trie.Getter, oldRoot, newRoot common.Hash) (
+	changes []KeyValue, err error) {
+	added, changed, removed, err := trie.TrieDiff(db, oldRoot, newRoot)
+	if err != nil {
+		return nil, fmt.Errorf("computing trie diff: %w", err)
+	}
+
+	changes = make([]KeyValue, 0, len(added)+len(changed)+len(removed))
+	for _, keyValues := range [][]trie.KeyValue{added, changed} {
+		for _, keyValue := range keyValues {
+			changes = append(changes, KeyValue{Key: keyValue.Key, Value: keyValue.Value})
+		}
+	}
+	for _, keyValue := range removed {
+		changes = append(changes, KeyValue{Key: keyValue.Key})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Key, changes[j].Key) < 0
+	})
+	return changes, nil
+}
+
+// storageChangesNotifier sends the storage changes of each block
+// to the subscriptions selecting them.
+type storageChangesNotifier struct {
+	// mutex protects the subscriptions, and is held while sending changes
+	// such that a subscription channel is never closed while sent to.
+	mutex      sync.Mutex
+	bufferSize int
+	// keyToSubscriptions indexes the subscriptions by their exact keys.
+	keyToSubscriptions map[string][]*storageChangesSubscription
+	// scanned are the subscriptions selecting all keys or key prefixes,
+	// which are checked against each change.
+	scanned map[*storageChangesSubscription]struct{}
+	count   int
+
+	// headers queues the headers of the blocks imported, whose changes are
+	// computed and sent by a goroutine running while there are subscriptions.
+	// It is nil when there is no subscription.
+	headers chan *types.Header
+	// changesOf returns the storage changes of the block with the given header.
+	changesOf func(header *types.Header) (changes []KeyValue, err error)
+}
+
+func newStorageChangesNotifier(bufferSize int,
+	changesOf func(header *types.Header) (changes []KeyValue, err error)) *storageChangesNotifier {
+	return &storageChangesNotifier{
+		bufferSize:         bufferSize,
+		keyToSubscriptions: make(map[string][]*storageChangesSubscription),
+		scanned:            make(map[*storageChangesSubscription]struct{}),
+		changesOf:          changesOf,
+	}
+}
+
+type storageChangesSubscription struct {
+	filter  StorageChangeFilter
+	changes chan StorageChanges
+	// dropped is true if changes were dropped since the last changes sent.
+	dropped bool
+}
+
+func (n *storageChangesNotifier) subscribe(filter StorageChangeFilter) (
+	changes <-chan StorageChanges, unsubscribe func()) {
+	subscription := &storageChangesSubscription{
+		filter:  filter,
+		changes: make(chan StorageChanges, n.bufferSize),
+	}
+
+	n.mutex.Lock()
+	n.add(subscription)
+	n.mutex.Unlock()
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			n.mutex.Lock()
+			defer n.mutex.Unlock()
+			n.remove(subscription)
+			close(subscription.changes)
+		})
+	}
+	return subscription.changes, unsubscribe
+}
+
+// add adds the subscription given, and starts the goroutine sending the
+// changes of the blocks queued for the first subscription. It must be
+// called with the mutex locked.
+func (n *storageChangesNotifier) add(subscription *storageChangesSubscription) {
+	n.count++
+	if n.count == 1 {
+		n.headers = make(chan *types.Header, n.bufferSize)
+		go n.run(n.headers)
+	}
+
+	if subscription.filter.all() || len(subscription.filter.Prefixes) > 0 {
+		n.scanned[subscription] = struct{}{}
+	}
+	for _, key := range subscription.filter.Keys {
+		n.keyToSubscriptions[string(key)] = append(n.keyToSubscriptions[string(key)], subscription)
+	}
+}
+
+// remove removes the subscription given, and stops the goroutine sending
+// the changes once the last subscription is removed. It must be called
+// with the mutex locked.
+func (n *storageChangesNotifier) remove(subscription *storageChangesSubscription) {
+	n.count--
+	if n.count == 0 {
+		close(n.headers)
+		n.headers = nil
+	}
+
+	delete(n.scanned, subscription)
+	for _, key := range subscription.filter.Keys {
+		subscriptions := n.keyToSubscriptions[string(key)]
+		for i, keySubscription := range subscriptions {
+			if keySubscription == subscription {
+				subscriptions = append(subscriptions[:i], subscriptions[i+1:]...)
+				break
+			}
+		}
+		if len(subscriptions) == 0 {
+			delete(n.keyToSubscriptions, string(key))
+		} else {
+			n.keyToSubscriptions[string(key)] = subscriptions
+		}
+	}
+}
+
+func (n *storageChangesNotifier) hasSubscriptions() bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.count > 0
+}
+
+// queue queues the header of the block imported given, whose changes are
+// sent by the goroutine running while there are subscriptions, without
+// blocking. If the queue is full, the changes of the block are dropped
+// for all the subscriptions.
+func (n *storageChangesNotifier) queue(header *types.Header) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.headers == nil {
+		return
+	}
+
+	select {
+	case n.headers <- header:
+	default:
+		logger.Debugf("dropping storage changes of block %s: queue is full", header.Hash())
+		for subscription := range n.scanned {
+			subscription.dropped = true
+		}
+		for _, subscriptions := range n.keyToSubscriptions {
+			for _, subscription := range subscriptions {
+				subscription.dropped = true
+			}
+		}
+	}
+}
+
+// run computes and sends the changes of the blocks of the headers
+// given, until the headers channel is closed.
+func (n *storageChangesNotifier) run(headers <-chan *types.Header) {
+	for header := range headers {
+		changes, err := n.changesOf(header)
+		if err != nil {
+			// for example, the parent state is not in the database after a fast sync.
+			logger.Debugf("cannot notify storage changes of block %s: %s", header.Hash(), err)
+			continue
+		}
+		n.notify(header.Hash(), changes)
+	}
+}
+
+// notify sends the changes of the block given, sorted by key, to each
+// subscription selecting at least one of them, without blocking.
+func (n *storageChangesNotifier) notify(blockHash common.Hash, changes []KeyValue) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	selected := make(map[*storageChangesSubscription][]KeyValue)
+	for _, change := range changes {
+		// a subscription may select a key more than once with its keys and
+		// prefixes, so the key is only added once for each subscription.
+		var keySubscriptions map[*storageChangesSubscription]struct{}
+		for _, subscription := range n.keyToSubscriptions[string(change.Key)] {
+			if keySubscriptions == nil {
+				keySubscriptions = make(map[*storageChangesSubscription]struct{})
+			} else if _, ok := keySubscriptions[subscription]; ok {
+				continue
+			}
+			keySubscriptions[subscription] = struct{}{}
+			selected[subscription] = append(selected[subscription], change)
+		}
+
+		for subscription := range n.scanned {
+			_, ok := keySubscriptions[subscription]
+			if ok || !subscription.selects(change.Key) {
+				continue
+			}
+			selected[subscription] = append(selected[subscription], change)
+		}
+	}
+
+	for subscription, subscriptionChanges := range selected {
+		subscription.send(StorageChanges{
+			BlockHash: blockHash,
+			Changes:   subscriptionChanges,
+		})
+	}
+}
+
+// selects returns true if the filter of the subscription
+// selects the key given with all keys or its prefixes.
+func (s *storageChangesSubscription) selects(key []byte) bool {
+	if s.filter.all() {
+		return true
+	}
+	for _, prefix := range s.filter.Prefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// send sends the changes given to the subscription channel,
+// or drops them if the channel buffer is full.
+func (s *storageChangesSubscription) send(changes StorageChanges) {
+	changes.Dropped = s.dropped
+	select {
+	case s.changes <- changes:
+		s.dropped = false
+	default:
+		s.dropped = true
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageState_SubscribeStorageChanges(t *testing.T) {
+	storage := newTestStorageState(t)
+
+	const subscribers = 1000
+	keys := make([][]byte, subscribers)
+	subscriptions := make([]<-chan StorageChanges, subscribers)
+	for i := range subscriptions {
+		keys[i] = []byte(fmt.Sprintf("key-%d", i))
+		filter := StorageChangeFilter{Keys: [][]byte{keys[i]}}
+		changes, unsubscribe := storage.SubscribeStorageChanges(filter)
+		t.Cleanup(unsubscribe)
+		subscriptions[i] = changes
+	}
+
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+	for i, key := range keys {
+		ts.Put(key, []byte(fmt.Sprintf("value-%d", i)))
+	}
+	ts.Put([]byte("other"), []byte("value"))
+
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: testGenesisHeader.Hash(),
+			Number:     1,
+			StateRoot:  ts.MustRoot(),
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: *types.NewBody([]types.Extrinsic{}),
+	}
+	err = storage.StoreTrie(ts, &block.Header)
+	require.NoError(t, err)
+	err = storage.blockState.AddBlock(block)
+	require.NoError(t, err)
+
+	for i, changes := range subscriptions {
+		expected := StorageChanges{
+			BlockHash: block.Header.Hash(),
+			Changes: []KeyValue{
+				{Key: keys[i], Value: []byte(fmt.Sprintf("value-%d", i))},
+			},
+		}
+		require.Equal(t, expected, <-changes)
+	}
+
+	ts, err = storage.TrieState(&block.Header.StateRoot)
+	require.NoError(t, err)
+	ts.Put(keys[0], []byte("new value"))
+	err = ts.Delete(keys[1])
+	require.NoError(t, err)
+	ts.Put([]byte("other"), []byte("new value"))
+
+	header := &types.Header{
+		ParentHash: block.Header.Hash(),
+		Number:     2,
+		StateRoot:  ts.MustRoot(),
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	err = storage.StoreTrie(ts, header)
+	require.NoError(t, err)
+
+	// the changes are only sent once the block is imported
+	select {
+	case changes := <-subscriptions[0]:
+		t.Fatalf("changes received before the block is imported: %v", changes)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	err = storage.blockState.AddBlock(&types.Block{
+		Header: *header,
+		Body:   *types.NewBody([]types.Extrinsic{}),
+	})
+	require.NoError(t, err)
+
+	expected := StorageChanges{
+		BlockHash: header.Hash(),
+		Changes:   []KeyValue{{Key: keys[0], Value: []byte("new value")}},
+	}
+	assert.Equal(t, expected, <-subscriptions[0])
+
+	expected = StorageChanges{
+		BlockHash: header.Hash(),
+		Changes:   []KeyValue{{Key: keys[1]}},
+	}
+	assert.Equal(t, expected, <-subscriptions[1])
+
+	for _, changes := range subscriptions[2:] {
+		assert.Empty(t, changes)
+	}
+}
+
+func Test_storageChangesNotifier(t *testing.T) {
+	t.Parallel()
+
+	changes := []KeyValue{
+		{Key: []byte("a1"), Value: []byte{1}},
+		{Key: []byte("a2"), Value: []byte{2}},
+		{Key: []byte("b1")},
+	}
+	blockHash := common.Hash{1}
+
+	testCases := map[string]struct {
+		filter          StorageChangeFilter
+		expectedChanges []KeyValue
+	}{
+		"all_keys": {
+			expectedChanges: changes,
+		},
+		"exact_keys": {
+			filter: StorageChangeFilter{
+				Keys: [][]byte{[]byte("a2"), []byte("b1"), []byte("c")},
+			},
+			expectedChanges: changes[1:],
+		},
+		"prefixes": {
+			filter: StorageChangeFilter{
+				Prefixes: [][]byte{[]byte("a")},
+			},
+			expectedChanges: changes[:2],
+		},
+		"keys_and_prefixes_overlapping": {
+			filter: StorageChangeFilter{
+				Keys:     [][]byte{[]byte("a1"), []byte("b1")},
+				Prefixes: [][]byte{[]byte("a")},
+			},
+			expectedChanges: changes,
+		},
+		"no_change_selected": {
+			filter: StorageChangeFilter{
+				Keys: [][]byte{[]byte("c")},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			notifier := newStorageChangesNotifier(1, nil)
+			subscription, unsubscribe := notifier.subscribe(testCase.filter)
+
+			notifier.notify(blockHash, changes)
+			unsubscribe()
+
+			received, ok := <-subscription
+			if testCase.expectedChanges == nil {
+				assert.False(t, ok)
+				return
+			}
+			expected := StorageChanges{
+				BlockHash: blockHash,
+				Changes:   testCase.expectedChanges,
+			}
+			assert.Equal(t, expected, received)
+		})
+	}
+}
+
+func Test_storageChangesNotifier_dropped(t *testing.T) {
+	t.Parallel()
+
+	notifier := newStorageChangesNotifier(1, nil)
+	subscription, unsubscribe := notifier.subscribe(StorageChangeFilter{})
+	defer unsubscribe()
+
+	changes := []KeyValue{{Key: []byte{1}, Value: []byte{1}}}
+	notifier.notify(common.Hash{1}, changes)
+	// the buffer is full so the changes of the next blocks are dropped.
+	notifier.notify(common.Hash{2}, changes)
+	notifier.notify(common.Hash{3}, changes)
+
+	received := <-subscription
+	assert.Equal(t, common.Hash{1}, received.BlockHash)
+	assert.False(t, received.Dropped)
+
+	notifier.notify(common.Hash{4}, changes)
+	received = <-subscription
+	assert.Equal(t, common.Hash{4}, received.BlockHash)
+	assert.True(t, received.Dropped)
+
+	notifier.notify(common.Hash{5}, changes)
+	received = <-subscription
+	assert.False(t, received.Dropped)
+}
+
+func Test_storageChangesNotifier_queue(t *testing.T) {
+	t.Parallel()
+
+	header := &types.Header{Number: 1}
+	changes := []KeyValue{{Key: []byte{1}, Value: []byte{1}}}
+	changesOf := func(header *types.Header) ([]KeyValue, error) {
+		if header.Number == 2 {
+			return nil, errors.New("test error")
+		}
+		return changes, nil
+	}
+	notifier := newStorageChangesNotifier(2, changesOf)
+
+	// blocks imported without any subscription are not queued.
+	notifier.queue(header)
+	assert.Nil(t, notifier.headers)
+
+	subscription, unsubscribe := notifier.subscribe(StorageChangeFilter{})
+	// the changes of a block which cannot be computed are skipped.
+	notifier.queue(&types.Header{Number: 2})
+	notifier.queue(header)
+
+	expected := StorageChanges{
+		BlockHash: header.Hash(),
+		Changes:   changes,
+	}
+	assert.Equal(t, expected, <-subscription)
+
+	unsubscribe()
+	_, ok := <-subscription
+	assert.False(t, ok)
+	assert.Nil(t, notifier.headers)
+}
+
+func Test_storageChangesNotifier_unsubscribeWhileNotifying(t *testing.T) {
+	t.Parallel()
+
+	notifier := newStorageChangesNotifier(1, nil)
+	changes := []KeyValue{{Key: []byte{1}, Value: []byte{1}}}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			notifier.notify(common.Hash{1}, changes)
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_, unsubscribe := notifier.subscribe(StorageChangeFilter{})
+		unsubscribe()
+		unsubscribe()
+	}
+	wg.Wait()
+
+	assert.False(t, notifier.hasSubscriptions())
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// deletedNodeHashesPrefix + block hash -> hashes of the trie nodes deleted by the block
+var deletedNodeHashesPrefix = []byte("dnh")
+
+// storeDeletedNodeHashes writes the hashes of the state trie nodes
+// deleted by the block with the given hash to the database.
+func (s *StorageState) storeDeletedNodeHashes(blockHash common.Hash,
+	deletedNodeHashes map[common.Hash]struct{}) (err error) {
+	nodeHashes := make([]common.Hash, 0, len(deletedNodeHashes))
+	for nodeHash := range deletedNodeHashes {
+		nodeHashes = append(nodeHashes, nodeHash)
+	}
+	sort.Slice(nodeHashes, func(i, j int) bool {
+		return bytes.Compare(nodeHashes[i][:], nodeHashes[j][:]) < 0
+	})
+
+	encoded, err := scale.Marshal(nodeHashes)
+	if err != nil {
+		return fmt.Errorf("encoding deleted node hashes: %w", err)
+	}
+
+	batch := s.db.NewBatch()
+	err = batch.Put(prefixKey(blockHash, deletedNodeHashesPrefix), encoded)
+	if err != nil {
+		batch.Reset()
+		return fmt.Errorf("putting deleted node hashes in batch: %w", err)
+	}
+	return batch.Flush()
+}
+
+// GetDeletedNodeHashes returns the hashes of the state trie nodes deleted
+// by the block with the given hash. These nodes can be removed from the
+// database by the pruner once the block falls behind the retention window
+// of finalised blocks.
+func (s *StorageState) GetDeletedNodeHashes(blockHash common.Hash) (
+	deletedNodeHashes map[common.Hash]struct{}, err error) {
+	encoded, err := s.db.Get(prefixKey(blockHash, deletedNodeHashesPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("getting deleted node hashes from database: %w", err)
+	}
+
+	var nodeHashes []common.Hash
+	err = scale.Unmarshal(encoded, &nodeHashes)
+	if err != nil {
+		return nil, fmt.Errorf("decoding deleted node hashes: %w", err)
+	}
+
+	deletedNodeHashes = make(map[common.Hash]struct{}, len(nodeHashes))
+	for _, nodeHash := range nodeHashes {
+		deletedNodeHashes[nodeHash] = struct{}{}
+	}
+	return deletedNodeHashes, nil
+}
+
+// DeleteDeletedNodeHashes removes the record of the state trie nodes
+// deleted by the block with the given hash from the database.
+func (s *StorageState) DeleteDeletedNodeHashes(blockHash common.Hash) (err error) {
+	batch := s.db.NewBatch()
+	err = batch.Del(prefixKey(blockHash, deletedNodeHashesPrefix))
+	if err != nil {
+		batch.Reset()
+		return fmt.Errorf("deleting deleted node hashes in batch: %w", err)
+	}
+	return batch.Flush()
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"github.com/ChainSafe/gossamer/dot/types"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	storageKeysWrittenCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_storage",
+		Name:      "keys_written_total",
+		Help:      "total number of storage keys written by the blocks stored",
+	})
+	storageKeysDeletedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_storage",
+		Name:      "keys_deleted_total",
+		Help:      "total number of storage keys deleted by the blocks stored",
+	})
+	storageValueBytesWrittenCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_storage",
+		Name:      "value_bytes_written_total",
+		Help:      "total size in bytes of the storage values written by the blocks stored",
+	})
+	storageTrieNodesPersistedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_storage",
+		Name:      "trie_nodes_persisted_total",
+		Help:      "total number of new trie nodes persisted to the database by the blocks stored",
+	})
+	storageTrieNodesGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gossamer_state_storage",
+		Name:      "trie_nodes",
+		Help:      "cumulative number of trie nodes persisted to the database by the blocks stored since the node started",
+	})
+)
+
+// storageMetrics records the storage changes of each block stored.
+type storageMetrics struct {
+	keysWritten        prometheus.Counter
+	keysDeleted        prometheus.Counter
+	valueBytesWritten  prometheus.Counter
+	trieNodesPersisted prometheus.Counter
+	trieNodesGauge     prometheus.Gauge
+	// trieNodes is the cumulative number of new trie nodes persisted.
+	trieNodes uint64
+	// logThreshold is the number of keys changed by a block from which
+	// a summary of its storage changes is logged, and 0 disables it.
+	logThreshold uint
+}
+
+func newStorageMetrics(logThreshold uint) *storageMetrics {
+	return &storageMetrics{
+		keysWritten:        storageKeysWrittenCounter,
+		keysDeleted:        storageKeysDeletedCounter,
+		valueBytesWritten:  storageValueBytesWrittenCounter,
+		trieNodesPersisted: storageTrieNodesPersistedCounter,
+		trieNodesGauge:     storageTrieNodesGauge,
+		logThreshold:       logThreshold,
+	}
+}
+
+// record records the storage change statistics and the number of new trie
+// nodes persisted for the block header given, which are the nodes written by
+// the block and not already in the database.
+func (m *storageMetrics) record(header *types.Header, stats rtstorage.ChangeStats, trieNodesPersisted uint) {
+	m.keysWritten.Add(float64(stats.KeysWritten))
+	m.keysDeleted.Add(float64(stats.KeysDeleted))
+	m.valueBytesWritten.Add(float64(stats.ValueBytesWritten))
+	m.trieNodesPersisted.Add(float64(trieNodesPersisted))
+	m.trieNodes += uint64(trieNodesPersisted)
+	m.trieNodesGauge.Set(float64(m.trieNodes))
+
+	keysChanged := stats.KeysWritten + stats.KeysDeleted
+	if m.logThreshold == 0 || keysChanged < m.logThreshold {
+		return
+	}
+
+	logger.Infof("block number %d with hash %s wrote %d storage keys (%d bytes), "+
+		"deleted %d storage keys and persisted %d new trie nodes",
+		header.Number, header.Hash(), stats.KeysWritten, stats.ValueBytesWritten,
+		stats.KeysDeleted, trieNodesPersisted)
+}
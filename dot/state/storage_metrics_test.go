@@ -0,0 +1,109 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageState_StoreTrie_metrics(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorageState(t)
+	parent := addTestStorageBlock(t, storage, testGenesisHeader, map[string][]byte{
+		"existing": {1},
+		"other":    {2},
+	})
+
+	const logThreshold = 1
+	storage.metrics = newStorageMetrics(logThreshold)
+	storage.metrics.keysWritten = prometheus.NewCounter(prometheus.CounterOpts{})
+	storage.metrics.keysDeleted = prometheus.NewCounter(prometheus.CounterOpts{})
+	storage.metrics.valueBytesWritten = prometheus.NewCounter(prometheus.CounterOpts{})
+	storage.metrics.trieNodesPersisted = prometheus.NewCounter(prometheus.CounterOpts{})
+	storage.metrics.trieNodesGauge = prometheus.NewGauge(prometheus.GaugeOpts{})
+
+	ts, err := storage.TrieState(&parent.StateRoot)
+	require.NoError(t, err)
+
+	// only the last value written for a key is accounted for.
+	err = ts.Put([]byte("a"), []byte{1, 2, 3})
+	require.NoError(t, err)
+	err = ts.Put([]byte("a"), []byte{4, 5})
+	require.NoError(t, err)
+	err = ts.Put([]byte("b"), []byte{6})
+	require.NoError(t, err)
+	err = ts.Delete([]byte("existing"))
+	require.NoError(t, err)
+	// deleting an absent key does not change the storage.
+	err = ts.Delete([]byte("absent"))
+	require.NoError(t, err)
+	// the changes rolled back are not accounted for.
+	ts.BeginStorageTransaction()
+	err = ts.Put([]byte("rolled back"), []byte{7})
+	require.NoError(t, err)
+	err = ts.Delete([]byte("other"))
+	require.NoError(t, err)
+	ts.RollbackStorageTransaction()
+
+	expectedStats := rtstorage.ChangeStats{
+		KeysWritten:       2,
+		KeysDeleted:       1,
+		ValueBytesWritten: 3,
+	}
+	assert.Equal(t, expectedStats, ts.ChangeStats())
+
+	insertedNodeHashes, _, err := ts.GetChangedNodeHashes()
+	require.NoError(t, err)
+	require.NotEmpty(t, insertedNodeHashes)
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     parent.Number + 1,
+		StateRoot:  ts.MustRoot(),
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	err = storage.StoreTrie(ts, header)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(storage.metrics.keysWritten))
+	assert.Equal(t, float64(1), testutil.ToFloat64(storage.metrics.keysDeleted))
+	assert.Equal(t, float64(3), testutil.ToFloat64(storage.metrics.valueBytesWritten))
+	assert.Equal(t, float64(len(insertedNodeHashes)), testutil.ToFloat64(storage.metrics.trieNodesPersisted))
+	assert.Equal(t, float64(len(insertedNodeHashes)), testutil.ToFloat64(storage.metrics.trieNodesGauge))
+
+	// a sibling block with the same storage changes
+	// writes trie nodes already in the database.
+	ts, err = storage.TrieState(&parent.StateRoot)
+	require.NoError(t, err)
+	err = ts.Put([]byte("a"), []byte{4, 5})
+	require.NoError(t, err)
+	err = ts.Put([]byte("b"), []byte{6})
+	require.NoError(t, err)
+	err = ts.Delete([]byte("existing"))
+	require.NoError(t, err)
+
+	sibling := &types.Header{
+		ParentHash:     parent.Hash(),
+		Number:         parent.Number + 1,
+		StateRoot:      ts.MustRoot(),
+		ExtrinsicsRoot: common.Hash{1},
+		Digest:         createPrimaryBABEDigest(t),
+	}
+	require.Equal(t, header.StateRoot, sibling.StateRoot)
+	err = storage.StoreTrie(ts, sibling)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(4), testutil.ToFloat64(storage.metrics.keysWritten))
+	assert.Equal(t, float64(len(insertedNodeHashes)), testutil.ToFloat64(storage.metrics.trieNodesPersisted))
+	assert.Equal(t, float64(len(insertedNodeHashes)), testutil.ToFloat64(storage.metrics.trieNodesGauge))
+}
@@ -5,9 +5,9 @@
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
@@ -44,102 +44,97 @@ type Observer interface {
 	GetFilter() map[string][]byte
 }
 
-// RegisterStorageObserver to add abserver to notification list
+// RegisterStorageObserver registers the observer given, which is updated with
+// the storage values of the best block for the hex encoded keys of its filter,
+// or for all the keys except the runtime code if its filter is empty, and then
+// with the storage changes of these keys for each block imported.
 func (s *StorageState) RegisterStorageObserver(o Observer) {
-	s.observerListMutex.Lock()
-	defer s.observerListMutex.Unlock()
-	s.observerList = append(s.observerList, o)
+	var filter StorageChangeFilter
+	for hexKey := range o.GetFilter() {
+		key, err := common.HexToBytes(hexKey)
+		if err != nil {
+			logger.Debugf("ignoring storage observer filter key %s: %s", hexKey, err)
+			continue
+		}
+		filter.Keys = append(filter.Keys, key)
+	}
+
+	changes, unsubscribe := s.SubscribeStorageChanges(filter)
+
+	s.observersMutex.Lock()
+	s.observers[o] = unsubscribe
+	s.observersMutex.Unlock()
 
-	// notifyObserver here to send storage value of current state
-	sr, err := s.blockState.BestBlockStateRoot()
+	bestBlockHeader, err := s.blockState.BestBlockHeader()
 	if err != nil {
-		logger.Debugf("error registering storage change channel: %s", err)
-		return
+		logger.Debugf("cannot send best block storage values to observer: %s", err)
 	}
+
 	go func() {
-		if err := s.notifyObserver(sr, o); err != nil {
-			logger.Warnf("failed to notify storage subscriptions: %s", err)
+		if bestBlockHeader != nil {
+			err := s.notifyObserver(bestBlockHeader, filter.Keys, o)
+			if err != nil {
+				logger.Warnf("failed to notify storage subscriptions: %s", err)
+			}
 		}
-	}()
 
+		for blockChanges := range changes {
+			o.Update(&SubscriptionResult{
+				Hash:    blockChanges.BlockHash,
+				Changes: blockChanges.Changes,
+			})
+		}
+	}()
 }
 
 // UnregisterStorageObserver removes observer from notification list
 func (s *StorageState) UnregisterStorageObserver(o Observer) {
-	s.observerListMutex.Lock()
-	defer s.observerListMutex.Unlock()
-	s.observerList = s.removeFromSlice(s.observerList, o)
-}
+	s.observersMutex.Lock()
+	defer s.observersMutex.Unlock()
 
-func (s *StorageState) notifyAll(root common.Hash) {
-	s.observerListMutex.RLock()
-	defer s.observerListMutex.RUnlock()
-	for _, observer := range s.observerList {
-		err := s.notifyObserver(root, observer)
-		if err != nil {
-			logger.Warnf("failed to notify storage subscriptions: %s", err)
-		}
+	unsubscribe, ok := s.observers[o]
+	if !ok {
+		return
 	}
+	unsubscribe()
+	delete(s.observers, o)
 }
 
-func (s *StorageState) notifyObserver(root common.Hash, o Observer) error {
-	t, err := s.TrieState(&root)
+// notifyObserver updates the observer given with the storage values of the
+// block with the header given for the keys given, or for all the keys
+// except the runtime code if no key is given.
+func (s *StorageState) notifyObserver(header *types.Header, keys [][]byte, o Observer) error {
+	t, err := s.TrieState(&header.StateRoot)
 	if err != nil {
 		return err
 	}
 
 	if t == nil {
-		return errTrieDoesNotExist(root)
+		return errTrieDoesNotExist(header.StateRoot)
 	}
 
 	subRes := &SubscriptionResult{
-		Hash: root,
+		Hash: header.Hash(),
 	}
-	if len(o.GetFilter()) == 0 {
+	if len(keys) == 0 {
 		// no filter, so send all changes
 		ent := t.TrieEntries()
 		for k, v := range ent {
-			if k != ":code" {
+			if k != string(codeKey) {
 				// currently we're ignoring :code since this is a lot of data
-				kv := &KeyValue{
-					Key:   common.MustHexToBytes(fmt.Sprintf("0x%x", k)),
-					Value: v,
-				}
-				subRes.Changes = append(subRes.Changes, *kv)
+				subRes.Changes = append(subRes.Changes, KeyValue{Key: []byte(k), Value: v})
 			}
 		}
 	} else {
-		// filter result to include only interested keys
-		for k, cachedValue := range o.GetFilter() {
-			value := t.Get(common.MustHexToBytes(k))
-			if !reflect.DeepEqual(cachedValue, value) {
-				kv := &KeyValue{
-					Key:   common.MustHexToBytes(k),
-					Value: value,
-				}
-				subRes.Changes = append(subRes.Changes, *kv)
-				o.GetFilter()[k] = value
-			}
+		for _, key := range keys {
+			subRes.Changes = append(subRes.Changes, KeyValue{Key: key, Value: t.Get(key)})
 		}
 	}
 
 	if len(subRes.Changes) > 0 {
 		logger.Tracef("update observer, changes are %v", subRes.Changes)
-		go func() {
-			o.Update(subRes)
-		}()
+		o.Update(subRes)
 	}
 
 	return nil
 }
-
-func (s *StorageState) removeFromSlice(observerList []Observer, observerToRemove Observer) []Observer {
-	observerListLength := len(observerList)
-	for i, observer := range observerList {
-		if observerToRemove.GetID() == observer.GetID() {
-			observerList[i] = observerList[observerListLength-1]
-			return observerList[:observerListLength-1]
-		}
-	}
-	return observerList
-}
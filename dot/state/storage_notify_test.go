@@ -9,127 +9,112 @@
 	"log"
 	"sync"
 	"testing"
-	"time"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	runtime "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/dgraph-io/badger/v4/pb"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
+// importTestBlock stores the trie state given and imports
+// a block with this state, child of the parent header given.
+func importTestBlock(t *testing.T, ss *StorageState, parent *types.Header,
+	ts *runtime.TrieState) *types.Header {
+	t.Helper()
+
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			StateRoot:  ts.MustRoot(),
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: *types.NewBody([]types.Extrinsic{}),
+	}
+	err := ss.StoreTrie(ts, &block.Header)
+	require.NoError(t, err)
+	err = ss.blockState.AddBlock(block)
+	require.NoError(t, err)
+	return &block.Header
+}
+
 func TestStorageState_RegisterStorageObserver(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	ss := newTestStorageState(t)
+	key1 := []byte("key1")
 
-	ts, err := ss.TrieState(nil)
-	require.NoError(t, err)
-
-	mockfilter := map[string][]byte{}
+	updates := make(chan *SubscriptionResult, 2)
 	mockobs := NewMockObserver(ctrl)
-
-	mockobs.EXPECT().GetID().Return(uint(10)).Times(2)
-
-	var fireAndForgetMockCallsWG sync.WaitGroup
-
-	fireAndForgetMockCallsWG.Add(2)
-	mockobs.EXPECT().GetFilter().DoAndReturn(func() map[string][]byte {
-		defer fireAndForgetMockCallsWG.Done()
-		return mockfilter
-	}).Times(2)
-
-	fireAndForgetMockCallsWG.Add(1)
+	mockobs.EXPECT().GetFilter().Return(map[string][]byte{common.BytesToHex(key1): {}})
 	mockobs.EXPECT().Update(gomock.Any()).
-		DoAndReturn(func(r *SubscriptionResult) map[string][]byte {
-			defer fireAndForgetMockCallsWG.Done()
-			return map[string][]byte{}
-		})
+		Do(func(result *SubscriptionResult) { updates <- result }).Times(2)
 
 	ss.RegisterStorageObserver(mockobs)
 	defer ss.UnregisterStorageObserver(mockobs)
 
-	ts.Put([]byte("mackcom"), []byte("wuz here"))
-	err = ss.StoreTrie(ts, nil)
+	// the observer is first updated with the value of the best block
+	expected := &SubscriptionResult{
+		Hash:    testGenesisHeader.Hash(),
+		Changes: []KeyValue{{Key: key1}},
+	}
+	require.Equal(t, expected, <-updates)
+
+	ts, err := ss.TrieState(nil)
 	require.NoError(t, err)
+	ts.Put(key1, []byte("value1"))
+	ts.Put([]byte("key2"), []byte("value2"))
+	header := importTestBlock(t, ss, testGenesisHeader, ts)
 
-	// We need to wait since GetFilter and Update are called
-	// in fire and forget goroutines. Not ideal, but it's out of scope
-	// to refactor the production code in this commit.
-	fireAndForgetMockCallsWG.Wait()
+	expected = &SubscriptionResult{
+		Hash:    header.Hash(),
+		Changes: []KeyValue{{Key: key1, Value: []byte("value1")}},
+	}
+	require.Equal(t, expected, <-updates)
 }
 
 func TestStorageState_RegisterStorageObserver_Multi(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	ss := newTestStorageState(t)
-	ts, err := ss.TrieState(nil)
-	require.NoError(t, err)
-
-	num := 5
-
-	var mocks []*MockObserver
-
-	for i := 0; i < num; i++ {
-		mockfilter := map[string][]byte{}
-		mockobs := NewMockObserver(ctrl)
-
-		mockobs.EXPECT().Update(gomock.Any())
-		mockobs.EXPECT().GetID().Return(uint(10)).Times(2)
-		mockobs.EXPECT().GetFilter().Return(mockfilter).Times(2)
-
-		mocks = append(mocks, mockobs)
-		ss.RegisterStorageObserver(mockobs)
-		require.NoError(t, err)
-	}
-
 	key1 := []byte("key1")
-	value1 := []byte("value1")
-
-	ts.Put(key1, value1)
 
-	err = ss.StoreTrie(ts, nil)
-	require.NoError(t, err)
-
-	time.Sleep(time.Millisecond * 10)
-
-	for _, observer := range mocks {
-		ss.UnregisterStorageObserver(observer)
-	}
-}
-
-func TestStorageState_RegisterStorageObserver_Multi_Filter(t *testing.T) {
-	t.Skip() // this seems to fail often on CI\
-
-	ctrl := gomock.NewController(t)
-	ss := newTestStorageState(t)
 	ts, err := ss.TrieState(nil)
 	require.NoError(t, err)
+	ts.Put(key1, []byte("value1"))
+	header1 := importTestBlock(t, ss, testGenesisHeader, ts)
 
-	key1 := []byte("key1")
-	value1 := []byte("value1")
-
-	num := 5
+	const num = 5
+	updates := make(chan *SubscriptionResult, 2*num)
 	var mocks []*MockObserver
-	filter := map[string][]byte{
-		common.BytesToHex(key1): {},
-	}
-
 	for i := 0; i < num; i++ {
 		mockobs := NewMockObserver(ctrl)
-		mockobs.EXPECT().Update(gomock.Any())
-		mockobs.EXPECT().GetID().Return(uint(i))
-		mockobs.EXPECT().GetFilter().Return(filter).Times(len(filter) + 3)
+		mockobs.EXPECT().GetFilter().Return(map[string][]byte{})
+		mockobs.EXPECT().Update(gomock.Any()).
+			Do(func(result *SubscriptionResult) { updates <- result }).Times(2)
 
 		mocks = append(mocks, mockobs)
 		ss.RegisterStorageObserver(mockobs)
 	}
 
-	ts.Put(key1, value1)
-	err = ss.StoreTrie(ts, nil)
+	ts, err = ss.TrieState(&header1.StateRoot)
 	require.NoError(t, err)
-
-	time.Sleep(time.Millisecond * 10)
+	ts.Put(key1, []byte("value2"))
+	header2 := importTestBlock(t, ss, header1, ts)
+
+	// the observers without filter are updated with all the values
+	// of the best block, and then with all the changes of each block.
+	expectedUpdates := map[common.Hash][]KeyValue{
+		header1.Hash(): {{Key: key1, Value: []byte("value1")}},
+		header2.Hash(): {{Key: key1, Value: []byte("value2")}},
+	}
+	for i := 0; i < 2*num; i++ {
+		result := <-updates
+		require.Equal(t, expectedUpdates[result.Hash], result.Changes)
+	}
 
 	for _, observer := range mocks {
 		ss.UnregisterStorageObserver(observer)
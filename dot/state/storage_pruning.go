@@ -0,0 +1,24 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"github.com/ChainSafe/gossamer/dot/types"
+)
+
+// pruneFinalisation prunes the state of the finalised blocks falling out
+// of the retention window and of the blocks pruned from forks, and removes
+// the tries of the state roots pruned from memory. It is called by the block
+// state on each finalisation.
+func (s *StorageState) pruneFinalisation(finalised, pruned []*types.Header) {
+	prunedRoots, err := s.pruner.Finalise(finalised, pruned)
+	if err != nil {
+		logger.Errorf("failed to prune state: %s", err)
+	}
+
+	for _, root := range prunedRoots {
+		s.tries.delete(root)
+		s.historicalTries.delete(root)
+	}
+}
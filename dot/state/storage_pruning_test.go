@@ -0,0 +1,223 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/state/pruner"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPrunedStorageState(t *testing.T, retainedBlocks uint32) *StorageState {
+	t.Helper()
+
+	db := NewInMemoryDB(t)
+	tries := newTriesEmpty()
+	blockState := newTestBlockState(t, tries)
+
+	prunerConfig := pruner.Config{
+		Mode:           pruner.Pruned,
+		RetainedBlocks: retainedBlocks,
+	}
+	storage, err := NewStorageState(db, blockState, tries, 0, prunerConfig)
+	require.NoError(t, err)
+	return storage
+}
+
+func TestStorageState_pruned(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestPrunedStorageState(t, 2)
+
+	block1 := addTestStorageBlock(t, storage, testGenesisHeader, map[string][]byte{
+		"counter":  {1},
+		"constant": {2},
+	})
+	block2 := addTestStorageBlock(t, storage, block1, map[string][]byte{
+		"counter": {2},
+	})
+	block3 := addTestStorageBlock(t, storage, block2, map[string][]byte{
+		"counter": {3},
+		"shared":  {3},
+	})
+	block4 := addTestStorageBlock(t, storage, block3, map[string][]byte{
+		"counter": {4},
+	})
+	block5 := addTestStorageBlock(t, storage, block4, map[string][]byte{
+		"counter": {5},
+	})
+	// the fork block inserts some of the trie nodes inserted by block 3
+	fork3 := addTestStorageBlock(t, storage, block2, map[string][]byte{
+		"counter": {3},
+		"shared":  {3},
+		"fork":    {3},
+	})
+
+	err := storage.blockState.SetFinalisedHash(block4.Hash(), 1, 0)
+	require.NoError(t, err)
+
+	for _, header := range []*types.Header{block1, block2, fork3} {
+		_, err = storage.GetStorage(&header.StateRoot, []byte("counter"))
+		assert.ErrorIs(t, err, ErrStatePruned, "block number %d", header.Number)
+
+		_, err = storage.LoadFromDB(header.StateRoot)
+		assert.ErrorIs(t, err, ErrStatePruned, "block number %d", header.Number)
+	}
+
+	for _, header := range []*types.Header{block3, block4, block5} {
+		// the state is fully loaded from the database
+		// since the tries are removed from memory.
+		storage.tries.delete(header.StateRoot)
+		trieState, err := storage.TrieState(&header.StateRoot)
+		require.NoError(t, err, "block number %d", header.Number)
+
+		assert.Equal(t, []byte{byte(header.Number)}, trieState.Get([]byte("counter")))
+		assert.Equal(t, []byte{2}, trieState.Get([]byte("constant")))
+		assert.Equal(t, []byte{3}, trieState.Get([]byte("shared")))
+	}
+
+	// the deleted node hashes of a block are removed once
+	// the state of its parent is pruned.
+	for _, header := range []*types.Header{block1, block2, block3, fork3} {
+		_, err = storage.GetDeletedNodeHashes(header.Hash())
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound, "block number %d", header.Number)
+	}
+	_, err = storage.GetDeletedNodeHashes(block4.Hash())
+	assert.NoError(t, err)
+}
+
+func TestStorageState_pruned_deletedChildTrie(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestPrunedStorageState(t, 1)
+	keyToChild := []byte("keyToChild")
+
+	ts, err := storage.TrieState(&testGenesisHeader.StateRoot)
+	require.NoError(t, err)
+	child := trie.NewEmptyTrie()
+	for i := 0; i < 10; i++ {
+		// the values are large enough for the child trie nodes not to be inlined.
+		err = child.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("%040d", i)))
+		require.NoError(t, err)
+	}
+	err = ts.SetChild(keyToChild, child)
+	require.NoError(t, err)
+	childNodeHashes, _, err := child.GetChangedNodeHashes()
+	require.NoError(t, err)
+	require.NotEmpty(t, childNodeHashes)
+	block1 := storeTestStorageBlock(t, storage, testGenesisHeader, ts)
+
+	ts, err = storage.TrieState(&block1.StateRoot)
+	require.NoError(t, err)
+	err = ts.DeleteChild(keyToChild)
+	require.NoError(t, err)
+	block2 := storeTestStorageBlock(t, storage, block1, ts)
+
+	for nodeHash := range childNodeHashes {
+		_, err = storage.db.Get(nodeHash[:])
+		require.NoError(t, err)
+	}
+
+	// the child trie nodes are pruned with the state of block 1.
+	err = storage.blockState.SetFinalisedHash(block2.Hash(), 1, 0)
+	require.NoError(t, err)
+
+	for nodeHash := range childNodeHashes {
+		_, err = storage.db.Get(nodeHash[:])
+		assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+	}
+
+	value, err := storage.GetStorage(&block2.StateRoot, []byte(":child_storage:default:keyToChild"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestStorageState_pruned_diskGrowthPlateaus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("importing thousands of blocks")
+	}
+	t.Parallel()
+
+	const (
+		retainedBlocks = 16
+		numberOfBlocks = 3000
+		numberOfKeys   = 200
+		keysPerBlock   = 10
+	)
+
+	storage := newTestPrunedStorageState(t, retainedBlocks)
+
+	// insertedNodeHashes are the hashes of all the trie nodes inserted,
+	// to count the trie nodes still stored in the database.
+	insertedNodeHashes := make(map[common.Hash]struct{})
+	countStoredNodes := func() (count int) {
+		for nodeHash := range insertedNodeHashes {
+			_, err := storage.db.Get(nodeHash[:])
+			if err == nil {
+				count++
+				continue
+			}
+			require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+		}
+		return count
+	}
+
+	parent := testGenesisHeader
+	var storedNodesAtMidpoint int
+	for number := uint(1); number <= numberOfBlocks; number++ {
+		trieState, err := storage.TrieState(&parent.StateRoot)
+		require.NoError(t, err)
+		for i := uint(0); i < keysPerBlock; i++ {
+			key := []byte(fmt.Sprintf("key%d", (number*keysPerBlock+i)%numberOfKeys))
+			err = trieState.Put(key, []byte(fmt.Sprintf("%040d", number)))
+			require.NoError(t, err)
+		}
+
+		inserted, _, err := trieState.GetChangedNodeHashes()
+		require.NoError(t, err)
+		for nodeHash := range inserted {
+			insertedNodeHashes[nodeHash] = struct{}{}
+		}
+
+		block := &types.Block{
+			Header: types.Header{
+				ParentHash: parent.Hash(),
+				Number:     number,
+				StateRoot:  trieState.MustRoot(),
+				Digest:     createPrimaryBABEDigest(t),
+			},
+			Body: *types.NewBody([]types.Extrinsic{}),
+		}
+		err = storage.StoreTrie(trieState, &block.Header)
+		require.NoError(t, err)
+		err = storage.blockState.AddBlock(block)
+		require.NoError(t, err)
+
+		err = storage.blockState.SetFinalisedHash(block.Header.Hash(), uint64(number), 0)
+		require.NoError(t, err)
+
+		if number == numberOfBlocks/2 {
+			storedNodesAtMidpoint = countStoredNodes()
+		}
+		parent = &block.Header
+	}
+
+	storedNodes := countStoredNodes()
+	// the number of stored nodes is bounded by the nodes of the
+	// state and the nodes changed in the retention window.
+	assert.LessOrEqual(t, storedNodes, storedNodesAtMidpoint*11/10)
+	assert.Less(t, storedNodes, len(insertedNodeHashes)/10)
+
+	// the last retained states are still fully stored
+	storage.tries.delete(parent.StateRoot)
+	_, err := storage.LoadFromDB(parent.StateRoot)
+	require.NoError(t, err)
+}
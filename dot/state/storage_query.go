@@ -0,0 +1,181 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// MaxQueryStorageBlocks is the maximum number of blocks
+// of the block range queried by QueryStorage.
+const MaxQueryStorageBlocks = 1000
+
+// maxCachedChangeSets is the maximum number of block
+// storage change sets kept in memory.
+const maxCachedChangeSets = 256
+
+// ErrQueryStorageRangeTooLarge is returned by QueryStorage when the block
+// range queried has more than MaxQueryStorageBlocks blocks.
+var ErrQueryStorageRangeTooLarge = errors.New("query storage block range is too large")
+
+// StorageChangeSet contains the values of the storage keys
+// queried changed by a block.
+type StorageChangeSet struct {
+	BlockHash common.Hash
+	// Changes are the key value pairs of the keys queried changed by
+	// the block, in the order the keys are queried. The value is nil
+	// if the key is deleted.
+	Changes []KeyValue
+}
+
+// QueryStorage returns the values of the given storage keys changed by each
+// block of the chain from the block with the fromHash to the block with the
+// toHash included. The change set of the first block contains the values of
+// all the keys at this block, and the change sets of the next blocks only
+// contain the keys whose value changed, such that blocks not changing any of
+// the keys are skipped. Only the trie nodes differing between the state of a
+// block and the state of its parent are read from the database, if the change
+// set of the block is not already in memory.
+// It returns an error wrapping ErrNotDescendant if the block with the fromHash
+// is not an ancestor of the block with the toHash, and an error wrapping
+// ErrQueryStorageRangeTooLarge if the range has more than MaxQueryStorageBlocks blocks.
+func (s *StorageState) QueryStorage(keys [][]byte, fromHash, toHash common.Hash) (
+	changeSets []StorageChangeSet, err error) {
+	fromHeader, err := s.blockState.GetHeader(fromHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting from header: %w", err)
+	}
+
+	toHeader, err := s.blockState.GetHeader(toHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting to header: %w", err)
+	}
+
+	if toHeader.Number >= fromHeader.Number &&
+		toHeader.Number-fromHeader.Number+1 > MaxQueryStorageBlocks {
+		return nil, fmt.Errorf("%w: %d blocks exceed the maximum of %d blocks",
+			ErrQueryStorageRangeTooLarge, toHeader.Number-fromHeader.Number+1, MaxQueryStorageBlocks)
+	}
+
+	hashes, err := SubChain(s.blockState, fromHash, toHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting chain from %s to %s: %w", fromHash, toHash, err)
+	}
+
+	changes := make([]KeyValue, len(keys))
+	for i, key := range keys {
+		value, err := s.GetStorage(&fromHeader.StateRoot, key)
+		if err != nil {
+			return nil, fmt.Errorf("getting value of key 0x%x at block %s: %w", key, fromHash, err)
+		}
+		changes[i] = KeyValue{Key: key, Value: value}
+	}
+	changeSets = []StorageChangeSet{{BlockHash: fromHash, Changes: changes}}
+
+	queried := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		queried[string(key)] = struct{}{}
+	}
+
+	parentRoot := fromHeader.StateRoot
+	for _, hash := range hashes[1:] {
+		header, err := s.blockState.GetHeader(hash)
+		if err != nil {
+			return nil, fmt.Errorf("getting header of block %s: %w", hash, err)
+		}
+
+		blockChanges, err := s.blockChangeSet(hash, parentRoot, header.StateRoot)
+		if err != nil {
+			return nil, fmt.Errorf("getting changes of block %s: %w", hash, err)
+		}
+		parentRoot = header.StateRoot
+
+		keyToValue := make(map[string][]byte)
+		for _, change := range blockChanges {
+			if _, ok := queried[string(change.Key)]; ok {
+				keyToValue[string(change.Key)] = change.Value
+			}
+		}
+		if len(keyToValue) == 0 {
+			continue
+		}
+
+		changes := make([]KeyValue, 0, len(keyToValue))
+		for _, key := range keys {
+			value, ok := keyToValue[string(key)]
+			if ok {
+				changes = append(changes, KeyValue{Key: key, Value: value})
+			}
+		}
+		changeSets = append(changeSets, StorageChangeSet{BlockHash: hash, Changes: changes})
+	}
+
+	return changeSets, nil
+}
+
+// blockChangeSet returns the storage changes of the block with the given
+// hash, from the change sets in memory or else from the diff between the
+// tries with the given parent and block state roots.
+func (s *StorageState) blockChangeSet(blockHash, parentRoot, root common.Hash) (
+	changes []KeyValue, err error) {
+	changes, ok := s.changeSets.get(blockHash)
+	if ok {
+		return changes, nil
+	}
+
+	changes, err = storageChanges(s.db, parentRoot, root)
+	if err != nil {
+		return nil, err
+	}
+	s.changeSets.set(blockHash, changes)
+	return changes, nil
+}
+
+// changeSetCache is a thread safe cache of the storage changes of
+// the blocks most recently computed, sorted by key for each block.
+type changeSetCache struct {
+	mutex              sync.Mutex
+	blockHashToChanges map[common.Hash][]KeyValue
+	// blockHashes are the block hashes cached, in insertion order.
+	blockHashes []common.Hash
+	maxSize     int
+}
+
+func newChangeSetCache(maxSize int) *changeSetCache {
+	return &changeSetCache{
+		blockHashToChanges: make(map[common.Hash][]KeyValue, maxSize),
+		maxSize:            maxSize,
+	}
+}
+
+func (c *changeSetCache) get(blockHash common.Hash) (changes []KeyValue, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	changes, ok = c.blockHashToChanges[blockHash]
+	return changes, ok
+}
+
+// set caches the changes of the block with the given hash, evicting
+// the changes of the block cached first if the cache is full.
+func (c *changeSetCache) set(blockHash common.Hash, changes []KeyValue) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, has := c.blockHashToChanges[blockHash]; has {
+		return
+	}
+
+	if len(c.blockHashes) == c.maxSize {
+		evicted := c.blockHashes[0]
+		c.blockHashes = c.blockHashes[1:]
+		delete(c.blockHashToChanges, evicted)
+	}
+
+	c.blockHashToChanges[blockHash] = changes
+	c.blockHashes = append(c.blockHashes, blockHash)
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addTestStorageBlock adds a block child of the given parent header with
+// its state trie modified by the given key values, where a nil value
+// deletes the key.
+func addTestStorageBlock(t *testing.T, storage *StorageState, parent *types.Header,
+	keyValues map[string][]byte) (header *types.Header) {
+	t.Helper()
+
+	ts, err := storage.TrieState(&parent.StateRoot)
+	require.NoError(t, err)
+	for key, value := range keyValues {
+		if value == nil {
+			err = ts.Delete([]byte(key))
+		} else {
+			err = ts.Put([]byte(key), value)
+		}
+		require.NoError(t, err)
+	}
+
+	return storeTestStorageBlock(t, storage, parent, ts)
+}
+
+// storeTestStorageBlock stores the trie state given as the state
+// of a new block child of the parent header given.
+func storeTestStorageBlock(t *testing.T, storage *StorageState, parent *types.Header,
+	ts *rtstorage.TrieState) (header *types.Header) {
+	t.Helper()
+
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			StateRoot:  ts.MustRoot(),
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: *types.NewBody([]types.Extrinsic{}),
+	}
+	err := storage.StoreTrie(ts, &block.Header)
+	require.NoError(t, err)
+	err = storage.blockState.AddBlock(block)
+	require.NoError(t, err)
+
+	return &block.Header
+}
+
+func TestStorageState_QueryStorage(t *testing.T) {
+	storage := newTestStorageState(t)
+
+	block1 := addTestStorageBlock(t, storage, testGenesisHeader, map[string][]byte{
+		"changing": {1},
+		"constant": {2},
+	})
+	block2 := addTestStorageBlock(t, storage, block1, map[string][]byte{
+		"other": {1},
+	})
+	block3 := addTestStorageBlock(t, storage, block2, map[string][]byte{
+		"changing": {3},
+		"other":    {3},
+	})
+	block4 := addTestStorageBlock(t, storage, block3, map[string][]byte{
+		"changing": nil,
+	})
+	block5 := addTestStorageBlock(t, storage, block4, map[string][]byte{
+		"changing": {5},
+	})
+	fork := addTestStorageBlock(t, storage, block1, map[string][]byte{
+		"changing": {6},
+	})
+
+	keys := [][]byte{[]byte("changing"), []byte("constant"), []byte("absent")}
+	expected := []StorageChangeSet{{
+		BlockHash: block1.Hash(),
+		Changes: []KeyValue{
+			{Key: keys[0], Value: []byte{1}},
+			{Key: keys[1], Value: []byte{2}},
+			{Key: keys[2]},
+		},
+	}, {
+		BlockHash: block3.Hash(),
+		Changes:   []KeyValue{{Key: keys[0], Value: []byte{3}}},
+	}, {
+		BlockHash: block4.Hash(),
+		Changes:   []KeyValue{{Key: keys[0]}},
+	}, {
+		BlockHash: block5.Hash(),
+		Changes:   []KeyValue{{Key: keys[0], Value: []byte{5}}},
+	}}
+
+	changeSets, err := storage.QueryStorage(keys, block1.Hash(), block5.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, expected, changeSets)
+
+	// the change sets of the blocks are now in memory.
+	for _, header := range []*types.Header{block2, block3, block4, block5} {
+		_, ok := storage.changeSets.get(header.Hash())
+		assert.True(t, ok)
+	}
+	changeSets, err = storage.QueryStorage(keys, block1.Hash(), block5.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, expected, changeSets)
+
+	changeSets, err = storage.QueryStorage(keys[1:], block2.Hash(), block5.Hash())
+	require.NoError(t, err)
+	expected = []StorageChangeSet{{
+		BlockHash: block2.Hash(),
+		Changes: []KeyValue{
+			{Key: keys[1], Value: []byte{2}},
+			{Key: keys[2]},
+		},
+	}}
+	assert.Equal(t, expected, changeSets)
+
+	_, err = storage.QueryStorage(keys, block2.Hash(), fork.Hash())
+	assert.ErrorIs(t, err, ErrNotDescendant)
+
+	_, err = storage.QueryStorage(keys, block5.Hash(), block1.Hash())
+	assert.ErrorIs(t, err, ErrNotDescendant)
+}
+
+func Test_changeSetCache(t *testing.T) {
+	t.Parallel()
+
+	cache := newChangeSetCache(2)
+	changes := []KeyValue{{Key: []byte{1}, Value: []byte{1}}}
+
+	cache.set(common.Hash{1}, changes)
+	cache.set(common.Hash{2}, nil)
+	cache.set(common.Hash{3}, changes)
+
+	_, ok := cache.get(common.Hash{1})
+	assert.False(t, ok)
+
+	cached, ok := cache.get(common.Hash{2})
+	assert.True(t, ok)
+	assert.Nil(t, cached)
+
+	cached, ok = cache.get(common.Hash{3})
+	assert.True(t, ok)
+	assert.Equal(t, changes, cached)
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// ReadOnlyStorageState is a view of the storage state serving reads from the
+// tries in memory and the database, without caching the tries loaded from the
+// database, and exposing no write. It is meant for state queries, such as the
+// RPC ones, which share the storage state with the block import path and must
+// never modify the tries used to import blocks.
+type ReadOnlyStorageState struct {
+	storage *StorageState
+}
+
+// NewReadOnlyStorageState returns a read-only view of the storage state given.
+func NewReadOnlyStorageState(storage *StorageState) *ReadOnlyStorageState {
+	return &ReadOnlyStorageState{storage: storage}
+}
+
+// GetStorage returns the value at the given key in the trie with the given
+// state root, or with the best block state root if the root is nil.
+func (s *ReadOnlyStorageState) GetStorage(root *common.Hash, key []byte) ([]byte, error) {
+	return s.storage.GetStorage(root, key)
+}
+
+// GetStorageByBlockHash returns the value at the given key in the state of the
+// block with the given hash, or of the best block if the hash is nil.
+func (s *ReadOnlyStorageState) GetStorageByBlockHash(blockHash *common.Hash, key []byte) ([]byte, error) {
+	return s.storage.GetStorageByBlockHash(blockHash, key)
+}
+
+// GetStorageChild returns a snapshot of the child trie at the given key, such
+// that modifying it leaves the child trie of the state unchanged.
+func (s *ReadOnlyStorageState) GetStorageChild(root *common.Hash, keyToChild []byte) (*trie.Trie, error) {
+	tr, err := s.storage.readTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err := tr.GetChild(keyToChild)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.Snapshot(), nil
+}
+
+// GetStorageFromChild returns the value at the given key in the child trie.
+func (s *ReadOnlyStorageState) GetStorageFromChild(root *common.Hash, keyToChild, key []byte) ([]byte, error) {
+	tr, err := s.storage.readTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.GetFromChild(keyToChild, key)
+}
+
+// GetStateRootFromBlock returns the state root of the block with the given hash.
+func (s *ReadOnlyStorageState) GetStateRootFromBlock(blockHash *common.Hash) (*common.Hash, error) {
+	return s.storage.GetStateRootFromBlock(blockHash)
+}
+
+// Entries returns the entries of the trie with the given state root.
+func (s *ReadOnlyStorageState) Entries(root *common.Hash) (map[string][]byte, error) {
+	tr, err := s.storage.readTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.Entries(), nil
+}
+
+// GetKeysWithPrefix returns the keys matching the given prefix
+// in the trie with the given state root, in lexicographic order.
+func (s *ReadOnlyStorageState) GetKeysWithPrefix(root *common.Hash, prefix []byte) ([][]byte, error) {
+	tr, err := s.storage.readTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.GetKeysWithPrefix(prefix), nil
+}
+
+// GetKeysPaged returns up to limit keys matching the given prefix and being
+// strictly after the startAfter key, in the trie with the given state root.
+func (s *ReadOnlyStorageState) GetKeysPaged(root *common.Hash, prefix, startAfter []byte,
+	limit uint) (keys [][]byte, err error) {
+	tr, err := s.storage.readTrie(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.GetKeysPaged(prefix, startAfter, limit), nil
+}
+
+// RegisterStorageObserver registers an observer notified of the storage changes.
+func (s *ReadOnlyStorageState) RegisterStorageObserver(o Observer) {
+	s.storage.RegisterStorageObserver(o)
+}
+
+// UnregisterStorageObserver removes the observer given.
+func (s *ReadOnlyStorageState) UnregisterStorageObserver(o Observer) {
+	s.storage.UnregisterStorageObserver(o)
+}
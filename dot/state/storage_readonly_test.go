@@ -0,0 +1,77 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyStorageState(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorageState(t)
+	view := NewReadOnlyStorageState(storage)
+
+	block1 := addTestStorageBlock(t, storage, testGenesisHeader, map[string][]byte{
+		"counter": {1},
+	})
+	block2 := addTestStorageBlock(t, storage, block1, map[string][]byte{
+		"counter": {2},
+	})
+
+	ts, err := storage.TrieState(&block2.StateRoot)
+	require.NoError(t, err)
+	err = ts.Put([]byte("counter"), []byte{3})
+	require.NoError(t, err)
+	err = ts.SetChild([]byte("keyToChild"), trie.NewEmptyTrie())
+	require.NoError(t, err)
+	err = ts.SetChildStorage([]byte("keyToChild"), []byte("childKey"), []byte("childValue"))
+	require.NoError(t, err)
+
+	// the historical state is served from the database,
+	// without caching its trie with the tries in memory.
+	storage.tries.delete(block1.StateRoot)
+	value, err := view.GetStorage(&block1.StateRoot, []byte("counter"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	entries, err := view.Entries(&block1.StateRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, entries["counter"])
+	assert.Nil(t, storage.tries.get(block1.StateRoot))
+
+	// the runtime calls made for queries modify a snapshot
+	// of the state which is not cached either.
+	queryTrieState, err := storage.QueryTrieState(&block1.StateRoot)
+	require.NoError(t, err)
+	err = queryTrieState.Put([]byte("counter"), []byte{4})
+	require.NoError(t, err)
+	assert.Nil(t, storage.tries.get(block1.StateRoot))
+	value, err = view.GetStorage(&block1.StateRoot, []byte("counter"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	block2Hash := block2.Hash()
+	value, err = view.GetStorageByBlockHash(&block2Hash, []byte("counter"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, value)
+
+	// modifying a child trie returned leaves the state unchanged.
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+	root := ts.MustRoot()
+	child, err := view.GetStorageChild(&root, []byte("keyToChild"))
+	require.NoError(t, err)
+	err = child.Put([]byte("childKey"), []byte("modified"))
+	require.NoError(t, err)
+
+	value, err = view.GetStorageFromChild(&root, []byte("keyToChild"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("childValue"), value)
+	assert.Equal(t, root, storage.tries.get(root).MustHash())
+}
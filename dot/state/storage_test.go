@@ -4,9 +4,13 @@
 package state
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/state/pruner"
 	"github.com/ChainSafe/gossamer/dot/telemetry"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/internal/trie/node"
@@ -16,6 +20,7 @@
 	"github.com/ChainSafe/gossamer/lib/utils"
 	"github.com/golang/mock/gomock"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -25,7 +30,7 @@ func newTestStorageState(t *testing.T) *StorageState {
 	tries := newTriesEmpty()
 	bs := newTestBlockState(t, tries)
 
-	s, err := NewStorageState(db, bs, tries)
+	s, err := NewStorageState(db, bs, tries, 0, pruner.Config{})
 	require.NoError(t, err)
 	return s
 }
@@ -84,6 +89,166 @@ func TestStorage_GetStorageByBlockHash(t *testing.T) {
 	require.Equal(t, value, res)
 }
 
+func TestStorage_GetStorageByBlockHash_historical(t *testing.T) {
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	value := make([]byte, 40)
+	for i := byte(0); i < 20; i++ {
+		ts.Put([]byte{i}, value)
+	}
+	key := []byte("testkey")
+	ts.Put(key, []byte("testvalue"))
+
+	root, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: testGenesisHeader.Hash(),
+			Number:     1,
+			StateRoot:  root,
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: *types.NewBody([]types.Extrinsic{}),
+	}
+	err = storage.blockState.AddBlock(block)
+	require.NoError(t, err)
+	blockHash := block.Header.Hash()
+
+	// the trie only exists on disk once evicted from memory.
+	storage.blockState.tries.delete(root)
+
+	res, err := storage.GetStorageByBlockHash(&blockHash, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testvalue"), res)
+	assert.Nil(t, storage.blockState.tries.get(root))
+	assert.NotNil(t, storage.historicalTries.get(root))
+
+	res, err = storage.GetStorageByBlockHash(&blockHash, []byte{1})
+	require.NoError(t, err)
+	assert.Equal(t, value, res)
+
+	// prune the trie by deleting its root node from the database.
+	batch := storage.db.NewBatch()
+	err = batch.Del(root.ToBytes())
+	require.NoError(t, err)
+	err = batch.Flush()
+	require.NoError(t, err)
+	storage.historicalTries.delete(root)
+
+	res, err = storage.GetStorageByBlockHash(&blockHash, key)
+	assert.ErrorIs(t, err, ErrStatePruned)
+	assert.Nil(t, res)
+	assert.Nil(t, storage.historicalTries.get(root))
+}
+
+func TestStorage_GetStorageByBlockHash_errors(t *testing.T) {
+	t.Parallel()
+
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	err = ts.Put([]byte("key"), []byte("value"))
+	require.NoError(t, err)
+	err = ts.SetChild([]byte("keyToChild"), trie.NewEmptyTrie())
+	require.NoError(t, err)
+	err = ts.SetChildStorage([]byte("keyToChild"), []byte("childKey"), []byte("childValue"))
+	require.NoError(t, err)
+
+	root, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	block := &types.Block{
+		Header: types.Header{
+			ParentHash: testGenesisHeader.Hash(),
+			Number:     1,
+			StateRoot:  root,
+			Digest:     createPrimaryBABEDigest(t),
+		},
+		Body: *types.NewBody([]types.Extrinsic{}),
+	}
+	err = storage.blockState.AddBlock(block)
+	require.NoError(t, err)
+	blockHash := block.Header.Hash()
+
+	// the historical lookups only load the nodes on the
+	// paths to the keys since the trie is not in memory.
+	storage.blockState.tries.delete(root)
+
+	value, err := storage.GetStorageByBlockHash(&blockHash, []byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	value, err = storage.GetStorageFromChildByBlockHash(&blockHash, []byte("keyToChild"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("childValue"), value)
+	assert.Nil(t, storage.blockState.tries.get(root))
+
+	_, err = storage.GetStorageByBlockHash(&blockHash, []byte("absent"))
+	assert.ErrorIs(t, err, ErrStorageKeyNotFound)
+
+	_, err = storage.GetStorageFromChildByBlockHash(&blockHash, []byte("keyToChild"), []byte("absent"))
+	assert.ErrorIs(t, err, ErrStorageKeyNotFound)
+
+	_, err = storage.GetStorageFromChildByBlockHash(&blockHash, []byte("absent"), []byte("childKey"))
+	assert.ErrorIs(t, err, ErrStorageKeyNotFound)
+
+	unknownHash := common.Hash{1}
+	_, err = storage.GetStorageByBlockHash(&unknownHash, []byte("key"))
+	assert.ErrorIs(t, err, ErrBlockUnknown)
+
+	_, err = storage.GetStorageFromChildByBlockHash(&unknownHash, []byte("keyToChild"), []byte("childKey"))
+	assert.ErrorIs(t, err, ErrBlockUnknown)
+
+	// prune the trie by deleting its root node from the database.
+	batch := storage.db.NewBatch()
+	err = batch.Del(root.ToBytes())
+	require.NoError(t, err)
+	err = batch.Flush()
+	require.NoError(t, err)
+	storage.historicalTries.delete(root)
+
+	_, err = storage.GetStorageByBlockHash(&blockHash, []byte("key"))
+	assert.ErrorIs(t, err, ErrStatePruned)
+
+	_, err = storage.GetStorageFromChildByBlockHash(&blockHash, []byte("keyToChild"), []byte("childKey"))
+	assert.ErrorIs(t, err, ErrStatePruned)
+}
+
+func Test_historicalTries(t *testing.T) {
+	t.Parallel()
+
+	tries := newHistoricalTries(2)
+	lazyTries := make([]*trie.LazyTrie, 3)
+	for i := range lazyTries {
+		lazyTries[i] = &trie.LazyTrie{}
+	}
+	roots := []common.Hash{{1}, {2}, {3}}
+
+	tries.set(roots[0], lazyTries[0])
+	tries.set(roots[1], lazyTries[1])
+
+	// touching the first root makes the second root the least recently used.
+	assert.Same(t, lazyTries[0], tries.get(roots[0]))
+
+	tries.set(roots[2], lazyTries[2])
+	assert.Same(t, lazyTries[0], tries.get(roots[0]))
+	assert.Nil(t, tries.get(roots[1]))
+	assert.Same(t, lazyTries[2], tries.get(roots[2]))
+	assert.Equal(t, []common.Hash{roots[0], roots[2]}, tries.roots)
+
+	tries.delete(roots[0])
+	assert.Nil(t, tries.get(roots[0]))
+	assert.Equal(t, []common.Hash{roots[2]}, tries.roots)
+}
+
 func TestStorage_TrieState(t *testing.T) {
 	storage := newTestStorageState(t)
 	ts, err := storage.TrieState(&trie.EmptyHash)
@@ -104,6 +269,73 @@ func TestStorage_TrieState(t *testing.T) {
 	require.Equal(t, ts.Trie().MustHash(), ts3.Trie().MustHash())
 }
 
+func TestStorage_TrieState_siblingBlocks(t *testing.T) {
+	storage := newTestStorageState(t)
+	key := []byte("key")
+
+	parent, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+	err = parent.Put(key, []byte("parent"))
+	require.NoError(t, err)
+	parentRoot := parent.MustRoot()
+	err = storage.StoreTrie(parent, nil)
+	require.NoError(t, err)
+
+	// execute two sibling blocks concurrently off the same parent state root.
+	siblingValues := [][]byte{[]byte("first"), []byte("second")}
+	siblingRoots := make([]common.Hash, len(siblingValues))
+	var wg sync.WaitGroup
+	for i, value := range siblingValues {
+		wg.Add(1)
+		go func(i int, value []byte) {
+			defer wg.Done()
+
+			ts, err := storage.TrieState(&parentRoot)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, []byte("parent"), ts.Get(key))
+
+			err = ts.Put(key, value)
+			assert.NoError(t, err)
+			err = ts.Put(value, value)
+			assert.NoError(t, err)
+			siblingRoots[i] = ts.MustRoot()
+
+			err = storage.StoreTrie(ts, nil)
+			assert.NoError(t, err)
+		}(i, value)
+	}
+	wg.Wait()
+	require.NotEqual(t, siblingRoots[0], siblingRoots[1])
+
+	assertState := func(t *testing.T) {
+		t.Helper()
+
+		ts, err := storage.TrieState(&parentRoot)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("parent"), ts.Get(key))
+		assert.Nil(t, ts.Get(siblingValues[0]))
+		assert.Nil(t, ts.Get(siblingValues[1]))
+
+		for i, value := range siblingValues {
+			ts, err := storage.TrieState(&siblingRoots[i])
+			require.NoError(t, err)
+			assert.Equal(t, value, ts.Get(key))
+			assert.Equal(t, value, ts.Get(value))
+			other := siblingValues[1-i]
+			assert.Nil(t, ts.Get(other))
+		}
+	}
+	assertState(t)
+
+	// evicting the tries from memory does not lose their state.
+	for _, root := range append(siblingRoots, parentRoot) {
+		storage.blockState.tries.delete(root)
+	}
+	assertState(t)
+}
+
 func TestStorage_LoadFromDB(t *testing.T) {
 	storage := newTestStorageState(t)
 	ts, err := storage.TrieState(&trie.EmptyHash)
@@ -164,6 +396,202 @@ func TestStorage_StoreTrie_NotSyncing(t *testing.T) {
 	require.Equal(t, 2, storage.blockState.tries.len())
 }
 
+func TestStorage_StoreTrie_HeadersOnly(t *testing.T) {
+	storage := newTestStorageState(t)
+	storage.blockState.headersOnly = true
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	ts.Put([]byte("testkey"), make([]byte, 64))
+	root := ts.MustRoot()
+	header := &types.Header{
+		ParentHash: testGenesisHeader.Hash(),
+		Number:     1,
+		StateRoot:  root,
+	}
+	err = storage.StoreTrie(ts, header)
+	require.NoError(t, err)
+
+	// The trie is kept in memory but not written to the database.
+	_, err = storage.TrieState(&root)
+	require.NoError(t, err)
+	_, err = storage.db.Get(root.ToBytes())
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+	_, err = storage.GetDeletedNodeHashes(header.Hash())
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+}
+
+func TestStorage_StoreTrie_DeletedNodeHashes(t *testing.T) {
+	tries := newTriesEmpty()
+	bs := newTestBlockState(t, tries)
+	prunerConfig := pruner.Config{Mode: pruner.Pruned, RetainedBlocks: 1}
+	storage, err := NewStorageState(NewInMemoryDB(t), bs, tries, 0, prunerConfig)
+	require.NoError(t, err)
+
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	value := make([]byte, 32)
+	for _, key := range []string{"key1", "key2", "key3"} {
+		ts.Put([]byte(key), value)
+	}
+	root, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	ts, err = storage.TrieState(&root)
+	require.NoError(t, err)
+	ts.Put([]byte("key1"), []byte("new value"))
+	expectedDeleted := ts.DeletedNodeHashes()
+	require.NotEmpty(t, expectedDeleted)
+
+	header := &types.Header{
+		ParentHash: testGenesisHeader.Hash(),
+		Number:     1,
+		StateRoot:  ts.MustRoot(),
+	}
+	err = storage.StoreTrie(ts, header)
+	require.NoError(t, err)
+
+	deleted, err := storage.GetDeletedNodeHashes(header.Hash())
+	require.NoError(t, err)
+	require.Equal(t, expectedDeleted, deleted)
+
+	err = storage.DeleteDeletedNodeHashes(header.Hash())
+	require.NoError(t, err)
+	_, err = storage.GetDeletedNodeHashes(header.Hash())
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+}
+
+func TestStorage_StoreTrie_DeletedNodeHashes_archive(t *testing.T) {
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	value := make([]byte, 32)
+	for _, key := range []string{"key1", "key2", "key3"} {
+		ts.Put([]byte(key), value)
+	}
+	root, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	ts, err = storage.TrieState(&root)
+	require.NoError(t, err)
+	ts.Put([]byte("key1"), []byte("new value"))
+	require.NotEmpty(t, ts.DeletedNodeHashes())
+
+	header := &types.Header{
+		ParentHash: testGenesisHeader.Hash(),
+		Number:     1,
+		StateRoot:  ts.MustRoot(),
+	}
+	err = storage.StoreTrie(ts, header)
+	require.NoError(t, err)
+
+	// The deleted node hashes are not recorded since nodes are never pruned.
+	_, err = storage.GetDeletedNodeHashes(header.Hash())
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+}
+
+func TestStorage_GetKeysPaged(t *testing.T) {
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	const numberOfKeys = 25
+	expectedKeys := make([][]byte, numberOfKeys)
+	for i := range expectedKeys {
+		expectedKeys[i] = []byte(fmt.Sprintf("prefix%02d", i))
+		ts.Put(expectedKeys[i], []byte{byte(i)})
+	}
+	ts.Put([]byte("other"), []byte{1})
+	root, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	const pageSize = 10
+	var keys [][]byte
+	var startAfter []byte
+	for {
+		page, err := storage.GetKeysPaged(&root, []byte("prefix"), startAfter, pageSize)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), pageSize)
+		if len(page) == 0 {
+			break
+		}
+		keys = append(keys, page...)
+		startAfter = page[len(page)-1]
+	}
+	require.Equal(t, expectedKeys, keys)
+
+	keys, err = storage.GetKeysPaged(&root, []byte("prefix"), []byte("zzz"), pageSize)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestStorage_GetRuntimeCode(t *testing.T) {
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	_, err = storage.GetRuntimeCode(&trie.EmptyHash)
+	require.ErrorIs(t, err, ErrRuntimeCodeNotFound)
+
+	oldCode := []byte("old runtime code")
+	ts.Put(common.CodeKey, oldCode)
+	oldRoot, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	// Runtime upgrade setting the new code and the number of heap pages.
+	ts, err = storage.TrieState(&oldRoot)
+	require.NoError(t, err)
+	newCode := []byte("new runtime code")
+	ts.Put(common.CodeKey, newCode)
+	ts.Put(common.HeapPagesKey, []byte{8, 0, 0, 0, 0, 0, 0, 0})
+	newRoot, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	runtimeCode, err := storage.GetRuntimeCode(&oldRoot)
+	require.NoError(t, err)
+	require.Equal(t, RuntimeCode{
+		Code:      oldCode,
+		CodeHash:  common.MustBlake2bHash(oldCode),
+		HeapPages: DefaultHeapPages,
+	}, runtimeCode)
+
+	// The runtime code is read from the trie in the database
+	// once the trie is no longer in memory.
+	storage.tries.delete(newRoot)
+	runtimeCode, err = storage.GetRuntimeCode(&newRoot)
+	require.NoError(t, err)
+	require.Equal(t, RuntimeCode{
+		Code:      newCode,
+		CodeHash:  common.MustBlake2bHash(newCode),
+		HeapPages: 8,
+	}, runtimeCode)
+
+	ts, err = storage.TrieState(&newRoot)
+	require.NoError(t, err)
+	ts.Put(common.HeapPagesKey, []byte{8})
+	invalidRoot, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+	_, err = storage.GetRuntimeCode(&invalidRoot)
+	require.ErrorIs(t, err, errHeapPagesEncodingLength)
+	require.ErrorIs(t, err, ErrWellKnownValueMalformed)
+	require.EqualError(t, err, "malformed value at well-known key :heappages: "+
+		"heap pages encoding length is invalid: 1 bytes instead of 8 bytes")
+}
+
 func TestGetStorageChildAndGetStorageFromChild(t *testing.T) {
 	// initialise database using data directory
 	basepath := t.TempDir()
@@ -196,7 +624,7 @@ func TestGetStorageChildAndGetStorageFromChild(t *testing.T) {
 	blockState, err := NewBlockStateFromGenesis(db, tries, &genHeader, telemetryMock)
 	require.NoError(t, err)
 
-	storage, err := NewStorageState(db, blockState, tries)
+	storage, err := NewStorageState(db, blockState, tries, 0, pruner.Config{})
 	require.NoError(t, err)
 
 	trieState := runtime.NewTrieState(&genTrie)
@@ -0,0 +1,76 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// MaxSubChainLength is the maximum number of block hashes returned by SubChain.
+const MaxSubChainLength = 1 << 16
+
+var (
+	// ErrNotDescendant is returned by SubChain when the end
+	// block is not a descendant of the start block.
+	ErrNotDescendant = errors.New("end block is not a descendant of start block")
+	// ErrSubChainTooLong is returned by SubChain when the sub chain
+	// requested is longer than MaxSubChainLength.
+	ErrSubChainTooLong = errors.New("sub chain is too long")
+)
+
+// SubChain returns the block hashes from the start block hash to the end block
+// hash included, ordered from the oldest block to the newest block. It walks
+// back the parent hashes of the headers given by the header getter from the end
+// header to the start header, so the range can include both finalised and
+// unfinalised blocks if the header getter is the block state.
+// It returns an error wrapping ErrNotDescendant if the end block is not a
+// descendant of the start block, and an error wrapping ErrSubChainTooLong if
+// the sub chain has more than MaxSubChainLength blocks.
+func SubChain(headerGetter HeaderGetter, start, end common.Hash) (hashes []common.Hash, err error) {
+	startHeader, err := headerGetter.GetHeader(start)
+	if err != nil {
+		return nil, fmt.Errorf("getting start header: %w", err)
+	}
+
+	header, err := headerGetter.GetHeader(end)
+	if err != nil {
+		return nil, fmt.Errorf("getting end header: %w", err)
+	}
+
+	if header.Number < startHeader.Number {
+		return nil, fmt.Errorf("%w: end block number %d is lower than start block number %d",
+			ErrNotDescendant, header.Number, startHeader.Number)
+	}
+
+	length := header.Number - startHeader.Number + 1
+	if length > MaxSubChainLength {
+		return nil, fmt.Errorf("%w: %d blocks exceed the maximum of %d blocks",
+			ErrSubChainTooLong, length, MaxSubChainLength)
+	}
+
+	hashes = make([]common.Hash, length)
+	hash := end
+	for i := int(length) - 1; ; i-- {
+		hashes[i] = hash
+		if i == 0 {
+			break
+		}
+
+		hash = header.ParentHash
+		header, err = headerGetter.GetHeader(hash)
+		if err != nil {
+			return nil, fmt.Errorf("getting header for block hash %s: %w", hash, err)
+		}
+	}
+
+	if hash != start {
+		return nil, fmt.Errorf("%w: block %s is the ancestor of block %s at number %d",
+			ErrNotDescendant, hash, end, startHeader.Number)
+	}
+
+	return hashes, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type headerMap map[common.Hash]*types.Header
+
+func (m headerMap) GetHeader(hash common.Hash) (header *types.Header, err error) {
+	header, ok := m[hash]
+	if !ok {
+		return nil, errors.New("header not found")
+	}
+	return header, nil
+}
+
+func Test_SubChain(t *testing.T) {
+	t.Parallel()
+
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisHeader, err := bs.GetHeader(bs.GenesisHash())
+	require.NoError(t, err)
+	arrivalTime := time.Unix(1, 0)
+
+	// Blocks 1 and 2 are finalised, chain A is the best chain,
+	// and chain B forks from chain A at block 3.
+	finalised := addTestChain(t, bs, genesisHeader, 2, common.Hash{0xa}, arrivalTime)
+	err = bs.SetFinalisedHash(finalised[1].Hash(), 1, 1)
+	require.NoError(t, err)
+	chainA := addTestChain(t, bs, finalised[1], 3, common.Hash{0xb}, arrivalTime)
+	chainB := addTestChain(t, bs, chainA[0], 2, common.Hash{0xc}, arrivalTime.Add(time.Second))
+
+	testCases := map[string]struct {
+		start, end common.Hash
+		hashes     []common.Hash
+		errWrapped error
+		errMessage string
+	}{
+		"start_equals_end": {
+			start:  chainA[1].Hash(),
+			end:    chainA[1].Hash(),
+			hashes: []common.Hash{chainA[1].Hash()},
+		},
+		"crossing_finalised_boundary": {
+			start: genesisHeader.Hash(),
+			end:   chainA[2].Hash(),
+			hashes: append(
+				[]common.Hash{genesisHeader.Hash()},
+				headersToHashes(append(finalised, chainA...))...),
+		},
+		"end_on_fork": {
+			start:  finalised[1].Hash(),
+			end:    chainB[1].Hash(),
+			hashes: headersToHashes([]*types.Header{finalised[1], chainA[0], chainB[0], chainB[1]}),
+		},
+		"start_and_end_on_different_branches": {
+			start:      chainA[1].Hash(),
+			end:        chainB[1].Hash(),
+			errWrapped: ErrNotDescendant,
+			errMessage: "end block is not a descendant of start block: block " +
+				chainB[0].Hash().String() + " is the ancestor of block " +
+				chainB[1].Hash().String() + " at number 4",
+		},
+		"end_lower_than_start": {
+			start:      chainA[2].Hash(),
+			end:        finalised[0].Hash(),
+			errWrapped: ErrNotDescendant,
+			errMessage: "end block is not a descendant of start block: " +
+				"end block number 1 is lower than start block number 5",
+		},
+		"start_not_found": {
+			start:      common.Hash{1},
+			end:        chainA[2].Hash(),
+			errWrapped: chaindb.ErrKeyNotFound,
+			errMessage: "getting start header: Key not found",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hashes, err := SubChain(bs, testCase.start, testCase.end)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.hashes, hashes)
+		})
+	}
+}
+
+func Test_SubChain_tooLong(t *testing.T) {
+	t.Parallel()
+
+	start := &types.Header{Digest: types.NewDigest()}
+	end := &types.Header{
+		ParentHash: common.Hash{1},
+		Number:     MaxSubChainLength,
+		Digest:     types.NewDigest(),
+	}
+	headers := headerMap{
+		start.Hash(): start,
+		end.Hash():   end,
+	}
+
+	hashes, err := SubChain(headers, start.Hash(), end.Hash())
+	assert.ErrorIs(t, err, ErrSubChainTooLong)
+	assert.EqualError(t, err, "sub chain is too long: 65537 blocks exceed the maximum of 65536 blocks")
+	assert.Nil(t, hashes)
+}
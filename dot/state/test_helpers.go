@@ -10,8 +10,8 @@
 	"testing"
 	"time"
 
-	"github.com/ChainSafe/chaindb"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/database"
 	"github.com/ChainSafe/gossamer/lib/common"
 	runtime "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/trie"
@@ -24,7 +24,7 @@
 var inc, _ = time.ParseDuration("1s")
 
 // NewInMemoryDB creates a new in-memory database
-func NewInMemoryDB(t *testing.T) *chaindb.BadgerDB {
+func NewInMemoryDB(t *testing.T) *database.BadgerDB {
 	testDatadirPath := t.TempDir()
 
 	db, err := utils.SetupDatabase(testDatadirPath, true)
@@ -53,6 +53,16 @@ func createPrimaryBABEDigest(t testing.TB) scale.VaryingDataTypeSlice {
 	return digest
 }
 
+func createSecondaryBABEDigest(t testing.TB) scale.VaryingDataTypeSlice {
+	preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, 1).ToPreRuntimeDigest()
+	require.NoError(t, err)
+
+	digest := types.NewDigest()
+	err = digest.Add(*preRuntimeDigest)
+	require.NoError(t, err)
+	return digest
+}
+
 // branch tree randomly
 type testBranch struct {
 	hash  common.Hash
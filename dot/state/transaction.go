@@ -4,6 +4,7 @@
 package state
 
 import (
+	"bytes"
 	"sync"
 	"time"
 
@@ -64,6 +65,12 @@ func (s *TransactionState) Pending() []*transaction.ValidTransaction {
 	return append(s.queue.Pending(), s.pool.Transactions()...)
 }
 
+// PendingInQueue returns the current transactions in the queue,
+// which are the transactions ready to be included in a block.
+func (s *TransactionState) PendingInQueue() []*transaction.ValidTransaction {
+	return s.queue.Pending()
+}
+
 // PendingInPool returns the current transactions in the pool
 func (s *TransactionState) PendingInPool() []*transaction.ValidTransaction {
 	return s.pool.Transactions()
@@ -81,6 +88,65 @@ func (s *TransactionState) RemoveExtrinsic(ext types.Extrinsic) {
 	s.queue.RemoveExtrinsic(ext)
 }
 
+// RemoveInvalidExtrinsic removes an extrinsic from the queue and pool,
+// and notifies the watchers of the extrinsic it is invalid.
+func (s *TransactionState) RemoveInvalidExtrinsic(ext types.Extrinsic) {
+	s.RemoveExtrinsic(ext)
+	s.notifyStatus(ext, transaction.Invalid)
+}
+
+// RemoveExtrinsics removes the transactions with the given extrinsic hashes from
+// the queue and pool, as well as the transactions depending on them, that is
+// requiring a tag provided by a transaction removed. The watchers of the
+// transactions removed are notified they are invalid. It returns the hashes
+// of the transactions removed, the given hashes of transactions not in the
+// queue or pool being ignored.
+func (s *TransactionState) RemoveExtrinsics(hashes []common.Hash) (removed []common.Hash) {
+	pending := make(map[common.Hash]*transaction.ValidTransaction)
+	for _, tx := range s.Pending() {
+		pending[tx.Extrinsic.Hash()] = tx
+	}
+
+	toRemove := append([]common.Hash{}, hashes...)
+	for len(toRemove) > 0 {
+		hash := toRemove[0]
+		toRemove = toRemove[1:]
+
+		tx, ok := pending[hash]
+		if !ok {
+			continue
+		}
+		delete(pending, hash)
+		s.RemoveInvalidExtrinsic(tx.Extrinsic)
+		removed = append(removed, hash)
+
+		for dependentHash, dependent := range pending {
+			if requiresTagProvided(dependent, tx) {
+				toRemove = append(toRemove, dependentHash)
+			}
+		}
+	}
+
+	return removed
+}
+
+// requiresTagProvided returns true if the transaction
+// requires a tag provided by the other transaction.
+func requiresTagProvided(tx, other *transaction.ValidTransaction) bool {
+	if tx.Validity == nil || other.Validity == nil {
+		return false
+	}
+
+	for _, required := range tx.Validity.Requires {
+		for _, provided := range other.Validity.Provides {
+			if bytes.Equal(required, provided) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RemoveExtrinsicFromPool removes an extrinsic from the pool
 func (s *TransactionState) RemoveExtrinsicFromPool(ext types.Extrinsic) {
 	s.pool.Remove(ext.Hash())
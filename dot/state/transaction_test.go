@@ -71,6 +71,56 @@ func TestTransactionState_Pending(t *testing.T) {
 	require.Nil(t, head)
 }
 
+func TestTransactionState_RemoveExtrinsics(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(gomock.Any()).AnyTimes()
+
+	ts := NewTransactionState(telemetryMock)
+
+	ready := &transaction.ValidTransaction{
+		Extrinsic: []byte("ready"),
+		Validity:  &transaction.Validity{Provides: [][]byte{{1}}},
+	}
+	independent := &transaction.ValidTransaction{
+		Extrinsic: []byte("independent"),
+		Validity:  &transaction.Validity{Provides: [][]byte{{2}}},
+	}
+	dependent := &transaction.ValidTransaction{
+		Extrinsic: []byte("dependent"),
+		Validity:  &transaction.Validity{Requires: [][]byte{{1}}, Provides: [][]byte{{3}}},
+	}
+	transitivelyDependent := &transaction.ValidTransaction{
+		Extrinsic: []byte("transitively dependent"),
+		Validity:  &transaction.Validity{Requires: [][]byte{{3}}},
+	}
+
+	for _, tx := range []*transaction.ValidTransaction{ready, independent} {
+		_, err := ts.Push(tx)
+		require.NoError(t, err)
+	}
+	ts.AddToPool(dependent)
+	ts.AddToPool(transitivelyDependent)
+
+	require.ElementsMatch(t, []*transaction.ValidTransaction{ready, independent}, ts.PendingInQueue())
+
+	statusCh := ts.GetStatusNotifierChannel(dependent.Extrinsic)
+	defer ts.FreeStatusNotifierChannel(statusCh)
+
+	removed := ts.RemoveExtrinsics([]common.Hash{ready.Extrinsic.Hash(), {0xff}})
+
+	expectedRemoved := []common.Hash{
+		ready.Extrinsic.Hash(), dependent.Extrinsic.Hash(), transitivelyDependent.Extrinsic.Hash(),
+	}
+	require.Equal(t, expectedRemoved, removed)
+	require.Equal(t, []*transaction.ValidTransaction{independent}, ts.Pending())
+	require.Equal(t, transaction.Invalid, <-statusCh)
+
+	removed = ts.RemoveExtrinsics([]common.Hash{ready.Extrinsic.Hash()})
+	require.Empty(t, removed)
+}
+
 func TestTransactionState_NotifierChannels(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	telemetryMock := NewMockTelemetry(ctrl)
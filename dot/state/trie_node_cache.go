@@ -0,0 +1,244 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// trieNodeCacheEntryOverhead is the approximate size in bytes of a cache
+// entry in addition to the node encoding size, accounting for the decoded
+// node and its children, the linked list element and the map entry.
+const trieNodeCacheEntryOverhead = 512
+
+var (
+	trieNodeCacheHitsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_trie_node_cache",
+		Name:      "hits_total",
+		Help:      "total number of trie nodes found in the trie node cache",
+	})
+	trieNodeCacheMissesCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_trie_node_cache",
+		Name:      "misses_total",
+		Help:      "total number of trie nodes not found in the trie node cache",
+	})
+	trieNodeCacheEvictionsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_state_trie_node_cache",
+		Name:      "evictions_total",
+		Help:      "total number of trie nodes evicted from the trie node cache",
+	})
+	trieNodeCacheSizeGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gossamer_state_trie_node_cache",
+		Name:      "size_bytes",
+		Help:      "approximate size in bytes of the trie nodes cached",
+	})
+)
+
+// trieNodeCache is a least recently used cache of decoded trie nodes,
+// keyed by their node hash and bounded by their approximate size in bytes.
+// Since nodes are content addressed, the cache is shared by all the tries
+// and its entries never need to be invalidated, only removed once their
+// node is deleted from the database.
+// It is safe for concurrent use.
+type trieNodeCache struct {
+	mutex   sync.Mutex
+	maxSize uint64
+	size    uint64
+	// elements maps a node hash to its element in the linked list.
+	elements map[common.Hash]*list.Element
+	// linkedList contains trieNodeCacheEntry values ordered from the
+	// most recently used at the front to the least recently used at the back.
+	linkedList *list.List
+
+	hitsCounter      prometheus.Counter
+	missesCounter    prometheus.Counter
+	evictionsCounter prometheus.Counter
+	sizeGauge        prometheus.Gauge
+}
+
+type trieNodeCacheEntry struct {
+	nodeHash common.Hash
+	node     *trie.Node
+	size     uint64
+}
+
+// newTrieNodeCache creates a trie node cache holding up
+// to approximately maxSize bytes of decoded trie nodes.
+func newTrieNodeCache(maxSize uint64) *trieNodeCache {
+	return &trieNodeCache{
+		maxSize:          maxSize,
+		elements:         make(map[common.Hash]*list.Element),
+		linkedList:       list.New(),
+		hitsCounter:      trieNodeCacheHitsCounter,
+		missesCounter:    trieNodeCacheMissesCounter,
+		evictionsCounter: trieNodeCacheEvictionsCounter,
+		sizeGauge:        trieNodeCacheSizeGauge,
+	}
+}
+
+// get returns a deep copy of the node cached for the given node hash,
+// such that it can be modified by the caller, and true if it is found.
+func (c *trieNodeCache) get(nodeHash common.Hash) (n *trie.Node, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.elements[nodeHash]
+	if !ok {
+		c.missesCounter.Inc()
+		return nil, false
+	}
+
+	c.hitsCounter.Inc()
+	c.linkedList.MoveToFront(element)
+	entry := element.Value.(trieNodeCacheEntry)
+	return entry.node.Copy(node.DeepCopySettings), true
+}
+
+// put caches the node given for the node hash given, evicting the least
+// recently used nodes until the cache size is below its maximum size.
+// The node given must not be modified after this call.
+// A node larger than the maximum size of the cache is not cached.
+func (c *trieNodeCache) put(nodeHash common.Hash, n *trie.Node, encodingSize int) {
+	size := uint64(encodingSize) + trieNodeCacheEntryOverhead
+	if size > c.maxSize {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.elements[nodeHash]
+	if ok {
+		c.linkedList.MoveToFront(element)
+		return
+	}
+
+	for c.size+size > c.maxSize {
+		c.removeElement(c.linkedList.Back())
+		c.evictionsCounter.Inc()
+	}
+
+	entry := trieNodeCacheEntry{
+		nodeHash: nodeHash,
+		node:     n,
+		size:     size,
+	}
+	c.elements[nodeHash] = c.linkedList.PushFront(entry)
+	c.size += size
+	c.sizeGauge.Set(float64(c.size))
+}
+
+// delete removes the nodes with the given node hashes from the cache.
+func (c *trieNodeCache) delete(nodeHashes []common.Hash) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, nodeHash := range nodeHashes {
+		element, ok := c.elements[nodeHash]
+		if !ok {
+			continue
+		}
+		c.removeElement(element)
+	}
+	c.sizeGauge.Set(float64(c.size))
+}
+
+func (c *trieNodeCache) removeElement(element *list.Element) {
+	entry := c.linkedList.Remove(element).(trieNodeCacheEntry)
+	delete(c.elements, entry.nodeHash)
+	c.size -= entry.size
+}
+
+// trieNodeCacheDatabase is a storage database getting the decoded trie
+// nodes from the trie node cache, and removing the nodes deleted from
+// the database using its batches from the cache.
+type trieNodeCacheDatabase struct {
+	GetNewBatcher
+	cache *trieNodeCache
+}
+
+func newTrieNodeCacheDatabase(db GetNewBatcher, cache *trieNodeCache) *trieNodeCacheDatabase {
+	return &trieNodeCacheDatabase{
+		GetNewBatcher: db,
+		cache:         cache,
+	}
+}
+
+// GetNode returns the decoded node for the given node hash, from the
+// cache if it is cached, or from the database otherwise in which case
+// it is added to the cache. It implements the trie.NodeGetter interface.
+func (d *trieNodeCacheDatabase) GetNode(nodeHash []byte) (n *trie.Node, err error) {
+	hash := common.NewHash(nodeHash)
+	n, ok := d.cache.get(hash)
+	if ok {
+		return n, nil
+	}
+
+	encodedNode, err := d.Get(nodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting node from database: %w", err)
+	}
+
+	n, err = node.Decode(bytes.NewReader(encodedNode))
+	if err != nil {
+		return nil, fmt.Errorf("decoding node: %w", err)
+	}
+	n.MerkleValue = nodeHash
+
+	d.cache.put(hash, n, len(encodedNode))
+	return n.Copy(node.DeepCopySettings), nil
+}
+
+// NewBatch returns a database batch removing the keys
+// deleted from the trie node cache once it is flushed.
+func (d *trieNodeCacheDatabase) NewBatch() chaindb.Batch {
+	return &trieNodeCacheBatch{
+		Batch: d.GetNewBatcher.NewBatch(),
+		cache: d.cache,
+	}
+}
+
+type trieNodeCacheBatch struct {
+	chaindb.Batch
+	cache   *trieNodeCache
+	deleted []common.Hash
+}
+
+func (b *trieNodeCacheBatch) Del(key []byte) error {
+	err := b.Batch.Del(key)
+	if err != nil {
+		return err
+	}
+
+	const hashLength = 32
+	if len(key) == hashLength {
+		b.deleted = append(b.deleted, common.NewHash(key))
+	}
+	return nil
+}
+
+// Flush writes the batch to the database and removes the nodes deleted
+// from the cache. Nodes are removed after writing the batch such that they
+// cannot be read again from the database once removed from the cache.
+func (b *trieNodeCacheBatch) Flush() error {
+	err := b.Batch.Flush()
+	b.cache.delete(b.deleted)
+	b.deleted = nil
+	return err
+}
+
+func (b *trieNodeCacheBatch) Reset() {
+	b.Batch.Reset()
+	b.deleted = nil
+}
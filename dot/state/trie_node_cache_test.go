@@ -0,0 +1,177 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTrieNodeCache creates a trie node cache with
+// metrics not shared with other trie node caches.
+func newTestTrieNodeCache(maxSize uint64) *trieNodeCache {
+	cache := newTrieNodeCache(maxSize)
+	cache.hitsCounter = prometheus.NewCounter(prometheus.CounterOpts{})
+	cache.missesCounter = prometheus.NewCounter(prometheus.CounterOpts{})
+	cache.evictionsCounter = prometheus.NewCounter(prometheus.CounterOpts{})
+	cache.sizeGauge = prometheus.NewGauge(prometheus.GaugeOpts{})
+	return cache
+}
+
+// newTestStorageTrie writes a trie with the given number of
+// entries to a new storage table and returns the table and
+// the trie root hash.
+func newTestStorageTrie(t testing.TB, entries int) (
+	db chaindb.Database, rootHash common.Hash) {
+	t.Helper()
+
+	tr := trie.NewEmptyTrie()
+	for i := 0; i < entries; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		value := []byte(fmt.Sprintf("some value long enough not to be inlined %d", i))
+		tr.Put(key, value)
+	}
+
+	inMemoryDB, err := chaindb.NewBadgerDB(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = inMemoryDB.Close()
+	})
+
+	db = chaindb.NewTable(inMemoryDB, storagePrefix)
+	err = tr.WriteDirty(db)
+	require.NoError(t, err)
+
+	return db, tr.MustHash()
+}
+
+func Test_trieNodeCache_eviction(t *testing.T) {
+	t.Parallel()
+
+	const encodingSize = 88
+	const entrySize = encodingSize + trieNodeCacheEntryOverhead
+	// The byte budget only fits two nodes.
+	cache := newTestTrieNodeCache(2*entrySize + entrySize/2)
+
+	hashes := []common.Hash{{1}, {2}, {3}}
+	nodes := []*trie.Node{
+		{PartialKey: []byte{1}, StorageValue: []byte{1}},
+		{PartialKey: []byte{2}, StorageValue: []byte{2}},
+		{PartialKey: []byte{3}, StorageValue: []byte{3}},
+	}
+
+	cache.put(hashes[0], nodes[0], encodingSize)
+	cache.put(hashes[1], nodes[1], encodingSize)
+	// Use the first node such that the second node
+	// is the least recently used node.
+	_, ok := cache.get(hashes[0])
+	require.True(t, ok)
+
+	cache.put(hashes[2], nodes[2], encodingSize)
+
+	assert.Equal(t, uint64(2*entrySize), cache.size)
+	assert.Len(t, cache.elements, 2)
+	assert.Equal(t, 2, cache.linkedList.Len())
+	_, ok = cache.get(hashes[1])
+	assert.False(t, ok)
+	for _, i := range []int{0, 2} {
+		n, ok := cache.get(hashes[i])
+		require.True(t, ok)
+		assert.Equal(t, nodes[i], n)
+		assert.NotSame(t, nodes[i], n)
+	}
+	assert.Equal(t, float64(1), testutil.ToFloat64(cache.evictionsCounter))
+	assert.Equal(t, float64(3), testutil.ToFloat64(cache.hitsCounter))
+	assert.Equal(t, float64(1), testutil.ToFloat64(cache.missesCounter))
+	assert.Equal(t, float64(2*entrySize), testutil.ToFloat64(cache.sizeGauge))
+
+	// A node larger than the cache is not cached.
+	cache.put(common.Hash{4}, nodes[0], int(cache.maxSize))
+	assert.Len(t, cache.elements, 2)
+
+	cache.delete([]common.Hash{hashes[0], {5}})
+	assert.Equal(t, uint64(entrySize), cache.size)
+	_, ok = cache.get(hashes[0])
+	assert.False(t, ok)
+}
+
+func Test_trieNodeCacheDatabase(t *testing.T) {
+	t.Parallel()
+
+	table, rootHash := newTestStorageTrie(t, 100)
+	cache := newTestTrieNodeCache(1 << 20)
+	db := newTrieNodeCacheDatabase(table, cache)
+
+	expectedTrie := trie.NewEmptyTrie()
+	err := expectedTrie.Load(table, rootHash)
+	require.NoError(t, err)
+
+	loadedTrie := trie.NewEmptyTrie()
+	err = loadedTrie.Load(db, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, expectedTrie.Entries(), loadedTrie.Entries())
+	assert.Zero(t, testutil.ToFloat64(cache.hitsCounter))
+	nodesCached := len(cache.elements)
+	assert.Equal(t, float64(nodesCached), testutil.ToFloat64(cache.missesCounter))
+
+	// Modifying the loaded trie does not modify the nodes cached.
+	loadedTrie.Put([]byte("key-1"), []byte("modified value"))
+
+	value, err := trie.GetFromDB(db, rootHash, []byte("key-1"))
+	require.NoError(t, err)
+	assert.Equal(t, expectedTrie.Get([]byte("key-1")), value)
+
+	reloadedTrie := trie.NewEmptyTrie()
+	err = reloadedTrie.Load(db, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, expectedTrie.Entries(), reloadedTrie.Entries())
+	assert.Equal(t, float64(nodesCached), testutil.ToFloat64(cache.missesCounter))
+	assert.Greater(t, testutil.ToFloat64(cache.hitsCounter), float64(nodesCached))
+
+	// Nodes deleted from the database are removed from the cache.
+	batch := db.NewBatch()
+	err = batch.Del(rootHash.ToBytes())
+	require.NoError(t, err)
+	err = batch.Flush()
+	require.NoError(t, err)
+
+	assert.Len(t, cache.elements, nodesCached-1)
+	err = trie.NewEmptyTrie().Load(db, rootHash)
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+}
+
+func Benchmark_TrieNodeCache_Load(b *testing.B) {
+	table, rootHash := newTestStorageTrie(b, 10000)
+
+	b.Run("without_cache", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := trie.NewEmptyTrie().Load(table, rootHash)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("with_cache", func(b *testing.B) {
+		db := newTrieNodeCacheDatabase(table, newTestTrieNodeCache(64<<20))
+		// Warm up the cache.
+		err := trie.NewEmptyTrie().Load(db, rootHash)
+		require.NoError(b, err)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			err := trie.NewEmptyTrie().Load(db, rootHash)
+			require.NoError(b, err)
+		}
+	})
+}
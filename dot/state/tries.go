@@ -30,10 +30,18 @@
 	})
 )
 
+// maxLoadedTries is the maximum number of tries loaded from the
+// database kept in memory, after which the oldest one is evicted.
+const maxLoadedTries = 8
+
 // Tries is a thread safe map of root hash
 // to trie.
 type Tries struct {
-	rootToTrie    map[common.Hash]*trie.Trie
+	rootToTrie map[common.Hash]*trie.Trie
+	// loadedRoots are the roots of the tries loaded from the database,
+	// in loading order. Only these tries are evicted from memory, since
+	// the tries set otherwise may not be fully written to the database.
+	loadedRoots   []common.Hash
 	mapMutex      sync.RWMutex
 	triesGauge    prometheus.Gauge
 	setCounter    prometheus.Counter
@@ -71,6 +79,8 @@ func (t *Tries) softSet(root common.Hash, trie *trie.Trie) {
 
 	_, has := t.rootToTrie[root]
 	if has {
+		// a loaded trie set again is no longer evicted.
+		t.removeLoadedRoot(root)
 		return
 	}
 
@@ -79,10 +89,49 @@ func (t *Tries) softSet(root common.Hash, trie *trie.Trie) {
 	t.rootToTrie[root] = trie
 }
 
+// setLoaded sets the given trie loaded from the database at the given
+// root hash in the memory map only if it is not already set. Since the
+// trie is in the database, it is evicted from memory once more than
+// maxLoadedTries tries loaded from the database are set after it.
+func (t *Tries) setLoaded(root common.Hash, trie *trie.Trie) {
+	t.mapMutex.Lock()
+	defer t.mapMutex.Unlock()
+
+	_, has := t.rootToTrie[root]
+	if has {
+		return
+	}
+
+	t.triesGauge.Inc()
+	t.setCounter.Inc()
+	t.rootToTrie[root] = trie
+	t.loadedRoots = append(t.loadedRoots, root)
+
+	if len(t.loadedRoots) > maxLoadedTries {
+		evicted := t.loadedRoots[0]
+		t.loadedRoots = t.loadedRoots[1:]
+		delete(t.rootToTrie, evicted)
+		t.triesGauge.Set(float64(len(t.rootToTrie)))
+		t.deleteCounter.Inc()
+	}
+}
+
+// removeLoadedRoot removes the root given from the loaded roots,
+// and must be called with the map mutex locked.
+func (t *Tries) removeLoadedRoot(root common.Hash) {
+	for i, loadedRoot := range t.loadedRoots {
+		if loadedRoot == root {
+			t.loadedRoots = append(t.loadedRoots[:i], t.loadedRoots[i+1:]...)
+			return
+		}
+	}
+}
+
 func (t *Tries) delete(root common.Hash) {
 	t.mapMutex.Lock()
 	defer t.mapMutex.Unlock()
 	delete(t.rootToTrie, root)
+	t.removeLoadedRoot(root)
 	// Note we use .Set instead of .Dec in case nothing
 	// was deleted since nothing existed at the hash given.
 	t.triesGauge.Set(float64(len(t.rootToTrie)))
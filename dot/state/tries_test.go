@@ -126,6 +126,37 @@ func Test_Tries_softSet(t *testing.T) {
 	}
 }
 
+func Test_Tries_setLoaded(t *testing.T) {
+	t.Parallel()
+
+	tries := NewTries()
+	stored := trie.NewEmptyTrie()
+	tries.softSet(common.Hash{0xff}, stored)
+
+	for i := 0; i <= maxLoadedTries; i++ {
+		tries.setLoaded(common.Hash{byte(i)}, trie.NewEmptyTrie())
+	}
+
+	// the oldest loaded trie is evicted, but not the stored trie.
+	assert.Nil(t, tries.get(common.Hash{0}))
+	assert.Same(t, stored, tries.get(common.Hash{0xff}))
+	assert.Equal(t, maxLoadedTries+1, tries.len())
+
+	// setting a stored trie over a loaded trie prevents its eviction.
+	tries.softSet(common.Hash{1}, trie.NewEmptyTrie())
+	tries.setLoaded(common.Hash{0xaa}, trie.NewEmptyTrie())
+	assert.NotNil(t, tries.get(common.Hash{1}))
+	assert.Nil(t, tries.get(common.Hash{2}))
+
+	// a deleted loaded trie is no longer counted as loaded.
+	tries.delete(common.Hash{3})
+	assert.Len(t, tries.loadedRoots, maxLoadedTries-1)
+
+	// setting an already set trie as loaded does not make it evictable.
+	tries.setLoaded(common.Hash{0xff}, trie.NewEmptyTrie())
+	assert.Len(t, tries.loadedRoots, maxLoadedTries-1)
+}
+
 func Test_Tries_delete(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,138 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+var errWarpSyncTargetNotAbove = errors.New("warp sync target is not above highest finalised block")
+
+// ImportWarpSyncTarget stores the header given as the highest finalised block, with
+// its justification finalising it in the round and by the set ID given, and replaces
+// the block tree with a block tree rooted at this header. The blocks between the
+// previous highest finalised block and the header are skipped by warp sync, so they
+// are not stored. The runtime instance of the previous highest finalised block is
+// used for the header until the runtime changes of the state of the header are handled.
+func (bs *BlockState) ImportWarpSyncTarget(header *types.Header, justification []byte,
+	round, setID uint64) error {
+	bs.Lock()
+	defer bs.unlockAndCallHooks()
+
+	lastFinalised, err := bs.GetHeader(bs.lastFinalised)
+	if err != nil {
+		return fmt.Errorf("getting highest finalised header: %w", err)
+	} else if header.Number <= lastFinalised.Number {
+		return fmt.Errorf("%w: block number %d is not above block number %d",
+			errWarpSyncTargetNotAbove, header.Number, lastFinalised.Number)
+	}
+
+	runtimeInstance, err := bs.bt.GetBlockRuntime(bs.lastFinalised)
+	if err != nil {
+		return fmt.Errorf("getting runtime of highest finalised block: %w", err)
+	}
+
+	batch := newBlockStateBatch(bs.db)
+	defer batch.Reset()
+
+	hash, err := StoreHeader(batch, header)
+	if err != nil {
+		return err
+	}
+
+	err = StoreJustification(batch, hash, justification)
+	if err != nil {
+		return err
+	}
+
+	stateRootIndex := newStateRootIndexBatch(bs.db)
+	err = stateRootIndex.add(header)
+	if err != nil {
+		return fmt.Errorf("adding block to state root index: %w", err)
+	}
+
+	err = stateRootIndex.put(batch)
+	if err != nil {
+		return fmt.Errorf("writing state root index: %w", err)
+	}
+
+	weight, err := newForkChoiceWeight(bs.db, header, time.Now())
+	if err != nil {
+		return fmt.Errorf("creating fork choice weight: %w", err)
+	}
+
+	err = StoreForkChoiceWeight(batch, hash, weight)
+	if err != nil {
+		return err
+	}
+
+	err = batch.Put(finalisedHashKey(round, setID), hash[:])
+	if err != nil {
+		return fmt.Errorf("failed to set finalised hash key: %w", err)
+	}
+
+	err = bs.putHighestRoundAndSetID(batch, round, setID)
+	if err != nil {
+		return fmt.Errorf("failed to set highest round and set ID: %w", err)
+	}
+
+	err = putHighestFinalisedHash(batch, hash)
+	if err != nil {
+		return err
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("writing warp sync target to database: %w", err)
+	}
+
+	// The unfinalised blocks descend from the previous highest
+	// finalised block, so they cannot descend from the header.
+	bs.unfinalisedBlocks = newHashToBlockMap()
+	bs.justifications = make(map[common.Hash][]byte)
+	bs.bt = blocktree.NewBlockTreeFromRoot(header)
+	bs.bt.StoreRuntime(hash, runtimeInstance)
+
+	err = bs.resetBlockNumberIndex(header)
+	if err != nil {
+		return fmt.Errorf("resetting block number index: %w", err)
+	}
+
+	bs.lastFinalised = hash
+	bs.notifyFinalized(hash, round, setID)
+	bs.finalisedNotifier.notify(header)
+	logger.Infof("⏩ imported warp sync target block number %d with hash %s", header.Number, hash)
+	return nil
+}
+
+// SetWarpSyncAuthorities sets the authorities given as the authorities of the current
+// authority set, as proven by a warp sync proof, where the block numbers given are
+// the last blocks of each authority set before the current one, in set ID order.
+func (s *GrandpaState) SetWarpSyncAuthorities(authorities []types.GrandpaVoter, setChanges []uint) error {
+	for i, number := range setChanges {
+		err := s.setChangeSetIDAtBlock(uint64(i+1), number)
+		if err != nil {
+			return fmt.Errorf("setting set id %d change: %w", i+1, err)
+		}
+	}
+
+	setID := uint64(len(setChanges))
+	err := s.setAuthorities(setID, authorities)
+	if err != nil {
+		return fmt.Errorf("setting authorities of set id %d: %w", setID, err)
+	}
+
+	err = s.setCurrentSetID(setID)
+	if err != nil {
+		return fmt.Errorf("setting current set id: %w", err)
+	}
+
+	return nil
+}
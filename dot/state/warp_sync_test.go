@@ -0,0 +1,112 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockState_ImportWarpSyncTarget(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	bs := newTestBlockState(t, newTriesEmpty())
+	genesisRuntime := NewMockInstance(ctrl)
+	bs.StoreRuntime(bs.genesisHash, genesisRuntime)
+
+	// a block imported before warp sync is discarded
+	block1 := &types.Block{
+		Header: types.Header{
+			ParentHash: bs.genesisHash,
+			Number:     1,
+			Digest:     types.NewDigest(),
+		},
+		Body: types.Body{},
+	}
+	err := bs.AddBlock(block1)
+	require.NoError(t, err)
+
+	target := &types.Header{
+		ParentHash: common.Hash{1},
+		Number:     100,
+		StateRoot:  common.Hash{2},
+		Digest:     types.NewDigest(),
+	}
+	targetHash := target.Hash()
+	finalisedCh := bs.GetFinalisedNotifierChannel()
+
+	err = bs.ImportWarpSyncTarget(target, []byte{3}, 4, 5)
+	require.NoError(t, err)
+
+	finalised, err := bs.GetHighestFinalisedHeader()
+	require.NoError(t, err)
+	assert.Equal(t, targetHash, finalised.Hash())
+	assert.Equal(t, targetHash, bs.BestBlockHash())
+	assert.Equal(t, []common.Hash{targetHash}, bs.Leaves())
+
+	hash, err := bs.GetHashByNumber(100)
+	require.NoError(t, err)
+	assert.Equal(t, targetHash, hash)
+	_, err = bs.GetHashByNumber(1)
+	assert.ErrorIs(t, err, ErrNoCanonicalAtHeight)
+
+	hash, err = bs.GetFinalisedHash(4, 5)
+	require.NoError(t, err)
+	assert.Equal(t, targetHash, hash)
+
+	justification, err := bs.GetJustification(targetHash)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{3}, justification)
+
+	stateRoot, err := GetStateRootFromBlock(bs.db, targetHash)
+	require.NoError(t, err)
+	assert.Equal(t, target.StateRoot, stateRoot)
+
+	runtimeInstance, err := bs.GetRuntime(targetHash)
+	require.NoError(t, err)
+	assert.Equal(t, genesisRuntime, runtimeInstance)
+
+	info := <-finalisedCh
+	assert.Equal(t, targetHash, info.Header.Hash())
+
+	err = bs.ImportWarpSyncTarget(&block1.Header, nil, 0, 0)
+	assert.ErrorIs(t, err, errWarpSyncTargetNotAbove)
+	assert.EqualError(t, err, "warp sync target is not above highest finalised block: "+
+		"block number 1 is not above block number 100")
+}
+
+func TestGrandpaState_SetWarpSyncAuthorities(t *testing.T) {
+	t.Parallel()
+
+	db := NewInMemoryDB(t)
+	gs, err := NewGrandpaStateFromGenesis(db, nil, testAuths, nil)
+	require.NoError(t, err)
+
+	authorities := []types.GrandpaVoter{
+		{Key: *kr.Bob().Public().(*ed25519.PublicKey), ID: 0},
+	}
+	err = gs.SetWarpSyncAuthorities(authorities, []uint{10, 20})
+	require.NoError(t, err)
+
+	setID, err := gs.GetCurrentSetID()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), setID)
+
+	stored, err := gs.GetAuthorities(2)
+	require.NoError(t, err)
+	assert.Equal(t, authorities, stored)
+
+	for number, expected := range map[uint]uint64{5: 0, 10: 0, 15: 1, 20: 1, 21: 2, 100: 2} {
+		setID, err := gs.GetSetIDByBlockNumber(number)
+		require.NoError(t, err)
+		assert.Equal(t, expected, setID, "block number %d", number)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// ErrWellKnownValueMalformed is returned when the value stored
+// at a well-known storage key cannot be decoded.
+var ErrWellKnownValueMalformed = errors.New("malformed value at well-known key")
+
+var (
+	errHeapPagesEncodingLength          = errors.New("heap pages encoding length is invalid")
+	errGrandpaAuthoritiesVersionMissing = errors.New("grandpa authorities version is missing")
+	errGrandpaAuthoritiesVersion        = errors.New("grandpa authorities version is not supported")
+)
+
+// DefaultHeapPages is the number of heap pages used by
+// the runtime if the :heappages key is not set.
+const DefaultHeapPages uint64 = 2048
+
+// grandpaAuthoritiesVersion is the only supported version
+// of the authorities stored at the :grandpa_authorities key.
+const grandpaAuthoritiesVersion = 1
+
+// storageGetter gets the value at a key of a state trie,
+// such as a *trie.Trie or a *storage.TrieState.
+type storageGetter interface {
+	Get(key []byte) []byte
+}
+
+// LoadCodeFromTrie returns the runtime code stored at the :code key of
+// the state trie given, or ErrRuntimeCodeNotFound if it is not set.
+func LoadCodeFromTrie(t storageGetter) (code []byte, err error) {
+	code = t.Get(codeKey)
+	if len(code) == 0 {
+		return nil, ErrRuntimeCodeNotFound
+	}
+	return code, nil
+}
+
+// LoadHeapPages returns the number of heap pages stored at the :heappages
+// key of the state trie given, or nil if it is not set, in which case the
+// default number of heap pages of the runtime is used.
+func LoadHeapPages(t storageGetter) (heapPages *uint64, err error) {
+	return decodeHeapPages(t.Get(heapPagesKey))
+}
+
+// decodeHeapPages decodes the little endian encoded
+// number of heap pages, returning nil if it is nil.
+func decodeHeapPages(encodedHeapPages []byte) (heapPages *uint64, err error) {
+	if encodedHeapPages == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	const heapPagesEncodingLength = 8
+	if len(encodedHeapPages) != heapPagesEncodingLength {
+		return nil, malformedWellKnownValue(heapPagesKey, fmt.Errorf("%w: %d bytes instead of %d bytes",
+			errHeapPagesEncodingLength, len(encodedHeapPages), heapPagesEncodingLength))
+	}
+
+	value := binary.LittleEndian.Uint64(encodedHeapPages)
+	return &value, nil
+}
+
+// LoadGrandpaAuthorities returns the GRANDPA authorities stored at the
+// :grandpa_authorities key of the state trie given, which is only set
+// in the genesis state, or an empty slice if it is not set.
+func LoadGrandpaAuthorities(t storageGetter) (voters []types.GrandpaVoter, err error) {
+	encodedAuthorities := t.Get(common.GrandpaAuthoritiesKey)
+	if encodedAuthorities == nil {
+		return []types.GrandpaVoter{}, nil
+	}
+
+	// The authorities are prefixed with the version of their encoding.
+	if len(encodedAuthorities) == 0 {
+		return nil, malformedWellKnownValue(common.GrandpaAuthoritiesKey, errGrandpaAuthoritiesVersionMissing)
+	} else if version := encodedAuthorities[0]; version != grandpaAuthoritiesVersion {
+		return nil, malformedWellKnownValue(common.GrandpaAuthoritiesKey,
+			fmt.Errorf("%w: %d", errGrandpaAuthoritiesVersion, version))
+	}
+
+	voters, err = types.DecodeGrandpaVoters(encodedAuthorities[1:])
+	if err != nil {
+		return nil, malformedWellKnownValue(common.GrandpaAuthoritiesKey, err)
+	}
+	return voters, nil
+}
+
+func malformedWellKnownValue(key []byte, err error) error {
+	return fmt.Errorf("%w %s: %w", ErrWellKnownValueMalformed, key, err)
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// westendLocalGrandpaAuthorities is the value at the :grandpa_authorities
+// key of the westend-local raw chain spec, with the authorities Charlie,
+// Alice and Bob each with a weight of 1.
+const westendLocalGrandpaAuthorities = "0x010c" +
+	"439660b36c6c03afafca027b910b4fecf99801834c62a5e6006f27d978de234f0100000000000000" +
+	"88dc3417d5058ec4b4503e0c12ea1a0a89be200fe98922423d4334014fa6b0ee0100000000000000" +
+	"d17c2d7823ebf260fd138f2d7e27d114c0145d968b5ff5006125f2414fadae690100000000000000"
+
+func newTestWellKnownKeysTrie(t *testing.T, entries map[string][]byte) *trie.Trie {
+	t.Helper()
+
+	stateTrie := trie.NewEmptyTrie()
+	for key, value := range entries {
+		err := stateTrie.Put([]byte(key), value)
+		require.NoError(t, err)
+	}
+	return stateTrie
+}
+
+func Test_LoadCodeFromTrie(t *testing.T) {
+	t.Parallel()
+
+	// Wasm magic number and version, as at the start of the westend-local runtime code.
+	code := common.MustHexToBytes("0x0061736d01000000")
+	stateTrie := newTestWellKnownKeysTrie(t, map[string][]byte{":code": code})
+	loadedCode, err := LoadCodeFromTrie(stateTrie)
+	require.NoError(t, err)
+	assert.Equal(t, code, loadedCode)
+
+	_, err = LoadCodeFromTrie(trie.NewEmptyTrie())
+	assert.ErrorIs(t, err, ErrRuntimeCodeNotFound)
+}
+
+func Test_LoadHeapPages(t *testing.T) {
+	t.Parallel()
+
+	heapPages := uint64(2048)
+	testCases := map[string]struct {
+		encodedHeapPages []byte
+		heapPages        *uint64
+		errWrapped       error
+		errMessage       string
+	}{
+		"not_set": {},
+		"set": {
+			encodedHeapPages: common.MustHexToBytes("0x0008000000000000"),
+			heapPages:        &heapPages,
+		},
+		"compact_encoded": {
+			encodedHeapPages: common.MustHexToBytes("0x0220"),
+			errWrapped:       ErrWellKnownValueMalformed,
+			errMessage: "malformed value at well-known key :heappages: " +
+				"heap pages encoding length is invalid: 2 bytes instead of 8 bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stateTrie := trie.NewEmptyTrie()
+			if testCase.encodedHeapPages != nil {
+				stateTrie = newTestWellKnownKeysTrie(t, map[string][]byte{
+					":heappages": testCase.encodedHeapPages,
+				})
+			}
+
+			result, err := LoadHeapPages(stateTrie)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.heapPages, result)
+		})
+	}
+}
+
+func Test_LoadGrandpaAuthorities(t *testing.T) {
+	t.Parallel()
+
+	encodedAuthorities := common.MustHexToBytes(westendLocalGrandpaAuthorities)
+
+	stateTrie := newTestWellKnownKeysTrie(t, map[string][]byte{":grandpa_authorities": encodedAuthorities})
+	voters, err := LoadGrandpaAuthorities(stateTrie)
+	require.NoError(t, err)
+	require.Len(t, voters, 3)
+	expectedKeys := []string{
+		"0x439660b36c6c03afafca027b910b4fecf99801834c62a5e6006f27d978de234f",
+		"0x88dc3417d5058ec4b4503e0c12ea1a0a89be200fe98922423d4334014fa6b0ee",
+		"0xd17c2d7823ebf260fd138f2d7e27d114c0145d968b5ff5006125f2414fadae69",
+	}
+	for i, voter := range voters {
+		assert.Equal(t, expectedKeys[i], voter.Key.Hex())
+		assert.Equal(t, uint64(1), voter.ID)
+	}
+
+	voters, err = LoadGrandpaAuthorities(trie.NewEmptyTrie())
+	require.NoError(t, err)
+	assert.Empty(t, voters)
+
+	unversioned := encodedAuthorities[1:]
+	stateTrie = newTestWellKnownKeysTrie(t, map[string][]byte{":grandpa_authorities": unversioned})
+	_, err = LoadGrandpaAuthorities(stateTrie)
+	assert.ErrorIs(t, err, ErrWellKnownValueMalformed)
+	assert.EqualError(t, err, "malformed value at well-known key :grandpa_authorities: "+
+		"grandpa authorities version is not supported: 12")
+
+	truncated := encodedAuthorities[:len(encodedAuthorities)-10]
+	stateTrie = newTestWellKnownKeysTrie(t, map[string][]byte{":grandpa_authorities": truncated})
+	_, err = LoadGrandpaAuthorities(stateTrie)
+	assert.ErrorIs(t, err, ErrWellKnownValueMalformed)
+	assert.ErrorContains(t, err, "malformed value at well-known key :grandpa_authorities: ")
+}
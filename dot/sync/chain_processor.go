@@ -8,10 +8,30 @@
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/ChainSafe/gossamer/dot/telemetry"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	importedBlocksCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gossamer_network_syncer",
+		Name:      "blocks_imported_total",
+		Help:      "total number of blocks imported",
+	})
+	blockImportDurationHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "gossamer_network_syncer",
+		Name:      "block_import_duration_seconds",
+		Help:      "duration of block imports, including the block execution",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	})
 )
 
 // ChainProcessor processes ready blocks.
@@ -25,6 +45,10 @@ type chainProcessor struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
+	// processingMutex is locked while a block data is being processed,
+	// such that stop can wait for the block import in progress to complete.
+	processingMutex sync.Mutex
+
 	chainSync ChainSync
 
 	// blocks that are ready for processing. ie. their parent is known, or their parent is ahead
@@ -77,8 +101,12 @@ func newChainProcessor(cfg chainProcessorConfig) *chainProcessor {
 	}
 }
 
+// stop stops processing ready blocks, and waits for the
+// block data being processed, if any, to be fully processed.
 func (s *chainProcessor) stop() {
 	s.cancel()
+	s.processingMutex.Lock()
+	s.processingMutex.Unlock() //nolint:staticcheck
 }
 
 func (s *chainProcessor) processReadyBlocks() {
@@ -91,7 +119,18 @@ func (s *chainProcessor) processReadyBlocks() {
 			panic(fmt.Sprintf("unhandled error: %s", err))
 		}
 
-		if err := s.processBlockData(*bd); err != nil {
+		s.processingMutex.Lock()
+		if s.ctx.Err() != nil {
+			// stopped between popping the block data and starting to process it,
+			// the block will be requested again on the next start.
+			s.processingMutex.Unlock()
+			logger.Debugf("not processing block data with hash %s since processor is stopped", bd.Hash)
+			return
+		}
+		err = s.processBlockData(*bd)
+		s.processingMutex.Unlock()
+
+		if err != nil {
 			// depending on the error, we might want to save this block for later
 			if !errors.Is(err, errFailedToGetParent) && !errors.Is(err, blocktree.ErrParentNotFound) {
 				logger.Errorf("block data processing for block with hash %s failed: %s", bd.Hash, err)
@@ -172,12 +211,12 @@ func (c *chainProcessor) processBlockDataWithStateHeaderAndBody(blockData types.
 		return fmt.Errorf("getting block by hash: %w", err)
 	}
 
-	err = c.blockState.AddBlockToBlockTree(block)
+	err = c.blockState.ImportBlocks([]*types.Block{block})
 	if errors.Is(err, blocktree.ErrBlockExists) {
 		logger.Debugf(
 			"block number %d with hash %s already exists in block tree, skipping it.",
 			block.Header.Number, blockData.Hash)
-		return nil
+		return c.handleMissingJustification(&block.Header, blockData.Justification)
 	} else if err != nil {
 		return fmt.Errorf("adding block to blocktree: %w", err)
 	}
@@ -236,6 +275,8 @@ func (s *chainProcessor) handleBody(body *types.Body) {
 
 // handleHeader handles blocks (header+body) included in BlockResponses
 func (s *chainProcessor) handleBlock(block *types.Block, announceImportedBlock bool) error {
+	importStart := time.Now()
+
 	parent, err := s.blockState.GetHeader(block.Header.ParentHash)
 	if err != nil {
 		return fmt.Errorf("%w: %s", errFailedToGetParent, err)
@@ -259,17 +300,37 @@ func (s *chainProcessor) handleBlock(block *types.Block, announceImportedBlock b
 		return err
 	}
 
-	rt.SetContextStorage(ts)
+	// The block is executed on an overlay of the parent state,
+	// whose changes are only applied once the block is executed.
+	overlay := rtstorage.NewOverlay(ts.Trie())
+	rt.SetContextStorage(overlay)
+
+	err = checkInherents(rt, block, time.Now())
+	if err != nil {
+		return fmt.Errorf("checking inherents of block %d: %w", block.Header.Number, err)
+	}
 
 	_, err = rt.ExecuteBlock(block)
 	if err != nil {
 		return fmt.Errorf("failed to execute block %d: %w", block.Header.Number, err)
 	}
 
+	changes, err := overlay.Commit()
+	if err != nil {
+		return fmt.Errorf("committing storage changes of block %d: %w", block.Header.Number, err)
+	}
+
+	_, err = ts.ApplyChanges(changes)
+	if err != nil {
+		return fmt.Errorf("applying storage changes of block %d: %w", block.Header.Number, err)
+	}
+
 	if err = s.blockImportHandler.HandleBlockImport(block, ts, announceImportedBlock); err != nil {
 		return err
 	}
 
+	importedBlocksCounter.Inc()
+	blockImportDurationHistogram.Observe(time.Since(importStart).Seconds())
 	logger.Debugf("🔗 imported block number %d with hash %s", block.Header.Number, block.Header.Hash())
 
 	blockHash := block.Header.Hash()
@@ -281,20 +342,73 @@ func (s *chainProcessor) handleBlock(block *types.Block, announceImportedBlock b
 	return nil
 }
 
+// checkInherents checks the inherents of the block using the runtime given,
+// against the inherent data of the timestamp given and of the block slot.
+// The BABE runtime module does not check the slot inherent data, so the slot
+// inherent data is only provided if the block header contains a BABE slot.
+func checkInherents(rt runtime.Instance, block *types.Block, now time.Time) (err error) {
+	providers := new(types.InherentDataProviders)
+	providers.Register(&types.TimestampInherentDataProvider{Timestamp: now})
+	slot, err := types.GetSlotFromHeader(&block.Header)
+	if err == nil {
+		providers.Register(&types.SlotInherentDataProvider{Slot: slot})
+	}
+
+	inherentData, err := providers.InherentData()
+	if err != nil {
+		return err
+	}
+
+	encodedInherentData, err := inherentData.Encode()
+	if err != nil {
+		return fmt.Errorf("encoding inherent data: %w", err)
+	}
+
+	result, err := rt.CheckInherents(block, encodedInherentData)
+	if err != nil {
+		return fmt.Errorf("calling runtime: %w", err)
+	}
+
+	if !result.Okay {
+		return fmt.Errorf("%w: fatal %t: %v", errInherentsCheckFailed, result.FatalError, result.Errors)
+	}
+
+	return nil
+}
+
+// handleMissingJustification handles the justification given for a block
+// already imported, if the justification is not empty and if there is no
+// justification stored for this block yet, so it is not dropped.
+func (s *chainProcessor) handleMissingJustification(header *types.Header, justification *[]byte) (err error) {
+	if justification == nil || len(*justification) == 0 {
+		return nil
+	}
+
+	has, err := s.blockState.HasJustification(header.Hash())
+	if err != nil {
+		return fmt.Errorf("checking if block state has justification: %w", err)
+	} else if has {
+		return nil
+	}
+
+	err = s.handleJustification(header, *justification)
+	if err != nil {
+		return fmt.Errorf("handling justification: %w", err)
+	}
+	return nil
+}
+
 func (s *chainProcessor) handleJustification(header *types.Header, justification []byte) (err error) {
 	logger.Debugf("handling justification for block %d...", header.Number)
 
 	headerHash := header.Hash()
+	// the justification is stored by the finality gadget
+	// in the same batch as the block it finalises.
 	err = s.finalityGadget.VerifyBlockJustification(headerHash, justification)
 	if err != nil {
 		return fmt.Errorf("verifying block number %d justification: %w", header.Number, err)
 	}
 
-	err = s.blockState.SetJustification(headerHash, justification)
-	if err != nil {
-		return fmt.Errorf("setting justification for block number %d: %w", header.Number, err)
-	}
-
 	logger.Infof("🔨 finalised block number %d with hash %s", header.Number, headerHash)
 	return nil
 }
@@ -6,7 +6,12 @@
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ChainSafe/gossamer/dot/telemetry"
 	"github.com/ChainSafe/gossamer/dot/types"
@@ -15,7 +20,9 @@
 	"github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/trie"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_chainProcessor_handleBlock(t *testing.T) {
@@ -80,6 +87,31 @@ func Test_chainProcessor_handleBlock(t *testing.T) {
 			},
 			wantErr: mockError,
 		},
+		"handle_check_inherents_failure": {
+			chainProcessorBuilder: func(ctrl *gomock.Controller) (chainProcessor chainProcessor) {
+				trieState := storage.NewTrieState(nil)
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(&types.Header{
+					StateRoot: testHash,
+				}, nil)
+				mockInstance := NewMockInstance(ctrl)
+				mockInstance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
+				mockInstance.EXPECT().CheckInherents(&types.Block{Body: types.Body{}}, gomock.Any()).
+					Return(&types.CheckInherentsResult{FatalError: true}, nil)
+				mockBlockState.EXPECT().GetRuntime(testParentHash).Return(mockInstance, nil)
+				chainProcessor.blockState = mockBlockState
+				mockStorageState := NewMockStorageState(ctrl)
+				mockStorageState.EXPECT().Lock()
+				mockStorageState.EXPECT().TrieState(&testHash).Return(trieState, nil)
+				mockStorageState.EXPECT().Unlock()
+				chainProcessor.storageState = mockStorageState
+				return
+			},
+			block: &types.Block{
+				Body: types.Body{},
+			},
+			wantErr: errInherentsCheckFailed,
+		},
 		"handle_runtime_ExecuteBlock_error": {
 			chainProcessorBuilder: func(ctrl *gomock.Controller) (chainProcessor chainProcessor) {
 				trieState := storage.NewTrieState(nil)
@@ -88,7 +120,9 @@ func Test_chainProcessor_handleBlock(t *testing.T) {
 					StateRoot: testHash,
 				}, nil)
 				mockInstance := NewMockInstance(ctrl)
-				mockInstance.EXPECT().SetContextStorage(trieState)
+				mockInstance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
+				mockInstance.EXPECT().CheckInherents(&types.Block{Body: types.Body{}}, gomock.Any()).
+					Return(&types.CheckInherentsResult{Okay: true}, nil)
 				mockInstance.EXPECT().ExecuteBlock(&types.Block{Body: types.Body{}}).Return(nil, mockError)
 				mockBlockState.EXPECT().GetRuntime(testParentHash).Return(mockInstance, nil)
 				chainProcessor.blockState = mockBlockState
@@ -113,7 +147,9 @@ func Test_chainProcessor_handleBlock(t *testing.T) {
 				}, nil)
 				mockBlock := &types.Block{Body: types.Body{}}
 				mockInstance := NewMockInstance(ctrl)
-				mockInstance.EXPECT().SetContextStorage(trieState)
+				mockInstance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
+				mockInstance.EXPECT().CheckInherents(mockBlock, gomock.Any()).
+					Return(&types.CheckInherentsResult{Okay: true}, nil)
 				mockInstance.EXPECT().ExecuteBlock(mockBlock).Return(nil, nil)
 				mockBlockState.EXPECT().GetRuntime(testParentHash).Return(mockInstance, nil)
 				chainProcessor.blockState = mockBlockState
@@ -148,7 +184,9 @@ func Test_chainProcessor_handleBlock(t *testing.T) {
 				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(mockHeader, nil)
 
 				mockInstance := NewMockInstance(ctrl)
-				mockInstance.EXPECT().SetContextStorage(trieState)
+				mockInstance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
+				mockInstance.EXPECT().CheckInherents(mockBlock, gomock.Any()).
+					Return(&types.CheckInherentsResult{Okay: true}, nil)
 				mockInstance.EXPECT().ExecuteBlock(mockBlock).Return(nil, nil)
 				mockBlockState.EXPECT().GetRuntime(mockHeaderHash).Return(mockInstance, nil)
 				chainProcessor.blockState = mockBlockState
@@ -188,7 +226,9 @@ func Test_chainProcessor_handleBlock(t *testing.T) {
 				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(mockHeader, nil)
 
 				mockInstance := NewMockInstance(ctrl)
-				mockInstance.EXPECT().SetContextStorage(trieState)
+				mockInstance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
+				mockInstance.EXPECT().CheckInherents(mockBlock, gomock.Any()).
+					Return(&types.CheckInherentsResult{Okay: true}, nil)
 				mockInstance.EXPECT().ExecuteBlock(mockBlock).Return(nil, nil)
 				mockBlockState.EXPECT().GetRuntime(mockHeaderHash).Return(mockInstance, nil)
 				chainProcessor.blockState = mockBlockState
@@ -252,6 +292,77 @@ func Test_chainProcessor_handleBlock(t *testing.T) {
 	})
 }
 
+// scrapeMetric returns the value of the metric with the given
+// full name from the metrics page served by the default registry.
+func scrapeMetric(t *testing.T, name string) (value float64) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	promhttp.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		valueString, found := strings.CutPrefix(line, name+" ")
+		if !found {
+			continue
+		}
+		value, err := strconv.ParseFloat(valueString, 64)
+		require.NoError(t, err)
+		return value
+	}
+
+	t.Fatalf("metric %s not found on metrics page", name)
+	return 0
+}
+
+func Test_chainProcessor_handleBlock_metrics(t *testing.T) {
+	// Note this test is not parallel since it reads global metrics
+	// which could be modified by other parallel tests.
+	ctrl := gomock.NewController(t)
+
+	block := &types.Block{
+		Body: types.Body{},
+	}
+	trieState := storage.NewTrieState(nil)
+	parentHeader := &types.Header{
+		StateRoot: trie.EmptyHash,
+	}
+	blockState := NewMockBlockState(ctrl)
+	blockState.EXPECT().GetHeader(common.Hash{}).Return(parentHeader, nil)
+	instance := NewMockInstance(ctrl)
+	instance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
+	instance.EXPECT().CheckInherents(block, gomock.Any()).
+		Return(&types.CheckInherentsResult{Okay: true}, nil)
+	instance.EXPECT().ExecuteBlock(block).Return(nil, nil)
+	blockState.EXPECT().GetRuntime(parentHeader.Hash()).Return(instance, nil)
+	storageState := NewMockStorageState(ctrl)
+	storageState.EXPECT().Lock()
+	storageState.EXPECT().TrieState(&trie.EmptyHash).Return(trieState, nil)
+	storageState.EXPECT().Unlock()
+	blockImportHandler := NewMockBlockImportHandler(ctrl)
+	blockImportHandler.EXPECT().HandleBlockImport(block, trieState, false).Return(nil)
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(gomock.Any())
+	processor := &chainProcessor{
+		blockState:         blockState,
+		storageState:       storageState,
+		blockImportHandler: blockImportHandler,
+		telemetry:          telemetryMock,
+	}
+
+	const importedMetric = "gossamer_network_syncer_blocks_imported_total"
+	const durationCountMetric = "gossamer_network_syncer_block_import_duration_seconds_count"
+	importedBefore := scrapeMetric(t, importedMetric)
+	durationCountBefore := scrapeMetric(t, durationCountMetric)
+
+	err := processor.handleBlock(block, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, importedBefore+1, scrapeMetric(t, importedMetric))
+	assert.Equal(t, durationCountBefore+1, scrapeMetric(t, durationCountMetric))
+}
+
 func Test_chainProcessor_handleBody(t *testing.T) {
 	t.Parallel()
 
@@ -308,32 +419,11 @@ type args struct {
 			sentinelError: errTest,
 			errorMessage:  "verifying block number 2 justification: test error",
 		},
-		"set_justification_error": {
-			chainProcessorBuilder: func(ctrl *gomock.Controller) chainProcessor {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().SetJustification(headerHash, []byte(`xx`)).Return(errTest)
-				mockFinalityGadget := NewMockFinalityGadget(ctrl)
-				mockFinalityGadget.EXPECT().VerifyBlockJustification(headerHash, []byte(`xx`)).Return(nil)
-				return chainProcessor{
-					blockState:     mockBlockState,
-					finalityGadget: mockFinalityGadget,
-				}
-			},
-			args: args{
-				header:        header,
-				justification: []byte(`xx`),
-			},
-			sentinelError: errTest,
-			errorMessage:  "setting justification for block number 2: test error",
-		},
 		"base_case_set": {
 			chainProcessorBuilder: func(ctrl *gomock.Controller) chainProcessor {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().SetJustification(headerHash, []byte(`1234`)).Return(nil)
 				mockFinalityGadget := NewMockFinalityGadget(ctrl)
 				mockFinalityGadget.EXPECT().VerifyBlockJustification(headerHash, []byte(`1234`)).Return(nil)
 				return chainProcessor{
-					blockState:     mockBlockState,
 					finalityGadget: mockFinalityGadget,
 				}
 			},
@@ -423,10 +513,8 @@ func Test_chainProcessor_processBlockData(t *testing.T) {
 				mockBlockState.EXPECT().HasHeader(common.Hash{}).Return(true, nil)
 				mockBlockState.EXPECT().HasBlockBody(common.Hash{}).Return(true, nil)
 				mockBlockState.EXPECT().GetBlockByHash(common.Hash{}).Return(mockBlock, nil)
-				mockBlockState.EXPECT().AddBlockToBlockTree(&types.Block{
-					Header: types.Header{Number: 1}}).Return(nil)
-				mockBlockState.EXPECT().SetJustification(common.MustHexToHash(
-					"0x6443a0b46e0412e626363028115a9f2cf963eeed526b8b33e5316f08b50d0dc3"), []byte{1, 2, 3})
+				mockBlockState.EXPECT().ImportBlocks([]*types.Block{{
+					Header: types.Header{Number: 1}}}).Return(nil)
 				mockFinalityGadget := NewMockFinalityGadget(ctrl)
 				mockFinalityGadget.EXPECT().VerifyBlockJustification(common.MustHexToHash(
 					"0x6443a0b46e0412e626363028115a9f2cf963eeed526b8b33e5316f08b50d0dc3"), []byte{1, 2,
@@ -533,7 +621,9 @@ func Test_chainProcessor_processBlockData(t *testing.T) {
 				mockBlock := &types.Block{Header: types.Header{}, Body: types.Body{}}
 
 				mockInstance := NewMockInstance(ctrl)
-				mockInstance.EXPECT().SetContextStorage(mockTrieState)
+				mockInstance.EXPECT().SetContextStorage(storage.NewOverlay(mockTrieState.Trie()))
+				mockInstance.EXPECT().CheckInherents(mockBlock, gomock.Any()).
+					Return(&types.CheckInherentsResult{Okay: true}, nil)
 				mockInstance.EXPECT().ExecuteBlock(mockBlock).Return(nil, nil)
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().HasHeader(common.Hash{}).Return(false, nil)
@@ -542,8 +632,6 @@ func Test_chainProcessor_processBlockData(t *testing.T) {
 					Number:    0,
 					StateRoot: stateRootHash,
 				}, nil)
-				mockBlockState.EXPECT().SetJustification(
-					common.MustHexToHash("0xdcdd89927d8a348e00257e1ecc8617f45edb5118efff3ea2f9961b2ad9b7690a"), []byte{1, 2, 3})
 				mockBlockState.EXPECT().CompareAndSetBlockData(gomock.AssignableToTypeOf(&types.BlockData{}))
 				mockBlockState.EXPECT().GetRuntime(runtimeHash).Return(mockInstance, nil)
 				mockBabeVerifier := NewMockBabeVerifier(ctrl)
@@ -627,19 +715,61 @@ func Test_chainProcessor_processBlockDataWithStateHeaderAndBody(t *testing.T) {
 				blockState := NewMockBlockState(ctrl)
 				block := &types.Block{Header: types.Header{Number: 2}}
 				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
-				blockState.EXPECT().AddBlockToBlockTree(block).Return(blocktree.ErrBlockExists)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(blocktree.ErrBlockExists)
 				return chainProcessor{
 					blockState: blockState,
 				}
 			},
 			blockData: types.BlockData{Hash: common.Hash{1}},
 		},
+		"block_already_exists_with_justification_stored": {
+			chainProcessorBuilder: func(ctrl *gomock.Controller) chainProcessor {
+				blockState := NewMockBlockState(ctrl)
+				blockHeader := types.Header{Number: 2}
+				block := &types.Block{Header: blockHeader}
+				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(blocktree.ErrBlockExists)
+				blockState.EXPECT().HasJustification(blockHeader.Hash()).Return(true, nil)
+				return chainProcessor{
+					blockState: blockState,
+				}
+			},
+			blockData: types.BlockData{
+				Hash:          common.Hash{1},
+				Justification: &[]byte{3},
+			},
+		},
+		"block_already_exists_with_justification_missing": {
+			chainProcessorBuilder: func(ctrl *gomock.Controller) chainProcessor {
+				blockState := NewMockBlockState(ctrl)
+				blockHeader := types.Header{Number: 2}
+				blockHeaderHash := blockHeader.Hash()
+				block := &types.Block{Header: blockHeader}
+				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(blocktree.ErrBlockExists)
+				blockState.EXPECT().HasJustification(blockHeaderHash).Return(false, nil)
+
+				finalityGadget := NewMockFinalityGadget(ctrl)
+				finalityGadget.EXPECT().
+					VerifyBlockJustification(blockHeaderHash, []byte{3}).
+					Return(nil)
+
+				return chainProcessor{
+					blockState:     blockState,
+					finalityGadget: finalityGadget,
+				}
+			},
+			blockData: types.BlockData{
+				Hash:          common.Hash{1},
+				Justification: &[]byte{3},
+			},
+		},
 		"add_block_to_blocktree_error": {
 			chainProcessorBuilder: func(ctrl *gomock.Controller) chainProcessor {
 				blockState := NewMockBlockState(ctrl)
 				block := &types.Block{Header: types.Header{Number: 2}}
 				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
-				blockState.EXPECT().AddBlockToBlockTree(block).Return(errTest)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(errTest)
 				return chainProcessor{
 					blockState: blockState,
 				}
@@ -655,7 +785,7 @@ func Test_chainProcessor_processBlockDataWithStateHeaderAndBody(t *testing.T) {
 				blockHeaderHash := blockHeader.Hash()
 				block := &types.Block{Header: blockHeader}
 				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
-				blockState.EXPECT().AddBlockToBlockTree(block).Return(nil)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(nil)
 
 				finalityGadget := NewMockFinalityGadget(ctrl)
 				finalityGadget.EXPECT().
@@ -680,7 +810,7 @@ func Test_chainProcessor_processBlockDataWithStateHeaderAndBody(t *testing.T) {
 				blockHeader := types.Header{StateRoot: common.Hash{2}}
 				block := &types.Block{Header: blockHeader}
 				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
-				blockState.EXPECT().AddBlockToBlockTree(block).Return(nil)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(nil)
 
 				storageState := NewMockStorageState(ctrl)
 				storageState.EXPECT().TrieState(&common.Hash{2}).
@@ -703,7 +833,7 @@ func Test_chainProcessor_processBlockDataWithStateHeaderAndBody(t *testing.T) {
 				blockHeader := types.Header{StateRoot: common.Hash{2}}
 				block := &types.Block{Header: blockHeader}
 				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
-				blockState.EXPECT().AddBlockToBlockTree(block).Return(nil)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(nil)
 
 				storageState := NewMockStorageState(ctrl)
 				trieState := storage.NewTrieState(nil)
@@ -734,7 +864,7 @@ func Test_chainProcessor_processBlockDataWithStateHeaderAndBody(t *testing.T) {
 				blockHeader := types.Header{StateRoot: common.Hash{2}}
 				block := &types.Block{Header: blockHeader}
 				blockState.EXPECT().GetBlockByHash(common.Hash{1}).Return(block, nil)
-				blockState.EXPECT().AddBlockToBlockTree(block).Return(nil)
+				blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(nil)
 
 				storageState := NewMockStorageState(ctrl)
 				trieState := storage.NewTrieState(nil)
@@ -863,11 +993,13 @@ func Test_chainProcessor_processBlockDataWithHeaderAndBody(t *testing.T) {
 				blockState.EXPECT().GetRuntime(parentHeaderHash).
 					Return(instance, nil)
 
-				instance.EXPECT().SetContextStorage(trieState)
+				instance.EXPECT().SetContextStorage(storage.NewOverlay(trieState.Trie()))
 				block := &types.Block{
 					Header: *expectedHeader,
 					Body:   types.Body{{2}},
 				}
+				instance.EXPECT().CheckInherents(block, gomock.Any()).
+					Return(&types.CheckInherentsResult{Okay: true}, nil)
 				instance.EXPECT().ExecuteBlock(block).Return(nil, nil)
 
 				blockImportHandler := NewMockBlockImportHandler(ctrl)
@@ -1104,6 +1236,117 @@ func Test_chainProcessor_processReadyBlocks(t *testing.T) {
 	}
 }
 
+func Test_chainProcessor_stop_waitsForBlockImport(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	block := &types.Block{
+		Header: types.Header{Number: 1},
+		Body:   types.Body{},
+	}
+	blockHash := block.Header.Hash()
+	trieState := storage.NewTrieState(nil)
+
+	chainSync := NewMockChainSync(ctrl)
+	chainSync.EXPECT().syncState().Return(tip)
+	blockState := NewMockBlockState(ctrl)
+	blockState.EXPECT().HasHeader(blockHash).Return(true, nil)
+	blockState.EXPECT().HasBlockBody(blockHash).Return(true, nil)
+	blockState.EXPECT().GetBlockByHash(blockHash).Return(block, nil)
+	blockState.EXPECT().ImportBlocks([]*types.Block{block}).Return(nil)
+	storageState := NewMockStorageState(ctrl)
+	storageState.EXPECT().TrieState(&block.Header.StateRoot).Return(trieState, nil)
+
+	importStarted := make(chan struct{})
+	releaseImport := make(chan struct{})
+	var importCommitted bool
+	blockImportHandler := NewMockBlockImportHandler(ctrl)
+	blockImportHandler.EXPECT().HandleBlockImport(block, trieState, true).
+		DoAndReturn(func(*types.Block, *storage.TrieState, bool) error {
+			close(importStarted)
+			<-releaseImport
+			importCommitted = true
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	readyBlocks := newBlockQueue(1)
+	processor := &chainProcessor{
+		ctx:                ctx,
+		cancel:             cancel,
+		readyBlocks:        readyBlocks,
+		chainSync:          chainSync,
+		blockState:         blockState,
+		storageState:       storageState,
+		blockImportHandler: blockImportHandler,
+	}
+
+	processingDone := make(chan struct{})
+	go func() {
+		processor.processReadyBlocks()
+		close(processingDone)
+	}()
+
+	readyBlocks.push(&types.BlockData{
+		Hash:   blockHash,
+		Header: &block.Header,
+		Body:   &block.Body,
+	})
+	<-importStarted
+
+	stopped := make(chan struct{})
+	go func() {
+		processor.stop()
+		close(stopped)
+	}()
+
+	// stop must wait for the block import in progress to complete.
+	select {
+	case <-stopped:
+		t.Fatal("processor stopped before the block import completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(releaseImport)
+	<-stopped
+	assert.True(t, importCommitted)
+	<-processingDone
+}
+
+func Test_chainProcessor_stop_beforeProcessing(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	readyBlocks := newBlockQueue(1)
+	processor := &chainProcessor{
+		ctx:         ctx,
+		cancel:      cancel,
+		readyBlocks: readyBlocks,
+	}
+
+	// Block data pushed before the processor starts, and the processor
+	// stopped before processing it: the block data must not be processed,
+	// which would fail on the nil block state.
+	processor.processingMutex.Lock()
+	readyBlocks.push(&types.BlockData{})
+	processingDone := make(chan struct{})
+	go func() {
+		processor.processReadyBlocks()
+		close(processingDone)
+	}()
+
+	stopped := make(chan struct{})
+	go func() {
+		processor.stop()
+		close(stopped)
+	}()
+	<-ctx.Done()
+	processor.processingMutex.Unlock()
+
+	<-stopped
+	<-processingDone
+}
+
 func Test_newChainProcessor(t *testing.T) {
 	t.Parallel()
 
@@ -27,11 +27,6 @@
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
 )
 
-const (
-	// maxWorkers is the maximum number of parallel sync workers
-	maxWorkers = 12
-)
-
 var _ ChainSync = &chainSync{}
 
 type chainSyncState byte
@@ -95,12 +90,16 @@ type ChainSync interface {
 	start()
 	stop()
 
-	// called upon receiving a BlockAnnounce
-	setBlockAnnounce(from peer.ID, header *types.Header) error
+	// called upon receiving a BlockAnnounce, it returns true if
+	// the announced header is validated and can be relayed
+	setBlockAnnounce(from peer.ID, header *types.Header) (gossip bool, err error)
 
 	// called upon receiving a BlockAnnounceHandshake
 	setPeerHead(p peer.ID, hash common.Hash, number uint) error
 
+	// called when the connection to a peer is closed
+	removePeer(who peer.ID)
+
 	// syncState returns the current syncing state
 	syncState() chainSyncState
 
@@ -168,7 +167,17 @@ type chainSync struct {
 	logSyncDone    chan struct{}
 	badBlocks      []string
 
+	// babeVerifier verifies the announced headers if
+	// validateBlockAnnounces is true.
+	babeVerifier           BabeVerifier
+	validateBlockAnnounces bool
+
 	blockReqRes network.RequestMaker
+
+	// requestWindows limits the block requests in flight, in total and
+	// for each peer. Its total limit is also the maximum number of
+	// parallel sync workers, each sending one request at a time.
+	requestWindows *requestWindows
 }
 
 type chainSyncConfig struct {
@@ -179,6 +188,13 @@ type chainSyncConfig struct {
 	minPeers, maxPeers int
 	slotDuration       time.Duration
 	badBlocks          []string
+	// babeVerifier is used to verify the announced
+	// headers if validateBlockAnnounces is true.
+	babeVerifier           BabeVerifier
+	validateBlockAnnounces bool
+	// maxInFlightRequests is the maximum number of block requests in
+	// flight across all peers, defaulting to 12 if it is zero.
+	maxInFlightRequests uint
 }
 
 func newChainSync(cfg chainSyncConfig, blockReqRes network.RequestMaker) *chainSync {
@@ -210,7 +226,11 @@ func newChainSync(cfg chainSyncConfig, blockReqRes network.RequestMaker) *chainS
 		logSyncTickerC:   logSyncTicker.C,
 		logSyncDone:      make(chan struct{}),
 		badBlocks:        cfg.badBlocks,
+		babeVerifier:     cfg.babeVerifier,
 		blockReqRes:      blockReqRes,
+		requestWindows:   newRequestWindows(cfg.maxInFlightRequests),
+
+		validateBlockAnnounces: cfg.validateBlockAnnounces,
 	}
 }
 
@@ -250,27 +270,68 @@ func (cs *chainSync) syncState() chainSyncState {
 	return cs.state
 }
 
-func (cs *chainSync) setBlockAnnounce(from peer.ID, header *types.Header) error {
+func (cs *chainSync) setBlockAnnounce(from peer.ID, header *types.Header) (gossip bool, err error) {
 	// check if we already know of this block, if not,
 	// add to pendingBlocks set
 	has, err := cs.blockState.HasHeader(header.Hash())
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if has {
-		return blocktree.ErrBlockExists
+		return false, blocktree.ErrBlockExists
+	}
+
+	if cs.validateBlockAnnounces {
+		gossip, err = cs.validateBlockAnnounce(from, header)
+		if err != nil {
+			return false, err
+		}
 	}
 
+	cs.setArrivalTime(header.Hash(), header.Number, time.Now())
+
 	if err = cs.pendingBlocks.addHeader(header); err != nil {
-		return err
+		return false, err
 	}
 
 	// we assume that if a peer sends us a block announce for a certain block,
 	// that is also has the chain up until and including that block.
 	// this may not be a valid assumption, but perhaps we can assume that
 	// it is likely they will receive this block and its ancestors before us.
-	return cs.setPeerHead(from, header.Hash(), header.Number)
+	return gossip, cs.setPeerHead(from, header.Hash(), header.Number)
+}
+
+// validateBlockAnnounce verifies the BABE seal and authorship of the announced
+// header given, and reports the peer if the header is not valid. It returns
+// true if the header is verified, and false if its parent is unknown, in which
+// case the header cannot be verified yet and is only queued as a pending block.
+func (cs *chainSync) validateBlockAnnounce(from peer.ID, header *types.Header) (verified bool, err error) {
+	parentKnown, err := cs.blockState.HasHeader(header.ParentHash)
+	if err != nil {
+		return false, fmt.Errorf("checking parent header exists: %w", err)
+	}
+
+	if !parentKnown {
+		return false, nil
+	}
+
+	err = cs.babeVerifier.VerifyBlock(header)
+	if err != nil {
+		cs.network.ReportPeer(peerset.ReputationChange{
+			Value:  peerset.BadBlockAnnouncementValue,
+			Reason: peerset.BadBlockAnnouncementReason,
+		}, from)
+		return false, fmt.Errorf("%w: block %s announced by peer %s: %s",
+			errInvalidBlockAnnounce, header.Hash(), from, err)
+	}
+
+	return true, nil
+}
+
+// removePeer removes the request window of the given peer, whose connection is closed.
+func (cs *chainSync) removePeer(who peer.ID) {
+	cs.requestWindows.remove(who)
 }
 
 // setPeerHead sets a peer's best known block and potentially adds the peer's state to the workQueue
@@ -616,7 +677,7 @@ func (cs *chainSync) handleWork(ps *peerState) error {
 
 func (cs *chainSync) tryDispatchWorker(w *worker) {
 	// if we already have the maximum number of workers, don't dispatch another
-	if len(cs.workerState.workers) >= maxWorkers {
+	if uint(len(cs.workerState.workers)) >= cs.requestWindows.maxInFlight {
 		logger.Trace("reached max workers, ignoring potential work")
 		return
 	}
@@ -696,12 +757,22 @@ func (cs *chainSync) doSync(req *network.BlockRequestMessage, peersTried map[pee
 	logger.Tracef("sending out block request: %s", req)
 
 	// TODO: use scoring to determine what peer to try to sync from first (#1399)
+	// The peers are tried from a random peer, such that requests are spread
+	// between the peers with a request slot available in their window.
 	idx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(peers))))
-	who := peers[idx.Int64()]
+	orderedPeers := make([]peer.ID, 0, len(peers))
+	orderedPeers = append(orderedPeers, peers[idx.Int64():]...)
+	orderedPeers = append(orderedPeers, peers[:idx.Int64()]...)
 
-	resp := new(network.BlockResponseMessage)
+	who, err := cs.requestWindows.acquire(cs.ctx, orderedPeers)
+	if err != nil {
+		return &workerError{
+			err: err,
+		}
+	}
 
-	err := cs.blockReqRes.Do(who, req, resp)
+	resp, err := cs.requestBlocks(who, req)
+	cs.requestWindows.release(who, requestOutcomeFromError(err))
 	if err != nil {
 		return &workerError{
 			err: err,
@@ -724,6 +795,13 @@ func (cs *chainSync) doSync(req *network.BlockRequestMessage, peersTried map[pee
 
 	logger.Trace("success! placing block response data in ready queue")
 
+	arrivalTime := time.Now()
+	for _, bd := range resp.BlockData {
+		if bd.Header != nil {
+			cs.setArrivalTime(bd.Hash, bd.Header.Number, arrivalTime)
+		}
+	}
+
 	// response was validated! place into ready block queue
 	for _, bd := range resp.BlockData {
 		// block is ready to be processed!
@@ -733,6 +811,84 @@ func (cs *chainSync) doSync(req *network.BlockRequestMessage, peersTried map[pee
 	return nil
 }
 
+// requestBlocks sends the block request given to the peer given, and if the
+// peer responds with fewer blocks than requested, for example because its block
+// responses are bounded in size, requests the remaining blocks in follow-up
+// requests. Each follow-up request starts from the last block received, such
+// that its chunk of blocks continues the same chain, and the chunks are
+// reassembled in request order. The follow-up requests stop once a chunk
+// brings no new block or its request fails, in which case the blocks
+// received so far are returned.
+func (cs *chainSync) requestBlocks(who peer.ID, req *network.BlockRequestMessage) (
+	resp *network.BlockResponseMessage, err error) {
+	resp = new(network.BlockResponseMessage)
+	err = cs.blockReqRes.Do(who, req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := uint32(maxResponseSize)
+	if req.Max != nil {
+		requested = *req.Max
+	}
+
+	blockData := resp.BlockData
+	for uint32(len(blockData)) < requested && len(blockData) > 0 {
+		last := blockData[len(blockData)-1]
+		if last == nil || !cs.peerMayHaveBlocksAfter(who, last, req.Direction) {
+			break
+		}
+
+		// The follow-up request includes the last block received,
+		// which is dropped from the chunk received.
+		max := requested - uint32(len(blockData)) + 1
+		chunkReq := &network.BlockRequestMessage{
+			RequestedData: req.RequestedData,
+			StartingBlock: *variadic.MustNewUint32OrHash(last.Hash),
+			Direction:     req.Direction,
+			Max:           &max,
+		}
+
+		chunk := new(network.BlockResponseMessage)
+		err = cs.blockReqRes.Do(who, chunkReq, chunk)
+		if err != nil {
+			logger.Debugf("requesting blocks after block %s from peer %s, "+
+				"after receiving %d of %d blocks: %s",
+				last.Hash, who, len(blockData), requested, err)
+			break
+		}
+
+		if len(chunk.BlockData) < 2 || chunk.BlockData[0] == nil || chunk.BlockData[0].Hash != last.Hash {
+			logger.Debugf("peer %s sent no block after block %s, after sending %d of %d blocks",
+				who, last.Hash, len(blockData), requested)
+			break
+		}
+		blockData = append(blockData, chunk.BlockData[1:]...)
+	}
+
+	return &network.BlockResponseMessage{BlockData: blockData}, nil
+}
+
+// peerMayHaveBlocksAfter returns false if the peer given cannot have
+// blocks after the block data given in the direction given, which is
+// if the block is the first block or is the best block of the peer.
+// It returns true if the block data has no header.
+func (cs *chainSync) peerMayHaveBlocksAfter(who peer.ID, bd *types.BlockData,
+	direction network.SyncDirection) bool {
+	if bd.Header == nil {
+		return true
+	}
+
+	if direction == network.Descending {
+		return bd.Header.Number > 1
+	}
+
+	cs.RLock()
+	defer cs.RUnlock()
+	state, ok := cs.peerState[who]
+	return !ok || state.number > bd.Header.Number
+}
+
 func (cs *chainSync) handleReadyBlock(bd *types.BlockData) {
 	if cs.readyBlocks.has(bd.Hash) {
 		logger.Tracef("ignoring block %s in response, already in ready queue", bd.Hash)
@@ -783,6 +939,17 @@ func (cs *chainSync) handleReadyBlock(bd *types.BlockData) {
 	}
 }
 
+// setArrivalTime records the arrival time of a block received from the
+// network, for the block to be verified against it even after a restart.
+// Failing to record it is only logged, since the slot time is used
+// instead for blocks without arrival time.
+func (cs *chainSync) setArrivalTime(hash common.Hash, number uint, arrivalTime time.Time) {
+	err := cs.blockState.SetArrivalTime(hash, number, arrivalTime)
+	if err != nil {
+		logger.Errorf("setting arrival time for block %s: %s", hash, err)
+	}
+}
+
 // determineSyncPeers returns a list of peers that likely have the blocks in the given block request.
 func (cs *chainSync) determineSyncPeers(req *network.BlockRequestMessage, peersTried map[peer.ID]struct{}) []peer.ID {
 	var start uint32
@@ -923,6 +923,9 @@ func TestChainSync_doSync(t *testing.T) {
 
 	mockBlockState := NewMockBlockState(ctrl)
 	mockBlockState.EXPECT().HasHeader(common.Hash{}).Return(true, nil).Times(2)
+	for number, hash := range []common.Hash{{0x1}, {0x2}, {0x3}} {
+		mockBlockState.EXPECT().SetArrivalTime(hash, uint(number+1), gomock.Any()).Return(nil)
+	}
 	cs.blockState = mockBlockState
 
 	workerErr := cs.doSync(req, make(map[peer.ID]struct{}))
@@ -1298,7 +1301,9 @@ type args struct {
 	tests := map[string]struct {
 		chainSyncBuilder func(*types.Header, *gomock.Controller) chainSync
 		args             args
+		gossip           bool
 		wantErr          error
+		errWrapped       error
 	}{
 		"base_case": {
 			wantErr: blocktree.ErrBlockExists,
@@ -1347,6 +1352,10 @@ type args struct {
 					HasHeader(argumentHeaderHash).
 					Return(false, nil)
 
+				mockBlockState.EXPECT().
+					SetArrivalTime(argumentHeaderHash, uint(2), gomock.Any()).
+					Return(nil)
+
 				mockBlockState.EXPECT().
 					BestBlockHeader().
 					Return(&types.Header{Number: 1}, nil)
@@ -1372,6 +1381,90 @@ type args struct {
 				}
 			},
 		},
+		"forged_seal_announce_rejected": {
+			errWrapped: errInvalidBlockAnnounce,
+			args: args{
+				from:   peer.ID("forger"),
+				header: &types.Header{ParentHash: common.Hash{1}, Number: 2},
+			},
+			chainSyncBuilder: func(header *types.Header, ctrl *gomock.Controller) chainSync {
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().HasHeader(header.Hash()).Return(false, nil)
+				mockBlockState.EXPECT().HasHeader(common.Hash{1}).Return(true, nil)
+
+				mockBabeVerifier := NewMockBabeVerifier(ctrl)
+				mockBabeVerifier.EXPECT().VerifyBlock(header).
+					Return(errors.New("could not verify signature"))
+
+				mockNetwork := NewMockNetwork(ctrl)
+				mockNetwork.EXPECT().ReportPeer(peerset.ReputationChange{
+					Value:  peerset.BadBlockAnnouncementValue,
+					Reason: peerset.BadBlockAnnouncementReason,
+				}, peer.ID("forger"))
+
+				return chainSync{
+					blockState:             mockBlockState,
+					network:                mockNetwork,
+					pendingBlocks:          NewMockDisjointBlockSet(ctrl),
+					babeVerifier:           mockBabeVerifier,
+					validateBlockAnnounces: true,
+				}
+			},
+		},
+		"valid_announce_relayed": {
+			gossip: true,
+			args: args{
+				header: &types.Header{ParentHash: common.Hash{1}, Number: 2},
+			},
+			chainSyncBuilder: func(header *types.Header, ctrl *gomock.Controller) chainSync {
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().HasHeader(header.Hash()).Return(false, nil)
+				mockBlockState.EXPECT().HasHeader(common.Hash{1}).Return(true, nil)
+				mockBlockState.EXPECT().SetArrivalTime(header.Hash(), uint(2), gomock.Any()).Return(nil)
+				mockBlockState.EXPECT().BestBlockHeader().Return(&types.Header{Number: 1}, nil)
+
+				mockBabeVerifier := NewMockBabeVerifier(ctrl)
+				mockBabeVerifier.EXPECT().VerifyBlock(header).Return(nil)
+
+				mockDisjointBlockSet := NewMockDisjointBlockSet(ctrl)
+				mockDisjointBlockSet.EXPECT().addHeader(header).Return(nil)
+				mockDisjointBlockSet.EXPECT().addHashAndNumber(header.Hash(), uint(2)).Return(nil)
+
+				return chainSync{
+					blockState:             mockBlockState,
+					pendingBlocks:          mockDisjointBlockSet,
+					babeVerifier:           mockBabeVerifier,
+					validateBlockAnnounces: true,
+					peerState:              make(map[peer.ID]*peerState),
+					workQueue:              make(chan *peerState, 1),
+				}
+			},
+		},
+		"announce_with_unknown_parent_queued_and_not_relayed": {
+			args: args{
+				header: &types.Header{ParentHash: common.Hash{1}, Number: 2},
+			},
+			chainSyncBuilder: func(header *types.Header, ctrl *gomock.Controller) chainSync {
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().HasHeader(header.Hash()).Return(false, nil)
+				mockBlockState.EXPECT().HasHeader(common.Hash{1}).Return(false, nil)
+				mockBlockState.EXPECT().SetArrivalTime(header.Hash(), uint(2), gomock.Any()).Return(nil)
+				mockBlockState.EXPECT().BestBlockHeader().Return(&types.Header{Number: 1}, nil)
+
+				mockDisjointBlockSet := NewMockDisjointBlockSet(ctrl)
+				mockDisjointBlockSet.EXPECT().addHeader(header).Return(nil)
+				mockDisjointBlockSet.EXPECT().addHashAndNumber(header.Hash(), uint(2)).Return(nil)
+
+				return chainSync{
+					blockState:             mockBlockState,
+					pendingBlocks:          mockDisjointBlockSet,
+					babeVerifier:           NewMockBabeVerifier(ctrl),
+					validateBlockAnnounces: true,
+					peerState:              make(map[peer.ID]*peerState),
+					workQueue:              make(chan *peerState, 1),
+				}
+			},
+		},
 	}
 	for name, tt := range tests {
 		tt := tt
@@ -1379,12 +1472,16 @@ type args struct {
 			t.Parallel()
 			ctrl := gomock.NewController(t)
 			sync := tt.chainSyncBuilder(tt.args.header, ctrl)
-			err := sync.setBlockAnnounce(tt.args.from, tt.args.header)
-			if tt.wantErr != nil {
+			gossip, err := sync.setBlockAnnounce(tt.args.from, tt.args.header)
+			switch {
+			case tt.wantErr != nil:
 				assert.EqualError(t, err, tt.wantErr.Error())
-			} else {
+			case tt.errWrapped != nil:
+				assert.ErrorIs(t, err, tt.errWrapped)
+			default:
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.gossip, gossip)
 
 			if sync.workQueue != nil {
 				assert.Equal(t, len(sync.workQueue), 1)
@@ -1659,3 +1756,143 @@ func newTestChainSync(ctrl *gomock.Controller) *chainSync {
 	readyBlocks := newBlockQueue(maxResponseSize)
 	return newTestChainSyncWithReadyBlocks(ctrl, readyBlocks)
 }
+
+func Test_chainSync_requestBlocks(t *testing.T) {
+	t.Parallel()
+
+	const requestedData = network.RequestedDataHeader + network.RequestedDataBody
+	who := peer.ID("noot")
+	max := uint32(10)
+
+	tests := map[string]struct {
+		chainLength   uint
+		peerBest      uint
+		failAfterCall int
+		req           *network.BlockRequestMessage
+		wantNumbers   []uint
+		wantCalls     int
+	}{
+		"ascending_range_in_chunks": {
+			chainLength: 20,
+			peerBest:    20,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			wantCalls:   5,
+		},
+		"descending_range_in_chunks": {
+			chainLength: 20,
+			peerBest:    20,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(12),
+				Direction:     network.Descending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{12, 11, 10, 9, 8, 7, 6, 5, 4, 3},
+			wantCalls:   5,
+		},
+		"descending_down_to_first_block": {
+			chainLength: 20,
+			peerBest:    20,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(5),
+				Direction:     network.Descending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{5, 4, 3, 2, 1},
+			wantCalls:   2,
+		},
+		"peer_best_block_reached": {
+			chainLength: 5,
+			peerBest:    5,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1, 2, 3, 4, 5},
+			wantCalls:   2,
+		},
+		"truncated_final_chunk": {
+			chainLength: 7,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1, 2, 3, 4, 5, 6, 7},
+			wantCalls:   4,
+		},
+		"peer_stops_mid_stream": {
+			chainLength:   20,
+			peerBest:      20,
+			failAfterCall: 2,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1, 2, 3, 4, 5},
+			wantCalls:   3,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			blockState, chain := newTestChainBlockState(ctrl, tt.chainLength)
+			encoded, err := (&network.BlockResponseMessage{BlockData: chain[1:2]}).Encode()
+			require.NoError(t, err)
+			// The peer serves block responses of at most 3 blocks.
+			server := &Service{
+				blockState:       blockState,
+				maxResponseBytes: 3 * uint(len(encoded)),
+			}
+
+			var calls int
+			blockReqRes := NewMockRequestMaker(ctrl)
+			blockReqRes.EXPECT().Do(who, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ peer.ID, req network.Message, resp network.ResponseMessage) error {
+					calls++
+					if tt.failAfterCall > 0 && calls > tt.failAfterCall {
+						return errors.New("stream reset")
+					}
+					served, err := server.CreateBlockResponse(req.(*network.BlockRequestMessage))
+					if err != nil {
+						return err
+					}
+					*resp.(*network.BlockResponseMessage) = *served
+					return nil
+				}).AnyTimes()
+
+			cs := &chainSync{
+				blockReqRes: blockReqRes,
+				peerState:   map[peer.ID]*peerState{},
+			}
+			if tt.peerBest > 0 {
+				cs.peerState[who] = &peerState{number: tt.peerBest}
+			}
+
+			resp, err := cs.requestBlocks(who, tt.req)
+			require.NoError(t, err)
+
+			want := make([]*types.BlockData, len(tt.wantNumbers))
+			for i, number := range tt.wantNumbers {
+				want[i] = chain[number]
+			}
+			assert.Equal(t, want, resp.BlockData)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
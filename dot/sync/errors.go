@@ -18,6 +18,7 @@
 	ErrInvalidBlockRequest     = errors.New("invalid block request")
 	errInvalidRequestDirection = errors.New("invalid request direction")
 	errRequestStartTooHigh     = errors.New("request start number is higher than our best block")
+	errBodyNotServed           = errors.New("block body is not served")
 
 	// chainSync errors
 	errEmptyBlockData               = errors.New("empty block data")
@@ -31,7 +32,22 @@
 	errUnknownParent                = errors.New("parent of first block in block response is unknown")
 	errUnknownBlockForJustification = errors.New("received justification for unknown block")
 	errFailedToGetParent            = errors.New("failed to get parent header")
-	errStartAndEndMismatch          = errors.New("request start and end hash are not on the same chain")
-	errFailedToGetDescendant        = errors.New("failed to find descendant block")
 	errBadBlock                     = errors.New("known bad block")
+	errInvalidBlockAnnounce         = errors.New("invalid block announce")
+	errInherentsCheckFailed         = errors.New("inherents check failed")
+
+	// fastSyncer errors
+	errEmptyStateResponse      = errors.New("empty state response")
+	errStateResponseNoProgress = errors.New("state response does not progress the state download")
+	errChildTrieNotDownloaded  = errors.New("child trie not downloaded")
+	errChildTrieRootMismatch   = errors.New("child trie root hash mismatch")
+	errStateRootMismatch       = errors.New("state root hash mismatch")
+
+	// state response errors
+	errStateProofNotSupported   = errors.New("state proofs are not supported")
+	errStateRequestStartInvalid = errors.New("state request start is not valid")
+
+	// WarpSyncer errors
+	errWarpSyncProofEmpty      = errors.New("warp sync proof is empty")
+	errWarpSyncProofNoProgress = errors.New("warp sync proof does not progress from begin block")
 )
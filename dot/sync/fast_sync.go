@@ -0,0 +1,573 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/variadic"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// fastSyncHeadersPerRequest is the maximum number
+	// of headers requested in a single block request.
+	fastSyncHeadersPerRequest = 128
+	// fastSyncRetryInterval is the time waited before
+	// retrying fast sync after it failed.
+	fastSyncRetryInterval = 5 * time.Second
+)
+
+// fastSyncer downloads and verifies the headers up to a recent finalised
+// block, and then downloads the state of this block from peers, such that
+// block import can continue from this block without executing all the
+// blocks before it.
+type fastSyncer struct {
+	blockState     BlockState
+	storageState   StorageState
+	network        Network
+	babeVerifier   BabeVerifier
+	finalityGadget FinalityGadget
+	digestHandler  BlockImportDigestHandler
+	blockReqRes    network.RequestMaker
+	stateReqRes    network.RequestMaker
+	minPeers       int
+	retryInterval  time.Duration
+}
+
+type fastSyncerConfig struct {
+	blockState     BlockState
+	storageState   StorageState
+	network        Network
+	babeVerifier   BabeVerifier
+	finalityGadget FinalityGadget
+	digestHandler  BlockImportDigestHandler
+	minPeers       int
+}
+
+func newFastSyncer(cfg fastSyncerConfig, blockReqRes, stateReqRes network.RequestMaker) *fastSyncer {
+	return &fastSyncer{
+		blockState:     cfg.blockState,
+		storageState:   cfg.storageState,
+		network:        cfg.network,
+		babeVerifier:   cfg.babeVerifier,
+		finalityGadget: cfg.finalityGadget,
+		digestHandler:  cfg.digestHandler,
+		blockReqRes:    blockReqRes,
+		stateReqRes:    stateReqRes,
+		minPeers:       cfg.minPeers,
+		retryInterval:  fastSyncRetryInterval,
+	}
+}
+
+// sync fast syncs the chain, and retries until it succeeds
+// or the context is canceled.
+func (f *fastSyncer) sync(ctx context.Context) (err error) {
+	return f.retry(ctx, "fast sync", f.syncOnce)
+}
+
+// retry waits for peers and runs syncOnce until it succeeds or
+// the context is canceled, waiting the retry interval between runs.
+func (f *fastSyncer) retry(ctx context.Context, name string,
+	syncOnce func(ctx context.Context) error) (err error) {
+	err = f.waitForPeers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err = syncOnce(ctx)
+		if err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		logger.Warnf("%s failed, retrying in %s: %s", name, f.retryInterval, err)
+		timer := time.NewTimer(f.retryInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// waitForPeers waits until we are connected to at least
+// the minimum number of peers, and to at least one peer.
+func (f *fastSyncer) waitForPeers(ctx context.Context) (err error) {
+	minPeers := f.minPeers
+	if minPeers < 1 {
+		minPeers = 1
+	}
+
+	const checkPeriod = 100 * time.Millisecond
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+
+	for len(f.network.Peers()) < minPeers {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (f *fastSyncer) syncOnce(ctx context.Context) (err error) {
+	target, err := f.syncHeaders(ctx)
+	if err != nil {
+		return fmt.Errorf("syncing headers: %w", err)
+	}
+
+	hasState, err := f.hasState(target)
+	if err != nil {
+		return fmt.Errorf("checking state of block number %d: %w", target.Number, err)
+	} else if hasState {
+		logger.Infof("state of finalised block number %d with hash %s is already stored",
+			target.Number, target.Hash())
+		return nil
+	}
+
+	logger.Infof("downloading state of finalised block number %d with hash %s...",
+		target.Number, target.Hash())
+	stateTrie, err := f.downloadState(ctx, target)
+	if err != nil {
+		return fmt.Errorf("downloading state of block number %d: %w", target.Number, err)
+	}
+
+	err = f.importState(target, stateTrie)
+	if err != nil {
+		return fmt.Errorf("importing state of block number %d: %w", target.Number, err)
+	}
+
+	logger.Infof("⏩ fast synced state of finalised block number %d with hash %s",
+		target.Number, target.Hash())
+	return nil
+}
+
+// fastSyncHeader is a downloaded header not yet imported,
+// with the peer which sent it.
+type fastSyncHeader struct {
+	header *types.Header
+	from   peer.ID
+}
+
+// syncHeaders downloads the headers from our highest finalised block, and
+// imports them up to the last downloaded header with a justification, once
+// this justification is verified. It returns the header of the last block
+// finalised, which is our highest finalised header if no justification is
+// found in the headers downloaded. The headers downloaded after the last
+// justification are discarded.
+func (f *fastSyncer) syncHeaders(ctx context.Context) (target *types.Header, err error) {
+	target, err = f.blockState.GetHighestFinalisedHeader()
+	if err != nil {
+		return nil, fmt.Errorf("getting highest finalised header: %w", err)
+	}
+
+	var pending []fastSyncHeader
+	tip := target
+	peersFailed := make(map[peer.ID]struct{})
+	for ctx.Err() == nil {
+		who, ok := f.selectPeer(tip.Number+1, peersFailed)
+		if !ok {
+			break
+		}
+
+		blockData, err := f.requestHeaders(who, tip)
+		if err != nil {
+			logger.Debugf("requesting headers after block number %d from peer %s: %s",
+				tip.Number, who, err)
+			peersFailed[who] = struct{}{}
+			continue
+		}
+
+		for _, bd := range blockData {
+			pending = append(pending, fastSyncHeader{header: bd.Header, from: who})
+			if bd.Justification == nil || len(*bd.Justification) == 0 {
+				continue
+			}
+
+			err = f.importHeaders(pending, *bd.Justification, who)
+			if err != nil {
+				return nil, err
+			}
+			target = bd.Header
+			pending = nil
+		}
+		tip = blockData[len(blockData)-1].Header
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(pending) > 0 {
+		logger.Debugf("discarding %d headers after the last finalised block number %d",
+			len(pending), target.Number)
+	}
+	return target, nil
+}
+
+// selectPeer returns the peer with the highest best block number,
+// where this number is at least the minimum block number given and
+// the peer is not one of the peers to exclude.
+func (f *fastSyncer) selectPeer(minBestNumber uint, exclude map[peer.ID]struct{}) (
+	who peer.ID, ok bool) {
+	var bestNumber uint
+	for _, peerInfo := range f.network.Peers() {
+		peerID, err := peer.Decode(peerInfo.PeerID)
+		if err != nil {
+			logger.Debugf("decoding peer id %s: %s", peerInfo.PeerID, err)
+			continue
+		}
+
+		if _, excluded := exclude[peerID]; excluded {
+			continue
+		}
+
+		peerBestNumber := uint(peerInfo.BestNumber)
+		if peerBestNumber < minBestNumber || (ok && peerBestNumber <= bestNumber) {
+			continue
+		}
+
+		who, bestNumber, ok = peerID, peerBestNumber, true
+	}
+	return who, ok
+}
+
+// requestHeaders requests the headers and justifications of the blocks
+// following the header given from the peer given, and checks they form
+// a chain descending from this header.
+func (f *fastSyncer) requestHeaders(who peer.ID, from *types.Header) (
+	blockData []*types.BlockData, err error) {
+	startingBlock := variadic.MustNewUint32OrHash(uint32(from.Number + 1))
+	max := uint32(fastSyncHeadersPerRequest)
+	request := &network.BlockRequestMessage{
+		RequestedData: network.RequestedDataHeader + network.RequestedDataJustification,
+		StartingBlock: *startingBlock,
+		Direction:     network.Ascending,
+		Max:           &max,
+	}
+
+	response := new(network.BlockResponseMessage)
+	err = f.blockReqRes.Do(who, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.BlockData) == 0 {
+		return nil, errEmptyBlockData
+	}
+
+	parent := from
+	for _, bd := range response.BlockData {
+		if bd == nil {
+			return nil, errNilBlockData
+		} else if bd.Header == nil {
+			return nil, fmt.Errorf("%w: for block hash %s", errNilHeaderInResponse, bd.Hash)
+		}
+
+		if bd.Header.ParentHash != parent.Hash() || bd.Header.Number != parent.Number+1 {
+			f.network.ReportPeer(peerset.ReputationChange{
+				Value:  peerset.IncompleteHeaderValue,
+				Reason: peerset.IncompleteHeaderReason,
+			}, who)
+			return nil, fmt.Errorf("%w: block number %d with parent hash %s does not follow block number %d",
+				errResponseIsNotChain, bd.Header.Number, bd.Header.ParentHash, parent.Number)
+		}
+		parent = bd.Header
+	}
+
+	return response.BlockData, nil
+}
+
+// importHeaders verifies and imports the pending headers given in the block
+// tree and handles their consensus digests, and then verifies the
+// justification given for the last pending header, which finalises it.
+func (f *fastSyncer) importHeaders(pending []fastSyncHeader,
+	justification []byte, justificationFrom peer.ID) (err error) {
+	for _, pendingHeader := range pending {
+		header := pendingHeader.header
+		err = f.babeVerifier.VerifyBlock(header)
+		if err != nil {
+			f.network.ReportPeer(peerset.ReputationChange{
+				Value:  peerset.BadBlockAnnouncementValue,
+				Reason: peerset.BadBlockAnnouncementReason,
+			}, pendingHeader.from)
+			return fmt.Errorf("babe verifying header of block number %d: %w", header.Number, err)
+		}
+
+		err = f.blockState.AddHeaderToBlockTree(header)
+		if errors.Is(err, blocktree.ErrBlockExists) {
+			// imported during a previous fast sync attempt
+			continue
+		} else if err != nil {
+			return fmt.Errorf("adding header of block number %d to block tree: %w", header.Number, err)
+		}
+
+		err = f.digestHandler.Handle(header)
+		if err != nil {
+			return fmt.Errorf("handling digests of block number %d: %w", header.Number, err)
+		}
+	}
+
+	header := pending[len(pending)-1].header
+	headerHash := header.Hash()
+	err = f.finalityGadget.VerifyBlockJustification(headerHash, justification)
+	if err != nil {
+		f.network.ReportPeer(peerset.ReputationChange{
+			Value:  peerset.BadJustificationValue,
+			Reason: peerset.BadJustificationReason,
+		}, justificationFrom)
+		return fmt.Errorf("verifying block number %d justification: %w", header.Number, err)
+	}
+
+	logger.Infof("🔨 finalised block number %d with hash %s", header.Number, headerHash)
+	return nil
+}
+
+// hasState returns true if the state trie of the block header
+// given is stored in the database.
+func (f *fastSyncer) hasState(header *types.Header) (has bool, err error) {
+	_, err = f.storageState.TrieState(&header.StateRoot)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// downloadState downloads the state of the block header given from peers.
+// The download continues with another peer if a state request fails, and
+// restarts with peers not involved if the state root downloaded does not
+// match the state root of the header.
+func (f *fastSyncer) downloadState(ctx context.Context, target *types.Header) (
+	stateTrie *trie.Trie, err error) {
+	targetHash := target.Hash()
+	peersExcluded := make(map[peer.ID]struct{})
+	download := newStateDownload()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if download.complete {
+			stateTrie, err = download.buildTrie(target.StateRoot)
+			if err == nil {
+				return stateTrie, nil
+			}
+
+			logger.Warnf("state downloaded for block number %d is invalid: %s", target.Number, err)
+			for who := range download.peers {
+				f.network.ReportPeer(peerset.ReputationChange{
+					Value:  peerset.BadMessageValue,
+					Reason: peerset.BadMessageReason,
+				}, who)
+				peersExcluded[who] = struct{}{}
+			}
+			download = newStateDownload()
+			continue
+		}
+
+		who, ok := f.selectPeer(target.Number, peersExcluded)
+		if !ok {
+			return nil, errNoPeers
+		}
+
+		request := download.nextRequest(targetHash)
+		response := new(network.StateResponseMessage)
+		err = f.stateReqRes.Do(who, request, response)
+		if err == nil {
+			err = download.handleResponse(who, response)
+		}
+
+		if err != nil {
+			logger.Debugf("requesting state of block number %d from peer %s: %s",
+				target.Number, who, err)
+			peersExcluded[who] = struct{}{}
+			continue
+		}
+	}
+}
+
+// importState stores the state trie given for the block header given,
+// and instantiates its runtime if its code differs from the code of
+// the runtime of the block.
+func (f *fastSyncer) importState(target *types.Header, stateTrie *trie.Trie) (err error) {
+	f.storageState.Lock()
+	defer f.storageState.Unlock()
+
+	state := rtstorage.NewTrieState(stateTrie)
+	err = f.storageState.StoreTrie(state, target)
+	if err != nil {
+		return fmt.Errorf("storing state trie: %w", err)
+	}
+
+	targetHash := target.Hash()
+	runtimeInstance, err := f.blockState.GetRuntime(targetHash)
+	if err != nil {
+		return fmt.Errorf("getting runtime: %w", err)
+	}
+
+	err = f.blockState.HandleRuntimeChanges(state, runtimeInstance, targetHash)
+	if err != nil {
+		return fmt.Errorf("handling runtime changes: %w", err)
+	}
+
+	return nil
+}
+
+// stateDownload accumulates the storage entries
+// downloaded from state responses.
+type stateDownload struct {
+	top *trie.Trie
+	// childTries maps the root hash of each child
+	// trie to the child trie downloaded so far.
+	childTries map[common.Hash]*trie.Trie
+	// start is the start key of the next state request.
+	start [][]byte
+	// complete is true once all the storage entries are downloaded.
+	complete bool
+	// peers contains the peers which sent storage entries.
+	peers map[peer.ID]struct{}
+}
+
+func newStateDownload() *stateDownload {
+	return &stateDownload{
+		top:        trie.NewEmptyTrie(),
+		childTries: make(map[common.Hash]*trie.Trie),
+		peers:      make(map[peer.ID]struct{}),
+	}
+}
+
+func (s *stateDownload) nextRequest(block common.Hash) *network.StateRequestMessage {
+	return &network.StateRequestMessage{
+		Block:   block,
+		Start:   s.start,
+		NoProof: true,
+	}
+}
+
+// handleResponse adds the storage entries of the response given to the
+// tries downloaded, and updates the start key of the next request.
+// The start key is the last key of the top trie, followed by the last key
+// of the child trie being downloaded, if there is one.
+func (s *stateDownload) handleResponse(from peer.ID, response *network.StateResponseMessage) (err error) {
+	if len(response.Entries) == 0 {
+		return errEmptyStateResponse
+	}
+
+	var start [][]byte
+	complete := true
+	for i, keyValueStateEntry := range response.Entries {
+		if keyValueStateEntry.Complete {
+			continue
+		}
+		complete = false
+
+		entries := keyValueStateEntry.Entries
+		switch {
+		case len(entries) > 0:
+			start = append(start, entries[len(entries)-1].Key)
+		case i < len(s.start):
+			// No entry is returned for this trie, for example for the
+			// top trie when downloading a child trie, so its start key
+			// stays the same.
+			start = append(start, s.start[i])
+		}
+	}
+
+	if !complete && startsEqual(start, s.start) {
+		return errStateResponseNoProgress
+	}
+
+	for _, keyValueStateEntry := range response.Entries {
+		t := s.top
+		if keyValueStateEntry.StateRoot != (common.Hash{}) {
+			t = s.childTries[keyValueStateEntry.StateRoot]
+			if t == nil {
+				t = trie.NewEmptyTrie()
+				s.childTries[keyValueStateEntry.StateRoot] = t
+			}
+		}
+
+		for _, entry := range keyValueStateEntry.Entries {
+			err = t.Put(entry.Key, entry.Value)
+			if err != nil {
+				return fmt.Errorf("putting storage entry at key 0x%x: %w", entry.Key, err)
+			}
+		}
+	}
+
+	s.start = start
+	s.complete = complete
+	s.peers[from] = struct{}{}
+	return nil
+}
+
+func startsEqual(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildTrie sets the child tries downloaded in the top trie downloaded,
+// and checks the root hash of each child trie and of the top trie.
+func (s *stateDownload) buildTrie(stateRoot common.Hash) (stateTrie *trie.Trie, err error) {
+	for _, key := range s.top.GetKeysWithPrefix(trie.ChildStorageKeyPrefix) {
+		childRoot := common.BytesToHash(s.top.Get(key))
+		child, ok := s.childTries[childRoot]
+		if !ok {
+			return nil, fmt.Errorf("%w: for child storage key 0x%x and root hash %s",
+				errChildTrieNotDownloaded, key, childRoot)
+		}
+
+		childHash, err := child.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("hashing child trie: %w", err)
+		} else if childHash != childRoot {
+			return nil, fmt.Errorf("%w: for child storage key 0x%x, expected %s but got %s",
+				errChildTrieRootMismatch, key, childRoot, childHash)
+		}
+
+		err = s.top.SetChild(key[len(trie.ChildStorageKeyPrefix):], child)
+		if err != nil {
+			return nil, fmt.Errorf("setting child trie at child storage key 0x%x: %w", key, err)
+		}
+	}
+
+	root, err := s.top.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("hashing state trie: %w", err)
+	} else if root != stateRoot {
+		return nil, fmt.Errorf("%w: expected %s but got %s",
+			errStateRootMismatch, stateRoot, root)
+	}
+
+	return s.top, nil
+}
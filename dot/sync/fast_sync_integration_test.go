@@ -0,0 +1,300 @@
+//go:build integration
+
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/ChainSafe/gossamer/lib/common"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveStateRequest responds to the state request given with at most
+// maxEntries storage entries of the state trie given, in the same way
+// as Substrate does.
+func serveStateRequest(stateTrie *trie.Trie, request *network.StateRequestMessage,
+	maxEntries int) (response *network.StateResponseMessage, err error) {
+	top := network.KeyValueStateEntry{}
+	var children []network.KeyValueStateEntry
+	count := 0
+
+	// childEntries appends the entries of the child trie at the child
+	// storage key given after the key given, and returns true if the
+	// child trie entries are complete.
+	childEntries := func(childStorageKey, after []byte) (complete bool, err error) {
+		child, err := stateTrie.GetChild(childStorageKey[len(trie.ChildStorageKeyPrefix):])
+		if err != nil {
+			return false, err
+		}
+
+		childEntry := network.KeyValueStateEntry{
+			StateRoot: child.MustHash(),
+			Complete:  true,
+		}
+		for _, key := range child.GetKeysWithPrefix(nil) {
+			if after != nil && bytes.Compare(key, after) <= 0 {
+				continue
+			} else if count >= maxEntries {
+				childEntry.Complete = false
+				break
+			}
+			childEntry.Entries = append(childEntry.Entries, network.StateEntry{Key: key, Value: child.Get(key)})
+			count++
+		}
+		children = append(children, childEntry)
+		return childEntry.Complete, nil
+	}
+
+	response = &network.StateResponseMessage{}
+	defer func() {
+		response.Entries = append([]network.KeyValueStateEntry{top}, children...)
+	}()
+
+	var topStart []byte
+	if len(request.Start) > 0 {
+		topStart = request.Start[0]
+	}
+
+	if len(request.Start) == 2 {
+		complete, err := childEntries(request.Start[0], request.Start[1])
+		if err != nil {
+			return nil, err
+		} else if !complete {
+			return response, nil
+		}
+	}
+
+	for _, key := range stateTrie.GetKeysWithPrefix(nil) {
+		if topStart != nil && bytes.Compare(key, topStart) <= 0 {
+			continue
+		} else if count >= maxEntries {
+			return response, nil
+		}
+
+		top.Entries = append(top.Entries, network.StateEntry{Key: key, Value: stateTrie.Get(key)})
+		count++
+
+		if bytes.HasPrefix(key, trie.ChildStorageKeyPrefix) {
+			complete, err := childEntries(key, nil)
+			if err != nil {
+				return nil, err
+			} else if !complete {
+				return response, nil
+			}
+		}
+	}
+
+	top.Complete = true
+	return response, nil
+}
+
+func Test_fastSyncer_sync(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(gomock.Any()).AnyTimes()
+
+	stateSrvc := state.NewService(state.Config{
+		Path:      t.TempDir(),
+		LogLevel:  log.Info,
+		Telemetry: telemetryMock,
+	})
+	stateSrvc.UseMemDB()
+
+	gen, genTrie, genHeader := newWestendDevGenesisWithTrieAndHeader(t)
+	err := stateSrvc.Initialise(&gen, &genHeader, &genTrie)
+	require.NoError(t, err)
+	err = stateSrvc.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := stateSrvc.Stop()
+		require.NoError(t, err)
+	})
+
+	rtCfg := wasmer.Config{
+		Storage: rtstorage.NewTrieState(&genTrie),
+		LogLvl:  log.Critical,
+	}
+	rtCfg.NodeStorage.BaseDB = stateSrvc.Base
+	rtCfg.CodeHash, err = stateSrvc.Storage.LoadCodeHash(nil)
+	require.NoError(t, err)
+	instance, err := wasmer.NewRuntimeFromGenesis(rtCfg)
+	require.NoError(t, err)
+	stateSrvc.Block.StoreRuntime(genHeader.Hash(), instance)
+
+	// The state to download is the genesis state with
+	// additional storage entries, and a child trie.
+	targetTrie := genTrie.DeepCopy()
+	err = targetTrie.Put([]byte("fast_sync"), []byte("value"))
+	require.NoError(t, err)
+	childTrie := trie.NewEmptyTrie()
+	for i := 0; i < 30; i++ {
+		err = childTrie.Put([]byte(fmt.Sprintf("child_key_%02d", i)), []byte{byte(i)})
+		require.NoError(t, err)
+	}
+	err = targetTrie.SetChild([]byte("child"), childTrie)
+	require.NoError(t, err)
+	targetRoot := targetTrie.MustHash()
+
+	// The tampered state only differs by the value of one storage entry.
+	tamperedTrie := targetTrie.DeepCopy()
+	err = tamperedTrie.Put([]byte("fast_sync"), []byte("tampered"))
+	require.NoError(t, err)
+
+	// Blocks 1 to 4 are served, and only block 3 has a justification,
+	// so block 3 is the fast sync target and block 4 is not imported.
+	headers := make([]*types.Header, 4)
+	parent := &genHeader
+	for i := range headers {
+		headers[i] = &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			StateRoot:  targetRoot,
+			Digest:     types.NewDigest(),
+		}
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, uint64(i+1)).ToPreRuntimeDigest()
+		require.NoError(t, err)
+		err = headers[i].Digest.Add(*preRuntimeDigest)
+		require.NoError(t, err)
+		parent = headers[i]
+	}
+	target := headers[2]
+	justification := []byte{3}
+
+	peerA := mustDecodePeer(t, testPeerA)
+	peerB := mustDecodePeer(t, testPeerB)
+
+	networkMock := NewMockNetwork(ctrl)
+	// Peers A and B have the highest best block numbers, so they are
+	// requested first. Peer A fails its second state request, and peer B
+	// serves a tampered state, so the state is downloaded again from peer C.
+	networkMock.EXPECT().Peers().Return([]common.PeerInfo{
+		{PeerID: testPeerA, BestNumber: 6},
+		{PeerID: testPeerB, BestNumber: 5},
+		{PeerID: testPeerC, BestNumber: 4},
+	}).AnyTimes()
+	badMessage := peerset.ReputationChange{
+		Value:  peerset.BadMessageValue,
+		Reason: peerset.BadMessageReason,
+	}
+	networkMock.EXPECT().ReportPeer(badMessage, peerA)
+	networkMock.EXPECT().ReportPeer(badMessage, peerB)
+
+	blockReqRes := NewMockRequestMaker(ctrl)
+	blockReqRes.EXPECT().Do(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ peer.ID, req network.Message, res network.ResponseMessage) error {
+			request := req.(*network.BlockRequestMessage)
+			response := res.(*network.BlockResponseMessage)
+			for _, header := range headers {
+				if header.Number < uint(request.StartingBlock.Uint32()) {
+					continue
+				}
+				bd := &types.BlockData{Hash: header.Hash(), Header: header}
+				if header == target {
+					bd.Justification = &justification
+				}
+				response.BlockData = append(response.BlockData, bd)
+			}
+			return nil
+		}).AnyTimes()
+
+	const maxEntries = 20
+	stateRequestsFromA := 0
+	stateReqRes := NewMockRequestMaker(ctrl)
+	stateReqRes.EXPECT().Do(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(who peer.ID, req network.Message, res network.ResponseMessage) error {
+			request := req.(*network.StateRequestMessage)
+			require.Equal(t, target.Hash(), request.Block)
+			require.True(t, request.NoProof)
+
+			stateTrie := targetTrie
+			switch who {
+			case peerA:
+				stateRequestsFromA++
+				if stateRequestsFromA == 2 {
+					return errors.New("test error")
+				}
+			case peerB:
+				stateTrie = tamperedTrie
+			}
+
+			response, err := serveStateRequest(stateTrie, request, maxEntries)
+			if err != nil {
+				return err
+			}
+			*res.(*network.StateResponseMessage) = *response
+			return nil
+		}).AnyTimes()
+
+	babeVerifier := NewMockBabeVerifier(ctrl)
+	babeVerifier.EXPECT().VerifyBlock(gomock.AssignableToTypeOf(&types.Header{})).Times(3)
+
+	finalityGadget := NewMockFinalityGadget(ctrl)
+	finalityGadget.EXPECT().VerifyBlockJustification(target.Hash(), justification).
+		DoAndReturn(func(hash common.Hash, _ []byte) error {
+			return stateSrvc.Block.SetFinalisedHash(hash, 1, 0)
+		})
+
+	digestHandler := NewMockBlockImportDigestHandler(ctrl)
+	digestHandler.EXPECT().Handle(gomock.AssignableToTypeOf(&types.Header{})).Times(3)
+
+	syncer := newFastSyncer(fastSyncerConfig{
+		blockState:     stateSrvc.Block,
+		storageState:   stateSrvc.Storage,
+		network:        networkMock,
+		babeVerifier:   babeVerifier,
+		finalityGadget: finalityGadget,
+		digestHandler:  digestHandler,
+		minPeers:       1,
+	}, blockReqRes, stateReqRes)
+
+	err = syncer.sync(context.Background())
+	require.NoError(t, err)
+
+	finalisedHash, err := stateSrvc.Block.GetHighestFinalisedHash()
+	require.NoError(t, err)
+	assert.Equal(t, target.Hash(), finalisedHash)
+	assert.Equal(t, target.Hash(), stateSrvc.Block.BestBlockHash())
+
+	has, err := stateSrvc.Block.HasHeader(headers[3].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	justificationStored, err := stateSrvc.Block.GetJustification(target.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, justification, justificationStored)
+
+	// the bodies of the fast synced blocks are not downloaded
+	hasBody, err := stateSrvc.Block.HasBlockBody(target.Hash())
+	require.NoError(t, err)
+	assert.False(t, hasBody)
+	_, err = stateSrvc.Block.GetBlockBody(target.Hash())
+	assert.ErrorIs(t, err, state.ErrBodiesNotStored)
+
+	trieState, err := stateSrvc.Storage.TrieState(&targetRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), trieState.Get([]byte("fast_sync")))
+	childValue, err := trieState.GetChildStorage([]byte("child"), []byte("child_key_29"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{29}, childValue)
+}
@@ -0,0 +1,380 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/variadic"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPeerA = "12D3KooWRc1iXpvfzf88rTxTxviboRX91qLSa3S5ER5sMvLLhNSm"
+	testPeerB = "12D3KooWCUEsjdVb6oTAC48PsEqyLwUDnwHKwnSzhLCSk3QrPU7M"
+	testPeerC = "12D3KooWE8VwSLKqKnTMCge6jK2NtQs8KxyT4PvqhGHQGkoEhUGx"
+)
+
+func mustDecodePeer(t *testing.T, s string) peer.ID {
+	t.Helper()
+	peerID, err := peer.Decode(s)
+	require.NoError(t, err)
+	return peerID
+}
+
+func Test_fastSyncer_selectPeer(t *testing.T) {
+	t.Parallel()
+
+	peers := []common.PeerInfo{
+		{PeerID: "invalid", BestNumber: 100},
+		{PeerID: testPeerA, BestNumber: 10},
+		{PeerID: testPeerB, BestNumber: 20},
+		{PeerID: testPeerC, BestNumber: 20},
+	}
+
+	testCases := map[string]struct {
+		minBestNumber uint
+		exclude       []string
+		who           string
+		ok            bool
+	}{
+		"highest_best_number": {
+			minBestNumber: 5,
+			who:           testPeerB,
+			ok:            true,
+		},
+		"excluded_peer": {
+			minBestNumber: 5,
+			exclude:       []string{testPeerB},
+			who:           testPeerC,
+			ok:            true,
+		},
+		"best_number_too_low": {
+			minBestNumber: 15,
+			exclude:       []string{testPeerB, testPeerC},
+		},
+		"minimum_best_number": {
+			minBestNumber: 10,
+			exclude:       []string{testPeerB, testPeerC},
+			who:           testPeerA,
+			ok:            true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			network := NewMockNetwork(ctrl)
+			network.EXPECT().Peers().Return(peers)
+			exclude := make(map[peer.ID]struct{}, len(testCase.exclude))
+			for _, s := range testCase.exclude {
+				exclude[mustDecodePeer(t, s)] = struct{}{}
+			}
+			syncer := &fastSyncer{network: network}
+
+			who, ok := syncer.selectPeer(testCase.minBestNumber, exclude)
+
+			assert.Equal(t, testCase.ok, ok)
+			if testCase.ok {
+				assert.Equal(t, mustDecodePeer(t, testCase.who), who)
+			}
+		})
+	}
+}
+
+func Test_fastSyncer_requestHeaders(t *testing.T) {
+	t.Parallel()
+
+	who := mustDecodePeer(t, testPeerA)
+	from := &types.Header{Number: 1}
+	header2 := &types.Header{ParentHash: from.Hash(), Number: 2}
+	header3 := &types.Header{ParentHash: header2.Hash(), Number: 3}
+	errTest := errors.New("test error")
+
+	testCases := map[string]struct {
+		blockData  []*types.BlockData
+		doErr      error
+		reportPeer bool
+		expected   []*types.BlockData
+		errWrapped error
+		errMessage string
+	}{
+		"request_error": {
+			doErr:      errTest,
+			errWrapped: errTest,
+			errMessage: "test error",
+		},
+		"empty_response": {
+			errWrapped: errEmptyBlockData,
+			errMessage: "empty block data",
+		},
+		"missing_header": {
+			blockData:  []*types.BlockData{{Hash: common.Hash{1}}},
+			errWrapped: errNilHeaderInResponse,
+			errMessage: "expected header, received none: for block hash " +
+				"0x0100000000000000000000000000000000000000000000000000000000000000",
+		},
+		"not_a_chain": {
+			blockData: []*types.BlockData{
+				{Hash: header3.Hash(), Header: header3},
+			},
+			reportPeer: true,
+			errWrapped: errResponseIsNotChain,
+			errMessage: "block response does not form a chain: block number 3 with parent hash " +
+				header2.Hash().String() + " does not follow block number 1",
+		},
+		"success": {
+			blockData: []*types.BlockData{
+				{Hash: header2.Hash(), Header: header2},
+				{Hash: header3.Hash(), Header: header3},
+			},
+			expected: []*types.BlockData{
+				{Hash: header2.Hash(), Header: header2},
+				{Hash: header3.Hash(), Header: header3},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			max := uint32(fastSyncHeadersPerRequest)
+			expectedRequest := &network.BlockRequestMessage{
+				RequestedData: network.RequestedDataHeader + network.RequestedDataJustification,
+				StartingBlock: *variadic.MustNewUint32OrHash(uint32(2)),
+				Direction:     network.Ascending,
+				Max:           &max,
+			}
+			blockReqRes := NewMockRequestMaker(ctrl)
+			blockReqRes.EXPECT().Do(who, expectedRequest, gomock.Any()).
+				DoAndReturn(func(_ peer.ID, _ network.Message, response network.ResponseMessage) error {
+					response.(*network.BlockResponseMessage).BlockData = testCase.blockData
+					return testCase.doErr
+				})
+			networkMock := NewMockNetwork(ctrl)
+			if testCase.reportPeer {
+				networkMock.EXPECT().ReportPeer(peerset.ReputationChange{
+					Value:  peerset.IncompleteHeaderValue,
+					Reason: peerset.IncompleteHeaderReason,
+				}, who)
+			}
+			syncer := &fastSyncer{
+				network:     networkMock,
+				blockReqRes: blockReqRes,
+			}
+
+			blockData, err := syncer.requestHeaders(who, from)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.expected, blockData)
+		})
+	}
+}
+
+func Test_stateDownload_handleResponse(t *testing.T) {
+	t.Parallel()
+
+	who := mustDecodePeer(t, testPeerA)
+	childRoot := common.Hash{1}
+	childStorageKey := append(append([]byte{}, trie.ChildStorageKeyPrefix...), 'c')
+
+	download := newStateDownload()
+	assert.Equal(t, &network.StateRequestMessage{
+		Block:   common.Hash{2},
+		NoProof: true,
+	}, download.nextRequest(common.Hash{2}))
+
+	err := download.handleResponse(who, &network.StateResponseMessage{})
+	assert.ErrorIs(t, err, errEmptyStateResponse)
+
+	// The top trie is partially downloaded.
+	err = download.handleResponse(who, &network.StateResponseMessage{
+		Entries: []network.KeyValueStateEntry{{
+			Entries: []network.StateEntry{
+				{Key: []byte{':', 'a'}, Value: []byte{1}},
+				{Key: []byte{':', 'b'}, Value: []byte{2}},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{':', 'b'}}, download.start)
+	assert.False(t, download.complete)
+
+	// The response does not contain any new entry.
+	err = download.handleResponse(who, &network.StateResponseMessage{
+		Entries: []network.KeyValueStateEntry{{}},
+	})
+	assert.ErrorIs(t, err, errStateResponseNoProgress)
+	assert.Equal(t, [][]byte{{':', 'b'}}, download.start)
+
+	// The top trie download stops at a child trie partially downloaded.
+	err = download.handleResponse(who, &network.StateResponseMessage{
+		Entries: []network.KeyValueStateEntry{
+			{
+				Entries: []network.StateEntry{
+					{Key: childStorageKey, Value: childRoot.ToBytes()},
+				},
+			},
+			{
+				StateRoot: childRoot,
+				Entries: []network.StateEntry{
+					{Key: []byte{1}, Value: []byte{1}},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{childStorageKey, {1}}, download.start)
+
+	// The response only contains entries of the child trie,
+	// so the top trie start key is kept.
+	err = download.handleResponse(who, &network.StateResponseMessage{
+		Entries: []network.KeyValueStateEntry{
+			{},
+			{
+				StateRoot: childRoot,
+				Entries: []network.StateEntry{
+					{Key: []byte{2}, Value: []byte{2}},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{childStorageKey, {2}}, download.start)
+
+	// The child trie and the top trie are completed.
+	err = download.handleResponse(who, &network.StateResponseMessage{
+		Entries: []network.KeyValueStateEntry{
+			{
+				Entries: []network.StateEntry{
+					{Key: []byte{':', 'd'}, Value: []byte{4}},
+				},
+				Complete: true,
+			},
+			{
+				StateRoot: childRoot,
+				Entries: []network.StateEntry{
+					{Key: []byte{3}, Value: []byte{3}},
+				},
+				Complete: true,
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, download.complete)
+	assert.Empty(t, download.start)
+
+	expectedTop := map[string][]byte{
+		":a":                    {1},
+		":b":                    {2},
+		string(childStorageKey): childRoot.ToBytes(),
+		":d":                    {4},
+	}
+	assert.Equal(t, expectedTop, download.top.Entries())
+	expectedChild := map[string][]byte{
+		string([]byte{1}): {1},
+		string([]byte{2}): {2},
+		string([]byte{3}): {3},
+	}
+	assert.Equal(t, expectedChild, download.childTries[childRoot].Entries())
+	assert.Equal(t, map[peer.ID]struct{}{who: {}}, download.peers)
+}
+
+func Test_stateDownload_buildTrie(t *testing.T) {
+	t.Parallel()
+
+	child := trie.NewEmptyTrie()
+	err := child.Put([]byte{1}, []byte{1})
+	require.NoError(t, err)
+	childRoot := child.MustHash()
+
+	expectedTrie := trie.NewEmptyTrie()
+	err = expectedTrie.Put([]byte{':', 'a'}, []byte{1})
+	require.NoError(t, err)
+	err = expectedTrie.SetChild([]byte{'c'}, child)
+	require.NoError(t, err)
+	stateRoot := expectedTrie.MustHash()
+
+	childStorageKey := append(append([]byte{}, trie.ChildStorageKeyPrefix...), 'c')
+
+	testCases := map[string]struct {
+		childTries map[common.Hash]*trie.Trie
+		stateRoot  common.Hash
+		errWrapped error
+		errMessage string
+	}{
+		"child_trie_not_downloaded": {
+			stateRoot:  stateRoot,
+			errWrapped: errChildTrieNotDownloaded,
+			errMessage: "child trie not downloaded: for child storage key 0x" +
+				common.BytesToHex(childStorageKey)[2:] + " and root hash " + childRoot.String(),
+		},
+		"child_trie_root_mismatch": {
+			childTries: map[common.Hash]*trie.Trie{childRoot: trie.NewEmptyTrie()},
+			stateRoot:  stateRoot,
+			errWrapped: errChildTrieRootMismatch,
+			errMessage: "child trie root hash mismatch: for child storage key 0x" +
+				common.BytesToHex(childStorageKey)[2:] + ", expected " + childRoot.String() +
+				" but got " + trie.EmptyHash.String(),
+		},
+		"state_root_mismatch": {
+			childTries: map[common.Hash]*trie.Trie{childRoot: child},
+			stateRoot:  common.Hash{1},
+			errWrapped: errStateRootMismatch,
+			errMessage: "state root hash mismatch: expected " + common.Hash{1}.String() +
+				" but got " + stateRoot.String(),
+		},
+		"success": {
+			childTries: map[common.Hash]*trie.Trie{childRoot: child},
+			stateRoot:  stateRoot,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			download := newStateDownload()
+			err := download.top.Put([]byte{':', 'a'}, []byte{1})
+			require.NoError(t, err)
+			err = download.top.Put(childStorageKey, childRoot.ToBytes())
+			require.NoError(t, err)
+			if testCase.childTries != nil {
+				download.childTries = testCase.childTries
+			}
+
+			stateTrie, err := download.buildTrie(testCase.stateRoot)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, stateTrie)
+				return
+			}
+			assert.Equal(t, stateRoot, stateTrie.MustHash())
+			childTrie, err := stateTrie.GetChild([]byte{'c'})
+			require.NoError(t, err)
+			assert.Equal(t, childRoot, childTrie.MustHash())
+		})
+	}
+}
@@ -6,8 +6,11 @@
 import (
 	"encoding/json"
 	"sync"
+	"time"
 
+	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/runtime"
@@ -28,26 +31,39 @@ type BlockState interface {
 	RangeInMemory(start, end common.Hash) ([]common.Hash, error)
 	GetReceipt(common.Hash) ([]byte, error)
 	GetMessageQueue(common.Hash) ([]byte, error)
+	HasJustification(hash common.Hash) (bool, error)
 	GetJustification(common.Hash) ([]byte, error)
-	SetJustification(hash common.Hash, data []byte) error
-	AddBlockToBlockTree(block *types.Block) error
+	ImportBlocks(blocks []*types.Block) error
+	AddHeaderToBlockTree(header *types.Header) error
+	SetArrivalTime(hash common.Hash, number uint, arrivalTime time.Time) error
 	GetHashByNumber(blockNumber uint) (common.Hash, error)
 	GetBlockByHash(common.Hash) (*types.Block, error)
+	GetBlocksInRange(from state.BlockID, direction state.Direction, max uint32,
+		withBody, withJustification bool) (blocks []state.RawBlockData, err error)
 	GetRuntime(blockHash common.Hash) (runtime runtime.Instance, err error)
 	StoreRuntime(blockHash common.Hash, runtime runtime.Instance)
+	HandleRuntimeChanges(newState *rtstorage.TrieState, parentRuntimeInstance runtime.Instance,
+		blockHash common.Hash) error
 	GetHighestFinalisedHeader() (*types.Header, error)
 	GetFinalisedNotifierChannel() chan *types.FinalisationInfo
 	GetHeaderByNumber(num uint) (*types.Header, error)
 	GetAllBlocksAtNumber(num uint) ([]common.Hash, error)
 	IsDescendantOf(parent, child common.Hash) (bool, error)
+	ImportWarpSyncTarget(header *types.Header, justification []byte, round, setID uint64) error
 }
 
 // StorageState is the interface for the storage state
 type StorageState interface {
 	TrieState(root *common.Hash) (*rtstorage.TrieState, error)
+	StoreTrie(ts *rtstorage.TrieState, header *types.Header) error
 	sync.Locker
 }
 
+// GrandpaState is the interface for the GRANDPA state
+type GrandpaState interface {
+	SetWarpSyncAuthorities(authorities []types.GrandpaVoter, setChanges []uint) error
+}
+
 // TransactionState is the interface for transaction queue methods
 type TransactionState interface {
 	RemoveExtrinsic(ext types.Extrinsic)
@@ -63,11 +79,23 @@ type FinalityGadget interface {
 	VerifyBlockJustification(common.Hash, []byte) error
 }
 
+// WarpSyncVerifier verifies the authority set changes of warp sync proofs
+type WarpSyncVerifier interface {
+	VerifyWarpSyncProof(proof *network.WarpSyncProof, setID uint64, authorities []types.GrandpaVoter) (
+		nextSetID uint64, nextAuthorities []types.GrandpaVoter, err error)
+}
+
 // BlockImportHandler is the interface for the handler of newly imported blocks
 type BlockImportHandler interface {
 	HandleBlockImport(block *types.Block, state *rtstorage.TrieState, announce bool) error
 }
 
+// BlockImportDigestHandler is the interface for the handler of
+// the consensus digests of newly imported block headers
+type BlockImportDigestHandler interface {
+	Handle(header *types.Header) error
+}
+
 // Network is the interface for the network
 type Network interface {
 	// Peers returns a list of currently connected peers
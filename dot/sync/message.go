@@ -4,380 +4,227 @@
 package sync
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
 )
 
 const (
 	// maxResponseSize is maximum number of block data a BlockResponse message can contain
 	maxResponseSize = 128
+	// defaultMaxResponseBytes is the default maximum encoded size of the block
+	// data of a BlockResponse message, which stays below the maximum block
+	// response size accepted by the network.
+	defaultMaxResponseBytes = 8 * 1024 * 1024
 )
 
-// CreateBlockResponse creates a block response message from a block request message
-func (s *Service) CreateBlockResponse(req *network.BlockRequestMessage) (*network.BlockResponseMessage, error) {
-	switch req.Direction {
-	case network.Ascending:
-		return s.handleAscendingRequest(req)
-	case network.Descending:
-		return s.handleDescendingRequest(req)
-	default:
-		return nil, errInvalidRequestDirection
-	}
+// responseLimits returns the maximum number of block data and the maximum
+// encoded size in bytes of the block data of a block response, which are the
+// defaults if they are not configured. The maximum size is clamped to the
+// maximum block response size accepted by the network.
+func (s *Service) responseLimits() (maxBlocks, maxBytes uint) {
+	maxBlocks, maxBytes = s.maxResponseBlocks, s.maxResponseBytes
+	if maxBlocks == 0 {
+		maxBlocks = maxResponseSize
+	}
+	switch {
+	case maxBytes == 0:
+		maxBytes = defaultMaxResponseBytes
+	case uint64(maxBytes) > network.MaxBlockResponseSize:
+		maxBytes = uint(network.MaxBlockResponseSize)
+	}
+	return maxBlocks, maxBytes
 }
 
-func (s *Service) handleAscendingRequest(req *network.BlockRequestMessage) (*network.BlockResponseMessage, error) {
-	var (
-		max         uint = maxResponseSize
-		startHash   *common.Hash
-		startNumber uint
-	)
+// blockResponseChunk accumulates the block data of a block response
+// and their encoding up to a maximum encoded size.
+type blockResponseChunk struct {
+	data     []*types.BlockData
+	encoding []byte
+	maxSize  uint
+}
 
-	// determine maximum response size
-	if req.Max != nil && *req.Max < maxResponseSize {
-		max = uint(*req.Max)
+func newBlockResponseChunk(capacity, maxSize uint) *blockResponseChunk {
+	return &blockResponseChunk{
+		data:    make([]*types.BlockData, 0, capacity),
+		maxSize: maxSize,
 	}
+}
 
-	bestBlockNumber, err := s.blockState.BestBlockNumber()
-	if err != nil {
-		return nil, fmt.Errorf("getting best block for request: %w", err)
+// add adds the block data given with its encoding to the chunk if it fits in the
+// maximum size, and returns false otherwise, in which case the chunk is full.
+// The first block data is always added, such that each chunk makes progress.
+func (c *blockResponseChunk) add(bd *types.BlockData, encoded []byte) (added bool) {
+	if len(c.data) > 0 && uint(len(c.encoding)+len(encoded)) > c.maxSize {
+		return false
 	}
 
-	switch startBlock := req.StartingBlock.Value().(type) {
-	case uint32:
-		if startBlock == 0 {
-			startBlock = 1
-		}
-
-		// if request start is higher than our best block, return error
-		if bestBlockNumber < uint(startBlock) {
-			return nil, errRequestStartTooHigh
-		}
-
-		startNumber = uint(startBlock)
-	case common.Hash:
-		startHash = &startBlock
+	c.data = append(c.data, bd)
+	c.encoding = append(c.encoding, encoded...)
+	return true
+}
 
-		// make sure we actually have the starting block
-		header, err := s.blockState.GetHeader(*startHash)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get start block %s for request: %w", startHash, err)
-		}
+// response returns the block response message of the chunk,
+// which is not encoded again when sent.
+func (c *blockResponseChunk) response() *network.BlockResponseMessage {
+	return network.NewEncodedBlockResponseMessage(c.data, c.encoding)
+}
 
-		startNumber = header.Number
+// CreateBlockResponse creates a block response message from a block request message
+func (s *Service) CreateBlockResponse(req *network.BlockRequestMessage) (*network.BlockResponseMessage, error) {
+	var direction state.Direction
+	switch req.Direction {
+	case network.Ascending:
+		direction = state.Ascending
+	case network.Descending:
+		direction = state.Descending
 	default:
-		return nil, ErrInvalidBlockRequest
-	}
-
-	endNumber := startNumber + max - 1
-	if endNumber > bestBlockNumber {
-		endNumber = bestBlockNumber
-	}
-
-	var endHash *common.Hash
-	if startHash != nil {
-		eh, err := s.checkOrGetDescendantHash(*startHash, nil, endNumber)
-		if err != nil {
-			return nil, err
-		}
-
-		endHash = &eh
+		return nil, errInvalidRequestDirection
 	}
 
-	if startHash == nil {
-		logger.Debugf("handling block request: direction %s, "+
-			"start block number: %d, "+
-			"end block number: %d",
-			req.Direction, startNumber, endNumber)
-
-		return s.handleAscendingByNumber(startNumber, endNumber, req.RequestedData)
+	from, err := s.blockRangeStart(req)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Debugf("handling block request: direction %s, "+
-		"start block hash: %s, "+
-		"end block hash: %s",
-		req.Direction, *startHash, *endHash)
-
-	return s.handleChainByHash(*startHash, *endHash, max, req.RequestedData, req.Direction)
-}
-
-func (s *Service) handleDescendingRequest(req *network.BlockRequestMessage) (*network.BlockResponseMessage, error) {
-	var (
-		startHash   *common.Hash
-		startNumber uint
-		max         uint = maxResponseSize
-	)
-
 	// determine maximum response size
-	if req.Max != nil && *req.Max < maxResponseSize {
+	max, maxBytes := s.responseLimits()
+	if req.Max != nil && uint(*req.Max) < max {
 		max = uint(*req.Max)
 	}
 
-	switch startBlock := req.StartingBlock.Value().(type) {
-	case uint32:
-		bestBlockNumber, err := s.blockState.BestBlockNumber()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get best block %d for request: %w", bestBlockNumber, err)
-		}
-
-		// if request start is higher than our best block, only return blocks from our best block and below
-		if bestBlockNumber < uint(startBlock) {
-			startNumber = bestBlockNumber
-		} else {
-			startNumber = uint(startBlock)
-		}
-	case common.Hash:
-		startHash = &startBlock
-
-		// make sure we actually have the starting block
-		header, err := s.blockState.GetHeader(*startHash)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get start block %s for request: %w", startHash, err)
-		}
-
-		startNumber = header.Number
-	default:
-		return nil, ErrInvalidBlockRequest
-	}
-
-	endNumber := uint(1)
-	if startNumber > max+1 {
-		endNumber = startNumber - max + 1
-	}
+	withBody := (req.RequestedData&network.RequestedDataBody)>>1 == 1
+	withJustification := (req.RequestedData&network.RequestedDataJustification)>>4 == 1
 
-	var endHash *common.Hash
-	if startHash != nil {
-		// need to get blocks by subchain if start hash is provided, get end hash
-		endHeader, err := s.blockState.GetHeaderByNumber(endNumber)
-		if err != nil {
-			return nil, fmt.Errorf("getting end block %d for request: %w", endNumber, err)
-		}
+	logger.Debugf("handling block request: direction %s, start block %v, max %d",
+		req.Direction, req.StartingBlock.Value(), max)
 
-		hash := endHeader.Hash()
-		endHash = &hash
-	}
-
-	if startHash == nil || endHash == nil {
-		logger.Debugf("handling BlockRequestMessage with direction %s "+
-			"from start block with number %d to end block with number %d",
-			req.Direction, startNumber, endNumber)
-		return s.handleDescendingByNumber(startNumber, endNumber, req.RequestedData)
+	blocks, err := s.blockState.GetBlocksInRange(from, direction, uint32(max), withBody, withJustification)
+	if err != nil {
+		return nil, fmt.Errorf("getting blocks in range: %w", err)
 	}
 
-	logger.Debugf("handling block request message with direction %s "+
-		"from start block with hash %s to end block with hash %s",
-		req.Direction, *startHash, *endHash)
-	return s.handleChainByHash(*endHash, *startHash, max, req.RequestedData, req.Direction)
-}
-
-// checkOrGetDescendantHash checks if the provided `descendant` is
-// on the same chain as the `ancestor`, if it's provided, otherwise
-// it sets `descendant` to a block with number=`descendantNumber` that is a descendant of the ancestor.
-// If used with an Ascending request, ancestor is the start block and descendant is the end block
-// If used with an Descending request, ancestor is the end block and descendant is the start block
-func (s *Service) checkOrGetDescendantHash(ancestor common.Hash,
-	descendant *common.Hash, descendantNumber uint) (common.Hash, error) {
-	// if `descendant` was provided, check that it's a descendant of `ancestor`
-	if descendant != nil {
-		header, err := s.blockState.GetHeader(ancestor)
-		if err != nil {
-			return common.Hash{}, fmt.Errorf("failed to get descendant %s: %w", *descendant, err)
-		}
-
-		// if descendant number is lower than ancestor number, this is an error
-		if header.Number > descendantNumber {
-			return common.Hash{},
-				fmt.Errorf("invalid request, descendant number %d is lower than ancestor %d",
-					descendantNumber, header.Number)
-		}
-
-		// check if provided start hash is descendant of provided descendant hash
-		is, err := s.blockState.IsDescendantOf(ancestor, *descendant)
+	chunk := newBlockResponseChunk(uint(len(blocks)), maxBytes)
+	for _, block := range blocks {
+		bd, raw, err := s.getBlockData(block, req.RequestedData)
 		if err != nil {
-			return common.Hash{}, err
-		}
-
-		if !is {
-			return common.Hash{}, errStartAndEndMismatch
+			return nil, err
 		}
 
-		return *descendant, nil
-	}
-
-	// otherwise, get block on canonical chain by descendantNumber
-	hash, err := s.blockState.GetHashByNumber(descendantNumber)
-	if err != nil {
-		return common.Hash{}, err
-	}
-
-	// check if it's a descendant of the provided ancestor hash
-	is, err := s.blockState.IsDescendantOf(ancestor, hash)
-	if err != nil {
-		return common.Hash{}, err
-	}
-
-	if !is {
-		// if it's not a descendant, search for a block that has number=descendantNumber that is
-		hashes, err := s.blockState.GetAllBlocksAtNumber(descendantNumber)
+		encoded, err := network.EncodeRawBlockData(raw)
 		if err != nil {
-			return common.Hash{}, fmt.Errorf("failed to get blocks at number %d: %w", descendantNumber, err)
+			return nil, fmt.Errorf("encoding block data for block %s: %w", block.Hash, err)
 		}
 
-		for _, hash := range hashes {
-			is, err := s.blockState.IsDescendantOf(ancestor, hash)
-			if err != nil || !is {
-				continue
-			}
-
-			// this sets the descendant hash to whatever the first block we find with descendantNumber
-			// is, however there might be multiple blocks that fit this criteria
-			h := common.Hash{}
-			copy(h[:], hash[:])
-			descendant = &h
+		if !chunk.add(bd, encoded) {
 			break
 		}
-
-		if descendant == nil {
-			return common.Hash{}, fmt.Errorf("%w with number %d", errFailedToGetDescendant, descendantNumber)
-		}
-	} else {
-		// if it is, set descendant hash to our block w/ descendantNumber
-		descendant = &hash
-	}
-
-	logger.Tracef("determined descendant %s with number %d and ancestor %s",
-		*descendant, descendantNumber, ancestor)
-	return *descendant, nil
-}
-
-func (s *Service) handleAscendingByNumber(start, end uint,
-	requestedData byte) (*network.BlockResponseMessage, error) {
-	var err error
-	data := make([]*types.BlockData, (end-start)+1)
-
-	for i := uint(0); start+i <= end; i++ {
-		blockNumber := start + i
-		data[i], err = s.getBlockDataByNumber(blockNumber, requestedData)
-		if err != nil {
-			return nil, err
-		}
 	}
 
-	return &network.BlockResponseMessage{
-		BlockData: data,
-	}, nil
+	return chunk.response(), nil
 }
 
-func (s *Service) handleDescendingByNumber(start, end uint,
-	requestedData byte) (*network.BlockResponseMessage, error) {
-	var err error
-	data := make([]*types.BlockData, (start-end)+1)
-
-	for i := uint(0); start-i >= end; i++ {
-		blockNumber := start - i
-		data[i], err = s.getBlockDataByNumber(blockNumber, requestedData)
+// blockRangeStart returns the starting block of the block range requested.
+// A starting block number above the best block number is an error for an
+// ascending request, and starts from the best block for a descending request.
+func (s *Service) blockRangeStart(req *network.BlockRequestMessage) (from state.BlockID, err error) {
+	switch startBlock := req.StartingBlock.Value().(type) {
+	case uint32:
+		bestBlockNumber, err := s.blockState.BestBlockNumber()
 		if err != nil {
-			return nil, err
-		}
-	}
-
-	return &network.BlockResponseMessage{
-		BlockData: data,
-	}, nil
-}
-
-func (s *Service) handleChainByHash(ancestor, descendant common.Hash,
-	max uint, requestedData byte, direction network.SyncDirection) (
-	*network.BlockResponseMessage, error) {
-	subchain, err := s.blockState.Range(ancestor, descendant)
-	if err != nil {
-		return nil, fmt.Errorf("retrieving range: %w", err)
-	}
-
-	// If the direction is descending, prune from the start.
-	// if the direction is ascending it should prune from the end.
-	if uint(len(subchain)) > max {
-		if direction == network.Ascending {
-			subchain = subchain[:max]
-		} else {
-			subchain = subchain[uint(len(subchain))-max:]
+			return from, fmt.Errorf("getting best block for request: %w", err)
 		}
-	}
 
-	data := make([]*types.BlockData, len(subchain))
+		from.Number = uint(startBlock)
+		if req.Direction == network.Ascending {
+			if from.Number == 0 {
+				from.Number = 1
+			}
 
-	for i, hash := range subchain {
-		data[i], err = s.getBlockData(hash, requestedData)
-		if err != nil {
-			return nil, err
+			// if request start is higher than our best block, return error
+			if from.Number > bestBlockNumber {
+				return from, errRequestStartTooHigh
+			}
+		} else if from.Number > bestBlockNumber {
+			// only return blocks from our best block and below
+			from.Number = bestBlockNumber
 		}
+	case common.Hash:
+		from.Hash = &startBlock
+	default:
+		return from, ErrInvalidBlockRequest
 	}
 
-	// reverse BlockData, if descending request
-	if direction == network.Descending {
-		reverseBlockData(data)
-	}
-
-	return &network.BlockResponseMessage{
-		BlockData: data,
-	}, nil
-}
-
-func (s *Service) getBlockDataByNumber(num uint, requestedData byte) (*types.BlockData, error) {
-	hash, err := s.blockState.GetHashByNumber(num)
-	if err != nil {
-		return nil, err
-	}
-
-	return s.getBlockData(hash, requestedData)
+	return from, nil
 }
 
-func (s *Service) getBlockData(hash common.Hash, requestedData byte) (*types.BlockData, error) {
-	var err error
-	blockData := &types.BlockData{
-		Hash: hash,
+// getBlockData returns the block data requested of the block given, decoded
+// and in its raw encoded form, such that it is not encoded again when sent.
+func (s *Service) getBlockData(block state.RawBlockData, requestedData byte) (
+	blockData *types.BlockData, raw network.RawBlockData, err error) {
+	blockData = &types.BlockData{
+		Hash: block.Hash,
 	}
+	raw.Hash = block.Hash
 
 	if requestedData == 0 {
-		return blockData, nil
+		return blockData, raw, nil
 	}
 
 	if (requestedData & network.RequestedDataHeader) == 1 {
-		blockData.Header, err = s.blockState.GetHeader(hash)
+		header := types.NewEmptyHeader()
+		err = scale.Unmarshal(block.Header, header)
 		if err != nil {
-			logger.Debugf("failed to get header for block with hash %s: %s", hash, err)
+			return nil, raw, fmt.Errorf("decoding header for block hash %s: %w", block.Hash, err)
 		}
+		blockData.Header = header
+		raw.Header = block.Header
 	}
 
 	if (requestedData&network.RequestedDataBody)>>1 == 1 {
-		blockData.Body, err = s.blockState.GetBlockBody(hash)
+		if block.Body == nil {
+			// A missing body cannot be told apart from an empty body
+			// by the requester, so the request is refused instead.
+			return nil, raw, fmt.Errorf("%w: for block hash %s: %w",
+				errBodyNotServed, block.Hash, state.ErrBodiesNotStored)
+		}
+
+		blockData.Body, err = types.NewBodyFromEncodedBytes(block.Body)
 		if err != nil {
-			logger.Debugf("failed to get body for block with hash %s: %s", hash, err)
+			return nil, raw, fmt.Errorf("decoding body for block hash %s: %w", block.Hash, err)
 		}
+		raw.Body = block.Body
 	}
 
 	if (requestedData&network.RequestedDataReceipt)>>2 == 1 {
-		retData, err := s.blockState.GetReceipt(hash)
-		if err == nil && retData != nil {
-			blockData.Receipt = &retData
+		receipt, err := s.blockState.GetReceipt(block.Hash)
+		if err == nil {
+			blockData.Receipt = &receipt
+			raw.Receipt = &receipt
+		} else if !errors.Is(err, state.ErrReceiptNotFound) {
+			logger.Debugf("failed to get receipt for block with hash %s: %s", block.Hash, err)
 		}
 	}
 
 	if (requestedData&network.RequestedDataMessageQueue)>>3 == 1 {
-		retData, err := s.blockState.GetMessageQueue(hash)
-		if err == nil && retData != nil {
-			blockData.MessageQueue = &retData
+		messageQueue, err := s.blockState.GetMessageQueue(block.Hash)
+		if err == nil {
+			blockData.MessageQueue = &messageQueue
+			raw.MessageQueue = &messageQueue
+		} else if !errors.Is(err, state.ErrMessageQueueNotFound) {
+			logger.Debugf("failed to get message queue for block with hash %s: %s", block.Hash, err)
 		}
 	}
 
-	if (requestedData&network.RequestedDataJustification)>>4 == 1 {
-		retData, err := s.blockState.GetJustification(hash)
-		if err == nil && retData != nil {
-			blockData.Justification = &retData
-		}
+	if (requestedData&network.RequestedDataJustification)>>4 == 1 && block.Justification != nil {
+		blockData.Justification = block.Justification
+		raw.Justification = block.Justification
 	}
 
-	return blockData, nil
+	return blockData, raw, nil
 }
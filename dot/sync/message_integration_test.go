@@ -11,6 +11,7 @@
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
 	"github.com/ChainSafe/gossamer/lib/trie"
 
@@ -184,9 +185,9 @@ func TestService_CreateBlockResponse_StartHash(t *testing.T) {
 
 	resp, err = s.CreateBlockResponse(req)
 	require.NoError(t, err)
-	require.Equal(t, int(16), len(resp.BlockData))
+	require.Equal(t, int(17), len(resp.BlockData))
 	require.Equal(t, uint(16), resp.BlockData[0].Number())
-	require.Equal(t, uint(1), resp.BlockData[15].Number())
+	require.Equal(t, uint(0), resp.BlockData[16].Number())
 
 	req = &network.BlockRequestMessage{
 		RequestedData: 3,
@@ -197,9 +198,9 @@ func TestService_CreateBlockResponse_StartHash(t *testing.T) {
 
 	resp, err = s.CreateBlockResponse(req)
 	require.NoError(t, err)
-	require.Equal(t, int(16), len(resp.BlockData))
+	require.Equal(t, int(17), len(resp.BlockData))
 	require.Equal(t, uint(16), resp.BlockData[0].Number())
-	require.Equal(t, uint(1), resp.BlockData[15].Number())
+	require.Equal(t, uint(0), resp.BlockData[16].Number())
 
 	// test descending with nil endBlockHash and start > maxResponseSize
 	startHash, err = s.blockState.GetHashByNumber(256)
@@ -241,7 +242,7 @@ func TestService_CreateBlockResponse_StartHash(t *testing.T) {
 	require.Equal(t, uint(1), resp.BlockData[127].Number())
 }
 
-func TestService_checkOrGetDescendantHash_integration(t *testing.T) {
+func TestService_CreateBlockResponse_DescendingFromFork(t *testing.T) {
 	t.Parallel()
 	s := newTestSyncer(t)
 	branches := map[uint]int{
@@ -249,80 +250,42 @@ func TestService_checkOrGetDescendantHash_integration(t *testing.T) {
 	}
 	state.AddBlocksToStateWithFixedBranches(t, s.blockState.(*state.BlockState), 16, branches)
 
-	// base case
-	ancestor, err := s.blockState.GetHashByNumber(1)
-	require.NoError(t, err)
-	descendant, err := s.blockState.GetHashByNumber(16)
-	require.NoError(t, err)
-	const descendantNumber uint = 16
-
-	res, err := s.checkOrGetDescendantHash(ancestor, &descendant, descendantNumber)
-	require.NoError(t, err)
-	require.Equal(t, descendant, res)
-
-	// supply descendant that's not on canonical chain
-	leaves := s.blockState.(*state.BlockState).Leaves()
-	require.Equal(t, 2, len(leaves))
-
-	ancestor, err = s.blockState.GetHashByNumber(1)
-	require.NoError(t, err)
-	descendant, err = s.blockState.GetHashByNumber(descendantNumber)
+	canonicalHash, err := s.blockState.GetHashByNumber(16)
 	require.NoError(t, err)
 
-	for _, leaf := range leaves {
-		if leaf != descendant {
-			descendant = leaf
+	var forkHash common.Hash
+	for _, leaf := range s.blockState.(*state.BlockState).Leaves() {
+		if leaf != canonicalHash {
+			forkHash = leaf
 			break
 		}
 	}
-
-	res, err = s.checkOrGetDescendantHash(ancestor, &descendant, descendantNumber)
-	require.NoError(t, err)
-	require.Equal(t, descendant, res)
-
-	// supply descedant that's not on same chain as ancestor
-	ancestor, err = s.blockState.GetHashByNumber(9)
-	require.NoError(t, err)
-	_, err = s.checkOrGetDescendantHash(ancestor, &descendant, descendantNumber)
-	require.Error(t, err)
-
-	// don't supply descendant, should return block on canonical chain
-	// as ancestor is on canonical chain
-	expected, err := s.blockState.GetHashByNumber(descendantNumber)
-	require.NoError(t, err)
-
-	res, err = s.checkOrGetDescendantHash(ancestor, nil, descendantNumber)
+	forkHeader, err := s.blockState.GetHeader(forkHash)
 	require.NoError(t, err)
-	require.Equal(t, expected, res)
 
-	// don't supply descendant and provide ancestor not on canonical chain
-	// should return descendant block also not on canonical chain
-	block9s, err := s.blockState.GetAllBlocksAtNumber(9)
+	start, err := variadic.NewUint32OrHash(forkHash)
 	require.NoError(t, err)
-	canonical, err := s.blockState.GetHashByNumber(9)
-	require.NoError(t, err)
-
-	// set ancestor to non-canonical block 9
-	for _, block := range block9s {
-		if canonical != block {
-			ancestor = block
-			break
-		}
+	max := uint32(forkHeader.Number - 4)
+	req := &network.BlockRequestMessage{
+		RequestedData: network.RequestedDataHeader,
+		StartingBlock: *start,
+		Direction:     network.Descending,
+		Max:           &max,
 	}
 
-	// expected is non-canonical block 16
-	for _, leaf := range leaves {
-		is, err := s.blockState.IsDescendantOf(ancestor, leaf)
-		require.NoError(t, err)
-		if is {
-			expected = leaf
-			break
-		}
+	resp, err := s.CreateBlockResponse(req)
+	require.NoError(t, err)
+	require.Len(t, resp.BlockData, int(max))
+	require.Equal(t, forkHash, resp.BlockData[0].Hash)
+	for i := 1; i < len(resp.BlockData); i++ {
+		require.Equal(t, resp.BlockData[i].Hash, resp.BlockData[i-1].Header.ParentHash)
 	}
 
-	res, err = s.checkOrGetDescendantHash(ancestor, nil, descendantNumber)
+	// the fork joins the canonical chain below the branch point
+	last := resp.BlockData[len(resp.BlockData)-1]
+	canonicalHash, err = s.blockState.GetHashByNumber(last.Number())
 	require.NoError(t, err)
-	require.Equal(t, expected, res)
+	require.Equal(t, canonicalHash, last.Hash)
 }
 
 func TestService_CreateBlockResponse_Fields(t *testing.T) {
@@ -8,16 +8,20 @@
 	"testing"
 
 	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
+	"github.com/ChainSafe/gossamer/pkg/scale"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_CreateBlockResponse(t *testing.T) {
 	t.Parallel()
 
+	maxTwo := uint32(2)
 	type args struct {
 		req *network.BlockRequestMessage
 	}
@@ -29,9 +33,7 @@ type args struct {
 	}{
 		"invalid_block_request": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().BestBlockNumber().Return(uint(1), nil)
-				return mockBlockState
+				return NewMockBlockState(ctrl)
 			},
 			args: args{req: &network.BlockRequestMessage{}},
 			err:  ErrInvalidBlockRequest,
@@ -40,7 +42,9 @@ type args struct {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().BestBlockNumber().Return(uint(1), nil)
-				mockBlockState.EXPECT().GetHashByNumber(uint(1)).Return(common.Hash{1, 2}, nil)
+				mockBlockState.EXPECT().GetBlocksInRange(state.BlockID{Number: 1}, state.Ascending,
+					uint32(maxResponseSize), false, false).
+					Return([]state.RawBlockData{{Hash: common.Hash{1, 2}}}, nil)
 				return mockBlockState
 			},
 			args: args{req: &network.BlockRequestMessage{
@@ -69,19 +73,26 @@ type args struct {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().BestBlockNumber().Return(uint(1), nil)
+				mockBlockState.EXPECT().GetBlocksInRange(state.BlockID{Number: 0}, state.Descending,
+					uint32(maxResponseSize), false, false).
+					Return([]state.RawBlockData{{Hash: common.Hash{1}}}, nil)
 				return mockBlockState
 			},
 			args: args{req: &network.BlockRequestMessage{
 				StartingBlock: *variadic.MustNewUint32OrHash(0),
 				Direction:     network.Descending,
 			}},
-			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{}},
+			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{{
+				Hash: common.Hash{1},
+			}}},
 		},
 		"descending_request_start_number_higher": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().BestBlockNumber().Return(uint(1), nil)
-				mockBlockState.EXPECT().GetHashByNumber(uint(1)).Return(common.Hash{1, 2}, nil)
+				mockBlockState.EXPECT().GetBlocksInRange(state.BlockID{Number: 1}, state.Descending,
+					uint32(maxResponseSize), false, false).
+					Return([]state.RawBlockData{{Hash: common.Hash{1, 2}}, {Hash: common.Hash{1}}}, nil)
 				return mockBlockState
 			},
 			args: args{req: &network.BlockRequestMessage{
@@ -89,55 +100,66 @@ type args struct {
 				Direction:     network.Descending,
 			}},
 			err: nil,
-			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{{
-				Hash: common.Hash{1, 2},
-			}}},
+			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{
+				{Hash: common.Hash{1, 2}},
+				{Hash: common.Hash{1}},
+			}},
 		},
 		"ascending_request_startHash": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(&types.Header{
-					Number: 1,
-				}, nil)
-				mockBlockState.EXPECT().BestBlockNumber().Return(uint(2), nil)
-				mockBlockState.EXPECT().GetHashByNumber(uint(2)).Return(common.Hash{1, 2, 3}, nil)
-				mockBlockState.EXPECT().IsDescendantOf(common.Hash{}, common.Hash{1, 2, 3}).Return(true,
-					nil)
-				mockBlockState.EXPECT().Range(common.Hash{}, common.Hash{1, 2, 3}).Return([]common.Hash{{1,
-					2}},
-					nil)
+				mockBlockState.EXPECT().GetBlocksInRange(state.BlockID{Hash: &common.Hash{1}}, state.Ascending,
+					uint32(2), false, false).
+					Return([]state.RawBlockData{{Hash: common.Hash{1}}, {Hash: common.Hash{1, 2}}}, nil)
 				return mockBlockState
 			},
 			args: args{req: &network.BlockRequestMessage{
-				StartingBlock: *variadic.MustNewUint32OrHash(common.Hash{}),
+				StartingBlock: *variadic.MustNewUint32OrHash(common.Hash{1}),
 				Direction:     network.Ascending,
+				Max:           &maxTwo,
+			}},
+			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{
+				{Hash: common.Hash{1}},
+				{Hash: common.Hash{1, 2}},
 			}},
-			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{{
-				Hash: common.Hash{1, 2},
-			}}},
 		},
 		"descending_request_startHash": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(&types.Header{
-					Number: 1,
-				}, nil)
-				mockBlockState.EXPECT().GetHeaderByNumber(uint(1)).Return(&types.Header{
-					Number: 1,
-				}, nil)
-				mockBlockState.EXPECT().Range(common.MustHexToHash(
-					"0x6443a0b46e0412e626363028115a9f2cf963eeed526b8b33e5316f08b50d0dc3"),
-					common.Hash{}).Return([]common.Hash{{1, 2}}, nil)
+				mockBlockState.EXPECT().GetBlocksInRange(state.BlockID{Hash: &common.Hash{1, 2}}, state.Descending,
+					uint32(maxResponseSize), true, true).
+					Return([]state.RawBlockData{{
+						Hash:          common.Hash{1, 2},
+						Body:          [][]byte{},
+						Justification: &[]byte{3},
+					}}, nil)
 				return mockBlockState
 			},
 			args: args{req: &network.BlockRequestMessage{
-				StartingBlock: *variadic.MustNewUint32OrHash(common.Hash{}),
+				RequestedData: network.RequestedDataBody | network.RequestedDataJustification,
+				StartingBlock: *variadic.MustNewUint32OrHash(common.Hash{1, 2}),
 				Direction:     network.Descending,
 			}},
 			want: &network.BlockResponseMessage{BlockData: []*types.BlockData{{
-				Hash: common.Hash{1, 2},
+				Hash:          common.Hash{1, 2},
+				Body:          types.NewBody([]types.Extrinsic{}),
+				Justification: &[]byte{3},
 			}}},
 		},
+		"get_blocks_in_range_error": {
+			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().GetBlocksInRange(state.BlockID{Hash: &common.Hash{1}}, state.Descending,
+					uint32(maxResponseSize), false, false).
+					Return(nil, state.ErrBlockRangeStartNotFound)
+				return mockBlockState
+			},
+			args: args{req: &network.BlockRequestMessage{
+				StartingBlock: *variadic.MustNewUint32OrHash(common.Hash{1}),
+				Direction:     network.Descending,
+			}},
+			err: errors.New("getting blocks in range: block range start not found"),
+		},
 		"invalid_direction": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				return nil
@@ -160,76 +182,18 @@ type args struct {
 			got, err := s.CreateBlockResponse(tt.args.req)
 			if tt.err != nil {
 				assert.EqualError(t, err, tt.err.Error())
-			} else {
-				assert.NoError(t, err)
+				assert.Nil(t, got)
+				return
 			}
-			assert.Equal(t, tt.want, got)
-		})
-	}
-}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.BlockData, got.BlockData)
 
-func TestService_checkOrGetDescendantHash(t *testing.T) {
-	t.Parallel()
-
-	type args struct {
-		ancestor         common.Hash
-		descendant       *common.Hash
-		descendantNumber uint
-	}
-	tests := map[string]struct {
-		name              string
-		blockStateBuilder func(ctrl *gomock.Controller) BlockState
-		args              args
-		want              common.Hash
-		expectedError     error
-	}{
-		"nil_descendant": {
-			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockStateBuilder := NewMockBlockState(ctrl)
-				mockStateBuilder.EXPECT().GetHashByNumber(uint(1)).Return(common.Hash{}, nil)
-				mockStateBuilder.EXPECT().IsDescendantOf(common.Hash{}, common.Hash{}).Return(true, nil)
-				return mockStateBuilder
-			},
-			args: args{ancestor: common.Hash{}, descendant: nil, descendantNumber: 1},
-		},
-		"not_nil_descendant": {
-			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(&types.Header{}, nil)
-				mockBlockState.EXPECT().IsDescendantOf(common.Hash{}, common.Hash{1, 2}).Return(true, nil)
-				return mockBlockState
-			},
-			args: args{ancestor: common.Hash{0}, descendant: &common.Hash{1, 2}, descendantNumber: 1},
-			want: common.Hash{1, 2},
-		},
-		"descendant_greater_than_header": {
-			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetHeader(common.Hash{2}).Return(&types.Header{
-					Number: 2,
-				}, nil)
-				return mockBlockState
-			},
-			args:          args{ancestor: common.Hash{2}, descendant: &common.Hash{1, 2}, descendantNumber: 1},
-			want:          common.Hash{},
-			expectedError: errors.New("invalid request, descendant number 1 is lower than ancestor 2"),
-		},
-	}
-	for _, tt := range tests {
-		tt := tt
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-			ctrl := gomock.NewController(t)
-			s := &Service{
-				blockState: tt.blockStateBuilder(ctrl),
-			}
-			got, err := s.checkOrGetDescendantHash(tt.args.ancestor, tt.args.descendant, tt.args.descendantNumber)
-			if tt.expectedError != nil {
-				assert.EqualError(t, err, tt.expectedError.Error())
-			} else {
-				assert.NoError(t, err)
-			}
-			assert.Equal(t, tt.want, got)
+			// the response is encoded as the message with its block data
+			expectedEncoding, err := tt.want.Encode()
+			require.NoError(t, err)
+			encoding, err := got.Encode()
+			require.NoError(t, err)
+			assert.Equal(t, expectedEncoding, encoding)
 		})
 	}
 }
@@ -237,14 +201,17 @@ type args struct {
 func TestService_getBlockData(t *testing.T) {
 	t.Parallel()
 
+	encodedHeader := scale.MustMarshal(types.Header{Number: 2, Digest: types.NewDigest()})
+
 	type args struct {
-		hash          common.Hash
+		block         state.RawBlockData
 		requestedData byte
 	}
 	tests := map[string]struct {
 		blockStateBuilder func(ctrl *gomock.Controller) BlockState
 		args              args
 		want              *types.BlockData
+		wantRaw           network.RawBlockData
 		err               error
 	}{
 		"requestedData_0": {
@@ -252,73 +219,70 @@ type args struct {
 				return nil
 			},
 			args: args{
-				hash:          common.Hash{},
+				block:         state.RawBlockData{Hash: common.Hash{1}, Header: encodedHeader},
 				requestedData: 0,
 			},
-			want: &types.BlockData{},
+			want:    &types.BlockData{Hash: common.Hash{1}},
+			wantRaw: network.RawBlockData{Hash: common.Hash{1}},
 		},
 		"requestedData_RequestedDataHeader_error": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetHeader(common.Hash{}).Return(nil, errors.New("empty hash"))
-				return mockBlockState
+				return nil
 			},
 			args: args{
-				hash:          common.Hash{0},
+				block:         state.RawBlockData{Hash: common.Hash{1}, Header: []byte{1}},
 				requestedData: network.RequestedDataHeader,
 			},
-			want: &types.BlockData{
-				Hash: common.Hash{},
-			},
+			err: errors.New("decoding header for block hash " +
+				"0x0100000000000000000000000000000000000000000000000000000000000000: " +
+				"decoding struct: unmarshalling field at index 0: EOF"),
 		},
 		"requestedData_RequestedDataHeader": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetHeader(common.Hash{1}).Return(&types.Header{
-					Number: 2,
-				}, nil)
-				return mockBlockState
+				return nil
 			},
 			args: args{
-				hash:          common.Hash{1},
+				block:         state.RawBlockData{Hash: common.Hash{1}, Header: encodedHeader},
 				requestedData: network.RequestedDataHeader,
 			},
 			want: &types.BlockData{
 				Hash: common.Hash{1},
 				Header: &types.Header{
 					Number: 2,
+					Digest: types.NewDigest(),
 				},
 			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{1}, Header: encodedHeader},
 		},
-		"requestedData_RequestedDataBody_error": {
+		"requestedData_RequestedDataBody_not_stored": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetBlockBody(common.Hash{}).Return(nil, errors.New("empty hash"))
-				return mockBlockState
+				return nil
 			},
-
 			args: args{
-				hash:          common.Hash{},
+				block:         state.RawBlockData{Hash: common.Hash{1}, Header: encodedHeader},
 				requestedData: network.RequestedDataBody,
 			},
-			want: &types.BlockData{
-				Hash: common.Hash{},
-			},
+			err: errors.New("block body is not served: " +
+				"for block hash 0x0100000000000000000000000000000000000000000000000000000000000000: " +
+				"block bodies are not stored"),
 		},
 		"requestedData_RequestedDataBody": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
-				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetBlockBody(common.Hash{1}).Return(&types.Body{[]byte{1}}, nil)
-				return mockBlockState
+				return nil
 			},
 			args: args{
-				hash:          common.Hash{1},
+				block: state.RawBlockData{
+					Hash:   common.Hash{1},
+					Header: encodedHeader,
+					Body:   [][]byte{{4, 1}},
+				},
 				requestedData: network.RequestedDataBody,
 			},
 			want: &types.BlockData{
 				Hash: common.Hash{1},
 				Body: &types.Body{[]byte{1}},
 			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{1}, Body: [][]byte{{4, 1}}},
 		},
 		"requestedData_RequestedDataReceipt": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
@@ -327,13 +291,14 @@ type args struct {
 				return mockBlockState
 			},
 			args: args{
-				hash:          common.Hash{1},
+				block:         state.RawBlockData{Hash: common.Hash{1}},
 				requestedData: network.RequestedDataReceipt,
 			},
 			want: &types.BlockData{
 				Hash:    common.Hash{1},
 				Receipt: &[]byte{1},
 			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{1}, Receipt: &[]byte{1}},
 		},
 		"requestedData_RequestedDataMessageQueue": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
@@ -342,28 +307,73 @@ type args struct {
 				return mockBlockState
 			},
 			args: args{
-				hash:          common.Hash{2},
+				block:         state.RawBlockData{Hash: common.Hash{2}},
 				requestedData: network.RequestedDataMessageQueue,
 			},
 			want: &types.BlockData{
 				Hash:         common.Hash{2},
 				MessageQueue: &[]byte{2},
 			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{2}, MessageQueue: &[]byte{2}},
 		},
-		"requestedData_RequestedDataJustification": {
+		"requestedData_RequestedDataReceipt_empty": {
 			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
 				mockBlockState := NewMockBlockState(ctrl)
-				mockBlockState.EXPECT().GetJustification(common.Hash{3}).Return([]byte{3}, nil)
+				mockBlockState.EXPECT().GetReceipt(common.Hash{1}).Return([]byte{}, nil)
 				return mockBlockState
 			},
 			args: args{
-				hash:          common.Hash{3},
+				block:         state.RawBlockData{Hash: common.Hash{1}},
+				requestedData: network.RequestedDataReceipt,
+			},
+			want: &types.BlockData{
+				Hash:    common.Hash{1},
+				Receipt: &[]byte{},
+			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{1}, Receipt: &[]byte{}},
+		},
+		"requestedData_RequestedDataMessageQueue_not_found": {
+			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().GetMessageQueue(common.Hash{2}).
+					Return(nil, state.ErrMessageQueueNotFound)
+				return mockBlockState
+			},
+			args: args{
+				block:         state.RawBlockData{Hash: common.Hash{2}},
+				requestedData: network.RequestedDataMessageQueue,
+			},
+			want: &types.BlockData{
+				Hash: common.Hash{2},
+			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{2}},
+		},
+		"requestedData_RequestedDataJustification": {
+			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
+				return nil
+			},
+			args: args{
+				block:         state.RawBlockData{Hash: common.Hash{3}, Justification: &[]byte{3}},
 				requestedData: network.RequestedDataJustification,
 			},
 			want: &types.BlockData{
 				Hash:          common.Hash{3},
 				Justification: &[]byte{3},
 			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{3}, Justification: &[]byte{3}},
+		},
+		"requestedData_RequestedDataJustification_not_found": {
+			blockStateBuilder: func(ctrl *gomock.Controller) BlockState {
+				return nil
+			},
+			args: args{
+				block:         state.RawBlockData{Hash: common.Hash{3}},
+				requestedData: network.RequestedDataJustification,
+			},
+			want: &types.BlockData{
+				Hash: common.Hash{3},
+			},
+			wantRaw: network.RawBlockData{Hash: common.Hash{3}},
 		},
 	}
 	for name, tt := range tests {
@@ -374,13 +384,254 @@ type args struct {
 			s := &Service{
 				blockState: tt.blockStateBuilder(ctrl),
 			}
-			got, err := s.getBlockData(tt.args.hash, tt.args.requestedData)
+			got, raw, err := s.getBlockData(tt.args.block, tt.args.requestedData)
 			if tt.err != nil {
 				assert.EqualError(t, err, tt.err.Error())
-			} else {
-				assert.NoError(t, err)
+				assert.Nil(t, got)
+				return
 			}
+			require.NoError(t, err)
 			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantRaw, raw)
+		})
+	}
+}
+
+// newTestChainBlockState returns a block state mock serving a chain of the
+// length given, with each block having a body, and the block data of the chain
+// indexed by block number, where the block data at index 0 is the genesis block.
+func newTestChainBlockState(ctrl *gomock.Controller, length uint) (
+	blockState *MockBlockState, chain []*types.BlockData) {
+	chain = make([]*types.BlockData, length+1)
+	numbers := make(map[common.Hash]uint, length+1)
+	var parentHash common.Hash
+	for number := uint(0); number <= length; number++ {
+		header := &types.Header{
+			ParentHash: parentHash,
+			Number:     number,
+			Digest:     types.NewDigest(),
+		}
+		// the header hash is computed on a copy, such that the header
+		// equals the header decoded from its encoding in a response.
+		hashedHeader := *header
+		hash := hashedHeader.Hash()
+		chain[number] = &types.BlockData{
+			Hash:   hash,
+			Header: header,
+			Body:   types.NewBody([]types.Extrinsic{make([]byte, 64)}),
+		}
+		numbers[hash] = number
+		parentHash = hash
+	}
+
+	blockState = NewMockBlockState(ctrl)
+	blockState.EXPECT().BestBlockNumber().Return(length, nil).AnyTimes()
+	blockState.EXPECT().GetHashByNumber(gomock.Any()).DoAndReturn(
+		func(number uint) (common.Hash, error) {
+			return chain[number].Hash, nil
+		}).AnyTimes()
+	blockState.EXPECT().GetHeaderByNumber(gomock.Any()).DoAndReturn(
+		func(number uint) (*types.Header, error) {
+			return chain[number].Header, nil
+		}).AnyTimes()
+	blockState.EXPECT().GetHeader(gomock.Any()).DoAndReturn(
+		func(hash common.Hash) (*types.Header, error) {
+			return chain[numbers[hash]].Header, nil
+		}).AnyTimes()
+	blockState.EXPECT().GetBlockBody(gomock.Any()).DoAndReturn(
+		func(hash common.Hash) (*types.Body, error) {
+			return chain[numbers[hash]].Body, nil
+		}).AnyTimes()
+	blockState.EXPECT().IsDescendantOf(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	blockState.EXPECT().Range(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(start, end common.Hash) ([]common.Hash, error) {
+			hashes := make([]common.Hash, 0, numbers[end]-numbers[start]+1)
+			for _, bd := range chain[numbers[start] : numbers[end]+1] {
+				hashes = append(hashes, bd.Hash)
+			}
+			return hashes, nil
+		}).AnyTimes()
+	blockState.EXPECT().GetBlocksInRange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), false).
+		DoAndReturn(func(from state.BlockID, direction state.Direction, max uint32,
+			withBody, _ bool) ([]state.RawBlockData, error) {
+			number := from.Number
+			if from.Hash != nil {
+				number = numbers[*from.Hash]
+			}
+
+			var blocks []state.RawBlockData
+			for uint32(len(blocks)) < max {
+				bd := chain[number]
+				block := state.RawBlockData{
+					Hash:   bd.Hash,
+					Header: scale.MustMarshal(*bd.Header),
+				}
+				if withBody {
+					extrinsics, err := bd.Body.AsEncodedExtrinsics()
+					if err != nil {
+						return nil, err
+					}
+					block.Body = types.ExtrinsicsArrayToBytesArray(extrinsics)
+				}
+				blocks = append(blocks, block)
+
+				if direction == state.Descending {
+					if number == 0 {
+						break
+					}
+					number--
+				} else {
+					if number == length {
+						break
+					}
+					number++
+				}
+			}
+			return blocks, nil
+		}).AnyTimes()
+
+	return blockState, chain
+}
+
+func TestService_CreateBlockResponse_responseLimits(t *testing.T) {
+	t.Parallel()
+
+	const requestedData = network.RequestedDataHeader + network.RequestedDataBody
+	blockSizeCtrl := gomock.NewController(t)
+	_, chain := newTestChainBlockState(blockSizeCtrl, 10)
+	encoded, err := (&network.BlockResponseMessage{BlockData: chain[1:2]}).Encode()
+	require.NoError(t, err)
+	blockSize := uint(len(encoded))
+
+	max := uint32(10)
+	tests := map[string]struct {
+		maxBlocks   uint
+		maxBytes    uint
+		req         *network.BlockRequestMessage
+		wantNumbers []uint
+	}{
+		"ascending_max_blocks": {
+			maxBlocks: 4,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1, 2, 3, 4},
+		},
+		"ascending_max_bytes_by_number": {
+			maxBytes: 3 * blockSize,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1, 2, 3},
+		},
+		"ascending_max_bytes_by_hash": {
+			maxBytes: 3*blockSize + 1,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(chain[2].Hash),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{2, 3, 4},
+		},
+		"descending_max_bytes_by_number": {
+			maxBytes: 3 * blockSize,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(10),
+				Direction:     network.Descending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{10, 9, 8},
+		},
+		"descending_max_bytes_by_hash": {
+			maxBytes: 2 * blockSize,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(chain[10].Hash),
+				Direction:     network.Descending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{10, 9},
+		},
+		"max_bytes_below_block_size": {
+			maxBytes: 1,
+			req: &network.BlockRequestMessage{
+				RequestedData: requestedData,
+				StartingBlock: *variadic.MustNewUint32OrHash(1),
+				Direction:     network.Ascending,
+				Max:           &max,
+			},
+			wantNumbers: []uint{1},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			blockState, chain := newTestChainBlockState(ctrl, 10)
+			s := &Service{
+				blockState:        blockState,
+				maxResponseBlocks: tt.maxBlocks,
+				maxResponseBytes:  tt.maxBytes,
+			}
+
+			got, err := s.CreateBlockResponse(tt.req)
+			require.NoError(t, err)
+
+			want := make([]*types.BlockData, len(tt.wantNumbers))
+			for i, number := range tt.wantNumbers {
+				want[i] = chain[number]
+			}
+			assert.Equal(t, want, got.BlockData)
+		})
+	}
+}
+
+func TestService_responseLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		service   *Service
+		maxBlocks uint
+		maxBytes  uint
+	}{
+		"defaults": {
+			service:   &Service{},
+			maxBlocks: maxResponseSize,
+			maxBytes:  defaultMaxResponseBytes,
+		},
+		"configured": {
+			service: &Service{
+				maxResponseBlocks: 10,
+				maxResponseBytes:  1000,
+			},
+			maxBlocks: 10,
+			maxBytes:  1000,
+		},
+		"max_bytes_clamped": {
+			service: &Service{
+				maxResponseBytes: uint(network.MaxBlockResponseSize) + 1,
+			},
+			maxBlocks: maxResponseSize,
+			maxBytes:  uint(network.MaxBlockResponseSize),
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			maxBlocks, maxBytes := tt.service.responseLimits()
+			assert.Equal(t, tt.maxBlocks, maxBlocks)
+			assert.Equal(t, tt.maxBytes, maxBytes)
 		})
 	}
 }
@@ -133,12 +133,25 @@ func (mr *MockChainSyncMockRecorder) getHighestBlock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getHighestBlock", reflect.TypeOf((*MockChainSync)(nil).getHighestBlock))
 }
 
+// removePeer mocks base method.
+func (m *MockChainSync) removePeer(who peer.ID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "removePeer", who)
+}
+
+// removePeer indicates an expected call of removePeer.
+func (mr *MockChainSyncMockRecorder) removePeer(who interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "removePeer", reflect.TypeOf((*MockChainSync)(nil).removePeer), who)
+}
+
 // setBlockAnnounce mocks base method.
-func (m *MockChainSync) setBlockAnnounce(from peer.ID, header *types.Header) error {
+func (m *MockChainSync) setBlockAnnounce(from peer.ID, header *types.Header) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "setBlockAnnounce", from, header)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // setBlockAnnounce indicates an expected call of setBlockAnnounce.
@@ -1,13 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/ChainSafe/gossamer/dot/sync (interfaces: BlockState,StorageState,TransactionState,BabeVerifier,FinalityGadget,BlockImportHandler,Network)
+// Source: github.com/ChainSafe/gossamer/dot/sync (interfaces: BlockState,StorageState,TransactionState,BabeVerifier,FinalityGadget,WarpSyncVerifier,BlockImportHandler,BlockImportDigestHandler,Network)
 
 // Package sync is a generated GoMock package.
 package sync
 
 import (
 	reflect "reflect"
+	time "time"
 
+	network "github.com/ChainSafe/gossamer/dot/network"
 	peerset "github.com/ChainSafe/gossamer/dot/peerset"
+	state "github.com/ChainSafe/gossamer/dot/state"
 	types "github.com/ChainSafe/gossamer/dot/types"
 	common "github.com/ChainSafe/gossamer/lib/common"
 	runtime "github.com/ChainSafe/gossamer/lib/runtime"
@@ -39,18 +42,18 @@ func (m *MockBlockState) EXPECT() *MockBlockStateMockRecorder {
 	return m.recorder
 }
 
-// AddBlockToBlockTree mocks base method.
-func (m *MockBlockState) AddBlockToBlockTree(arg0 *types.Block) error {
+// AddHeaderToBlockTree mocks base method.
+func (m *MockBlockState) AddHeaderToBlockTree(arg0 *types.Header) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddBlockToBlockTree", arg0)
+	ret := m.ctrl.Call(m, "AddHeaderToBlockTree", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// AddBlockToBlockTree indicates an expected call of AddBlockToBlockTree.
-func (mr *MockBlockStateMockRecorder) AddBlockToBlockTree(arg0 interface{}) *gomock.Call {
+// AddHeaderToBlockTree indicates an expected call of AddHeaderToBlockTree.
+func (mr *MockBlockStateMockRecorder) AddHeaderToBlockTree(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBlockToBlockTree", reflect.TypeOf((*MockBlockState)(nil).AddBlockToBlockTree), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddHeaderToBlockTree", reflect.TypeOf((*MockBlockState)(nil).AddHeaderToBlockTree), arg0)
 }
 
 // BestBlockHeader mocks base method.
@@ -142,6 +145,21 @@ func (mr *MockBlockStateMockRecorder) GetBlockByHash(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockByHash", reflect.TypeOf((*MockBlockState)(nil).GetBlockByHash), arg0)
 }
 
+// GetBlocksInRange mocks base method.
+func (m *MockBlockState) GetBlocksInRange(arg0 state.BlockID, arg1 state.Direction, arg2 uint32, arg3, arg4 bool) ([]state.RawBlockData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksInRange", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]state.RawBlockData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksInRange indicates an expected call of GetBlocksInRange.
+func (mr *MockBlockStateMockRecorder) GetBlocksInRange(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksInRange", reflect.TypeOf((*MockBlockState)(nil).GetBlocksInRange), arg0, arg1, arg2, arg3, arg4)
+}
+
 // GetFinalisedNotifierChannel mocks base method.
 func (m *MockBlockState) GetFinalisedNotifierChannel() chan *types.FinalisationInfo {
 	m.ctrl.T.Helper()
@@ -276,6 +294,20 @@ func (mr *MockBlockStateMockRecorder) GetRuntime(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuntime", reflect.TypeOf((*MockBlockState)(nil).GetRuntime), arg0)
 }
 
+// HandleRuntimeChanges mocks base method.
+func (m *MockBlockState) HandleRuntimeChanges(arg0 *storage.TrieState, arg1 runtime.Instance, arg2 common.Hash) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleRuntimeChanges", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleRuntimeChanges indicates an expected call of HandleRuntimeChanges.
+func (mr *MockBlockStateMockRecorder) HandleRuntimeChanges(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleRuntimeChanges", reflect.TypeOf((*MockBlockState)(nil).HandleRuntimeChanges), arg0, arg1, arg2)
+}
+
 // HasBlockBody mocks base method.
 func (m *MockBlockState) HasBlockBody(arg0 common.Hash) (bool, error) {
 	m.ctrl.T.Helper()
@@ -306,6 +338,49 @@ func (mr *MockBlockStateMockRecorder) HasHeader(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasHeader", reflect.TypeOf((*MockBlockState)(nil).HasHeader), arg0)
 }
 
+// HasJustification mocks base method.
+func (m *MockBlockState) HasJustification(arg0 common.Hash) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasJustification", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasJustification indicates an expected call of HasJustification.
+func (mr *MockBlockStateMockRecorder) HasJustification(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasJustification", reflect.TypeOf((*MockBlockState)(nil).HasJustification), arg0)
+}
+
+// ImportBlocks mocks base method.
+func (m *MockBlockState) ImportBlocks(arg0 []*types.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportBlocks", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportBlocks indicates an expected call of ImportBlocks.
+func (mr *MockBlockStateMockRecorder) ImportBlocks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBlocks", reflect.TypeOf((*MockBlockState)(nil).ImportBlocks), arg0)
+}
+
+// ImportWarpSyncTarget mocks base method.
+func (m *MockBlockState) ImportWarpSyncTarget(arg0 *types.Header, arg1 []byte, arg2, arg3 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportWarpSyncTarget", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportWarpSyncTarget indicates an expected call of ImportWarpSyncTarget.
+func (mr *MockBlockStateMockRecorder) ImportWarpSyncTarget(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportWarpSyncTarget", reflect.TypeOf((*MockBlockState)(nil).ImportWarpSyncTarget), arg0, arg1, arg2, arg3)
+}
+
 // IsDescendantOf mocks base method.
 func (m *MockBlockState) IsDescendantOf(arg0, arg1 common.Hash) (bool, error) {
 	m.ctrl.T.Helper()
@@ -351,18 +426,18 @@ func (mr *MockBlockStateMockRecorder) RangeInMemory(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RangeInMemory", reflect.TypeOf((*MockBlockState)(nil).RangeInMemory), arg0, arg1)
 }
 
-// SetJustification mocks base method.
-func (m *MockBlockState) SetJustification(arg0 common.Hash, arg1 []byte) error {
+// SetArrivalTime mocks base method.
+func (m *MockBlockState) SetArrivalTime(arg0 common.Hash, arg1 uint, arg2 time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetJustification", arg0, arg1)
+	ret := m.ctrl.Call(m, "SetArrivalTime", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetJustification indicates an expected call of SetJustification.
-func (mr *MockBlockStateMockRecorder) SetJustification(arg0, arg1 interface{}) *gomock.Call {
+// SetArrivalTime indicates an expected call of SetArrivalTime.
+func (mr *MockBlockStateMockRecorder) SetArrivalTime(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetJustification", reflect.TypeOf((*MockBlockState)(nil).SetJustification), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetArrivalTime", reflect.TypeOf((*MockBlockState)(nil).SetArrivalTime), arg0, arg1, arg2)
 }
 
 // StoreRuntime mocks base method.
@@ -412,6 +487,20 @@ func (mr *MockStorageStateMockRecorder) Lock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockStorageState)(nil).Lock))
 }
 
+// StoreTrie mocks base method.
+func (m *MockStorageState) StoreTrie(arg0 *storage.TrieState, arg1 *types.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreTrie", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreTrie indicates an expected call of StoreTrie.
+func (mr *MockStorageStateMockRecorder) StoreTrie(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreTrie", reflect.TypeOf((*MockStorageState)(nil).StoreTrie), arg0, arg1)
+}
+
 // TrieState mocks base method.
 func (m *MockStorageState) TrieState(arg0 *common.Hash) (*storage.TrieState, error) {
 	m.ctrl.T.Helper()
@@ -439,6 +528,43 @@ func (mr *MockStorageStateMockRecorder) Unlock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockStorageState)(nil).Unlock))
 }
 
+// MockGrandpaState is a mock of GrandpaState interface.
+type MockGrandpaState struct {
+	ctrl     *gomock.Controller
+	recorder *MockGrandpaStateMockRecorder
+}
+
+// MockGrandpaStateMockRecorder is the mock recorder for MockGrandpaState.
+type MockGrandpaStateMockRecorder struct {
+	mock *MockGrandpaState
+}
+
+// NewMockGrandpaState creates a new mock instance.
+func NewMockGrandpaState(ctrl *gomock.Controller) *MockGrandpaState {
+	mock := &MockGrandpaState{ctrl: ctrl}
+	mock.recorder = &MockGrandpaStateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGrandpaState) EXPECT() *MockGrandpaStateMockRecorder {
+	return m.recorder
+}
+
+// SetWarpSyncAuthorities mocks base method.
+func (m *MockGrandpaState) SetWarpSyncAuthorities(arg0 []types.GrandpaVoter, arg1 []uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWarpSyncAuthorities", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWarpSyncAuthorities indicates an expected call of SetWarpSyncAuthorities.
+func (mr *MockGrandpaStateMockRecorder) SetWarpSyncAuthorities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWarpSyncAuthorities", reflect.TypeOf((*MockGrandpaState)(nil).SetWarpSyncAuthorities), arg0, arg1)
+}
+
 // MockTransactionState is a mock of TransactionState interface.
 type MockTransactionState struct {
 	ctrl     *gomock.Controller
@@ -548,6 +674,45 @@ func (mr *MockFinalityGadgetMockRecorder) VerifyBlockJustification(arg0, arg1 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyBlockJustification", reflect.TypeOf((*MockFinalityGadget)(nil).VerifyBlockJustification), arg0, arg1)
 }
 
+// MockWarpSyncVerifier is a mock of WarpSyncVerifier interface.
+type MockWarpSyncVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockWarpSyncVerifierMockRecorder
+}
+
+// MockWarpSyncVerifierMockRecorder is the mock recorder for MockWarpSyncVerifier.
+type MockWarpSyncVerifierMockRecorder struct {
+	mock *MockWarpSyncVerifier
+}
+
+// NewMockWarpSyncVerifier creates a new mock instance.
+func NewMockWarpSyncVerifier(ctrl *gomock.Controller) *MockWarpSyncVerifier {
+	mock := &MockWarpSyncVerifier{ctrl: ctrl}
+	mock.recorder = &MockWarpSyncVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWarpSyncVerifier) EXPECT() *MockWarpSyncVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyWarpSyncProof mocks base method.
+func (m *MockWarpSyncVerifier) VerifyWarpSyncProof(arg0 *network.WarpSyncProof, arg1 uint64, arg2 []types.GrandpaVoter) (uint64, []types.GrandpaVoter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyWarpSyncProof", arg0, arg1, arg2)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].([]types.GrandpaVoter)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// VerifyWarpSyncProof indicates an expected call of VerifyWarpSyncProof.
+func (mr *MockWarpSyncVerifierMockRecorder) VerifyWarpSyncProof(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyWarpSyncProof", reflect.TypeOf((*MockWarpSyncVerifier)(nil).VerifyWarpSyncProof), arg0, arg1, arg2)
+}
+
 // MockBlockImportHandler is a mock of BlockImportHandler interface.
 type MockBlockImportHandler struct {
 	ctrl     *gomock.Controller
@@ -585,6 +750,43 @@ func (mr *MockBlockImportHandlerMockRecorder) HandleBlockImport(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleBlockImport", reflect.TypeOf((*MockBlockImportHandler)(nil).HandleBlockImport), arg0, arg1, arg2)
 }
 
+// MockBlockImportDigestHandler is a mock of BlockImportDigestHandler interface.
+type MockBlockImportDigestHandler struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlockImportDigestHandlerMockRecorder
+}
+
+// MockBlockImportDigestHandlerMockRecorder is the mock recorder for MockBlockImportDigestHandler.
+type MockBlockImportDigestHandlerMockRecorder struct {
+	mock *MockBlockImportDigestHandler
+}
+
+// NewMockBlockImportDigestHandler creates a new mock instance.
+func NewMockBlockImportDigestHandler(ctrl *gomock.Controller) *MockBlockImportDigestHandler {
+	mock := &MockBlockImportDigestHandler{ctrl: ctrl}
+	mock.recorder = &MockBlockImportDigestHandlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlockImportDigestHandler) EXPECT() *MockBlockImportDigestHandlerMockRecorder {
+	return m.recorder
+}
+
+// Handle mocks base method.
+func (m *MockBlockImportDigestHandler) Handle(arg0 *types.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handle", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Handle indicates an expected call of Handle.
+func (mr *MockBlockImportDigestHandlerMockRecorder) Handle(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockBlockImportDigestHandler)(nil).Handle), arg0)
+}
+
 // MockNetwork is a mock of Network interface.
 type MockNetwork struct {
 	ctrl     *gomock.Controller
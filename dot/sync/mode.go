@@ -0,0 +1,56 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Mode is the mode used to sync the chain.
+type Mode uint8
+
+const (
+	// FullSync imports and executes every block from the
+	// highest finalised block we have.
+	FullSync Mode = iota
+	// FastSync downloads and verifies the headers up to a recent finalised
+	// block, downloads the state of this block from peers, and then
+	// switches to full sync from this block.
+	FastSync
+	// WarpSync downloads and verifies the GRANDPA authority set changes
+	// up to a recent finalised block, downloads the state of this block
+	// from peers, and then switches to full sync from this block.
+	WarpSync
+)
+
+func (mode Mode) String() (s string) {
+	switch mode {
+	case FullSync:
+		return "full"
+	case FastSync:
+		return "fast"
+	case WarpSync:
+		return "warp"
+	default:
+		return "???"
+	}
+}
+
+var ErrModeNotRecognised = errors.New("sync mode is not recognised")
+
+// ParseMode parses a string into a sync mode, and returns an
+// error if it fails. It accepts 'full', 'fast' and 'warp'.
+func ParseMode(s string) (mode Mode, err error) {
+	switch strings.ToLower(s) {
+	case FullSync.String():
+		return FullSync, nil
+	case FastSync.String():
+		return FastSync, nil
+	case WarpSync.String():
+		return WarpSync, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrModeNotRecognised, s)
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseMode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		s          string
+		mode       Mode
+		errWrapped error
+		errMessage string
+	}{
+		"full": {
+			s:    "full",
+			mode: FullSync,
+		},
+		"fast": {
+			s:    "Fast",
+			mode: FastSync,
+		},
+		"warp": {
+			s:    "warp",
+			mode: WarpSync,
+		},
+		"invalid": {
+			s:          "light",
+			errWrapped: ErrModeNotRecognised,
+			errMessage: "sync mode is not recognised: light",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mode, err := ParseMode(testCase.s)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.mode, mode)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// defaultMaxInFlightRequests is the default maximum number
+	// of block requests in flight across all peers.
+	defaultMaxInFlightRequests = 12
+	// maxPeerRequestWindow is the maximum number of
+	// block requests in flight to a single peer.
+	maxPeerRequestWindow = 8
+)
+
+// requestOutcome is the outcome of a block request, adapting
+// the request window of the peer the request was sent to.
+type requestOutcome byte
+
+const (
+	// requestSucceeded grows the request window of the peer by one request.
+	requestSucceeded requestOutcome = iota
+	// requestTimedOut halves the request window of the peer.
+	requestTimedOut
+	// requestFailed leaves the request window of the peer unchanged.
+	requestFailed
+)
+
+// requestWindows limits the number of block requests in flight, both in
+// total and for each peer. The window of each peer is the number of requests
+// which can be in flight to the peer: it starts at one request, grows by one
+// request on each successful response up to maxPeerRequestWindow, and is
+// halved on each request timing out, such that slow peers are sent fewer
+// concurrent requests than fast peers.
+type requestWindows struct {
+	mutex       sync.Mutex
+	maxInFlight uint
+	inFlight    uint
+	peers       map[peer.ID]*peerRequestWindow
+	// released is closed and replaced each time a request is released,
+	// to wake up the callers waiting for a request slot.
+	released chan struct{}
+}
+
+type peerRequestWindow struct {
+	size     uint
+	inFlight uint
+	// removed is true if the peer is disconnected while requests are
+	// in flight to it, such that the window is removed once they are all
+	// released.
+	removed bool
+}
+
+// newRequestWindows returns request windows with the given maximum
+// number of requests in flight in total, defaulting to
+// defaultMaxInFlightRequests if it is zero.
+func newRequestWindows(maxInFlight uint) *requestWindows {
+	if maxInFlight == 0 {
+		maxInFlight = defaultMaxInFlightRequests
+	}
+
+	return &requestWindows{
+		maxInFlight: maxInFlight,
+		peers:       make(map[peer.ID]*peerRequestWindow),
+		released:    make(chan struct{}),
+	}
+}
+
+// acquire reserves a request slot for the first of the peers given with a
+// request slot available, and waits for a request to be released if none of
+// them has one. It returns the peer the request slot is reserved for, which
+// must be released with release once the request completes, or the context
+// error if the context is canceled while waiting.
+func (w *requestWindows) acquire(ctx context.Context, peers []peer.ID) (who peer.ID, err error) {
+	for {
+		w.mutex.Lock()
+		for _, who = range peers {
+			if w.tryAcquire(who) {
+				w.mutex.Unlock()
+				return who, nil
+			}
+		}
+		released := w.released
+		w.mutex.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// tryAcquire reserves a request slot for the peer given and returns true,
+// or returns false if the total maximum number of requests in flight or
+// the request window of the peer are reached. It must be called with the
+// mutex locked.
+func (w *requestWindows) tryAcquire(who peer.ID) (acquired bool) {
+	if w.inFlight >= w.maxInFlight {
+		return false
+	}
+
+	window, ok := w.peers[who]
+	if !ok {
+		window = &peerRequestWindow{size: 1}
+		w.peers[who] = window
+	}
+
+	if window.inFlight >= window.size {
+		return false
+	}
+
+	window.inFlight++
+	w.inFlight++
+	return true
+}
+
+// release releases the request slot reserved for the peer given,
+// and adapts the request window of the peer to the request outcome.
+func (w *requestWindows) release(who peer.ID, outcome requestOutcome) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	window, ok := w.peers[who]
+	if !ok || window.inFlight == 0 {
+		return
+	}
+
+	window.inFlight--
+	w.inFlight--
+
+	if window.removed && window.inFlight == 0 {
+		delete(w.peers, who)
+	}
+
+	switch outcome {
+	case requestSucceeded:
+		if window.size < maxPeerRequestWindow {
+			window.size++
+		}
+	case requestTimedOut:
+		window.size /= 2
+		if window.size == 0 {
+			window.size = 1
+		}
+	case requestFailed:
+	}
+
+	close(w.released)
+	w.released = make(chan struct{})
+}
+
+// remove removes the request window of the peer given, once the requests
+// in flight to the peer are released if there are any, such that a peer
+// connecting again starts with a request window of one request.
+func (w *requestWindows) remove(who peer.ID) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	window, ok := w.peers[who]
+	if !ok {
+		return
+	}
+
+	if window.inFlight > 0 {
+		window.removed = true
+		return
+	}
+	delete(w.peers, who)
+}
+
+// requestOutcomeFromError returns the request outcome for the request error given.
+func requestOutcomeFromError(err error) requestOutcome {
+	if err == nil {
+		return requestSucceeded
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return requestTimedOut
+	}
+	return requestFailed
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_requestWindows_fastAndSlowPeers(t *testing.T) {
+	t.Parallel()
+
+	const (
+		fastPeer = peer.ID("fast")
+		slowPeer = peer.ID("slow")
+		workers  = 12
+		requests = 30
+	)
+
+	windows := newRequestWindows(0)
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	inFlight := map[peer.ID]int{}
+	maxInFlight := map[peer.ID]int{}
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		// Half of the workers try the slow peer first.
+		peers := []peer.ID{fastPeer, slowPeer}
+		if i%2 == 1 {
+			peers = []peer.ID{slowPeer, fastPeer}
+		}
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for j := 0; j < requests; j++ {
+				who, err := windows.acquire(ctx, peers)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				mutex.Lock()
+				inFlight[who]++
+				if inFlight[who] > maxInFlight[who] {
+					maxInFlight[who] = inFlight[who]
+				}
+				mutex.Unlock()
+
+				outcome := requestSucceeded
+				duration := time.Millisecond
+				if who == slowPeer {
+					outcome = requestTimedOut
+					duration = 5 * time.Millisecond
+				}
+				time.Sleep(duration)
+
+				mutex.Lock()
+				inFlight[who]--
+				mutex.Unlock()
+				windows.release(who, outcome)
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	assert.Equal(t, 1, maxInFlight[slowPeer])
+	assert.Greater(t, maxInFlight[fastPeer], maxInFlight[slowPeer])
+	assert.LessOrEqual(t, maxInFlight[fastPeer], maxPeerRequestWindow)
+	assert.Equal(t, uint(maxPeerRequestWindow), windows.peers[fastPeer].size)
+	assert.Equal(t, uint(1), windows.peers[slowPeer].size)
+	assert.Zero(t, windows.inFlight)
+}
+
+func Test_requestWindows_backOff(t *testing.T) {
+	t.Parallel()
+
+	const who = peer.ID("peer")
+	windows := newRequestWindows(4)
+	ctx := context.Background()
+
+	acquireAll := func() (acquired int) {
+		for windows.tryAcquire(who) {
+			acquired++
+		}
+		return acquired
+	}
+	releaseAll := func(acquired int, outcome requestOutcome) {
+		for i := 0; i < acquired; i++ {
+			windows.release(who, outcome)
+		}
+	}
+
+	// The window grows by one request on each success,
+	// and is bounded by the total maximum in flight.
+	for _, expected := range []int{1, 2, 4, 4} {
+		acquired := acquireAll()
+		assert.Equal(t, expected, acquired)
+		releaseAll(acquired, requestSucceeded)
+	}
+	assert.Equal(t, uint(maxPeerRequestWindow), windows.peers[who].size)
+
+	// The window is halved once for each timeout.
+	_, err := windows.acquire(ctx, []peer.ID{who})
+	require.NoError(t, err)
+	windows.release(who, requestTimedOut)
+	assert.Equal(t, uint(4), windows.peers[who].size)
+
+	// Other failures leave the window unchanged.
+	_, err = windows.acquire(ctx, []peer.ID{who})
+	require.NoError(t, err)
+	windows.release(who, requestFailed)
+	assert.Equal(t, uint(4), windows.peers[who].size)
+
+	// Waiting for a request slot stops once the context is canceled.
+	assert.Equal(t, 4, acquireAll())
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = windows.acquire(canceledCtx, []peer.ID{who})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_requestWindows_remove(t *testing.T) {
+	t.Parallel()
+
+	const (
+		idlePeer = peer.ID("idle")
+		busyPeer = peer.ID("busy")
+	)
+	windows := newRequestWindows(0)
+
+	require.True(t, windows.tryAcquire(idlePeer))
+	windows.release(idlePeer, requestSucceeded)
+	require.True(t, windows.tryAcquire(busyPeer))
+
+	windows.remove(idlePeer)
+	assert.NotContains(t, windows.peers, idlePeer)
+
+	// The window of a peer with a request in flight
+	// is only removed once the request is released.
+	windows.remove(busyPeer)
+	assert.Contains(t, windows.peers, busyPeer)
+	windows.release(busyPeer, requestFailed)
+	assert.NotContains(t, windows.peers, busyPeer)
+	assert.Zero(t, windows.inFlight)
+
+	windows.remove(peer.ID("unknown"))
+}
+
+func Test_requestOutcomeFromError(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		err     error
+		outcome requestOutcome
+	}{
+		"nil_error": {
+			outcome: requestSucceeded,
+		},
+		"deadline_exceeded": {
+			err:     fmt.Errorf("reading stream: %w", context.DeadlineExceeded),
+			outcome: requestTimedOut,
+		},
+		"other_error": {
+			err:     errors.New("stream reset"),
+			outcome: requestFailed,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.outcome, requestOutcomeFromError(testCase.err))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// maxStateResponseBytes is the maximum size of the storage
+// entries of a state response, as in Substrate.
+const maxStateResponseBytes = 2 * 1024 * 1024
+
+// CreateStateResponse creates a state response message from a state request message.
+// The response contains the storage entries of the state of the requested block
+// following the start key of the request, in key order, up to maxStateResponseBytes.
+// The entries of a child trie follow the entry of its child storage key in the top trie,
+// and a response ending in a child trie is resumed with a request starting with the
+// child storage key followed by the last child trie key received.
+// Only requests for storage entries are served, and not requests for proofs.
+func (s *Service) CreateStateResponse(req *network.StateRequestMessage) (
+	response *network.StateResponseMessage, err error) {
+	if !req.NoProof {
+		return nil, errStateProofNotSupported
+	} else if len(req.Start) > 2 {
+		return nil, fmt.Errorf("%w: %d keys", errStateRequestStartInvalid, len(req.Start))
+	}
+
+	header, err := s.blockState.GetHeader(req.Block)
+	if err != nil {
+		return nil, fmt.Errorf("getting header: %w", err)
+	}
+
+	trieState, err := s.storageState.TrieState(&header.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("getting state of block number %d: %w", header.Number, err)
+	}
+
+	var (
+		top      network.KeyValueStateEntry
+		children []network.KeyValueStateEntry
+		size     int
+		topStart []byte
+	)
+	if len(req.Start) > 0 {
+		topStart = req.Start[0]
+	}
+
+	if len(req.Start) == 2 {
+		if !bytes.HasPrefix(topStart, trie.ChildStorageKeyPrefix) {
+			return nil, fmt.Errorf("%w: 0x%x is not a child storage key", errStateRequestStartInvalid, topStart)
+		}
+
+		child, err := collectChildStateEntries(trieState.Trie(), topStart, req.Start[1], &size)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	key := topStart
+	for len(children) == 0 || children[len(children)-1].Complete {
+		if len(top.Entries) > 0 && size >= maxStateResponseBytes {
+			break
+		}
+
+		key = trieState.NextKey(key)
+		if key == nil {
+			top.Complete = true
+			break
+		}
+
+		value := trieState.Get(key)
+		top.Entries = append(top.Entries, network.StateEntry{Key: key, Value: value})
+		size += len(key) + len(value)
+
+		if !bytes.HasPrefix(key, trie.ChildStorageKeyPrefix) {
+			continue
+		}
+
+		child, err := collectChildStateEntries(trieState.Trie(), key, nil, &size)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	return &network.StateResponseMessage{
+		Entries: append([]network.KeyValueStateEntry{top}, children...),
+	}, nil
+}
+
+// collectChildStateEntries returns the storage entries of the child trie at
+// the child storage key given, following the start key given, up to
+// maxStateResponseBytes minus the size given, which is increased by the
+// size of the entries returned. At least one entry is returned if the
+// child trie has an entry after the start key.
+func collectChildStateEntries(stateTrie *trie.Trie, childStorageKey, start []byte,
+	size *int) (child network.KeyValueStateEntry, err error) {
+	childTrie, err := stateTrie.GetChild(childStorageKey[len(trie.ChildStorageKeyPrefix):])
+	if err != nil {
+		return child, fmt.Errorf("getting child trie: %w", err)
+	}
+
+	child.StateRoot = common.BytesToHash(stateTrie.Get(childStorageKey))
+	child.Entries, child.Complete = collectStateEntries(childTrie, start, size)
+	return child, nil
+}
+
+// collectStateEntries returns the storage entries of the trie given following
+// the start key given, up to maxStateResponseBytes minus the size given, which
+// is increased by the size of the entries returned, and whether all the entries
+// of the trie are returned. At least one entry is returned if there is one.
+func collectStateEntries(t *trie.Trie, start []byte, size *int) (
+	entries []network.StateEntry, complete bool) {
+	key := start
+	for len(entries) == 0 || *size < maxStateResponseBytes {
+		key = t.NextKey(key)
+		if key == nil {
+			return entries, true
+		}
+
+		value := t.Get(key)
+		entries = append(entries, network.StateEntry{Key: key, Value: value})
+		*size += len(key) + len(value)
+	}
+	return entries, false
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CreateStateResponse(t *testing.T) {
+	t.Parallel()
+
+	// The values are large enough for the state to be
+	// served in multiple responses, one of them ending
+	// in the middle of the child trie.
+	largeValue := bytes.Repeat([]byte{1}, maxStateResponseBytes/3)
+
+	child := trie.NewEmptyTrie()
+	for i := byte(0); i < 4; i++ {
+		err := child.Put([]byte{'k', i}, largeValue)
+		require.NoError(t, err)
+	}
+
+	stateTrie := trie.NewEmptyTrie()
+	for _, key := range []string{":a", ":b", ":z"} {
+		err := stateTrie.Put([]byte(key), largeValue)
+		require.NoError(t, err)
+	}
+	err := stateTrie.SetChild([]byte{'c'}, child)
+	require.NoError(t, err)
+	stateRoot := stateTrie.MustHash()
+
+	header := &types.Header{Number: 1, StateRoot: stateRoot}
+	blockHash := header.Hash()
+
+	ctrl := gomock.NewController(t)
+	blockState := NewMockBlockState(ctrl)
+	blockState.EXPECT().GetHeader(blockHash).Return(header, nil).AnyTimes()
+	storageState := NewMockStorageState(ctrl)
+	storageState.EXPECT().TrieState(&stateRoot).
+		Return(rtstorage.NewTrieState(stateTrie), nil).AnyTimes()
+
+	service := &Service{
+		blockState:   blockState,
+		storageState: storageState,
+	}
+
+	// the state served is downloaded as by fast sync
+	who := mustDecodePeer(t, testPeerA)
+	download := newStateDownload()
+	responses := 0
+	for !download.complete {
+		response, err := service.CreateStateResponse(download.nextRequest(blockHash))
+		require.NoError(t, err)
+
+		size := 0
+		for _, keyValueStateEntry := range response.Entries {
+			for _, entry := range keyValueStateEntry.Entries {
+				size += len(entry.Key) + len(entry.Value)
+			}
+		}
+		assert.LessOrEqual(t, size, maxStateResponseBytes+len(largeValue)+2)
+
+		err = download.handleResponse(who, response)
+		require.NoError(t, err)
+		responses++
+	}
+	assert.Equal(t, 3, responses)
+
+	downloaded, err := download.buildTrie(stateRoot)
+	require.NoError(t, err)
+	assert.Equal(t, stateTrie.Entries(), downloaded.Entries())
+
+	_, err = service.CreateStateResponse(&network.StateRequestMessage{Block: blockHash})
+	assert.ErrorIs(t, err, errStateProofNotSupported)
+
+	_, err = service.CreateStateResponse(&network.StateRequestMessage{
+		Block:   blockHash,
+		Start:   [][]byte{[]byte(":a"), {1}},
+		NoProof: true,
+	})
+	assert.ErrorIs(t, err, errStateRequestStartInvalid)
+	assert.EqualError(t, err, "state request start is not valid: 0x3a61 is not a child storage key")
+
+	errTest := errors.New("test error")
+	blockState.EXPECT().GetHeader(common.Hash{1}).Return(nil, errTest)
+	_, err = service.CreateStateResponse(&network.StateRequestMessage{
+		Block:   common.Hash{1},
+		NoProof: true,
+	})
+	assert.ErrorIs(t, err, errTest)
+	assert.EqualError(t, err, "getting header: test error")
+}
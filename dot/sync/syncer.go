@@ -4,10 +4,13 @@
 package sync
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -18,9 +21,23 @@
 // Service deals with chain syncing by sending block request messages and watching for responses.
 type Service struct {
 	blockState     BlockState
+	storageState   StorageState
 	chainSync      ChainSync
 	chainProcessor ChainProcessor
 	network        Network
+
+	mode       Mode
+	fastSyncer *fastSyncer
+	warpSyncer *WarpSyncer
+	// cancel cancels fast or warp sync, and done is closed once
+	// fast or warp sync and the start of full sync are done.
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// maxResponseBlocks and maxResponseBytes bound the block responses
+	// served, and are the defaults if zero.
+	maxResponseBlocks uint
+	maxResponseBytes  uint
 }
 
 // Config is the configuration for the sync Service.
@@ -32,15 +49,43 @@ type Config struct {
 	FinalityGadget     FinalityGadget
 	TransactionState   TransactionState
 	BlockImportHandler BlockImportHandler
-	BabeVerifier       BabeVerifier
+	// BlockImportDigestHandler handles the consensus digests
+	// of the headers imported during fast sync.
+	BlockImportDigestHandler BlockImportDigestHandler
+	BabeVerifier             BabeVerifier
+	// WarpSyncVerifier and GrandpaState are used in warp sync mode to verify
+	// warp sync proofs, and to store the GRANDPA authority set proven.
+	WarpSyncVerifier WarpSyncVerifier
+	GrandpaState     GrandpaState
+	// GenesisHash and GenesisAuthorities are the genesis block hash and
+	// the GRANDPA authorities of set ID 0, used in warp sync mode.
+	GenesisHash        common.Hash
+	GenesisAuthorities []types.GrandpaVoter
 	MinPeers, MaxPeers int
 	SlotDuration       time.Duration
 	Telemetry          Telemetry
 	BadBlocks          []string
+	// Mode is the sync mode to use when the service starts.
+	Mode Mode
+	// ValidateBlockAnnounces can be set to true to verify the BABE seal of
+	// announced headers with a known parent before relaying them.
+	ValidateBlockAnnounces bool
+	// MaxBlockResponseBlocks and MaxBlockResponseBytes are the maximum number
+	// of blocks and maximum encoded size in bytes of each block response served,
+	// such that a request for more blocks is served in several chunks.
+	// They default to 128 blocks and 8 MiB if set to zero.
+	MaxBlockResponseBlocks uint
+	MaxBlockResponseBytes  uint
+	// MaxInFlightBlockRequests is the maximum number of block requests in
+	// flight while syncing, across all peers. The requests in flight to each
+	// peer are further limited by a window adapting to the peer responses.
+	// It defaults to 12 if set to zero.
+	MaxInFlightBlockRequests uint
 }
 
-// NewService returns a new *sync.Service
-func NewService(cfg *Config, blockReqRes network.RequestMaker) (*Service, error) {
+// NewService returns a new *sync.Service. The state request maker is only used
+// in fast and warp sync modes, and the warp request maker in warp sync mode.
+func NewService(cfg *Config, blockReqRes, stateReqRes, warpReqRes network.RequestMaker) (*Service, error) {
 	logger.Patch(log.SetLevel(cfg.LogLvl))
 
 	readyBlocks := newBlockQueue(maxResponseSize * 30)
@@ -54,6 +99,10 @@ func NewService(cfg *Config, blockReqRes network.RequestMaker) (*Service, error)
 		minPeers:      cfg.MinPeers,
 		maxPeers:      cfg.MaxPeers,
 		slotDuration:  cfg.SlotDuration,
+		babeVerifier:  cfg.BabeVerifier,
+
+		validateBlockAnnounces: cfg.ValidateBlockAnnounces,
+		maxInFlightRequests:    cfg.MaxInFlightBlockRequests,
 	}
 	chainSync := newChainSync(csCfg, blockReqRes)
 
@@ -72,23 +121,86 @@ func NewService(cfg *Config, blockReqRes network.RequestMaker) (*Service, error)
 	}
 	chainProcessor := newChainProcessor(cpCfg)
 
-	return &Service{
+	fsCfg := fastSyncerConfig{
 		blockState:     cfg.BlockState,
-		chainSync:      chainSync,
-		chainProcessor: chainProcessor,
+		storageState:   cfg.StorageState,
 		network:        cfg.Network,
+		babeVerifier:   cfg.BabeVerifier,
+		finalityGadget: cfg.FinalityGadget,
+		digestHandler:  cfg.BlockImportDigestHandler,
+		minPeers:       cfg.MinPeers,
+	}
+	fastSyncer := newFastSyncer(fsCfg, blockReqRes, stateReqRes)
+
+	warpSyncer := NewWarpSyncer(WarpSyncerConfig{
+		Network:            cfg.Network,
+		Verifier:           cfg.WarpSyncVerifier,
+		BlockState:         cfg.BlockState,
+		StorageState:       cfg.StorageState,
+		GrandpaState:       cfg.GrandpaState,
+		GenesisHash:        cfg.GenesisHash,
+		GenesisAuthorities: cfg.GenesisAuthorities,
+		MinPeers:           cfg.MinPeers,
+	}, warpReqRes, stateReqRes)
+
+	return &Service{
+		blockState:        cfg.BlockState,
+		storageState:      cfg.StorageState,
+		chainSync:         chainSync,
+		chainProcessor:    chainProcessor,
+		network:           cfg.Network,
+		mode:              cfg.Mode,
+		fastSyncer:        fastSyncer,
+		warpSyncer:        warpSyncer,
+		maxResponseBlocks: cfg.MaxBlockResponseBlocks,
+		maxResponseBytes:  cfg.MaxBlockResponseBytes,
 	}, nil
 }
 
-// Start begins the chainSync and chainProcessor modules. It begins syncing in bootstrap mode
+// Start begins the chainSync and chainProcessor modules. It begins syncing in bootstrap mode.
+// In fast or warp sync mode, it first fast or warp syncs the chain in the background, and then
+// starts the chainSync and chainProcessor modules to continue with a full sync.
 func (s *Service) Start() error {
+	var syncFunc func(ctx context.Context) error
+	switch s.mode {
+	case FastSync:
+		syncFunc = s.fastSyncer.sync
+	case WarpSync:
+		syncFunc = s.warpSyncer.sync
+	default:
+		s.startFullSync()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		logger.Infof("⏩ %s syncing...", s.mode)
+		err := syncFunc(ctx)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger.Errorf("%s sync failed: %s", s.mode, err)
+			}
+			return
+		}
+		s.startFullSync()
+	}()
+	return nil
+}
+
+func (s *Service) startFullSync() {
 	go s.chainSync.start()
 	go s.chainProcessor.processReadyBlocks()
-	return nil
 }
 
-// Stop stops the chainSync and chainProcessor modules
+// Stop stops fast or warp sync if it is running, and the chainSync and chainProcessor modules
 func (s *Service) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
 	s.chainSync.stop()
 	s.chainProcessor.stop()
 	return nil
@@ -101,12 +213,19 @@ func (s *Service) HandleBlockAnnounceHandshake(from peer.ID, msg *network.BlockA
 }
 
 // HandleBlockAnnounce notifies the `chainSync` module that we have received a block announcement from the given peer.
-func (s *Service) HandleBlockAnnounce(from peer.ID, msg *network.BlockAnnounceMessage) error {
+// It returns true if the announced header is validated and the announcement can be relayed.
+func (s *Service) HandleBlockAnnounce(from peer.ID, msg *network.BlockAnnounceMessage) (gossip bool, err error) {
 	logger.Debug("received BlockAnnounceMessage")
 	header := types.NewHeader(msg.ParentHash, msg.StateRoot, msg.ExtrinsicsRoot, msg.Number, msg.Digest)
 	return s.chainSync.setBlockAnnounce(from, header)
 }
 
+// HandlePeerDisconnected notifies the `chainSync` module that
+// the connection to the given peer is closed.
+func (s *Service) HandlePeerDisconnected(who peer.ID) {
+	s.chainSync.removePeer(who)
+}
+
 // IsSynced exposes the synced state
 func (s *Service) IsSynced() bool {
 	return s.chainSync.syncState() == tip
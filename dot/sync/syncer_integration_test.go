@@ -117,7 +117,7 @@ func(block *types.Block, ts *rtstorage.TrieState, _ bool) error {
 	cfg.Network = NewMockNetwork(ctrl)
 	cfg.Telemetry = mockTelemetryClient
 	mockReqRes := NewMockRequestMaker(ctrl)
-	syncer, err := NewService(cfg, mockReqRes)
+	syncer, err := NewService(cfg, mockReqRes, nil, nil)
 	require.NoError(t, err)
 	return syncer
 }
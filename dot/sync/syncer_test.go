@@ -47,8 +47,10 @@ func TestNewService(t *testing.T) {
 
 			config := tt.cfgBuilder(ctrl)
 			mockReqRes := NewMockRequestMaker(ctrl)
+			mockStateReqRes := NewMockRequestMaker(ctrl)
+			mockWarpReqRes := NewMockRequestMaker(ctrl)
 
-			got, err := NewService(config, mockReqRes)
+			got, err := NewService(config, mockReqRes, mockStateReqRes, mockWarpReqRes)
 			if tt.err != nil {
 				assert.EqualError(t, err, tt.err.Error())
 			} else {
@@ -105,7 +107,7 @@ type args struct {
 			s := &Service{
 				chainSync: tt.fields.chainSync,
 			}
-			if err := s.HandleBlockAnnounce(tt.args.from, tt.args.msg); (err != nil) != tt.wantErr {
+			if _, err := s.HandleBlockAnnounce(tt.args.from, tt.args.msg); (err != nil) != tt.wantErr {
 				t.Errorf("HandleBlockAnnounce() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -117,7 +119,7 @@ func newMockChainSync(ctrl *gomock.Controller) ChainSync {
 	header := types.NewHeader(common.Hash{}, common.Hash{}, common.Hash{}, 1,
 		scale.VaryingDataTypeSlice{})
 
-	mock.EXPECT().setBlockAnnounce(peer.ID("1"), header).Return(nil).AnyTimes()
+	mock.EXPECT().setBlockAnnounce(peer.ID("1"), header).Return(false, nil).AnyTimes()
 	mock.EXPECT().setPeerHead(peer.ID("1"), common.Hash{}, uint(0)).Return(nil).AnyTimes()
 	mock.EXPECT().syncState().Return(bootstrap).AnyTimes()
 	mock.EXPECT().start().AnyTimes()
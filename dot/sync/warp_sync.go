@@ -0,0 +1,274 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// WarpSyncer downloads and verifies the chain of GRANDPA authority set
+// changes from the genesis authorities up to a recent finalised block,
+// and then downloads the header and state of this block from peers,
+// without downloading the headers in between.
+type WarpSyncer struct {
+	network            Network
+	verifier           WarpSyncVerifier
+	blockState         BlockState
+	grandpaState       GrandpaState
+	genesisHash        common.Hash
+	genesisAuthorities []types.GrandpaVoter
+	warpReqRes         network.RequestMaker
+	// stateDownloader is used to select peers, and
+	// to download and import the state of the target block.
+	stateDownloader *fastSyncer
+}
+
+// WarpSyncerConfig is the configuration of a WarpSyncer.
+type WarpSyncerConfig struct {
+	Network      Network
+	Verifier     WarpSyncVerifier
+	BlockState   BlockState
+	StorageState StorageState
+	GrandpaState GrandpaState
+	// GenesisHash is the hash of the genesis block,
+	// where the first warp sync proof starts.
+	GenesisHash common.Hash
+	// GenesisAuthorities are the GRANDPA authorities of set ID 0.
+	GenesisAuthorities []types.GrandpaVoter
+	MinPeers           int
+}
+
+// WarpSyncResult is the result of a successful warp sync.
+type WarpSyncResult struct {
+	// Target is the header of the last finalised block proven,
+	// and Justification is its justification.
+	Target        *types.Header
+	Justification *types.GrandpaJustification
+	// SetChanges are the block numbers of the last block of each
+	// authority set before the authority set with the set ID SetID.
+	SetChanges []uint
+	// SetID and Authorities are the GRANDPA authority
+	// set following the Target block.
+	SetID       uint64
+	Authorities []types.GrandpaVoter
+	// State is the state trie of the Target block.
+	State *trie.Trie
+}
+
+// NewWarpSyncer creates a warp syncer requesting warp sync proofs
+// with the warp request maker, and states with the state request maker.
+func NewWarpSyncer(cfg WarpSyncerConfig, warpReqRes, stateReqRes network.RequestMaker) *WarpSyncer {
+	stateDownloader := newFastSyncer(fastSyncerConfig{
+		blockState:   cfg.BlockState,
+		storageState: cfg.StorageState,
+		network:      cfg.Network,
+		minPeers:     cfg.MinPeers,
+	}, nil, stateReqRes)
+
+	return &WarpSyncer{
+		network:            cfg.Network,
+		verifier:           cfg.Verifier,
+		blockState:         cfg.BlockState,
+		grandpaState:       cfg.GrandpaState,
+		genesisHash:        cfg.GenesisHash,
+		genesisAuthorities: cfg.GenesisAuthorities,
+		warpReqRes:         warpReqRes,
+		stateDownloader:    stateDownloader,
+	}
+}
+
+// sync warp syncs the chain and imports the result, and retries
+// until it succeeds or the context is canceled.
+func (w *WarpSyncer) sync(ctx context.Context) (err error) {
+	return w.stateDownloader.retry(ctx, "warp sync", w.syncOnce)
+}
+
+func (w *WarpSyncer) syncOnce(ctx context.Context) (err error) {
+	result, err := w.Sync(ctx)
+	if err != nil {
+		return err
+	}
+
+	highestFinalised, err := w.blockState.GetHighestFinalisedHeader()
+	if err != nil {
+		return fmt.Errorf("getting highest finalised header: %w", err)
+	} else if result.Target.Number <= highestFinalised.Number {
+		logger.Infof("warp sync target block number %d is not above finalised block number %d",
+			result.Target.Number, highestFinalised.Number)
+		return nil
+	}
+
+	err = w.Import(result)
+	if err != nil {
+		return fmt.Errorf("importing warp sync target block number %d: %w", result.Target.Number, err)
+	}
+
+	return nil
+}
+
+// Sync verifies the warp sync proofs from peers up to a recent finalised block,
+// and downloads the state of this block. The target header and state returned
+// are verified, but are not imported.
+func (w *WarpSyncer) Sync(ctx context.Context) (result *WarpSyncResult, err error) {
+	err = w.stateDownloader.waitForPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = w.syncProofs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("syncing warp sync proofs: %w", err)
+	}
+	target := result.Target
+
+	logger.Infof("downloading state of warp sync target block number %d with hash %s...",
+		target.Number, target.Hash())
+	result.State, err = w.stateDownloader.downloadState(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("downloading state of block number %d: %w", target.Number, err)
+	}
+
+	logger.Infof("⏩ warp synced state of finalised block number %d with hash %s",
+		target.Number, target.Hash())
+	return result, nil
+}
+
+// Import imports the result of a warp sync given: the target header is stored as
+// the highest finalised block with its justification, the authority set proven is
+// set as the current GRANDPA authority set, and the state of the target is stored.
+func (w *WarpSyncer) Import(result *WarpSyncResult) (err error) {
+	// The target is finalised by the authority set before
+	// the authority set it changes to, if it changes it.
+	justificationSetID := result.SetID
+	lastChange := len(result.SetChanges) - 1
+	if lastChange >= 0 && result.SetChanges[lastChange] == result.Target.Number {
+		justificationSetID--
+	}
+
+	justification, err := scale.Marshal(*result.Justification)
+	if err != nil {
+		return fmt.Errorf("encoding justification: %w", err)
+	}
+
+	err = w.blockState.ImportWarpSyncTarget(result.Target, justification,
+		result.Justification.Round, justificationSetID)
+	if err != nil {
+		return fmt.Errorf("importing target header: %w", err)
+	}
+
+	err = w.grandpaState.SetWarpSyncAuthorities(result.Authorities, result.SetChanges)
+	if err != nil {
+		return fmt.Errorf("setting authorities: %w", err)
+	}
+
+	err = w.stateDownloader.importState(result.Target, result.State)
+	if err != nil {
+		return fmt.Errorf("importing state: %w", err)
+	}
+
+	return nil
+}
+
+// syncProofs requests and verifies warp sync proofs, starting from the genesis
+// authorities, until a finished proof is verified. A proof is requested from
+// the block of the last fragment verified, such that if a peer cannot serve a
+// fragment, or sends an invalid proof, the proof is continued with another peer.
+// It returns a result without state, with the header and justification of the
+// last fragment verified, and the authority set following this fragment.
+func (w *WarpSyncer) syncProofs(ctx context.Context) (result *WarpSyncResult, err error) {
+	result = &WarpSyncResult{Authorities: w.genesisAuthorities}
+	begin := w.genesisHash
+	var beginNumber uint
+	peersExcluded := make(map[peer.ID]struct{})
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		who, ok := w.stateDownloader.selectPeer(beginNumber, peersExcluded)
+		if !ok {
+			return nil, fmt.Errorf("%w: for warp sync proof from block %s", errNoPeers, begin)
+		}
+
+		proof, err := w.requestProof(who, begin, beginNumber)
+		if err != nil {
+			logger.Debugf("requesting warp sync proof from block %s from peer %s: %s", begin, who, err)
+			peersExcluded[who] = struct{}{}
+			continue
+		}
+
+		if len(proof.Fragments) == 0 {
+			// The peer has no finalised block after the last fragment verified.
+			return result, nil
+		}
+
+		nextSetID, nextAuthorities, err := w.verifier.VerifyWarpSyncProof(proof, result.SetID, result.Authorities)
+		if err != nil {
+			w.network.ReportPeer(peerset.ReputationChange{
+				Value:  peerset.BadJustificationValue,
+				Reason: peerset.BadJustificationReason,
+			}, who)
+			logger.Debugf("verifying warp sync proof from block %s from peer %s: %s", begin, who, err)
+			peersExcluded[who] = struct{}{}
+			continue
+		}
+
+		// Each fragment changes the authority set, except
+		// the last fragment of a finished proof if it is not
+		// the last block of its authority set.
+		for _, fragment := range proof.Fragments[:nextSetID-result.SetID] {
+			result.SetChanges = append(result.SetChanges, fragment.Header.Number)
+		}
+
+		last := proof.Fragments[len(proof.Fragments)-1]
+		result.Target = &last.Header
+		result.Justification = &last.Justification
+		begin, beginNumber = last.Header.Hash(), last.Header.Number
+		result.SetID, result.Authorities = nextSetID, nextAuthorities
+		logger.Debugf("verified warp sync proof up to block number %d with set id %d",
+			beginNumber, result.SetID)
+
+		if proof.IsFinished {
+			return result, nil
+		}
+	}
+}
+
+// requestProof requests the warp sync proof from the block given from the peer given.
+// It returns an error if the proof makes no progress, and only returns an empty proof
+// if it is finished and the begin block is not the genesis block.
+func (w *WarpSyncer) requestProof(who peer.ID, begin common.Hash, beginNumber uint) (
+	proof *network.WarpSyncProof, err error) {
+	request := &network.WarpProofRequestMessage{Begin: begin}
+	proof = new(network.WarpSyncProof)
+	err = w.warpReqRes.Do(who, request, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(proof.Fragments) == 0 {
+		if !proof.IsFinished || beginNumber == 0 {
+			return nil, errWarpSyncProofEmpty
+		}
+		return proof, nil
+	}
+
+	firstNumber := proof.Fragments[0].Header.Number
+	if firstNumber <= beginNumber {
+		return nil, fmt.Errorf("%w: first fragment block number %d is not above block number %d",
+			errWarpSyncProofNoProgress, firstNumber, beginNumber)
+	}
+
+	return proof, nil
+}
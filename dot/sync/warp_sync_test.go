@@ -0,0 +1,315 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/peerset"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setWarpSyncProofResponse returns a function setting the warp sync
+// proof response given, to be used with RequestMaker.Do.
+func setWarpSyncProofResponse(proof network.WarpSyncProof) func(peer.ID,
+	network.Message, network.ResponseMessage) error {
+	return func(_ peer.ID, _ network.Message, response network.ResponseMessage) error {
+		*response.(*network.WarpSyncProof) = proof
+		return nil
+	}
+}
+
+func Test_WarpSyncer_Sync(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	peerA := mustDecodePeer(t, testPeerA)
+	peerB := mustDecodePeer(t, testPeerB)
+	peerC := mustDecodePeer(t, testPeerC)
+
+	expectedTrie := trie.NewEmptyTrie()
+	err := expectedTrie.Put([]byte(":code"), []byte{1})
+	require.NoError(t, err)
+	stateRoot, err := expectedTrie.Hash()
+	require.NoError(t, err)
+
+	genesisHash := common.Hash{0xa}
+	header2 := types.Header{ParentHash: common.Hash{1}, Number: 2, Digest: types.NewDigest()}
+	header4 := types.Header{ParentHash: common.Hash{3}, Number: 4, Digest: types.NewDigest()}
+	header6 := types.Header{ParentHash: common.Hash{5}, Number: 6,
+		StateRoot: stateRoot, Digest: types.NewDigest()}
+	invalidProof := network.WarpSyncProof{
+		Fragments: []network.WarpSyncFragment{
+			{Header: header2, Justification: types.GrandpaJustification{Round: 0}},
+		},
+	}
+	firstProof := network.WarpSyncProof{
+		Fragments: []network.WarpSyncFragment{
+			{Header: header2, Justification: types.GrandpaJustification{Round: 1}},
+		},
+	}
+	lastProof := network.WarpSyncProof{
+		Fragments: []network.WarpSyncFragment{
+			{Header: header4, Justification: types.GrandpaJustification{Round: 2}},
+			{Header: header6, Justification: types.GrandpaJustification{Round: 3}},
+		},
+		IsFinished: true,
+	}
+
+	set0 := []types.GrandpaVoter{{Key: ed25519.PublicKey{}, ID: 0}}
+	set1 := []types.GrandpaVoter{{Key: ed25519.PublicKey{}, ID: 1}}
+	set2 := []types.GrandpaVoter{{Key: ed25519.PublicKey{}, ID: 2}}
+
+	networkMock := NewMockNetwork(ctrl)
+	networkMock.EXPECT().Peers().Return([]common.PeerInfo{
+		{PeerID: testPeerA, BestNumber: 10},
+		{PeerID: testPeerB, BestNumber: 9},
+		{PeerID: testPeerC, BestNumber: 8},
+	}).AnyTimes()
+
+	verifier := NewMockWarpSyncVerifier(ctrl)
+	warpReqRes := NewMockRequestMaker(ctrl)
+	stateReqRes := NewMockRequestMaker(ctrl)
+
+	genesisRequest := &network.WarpProofRequestMessage{Begin: genesisHash}
+	header2Request := &network.WarpProofRequestMessage{Begin: header2.Hash()}
+	gomock.InOrder(
+		// Peer A sends an invalid proof and is reported.
+		warpReqRes.EXPECT().Do(peerA, genesisRequest, gomock.Any()).
+			DoAndReturn(setWarpSyncProofResponse(invalidProof)),
+		verifier.EXPECT().VerifyWarpSyncProof(&invalidProof, uint64(0), set0).
+			Return(uint64(0), nil, errors.New("test error")),
+		networkMock.EXPECT().ReportPeer(peerset.ReputationChange{
+			Value:  peerset.BadJustificationValue,
+			Reason: peerset.BadJustificationReason,
+		}, peerA),
+		// Peer B sends the first set change only, and cannot
+		// serve the fragment of the second set change.
+		warpReqRes.EXPECT().Do(peerB, genesisRequest, gomock.Any()).
+			DoAndReturn(setWarpSyncProofResponse(firstProof)),
+		verifier.EXPECT().VerifyWarpSyncProof(&firstProof, uint64(0), set0).
+			Return(uint64(1), set1, nil),
+		warpReqRes.EXPECT().Do(peerB, header2Request, gomock.Any()).
+			Return(errors.New("fragment not available")),
+		// Peer C continues the proof from the first set change.
+		warpReqRes.EXPECT().Do(peerC, header2Request, gomock.Any()).
+			DoAndReturn(setWarpSyncProofResponse(lastProof)),
+		verifier.EXPECT().VerifyWarpSyncProof(&lastProof, uint64(1), set1).
+			Return(uint64(2), set2, nil),
+		// The state of the target block is downloaded from any peer.
+		stateReqRes.EXPECT().Do(peerA, &network.StateRequestMessage{
+			Block:   header6.Hash(),
+			NoProof: true,
+		}, gomock.Any()).DoAndReturn(func(_ peer.ID, _ network.Message,
+			response network.ResponseMessage) error {
+			*response.(*network.StateResponseMessage) = network.StateResponseMessage{
+				Entries: []network.KeyValueStateEntry{{
+					Entries:  []network.StateEntry{{Key: []byte(":code"), Value: []byte{1}}},
+					Complete: true,
+				}},
+			}
+			return nil
+		}),
+	)
+
+	syncer := NewWarpSyncer(WarpSyncerConfig{
+		Network:            networkMock,
+		Verifier:           verifier,
+		GenesisHash:        genesisHash,
+		GenesisAuthorities: set0,
+	}, warpReqRes, stateReqRes)
+
+	result, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, &header6, result.Target)
+	assert.Equal(t, &types.GrandpaJustification{Round: 3}, result.Justification)
+	assert.Equal(t, []uint{2, 4}, result.SetChanges)
+	assert.Equal(t, uint64(2), result.SetID)
+	assert.Equal(t, set2, result.Authorities)
+	resultRoot, err := result.State.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, stateRoot, resultRoot)
+}
+
+func Test_WarpSyncer_syncProofs_noPeerServing(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	networkMock := NewMockNetwork(ctrl)
+	networkMock.EXPECT().Peers().Return([]common.PeerInfo{
+		{PeerID: testPeerA, BestNumber: 10},
+		{PeerID: testPeerB, BestNumber: 9},
+	}).AnyTimes()
+
+	warpReqRes := NewMockRequestMaker(ctrl)
+	genesisRequest := &network.WarpProofRequestMessage{Begin: common.Hash{0xa}}
+	// Peer A has no justification to serve, and peer B
+	// sends an unfinished proof without fragments.
+	warpReqRes.EXPECT().Do(mustDecodePeer(t, testPeerA), genesisRequest, gomock.Any()).
+		DoAndReturn(setWarpSyncProofResponse(network.WarpSyncProof{IsFinished: true}))
+	warpReqRes.EXPECT().Do(mustDecodePeer(t, testPeerB), genesisRequest, gomock.Any()).
+		DoAndReturn(setWarpSyncProofResponse(network.WarpSyncProof{}))
+
+	syncer := NewWarpSyncer(WarpSyncerConfig{
+		Network:     networkMock,
+		GenesisHash: common.Hash{0xa},
+	}, warpReqRes, nil)
+
+	result, err := syncer.syncProofs(context.Background())
+
+	assert.ErrorIs(t, err, errNoPeers)
+	assert.EqualError(t, err, "no peers to sync with: for warp sync proof from block "+
+		common.Hash{0xa}.String())
+	assert.Nil(t, result)
+}
+
+func Test_WarpSyncer_Import(t *testing.T) {
+	t.Parallel()
+
+	stateTrie := trie.NewEmptyTrie()
+	target := &types.Header{Number: 6, Digest: types.NewDigest()}
+	justification := &types.GrandpaJustification{
+		Round:  3,
+		Commit: types.GrandpaCommit{Hash: target.Hash(), Number: 6},
+	}
+	encodedJustification, err := scale.Marshal(*justification)
+	require.NoError(t, err)
+	authorities := []types.GrandpaVoter{{Key: ed25519.PublicKey{}, ID: 2}}
+
+	testCases := map[string]struct {
+		setChanges         []uint
+		justificationSetID uint64
+	}{
+		"target_in_current_set": {
+			setChanges:         []uint{2, 4},
+			justificationSetID: 2,
+		},
+		"target_changing_set": {
+			setChanges:         []uint{2, 6},
+			justificationSetID: 1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			blockState := NewMockBlockState(ctrl)
+			grandpaState := NewMockGrandpaState(ctrl)
+			storageState := NewMockStorageState(ctrl)
+			runtimeInstance := NewMockInstance(ctrl)
+			gomock.InOrder(
+				blockState.EXPECT().ImportWarpSyncTarget(target, encodedJustification,
+					uint64(3), testCase.justificationSetID),
+				grandpaState.EXPECT().SetWarpSyncAuthorities(authorities, testCase.setChanges),
+				storageState.EXPECT().Lock(),
+				storageState.EXPECT().StoreTrie(gomock.Any(), target),
+				blockState.EXPECT().GetRuntime(target.Hash()).Return(runtimeInstance, nil),
+				blockState.EXPECT().HandleRuntimeChanges(gomock.Any(), runtimeInstance, target.Hash()),
+				storageState.EXPECT().Unlock(),
+			)
+
+			syncer := NewWarpSyncer(WarpSyncerConfig{
+				BlockState:   blockState,
+				StorageState: storageState,
+				GrandpaState: grandpaState,
+			}, nil, nil)
+
+			err := syncer.Import(&WarpSyncResult{
+				Target:        target,
+				Justification: justification,
+				SetChanges:    testCase.setChanges,
+				SetID:         2,
+				Authorities:   authorities,
+				State:         stateTrie,
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_WarpSyncer_requestProof(t *testing.T) {
+	t.Parallel()
+
+	who := mustDecodePeer(t, testPeerA)
+	header := types.Header{Number: 4, Digest: types.NewDigest()}
+
+	testCases := map[string]struct {
+		beginNumber uint
+		response    network.WarpSyncProof
+		proof       *network.WarpSyncProof
+		errWrapped  error
+		errMessage  string
+	}{
+		"empty_from_genesis": {
+			response:   network.WarpSyncProof{IsFinished: true},
+			errWrapped: errWarpSyncProofEmpty,
+			errMessage: "warp sync proof is empty",
+		},
+		"empty_not_finished": {
+			beginNumber: 2,
+			response:    network.WarpSyncProof{},
+			errWrapped:  errWarpSyncProofEmpty,
+			errMessage:  "warp sync proof is empty",
+		},
+		"empty_finished": {
+			beginNumber: 2,
+			response:    network.WarpSyncProof{IsFinished: true},
+			proof:       &network.WarpSyncProof{IsFinished: true},
+		},
+		"no_progress": {
+			beginNumber: 4,
+			response: network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{Header: header}},
+			},
+			errWrapped: errWarpSyncProofNoProgress,
+			errMessage: "warp sync proof does not progress from begin block: " +
+				"first fragment block number 4 is not above block number 4",
+		},
+		"progress": {
+			beginNumber: 2,
+			response: network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{Header: header}},
+			},
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{Header: header}},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			warpReqRes := NewMockRequestMaker(ctrl)
+			warpReqRes.EXPECT().Do(who, &network.WarpProofRequestMessage{Begin: common.Hash{1}}, gomock.Any()).
+				DoAndReturn(setWarpSyncProofResponse(testCase.response))
+			syncer := &WarpSyncer{warpReqRes: warpReqRes}
+
+			proof, err := syncer.requestProof(who, common.Hash{1}, testCase.beginNumber)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.proof, proof)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	dialTimeout  = 3 * time.Second
+	writeTimeout = 3 * time.Second
+)
+
+// connectionSettings are the settings of the connection to a telemetry endpoint.
+type connectionSettings struct {
+	// queueSize is the maximum number of messages queued for the
+	// endpoint, after which the oldest queued message is dropped.
+	queueSize int
+	// minReconnectDelay is the delay before reconnecting after a disconnection
+	// or a failed dial, which doubles after each failed dial up to maxReconnectDelay.
+	minReconnectDelay time.Duration
+	maxReconnectDelay time.Duration
+}
+
+var defaultConnectionSettings = connectionSettings{
+	queueSize:         256,
+	minReconnectDelay: time.Second,
+	maxReconnectDelay: time.Minute,
+}
+
+// telemetryConnection sends the messages queued for a telemetry endpoint,
+// reconnecting to the endpoint when it is disconnected.
+type telemetryConnection struct {
+	endpoint  string
+	verbosity int
+	queue     *messageQueue
+	settings  connectionSettings
+	logger    Logger
+
+	// connectedMessage is the last system.connected message sent,
+	// which is sent again each time the endpoint is reconnected.
+	connectedMessage      json.Marshaler
+	connectedMessageMutex sync.Mutex
+}
+
+func newTelemetryConnection(endpoint string, verbosity int,
+	settings connectionSettings, logger Logger) *telemetryConnection {
+	return &telemetryConnection{
+		endpoint:  endpoint,
+		verbosity: verbosity,
+		queue:     newMessageQueue(settings.queueSize),
+		settings:  settings,
+		logger:    logger,
+	}
+}
+
+// enqueue queues the encoded message given to be sent to the endpoint,
+// dropping the oldest queued message if the queue is full.
+func (c *telemetryConnection) enqueue(message []byte) {
+	dropped := c.queue.push(message)
+	if dropped {
+		c.logger.Debugf("telemetry queue for %s is full, dropped its oldest message", c.endpoint)
+	}
+}
+
+func (c *telemetryConnection) setConnectedMessage(message json.Marshaler) {
+	c.connectedMessageMutex.Lock()
+	defer c.connectedMessageMutex.Unlock()
+	c.connectedMessage = message
+}
+
+// run connects to the endpoint and sends it the queued messages, and
+// reconnects to it after a delay if it is disconnected, until the
+// context given is canceled.
+func (c *telemetryConnection) run(ctx context.Context) {
+	delay := c.settings.minReconnectDelay
+	connectedBefore := false
+	for {
+		conn, err := c.dial(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Debugf("cannot dial telemetry endpoint %s, retrying in %s: %s",
+				c.endpoint, delay, err)
+			if !sleep(ctx, delay) {
+				return
+			}
+			delay *= 2
+			if delay > c.settings.maxReconnectDelay {
+				delay = c.settings.maxReconnectDelay
+			}
+			continue
+		}
+		delay = c.settings.minReconnectDelay
+
+		// The system.connected message is queued when it is first sent, and is
+		// sent again on reconnection such that the endpoint knows the node.
+		if connectedBefore {
+			err = c.sendConnectedMessage(conn)
+		}
+		connectedBefore = true
+
+		if err == nil {
+			err = c.sendMessages(ctx, conn)
+		}
+
+		closeErr := conn.Close()
+		if closeErr != nil {
+			c.logger.Debugf("cannot close connection to telemetry endpoint %s: %s", c.endpoint, closeErr)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.logger.Debugf("disconnected from telemetry endpoint %s, reconnecting in %s: %s",
+			c.endpoint, delay, err)
+		if !sleep(ctx, delay) {
+			return
+		}
+	}
+}
+
+func (c *telemetryConnection) dial(ctx context.Context) (conn *websocket.Conn, err error) {
+	dialCtx, dialCancel := context.WithTimeout(ctx, dialTimeout)
+	defer dialCancel()
+
+	conn, response, err := websocket.DefaultDialer.DialContext(dialCtx, c.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = response.Body.Close()
+	if err != nil {
+		c.logger.Warnf("cannot close body of response from %s: %s", c.endpoint, err)
+	}
+
+	return conn, nil
+}
+
+func (c *telemetryConnection) sendConnectedMessage(conn *websocket.Conn) (err error) {
+	c.connectedMessageMutex.Lock()
+	message := c.connectedMessage
+	c.connectedMessageMutex.Unlock()
+	if message == nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		c.logger.Debugf("issue encoding %T telemetry message: %s", message, err)
+		return nil
+	}
+
+	return write(conn, encoded)
+}
+
+// sendMessages sends the queued messages to the connection given as they
+// are queued, until the connection fails or the context is canceled.
+func (c *telemetryConnection) sendMessages(ctx context.Context, conn *websocket.Conn) (err error) {
+	// The connection is read to detect its closure by the endpoint,
+	// and the read loop exits once the connection is closed.
+	readErrCh := make(chan error, 1)
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				readErrCh <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		message, ok := c.queue.pop()
+		if ok {
+			err = write(conn, message)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErrCh:
+			return fmt.Errorf("reading: %w", err)
+		case <-c.queue.signal:
+		}
+	}
+}
+
+func write(conn *websocket.Conn, message []byte) (err error) {
+	err = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err != nil {
+		return fmt.Errorf("setting write deadline: %w", err)
+	}
+
+	err = conn.WriteMessage(websocket.TextMessage, message)
+	if err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	return nil
+}
+
+// sleep sleeps for the duration given, and returns
+// false if the context is canceled before.
+func sleep(ctx context.Context, duration time.Duration) (slept bool) {
+	timer := time.NewTimer(duration)
+	select {
+	case <-ctx.Done():
+		if !timer.Stop() {
+			<-timer.C
+		}
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -40,7 +40,7 @@ func newTestMailer(t *testing.T, handler http.HandlerFunc) (mailer *Mailer) {
 	wsAddr := strings.ReplaceAll(srv.URL, "http", "ws")
 	var testEndpoint1 = &genesis.TelemetryEndpoint{
 		Endpoint:  wsAddr,
-		Verbosity: 0,
+		Verbosity: consensusDebugVerbosity,
 	}
 
 	// instantiate telemetry to connect to websocket (test) server
@@ -48,7 +48,11 @@ func newTestMailer(t *testing.T, handler http.HandlerFunc) (mailer *Mailer) {
 
 	logger := log.New(log.SetWriter(io.Discard))
 
-	mailer, err := BootstrapMailer(context.Background(), testEndpoints, logger)
+	// the mailer is stopped before the test server is closed.
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	mailer, err := BootstrapMailer(ctx, testEndpoints, logger)
 	require.NoError(t, err)
 
 	return mailer
@@ -219,6 +223,128 @@ func TestListenerConcurrency(t *testing.T) {
 	<-serverHandlerDone
 }
 
+func TestMailer_reconnect(t *testing.T) {
+	t.Parallel()
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	connections := make(chan *websocket.Conn, 10)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		connections <- c
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	endpoints := []*genesis.TelemetryEndpoint{{
+		Endpoint: strings.ReplaceAll(srv.URL, "http", "ws"),
+	}}
+	settings := connectionSettings{
+		queueSize:         10,
+		minReconnectDelay: 10 * time.Millisecond,
+		maxReconnectDelay: 100 * time.Millisecond,
+	}
+	logger := log.New(log.SetWriter(io.Discard))
+	mailer, err := bootstrapMailer(ctx, endpoints, logger, settings)
+	require.NoError(t, err)
+
+	receiveConnection := func() *websocket.Conn {
+		select {
+		case c := <-connections:
+			t.Cleanup(func() { _ = c.Close() })
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for telemetry connection")
+			return nil
+		}
+	}
+	receiveMessage := func(c *websocket.Conn) string {
+		err := c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		require.NoError(t, err)
+		_, msg, err := c.ReadMessage()
+		require.NoError(t, err)
+		return string(msg)
+	}
+
+	genesisHash := common.Hash{1}
+	mailer.SendMessage(NewSystemConnected(false, "chain", &genesisHash,
+		"gossamer", "node", "netID", "startTime", "0.1"))
+
+	firstConnection := receiveConnection()
+	assert.Contains(t, receiveMessage(firstConnection), `"msg":"system.connected"`)
+
+	// force a disconnection from the server side
+	err = firstConnection.Close()
+	require.NoError(t, err)
+
+	// the system.connected message is sent again on reconnection
+	secondConnection := receiveConnection()
+	assert.Contains(t, receiveMessage(secondConnection), `"msg":"system.connected"`)
+
+	bestHash := common.Hash{2}
+	mailer.SendMessage(NewBlockImport(&bestHash, 2, "NetworkInitialSync"))
+	assert.Contains(t, receiveMessage(secondConnection), `"msg":"block.import"`)
+}
+
+func TestMailer_SendMessage_dropsOldest(t *testing.T) {
+	t.Parallel()
+
+	// the endpoint cannot be dialed, so messages stay queued.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	endpoints := []*genesis.TelemetryEndpoint{{
+		Endpoint:  strings.ReplaceAll(srv.URL, "http", "ws"),
+		Verbosity: infoVerbosity,
+	}}
+	settings := connectionSettings{
+		queueSize:         2,
+		minReconnectDelay: time.Hour,
+		maxReconnectDelay: time.Hour,
+	}
+	logger := log.New(log.SetWriter(io.Discard))
+	mailer, err := bootstrapMailer(ctx, endpoints, logger, settings)
+	require.NoError(t, err)
+
+	const messages = 1000
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := uint(1); i <= messages; i++ {
+			bestHash := common.Hash{}
+			mailer.SendMessage(NewBlockImport(&bestHash, i, "NetworkInitialSync"))
+		}
+		// messages with a verbosity above the endpoint verbosity are not queued.
+		mailer.SendMessage(NewAfgReceivedPrevote(common.Hash{}, "1", ""))
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sending telemetry messages blocked")
+	}
+
+	queue := mailer.connections[0].queue
+	require.Equal(t, 2, queue.len())
+	for _, expectedHeight := range []string{`"height":999,`, `"height":1000,`} {
+		message, ok := queue.pop()
+		require.True(t, ok)
+		assert.Contains(t, string(message), expectedHeight)
+	}
+}
+
 func TestTelemetryMarshalMessage(t *testing.T) {
 	t.Parallel()
 	tests := map[string]struct {
@@ -0,0 +1,68 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package telemetry
+
+import "sync"
+
+// messageQueue is a bounded queue of encoded telemetry messages, which drops
+// its oldest message when a message is pushed and the queue is full, such
+// that pushing a message never blocks.
+type messageQueue struct {
+	mutex    sync.Mutex
+	messages [][]byte
+	maxSize  int
+	// signal is signaled when a message is pushed.
+	signal chan struct{}
+}
+
+func newMessageQueue(maxSize int) *messageQueue {
+	return &messageQueue{
+		messages: make([][]byte, 0, maxSize),
+		maxSize:  maxSize,
+		signal:   make(chan struct{}, 1),
+	}
+}
+
+// push pushes the message given at the back of the queue, and drops
+// the message at the front of the queue if the queue is full, in
+// which case it returns true.
+func (q *messageQueue) push(message []byte) (dropped bool) {
+	q.mutex.Lock()
+	if len(q.messages) == q.maxSize {
+		q.messages[0] = nil
+		q.messages = q.messages[1:]
+		dropped = true
+	}
+	q.messages = append(q.messages, message)
+	q.mutex.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+	return dropped
+}
+
+// pop pops the message at the front of the queue,
+// and returns false if the queue is empty.
+func (q *messageQueue) pop() (message []byte, ok bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.messages) == 0 {
+		return nil, false
+	}
+
+	message = q.messages[0]
+	q.messages[0] = nil
+	q.messages = q.messages[1:]
+	return message, true
+}
+
+// len returns the number of messages in the queue.
+func (q *messageQueue) len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.messages)
+}
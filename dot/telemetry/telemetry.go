@@ -27,6 +27,33 @@
 	txPoolImportMsg = "txpool.import"
 )
 
+// Verbosity levels of the telemetry messages, where a telemetry
+// endpoint only receives the messages with a verbosity lower or
+// equal to its verbosity.
+const (
+	infoVerbosity           = 0
+	consensusInfoVerbosity  = 1
+	consensusDebugVerbosity = 5
+)
+
+// messageVerbosity returns the verbosity level of the telemetry message given.
+func messageVerbosity(msg json.Marshaler) (verbosity int) {
+	switch msg.(type) {
+	case *AfgReceivedCommit, *AfgReceivedPrecommit, *AfgReceivedPrevote,
+		AfgReceivedCommit, AfgReceivedPrecommit, AfgReceivedPrevote:
+		return consensusDebugVerbosity
+	case *AfgAuthoritySet, *AfgFinalizedBlocksUpTo,
+		*AfgApplyingScheduledAuthoritySetChange, *AfgApplyingForcedAuthoritySetChange,
+		*PreparedBlockForProposing,
+		AfgAuthoritySet, AfgFinalizedBlocksUpTo,
+		AfgApplyingScheduledAuthoritySetChange, AfgApplyingForcedAuthoritySetChange,
+		PreparedBlockForProposing:
+		return consensusInfoVerbosity
+	default:
+		return infoVerbosity
+	}
+}
+
 // Client is the interface to send messages to telemetry servers
 type Client interface {
 	SendMessage(msg json.Marshaler)
@@ -4,7 +4,9 @@
 package types
 
 import (
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
@@ -103,15 +105,17 @@ func NewGrandpaVotersFromAuthorities(ad []Authority) []GrandpaVoter {
 func NewGrandpaVotersFromAuthoritiesRaw(ad []GrandpaAuthoritiesRaw) ([]GrandpaVoter, error) {
 	v := make([]GrandpaVoter, len(ad))
 
-	for i, d := range ad {
-		key, err := ed25519.NewPublicKey(d.Key[:])
+	for i := range ad {
+		// the public key references the bytes given
+		// so the loop variable cannot be used here.
+		key, err := ed25519.NewPublicKey(ad[i].Key[:])
 		if err != nil {
 			return nil, err
 		}
 
 		v[i] = GrandpaVoter{
 			Key: *key,
-			ID:  d.ID,
+			ID:  ad[i].ID,
 		}
 	}
 
@@ -197,6 +201,70 @@ func (v GrandpaVote) String() string {
 	return fmt.Sprintf("hash=%s number=%d", v.Hash, v.Number)
 }
 
+// GrandpaCommit contains the signed precommits for the block with the given hash and number
+type GrandpaCommit struct {
+	Hash       common.Hash
+	Number     uint32
+	Precommits []GrandpaSignedVote
+}
+
+// GrandpaJustification is the finality justification of a block as encoded by Substrate,
+// where the vote ancestries are the headers between the block finalised and the blocks
+// of the precommits, used to check each precommit is for a descendant of the block.
+// https://github.com/paritytech/substrate/blob/fb22096d2ec6bf38e67ce811ad2c31415237a9a5/client/finality-grandpa/src/justification.rs#L43 //nolint:lll
+type GrandpaJustification struct {
+	Round           uint64
+	Commit          GrandpaCommit
+	VotesAncestries []Header
+}
+
+// DecodeGrandpaJustification decodes a SCALE encoded GrandpaJustification from the
+// reader given, without reading past its end. Each vote ancestry header is decoded
+// one at a time, since the digest of each header has to be initialised before decoding.
+// The vote ancestries are empty if the reader ends after the commit, as for the
+// justifications this node stores when finalising blocks.
+func DecodeGrandpaJustification(reader io.Reader) (justification *GrandpaJustification, err error) {
+	decoder := scale.NewDecoder(reader)
+	justification = new(GrandpaJustification)
+	err = decoder.Decode(&justification.Round)
+	if err != nil {
+		return nil, fmt.Errorf("decoding round: %w", err)
+	}
+
+	err = decoder.Decode(&justification.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("decoding commit: %w", err)
+	}
+
+	var length uint
+	err = decoder.Decode(&length)
+	if errors.Is(err, io.EOF) || (err == nil && length == 0) {
+		return justification, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("decoding number of vote ancestries: %w", err)
+	}
+
+	capacity := length
+	if capacity > maxPreallocatedAncestries {
+		capacity = maxPreallocatedAncestries
+	}
+	justification.VotesAncestries = make([]Header, 0, capacity)
+	for i := uint(0); i < length; i++ {
+		header := NewEmptyHeader()
+		err = decoder.Decode(header)
+		if err != nil {
+			return nil, fmt.Errorf("decoding vote ancestry %d: %w", i, err)
+		}
+		justification.VotesAncestries = append(justification.VotesAncestries, *header)
+	}
+
+	return justification, nil
+}
+
+// maxPreallocatedAncestries bounds the vote ancestries allocated before
+// decoding them, since their number is read from untrusted input.
+const maxPreallocatedAncestries = 1024
+
 // GrandpaEquivocation is used to create a proof of equivocation
 // https://github.com/paritytech/finality-grandpa/blob/19d251d0b0105d51a79d3c4532a9aae75a5035bd/src/lib.rs#L213 //nolint:lll
 type GrandpaEquivocation struct {
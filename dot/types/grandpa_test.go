@@ -4,6 +4,7 @@
 package types
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -127,6 +128,53 @@ func TestEncodeSignedVote(t *testing.T) {
 	require.Equal(t, signedVote, grandpaSignedVote)
 }
 
+func Test_DecodeGrandpaJustification(t *testing.T) {
+	t.Parallel()
+
+	digest := NewDigest()
+	err := digest.Add(PreRuntimeDigest{
+		ConsensusEngineID: BabeEngineID,
+		Data:              []byte{1, 2},
+	})
+	require.NoError(t, err)
+
+	justification := GrandpaJustification{
+		Round: 1,
+		Commit: GrandpaCommit{
+			Hash:   common.Hash{2},
+			Number: 3,
+			Precommits: []GrandpaSignedVote{{
+				Vote:        GrandpaVote{Hash: common.Hash{4}, Number: 4},
+				Signature:   [64]byte{5},
+				AuthorityID: [32]byte{6},
+			}},
+		},
+		VotesAncestries: []Header{{
+			ParentHash: common.Hash{2},
+			Number:     4,
+			Digest:     digest,
+		}},
+	}
+	encoded := scale.MustMarshal(justification)
+
+	// the bytes following the justification are not read
+	reader := bytes.NewReader(append(encoded, 7))
+	decoded, err := DecodeGrandpaJustification(reader)
+	require.NoError(t, err)
+	require.Equal(t, &justification, decoded)
+	require.Equal(t, 1, reader.Len())
+
+	// the justifications stored when finalising blocks have no vote ancestries
+	withoutAncestries := scale.MustMarshal(struct {
+		Round  uint64
+		Commit GrandpaCommit
+	}{Round: justification.Round, Commit: justification.Commit})
+	decoded, err = DecodeGrandpaJustification(bytes.NewReader(withoutAncestries))
+	require.NoError(t, err)
+	justification.VotesAncestries = nil
+	require.Equal(t, &justification, decoded)
+}
+
 func TestGrandpaAuthoritiesRawToAuthorities(t *testing.T) {
 	t.Parallel()
 	expectedEncoding := common.MustHexToBytes("0x08eea1eabcac7d2c8a6459b7322cf997874482bfc3d2ec7a80888a3a7d714103640000000000000000b64994460e59b30364cad3c92e3df6052f9b0ebbb8f88460c194dc5794d6d7170100000000000000") //nolint:lll
@@ -155,3 +203,21 @@ func TestGrandpaAuthoritiesRawToAuthorities(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, authority, authorities[1])
 }
+
+func Test_NewGrandpaVotersFromAuthoritiesRaw(t *testing.T) {
+	t.Parallel()
+
+	authorities := []GrandpaAuthoritiesRaw{
+		{Key: [32]byte{1}, ID: 1},
+		{Key: [32]byte{2}, ID: 2},
+	}
+
+	voters, err := NewGrandpaVotersFromAuthoritiesRaw(authorities)
+	require.NoError(t, err)
+
+	require.Len(t, voters, 2)
+	require.Equal(t, ed25519.PublicKeyBytes(authorities[0].Key), voters[0].Key.AsBytes())
+	require.Equal(t, uint64(1), voters[0].ID)
+	require.Equal(t, ed25519.PublicKeyBytes(authorities[1].Key), voters[1].Key.AsBytes())
+	require.Equal(t, uint64(2), voters[1].ID)
+}
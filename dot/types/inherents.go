@@ -8,6 +8,7 @@
 	"fmt"
 	"math/big"
 	"sort"
+	"time"
 
 	"github.com/ChainSafe/gossamer/pkg/scale"
 	"golang.org/x/exp/maps"
@@ -125,3 +126,80 @@ func (d *InherentData) Encode() ([]byte, error) {
 
 	return buffer.Bytes(), nil
 }
+
+// InherentDataProvider provides inherent data to be included in a block.
+type InherentDataProvider interface {
+	ProvideInherentData(data *InherentData) error
+}
+
+// InherentDataProviders is a registry of inherent data providers.
+// Its zero value is ready to use.
+type InherentDataProviders struct {
+	providers []InherentDataProvider
+}
+
+// Register registers the inherent data provider given.
+func (p *InherentDataProviders) Register(provider InherentDataProvider) {
+	p.providers = append(p.providers, provider)
+}
+
+// InherentData returns the inherent data provided by all the registered providers.
+func (p *InherentDataProviders) InherentData() (data *InherentData, err error) {
+	data = NewInherentData()
+	for _, provider := range p.providers {
+		err = provider.ProvideInherentData(data)
+		if err != nil {
+			return nil, fmt.Errorf("providing inherent data: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// TimestampInherentDataProvider provides the timestamp inherent data.
+type TimestampInherentDataProvider struct {
+	Timestamp time.Time
+}
+
+// ProvideInherentData sets the timestamp in milliseconds in the inherent data.
+func (p *TimestampInherentDataProvider) ProvideInherentData(data *InherentData) error {
+	err := data.SetInherent(Timstap0, uint64(p.Timestamp.UnixMilli()))
+	if err != nil {
+		return fmt.Errorf("setting timestamp inherent: %w", err)
+	}
+	return nil
+}
+
+// SlotInherentDataProvider provides the BABE slot inherent data.
+type SlotInherentDataProvider struct {
+	Slot uint64
+}
+
+// ProvideInherentData sets the slot in the inherent data.
+func (p *SlotInherentDataProvider) ProvideInherentData(data *InherentData) error {
+	err := data.SetInherent(Babeslot, p.Slot)
+	if err != nil {
+		return fmt.Errorf("setting slot inherent: %w", err)
+	}
+	return nil
+}
+
+// CheckInherentsResult is the result of the runtime inherents check of a block.
+type CheckInherentsResult struct {
+	// Okay is true if all the inherents of the block are valid.
+	Okay bool
+	// FatalError is true if one of the errors is fatal.
+	FatalError bool
+	// Errors are the errors of the inherents checks.
+	Errors []InherentError
+}
+
+// InherentError is an error of the runtime check of an inherent.
+type InherentError struct {
+	Identifier [8]byte
+	// Error is the SCALE encoded runtime error.
+	Error []byte
+}
+
+func (e InherentError) String() string {
+	return fmt.Sprintf("inherent %q: error 0x%x", e.Identifier[:], e.Error)
+}
@@ -5,7 +5,9 @@
 
 import (
 	"testing"
+	"time"
 
+	"github.com/ChainSafe/gossamer/pkg/scale"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,3 +45,41 @@ func TestInherentDataMarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestInherentDataProviders(t *testing.T) {
+	t.Parallel()
+
+	providers := new(InherentDataProviders)
+	providers.Register(&TimestampInherentDataProvider{Timestamp: time.UnixMilli(99)})
+	providers.Register(&SlotInherentDataProvider{Slot: 99})
+
+	data, err := providers.InherentData()
+	require.NoError(t, err)
+
+	expected := NewInherentData()
+	err = expected.SetInherent(Babeslot, uint64(99))
+	require.NoError(t, err)
+	err = expected.SetInherent(Timstap0, uint64(99))
+	require.NoError(t, err)
+	require.Equal(t, expected, data)
+}
+
+func TestCheckInherentsResultDecode(t *testing.T) {
+	t.Parallel()
+
+	// okay false, fatal error true and one error for timstap0
+	encoded := []byte{0, 1, 4, 116, 105, 109, 115, 116, 97, 112, 48, 8, 1, 2}
+
+	var result CheckInherentsResult
+	err := scale.Unmarshal(encoded, &result)
+	require.NoError(t, err)
+
+	expected := CheckInherentsResult{
+		FatalError: true,
+		Errors: []InherentError{{
+			Identifier: Timstap0.Bytes(),
+			Error:      []byte{1, 2},
+		}},
+	}
+	require.Equal(t, expected, result)
+}
@@ -0,0 +1,211 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/pb"
+)
+
+var logger = log.NewFromGlobal(log.AddContext("pkg", "database"))
+
+var _ chaindb.Database = (*BadgerDB)(nil)
+
+// BadgerDB is a badger database implementing the chaindb database
+// interface, opened with the given tuning options and running the
+// value log garbage collection periodically.
+type BadgerDB struct {
+	dataDir string
+	db      *badger.DB
+
+	gcInterval     time.Duration
+	gcDiscardRatio float64
+	gcStop         chan struct{}
+	gcDone         chan struct{}
+	closeOnce      sync.Once
+}
+
+// New opens the badger database in the given data directory, or in memory
+// if inMemory is true, and starts its value log garbage collection.
+func New(dataDir string, inMemory bool, options Options) (*BadgerDB, error) {
+	if !inMemory {
+		err := os.MkdirAll(dataDir, os.ModePerm)
+		if err != nil {
+			return nil, fmt.Errorf("creating data directory: %w", err)
+		}
+	}
+
+	db, err := badger.Open(badgerOptions(dataDir, inMemory, options))
+	if err != nil {
+		return nil, fmt.Errorf("opening badger database: %w", err)
+	}
+
+	database := &BadgerDB{
+		dataDir:        dataDir,
+		db:             db,
+		gcInterval:     options.ValueLogGCInterval,
+		gcDiscardRatio: options.ValueLogGCDiscardRatio,
+		gcStop:         make(chan struct{}),
+		gcDone:         make(chan struct{}),
+	}
+
+	if inMemory || database.gcInterval == 0 {
+		// the value log garbage collection is not supported in memory
+		close(database.gcDone)
+	} else {
+		go database.runValueLogGC()
+	}
+
+	return database, nil
+}
+
+func (db *BadgerDB) runValueLogGC() {
+	defer close(db.gcDone)
+
+	ticker := time.NewTicker(db.gcInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.gcStop:
+			return
+		case <-ticker.C:
+			err := db.ValueLogGC()
+			if err != nil {
+				logger.Warnf("running value log garbage collection: %s", err)
+			}
+		}
+	}
+}
+
+// ValueLogGC runs the value log garbage collection, rewriting value log
+// files until no more file has enough discardable data.
+func (db *BadgerDB) ValueLogGC() error {
+	rewrites := 0
+	for {
+		select {
+		case <-db.gcStop:
+			return nil
+		default:
+		}
+
+		err := db.db.RunValueLogGC(db.gcDiscardRatio)
+		switch {
+		case err == nil:
+			rewrites++
+			continue
+		case errors.Is(err, badger.ErrNoRewrite), errors.Is(err, badger.ErrRejected):
+			logger.Debugf("value log garbage collection rewrote %d files", rewrites)
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// Path returns the path to the database directory.
+func (db *BadgerDB) Path() string {
+	return db.dataDir
+}
+
+// Get returns the value for the given key, and chaindb.ErrKeyNotFound
+// if the key is not found.
+func (db *BadgerDB) Get(key []byte) (value []byte, err error) {
+	err = db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Has returns true if the given key exists.
+func (db *BadgerDB) Has(key []byte) (exists bool, err error) {
+	err = db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		exists = true
+		return nil
+	})
+	return exists, err
+}
+
+// Put sets the value for the given key.
+func (db *BadgerDB) Put(key, value []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+// Del deletes the given key.
+func (db *BadgerDB) Del(key []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// Flush syncs the database to disk.
+func (db *BadgerDB) Flush() error {
+	return db.db.Sync()
+}
+
+// Close stops the value log garbage collection and closes the database.
+func (db *BadgerDB) Close() (err error) {
+	db.closeOnce.Do(func() {
+		close(db.gcStop)
+		<-db.gcDone
+		err = db.db.Close()
+	})
+	return err
+}
+
+// ClearAll deletes all the data stored in the database.
+func (db *BadgerDB) ClearAll() error {
+	return db.db.DropAll()
+}
+
+// Subscribe calls the given callback with the changes of the keys
+// matching the given prefixes, until the context is canceled.
+func (db *BadgerDB) Subscribe(ctx context.Context, cb func(kv *chaindb.KVList) error,
+	prefixes []pb.Match) error {
+	return db.db.Subscribe(ctx, cb, prefixes)
+}
+
+// NewBatch returns a new batch of writes.
+func (db *BadgerDB) NewBatch() chaindb.Batch {
+	return &batch{
+		db:      db.db,
+		updates: make(map[string][]byte),
+		deletes: make(map[string]struct{}),
+	}
+}
+
+// NewIterator returns a new iterator over all the keys of the database,
+// which must be released after use.
+func (db *BadgerDB) NewIterator() chaindb.Iterator {
+	txn := db.db.NewTransaction(false)
+	return &iterator{
+		txn:  txn,
+		iter: txn.NewIterator(badger.DefaultIteratorOptions),
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package database
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_badgerOptions(t *testing.T) {
+	t.Parallel()
+
+	options := Options{
+		BlockCacheSize:   1 << 20,
+		IndexCacheSize:   2 << 20,
+		ValueLogFileSize: 3 << 20,
+		NumCompactors:    5,
+	}
+
+	badgerOpts := badgerOptions("datadir", false, options)
+
+	assert.Equal(t, "datadir", badgerOpts.Dir)
+	assert.Equal(t, "datadir", badgerOpts.ValueDir)
+	assert.False(t, badgerOpts.InMemory)
+	assert.False(t, badgerOpts.SyncWrites)
+	assert.Nil(t, badgerOpts.Logger)
+	assert.Equal(t, int64(1<<20), badgerOpts.BlockCacheSize)
+	assert.Equal(t, int64(2<<20), badgerOpts.IndexCacheSize)
+	assert.Equal(t, int64(3<<20), badgerOpts.ValueLogFileSize)
+	assert.Equal(t, 5, badgerOpts.NumCompactors)
+
+	badgerOpts = badgerOptions("datadir", true, options)
+
+	assert.Empty(t, badgerOpts.Dir)
+	assert.True(t, badgerOpts.InMemory)
+}
+
+func Test_New_options(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultOptions()
+	options.BlockCacheSize = 8 << 20
+	options.IndexCacheSize = 4 << 20
+	options.ValueLogFileSize = 16 << 20
+	options.NumCompactors = 2
+	dataDir := filepath.Join(t.TempDir(), "db")
+
+	db, err := New(dataDir, false, options)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	badgerOpts := db.db.Opts()
+	assert.Equal(t, int64(8<<20), badgerOpts.BlockCacheSize)
+	assert.Equal(t, int64(4<<20), badgerOpts.IndexCacheSize)
+	assert.Equal(t, int64(16<<20), badgerOpts.ValueLogFileSize)
+	assert.Equal(t, 2, badgerOpts.NumCompactors)
+	assert.Equal(t, dataDir, db.Path())
+}
+
+func Test_BadgerDB_ValueLogGC(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultOptions()
+	options.ValueLogFileSize = 1 << 20
+	options.ValueLogGCInterval = 10 * time.Millisecond
+
+	db, err := New(t.TempDir(), false, options)
+	require.NoError(t, err)
+
+	value := bytes.Repeat([]byte{1}, 1<<16)
+	for i := 0; i < 64; i++ {
+		key := []byte{byte(i)}
+		err = db.Put(key, value)
+		require.NoError(t, err)
+		err = db.Del(key)
+		require.NoError(t, err)
+	}
+
+	err = db.ValueLogGC()
+	require.NoError(t, err)
+
+	// let the background garbage collection run
+	time.Sleep(50 * time.Millisecond)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	select {
+	case <-db.gcDone:
+	default:
+		t.Fatal("value log garbage collection is still running")
+	}
+
+	// closing again is a no-op
+	err = db.Close()
+	require.NoError(t, err)
+}
+
+func Test_BadgerDB_inMemory(t *testing.T) {
+	t.Parallel()
+
+	db, err := New("", true, DefaultOptions())
+	require.NoError(t, err)
+
+	// the value log garbage collection does not run in memory
+	select {
+	case <-db.gcDone:
+	default:
+		t.Fatal("value log garbage collection is running")
+	}
+
+	err = db.Close()
+	require.NoError(t, err)
+}
+
+func Test_BadgerDB(t *testing.T) {
+	t.Parallel()
+
+	db, err := New("", true, DefaultOptions())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	value, err := db.Get([]byte("a"))
+	assert.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+	assert.Nil(t, value)
+
+	err = db.Put([]byte("a"), []byte{1})
+	require.NoError(t, err)
+
+	batch := db.NewBatch()
+	err = batch.Put([]byte("b"), []byte{2})
+	require.NoError(t, err)
+	err = batch.Put([]byte("c"), []byte{3})
+	require.NoError(t, err)
+	err = batch.Del([]byte("c"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, batch.ValueSize())
+	err = batch.Flush()
+	require.NoError(t, err)
+
+	value, err = db.Get([]byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, value)
+
+	has, err := db.Has([]byte("c"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	err = db.Del([]byte("a"))
+	require.NoError(t, err)
+	has, err = db.Has([]byte("a"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	err = db.Put([]byte("d"), []byte{4})
+	require.NoError(t, err)
+
+	iter := db.NewIterator()
+	defer iter.Release()
+	var keys, values [][]byte
+	for iter.Next() {
+		keys = append(keys, iter.Key())
+		values = append(values, iter.Value())
+	}
+	assert.Equal(t, [][]byte{[]byte("b"), []byte("d")}, keys)
+	assert.Equal(t, [][]byte{{2}, {4}}, values)
+	assert.False(t, iter.Valid())
+
+	table := chaindb.NewTable(db, "prefix")
+	err = table.Put([]byte("e"), []byte{5})
+	require.NoError(t, err)
+	value, err = db.Get([]byte("prefixe"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{5}, value)
+}
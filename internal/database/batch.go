@@ -0,0 +1,83 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package database
+
+import (
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// batch is a batch of writes committed together when flushed.
+type batch struct {
+	db      *badger.DB
+	updates map[string][]byte
+	deletes map[string]struct{}
+	size    int
+	mutex   sync.Mutex
+}
+
+// Put adds the given key and value to the batch.
+func (b *batch) Put(key, value []byte) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stringKey := string(key)
+	b.updates[stringKey] = value
+	b.size += len(value)
+	delete(b.deletes, stringKey)
+	return nil
+}
+
+// Del adds the deletion of the given key to the batch.
+func (b *batch) Del(key []byte) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stringKey := string(key)
+	b.deletes[stringKey] = struct{}{}
+	delete(b.updates, stringKey)
+	return nil
+}
+
+// Flush commits the writes of the batch to the database.
+func (b *batch) Flush() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	writeBatch := b.db.NewWriteBatch()
+	defer writeBatch.Cancel()
+
+	for key, value := range b.updates {
+		err := writeBatch.Set([]byte(key), value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for key := range b.deletes {
+		err := writeBatch.Delete([]byte(key))
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeBatch.Flush()
+}
+
+// ValueSize returns the size of the values of the batch.
+func (b *batch) ValueSize() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.size
+}
+
+// Reset clears the batch.
+func (b *batch) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.updates = make(map[string][]byte)
+	b.deletes = make(map[string]struct{})
+	b.size = 0
+}
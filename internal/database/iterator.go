@@ -0,0 +1,60 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package database
+
+import (
+	"github.com/dgraph-io/badger/v4"
+)
+
+// iterator iterates over the keys of the database in ascending order,
+// within a read-only transaction discarded when it is released.
+type iterator struct {
+	txn         *badger.Txn
+	iter        *badger.Iterator
+	initialised bool
+}
+
+// Valid returns true if the iterator is positioned on an item.
+func (i *iterator) Valid() bool {
+	return i.iter.Valid()
+}
+
+// Next moves the iterator to the first item on the first call,
+// and to the next item afterwards. It returns true if the
+// iterator is positioned on an item.
+func (i *iterator) Next() bool {
+	if !i.initialised {
+		i.iter.Rewind()
+		i.initialised = true
+		return i.iter.Valid()
+	}
+
+	if !i.iter.Valid() {
+		return false
+	}
+	i.iter.Next()
+	return i.iter.Valid()
+}
+
+// Key returns the key of the current item.
+func (i *iterator) Key() []byte {
+	return i.iter.Item().KeyCopy(nil)
+}
+
+// Value returns a copy of the value of the current item,
+// and nil if the value cannot be read.
+func (i *iterator) Value() []byte {
+	value, err := i.iter.Item().ValueCopy(nil)
+	if err != nil {
+		logger.Warnf("reading value of key 0x%x: %s", i.iter.Item().Key(), err)
+		return nil
+	}
+	return value
+}
+
+// Release closes the iterator and discards its transaction.
+func (i *iterator) Release() {
+	i.iter.Close()
+	i.txn.Discard()
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package database
+
+import (
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	// DefaultBlockCacheSize is the default size in bytes of the cache
+	// of the table blocks read from disk.
+	DefaultBlockCacheSize = 256 << 20
+	// DefaultIndexCacheSize is the default size in bytes of the cache of the
+	// table indices and bloom filters, 0 keeping them all in memory.
+	DefaultIndexCacheSize = 0
+	// DefaultValueLogFileSize is the default maximum size in bytes
+	// of each value log file.
+	DefaultValueLogFileSize = 1 << 30
+	// DefaultNumCompactors is the default number of concurrent compactions.
+	DefaultNumCompactors = 4
+	// DefaultValueLogGCInterval is the default interval at which
+	// the value log garbage collection runs.
+	DefaultValueLogGCInterval = 10 * time.Minute
+	// DefaultValueLogGCDiscardRatio is the default fraction of a value log
+	// file which must be discardable for the file to be rewritten.
+	DefaultValueLogGCDiscardRatio = 0.5
+)
+
+// Options contains the tuning options of the badger database.
+type Options struct {
+	// BlockCacheSize is the size in bytes of the cache
+	// of the table blocks read from disk.
+	BlockCacheSize int64
+	// IndexCacheSize is the size in bytes of the cache of the table
+	// indices and bloom filters, 0 keeping them all in memory.
+	IndexCacheSize int64
+	// ValueLogFileSize is the maximum size in bytes of each value log file.
+	ValueLogFileSize int64
+	// NumCompactors is the number of concurrent compactions.
+	NumCompactors int
+	// ValueLogGCInterval is the interval at which the value log garbage
+	// collection runs, and 0 disables it.
+	ValueLogGCInterval time.Duration
+	// ValueLogGCDiscardRatio is the fraction of a value log file
+	// which must be discardable for the file to be rewritten.
+	ValueLogGCDiscardRatio float64
+}
+
+// DefaultOptions returns the default options of the badger database.
+func DefaultOptions() Options {
+	return Options{
+		BlockCacheSize:         DefaultBlockCacheSize,
+		IndexCacheSize:         DefaultIndexCacheSize,
+		ValueLogFileSize:       DefaultValueLogFileSize,
+		NumCompactors:          DefaultNumCompactors,
+		ValueLogGCInterval:     DefaultValueLogGCInterval,
+		ValueLogGCDiscardRatio: DefaultValueLogGCDiscardRatio,
+	}
+}
+
+func badgerOptions(dataDir string, inMemory bool, options Options) badger.Options {
+	if inMemory {
+		dataDir = ""
+	}
+
+	return badger.DefaultOptions(dataDir).
+		WithInMemory(inMemory).
+		WithLogger(nil).
+		WithSyncWrites(false).
+		WithBlockCacheSize(options.BlockCacheSize).
+		WithIndexCacheSize(options.IndexCacheSize).
+		WithValueLogFileSize(options.ValueLogFileSize).
+		WithNumCompactors(options.NumCompactors)
+}
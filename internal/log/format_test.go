@@ -0,0 +1,50 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseFormat(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		s          string
+		format     Format
+		errWrapped error
+		errMessage string
+	}{
+		"console": {
+			s:      "console",
+			format: FormatConsole,
+		},
+		"json": {
+			s:      "JSON",
+			format: FormatJSON,
+		},
+		"invalid": {
+			s:          "someinvalid",
+			errWrapped: ErrFormatNotRecognised,
+			errMessage: "format is not recognised: someinvalid",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			format, err := ParseFormat(testCase.s)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.format, format)
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -16,7 +17,7 @@ func (l *Logger) log(logLevel Level, s string, args ...interface{}) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	if *l.settings.level < logLevel {
+	if l.settings.effectiveLevel() < logLevel {
 		return
 	}
 
@@ -24,9 +25,22 @@ func (l *Logger) log(logLevel Level, s string, args ...interface{}) {
 		s = fmt.Sprintf(s, args...)
 	}
 
-	line := time.Now().Format(time.RFC3339) + " " + logLevel.format() + " " + s
-
 	callerString := getCallerString(l.settings.caller)
+
+	var line string
+	if l.settings.format != nil && *l.settings.format == FormatJSON {
+		line = l.formatJSON(logLevel, s, callerString)
+	} else {
+		line = l.formatConsole(logLevel, s, callerString)
+	}
+
+	line += "\n"
+	_, _ = io.WriteString(l.settings.writer, line)
+}
+
+func (l *Logger) formatConsole(logLevel Level, s, callerString string) (line string) {
+	line = time.Now().Format(time.RFC3339) + " " + logLevel.format() + " " + s
+
 	if callerString != "" {
 		line += "\t" + color.HiWhiteString(callerString)
 	}
@@ -41,8 +55,39 @@ func (l *Logger) log(logLevel Level, s string, args ...interface{}) {
 		line += "\t" + strings.Join(keyValues, " ")
 	}
 
-	line += "\n"
-	_, _ = io.WriteString(l.settings.writer, line)
+	return line
+}
+
+// formatJSON formats the log line as a JSON object without colors.
+// Context keys are set as fields of the object, with multiple values
+// comma separated.
+func (l *Logger) formatJSON(logLevel Level, s, callerString string) (line string) {
+	fields := make([]string, 0, 4+len(l.settings.context))
+	fields = append(fields,
+		jsonField("time", time.Now().Format(time.RFC3339)),
+		jsonField("level", logLevel.String()),
+		jsonField("message", s),
+	)
+
+	if callerString != "" {
+		fields = append(fields, jsonField("caller", callerString))
+	}
+
+	for _, kvs := range l.settings.context {
+		valuesString := strings.Join(kvs.values, ",")
+		fields = append(fields, jsonField(kvs.key, valuesString))
+	}
+
+	return "{" + strings.Join(fields, ",") + "}"
+}
+
+func jsonField(key, value string) (field string) {
+	return jsonString(key) + ":" + jsonString(value)
+}
+
+func jsonString(s string) (quoted string) {
+	b, _ := json.Marshal(s) // marshaling a string cannot fail
+	return string(b)
 }
 
 // Trace logs with the trce level.
@@ -101,6 +101,95 @@ func Test_Logger_log(t *testing.T) {
 			s:           "some words",
 			outputRegex: timePrefixRegex + "TRACE    some words\tkey1=a,b key2=c,d\n$",
 		},
+		"json_format": {
+			logger: &Logger{
+				settings: settings{
+					level:  levelPtr(Trace),
+					format: formatPtr(FormatJSON),
+					caller: newCallerSettings(true, true, false),
+					context: []contextKeyValues{
+						{key: "pkg", values: []string{"sync"}},
+						{key: "key", values: []string{"a", "b"}},
+					},
+				},
+				mutex: new(sync.Mutex),
+			},
+			level: Warn,
+			s:     "some \"quoted\" %s",
+			args:  []interface{}{"words"},
+			outputRegex: `^{"time":"[^"]+","level":"WARN","message":"some \\"quoted\\" words",` +
+				`"caller":"log_test.go:L[0-9]+","pkg":"sync","key":"a,b"}\n$`,
+		},
+		"module_level_filters": {
+			logger: &Logger{
+				settings: settings{
+					level:        levelPtr(Trace),
+					caller:       newCallerSettings(false, false, false),
+					context:      []contextKeyValues{{key: "pkg", values: []string{"network"}}},
+					moduleLevels: map[string]Level{"network": Warn, "sync": Debug},
+				},
+				mutex: new(sync.Mutex),
+			},
+			level:       Info,
+			s:           "some words",
+			outputRegex: "^$",
+		},
+		"module_level_passes": {
+			logger: &Logger{
+				settings: settings{
+					level:        levelPtr(Info),
+					caller:       newCallerSettings(false, false, false),
+					context:      []contextKeyValues{{key: "pkg", values: []string{"sync"}}},
+					moduleLevels: map[string]Level{"network": Warn, "sync": Debug},
+				},
+				mutex: new(sync.Mutex),
+			},
+			level:       Debug,
+			s:           "some words",
+			outputRegex: timePrefixRegex + "DEBUG    some words\tpkg=sync\n$",
+		},
+		"module_level_sub_package": {
+			logger: &Logger{
+				settings: settings{
+					level:        levelPtr(Trace),
+					caller:       newCallerSettings(false, false, false),
+					context:      []contextKeyValues{{key: "pkg", values: []string{"rpc/subscription"}}},
+					moduleLevels: map[string]Level{"rpc": Error},
+				},
+				mutex: new(sync.Mutex),
+			},
+			level:       Warn,
+			s:           "some words",
+			outputRegex: "^$",
+		},
+		"module_level_longest_match": {
+			logger: &Logger{
+				settings: settings{
+					level:        levelPtr(Info),
+					caller:       newCallerSettings(false, false, false),
+					context:      []contextKeyValues{{key: "pkg", values: []string{"runtime/wasmer"}}},
+					moduleLevels: map[string]Level{"runtime": Error, "runtime/wasmer": Trace},
+				},
+				mutex: new(sync.Mutex),
+			},
+			level:       Trace,
+			s:           "some words",
+			outputRegex: timePrefixRegex + "TRACE    some words\tpkg=runtime/wasmer\n$",
+		},
+		"module_level_not_matching": {
+			logger: &Logger{
+				settings: settings{
+					level:        levelPtr(Info),
+					caller:       newCallerSettings(false, false, false),
+					context:      []contextKeyValues{{key: "pkg", values: []string{"syncer"}}},
+					moduleLevels: map[string]Level{"sync": Trace},
+				},
+				mutex: new(sync.Mutex),
+			},
+			level:       Debug,
+			s:           "some words",
+			outputRegex: "^$",
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -181,3 +270,48 @@ func Test_Logger_LevelsLog(t *testing.T) {
 			"line %q does not match regex %q", lines[i], expectedRegexes[i])
 	}
 }
+
+func Test_Logger_Patch_moduleLevels(t *testing.T) {
+	t.Parallel()
+
+	buffer := bytes.NewBuffer(nil)
+	parent := New(SetWriter(buffer), SetLevel(Info))
+	networkLogger := parent.New(AddContext("pkg", "network"))
+	syncLogger := parent.New(AddContext("pkg", "sync"))
+
+	parent.Patch(SetFormat(FormatJSON), SetModuleLevels(map[string]Level{
+		"network": Warn,
+		"sync":    Debug,
+	}))
+
+	networkLogger.Info("network info")
+	networkLogger.Warn("network warn")
+	syncLogger.Debug("sync debug")
+	syncLogger.Trace("sync trace")
+	parent.Debug("parent debug")
+	parent.Info("parent info")
+
+	lines := strings.Split(buffer.String(), "\n")
+	buffer.Reset()
+
+	// Check for trailing newline
+	require.NotEmpty(t, lines)
+	assert.Equal(t, "", lines[len(lines)-1])
+	lines = lines[:len(lines)-1]
+
+	expectedRegexes := []string{
+		`^{"time":"[^"]+","level":"WARN","message":"network warn","pkg":"network"}$`,
+		`^{"time":"[^"]+","level":"DEBUG","message":"sync debug","pkg":"sync"}$`,
+		`^{"time":"[^"]+","level":"INFO","message":"parent info"}$`,
+	}
+
+	require.Equal(t, len(expectedRegexes), len(lines))
+
+	for i := range lines {
+		regex, err := regexp.Compile(expectedRegexes[i])
+		require.NoError(t, err)
+
+		assert.True(t, regex.MatchString(lines[i]),
+			"line %q does not match regex %q", lines[i], expectedRegexes[i])
+	}
+}
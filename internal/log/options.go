@@ -22,6 +22,19 @@ func SetLevel(level Level) Option {
 	}
 }
 
+// SetModuleLevels sets levels per module name, overriding the level
+// of loggers having a "pkg" context value matching the module name.
+// See the effectiveLevel settings method for the matching rules.
+// Module levels are not set by default.
+func SetModuleLevels(moduleToLevel map[string]Level) Option {
+	return func(s *settings) {
+		s.moduleLevels = make(map[string]Level, len(moduleToLevel))
+		for module, level := range moduleToLevel {
+			s.moduleLevels[module] = level
+		}
+	}
+}
+
 // SetCallerFile enables or disables logging the caller file.
 // The default is disabled.
 func SetCallerFile(enabled bool) Option {
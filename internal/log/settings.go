@@ -6,6 +6,7 @@
 import (
 	"io"
 	"os"
+	"strings"
 )
 
 type settings struct {
@@ -14,6 +15,9 @@ type settings struct {
 	format  *Format
 	caller  callerSettings
 	context []contextKeyValues
+	// moduleLevels maps module names to levels overriding
+	// the level for loggers of a matching module.
+	moduleLevels map[string]Level
 }
 
 type contextKeyValues struct {
@@ -66,6 +70,13 @@ func (s *settings) mergeWith(other settings) {
 
 	s.caller.mergeWith(other.caller)
 
+	if other.moduleLevels != nil {
+		s.moduleLevels = make(map[string]Level, len(other.moduleLevels))
+		for module, level := range other.moduleLevels {
+			s.moduleLevels[module] = level
+		}
+	}
+
 	existingKeyToIndex := make(map[string]int, len(s.context))
 	for i, kvs := range s.context {
 		existingKeyToIndex[kvs.key] = i
@@ -84,3 +95,36 @@ func (s *settings) mergeWith(other settings) {
 		s.context = append(s.context, kvsCopy)
 	}
 }
+
+// effectiveLevel returns the module level matching the "pkg" context value
+// of the logger, if any, and the logger level otherwise. A module matches
+// a "pkg" context value equal to it or prefixed with the module name
+// followed by a slash, such as "rpc" for "rpc/subscription". The longest
+// matching module takes precedence.
+func (s *settings) effectiveLevel() (level Level) {
+	level = *s.level
+	if len(s.moduleLevels) == 0 {
+		return level
+	}
+
+	for _, kvs := range s.context {
+		if kvs.key != "pkg" {
+			continue
+		}
+
+		longestMatchLength := 0
+		for _, pkg := range kvs.values {
+			for module, moduleLevel := range s.moduleLevels {
+				matches := pkg == module || strings.HasPrefix(pkg, module+"/")
+				if !matches || len(module) <= longestMatchLength {
+					continue
+				}
+				longestMatchLength = len(module)
+				level = moduleLevel
+			}
+		}
+		break
+	}
+
+	return level
+}
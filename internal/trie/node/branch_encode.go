@@ -35,13 +35,20 @@ func runEncodeChild(child *Node, index int,
 	}
 }
 
-var parallelLimit = runtime.NumCPU()
+var parallelLimit = runtime.GOMAXPROCS(0)
 
 var parallelEncodingRateLimit = make(chan struct{}, parallelLimit)
 
+// parallelEncodingMinDescendants is the minimum number of descendants
+// a branch child must have to be encoded in its own goroutine.
+// Smaller subtrees are encoded in the calling goroutine since the
+// goroutine and channel overhead outweighs the parallel gain.
+const parallelEncodingMinDescendants = 64
+
 // encodeChildrenOpportunisticParallel encodes children in parallel eventually.
-// Leaves are encoded in a blocking way, and branches are encoded in separate
-// goroutines IF they are less than the parallelLimit number of goroutines already
+// Leaves, small branches and branches with a cached Merkle value are encoded
+// in a blocking way, and other branches are encoded in separate goroutines
+// IF they are less than the parallelLimit number of goroutines already
 // running. This is designed to limit the total number of goroutines in order to
 // avoid using too much memory on the stack.
 func encodeChildrenOpportunisticParallel(children []*Node, buffer io.Writer) (err error) {
@@ -55,7 +62,9 @@ func encodeChildrenOpportunisticParallel(children []*Node, buffer io.Writer) (er
 			continue
 		}
 
-		if child.Kind() == Leaf {
+		merkleValueCached := !child.Dirty && child.MerkleValue != nil
+		if child.Kind() == Leaf || merkleValueCached ||
+			child.Descendants < parallelEncodingMinDescendants {
 			runEncodeChild(child, i, resultsCh, nil)
 			continue
 		}
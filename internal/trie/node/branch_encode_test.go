@@ -43,10 +43,17 @@ func populateChildren(valueSize, depth int) (children []*Node) {
 	}
 
 	for i := range children {
+		grandChildren := populateChildren(valueSize, depth-1)
+		var descendants uint32
+		for _, grandChild := range grandChildren {
+			descendants += 1 + grandChild.Descendants
+		}
+
 		children[i] = &Node{
 			PartialKey:   someValue,
 			StorageValue: someValue,
-			Children:     populateChildren(valueSize, depth-1),
+			Children:     grandChildren,
+			Descendants:  descendants,
 		}
 	}
 
@@ -178,6 +185,43 @@ func Test_encodeChildrenOpportunisticParallel(t *testing.T) {
 			0xc, 0x80, 0x0, 0x0, 0xc, 0x80, 0x0, 0x0}
 		assert.Equal(t, expectedBytes, buffer.Bytes())
 	})
+
+	t.Run("parallel_encoding_matches_sequential_encoding", func(t *testing.T) {
+		t.Parallel()
+
+		const valueBytesSize, depth = 10, 2
+		children := populateChildren(valueBytesSize, depth)
+
+		parallelBuffer := bytes.NewBuffer(nil)
+		err := encodeChildrenOpportunisticParallel(children, parallelBuffer)
+		require.NoError(t, err)
+
+		sequentialBuffer := bytes.NewBuffer(nil)
+		err = encodeChildrenSequentially(children, sequentialBuffer)
+		require.NoError(t, err)
+
+		assert.Equal(t, sequentialBuffer.Bytes(), parallelBuffer.Bytes())
+	})
+
+	t.Run("cached_merkle_value_of_clean_branch", func(t *testing.T) {
+		t.Parallel()
+
+		children := []*Node{
+			{
+				PartialKey:  []byte{1},
+				Children:    make([]*Node, ChildrenCapacity),
+				MerkleValue: []byte{1, 2, 3},
+				Descendants: parallelEncodingMinDescendants,
+			},
+		}
+
+		buffer := bytes.NewBuffer(nil)
+		err := encodeChildrenOpportunisticParallel(children, buffer)
+
+		require.NoError(t, err)
+		expectedBytes := []byte{0xc, 0x1, 0x2, 0x3}
+		assert.Equal(t, expectedBytes, buffer.Bytes())
+	})
 }
 
 func Test_encodeChildrenSequentially(t *testing.T) {
@@ -41,6 +41,9 @@ type Service struct {
 	// BABE authority keypair
 	keypair *sr25519.Keypair // TODO: change to BABE keystore (#1864)
 
+	// maxBlockWeight is the maximum total weight of the extrinsics of the blocks produced.
+	maxBlockWeight uint64
+
 	// State variables
 	sync.RWMutex
 	pause chan struct{}
@@ -61,6 +64,10 @@ type ServiceConfig struct {
 	IsDev              bool
 	Authority          bool
 	Telemetry          Telemetry
+	// MaxBlockWeight is the maximum total weight of the extrinsics of the
+	// blocks produced, as returned by MaxBlockWeight for the metadata of
+	// the runtime. It defaults to DefaultMaxBlockWeight if left to zero.
+	MaxBlockWeight uint64
 }
 
 // Validate returns error if config does not contain required attributes
@@ -111,7 +118,12 @@ func (Builder) NewServiceIFace(cfg *ServiceConfig) (service *Service, err error)
 			slotDuration: slotDuration,
 			epochLength:  epochLength,
 		},
-		telemetry: cfg.Telemetry,
+		telemetry:      cfg.Telemetry,
+		maxBlockWeight: cfg.MaxBlockWeight,
+	}
+
+	if babeService.maxBlockWeight == 0 {
+		babeService.maxBlockWeight = DefaultMaxBlockWeight
 	}
 
 	logger.Debugf(
@@ -158,7 +170,12 @@ func NewService(cfg *ServiceConfig) (*Service, error) {
 			slotDuration: slotDuration,
 			epochLength:  epochLength,
 		},
-		telemetry: cfg.Telemetry,
+		telemetry:      cfg.Telemetry,
+		maxBlockWeight: cfg.MaxBlockWeight,
+	}
+
+	if babeService.maxBlockWeight == 0 {
+		babeService.maxBlockWeight = DefaultMaxBlockWeight
 	}
 
 	logger.Debugf(
@@ -470,6 +487,18 @@ func (b *Service) handleSlot(epoch uint64, slot Slot,
 		return err
 	}
 
+	// the block is already imported, so failing to store its
+	// VRF randomness must not fail the slot handling.
+	epochData, err := b.epochState.GetEpochData(epoch, &block.Header)
+	if err != nil {
+		logger.Errorf("getting epoch data for epoch %d: %s", epoch, err)
+		return nil
+	}
+
+	err = storeVRFRandomness(b.epochState, epoch, epochData.Randomness, epochData.Authorities, &block.Header)
+	if err != nil {
+		logger.Errorf("storing VRF randomness of built block %s: %s", block.Header.Hash(), err)
+	}
 	return nil
 }
 
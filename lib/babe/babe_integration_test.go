@@ -221,6 +221,7 @@ func TestService_HandleSlotWithSameSlot(t *testing.T) {
 		babeService.blockState,
 		authorityIndex,
 		preRuntimeDigest,
+		DefaultMaxBlockWeight,
 	)
 
 	block, err := builder.buildBlock(&genesisHeader, slot, runtime)
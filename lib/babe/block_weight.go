@@ -0,0 +1,81 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package babe
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ChainSafe/gossamer/lib/runtime/extrinsic"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+var errBlockWeightsMalformed = errors.New("block weights constant is malformed")
+
+// MaxBlockWeight returns the maximum total weight of the extrinsics of a block
+// read from the BlockWeights constant of the System pallet of the runtime
+// metadata given. It is the maximum total weight of the normal dispatch class
+// if the runtime limits it, and the maximum weight of a block otherwise.
+// Only the reference time of the weight is accounted for.
+func MaxBlockWeight(metadata *ctypes.Metadata) (weight uint64, err error) {
+	blockWeights, err := extrinsic.DecodeConstant(metadata, "System", "BlockWeights")
+	if err != nil {
+		return 0, err
+	}
+
+	perClass, ok := fieldValue(blockWeights, "per_class")
+	if ok {
+		normal, _ := fieldValue(perClass, "normal")
+		maxTotal, _ := fieldValue(normal, "max_total")
+		if option, ok := maxTotal.(extrinsic.Variant); ok && option.Name == "Some" && len(option.Fields) == 1 {
+			return refTime(option.Fields[0].Value)
+		}
+	}
+
+	maxBlock, ok := fieldValue(blockWeights, "max_block")
+	if !ok {
+		return 0, fmt.Errorf("%w: max_block not found", errBlockWeightsMalformed)
+	}
+	return refTime(maxBlock)
+}
+
+// fieldValue returns the value of the field with the given
+// name if the value given is decoded as a composite value.
+func fieldValue(value interface{}, name string) (fieldValue interface{}, ok bool) {
+	fields, ok := value.([]extrinsic.Field)
+	if !ok {
+		return nil, false
+	}
+
+	for _, field := range fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+// refTime returns the reference time of the weight given, which is decoded
+// either as an integer for runtimes before weights v2, or as a composite
+// value with a reference time field.
+func refTime(weight interface{}) (uint64, error) {
+	switch weight := weight.(type) {
+	case uint64:
+		return weight, nil
+	case *big.Int:
+		if !weight.IsUint64() {
+			return 0, fmt.Errorf("%w: weight %s overflows uint64", errBlockWeightsMalformed, weight)
+		}
+		return weight.Uint64(), nil
+	case []extrinsic.Field:
+		value, ok := fieldValue(weight, "ref_time")
+		if !ok {
+			return 0, fmt.Errorf("%w: ref_time not found", errBlockWeightsMalformed)
+		}
+		return refTime(value)
+	default:
+		return 0, fmt.Errorf("%w: weight decoded as %T", errBlockWeightsMalformed, weight)
+	}
+}
@@ -23,6 +23,12 @@
 	buildBlockErrors = "gossamer/proposer/block/constructed/errors"
 )
 
+// DefaultMaxBlockWeight is the default maximum total weight of the extrinsics
+// of a block produced, used if the runtime metadata does not provide it. It is
+// the 75% normal dispatch ratio of the maximum block weight of two seconds of
+// execution of the Substrate based relay chains.
+const DefaultMaxBlockWeight uint64 = 1_500_000_000_000
+
 // construct a block for this slot with the given parent
 func (b *Service) buildBlock(parent *types.Header, slot Slot, rt Runtime,
 	authorityIndex uint32, preRuntimeDigest *types.PreRuntimeDigest) (*types.Block, error) {
@@ -32,6 +38,7 @@ func (b *Service) buildBlock(parent *types.Header, slot Slot, rt Runtime,
 		b.blockState,
 		authorityIndex,
 		preRuntimeDigest,
+		b.maxBlockWeight,
 	)
 
 	// is necessary to enable ethmetrics to be possible register values
@@ -57,6 +64,7 @@ type BlockBuilder struct {
 	blockState            BlockState
 	currentAuthorityIndex uint32
 	preRuntimeDigest      *types.PreRuntimeDigest
+	maxBlockWeight        uint64
 }
 
 // NewBlockBuilder creates a new block builder.
@@ -66,6 +74,7 @@ func NewBlockBuilder(
 	bs BlockState,
 	authidx uint32,
 	preRuntimeDigest *types.PreRuntimeDigest,
+	maxBlockWeight uint64,
 ) *BlockBuilder {
 	return &BlockBuilder{
 		keypair:               kp,
@@ -73,6 +82,7 @@ func NewBlockBuilder(
 		blockState:            bs,
 		currentAuthorityIndex: authidx,
 		preRuntimeDigest:      preRuntimeDigest,
+		maxBlockWeight:        maxBlockWeight,
 	}
 }
 
@@ -104,8 +114,12 @@ func (b *BlockBuilder) buildBlock(parent *types.Header, slot Slot, rt Runtime) (
 
 	logger.Tracef("built block encoded inherents: %v", inherents)
 
+	// the mandatory inherents are always included, so their
+	// weight is accounted for before adding the extrinsics.
+	inherentsWeight := extrinsicsWeight(rt, inherents)
+
 	// add block extrinsics
-	included := b.buildBlockExtrinsics(slot, rt)
+	included := b.buildBlockExtrinsics(slot, rt, inherentsWeight)
 
 	logger.Trace("built block extrinsics")
 
@@ -170,8 +184,11 @@ func (b *BlockBuilder) buildBlockSeal(header *types.Header) (*types.SealDigest,
 
 // buildBlockExtrinsics applies extrinsics to the block. it returns an array of included extrinsics.
 // for each extrinsic in queue, add it to the block, until the slot ends or the block is full.
+// The block is full once the weight of the next extrinsic exceeds the maximum block weight
+// minus the weight already used, in which case the extrinsic is left in the queue.
 // if any extrinsic fails, it returns an empty array and an error.
-func (b *BlockBuilder) buildBlockExtrinsics(slot Slot, rt ExtrinsicHandler) []*transaction.ValidTransaction {
+func (b *BlockBuilder) buildBlockExtrinsics(slot Slot, rt ExtrinsicHandler,
+	usedWeight uint64) []*transaction.ValidTransaction {
 	var included []*transaction.ValidTransaction
 
 	slotEnd := slot.start.Add(slot.duration * 2 / 3) // reserve last 1/3 of slot for block finalisation
@@ -188,6 +205,27 @@ func (b *BlockBuilder) buildBlockExtrinsics(slot Slot, rt ExtrinsicHandler) []*t
 		extrinsic := txn.Extrinsic
 		logger.Tracef("build block, applying extrinsic %s", extrinsic)
 
+		// The apply extrinsic results do not contain the weight of the extrinsic,
+		// so the weight is queried before applying it. If the query fails, the
+		// runtime still rejects the extrinsic if it exhausts the block resources.
+		var weight uint64
+		dispatchInfo, err := rt.PaymentQueryInfo(extrinsic)
+		if err != nil {
+			logger.Warnf("querying weight of extrinsic %s: %s", extrinsic, err)
+		} else {
+			weight = dispatchInfo.Weight
+		}
+
+		if weight > b.maxBlockWeight || usedWeight > b.maxBlockWeight-weight {
+			logger.Debugf("block is full with weight %d, extrinsic %s of weight %d left in queue",
+				usedWeight, extrinsic, weight)
+			hash, err := b.transactionState.Push(txn)
+			if err != nil {
+				logger.Debugf("failed to re-add transaction with hash %s to queue: %s", hash, err)
+			}
+			break
+		}
+
 		ret, err := rt.ApplyExtrinsic(extrinsic)
 		if err != nil {
 			logger.Warnf("determining apply extrinsic call error: %s", err)
@@ -221,39 +259,72 @@ func (b *BlockBuilder) buildBlockExtrinsics(slot Slot, rt ExtrinsicHandler) []*t
 
 		logger.Debugf("build block applied extrinsic %s", extrinsic)
 		included = append(included, txn)
+		usedWeight += weight
 	}
 
 	return included
 }
 
-func buildBlockInherents(slot Slot, rt ExtrinsicHandler, parent *types.Header) ([][]byte, error) {
-	// Setup inherents: add timstap0
-	idata := types.NewInherentData()
-	err := idata.SetInherent(types.Timstap0, uint64(slot.start.UnixMilli()))
-	if err != nil {
-		return nil, err
-	}
+// extrinsicsWeight returns the total weight of the given extrinsics, each
+// extrinsic being SCALE encoded as a byte array before querying its weight.
+// The weight of an extrinsic is ignored if it cannot be queried.
+func extrinsicsWeight(rt ExtrinsicHandler, extrinsics [][]byte) (weight uint64) {
+	for _, extrinsic := range extrinsics {
+		encodedExtrinsic, err := scale.Marshal(extrinsic)
+		if err != nil {
+			logger.Warnf("encoding extrinsic 0x%x: %s", extrinsic, err)
+			continue
+		}
 
-	// add babeslot
-	err = idata.SetInherent(types.Babeslot, slot.number)
-	if err != nil {
-		return nil, err
+		dispatchInfo, err := rt.PaymentQueryInfo(encodedExtrinsic)
+		if err != nil {
+			logger.Warnf("querying weight of extrinsic 0x%x: %s", extrinsic, err)
+			continue
+		}
+		weight += dispatchInfo.Weight
 	}
+	return weight
+}
+
+// inherentDataProviders returns the inherent data providers
+// for a block produced in the given slot with the given parent.
+func inherentDataProviders(slot Slot, parent *types.Header) (providers *types.InherentDataProviders) {
+	providers = new(types.InherentDataProviders)
+	providers.Register(&types.TimestampInherentDataProvider{Timestamp: slot.start})
+	providers.Register(&types.SlotInherentDataProvider{Slot: slot.number})
+	providers.Register(&parachainInherentDataProvider{parentHeader: *parent})
+	return providers
+}
 
+// parachainInherentDataProvider provides the parachn0 and newheads inherent data.
+// For now it provides "empty" values, as parachain-specific logic is
+// required to actually provide the data.
+type parachainInherentDataProvider struct {
+	parentHeader types.Header
+}
+
+func (p *parachainInherentDataProvider) ProvideInherentData(data *types.InherentData) (err error) {
 	parachainInherent := inherents.ParachainInherentData{
-		ParentHeader: *parent,
+		ParentHeader: p.parentHeader,
 	}
 
-	// add parachn0 and newheads
-	// for now we can use "empty" values, as we require parachain-specific
-	// logic to actually provide the data.
+	if err = data.SetInherent(types.Parachn0, parachainInherent); err != nil {
+		return fmt.Errorf("setting inherent %q: %w", types.Parachn0, err)
+	}
 
-	if err = idata.SetInherent(types.Parachn0, parachainInherent); err != nil {
-		return nil, fmt.Errorf("setting inherent %q: %w", types.Parachn0, err)
+	if err = data.SetInherent(types.Newheads, []byte{0}); err != nil {
+		return fmt.Errorf("setting inherent %q: %w", types.Newheads, err)
 	}
 
-	if err = idata.SetInherent(types.Newheads, []byte{0}); err != nil {
-		return nil, fmt.Errorf("setting inherent %q: %w", types.Newheads, err)
+	return nil
+}
+
+// buildBlockInherents applies the inherent extrinsics created by the runtime from
+// the inherent data of the block, before any other extrinsic is applied.
+func buildBlockInherents(slot Slot, rt ExtrinsicHandler, parent *types.Header) ([][]byte, error) {
+	idata, err := inherentDataProviders(slot, parent).InherentData()
+	if err != nil {
+		return nil, err
 	}
 
 	ienc, err := idata.Encode()
@@ -7,6 +7,7 @@
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
 	"time"
 
@@ -23,6 +24,7 @@
 	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -93,6 +95,49 @@ func TestBuildBlock_ok(t *testing.T) {
 	require.Equal(t, expectedSecondExtrinsic, common.BytesToHex(extsBytes[1]))
 }
 
+func TestBuildBlock_inherents(t *testing.T) {
+	genesis, genesisTrie, genesisHeader := newWestendDevGenesisWithTrieAndHeader(t)
+	babeService := createTestService(t, ServiceConfig{}, genesis, genesisTrie, genesisHeader, nil)
+
+	bestBlockHash := babeService.blockState.BestBlockHash()
+	rt, err := babeService.blockState.GetRuntime(bestBlockHash)
+	require.NoError(t, err)
+
+	testEpochData, err := babeService.initiateEpoch(testEpochIndex)
+	require.NoError(t, err)
+
+	slot := getSlot(t, rt, time.Now())
+	block := createTestBlockWithSlot(t, babeService, &genesisHeader, nil, testEpochIndex, testEpochData, slot)
+
+	// The first extrinsic is the unsigned Timestamp.set call with
+	// the slot start timestamp in milliseconds as compact integer.
+	encodedTimestamp, err := scale.Marshal(big.NewInt(slot.start.UnixMilli()))
+	require.NoError(t, err)
+	const timestampModuleIndex, setCallIndex = 2, 0
+	expectedTimestampExtrinsic := append([]byte{4, timestampModuleIndex, setCallIndex}, encodedTimestamp...)
+	extsBytes := types.ExtrinsicsArrayToBytesArray(block.Body)
+	require.NotEmpty(t, extsBytes)
+	assert.Equal(t, expectedTimestampExtrinsic, extsBytes[0])
+
+	// The inherents are checked on import against the parent state.
+	parentState, err := babeService.storageState.TrieState(&genesisHeader.StateRoot)
+	require.NoError(t, err)
+	rt.SetContextStorage(parentState)
+
+	providers := new(types.InherentDataProviders)
+	providers.Register(&types.TimestampInherentDataProvider{Timestamp: time.Now()})
+	providers.Register(&types.SlotInherentDataProvider{Slot: slot.number})
+	inherentData, err := providers.InherentData()
+	require.NoError(t, err)
+	encodedInherentData, err := inherentData.Encode()
+	require.NoError(t, err)
+
+	result, err := rt.CheckInherents(block, encodedInherentData)
+	require.NoError(t, err)
+	assert.True(t, result.Okay, "inherent errors: %v", result.Errors)
+	assert.False(t, result.FatalError)
+}
+
 func TestApplyExtrinsicAfterFirstBlockFinalized(t *testing.T) {
 	genesis, genesisTrie, genesisHeader := newWestendDevGenesisWithTrieAndHeader(t)
 	babeService := createTestService(t, ServiceConfig{}, genesis, genesisTrie, genesisHeader, nil)
@@ -115,6 +160,7 @@ func TestApplyExtrinsicAfterFirstBlockFinalized(t *testing.T) {
 		babeService.blockState,
 		authorityIndex,
 		preRuntimeDigest,
+		DefaultMaxBlockWeight,
 	)
 
 	parentHeader := emptyHeader
@@ -314,6 +360,30 @@ func TestBuildAndApplyExtrinsic_InvalidPayment(t *testing.T) {
 	require.Equal(t, "transaction validity error: invalid payment", err.Error())
 }
 
+func TestMaxBlockWeight(t *testing.T) {
+	genesis, genesisTrie, genesisHeader := newWestendLocalGenesisWithTrieAndHeader(t)
+	babeService := createTestService(t, ServiceConfig{}, genesis, genesisTrie, genesisHeader, nil)
+
+	rt, err := babeService.blockState.GetRuntime(babeService.blockState.BestBlockHash())
+	require.NoError(t, err)
+
+	rawMeta, err := rt.Metadata()
+	require.NoError(t, err)
+	var metadataBytes []byte
+	err = scale.Unmarshal(rawMeta, &metadataBytes)
+	require.NoError(t, err)
+
+	meta := &ctypes.Metadata{}
+	err = codec.Decode(metadataBytes, meta)
+	require.NoError(t, err)
+
+	// the normal extrinsics of Westend blocks use at most 75%
+	// of the two seconds of execution time of a block.
+	weight, err := MaxBlockWeight(meta)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1_500_000_000_000), weight)
+}
+
 func TestDecodeExtrinsicBody(t *testing.T) {
 	ext := types.NewExtrinsic([]byte{0x1, 0x2, 0x3})
 	inh := [][]byte{{0x4, 0x5}, {0x6, 0x7}}
@@ -0,0 +1,74 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package babe
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/babe/mocks"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BlockBuilder_buildBlockExtrinsics_maxBlockWeight(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	const (
+		maxBlockWeight   = 1000
+		inherentsWeight  = 100
+		extrinsicWeight  = 300
+		numberOfPending  = 10
+		numberOfIncluded = 3 // 100 + 3 * 300 <= 1000 < 100 + 4 * 300
+	)
+
+	transactionState := state.NewTransactionState(nil)
+	for i := 0; i < numberOfPending; i++ {
+		_, err := transactionState.Push(transaction.NewValidTransaction(
+			types.Extrinsic{byte(i)}, &transaction.Validity{}))
+		require.NoError(t, err)
+	}
+
+	rt := mocks.NewMockInstance(ctrl)
+	rt.EXPECT().PaymentQueryInfo(gomock.Any()).
+		Return(&types.RuntimeDispatchInfo{Weight: extrinsicWeight}, nil).
+		Times(numberOfIncluded + 1)
+	rt.EXPECT().ApplyExtrinsic(gomock.Any()).
+		Return([]byte{0, 0}, nil).
+		Times(numberOfIncluded)
+
+	builder := NewBlockBuilder(nil, transactionState, nil, 0, nil, maxBlockWeight)
+	slot := Slot{start: time.Now(), duration: time.Minute}
+
+	included := builder.buildBlockExtrinsics(slot, rt, inherentsWeight)
+
+	assert.Len(t, included, numberOfIncluded)
+	assert.LessOrEqual(t, inherentsWeight+len(included)*extrinsicWeight, maxBlockWeight)
+	// the extrinsics not included are left in the queue.
+	assert.Len(t, transactionState.Pending(), numberOfPending-numberOfIncluded)
+}
+
+func Test_extrinsicsWeight(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	rt := mocks.NewMockInstance(ctrl)
+	// the extrinsics are SCALE encoded as byte arrays
+	rt.EXPECT().PaymentQueryInfo([]byte{4, 1}).
+		Return(&types.RuntimeDispatchInfo{Weight: 10}, nil)
+	rt.EXPECT().PaymentQueryInfo([]byte{8, 2, 3}).
+		Return(&types.RuntimeDispatchInfo{Weight: 20}, nil)
+	rt.EXPECT().PaymentQueryInfo([]byte{4, 4}).
+		Return(nil, errors.New("test error"))
+
+	weight := extrinsicsWeight(rt, [][]byte{{1}, {2, 3}, {4}})
+
+	assert.Equal(t, uint64(30), weight)
+}
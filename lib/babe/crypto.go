@@ -20,6 +20,10 @@
 // https://github.com/paritytech/substrate/blob/89275433863532d797318b75bb5321af098fea7c/primitives/consensus/babe/src/lib.rs#L93
 var babeVRFPrefix = []byte("substrate-babe-vrf")
 
+// babeRandomnessVRFContext is the context used to make the VRF randomness of
+// primary blocks, which is accumulated in the randomness of the epoch after next.
+var babeRandomnessVRFContext = []byte("BabeVRFInOutContext")
+
 func makeTranscript(randomness Randomness, slot, epoch uint64) *merlin.Transcript {
 	t := merlin.NewTranscript("BABE") //string(types.BabeEngineID[:])
 	crypto.AppendUint64(t, []byte("slot number"), slot)
@@ -92,6 +96,28 @@ func checkPrimaryThreshold(randomness Randomness,
 	return inoutUint.Compare(threshold) < 0, nil
 }
 
+// makeVRFRandomness returns the VRF randomness of a primary block
+// from its VRF output and from the public key of its author.
+func makeVRFRandomness(randomness Randomness,
+	slot, epoch uint64,
+	output [sr25519.VRFOutputLength]byte,
+	pub *sr25519.PublicKey,
+) (vrfRandomness Randomness, err error) {
+	t := makeTranscript(randomness, slot, epoch)
+	inout, err := sr25519.AttachInput(output, pub, t)
+	if err != nil {
+		return vrfRandomness, fmt.Errorf("attaching sr25519 input: %w", err)
+	}
+
+	res, err := inout.MakeBytes(types.RandomnessLength, babeRandomnessVRFContext)
+	if err != nil {
+		return vrfRandomness, fmt.Errorf("making sr25519 bytes: %w", err)
+	}
+
+	copy(vrfRandomness[:], res)
+	return vrfRandomness, nil
+}
+
 func claimSecondarySlotVRF(randomness Randomness,
 	slot, epoch uint64,
 	authorities []types.Authority,
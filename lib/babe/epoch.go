@@ -60,7 +60,19 @@ func (b *Service) initiateEpoch(epoch uint64) (*epochData, error) {
 	return epochData, nil
 }
 
+// checkAndSetFirstSlot sets the first slot to the slot number of block 1 if it
+// differs. Once block 1 is finalised, the first slot stored by the state is final
+// and block 1 is not loaded, since it may have been pruned or never synced.
 func (b *Service) checkAndSetFirstSlot() error {
+	blockOneIsFinal, err := b.blockState.NumberIsFinalised(1)
+	if err != nil {
+		return fmt.Errorf("checking if block 1 is finalised: %w", err)
+	}
+
+	if blockOneIsFinal {
+		return nil
+	}
+
 	firstSlot, err := b.epochState.GetStartSlotForEpoch(0)
 	if err != nil {
 		return fmt.Errorf("cannot set first slot: %w", err)
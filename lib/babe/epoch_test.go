@@ -37,9 +37,14 @@ func TestBabeService_checkAndSetFirstSlot(t *testing.T) {
 		Header: *header,
 	}
 
+	mockBlockState.EXPECT().NumberIsFinalised(uint(1)).Return(false, nil).Times(2)
 	mockBlockState.EXPECT().GetBlockByNumber(uint(1)).Return(block, nil)
 	mockBlockState.EXPECT().GetBlockByNumber(uint(1)).Return(block, nil)
 
+	// block 1 is finalised, so it is not loaded since it may be pruned
+	mockFinalisedBlockState := NewMockBlockState(ctrl)
+	mockFinalisedBlockState.EXPECT().NumberIsFinalised(uint(1)).Return(true, nil)
+
 	bs0 := &Service{
 		epochState: mockEpochState0,
 		blockState: mockBlockState,
@@ -50,6 +55,11 @@ func TestBabeService_checkAndSetFirstSlot(t *testing.T) {
 		blockState: mockBlockState,
 	}
 
+	bs2 := &Service{
+		epochState: NewMockEpochState(ctrl),
+		blockState: mockFinalisedBlockState,
+	}
+
 	cases := []struct {
 		name    string
 		service *Service
@@ -62,6 +72,10 @@ func TestBabeService_checkAndSetFirstSlot(t *testing.T) {
 			name:    "should update first slot, as it's set incorrectly",
 			service: bs1,
 		},
+		{
+			name:    "should not check first slot, as block 1 is finalised",
+			service: bs2,
+		},
 	}
 
 	for _, tc := range cases {
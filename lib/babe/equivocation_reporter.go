@@ -0,0 +1,176 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package babe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+// equivocationResubmitInterval is the number of blocks after which the report
+// of an equivocation not yet included on-chain is submitted again, since the
+// transaction pool may have dropped it.
+const equivocationResubmitInterval = 16
+
+// reportedEquivocation identifies the equivocation of an authority in a slot.
+type reportedEquivocation struct {
+	slot     uint64
+	offender types.AuthorityID
+}
+
+// EquivocationReporter follows the new best blocks imported to mark the
+// equivocations stored as reported once their report extrinsic is included
+// on-chain, and submits again the reports not included after
+// equivocationResubmitInterval blocks, including after a restart.
+type EquivocationReporter struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	blockState BlockState
+	slotState  SlotState
+	imported   chan *types.Block
+
+	// submittedAt maps each unreported equivocation to the number
+	// of the best block when its report was last submitted.
+	submittedAt map[reportedEquivocation]uint
+}
+
+// NewEquivocationReporter returns a new equivocation reporter.
+func NewEquivocationReporter(blockState BlockState, slotState SlotState) *EquivocationReporter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EquivocationReporter{
+		ctx:         ctx,
+		cancel:      cancel,
+		blockState:  blockState,
+		slotState:   slotState,
+		submittedAt: make(map[reportedEquivocation]uint),
+	}
+}
+
+// Start starts following the new best blocks imported.
+func (r *EquivocationReporter) Start() error {
+	r.imported = r.blockState.GetImportedBlockNotifierChannel()
+	r.wg.Add(1)
+	go r.run()
+	return nil
+}
+
+// Stop stops the reporter and waits for its goroutine to return.
+func (r *EquivocationReporter) Stop() error {
+	r.cancel()
+	r.wg.Wait()
+	r.blockState.FreeImportedBlockNotifierChannel(r.imported)
+	return nil
+}
+
+func (r *EquivocationReporter) run() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case block, ok := <-r.imported:
+			if !ok {
+				return
+			}
+
+			if block.Header.Hash() != r.blockState.BestBlockHash() {
+				continue
+			}
+
+			err := r.handleBlock(block)
+			if err != nil {
+				logger.Errorf("handling equivocation reports for block #%d (%s): %s",
+					block.Header.Number, block.Header.Hash(), err)
+			}
+		}
+	}
+}
+
+// handleBlock marks the unreported equivocations whose report is included in
+// the block given as reported, and submits again the other reports if they
+// were last submitted at least equivocationResubmitInterval blocks before.
+func (r *EquivocationReporter) handleBlock(block *types.Block) error {
+	proofs, err := r.slotState.GetUnreportedEquivocations()
+	if err != nil {
+		return fmt.Errorf("getting unreported equivocations: %w", err)
+	}
+
+	unreported := make(map[reportedEquivocation]struct{}, len(proofs))
+	for i := range proofs {
+		proof := &proofs[i]
+		id := reportedEquivocation{slot: proof.Slot, offender: proof.Offender}
+
+		included, err := reportIncluded(block.Body, proof)
+		if err != nil {
+			return err
+		}
+
+		if included {
+			// the equivocations are stored by the authority index
+			// found in the pre-runtime digest of the headers.
+			authorityIndex, _, err := getAuthorityIndexAndSlot(&proof.FirstHeader)
+			if err != nil {
+				return fmt.Errorf("getting authority index of equivocation in slot %d: %w", proof.Slot, err)
+			}
+
+			err = r.slotState.SetEquivocationReported(proof.Slot, authorityIndex)
+			if err != nil {
+				return fmt.Errorf("setting equivocation in slot %d as reported: %w", proof.Slot, err)
+			}
+			logger.Debugf("report of equivocation of authority 0x%x in slot %d is included in block #%d",
+				proof.Offender, proof.Slot, block.Header.Number)
+			continue
+		}
+		unreported[id] = struct{}{}
+
+		// the report was submitted when the equivocation was first
+		// detected, or is submitted after the interval following a restart.
+		submittedAt, ok := r.submittedAt[id]
+		if !ok {
+			r.submittedAt[id] = block.Header.Number
+			continue
+		} else if block.Header.Number < submittedAt+equivocationResubmitInterval {
+			continue
+		}
+
+		err = submitEquivocationReport(r.blockState, proof)
+		if err != nil {
+			logger.Warnf("resubmitting report of equivocation of authority 0x%x in slot %d: %s",
+				proof.Offender, proof.Slot, err)
+		}
+		r.submittedAt[id] = block.Header.Number
+	}
+
+	for id := range r.submittedAt {
+		if _, ok := unreported[id]; !ok {
+			delete(r.submittedAt, id)
+		}
+	}
+
+	return nil
+}
+
+// reportIncluded returns true if one of the extrinsics given contains the
+// equivocation proof given, as encoded in its report extrinsic.
+func reportIncluded(body types.Body, proof *types.BabeEquivocationProof) (bool, error) {
+	encodedProof, err := scale.Marshal(*proof)
+	if err != nil {
+		return false, fmt.Errorf("encoding equivocation proof: %w", err)
+	}
+
+	for _, extrinsic := range body {
+		if bytes.Contains(extrinsic, encodedProof) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
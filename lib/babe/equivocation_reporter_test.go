@@ -0,0 +1,90 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package babe
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/babe/mocks"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EquivocationReporter_handleBlock(t *testing.T) {
+	t.Parallel()
+
+	const authorityIndex = uint32(4)
+	preRuntimeDigest, err := types.BabeSecondaryPlainPreDigest{
+		AuthorityIndex: authorityIndex,
+		SlotNumber:     2,
+	}.ToPreRuntimeDigest()
+	require.NoError(t, err)
+	header := newTestHeader(t, *preRuntimeDigest)
+
+	proof := types.BabeEquivocationProof{
+		Offender:     types.AuthorityID{1},
+		Slot:         2,
+		FirstHeader:  *header,
+		SecondHeader: *header,
+	}
+	proof.SecondHeader.Number = 2
+	encodedProof, err := scale.Marshal(proof)
+	require.NoError(t, err)
+
+	newBlock := func(number uint, body types.Body) *types.Block {
+		header := types.NewEmptyHeader()
+		header.Number = number
+		return &types.Block{Header: *header, Body: body}
+	}
+
+	ctrl := gomock.NewController(t)
+	slotState := NewMockSlotState(ctrl)
+	blockState := NewMockBlockState(ctrl)
+	runtimeInstance := mocks.NewMockInstance(ctrl)
+	reporter := NewEquivocationReporter(blockState, slotState)
+
+	// the report is not submitted again when the
+	// equivocation is first seen unreported
+	slotState.EXPECT().GetUnreportedEquivocations().Return([]types.BabeEquivocationProof{proof}, nil)
+	err = reporter.handleBlock(newBlock(10, types.Body{{1}}))
+	require.NoError(t, err)
+
+	// nor before the resubmit interval elapsed
+	slotState.EXPECT().GetUnreportedEquivocations().Return([]types.BabeEquivocationProof{proof}, nil)
+	err = reporter.handleBlock(newBlock(10+equivocationResubmitInterval-1, nil))
+	require.NoError(t, err)
+
+	// the report is submitted again once the resubmit interval elapsed,
+	// and a submission failure does not fail the block handling
+	slotState.EXPECT().GetUnreportedEquivocations().Return([]types.BabeEquivocationProof{proof}, nil)
+	blockState.EXPECT().BestBlockHash().Return(common.Hash{1})
+	blockState.EXPECT().GetRuntime(common.Hash{1}).Return(runtimeInstance, nil)
+	runtimeInstance.EXPECT().BabeGenerateKeyOwnershipProof(proof.Slot, proof.Offender).
+		Return(testKeyOwnershipProof, nil)
+	runtimeInstance.EXPECT().BabeSubmitReportEquivocationUnsignedExtrinsic(proof, testKeyOwnershipProof).
+		Return(errors.New("test error"))
+	err = reporter.handleBlock(newBlock(10+equivocationResubmitInterval, nil))
+	require.NoError(t, err)
+	assert.Equal(t, uint(10+equivocationResubmitInterval),
+		reporter.submittedAt[reportedEquivocation{slot: proof.Slot, offender: proof.Offender}])
+
+	// the equivocation is set as reported once its report is included
+	slotState.EXPECT().GetUnreportedEquivocations().Return([]types.BabeEquivocationProof{proof}, nil)
+	slotState.EXPECT().SetEquivocationReported(proof.Slot, authorityIndex).Return(nil)
+	reportExtrinsic := append([]byte{5, 0x13, 0x02}, encodedProof...)
+	err = reporter.handleBlock(newBlock(30, types.Body{{1}, reportExtrinsic}))
+	require.NoError(t, err)
+	assert.Empty(t, reporter.submittedAt)
+
+	errTest := errors.New("test error")
+	slotState.EXPECT().GetUnreportedEquivocations().Return(nil, errTest)
+	err = reporter.handleBlock(newBlock(31, nil))
+	assert.ErrorIs(t, err, errTest)
+	assert.EqualError(t, err, "getting unreported equivocations: test error")
+}
@@ -60,6 +60,10 @@
 	// ErrNotAuthority is returned when trying to perform authority functions when not an authority
 	ErrNotAuthority = errors.New("node is not an authority")
 
+	// ErrBlockFromFuture is returned when a block is received before the start of the slot
+	// preceding its slot, according to the local clock
+	ErrBlockFromFuture = errors.New("block received before its slot")
+
 	// ErrThresholdOneIsZero is returned when one of or both parameters to CalculateThreshold is zero
 	ErrThresholdOneIsZero = errors.New("numerator or denominator cannot be 0")
 
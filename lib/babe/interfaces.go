@@ -25,6 +25,7 @@ type BlockHandler interface {
 type ExtrinsicHandler interface {
 	InherentExtrinsics(data []byte) ([]byte, error)
 	ApplyExtrinsic(data types.Extrinsic) ([]byte, error)
+	PaymentQueryInfo(ext []byte) (*types.RuntimeDispatchInfo, error)
 }
 
 // Telemetry is the telemetry client to send telemetry messages.
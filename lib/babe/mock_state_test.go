@@ -122,6 +122,21 @@ func (mr *MockBlockStateMockRecorder) GetAllBlocksAtDepth(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllBlocksAtDepth", reflect.TypeOf((*MockBlockState)(nil).GetAllBlocksAtDepth), arg0)
 }
 
+// GetArrivalTime mocks base method.
+func (m *MockBlockState) GetArrivalTime(arg0 common.Hash) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArrivalTime", arg0)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArrivalTime indicates an expected call of GetArrivalTime.
+func (mr *MockBlockStateMockRecorder) GetArrivalTime(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArrivalTime", reflect.TypeOf((*MockBlockState)(nil).GetArrivalTime), arg0)
+}
+
 // GetBlockByNumber mocks base method.
 func (m *MockBlockState) GetBlockByNumber(arg0 uint) (*types.Block, error) {
 	m.ctrl.T.Helper()
@@ -484,6 +499,21 @@ func (mr *MockEpochStateMockRecorder) GetEpochData(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpochData", reflect.TypeOf((*MockEpochState)(nil).GetEpochData), arg0, arg1)
 }
 
+// GetEpochDataForBlock mocks base method.
+func (m *MockEpochState) GetEpochDataForBlock(arg0 uint64, arg1 *types.Header) (*types.EpochData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEpochDataForBlock", arg0, arg1)
+	ret0, _ := ret[0].(*types.EpochData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEpochDataForBlock indicates an expected call of GetEpochDataForBlock.
+func (mr *MockEpochStateMockRecorder) GetEpochDataForBlock(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpochDataForBlock", reflect.TypeOf((*MockEpochState)(nil).GetEpochDataForBlock), arg0, arg1)
+}
+
 // GetEpochForBlock mocks base method.
 func (m *MockEpochState) GetEpochForBlock(arg0 *types.Header) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -631,6 +661,20 @@ func (mr *MockEpochStateMockRecorder) SkipVerify(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkipVerify", reflect.TypeOf((*MockEpochState)(nil).SkipVerify), arg0)
 }
 
+// StoreVRFRandomness mocks base method.
+func (m *MockEpochState) StoreVRFRandomness(arg0 uint64, arg1 common.Hash, arg2 [32]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreVRFRandomness", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreVRFRandomness indicates an expected call of StoreVRFRandomness.
+func (mr *MockEpochStateMockRecorder) StoreVRFRandomness(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreVRFRandomness", reflect.TypeOf((*MockEpochState)(nil).StoreVRFRandomness), arg0, arg1, arg2)
+}
+
 // MockBlockImportHandler is a mock of BlockImportHandler interface.
 type MockBlockImportHandler struct {
 	ctrl     *gomock.Controller
@@ -705,3 +749,47 @@ func (mr *MockSlotStateMockRecorder) CheckEquivocation(arg0, arg1, arg2, arg3 in
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckEquivocation", reflect.TypeOf((*MockSlotState)(nil).CheckEquivocation), arg0, arg1, arg2, arg3)
 }
+
+// GetUnreportedEquivocations mocks base method.
+func (m *MockSlotState) GetUnreportedEquivocations() ([]types.BabeEquivocationProof, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnreportedEquivocations")
+	ret0, _ := ret[0].([]types.BabeEquivocationProof)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnreportedEquivocations indicates an expected call of GetUnreportedEquivocations.
+func (mr *MockSlotStateMockRecorder) GetUnreportedEquivocations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnreportedEquivocations", reflect.TypeOf((*MockSlotState)(nil).GetUnreportedEquivocations))
+}
+
+// SetEquivocationReported mocks base method.
+func (m *MockSlotState) SetEquivocationReported(arg0 uint64, arg1 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEquivocationReported", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEquivocationReported indicates an expected call of SetEquivocationReported.
+func (mr *MockSlotStateMockRecorder) SetEquivocationReported(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEquivocationReported", reflect.TypeOf((*MockSlotState)(nil).SetEquivocationReported), arg0, arg1)
+}
+
+// StoreEquivocation mocks base method.
+func (m *MockSlotState) StoreEquivocation(arg0 uint64, arg1 uint32, arg2, arg3 *types.Header) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreEquivocation", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreEquivocation indicates an expected call of StoreEquivocation.
+func (mr *MockSlotStateMockRecorder) StoreEquivocation(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreEquivocation", reflect.TypeOf((*MockSlotState)(nil).StoreEquivocation), arg0, arg1, arg2, arg3)
+}
@@ -17,6 +17,10 @@
 type SlotState interface {
 	CheckEquivocation(slotNow, slot uint64, header *types.Header,
 		signer types.AuthorityID) (*types.BabeEquivocationProof, error)
+	StoreEquivocation(slot uint64, authorityIndex uint32,
+		firstHeader, secondHeader *types.Header) (isNew bool, err error)
+	GetUnreportedEquivocations() (proofs []types.BabeEquivocationProof, err error)
+	SetEquivocationReported(slot uint64, authorityIndex uint32) error
 }
 
 // BlockState interface for block state methods
@@ -25,6 +29,7 @@ type BlockState interface {
 	BestBlockHeader() (*types.Header, error)
 	AddBlock(*types.Block) error
 	GetAllBlocksAtDepth(hash common.Hash) []common.Hash
+	GetArrivalTime(hash common.Hash) (time.Time, error)
 	GetHeader(common.Hash) (*types.Header, error)
 	GetBlockByNumber(blockNumber uint) (*types.Block, error)
 	GetBlockHashesBySlot(slot uint64) (blockHashes []common.Hash, err error)
@@ -64,6 +69,7 @@ type EpochState interface {
 	SetEpochData(epoch uint64, info *types.EpochData) error
 
 	GetEpochData(epoch uint64, header *types.Header) (*types.EpochData, error)
+	GetEpochDataForBlock(epoch uint64, header *types.Header) (*types.EpochData, error)
 	GetConfigData(epoch uint64, header *types.Header) (*types.ConfigData, error)
 
 	GetLatestConfigData() (*types.ConfigData, error)
@@ -72,6 +78,7 @@ type EpochState interface {
 	SetFirstSlot(slot uint64) error
 	GetLatestEpochData() (*types.EpochData, error)
 	SkipVerify(*types.Header) (bool, error)
+	StoreVRFRandomness(epoch uint64, hash common.Hash, vrfRandomness [types.RandomnessLength]byte) error
 }
 
 // BlockImportHandler is the interface for the handler of new blocks
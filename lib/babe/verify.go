@@ -9,6 +9,7 @@
 	"sync"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
@@ -17,6 +18,10 @@
 
 var errEmptyKeyOwnershipProof = errors.New("key ownership proof is nil")
 
+// maxSlotDrift is the number of slots a block can be received
+// ahead of its slot, to tolerate clock drifts between nodes.
+const maxSlotDrift = 1
+
 // verifierInfo contains the information needed to verify blocks
 // it remains the same for an epoch
 type verifierInfo struct {
@@ -191,11 +196,45 @@ func (v *VerificationManager) VerifyBlock(header *types.Header) error {
 	}
 
 	verifier := newVerifier(v.blockState, v.slotState, epoch, info, slotDuration)
-	return verifier.verifyAuthorshipRight(header)
+	err = verifier.verifyAuthorshipRight(header)
+	if err != nil {
+		return err
+	}
+
+	err = v.verifySlotArrival(header, slotDuration)
+	if err != nil {
+		return err
+	}
+
+	return storeVRFRandomness(v.epochState, epoch, info.randomness, info.authorities, header)
+}
+
+// verifySlotArrival verifies the block was not received from the network more than
+// maxSlotDrift slots before its slot, according to the local clock reading at the
+// time it was first received. Blocks without an arrival time, such as blocks built
+// locally or blocks loaded from a snapshot, are only checked against their slot time.
+func (v *VerificationManager) verifySlotArrival(header *types.Header, slotDuration time.Duration) error {
+	arrivalTime, err := v.blockState.GetArrivalTime(header.Hash())
+	if errors.Is(err, state.ErrArrivalTimeNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("getting arrival time: %w", err)
+	}
+
+	slot, err := types.GetSlotFromHeader(header)
+	if err != nil {
+		return fmt.Errorf("getting slot from header: %w", err)
+	}
+
+	arrivalSlot := uint64(arrivalTime.UnixNano()) / uint64(slotDuration.Nanoseconds())
+	if slot > arrivalSlot+maxSlotDrift {
+		return fmt.Errorf("%w: slot %d received at slot %d", ErrBlockFromFuture, slot, arrivalSlot)
+	}
+	return nil
 }
 
 func (v *VerificationManager) getVerifierInfo(epoch uint64, header *types.Header) (*verifierInfo, error) {
-	epochData, err := v.epochState.GetEpochData(epoch, header)
+	epochData, err := v.epochState.GetEpochDataForBlock(epoch, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get epoch data for epoch %d: %w", epoch, err)
 	}
@@ -353,8 +392,14 @@ func (b *verifier) verifyAuthorshipRight(header *types.Header) error {
 }
 
 func (b *verifier) submitAndReportEquivocation(equivocationProof *types.BabeEquivocationProof) error {
-	bestBlockHash := b.blockState.BestBlockHash()
-	runtimeInstance, err := b.blockState.GetRuntime(bestBlockHash)
+	return submitEquivocationReport(b.blockState, equivocationProof)
+}
+
+// submitEquivocationReport submits the report of the equivocation proof
+// given to the transaction pool, through the runtime of the best block.
+func submitEquivocationReport(blockState BlockState, equivocationProof *types.BabeEquivocationProof) error {
+	bestBlockHash := blockState.BestBlockHash()
+	runtimeInstance, err := blockState.GetRuntime(bestBlockHash)
 	if err != nil {
 		return fmt.Errorf("getting runtime: %w", err)
 	}
@@ -407,6 +452,17 @@ func (b *verifier) verifyBlockEquivocation(header *types.Header) (bool, error) {
 		return false, nil
 	}
 
+	isNew, err := b.slotState.StoreEquivocation(slotNumber, authorityIndex,
+		&equivocationProof.FirstHeader, &equivocationProof.SecondHeader)
+	if err != nil {
+		return false, fmt.Errorf("storing equivocation: %w", err)
+	}
+
+	if !isNew {
+		logger.Debugf("equivocation of authority %d in slot %d is already stored", authorityIndex, slotNumber)
+		return true, nil
+	}
+
 	err = b.submitAndReportEquivocation(equivocationProof)
 	if err != nil {
 		return false, fmt.Errorf("submiting equivocation: %w", err)
@@ -554,3 +610,53 @@ func getAuthorityIndexAndSlot(header *types.Header) (authIdx uint32, slot uint64
 
 	return authIdx, slot, nil
 }
+
+// storeVRFRandomness stores the VRF randomness of the block header given
+// if it is a primary block, for it to be accumulated in the randomness
+// of the epoch after next.
+func storeVRFRandomness(epochState EpochState, epoch uint64, randomness Randomness,
+	authorities []types.Authority, header *types.Header) error {
+	if len(header.Digest.Types) == 0 {
+		return fmt.Errorf("for block hash %s: %w", header.Hash(), errNoDigest)
+	}
+
+	digestValue, err := header.Digest.Types[0].Value()
+	if err != nil {
+		return fmt.Errorf("getting first digest type value: %w", err)
+	}
+	preDigest, ok := digestValue.(types.PreRuntimeDigest)
+	if !ok {
+		return types.ErrNoFirstPreDigest
+	}
+
+	babePreDigest, err := types.DecodeBabePreDigest(preDigest.Data)
+	if err != nil {
+		return fmt.Errorf("decoding babe pre-digest: %w", err)
+	}
+
+	primaryPreDigest, ok := babePreDigest.(types.BabePrimaryPreDigest)
+	if !ok {
+		return nil
+	}
+
+	if uint64(len(authorities)) <= uint64(primaryPreDigest.AuthorityIndex) {
+		return ErrInvalidBlockProducerIndex
+	}
+
+	pub, err := sr25519.NewPublicKey(authorities[primaryPreDigest.AuthorityIndex].Key.Encode())
+	if err != nil {
+		return fmt.Errorf("creating public key: %w", err)
+	}
+
+	vrfRandomness, err := makeVRFRandomness(randomness, primaryPreDigest.SlotNumber, epoch,
+		primaryPreDigest.VRFOutput, pub)
+	if err != nil {
+		return fmt.Errorf("making VRF randomness: %w", err)
+	}
+
+	err = epochState.StoreVRFRandomness(epoch, header.Hash(), vrfRandomness)
+	if err != nil {
+		return fmt.Errorf("storing VRF randomness: %w", err)
+	}
+	return nil
+}
@@ -837,3 +837,128 @@ func issueNewBlockFrom(t *testing.T, parentHeader *types.Header,
 
 	return header
 }
+
+func TestVerificationManager_VerifyBlock_SkippedEpochs(t *testing.T) {
+	keyPairs := []*sr25519.Keypair{keyring.KeyAlice, keyring.KeyBob, keyring.KeyCharlie}
+	authorities := make([]types.AuthorityRaw, len(keyPairs))
+	for i, keyPair := range keyPairs {
+		authorities[i] = types.AuthorityRaw{
+			Key: keyPair.Public().(*sr25519.PublicKey).AsBytes(),
+		}
+	}
+
+	// epoch e is from slot 10e+1 to slot 10e+10
+	epochBABEConfig := &types.BabeConfiguration{
+		SlotDuration:       1000,
+		EpochLength:        10,
+		C1:                 1,
+		C2:                 4,
+		GenesisAuthorities: authorities[:1],
+		SecondarySlots:     1,
+	}
+
+	genesis, trie, genesisHeader := newWestendDevGenesisWithTrieAndHeader(t)
+
+	ctrl := gomock.NewController(t)
+	telemetryMock := NewMockTelemetry(ctrl)
+	telemetryMock.EXPECT().SendMessage(
+		telemetry.NewNotifyFinalized(
+			genesisHeader.Hash(),
+			fmt.Sprint(genesisHeader.Number),
+		),
+	)
+
+	stateService := state.NewService(state.Config{
+		Path:      t.TempDir(),
+		Telemetry: telemetryMock,
+	})
+	stateService.UseMemDB()
+
+	err := stateService.Initialise(&genesis, &genesisHeader, &trie)
+	require.NoError(t, err)
+
+	inMemoryDB, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+		DataDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	epochState, err := state.NewEpochStateFromGenesis(inMemoryDB, stateService.Block, epochBABEConfig)
+	require.NoError(t, err)
+
+	onBlockImportDigestHandler := digest.NewBlockImportHandler(epochState)
+	verificationManager := NewVerificationManager(stateService.Block, stateService.Slot, epochState)
+
+	// newBlock returns a block built on the parent given at the slot given by the key pair given,
+	// as a secondary plain slot author of a single authority, announcing the next epoch authority.
+	newBlock := func(parent *types.Header, slot uint64, keyPair *sr25519.Keypair,
+		nextAuthority *types.AuthorityRaw) *types.Header {
+		preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, slot).ToPreRuntimeDigest()
+		require.NoError(t, err)
+
+		digest := types.NewDigest()
+		require.NoError(t, digest.Add(*preRuntimeDigest))
+
+		if nextAuthority != nil {
+			nextEpochDigest := types.NewBabeConsensusDigest()
+			require.NoError(t, nextEpochDigest.Set(types.NextEpochData{
+				Authorities: []types.AuthorityRaw{*nextAuthority},
+			}))
+			nextEpochData, err := scale.Marshal(nextEpochDigest)
+			require.NoError(t, err)
+			require.NoError(t, digest.Add(types.ConsensusDigest{
+				ConsensusEngineID: types.BabeEngineID,
+				Data:              nextEpochData,
+			}))
+		}
+
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     parent.Number + 1,
+			Digest:     digest,
+		}
+		hash := encodeAndHashHeader(t, header)
+		signAndAddSeal(t, keyPair, header, hash[:])
+		return header
+	}
+
+	importBlock := func(header *types.Header) {
+		err := stateService.Block.AddBlock(&types.Block{
+			Header: *header,
+			Body:   *types.NewBody([]types.Extrinsic{}),
+		})
+		require.NoError(t, err)
+
+		err = onBlockImportDigestHandler.Handle(header)
+		require.NoError(t, err)
+	}
+
+	// Alice authors epoch 0 and announces Bob for epoch 1,
+	// Bob authors epoch 1 and announces Charlie for epoch 2.
+	block1 := newBlock(&genesisHeader, 1, keyring.KeyAlice, &authorities[1])
+	require.NoError(t, verificationManager.VerifyBlock(block1))
+	importBlock(block1)
+
+	block2 := newBlock(block1, 15, keyring.KeyBob, &authorities[2])
+	require.NoError(t, verificationManager.VerifyBlock(block2))
+	importBlock(block2)
+
+	// no block is produced in epochs 2 and 3, so the block of epoch 4 is verified
+	// with the epoch data announced for epoch 2 by the last block before the stall.
+	block3 := newBlock(block2, 45, keyring.KeyCharlie, nil)
+	require.NoError(t, verificationManager.VerifyBlock(block3))
+
+	block3ByBob := newBlock(block2, 46, keyring.KeyBob, nil)
+	err = verificationManager.VerifyBlock(block3ByBob)
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	// epoch 3 is not skipped for a block built on a block of epoch 2,
+	// and no epoch data was announced for it.
+	block3Fork := newBlock(block2, 25, keyring.KeyCharlie, nil)
+	require.NoError(t, verificationManager.VerifyBlock(block3Fork))
+	importBlock(block3Fork)
+
+	block4Fork := newBlock(block3Fork, 35, keyring.KeyCharlie, nil)
+	err = verificationManager.VerifyBlock(block4Fork)
+	require.ErrorIs(t, err, state.ErrEpochNotInMemory)
+}
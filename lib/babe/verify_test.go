@@ -14,6 +14,7 @@
 	"github.com/ChainSafe/gossamer/lib/babe/mocks"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/utils"
 	"github.com/ChainSafe/gossamer/pkg/scale"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -836,6 +837,11 @@ func Test_verifyBlockEquivocation(t *testing.T) {
 					CheckEquivocation(gomock.Any(), uint64(1),
 						defaultHeader, expectedAuthorityId).
 					Return(mockedEquivocationProof, nil)
+				mockSlotState.
+					EXPECT().
+					StoreEquivocation(uint64(1), uint32(0),
+						&mockedEquivocationProof.FirstHeader, &mockedEquivocationProof.SecondHeader).
+					Return(true, nil)
 
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().GenesisHash().Return(common.Hash([32]byte{}))
@@ -867,6 +873,50 @@ func Test_verifyBlockEquivocation(t *testing.T) {
 				}
 			},
 		},
+		"equivocation_already_stored": {
+			expected: true,
+			header:   defaultHeader,
+			buildVerifier: func(t *testing.T) *verifier {
+				ctrl := gomock.NewController(t)
+
+				expectedAuthorityId := types.AuthorityID(kp.Public().Encode())
+
+				mockedEquivocationProof := &types.BabeEquivocationProof{
+					Offender:     expectedAuthorityId,
+					Slot:         1,
+					FirstHeader:  *defaultHeader,
+					SecondHeader: *types.NewEmptyHeader(),
+				}
+
+				mockSlotState := NewMockSlotState(ctrl)
+				mockSlotState.
+					EXPECT().
+					CheckEquivocation(gomock.Any(), uint64(1),
+						defaultHeader, expectedAuthorityId).
+					Return(mockedEquivocationProof, nil)
+				// the equivocation is not submitted again
+				mockSlotState.
+					EXPECT().
+					StoreEquivocation(uint64(1), uint32(0),
+						&mockedEquivocationProof.FirstHeader, &mockedEquivocationProof.SecondHeader).
+					Return(false, nil)
+
+				mockBlockState := NewMockBlockState(ctrl)
+				mockBlockState.EXPECT().GenesisHash().Return(common.Hash([32]byte{}))
+
+				return &verifier{
+					authorities: []types.Authority{
+						{
+							Key:    kp.Public(),
+							Weight: 1,
+						},
+					},
+					blockState:   mockBlockState,
+					slotState:    mockSlotState,
+					slotDuration: 6 * time.Second,
+				}
+			},
+		},
 		"failed_to_get_runtime_while_submiting_equivocation": {
 			header:    defaultHeader,
 			wantErr:   getRuntimeErr,
@@ -893,6 +943,11 @@ func Test_verifyBlockEquivocation(t *testing.T) {
 					CheckEquivocation(gomock.Any(), uint64(1),
 						defaultHeader, expectedAuthorityId).
 					Return(mockedEquivocationProof, nil)
+				mockSlotState.
+					EXPECT().
+					StoreEquivocation(uint64(1), uint32(0),
+						&mockedEquivocationProof.FirstHeader, &mockedEquivocationProof.SecondHeader).
+					Return(true, nil)
 
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().GenesisHash().Return(common.Hash([32]byte{}))
@@ -1060,6 +1115,9 @@ func Test_verifier_verifyAuthorshipRightEquivocatory(t *testing.T) {
 				mockSlotState.EXPECT().
 					CheckEquivocation(gomock.Any(), slot, header, offenderPublicKey).
 					Return(equivocationProof, nil)
+				mockSlotState.EXPECT().
+					StoreEquivocation(slot, uint32(1), &equivocationProof.FirstHeader, &equivocationProof.SecondHeader).
+					Return(true, nil)
 
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().GenesisHash().Return(common.Hash([32]byte{}))
@@ -1121,6 +1179,9 @@ func Test_verifier_verifyAuthorshipRightEquivocatory(t *testing.T) {
 				mockSlotState.EXPECT().
 					CheckEquivocation(gomock.Any(), slot, header, offenderPublicKey).
 					Return(equivocationProof, nil)
+				mockSlotState.EXPECT().
+					StoreEquivocation(slot, uint32(1), &equivocationProof.FirstHeader, &equivocationProof.SecondHeader).
+					Return(true, nil)
 
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().GenesisHash().Return(common.Hash([32]byte{}))
@@ -1191,6 +1252,9 @@ func Test_verifier_verifyAuthorshipRightEquivocatory(t *testing.T) {
 				mockSlotState.EXPECT().
 					CheckEquivocation(gomock.Any(), slot, header, offenderPublicKey).
 					Return(equivocationProof, nil)
+				mockSlotState.EXPECT().
+					StoreEquivocation(slot, uint32(1), &equivocationProof.FirstHeader, &equivocationProof.SecondHeader).
+					Return(true, nil)
 
 				mockBlockState := NewMockBlockState(ctrl)
 				mockBlockState.EXPECT().GenesisHash().Return(common.Hash([32]byte{}))
@@ -1234,6 +1298,100 @@ func Test_verifier_verifyAuthorshipRightEquivocatory(t *testing.T) {
 	}
 }
 
+func Test_verifier_verifyAuthorshipRight_equivocationStoredOnce(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	kp, err := sr25519.GenerateKeypair()
+	require.NoError(t, err)
+	offender := types.AuthorityID(kp.Public().Encode())
+	slot := getCurrentSlot(testSlotDuration)
+
+	newHeader := func(parentHash common.Hash) *types.Header {
+		digest := types.BabeSecondaryPlainPreDigest{
+			AuthorityIndex: 0,
+			SlotNumber:     slot,
+		}
+		preRuntimeDigest, err := digest.ToPreRuntimeDigest()
+		require.NoError(t, err)
+
+		header := newTestHeader(t, *preRuntimeDigest)
+		header.ParentHash = parentHash
+		headerHash := header.Hash()
+		signAndAddSeal(t, kp, header, headerHash[:])
+		return header
+	}
+	firstHeader := newHeader(common.Hash{1})
+	secondHeader := newHeader(common.Hash{2})
+
+	mockRuntime := mocks.NewMockInstance(ctrl)
+	mockRuntime.EXPECT().
+		BabeGenerateKeyOwnershipProof(slot, offender).
+		Return(testKeyOwnershipProof, nil)
+	mockRuntime.EXPECT().
+		BabeSubmitReportEquivocationUnsignedExtrinsic(gomock.Any(), testKeyOwnershipProof).
+		Return(nil)
+
+	mockBlockState := NewMockBlockState(ctrl)
+	mockBlockState.EXPECT().GenesisHash().Return(common.Hash{}).AnyTimes()
+	mockBlockState.EXPECT().BestBlockHash().Return(common.Hash{3})
+	mockBlockState.EXPECT().GetRuntime(common.Hash{3}).Return(mockRuntime, nil)
+
+	info := &verifierInfo{
+		authorities:    []types.Authority{*types.NewAuthority(kp.Public(), 1)},
+		threshold:      scale.MaxUint128,
+		secondarySlots: true,
+	}
+
+	dataDir := t.TempDir()
+	db, err := utils.SetupDatabase(dataDir, false)
+	require.NoError(t, err)
+	verifier := newVerifier(mockBlockState, state.NewSlotState(db), 1, info, testSlotDuration)
+
+	err = verifier.verifyAuthorshipRight(firstHeader)
+	require.NoError(t, err)
+	err = verifier.verifyAuthorshipRight(secondHeader)
+	require.ErrorIs(t, err, ErrProducerEquivocated)
+
+	// verifying the equivocating header again after reopening the database
+	// detects the equivocation, which is neither stored nor submitted again.
+	err = db.Close()
+	require.NoError(t, err)
+	db, err = utils.SetupDatabase(dataDir, false)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := db.Close()
+		require.NoError(t, err)
+	})
+
+	slotState := state.NewSlotState(db)
+	verifier = newVerifier(mockBlockState, slotState, 1, info, testSlotDuration)
+	err = verifier.verifyAuthorshipRight(secondHeader)
+	require.ErrorIs(t, err, ErrProducerEquivocated)
+
+	proofs, err := slotState.GetUnreportedEquivocations()
+	require.NoError(t, err)
+	require.Len(t, proofs, 1)
+	assert.Equal(t, offender, proofs[0].Offender)
+	assert.Equal(t, slot, proofs[0].Slot)
+
+	// the equivocation is no longer unreported once
+	// its report is included in an imported block
+	encodedProof, err := scale.Marshal(proofs[0])
+	require.NoError(t, err)
+	reportExtrinsic := append([]byte{1, 2, 3}, encodedProof...)
+	reporter := NewEquivocationReporter(mockBlockState, slotState)
+	err = reporter.handleBlock(&types.Block{
+		Header: *types.NewEmptyHeader(),
+		Body:   types.Body{{4}, reportExtrinsic},
+	})
+	require.NoError(t, err)
+
+	proofs, err = slotState.GetUnreportedEquivocations()
+	require.NoError(t, err)
+	assert.Empty(t, proofs)
+}
+
 func TestVerificationManager_getVerifierInfo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockEpochStateGetErr := NewMockEpochState(ctrl)
@@ -1243,19 +1401,19 @@ func TestVerificationManager_getVerifierInfo(t *testing.T) {
 
 	testHeader := types.NewEmptyHeader()
 
-	mockEpochStateGetErr.EXPECT().GetEpochData(uint64(0), testHeader).Return(nil, state.ErrEpochNotInMemory)
+	mockEpochStateGetErr.EXPECT().GetEpochDataForBlock(uint64(0), testHeader).Return(nil, state.ErrEpochNotInMemory)
 
-	mockEpochStateHasErr.EXPECT().GetEpochData(uint64(0), testHeader).Return(&types.EpochData{}, nil)
+	mockEpochStateHasErr.EXPECT().GetEpochDataForBlock(uint64(0), testHeader).Return(&types.EpochData{}, nil)
 	mockEpochStateHasErr.EXPECT().GetConfigData(uint64(0), testHeader).Return(&types.ConfigData{}, state.ErrConfigNotFound)
 
-	mockEpochStateThresholdErr.EXPECT().GetEpochData(uint64(0), testHeader).Return(&types.EpochData{}, nil)
+	mockEpochStateThresholdErr.EXPECT().GetEpochDataForBlock(uint64(0), testHeader).Return(&types.EpochData{}, nil)
 	mockEpochStateThresholdErr.EXPECT().GetConfigData(uint64(0), testHeader).
 		Return(&types.ConfigData{
 			C1: 3,
 			C2: 1,
 		}, nil)
 
-	mockEpochStateOk.EXPECT().GetEpochData(uint64(0), testHeader).Return(&types.EpochData{}, nil)
+	mockEpochStateOk.EXPECT().GetEpochDataForBlock(uint64(0), testHeader).Return(&types.EpochData{}, nil)
 	mockEpochStateOk.EXPECT().GetConfigData(uint64(0), testHeader).
 		Return(&types.ConfigData{
 			C1: 1,
@@ -1352,16 +1510,18 @@ func TestVerificationManager_VerifyBlock(t *testing.T) {
 
 	mockEpochStateSkipVerifyErr.EXPECT().GetEpochForBlock(testBlockHeaderEmpty).Return(uint64(1), nil)
 	errTestGetEpochData := errors.New("test get epoch data error")
-	mockEpochStateSkipVerifyErr.EXPECT().GetEpochData(uint64(1), testBlockHeaderEmpty).Return(nil, errTestGetEpochData)
+	mockEpochStateSkipVerifyErr.EXPECT().GetEpochDataForBlock(uint64(1), testBlockHeaderEmpty).
+		Return(nil, errTestGetEpochData)
 	errTestSkipVerify := errors.New("test skip verify error")
 	mockEpochStateSkipVerifyErr.EXPECT().SkipVerify(testBlockHeaderEmpty).Return(false, errTestSkipVerify)
 
 	mockEpochStateSkipVerifyTrue.EXPECT().GetEpochForBlock(testBlockHeaderEmpty).Return(uint64(1), nil)
-	mockEpochStateSkipVerifyTrue.EXPECT().GetEpochData(uint64(1), testBlockHeaderEmpty).Return(nil, errTestGetEpochData)
+	mockEpochStateSkipVerifyTrue.EXPECT().GetEpochDataForBlock(uint64(1), testBlockHeaderEmpty).
+		Return(nil, errTestGetEpochData)
 	mockEpochStateSkipVerifyTrue.EXPECT().SkipVerify(testBlockHeaderEmpty).Return(true, nil)
 
 	mockEpochStateGetVerifierInfoErr.EXPECT().GetEpochForBlock(testBlockHeaderEmpty).Return(uint64(1), nil)
-	mockEpochStateGetVerifierInfoErr.EXPECT().GetEpochData(uint64(1), testBlockHeaderEmpty).
+	mockEpochStateGetVerifierInfoErr.EXPECT().GetEpochDataForBlock(uint64(1), testBlockHeaderEmpty).
 		Return(nil, errTestGetEpochData)
 	mockEpochStateGetVerifierInfoErr.EXPECT().SkipVerify(testBlockHeaderEmpty).Return(false, nil)
 
@@ -1468,6 +1628,69 @@ func TestVerificationManager_VerifyBlock(t *testing.T) {
 	}
 }
 
+func TestVerificationManager_verifySlotArrival(t *testing.T) {
+	t.Parallel()
+
+	const slotDuration = 6 * time.Second
+	slotTime := func(slot int64) time.Time {
+		return time.Unix(0, slot*slotDuration.Nanoseconds())
+	}
+
+	encodedDigest := newEncodedBabeDigest(t, types.BabeSecondaryPlainPreDigest{SlotNumber: 10})
+	header := newTestHeader(t, *types.NewBABEPreRuntimeDigest(encodedDigest))
+	errTest := errors.New("test error")
+
+	testCases := map[string]struct {
+		arrivalTime time.Time
+		arrivalErr  error
+		errWrapped  error
+		errMessage  string
+	}{
+		"arrival_time_not_found": {
+			arrivalErr: state.ErrArrivalTimeNotFound,
+		},
+		"arrival_time_error": {
+			arrivalErr: errTest,
+			errWrapped: errTest,
+			errMessage: "getting arrival time: test error",
+		},
+		"received_during_slot": {
+			arrivalTime: slotTime(10).Add(time.Second),
+		},
+		"received_after_slot": {
+			arrivalTime: slotTime(12),
+		},
+		"received_during_previous_slot": {
+			arrivalTime: slotTime(9),
+		},
+		"received_before_previous_slot": {
+			arrivalTime: slotTime(9).Add(-time.Nanosecond),
+			errWrapped:  ErrBlockFromFuture,
+			errMessage:  "block received before its slot: slot 10 received at slot 8",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			blockState := NewMockBlockState(ctrl)
+			blockState.EXPECT().GetArrivalTime(header.Hash()).
+				Return(testCase.arrivalTime, testCase.arrivalErr)
+			verificationManager := &VerificationManager{blockState: blockState}
+
+			err := verificationManager.verifySlotArrival(header, slotDuration)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
 func TestVerificationManager_SetOnDisabled(t *testing.T) {
 	//Generate keys
 	kp, err := sr25519.GenerateKeypair()
@@ -1495,7 +1718,8 @@ func TestVerificationManager_SetOnDisabled(t *testing.T) {
 
 	mockEpochStateGetEpochDataErr.EXPECT().GetEpochForBlock(types.NewEmptyHeader()).Return(uint64(0), nil)
 	errTestGetEpochData := errors.New("test get epoch data error")
-	mockEpochStateGetEpochDataErr.EXPECT().GetEpochData(uint64(0), types.NewEmptyHeader()).Return(nil, errTestGetEpochData)
+	mockEpochStateGetEpochDataErr.EXPECT().GetEpochDataForBlock(uint64(0), types.NewEmptyHeader()).
+		Return(nil, errTestGetEpochData)
 
 	mockEpochStateIndexLenErr.EXPECT().GetEpochForBlock(types.NewEmptyHeader()).Return(uint64(2), nil)
 
@@ -27,6 +27,16 @@
 		Name:      "leaves_total",
 		Help:      "total number of blocktree leaves",
 	})
+	bestBlockNumberGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gossamer_block",
+		Name:      "best_number",
+		Help:      "number of the best block of the blocktree",
+	})
+	finalisedBlockNumberGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gossamer_block",
+		Name:      "finalised_number",
+		Help:      "number of the last finalised block, which is the root of the blocktree",
+	})
 	inMemoryRuntimesGauge = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "gossamer_blocktree",
 		Name:      "runtimes_total",
@@ -67,6 +77,9 @@ func NewBlockTreeFromRoot(root *types.Header) *BlockTree {
 		arrivalTime: time.Now(),
 	}
 
+	bestBlockNumberGauge.Set(float64(n.number))
+	finalisedBlockNumberGauge.Set(float64(n.number))
+
 	return &BlockTree{
 		root:     n,
 		leaves:   newLeafMap(n),
@@ -117,6 +130,115 @@ func (bt *BlockTree) AddBlock(header *types.Header, arrivalTime time.Time) (err
 	bt.leaves.replace(parent, n)
 
 	leavesGauge.Set(float64(len(bt.leaves.nodes())))
+	bestBlockNumberGauge.Set(float64(bt.best().number))
+	return nil
+}
+
+// AddBlocks adds the block headers given to the blocktree, in the order given
+// such that a block can be the child of a block preceding it in the slice.
+// The headers are all checked before adding any of them, such that either all
+// the blocks are added or none is added, and readers of the blocktree never
+// observe only some of the blocks added.
+func (bt *BlockTree) AddBlocks(headers []*types.Header, arrivalTime time.Time) (err error) {
+	bt.Lock()
+	defer bt.Unlock()
+
+	added := make(map[Hash]*node, len(headers))
+	nodes := make([]*node, len(headers))
+	for i, header := range headers {
+		hash := header.Hash()
+		parent := added[header.ParentHash]
+		if parent == nil {
+			parent = bt.getNode(header.ParentHash)
+		}
+		if parent == nil {
+			return fmt.Errorf("%w: for block hash %s", ErrParentNotFound, hash)
+		}
+
+		if added[hash] != nil || bt.getNode(hash) != nil {
+			return fmt.Errorf("%w: for block hash %s", ErrBlockExists, hash)
+		}
+
+		number := parent.number + 1
+		if number != header.Number {
+			return fmt.Errorf("%w: %d instead of %d for block hash %s",
+				errUnexpectedNumber, header.Number, number, hash)
+		}
+
+		isPrimary, err := types.IsPrimary(header)
+		if err != nil {
+			return fmt.Errorf("failed to check if block was primary: %w", err)
+		}
+
+		n := &node{
+			hash:        hash,
+			parent:      parent,
+			children:    []*node{},
+			number:      number,
+			arrivalTime: arrivalTime,
+			isPrimary:   isPrimary,
+		}
+		added[hash] = n
+		nodes[i] = n
+	}
+
+	for _, n := range nodes {
+		n.parent.addChild(n)
+		bt.leaves.replace(n.parent, n)
+	}
+
+	leavesGauge.Set(float64(len(bt.leaves.nodes())))
+	bestBlockNumberGauge.Set(float64(bt.best().number))
+	return nil
+}
+
+// RemoveBlocks removes the blocks given from the blocktree, undoing AddBlocks
+// for the same blocks, for example if they cannot be written to the database.
+// The blocks are all checked before removing any of them, and a block can only
+// be removed with all its children, such that either all the blocks are removed
+// or none is removed.
+func (bt *BlockTree) RemoveBlocks(hashes []Hash) (err error) {
+	bt.Lock()
+	defer bt.Unlock()
+
+	removed := make(map[Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		removed[hash] = struct{}{}
+	}
+
+	nodes := make([]*node, len(hashes))
+	for i, hash := range hashes {
+		n := bt.getNode(hash)
+		if n == nil {
+			return fmt.Errorf("%w: for block hash %s", ErrNodeNotFound, hash)
+		} else if n.parent == nil {
+			return fmt.Errorf("%w: for block hash %s", errRemoveRoot, hash)
+		}
+
+		for _, child := range n.children {
+			if _, ok := removed[child.hash]; !ok {
+				return fmt.Errorf("%w: for block hash %s with child block hash %s",
+					errChildNotRemoved, hash, child.hash)
+			}
+		}
+		nodes[i] = n
+	}
+
+	for _, n := range nodes {
+		n.parent.deleteChild(n)
+		bt.leaves.delete(n.hash)
+	}
+
+	// Parents left without children are leaves again.
+	for _, n := range nodes {
+		_, parentRemoved := removed[n.parent.hash]
+		if !parentRemoved && len(n.parent.children) == 0 {
+			bt.leaves.store(n.parent.hash, n.parent)
+		}
+	}
+
+	leavesGauge.Set(float64(len(bt.leaves.nodes())))
+	bestBlockNumberGauge.Set(float64(bt.best().number))
 	return nil
 }
 
@@ -286,7 +408,8 @@ func (bt *BlockTree) Prune(finalised Hash) (pruned []Hash) {
 		canonicalChainBlock = canonicalChainBlock.parent
 	}
 
-	bt.runtimes.onFinalisation(newCanonicalChainBlockHashes)
+	unfinalisedBlockHashes := n.getAllDescendants(nil)[1:]
+	bt.runtimes.onFinalisation(newCanonicalChainBlockHashes, unfinalisedBlockHashes)
 
 	pruned = bt.root.prune(n, nil)
 	bt.root = n
@@ -299,6 +422,8 @@ func (bt *BlockTree) Prune(finalised Hash) (pruned []Hash) {
 	}
 
 	leavesGauge.Set(float64(len(bt.leaves.nodes())))
+	bestBlockNumberGauge.Set(float64(bt.best().number))
+	finalisedBlockNumberGauge.Set(float64(n.number))
 	return pruned
 }
 
@@ -532,6 +657,13 @@ func (bt *BlockTree) StoreRuntime(hash common.Hash, instance runtime.Instance) {
 	bt.runtimes.set(hash, instance)
 }
 
+// GetRuntimeByCodeHash returns a runtime instance stored with the code
+// hash given, such that the same runtime code is not instantiated again
+// for a runtime upgrade on another fork, or nil if there is none.
+func (bt *BlockTree) GetRuntimeByCodeHash(codeHash common.Hash) (instance runtime.Instance) {
+	return bt.runtimes.getByCodeHash(codeHash)
+}
+
 // GetBlockRuntime returns the runtime corresponding to the given block hash. If there is no instance for
 // the given block hash it will lookup an instance of an ancestor and return it.
 func (bt *BlockTree) GetBlockRuntime(hash common.Hash) (runtime.Instance, error) {
@@ -82,6 +82,102 @@ func Test_BlockTree_AddBlock(t *testing.T) {
 	}
 }
 
+func Test_BlockTree_AddBlocks(t *testing.T) {
+	t.Parallel()
+
+	bt, hashes := createFlatTree(t, 1)
+
+	first := &types.Header{
+		ParentHash: hashes[1],
+		Number:     2,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	second := &types.Header{
+		ParentHash: first.Hash(),
+		Number:     3,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	fork := &types.Header{
+		ParentHash: hashes[1],
+		Number:     2,
+		Digest:     createPrimaryBABEDigest(t),
+		// different extrinsics root for a different block hash
+		ExtrinsicsRoot: common.Hash{1},
+	}
+	unknownParent := &types.Header{
+		ParentHash: common.Hash{9},
+		Number:     3,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+
+	// No block is added if one of the blocks cannot be added.
+	err := bt.AddBlocks([]*types.Header{first, second, unknownParent}, time.Unix(0, 0))
+	assert.ErrorIs(t, err, ErrParentNotFound)
+	wrongNumber := *fork
+	wrongNumber.Number = 3
+	err = bt.AddBlocks([]*types.Header{first, &wrongNumber}, time.Unix(0, 0))
+	assert.ErrorIs(t, err, errUnexpectedNumber)
+	err = bt.AddBlocks([]*types.Header{first, first}, time.Unix(0, 0))
+	assert.ErrorIs(t, err, ErrBlockExists)
+	assert.Nil(t, bt.getNode(first.Hash()))
+	assert.Equal(t, hashes[1], bt.BestBlockHash())
+
+	err = bt.AddBlocks([]*types.Header{first, fork, second}, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, second.Hash(), bt.BestBlockHash())
+	assert.ElementsMatch(t, []common.Hash{fork.Hash(), second.Hash()}, bt.Leaves())
+	assert.Equal(t, uint(3), bt.getNode(second.Hash()).number)
+
+	err = bt.AddBlocks([]*types.Header{second}, time.Unix(0, 0))
+	assert.ErrorIs(t, err, ErrBlockExists)
+}
+
+func Test_BlockTree_RemoveBlocks(t *testing.T) {
+	t.Parallel()
+
+	bt, hashes := createFlatTree(t, 1)
+
+	first := &types.Header{
+		ParentHash: hashes[1],
+		Number:     2,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	second := &types.Header{
+		ParentHash: first.Hash(),
+		Number:     3,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	fork := &types.Header{
+		ParentHash:     hashes[1],
+		Number:         2,
+		Digest:         createPrimaryBABEDigest(t),
+		ExtrinsicsRoot: common.Hash{1},
+	}
+	err := bt.AddBlocks([]*types.Header{first, second, fork}, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	// No block is removed if one of the blocks cannot be removed.
+	err = bt.RemoveBlocks([]common.Hash{fork.Hash(), first.Hash()})
+	assert.ErrorIs(t, err, errChildNotRemoved)
+	err = bt.RemoveBlocks([]common.Hash{fork.Hash(), {9}})
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+	err = bt.RemoveBlocks([]common.Hash{hashes[0]})
+	assert.ErrorIs(t, err, errRemoveRoot)
+	assert.ElementsMatch(t, []common.Hash{fork.Hash(), second.Hash()}, bt.Leaves())
+
+	err = bt.RemoveBlocks([]common.Hash{first.Hash(), second.Hash()})
+	require.NoError(t, err)
+	assert.Nil(t, bt.getNode(first.Hash()))
+	assert.Nil(t, bt.getNode(second.Hash()))
+	assert.Equal(t, []common.Hash{fork.Hash()}, bt.Leaves())
+
+	err = bt.RemoveBlocks([]common.Hash{fork.Hash()})
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{hashes[1]}, bt.Leaves())
+	assert.Equal(t, hashes[1], bt.BestBlockHash())
+}
+
 func Test_Node_isDecendantOf(t *testing.T) {
 	// Create tree with number 4 (with 4 nodes)
 	bt, hashes := createFlatTree(t, 4)
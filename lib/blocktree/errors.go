@@ -44,4 +44,6 @@
 	ErrNoCommonAncestor = errors.New("no common ancestor between two nodes")
 
 	errUnexpectedNumber = errors.New("block number is not parent number + 1")
+	errRemoveRoot       = errors.New("cannot remove root block from blocktree")
+	errChildNotRemoved  = errors.New("cannot remove block without its children")
 )
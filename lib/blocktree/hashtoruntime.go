@@ -49,10 +49,27 @@ func (h *hashToRuntime) hashes() (hashes []common.Hash) {
 	return maps.Keys(h.mapping)
 }
 
+// getByCodeHash returns a runtime instance with the code hash given,
+// or nil if no runtime instance with this code hash is stored.
+func (h *hashToRuntime) getByCodeHash(codeHash common.Hash) (instance runtime.Instance) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for _, instance := range h.mapping {
+		if instance.GetCodeHash() == codeHash {
+			return instance
+		}
+	}
+	return nil
+}
+
 // onFinalisation handles pruning and recording on block finalisation.
 // newCanonicalBlockHashes is the block hashes of the blocks newly finalised.
 // The last element is the finalised block hash.
-func (h *hashToRuntime) onFinalisation(newCanonicalBlockHashes []common.Hash) {
+// unfinalisedBlockHashes is the block hashes of the descendants of the
+// finalised block, for which runtimes are kept since they are still
+// used to import their descendants.
+func (h *hashToRuntime) onFinalisation(newCanonicalBlockHashes, unfinalisedBlockHashes []common.Hash) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
@@ -80,29 +97,45 @@ func (h *hashToRuntime) onFinalisation(newCanonicalBlockHashes []common.Hash) {
 	// we procced from backwards since the last element in the newCanonicalBlockHashes
 	// is the finalized one, verifying if there is a runtime instance closest to the finalized
 	// hash. When we find it we clear all the map entries and keeping only the instance found
-	// with the finalised hash as the key
-	lastElementIdx := len(newCanonicalBlockHashes) - 1
-	for idx := lastElementIdx; idx >= 0; idx-- {
-		currentHash := newCanonicalBlockHashes[idx]
-		inMemoryRuntime := h.mapping[currentHash]
-
-		if inMemoryRuntime != nil {
-			// stop all the running instances created by forks keeping
-			// just the closest instance to the finalized block hash
-			stoppedRuntimes := make(map[runtime.Instance]struct{})
-			for _, runtimeToPrune := range h.mapping {
-				if inMemoryRuntime != runtimeToPrune {
-					_, stopped := stoppedRuntimes[runtimeToPrune]
-					if !stopped {
-						runtimeToPrune.Stop()
-						stoppedRuntimes[runtimeToPrune] = struct{}{}
-					}
-				}
-			}
-
-			h.mapping = make(map[Hash]runtime.Instance)
-			h.mapping[finalisedHash] = inMemoryRuntime
-			return
+	// with the finalised hash as the key, and the instances of the unfinalised blocks.
+	var finalisedRuntime runtime.Instance
+	for idx := len(newCanonicalBlockHashes) - 1; idx >= 0; idx-- {
+		finalisedRuntime = h.mapping[newCanonicalBlockHashes[idx]]
+		if finalisedRuntime != nil {
+			break
+		}
+	}
+
+	if finalisedRuntime == nil {
+		return
+	}
+
+	mapping := make(map[Hash]runtime.Instance)
+	mapping[finalisedHash] = finalisedRuntime
+	for _, hash := range unfinalisedBlockHashes {
+		instance := h.mapping[hash]
+		if instance != nil {
+			mapping[hash] = instance
 		}
 	}
+
+	// stop all the running instances created by forks and by the
+	// newly finalised chain which are no longer used. The same
+	// instance can be stored for multiple block hashes.
+	keptRuntimes := make(map[runtime.Instance]struct{}, len(mapping))
+	for _, instance := range mapping {
+		keptRuntimes[instance] = struct{}{}
+	}
+	stoppedRuntimes := make(map[runtime.Instance]struct{})
+	for _, runtimeToPrune := range h.mapping {
+		_, kept := keptRuntimes[runtimeToPrune]
+		_, stopped := stoppedRuntimes[runtimeToPrune]
+		if kept || stopped {
+			continue
+		}
+		runtimeToPrune.Stop()
+		stoppedRuntimes[runtimeToPrune] = struct{}{}
+	}
+
+	h.mapping = mapping
 }
@@ -86,6 +86,27 @@ func Test_hashToRuntime_hashes(t *testing.T) {
 	assert.ElementsMatch(t, expectedHashes, hashes)
 }
 
+func Test_hashToRuntime_getByCodeHash(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	instance := NewMockInstance(ctrl)
+	instance.EXPECT().GetCodeHash().Return(common.Hash{2}).AnyTimes()
+	otherInstance := NewMockInstance(ctrl)
+	otherInstance.EXPECT().GetCodeHash().Return(common.Hash{1}).AnyTimes()
+
+	htr := &hashToRuntime{
+		mapping: map[Hash]runtime.Instance{
+			{1}: otherInstance,
+			{2}: instance,
+			{3}: instance,
+		},
+	}
+
+	assert.Same(t, instance, htr.getByCodeHash(common.Hash{2}))
+	assert.Nil(t, htr.getByCodeHash(common.Hash{3}))
+}
+
 func Test_hashToRuntime_set(t *testing.T) {
 	t.Parallel()
 
@@ -187,6 +208,7 @@ func Test_hashToRuntime_onFinalisation(t *testing.T) {
 	testCases := map[string]struct {
 		makeParameters          func(ctrl *gomock.Controller) (initial, expected *hashToRuntime)
 		newCanonicalBlockHashes []Hash
+		unfinalisedBlockHashes  []Hash
 	}{
 		"new_finalised_runtime_not_found": {
 			makeParameters: func(ctrl *gomock.Controller) (initial, expected *hashToRuntime) {
@@ -282,6 +304,41 @@ func Test_hashToRuntime_onFinalisation(t *testing.T) {
 			},
 			newCanonicalBlockHashes: []Hash{{2}, {3}, {4}, {5}, {6}},
 		},
+		"keep_unfinalised_runtimes": {
+			makeParameters: func(ctrl *gomock.Controller) (initial, expected *hashToRuntime) {
+				finalisedRuntime := NewMockInstance(ctrl)
+				newFinalisedRuntime := NewMockInstance(ctrl)
+				upgradedRuntime := NewMockInstance(ctrl)
+				prunedForkRuntime := NewMockInstance(ctrl)
+
+				finalisedRuntime.EXPECT().Stop()
+				prunedForkRuntime.EXPECT().Stop()
+
+				initial = &hashToRuntime{
+					mapping: map[Hash]runtime.Instance{
+						{0}: finalisedRuntime,
+						{1}: newFinalisedRuntime,
+						// Runtime upgrades of blocks descending from the
+						// finalised block, the upgraded runtime being
+						// also used by a pruned fork.
+						{3}:   upgradedRuntime,
+						{4}:   newFinalisedRuntime,
+						{100}: upgradedRuntime,
+						{101}: prunedForkRuntime,
+					},
+				}
+				expected = &hashToRuntime{
+					mapping: map[Hash]runtime.Instance{
+						{2}: newFinalisedRuntime,
+						{3}: upgradedRuntime,
+						{4}: newFinalisedRuntime,
+					},
+				}
+				return initial, expected
+			},
+			newCanonicalBlockHashes: []Hash{{1}, {2}},
+			unfinalisedBlockHashes:  []Hash{{3}, {4}, {5}},
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -291,7 +348,7 @@ func Test_hashToRuntime_onFinalisation(t *testing.T) {
 			ctrl := gomock.NewController(t)
 
 			htr, expectedHtr := testCase.makeParameters(ctrl)
-			htr.onFinalisation(testCase.newCanonicalBlockHashes)
+			htr.onFinalisation(testCase.newCanonicalBlockHashes, testCase.unfinalisedBlockHashes)
 
 			assert.Equal(t, expectedHtr, htr)
 		})
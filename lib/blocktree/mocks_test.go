@@ -6,6 +6,7 @@
 
 import (
 	reflect "reflect"
+	time "time"
 
 	types "github.com/ChainSafe/gossamer/dot/types"
 	common "github.com/ChainSafe/gossamer/lib/common"
@@ -99,15 +100,18 @@ func (mr *MockInstanceMockRecorder) BabeSubmitReportEquivocationUnsignedExtrinsi
 }
 
 // CheckInherents mocks base method.
-func (m *MockInstance) CheckInherents() {
+func (m *MockInstance) CheckInherents(arg0 *types.Block, arg1 []byte) (*types.CheckInherentsResult, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "CheckInherents")
+	ret := m.ctrl.Call(m, "CheckInherents", arg0, arg1)
+	ret0, _ := ret[0].(*types.CheckInherentsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CheckInherents indicates an expected call of CheckInherents.
-func (mr *MockInstanceMockRecorder) CheckInherents() *gomock.Call {
+func (mr *MockInstanceMockRecorder) CheckInherents(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckInherents", reflect.TypeOf((*MockInstance)(nil).CheckInherents))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckInherents", reflect.TypeOf((*MockInstance)(nil).CheckInherents), arg0, arg1)
 }
 
 // DecodeSessionKeys mocks base method.
@@ -140,6 +144,21 @@ func (mr *MockInstanceMockRecorder) Exec(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockInstance)(nil).Exec), arg0, arg1)
 }
 
+// ExecUntrusted mocks base method.
+func (m *MockInstance) ExecUntrusted(arg0 string, arg1 []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecUntrusted", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecUntrusted indicates an expected call of ExecUntrusted.
+func (mr *MockInstanceMockRecorder) ExecUntrusted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecUntrusted", reflect.TypeOf((*MockInstance)(nil).ExecUntrusted), arg0, arg1)
+}
+
 // ExecuteBlock mocks base method.
 func (m *MockInstance) ExecuteBlock(arg0 *types.Block) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -327,15 +346,17 @@ func (mr *MockInstanceMockRecorder) NodeStorage() *gomock.Call {
 }
 
 // OffchainWorker mocks base method.
-func (m *MockInstance) OffchainWorker() {
+func (m *MockInstance) OffchainWorker(arg0 *types.Header) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OffchainWorker")
+	ret := m.ctrl.Call(m, "OffchainWorker", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // OffchainWorker indicates an expected call of OffchainWorker.
-func (mr *MockInstanceMockRecorder) OffchainWorker() *gomock.Call {
+func (mr *MockInstanceMockRecorder) OffchainWorker(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OffchainWorker", reflect.TypeOf((*MockInstance)(nil).OffchainWorker))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OffchainWorker", reflect.TypeOf((*MockInstance)(nil).OffchainWorker), arg0)
 }
 
 // PaymentQueryInfo mocks base method.
@@ -389,6 +410,20 @@ func (mr *MockInstanceMockRecorder) Stop() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockInstance)(nil).Stop))
 }
 
+// UntrustedCallTimeout mocks base method.
+func (m *MockInstance) UntrustedCallTimeout() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UntrustedCallTimeout")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// UntrustedCallTimeout indicates an expected call of UntrustedCallTimeout.
+func (mr *MockInstanceMockRecorder) UntrustedCallTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UntrustedCallTimeout", reflect.TypeOf((*MockInstance)(nil).UntrustedCallTimeout))
+}
+
 // ValidateTransaction mocks base method.
 func (m *MockInstance) ValidateTransaction(arg0 types.Extrinsic) (*transaction.Validity, error) {
 	m.ctrl.T.Helper()
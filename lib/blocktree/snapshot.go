@@ -0,0 +1,110 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package blocktree
+
+import (
+	"fmt"
+	"time"
+)
+
+// Snapshot is a copy of the nodes of the block tree which can be SCALE
+// encoded to persist the block tree, and used to restore it on restart.
+type Snapshot struct {
+	// Nodes are the nodes of the block tree, ordered such that
+	// each parent node is before its children nodes, and the
+	// children of a node are in the order they were added.
+	Nodes []SnapshotNode
+	// BestBlockHash is the best block hash of the block tree.
+	BestBlockHash Hash
+}
+
+// SnapshotNode is a node of a block tree snapshot.
+type SnapshotNode struct {
+	Hash       Hash
+	ParentHash Hash
+	Number     uint
+	// ArrivalTime is the arrival time of the block in nanoseconds since the Unix epoch.
+	ArrivalTime int64
+	IsPrimary   bool
+}
+
+// Snapshot returns a snapshot of the nodes of the block tree.
+func (bt *BlockTree) Snapshot() (snapshot Snapshot) {
+	bt.RLock()
+	defer bt.RUnlock()
+
+	if bt.root == nil {
+		return snapshot
+	}
+
+	var appendNodes func(n *node)
+	appendNodes = func(n *node) {
+		snapshotNode := SnapshotNode{
+			Hash:        n.hash,
+			Number:      n.number,
+			ArrivalTime: n.arrivalTime.UnixNano(),
+			IsPrimary:   n.isPrimary,
+		}
+		if n.parent != nil {
+			snapshotNode.ParentHash = n.parent.hash
+		}
+		snapshot.Nodes = append(snapshot.Nodes, snapshotNode)
+
+		for _, child := range n.children {
+			appendNodes(child)
+		}
+	}
+	appendNodes(bt.root)
+
+	snapshot.BestBlockHash = bt.best().hash
+	return snapshot
+}
+
+// Restore adds the nodes of the snapshot given descending from the root
+// of the block tree. The exists function is called for each node to add,
+// and the node is discarded if it returns false. Nodes already in the block
+// tree and nodes with a parent not in the block tree are discarded as well,
+// such that the descendants of a discarded node are always discarded.
+// It returns the hashes of the nodes discarded, excluding the nodes already
+// in the block tree.
+func (bt *BlockTree) Restore(snapshot Snapshot, exists func(hash Hash) (bool, error)) (
+	discarded []Hash, err error) {
+	bt.Lock()
+	defer bt.Unlock()
+
+	for _, snapshotNode := range snapshot.Nodes {
+		if bt.getNode(snapshotNode.Hash) != nil {
+			continue
+		}
+
+		parent := bt.getNode(snapshotNode.ParentHash)
+		if parent == nil || parent.number+1 != snapshotNode.Number {
+			discarded = append(discarded, snapshotNode.Hash)
+			continue
+		}
+
+		ok, err := exists(snapshotNode.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("checking block hash %s exists: %w", snapshotNode.Hash, err)
+		} else if !ok {
+			discarded = append(discarded, snapshotNode.Hash)
+			continue
+		}
+
+		n := &node{
+			hash:        snapshotNode.Hash,
+			parent:      parent,
+			children:    []*node{},
+			number:      snapshotNode.Number,
+			arrivalTime: time.Unix(0, snapshotNode.ArrivalTime),
+			isPrimary:   snapshotNode.IsPrimary,
+		}
+		parent.addChild(n)
+		bt.leaves.replace(parent, n)
+	}
+
+	leavesGauge.Set(float64(len(bt.leaves.nodes())))
+	bestBlockNumberGauge.Set(float64(bt.best().number))
+	return discarded, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package blocktree
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BlockTree_Snapshot_Restore(t *testing.T) {
+	t.Parallel()
+
+	bt, _ := createTestBlockTree(t, testHeader, 8)
+
+	// The longest chain is authored in secondary slots, so the best block
+	// is only selected correctly if the primary flags are restored.
+	secondaryDigest := types.NewDigest()
+	preRuntimeDigest, err := types.NewBabeSecondaryPlainPreDigest(0, 1).ToPreRuntimeDigest()
+	require.NoError(t, err)
+	err = secondaryDigest.Add(*preRuntimeDigest)
+	require.NoError(t, err)
+	parentHash := bt.root.hash
+	for number := uint(1); number <= 10; number++ {
+		header := &types.Header{
+			ParentHash: parentHash,
+			Number:     number,
+			StateRoot:  common.Hash{0x2},
+			Digest:     secondaryDigest,
+		}
+		err = bt.AddBlock(header, time.Unix(0, int64(number)))
+		require.NoError(t, err)
+		parentHash = header.Hash()
+	}
+
+	encoded, err := scale.Marshal(bt.Snapshot())
+	require.NoError(t, err)
+	var snapshot Snapshot
+	err = scale.Unmarshal(encoded, &snapshot)
+	require.NoError(t, err)
+
+	restored := NewBlockTreeFromRoot(testHeader)
+	restored.root.arrivalTime = bt.root.arrivalTime
+	discarded, err := restored.Restore(snapshot, func(Hash) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.Empty(t, discarded)
+
+	equalNodeValue(t, bt.root, restored.root)
+	equalLeaves(t, bt.leaves, restored.leaves)
+	assert.ElementsMatch(t, bt.Leaves(), restored.Leaves())
+	assert.Equal(t, bt.BestBlockHash(), restored.BestBlockHash())
+	assert.Equal(t, snapshot.BestBlockHash, restored.BestBlockHash())
+	assert.NotEqual(t, parentHash, restored.BestBlockHash())
+}
+
+func Test_BlockTree_Restore_discarded(t *testing.T) {
+	t.Parallel()
+
+	bt, hashes := createFlatTree(t, 4)
+	snapshot := bt.Snapshot()
+
+	// The snapshot has a node which is not a descendant of the root,
+	// and a node with an unexpected number.
+	snapshot.Nodes = append(snapshot.Nodes,
+		SnapshotNode{Hash: common.Hash{0xa}, ParentHash: common.Hash{0xb}, Number: 1},
+		SnapshotNode{Hash: common.Hash{0xc}, ParentHash: hashes[1], Number: 3},
+	)
+
+	rootHeader := &types.Header{
+		ParentHash: zeroHash,
+		Digest:     createPrimaryBABEDigest(t),
+	}
+	restored := NewBlockTreeFromRoot(rootHeader)
+
+	// The header of block 2 no longer exists, so blocks 2 to 4 are discarded.
+	exists := func(hash Hash) (bool, error) {
+		return hash != hashes[2], nil
+	}
+	discarded, err := restored.Restore(snapshot, exists)
+	require.NoError(t, err)
+	expectedDiscarded := []Hash{hashes[2], hashes[3], hashes[4], {0xa}, {0xc}}
+	assert.Equal(t, expectedDiscarded, discarded)
+	assert.Equal(t, []Hash{hashes[1]}, restored.Leaves())
+	assert.Equal(t, hashes[1], restored.BestBlockHash())
+
+	errTest := errors.New("test error")
+	_, err = NewBlockTreeFromRoot(rootHeader).Restore(bt.Snapshot(),
+		func(Hash) (bool, error) { return false, errTest })
+	assert.ErrorIs(t, err, errTest)
+}
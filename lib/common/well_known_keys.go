@@ -3,11 +3,45 @@
 
 package common
 
+import "fmt"
+
 var (
 	// CodeKey is the key where runtime code is stored in the trie
 	CodeKey = []byte(":code")
 
+	// HeapPagesKey is the key where the number of heap pages
+	// of the runtime is stored in the trie, if it is set
+	HeapPagesKey = []byte(":heappages")
+
+	// GrandpaAuthoritiesKey is the key where the versioned GRANDPA
+	// authorities of the genesis block are stored in the trie
+	GrandpaAuthoritiesKey = []byte(":grandpa_authorities")
+
 	// UpgradedToDualRefKey is set to true (0x01) if the account format has been upgraded to v0.9
 	// it's set to empty or false (0x00) otherwise
 	UpgradedToDualRefKey = MustHexToBytes("0x26aa394eea5630e07c48ae0c9558cef7c21aab032aaa6e946ca50ad39ab66603")
+
+	// SystemEventsKey is the key where the events deposited in the current block
+	// are stored, which is Twox128Hash("System") + Twox128Hash("Events")
+	SystemEventsKey = MustHexToBytes("0x26aa394eea5630e07c48ae0c9558cef780d41e5e16056765bc8461851072c9d7")
+
+	// SystemAccountKeyPrefix is the prefix of the keys where the account
+	// information is stored, which is Twox128Hash("System") + Twox128Hash("Account")
+	SystemAccountKeyPrefix = MustHexToBytes("0x26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9")
 )
+
+// SystemAccountKey returns the key where the account information of the
+// given account id is stored, which is the system account key prefix
+// followed by the Blake2_128Concat hash of the account id.
+func SystemAccountKey(accountID []byte) (key []byte, err error) {
+	accountIDHash, err := Blake2b128(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("hashing account id: %w", err)
+	}
+
+	key = make([]byte, 0, len(SystemAccountKeyPrefix)+len(accountIDHash)+len(accountID))
+	key = append(key, SystemAccountKeyPrefix...)
+	key = append(key, accountIDHash...)
+	key = append(key, accountID...)
+	return key, nil
+}
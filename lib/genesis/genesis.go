@@ -4,9 +4,25 @@
 package genesis
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
+var (
+	// ErrRuntimeGenesisNotFound is returned when a chain spec has
+	// no human-readable runtime genesis configuration.
+	ErrRuntimeGenesisNotFound = errors.New("runtime genesis configuration not found")
+	// ErrRuntimeGenesisAmbiguous is returned when a runtime genesis
+	// has both a full configuration and a configuration patch.
+	ErrRuntimeGenesisAmbiguous = errors.New("runtime genesis has both a config and a patch")
+	// ErrRuntimeGenesisBuilderRequired is returned when converting a runtime
+	// genesis to raw without a runtime to build its storage.
+	ErrRuntimeGenesisBuilderRequired = errors.New("runtime genesis requires the runtime to be built")
+)
+
 // Genesis stores the data parsed from the genesis configuration file
 type Genesis struct {
 	Name               string                 `json:"name"`
@@ -46,8 +62,19 @@ type TelemetryEndpoint struct {
 
 // Fields stores genesis raw data, and human readable runtime data
 type Fields struct {
-	Raw     map[string]map[string]string      `json:"raw,omitempty"`
-	Runtime map[string]map[string]interface{} `json:"runtime,omitempty"`
+	Raw            map[string]map[string]string      `json:"raw,omitempty"`
+	Runtime        map[string]map[string]interface{} `json:"runtime,omitempty"`
+	RuntimeGenesis *RuntimeGenesis                   `json:"runtimeGenesis,omitempty"`
+}
+
+// RuntimeGenesis stores the human readable runtime genesis configuration
+// together with the runtime code. The configuration is either the full
+// configuration of the runtime pallets, or a patch of the default
+// configuration of the runtime pallets. Pallet names are in lower camel case.
+type RuntimeGenesis struct {
+	Code   string                            `json:"code,omitempty"`
+	Config map[string]map[string]interface{} `json:"config,omitempty"`
+	Patch  map[string]map[string]interface{} `json:"patch,omitempty"`
 }
 
 // GenesisData formats genesis for trie storage
@@ -74,17 +101,23 @@ func (g *Genesis) GenesisFields() Fields {
 
 // IsRaw returns whether the genesis is raw or not
 func (g *Genesis) IsRaw() bool {
-	return g.Genesis.Raw != nil || g.Genesis.Runtime == nil
+	return g.Genesis.Raw != nil ||
+		(g.Genesis.Runtime == nil && g.Genesis.RuntimeGenesis == nil)
 }
 
-// ToRaw converts a non-raw genesis to a raw genesis
+// ToRaw converts a non-raw genesis to a raw genesis. A genesis in the
+// runtime genesis format cannot be converted without its runtime, and
+// must be converted with BuildRawFromHuman instead.
 func (g *Genesis) ToRaw() error {
 	if g.IsRaw() {
 		return nil
 	}
 
-	grt := g.Genesis.Runtime
-	res, err := buildRawMap(grt)
+	if g.Genesis.Runtime == nil {
+		return fmt.Errorf("%w: in genesis %s", ErrRuntimeGenesisBuilderRequired, g.Name)
+	}
+
+	res, err := buildRawMap(g.Genesis.Runtime)
 	if err != nil {
 		return err
 	}
@@ -94,6 +127,131 @@ func (g *Genesis) ToRaw() error {
 	return nil
 }
 
+// RuntimeGenesisBuilder builds the genesis storage of a chain
+// spec with the GenesisBuilder runtime API of its runtime.
+type RuntimeGenesisBuilder interface {
+	// DefaultGenesisConfig returns the JSON encoded default
+	// genesis configuration of the runtime pallets.
+	DefaultGenesisConfig() (config []byte, err error)
+	// BuildGenesisStorage builds the genesis storage from the JSON encoded
+	// genesis configuration given, and returns its top storage key values
+	// hex encoded.
+	BuildGenesisStorage(config []byte) (top map[string]string, err error)
+	// Stop stops the runtime.
+	Stop()
+}
+
+// NewRuntimeGenesisBuilder instantiates a runtime genesis builder with the runtime code given.
+type NewRuntimeGenesisBuilder func(code []byte) (builder RuntimeGenesisBuilder, err error)
+
+// BuildRawFromHuman builds the raw top storage key values from the
+// human-readable runtime genesis configuration of the chain spec given,
+// and returns the raw chain spec. The chain spec given is not modified.
+// The runtime genesis configuration is either in the legacy runtime format,
+// whose storage is built without the runtime, or in the runtime genesis format,
+// whose storage is built by the runtime instantiated with newBuilder. A patch
+// in the runtime genesis format is merged over the default configuration of
+// the runtime, and a full configuration replaces it. The default configuration
+// is used if there is neither a patch nor a full configuration.
+func BuildRawFromHuman(spec *Genesis, newBuilder NewRuntimeGenesisBuilder) (raw *Genesis, err error) {
+	var top map[string]string
+	if spec.Genesis.Runtime != nil {
+		top, err = buildRawMap(spec.Genesis.Runtime)
+	} else {
+		top, err = buildRuntimeGenesis(spec.Name, spec.Genesis.RuntimeGenesis, newBuilder)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("building raw storage: %w", err)
+	}
+
+	rawSpec := *spec
+	rawSpec.Genesis = Fields{
+		Raw: map[string]map[string]string{
+			"top": top,
+		},
+	}
+	return &rawSpec, nil
+}
+
+// buildRuntimeGenesis builds the top storage key values of the runtime genesis
+// given with the runtime of its code, and sets the code in the storage built.
+func buildRuntimeGenesis(name string, runtimeGenesis *RuntimeGenesis,
+	newBuilder NewRuntimeGenesisBuilder) (top map[string]string, err error) {
+	if runtimeGenesis == nil {
+		return nil, fmt.Errorf("%w: in genesis %s", ErrRuntimeGenesisNotFound, name)
+	} else if runtimeGenesis.Config != nil && runtimeGenesis.Patch != nil {
+		return nil, fmt.Errorf("%w: in genesis %s", ErrRuntimeGenesisAmbiguous, name)
+	}
+
+	code, err := common.HexToBytes(runtimeGenesis.Code)
+	if err != nil {
+		return nil, fmt.Errorf("decoding runtime code: %w", err)
+	}
+
+	builder, err := newBuilder(code)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating runtime: %w", err)
+	}
+	defer builder.Stop()
+
+	var config interface{} = runtimeGenesis.Config
+	if runtimeGenesis.Config == nil {
+		encodedDefaultConfig, err := builder.DefaultGenesisConfig()
+		if err != nil {
+			return nil, fmt.Errorf("getting default genesis config: %w", err)
+		}
+
+		var defaultConfig interface{}
+		err = json.Unmarshal(encodedDefaultConfig, &defaultConfig)
+		if err != nil {
+			return nil, fmt.Errorf("decoding default genesis config: %w", err)
+		}
+
+		patch := make(map[string]interface{}, len(runtimeGenesis.Patch))
+		for pallet, fields := range runtimeGenesis.Patch {
+			patch[pallet] = fields
+		}
+		config = mergeJSON(defaultConfig, patch)
+	}
+
+	encodedConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("encoding genesis config: %w", err)
+	}
+
+	top, err = builder.BuildGenesisStorage(encodedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("building genesis storage: %w", err)
+	}
+
+	top[codeKeyHex] = common.BytesToHex(code)
+	return top, nil
+}
+
+// mergeJSON merges the decoded JSON patch given over the decoded JSON value
+// given, as Substrate merges genesis config patches: the fields of objects are
+// merged recursively, and any other value is replaced by the patch value.
+// The value given is not modified.
+func mergeJSON(value, patch interface{}) (merged interface{}) {
+	valueObject, ok := value.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	mergedObject := make(map[string]interface{}, len(valueObject)+len(patchObject))
+	for key, fieldValue := range valueObject {
+		mergedObject[key] = fieldValue
+	}
+	for key, fieldPatch := range patchObject {
+		mergedObject[key] = mergeJSON(mergedObject[key], fieldPatch)
+	}
+	return mergedObject
+}
+
 func interfaceToTelemetryEndpoint(endpoints []interface{}) []*TelemetryEndpoint {
 	var res []*TelemetryEndpoint
 	for _, v := range endpoints {
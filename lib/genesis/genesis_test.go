@@ -4,8 +4,16 @@
 package genesis
 
 import (
+	"encoding/json"
+	"errors"
+	"math/big"
 	"testing"
 
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -62,3 +70,159 @@ func Test_interfaceToTelemetryEndpoint(t *testing.T) {
 		})
 	}
 }
+
+// testGenesisBuilder is a runtime genesis builder building the
+// genesis storage as a single key value, the genesis config given.
+type testGenesisBuilder struct {
+	defaultConfig string
+	buildErr      error
+	stopped       bool
+}
+
+func (b *testGenesisBuilder) DefaultGenesisConfig() (config []byte, err error) {
+	return []byte(b.defaultConfig), nil
+}
+
+func (b *testGenesisBuilder) BuildGenesisStorage(config []byte) (top map[string]string, err error) {
+	if b.buildErr != nil {
+		return nil, b.buildErr
+	}
+	return map[string]string{"0x01": string(config)}, nil
+}
+
+func (b *testGenesisBuilder) Stop() {
+	b.stopped = true
+}
+
+func Test_BuildRawFromHuman(t *testing.T) {
+	t.Parallel()
+
+	const aliceAddress = "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"
+	alicePublicKey := crypto.PublicAddressToByteArray(common.Address(aliceAddress))
+	aliceHash, err := common.Blake2b128(alicePublicKey)
+	require.NoError(t, err)
+	aliceAccountKey := append(common.MustHexToBytes(systemAccountKeyHex), aliceHash...)
+	aliceAccountKey = append(aliceAccountKey, alicePublicKey...)
+	aliceAccountInfo, err := scale.Marshal(types.AccountInfo{
+		Data: types.AccountData{
+			Free:       scale.MustNewUint128(big.NewInt(1000)),
+			Reserved:   scale.MustNewUint128(big.NewInt(0)),
+			MiscFrozen: scale.MustNewUint128(big.NewInt(0)),
+			FreeFrozen: scale.MustNewUint128(big.NewInt(0)),
+		},
+	})
+	require.NoError(t, err)
+
+	const defaultConfig = `{"balances": {"balances": []}, ` +
+		`"babe": {"authorities": [], "epochConfig": {"c": [1, 4], "allowed_slots": "PrimarySlots"}}}`
+	errTest := errors.New("test error")
+
+	testCases := map[string]struct {
+		specJSON   string
+		buildErr   error
+		raw        *Genesis
+		errWrapped error
+		errMessage string
+	}{
+		"patch": {
+			specJSON: `{"name": "test", "genesis": {"runtimeGenesis": {"code": "0x0102",
+				"patch": {"balances": {"balances": [["` + aliceAddress + `", 1000]]},
+				"babe": {"epochConfig": {"allowed_slots": "PrimaryAndSecondaryVRFSlots"}}}}}}`,
+			raw: &Genesis{
+				Name: "test",
+				Genesis: Fields{Raw: map[string]map[string]string{"top": {
+					codeKeyHex: "0x0102",
+					"0x01": `{"babe":{"authorities":[],` +
+						`"epochConfig":{"allowed_slots":"PrimaryAndSecondaryVRFSlots","c":[1,4]}},` +
+						`"balances":{"balances":[["` + aliceAddress + `",1000]]}}`,
+				}}},
+			},
+		},
+		"default_config": {
+			specJSON: `{"name": "test", "genesis": {"runtimeGenesis": {"code": "0x0102"}}}`,
+			raw: &Genesis{
+				Name: "test",
+				Genesis: Fields{Raw: map[string]map[string]string{"top": {
+					codeKeyHex: "0x0102",
+					"0x01": `{"babe":{"authorities":[],` +
+						`"epochConfig":{"allowed_slots":"PrimarySlots","c":[1,4]}},"balances":{"balances":[]}}`,
+				}}},
+			},
+		},
+		"full_config": {
+			specJSON: `{"name": "test", "genesis": {"runtimeGenesis": {"code": "0x0102",
+				"config": {"balances": {"balances": [["` + aliceAddress + `", 1000]]}}}}}`,
+			raw: &Genesis{
+				Name: "test",
+				Genesis: Fields{Raw: map[string]map[string]string{"top": {
+					codeKeyHex: "0x0102",
+					"0x01":     `{"balances":{"balances":[["` + aliceAddress + `",1000]]}}`,
+				}}},
+			},
+		},
+		"legacy_runtime": {
+			specJSON: `{"name": "test", "genesis": {"runtime": {"System": {"code": "0x0102"},
+				"Balances": {"balances": [["` + aliceAddress + `", 1000]]}}}}`,
+			raw: &Genesis{
+				Name: "test",
+				Genesis: Fields{Raw: map[string]map[string]string{"top": {
+					codeKeyHex:                                     "0x0102",
+					common.BytesToHex(aliceAccountKey):             common.BytesToHex(aliceAccountInfo),
+					common.BytesToHex(common.UpgradedToDualRefKey): "0x01",
+				}}},
+			},
+		},
+		"build_error": {
+			specJSON:   `{"name": "test", "genesis": {"runtimeGenesis": {"code": "0x0102", "config": {}}}}`,
+			buildErr:   errTest,
+			errWrapped: errTest,
+			errMessage: "building raw storage: building genesis storage: test error",
+		},
+		"config_and_patch": {
+			specJSON: `{"name": "test", "genesis": {"runtimeGenesis": {"code": "0x0102",
+				"config": {}, "patch": {}}}}`,
+			errWrapped: ErrRuntimeGenesisAmbiguous,
+			errMessage: "building raw storage: " +
+				"runtime genesis has both a config and a patch: in genesis test",
+		},
+		"no_runtime_genesis": {
+			specJSON:   `{"name": "test", "genesis": {}}`,
+			errWrapped: ErrRuntimeGenesisNotFound,
+			errMessage: "building raw storage: " +
+				"runtime genesis configuration not found: in genesis test",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := new(Genesis)
+			err := json.Unmarshal([]byte(testCase.specJSON), spec)
+			require.NoError(t, err)
+			specFields := spec.Genesis
+
+			builder := &testGenesisBuilder{
+				defaultConfig: defaultConfig,
+				buildErr:      testCase.buildErr,
+			}
+			newBuilder := func(code []byte) (RuntimeGenesisBuilder, error) {
+				assert.Equal(t, []byte{1, 2}, code)
+				return builder, nil
+			}
+
+			raw, err := BuildRawFromHuman(spec, newBuilder)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.raw, raw)
+			assert.Equal(t, specFields, spec.Genesis)
+			if spec.Genesis.RuntimeGenesis != nil && testCase.errWrapped != ErrRuntimeGenesisAmbiguous {
+				assert.True(t, builder.stopped)
+			}
+		})
+	}
+}
@@ -545,15 +545,10 @@ func buildBalances(kv *keyValue, res map[string]string) error {
 	for i := range kv.iVal {
 		if i%2 == 0 {
 			// build key
-			bKey := common.MustHexToBytes(systemAccountKeyHex)
-
-			addHash, err := common.Blake2b128(kv.iVal[i].([]byte))
+			bKey, err := common.SystemAccountKey(kv.iVal[i].([]byte))
 			if err != nil {
 				return err
 			}
-			bKey = append(bKey, addHash...)
-
-			bKey = append(bKey, kv.iVal[i].([]byte)...)
 
 			accInfo := types.AccountInfo{
 				Nonce: 0,
@@ -582,7 +577,7 @@ func BuildFromMap(m map[string][]byte, gen *Genesis) error {
 		key := fmt.Sprintf("0x%x", k)
 		switch key {
 
-		case "0x3a636f6465":
+		case codeKeyHex:
 			// handle :code
 			addCodeValue(v, gen)
 			addRawValue(key, v, gen)
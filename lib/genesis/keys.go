@@ -4,6 +4,10 @@
 package genesis
 
 const (
+	// codeKeyHex is the hex encoding of the key to the runtime code
+	// in the storage trie, which is ":code".
+	codeKeyHex = "0x3a636f6465"
+
 	// babePrefixHex is the hex encoding of: Twox128Hash("Babe")
 	babePrefixHex = "0x1cb6f36e027abb2091cfb5110ab5087f"
 	// BABEAuthoritiesKeyHex is the hex encoding of:
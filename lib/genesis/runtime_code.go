@@ -0,0 +1,92 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package genesis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	wasm "github.com/wasmerio/go-ext-wasm/wasmer"
+)
+
+var (
+	// ErrRuntimeCodeInvalid is returned when the runtime code
+	// given is not a valid Wasm module.
+	ErrRuntimeCodeInvalid = errors.New("runtime code is not a valid wasm module")
+	// ErrGenesisTopNotFound is returned when the raw genesis
+	// has no top storage key values.
+	ErrGenesisTopNotFound = errors.New("genesis top not found")
+)
+
+// OverrideRuntimeCode loads the chain spec at the input path, replaces its
+// runtime code with the code given, and writes the resulting chain spec in raw
+// format at the output path. The chain spec loaded can be raw or human-readable.
+// It returns the genesis state root recomputed from the top storage key values
+// of the chain spec written.
+func OverrideRuntimeCode(inputPath, outputPath string, code []byte) (stateRoot common.Hash, err error) {
+	err = validateRuntimeCode(code)
+	if err != nil {
+		return stateRoot, fmt.Errorf("validating runtime code: %w", err)
+	}
+
+	gen, err := NewGenesisSpecFromJSON(inputPath)
+	if err != nil {
+		return stateRoot, fmt.Errorf("loading chain spec: %w", err)
+	}
+
+	err = gen.ToRaw()
+	if err != nil {
+		return stateRoot, fmt.Errorf("converting chain spec to raw: %w", err)
+	}
+	gen.Genesis.Runtime = nil
+
+	top, ok := gen.Genesis.Raw["top"]
+	if !ok {
+		return stateRoot, fmt.Errorf("%w: in chain spec %s", ErrGenesisTopNotFound, gen.Name)
+	}
+	top[codeKeyHex] = common.BytesToHex(code)
+
+	genesisTrie, err := trie.LoadFromMap(top)
+	if err != nil {
+		return stateRoot, fmt.Errorf("loading genesis top key values into trie: %w", err)
+	}
+
+	stateRoot, err = genesisTrie.Hash()
+	if err != nil {
+		return stateRoot, fmt.Errorf("hashing genesis trie: %w", err)
+	}
+
+	data, err := json.MarshalIndent(gen, "", "    ")
+	if err != nil {
+		return stateRoot, fmt.Errorf("encoding chain spec: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Clean(outputPath), data, 0600)
+	if err != nil {
+		return stateRoot, fmt.Errorf("writing chain spec: %w", err)
+	}
+
+	return stateRoot, nil
+}
+
+// validateRuntimeCode returns an error wrapping ErrRuntimeCodeInvalid if the
+// runtime code given, which may be zstd compressed, is not a valid Wasm module.
+func validateRuntimeCode(code []byte) (err error) {
+	code, err = runtime.DecompressWasm(code)
+	if err != nil {
+		return fmt.Errorf("%w: decompressing: %s", ErrRuntimeCodeInvalid, err)
+	}
+
+	if !wasm.Validate(code) {
+		return ErrRuntimeCodeInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package genesis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyWasmModule is the binary encoding of an empty Wasm module.
+var emptyWasmModule = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+func Test_OverrideRuntimeCode(t *testing.T) {
+	t.Parallel()
+
+	inputPath := filepath.Join("..", "..", "chain", "westend-dev", "westend-dev-spec-raw.json")
+	input, err := NewGenesisFromJSONRaw(inputPath)
+	require.NoError(t, err)
+	inputTrie, err := trie.LoadFromMap(input.Genesis.Raw["top"])
+	require.NoError(t, err)
+
+	outputPath := filepath.Join(t.TempDir(), "spec-raw.json")
+	stateRoot, err := OverrideRuntimeCode(inputPath, outputPath, emptyWasmModule)
+	require.NoError(t, err)
+
+	output, err := NewGenesisFromJSONRaw(outputPath)
+	require.NoError(t, err)
+	assert.True(t, output.IsRaw())
+	assert.Equal(t, input.Name, output.Name)
+	assert.Equal(t, input.ProtocolID, output.ProtocolID)
+
+	expectedTop := input.Genesis.Raw["top"]
+	expectedTop[codeKeyHex] = common.BytesToHex(emptyWasmModule)
+	assert.Equal(t, expectedTop, output.Genesis.Raw["top"])
+
+	outputTrie, err := trie.LoadFromMap(output.Genesis.Raw["top"])
+	require.NoError(t, err)
+	assert.Equal(t, outputTrie.MustHash(), stateRoot)
+	assert.NotEqual(t, inputTrie.MustHash(), stateRoot)
+}
+
+func Test_OverrideRuntimeCode_invalidCode(t *testing.T) {
+	t.Parallel()
+
+	inputPath := filepath.Join("..", "..", "chain", "westend-dev", "westend-dev-spec-raw.json")
+	outputPath := filepath.Join(t.TempDir(), "spec-raw.json")
+	_, err := OverrideRuntimeCode(inputPath, outputPath, []byte{1, 2, 3})
+	assert.ErrorIs(t, err, ErrRuntimeCodeInvalid)
+	assert.EqualError(t, err, "validating runtime code: runtime code is not a valid wasm module")
+
+	_, err = os.Stat(outputPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_validateRuntimeCode(t *testing.T) {
+	t.Parallel()
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	compressionFlag := []byte{82, 188, 83, 118, 70, 219, 142, 5}
+	compressed := append(compressionFlag, encoder.EncodeAll(emptyWasmModule, nil)...)
+	err = encoder.Close()
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		code       []byte
+		errWrapped error
+		errMessage string
+	}{
+		"wasm_module": {
+			code: emptyWasmModule,
+		},
+		"compressed_wasm_module": {
+			code: compressed,
+		},
+		"empty_code": {
+			errWrapped: ErrRuntimeCodeInvalid,
+			errMessage: "runtime code is not a valid wasm module",
+		},
+		"invalid_compressed_code": {
+			code:       []byte{82, 188, 83, 118, 70, 219, 142, 5, 1, 2, 3},
+			errWrapped: ErrRuntimeCodeInvalid,
+			errMessage: "runtime code is not a valid wasm module: decompressing: unexpected EOF",
+		},
+		"truncated_wasm_module": {
+			code:       emptyWasmModule[:4],
+			errWrapped: ErrRuntimeCodeInvalid,
+			errMessage: "runtime code is not a valid wasm module",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateRuntimeCode(testCase.code)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
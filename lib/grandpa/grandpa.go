@@ -71,6 +71,8 @@ type Service struct {
 	pcEquivocations map[ed25519.PublicKeyBytes][]*SignedVote // equivocatory votes for current pre-commit stage
 	tracker         *tracker                                 // tracker of vote messages we may need in the future
 	head            *types.Header                            // most recently finalised block
+	neighbours      *neighbours                              // views announced by peers in neighbour messages
+	viewChanged     chan struct{}                            // notified when our round or set id changes
 
 	// historical information
 	preVotedBlock      map[uint64]*Vote // map of round number -> pre-voted block
@@ -147,6 +149,8 @@ func NewService(cfg *Config) (*Service, error) {
 		preVotedBlock:      make(map[uint64]*Vote),
 		bestFinalCandidate: make(map[uint64]*Vote),
 		head:               head,
+		neighbours:         newNeighbours(),
+		viewChanged:        make(chan struct{}, 1),
 		resumed:            make(chan struct{}),
 		network:            cfg.Network,
 		finalisedCh:        finalisedCh,
@@ -173,6 +177,7 @@ func (s *Service) Start() error {
 	}
 
 	s.tracker.start()
+	go s.sendNeighbourMessages()
 
 	go func() {
 		err := s.initiate()
@@ -327,6 +332,7 @@ func (s *Service) initiateRound() error {
 	s.precommits = new(sync.Map)
 	s.pvEquivocations = make(map[ed25519.PublicKeyBytes][]*SignedVote)
 	s.pcEquivocations = make(map[ed25519.PublicKeyBytes][]*SignedVote)
+	s.notifyViewChanged()
 
 	return nil
 }
@@ -386,7 +392,7 @@ func (s *Service) handleIsPrimary() (bool, error) {
 		return false, fmt.Errorf("failed to encode finalisation message: %w", err)
 	}
 
-	s.network.GossipMessage(msg)
+	s.gossipVoteMessage(msg, primProposal.Round, primProposal.SetID)
 	return true, nil
 }
 
@@ -497,7 +503,7 @@ func (s *Service) sendPrecommitMessage(voteMessage *VoteMessage) (err error) {
 		return fmt.Errorf("transforming pre-commit into consensus message: %w", err)
 	}
 
-	s.network.GossipMessage(consensusMessage)
+	s.gossipVoteMessage(consensusMessage, voteMessage.Round, voteMessage.SetID)
 	logger.Tracef("sent pre-commit message: %v", consensusMessage)
 	return nil
 }
@@ -510,7 +516,7 @@ func (s *Service) sendPrevoteMessage(vm *VoteMessage) (err error) {
 		return fmt.Errorf("transforming pre-vote into consensus message: %w", err)
 	}
 
-	s.network.GossipMessage(consensusMessage)
+	s.gossipVoteMessage(consensusMessage, vm.Round, vm.SetID)
 	logger.Tracef("sent pre-vote message: %v", consensusMessage)
 	return nil
 }
@@ -69,6 +69,10 @@ func (n *testNetwork) GossipMessage(msg NotificationsMessage) {
 	n.out <- gmsg
 }
 
+func (n *testNetwork) GossipMessageExcluding(msg NotificationsMessage, _ ...peer.ID) {
+	n.GossipMessage(msg)
+}
+
 func (n *testNetwork) SendMessage(_ peer.ID, _ NotificationsMessage) error {
 	return nil
 }
@@ -61,7 +61,7 @@ func (h *MessageHandler) handleMessage(from peer.ID, m GrandpaMessage) (network.
 		return nil, nil
 	case *NeighbourPacketV1:
 		// we can afford to not retry handling neighbour message, if it errors.
-		return nil, h.handleNeighbourMessage(msg)
+		return nil, h.handleNeighbourMessage(from, msg)
 	case *CatchUpRequest:
 		return h.handleCatchUpRequest(msg)
 	case *CatchUpResponse:
@@ -79,52 +79,30 @@ func (h *MessageHandler) handleMessage(from peer.ID, m GrandpaMessage) (network.
 	}
 }
 
-func (h *MessageHandler) handleNeighbourMessage(msg *NeighbourPacketV1) error {
-	if h.grandpa.authority {
-		// TODO(#2931): this is a simple hack to ensure that the neighbour messages
-		// sent by gossamer are being received by substrate nodes
-		// not intended to be production code
-		h.grandpa.roundLock.Lock()
-		neighbourMessage := &NeighbourPacketV1{
-			Round:  h.grandpa.state.round,
-			SetID:  h.grandpa.state.setID,
-			Number: uint32(h.grandpa.head.Number),
-		}
-		h.grandpa.roundLock.Unlock()
-
-		cm, err := neighbourMessage.ToConsensusMessage()
-		if err != nil {
-			return fmt.Errorf("converting neighbour message to network message: %w", err)
-		}
+// handleNeighbourMessage records the view announced by the peer, answers with
+// our own view if the peer view was not known, and sends a catch up request to
+// the peer if it is far enough ahead of us.
+func (h *MessageHandler) handleNeighbourMessage(from peer.ID, msg *NeighbourPacketV1) error {
+	logger.Debugf("got neighbour message from %s with number %d, set id %d and round %d",
+		from, msg.Number, msg.SetID, msg.Round)
 
-		logger.Debugf("sending neighbour message: %v", neighbourMessage)
-		h.grandpa.network.GossipMessage(cm)
-	}
+	isNew := h.grandpa.neighbours.update(from, msg)
 
-	currFinalized, err := h.blockState.GetFinalisedHeader(0, 0)
-	if err != nil {
-		return err
-	}
-
-	// ignore neighbour messages where our best finalised number is greater than theirs
-	if currFinalized.Number >= uint(msg.Number) {
+	if !h.grandpa.authority {
 		return nil
 	}
 
-	// TODO; determine if there is some reason we don't receive justifications in responses near the head (usually),
-	// and remove the following code if it's fixed. (#1815)
-	head, err := h.blockState.BestBlockNumber()
-	if err != nil {
-		return err
+	if isNew {
+		err := h.grandpa.sendNeighbourMessage(from)
+		if err != nil {
+			return fmt.Errorf("sending neighbour message: %w", err)
+		}
 	}
 
-	// ignore neighbour messages that are above our head
-	if uint(msg.Number) > head {
-		return nil
+	err := h.grandpa.requestCatchUp(from, msg)
+	if err != nil {
+		return fmt.Errorf("requesting catch up: %w", err)
 	}
-
-	logger.Debugf("got neighbour message with number %d, set id %d and round %d", msg.Number, msg.SetID, msg.Round)
-	// TODO: should we send a justification request here? potentially re-connect this to sync package? (#1815)
 	return nil
 }
 
@@ -402,8 +380,8 @@ func (h *MessageHandler) verifyPreCommitJustification(msg *CatchUpResponse) erro
 	return nil
 }
 
-// VerifyBlockJustification verifies the finality justification for a block, returns scale encoded justification with
-// any extra bytes removed.
+// VerifyBlockJustification verifies the finality justification for a block,
+// and finalises the block, writing the justification together with it.
 func (s *Service) VerifyBlockJustification(hash common.Hash, justification []byte) error {
 	fj := Justification{}
 	err := scale.Unmarshal(justification, &fj)
@@ -435,6 +413,12 @@ func (s *Service) VerifyBlockJustification(hash common.Hash, justification []byt
 			return fmt.Errorf("%w, setID=%d and round=%d", errFinalisedBlocksMismatch, setID, fj.Round)
 		}
 
+		// the block is already finalised and written to the database,
+		// so the justification is written on its own.
+		err = s.blockState.SetJustification(hash, justification)
+		if err != nil {
+			return fmt.Errorf("setting justification: %w", err)
+		}
 		return nil
 	}
 
@@ -536,6 +520,13 @@ func (s *Service) VerifyBlockJustification(hash common.Hash, justification []byt
 		}
 	}
 
+	// the justification is set before the block is finalised, such that it
+	// is written to the database in the same batch as the finalised block.
+	err = s.blockState.SetJustification(hash, justification)
+	if err != nil {
+		return fmt.Errorf("setting justification: %w", err)
+	}
+
 	err = s.blockState.SetFinalisedHash(hash, fj.Round, setID)
 	if err != nil {
 		return fmt.Errorf("setting finalised hash: %w", err)
@@ -20,6 +20,7 @@
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 )
 
 var testHeader = &types.Header{
@@ -1476,6 +1477,8 @@ func TestService_VerifyBlockJustification(t *testing.T) { //nolint
 	justification := newJustification(1, testHash, 1, precommits)
 	justificationBytes, err := scale.Marshal(*justification)
 	require.NoError(t, err)
+	justificationExtraBytes := append(slices.Clone(justificationBytes),
+		[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}...)
 
 	type fields struct {
 		blockStateBuilder   func(ctrl *gomock.Controller) BlockState
@@ -1517,6 +1520,7 @@ type args struct {
 					mockBlockState.EXPECT().IsDescendantOf(testHash, testHash).
 						Return(true, nil).Times(3)
 					mockBlockState.EXPECT().GetHeader(testHash).Return(testHeader, nil).Times(3)
+					mockBlockState.EXPECT().SetJustification(testHash, justificationBytes).Return(nil)
 					mockBlockState.EXPECT().SetFinalisedHash(testHash, uint64(1),
 						uint64(0)).Return(nil)
 					return mockBlockState
@@ -1547,6 +1551,7 @@ type args struct {
 					mockBlockState.EXPECT().IsDescendantOf(testHash, testHash).
 						Return(true, nil).Times(3)
 					mockBlockState.EXPECT().GetHeader(testHash).Return(testHeader, nil).Times(3)
+					mockBlockState.EXPECT().SetJustification(testHash, justificationExtraBytes).Return(nil)
 					mockBlockState.EXPECT().SetFinalisedHash(testHash, uint64(1),
 						uint64(0)).Return(nil)
 					return mockBlockState
@@ -1564,7 +1569,7 @@ type args struct {
 			},
 			args: args{
 				hash:          testHash,
-				justification: append(justificationBytes, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}...),
+				justification: justificationExtraBytes,
 			},
 			want: justificationBytes,
 		},
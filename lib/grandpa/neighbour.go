@@ -0,0 +1,214 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grandpa
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// neighbourMessageInterval is the interval at which our neighbour
+	// message is gossiped again if our view did not change meanwhile.
+	neighbourMessageInterval = 2 * time.Minute
+	// neighbourViewExpiry is the duration after which the view of a peer
+	// is forgotten if no neighbour message was received from it.
+	neighbourViewExpiry = 3 * neighbourMessageInterval
+	// catchUpThreshold is the number of rounds a peer in our voter set
+	// must be ahead of us for a catch up request to be sent to it.
+	catchUpThreshold = 2
+	// catchUpRequestTimeout is the duration after which another catch up
+	// request can be sent if the pending catch up request was not answered.
+	catchUpRequestTimeout = 45 * time.Second
+)
+
+// neighbourView is the view of a peer, as announced in its last neighbour message.
+type neighbourView struct {
+	round      uint64
+	setID      uint64
+	receivedAt time.Time
+}
+
+// neighbours tracks the views announced by peers in their neighbour messages,
+// used to decide when to catch up and which peers votes are relevant to.
+type neighbours struct {
+	mutex sync.Mutex
+	views map[peer.ID]neighbourView
+	// catchUpRequestedAt is the time the pending catch up request was
+	// sent at, and is the zero time if no catch up request was sent.
+	catchUpRequestedAt time.Time
+}
+
+func newNeighbours() *neighbours {
+	return &neighbours{
+		views: make(map[peer.ID]neighbourView),
+	}
+}
+
+// update sets the view of the peer given from its neighbour message,
+// and returns true if the view of the peer was not known.
+func (n *neighbours) update(who peer.ID, msg *NeighbourPacketV1) (isNew bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	now := time.Now()
+	previous, ok := n.views[who]
+	isNew = !ok || now.Sub(previous.receivedAt) > neighbourViewExpiry
+	n.views[who] = neighbourView{
+		round:      msg.Round,
+		setID:      msg.SetID,
+		receivedAt: now,
+	}
+	return isNew
+}
+
+// peersNotInterestedIn returns the peers for which a vote in the given round and
+// set id is known not to be relevant, that is the peers which announced a view in
+// another voter set or more than one round away from the vote round. The peers
+// whose view is not known are not returned.
+func (n *neighbours) peersNotInterestedIn(round, setID uint64) (peers []peer.ID) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	now := time.Now()
+	for who, view := range n.views {
+		if now.Sub(view.receivedAt) > neighbourViewExpiry {
+			delete(n.views, who)
+			continue
+		}
+
+		if view.setID == setID && round <= view.round+1 && view.round <= round+1 {
+			continue
+		}
+		peers = append(peers, who)
+	}
+	return peers
+}
+
+// tryStartCatchUp returns true and marks a catch up request as pending,
+// unless a catch up request is already pending and did not time out.
+func (n *neighbours) tryStartCatchUp() (started bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	now := time.Now()
+	if !n.catchUpRequestedAt.IsZero() && now.Sub(n.catchUpRequestedAt) < catchUpRequestTimeout {
+		return false
+	}
+	n.catchUpRequestedAt = now
+	return true
+}
+
+// newNeighbourMessage returns the neighbour message announcing our current view.
+func (s *Service) newNeighbourMessage() *NeighbourPacketV1 {
+	s.roundLock.Lock()
+	defer s.roundLock.Unlock()
+	return &NeighbourPacketV1{
+		Round:  s.state.round,
+		SetID:  s.state.setID,
+		Number: uint32(s.head.Number),
+	}
+}
+
+// notifyViewChanged wakes up the neighbour messages sender such that our
+// new view is gossiped, without blocking if a notification is pending.
+func (s *Service) notifyViewChanged() {
+	select {
+	case s.viewChanged <- struct{}{}:
+	default:
+	}
+}
+
+// sendNeighbourMessages gossips our neighbour message each time our view
+// changes, and every neighbourMessageInterval, until the service is stopped.
+func (s *Service) sendNeighbourMessages() {
+	ticker := time.NewTicker(neighbourMessageInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.viewChanged:
+		}
+
+		err := s.gossipNeighbourMessage()
+		if err != nil {
+			logger.Warnf("failed to gossip neighbour message: %s", err)
+		}
+	}
+}
+
+func (s *Service) gossipNeighbourMessage() error {
+	neighbourMessage := s.newNeighbourMessage()
+	cm, err := neighbourMessage.ToConsensusMessage()
+	if err != nil {
+		return fmt.Errorf("converting neighbour message to network message: %w", err)
+	}
+
+	logger.Debugf("gossiping neighbour message: %v", neighbourMessage)
+	s.network.GossipMessage(cm)
+	return nil
+}
+
+// sendNeighbourMessage sends our neighbour message to the peer given only.
+func (s *Service) sendNeighbourMessage(to peer.ID) error {
+	neighbourMessage := s.newNeighbourMessage()
+	cm, err := neighbourMessage.ToConsensusMessage()
+	if err != nil {
+		return fmt.Errorf("converting neighbour message to network message: %w", err)
+	}
+
+	logger.Debugf("sending neighbour message %v to peer %s", neighbourMessage, to)
+	return s.network.SendMessage(to, cm)
+}
+
+// requestCatchUp sends a catch up request to the peer given if the view
+// it announced is at least catchUpThreshold rounds ahead of our round in
+// our voter set, and if no other catch up request is pending.
+func (s *Service) requestCatchUp(from peer.ID, msg *NeighbourPacketV1) error {
+	s.roundLock.Lock()
+	round, setID := s.state.round, s.state.setID
+	s.roundLock.Unlock()
+
+	if msg.SetID != setID || msg.Round < round+catchUpThreshold {
+		return nil
+	}
+
+	if !s.neighbours.tryStartCatchUp() {
+		return nil
+	}
+
+	// the peer can only answer with the rounds it completed
+	catchUpRequest := newCatchUpRequest(msg.Round-1, setID)
+	cm, err := catchUpRequest.ToConsensusMessage()
+	if err != nil {
+		return fmt.Errorf("converting catch up request to network message: %w", err)
+	}
+
+	logger.Debugf("peer %s is at round %d and we are at round %d, sending catch up request %v",
+		from, msg.Round, round, catchUpRequest)
+	err = s.network.SendMessage(from, cm)
+	if err != nil {
+		return fmt.Errorf("sending catch up request: %w", err)
+	}
+	return nil
+}
+
+// gossipVoteMessage gossips the vote consensus message given to our peers,
+// except to the peers known not to be interested in the vote according to
+// their neighbour messages.
+func (s *Service) gossipVoteMessage(cm *ConsensusMessage, round, setID uint64) {
+	excluding := s.neighbours.peersNotInterestedIn(round, setID)
+	if len(excluding) == 0 {
+		s.network.GossipMessage(cm)
+		return
+	}
+
+	s.network.GossipMessageExcluding(cm, excluding...)
+}
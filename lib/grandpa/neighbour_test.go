@@ -0,0 +1,155 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grandpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sentMessage is a grandpa message sent to a peer through a mocked network.
+type sentMessage struct {
+	to      peer.ID
+	message GrandpaMessage
+}
+
+func newNeighbourTestService(t *testing.T, round, setID uint64) (
+	service *Service, gossiped chan GrandpaMessage, sent chan sentMessage) {
+	t.Helper()
+
+	gossiped = make(chan GrandpaMessage, 8)
+	sent = make(chan sentMessage, 8)
+
+	ctrl := gomock.NewController(t)
+	network := NewMockNetwork(ctrl)
+	network.EXPECT().GossipMessage(gomock.Any()).DoAndReturn(func(msg NotificationsMessage) {
+		message, err := decodeMessage(msg.(*ConsensusMessage))
+		require.NoError(t, err)
+		gossiped <- message
+	}).AnyTimes()
+	network.EXPECT().SendMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(to peer.ID, msg NotificationsMessage) error {
+			message, err := decodeMessage(msg.(*ConsensusMessage))
+			require.NoError(t, err)
+			sent <- sentMessage{to: to, message: message}
+			return nil
+		}).AnyTimes()
+
+	service = &Service{
+		state:      NewState(newTestVoters(t), setID, round),
+		head:       &types.Header{Number: uint(round)},
+		authority:  true,
+		network:    network,
+		neighbours: newNeighbours(),
+	}
+	service.messageHandler = NewMessageHandler(service, nil, nil)
+	return service, gossiped, sent
+}
+
+func Test_neighbourMessages_catchUp(t *testing.T) {
+	t.Parallel()
+
+	const (
+		aheadPeer  = peer.ID("ahead")
+		behindPeer = peer.ID("behind")
+		setID      = 1
+	)
+
+	ahead, aheadGossiped, aheadSent := newNeighbourTestService(t, 10, setID)
+	behind, _, behindSent := newNeighbourTestService(t, 3, setID)
+
+	// The node ahead gossips its view.
+	err := ahead.gossipNeighbourMessage()
+	require.NoError(t, err)
+	aheadView := <-aheadGossiped
+	assert.Equal(t, &NeighbourPacketV1{Round: 10, SetID: setID, Number: 10}, aheadView)
+
+	// The node behind answers with its own view, and asks for a catch up
+	// to the last round completed by the node ahead.
+	_, err = behind.messageHandler.handleMessage(aheadPeer, aheadView)
+	require.NoError(t, err)
+
+	behindView := <-behindSent
+	assert.Equal(t, sentMessage{
+		to:      aheadPeer,
+		message: &NeighbourPacketV1{Round: 3, SetID: setID, Number: 3},
+	}, behindView)
+	assert.Equal(t, sentMessage{
+		to:      aheadPeer,
+		message: &CatchUpRequest{Round: 9, SetID: setID},
+	}, <-behindSent)
+
+	// A single catch up request is pending at a time.
+	_, err = behind.messageHandler.handleMessage(aheadPeer, aheadView)
+	require.NoError(t, err)
+	assert.Empty(t, behindSent)
+
+	// The node ahead learns the view of the node behind, answers with its
+	// view since it did not know the node behind, and does not catch up.
+	_, err = ahead.messageHandler.handleMessage(behindPeer, behindView.message)
+	require.NoError(t, err)
+	assert.Equal(t, sentMessage{to: behindPeer, message: aheadView}, <-aheadSent)
+	assert.Empty(t, aheadSent)
+
+	// Votes of the node ahead are not relevant to the node behind.
+	assert.Equal(t, []peer.ID{behindPeer}, ahead.neighbours.peersNotInterestedIn(10, setID))
+	assert.Equal(t, []peer.ID{aheadPeer}, behind.neighbours.peersNotInterestedIn(3, setID))
+	assert.Empty(t, behind.neighbours.peersNotInterestedIn(9, setID))
+}
+
+func Test_Service_gossipVoteMessage(t *testing.T) {
+	t.Parallel()
+
+	cm, err := (&NeighbourPacketV1{}).ToConsensusMessage()
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	network := NewMockNetwork(ctrl)
+	service := &Service{
+		network:    network,
+		neighbours: newNeighbours(),
+	}
+
+	// Votes are gossiped to all peers if no peer is known not to be interested.
+	service.neighbours.update("same_round", &NeighbourPacketV1{Round: 5, SetID: 1})
+	network.EXPECT().GossipMessage(cm)
+	service.gossipVoteMessage(cm, 5, 1)
+
+	// Peers known to be in another round or set are excluded from the gossip.
+	service.neighbours.update("other_set", &NeighbourPacketV1{Round: 5, SetID: 2})
+	network.EXPECT().GossipMessageExcluding(cm, peer.ID("other_set"))
+	service.gossipVoteMessage(cm, 5, 1)
+}
+
+func Test_neighbours(t *testing.T) {
+	t.Parallel()
+
+	n := newNeighbours()
+
+	assert.Empty(t, n.peersNotInterestedIn(5, 1))
+
+	assert.True(t, n.update("a", &NeighbourPacketV1{Round: 5, SetID: 1}))
+	assert.False(t, n.update("a", &NeighbourPacketV1{Round: 6, SetID: 1}))
+	assert.True(t, n.update("b", &NeighbourPacketV1{Round: 6, SetID: 2}))
+	assert.True(t, n.update("c", &NeighbourPacketV1{Round: 9, SetID: 1}))
+
+	assert.ElementsMatch(t, []peer.ID{"b", "c"}, n.peersNotInterestedIn(5, 1))
+
+	// Expired views are forgotten.
+	n.views["c"] = neighbourView{round: 9, setID: 1, receivedAt: time.Now().Add(-neighbourViewExpiry - time.Second)}
+	assert.Equal(t, []peer.ID{"b"}, n.peersNotInterestedIn(5, 1))
+	assert.True(t, n.update("c", &NeighbourPacketV1{Round: 9, SetID: 1}))
+
+	// Another catch up request can be sent once the pending one timed out.
+	assert.True(t, n.tryStartCatchUp())
+	assert.False(t, n.tryStartCatchUp())
+	n.catchUpRequestedAt = time.Now().Add(-catchUpRequestTimeout)
+	assert.True(t, n.tryStartCatchUp())
+}
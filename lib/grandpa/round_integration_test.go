@@ -204,6 +204,7 @@ func TestPlayGrandpaRound(t *testing.T) {
 					precommits:         new(sync.Map),
 					preVotedBlock:      make(map[uint64]*Vote),
 					bestFinalCandidate: make(map[uint64]*Vote),
+					neighbours:         newNeighbours(),
 					pvEquivocations:    make(map[ed25519.PublicKeyBytes][]*SignedVote),
 					pcEquivocations:    make(map[ed25519.PublicKeyBytes][]*SignedVote),
 				}
@@ -375,6 +376,7 @@ func TestPlayGrandpaRoundMultipleRounds(t *testing.T) {
 			keypair:            voters[idx],
 			preVotedBlock:      make(map[uint64]*Vote),
 			bestFinalCandidate: make(map[uint64]*Vote),
+			neighbours:         newNeighbours(),
 		}
 		grandpaServices[idx].paused.Store(false)
 
@@ -684,6 +686,7 @@ func TestSendingVotesInRightStage(t *testing.T) {
 		precommits:         new(sync.Map),
 		preVotedBlock:      make(map[uint64]*Vote),
 		bestFinalCandidate: make(map[uint64]*Vote),
+		neighbours:         newNeighbours(),
 		telemetry:          mockedTelemetry,
 	}
 	grandpa.paused.Store(false)
@@ -32,6 +32,7 @@ type BlockState interface {
 	GetFinalisedNotifierChannel() chan *types.FinalisationInfo
 	FreeFinalisedNotifierChannel(ch chan *types.FinalisationInfo)
 	SetJustification(hash common.Hash, data []byte) error
+	GetJustification(hash common.Hash) ([]byte, error)
 	BestBlockNumber() (blockNumber uint, err error)
 	GetHighestRoundAndSetID() (uint64, uint64, error)
 	BestBlockHash() common.Hash
@@ -43,6 +44,7 @@ type GrandpaState interface {
 	GetCurrentSetID() (uint64, error)
 	GetAuthorities(setID uint64) ([]types.GrandpaVoter, error)
 	GetSetIDByBlockNumber(num uint) (uint64, error)
+	GetSetIDChange(setID uint64) (blockNumber uint, err error)
 	SetLatestRound(round uint64) error
 	GetLatestRound() (uint64, error)
 	SetPrevotes(round, setID uint64, data []SignedVote) error
@@ -55,6 +57,7 @@ type GrandpaState interface {
 // Network is the interface required by GRANDPA for the network
 type Network interface {
 	GossipMessage(msg network.NotificationsMessage)
+	GossipMessageExcluding(msg network.NotificationsMessage, excluding ...peer.ID)
 	SendMessage(to peer.ID, msg NotificationsMessage) error
 	RegisterNotificationsProtocol(sub protocol.ID,
 		messageID network.MessageType,
@@ -0,0 +1,361 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grandpa
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+)
+
+var (
+	errWarpSyncBeginNotFinalised   = errors.New("warp sync begin block is not finalised")
+	errWarpSyncFragmentUnavailable = errors.New("warp sync fragment is not available")
+	errWarpSyncFragmentNotAbove    = errors.New("warp sync fragment is not above previous block")
+	errWarpSyncNoSetChange         = errors.New("warp sync fragment header has no authority set change")
+	errWarpSyncDelayedSetChange    = errors.New("warp sync fragment authority set change is delayed")
+	errWarpSyncUnusedAncestries    = errors.New("warp sync justification has unused vote ancestries")
+)
+
+// warpSyncProofOverhead is the maximum encoded size of a warp sync
+// proof without its fragments, which is the compact encoded number
+// of fragments and the is finished boolean.
+const warpSyncProofOverhead = 5 + 1
+
+// GenerateWarpSyncProof returns a warp sync proof containing a fragment for
+// each authority set change after the finalised block with the hash given,
+// followed by a fragment for the highest finalised block if this one has a
+// justification. The proof is not finished if it reaches the maximum warp
+// sync proof size, or if the header or justification of an authority set
+// change block is not stored, in which case the proof can be continued
+// from its last fragment, possibly with another node.
+// It returns an error wrapping errWarpSyncFragmentUnavailable if the first
+// fragment after the begin block is not available.
+func (s *Service) GenerateWarpSyncProof(begin common.Hash) (proof *network.WarpSyncProof, err error) {
+	beginHeader, err := s.blockState.GetHeader(begin)
+	if err != nil {
+		return nil, fmt.Errorf("getting begin header: %w", err)
+	}
+
+	highestFinalised, err := s.blockState.GetHighestFinalisedHeader()
+	if err != nil {
+		return nil, fmt.Errorf("getting highest finalised header: %w", err)
+	}
+
+	if beginHeader.Number > highestFinalised.Number {
+		return nil, fmt.Errorf("%w: block number %d is above highest finalised block number %d",
+			errWarpSyncBeginNotFinalised, beginHeader.Number, highestFinalised.Number)
+	}
+
+	canonicalHeader, err := s.blockState.GetHeaderByNumber(beginHeader.Number)
+	if err != nil {
+		return nil, fmt.Errorf("getting canonical header of block number %d: %w", beginHeader.Number, err)
+	} else if canonicalHeader.Hash() != begin {
+		return nil, fmt.Errorf("%w: block %s is not in the canonical chain",
+			errWarpSyncBeginNotFinalised, begin)
+	}
+
+	beginSetID, err := s.grandpaState.GetSetIDByBlockNumber(beginHeader.Number)
+	if err != nil {
+		return nil, fmt.Errorf("getting set id of block number %d: %w", beginHeader.Number, err)
+	}
+
+	currentSetID, err := s.grandpaState.GetCurrentSetID()
+	if err != nil {
+		return nil, fmt.Errorf("getting current set id: %w", err)
+	}
+
+	proof = &network.WarpSyncProof{}
+	size := uint64(warpSyncProofOverhead)
+	lastNumber := beginHeader.Number
+	for setID := beginSetID + 1; setID <= currentSetID; setID++ {
+		changeNumber, err := s.grandpaState.GetSetIDChange(setID)
+		if err != nil {
+			return nil, fmt.Errorf("getting block number of set id %d change: %w", setID, err)
+		} else if changeNumber <= lastNumber {
+			// The begin block is the last block of the previous set,
+			// so the set change is already proven to the requester.
+			continue
+		}
+
+		fragment, err := s.warpSyncFragment(changeNumber)
+		if errors.Is(err, errWarpSyncFragmentUnavailable) && len(proof.Fragments) > 0 {
+			logger.Debugf("stopping warp sync proof before set id %d change: %s", setID, err)
+			return proof, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("getting fragment of set id %d change: %w", setID, err)
+		}
+
+		fragmentSize, err := encodedFragmentSize(fragment)
+		if err != nil {
+			return nil, err
+		} else if size+fragmentSize > network.MaxWarpSyncProofSize {
+			return proof, nil
+		}
+
+		proof.Fragments = append(proof.Fragments, fragment)
+		size += fragmentSize
+		lastNumber = changeNumber
+	}
+
+	if highestFinalised.Number > lastNumber {
+		fragment, err := s.warpSyncFragment(highestFinalised.Number)
+		switch {
+		case errors.Is(err, errWarpSyncFragmentUnavailable):
+			logger.Debugf("highest finalised block is not in warp sync proof: %s", err)
+		case err != nil:
+			return nil, fmt.Errorf("getting fragment of highest finalised block: %w", err)
+		default:
+			fragmentSize, err := encodedFragmentSize(fragment)
+			if err != nil {
+				return nil, err
+			} else if size+fragmentSize > network.MaxWarpSyncProofSize {
+				return proof, nil
+			}
+			proof.Fragments = append(proof.Fragments, fragment)
+		}
+	}
+
+	proof.IsFinished = true
+	return proof, nil
+}
+
+// warpSyncFragment returns the warp sync fragment of the canonical block
+// with the number given. It returns an error wrapping
+// errWarpSyncFragmentUnavailable if the header or the justification
+// of the block is not stored.
+func (s *Service) warpSyncFragment(number uint) (fragment network.WarpSyncFragment, err error) {
+	header, err := s.blockState.GetHeaderByNumber(number)
+	if errors.Is(err, chaindb.ErrKeyNotFound) || errors.Is(err, state.ErrNoCanonicalAtHeight) {
+		return fragment, fmt.Errorf("%w: header of block number %d not found: %s",
+			errWarpSyncFragmentUnavailable, number, err)
+	} else if err != nil {
+		return fragment, fmt.Errorf("getting header of block number %d: %w", number, err)
+	}
+
+	encodedJustification, err := s.blockState.GetJustification(header.Hash())
+	if errors.Is(err, state.ErrJustificationNotFound) {
+		return fragment, fmt.Errorf("%w: justification of block number %d not found",
+			errWarpSyncFragmentUnavailable, number)
+	} else if err != nil {
+		return fragment, fmt.Errorf("getting justification of block number %d: %w", number, err)
+	}
+
+	justification, err := types.DecodeGrandpaJustification(bytes.NewReader(encodedJustification))
+	if err != nil {
+		return fragment, fmt.Errorf("decoding justification of block number %d: %w", number, err)
+	}
+
+	return network.WarpSyncFragment{
+		Header:        *header,
+		Justification: *justification,
+	}, nil
+}
+
+func encodedFragmentSize(fragment network.WarpSyncFragment) (size uint64, err error) {
+	encoded, err := scale.Marshal(fragment)
+	if err != nil {
+		return 0, fmt.Errorf("encoding fragment of block number %d: %w", fragment.Header.Number, err)
+	}
+	return uint64(len(encoded)), nil
+}
+
+// VerifyWarpSyncProof verifies each fragment of the warp sync proof given,
+// starting with the authority set with the set ID and authorities given,
+// and returns the set ID and authorities of the authority set following
+// the last fragment. Each fragment header must contain a scheduled authority
+// set change without delay, except for the last fragment of a finished proof.
+// It does not use the node state, such that it can verify the proof of a
+// chain not yet downloaded.
+func (s *Service) VerifyWarpSyncProof(proof *network.WarpSyncProof, setID uint64,
+	authorities []types.GrandpaVoter) (nextSetID uint64, nextAuthorities []types.GrandpaVoter, err error) {
+	var previousNumber uint
+	for i, fragment := range proof.Fragments {
+		header := fragment.Header
+		if i > 0 && header.Number <= previousNumber {
+			return 0, nil, fmt.Errorf("%w: fragment block number %d is not above block number %d",
+				errWarpSyncFragmentNotAbove, header.Number, previousNumber)
+		}
+		previousNumber = header.Number
+
+		err = verifyWarpSyncJustification(&header, &fragment.Justification, setID, authorities)
+		if err != nil {
+			return 0, nil, fmt.Errorf("verifying justification of block number %d for set id %d: %w",
+				header.Number, setID, err)
+		}
+
+		changeAuthorities, err := findScheduledChange(&header)
+		if err != nil {
+			// The last fragment of a finished proof can be the highest
+			// finalised block, which is not the last block of its set.
+			isLastOfFinished := proof.IsFinished && i == len(proof.Fragments)-1
+			if isLastOfFinished && (errors.Is(err, errWarpSyncNoSetChange) ||
+				errors.Is(err, errWarpSyncDelayedSetChange)) {
+				break
+			}
+			return 0, nil, fmt.Errorf("block number %d: %w", header.Number, err)
+		}
+
+		setID++
+		authorities = changeAuthorities
+	}
+
+	return setID, authorities, nil
+}
+
+// verifyWarpSyncJustification verifies the justification given finalises the
+// header given, using the set ID and authorities given, as Substrate does: each
+// precommit must be signed by an authority of the set and be for the header or
+// for a descendant of the header proven by the vote ancestries, which must all
+// be used. The header is finalised if the distinct authorities which signed a
+// precommit reach the supermajority threshold of the set, where an authority
+// signing several precommits is only counted once.
+func verifyWarpSyncJustification(header *types.Header, justification *types.GrandpaJustification,
+	setID uint64, authorities []types.GrandpaVoter) (err error) {
+	headerHash := header.Hash()
+	if justification.Commit.Hash != headerHash {
+		return fmt.Errorf("%w: justification %s and block hash %s",
+			ErrJustificationMismatch, justification.Commit.Hash.Short(), headerHash.Short())
+	} else if uint(justification.Commit.Number) != header.Number {
+		return fmt.Errorf("%w: justification number %d and block number %d",
+			ErrBlockNumbersMismatch, justification.Commit.Number, header.Number)
+	}
+
+	ancestries := make(map[common.Hash]*types.Header, len(justification.VotesAncestries))
+	for i := range justification.VotesAncestries {
+		ancestry := &justification.VotesAncestries[i]
+		ancestries[ancestry.Hash()] = ancestry
+	}
+	usedAncestries := make(map[common.Hash]struct{}, len(ancestries))
+
+	voters := make(map[ed25519.PublicKeyBytes]struct{}, len(justification.Commit.Precommits))
+	for _, signedPrecommit := range justification.Commit.Precommits {
+		publicKey, err := ed25519.NewPublicKey(signedPrecommit.AuthorityID[:])
+		if err != nil {
+			return err
+		}
+
+		if !isInAuthSet(publicKey, authorities) {
+			return ErrAuthorityNotInSet
+		}
+
+		msg, err := scale.Marshal(FullVote{
+			Stage: precommit,
+			Vote:  signedPrecommit.Vote,
+			Round: justification.Round,
+			SetID: setID,
+		})
+		if err != nil {
+			return err
+		}
+
+		ok, err := publicKey.Verify(msg, signedPrecommit.Signature[:])
+		if err != nil {
+			return err
+		} else if !ok {
+			return ErrInvalidSignature
+		}
+
+		err = checkPrecommitAncestry(header, signedPrecommit.Vote, ancestries, usedAncestries)
+		if err != nil {
+			return err
+		}
+
+		voters[signedPrecommit.AuthorityID] = struct{}{}
+	}
+
+	if len(usedAncestries) != len(ancestries) {
+		return fmt.Errorf("%w: %d vote ancestries are not used",
+			errWarpSyncUnusedAncestries, len(ancestries)-len(usedAncestries))
+	}
+
+	// The threshold is the number of authorities minus the maximum number of
+	// faulty authorities, which is strictly more than two thirds of the set.
+	threshold := len(authorities) - (len(authorities)-1)/3
+	if len(voters) == 0 || len(voters) < threshold {
+		return fmt.Errorf("%w: %d distinct voters for a threshold of %d",
+			ErrMinVotesNotMet, len(voters), threshold)
+	}
+
+	return nil
+}
+
+// checkPrecommitAncestry checks the vote given is for the header given or for
+// one of its descendants, by walking back from the vote block to the header
+// through the vote ancestries given. The hash of each vote ancestry walked
+// through is added to the used ancestries map given.
+func checkPrecommitAncestry(header *types.Header, vote types.GrandpaVote,
+	ancestries map[common.Hash]*types.Header, usedAncestries map[common.Hash]struct{}) error {
+	headerHash := header.Hash()
+	hash, number := vote.Hash, uint(vote.Number)
+	for hash != headerHash {
+		ancestry, ok := ancestries[hash]
+		if !ok || number <= header.Number {
+			return fmt.Errorf("%w: precommit for block %s is not proven to descend from block %s",
+				ErrPrecommitBlockMismatch, vote.Hash.Short(), headerHash.Short())
+		} else if ancestry.Number != number {
+			return fmt.Errorf("%w: vote ancestry number %d and expected block number %d",
+				ErrBlockNumbersMismatch, ancestry.Number, number)
+		}
+
+		usedAncestries[hash] = struct{}{}
+		hash, number = ancestry.ParentHash, ancestry.Number-1
+	}
+
+	if number != header.Number {
+		return fmt.Errorf("%w: precommit number %d and block number %d",
+			ErrBlockNumbersMismatch, number, header.Number)
+	}
+
+	return nil
+}
+
+// findScheduledChange returns the next authorities of the GRANDPA scheduled
+// change digest of the header given. It returns an error wrapping
+// errWarpSyncNoSetChange if the header has no scheduled change digest, and
+// an error wrapping errWarpSyncDelayedSetChange if the scheduled change is
+// delayed, since the header is then not the last block of the authority set.
+func findScheduledChange(header *types.Header) (authorities []types.GrandpaVoter, err error) {
+	for _, digestItem := range header.Digest.Types {
+		digestValue, err := digestItem.Value()
+		if err != nil {
+			return nil, fmt.Errorf("getting digest value: %w", err)
+		}
+
+		consensusDigest, ok := digestValue.(types.ConsensusDigest)
+		if !ok || consensusDigest.ConsensusEngineID != types.GrandpaEngineID {
+			continue
+		}
+
+		data := types.NewGrandpaConsensusDigest()
+		err = scale.Unmarshal(consensusDigest.Data, &data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding grandpa consensus digest: %w", err)
+		}
+
+		dataValue, err := data.Value()
+		if err != nil {
+			return nil, fmt.Errorf("getting grandpa consensus digest value: %w", err)
+		}
+
+		scheduledChange, ok := dataValue.(types.GrandpaScheduledChange)
+		if !ok {
+			continue
+		} else if scheduledChange.Delay != 0 {
+			return nil, fmt.Errorf("%w: by %d blocks", errWarpSyncDelayedSetChange, scheduledChange.Delay)
+		}
+
+		return types.NewGrandpaVotersFromAuthoritiesRaw(scheduledChange.Auths)
+	}
+
+	return nil, errWarpSyncNoSetChange
+}
@@ -0,0 +1,490 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grandpa
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/network"
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWarpSyncTestVoters(keypairs []*ed25519.Keypair) (voters []types.GrandpaVoter) {
+	voters = make([]types.GrandpaVoter, len(keypairs))
+	for i, keypair := range keypairs {
+		voters[i] = types.GrandpaVoter{
+			Key: *keypair.Public().(*ed25519.PublicKey),
+			ID:  uint64(i),
+		}
+	}
+	return voters
+}
+
+// newScheduledChangeDigest returns a digest containing a GRANDPA scheduled
+// change to the authorities with the keypairs given, with the delay given.
+func newScheduledChangeDigest(t *testing.T, keypairs []*ed25519.Keypair,
+	delay uint32) scale.VaryingDataTypeSlice {
+	t.Helper()
+
+	auths := make([]types.GrandpaAuthoritiesRaw, len(keypairs))
+	for i, keypair := range keypairs {
+		auths[i] = types.GrandpaAuthoritiesRaw{
+			Key: keypair.Public().(*ed25519.PublicKey).AsBytes(),
+			ID:  uint64(i),
+		}
+	}
+
+	grandpaDigest := types.NewGrandpaConsensusDigest()
+	err := grandpaDigest.Set(types.GrandpaScheduledChange{Auths: auths, Delay: delay})
+	require.NoError(t, err)
+	data, err := scale.Marshal(grandpaDigest)
+	require.NoError(t, err)
+
+	digest := types.NewDigest()
+	err = digest.Add(types.ConsensusDigest{
+		ConsensusEngineID: types.GrandpaEngineID,
+		Data:              data,
+	})
+	require.NoError(t, err)
+	return digest
+}
+
+// newWarpSyncTestPrecommit returns the precommit for the vote given
+// signed by the keypair given, in round 1 of the set ID given.
+func newWarpSyncTestPrecommit(t *testing.T, keypair *ed25519.Keypair, vote Vote, setID uint64) SignedVote {
+	t.Helper()
+
+	msg, err := scale.Marshal(FullVote{
+		Stage: precommit,
+		Vote:  vote,
+		Round: 1,
+		SetID: setID,
+	})
+	require.NoError(t, err)
+
+	signature, err := keypair.Sign(msg)
+	require.NoError(t, err)
+
+	signedVote := SignedVote{
+		Vote:        vote,
+		AuthorityID: keypair.Public().(*ed25519.PublicKey).AsBytes(),
+	}
+	copy(signedVote.Signature[:], signature)
+	return signedVote
+}
+
+// newWarpSyncTestJustification returns the justification of the header
+// given, with a precommit for the header by each of the keypairs given.
+func newWarpSyncTestJustification(t *testing.T, header *types.Header, setID uint64,
+	keypairs []*ed25519.Keypair) types.GrandpaJustification {
+	t.Helper()
+
+	vote := *NewVoteFromHeader(header)
+	precommits := make([]SignedVote, len(keypairs))
+	for i, keypair := range keypairs {
+		precommits[i] = newWarpSyncTestPrecommit(t, keypair, vote, setID)
+	}
+
+	return types.GrandpaJustification{
+		Round: 1,
+		Commit: types.GrandpaCommit{
+			Hash:       vote.Hash,
+			Number:     vote.Number,
+			Precommits: precommits,
+		},
+	}
+}
+
+// newDescendantWarpSyncTestJustification returns a justification of block 2 of the
+// chain given with precommits of set 0 for block 3, and the vote ancestries given.
+func newDescendantWarpSyncTestJustification(t *testing.T, chain *warpSyncTestChain,
+	ancestries []types.Header) types.GrandpaJustification {
+	t.Helper()
+
+	justification := newWarpSyncTestJustification(t, chain.headers[2], 0, nil)
+	vote := *NewVoteFromHeader(chain.headers[3])
+	for _, keypair := range chain.sets[0] {
+		justification.Commit.Precommits = append(justification.Commit.Precommits,
+			newWarpSyncTestPrecommit(t, keypair, vote, 0))
+	}
+	justification.VotesAncestries = ancestries
+	return justification
+}
+
+// warpSyncTestChain is a chain of 6 blocks after the genesis block with
+// authority set 0 changing to set 1 at block 2 and to set 2 at block 4.
+type warpSyncTestChain struct {
+	headers        []*types.Header
+	justifications map[uint]types.GrandpaJustification
+	sets           [3][]*ed25519.Keypair
+}
+
+func newWarpSyncTestChain(t *testing.T) *warpSyncTestChain {
+	t.Helper()
+
+	kr, err := keystore.NewEd25519Keyring()
+	require.NoError(t, err)
+
+	chain := &warpSyncTestChain{
+		justifications: make(map[uint]types.GrandpaJustification),
+		sets:           [3][]*ed25519.Keypair{kr.Keys[0:3], kr.Keys[3:6], kr.Keys[6:9]},
+	}
+
+	digests := map[uint]scale.VaryingDataTypeSlice{
+		2: newScheduledChangeDigest(t, chain.sets[1], 0),
+		4: newScheduledChangeDigest(t, chain.sets[2], 0),
+	}
+
+	previous := testGenesisHeader
+	chain.headers = []*types.Header{previous}
+	for number := uint(1); number <= 6; number++ {
+		digest, ok := digests[number]
+		if !ok {
+			digest = types.NewDigest()
+		}
+		header := &types.Header{
+			ParentHash: previous.Hash(),
+			Number:     number,
+			Digest:     digest,
+		}
+		chain.headers = append(chain.headers, header)
+		previous = header
+	}
+
+	chain.justifications[2] = newWarpSyncTestJustification(t, chain.headers[2], 0, chain.sets[0])
+	chain.justifications[4] = newWarpSyncTestJustification(t, chain.headers[4], 1, chain.sets[1])
+	chain.justifications[6] = newWarpSyncTestJustification(t, chain.headers[6], 2, chain.sets[2])
+	return chain
+}
+
+// newWarpSyncTestService returns a service with mocked block and grandpa
+// states serving the chain given, where the justifications of the
+// block numbers given are missing.
+func newWarpSyncTestService(ctrl *gomock.Controller, chain *warpSyncTestChain,
+	missingJustifications ...uint) *Service {
+	blockState := NewMockBlockState(ctrl)
+	grandpaState := NewMockGrandpaState(ctrl)
+
+	headersByHash := make(map[interface{}]*types.Header, len(chain.headers))
+	for _, header := range chain.headers {
+		headersByHash[header.Hash()] = header
+	}
+
+	blockState.EXPECT().GetHeader(gomock.Any()).DoAndReturn(
+		func(hash interface{}) (*types.Header, error) {
+			return headersByHash[hash], nil
+		}).AnyTimes()
+	blockState.EXPECT().GetHeaderByNumber(gomock.Any()).DoAndReturn(
+		func(number uint) (*types.Header, error) {
+			return chain.headers[number], nil
+		}).AnyTimes()
+	blockState.EXPECT().GetHighestFinalisedHeader().
+		Return(chain.headers[len(chain.headers)-1], nil).AnyTimes()
+
+	missing := make(map[uint]struct{}, len(missingJustifications))
+	for _, number := range missingJustifications {
+		missing[number] = struct{}{}
+	}
+	blockState.EXPECT().GetJustification(gomock.Any()).DoAndReturn(
+		func(hash interface{}) ([]byte, error) {
+			number := headersByHash[hash].Number
+			justification, ok := chain.justifications[number]
+			if _, isMissing := missing[number]; isMissing || !ok {
+				return nil, state.ErrJustificationNotFound
+			}
+			return scale.Marshal(justification)
+		}).AnyTimes()
+
+	grandpaState.EXPECT().GetCurrentSetID().Return(uint64(2), nil).AnyTimes()
+	grandpaState.EXPECT().GetSetIDChange(uint64(1)).Return(uint(2), nil).AnyTimes()
+	grandpaState.EXPECT().GetSetIDChange(uint64(2)).Return(uint(4), nil).AnyTimes()
+	grandpaState.EXPECT().GetSetIDByBlockNumber(gomock.Any()).DoAndReturn(
+		func(number uint) (uint64, error) {
+			switch {
+			case number <= 2:
+				return 0, nil
+			case number <= 4:
+				return 1, nil
+			default:
+				return 2, nil
+			}
+		}).AnyTimes()
+
+	return &Service{
+		blockState:   blockState,
+		grandpaState: grandpaState,
+	}
+}
+
+func Test_Service_WarpSyncProof_endToEnd(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	chain := newWarpSyncTestChain(t)
+	service := newWarpSyncTestService(ctrl, chain)
+
+	proof, err := service.GenerateWarpSyncProof(chain.headers[0].Hash())
+	require.NoError(t, err)
+
+	expectedProof := &network.WarpSyncProof{
+		Fragments: []network.WarpSyncFragment{
+			{Header: *chain.headers[2], Justification: chain.justifications[2]},
+			{Header: *chain.headers[4], Justification: chain.justifications[4]},
+			{Header: *chain.headers[6], Justification: chain.justifications[6]},
+		},
+		IsFinished: true,
+	}
+	assert.Equal(t, expectedProof, proof)
+
+	// The proof goes through the network encoding.
+	encoded, err := proof.Encode()
+	require.NoError(t, err)
+	proof = new(network.WarpSyncProof)
+	err = proof.Decode(encoded)
+	require.NoError(t, err)
+
+	setID, authorities, err := service.VerifyWarpSyncProof(proof, 0, newWarpSyncTestVoters(chain.sets[0]))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), setID)
+	assert.Equal(t, newWarpSyncTestVoters(chain.sets[2]), authorities)
+
+	// Starting from the block of the first set change only
+	// proves the second set change and the finalised block.
+	proof, err = service.GenerateWarpSyncProof(chain.headers[2].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, expectedProof.Fragments[1:], proof.Fragments)
+	setID, authorities, err = service.VerifyWarpSyncProof(proof, 1, newWarpSyncTestVoters(chain.sets[1]))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), setID)
+	assert.Equal(t, newWarpSyncTestVoters(chain.sets[2]), authorities)
+}
+
+func Test_Service_GenerateWarpSyncProof_gaps(t *testing.T) {
+	t.Parallel()
+
+	chain := newWarpSyncTestChain(t)
+
+	t.Run("missing_second_set_change", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		service := newWarpSyncTestService(ctrl, chain, 4)
+
+		proof, err := service.GenerateWarpSyncProof(chain.headers[0].Hash())
+		require.NoError(t, err)
+		expectedProof := &network.WarpSyncProof{
+			Fragments: []network.WarpSyncFragment{
+				{Header: *chain.headers[2], Justification: chain.justifications[2]},
+			},
+		}
+		assert.Equal(t, expectedProof, proof)
+	})
+
+	t.Run("missing_first_set_change", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		service := newWarpSyncTestService(ctrl, chain, 2)
+
+		proof, err := service.GenerateWarpSyncProof(chain.headers[0].Hash())
+		assert.ErrorIs(t, err, errWarpSyncFragmentUnavailable)
+		assert.EqualError(t, err, "getting fragment of set id 1 change: "+
+			"warp sync fragment is not available: justification of block number 2 not found")
+		assert.Nil(t, proof)
+	})
+
+	t.Run("missing_highest_finalised", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		service := newWarpSyncTestService(ctrl, chain, 6)
+
+		proof, err := service.GenerateWarpSyncProof(chain.headers[0].Hash())
+		require.NoError(t, err)
+		assert.Len(t, proof.Fragments, 2)
+		assert.True(t, proof.IsFinished)
+	})
+}
+
+func Test_Service_VerifyWarpSyncProof(t *testing.T) {
+	t.Parallel()
+
+	chain := newWarpSyncTestChain(t)
+	set0 := newWarpSyncTestVoters(chain.sets[0])
+
+	fragment := func(number uint) network.WarpSyncFragment {
+		return network.WarpSyncFragment{
+			Header:        *chain.headers[number],
+			Justification: chain.justifications[number],
+		}
+	}
+
+	unusedAncestryJustification := newWarpSyncTestJustification(t, chain.headers[2], 0, chain.sets[0])
+	unusedAncestryJustification.VotesAncestries = []types.Header{*chain.headers[3]}
+
+	forgedHeader := *chain.headers[3]
+	forgedHeader.Digest = newScheduledChangeDigest(t, chain.sets[2], 0)
+
+	delayedHeader := *chain.headers[2]
+	delayedHeader.Digest = newScheduledChangeDigest(t, chain.sets[1], 1)
+
+	testCases := map[string]struct {
+		proof      *network.WarpSyncProof
+		setID      uint64
+		errWrapped error
+		errMessage string
+	}{
+		"justification_from_wrong_set": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{fragment(4)},
+			},
+			errWrapped: ErrAuthorityNotInSet,
+			errMessage: "verifying justification of block number 4 for set id 0: " +
+				"authority is not in set",
+		},
+		"signature_for_wrong_set_id": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{fragment(2)},
+			},
+			setID:      1,
+			errWrapped: ErrInvalidSignature,
+			errMessage: "verifying justification of block number 2 for set id 1: " +
+				"signature is not valid",
+		},
+		"justification_for_other_block": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header:        forgedHeader,
+					Justification: chain.justifications[2],
+				}},
+			},
+			errWrapped: ErrJustificationMismatch,
+			errMessage: "verifying justification of block number 3 for set id 0: " +
+				"justification does not correspond to given block hash: justification " +
+				chain.headers[2].Hash().Short() + " and block hash " + forgedHeader.Hash().Short(),
+		},
+		"not_enough_signatures": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header: *chain.headers[2],
+					Justification: newWarpSyncTestJustification(t, chain.headers[2],
+						0, chain.sets[0][:1]),
+				}},
+			},
+			errWrapped: ErrMinVotesNotMet,
+			errMessage: "verifying justification of block number 2 for set id 0: " +
+				"minimum number of votes not met in a Justification: 1 distinct voters for a threshold of 3",
+		},
+		"duplicate_precommits": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header: *chain.headers[2],
+					Justification: newWarpSyncTestJustification(t, chain.headers[2],
+						0, []*ed25519.Keypair{chain.sets[0][0], chain.sets[0][0], chain.sets[0][1]}),
+				}},
+			},
+			errWrapped: ErrMinVotesNotMet,
+			errMessage: "verifying justification of block number 2 for set id 0: " +
+				"minimum number of votes not met in a Justification: 2 distinct voters for a threshold of 3",
+		},
+		"precommit_for_unproven_descendant": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header:        *chain.headers[2],
+					Justification: newDescendantWarpSyncTestJustification(t, chain, nil),
+				}},
+			},
+			errWrapped: ErrPrecommitBlockMismatch,
+			errMessage: "verifying justification of block number 2 for set id 0: " +
+				"precommit block is not descendant of committed block: precommit for block " +
+				chain.headers[3].Hash().Short() + " is not proven to descend from block " +
+				chain.headers[2].Hash().Short(),
+		},
+		"unused_vote_ancestry": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header:        *chain.headers[2],
+					Justification: unusedAncestryJustification,
+				}},
+			},
+			errWrapped: errWarpSyncUnusedAncestries,
+			errMessage: "verifying justification of block number 2 for set id 0: " +
+				"warp sync justification has unused vote ancestries: 1 vote ancestries are not used",
+		},
+		"missing_set_change": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header: *chain.headers[1],
+					Justification: newWarpSyncTestJustification(t, chain.headers[1],
+						0, chain.sets[0]),
+				}},
+			},
+			errWrapped: errWarpSyncNoSetChange,
+			errMessage: "block number 1: warp sync fragment header has no authority set change",
+		},
+		"delayed_set_change": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{{
+					Header:        delayedHeader,
+					Justification: newWarpSyncTestJustification(t, &delayedHeader, 0, chain.sets[0]),
+				}},
+			},
+			errWrapped: errWarpSyncDelayedSetChange,
+			errMessage: "block number 2: warp sync fragment authority set change is delayed: by 1 blocks",
+		},
+		"fragments_not_increasing": {
+			proof: &network.WarpSyncProof{
+				Fragments: []network.WarpSyncFragment{fragment(2), fragment(2)},
+			},
+			errWrapped: errWarpSyncFragmentNotAbove,
+			errMessage: "warp sync fragment is not above previous block: " +
+				"fragment block number 2 is not above block number 2",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			service := &Service{}
+			setID, authorities, err := service.VerifyWarpSyncProof(testCase.proof, testCase.setID, set0)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.EqualError(t, err, testCase.errMessage)
+			assert.Zero(t, setID)
+			assert.Nil(t, authorities)
+		})
+	}
+}
+
+func Test_Service_VerifyWarpSyncProof_descendantPrecommits(t *testing.T) {
+	t.Parallel()
+
+	chain := newWarpSyncTestChain(t)
+
+	// block 2 is finalised by precommits for block 3, proven
+	// to descend from block 2 by the vote ancestries.
+	justification := newDescendantWarpSyncTestJustification(t, chain, []types.Header{*chain.headers[3]})
+
+	proof := &network.WarpSyncProof{
+		Fragments: []network.WarpSyncFragment{{
+			Header:        *chain.headers[2],
+			Justification: justification,
+		}},
+	}
+
+	service := &Service{}
+	setID, authorities, err := service.VerifyWarpSyncProof(proof, 0, newWarpSyncTestVoters(chain.sets[0]))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), setID)
+	assert.Equal(t, newWarpSyncTestVoters(chain.sets[1]), authorities)
+}
@@ -22,6 +22,9 @@
 	"golang.org/x/crypto/blake2b"
 )
 
+// ErrCiphertextTooShort is returned when decrypting data shorter than the nonce.
+var ErrCiphertextTooShort = errors.New("ciphertext is too short")
+
 // EncryptedKeystore holds Type PublicKey and Ciphertext
 type EncryptedKeystore struct {
 	Type       string
@@ -70,6 +73,10 @@ func Decrypt(data, password []byte) ([]byte, error) {
 	}
 
 	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than the nonce size %d",
+			ErrCiphertextTooShort, len(data), nonceSize)
+	}
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
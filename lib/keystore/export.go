@@ -0,0 +1,192 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/utils"
+)
+
+// keystoreExportVersion is the version of the keystore export file format.
+const keystoreExportVersion = 1
+
+var (
+	// ErrEmptyPassphrase is returned when exporting or importing
+	// the keystore with an empty passphrase.
+	ErrEmptyPassphrase = errors.New("passphrase cannot be empty")
+	// ErrExportAuthentication is returned when the keystore export cannot
+	// be decrypted, because the passphrase is wrong or the file was tampered with.
+	ErrExportAuthentication = errors.New("authenticating keystore export failed: wrong passphrase or tampered file")
+	// ErrExportVersionUnsupported is returned when importing a keystore
+	// export with a file format version not supported.
+	ErrExportVersionUnsupported = errors.New("keystore export version is not supported")
+	// ErrKeyFileInvalid is returned when a key file is not a valid encrypted keystore.
+	ErrKeyFileInvalid = errors.New("key file is invalid")
+)
+
+// EncryptedKeystoreExport is the content of a keystore export file.
+type EncryptedKeystoreExport struct {
+	Version uint
+	// Ciphertext is the JSON encoding of the encrypted keystores
+	// of the exported key files, encrypted with the export passphrase.
+	Ciphertext []byte
+}
+
+// ExportKeystore writes all the key files of the keystore in the basepath
+// given to a single file at the output path, encrypted with the passphrase given.
+// The key files are kept encrypted with their own password, such that no
+// unencrypted secret is ever written to disk.
+// It returns the number of keys exported.
+func ExportKeystore(basepath, outputPath string, passphrase []byte) (keys int, err error) {
+	if len(passphrase) == 0 {
+		return 0, ErrEmptyPassphrase
+	}
+
+	keyDir, err := utils.KeystoreDir(basepath)
+	if err != nil {
+		return 0, fmt.Errorf("getting keystore directory: %w", err)
+	}
+
+	keyFiles, err := utils.KeystoreFiles(basepath)
+	if err != nil {
+		return 0, fmt.Errorf("listing key files: %w", err)
+	}
+
+	encryptedKeystores := make([]EncryptedKeystore, len(keyFiles))
+	for i, keyFile := range keyFiles {
+		data, err := os.ReadFile(filepath.Join(keyDir, keyFile))
+		if err != nil {
+			return 0, fmt.Errorf("reading key file: %w", err)
+		}
+
+		err = json.Unmarshal(data, &encryptedKeystores[i])
+		if err != nil {
+			return 0, fmt.Errorf("decoding key file %s: %w", keyFile, err)
+		}
+
+		err = validateEncryptedKeystore(encryptedKeystores[i])
+		if err != nil {
+			return 0, fmt.Errorf("validating key file %s: %w", keyFile, err)
+		}
+	}
+
+	plaintext, err := json.Marshal(encryptedKeystores)
+	if err != nil {
+		return 0, fmt.Errorf("encoding key files: %w", err)
+	}
+
+	ciphertext, err := Encrypt(plaintext, passphrase)
+	if err != nil {
+		return 0, fmt.Errorf("encrypting key files: %w", err)
+	}
+
+	export := EncryptedKeystoreExport{
+		Version:    keystoreExportVersion,
+		Ciphertext: ciphertext,
+	}
+	data, err := json.MarshalIndent(export, "", "\t")
+	if err != nil {
+		return 0, fmt.Errorf("encoding keystore export: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Clean(outputPath), append(data, byte('\n')), 0600)
+	if err != nil {
+		return 0, fmt.Errorf("writing keystore export: %w", err)
+	}
+
+	return len(encryptedKeystores), nil
+}
+
+// ImportKeystore decrypts the keystore export file at the input path given
+// with the passphrase given, and writes its key files to the keystore in
+// the basepath given, as "[publickey].key" like ImportKeypair does.
+// It returns an error wrapping ErrExportAuthentication if the passphrase
+// is wrong or the file was tampered with, in which case no key is imported.
+// It returns the number of keys imported.
+func ImportKeystore(inputPath, basepath string, passphrase []byte) (keys int, err error) {
+	if len(passphrase) == 0 {
+		return 0, ErrEmptyPassphrase
+	}
+
+	data, err := os.ReadFile(filepath.Clean(inputPath))
+	if err != nil {
+		return 0, fmt.Errorf("reading keystore export: %w", err)
+	}
+
+	var export EncryptedKeystoreExport
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		return 0, fmt.Errorf("decoding keystore export: %w", err)
+	}
+
+	if export.Version != keystoreExportVersion {
+		return 0, fmt.Errorf("%w: %d", ErrExportVersionUnsupported, export.Version)
+	}
+
+	plaintext, err := Decrypt(export.Ciphertext, passphrase)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrExportAuthentication, err)
+	}
+
+	var encryptedKeystores []EncryptedKeystore
+	err = json.Unmarshal(plaintext, &encryptedKeystores)
+	if err != nil {
+		return 0, fmt.Errorf("decoding key files: %w", err)
+	}
+
+	// All the keys are validated before writing any key file,
+	// such that either all the keys or no key are imported.
+	for _, encryptedKeystore := range encryptedKeystores {
+		err = validateEncryptedKeystore(encryptedKeystore)
+		if err != nil {
+			return 0, fmt.Errorf("validating encrypted keystore: %w", err)
+		}
+	}
+
+	keyDir, err := utils.KeystoreDir(basepath)
+	if err != nil {
+		return 0, fmt.Errorf("getting keystore directory: %w", err)
+	}
+
+	for _, encryptedKeystore := range encryptedKeystores {
+		keyData, err := json.MarshalIndent(encryptedKeystore, "", "\t")
+		if err != nil {
+			return keys, fmt.Errorf("encoding encrypted keystore: %w", err)
+		}
+
+		keyFilePath := filepath.Join(keyDir, encryptedKeystore.PublicKey[2:]+".key")
+		err = os.WriteFile(keyFilePath, append(keyData, byte('\n')), 0600)
+		if err != nil {
+			return keys, fmt.Errorf("writing key file: %w", err)
+		}
+		keys++
+	}
+
+	return keys, nil
+}
+
+// validateEncryptedKeystore checks the encrypted keystore given has a
+// supported key type and a hex encoded public key, since the public key
+// is used as the key file name.
+func validateEncryptedKeystore(encryptedKeystore EncryptedKeystore) (err error) {
+	switch encryptedKeystore.Type {
+	case crypto.Ed25519Type, crypto.Sr25519Type, crypto.Secp256k1Type:
+	default:
+		return fmt.Errorf("%w: unsupported key type %q", ErrKeyFileInvalid, encryptedKeystore.Type)
+	}
+
+	publicKey, err := common.HexToBytes(encryptedKeystore.PublicKey)
+	if err != nil || len(publicKey) == 0 {
+		return fmt.Errorf("%w: public key %q is not hex encoded", ErrKeyFileInvalid, encryptedKeystore.PublicKey)
+	}
+
+	return nil
+}
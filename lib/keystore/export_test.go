@@ -0,0 +1,171 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package keystore
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestKeystore generates keys in the keystore of a new base path,
+// encrypted with different passwords. It returns the base path, and the
+// key file paths generated and their password.
+func newTestKeystore(t *testing.T) (basepath string, keyFilePasswords map[string][]byte) {
+	t.Helper()
+
+	basepath = t.TempDir()
+	keyFilePasswords = make(map[string][]byte)
+	for _, keytype := range []string{crypto.Sr25519Type, crypto.Ed25519Type, crypto.Secp256k1Type} {
+		password := []byte("password-" + keytype)
+		keyFile, err := GenerateKeypair(keytype, nil, basepath, password)
+		require.NoError(t, err)
+		keyFilePasswords[keyFile] = password
+	}
+	return basepath, keyFilePasswords
+}
+
+func Test_ExportKeystore_ImportKeystore(t *testing.T) {
+	t.Parallel()
+
+	sourceBasepath, keyFilePasswords := newTestKeystore(t)
+	exportPath := filepath.Join(t.TempDir(), "export.json")
+	passphrase := []byte("export-passphrase")
+
+	exported, err := ExportKeystore(sourceBasepath, exportPath, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, len(keyFilePasswords), exported)
+
+	// The export file does not contain the key files in clear.
+	exportData, err := os.ReadFile(exportPath)
+	require.NoError(t, err)
+	for keyFile := range keyFilePasswords {
+		publicKeyHex := strings.TrimSuffix(filepath.Base(keyFile), ".key")
+		assert.NotContains(t, string(exportData), publicKeyHex)
+	}
+	info, err := os.Stat(exportPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	targetBasepath := t.TempDir()
+	imported, err := ImportKeystore(exportPath, targetBasepath, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, exported, imported)
+
+	targetKeyDir, err := utils.KeystoreDir(targetBasepath)
+	require.NoError(t, err)
+	for keyFile, password := range keyFilePasswords {
+		importedKeyFile := filepath.Join(targetKeyDir, filepath.Base(keyFile))
+
+		expectedData, err := os.ReadFile(keyFile)
+		require.NoError(t, err)
+		data, err := os.ReadFile(importedKeyFile)
+		require.NoError(t, err)
+		assert.Equal(t, expectedData, data)
+
+		expectedPrivateKey, err := ReadFromFileAndDecrypt(keyFile, password)
+		require.NoError(t, err)
+		privateKey, err := ReadFromFileAndDecrypt(importedKeyFile, password)
+		require.NoError(t, err)
+		assert.Equal(t, expectedPrivateKey.Encode(), privateKey.Encode())
+	}
+}
+
+func Test_ImportKeystore_errors(t *testing.T) {
+	t.Parallel()
+
+	sourceBasepath, _ := newTestKeystore(t)
+	exportPath := filepath.Join(t.TempDir(), "export.json")
+	passphrase := []byte("export-passphrase")
+	_, err := ExportKeystore(sourceBasepath, exportPath, passphrase)
+	require.NoError(t, err)
+
+	exportData, err := os.ReadFile(exportPath)
+	require.NoError(t, err)
+	var export EncryptedKeystoreExport
+	err = json.Unmarshal(exportData, &export)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		modifyExport func(export *EncryptedKeystoreExport)
+		passphrase   []byte
+		errWrapped   error
+		errMessage   string
+	}{
+		"empty_passphrase": {
+			errWrapped: ErrEmptyPassphrase,
+			errMessage: "passphrase cannot be empty",
+		},
+		"wrong_passphrase": {
+			passphrase: []byte("wrong-passphrase"),
+			errWrapped: ErrExportAuthentication,
+			errMessage: "authenticating keystore export failed: wrong passphrase or tampered file: " +
+				"cipher: message authentication failed",
+		},
+		"tampered_ciphertext": {
+			modifyExport: func(export *EncryptedKeystoreExport) {
+				export.Ciphertext[len(export.Ciphertext)/2] ^= 0xff
+			},
+			passphrase: passphrase,
+			errWrapped: ErrExportAuthentication,
+			errMessage: "authenticating keystore export failed: wrong passphrase or tampered file: " +
+				"cipher: message authentication failed",
+		},
+		"truncated_ciphertext": {
+			modifyExport: func(export *EncryptedKeystoreExport) {
+				export.Ciphertext = export.Ciphertext[:4]
+			},
+			passphrase: passphrase,
+			errWrapped: ErrExportAuthentication,
+			errMessage: "authenticating keystore export failed: wrong passphrase or tampered file: " +
+				"ciphertext is too short: 4 bytes is shorter than the nonce size 12",
+		},
+		"unsupported_version": {
+			modifyExport: func(export *EncryptedKeystoreExport) {
+				export.Version = 2
+			},
+			passphrase: passphrase,
+			errWrapped: ErrExportVersionUnsupported,
+			errMessage: "keystore export version is not supported: 2",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			modifiedExport := EncryptedKeystoreExport{
+				Version:    export.Version,
+				Ciphertext: append([]byte(nil), export.Ciphertext...),
+			}
+			if testCase.modifyExport != nil {
+				testCase.modifyExport(&modifiedExport)
+			}
+			data, err := json.Marshal(modifiedExport)
+			require.NoError(t, err)
+			inputPath := filepath.Join(t.TempDir(), "export.json")
+			err = os.WriteFile(inputPath, data, 0600)
+			require.NoError(t, err)
+
+			targetBasepath := t.TempDir()
+			imported, err := ImportKeystore(inputPath, targetBasepath, testCase.passphrase)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.EqualError(t, err, testCase.errMessage)
+			assert.Zero(t, imported)
+
+			keyFiles, err := utils.KeystoreFiles(targetBasepath)
+			require.NoError(t, err)
+			assert.Empty(t, keyFiles)
+		})
+	}
+}
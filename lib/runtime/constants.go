@@ -48,10 +48,14 @@
 	BabeAPIConfiguration = "BabeApi_configuration"
 	// BlockBuilderInherentExtrinsics is the runtime API call BlockBuilder_inherent_extrinsics
 	BlockBuilderInherentExtrinsics = "BlockBuilder_inherent_extrinsics"
+	// BlockBuilderCheckInherents is the runtime API call BlockBuilder_check_inherents
+	BlockBuilderCheckInherents = "BlockBuilder_check_inherents"
 	// BlockBuilderApplyExtrinsic is the runtime API call BlockBuilder_apply_extrinsic
 	BlockBuilderApplyExtrinsic = "BlockBuilder_apply_extrinsic"
 	// BlockBuilderFinalizeBlock is the runtime API call BlockBuilder_finalize_block
 	BlockBuilderFinalizeBlock = "BlockBuilder_finalize_block"
+	// OffchainWorkerAPIOffchainWorker is the runtime API call OffchainWorkerApi_offchain_worker
+	OffchainWorkerAPIOffchainWorker = "OffchainWorkerApi_offchain_worker"
 	// DecodeSessionKeys is the runtime API call SessionKeys_decode_session_keys
 	DecodeSessionKeys = "SessionKeys_decode_session_keys"
 	// TransactionPaymentAPIQueryInfo returns information of a given extrinsic
@@ -60,4 +64,8 @@
 	TransactionPaymentCallAPIQueryCallInfo = "TransactionPaymentCallApi_query_call_info"
 	// TransactionPaymentCallAPIQueryCallFeeDetails returns call query call fee details
 	TransactionPaymentCallAPIQueryCallFeeDetails = "TransactionPaymentCallApi_query_call_fee_details"
+	// GenesisBuilderCreateDefaultConfig is the runtime API call GenesisBuilder_create_default_config
+	GenesisBuilderCreateDefaultConfig = "GenesisBuilder_create_default_config"
+	// GenesisBuilderBuildConfig is the runtime API call GenesisBuilder_build_config
+	GenesisBuilderBuildConfig = "GenesisBuilder_build_config"
 )
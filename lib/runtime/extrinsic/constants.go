@@ -0,0 +1,52 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"errors"
+	"fmt"
+
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+var (
+	errConstantNotFound      = errors.New("constant not found")
+	errTrailingConstantBytes = errors.New("trailing bytes after constant")
+)
+
+// DecodeConstant decodes the value of the constant with the given name of the
+// pallet with the given name, using the type of the constant in the runtime
+// metadata given, which must be in the version 14 format.
+func DecodeConstant(metadata *ctypes.Metadata, palletName, constantName string) (
+	value interface{}, err error) {
+	if metadata.Version != 14 {
+		return nil, fmt.Errorf("%w: %d", ErrMetadataVersion, metadata.Version)
+	}
+
+	for _, pallet := range metadata.AsMetadataV14.Pallets {
+		if string(pallet.Name) != palletName {
+			continue
+		}
+
+		for _, constant := range pallet.Constants {
+			if string(constant.Name) != constantName {
+				continue
+			}
+
+			d := newDecoder(&metadata.AsMetadataV14, constant.Value)
+			value, err = d.decode(constant.Type)
+			if err != nil {
+				return nil, fmt.Errorf("decoding constant %s.%s: %w", palletName, constantName, err)
+			} else if d.reader.Len() > 0 {
+				return nil, fmt.Errorf("%w: %d bytes for constant %s.%s",
+					errTrailingConstantBytes, d.reader.Len(), palletName, constantName)
+			}
+			return value, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s.%s", errConstantNotFound, palletName, constantName)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrPalletNotFound, palletName)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"testing"
+
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DecodeConstant(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		metadata     *ctypes.Metadata
+		palletName   string
+		constantName string
+		value        interface{}
+		errWrapped   error
+		errMessage   string
+	}{
+		"metadata_version_not_supported": {
+			metadata:   &ctypes.Metadata{Version: 12},
+			errWrapped: ErrMetadataVersion,
+			errMessage: "metadata version is not supported: 12",
+		},
+		"pallet_not_found": {
+			metadata:     newTestMetadata(),
+			palletName:   "Timestamp",
+			constantName: "MinimumPeriod",
+			errWrapped:   ErrPalletNotFound,
+			errMessage:   "pallet not found: Timestamp",
+		},
+		"constant_not_found": {
+			metadata:     newTestMetadata(),
+			palletName:   "System",
+			constantName: "BlockWeights",
+			errWrapped:   errConstantNotFound,
+			errMessage:   "constant not found: System.BlockWeights",
+		},
+		"trailing_bytes": {
+			metadata: func() *ctypes.Metadata {
+				metadata := newTestMetadata()
+				constant := &metadata.AsMetadataV14.Pallets[0].Constants[0]
+				constant.Value = append(constant.Value, 0)
+				return metadata
+			}(),
+			palletName:   "System",
+			constantName: "BlockHashCount",
+			errWrapped:   errTrailingConstantBytes,
+			errMessage:   "trailing bytes after constant: 1 bytes for constant System.BlockHashCount",
+		},
+		"constant_decoded": {
+			metadata:     newTestMetadata(),
+			palletName:   "System",
+			constantName: "BlockHashCount",
+			value:        uint32(2400),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := DecodeConstant(testCase.metadata, testCase.palletName, testCase.constantName)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.value, value)
+		})
+	}
+}
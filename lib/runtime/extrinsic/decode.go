@@ -0,0 +1,450 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+const (
+	supportedExtrinsicVersion = 4
+	signedBitMask             = 0b1000_0000
+)
+
+var (
+	// ErrMetadataVersion is returned when the metadata given
+	// is not in the version 14 format.
+	ErrMetadataVersion = errors.New("metadata version is not supported")
+	// ErrExtrinsicVersion is returned when the extrinsic
+	// version is not supported.
+	ErrExtrinsicVersion = errors.New("extrinsic version is not supported")
+	// ErrPalletNotFound is returned when no pallet with calls
+	// is found in the metadata for the call pallet index.
+	ErrPalletNotFound = errors.New("pallet not found")
+	// ErrCallNotFound is returned when no call is found
+	// in the metadata of the pallet for the call index.
+	ErrCallNotFound = errors.New("call not found")
+
+	errLengthMismatch         = errors.New("extrinsic length mismatch")
+	errTrailingBytes          = errors.New("trailing bytes after extrinsic")
+	errTypeNotFound           = errors.New("type not found in metadata")
+	errTypeNotSupported       = errors.New("type definition not supported")
+	errVariantNotFound        = errors.New("variant not found")
+	errSequenceTooLong        = errors.New("sequence length exceeds remaining bytes")
+	errExtrinsicParamNotFound = errors.New("extrinsic type parameter not found")
+)
+
+// Decode decodes the SCALE encoded extrinsic given, prefixed with its
+// compact encoded length, using the runtime metadata given, which must
+// be in the version 14 format. The call arguments, the signature and
+// its signed extensions are decoded using the types of the metadata.
+func Decode(metadata *ctypes.Metadata, encoded []byte) (extrinsic Extrinsic, err error) {
+	if metadata.Version != 14 {
+		return extrinsic, fmt.Errorf("%w: %d", ErrMetadataVersion, metadata.Version)
+	}
+
+	d := newDecoder(&metadata.AsMetadataV14, encoded)
+
+	length, err := d.decodeCompact()
+	if err != nil {
+		return extrinsic, fmt.Errorf("decoding length: %w", err)
+	}
+	if !length.IsUint64() || length.Uint64() != uint64(d.reader.Len()) {
+		return extrinsic, fmt.Errorf("%w: encoded length %s but %d bytes remaining",
+			errLengthMismatch, length, d.reader.Len())
+	}
+
+	versionByte, err := d.reader.ReadByte()
+	if err != nil {
+		return extrinsic, fmt.Errorf("reading version: %w", err)
+	}
+	extrinsic.Version = versionByte &^ signedBitMask
+	if extrinsic.Version != supportedExtrinsicVersion {
+		return extrinsic, fmt.Errorf("%w: %d", ErrExtrinsicVersion, extrinsic.Version)
+	}
+
+	if versionByte&signedBitMask != 0 {
+		extrinsic.Signature, err = d.decodeSignature()
+		if err != nil {
+			return extrinsic, fmt.Errorf("decoding signature: %w", err)
+		}
+	}
+
+	extrinsic.Call, err = d.decodeCall()
+	if err != nil {
+		return extrinsic, fmt.Errorf("decoding call: %w", err)
+	}
+
+	if d.reader.Len() > 0 {
+		return extrinsic, fmt.Errorf("%w: %d bytes", errTrailingBytes, d.reader.Len())
+	}
+
+	return extrinsic, nil
+}
+
+type decoder struct {
+	metadata *ctypes.MetadataV14
+	types    map[int64]*ctypes.Si1Type
+	reader   *bytes.Reader
+}
+
+func newDecoder(metadata *ctypes.MetadataV14, encoded []byte) *decoder {
+	types := make(map[int64]*ctypes.Si1Type, len(metadata.Lookup.Types))
+	for i := range metadata.Lookup.Types {
+		portableType := &metadata.Lookup.Types[i]
+		types[typeID(portableType.ID)] = &portableType.Type
+	}
+
+	return &decoder{
+		metadata: metadata,
+		types:    types,
+		reader:   bytes.NewReader(encoded),
+	}
+}
+
+func typeID(id ctypes.Si1LookupTypeID) int64 {
+	n := big.Int(id.UCompact)
+	return n.Int64()
+}
+
+// decodeSignature decodes the signer address, the signature and the values
+// of the signed extensions, using the types of the extrinsic type parameters
+// and of the signed extensions of the metadata.
+func (d *decoder) decodeSignature() (signature *Signature, err error) {
+	addressType, err := d.extrinsicParamType("Address")
+	if err != nil {
+		return nil, err
+	}
+	signatureType, err := d.extrinsicParamType("Signature")
+	if err != nil {
+		return nil, err
+	}
+
+	signature = new(Signature)
+	signature.Address, err = d.decode(addressType)
+	if err != nil {
+		return nil, fmt.Errorf("decoding address: %w", err)
+	}
+
+	signature.Signature, err = d.decode(signatureType)
+	if err != nil {
+		return nil, fmt.Errorf("decoding signature: %w", err)
+	}
+
+	for _, signedExtension := range d.metadata.Extrinsic.SignedExtensions {
+		remaining := d.reader.Len()
+		value, err := d.decode(signedExtension.Type)
+		if err != nil {
+			return nil, fmt.Errorf("decoding signed extension %s: %w", signedExtension.Identifier, err)
+		}
+		if d.reader.Len() == remaining {
+			continue
+		}
+		signature.Extra = append(signature.Extra, Field{
+			Name:  string(signedExtension.Identifier),
+			Value: value,
+		})
+	}
+
+	return signature, nil
+}
+
+// extrinsicParamType returns the type of the type parameter
+// with the given name of the extrinsic type of the metadata.
+func (d *decoder) extrinsicParamType(name string) (id ctypes.Si1LookupTypeID, err error) {
+	extrinsicType, ok := d.types[typeID(d.metadata.Extrinsic.Type)]
+	if !ok {
+		return id, fmt.Errorf("%w: extrinsic type %d", errTypeNotFound, typeID(d.metadata.Extrinsic.Type))
+	}
+
+	for _, param := range extrinsicType.Params {
+		if string(param.Name) == name && param.HasType {
+			return param.Type, nil
+		}
+	}
+	return id, fmt.Errorf("%w: %s", errExtrinsicParamNotFound, name)
+}
+
+// decodeCall decodes the pallet index and the call index of the call,
+// and its arguments using the types of the call of the pallet metadata.
+func (d *decoder) decodeCall() (call Call, err error) {
+	palletIndex, err := d.reader.ReadByte()
+	if err != nil {
+		return call, fmt.Errorf("reading pallet index: %w", err)
+	}
+
+	var pallet *ctypes.PalletMetadataV14
+	for i := range d.metadata.Pallets {
+		if d.metadata.Pallets[i].HasCalls && uint8(d.metadata.Pallets[i].Index) == palletIndex {
+			pallet = &d.metadata.Pallets[i]
+			break
+		}
+	}
+	if pallet == nil {
+		return call, fmt.Errorf("%w: for index %d", ErrPalletNotFound, palletIndex)
+	}
+	call.Pallet = string(pallet.Name)
+
+	callsType, ok := d.types[typeID(pallet.Calls.Type)]
+	if !ok || !callsType.Def.IsVariant {
+		return call, fmt.Errorf("%w: calls variant type %d of pallet %s",
+			errTypeNotFound, typeID(pallet.Calls.Type), call.Pallet)
+	}
+
+	callIndex, err := d.reader.ReadByte()
+	if err != nil {
+		return call, fmt.Errorf("reading call index: %w", err)
+	}
+
+	variant := findVariant(callsType.Def.Variant, callIndex)
+	if variant == nil {
+		return call, fmt.Errorf("%w: for index %d in pallet %s", ErrCallNotFound, callIndex, call.Pallet)
+	}
+	call.Name = string(variant.Name)
+
+	call.Args, err = d.decodeFields(variant.Fields)
+	if err != nil {
+		return call, fmt.Errorf("decoding arguments of %s.%s: %w", call.Pallet, call.Name, err)
+	}
+
+	return call, nil
+}
+
+func findVariant(variantDef ctypes.Si1TypeDefVariant, index byte) (variant *ctypes.Si1Variant) {
+	for i := range variantDef.Variants {
+		if uint8(variantDef.Variants[i].Index) == index {
+			return &variantDef.Variants[i]
+		}
+	}
+	return nil
+}
+
+// decode decodes a value of the type with the given identifier. Composite
+// values are decoded as a slice of fields, except composites with a single
+// unnamed field which are decoded as their field value. Enum values are
+// decoded as a Variant, sequences and arrays of bytes as Bytes, other
+// sequences, arrays and tuples as a slice of values, compact encoded
+// integers and 128 and 256 bits integers as a *big.Int, and the other
+// primitive values as their corresponding Go type.
+func (d *decoder) decode(id ctypes.Si1LookupTypeID) (value interface{}, err error) {
+	t, ok := d.types[typeID(id)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", errTypeNotFound, typeID(id))
+	}
+
+	def := t.Def
+	switch {
+	case def.IsComposite:
+		fields := def.Composite.Fields
+		if len(fields) == 1 && !fields[0].HasName {
+			return d.decode(fields[0].Type)
+		}
+		return d.decodeFields(fields)
+	case def.IsVariant:
+		index, err := d.reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading variant index: %w", err)
+		}
+		variant := findVariant(def.Variant, index)
+		if variant == nil {
+			return nil, fmt.Errorf("%w: for index %d of type %d", errVariantNotFound, index, typeID(id))
+		}
+		fields, err := d.decodeFields(variant.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("decoding variant %s: %w", variant.Name, err)
+		}
+		return Variant{Name: string(variant.Name), Fields: fields}, nil
+	case def.IsSequence:
+		length, err := d.decodeCompact()
+		if err != nil {
+			return nil, fmt.Errorf("decoding sequence length: %w", err)
+		}
+		// Each element is encoded with at least one byte, except zero
+		// sized elements which are not expected in extrinsics.
+		if !length.IsUint64() || length.Uint64() > uint64(d.reader.Len()) {
+			return nil, fmt.Errorf("%w: %s elements for %d bytes",
+				errSequenceTooLong, length, d.reader.Len())
+		}
+		return d.decodeElements(def.Sequence.Type, int(length.Uint64()))
+	case def.IsArray:
+		return d.decodeElements(def.Array.Type, int(def.Array.Len))
+	case def.IsTuple:
+		values := make([]interface{}, len(def.Tuple))
+		for i, elementType := range def.Tuple {
+			values[i], err = d.decode(elementType)
+			if err != nil {
+				return nil, fmt.Errorf("decoding tuple element %d: %w", i, err)
+			}
+		}
+		return values, nil
+	case def.IsPrimitive:
+		return d.decodePrimitive(def.Primitive.Si0TypeDefPrimitive)
+	case def.IsCompact:
+		return d.decodeCompact()
+	default:
+		return nil, fmt.Errorf("%w: for type %d", errTypeNotSupported, typeID(id))
+	}
+}
+
+func (d *decoder) decodeFields(metadataFields []ctypes.Si1Field) (fields []Field, err error) {
+	for _, metadataField := range metadataFields {
+		value, err := d.decode(metadataField.Type)
+		if err != nil {
+			return nil, fmt.Errorf("decoding field %s: %w", metadataField.Name, err)
+		}
+		fields = append(fields, Field{
+			Name:  string(metadataField.Name),
+			Value: value,
+		})
+	}
+	return fields, nil
+}
+
+func (d *decoder) decodeElements(elementType ctypes.Si1LookupTypeID, length int) (
+	value interface{}, err error) {
+	if d.isByteType(elementType) {
+		if length > d.reader.Len() {
+			return nil, fmt.Errorf("reading %d bytes: %w", length, io.ErrUnexpectedEOF)
+		}
+		b := make(Bytes, length)
+		_, _ = d.reader.Read(b)
+		return b, nil
+	}
+
+	values := make([]interface{}, length)
+	for i := range values {
+		values[i], err = d.decode(elementType)
+		if err != nil {
+			return nil, fmt.Errorf("decoding element %d: %w", i, err)
+		}
+	}
+	return values, nil
+}
+
+func (d *decoder) isByteType(id ctypes.Si1LookupTypeID) bool {
+	t, ok := d.types[typeID(id)]
+	return ok && t.Def.IsPrimitive && t.Def.Primitive.Si0TypeDefPrimitive == ctypes.IsU8
+}
+
+func (d *decoder) decodePrimitive(primitive ctypes.Si0TypeDefPrimitive) (value interface{}, err error) {
+	switch primitive {
+	case ctypes.IsBool:
+		b, err := d.reader.ReadByte()
+		return b != 0, err
+	case ctypes.IsChar:
+		b, err := d.readLittleEndian(4)
+		return string(rune(binary.LittleEndian.Uint32(b))), err
+	case ctypes.IsStr:
+		length, err := d.decodeCompact()
+		if err != nil {
+			return nil, fmt.Errorf("decoding string length: %w", err)
+		}
+		if !length.IsUint64() || length.Uint64() > uint64(d.reader.Len()) {
+			return nil, fmt.Errorf("reading %s string bytes: %w", length, io.ErrUnexpectedEOF)
+		}
+		b, err := d.readLittleEndian(int(length.Uint64()))
+		return string(b), err
+	case ctypes.IsU8:
+		return d.reader.ReadByte()
+	case ctypes.IsU16:
+		b, err := d.readLittleEndian(2)
+		return binary.LittleEndian.Uint16(b), err
+	case ctypes.IsU32:
+		b, err := d.readLittleEndian(4)
+		return binary.LittleEndian.Uint32(b), err
+	case ctypes.IsU64:
+		b, err := d.readLittleEndian(8)
+		return binary.LittleEndian.Uint64(b), err
+	case ctypes.IsU128:
+		return d.decodeBigInt(16, false)
+	case ctypes.IsU256:
+		return d.decodeBigInt(32, false)
+	case ctypes.IsI8:
+		b, err := d.reader.ReadByte()
+		return int8(b), err
+	case ctypes.IsI16:
+		b, err := d.readLittleEndian(2)
+		return int16(binary.LittleEndian.Uint16(b)), err
+	case ctypes.IsI32:
+		b, err := d.readLittleEndian(4)
+		return int32(binary.LittleEndian.Uint32(b)), err
+	case ctypes.IsI64:
+		b, err := d.readLittleEndian(8)
+		return int64(binary.LittleEndian.Uint64(b)), err
+	case ctypes.IsI128:
+		return d.decodeBigInt(16, true)
+	case ctypes.IsI256:
+		return d.decodeBigInt(32, true)
+	default:
+		return nil, fmt.Errorf("%w: primitive %d", errTypeNotSupported, primitive)
+	}
+}
+
+// readLittleEndian reads the given number of bytes, and always returns
+// a slice of this length such that it can be decoded even on error.
+func (d *decoder) readLittleEndian(length int) (b []byte, err error) {
+	b = make([]byte, length)
+	_, err = io.ReadFull(d.reader, b)
+	if err != nil {
+		return b, fmt.Errorf("reading %d bytes: %w", length, err)
+	}
+	return b, nil
+}
+
+// decodeBigInt decodes a little endian integer of the given byte length,
+// using the two's complement representation if it is signed.
+func (d *decoder) decodeBigInt(length int, signed bool) (n *big.Int, err error) {
+	b, err := d.readLittleEndian(length)
+	if err != nil {
+		return nil, err
+	}
+
+	bigEndian := make([]byte, length)
+	for i := range b {
+		bigEndian[length-1-i] = b[i]
+	}
+	n = new(big.Int).SetBytes(bigEndian)
+
+	if signed && bigEndian[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*length)))
+	}
+	return n, nil
+}
+
+// decodeCompact decodes a SCALE compact encoded unsigned integer.
+func (d *decoder) decodeCompact() (n *big.Int, err error) {
+	first, err := d.reader.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("reading compact first byte: %w", err)
+	}
+
+	switch first & 0b11 {
+	case 0b00:
+		return new(big.Int).SetUint64(uint64(first >> 2)), nil
+	case 0b01:
+		second, err := d.reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading compact second byte: %w", err)
+		}
+		value := binary.LittleEndian.Uint16([]byte{first, second}) >> 2
+		return new(big.Int).SetUint64(uint64(value)), nil
+	case 0b10:
+		rest, err := d.readLittleEndian(3)
+		if err != nil {
+			return nil, err
+		}
+		value := binary.LittleEndian.Uint32(append([]byte{first}, rest...)) >> 2
+		return new(big.Int).SetUint64(uint64(value)), nil
+	default:
+		length := int(first>>2) + 4
+		return d.decodeBigInt(length, false)
+	}
+}
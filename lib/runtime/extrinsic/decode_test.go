@@ -0,0 +1,326 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lookupID(id uint64) ctypes.Si1LookupTypeID {
+	return ctypes.Si1LookupTypeID{UCompact: ctypes.NewUCompactFromUInt(id)}
+}
+
+func primitiveType(primitive ctypes.Si0TypeDefPrimitive) ctypes.Si1Type {
+	return ctypes.Si1Type{Def: ctypes.Si1TypeDef{
+		IsPrimitive: true,
+		Primitive:   ctypes.Si1TypeDefPrimitive{Si0TypeDefPrimitive: primitive},
+	}}
+}
+
+func compactType(id uint64) ctypes.Si1Type {
+	return ctypes.Si1Type{Def: ctypes.Si1TypeDef{
+		IsCompact: true,
+		Compact:   ctypes.Si1TypeDefCompact{Type: lookupID(id)},
+	}}
+}
+
+func arrayType(length uint32, id uint64) ctypes.Si1Type {
+	return ctypes.Si1Type{Def: ctypes.Si1TypeDef{
+		IsArray: true,
+		Array:   ctypes.Si1TypeDefArray{Len: ctypes.U32(length), Type: lookupID(id)},
+	}}
+}
+
+func field(name string, id uint64) ctypes.Si1Field {
+	return ctypes.Si1Field{
+		HasName: name != "",
+		Name:    ctypes.Text(name),
+		Type:    lookupID(id),
+	}
+}
+
+func compositeType(fields ...ctypes.Si1Field) ctypes.Si1Type {
+	return ctypes.Si1Type{Def: ctypes.Si1TypeDef{
+		IsComposite: true,
+		Composite:   ctypes.Si1TypeDefComposite{Fields: fields},
+	}}
+}
+
+func variant(name string, index uint8, fields ...ctypes.Si1Field) ctypes.Si1Variant {
+	return ctypes.Si1Variant{
+		Name:   ctypes.Text(name),
+		Index:  ctypes.U8(index),
+		Fields: fields,
+	}
+}
+
+func variantType(variants ...ctypes.Si1Variant) ctypes.Si1Type {
+	return ctypes.Si1Type{Def: ctypes.Si1TypeDef{
+		IsVariant: true,
+		Variant:   ctypes.Si1TypeDefVariant{Variants: variants},
+	}}
+}
+
+// newTestMetadata returns a minimal version 14 metadata with a System pallet
+// without calls at index 0 storing the events and with a block hash count
+// constant, and a Balances pallet at index 5 with a transfer call and event,
+// similar to the metadata of Substrate based runtimes.
+func newTestMetadata() *ctypes.Metadata {
+	extrinsicType := compositeType(field("", 6))
+	extrinsicType.Params = []ctypes.Si1TypeParameter{
+		{Name: "Address", HasType: true, Type: lookupID(5)},
+		{Name: "Call", HasType: true, Type: lookupID(18)},
+		{Name: "Signature", HasType: true, Type: lookupID(8)},
+		{Name: "Extra", HasType: true, Type: lookupID(19)},
+	}
+
+	types := []ctypes.Si1Type{
+		0: primitiveType(ctypes.IsU8),
+		1: arrayType(32, 0),
+		2: compositeType(field("", 1)), // AccountId32
+		3: compactType(4),
+		4: primitiveType(ctypes.IsU128),
+		5: variantType( // MultiAddress
+			variant("Id", 0, field("", 2)),
+			variant("Raw", 2, field("", 6)),
+		),
+		6: {Def: ctypes.Si1TypeDef{
+			IsSequence: true,
+			Sequence:   ctypes.Si1TypeDefSequence{Type: lookupID(0)},
+		}},
+		7: arrayType(64, 0),
+		8: variantType( // MultiSignature
+			variant("Ed25519", 0, field("", 9)),
+			variant("Sr25519", 1, field("", 9)),
+		),
+		9:  compositeType(field("", 7)),
+		10: variantType(variant("transfer", 0, field("dest", 5), field("value", 3))),
+		11: variantType( // Era
+			variant("Immortal", 0),
+			variant("Mortal1", 1, field("", 0)),
+		),
+		12: compositeType(field("", 13)), // CheckNonce
+		13: compactType(14),
+		14: primitiveType(ctypes.IsU32),
+		15: compositeType(field("", 3)), // ChargeTransactionPayment
+		16: compositeType(),
+		17: extrinsicType,
+		18: variantType(variant("Balances", 5, field("", 10))),
+		19: {Def: ctypes.Si1TypeDef{
+			IsTuple: true,
+			Tuple:   ctypes.Si1TypeDefTuple{lookupID(16), lookupID(11), lookupID(12), lookupID(15)},
+		}},
+		20: variantType( // Phase
+			variant("ApplyExtrinsic", 0, field("", 14)),
+			variant("Finalization", 1),
+			variant("Initialization", 2),
+		),
+		21: variantType( // pallet_balances::Event
+			variant("Transfer", 2, field("from", 2), field("to", 2), field("amount", 4)),
+		),
+		22: variantType(variant("Balances", 5, field("", 21))), // RuntimeEvent
+		23: compositeType(field("", 1)),                        // H256
+		24: {Def: ctypes.Si1TypeDef{
+			IsSequence: true,
+			Sequence:   ctypes.Si1TypeDefSequence{Type: lookupID(23)},
+		}},
+		25: compositeType(field("phase", 20), field("event", 22), field("topics", 24)), // EventRecord
+		26: {Def: ctypes.Si1TypeDef{
+			IsSequence: true,
+			Sequence:   ctypes.Si1TypeDefSequence{Type: lookupID(25)},
+		}},
+	}
+
+	metadata := &ctypes.Metadata{
+		MagicNumber:   0x6174656d,
+		Version:       14,
+		IsMetadataV14: true,
+	}
+	v14 := &metadata.AsMetadataV14
+	for i, t := range types {
+		v14.Lookup.Types = append(v14.Lookup.Types, ctypes.PortableTypeV14{
+			ID:   lookupID(uint64(i)),
+			Type: t,
+		})
+	}
+	v14.Pallets = []ctypes.PalletMetadataV14{
+		{
+			Name:       "System",
+			Index:      0,
+			HasStorage: true,
+			Storage: ctypes.StorageMetadataV14{
+				Prefix: "System",
+				Items: []ctypes.StorageEntryMetadataV14{{
+					Name: "Events",
+					Type: ctypes.StorageEntryTypeV14{IsPlainType: true, AsPlainType: lookupID(26)},
+				}},
+			},
+			Constants: []ctypes.ConstantMetadataV14{{
+				Name:  "BlockHashCount",
+				Type:  lookupID(14),
+				Value: []byte{0x60, 0x09, 0, 0},
+			}},
+		},
+		{
+			Name:     "Balances",
+			Index:    5,
+			HasCalls: true,
+			Calls:    ctypes.FunctionMetadataV14{Type: lookupID(10)},
+		},
+	}
+	v14.Extrinsic = ctypes.ExtrinsicV14{
+		Type:    lookupID(17),
+		Version: 4,
+		SignedExtensions: []ctypes.SignedExtensionMetadataV14{
+			{Identifier: "CheckSpecVersion", Type: lookupID(16), AdditionalSigned: lookupID(14)},
+			{Identifier: "CheckMortality", Type: lookupID(11), AdditionalSigned: lookupID(1)},
+			{Identifier: "CheckNonce", Type: lookupID(12), AdditionalSigned: lookupID(16)},
+			{Identifier: "ChargeTransactionPayment", Type: lookupID(15), AdditionalSigned: lookupID(16)},
+		},
+	}
+	return metadata
+}
+
+func withLengthPrefix(t *testing.T, encoded []byte) []byte {
+	t.Helper()
+	prefixed, err := scale.Marshal(encoded)
+	require.NoError(t, err)
+	return prefixed
+}
+
+func concat(t *testing.T, values ...interface{}) []byte {
+	t.Helper()
+	var encoded []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case []byte:
+			encoded = append(encoded, v...)
+		case byte:
+			encoded = append(encoded, v)
+		default:
+			b, err := scale.Marshal(v)
+			require.NoError(t, err)
+			encoded = append(encoded, b...)
+		}
+	}
+	return encoded
+}
+
+func Test_Decode(t *testing.T) {
+	t.Parallel()
+
+	alice := bytes.Repeat([]byte{0xaa}, 32)
+	bob := bytes.Repeat([]byte{0xbb}, 32)
+	signature := bytes.Repeat([]byte{0x55}, 64)
+
+	// balances.transfer(dest: MultiAddress::Id(bob), value: 12345)
+	transferCall := concat(t, byte(5), byte(0), byte(0), bob, uint(12345))
+	transferArgs := []Field{
+		{Name: "dest", Value: Variant{Name: "Id", Fields: []Field{{Value: Bytes(bob)}}}},
+		{Name: "value", Value: big.NewInt(12345)},
+	}
+
+	testCases := map[string]struct {
+		metadata   *ctypes.Metadata
+		encoded    []byte
+		extrinsic  Extrinsic
+		errWrapped error
+		errMessage string
+	}{
+		"signed_transfer": {
+			metadata: newTestMetadata(),
+			encoded: withLengthPrefix(t, concat(t,
+				byte(0x84),
+				byte(0), alice, // MultiAddress::Id
+				byte(1), signature, // MultiSignature::Sr25519
+				byte(0),  // Era::Immortal
+				uint(7),  // nonce
+				uint(10), // tip
+				transferCall,
+			)),
+			extrinsic: Extrinsic{
+				Version: 4,
+				Signature: &Signature{
+					Address: Variant{Name: "Id", Fields: []Field{{Value: Bytes(alice)}}},
+					Signature: Variant{
+						Name:   "Sr25519",
+						Fields: []Field{{Value: Bytes(signature)}},
+					},
+					Extra: []Field{
+						{Name: "CheckMortality", Value: Variant{Name: "Immortal"}},
+						{Name: "CheckNonce", Value: big.NewInt(7)},
+						{Name: "ChargeTransactionPayment", Value: big.NewInt(10)},
+					},
+				},
+				Call: Call{Pallet: "Balances", Name: "transfer", Args: transferArgs},
+			},
+		},
+		"unsigned_transfer": {
+			metadata: newTestMetadata(),
+			encoded:  withLengthPrefix(t, concat(t, byte(0x04), transferCall)),
+			extrinsic: Extrinsic{
+				Version: 4,
+				Call:    Call{Pallet: "Balances", Name: "transfer", Args: transferArgs},
+			},
+		},
+		"metadata_version_not_supported": {
+			metadata:   &ctypes.Metadata{Version: 13},
+			errWrapped: ErrMetadataVersion,
+			errMessage: "metadata version is not supported: 13",
+		},
+		"length_mismatch": {
+			metadata:   newTestMetadata(),
+			encoded:    append(withLengthPrefix(t, concat(t, byte(0x04), transferCall)), 0),
+			errWrapped: errLengthMismatch,
+			errMessage: "extrinsic length mismatch: encoded length 38 but 39 bytes remaining",
+		},
+		"extrinsic_version_not_supported": {
+			metadata:   newTestMetadata(),
+			encoded:    withLengthPrefix(t, concat(t, byte(0x03), transferCall)),
+			errWrapped: ErrExtrinsicVersion,
+			errMessage: "extrinsic version is not supported: 3",
+		},
+		"pallet_without_calls": {
+			metadata:   newTestMetadata(),
+			encoded:    withLengthPrefix(t, concat(t, byte(0x04), byte(0), byte(0))),
+			errWrapped: ErrPalletNotFound,
+			errMessage: "decoding call: pallet not found: for index 0",
+		},
+		"call_not_found": {
+			metadata:   newTestMetadata(),
+			encoded:    withLengthPrefix(t, concat(t, byte(0x04), byte(5), byte(9))),
+			errWrapped: ErrCallNotFound,
+			errMessage: "decoding call: call not found: for index 9 in pallet Balances",
+		},
+		"trailing_bytes": {
+			metadata:   newTestMetadata(),
+			encoded:    withLengthPrefix(t, concat(t, byte(0x04), transferCall, byte(0))),
+			errWrapped: errTrailingBytes,
+			errMessage: "trailing bytes after extrinsic: 1 bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			extrinsic, err := Decode(testCase.metadata, testCase.encoded)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.extrinsic, extrinsic)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"errors"
+	"fmt"
+
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+var (
+	errEventsStorageNotFound = errors.New("system events storage not found")
+	errEventRecordMalformed  = errors.New("event record is malformed")
+	errTrailingEventsBytes   = errors.New("trailing bytes after events")
+)
+
+// EventRecord is an event record decoded using the runtime metadata.
+type EventRecord struct {
+	// Phase is the phase of the block in which the event is
+	// deposited, for example ApplyExtrinsic with the index of
+	// the extrinsic depositing the event.
+	Phase  Variant `json:"phase"`
+	Pallet string  `json:"pallet"`
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// DecodeEvents decodes the SCALE encoded vector of event records given, as
+// stored in the System Events storage, using the type of this storage in the
+// runtime metadata given, which must be in the version 14 format if there is
+// at least one event record. The order of the event records is preserved.
+func DecodeEvents(metadata *ctypes.Metadata, encoded []byte) (records []EventRecord, err error) {
+	d := newDecoder(&metadata.AsMetadataV14, encoded)
+
+	count, err := d.decodeCompact()
+	if err != nil {
+		return nil, fmt.Errorf("decoding events count: %w", err)
+	}
+	records = []EventRecord{}
+	if count.Sign() == 0 && d.reader.Len() == 0 {
+		return records, nil
+	}
+
+	if metadata.Version != 14 {
+		return nil, fmt.Errorf("%w: %d", ErrMetadataVersion, metadata.Version)
+	}
+
+	recordType, err := d.eventRecordType()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each event record is encoded with at least two bytes,
+	// for its phase and for the index of its pallet.
+	if !count.IsUint64() || count.Uint64() > uint64(d.reader.Len()) {
+		return nil, fmt.Errorf("%w: %s event records for %d bytes",
+			errSequenceTooLong, count, d.reader.Len())
+	}
+
+	records = make([]EventRecord, count.Uint64())
+	for i := range records {
+		value, err := d.decode(recordType)
+		if err != nil {
+			return nil, fmt.Errorf("decoding event record %d: %w", i, err)
+		}
+
+		records[i], err = newEventRecord(value)
+		if err != nil {
+			return nil, fmt.Errorf("for event record %d: %w", i, err)
+		}
+	}
+
+	if d.reader.Len() > 0 {
+		return nil, fmt.Errorf("%w: %d bytes", errTrailingEventsBytes, d.reader.Len())
+	}
+
+	return records, nil
+}
+
+// eventRecordType returns the type of the elements of the
+// vector stored in the Events storage of the System pallet.
+func (d *decoder) eventRecordType() (id ctypes.Si1LookupTypeID, err error) {
+	for _, pallet := range d.metadata.Pallets {
+		if string(pallet.Name) != "System" || !pallet.HasStorage {
+			continue
+		}
+
+		for _, item := range pallet.Storage.Items {
+			if string(item.Name) != "Events" || !item.Type.IsPlainType {
+				continue
+			}
+
+			eventsType, ok := d.types[typeID(item.Type.AsPlainType)]
+			if !ok || !eventsType.Def.IsSequence {
+				return id, fmt.Errorf("%w: events sequence type %d",
+					errTypeNotFound, typeID(item.Type.AsPlainType))
+			}
+			return eventsType.Def.Sequence.Type, nil
+		}
+	}
+
+	return id, errEventsStorageNotFound
+}
+
+// newEventRecord returns the event record from the value given, decoded
+// using the event record type of the metadata, whose phase is decoded as
+// a Variant, and whose event is decoded as a Variant named after the
+// pallet with a single field, the Variant of the event of the pallet.
+func newEventRecord(value interface{}) (record EventRecord, err error) {
+	fields, ok := value.([]Field)
+	if !ok {
+		return record, fmt.Errorf("%w: decoded as %T", errEventRecordMalformed, value)
+	}
+
+	var phaseFound, eventFound bool
+	for _, field := range fields {
+		switch field.Name {
+		case "phase":
+			record.Phase, phaseFound = field.Value.(Variant)
+		case "event":
+			palletEvent, ok := field.Value.(Variant)
+			if !ok || len(palletEvent.Fields) != 1 {
+				break
+			}
+
+			event, ok := palletEvent.Fields[0].Value.(Variant)
+			if !ok {
+				break
+			}
+
+			record.Pallet = palletEvent.Name
+			record.Name = event.Name
+			record.Fields = event.Fields
+			eventFound = true
+		}
+	}
+
+	if !phaseFound || !eventFound {
+		return record, fmt.Errorf("%w: phase or event not found", errEventRecordMalformed)
+	}
+	return record, nil
+}
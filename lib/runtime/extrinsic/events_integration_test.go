@@ -0,0 +1,103 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+//go:build integration
+
+package extrinsic
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/genesis"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
+	"github.com/ChainSafe/gossamer/lib/utils"
+	"github.com/ChainSafe/gossamer/pkg/scale"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeEvents_westendRuntime(t *testing.T) {
+	t.Parallel()
+
+	gen, err := genesis.NewGenesisFromJSONRaw(utils.GetWestendDevRawGenesisPath(t))
+	require.NoError(t, err)
+	genTrie, err := wasmer.NewTrieFromGenesis(*gen)
+	require.NoError(t, err)
+
+	instance, err := wasmer.NewRuntimeFromGenesis(wasmer.Config{
+		Storage: storage.NewTrieState(&genTrie),
+		LogLvl:  log.Critical,
+	})
+	require.NoError(t, err)
+
+	state := storage.NewTrieState(&genTrie)
+	instance.SetContextStorage(state)
+
+	encodedMetadata, err := instance.Metadata()
+	require.NoError(t, err)
+	var decodedMetadata []byte
+	err = scale.Unmarshal(encodedMetadata, &decodedMetadata)
+	require.NoError(t, err)
+	metadata := &ctypes.Metadata{}
+	err = codec.Decode(decodedMetadata, metadata)
+	require.NoError(t, err)
+	require.Equal(t, uint8(14), metadata.Version)
+
+	genesisHeader := &types.Header{
+		Number:    0,
+		StateRoot: genTrie.MustHash(),
+	}
+	err = instance.InitializeBlock(&types.Header{
+		ParentHash: genesisHeader.Hash(),
+		Number:     1,
+		Digest:     types.NewDigest(),
+	})
+	require.NoError(t, err)
+
+	const bobHex = "0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48"
+	bob, err := ctypes.NewMultiAddressFromHexAccountID(bobHex)
+	require.NoError(t, err)
+	const amount = 12345
+	extrinsicHex := runtime.NewTestExtrinsic(t, instance, genesisHeader.Hash(), genesisHeader.Hash(),
+		0, signature.TestKeyringPairAlice, "Balances.transfer", bob, ctypes.NewUCompactFromUInt(amount))
+	result, err := instance.ApplyExtrinsic(common.MustHexToBytes(extrinsicHex))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0}, result)
+
+	eventsKey, err := ctypes.CreateStorageKey(metadata, "System", "Events")
+	require.NoError(t, err)
+
+	records, err := DecodeEvents(metadata, state.Get(eventsKey))
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+
+	extrinsicPhase := Variant{Name: "ApplyExtrinsic", Fields: []Field{{Value: uint32(0)}}}
+	for _, record := range records {
+		assert.Equal(t, extrinsicPhase, record.Phase)
+	}
+
+	expectedTransfer := EventRecord{
+		Phase:  extrinsicPhase,
+		Pallet: "Balances",
+		Name:   "Transfer",
+		Fields: []Field{
+			{Name: "from", Value: Bytes(signature.TestKeyringPairAlice.PublicKey)},
+			{Name: "to", Value: Bytes(common.MustHexToBytes(bobHex))},
+			{Name: "amount", Value: big.NewInt(amount)},
+		},
+	}
+	assert.Contains(t, records, expectedTransfer)
+
+	lastRecord := records[len(records)-1]
+	assert.Equal(t, "System", lastRecord.Pallet)
+	assert.Equal(t, "ExtrinsicSuccess", lastRecord.Name)
+}
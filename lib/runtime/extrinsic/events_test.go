@@ -0,0 +1,117 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	ctypes "github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DecodeEvents(t *testing.T) {
+	t.Parallel()
+
+	alice := bytes.Repeat([]byte{0xaa}, 32)
+	bob := bytes.Repeat([]byte{0xbb}, 32)
+	topic := bytes.Repeat([]byte{0x11}, 32)
+	amount := make([]byte, 16)
+	amount[0] = 100
+
+	// Balances.Transfer(from: alice, to: bob, amount: 100)
+	transferEvent := concat(t, byte(5), byte(2), alice, bob, amount)
+	transferFields := []Field{
+		{Name: "from", Value: Bytes(alice)},
+		{Name: "to", Value: Bytes(bob)},
+		{Name: "amount", Value: big.NewInt(100)},
+	}
+
+	testCases := map[string]struct {
+		metadata   *ctypes.Metadata
+		encoded    []byte
+		records    []EventRecord
+		errWrapped error
+		errMessage string
+	}{
+		"no_events": {
+			metadata: &ctypes.Metadata{Version: 12},
+			encoded:  []byte{0},
+			records:  []EventRecord{},
+		},
+		"events": {
+			metadata: newTestMetadata(),
+			encoded: concat(t, uint(2),
+				byte(0), uint32(1), transferEvent, uint(1), topic, // ApplyExtrinsic(1)
+				byte(1), transferEvent, uint(0), // Finalization
+			),
+			records: []EventRecord{
+				{
+					Phase:  Variant{Name: "ApplyExtrinsic", Fields: []Field{{Value: uint32(1)}}},
+					Pallet: "Balances",
+					Name:   "Transfer",
+					Fields: transferFields,
+				},
+				{
+					Phase:  Variant{Name: "Finalization"},
+					Pallet: "Balances",
+					Name:   "Transfer",
+					Fields: transferFields,
+				},
+			},
+		},
+		"metadata_version_not_supported": {
+			metadata:   &ctypes.Metadata{Version: 12},
+			encoded:    concat(t, uint(1), byte(1), transferEvent, uint(0)),
+			errWrapped: ErrMetadataVersion,
+			errMessage: "metadata version is not supported: 12",
+		},
+		"events_storage_not_found": {
+			metadata: func() *ctypes.Metadata {
+				metadata := newTestMetadata()
+				metadata.AsMetadataV14.Pallets[0].HasStorage = false
+				return metadata
+			}(),
+			encoded:    concat(t, uint(1), byte(1), transferEvent, uint(0)),
+			errWrapped: errEventsStorageNotFound,
+			errMessage: "system events storage not found",
+		},
+		"too_many_events": {
+			metadata:   newTestMetadata(),
+			encoded:    concat(t, uint(1000), byte(1)),
+			errWrapped: errSequenceTooLong,
+			errMessage: "sequence length exceeds remaining bytes: 1000 event records for 1 bytes",
+		},
+		"event_not_found": {
+			metadata:   newTestMetadata(),
+			encoded:    concat(t, uint(1), byte(1), byte(5), byte(9)),
+			errWrapped: errVariantNotFound,
+			errMessage: "decoding event record 0: decoding field event: " +
+				"decoding variant Balances: decoding field : variant not found: for index 9 of type 21",
+		},
+		"trailing_bytes": {
+			metadata:   newTestMetadata(),
+			encoded:    concat(t, uint(1), byte(1), transferEvent, uint(0), byte(0)),
+			errWrapped: errTrailingEventsBytes,
+			errMessage: "trailing bytes after events: 1 bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			records, err := DecodeEvents(testCase.metadata, testCase.encoded)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.records, records)
+		})
+	}
+}
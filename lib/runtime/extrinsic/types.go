@@ -0,0 +1,64 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package extrinsic
+
+import (
+	"encoding/json"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// Extrinsic is an extrinsic decoded using the runtime metadata.
+type Extrinsic struct {
+	Version uint8 `json:"version"`
+	// Signature is the signature of the extrinsic,
+	// and is nil if the extrinsic is not signed.
+	Signature *Signature `json:"signature"`
+	Call      Call       `json:"call"`
+}
+
+// Signature is the signature of a signed extrinsic.
+type Signature struct {
+	Address   interface{} `json:"address"`
+	Signature interface{} `json:"signature"`
+	// Extra contains the values of the signed extensions part of the
+	// signature payload, such as the era, the nonce and the tip, named
+	// by their signed extension identifier. Signed extensions without
+	// any encoded value are not included.
+	Extra []Field `json:"extra"`
+}
+
+// Call is the call of an extrinsic.
+type Call struct {
+	Pallet string  `json:"pallet"`
+	Name   string  `json:"name"`
+	Args   []Field `json:"args"`
+}
+
+// Field is a decoded value with the name of its field,
+// which is empty for fields without a name.
+type Field struct {
+	Name  string      `json:"name,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// Variant is a decoded enum value.
+type Variant struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Bytes is a decoded sequence or array of bytes,
+// which is presented as a 0x prefixed hex string.
+type Bytes []byte
+
+// String returns the 0x prefixed hex encoding of the bytes.
+func (b Bytes) String() string {
+	return common.BytesToHex(b)
+}
+
+// MarshalJSON encodes the bytes as a 0x prefixed hex JSON string.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
@@ -4,6 +4,9 @@
 package runtime
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
@@ -11,6 +14,14 @@
 	"github.com/ChainSafe/gossamer/lib/transaction"
 )
 
+// ErrExecutionTimeout is returned when a runtime call is
+// aborted since it runs for longer than its time limit.
+var ErrExecutionTimeout = errors.New("runtime execution timeout")
+
+// ErrExecutionCanceled is returned when a runtime call is aborted
+// since the done channel of its instance is closed.
+var ErrExecutionCanceled = errors.New("runtime execution canceled")
+
 // Instance for runtime methods
 type Instance interface {
 	Stop()
@@ -19,6 +30,8 @@ type Instance interface {
 	Keystore() *keystore.GlobalKeystore
 	Validator() bool
 	Exec(function string, data []byte) ([]byte, error)
+	ExecUntrusted(function string, data []byte) ([]byte, error)
+	UntrustedCallTimeout() time.Duration
 	SetContextStorage(s Storage)
 	GetCodeHash() common.Hash
 	Version() (Version, error)
@@ -33,7 +46,7 @@ type Instance interface {
 	ExecuteBlock(block *types.Block) ([]byte, error)
 	DecodeSessionKeys(enc []byte) ([]byte, error)
 	PaymentQueryInfo(ext []byte) (*types.RuntimeDispatchInfo, error)
-	CheckInherents()
+	CheckInherents(block *types.Block, inherentData []byte) (*types.CheckInherentsResult, error)
 	BabeGenerateKeyOwnershipProof(slot uint64, authorityID [32]byte) (
 		types.OpaqueKeyOwnershipProof, error)
 	BabeSubmitReportEquivocationUnsignedExtrinsic(
@@ -41,7 +54,7 @@ type Instance interface {
 		keyOwnershipProof types.OpaqueKeyOwnershipProof,
 	) error
 	RandomSeed()
-	OffchainWorker()
+	OffchainWorker(header *types.Header) error
 	GenerateSessionKeys()
 	GrandpaGenerateKeyOwnershipProof(authSetID uint64, authorityID ed25519.PublicKeyBytes) (
 		types.GrandpaOpaqueKeyOwnershipProof, error)
@@ -68,9 +68,27 @@ func (_m *Instance) BabeConfiguration() (*types.BabeConfiguration, error) {
 	return r0, r1
 }
 
-// CheckInherents provides a mock function with given fields:
-func (_m *Instance) CheckInherents() {
-	_m.Called()
+// CheckInherents provides a mock function with given fields: block, inherentData
+func (_m *Instance) CheckInherents(block *types.Block, inherentData []byte) (*types.CheckInherentsResult, error) {
+	ret := _m.Called(block, inherentData)
+
+	var r0 *types.CheckInherentsResult
+	if rf, ok := ret.Get(0).(func(*types.Block, []byte) *types.CheckInherentsResult); ok {
+		r0 = rf(block, inherentData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.CheckInherentsResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Block, []byte) error); ok {
+		r1 = rf(block, inherentData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // DecodeSessionKeys provides a mock function with given fields: enc
@@ -352,9 +370,18 @@ func (_m *Instance) NodeStorage() runtime.NodeStorage {
 	return r0
 }
 
-// OffchainWorker provides a mock function with given fields:
-func (_m *Instance) OffchainWorker() {
-	_m.Called()
+// OffchainWorker provides a mock function with given fields: header
+func (_m *Instance) OffchainWorker(header *types.Header) error {
+	ret := _m.Called(header)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*types.Header) error); ok {
+		r0 = rf(header)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // PaymentQueryInfo provides a mock function with given fields: ext
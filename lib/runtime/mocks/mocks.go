@@ -1,17 +1,19 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/ChainSafe/gossamer/lib/runtime (interfaces: Instance,TransactionState)
+// Source: github.com/ChainSafe/gossamer/lib/runtime (interfaces: Instance,OffchainWorkerBlockState,OffchainWorkerStorageState,TransactionState)
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	types "github.com/ChainSafe/gossamer/dot/types"
 	common "github.com/ChainSafe/gossamer/lib/common"
 	ed25519 "github.com/ChainSafe/gossamer/lib/crypto/ed25519"
 	keystore "github.com/ChainSafe/gossamer/lib/keystore"
 	runtime "github.com/ChainSafe/gossamer/lib/runtime"
+	storage "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	transaction "github.com/ChainSafe/gossamer/lib/transaction"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -99,15 +101,18 @@ func (mr *MockInstanceMockRecorder) BabeSubmitReportEquivocationUnsignedExtrinsi
 }
 
 // CheckInherents mocks base method.
-func (m *MockInstance) CheckInherents() {
+func (m *MockInstance) CheckInherents(arg0 *types.Block, arg1 []byte) (*types.CheckInherentsResult, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "CheckInherents")
+	ret := m.ctrl.Call(m, "CheckInherents", arg0, arg1)
+	ret0, _ := ret[0].(*types.CheckInherentsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CheckInherents indicates an expected call of CheckInherents.
-func (mr *MockInstanceMockRecorder) CheckInherents() *gomock.Call {
+func (mr *MockInstanceMockRecorder) CheckInherents(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckInherents", reflect.TypeOf((*MockInstance)(nil).CheckInherents))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckInherents", reflect.TypeOf((*MockInstance)(nil).CheckInherents), arg0, arg1)
 }
 
 // DecodeSessionKeys mocks base method.
@@ -140,6 +145,21 @@ func (mr *MockInstanceMockRecorder) Exec(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockInstance)(nil).Exec), arg0, arg1)
 }
 
+// ExecUntrusted mocks base method.
+func (m *MockInstance) ExecUntrusted(arg0 string, arg1 []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecUntrusted", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecUntrusted indicates an expected call of ExecUntrusted.
+func (mr *MockInstanceMockRecorder) ExecUntrusted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecUntrusted", reflect.TypeOf((*MockInstance)(nil).ExecUntrusted), arg0, arg1)
+}
+
 // ExecuteBlock mocks base method.
 func (m *MockInstance) ExecuteBlock(arg0 *types.Block) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -327,15 +347,17 @@ func (mr *MockInstanceMockRecorder) NodeStorage() *gomock.Call {
 }
 
 // OffchainWorker mocks base method.
-func (m *MockInstance) OffchainWorker() {
+func (m *MockInstance) OffchainWorker(arg0 *types.Header) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OffchainWorker")
+	ret := m.ctrl.Call(m, "OffchainWorker", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // OffchainWorker indicates an expected call of OffchainWorker.
-func (mr *MockInstanceMockRecorder) OffchainWorker() *gomock.Call {
+func (mr *MockInstanceMockRecorder) OffchainWorker(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OffchainWorker", reflect.TypeOf((*MockInstance)(nil).OffchainWorker))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OffchainWorker", reflect.TypeOf((*MockInstance)(nil).OffchainWorker), arg0)
 }
 
 // PaymentQueryInfo mocks base method.
@@ -389,6 +411,20 @@ func (mr *MockInstanceMockRecorder) Stop() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockInstance)(nil).Stop))
 }
 
+// UntrustedCallTimeout mocks base method.
+func (m *MockInstance) UntrustedCallTimeout() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UntrustedCallTimeout")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// UntrustedCallTimeout indicates an expected call of UntrustedCallTimeout.
+func (mr *MockInstanceMockRecorder) UntrustedCallTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UntrustedCallTimeout", reflect.TypeOf((*MockInstance)(nil).UntrustedCallTimeout))
+}
+
 // ValidateTransaction mocks base method.
 func (m *MockInstance) ValidateTransaction(arg0 types.Extrinsic) (*transaction.Validity, error) {
 	m.ctrl.T.Helper()
@@ -433,6 +469,122 @@ func (mr *MockInstanceMockRecorder) Version() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockInstance)(nil).Version))
 }
 
+// MockOffchainWorkerBlockState is a mock of OffchainWorkerBlockState interface.
+type MockOffchainWorkerBlockState struct {
+	ctrl     *gomock.Controller
+	recorder *MockOffchainWorkerBlockStateMockRecorder
+}
+
+// MockOffchainWorkerBlockStateMockRecorder is the mock recorder for MockOffchainWorkerBlockState.
+type MockOffchainWorkerBlockStateMockRecorder struct {
+	mock *MockOffchainWorkerBlockState
+}
+
+// NewMockOffchainWorkerBlockState creates a new mock instance.
+func NewMockOffchainWorkerBlockState(ctrl *gomock.Controller) *MockOffchainWorkerBlockState {
+	mock := &MockOffchainWorkerBlockState{ctrl: ctrl}
+	mock.recorder = &MockOffchainWorkerBlockStateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOffchainWorkerBlockState) EXPECT() *MockOffchainWorkerBlockStateMockRecorder {
+	return m.recorder
+}
+
+// BestBlockHash mocks base method.
+func (m *MockOffchainWorkerBlockState) BestBlockHash() common.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BestBlockHash")
+	ret0, _ := ret[0].(common.Hash)
+	return ret0
+}
+
+// BestBlockHash indicates an expected call of BestBlockHash.
+func (mr *MockOffchainWorkerBlockStateMockRecorder) BestBlockHash() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BestBlockHash", reflect.TypeOf((*MockOffchainWorkerBlockState)(nil).BestBlockHash))
+}
+
+// FreeImportedBlockNotifierChannel mocks base method.
+func (m *MockOffchainWorkerBlockState) FreeImportedBlockNotifierChannel(arg0 chan *types.Block) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "FreeImportedBlockNotifierChannel", arg0)
+}
+
+// FreeImportedBlockNotifierChannel indicates an expected call of FreeImportedBlockNotifierChannel.
+func (mr *MockOffchainWorkerBlockStateMockRecorder) FreeImportedBlockNotifierChannel(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeImportedBlockNotifierChannel", reflect.TypeOf((*MockOffchainWorkerBlockState)(nil).FreeImportedBlockNotifierChannel), arg0)
+}
+
+// GetImportedBlockNotifierChannel mocks base method.
+func (m *MockOffchainWorkerBlockState) GetImportedBlockNotifierChannel() chan *types.Block {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportedBlockNotifierChannel")
+	ret0, _ := ret[0].(chan *types.Block)
+	return ret0
+}
+
+// GetImportedBlockNotifierChannel indicates an expected call of GetImportedBlockNotifierChannel.
+func (mr *MockOffchainWorkerBlockStateMockRecorder) GetImportedBlockNotifierChannel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportedBlockNotifierChannel", reflect.TypeOf((*MockOffchainWorkerBlockState)(nil).GetImportedBlockNotifierChannel))
+}
+
+// GetRuntime mocks base method.
+func (m *MockOffchainWorkerBlockState) GetRuntime(arg0 common.Hash) (runtime.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRuntime", arg0)
+	ret0, _ := ret[0].(runtime.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRuntime indicates an expected call of GetRuntime.
+func (mr *MockOffchainWorkerBlockStateMockRecorder) GetRuntime(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuntime", reflect.TypeOf((*MockOffchainWorkerBlockState)(nil).GetRuntime), arg0)
+}
+
+// MockOffchainWorkerStorageState is a mock of OffchainWorkerStorageState interface.
+type MockOffchainWorkerStorageState struct {
+	ctrl     *gomock.Controller
+	recorder *MockOffchainWorkerStorageStateMockRecorder
+}
+
+// MockOffchainWorkerStorageStateMockRecorder is the mock recorder for MockOffchainWorkerStorageState.
+type MockOffchainWorkerStorageStateMockRecorder struct {
+	mock *MockOffchainWorkerStorageState
+}
+
+// NewMockOffchainWorkerStorageState creates a new mock instance.
+func NewMockOffchainWorkerStorageState(ctrl *gomock.Controller) *MockOffchainWorkerStorageState {
+	mock := &MockOffchainWorkerStorageState{ctrl: ctrl}
+	mock.recorder = &MockOffchainWorkerStorageStateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOffchainWorkerStorageState) EXPECT() *MockOffchainWorkerStorageStateMockRecorder {
+	return m.recorder
+}
+
+// TrieState mocks base method.
+func (m *MockOffchainWorkerStorageState) TrieState(arg0 *common.Hash) (*storage.TrieState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrieState", arg0)
+	ret0, _ := ret[0].(*storage.TrieState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TrieState indicates an expected call of TrieState.
+func (mr *MockOffchainWorkerStorageStateMockRecorder) TrieState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrieState", reflect.TypeOf((*MockOffchainWorkerStorageState)(nil).TrieState), arg0)
+}
+
 // MockTransactionState is a mock of TransactionState interface.
 type MockTransactionState struct {
 	ctrl     *gomock.Controller
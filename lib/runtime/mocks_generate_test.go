@@ -3,5 +3,5 @@
 
 package runtime
 
-//go:generate mockgen -destination=mocks/mocks.go -package mocks . Instance,TransactionState
+//go:generate mockgen -destination=mocks/mocks.go -package mocks . Instance,OffchainWorkerBlockState,OffchainWorkerStorageState,TransactionState
 //go:generate mockgen -destination=mocks_test.go -package $GOPACKAGE . Memory
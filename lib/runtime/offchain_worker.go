@@ -0,0 +1,174 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+)
+
+var logger = log.NewFromGlobal(log.AddContext("pkg", "runtime"))
+
+// OffchainWorkerBlockState is the block state used by the offchain worker scheduler.
+type OffchainWorkerBlockState interface {
+	BestBlockHash() common.Hash
+	GetImportedBlockNotifierChannel() chan *types.Block
+	FreeImportedBlockNotifierChannel(ch chan *types.Block)
+	GetRuntime(blockHash common.Hash) (instance Instance, err error)
+}
+
+// OffchainWorkerStorageState is the storage state used by the offchain worker scheduler.
+type OffchainWorkerStorageState interface {
+	TrieState(root *common.Hash) (*storage.TrieState, error)
+}
+
+// OffchainWorkerInstanceCreator creates the runtime instance dedicated to running
+// the offchain worker of a block, from the runtime instance of the block and the
+// trie state of the block. Closing the done channel given must abort any runtime
+// call of the instance created.
+type OffchainWorkerInstanceCreator func(done <-chan struct{}, blockInstance Instance,
+	trieState *storage.TrieState) (instance Instance, err error)
+
+// OffchainWorkerScheduler runs the runtime offchain worker for each new best
+// block imported, on its own goroutine so it does not block the block import.
+// Each offchain worker runs on its own runtime instance, so it does not hold
+// the lock of the runtime instance of the block, used to import blocks.
+type OffchainWorkerScheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	blockState   OffchainWorkerBlockState
+	storageState OffchainWorkerStorageState
+	newInstance  OffchainWorkerInstanceCreator
+	imported     chan *types.Block
+
+	// workers is a semaphore limiting the number of offchain workers running at once.
+	workers chan struct{}
+}
+
+// NewOffchainWorkerScheduler returns a new offchain worker scheduler
+// running at most maxWorkers offchain workers at once.
+func NewOffchainWorkerScheduler(blockState OffchainWorkerBlockState,
+	storageState OffchainWorkerStorageState, newInstance OffchainWorkerInstanceCreator,
+	maxWorkers uint) *OffchainWorkerScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OffchainWorkerScheduler{
+		ctx:          ctx,
+		cancel:       cancel,
+		blockState:   blockState,
+		storageState: storageState,
+		newInstance:  newInstance,
+		workers:      make(chan struct{}, maxWorkers),
+	}
+}
+
+// Start starts running the offchain worker for the new best blocks imported.
+func (s *OffchainWorkerScheduler) Start() error {
+	s.imported = s.blockState.GetImportedBlockNotifierChannel()
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Stop stops the scheduler, aborts the offchain workers
+// running and waits for all its goroutines to return.
+func (s *OffchainWorkerScheduler) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	s.blockState.FreeImportedBlockNotifierChannel(s.imported)
+	return nil
+}
+
+func (s *OffchainWorkerScheduler) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case block, ok := <-s.imported:
+			if !ok {
+				return
+			}
+
+			blockHash := block.Header.Hash()
+			if blockHash != s.blockState.BestBlockHash() {
+				continue
+			}
+
+			select {
+			case s.workers <- struct{}{}:
+			default:
+				logger.Debugf("not running offchain worker for block #%d (%s): %d offchain workers already running",
+					block.Header.Number, blockHash, cap(s.workers))
+				continue
+			}
+
+			s.wg.Add(1)
+			go s.runWorker(block.Header)
+		}
+	}
+}
+
+// runWorker runs the offchain worker for the block header given, logging any
+// error since a failing offchain worker must not affect the chain.
+func (s *OffchainWorkerScheduler) runWorker(header types.Header) {
+	defer s.wg.Done()
+	defer func() { <-s.workers }()
+
+	err := s.runOffchainWorker(&header)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrExecutionCanceled):
+		logger.Debugf("offchain worker for block #%d (%s) aborted: %s", header.Number, header.Hash(), err)
+	default:
+		logger.Errorf("running offchain worker for block #%d (%s): %s", header.Number, header.Hash(), err)
+	}
+}
+
+func (s *OffchainWorkerScheduler) runOffchainWorker(header *types.Header) error {
+	blockInstance, err := s.blockState.GetRuntime(header.Hash())
+	if err != nil {
+		return fmt.Errorf("getting runtime: %w", err)
+	}
+
+	version, err := blockInstance.Version()
+	if err != nil {
+		return fmt.Errorf("getting runtime version: %w", err)
+	}
+
+	apiVersion, err := version.OffchainWorkerAPIVersion()
+	if errors.Is(err, ErrOffchainWorkerAPINotFound) {
+		logger.Tracef("runtime of block #%d (%s) has no offchain worker", header.Number, header.Hash())
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("getting offchain worker api version: %w", err)
+	} else if apiVersion < 2 {
+		// version 1 takes the block number instead of the block header.
+		logger.Debugf("not running offchain worker for block #%d (%s): offchain worker api version %d not supported",
+			header.Number, header.Hash(), apiVersion)
+		return nil
+	}
+
+	trieState, err := s.storageState.TrieState(&header.StateRoot)
+	if err != nil {
+		return fmt.Errorf("getting trie state: %w", err)
+	}
+
+	instance, err := s.newInstance(s.ctx.Done(), blockInstance, trieState)
+	if err != nil {
+		return fmt.Errorf("creating runtime instance: %w", err)
+	}
+	defer instance.Stop()
+
+	return instance.OffchainWorker(header)
+}
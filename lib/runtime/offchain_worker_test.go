@@ -0,0 +1,187 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/mocks"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func offchainWorkerTestVersion(t *testing.T, apiVersion uint32) runtime.Version {
+	t.Helper()
+	name, err := common.Blake2b8([]byte("OffchainWorkerApi"))
+	require.NoError(t, err)
+	return runtime.Version{APIItems: []runtime.APIItem{{Name: name, Ver: apiVersion}}}
+}
+
+func TestOffchainWorkerScheduler(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	blockState := mocks.NewMockOffchainWorkerBlockState(ctrl)
+	storageState := mocks.NewMockOffchainWorkerStorageState(ctrl)
+	blockInstance := mocks.NewMockInstance(ctrl)
+	workerInstance := mocks.NewMockInstance(ctrl)
+
+	forkHeader := types.Header{Number: 1, StateRoot: common.Hash{1}, Digest: types.NewDigest()}
+	bestHeader := types.Header{Number: 1, StateRoot: common.Hash{2}, Digest: types.NewDigest()}
+	trieState := storage.NewTrieState(trie.NewEmptyTrie())
+
+	imported := make(chan *types.Block, 2)
+	blockState.EXPECT().GetImportedBlockNotifierChannel().Return(imported)
+	blockState.EXPECT().BestBlockHash().Return(bestHeader.Hash()).Times(2)
+	blockState.EXPECT().GetRuntime(bestHeader.Hash()).Return(blockInstance, nil)
+	blockInstance.EXPECT().Version().Return(offchainWorkerTestVersion(t, 2), nil)
+	storageState.EXPECT().TrieState(&bestHeader.StateRoot).Return(trieState, nil)
+
+	newInstance := func(done <-chan struct{}, instance runtime.Instance,
+		state *storage.TrieState) (runtime.Instance, error) {
+		assert.Equal(t, blockInstance, instance)
+		assert.Equal(t, trieState, state)
+		return workerInstance, nil
+	}
+
+	invoked := make(chan *types.Header, 1)
+	workerInstance.EXPECT().OffchainWorker(gomock.Any()).DoAndReturn(func(header *types.Header) error {
+		invoked <- header
+		// a failing offchain worker is only logged
+		return errors.New("test error")
+	})
+	workerInstance.EXPECT().Stop()
+
+	scheduler := runtime.NewOffchainWorkerScheduler(blockState, storageState, newInstance, 1)
+	err := scheduler.Start()
+	require.NoError(t, err)
+
+	// the offchain worker only runs for new best blocks
+	imported <- &types.Block{Header: forkHeader}
+	imported <- &types.Block{Header: bestHeader}
+
+	select {
+	case header := <-invoked:
+		assert.Equal(t, &bestHeader, header)
+	case <-time.After(time.Second):
+		t.Fatal("offchain worker not invoked")
+	}
+
+	blockState.EXPECT().FreeImportedBlockNotifierChannel(imported)
+	err = scheduler.Stop()
+	require.NoError(t, err)
+}
+
+func TestOffchainWorkerScheduler_apiVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		version runtime.Version
+	}{
+		"no_offchain_worker_api": {},
+		"offchain_worker_api_version_1": {
+			version: offchainWorkerTestVersion(t, 1),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			blockState := mocks.NewMockOffchainWorkerBlockState(ctrl)
+			storageState := mocks.NewMockOffchainWorkerStorageState(ctrl)
+			blockInstance := mocks.NewMockInstance(ctrl)
+
+			header := types.Header{Number: 1, Digest: types.NewDigest()}
+
+			imported := make(chan *types.Block, 1)
+			blockState.EXPECT().GetImportedBlockNotifierChannel().Return(imported)
+			blockState.EXPECT().BestBlockHash().Return(header.Hash())
+			blockState.EXPECT().GetRuntime(header.Hash()).Return(blockInstance, nil)
+
+			checked := make(chan struct{})
+			blockInstance.EXPECT().Version().DoAndReturn(func() (runtime.Version, error) {
+				close(checked)
+				return testCase.version, nil
+			})
+
+			newInstance := func(<-chan struct{}, runtime.Instance, *storage.TrieState) (runtime.Instance, error) {
+				t.Error("offchain worker instance created")
+				return nil, nil
+			}
+
+			scheduler := runtime.NewOffchainWorkerScheduler(blockState, storageState, newInstance, 1)
+			err := scheduler.Start()
+			require.NoError(t, err)
+
+			imported <- &types.Block{Header: header}
+			<-checked
+
+			blockState.EXPECT().FreeImportedBlockNotifierChannel(imported)
+			err = scheduler.Stop()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOffchainWorkerScheduler_Stop(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	blockState := mocks.NewMockOffchainWorkerBlockState(ctrl)
+	storageState := mocks.NewMockOffchainWorkerStorageState(ctrl)
+	blockInstance := mocks.NewMockInstance(ctrl)
+	workerInstance := mocks.NewMockInstance(ctrl)
+
+	header := types.Header{Number: 1, Digest: types.NewDigest()}
+	trieState := storage.NewTrieState(trie.NewEmptyTrie())
+
+	imported := make(chan *types.Block, 1)
+	blockState.EXPECT().GetImportedBlockNotifierChannel().Return(imported)
+	blockState.EXPECT().BestBlockHash().Return(header.Hash())
+	blockState.EXPECT().GetRuntime(header.Hash()).Return(blockInstance, nil)
+	blockInstance.EXPECT().Version().Return(offchainWorkerTestVersion(t, 2), nil)
+	storageState.EXPECT().TrieState(&header.StateRoot).Return(trieState, nil)
+
+	var workerDone <-chan struct{}
+	newInstance := func(done <-chan struct{}, _ runtime.Instance,
+		_ *storage.TrieState) (runtime.Instance, error) {
+		workerDone = done
+		return workerInstance, nil
+	}
+
+	running := make(chan struct{})
+	aborted := false
+	workerInstance.EXPECT().OffchainWorker(&header).DoAndReturn(func(*types.Header) error {
+		close(running)
+		// the runtime call runs until it is aborted
+		<-workerDone
+		aborted = true
+		return runtime.ErrExecutionCanceled
+	})
+	workerInstance.EXPECT().Stop()
+
+	scheduler := runtime.NewOffchainWorkerScheduler(blockState, storageState, newInstance, 1)
+	err := scheduler.Start()
+	require.NoError(t, err)
+
+	imported <- &types.Block{Header: header}
+	<-running
+
+	// stopping aborts the offchain worker running and waits for it
+	blockState.EXPECT().FreeImportedBlockNotifierChannel(imported)
+	err = scheduler.Stop()
+	require.NoError(t, err)
+	assert.True(t, aborted)
+}
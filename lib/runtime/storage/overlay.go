@@ -0,0 +1,562 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// ErrStorageTransactionsOpen is returned when committing the overlay
+// changes while storage transactions are still open.
+var ErrStorageTransactionsOpen = errors.New("storage transactions are still open")
+
+// OverlayBackend is the state the overlay reads through to,
+// such as the trie of the parent block state.
+type OverlayBackend interface {
+	Get(key []byte) (value []byte)
+	GetFromChild(keyToChild, key []byte) (value []byte, err error)
+	GetKeysWithPrefix(prefix []byte) (keys [][]byte)
+	Snapshot() (snapshot *trie.Trie)
+}
+
+var _ OverlayBackend = (*trie.Trie)(nil)
+
+// Overlay is a copy-on-write overlay of storage changes layered over a
+// backend state, such that executing a block never modifies the backend
+// state. Reads return the value of the last change made to the key, and
+// fall through to the backend state for keys not changed.
+//
+// The changes are recorded in layers: the first layer records the changes
+// committed, and each storage transaction started adds a layer on top of
+// it. Committing a storage transaction merges its layer into the layer
+// below, and rolling it back discards its layer, such that none of the
+// changes made in a transaction rolled back leak into the transactions
+// below it. Once all storage transactions are closed, Commit returns the
+// changes made in order, to be applied with TrieState.ApplyChanges.
+//
+// The queries depending on the structure of the trie, such as the next key
+// or the root hash, are answered by a snapshot of the backend state with
+// the changes applied, which is only updated when such a query is made.
+//
+// It implements the runtime storage and is safe for concurrent use.
+type Overlay struct {
+	backend OverlayBackend
+	lock    sync.RWMutex
+	// layers contains the committed changes layer first,
+	// followed by a layer for each open storage transaction.
+	layers []*overlayLayer
+	// view is a snapshot of the backend state with the first viewChanges
+	// changes of the overlay applied, and is nil until a query needs it,
+	// or once changes applied to it are rolled back or committed.
+	view        *TrieState
+	viewChanges int
+}
+
+// overlayKey identifies a key of the main trie or of a child trie.
+type overlayKey struct {
+	isChild    bool
+	keyToChild string
+	key        string
+}
+
+// overlayLayer is a layer of storage changes.
+type overlayLayer struct {
+	// changes is the journal of the changes made, in order.
+	changes []StorageChange
+	// values maps each key changed to its last value,
+	// which is nil if the key is deleted.
+	values map[overlayKey][]byte
+}
+
+func newOverlayLayer() *overlayLayer {
+	return &overlayLayer{
+		values: make(map[overlayKey][]byte),
+	}
+}
+
+// NewOverlay returns an overlay without changes over the backend given.
+func NewOverlay(backend OverlayBackend) *Overlay {
+	return &Overlay{
+		backend: backend,
+		layers:  []*overlayLayer{newOverlayLayer()},
+	}
+}
+
+// Get returns the value at the given key in the main trie,
+// or nil if the key is absent or deleted.
+func (o *Overlay) Get(key []byte) (value []byte) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	value, changed := o.lastValue(overlayKey{key: string(key)})
+	if changed {
+		return value
+	}
+	return o.backend.Get(key)
+}
+
+// GetChildStorage returns the value at the given key in the child
+// trie located at the given key to child, or nil if the key is absent
+// or deleted, including if the child trie does not exist.
+func (o *Overlay) GetChildStorage(keyToChild, key []byte) (value []byte, err error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.getChildStorage(keyToChild, key)
+}
+
+// getChildStorage returns the value at the given key in the child
+// trie located at the given key to child, and must be called with
+// the lock held.
+func (o *Overlay) getChildStorage(keyToChild, key []byte) (value []byte, err error) {
+	value, changed := o.lastValue(overlayKey{isChild: true, keyToChild: string(keyToChild), key: string(key)})
+	if changed {
+		return value, nil
+	}
+
+	value, err = o.backend.GetFromChild(keyToChild, key)
+	if errors.Is(err, trie.ErrChildTrieDoesNotExist) {
+		return nil, nil
+	}
+	return value, err
+}
+
+// Put sets the value at the given key in the main trie.
+func (o *Overlay) Put(key, value []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.record(StorageChange{Key: key, Value: nonNilValue(value)})
+	return nil
+}
+
+// Delete deletes the given key from the main trie.
+func (o *Overlay) Delete(key []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.record(StorageChange{Key: key})
+	return nil
+}
+
+// LoadCode returns the runtime code located at the :code key.
+func (o *Overlay) LoadCode() (code []byte) {
+	return o.Get(common.CodeKey)
+}
+
+// SetChildStorage sets the value at the given key in the child trie
+// located at the given key to child, which is created if it does not
+// exist once the changes are applied.
+func (o *Overlay) SetChildStorage(keyToChild, key, value []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.record(StorageChange{KeyToChild: nonNilValue(keyToChild), Key: key, Value: nonNilValue(value)})
+	return nil
+}
+
+// SetChild replaces the child trie located at the given key to child by the
+// child trie given, by deleting the keys of the current child trie and writing
+// the entries of the child trie given. Setting an empty child trie therefore
+// deletes the current child trie.
+func (o *Overlay) SetChild(keyToChild []byte, child *trie.Trie) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	err = o.deleteChild(keyToChild)
+	if err != nil {
+		return err
+	}
+
+	entries := child.Entries()
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o.record(StorageChange{
+			KeyToChild: nonNilValue(keyToChild),
+			Key:        []byte(key),
+			Value:      nonNilValue(entries[key]),
+		})
+	}
+	return nil
+}
+
+// DeleteChild deletes the child trie located at the given key to child,
+// and does nothing if the child trie does not exist.
+func (o *Overlay) DeleteChild(keyToChild []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.deleteChild(keyToChild)
+}
+
+// deleteChild records the deletion of each key of the child trie located at
+// the given key to child, followed by the deletion of the child trie root in
+// the main trie, and must be called with the lock held.
+func (o *Overlay) deleteChild(keyToChild []byte) (err error) {
+	child, err := o.getChild(keyToChild)
+	if errors.Is(err, trie.ErrChildTrieDoesNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, key := range child.GetKeysWithPrefix(nil) {
+		o.record(StorageChange{KeyToChild: nonNilValue(keyToChild), Key: key})
+	}
+	o.record(StorageChange{Key: trie.ChildStorageKey(keyToChild)})
+	return nil
+}
+
+// DeleteChildLimit deletes up to the little endian uint32 limit given of keys
+// of the child trie located at the given key to child, in lexicographic order,
+// or deletes the whole child trie if limit is nil. It returns the number of
+// keys deleted and whether all the keys of the child trie are deleted.
+func (o *Overlay) DeleteChildLimit(keyToChild []byte, limit *[]byte) (
+	deleted uint32, allDeleted bool, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	child, err := o.getChild(keyToChild)
+	if err != nil {
+		return 0, false, err
+	}
+
+	keys := child.GetKeysWithPrefix(nil)
+	if limit == nil {
+		err = o.deleteChild(keyToChild)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint32(len(keys)), true, nil
+	}
+
+	limitUint := binary.LittleEndian.Uint32(*limit)
+	for _, key := range keys {
+		if deleted == limitUint {
+			break
+		}
+		o.record(StorageChange{KeyToChild: nonNilValue(keyToChild), Key: key})
+		deleted++
+	}
+	return deleted, deleted == uint32(len(keys)), nil
+}
+
+// GetChild returns the child trie located at the given key to child, with
+// the changes of the overlay applied, which must not be modified.
+func (o *Overlay) GetChild(keyToChild []byte) (child *trie.Trie, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.getChild(keyToChild)
+}
+
+// getChild returns the child trie located at the given key to child in
+// the view, and must be called with the lock held.
+func (o *Overlay) getChild(keyToChild []byte) (child *trie.Trie, err error) {
+	view, err := o.updateView()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetChild(keyToChild)
+}
+
+// GetChildNextKey returns the next key after the given key in the child
+// trie located at the given key to child, or nil if there is none.
+func (o *Overlay) GetChildNextKey(keyToChild, key []byte) (nextKey []byte, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	view, err := o.updateView()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetChildNextKey(keyToChild, key)
+}
+
+// ClearPrefixInChild deletes all the keys starting with the given
+// prefix from the child trie located at the given key to child.
+func (o *Overlay) ClearPrefixInChild(keyToChild, prefix []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	child, err := o.getChild(keyToChild)
+	if errors.Is(err, trie.ErrChildTrieDoesNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, key := range child.GetKeysWithPrefix(prefix) {
+		o.record(StorageChange{KeyToChild: nonNilValue(keyToChild), Key: key})
+	}
+	return nil
+}
+
+// NextKey returns the next key after the given key in
+// the main trie, or nil if there is none.
+func (o *Overlay) NextKey(key []byte) (nextKey []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	view, err := o.updateView()
+	if err != nil {
+		panic(fmt.Sprintf("updating overlay view: %s", err))
+	}
+	return view.NextKey(key)
+}
+
+// Root returns the root hash of the main trie with the changes of the overlay applied.
+func (o *Overlay) Root() (root common.Hash, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	view, err := o.updateView()
+	if err != nil {
+		return root, err
+	}
+	return view.Root()
+}
+
+// ClearChildStorage deletes the given key from the child trie located
+// at the given key to child. Nothing is recorded if the key is absent,
+// such that no change is made to a child trie which does not exist.
+func (o *Overlay) ClearChildStorage(keyToChild, key []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	value, err := o.getChildStorage(keyToChild, key)
+	if err != nil {
+		return fmt.Errorf("getting child storage: %w", err)
+	} else if value == nil {
+		return nil
+	}
+
+	o.record(StorageChange{KeyToChild: nonNilValue(keyToChild), Key: key})
+	return nil
+}
+
+// ClearPrefix deletes all the keys of the main trie starting
+// with the given prefix, both from the backend and from the
+// changes made in the overlay.
+func (o *Overlay) ClearPrefix(prefix []byte) (err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	keys := make(map[string]struct{})
+	for _, key := range o.backend.GetKeysWithPrefix(prefix) {
+		keys[string(key)] = struct{}{}
+	}
+	for _, layer := range o.layers {
+		for key := range layer.values {
+			if !key.isChild && strings.HasPrefix(key.key, string(prefix)) {
+				keys[key.key] = struct{}{}
+			}
+		}
+	}
+
+	// The keys are deleted in order for the changes to be deterministic.
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		value, changed := o.lastValue(overlayKey{key: key})
+		if changed && value == nil {
+			continue
+		}
+		o.record(StorageChange{Key: []byte(key)})
+	}
+	return nil
+}
+
+// ClearPrefixLimit deletes up to limit keys of the main trie starting with the
+// given prefix, in lexicographic order. It returns the number of keys deleted
+// and whether all the keys starting with the prefix are deleted.
+func (o *Overlay) ClearPrefixLimit(prefix []byte, limit uint32) (
+	deleted uint32, allDeleted bool, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	view, err := o.updateView()
+	if err != nil {
+		return 0, false, err
+	}
+
+	keys := view.Trie().GetKeysWithPrefix(prefix)
+	for _, key := range keys {
+		if deleted == limit {
+			break
+		}
+		o.record(StorageChange{Key: key})
+		deleted++
+	}
+	return deleted, deleted == uint32(len(keys)), nil
+}
+
+// BeginStorageTransaction starts a new storage transaction,
+// nested in the storage transactions already open if any.
+func (o *Overlay) BeginStorageTransaction() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.layers = append(o.layers, newOverlayLayer())
+}
+
+// CommitStorageTransaction commits the changes made in the last storage
+// transaction started into the storage transaction it is nested in, or
+// into the committed changes if it is not nested. It does nothing if no
+// storage transaction is open.
+func (o *Overlay) CommitStorageTransaction() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.layers) == 1 {
+		return
+	}
+
+	top := o.layers[len(o.layers)-1]
+	o.layers = o.layers[:len(o.layers)-1]
+	parent := o.layers[len(o.layers)-1]
+	parent.changes = append(parent.changes, top.changes...)
+	for key, value := range top.values {
+		parent.values[key] = value
+	}
+}
+
+// RollbackStorageTransaction discards the changes made in the last storage
+// transaction started, including the changes of the storage transactions
+// committed into it. It does nothing if no storage transaction is open.
+func (o *Overlay) RollbackStorageTransaction() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.layers) == 1 {
+		return
+	}
+
+	o.layers = o.layers[:len(o.layers)-1]
+	if o.viewChanges > o.changesCount() {
+		// The view contains changes rolled back.
+		o.view = nil
+	}
+}
+
+// TransactionDepth returns the number of storage transactions open.
+func (o *Overlay) TransactionDepth() (depth uint) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return uint(len(o.layers) - 1)
+}
+
+// Commit returns the changes committed in the order they were made,
+// to be applied to the backend trie with TrieState.ApplyChanges, and
+// resets the overlay. It returns an error wrapping
+// ErrStorageTransactionsOpen if storage transactions are still open,
+// in which case the overlay is left unchanged.
+func (o *Overlay) Commit() (changes []StorageChange, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.layers) > 1 {
+		return nil, fmt.Errorf("%w: %d transactions", ErrStorageTransactionsOpen, len(o.layers)-1)
+	}
+
+	changes = o.layers[0].changes
+	o.layers[0] = newOverlayLayer()
+	o.view = nil
+	return changes, nil
+}
+
+// changesCount returns the number of changes made in all the layers,
+// and must be called with the lock held.
+func (o *Overlay) changesCount() (count int) {
+	for _, layer := range o.layers {
+		count += len(layer.changes)
+	}
+	return count
+}
+
+// updateView applies the changes made since the view was last updated to
+// the view, creating it from a snapshot of the backend if needed, and must
+// be called with the lock held.
+func (o *Overlay) updateView() (view *TrieState, err error) {
+	if o.view == nil {
+		o.view = NewTrieState(o.backend.Snapshot())
+		o.viewChanges = 0
+	}
+
+	o.view.lock.Lock()
+	defer o.view.lock.Unlock()
+
+	// The changes of the layers, in order, are the changes made in order.
+	skip := o.viewChanges
+	for _, layer := range o.layers {
+		if skip >= len(layer.changes) {
+			skip -= len(layer.changes)
+			continue
+		}
+
+		for _, change := range layer.changes[skip:] {
+			err = o.view.applyChange(change)
+			if err != nil {
+				o.view = nil
+				return nil, fmt.Errorf("applying change to overlay view: %w", err)
+			}
+			o.viewChanges++
+		}
+		skip = 0
+	}
+	return o.view, nil
+}
+
+// lastValue returns the value of the last change made to the key given,
+// and false if the key is not changed in the overlay. It must be called
+// with the lock held.
+func (o *Overlay) lastValue(key overlayKey) (value []byte, changed bool) {
+	for i := len(o.layers) - 1; i >= 0; i-- {
+		value, changed = o.layers[i].values[key]
+		if changed {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// record records the change given in the top layer. The keys and value
+// are copied since they can be backed by the runtime memory. It must be
+// called with the lock held.
+func (o *Overlay) record(change StorageChange) {
+	change = StorageChange{
+		KeyToChild: bytes.Clone(change.KeyToChild),
+		Key:        bytes.Clone(change.Key),
+		Value:      bytes.Clone(change.Value),
+	}
+
+	key := overlayKey{
+		isChild:    change.KeyToChild != nil,
+		keyToChild: string(change.KeyToChild),
+		key:        string(change.Key),
+	}
+
+	top := o.layers[len(o.layers)-1]
+	top.changes = append(top.changes, change)
+	top.values[key] = change.Value
+}
+
+// nonNilValue returns an empty slice if the value given is nil, such that
+// an empty value written is not mistaken for a deletion.
+func nonNilValue(value []byte) []byte {
+	if value == nil {
+		return []byte{}
+	}
+	return value
+}
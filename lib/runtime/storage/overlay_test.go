@@ -0,0 +1,216 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOverlayBackend(t *testing.T) *trie.Trie {
+	t.Helper()
+
+	backend := trie.NewEmptyTrie()
+	for _, key := range []string{"a", "prefix1", "prefix2"} {
+		err := backend.Put([]byte(key), []byte("backend "+key))
+		require.NoError(t, err)
+	}
+	err := backend.SetChild([]byte("child"), trie.NewEmptyTrie())
+	require.NoError(t, err)
+	err = backend.PutIntoChild([]byte("child"), []byte("childKey"), []byte("backend child"))
+	require.NoError(t, err)
+	return backend
+}
+
+func TestOverlay_readsFallThrough(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestOverlayBackend(t)
+	backendRoot := backend.MustHash()
+	overlay := NewOverlay(backend)
+
+	assert.Equal(t, []byte("backend a"), overlay.Get([]byte("a")))
+	value, err := overlay.GetChildStorage([]byte("child"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("backend child"), value)
+	value, err = overlay.GetChildStorage([]byte("absentChild"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, overlay.Put([]byte("a"), []byte("overlay a")))
+	require.NoError(t, overlay.Delete([]byte("prefix1")))
+	require.NoError(t, overlay.Put([]byte("empty"), nil))
+	require.NoError(t, overlay.SetChildStorage([]byte("child"), []byte("childKey"), []byte("overlay child")))
+
+	assert.Equal(t, []byte("overlay a"), overlay.Get([]byte("a")))
+	assert.Nil(t, overlay.Get([]byte("prefix1")))
+	assert.Equal(t, []byte{}, overlay.Get([]byte("empty")))
+	value, err = overlay.GetChildStorage([]byte("child"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("overlay child"), value)
+
+	// The backend is never modified.
+	assert.Equal(t, []byte("backend a"), backend.Get([]byte("a")))
+	assert.Equal(t, backendRoot, backend.MustHash())
+}
+
+func TestOverlay_nestedTransactions(t *testing.T) {
+	t.Parallel()
+
+	overlay := NewOverlay(newTestOverlayBackend(t))
+	key := []byte("a")
+
+	overlay.BeginStorageTransaction()
+	require.NoError(t, overlay.Put(key, []byte("outer")))
+
+	overlay.BeginStorageTransaction()
+	require.NoError(t, overlay.Put(key, []byte("inner")))
+	require.NoError(t, overlay.Put([]byte("innerOnly"), []byte("inner")))
+	require.NoError(t, overlay.SetChildStorage([]byte("child"), []byte("innerChildKey"), []byte("inner")))
+	assert.Equal(t, uint(2), overlay.TransactionDepth())
+
+	// The rolled back inner transaction does not leak into the outer transaction.
+	overlay.RollbackStorageTransaction()
+	assert.Equal(t, []byte("outer"), overlay.Get(key))
+	assert.Nil(t, overlay.Get([]byte("innerOnly")))
+	value, err := overlay.GetChildStorage([]byte("child"), []byte("innerChildKey"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	// A committed inner transaction is merged into the outer transaction...
+	overlay.BeginStorageTransaction()
+	require.NoError(t, overlay.Put(key, []byte("committed inner")))
+	overlay.CommitStorageTransaction()
+	assert.Equal(t, []byte("committed inner"), overlay.Get(key))
+
+	// ... and rolled back with it.
+	overlay.RollbackStorageTransaction()
+	assert.Equal(t, []byte("backend a"), overlay.Get(key))
+	assert.Equal(t, uint(0), overlay.TransactionDepth())
+
+	// Committing or rolling back without a transaction open does nothing.
+	overlay.RollbackStorageTransaction()
+	overlay.CommitStorageTransaction()
+	assert.Equal(t, uint(0), overlay.TransactionDepth())
+
+	changes, err := overlay.Commit()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestOverlay_Commit(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestOverlayBackend(t)
+	overlay := NewOverlay(backend)
+
+	require.NoError(t, overlay.Put([]byte("a"), []byte("first")))
+	overlay.BeginStorageTransaction()
+	require.NoError(t, overlay.Put([]byte("a"), []byte("second")))
+	require.NoError(t, overlay.Put([]byte("prefix3"), []byte("value")))
+	require.NoError(t, overlay.ClearPrefix([]byte("prefix")))
+	overlay.CommitStorageTransaction()
+
+	overlay.BeginStorageTransaction()
+	require.NoError(t, overlay.Put([]byte("rolledBack"), []byte("value")))
+	_, err := overlay.Commit()
+	assert.ErrorIs(t, err, ErrStorageTransactionsOpen)
+	overlay.RollbackStorageTransaction()
+
+	require.NoError(t, overlay.SetChildStorage([]byte("child"), []byte("childKey2"), []byte("value")))
+	require.NoError(t, overlay.SetChildStorage([]byte("newChild"), []byte("key"), []byte("value")))
+	err = overlay.ClearChildStorage([]byte("child"), []byte("childKey"))
+	require.NoError(t, err)
+	err = overlay.ClearChildStorage([]byte("absentChild"), []byte("key"))
+	require.NoError(t, err)
+
+	changes, err := overlay.Commit()
+	require.NoError(t, err)
+	expectedChanges := []StorageChange{
+		{Key: []byte("a"), Value: []byte("first")},
+		{Key: []byte("a"), Value: []byte("second")},
+		{Key: []byte("prefix3"), Value: []byte("value")},
+		{Key: []byte("prefix1")},
+		{Key: []byte("prefix2")},
+		{Key: []byte("prefix3")},
+		{KeyToChild: []byte("child"), Key: []byte("childKey2"), Value: []byte("value")},
+		{KeyToChild: []byte("newChild"), Key: []byte("key"), Value: []byte("value")},
+		{KeyToChild: []byte("child"), Key: []byte("childKey")},
+	}
+	assert.Equal(t, expectedChanges, changes)
+
+	// The overlay is reset once committed.
+	assert.Equal(t, []byte("backend a"), overlay.Get([]byte("a")))
+
+	// Applying the changes gives the same state as making them on the trie.
+	expected := NewTrieState(newTestOverlayBackend(t))
+	require.NoError(t, expected.Put([]byte("a"), []byte("second")))
+	require.NoError(t, expected.ClearPrefix([]byte("prefix")))
+	require.NoError(t, expected.SetChildStorage([]byte("child"), []byte("childKey2"), []byte("value")))
+	require.NoError(t, expected.SetChildStorage([]byte("newChild"), []byte("key"), []byte("value")))
+	require.NoError(t, expected.ClearChildStorage([]byte("child"), []byte("childKey")))
+
+	ts := NewTrieState(backend)
+	root, err := ts.ApplyChanges(changes)
+	require.NoError(t, err)
+	assert.Equal(t, expected.MustRoot(), root)
+}
+
+func TestOverlay_trieQueries(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestOverlayBackend(t)
+	overlay := NewOverlay(backend)
+	expected := NewTrieState(newTestOverlayBackend(t))
+
+	assert.Equal(t, []byte("prefix1"), overlay.NextKey([]byte("a")))
+
+	require.NoError(t, overlay.Put([]byte("b"), []byte("value")))
+	require.NoError(t, expected.Put([]byte("b"), []byte("value")))
+	assert.Equal(t, []byte("b"), overlay.NextKey([]byte("a")))
+
+	// The view is updated with the changes made after a query.
+	overlay.BeginStorageTransaction()
+	require.NoError(t, overlay.Put([]byte("aa"), []byte("value")))
+	assert.Equal(t, []byte("aa"), overlay.NextKey([]byte("a")))
+
+	// The view does not contain the changes rolled back.
+	overlay.RollbackStorageTransaction()
+	assert.Equal(t, []byte("b"), overlay.NextKey([]byte("a")))
+
+	deleted, allDeleted, err := overlay.ClearPrefixLimit([]byte("prefix"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), deleted)
+	assert.False(t, allDeleted)
+	_, _, err = expected.ClearPrefixLimit([]byte("prefix"), 1)
+	require.NoError(t, err)
+	assert.Nil(t, overlay.Get([]byte("prefix1")))
+	assert.Equal(t, []byte("backend prefix2"), overlay.Get([]byte("prefix2")))
+
+	nextKey, err := overlay.GetChildNextKey([]byte("child"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("childKey"), nextKey)
+
+	require.NoError(t, overlay.DeleteChild([]byte("child")))
+	require.NoError(t, expected.DeleteChild([]byte("child")))
+	value, err := overlay.GetChildStorage([]byte("child"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	_, err = overlay.GetChild([]byte("child"))
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+
+	root, err := overlay.Root()
+	require.NoError(t, err)
+	assert.Equal(t, expected.MustRoot(), root)
+
+	// Applying the changes gives the root hash computed by the overlay.
+	changes, err := overlay.Commit()
+	require.NoError(t, err)
+	appliedRoot, err := NewTrieState(backend).ApplyChanges(changes)
+	require.NoError(t, err)
+	assert.Equal(t, root, appliedRoot)
+}
@@ -0,0 +1,213 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+var (
+	// ErrProofBackedStateReadOnly is returned when attempting
+	// to modify the state through a proof backed state.
+	ErrProofBackedStateReadOnly = errors.New("proof backed state is read-only")
+	// ErrInvalidProof is returned when a proof fetched does not contain
+	// the trie nodes on the path to the key read, for example because
+	// it is a proof for another state root.
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+var errProofNodeNotFound = errors.New("trie node not found in proofs")
+
+// ProofFetcher fetches the encoded trie nodes proving the value at the given
+// key in the trie with the given root hash, such as a remote read proof.
+type ProofFetcher func(rootHash common.Hash, key []byte) (encodedProofNodes [][]byte, err error)
+
+// ProofBackedState is a read only state at a state root, answering reads
+// from the proofs fetched for the keys read instead of the full state trie.
+// The trie nodes of the proofs are only used if they are on the path from
+// the state root to the key read, so the values read are verified against
+// the state root. The trie nodes are cached, such that reads of keys close
+// to keys already read reuse them, and a proof is only fetched when a trie
+// node on the path to the key read is missing.
+//
+// Unlike the TrieState, reads return an error, since fetching proofs may fail.
+// It is safe for concurrent use.
+type ProofBackedState struct {
+	root       common.Hash
+	fetchProof ProofFetcher
+	nodes      *proofNodes
+	// triesMutex protects tries, the lazy tries reading from the proof
+	// nodes, for the state root and the child trie root hashes.
+	triesMutex sync.Mutex
+	tries      map[common.Hash]*trie.LazyTrie
+}
+
+// NewProofBackedState returns a read only state at the given state
+// root, reading the values from the proofs fetched with fetchProof.
+func NewProofBackedState(root common.Hash, fetchProof ProofFetcher) *ProofBackedState {
+	return &ProofBackedState{
+		root:       root,
+		fetchProof: fetchProof,
+		nodes:      newProofNodes(),
+		tries:      make(map[common.Hash]*trie.LazyTrie),
+	}
+}
+
+// Root returns the state root of the state.
+func (s *ProofBackedState) Root() (common.Hash, error) {
+	return s.root, nil
+}
+
+// Get returns the value at the given key, or nil if the key is absent.
+// An error wrapping ErrInvalidProof is returned if the proof fetched does
+// not prove the value at the key for the state root.
+func (s *ProofBackedState) Get(key []byte) (value []byte, err error) {
+	return s.get(s.root, key)
+}
+
+// Has returns whether the given key is present in the state.
+func (s *ProofBackedState) Has(key []byte) (has bool, err error) {
+	value, err := s.Get(key)
+	return value != nil, err
+}
+
+// LoadCode returns the runtime code stored at the :code key.
+func (s *ProofBackedState) LoadCode() (code []byte, err error) {
+	return s.Get(common.CodeKey)
+}
+
+// GetChildStorage returns the value at the given key in the child trie
+// located at the given key to child, or nil if the key is absent.
+// An error wrapping trie.ErrChildTrieDoesNotExist is returned if the
+// child trie does not exist.
+func (s *ProofBackedState) GetChildStorage(keyToChild, key []byte) (value []byte, err error) {
+	childStorageKey := trie.ChildStorageKey(keyToChild)
+
+	encodedChildRoot, err := s.get(s.root, childStorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("getting child trie root hash: %w", err)
+	} else if encodedChildRoot == nil {
+		return nil, fmt.Errorf("%w at key 0x%x", trie.ErrChildTrieDoesNotExist, childStorageKey)
+	}
+
+	return s.get(common.BytesToHash(encodedChildRoot), key)
+}
+
+// Put returns ErrProofBackedStateReadOnly.
+func (*ProofBackedState) Put(_, _ []byte) error {
+	return ErrProofBackedStateReadOnly
+}
+
+// Delete returns ErrProofBackedStateReadOnly.
+func (*ProofBackedState) Delete(_ []byte) error {
+	return ErrProofBackedStateReadOnly
+}
+
+// ClearPrefix returns ErrProofBackedStateReadOnly.
+func (*ProofBackedState) ClearPrefix(_ []byte) error {
+	return ErrProofBackedStateReadOnly
+}
+
+// SetChildStorage returns ErrProofBackedStateReadOnly.
+func (*ProofBackedState) SetChildStorage(_, _, _ []byte) error {
+	return ErrProofBackedStateReadOnly
+}
+
+// ClearChildStorage returns ErrProofBackedStateReadOnly.
+func (*ProofBackedState) ClearChildStorage(_, _ []byte) error {
+	return ErrProofBackedStateReadOnly
+}
+
+// DeleteChild returns ErrProofBackedStateReadOnly.
+func (*ProofBackedState) DeleteChild(_ []byte) error {
+	return ErrProofBackedStateReadOnly
+}
+
+// get returns the value at the given key in the trie with the given root
+// hash, from the cached trie nodes if they contain the path to the key, or
+// else from the proof fetched for the key.
+func (s *ProofBackedState) get(rootHash common.Hash, key []byte) (value []byte, err error) {
+	value, err = s.getFromProofNodes(rootHash, key)
+	if !errors.Is(err, errProofNodeNotFound) {
+		return value, err
+	}
+
+	encodedProofNodes, err := s.fetchProof(rootHash, key)
+	if err != nil {
+		return nil, fmt.Errorf("fetching proof for key 0x%x at root %s: %w", key, rootHash, err)
+	}
+
+	err = s.nodes.add(encodedProofNodes)
+	if err != nil {
+		return nil, fmt.Errorf("adding proof nodes: %w", err)
+	}
+
+	value, err = s.getFromProofNodes(rootHash, key)
+	if errors.Is(err, errProofNodeNotFound) {
+		return nil, fmt.Errorf("%w: for key 0x%x at root %s: %s", ErrInvalidProof, key, rootHash, err)
+	}
+	return value, err
+}
+
+func (s *ProofBackedState) getFromProofNodes(rootHash common.Hash, key []byte) (value []byte, err error) {
+	s.triesMutex.Lock()
+	lazyTrie, ok := s.tries[rootHash]
+	if !ok {
+		lazyTrie, err = trie.NewLazyTrie(s.nodes, rootHash)
+		if err != nil {
+			s.triesMutex.Unlock()
+			return nil, err
+		}
+		s.tries[rootHash] = lazyTrie
+	}
+	s.triesMutex.Unlock()
+
+	return lazyTrie.Get(key)
+}
+
+// proofNodes is a database of the encoded trie nodes of the proofs fetched,
+// indexed by their node hash, such that a node read with its node hash is
+// always the node with this hash.
+type proofNodes struct {
+	mutex              sync.RWMutex
+	nodeHashToEncoding map[common.Hash][]byte
+}
+
+func newProofNodes() *proofNodes {
+	return &proofNodes{
+		nodeHashToEncoding: make(map[common.Hash][]byte),
+	}
+}
+
+func (p *proofNodes) add(encodedProofNodes [][]byte) (err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, encodedProofNode := range encodedProofNodes {
+		nodeHash, err := common.Blake2bHash(encodedProofNode)
+		if err != nil {
+			return fmt.Errorf("hashing proof node: %w", err)
+		}
+		p.nodeHashToEncoding[nodeHash] = encodedProofNode
+	}
+	return nil
+}
+
+// Get returns the encoded trie node with the given node hash,
+// or an error wrapping errProofNodeNotFound if it is not known.
+func (p *proofNodes) Get(nodeHash []byte) (encodedNode []byte, err error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	encodedNode, ok := p.nodeHashToEncoding[common.BytesToHash(nodeHash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: 0x%x", errProofNodeNotFound, nodeHash)
+	}
+	return encodedNode, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/lib/trie/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapDatabase is an in memory database of trie nodes.
+type mapDatabase map[string][]byte
+
+func (db mapDatabase) Put(key, value []byte) error {
+	db[string(key)] = value
+	return nil
+}
+
+func (db mapDatabase) Get(key []byte) (value []byte, err error) {
+	value, ok := db[string(key)]
+	if !ok {
+		return nil, chaindb.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+// newTestProofSource returns a full trie written to a database serving
+// the proofs of its keys, and of its child trie at the key "child".
+func newTestProofSource(t *testing.T) (fullTrie *trie.Trie, db mapDatabase) {
+	t.Helper()
+
+	fullTrie = trie.NewEmptyTrie()
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		value := []byte(fmt.Sprintf("a value long enough to not be inlined %d", i))
+		err := fullTrie.Put(key, value)
+		require.NoError(t, err)
+	}
+
+	child := trie.NewEmptyTrie()
+	err := child.Put([]byte("childKey"), []byte("childValue"))
+	require.NoError(t, err)
+	err = fullTrie.SetChild([]byte("child"), child)
+	require.NoError(t, err)
+
+	db = make(mapDatabase)
+	err = fullTrie.WriteDirtyTo(db)
+	require.NoError(t, err)
+	return fullTrie, db
+}
+
+// generateTestProof generates the proof for the given key in the trie with
+// the given root hash. Since proofs are only generated for present keys, the
+// proof of an absent key contains all the trie nodes, proving its absence.
+func generateTestProof(t *testing.T, db mapDatabase, rootHash common.Hash, key []byte) [][]byte {
+	t.Helper()
+
+	encodedProofNodes, err := proof.Generate(rootHash.ToBytes(), [][]byte{key}, db)
+	if errors.Is(err, proof.ErrKeyNotFound) {
+		encodedProofNodes = make([][]byte, 0, len(db))
+		for _, encodedNode := range db {
+			encodedProofNodes = append(encodedProofNodes, encodedNode)
+		}
+		return encodedProofNodes
+	}
+	require.NoError(t, err)
+	return encodedProofNodes
+}
+
+func TestProofBackedState(t *testing.T) {
+	t.Parallel()
+
+	fullTrie, db := newTestProofSource(t)
+	root := fullTrie.MustHash()
+
+	var fetchedKeys [][]byte
+	state := NewProofBackedState(root, func(rootHash common.Hash, key []byte) ([][]byte, error) {
+		fetchedKeys = append(fetchedKeys, key)
+		return generateTestProof(t, db, rootHash, key), nil
+	})
+
+	stateRoot, err := state.Root()
+	require.NoError(t, err)
+	assert.Equal(t, root, stateRoot)
+
+	value, err := state.Get([]byte("key10"))
+	require.NoError(t, err)
+	assert.Equal(t, fullTrie.Get([]byte("key10")), value)
+	assert.Equal(t, [][]byte{[]byte("key10")}, fetchedKeys)
+
+	// keys on the path to a key already read are read from the
+	// cached trie nodes, without fetching another proof.
+	value, err = state.Get([]byte("key1"))
+	require.NoError(t, err)
+	assert.Equal(t, fullTrie.Get([]byte("key1")), value)
+	has, err := state.Has([]byte("key1"))
+	require.NoError(t, err)
+	assert.True(t, has)
+	assert.Len(t, fetchedKeys, 1)
+
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		value, err = state.Get(key)
+		require.NoError(t, err)
+		assert.Equal(t, fullTrie.Get(key), value)
+	}
+
+	value, err = state.Get([]byte("absent"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = state.GetChildStorage([]byte("child"), []byte("childKey"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("childValue"), value)
+
+	value, err = state.GetChildStorage([]byte("child"), []byte("absent"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, err = state.GetChildStorage([]byte("absentChild"), []byte("childKey"))
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+
+	err = state.Put([]byte("key1"), []byte("value"))
+	assert.ErrorIs(t, err, ErrProofBackedStateReadOnly)
+	err = state.Delete([]byte("key1"))
+	assert.ErrorIs(t, err, ErrProofBackedStateReadOnly)
+	err = state.ClearPrefix([]byte("key"))
+	assert.ErrorIs(t, err, ErrProofBackedStateReadOnly)
+	err = state.SetChildStorage([]byte("child"), []byte("childKey"), []byte("value"))
+	assert.ErrorIs(t, err, ErrProofBackedStateReadOnly)
+	err = state.ClearChildStorage([]byte("child"), []byte("childKey"))
+	assert.ErrorIs(t, err, ErrProofBackedStateReadOnly)
+	err = state.DeleteChild([]byte("child"))
+	assert.ErrorIs(t, err, ErrProofBackedStateReadOnly)
+}
+
+func TestProofBackedState_invalidProofs(t *testing.T) {
+	t.Parallel()
+
+	fullTrie, db := newTestProofSource(t)
+	staleRoot := fullTrie.MustHash()
+	err := fullTrie.Put([]byte("key1"), []byte("a new value long enough to not be inlined"))
+	require.NoError(t, err)
+	err = fullTrie.WriteDirtyTo(db)
+	require.NoError(t, err)
+	root := fullTrie.MustHash()
+
+	testCases := map[string]struct {
+		fetchProof ProofFetcher
+		errWrapped error
+	}{
+		"stale_proof": {
+			fetchProof: func(_ common.Hash, key []byte) ([][]byte, error) {
+				return generateTestProof(t, db, staleRoot, key), nil
+			},
+			errWrapped: ErrInvalidProof,
+		},
+		"incomplete_proof": {
+			fetchProof: func(rootHash common.Hash, key []byte) ([][]byte, error) {
+				encodedProofNodes := generateTestProof(t, db, rootHash, key)
+				return encodedProofNodes[:len(encodedProofNodes)-1], nil
+			},
+			errWrapped: ErrInvalidProof,
+		},
+		"tampered_proof": {
+			fetchProof: func(rootHash common.Hash, key []byte) ([][]byte, error) {
+				encodedProofNodes := generateTestProof(t, db, rootHash, key)
+				last := encodedProofNodes[len(encodedProofNodes)-1]
+				tampered := append([]byte{}, last...)
+				tampered[len(tampered)-1]++
+				encodedProofNodes[len(encodedProofNodes)-1] = tampered
+				return encodedProofNodes, nil
+			},
+			errWrapped: ErrInvalidProof,
+		},
+		"fetch_error": {
+			fetchProof: func(common.Hash, []byte) ([][]byte, error) {
+				return nil, chaindb.ErrKeyNotFound
+			},
+			errWrapped: chaindb.ErrKeyNotFound,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			state := NewProofBackedState(root, testCase.fetchProof)
+
+			value, err := state.Get([]byte("key1"))
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Nil(t, value)
+		})
+	}
+}
@@ -4,7 +4,9 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -17,9 +19,23 @@
 // TrieState is a wrapper around a transient trie that is used during the course of executing some runtime call.
 // If the execution of the call is successful, the trie will be saved in the StorageState.
 type TrieState struct {
-	t       *trie.Trie
-	oldTrie *trie.Trie // this is the trie before BeginStorageTransaction is called. set to nil if it isn't called
-	lock    sync.RWMutex
+	t    *trie.Trie
+	lock sync.RWMutex
+	// transactions is the stack of the storage transactions open,
+	// the last transaction being the last one started.
+	transactions []storageTransaction
+	// changes is the journal of the key changes made, to compute the change statistics.
+	changes []keyChange
+}
+
+// storageTransaction records the state of the TrieState
+// when a storage transaction is started, to roll it back.
+type storageTransaction struct {
+	// trie is the trie before the storage transaction is started.
+	trie *trie.Trie
+	// changesLength is the length of the changes journal
+	// when the storage transaction is started.
+	changesLength int
 }
 
 // NewTrieState returns a new TrieState with the given trie
@@ -50,30 +66,51 @@ func (s *TrieState) Snapshot() *trie.Trie {
 func (s *TrieState) BeginStorageTransaction() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.oldTrie = s.t
+	s.transactions = append(s.transactions, storageTransaction{
+		trie:          s.t,
+		changesLength: len(s.changes),
+	})
 	s.t = s.t.Snapshot()
 }
 
-// CommitStorageTransaction commits all storage changes made since BeginStorageTransaction was called.
+// CommitStorageTransaction commits all storage changes made since the last
+// BeginStorageTransaction call into the storage transaction it is nested in,
+// if any. It does nothing if no storage transaction is open.
 func (s *TrieState) CommitStorageTransaction() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.oldTrie = nil
+	if len(s.transactions) == 0 {
+		return
+	}
+	s.transactions = s.transactions[:len(s.transactions)-1]
 }
 
-// RollbackStorageTransaction rolls back all storage changes made since BeginStorageTransaction was called.
+// RollbackStorageTransaction rolls back all storage changes made since the
+// last BeginStorageTransaction call, including the changes of the storage
+// transactions nested in it and committed. It does nothing if no storage
+// transaction is open.
 func (s *TrieState) RollbackStorageTransaction() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.t = s.oldTrie
-	s.oldTrie = nil
+	if len(s.transactions) == 0 {
+		return
+	}
+	transaction := s.transactions[len(s.transactions)-1]
+	s.transactions = s.transactions[:len(s.transactions)-1]
+	s.t = transaction.trie
+	s.changes = s.changes[:transaction.changesLength]
 }
 
 // Put puts a key-value pair in the trie
 func (s *TrieState) Put(key, value []byte) (err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.Put(key, value)
+	err = s.t.Put(key, value)
+	if err != nil {
+		return err
+	}
+	s.recordWrite(nil, key, value)
+	return nil
 }
 
 // Get gets a value from the trie
@@ -111,10 +148,289 @@ func (s *TrieState) Delete(key []byte) (err error) {
 	if err != nil {
 		return fmt.Errorf("deleting from trie: %w", err)
 	}
+	s.recordDelete(nil, key)
+
+	return nil
+}
+
+// ChangeStats are the statistics of the key changes made in the main trie and
+// in the child tries since the TrieState was created, where only the last
+// change of each key is accounted for, and changes rolled back are ignored.
+type ChangeStats struct {
+	// KeysWritten is the number of distinct keys written.
+	KeysWritten uint
+	// KeysDeleted is the number of distinct keys deleted.
+	KeysDeleted uint
+	// ValueBytesWritten is the total size in bytes of the values of the keys written.
+	ValueBytesWritten uint
+}
+
+// keyChange is a key change recorded in the changes journal of the TrieState.
+type keyChange struct {
+	// keyToChild is the key of the child trie, and is nil for the main trie.
+	keyToChild []byte
+	key        []byte
+	// valueSize is the size in bytes of the value written, and is -1 if the key is deleted.
+	valueSize int
+}
+
+// recordWrite records a key written. The keys are copied
+// since they can be backed by the runtime memory.
+func (s *TrieState) recordWrite(keyToChild, key, value []byte) {
+	s.changes = append(s.changes, keyChange{
+		keyToChild: bytes.Clone(keyToChild),
+		key:        bytes.Clone(key),
+		valueSize:  len(value),
+	})
+}
+
+// recordDelete records a key deleted. The keys are copied
+// since they can be backed by the runtime memory.
+func (s *TrieState) recordDelete(keyToChild, key []byte) {
+	s.changes = append(s.changes, keyChange{
+		keyToChild: bytes.Clone(keyToChild),
+		key:        bytes.Clone(key),
+		valueSize:  -1,
+	})
+}
+
+func (s *TrieState) recordChildDeletion(keyToChild []byte, child *trie.Trie) {
+	if child == nil {
+		return
+	}
+	for key := range child.Entries() {
+		s.recordDelete(keyToChild, []byte(key))
+	}
+}
+
+// ChangeStats returns the statistics of the key changes made.
+func (s *TrieState) ChangeStats() (stats ChangeStats) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	type trieKey struct {
+		isChild    bool
+		keyToChild string
+		key        string
+	}
+	lastValueSizes := make(map[trieKey]int, len(s.changes))
+	for _, change := range s.changes {
+		key := trieKey{
+			isChild:    change.keyToChild != nil,
+			keyToChild: string(change.keyToChild),
+			key:        string(change.key),
+		}
+		lastValueSizes[key] = change.valueSize
+	}
+
+	for _, valueSize := range lastValueSizes {
+		if valueSize == -1 {
+			stats.KeysDeleted++
+			continue
+		}
+		stats.KeysWritten++
+		stats.ValueBytesWritten += uint(valueSize)
+	}
+	return stats
+}
+
+// StorageChange is a storage change made by the runtime.
+type StorageChange struct {
+	// KeyToChild is the key of the child trie in the main trie,
+	// and is nil if the change is made in the main trie.
+	KeyToChild []byte
+	Key        []byte
+	// Value is the new value for the key, and is nil if the key is deleted.
+	Value []byte
+}
+
+// ApplyChanges applies the storage changes given to the trie, and returns
+// the new root hash of the trie. The result is the same as applying the
+// changes one by one in order, but the changes are sorted by key such that
+// only the last change of each key is applied, and the root hash of each
+// child trie changed is only computed once.
+func (s *TrieState) ApplyChanges(changes []StorageChange) (root common.Hash, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, change := range changes {
+		if change.KeyToChild == nil && bytes.HasPrefix(change.Key, trie.ChildStorageKeyPrefix) {
+			// Changes to the child trie roots in the main trie depend
+			// on the changes order, so they are applied one by one.
+			for _, change := range changes {
+				err = s.applyChange(change)
+				if err != nil {
+					return root, err
+				}
+			}
+			return s.t.Hash()
+		}
+	}
+
+	changes = lastChangePerKey(changes)
+
+	for len(changes) > 0 {
+		keyToChild := changes[0].KeyToChild
+		end := 1
+		for end < len(changes) && sameTrie(changes[end].KeyToChild, keyToChild) {
+			end++
+		}
+		trieChanges := changes[:end]
+		changes = changes[end:]
+
+		if keyToChild == nil {
+			for _, change := range trieChanges {
+				err = s.applyChange(change)
+				if err != nil {
+					return root, err
+				}
+			}
+			continue
+		}
 
+		err = s.createChildIfWritten(keyToChild, trieChanges)
+		if err != nil {
+			return root, err
+		}
+
+		err = s.t.UpdateChild(keyToChild, func(child *trie.Trie) error {
+			for _, change := range trieChanges {
+				err := s.applyChildChange(child, change)
+				if err != nil {
+					return fmt.Errorf("applying change to child trie located at key 0x%x: %w", keyToChild, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return root, err
+		}
+	}
+
+	return s.t.Hash()
+}
+
+// applyChange applies a single storage change to the trie,
+// and must be called with the lock held.
+func (s *TrieState) applyChange(change StorageChange) (err error) {
+	switch {
+	case change.KeyToChild != nil && change.Value == nil:
+		return s.clearChildStorage(change.KeyToChild, change.Key)
+	case change.KeyToChild != nil:
+		err = s.createChildIfWritten(change.KeyToChild, []StorageChange{change})
+		if err != nil {
+			return err
+		}
+		err = s.t.PutIntoChild(change.KeyToChild, change.Key, change.Value)
+	case change.Value == nil:
+		if s.t.Get(change.Key) == nil {
+			return nil
+		}
+		err = s.t.Delete(change.Key)
+		if err != nil {
+			return fmt.Errorf("deleting from trie: %w", err)
+		}
+		s.recordDelete(nil, change.Key)
+		return nil
+	default:
+		err = s.t.Put(change.Key, change.Value)
+	}
+	if err != nil {
+		return err
+	}
+	s.recordWrite(change.KeyToChild, change.Key, change.Value)
 	return nil
 }
 
+// createChildIfWritten creates an empty child trie at the key to child given
+// if it does not exist and one of the changes given writes a value, such that
+// values can be written to new child tries as with SetChildStorage. It must be
+// called with the lock held.
+func (s *TrieState) createChildIfWritten(keyToChild []byte, changes []StorageChange) (err error) {
+	written := false
+	for _, change := range changes {
+		if change.Value != nil {
+			written = true
+			break
+		}
+	}
+	if !written {
+		return nil
+	}
+
+	_, err = s.t.GetChild(keyToChild)
+	if !errors.Is(err, trie.ErrChildTrieDoesNotExist) {
+		return err
+	}
+
+	err = s.t.SetChild(keyToChild, trie.NewEmptyTrie())
+	if err != nil {
+		return fmt.Errorf("creating child trie: %w", err)
+	}
+	return nil
+}
+
+// applyChildChange applies a single storage change to the child trie given,
+// and must be called with the lock held.
+func (s *TrieState) applyChildChange(child *trie.Trie, change StorageChange) (err error) {
+	if change.Value == nil {
+		if child.Get(change.Key) == nil {
+			return nil
+		}
+		err = child.Delete(change.Key)
+		if err != nil {
+			return err
+		}
+		s.recordDelete(change.KeyToChild, change.Key)
+		return nil
+	}
+
+	err = child.Put(change.Key, change.Value)
+	if err != nil {
+		return err
+	}
+	s.recordWrite(change.KeyToChild, change.Key, change.Value)
+	return nil
+}
+
+// lastChangePerKey returns a copy of the changes sorted with the main trie
+// changes first, then by child trie key and then by key, keeping only the
+// last change for each key.
+func lastChangePerKey(changes []StorageChange) (sorted []StorageChange) {
+	sorted = make([]StorageChange, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if !sameTrie(a.KeyToChild, b.KeyToChild) {
+			if a.KeyToChild == nil || b.KeyToChild == nil {
+				return a.KeyToChild == nil
+			}
+			return bytes.Compare(a.KeyToChild, b.KeyToChild) < 0
+		}
+		return bytes.Compare(a.Key, b.Key) < 0
+	})
+
+	last := sorted[:0]
+	for i, change := range sorted {
+		if i+1 < len(sorted) &&
+			sameTrie(sorted[i+1].KeyToChild, change.KeyToChild) &&
+			bytes.Equal(sorted[i+1].Key, change.Key) {
+			continue
+		}
+		last = append(last, change)
+	}
+	return last
+}
+
+// sameTrie returns true if the two child trie keys given designate
+// the same trie, where a nil key designates the main trie.
+func sameTrie(a, b []byte) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
 // NextKey returns the next key in the trie in lexicographical order. If it does not exist, it returns nil.
 func (s *TrieState) NextKey(key []byte) []byte {
 	s.lock.RLock()
@@ -126,7 +442,15 @@ func (s *TrieState) NextKey(key []byte) []byte {
 func (s *TrieState) ClearPrefix(prefix []byte) (err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.ClearPrefix(prefix)
+	keys := s.t.GetKeysWithPrefix(prefix)
+	err = s.t.ClearPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		s.recordDelete(nil, key)
+	}
+	return nil
 }
 
 // ClearPrefixLimit deletes key-value pairs from the trie where the key starts with the given prefix till limit reached
@@ -135,7 +459,14 @@ func (s *TrieState) ClearPrefixLimit(prefix []byte, limit uint32) (
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	return s.t.ClearPrefixLimit(prefix, limit)
+	keys := s.t.GetKeysWithPrefix(prefix)
+	deleted, allDeleted, err = s.t.ClearPrefixLimit(prefix, limit)
+	for _, key := range keys {
+		if s.t.Get(key) == nil {
+			s.recordDelete(nil, key)
+		}
+	}
+	return deleted, allDeleted, err
 }
 
 // TrieEntries returns every key-value pair in the trie
@@ -149,14 +480,35 @@ func (s *TrieState) TrieEntries() map[string][]byte {
 func (s *TrieState) SetChild(keyToChild []byte, child *trie.Trie) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.SetChild(keyToChild, child)
+	err := s.t.SetChild(keyToChild, child)
+	if err != nil {
+		return err
+	}
+	for key, value := range child.Entries() {
+		s.recordWrite(keyToChild, []byte(key), value)
+	}
+	return nil
 }
 
-// SetChildStorage sets a key-value pair in a child trie
+// SetChildStorage sets a key-value pair in a child trie,
+// creating the child trie if it does not exist.
 func (s *TrieState) SetChildStorage(keyToChild, key, value []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.PutIntoChild(keyToChild, key, value)
+	_, err := s.t.GetChild(keyToChild)
+	if errors.Is(err, trie.ErrChildTrieDoesNotExist) {
+		err = s.t.SetChild(keyToChild, trie.NewEmptyTrie())
+		if err != nil {
+			return fmt.Errorf("creating child trie: %w", err)
+		}
+	}
+
+	err = s.t.PutIntoChild(keyToChild, key, value)
+	if err != nil {
+		return err
+	}
+	s.recordWrite(keyToChild, key, value)
+	return nil
 }
 
 // GetChild returns the child trie at the given key
@@ -177,7 +529,14 @@ func (s *TrieState) GetChildStorage(keyToChild, key []byte) ([]byte, error) {
 func (s *TrieState) DeleteChild(key []byte) (err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.DeleteChild(key)
+	// the child trie is nil if it does not exist, in which case there is nothing to delete.
+	child, _ := s.t.GetChild(key)
+	err = s.t.DeleteChild(key)
+	if err != nil {
+		return err
+	}
+	s.recordChildDeletion(key, child)
+	return nil
 }
 
 // DeleteChildLimit deletes up to limit of database entries by lexicographic order.
@@ -197,6 +556,7 @@ func (s *TrieState) DeleteChildLimit(key []byte, limit *[]byte) (
 		if err != nil {
 			return 0, false, fmt.Errorf("deleting child trie: %w", err)
 		}
+		s.recordChildDeletion(key, tr)
 
 		return qtyEntries, true, nil
 	}
@@ -204,21 +564,28 @@ func (s *TrieState) DeleteChildLimit(key []byte, limit *[]byte) (
 
 	keys := maps.Keys(childTrieEntries)
 	sort.Strings(keys)
-	for _, k := range keys {
-		// TODO have a transactional/atomic way to delete multiple keys in trie.
-		// If one deletion fails, the child trie and its parent trie are then in
-		// a bad intermediary state. Take also care of the caching of deleted Merkle
-		// values within the tries, which is used for online pruning.
-		// See https://github.com/ChainSafe/gossamer/issues/3032
-		err = tr.Delete([]byte(k))
-		if err != nil {
-			return deleted, allDeleted, fmt.Errorf("deleting from child trie located at key 0x%x: %w", key, err)
-		}
-
-		deleted++
-		if deleted == limitUint {
-			break
+	// the child trie root hash is updated in the main trie once all the keys are deleted.
+	err = s.t.UpdateChild(key, func(child *trie.Trie) error {
+		for _, k := range keys {
+			if deleted == limitUint {
+				break
+			}
+			// TODO have a transactional/atomic way to delete multiple keys in trie.
+			// If one deletion fails, the child trie and its parent trie are then in
+			// a bad intermediary state. Take also care of the caching of deleted Merkle
+			// values within the tries, which is used for online pruning.
+			// See https://github.com/ChainSafe/gossamer/issues/3032
+			err := child.Delete([]byte(k))
+			if err != nil {
+				return fmt.Errorf("deleting from child trie located at key 0x%x: %w", key, err)
+			}
+			s.recordDelete(key, []byte(k))
+			deleted++
 		}
+		return nil
+	})
+	if err != nil {
+		return deleted, allDeleted, err
 	}
 
 	allDeleted = deleted == qtyEntries
@@ -229,7 +596,22 @@ func (s *TrieState) DeleteChildLimit(key []byte, limit *[]byte) (
 func (s *TrieState) ClearChildStorage(keyToChild, key []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.ClearFromChild(keyToChild, key)
+	return s.clearChildStorage(keyToChild, key)
+}
+
+// clearChildStorage removes the child storage entry from the trie,
+// and must be called with the lock held.
+func (s *TrieState) clearChildStorage(keyToChild, key []byte) error {
+	// the error is returned by ClearFromChild if the child trie does not exist.
+	value, _ := s.t.GetFromChild(keyToChild, key)
+	err := s.t.ClearFromChild(keyToChild, key)
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		s.recordDelete(keyToChild, key)
+	}
+	return nil
 }
 
 // ClearPrefixInChild clears all the keys from the child trie that have the given prefix
@@ -245,9 +627,19 @@ func (s *TrieState) ClearPrefixInChild(keyToChild, prefix []byte) error {
 		return nil
 	}
 
-	err = child.ClearPrefix(prefix)
+	keys := child.GetKeysWithPrefix(prefix)
+	err = s.t.UpdateChild(keyToChild, func(childTrie *trie.Trie) error {
+		err := childTrie.ClearPrefix(prefix)
+		if err != nil {
+			return fmt.Errorf("clearing prefix in child trie located at key 0x%x: %w", keyToChild, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("clearing prefix in child trie located at key 0x%x: %w", keyToChild, err)
+		return err
+	}
+	for _, key := range keys {
+		s.recordDelete(keyToChild, key)
 	}
 
 	return nil
@@ -297,3 +689,11 @@ func (s *TrieState) GetChangedNodeHashes() (inserted, deleted map[common.Hash]st
 	defer s.lock.RUnlock()
 	return s.t.GetChangedNodeHashes()
 }
+
+// DeletedNodeHashes returns the set of hashes of the nodes deleted in
+// the state trie since the last block produced (trie snapshot).
+func (s *TrieState) DeletedNodeHashes() (nodeHashes map[common.Hash]struct{}) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.t.DeletedNodeHashes()
+}
@@ -7,11 +7,13 @@
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math/rand"
 	"sort"
 	"testing"
 
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -180,6 +182,37 @@ func TestTrieState_RollbackStorageTransaction(t *testing.T) {
 	require.Equal(t, []byte(testCases[0]), val)
 }
 
+func TestTrieState_NestedStorageTransactions(t *testing.T) {
+	ts := &TrieState{t: trie.NewEmptyTrie()}
+	key := []byte("key")
+	ts.Put(key, []byte("block"))
+
+	ts.BeginStorageTransaction()
+	ts.Put(key, []byte("outer"))
+
+	ts.BeginStorageTransaction()
+	ts.Put(key, []byte("inner"))
+	ts.Put([]byte("inner"), []byte("inner"))
+	ts.RollbackStorageTransaction()
+	require.Equal(t, []byte("outer"), ts.Get(key))
+	require.Nil(t, ts.Get([]byte("inner")))
+
+	ts.BeginStorageTransaction()
+	ts.Put(key, []byte("committed inner"))
+	ts.CommitStorageTransaction()
+	require.Equal(t, []byte("committed inner"), ts.Get(key))
+
+	// Rolling back the outer transaction rolls back the inner transaction committed.
+	ts.RollbackStorageTransaction()
+	require.Equal(t, []byte("block"), ts.Get(key))
+	require.Equal(t, ChangeStats{KeysWritten: 1, ValueBytesWritten: 5}, ts.ChangeStats())
+
+	// Closing a transaction while none is open does nothing.
+	ts.RollbackStorageTransaction()
+	ts.CommitStorageTransaction()
+	require.Equal(t, []byte("block"), ts.Get(key))
+}
+
 func TestTrieState_DeleteChildLimit(t *testing.T) {
 	ts := &TrieState{t: trie.NewEmptyTrie()}
 	child := trie.NewEmptyTrie()
@@ -232,3 +265,250 @@ func TestTrieState_DeleteChildLimit(t *testing.T) {
 		require.Equal(t, test.expectedDelAll, all)
 	}
 }
+
+func TestTrieState_ApplyChanges(t *testing.T) {
+	t.Parallel()
+
+	childKeys := [][]byte{[]byte("child1"), []byte("child2")}
+	newTestTrieState := func(t *testing.T) *TrieState {
+		t.Helper()
+		ts := NewTrieState(nil)
+		for i, key := range [][]byte{{1}, {1, 2}, {2}, {3, 4}} {
+			err := ts.Put(key, []byte{byte(i)})
+			require.NoError(t, err)
+		}
+		for i, keyToChild := range childKeys {
+			child := trie.NewEmptyTrie()
+			err := child.Put([]byte{1}, []byte{byte(i)})
+			require.NoError(t, err)
+			err = ts.SetChild(keyToChild, child)
+			require.NoError(t, err)
+		}
+		return ts
+	}
+
+	// keys are generated from a small key space such that
+	// changes often target the same key or share prefixes.
+	generator := rand.New(rand.NewSource(1)) //nolint:gosec
+	randomChange := func() (change StorageChange) {
+		if generator.Intn(3) > 0 {
+			change.KeyToChild = childKeys[generator.Intn(len(childKeys))]
+		}
+		change.Key = make([]byte, 1+generator.Intn(3))
+		for i := range change.Key {
+			change.Key[i] = byte(generator.Intn(4))
+		}
+		if generator.Intn(3) > 0 {
+			change.Value = []byte{byte(generator.Intn(256))}
+		}
+		return change
+	}
+
+	const numberOfRuns = 200
+	for run := 0; run < numberOfRuns; run++ {
+		changes := make([]StorageChange, generator.Intn(50))
+		for i := range changes {
+			changes[i] = randomChange()
+		}
+
+		expected := newTestTrieState(t)
+		for _, change := range changes {
+			var err error
+			switch {
+			case change.KeyToChild != nil && change.Value == nil:
+				err = expected.ClearChildStorage(change.KeyToChild, change.Key)
+			case change.KeyToChild != nil:
+				err = expected.SetChildStorage(change.KeyToChild, change.Key, change.Value)
+			case change.Value == nil:
+				err = expected.Delete(change.Key)
+			default:
+				err = expected.Put(change.Key, change.Value)
+			}
+			require.NoError(t, err)
+		}
+		expectedRoot, err := expected.Root()
+		require.NoError(t, err)
+
+		ts := newTestTrieState(t)
+		root, err := ts.ApplyChanges(changes)
+		require.NoError(t, err)
+
+		require.Equal(t, expectedRoot, root, "run %d with changes %v", run, changes)
+		require.Equal(t, expected.TrieEntries(), ts.TrieEntries())
+		for _, keyToChild := range childKeys {
+			expectedChild, err := expected.GetChild(keyToChild)
+			require.NoError(t, err)
+			child, err := ts.GetChild(keyToChild)
+			require.NoError(t, err)
+			require.Equal(t, expectedChild.Entries(), child.Entries())
+		}
+	}
+}
+
+func TestTrieState_ApplyChanges_childTrieRootKey(t *testing.T) {
+	t.Parallel()
+
+	ts := NewTrieState(nil)
+	keyToChild := []byte("child")
+	err := ts.SetChild(keyToChild, trie.NewEmptyTrie())
+	require.NoError(t, err)
+
+	// the changes are applied in order, so the child trie is deleted
+	// after being changed instead of failing to change a deleted child trie.
+	changes := []StorageChange{
+		{KeyToChild: keyToChild, Key: []byte{1}, Value: []byte{1}},
+		{Key: append(append([]byte{}, trie.ChildStorageKeyPrefix...), keyToChild...)},
+	}
+	root, err := ts.ApplyChanges(changes)
+	require.NoError(t, err)
+
+	assert.Equal(t, trie.EmptyHash, root)
+}
+
+func TestTrieState_childStorageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	keyToChild := []byte("keyToChild")
+	limit := make([]byte, 4)
+	binary.LittleEndian.PutUint32(limit, 1)
+
+	steps := []struct {
+		name         string
+		operation    func(ts *TrieState) error
+		childEntries map[string][]byte
+		childExists  bool
+	}{
+		{
+			name: "set_creates_child_trie",
+			operation: func(ts *TrieState) error {
+				return ts.SetChildStorage(keyToChild, []byte("key1"), []byte("value1"))
+			},
+			childEntries: map[string][]byte{"key1": []byte("value1")},
+			childExists:  true,
+		},
+		{
+			name: "set_same_value",
+			operation: func(ts *TrieState) error {
+				return ts.SetChildStorage(keyToChild, []byte("key1"), []byte("value1"))
+			},
+			childEntries: map[string][]byte{"key1": []byte("value1")},
+			childExists:  true,
+		},
+		{
+			name: "set_other_keys",
+			operation: func(ts *TrieState) error {
+				for _, key := range []string{"key2", "prefix1", "prefix2"} {
+					err := ts.SetChildStorage(keyToChild, []byte(key), []byte("value"))
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			childEntries: map[string][]byte{
+				"key1":    []byte("value1"),
+				"key2":    []byte("value"),
+				"prefix1": []byte("value"),
+				"prefix2": []byte("value"),
+			},
+			childExists: true,
+		},
+		{
+			name: "clear_absent_key",
+			operation: func(ts *TrieState) error {
+				return ts.ClearChildStorage(keyToChild, []byte("absent"))
+			},
+			childEntries: map[string][]byte{
+				"key1":    []byte("value1"),
+				"key2":    []byte("value"),
+				"prefix1": []byte("value"),
+				"prefix2": []byte("value"),
+			},
+			childExists: true,
+		},
+		{
+			name: "clear_key",
+			operation: func(ts *TrieState) error {
+				return ts.ClearChildStorage(keyToChild, []byte("key2"))
+			},
+			childEntries: map[string][]byte{
+				"key1":    []byte("value1"),
+				"prefix1": []byte("value"),
+				"prefix2": []byte("value"),
+			},
+			childExists: true,
+		},
+		{
+			name: "clear_prefix",
+			operation: func(ts *TrieState) error {
+				return ts.ClearPrefixInChild(keyToChild, []byte("prefix"))
+			},
+			childEntries: map[string][]byte{"key1": []byte("value1")},
+			childExists:  true,
+		},
+		{
+			name: "delete_with_limit",
+			operation: func(ts *TrieState) error {
+				err := ts.SetChildStorage(keyToChild, []byte("key2"), []byte("value2"))
+				if err != nil {
+					return err
+				}
+				_, _, err = ts.DeleteChildLimit(keyToChild, &limit)
+				return err
+			},
+			childEntries: map[string][]byte{"key2": []byte("value2")},
+			childExists:  true,
+		},
+		{
+			name: "delete_child_trie",
+			operation: func(ts *TrieState) error {
+				return ts.DeleteChild(keyToChild)
+			},
+		},
+	}
+
+	ts := NewTrieState(nil)
+	err := ts.Put([]byte("mainKey"), []byte("mainValue"))
+	require.NoError(t, err)
+
+	previousRoot := ts.MustRoot()
+	var previousChildEntries map[string][]byte
+	for _, step := range steps {
+		err := step.operation(ts)
+		require.NoError(t, err, step.name)
+
+		child, err := ts.GetChild(keyToChild)
+		if step.childExists {
+			require.NoError(t, err, step.name)
+			assert.Equal(t, step.childEntries, child.Entries(), step.name)
+		} else {
+			assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist, step.name)
+		}
+
+		// the main trie root is the one of a trie built from scratch with the same contents.
+		expected := trie.NewEmptyTrie()
+		err = expected.Put([]byte("mainKey"), []byte("mainValue"))
+		require.NoError(t, err)
+		if step.childExists {
+			expectedChild := trie.NewEmptyTrie()
+			for key, value := range step.childEntries {
+				err = expectedChild.Put([]byte(key), value)
+				require.NoError(t, err)
+			}
+			err = expected.SetChild(keyToChild, expectedChild)
+			require.NoError(t, err)
+		}
+		root := ts.MustRoot()
+		assert.Equal(t, expected.MustHash(), root, step.name)
+
+		childChanged := !assert.ObjectsAreEqual(previousChildEntries, step.childEntries)
+		assert.Equal(t, childChanged, root != previousRoot, step.name)
+
+		previousRoot = root
+		previousChildEntries = step.childEntries
+	}
+
+	value, err := ts.GetChildStorage(keyToChild, []byte("key2"))
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+	assert.Nil(t, value)
+}
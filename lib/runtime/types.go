@@ -4,6 +4,8 @@
 package runtime
 
 import (
+	"time"
+
 	"github.com/ChainSafe/gossamer/lib/crypto"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
@@ -57,4 +59,9 @@ type Context struct {
 	SigVerifier     *crypto.SignatureVerifier
 	OffchainHTTPSet *offchain.HTTPSet
 	Version         *Version
+	// Deadline is the time after which the runtime call running
+	// is aborted, and is the zero time if the call has no time limit.
+	Deadline time.Time
+	// Done aborts any runtime call running once closed, if not nil.
+	Done <-chan struct{}
 }
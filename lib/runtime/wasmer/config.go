@@ -5,6 +5,7 @@
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -12,6 +13,10 @@
 	"github.com/ChainSafe/gossamer/lib/runtime"
 )
 
+// DefaultUntrustedCallTimeout is the default time limit
+// of the runtime calls made for untrusted data.
+const DefaultUntrustedCallTimeout = 2 * time.Second
+
 // Config is the configuration used to create a Wasmer runtime instance.
 type Config struct {
 	Storage     runtime.Storage
@@ -22,6 +27,14 @@ type Config struct {
 	Network     runtime.BasicNetwork
 	Transaction runtime.TransactionState
 	CodeHash    common.Hash
+	// UntrustedCallTimeout is the time limit of the runtime calls made
+	// for untrusted data, such as validating transactions received from
+	// the network. Calls for blocks, including block production, are not
+	// limited. It defaults to DefaultUntrustedCallTimeout if left to zero.
+	UntrustedCallTimeout time.Duration
+	// Done, if not nil, aborts any runtime call of the instance once closed.
+	// The instance cannot be used anymore after this.
+	Done        <-chan struct{}
 	testVersion *runtime.Version
 }
 
@@ -17,10 +17,12 @@
 // ValidateTransaction runs the extrinsic through the runtime function
 // TaggedTransactionQueue_validate_transaction and returns *transaction.Validity. The error can
 // be a VDT of either transaction.InvalidTransaction or transaction.UnknownTransaction, or can represent
-// a normal error i.e. unmarshalling error
+// a normal error i.e. unmarshalling error.
+// Since the transaction is untrusted, the call is aborted with an error wrapping
+// runtime.ErrExecutionTimeout if it runs for longer than the untrusted call timeout.
 func (in *Instance) ValidateTransaction(e types.Extrinsic) (
 	*transaction.Validity, error) {
-	ret, err := in.Exec(runtime.TaggedTransactionQueueValidateTransaction, e)
+	ret, err := in.ExecUntrusted(runtime.TaggedTransactionQueueValidateTransaction, e)
 	if err != nil {
 		return nil, err
 	}
@@ -191,6 +193,21 @@ func (in *Instance) FinalizeBlock() (*types.Header, error) {
 
 // ExecuteBlock calls runtime function Core_execute_block
 func (in *Instance) ExecuteBlock(block *types.Block) ([]byte, error) {
+	b, err := blockWithoutSeal(block)
+	if err != nil {
+		return nil, err
+	}
+
+	bdEnc, err := b.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return in.Exec(runtime.CoreExecuteBlock, bdEnc)
+}
+
+// blockWithoutSeal returns a copy of the block given without its seal digest.
+func blockWithoutSeal(block *types.Block) (*types.Block, error) {
 	// copy block since we're going to modify it
 	b, err := block.DeepCopy()
 	if err != nil {
@@ -216,47 +233,97 @@ func (in *Instance) ExecuteBlock(block *types.Block) ([]byte, error) {
 		}
 	}
 
-	bdEnc, err := b.Encode()
-	if err != nil {
-		return nil, err
-	}
-
-	return in.Exec(runtime.CoreExecuteBlock, bdEnc)
+	return &b, nil
 }
 
 // DecodeSessionKeys decodes the given public session keys. Returns a list of raw public keys including their key type.
+// The keys are untrusted, so the call is limited by the untrusted call timeout.
 func (in *Instance) DecodeSessionKeys(enc []byte) ([]byte, error) {
-	return in.Exec(runtime.DecodeSessionKeys, enc)
+	return in.ExecUntrusted(runtime.DecodeSessionKeys, enc)
 }
 
-// PaymentQueryInfo returns information of a given extrinsic
+// PaymentQueryInfo returns information of a given extrinsic.
+// The extrinsic is untrusted, so the call is limited by the untrusted call timeout.
 func (in *Instance) PaymentQueryInfo(ext []byte) (*types.RuntimeDispatchInfo, error) {
 	encLen, err := scale.Marshal(uint32(len(ext)))
 	if err != nil {
 		return nil, err
 	}
 
-	resBytes, err := in.Exec(runtime.TransactionPaymentAPIQueryInfo, append(ext, encLen...))
+	version, err := in.Version()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("getting runtime version: %w", err)
 	}
 
-	dispatchInfo := new(types.RuntimeDispatchInfo)
-	if err = scale.Unmarshal(resBytes, dispatchInfo); err != nil {
+	paymentAPIVersion, err := version.TransactionPaymentAPIVersion()
+	if err != nil {
+		return nil, fmt.Errorf("getting transaction payment API version: %w", err)
+	}
+
+	resBytes, err := in.ExecUntrusted(runtime.TransactionPaymentAPIQueryInfo, append(ext, encLen...))
+	if err != nil {
 		return nil, err
 	}
 
-	return dispatchInfo, nil
+	return decodeRuntimeDispatchInfo(resBytes, paymentAPIVersion)
 }
 
-// QueryCallInfo returns information of a given extrinsic
+// decodeRuntimeDispatchInfo decodes the dispatch info returned by the
+// TransactionPaymentApi with the API version given. The weight is encoded as
+// a fixed width u64 before version 2, and as its compact reference time followed
+// by its compact proof size from version 2, in which case only the reference time
+// is returned as the weight. The dispatch class is encoded as a single byte.
+func decodeRuntimeDispatchInfo(encoded []byte, paymentAPIVersion uint32) (
+	dispatchInfo *types.RuntimeDispatchInfo, err error) {
+	var weight uint64
+	var class uint8
+	var partialFee *scale.Uint128
+	decoder := scale.NewDecoder(bytes.NewReader(encoded))
+
+	if paymentAPIVersion < 2 {
+		err = decoder.Decode(&weight)
+		if err != nil {
+			return nil, fmt.Errorf("decoding weight: %w", err)
+		}
+	} else {
+		var refTime, proofSize uint
+		err = decoder.Decode(&refTime)
+		if err != nil {
+			return nil, fmt.Errorf("decoding weight reference time: %w", err)
+		}
+		err = decoder.Decode(&proofSize)
+		if err != nil {
+			return nil, fmt.Errorf("decoding weight proof size: %w", err)
+		}
+		weight = uint64(refTime)
+	}
+
+	err = decoder.Decode(&class)
+	if err != nil {
+		return nil, fmt.Errorf("decoding dispatch class: %w", err)
+	}
+
+	err = decoder.Decode(&partialFee)
+	if err != nil {
+		return nil, fmt.Errorf("decoding partial fee: %w", err)
+	}
+
+	return &types.RuntimeDispatchInfo{
+		Weight:     weight,
+		Class:      int(class),
+		PartialFee: partialFee,
+	}, nil
+}
+
+// QueryCallInfo returns information of a given extrinsic.
+// The call is untrusted, so the call is limited by the untrusted call timeout.
 func (in *Instance) QueryCallInfo(ext []byte) (*types.RuntimeDispatchInfo, error) {
 	encLen, err := scale.Marshal(uint32(len(ext)))
 	if err != nil {
 		return nil, err
 	}
 
-	resBytes, err := in.Exec(runtime.TransactionPaymentCallAPIQueryCallInfo, append(ext, encLen...))
+	resBytes, err := in.ExecUntrusted(runtime.TransactionPaymentCallAPIQueryCallInfo, append(ext, encLen...))
 	if err != nil {
 		return nil, err
 	}
@@ -269,14 +336,15 @@ func (in *Instance) QueryCallInfo(ext []byte) (*types.RuntimeDispatchInfo, error
 	return dispatchInfo, nil
 }
 
-// QueryCallFeeDetails returns call fee details for given call
+// QueryCallFeeDetails returns call fee details for given call.
+// The call is untrusted, so the call is limited by the untrusted call timeout.
 func (in *Instance) QueryCallFeeDetails(ext []byte) (*types.FeeDetails, error) {
 	encLen, err := scale.Marshal(uint32(len(ext)))
 	if err != nil {
 		return nil, err
 	}
 
-	resBytes, err := in.Exec(runtime.TransactionPaymentCallAPIQueryCallFeeDetails, append(ext, encLen...))
+	resBytes, err := in.ExecUntrusted(runtime.TransactionPaymentCallAPIQueryCallFeeDetails, append(ext, encLen...))
 	if err != nil {
 		return nil, err
 	}
@@ -289,9 +357,34 @@ func (in *Instance) QueryCallFeeDetails(ext []byte) (*types.FeeDetails, error) {
 	return dispatchInfo, nil
 }
 
-// CheckInherents checks inherents in the block verification process.
-// TODO: use this in block verification process (#1873)
-func (in *Instance) CheckInherents() {}
+// CheckInherents calls runtime API function BlockBuilder_check_inherents to check
+// the inherents of the block given against the SCALE encoded inherent data given.
+func (in *Instance) CheckInherents(block *types.Block, inherentData []byte) (
+	result *types.CheckInherentsResult, err error) {
+	b, err := blockWithoutSeal(block)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedBlock, err := b.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("encoding block: %w", err)
+	}
+
+	data := append(encodedBlock, inherentData...)
+	encodedResult, err := in.Exec(runtime.BlockBuilderCheckInherents, data)
+	if err != nil {
+		return nil, err
+	}
+
+	result = new(types.CheckInherentsResult)
+	err = scale.Unmarshal(encodedResult, result)
+	if err != nil {
+		return nil, fmt.Errorf("decoding check inherents result: %w", err)
+	}
+
+	return result, nil
+}
 
 // GrandpaGenerateKeyOwnershipProof returns grandpa key ownership proof from the runtime.
 func (in *Instance) GrandpaGenerateKeyOwnershipProof(authSetID uint64, authorityID ed25519.PublicKeyBytes) (
@@ -346,6 +439,16 @@ func (in *Instance) GrandpaSubmitReportEquivocationUnsignedExtrinsic(
 	return nil
 }
 
+// OffchainWorker runs the runtime offchain worker for the block header given.
+func (in *Instance) OffchainWorker(header *types.Header) error {
+	encodedHeader, err := scale.Marshal(*header)
+	if err != nil {
+		return fmt.Errorf("cannot encode header: %w", err)
+	}
+
+	_, err = in.Exec(runtime.OffchainWorkerAPIOffchainWorker, encodedHeader)
+	return err
+}
+
 func (in *Instance) RandomSeed()          {}
-func (in *Instance) OffchainWorker()      {}
 func (in *Instance) GenerateSessionKeys() {}
@@ -983,6 +983,65 @@ func TestInstance_PaymentQueryInfo(t *testing.T) {
 	}
 }
 
+func Test_decodeRuntimeDispatchInfo(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		encoded           []byte
+		paymentAPIVersion uint32
+		dispatchInfo      *types.RuntimeDispatchInfo
+		errMessage        string
+	}{
+		"version_1": {
+			encoded: common.MustHexToBytes("0x" +
+				"0807060504030201" + // weight
+				"01" + // operational class
+				"00d0ed902e0000000000000000000000"), // partial fee
+			paymentAPIVersion: 1,
+			dispatchInfo: &types.RuntimeDispatchInfo{
+				Weight:     0x0102030405060708,
+				Class:      1,
+				PartialFee: &scale.Uint128{Lower: 200000000000},
+			},
+		},
+		"version_2": {
+			// returned by the westend local runtime for a system remark call
+			encoded: common.MustHexToBytes("0x" +
+				"82fad500" + // compact weight reference time
+				"00" + // compact weight proof size
+				"02" + // mandatory class
+				"477fabc7020000000000000000000000"), // partial fee
+			paymentAPIVersion: 2,
+			dispatchInfo: &types.RuntimeDispatchInfo{
+				Weight:     3505824,
+				Class:      2,
+				PartialFee: &scale.Uint128{Lower: 11939839815},
+			},
+		},
+		"missing_partial_fee": {
+			encoded:           common.MustHexToBytes("0x82fad5000000"),
+			paymentAPIVersion: 2,
+			errMessage:        "decoding partial fee: EOF",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dispatchInfo, err := decodeRuntimeDispatchInfo(testCase.encoded, testCase.paymentAPIVersion)
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.dispatchInfo, dispatchInfo)
+		})
+	}
+}
+
 func newTrieFromPairs(t *testing.T, filename string) *trie.Trie {
 	data, err := os.ReadFile(filename)
 	require.NoError(t, err)
@@ -7,12 +7,19 @@
 	"errors"
 	"fmt"
 
+	"github.com/ChainSafe/gossamer/internal/log"
+	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/genesis"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/ChainSafe/gossamer/pkg/scale"
 )
 
 var (
 	ErrGenesisTopNotFound = errors.New("genesis top not found")
+
+	errGenesisBuildFailed = errors.New("runtime failed building genesis config")
 )
 
 // NewTrieFromGenesis creates a new trie from the raw genesis data
@@ -33,3 +40,78 @@ func NewTrieFromGenesis(gen genesis.Genesis) (tr trie.Trie, err error) {
 
 	return tr, nil
 }
+
+type genesisBuilder struct {
+	instance *Instance
+	state    *storage.TrieState
+}
+
+// NewGenesisBuilder instantiates the runtime code given with an empty state,
+// to build a genesis storage with its GenesisBuilder runtime API.
+func NewGenesisBuilder(code []byte) (builder genesis.RuntimeGenesisBuilder, err error) {
+	state := storage.NewTrieState(trie.NewEmptyTrie())
+	instance, err := NewInstance(code, Config{Storage: state, LogLvl: log.DoNotChange})
+	if err != nil {
+		return nil, err
+	}
+
+	return &genesisBuilder{
+		instance: instance,
+		state:    state,
+	}, nil
+}
+
+var _ genesis.NewRuntimeGenesisBuilder = NewGenesisBuilder
+
+// DefaultGenesisConfig returns the JSON encoded default genesis configuration of the runtime.
+func (b *genesisBuilder) DefaultGenesisConfig() (config []byte, err error) {
+	encodedConfig, err := b.instance.Exec(runtime.GenesisBuilderCreateDefaultConfig, []byte{})
+	if err != nil {
+		return nil, fmt.Errorf("executing %s: %w", runtime.GenesisBuilderCreateDefaultConfig, err)
+	}
+
+	err = scale.Unmarshal(encodedConfig, &config)
+	if err != nil {
+		return nil, fmt.Errorf("decoding default genesis config: %w", err)
+	}
+	return config, nil
+}
+
+// BuildGenesisStorage builds the genesis storage in the state of the runtime from
+// the JSON encoded genesis configuration given, and returns its top storage key
+// values hex encoded.
+func (b *genesisBuilder) BuildGenesisStorage(config []byte) (top map[string]string, err error) {
+	encodedConfig, err := scale.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("encoding genesis config: %w", err)
+	}
+
+	encodedResult, err := b.instance.Exec(runtime.GenesisBuilderBuildConfig, encodedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("executing %s: %w", runtime.GenesisBuilderBuildConfig, err)
+	}
+
+	// The result is a Result<(), String> with the error message of the runtime.
+	if len(encodedResult) == 0 {
+		return nil, fmt.Errorf("%w: empty result", errGenesisBuildFailed)
+	} else if encodedResult[0] != 0 {
+		var message string
+		err = scale.Unmarshal(encodedResult[1:], &message)
+		if err != nil {
+			return nil, fmt.Errorf("decoding genesis build error: %w", err)
+		}
+		return nil, fmt.Errorf("%w: %s", errGenesisBuildFailed, message)
+	}
+
+	entries := b.state.Trie().Entries()
+	top = make(map[string]string, len(entries))
+	for key, value := range entries {
+		top[common.BytesToHex([]byte(key))] = common.BytesToHex(value)
+	}
+	return top, nil
+}
+
+// Stop stops the runtime.
+func (b *genesisBuilder) Stop() {
+	b.instance.Stop()
+}
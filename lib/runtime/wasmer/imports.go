@@ -98,6 +98,8 @@
 //
 // extern void ext_transaction_index_index_version_1(void *context, int32_t a, int32_t b, int32_t c);
 // extern void ext_transaction_index_renew_version_1(void *context, int32_t a, int32_t b);
+//
+// extern int64_t ext_gossamer_metering_refuel(void *context);
 import "C" //skipcq: SCC-compile
 
 import (
@@ -169,6 +171,22 @@ func ext_transaction_index_renew_version_1(context unsafe.Pointer, a, b C.int32_
 	logger.Warn("unimplemented")
 }
 
+//export ext_gossamer_metering_refuel
+func ext_gossamer_metering_refuel(context unsafe.Pointer) C.int64_t {
+	instanceContext := wasm.IntoInstanceContext(context)
+	runtimeCtx := instanceContext.Data().(*runtime.Context)
+	if !runtimeCtx.Deadline.IsZero() && time.Now().After(runtimeCtx.Deadline) {
+		return 0
+	}
+
+	select {
+	case <-runtimeCtx.Done:
+		return 0
+	default:
+		return meteringFuelPerRefuel
+	}
+}
+
 //export ext_sandbox_instance_teardown_version_1
 func ext_sandbox_instance_teardown_version_1(context unsafe.Pointer, a C.int32_t) {
 	logger.Trace("executing...")
@@ -2126,6 +2144,7 @@ func importsNodeRuntime() (imports *wasm.Imports, err error) {
 		{"ext_default_child_storage_storage_kill_version_1", ext_default_child_storage_storage_kill_version_1, C.ext_default_child_storage_storage_kill_version_1},
 		{"ext_default_child_storage_storage_kill_version_2", ext_default_child_storage_storage_kill_version_2, C.ext_default_child_storage_storage_kill_version_2},
 		{"ext_default_child_storage_storage_kill_version_3", ext_default_child_storage_storage_kill_version_3, C.ext_default_child_storage_storage_kill_version_3},
+		{meteringRefuelImport, ext_gossamer_metering_refuel, C.ext_gossamer_metering_refuel},
 		{"ext_hashing_blake2_128_version_1", ext_hashing_blake2_128_version_1, C.ext_hashing_blake2_128_version_1},
 		{"ext_hashing_blake2_256_version_1", ext_hashing_blake2_256_version_1, C.ext_hashing_blake2_256_version_1},
 		{"ext_hashing_keccak_256_version_1", ext_hashing_keccak_256_version_1, C.ext_hashing_keccak_256_version_1},
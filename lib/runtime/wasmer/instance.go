@@ -4,23 +4,22 @@
 package wasmer
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/trie"
 
 	"github.com/ChainSafe/gossamer/lib/crypto"
 
 	wasm "github.com/wasmerio/go-ext-wasm/wasmer"
-
-	"github.com/klauspost/compress/zstd"
 )
 
 // Name represents the name of the interpreter
@@ -28,7 +27,7 @@
 
 var (
 	logger = log.NewFromGlobal(
-		log.AddContext("pkg", "runtime"),
+		log.AddContext("pkg", "runtime/wasmer"),
 		log.AddContext("module", "go-wasmer"),
 	)
 )
@@ -38,8 +37,20 @@ type Instance struct {
 	vm       wasm.Instance
 	ctx      *runtime.Context
 	isClosed bool
-	codeHash common.Hash
-	mutex    sync.Mutex
+	// metered is true if the code of vm is instrumented with fuel
+	// metering, which is only the case for the instances whose calls
+	// are all cancellable, such that trusted calls run at full speed.
+	metered bool
+	// untrusted is the code instrumented with fuel metering and instantiated
+	// again for the untrusted calls, on the first untrusted call, if vm is not
+	// metered. It is nil until then, and once an untrusted call is aborted.
+	untrusted *untrustedVM
+	// code is the runtime code, kept to instantiate
+	// the runtime again after a call is aborted.
+	code                 []byte
+	codeHash             common.Hash
+	untrustedCallTimeout time.Duration
+	mutex                sync.Mutex
 }
 
 // NewRuntimeFromGenesis creates a runtime instance from the genesis data
@@ -77,11 +88,49 @@ func NewInstanceFromFile(fp string, cfg Config) (*Instance, error) {
 	return NewInstance(bytes, cfg)
 }
 
+// NewOffchainWorkerInstance instantiates the code of the block instance given again,
+// with the trie state given, to run an offchain worker without holding the lock of
+// the block instance. The runtime calls of the instance are aborted once done is closed.
+// It implements runtime.OffchainWorkerInstanceCreator.
+func NewOffchainWorkerInstance(done <-chan struct{}, blockInstance runtime.Instance,
+	trieState *storage.TrieState) (instance runtime.Instance, err error) {
+	in, ok := blockInstance.(*Instance)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrInstanceTypeNotSupported, blockInstance)
+	}
+
+	cfg := Config{
+		Storage:              trieState,
+		LogLvl:               log.DoNotChange,
+		Keystore:             in.ctx.Keystore,
+		NodeStorage:          in.ctx.NodeStorage,
+		Network:              in.ctx.Network,
+		Transaction:          in.ctx.Transaction,
+		CodeHash:             in.codeHash,
+		UntrustedCallTimeout: in.untrustedCallTimeout,
+		Done:                 done,
+	}
+	if in.ctx.Validator {
+		cfg.Role = common.AuthorityRole
+	}
+
+	return NewInstance(in.code, cfg)
+}
+
+// untrustedVM is a wasm instance with its own allocator, running the
+// untrusted calls of an instance, such that they can be aborted.
+type untrustedVM struct {
+	vm        wasm.Instance
+	allocator *runtime.FreeingBumpHeapAllocator
+}
+
 // NewInstance instantiates a runtime from raw wasm bytecode
 func NewInstance(code []byte, cfg Config) (instance *Instance, err error) {
 	logger.Patch(log.SetLevel(cfg.LogLvl), log.SetCallerFunc(true))
 
-	wasmInstance, allocator, err := setupVM(code)
+	// All the calls of an instance with a done channel can be canceled,
+	// so its code is instrumented with fuel metering.
+	wasmInstance, allocator, metered, err := setupVM(code, cfg.Done != nil)
 	if err != nil {
 		return nil, fmt.Errorf("setting up VM: %w", err)
 	}
@@ -96,13 +145,22 @@ func NewInstance(code []byte, cfg Config) (instance *Instance, err error) {
 		Transaction:     cfg.Transaction,
 		SigVerifier:     crypto.NewSignatureVerifier(logger),
 		OffchainHTTPSet: offchain.NewHTTPSet(),
+		Done:            cfg.Done,
 	}
 	wasmInstance.SetContextData(runtimeCtx)
 
+	untrustedCallTimeout := cfg.UntrustedCallTimeout
+	if untrustedCallTimeout == 0 {
+		untrustedCallTimeout = DefaultUntrustedCallTimeout
+	}
+
 	instance = &Instance{
-		vm:       wasmInstance,
-		ctx:      runtimeCtx,
-		codeHash: cfg.CodeHash,
+		vm:                   wasmInstance,
+		ctx:                  runtimeCtx,
+		metered:              metered,
+		code:                 code,
+		codeHash:             cfg.CodeHash,
+		untrustedCallTimeout: untrustedCallTimeout,
 	}
 
 	if cfg.testVersion != nil {
@@ -114,22 +172,6 @@ func NewInstance(code []byte, cfg Config) (instance *Instance, err error) {
 	return instance, nil
 }
 
-// decompressWasm decompresses a Wasm blob that may or may not be compressed with zstd
-// ref: https://github.com/paritytech/substrate/blob/master/primitives/maybe-compressed-blob/src/lib.rs
-func decompressWasm(code []byte) ([]byte, error) {
-	compressionFlag := []byte{82, 188, 83, 118, 70, 219, 142, 5}
-	if !bytes.HasPrefix(code, compressionFlag) {
-		return code, nil
-	}
-
-	decoder, err := zstd.NewReader(nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating zstd reader: %s", err)
-	}
-
-	return decoder.DecodeAll(code[len(compressionFlag):], nil)
-}
-
 // GetCodeHash returns the code of the instance
 func (in *Instance) GetCodeHash() common.Hash {
 	return in.codeHash
@@ -165,22 +207,36 @@ func GetRuntimeVersion(code []byte) (version runtime.Version, err error) {
 	ErrWASMDecompress = errors.New("wasm decompression failed")
 )
 
-func setupVM(code []byte) (instance wasm.Instance,
-	allocator *runtime.FreeingBumpHeapAllocator, err error) {
+// setupVM instantiates the code given, instrumented with fuel metering if
+// metering is true. If the code cannot be instrumented, for example because
+// it uses an instruction unknown to the instrumentation, it is instantiated
+// without fuel metering and metered is returned as false.
+func setupVM(code []byte, metering bool) (instance wasm.Instance,
+	allocator *runtime.FreeingBumpHeapAllocator, metered bool, err error) {
 	if len(code) == 0 {
-		return instance, nil, ErrCodeEmpty
+		return instance, nil, false, ErrCodeEmpty
 	}
 
-	code, err = decompressWasm(code)
+	code, err = runtime.DecompressWasm(code)
 	if err != nil {
 		// Note the sentinel error is wrapped here since the ztsd Go library
 		// does not return any exported sentinel errors.
-		return instance, nil, fmt.Errorf("%w: %s", ErrWASMDecompress, err)
+		return instance, nil, false, fmt.Errorf("%w: %s", ErrWASMDecompress, err)
+	}
+
+	if metering {
+		meteredCode, err := injectMetering(code)
+		if err != nil {
+			logger.Warnf("runtime calls cannot be aborted: injecting metering: %s", err)
+		} else {
+			code = meteredCode
+			metered = true
+		}
 	}
 
 	imports, err := importsNodeRuntime()
 	if err != nil {
-		return instance, nil, fmt.Errorf("creating node runtime imports: %w", err)
+		return instance, nil, false, fmt.Errorf("creating node runtime imports: %w", err)
 	}
 
 	// Provide importable memory for newer runtimes
@@ -188,18 +244,18 @@ func setupVM(code []byte) (instance wasm.Instance,
 	// should be doable w/ wasmer 1.0.0. (#1268)
 	memory, err := wasm.NewMemory(23, 0)
 	if err != nil {
-		return instance, nil, fmt.Errorf("creating web assembly memory: %w", err)
+		return instance, nil, false, fmt.Errorf("creating web assembly memory: %w", err)
 	}
 
 	_, err = imports.AppendMemory("memory", memory)
 	if err != nil {
-		return instance, nil, fmt.Errorf("appending memory to imports: %w", err)
+		return instance, nil, false, fmt.Errorf("appending memory to imports: %w", err)
 	}
 
 	// Instantiates the WebAssembly module.
 	instance, err = wasm.NewInstanceWithImports(code, imports)
 	if err != nil {
-		return instance, nil, fmt.Errorf("creating web assembly instance: %w", err)
+		return instance, nil, false, fmt.Errorf("creating web assembly instance: %w", err)
 	}
 
 	// Assume imported memory is used if runtime does not export any
@@ -213,7 +269,7 @@ func setupVM(code []byte) (instance wasm.Instance,
 
 	allocator = runtime.NewAllocator(&memoryShim{instance.Memory}, heapBase)
 
-	return instance, allocator, nil
+	return instance, allocator, metered, nil
 }
 
 type memoryShim struct {
@@ -281,16 +337,51 @@ func (in *Instance) close() {
 
 	in.vm.Close()
 	in.ctx.Allocator.Clear()
+	in.closeUntrusted()
 	in.isClosed = true
 }
 
+// closeUntrusted closes the wasm instance running the untrusted calls,
+// if any, such that it is instantiated again on the next untrusted call.
+// It is NOT THREAD SAFE to use.
+func (in *Instance) closeUntrusted() {
+	if in.untrusted == nil {
+		return
+	}
+
+	in.untrusted.vm.Close()
+	in.untrusted.allocator.Clear()
+	in.untrusted = nil
+}
+
 var (
-	ErrInstanceIsStopped      = errors.New("instance is stopped")
-	ErrExportFunctionNotFound = errors.New("export function not found")
+	ErrInstanceIsStopped        = errors.New("instance is stopped")
+	ErrExportFunctionNotFound   = errors.New("export function not found")
+	ErrInstanceTypeNotSupported = errors.New("instance type not supported")
 )
 
 // Exec calls the given function with the given data
 func (in *Instance) Exec(function string, data []byte) (result []byte, err error) {
+	return in.exec(function, data, 0)
+}
+
+// ExecUntrusted calls the given function with the given data, which is untrusted,
+// and aborts the call with an error wrapping runtime.ErrExecutionTimeout if it runs
+// for longer than the untrusted call timeout of the instance. Unless the instance
+// is metered already, the call runs on the code instrumented with fuel metering
+// and instantiated again, which is kept for the following untrusted calls.
+func (in *Instance) ExecUntrusted(function string, data []byte) (result []byte, err error) {
+	return in.exec(function, data, in.untrustedCallTimeout)
+}
+
+// UntrustedCallTimeout returns the time limit of the untrusted calls of the instance.
+func (in *Instance) UntrustedCallTimeout() time.Duration {
+	return in.untrustedCallTimeout
+}
+
+// exec calls the given function with the given data, and aborts the call if
+// it runs for longer than the timeout given, unless the timeout is zero.
+func (in *Instance) exec(function string, data []byte, timeout time.Duration) (result []byte, err error) {
 	in.mutex.Lock()
 	defer in.mutex.Unlock()
 
@@ -298,6 +389,26 @@ func (in *Instance) Exec(function string, data []byte) (result []byte, err error
 		return nil, ErrInstanceIsStopped
 	}
 
+	vm := in.vm
+	runsUntrusted := timeout > 0 && !in.metered
+	if runsUntrusted {
+		untrusted, err := in.getUntrustedVM()
+		if err != nil {
+			return nil, fmt.Errorf("setting up untrusted VM: %w", err)
+		}
+
+		// The host functions allocate with the allocator of the context.
+		vm = untrusted.vm
+		allocator := in.ctx.Allocator
+		in.ctx.Allocator = untrusted.allocator
+		defer func() { in.ctx.Allocator = allocator }()
+	}
+
+	runtimeFunc, ok := vm.Exports[function]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExportFunctionNotFound, function)
+	}
+
 	dataLength := uint32(len(data))
 	inputPtr, err := in.ctx.Allocator.Allocate(dataLength)
 	if err != nil {
@@ -307,24 +418,86 @@ func (in *Instance) Exec(function string, data []byte) (result []byte, err error
 	defer in.ctx.Allocator.Clear()
 
 	// Store the data into memory
-	memory := in.vm.Memory.Data()
+	memory := vm.Memory.Data()
 	copy(memory[inputPtr:inputPtr+dataLength], data)
 
-	runtimeFunc, ok := in.vm.Exports[function]
-	if !ok {
-		return nil, fmt.Errorf("%w: %s", ErrExportFunctionNotFound, function)
+	if timeout > 0 {
+		in.ctx.Deadline = time.Now().Add(timeout)
+		defer func() { in.ctx.Deadline = time.Time{} }()
 	}
 
 	wasmValue, err := runtimeFunc(int32(inputPtr), int32(dataLength))
 	if err != nil {
+		select {
+		case <-in.ctx.Done:
+			return nil, fmt.Errorf("%w: for %s", runtime.ErrExecutionCanceled, function)
+		default:
+		}
+
+		if timeout > 0 && time.Now().After(in.ctx.Deadline) {
+			logger.Warnf("runtime call %s aborted after %s", function, timeout)
+			resetErr := in.reset(runsUntrusted)
+			if resetErr != nil {
+				logger.Errorf("instantiating runtime again after aborted call: %s", resetErr)
+			}
+			return nil, fmt.Errorf("%w: for %s after %s", runtime.ErrExecutionTimeout, function, timeout)
+		}
 		return nil, fmt.Errorf("running runtime function: %w", err)
 	}
 
 	outputPtr, outputLength := splitPointerSize(wasmValue.ToI64())
-	memory = in.vm.Memory.Data() // call Data() again to get larger slice
+	memory = vm.Memory.Data() // call Data() again to get larger slice
 	return memory[outputPtr : outputPtr+outputLength], nil
 }
 
+// getUntrustedVM returns the wasm instance running the untrusted calls,
+// instantiating the code instrumented with fuel metering if needed.
+// If the code cannot be instrumented, the untrusted calls run unmetered.
+// It is NOT THREAD SAFE to use.
+func (in *Instance) getUntrustedVM() (untrusted *untrustedVM, err error) {
+	if in.untrusted != nil {
+		return in.untrusted, nil
+	}
+
+	wasmInstance, allocator, _, err := setupVM(in.code, true)
+	if err != nil {
+		return nil, err
+	}
+	wasmInstance.SetContextData(in.ctx)
+
+	in.untrusted = &untrustedVM{
+		vm:        wasmInstance,
+		allocator: allocator,
+	}
+	return in.untrusted, nil
+}
+
+// reset discards the wasm instance whose call was aborted, which is the one
+// running the untrusted calls if untrusted is true, since an aborted call can
+// leave the memory and globals of the instance, such as its stack pointer, in
+// an inconsistent state. The wasm instance running the untrusted calls is
+// instantiated again on the next untrusted call, and the main wasm instance
+// is instantiated again right away, with the same context.
+// It is NOT THREAD SAFE to use.
+func (in *Instance) reset(untrusted bool) (err error) {
+	if untrusted {
+		in.closeUntrusted()
+		return nil
+	}
+
+	wasmInstance, allocator, metered, err := setupVM(in.code, in.metered)
+	if err != nil {
+		return fmt.Errorf("setting up VM: %w", err)
+	}
+
+	in.vm.Close()
+	in.vm = wasmInstance
+	in.metered = metered
+	in.ctx.Allocator = allocator
+	in.vm.SetContextData(in.ctx)
+	return nil
+}
+
 // NodeStorage to get reference to runtime node service
 func (in *Instance) NodeStorage() runtime.NodeStorage {
 	return in.ctx.NodeStorage
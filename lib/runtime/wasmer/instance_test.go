@@ -7,11 +7,12 @@
 	"context"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	"github.com/klauspost/compress/zstd"
 )
 
 // test used for ensuring runtime exec calls can be made concurrently
@@ -27,6 +28,217 @@ func TestConcurrentRuntimeCalls(t *testing.T) {
 	}()
 }
 
+// loopingRuntimeCode returns the code of a Wasm module exporting a function
+// with the given name and the signature of runtime calls, looping forever:
+// (module (func (export "name") (param i32 i32) (result i64) (loop (br 0)) unreachable))
+func loopingRuntimeCode(name string) (code []byte) {
+	exportSection := append([]byte{0x01, byte(len(name))}, name...)
+	exportSection = append(exportSection, 0x00, 0x00)
+
+	code = []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic number and version
+		0x01, 0x07, 0x01, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, // type section
+		0x03, 0x02, 0x01, 0x00, // function section
+		0x07, byte(len(exportSection)),
+	}
+	code = append(code, exportSection...)
+	code = append(code, 0x0a, 0x0a, 0x01, 0x08, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b) // code section
+	return code
+}
+
+// countingRuntimeCode returns the code of a Wasm module importing a host function,
+// and exporting a function with the given name and the signature of runtime calls,
+// which calls a function counting down from the count given, and returns 0:
+//
+//	(module
+//	  (import "env" "ext_allocator_free_version_1" (func (param i32)))
+//	  (func $count (param i32) (result i64) (local i32)
+//	    (local.set 1 (i32.const count))
+//	    (loop (br_if 0 (local.tee 1 (i32.sub (local.get 1) (i32.const 1)))))
+//	    (i64.const 0))
+//	  (func (export "name") (param i32 i32) (result i64) (call $count (local.get 0))))
+func countingRuntimeCode(name string, count int32) (code []byte) {
+	appendSection := func(code []byte, id byte, payload []byte) []byte {
+		code = append(code, id, byte(len(payload)))
+		return append(code, payload...)
+	}
+
+	code = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00} // magic number and version
+	code = appendSection(code, 0x01, []byte{
+		0x03,                   // 3 types
+		0x60, 0x01, 0x7f, 0x00, // [i32] -> []
+		0x60, 0x01, 0x7f, 0x01, 0x7e, // [i32] -> [i64]
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, // [i32 i32] -> [i64]
+	})
+	importSection := []byte{0x01, 0x03, 'e', 'n', 'v', 28}
+	importSection = append(importSection, "ext_allocator_free_version_1"...)
+	code = appendSection(code, 0x02, append(importSection, 0x00, 0x00))
+	code = appendSection(code, 0x03, []byte{0x02, 0x01, 0x02}) // functions
+	exportSection := append([]byte{0x01, byte(len(name))}, name...)
+	code = appendSection(code, 0x07, append(exportSection, 0x00, 0x02))
+
+	countBody := []byte{0x01, 0x01, 0x7f, 0x41} // local i32, i32.const
+	countBody = appendS64(countBody, int64(count))
+	countBody = append(countBody,
+		0x21, 0x01, // local.set 1
+		0x03, 0x40, // loop
+		0x20, 0x01, 0x41, 0x01, 0x6b, 0x22, 0x01, // local.get 1, i32.const 1, i32.sub, local.tee 1
+		0x0d, 0x00, 0x0b, // br_if 0, end
+		0x42, 0x00, 0x0b) // i64.const 0, end
+	callBody := []byte{0x00, 0x20, 0x00, 0x10, 0x01, 0x0b} // local.get 0, call 1, end
+	codeSection := []byte{0x02, byte(len(countBody))}
+	codeSection = append(codeSection, countBody...)
+	codeSection = append(codeSection, byte(len(callBody)))
+	codeSection = append(codeSection, callBody...)
+	return appendSection(code, 0x0a, codeSection)
+}
+
+func Test_Instance_untrustedCallTimeout(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 100 * time.Millisecond
+
+	testCases := map[string]struct {
+		function string
+		call     func(instance *Instance) error
+	}{
+		"validate_transaction": {
+			function: runtime.TaggedTransactionQueueValidateTransaction,
+			call: func(instance *Instance) error {
+				_, err := instance.ValidateTransaction(types.Extrinsic{1, 2, 3})
+				return err
+			},
+		},
+		"decode_session_keys": {
+			function: runtime.DecodeSessionKeys,
+			call: func(instance *Instance) error {
+				_, err := instance.DecodeSessionKeys([]byte{1})
+				return err
+			},
+		},
+		"query_call_info": {
+			function: runtime.TransactionPaymentCallAPIQueryCallInfo,
+			call: func(instance *Instance) error {
+				_, err := instance.QueryCallInfo([]byte{1})
+				return err
+			},
+		},
+		"exec_untrusted": {
+			function: runtime.CoreVersion,
+			call: func(instance *Instance) error {
+				_, err := instance.ExecUntrusted(runtime.CoreVersion, nil)
+				return err
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			instance, err := NewInstance(loopingRuntimeCode(testCase.function),
+				Config{UntrustedCallTimeout: timeout})
+			require.NoError(t, err)
+			defer instance.Stop()
+
+			// The instance is usable again once a call is aborted.
+			for i := 0; i < 2; i++ {
+				start := time.Now()
+				err = testCase.call(instance)
+				assert.ErrorIs(t, err, runtime.ErrExecutionTimeout)
+				assert.Less(t, time.Since(start), 10*timeout)
+			}
+		})
+	}
+}
+
+func Test_Instance_Exec_refuel(t *testing.T) {
+	t.Parallel()
+
+	// The function loops for more instructions than given by a refuel,
+	// and calls a function defined by the module, whose index is shifted
+	// by the refuel function import.
+	instance, err := NewInstance(countingRuntimeCode("Test_count", 5_000_000),
+		Config{UntrustedCallTimeout: time.Minute})
+	require.NoError(t, err)
+	defer instance.Stop()
+
+	result, err := instance.Exec("Test_count", nil)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	result, err = instance.ExecUntrusted("Test_count", nil)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func Test_Instance_metering(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 100 * time.Millisecond
+	instance, err := NewInstance(loopingRuntimeCode("Test_loop"),
+		Config{UntrustedCallTimeout: timeout})
+	require.NoError(t, err)
+	defer instance.Stop()
+
+	// The trusted calls run on the code not instrumented with fuel metering.
+	assert.False(t, instance.metered)
+	assert.Nil(t, instance.untrusted)
+
+	_, err = instance.ExecUntrusted("Test_loop", nil)
+	assert.ErrorIs(t, err, runtime.ErrExecutionTimeout)
+
+	// The aborted untrusted VM is discarded, and the allocator
+	// of the main VM is restored in the context.
+	assert.Nil(t, instance.untrusted)
+	assert.False(t, instance.metered)
+	mainAllocator := instance.ctx.Allocator
+
+	_, err = instance.ExecUntrusted("Test_loop", nil)
+	assert.ErrorIs(t, err, runtime.ErrExecutionTimeout)
+	assert.Same(t, mainAllocator, instance.ctx.Allocator)
+
+	countingInstance, err := NewInstance(countingRuntimeCode("Test_count", 10),
+		Config{UntrustedCallTimeout: timeout})
+	require.NoError(t, err)
+	defer countingInstance.Stop()
+
+	_, err = countingInstance.ExecUntrusted("Test_count", nil)
+	require.NoError(t, err)
+	require.NotNil(t, countingInstance.untrusted)
+	untrusted := countingInstance.untrusted
+
+	// The untrusted VM is kept for the following untrusted calls.
+	_, err = countingInstance.ExecUntrusted("Test_count", nil)
+	require.NoError(t, err)
+	assert.Same(t, untrusted, countingInstance.untrusted)
+
+	// All the calls of an instance with a done channel can be
+	// canceled, so its code is instrumented with fuel metering.
+	doneInstance, err := NewInstance(loopingRuntimeCode("Test_loop"),
+		Config{Done: make(chan struct{})})
+	require.NoError(t, err)
+	defer doneInstance.Stop()
+	assert.True(t, doneInstance.metered)
+}
+
+func Test_Instance_Exec_done(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	instance, err := NewInstance(loopingRuntimeCode("Test_loop"), Config{Done: done})
+	require.NoError(t, err)
+	defer instance.Stop()
+
+	time.AfterFunc(10*time.Millisecond, func() { close(done) })
+
+	result, err := instance.Exec("Test_loop", nil)
+	assert.ErrorIs(t, err, runtime.ErrExecutionCanceled)
+	assert.EqualError(t, err, "runtime execution canceled: for Test_loop")
+	assert.Nil(t, result)
+}
+
 func Test_GetRuntimeVersion(t *testing.T) {
 	polkadotRuntimeFilepath, err := runtime.GetRuntime(
 		context.Background(), runtime.POLKADOT_RUNTIME_v0929)
@@ -77,41 +289,3 @@ func Benchmark_GetRuntimeVersion(b *testing.B) {
 		_, _ = GetRuntimeVersion(code)
 	}
 }
-
-func TestDecompressWasm(t *testing.T) {
-	encoder, err := zstd.NewWriter(nil)
-	require.NoError(t, err)
-
-	cases := []struct {
-		in       []byte
-		expected []byte
-		msg      string
-	}{
-		{
-			[]byte{82, 188, 83, 118, 70, 219, 142},
-			[]byte{82, 188, 83, 118, 70, 219, 142},
-			"partial compression flag",
-		},
-		{
-			[]byte{82, 188, 83, 118, 70, 219, 142, 6},
-			[]byte{82, 188, 83, 118, 70, 219, 142, 6},
-			"wrong compression flag",
-		},
-		{
-			[]byte{82, 188, 83, 118, 70, 219, 142, 6, 221},
-			[]byte{82, 188, 83, 118, 70, 219, 142, 6, 221},
-			"wrong compression flag with data",
-		},
-		{
-			append([]byte{82, 188, 83, 118, 70, 219, 142, 5}, encoder.EncodeAll([]byte("compressed"), nil)...),
-			[]byte("compressed"),
-			"compressed data",
-		},
-	}
-
-	for _, test := range cases {
-		actual, err := decompressWasm(test.in)
-		require.NoError(t, err)
-		require.Equal(t, test.expected, actual)
-	}
-}
@@ -0,0 +1,682 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package wasmer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	// meteringRefuelImport is the name of the host function imported by the
+	// metered code when it runs out of fuel. It returns the fuel to continue
+	// with, or 0 to abort the call once its deadline is exceeded.
+	meteringRefuelImport = "ext_gossamer_metering_refuel"
+	// meteringFuelPerRefuel is the fuel given on each refuel, such that the
+	// deadline of a call is checked every few million Wasm instructions.
+	meteringFuelPerRefuel = 1 << 22
+)
+
+var (
+	errWasmMalformed         = errors.New("malformed wasm module")
+	errWasmOpcodeUnsupported = errors.New("wasm opcode not supported")
+)
+
+// Wasm section IDs, see https://webassembly.github.io/spec/core/binary/modules.html#sections
+const (
+	wasmSectionCustom    byte = 0
+	wasmSectionType      byte = 1
+	wasmSectionImport    byte = 2
+	wasmSectionGlobal    byte = 6
+	wasmSectionExport    byte = 7
+	wasmSectionStart     byte = 8
+	wasmSectionElement   byte = 9
+	wasmSectionCode      byte = 10
+	wasmSectionDataCount byte = 12
+)
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+type wasmSection struct {
+	id      byte
+	payload []byte
+}
+
+// wasmSectionOrder returns the position of the section with the given
+// id in a module, since the data count section precedes the code section.
+func wasmSectionOrder(id byte) (order float64) {
+	if id == wasmSectionDataCount {
+		return float64(wasmSectionCode) - 0.5
+	}
+	return float64(id)
+}
+
+// injectMetering instruments the Wasm code given with fuel metering, such
+// that runtime calls can be aborted. A mutable i64 global holds the fuel left,
+// which is decremented on entry of each function and on each loop iteration by
+// the number of instructions up to the next metering point. Once the fuel runs
+// out, the imported refuel host function is called, and the call traps if it
+// returns zero. The refuel import is appended to the function imports, so the
+// indices of the functions defined by the module are shifted by one.
+func injectMetering(code []byte) (metered []byte, err error) {
+	if !bytes.HasPrefix(code, wasmHeader) {
+		return nil, fmt.Errorf("%w: invalid header", errWasmMalformed)
+	}
+
+	reader := &wasmReader{data: code, offset: len(wasmHeader)}
+	var sections []wasmSection
+	for reader.offset < len(reader.data) {
+		id, err := reader.byte()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := reader.vector()
+		if err != nil {
+			return nil, fmt.Errorf("reading section %d: %w", id, err)
+		}
+		sections = append(sections, wasmSection{id: id, payload: payload})
+	}
+
+	var numTypes, numImportedFuncs, numImportedGlobals, numGlobals uint32
+	for _, section := range sections {
+		switch section.id {
+		case wasmSectionType:
+			numTypes, err = (&wasmReader{data: section.payload}).u32()
+		case wasmSectionImport:
+			numImportedFuncs, numImportedGlobals, err = countWasmImports(section.payload)
+		case wasmSectionGlobal:
+			numGlobals, err = (&wasmReader{data: section.payload}).u32()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading section %d: %w", section.id, err)
+		}
+	}
+
+	m := &meteringInjector{
+		importedFuncs: numImportedFuncs,
+		refuelFunc:    numImportedFuncs,
+		fuelGlobal:    numImportedGlobals + numGlobals,
+		refuelType:    numTypes,
+	}
+
+	// The type, import and global sections are created if the module has none.
+	missing := map[byte]struct{}{
+		wasmSectionType:   {},
+		wasmSectionImport: {},
+		wasmSectionGlobal: {},
+	}
+	metered = append([]byte(nil), wasmHeader...)
+	for _, section := range sections {
+		for _, id := range []byte{wasmSectionType, wasmSectionImport, wasmSectionGlobal} {
+			_, isMissing := missing[id]
+			if isMissing && section.id != wasmSectionCustom && wasmSectionOrder(id) < wasmSectionOrder(section.id) {
+				metered = appendWasmSection(metered, id, m.emptySection(id))
+				delete(missing, id)
+			}
+		}
+
+		delete(missing, section.id)
+		payload, err := m.rewriteSection(section)
+		if err != nil {
+			return nil, fmt.Errorf("instrumenting section %d: %w", section.id, err)
+		}
+		if payload != nil {
+			metered = appendWasmSection(metered, section.id, payload)
+		}
+	}
+	for _, id := range []byte{wasmSectionType, wasmSectionImport, wasmSectionGlobal} {
+		if _, isMissing := missing[id]; isMissing {
+			metered = appendWasmSection(metered, id, m.emptySection(id))
+		}
+	}
+
+	return metered, nil
+}
+
+func countWasmImports(payload []byte) (funcs, globals uint32, err error) {
+	reader := &wasmReader{data: payload}
+	count, err := reader.u32()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := uint32(0); i < count; i++ {
+		for j := 0; j < 2; j++ { // module and field names
+			_, err = reader.vector()
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+
+		kind, err := reader.byte()
+		if err != nil {
+			return 0, 0, err
+		}
+
+		switch kind {
+		case 0x00: // function type index
+			funcs++
+			_, err = reader.u32()
+		case 0x01: // table reference type and limits
+			_, err = reader.byte()
+			if err == nil {
+				err = reader.skipLimits()
+			}
+		case 0x02: // memory limits
+			err = reader.skipLimits()
+		case 0x03: // global value type and mutability
+			globals++
+			_, err = reader.bytes(2)
+		default:
+			err = fmt.Errorf("%w: import kind %d", errWasmMalformed, kind)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return funcs, globals, nil
+}
+
+func appendWasmSection(module []byte, id byte, payload []byte) []byte {
+	module = append(module, id)
+	module = appendU32(module, uint32(len(payload)))
+	return append(module, payload...)
+}
+
+// meteringInjector rewrites the sections of a module to inject metering.
+type meteringInjector struct {
+	importedFuncs uint32
+	refuelFunc    uint32
+	fuelGlobal    uint32
+	refuelType    uint32
+}
+
+func (m *meteringInjector) emptySection(id byte) (payload []byte) {
+	payload, _ = m.rewriteSection(wasmSection{id: id, payload: []byte{0}})
+	return payload
+}
+
+// rewriteSection returns the payload of the section given instrumented,
+// or nil if the section is to be removed.
+func (m *meteringInjector) rewriteSection(section wasmSection) (payload []byte, err error) {
+	reader := &wasmReader{data: section.payload}
+	switch section.id {
+	case wasmSectionCustom:
+		// The name section refers to the function indices shifted.
+		name, err := reader.vector()
+		if err != nil {
+			return nil, err
+		} else if string(name) == "name" {
+			return nil, nil
+		}
+		return section.payload, nil
+	case wasmSectionType:
+		// The refuel function type: [] -> [i64]
+		return appendVectorItem(reader, []byte{0x60, 0x00, 0x01, 0x7e})
+	case wasmSectionImport:
+		item := appendName(nil, "env")
+		item = appendName(item, meteringRefuelImport)
+		item = append(item, 0x00)
+		item = appendU32(item, m.refuelType)
+		return appendVectorItem(reader, item)
+	case wasmSectionGlobal:
+		// mutable i64 global initialised with i64.const
+		item := []byte{0x7e, 0x01, 0x42}
+		item = appendS64(item, meteringFuelPerRefuel)
+		item = append(item, 0x0b)
+		return appendVectorItem(reader, item)
+	case wasmSectionExport:
+		return m.rewriteExports(reader)
+	case wasmSectionStart:
+		index, err := reader.u32()
+		if err != nil {
+			return nil, err
+		}
+		return appendU32(nil, m.shift(index)), nil
+	case wasmSectionElement:
+		return m.rewriteElements(reader)
+	case wasmSectionCode:
+		return m.rewriteCode(reader)
+	default:
+		return section.payload, nil
+	}
+}
+
+// appendVectorItem returns the vector read with the item given appended.
+func appendVectorItem(reader *wasmReader, item []byte) (payload []byte, err error) {
+	count, err := reader.u32()
+	if err != nil {
+		return nil, err
+	}
+	payload = appendU32(nil, count+1)
+	payload = append(payload, reader.data[reader.offset:]...)
+	return append(payload, item...), nil
+}
+
+// shift returns the index of the function with the index given,
+// once the refuel function is imported.
+func (m *meteringInjector) shift(funcIndex uint32) uint32 {
+	if funcIndex >= m.importedFuncs {
+		return funcIndex + 1
+	}
+	return funcIndex
+}
+
+func (m *meteringInjector) rewriteExports(reader *wasmReader) (payload []byte, err error) {
+	count, err := reader.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	payload = appendU32(nil, count)
+	for i := uint32(0); i < count; i++ {
+		start := reader.offset
+		_, err = reader.vector()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := reader.byte()
+		if err != nil {
+			return nil, err
+		}
+		payload = append(payload, reader.data[start:reader.offset]...)
+
+		index, err := reader.u32()
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x00 {
+			index = m.shift(index)
+		}
+		payload = appendU32(payload, index)
+	}
+	return payload, nil
+}
+
+func (m *meteringInjector) rewriteElements(reader *wasmReader) (payload []byte, err error) {
+	count, err := reader.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	payload = appendU32(nil, count)
+	for i := uint32(0); i < count; i++ {
+		flags, err := reader.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload = appendU32(payload, flags)
+
+		const (
+			passiveOrDeclarative = 0b001
+			explicitTable        = 0b010
+			expressions          = 0b100
+		)
+		if flags > 7 {
+			return nil, fmt.Errorf("%w: element segment flags %d", errWasmMalformed, flags)
+		}
+
+		if flags&passiveOrDeclarative == 0 {
+			if flags&explicitTable != 0 {
+				tableIndex, err := reader.u32()
+				if err != nil {
+					return nil, err
+				}
+				payload = appendU32(payload, tableIndex)
+			}
+			payload, err = m.rewriteExpression(reader, payload)
+			if err != nil {
+				return nil, fmt.Errorf("rewriting offset expression: %w", err)
+			}
+		}
+
+		if flags&(passiveOrDeclarative|explicitTable) != 0 {
+			// element kind or reference type
+			kind, err := reader.byte()
+			if err != nil {
+				return nil, err
+			}
+			payload = append(payload, kind)
+		}
+
+		length, err := reader.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload = appendU32(payload, length)
+		for j := uint32(0); j < length; j++ {
+			if flags&expressions != 0 {
+				payload, err = m.rewriteExpression(reader, payload)
+				if err != nil {
+					return nil, fmt.Errorf("rewriting element expression: %w", err)
+				}
+				continue
+			}
+
+			index, err := reader.u32()
+			if err != nil {
+				return nil, err
+			}
+			payload = appendU32(payload, m.shift(index))
+		}
+	}
+	return payload, nil
+}
+
+// rewriteExpression rewrites a constant expression up to its end.
+func (m *meteringInjector) rewriteExpression(reader *wasmReader, payload []byte) (
+	rewritten []byte, err error) {
+	for {
+		var opcode byte
+		payload, opcode, err = m.rewriteInstruction(reader, payload)
+		if err != nil {
+			return nil, err
+		} else if opcode == 0x0b { // end
+			return payload, nil
+		}
+	}
+}
+
+func (m *meteringInjector) rewriteCode(reader *wasmReader) (payload []byte, err error) {
+	count, err := reader.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	payload = appendU32(nil, count)
+	for i := uint32(0); i < count; i++ {
+		body, err := reader.vector()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err = m.rewriteFunctionBody(body)
+		if err != nil {
+			return nil, fmt.Errorf("instrumenting function %d: %w", i, err)
+		}
+		payload = appendU32(payload, uint32(len(body)))
+		payload = append(payload, body...)
+	}
+	return payload, nil
+}
+
+// rewriteFunctionBody rewrites the function body given, and inserts a
+// metering point at the function entry and at the start of each loop.
+func (m *meteringInjector) rewriteFunctionBody(body []byte) (rewritten []byte, err error) {
+	reader := &wasmReader{data: body}
+	localsCount, err := reader.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < localsCount; i++ {
+		_, err = reader.u32()
+		if err != nil {
+			return nil, err
+		}
+		_, err = reader.byte()
+		if err != nil {
+			return nil, err
+		}
+	}
+	locals := body[:reader.offset]
+
+	// instructions are split in segments each starting with a metering point.
+	type segment struct {
+		instructions []byte
+		cost         int64
+	}
+	segments := []segment{{}}
+	for reader.offset < len(reader.data) {
+		current := &segments[len(segments)-1]
+		var opcode byte
+		current.instructions, opcode, err = m.rewriteInstruction(reader, current.instructions)
+		if err != nil {
+			return nil, err
+		}
+		current.cost++
+
+		if opcode == 0x03 { // loop
+			segments = append(segments, segment{})
+		}
+	}
+
+	rewritten = append([]byte(nil), locals...)
+	for _, segment := range segments {
+		cost := segment.cost
+		if cost == 0 {
+			cost = 1
+		}
+		rewritten = m.appendMeteringPoint(rewritten, cost)
+		rewritten = append(rewritten, segment.instructions...)
+	}
+	return rewritten, nil
+}
+
+// appendMeteringPoint appends the instructions consuming the fuel cost
+// given, and refueling or trapping if the fuel runs out.
+func (m *meteringInjector) appendMeteringPoint(code []byte, cost int64) []byte {
+	code = append(code, 0x23) // global.get
+	code = appendU32(code, m.fuelGlobal)
+	code = append(code, 0x42) // i64.const
+	code = appendS64(code, cost)
+	code = append(code, 0x7d, 0x24) // i64.sub, global.set
+	code = appendU32(code, m.fuelGlobal)
+	code = append(code, 0x23) // global.get
+	code = appendU32(code, m.fuelGlobal)
+	code = append(code, 0x42, 0x00, 0x53, 0x04, 0x40, 0x10) // i64.const 0, i64.lt_s, if, call
+	code = appendU32(code, m.refuelFunc)
+	code = append(code, 0x24) // global.set
+	code = appendU32(code, m.fuelGlobal)
+	code = append(code, 0x23) // global.get
+	code = appendU32(code, m.fuelGlobal)
+	// i64.eqz, if, unreachable, end, end
+	return append(code, 0x50, 0x04, 0x40, 0x00, 0x0b, 0x0b)
+}
+
+// rewriteInstruction appends the next instruction read to the code given,
+// with its function index shifted if it refers to a function.
+func (m *meteringInjector) rewriteInstruction(reader *wasmReader, code []byte) (
+	rewritten []byte, opcode byte, err error) {
+	start := reader.offset
+	opcode, err = reader.byte()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case opcode == 0x10 || opcode == 0x12 || opcode == 0xd2: // call, return_call, ref.func
+		index, err := reader.u32()
+		if err != nil {
+			return nil, 0, err
+		}
+		code = append(code, opcode)
+		return appendU32(code, m.shift(index)), opcode, nil
+	case opcode <= 0x01, opcode == 0x05, opcode == 0x0b, opcode == 0x0f, // unreachable, nop, else, end, return
+		opcode == 0x1a, opcode == 0x1b, // drop, select
+		opcode >= 0x45 && opcode <= 0xc4, // numeric and sign extension instructions
+		opcode == 0xd1:                   // ref.is_null
+	case opcode >= 0x02 && opcode <= 0x04: // block, loop, if
+		err = reader.skipBlockType()
+	case opcode == 0x0c, opcode == 0x0d, // br, br_if
+		opcode >= 0x20 && opcode <= 0x26, // local, global and table get and set
+		opcode == 0x3f, opcode == 0x40:   // memory.size, memory.grow
+		_, err = reader.u32()
+	case opcode == 0x0e: // br_table
+		var length uint32
+		length, err = reader.u32()
+		if err == nil {
+			err = reader.skipU32s(int(length) + 1)
+		}
+	case opcode == 0x11, opcode == 0x13, // call_indirect, return_call_indirect
+		opcode >= 0x28 && opcode <= 0x3e: // memory loads and stores
+		err = reader.skipU32s(2)
+	case opcode == 0x1c: // typed select
+		var length uint32
+		length, err = reader.u32()
+		if err == nil {
+			_, err = reader.bytes(int(length))
+		}
+	case opcode == 0x41, opcode == 0x42: // i32.const, i64.const
+		err = reader.skipSignedLEB()
+	case opcode == 0x43: // f32.const
+		_, err = reader.bytes(4)
+	case opcode == 0x44: // f64.const
+		_, err = reader.bytes(8)
+	case opcode == 0xd0: // ref.null
+		_, err = reader.byte()
+	case opcode == 0xfc:
+		err = reader.skipPrefixedImmediates()
+	default:
+		err = fmt.Errorf("%w: 0x%x", errWasmOpcodeUnsupported, opcode)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return append(code, reader.data[start:reader.offset]...), opcode, nil
+}
+
+// wasmReader reads a Wasm binary.
+type wasmReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *wasmReader) byte() (b byte, err error) {
+	if r.offset >= len(r.data) {
+		return 0, fmt.Errorf("%w: unexpected end", errWasmMalformed)
+	}
+	b = r.data[r.offset]
+	r.offset++
+	return b, nil
+}
+
+func (r *wasmReader) bytes(n int) (b []byte, err error) {
+	if n < 0 || r.offset+n > len(r.data) {
+		return nil, fmt.Errorf("%w: unexpected end", errWasmMalformed)
+	}
+	b = r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b, nil
+}
+
+func (r *wasmReader) vector() (b []byte, err error) {
+	length, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	return r.bytes(int(length))
+}
+
+func (r *wasmReader) u32() (value uint32, err error) {
+	for shift := 0; shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: u32 too long", errWasmMalformed)
+}
+
+func (r *wasmReader) skipU32s(n int) (err error) {
+	for i := 0; i < n; i++ {
+		_, err = r.u32()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *wasmReader) skipSignedLEB() (err error) {
+	const maxLength = 10
+	for i := 0; i < maxLength; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: integer too long", errWasmMalformed)
+}
+
+func (r *wasmReader) skipLimits() (err error) {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if flags&0x01 != 0 { // maximum present
+		return r.skipU32s(2)
+	}
+	return r.skipU32s(1)
+}
+
+func (r *wasmReader) skipBlockType() (err error) {
+	if r.offset >= len(r.data) {
+		return fmt.Errorf("%w: unexpected end", errWasmMalformed)
+	}
+	switch r.data[r.offset] {
+	case 0x40, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f: // empty or value type
+		r.offset++
+		return nil
+	default: // type index
+		return r.skipSignedLEB()
+	}
+}
+
+// skipPrefixedImmediates skips the immediates of an instruction prefixed with 0xfc.
+func (r *wasmReader) skipPrefixedImmediates() (err error) {
+	subOpcode, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case subOpcode <= 7: // saturating truncations
+		return nil
+	case subOpcode == 8, subOpcode == 10, subOpcode == 12, subOpcode == 14: // memory.init, memory.copy,
+		// table.init, table.copy
+		return r.skipU32s(2)
+	case subOpcode == 9, subOpcode == 11, subOpcode == 13, // data.drop, memory.fill, elem.drop
+		subOpcode >= 15 && subOpcode <= 17: // table.grow, table.size, table.fill
+		return r.skipU32s(1)
+	default:
+		return fmt.Errorf("%w: 0xfc %d", errWasmOpcodeUnsupported, subOpcode)
+	}
+}
+
+func appendName(b []byte, name string) []byte {
+	b = appendU32(b, uint32(len(name)))
+	return append(b, name...)
+}
+
+func appendU32(b []byte, value uint32) []byte {
+	for {
+		next := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(b, next)
+		}
+		b = append(b, next|0x80)
+	}
+}
+
+func appendS64(b []byte, value int64) []byte {
+	for {
+		next := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && next&0x40 == 0) || (value == -1 && next&0x40 != 0) {
+			return append(b, next)
+		}
+		b = append(b, next|0x80)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package wasmer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_injectMetering(t *testing.T) {
+	t.Parallel()
+
+	meteringPoint := func(cost byte) []byte {
+		return []byte{
+			0x23, 0x00, 0x42, cost, 0x7d, 0x24, 0x00, // fuel -= cost
+			0x23, 0x00, 0x42, 0x00, 0x53, 0x04, 0x40, // if fuel < 0
+			0x10, 0x00, 0x24, 0x00, // fuel = refuel()
+			0x23, 0x00, 0x50, 0x04, 0x40, 0x00, 0x0b, // if fuel == 0 { unreachable }
+			0x0b,
+		}
+	}
+	body := []byte{0x00}                              // no local
+	body = append(body, meteringPoint(1)...)          // function entry
+	body = append(body, 0x03, 0x40)                   // loop
+	body = append(body, meteringPoint(4)...)          // loop iteration
+	body = append(body, 0x0c, 0x00, 0x0b, 0x00, 0x0b) // br 0, end, unreachable, end
+
+	refuelImport := appendName(appendName([]byte{0x01}, "env"), meteringRefuelImport)
+	refuelImport = append(refuelImport, 0x00, 0x01) // function of type 1
+
+	expected := append([]byte(nil), wasmHeader...)
+	expected = appendWasmSection(expected, wasmSectionType,
+		[]byte{0x02, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, 0x60, 0x00, 0x01, 0x7e})
+	expected = appendWasmSection(expected, wasmSectionImport, refuelImport)
+	expected = appendWasmSection(expected, 3, []byte{0x01, 0x00})
+	expected = appendWasmSection(expected, wasmSectionGlobal,
+		append(appendS64([]byte{0x01, 0x7e, 0x01, 0x42}, meteringFuelPerRefuel), 0x0b))
+	expected = appendWasmSection(expected, wasmSectionExport, []byte{0x01, 0x01, 'f', 0x00, 0x01})
+	expected = appendWasmSection(expected, wasmSectionCode,
+		append([]byte{0x01, byte(len(body))}, body...))
+
+	metered, err := injectMetering(loopingRuntimeCode("f"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, metered)
+}
+
+func Test_injectMetering_errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		code       []byte
+		errWrapped error
+		errMessage string
+	}{
+		"invalid_header": {
+			code:       []byte{1, 2, 3},
+			errWrapped: errWasmMalformed,
+			errMessage: "malformed wasm module: invalid header",
+		},
+		"truncated_section": {
+			code:       append(append([]byte(nil), wasmHeader...), wasmSectionType, 0x05, 0x01),
+			errWrapped: errWasmMalformed,
+			errMessage: "reading section 1: malformed wasm module: unexpected end",
+		},
+		"unsupported_opcode": {
+			// function body with a SIMD instruction
+			code: appendWasmSection(append([]byte(nil), wasmHeader...), wasmSectionCode,
+				[]byte{0x01, 0x04, 0x00, 0xfd, 0x0c, 0x0b}),
+			errWrapped: errWasmOpcodeUnsupported,
+			errMessage: "instrumenting section 10: instrumenting function 0: wasm opcode not supported: 0xfd",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			metered, err := injectMetering(testCase.code)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.EqualError(t, err, testCase.errMessage)
+			assert.Nil(t, metered)
+		})
+	}
+}
+
+func Test_appendS64(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[int64][]byte{
+		0:       {0x00},
+		63:      {0x3f},
+		64:      {0xc0, 0x00},
+		-1:      {0x7f},
+		-65:     {0xbf, 0x7f},
+		1 << 22: {0x80, 0x80, 0x80, 0x02},
+	}
+
+	for value, expected := range testCases {
+		assert.Equal(t, expected, appendS64(nil, value), "value %d", value)
+	}
+}
@@ -5,6 +5,7 @@
 
 import (
 	"reflect"
+	"time"
 )
 
 // Service must be implemented by all services
@@ -18,13 +19,17 @@ type ServiceRegistry struct {
 	services     map[reflect.Type]Service // map of types to service instances
 	serviceTypes []reflect.Type           // all known service types, used to iterate through services
 	logger       Logger
+	stopTimeout  time.Duration // maximum duration to stop all services, 0 for no limit
 }
 
-// NewServiceRegistry creates an empty registry
-func NewServiceRegistry(logger Logger) *ServiceRegistry {
+// NewServiceRegistry creates an empty registry. The stop timeout is the
+// maximum duration StopAll waits for all services to stop, and can be
+// set to 0 to wait indefinitely.
+func NewServiceRegistry(logger Logger, stopTimeout time.Duration) *ServiceRegistry {
 	return &ServiceRegistry{
-		services: make(map[reflect.Type]Service),
-		logger:   logger,
+		services:    make(map[reflect.Type]Service),
+		logger:      logger,
+		stopTimeout: stopTimeout,
 	}
 }
 
@@ -52,17 +57,48 @@ func (s *ServiceRegistry) StartAll() {
 	s.logger.Debug("All services started.")
 }
 
-// StopAll calls `Service.Stop()` for all registered services
+// StopAll calls `Service.Stop()` for all registered services, one after
+// the other in the order they were registered. If the stop timeout elapses
+// before all services are stopped, it logs an error for the service being
+// stopped, stops waiting for it and continues stopping the remaining
+// services, each of them being given the stop timeout to stop.
 func (s *ServiceRegistry) StopAll() {
 	s.logger.Infof("Stopping services: %v", s.serviceTypes)
-	for _, typ := range s.serviceTypes {
+
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if s.stopTimeout > 0 {
+		timer = time.NewTimer(s.stopTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	timedOut := false
+	for i, typ := range s.serviceTypes {
 		s.logger.Debugf("Stopping service %s", typ)
-		err := s.services[typ].Stop()
-		if err != nil {
-			s.logger.Errorf("Error stopping service %s: %s", typ, err)
+
+		errCh := make(chan error, 1)
+		go func(service Service) {
+			errCh <- service.Stop()
+		}(s.services[typ])
+
+		select {
+		case err := <-errCh:
+			if err != nil {
+				s.logger.Errorf("Error stopping service %s: %s", typ, err)
+			}
+		case <-timeout:
+			timedOut = true
+			s.logger.Errorf("Timed out after %s stopping service %s, continuing with services still pending: %v",
+				s.stopTimeout, typ, s.serviceTypes[i+1:])
+			// the timer fired and its channel is drained, so it can be reset.
+			timer.Reset(s.stopTimeout)
 		}
 	}
-	s.logger.Debug("All services stopped.")
+
+	if !timedOut {
+		s.logger.Debug("All services stopped.")
+	}
 }
 
 // Get retrieves a service and stores a reference to it in the passed in `srvc`
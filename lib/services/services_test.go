@@ -4,16 +4,19 @@
 package services
 
 import (
+	"bytes"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/ChainSafe/gossamer/internal/log"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestServiceRegistry_RegisterService(t *testing.T) {
-	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)))
+	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)), 0)
 
 	r.RegisterService(&MockService{})
 	r.RegisterService(&MockService{})
@@ -22,7 +25,7 @@ func TestServiceRegistry_RegisterService(t *testing.T) {
 }
 
 func TestServiceRegistry_StartStopAll(t *testing.T) {
-	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)))
+	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)), 0)
 	ctrl := gomock.NewController(t)
 	m := NewMockService(ctrl)
 	m.EXPECT().Start().Return(nil)
@@ -35,7 +38,7 @@ func TestServiceRegistry_StartStopAll(t *testing.T) {
 }
 
 func TestServiceRegistry_Get_Err(t *testing.T) {
-	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)))
+	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)), 0)
 
 	a := NewMockService(nil)
 
@@ -45,3 +48,63 @@ func TestServiceRegistry_Get_Err(t *testing.T) {
 	f := struct{}{}
 	require.Nil(t, r.Get(f))
 }
+
+// firstService and secondService are distinct service types,
+// since the registry holds a single service per type.
+type firstService struct{ stop func() error }
+
+func (firstService) Start() error  { return nil }
+func (s firstService) Stop() error { return s.stop() }
+
+type secondService struct{ stop func() error }
+
+func (secondService) Start() error  { return nil }
+func (s secondService) Stop() error { return s.stop() }
+
+func TestServiceRegistry_StopAll_order(t *testing.T) {
+	t.Parallel()
+
+	r := NewServiceRegistry(log.New(log.SetWriter(io.Discard)), time.Minute)
+
+	var stopped []string
+	r.RegisterService(firstService{stop: func() error {
+		stopped = append(stopped, "first")
+		return nil
+	}})
+	r.RegisterService(secondService{stop: func() error {
+		stopped = append(stopped, "second")
+		return nil
+	}})
+
+	r.StopAll()
+
+	assert.Equal(t, []string{"first", "second"}, stopped)
+}
+
+func TestServiceRegistry_StopAll_timeout(t *testing.T) {
+	t.Parallel()
+
+	buffer := bytes.NewBuffer(nil)
+	const timeout = 50 * time.Millisecond
+	r := NewServiceRegistry(log.New(log.SetWriter(buffer)), timeout)
+
+	blockStop := make(chan struct{})
+	defer close(blockStop)
+	r.RegisterService(firstService{stop: func() error {
+		<-blockStop
+		return nil
+	}})
+	secondStopped := false
+	r.RegisterService(secondService{stop: func() error {
+		secondStopped = true
+		return nil
+	}})
+
+	start := time.Now()
+	r.StopAll()
+
+	assert.GreaterOrEqual(t, time.Since(start), timeout)
+	assert.True(t, secondStopped)
+	assert.Contains(t, buffer.String(), "Timed out after 50ms stopping service services.firstService, "+
+		"continuing with services still pending: [services.secondService]")
+}
@@ -4,9 +4,11 @@
 package trie
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
+	"github.com/ChainSafe/gossamer/internal/trie/tracking"
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
@@ -15,6 +17,24 @@
 
 var ErrChildTrieDoesNotExist = errors.New("child trie does not exist")
 
+// ChildStorageKey returns the key :child_storage:default:[keyToChild]
+// at which the root hash of the child trie is stored in the main trie.
+func ChildStorageKey(keyToChild []byte) (childStorageKey []byte) {
+	childStorageKey = make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
+	copy(childStorageKey, ChildStorageKeyPrefix)
+	copy(childStorageKey[len(ChildStorageKeyPrefix):], keyToChild)
+	return childStorageKey
+}
+
+// KeyToChild returns the key to the child trie given its child storage
+// key, and false if the key given is not a child storage key.
+func KeyToChild(childStorageKey []byte) (keyToChild []byte, ok bool) {
+	if !bytes.HasPrefix(childStorageKey, ChildStorageKeyPrefix) {
+		return nil, false
+	}
+	return childStorageKey[len(ChildStorageKeyPrefix):], true
+}
+
 // SetChild inserts a child trie into the main trie at key :child_storage:[keyToChild]
 // A child trie is added as a node (K, V) in the main trie. K is the child storage key
 // associated to the child trie, and V is the root hash of the child trie.
@@ -24,9 +44,7 @@ func (t *Trie) SetChild(keyToChild []byte, child *Trie) error {
 		return err
 	}
 
-	key := make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
-	copy(key, ChildStorageKeyPrefix)
-	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
+	key := ChildStorageKey(keyToChild)
 
 	err = t.Put(key, childHash.ToBytes())
 	if err != nil {
@@ -39,9 +57,7 @@ func (t *Trie) SetChild(keyToChild []byte, child *Trie) error {
 
 // GetChild returns the child trie at key :child_storage:[keyToChild]
 func (t *Trie) GetChild(keyToChild []byte) (*Trie, error) {
-	key := make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
-	copy(key, ChildStorageKeyPrefix)
-	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
+	key := ChildStorageKey(keyToChild)
 
 	childHash := t.Get(key)
 	if childHash == nil {
@@ -53,29 +69,39 @@ func (t *Trie) GetChild(keyToChild []byte) (*Trie, error) {
 
 // PutIntoChild puts a key-value pair into the child trie located in the main trie at key :child_storage:[keyToChild]
 func (t *Trie) PutIntoChild(keyToChild, key, value []byte) error {
+	return t.UpdateChild(keyToChild, func(child *Trie) error {
+		err := child.Put(key, value)
+		if err != nil {
+			return fmt.Errorf("putting into child trie located at key 0x%x: %w", keyToChild, err)
+		}
+		return nil
+	})
+}
+
+// UpdateChild calls the update function given with the child trie located in
+// the main trie at key :child_storage:[keyToChild], and then puts the new root
+// hash of the child trie in the main trie, such that the child trie root hash is
+// only computed once for all the changes made by the update function.
+func (t *Trie) UpdateChild(keyToChild []byte, update func(child *Trie) error) error {
 	child, err := t.GetChild(keyToChild)
 	if err != nil {
 		return err
 	}
+	if child == nil {
+		return fmt.Errorf("%w at key 0x%x%x", ErrChildTrieDoesNotExist, ChildStorageKeyPrefix, keyToChild)
+	}
 
 	origChildHash, err := child.Hash()
 	if err != nil {
 		return err
 	}
 
-	err = child.Put(key, value)
-	if err != nil {
-		return fmt.Errorf("putting into child trie located at key 0x%x: %w", keyToChild, err)
-	}
-
-	childHash, err := child.Hash()
+	err = update(child)
 	if err != nil {
 		return err
 	}
 
 	delete(t.childTries, origChildHash)
-	t.childTries[childHash] = child
-
 	return t.SetChild(keyToChild, child)
 }
 
@@ -95,33 +121,45 @@ func (t *Trie) GetFromChild(keyToChild, key []byte) ([]byte, error) {
 	return val, nil
 }
 
-// DeleteChild deletes the child storage trie
+// DeleteChild deletes the child trie located in the main trie at key :child_storage:[keyToChild].
+// The nodes of the child trie present at the last snapshot are recorded as deleted,
+// such that they are pruned once the state before the deletion is pruned.
 func (t *Trie) DeleteChild(keyToChild []byte) (err error) {
-	key := make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
-	copy(key, ChildStorageKeyPrefix)
-	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
+	key := ChildStorageKey(keyToChild)
+
+	pendingDeltas := tracking.New()
+	childHash := common.BytesToHash(t.Get(key))
+	child := t.childTries[childHash]
+	if child != nil {
+		if child.deltas != nil {
+			pendingDeltas.MergeWith(child.deltas)
+		}
+		err = child.registerDeletedNodeHashes(child.root, pendingDeltas)
+		if err != nil {
+			return fmt.Errorf("registering deleted nodes of child trie located at key 0x%x: %w", keyToChild, err)
+		}
+	}
 
 	err = t.Delete(key)
 	if err != nil {
 		return fmt.Errorf("deleting child trie located at key 0x%x: %w", keyToChild, err)
 	}
+
+	if child != nil {
+		delete(t.childTries, childHash)
+		const success = true
+		t.handleTrackedDeltas(success, pendingDeltas)
+	}
 	return nil
 }
 
 // ClearFromChild removes the child storage entry
 func (t *Trie) ClearFromChild(keyToChild, key []byte) error {
-	child, err := t.GetChild(keyToChild)
-	if err != nil {
-		return err
-	}
-	if child == nil {
-		return fmt.Errorf("%w at key 0x%x%x", ErrChildTrieDoesNotExist, ChildStorageKeyPrefix, keyToChild)
-	}
-
-	err = child.Delete(key)
-	if err != nil {
-		return fmt.Errorf("deleting from child trie located at key 0x%x: %w", keyToChild, err)
-	}
-
-	return nil
+	return t.UpdateChild(keyToChild, func(child *Trie) error {
+		err := child.Delete(key)
+		if err != nil {
+			return fmt.Errorf("deleting from child trie located at key 0x%x: %w", keyToChild, err)
+		}
+		return nil
+	})
 }
@@ -71,3 +71,22 @@ func TestPutAndGetFromChild(t *testing.T) {
 		t.Fatalf("Fail: got %x expected %x", valueRes, testValue)
 	}
 }
+
+func TestChildStorageKey(t *testing.T) {
+	keyToChild := []byte("default")
+
+	childStorageKey := ChildStorageKey(keyToChild)
+	if !bytes.Equal(childStorageKey, []byte(":child_storage:default:default")) {
+		t.Fatalf("Fail: got %s", childStorageKey)
+	}
+
+	result, ok := KeyToChild(childStorageKey)
+	if !ok || !bytes.Equal(result, keyToChild) {
+		t.Fatalf("Fail: got %s and %t", result, ok)
+	}
+
+	_, ok = KeyToChild([]byte(":code"))
+	if ok {
+		t.Fatal("Fail: :code is not a child storage key")
+	}
+}
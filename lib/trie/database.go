@@ -24,11 +24,24 @@ type Putter interface {
 	Put(key []byte, value []byte) error
 }
 
+// Deleter deletes the value at the given key and returns an error.
+type Deleter interface {
+	Del(key []byte) error
+}
+
 // NewBatcher creates a new database batch.
 type NewBatcher interface {
 	NewBatch() chaindb.Batch
 }
 
+// NodeGetter gets the decoded node corresponding to the given node hash.
+// A database implementing it in addition to Getter is used to get decoded
+// nodes instead of reading and decoding their encoding, for example to
+// serve nodes from a cache. The node returned must be safe to modify.
+type NodeGetter interface {
+	GetNode(nodeHash []byte) (n *Node, err error)
+}
+
 // Load reconstructs the trie from the database from the given root hash.
 // It is used when restarting the node to load the current state trie.
 func (t *Trie) Load(db Getter, rootHash common.Hash) error {
@@ -36,21 +49,13 @@ func (t *Trie) Load(db Getter, rootHash common.Hash) error {
 		t.root = nil
 		return nil
 	}
-	rootHashBytes := rootHash.ToBytes()
-
-	encodedNode, err := db.Get(rootHashBytes)
-	if err != nil {
-		return fmt.Errorf("failed to find root key %s: %w", rootHash, err)
-	}
 
-	reader := bytes.NewReader(encodedNode)
-	root, err := node.Decode(reader)
+	root, err := loadNodeFromDB(db, rootHash.ToBytes())
 	if err != nil {
-		return fmt.Errorf("cannot decode root node: %w", err)
+		return fmt.Errorf("loading root node: %w", err)
 	}
 
 	t.root = root
-	t.root.MerkleValue = rootHashBytes
 
 	return t.loadNode(db, t.root)
 }
@@ -79,18 +84,11 @@ func (t *Trie) loadNode(db Getter, n *Node) error {
 		}
 
 		nodeHash := merkleValue
-		encodedNode, err := db.Get(nodeHash)
+		decodedNode, err := loadNodeFromDB(db, nodeHash)
 		if err != nil {
-			return fmt.Errorf("cannot find child node key 0x%x in database: %w", nodeHash, err)
+			return fmt.Errorf("loading child node: %w", err)
 		}
 
-		reader := bytes.NewReader(encodedNode)
-		decodedNode, err := node.Decode(reader)
-		if err != nil {
-			return fmt.Errorf("decoding node with hash 0x%x: %w", nodeHash, err)
-		}
-
-		decodedNode.MerkleValue = nodeHash
 		branch.Children[i] = decodedNode
 
 		err = t.loadNode(db, decodedNode)
@@ -205,15 +203,9 @@ func GetFromDB(db Getter, rootHash common.Hash, key []byte) (
 
 	k := codec.KeyLEToNibbles(key)
 
-	encodedRootNode, err := db.Get(rootHash[:])
-	if err != nil {
-		return nil, fmt.Errorf("cannot find root hash key %s: %w", rootHash, err)
-	}
-
-	reader := bytes.NewReader(encodedRootNode)
-	rootNode, err := node.Decode(reader)
+	rootNode, err := loadNodeFromDB(db, rootHash.ToBytes())
 	if err != nil {
-		return nil, fmt.Errorf("cannot decode root node: %w", err)
+		return nil, fmt.Errorf("loading root node: %w", err)
 	}
 
 	return getFromDBAtNode(db, rootNode, k)
@@ -259,29 +251,22 @@ func getFromDBAtNode(db Getter, n *Node, key []byte) (
 		return getFromDBAtNode(db, child, key[commonPrefixLength+1:])
 	}
 
-	encodedChild, err := db.Get(childMerkleValue)
+	decodedChild, err := loadNodeFromDB(db, childMerkleValue)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"finding child node with hash 0x%x in database: %w",
-			childMerkleValue, err)
-	}
-
-	reader := bytes.NewReader(encodedChild)
-	decodedChild, err := node.Decode(reader)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"decoding child node with hash 0x%x: %w",
-			childMerkleValue, err)
+		return nil, fmt.Errorf("loading child node: %w", err)
 	}
 
 	return getFromDBAtNode(db, decodedChild, key[commonPrefixLength+1:])
 	// Note: do not wrap error since it's called recursively.
 }
 
-// WriteDirty writes all dirty nodes to the database and sets them to clean
+// WriteDirty writes all dirty nodes to the database and sets them to clean.
+// Since the ancestors of a modified node are always dirty, clean subtrees
+// are skipped entirely and only the nodes modified since the last write
+// are written to the database.
 func (t *Trie) WriteDirty(db NewBatcher) error {
 	batch := db.NewBatch()
-	err := t.writeDirtyNode(batch, t.root)
+	err := t.writeDirty(batch)
 	if err != nil {
 		batch.Reset()
 		return err
@@ -290,6 +275,31 @@ func (t *Trie) WriteDirty(db NewBatcher) error {
 	return batch.Flush()
 }
 
+// WriteDirtyTo puts the dirty nodes of the trie and of its child tries
+// in the putter given, for example a database batch shared with other
+// writes, and marks them as clean.
+func (t *Trie) WriteDirtyTo(db Putter) error {
+	return t.writeDirty(db)
+}
+
+// writeDirty writes the dirty nodes of the trie and
+// of its child tries to the database.
+func (t *Trie) writeDirty(db Putter) (err error) {
+	err = t.writeDirtyNode(db, t.root)
+	if err != nil {
+		return err
+	}
+
+	for _, childTrie := range t.childTries {
+		err = childTrie.writeDirty(db)
+		if err != nil {
+			return fmt.Errorf("writing dirty node to database: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (t *Trie) writeDirtyNode(db Putter, n *Node) (err error) {
 	if n == nil || !n.Dirty {
 		return nil
@@ -341,20 +351,14 @@ func (t *Trie) writeDirtyNode(db Putter, n *Node) (err error) {
 		}
 	}
 
-	for _, childTrie := range t.childTries {
-		if err := childTrie.writeDirtyNode(db, childTrie.root); err != nil {
-			return fmt.Errorf("writing dirty node to database: %w", err)
-		}
-	}
-
 	n.SetClean()
 
 	return nil
 }
 
 // GetChangedNodeHashes returns the two sets of hashes for all nodes
-// inserted and deleted in the state trie since the last snapshot.
-// Returned inserted map is safe for mutation, but deleted is not safe for mutation.
+// inserted and deleted in the state trie and in its child tries since
+// the last snapshot. Both returned maps are safe for mutation.
 func (t *Trie) GetChangedNodeHashes() (inserted, deleted map[common.Hash]struct{}, err error) {
 	inserted = make(map[common.Hash]struct{})
 	err = t.getInsertedNodeHashesAtNode(t.root, inserted)
@@ -362,11 +366,42 @@ func (t *Trie) GetChangedNodeHashes() (inserted, deleted map[common.Hash]struct{
 		return nil, nil, fmt.Errorf("getting inserted node hashes: %w", err)
 	}
 
-	deleted = t.deltas.Deleted()
+	for _, childTrie := range t.childTries {
+		err = childTrie.getInsertedNodeHashesAtNode(childTrie.root, inserted)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting inserted node hashes of child trie: %w", err)
+		}
+	}
+
+	deleted = t.DeletedNodeHashes()
 
 	return inserted, deleted, nil
 }
 
+// DeletedNodeHashes returns a copy of the set of hashes of the nodes replaced
+// or removed by the trie mutations done since the last snapshot, including the
+// nodes of its child tries and of its deleted child tries. These nodes were
+// present in the trie at the last snapshot and are no longer referenced
+// by the current trie. Inlined nodes are not tracked since they are not stored
+// in the database.
+func (t *Trie) DeletedNodeHashes() (nodeHashes map[common.Hash]struct{}) {
+	deleted := t.deltas.Deleted()
+	nodeHashes = make(map[common.Hash]struct{}, len(deleted))
+	for nodeHash := range deleted {
+		nodeHashes[nodeHash] = struct{}{}
+	}
+
+	for _, childTrie := range t.childTries {
+		if childTrie.deltas == nil {
+			continue
+		}
+		for nodeHash := range childTrie.deltas.Deleted() {
+			nodeHashes[nodeHash] = struct{}{}
+		}
+	}
+	return nodeHashes
+}
+
 func (t *Trie) getInsertedNodeHashesAtNode(n *Node, nodeHashes map[common.Hash]struct{}) (err error) {
 	if n == nil || !n.Dirty {
 		return nil
@@ -12,7 +12,7 @@
 	"github.com/stretchr/testify/require"
 )
 
-func newTestDB(t *testing.T) chaindb.Database {
+func newTestDB(t testing.TB) chaindb.Database {
 	chainDBConfig := &chaindb.Config{
 		InMemory: true,
 	}
@@ -159,6 +159,121 @@ func Test_Trie_WriteDirty_ClearPrefix(t *testing.T) {
 	assert.Equal(t, trie.String(), trieFromDB.String())
 }
 
+// countingBatcher counts the database writes done
+// through the batches it creates.
+type countingBatcher struct {
+	NewBatcher
+	puts int
+}
+
+func (c *countingBatcher) NewBatch() chaindb.Batch {
+	return &countingBatch{
+		Batch: c.NewBatcher.NewBatch(),
+		puts:  &c.puts,
+	}
+}
+
+type countingBatch struct {
+	chaindb.Batch
+	puts *int
+}
+
+func (c *countingBatch) Put(key, value []byte) error {
+	*c.puts++
+	return c.Batch.Put(key, value)
+}
+
+func Test_Trie_WriteDirty_Incremental(t *testing.T) {
+	t.Parallel()
+
+	const size = 1000
+	trie, keyValues := makeSeededTrie(t, size)
+
+	db := newTestDB(t)
+	fullDB := &countingBatcher{NewBatcher: db}
+	err := trie.WriteDirty(fullDB)
+	require.NoError(t, err)
+
+	trie = trie.Snapshot()
+	generator := newGenerator()
+	for _, key := range pickKeys(keyValues, generator, 10) {
+		keyValues[string(key)] = []byte("new value")
+		err = trie.Put(key, keyValues[string(key)])
+		require.NoError(t, err)
+	}
+
+	insertedNodeHashes, _, err := trie.GetChangedNodeHashes()
+	require.NoError(t, err)
+
+	incrementalDB := &countingBatcher{NewBatcher: db}
+	err = trie.WriteDirty(incrementalDB)
+	require.NoError(t, err)
+	assert.Equal(t, len(insertedNodeHashes), incrementalDB.puts)
+	assert.Less(t, incrementalDB.puts, fullDB.puts)
+
+	// Nothing is written if the trie is not modified.
+	noChangeDB := &countingBatcher{NewBatcher: db}
+	err = trie.WriteDirty(noChangeDB)
+	require.NoError(t, err)
+	assert.Zero(t, noChangeDB.puts)
+
+	// All the nodes reachable from the new root are in the database.
+	trieFromDB := NewEmptyTrie()
+	err = trieFromDB.Load(db, trie.MustHash())
+	require.NoError(t, err)
+	assert.Equal(t, keyValues, trieFromDB.Entries())
+}
+
+func Benchmark_Trie_WriteDirty(b *testing.B) {
+	generator := newGenerator()
+	const size = 1000000
+	const keySize, valueSize = 32, 32
+	keyValues := make(map[string][]byte, size)
+	for i := 0; i < size; i++ {
+		populateKeyValueMap(b, keyValues, generator, keySize, valueSize)
+	}
+
+	trie := NewEmptyTrie()
+	for keyString, value := range keyValues {
+		err := trie.Put([]byte(keyString), value)
+		require.NoError(b, err)
+	}
+	const mutatedKeysCount = 100
+	mutatedKeys := pickKeys(keyValues, generator, mutatedKeysCount)
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			// all nodes of the deep copy are dirty
+			trieCopy := trie.DeepCopy()
+			db := newTestDB(b)
+			b.StartTimer()
+
+			err := trieCopy.WriteDirty(db)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("incremental", func(b *testing.B) {
+		db := newTestDB(b)
+		err := trie.WriteDirty(db)
+		require.NoError(b, err)
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			trie = trie.Snapshot()
+			for _, key := range mutatedKeys {
+				err = trie.Put(key, []byte{byte(i)})
+				require.NoError(b, err)
+			}
+			b.StartTimer()
+
+			err = trie.WriteDirty(db)
+			require.NoError(b, err)
+		}
+	})
+}
+
 func Test_PopulateNodeHashes(t *testing.T) {
 	t.Parallel()
 
@@ -316,3 +431,53 @@ func Test_Trie_PutChild_Store_Load(t *testing.T) {
 		assert.Equal(t, trie.String(), trieFromDB.String())
 	}
 }
+
+func Test_Trie_DeletedNodeHashes(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, keyValues := makeSeededTrie(t, size)
+	generator := newGenerator()
+	key := pickKeys(keyValues, generator, 1)[0]
+
+	// Nodes are only tracked as deleted once written to the database.
+	previousTrie := trie.Snapshot()
+	err := previousTrie.WriteDirty(newTestDB(t))
+	require.NoError(t, err)
+	previousNodeHashes := make(map[common.Hash]struct{})
+	PopulateNodeHashes(previousTrie.root, previousNodeHashes)
+
+	trie = previousTrie.Snapshot()
+
+	// Re-inserting an identical value does not delete any node.
+	err = trie.Put(key, keyValues[string(key)])
+	require.NoError(t, err)
+	assert.Empty(t, trie.DeletedNodeHashes())
+
+	err = trie.Put(key, []byte("new value"))
+	require.NoError(t, err)
+	deleted := trie.DeletedNodeHashes()
+	require.NotEmpty(t, deleted)
+	for nodeHash := range deleted {
+		assert.Contains(t, previousNodeHashes, nodeHash)
+	}
+
+	_, err = trie.Hash()
+	require.NoError(t, err)
+	currentNodeHashes := make(map[common.Hash]struct{})
+	PopulateNodeHashes(trie.root, currentNodeHashes)
+	for nodeHash := range deleted {
+		assert.NotContains(t, currentNodeHashes, nodeHash)
+	}
+
+	// The returned set is a copy safe for mutation.
+	for nodeHash := range deleted {
+		delete(deleted, nodeHash)
+	}
+	assert.NotEmpty(t, trie.DeletedNodeHashes())
+
+	// Deletions are tracked independently for each snapshot.
+	snapshot := trie.Snapshot()
+	assert.Empty(t, snapshot.DeletedNodeHashes())
+	assert.NotEmpty(t, trie.DeletedNodeHashes())
+}
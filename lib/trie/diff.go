@@ -0,0 +1,224 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/internal/trie/codec"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// KeyValue is a key value pair where the key is in little Endian format.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// TrieDiff returns the key value pairs added, changed and removed
+// between the trie stored in the database at oldRoot and the trie stored
+// in the database at newRoot. Changed key value pairs contain the value
+// from the new trie. Each returned slice is sorted by key in
+// lexicographic order.
+// Subtrees found at the same position in both tries with an equal
+// node hash are skipped, so only nodes differing between the two tries
+// are read from the database.
+func TrieDiff(db Getter, oldRoot, newRoot common.Hash) (
+	added, changed, removed []KeyValue, err error) {
+	if oldRoot == newRoot {
+		return nil, nil, nil, nil
+	}
+
+	oldCursor, err := newDiffCursorFromRoot(db, oldRoot)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("loading old root node: %w", err)
+	}
+
+	newCursor, err := newDiffCursorFromRoot(db, newRoot)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("loading new root node: %w", err)
+	}
+
+	d := &differ{
+		oldDB: db,
+		newDB: db,
+		handleDifference: func(keyLE, oldValue, newValue []byte) (stop bool) {
+			switch {
+			case oldValue == nil:
+				added = append(added, KeyValue{Key: keyLE, Value: newValue})
+			case newValue == nil:
+				removed = append(removed, KeyValue{Key: keyLE, Value: oldValue})
+			default:
+				changed = append(changed, KeyValue{Key: keyLE, Value: newValue})
+			}
+			return false
+		},
+	}
+	err = d.diff(oldCursor, newCursor, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return added, changed, removed, nil
+}
+
+// diffCursor points to a nibble position within the partial key
+// of a node. A cursor with a non-zero offset is treated as a branch
+// without storage value and with the single child at the nibble
+// PartialKey[offset], such that two tries with different node
+// layouts can be walked one nibble at a time.
+// A cursor with a nil node points to no node.
+type diffCursor struct {
+	node   *Node
+	offset int
+}
+
+func newDiffCursorFromRoot(db Getter, rootHash common.Hash) (
+	cursor diffCursor, err error) {
+	if rootHash == EmptyHash {
+		return cursor, nil
+	}
+
+	cursor.node, err = loadNodeFromDB(db, rootHash.ToBytes())
+	return cursor, err
+}
+
+// loadNodeFromDB reads and decodes the node with the given
+// node hash from the database, and sets its Merkle value.
+// If the database implements NodeGetter, the decoded node is
+// obtained from it instead.
+func loadNodeFromDB(db Getter, nodeHash []byte) (n *Node, err error) {
+	if nodeGetter, ok := db.(NodeGetter); ok {
+		n, err = nodeGetter.GetNode(nodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("getting node with hash 0x%x: %w", nodeHash, err)
+		}
+		n.MerkleValue = nodeHash
+		return n, nil
+	}
+
+	encodedNode, err := db.Get(nodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting node with hash 0x%x from database: %w",
+			nodeHash, err)
+	}
+
+	n, err = node.Decode(bytes.NewReader(encodedNode))
+	if err != nil {
+		return nil, fmt.Errorf("decoding node with hash 0x%x: %w", nodeHash, err)
+	}
+	n.MerkleValue = nodeHash
+
+	return n, nil
+}
+
+// storageValue returns the storage value at the cursor position,
+// or nil if there is no storage value at this position.
+func (c diffCursor) storageValue() (value []byte) {
+	if c.node == nil || c.offset < len(c.node.PartialKey) {
+		return nil
+	}
+	return c.node.StorageValue
+}
+
+// child returns the cursor for the child at the given nibble index,
+// or a cursor pointing to no node if there is no child at this index.
+// Child nodes only stored as a hash pointer are not loaded, such that
+// identical subtrees can be skipped without reading them.
+func (c diffCursor) child(index byte) (child diffCursor) {
+	if c.node == nil {
+		return child
+	}
+
+	if c.offset < len(c.node.PartialKey) {
+		if c.node.PartialKey[c.offset] != index {
+			return child
+		}
+		return diffCursor{node: c.node, offset: c.offset + 1}
+	}
+
+	if c.node.Kind() == node.Leaf {
+		return child
+	}
+
+	return diffCursor{node: c.node.Children[index]}
+}
+
+// load returns the cursor with its node loaded from the
+// database if the node is only a hash pointer.
+func (c diffCursor) load(db Getter) (loaded diffCursor, err error) {
+	if c.node == nil || !isHashPointerStub(c.node) {
+		return c, nil
+	}
+
+	loaded.node, err = loadNodeFromDB(db, c.node.MerkleValue)
+	return loaded, err
+}
+
+// sameSubtree returns true if both cursors point to the
+// start of nodes with the same non empty Merkle value.
+func sameSubtree(a, b diffCursor) bool {
+	return a.node != nil && b.node != nil &&
+		a.offset == 0 && b.offset == 0 &&
+		len(a.node.MerkleValue) > 0 &&
+		bytes.Equal(a.node.MerkleValue, b.node.MerkleValue)
+}
+
+type differ struct {
+	oldDB Getter
+	newDB Getter
+	// handleDifference is called for each key having a different
+	// value in the old and new tries, in lexicographic order of keys.
+	// A nil value means the key is not in the trie. It returns true
+	// to stop walking the tries.
+	handleDifference func(keyLE, oldValue, newValue []byte) (stop bool)
+	stopped          bool
+}
+
+// diff recursively compares the subtrees at the old and new
+// cursors, both located at the nibbles key given.
+func (d *differ) diff(oldCursor, newCursor diffCursor, key []byte) (err error) {
+	if oldCursor.node == nil && newCursor.node == nil {
+		return nil
+	}
+
+	if sameSubtree(oldCursor, newCursor) {
+		return nil
+	}
+
+	oldCursor, err = oldCursor.load(d.oldDB)
+	if err != nil {
+		return fmt.Errorf("loading node of old trie: %w", err)
+	}
+
+	newCursor, err = newCursor.load(d.newDB)
+	if err != nil {
+		return fmt.Errorf("loading node of new trie: %w", err)
+	}
+
+	oldValue := oldCursor.storageValue()
+	newValue := newCursor.storageValue()
+	presenceDiffers := (oldValue == nil) != (newValue == nil)
+	if presenceDiffers || !bytes.Equal(oldValue, newValue) {
+		d.stopped = d.handleDifference(codec.NibblesToKeyLE(key), oldValue, newValue)
+		if d.stopped {
+			return nil
+		}
+	}
+
+	for i := byte(0); i < node.ChildrenCapacity; i++ {
+		childKey := concatenateSlices(key, []byte{i})
+		err = d.diff(oldCursor.child(i), newCursor.child(i), childKey)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return err
+		} else if d.stopped {
+			return nil
+		}
+	}
+
+	return nil
+}
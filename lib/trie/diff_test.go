@@ -0,0 +1,167 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TrieDiff(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		oldEntries map[string][]byte
+		newEntries map[string][]byte
+		added      []KeyValue
+		changed    []KeyValue
+		removed    []KeyValue
+	}{
+		"both_empty": {},
+		"old_empty": {
+			newEntries: map[string][]byte{
+				"\x01\x02": {1},
+				"\x01\x03": {2},
+			},
+			added: []KeyValue{
+				{Key: []byte{1, 2}, Value: []byte{1}},
+				{Key: []byte{1, 3}, Value: []byte{2}},
+			},
+		},
+		"new_empty": {
+			oldEntries: map[string][]byte{
+				"\x01\x02": {1},
+				"\x01\x03": {2},
+			},
+			removed: []KeyValue{
+				{Key: []byte{1, 2}, Value: []byte{1}},
+				{Key: []byte{1, 3}, Value: []byte{2}},
+			},
+		},
+		"identical": {
+			oldEntries: map[string][]byte{
+				"\x01\x02": {1},
+				"\x01\x03": {2},
+			},
+			newEntries: map[string][]byte{
+				"\x01\x02": {1},
+				"\x01\x03": {2},
+			},
+		},
+		"overlapping_keys": {
+			oldEntries: map[string][]byte{
+				"\x01":         {1},
+				"\x01\x02":     {2},
+				"\x01\x02\x03": {3},
+				"\x05\x06":     {4},
+			},
+			newEntries: map[string][]byte{
+				"\x01":         {1},
+				"\x01\x02":     {9},
+				"\x01\x02\x04": {5},
+				"\x05\x06":     {4},
+			},
+			added: []KeyValue{
+				{Key: []byte{1, 2, 4}, Value: []byte{5}},
+			},
+			changed: []KeyValue{
+				{Key: []byte{1, 2}, Value: []byte{9}},
+			},
+			removed: []KeyValue{
+				{Key: []byte{1, 2, 3}, Value: []byte{3}},
+			},
+		},
+		"disjoint_keys": {
+			oldEntries: map[string][]byte{
+				"\x01\x02": {1},
+				"\x03\x04": {2},
+			},
+			newEntries: map[string][]byte{
+				"\x01\x03": {3},
+				"\xf0":     {4},
+			},
+			added: []KeyValue{
+				{Key: []byte{1, 3}, Value: []byte{3}},
+				{Key: []byte{0xf0}, Value: []byte{4}},
+			},
+			removed: []KeyValue{
+				{Key: []byte{1, 2}, Value: []byte{1}},
+				{Key: []byte{3, 4}, Value: []byte{2}},
+			},
+		},
+		"different_node_layouts": {
+			oldEntries: map[string][]byte{
+				"\xab\xcd\x01": {1},
+				"\xab\xcd\x02": {2},
+			},
+			newEntries: map[string][]byte{
+				"\xab\xcd\x01": {1},
+				"\xab\xcd\x02": {2},
+				"\xab\x00":     {3},
+			},
+			added: []KeyValue{
+				{Key: []byte{0xab, 0x00}, Value: []byte{3}},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newTestDB(t)
+			oldRoot := writeTrieEntries(t, db, testCase.oldEntries)
+			newRoot := writeTrieEntries(t, db, testCase.newEntries)
+
+			added, changed, removed, err := TrieDiff(db, oldRoot, newRoot)
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.added, added)
+			assert.Equal(t, testCase.changed, changed)
+			assert.Equal(t, testCase.removed, removed)
+		})
+	}
+}
+
+func Test_TrieDiff_seeded(t *testing.T) {
+	t.Parallel()
+
+	const size = 1000
+	oldTrie, keyValues := makeSeededTrie(t, size)
+
+	db := newTestDB(t)
+	err := oldTrie.WriteDirty(db)
+	require.NoError(t, err)
+	oldRoot := oldTrie.MustHash()
+
+	newTrie := oldTrie.Snapshot()
+	generator := newGenerator()
+	const keysToDelete = 10
+	removedKeys := make(map[string]struct{}, keysToDelete)
+	for _, key := range pickKeys(keyValues, generator, keysToDelete) {
+		err = newTrie.Delete(key)
+		require.NoError(t, err)
+		removedKeys[string(key)] = struct{}{}
+	}
+	err = newTrie.Put([]byte{0xff, 0xff, 0xff}, []byte{1})
+	require.NoError(t, err)
+
+	err = newTrie.WriteDirty(db)
+	require.NoError(t, err)
+	newRoot := newTrie.MustHash()
+
+	added, changed, removed, err := TrieDiff(db, oldRoot, newRoot)
+	require.NoError(t, err)
+
+	assert.Equal(t, []KeyValue{{Key: []byte{0xff, 0xff, 0xff}, Value: []byte{1}}}, added)
+	assert.Empty(t, changed)
+	require.Len(t, removed, len(removedKeys))
+	for _, keyValue := range removed {
+		assert.Contains(t, removedKeys, string(keyValue.Key))
+		assert.Equal(t, keyValues[string(keyValue.Key)], keyValue.Value)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// subtreesPerWorker is the minimum number of subtrees to collect
+// per hashing worker, in order to balance the work between workers
+// since subtrees can have very different sizes.
+const subtreesPerWorker = 4
+
+// HashParallel returns the hashed root of the trie, hashing independent
+// subtrees concurrently using a pool of parallelism goroutines, each of them
+// hashing its subtrees sequentially. A parallelism of one hashes the trie
+// sequentially in the calling goroutine, and a parallelism of zero or
+// negative defaults to GOMAXPROCS.
+// The root hash returned is identical to the root hash returned by Hash,
+// and is meant to speed up hashing tries with many modified nodes,
+// for example after committing a block with thousands of storage changes.
+func (t *Trie) HashParallel(parallelism int) (rootHash common.Hash, err error) {
+	if t.root == nil {
+		return EmptyHash, nil
+	}
+
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+
+	const isRoot = true
+	if parallelism == 1 {
+		merkleValue, err := calculateMerkleValueWithHashedSubtrees(t.root, nil, isRoot)
+		if err != nil {
+			return rootHash, err
+		}
+		copy(rootHash[:], merkleValue)
+		return rootHash, nil
+	}
+
+	subtrees := collectSubtreesToHash(t.root, parallelism*subtreesPerWorker)
+	err = hashSubtrees(subtrees, parallelism)
+	if err != nil {
+		return rootHash, fmt.Errorf("hashing subtrees: %w", err)
+	}
+
+	hashedSubtrees := make(map[*Node]struct{}, len(subtrees))
+	for _, subtree := range subtrees {
+		hashedSubtrees[subtree] = struct{}{}
+	}
+
+	merkleValue, err := calculateMerkleValueWithHashedSubtrees(
+		t.root, hashedSubtrees, isRoot)
+	if err != nil {
+		return rootHash, err
+	}
+
+	copy(rootHash[:], merkleValue)
+	return rootHash, nil
+}
+
+// needsHashing returns true if the node has no valid cached Merkle value.
+func needsHashing(n *Node) bool {
+	return n.Dirty || n.MerkleValue == nil
+}
+
+// collectSubtreesToHash walks down the trie levels from the children
+// of the root node, and returns the branches needing hashing at the first
+// level having at least minimum of such branches, or at the deepest level.
+func collectSubtreesToHash(root *Node, minimum int) (subtrees []*Node) {
+	level := []*Node{root}
+	for {
+		var nextLevel []*Node
+		for _, parent := range level {
+			if parent.Kind() != node.Branch {
+				continue
+			}
+
+			for _, child := range parent.Children {
+				if child == nil || child.Kind() != node.Branch || !needsHashing(child) {
+					continue
+				}
+				nextLevel = append(nextLevel, child)
+			}
+		}
+
+		if len(nextLevel) == 0 {
+			if level[0] == root {
+				return nil
+			}
+			return level
+		} else if len(nextLevel) >= minimum {
+			return nextLevel
+		}
+		level = nextLevel
+	}
+}
+
+// hashSubtrees calculates the Merkle values of the subtree nodes given
+// using a pool of parallelism goroutines, each subtree being hashed
+// sequentially such that no more than parallelism goroutines hash nodes.
+func hashSubtrees(subtrees []*Node, parallelism int) (err error) {
+	subtreesCh := make(chan *Node)
+	errorsCh := make(chan error, parallelism)
+	var wg sync.WaitGroup
+
+	wg.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			defer wg.Done()
+			for subtree := range subtreesCh {
+				const isRoot = false
+				_, err := calculateMerkleValueWithHashedSubtrees(subtree, nil, isRoot)
+				if err != nil {
+					errorsCh <- fmt.Errorf("calculating Merkle value of subtree "+
+						"with partial key 0x%x: %w", subtree.PartialKey, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for _, subtree := range subtrees {
+		select {
+		case subtreesCh <- subtree:
+		case err = <-errorsCh:
+		}
+		if err != nil {
+			break
+		}
+	}
+	close(subtreesCh)
+	wg.Wait()
+	close(errorsCh)
+
+	if err != nil {
+		return err
+	}
+	return <-errorsCh // nil if the channel is empty
+}
+
+// calculateMerkleValueWithHashedSubtrees calculates the Merkle value
+// of the node given, using the Merkle values already calculated for
+// the nodes of the hashed subtrees set. The Merkle value is set on each
+// node between the node given and the hashed subtrees, as done by
+// the node CalculateMerkleValue method. Unlike this method, the nodes
+// are hashed sequentially in the calling goroutine.
+func calculateMerkleValueWithHashedSubtrees(n *Node,
+	hashedSubtrees map[*Node]struct{}, isRoot bool) (
+	merkleValue []byte, err error) {
+	_, hashed := hashedSubtrees[n]
+	if hashed {
+		return n.MerkleValue, nil
+	}
+
+	if n.Kind() == node.Leaf || !needsHashing(n) {
+		if isRoot {
+			return n.CalculateRootMerkleValue()
+		}
+		return n.CalculateMerkleValue()
+	}
+
+	// stubbedBranch is a shallow copy of the branch where each child
+	// is replaced by a clean node containing only its Merkle value,
+	// such that encoding it does not encode its children again.
+	stubbedBranch := n.Copy(node.CopySettings{})
+	stubbedBranch.PartialKey = n.PartialKey
+	stubbedBranch.StorageValue = n.StorageValue
+	for i, child := range n.Children {
+		if child == nil {
+			continue
+		}
+
+		const childIsRoot = false
+		childMerkleValue, err := calculateMerkleValueWithHashedSubtrees(
+			child, hashedSubtrees, childIsRoot)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return nil, err
+		}
+		stubbedBranch.Children[i] = &Node{MerkleValue: childMerkleValue}
+	}
+
+	if isRoot {
+		_, merkleValue, err = stubbedBranch.EncodeAndHashRoot()
+	} else {
+		_, merkleValue, err = stubbedBranch.EncodeAndHash()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding and hashing branch with partial key 0x%x: %w",
+			n.PartialKey, err)
+	}
+	n.MerkleValue = merkleValue
+
+	return merkleValue, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_HashParallel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty_trie", func(t *testing.T) {
+		t.Parallel()
+
+		rootHash, err := NewEmptyTrie().HashParallel(0)
+
+		require.NoError(t, err)
+		assert.Equal(t, EmptyHash, rootHash)
+	})
+
+	t.Run("leaf_root", func(t *testing.T) {
+		t.Parallel()
+
+		trie := NewEmptyTrie()
+		trie.Put([]byte{1}, []byte{2})
+		expectedRootHash := trie.DeepCopy().MustHash()
+
+		rootHash, err := trie.HashParallel(0)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedRootHash, rootHash)
+	})
+
+	for _, parallelism := range []int{0, 1, 2, 3, 16} {
+		parallelism := parallelism
+		t.Run(fmt.Sprintf("parallelism_%d", parallelism), func(t *testing.T) {
+			t.Parallel()
+
+			const size = 5000
+			trie, _ := makeSeededTrie(t, size)
+			serialTrie := trie.DeepCopy()
+			expectedRootHash := serialTrie.MustHash()
+
+			rootHash, err := trie.HashParallel(parallelism)
+
+			require.NoError(t, err)
+			assert.Equal(t, expectedRootHash, rootHash)
+			// Merkle values cached on nodes must be the same.
+			assert.Equal(t, serialTrie.String(), trie.String())
+		})
+	}
+
+	t.Run("partially_clean_trie", func(t *testing.T) {
+		t.Parallel()
+
+		const size = 5000
+		trie, keyValues := makeSeededTrie(t, size)
+		db := newTestDB(t)
+		err := trie.WriteDirty(db)
+		require.NoError(t, err)
+
+		generator := newGenerator()
+		for _, key := range pickKeys(keyValues, generator, 100) {
+			err = trie.Put(key, []byte{1, 2, 3})
+			require.NoError(t, err)
+		}
+		expectedRootHash := trie.DeepCopy().MustHash()
+
+		rootHash, err := trie.HashParallel(0)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedRootHash, rootHash)
+	})
+}
+
+func Benchmark_Trie_HashParallel(b *testing.B) {
+	generator := newGenerator()
+	const kvSize = 100000
+	kv := generateKeyValues(b, generator, kvSize)
+
+	trie := NewEmptyTrie()
+	for keyString, value := range kv {
+		err := trie.Put([]byte(keyString), value)
+		require.NoError(b, err)
+	}
+
+	// All the nodes are dirty so every iteration hashes the full trie.
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := trie.HashParallel(1)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := trie.HashParallel(0)
+			require.NoError(b, err)
+		}
+	})
+}
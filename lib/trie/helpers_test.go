@@ -8,6 +8,7 @@
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/chaindb"
 	"github.com/ChainSafe/gossamer/internal/trie/node"
 	"github.com/ChainSafe/gossamer/internal/trie/tracking"
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -143,3 +144,21 @@ func newDeltas(deletedNodeHashesHex ...string) (deltas *tracking.Deltas) {
 	}
 	return deltas
 }
+
+// writeTrieEntries creates a trie from the entries given, writes it
+// to the database and returns its root hash.
+func writeTrieEntries(t *testing.T, db chaindb.Database,
+	entries map[string][]byte) (rootHash common.Hash) {
+	t.Helper()
+
+	trie := NewEmptyTrie()
+	for keyString, value := range entries {
+		err := trie.Put([]byte(keyString), value)
+		require.NoError(t, err)
+	}
+
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+
+	return trie.MustHash()
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/internal/trie/codec"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// LazyTrie is a read only trie loading its nodes from the database
+// only when they are traversed. Loaded nodes are cached in the trie,
+// such that looking up a single key only reads the nodes on the path
+// to this key from the database.
+// It is safe for concurrent use.
+type LazyTrie struct {
+	db       Getter
+	rootHash common.Hash
+	// mutex protects root and the children of its descendant
+	// nodes, which are replaced by their decoded node once loaded.
+	mutex sync.Mutex
+	root  *Node
+}
+
+// NewLazyTrie creates a lazy trie for the given root hash,
+// reading the root node from the database given.
+func NewLazyTrie(db Getter, rootHash common.Hash) (trie *LazyTrie, err error) {
+	trie = &LazyTrie{
+		db:       db,
+		rootHash: rootHash,
+	}
+
+	if rootHash == EmptyHash {
+		return trie, nil
+	}
+
+	trie.root, err = loadNodeFromDB(db, rootHash.ToBytes())
+	if err != nil {
+		return nil, fmt.Errorf("loading root node: %w", err)
+	}
+
+	return trie, nil
+}
+
+// Hash returns the root hash of the trie.
+func (t *LazyTrie) Hash() (rootHash common.Hash) {
+	return t.rootHash
+}
+
+// Get returns the value in the node of the trie
+// which matches its key with the key given.
+// Note the key argument is given in little Endian format.
+// Nodes not yet loaded on the path to the key are read
+// from the database and cached.
+func (t *LazyTrie) Get(keyLE []byte) (value []byte, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.root == nil {
+		return nil, nil
+	}
+
+	keyNibbles := codec.KeyLEToNibbles(keyLE)
+	return t.retrieve(t.root, keyNibbles)
+}
+
+func (t *LazyTrie) retrieve(parent *Node, key []byte) (value []byte, err error) {
+	if parent.Kind() == node.Leaf {
+		return retrieveFromLeaf(parent, key), nil
+	}
+
+	branch := parent
+	if len(key) == 0 || bytes.Equal(branch.PartialKey, key) {
+		return branch.StorageValue, nil
+	}
+
+	if len(branch.PartialKey) > len(key) && bytes.HasPrefix(branch.PartialKey, key) {
+		return nil, nil
+	}
+
+	commonPrefixLength := lenCommonPrefix(branch.PartialKey, key)
+	if commonPrefixLength < len(branch.PartialKey) {
+		return nil, nil
+	}
+
+	childIndex := key[commonPrefixLength]
+	child, err := t.loadChild(branch, childIndex)
+	if err != nil {
+		return nil, err
+	} else if child == nil {
+		return nil, nil
+	}
+
+	childKey := key[commonPrefixLength+1:]
+	// Note: do not wrap error since this is called recursively.
+	return t.retrieve(child, childKey)
+}
+
+// loadChild returns the child of the branch at the given index,
+// loading it from the database if only its node hash is known.
+// The loaded child is cached in the branch children.
+func (t *LazyTrie) loadChild(branch *Node, childIndex byte) (child *Node, err error) {
+	child = branch.Children[childIndex]
+	if child == nil || !isHashPointerStub(child) {
+		return child, nil
+	}
+
+	child, err = loadNodeFromDB(t.db, child.MerkleValue)
+	if err != nil {
+		return nil, fmt.Errorf("loading child at index %d: %w", childIndex, err)
+	}
+	branch.Children[childIndex] = child
+
+	return child, nil
+}
+
+// isHashPointerStub returns true if the node is a decoded child
+// only containing the node hash of the child node.
+func isHashPointerStub(n *Node) bool {
+	const hashLength = 32
+	return len(n.MerkleValue) == hashLength &&
+		n.PartialKey == nil && n.StorageValue == nil && n.Children == nil
+}
+
+// Materialize loads the entire trie from the database and returns
+// it as a regular trie which can be mutated.
+func (t *LazyTrie) Materialize() (trie *Trie, err error) {
+	trie = NewEmptyTrie()
+	err = trie.Load(t.db, t.rootHash)
+	if err != nil {
+		return nil, fmt.Errorf("loading trie: %w", err)
+	}
+	return trie, nil
+}
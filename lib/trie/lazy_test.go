@@ -0,0 +1,125 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/internal/trie/codec"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingGetter counts the number of database reads.
+type countingGetter struct {
+	getter Getter
+	reads  int
+}
+
+func (c *countingGetter) Get(key []byte) (value []byte, err error) {
+	c.reads++
+	return c.getter.Get(key)
+}
+
+// countStoredNodesOnPath returns the number of nodes stored in the
+// database, that is non-inlined nodes, on the path to the given key.
+func countStoredNodesOnPath(parent *Node, key []byte) (count int) {
+	for parent != nil {
+		if len(parent.MerkleValue) >= 32 {
+			count++
+		}
+
+		if parent.Kind() == node.Leaf ||
+			len(key) <= len(parent.PartialKey) {
+			return count
+		}
+
+		childIndex := key[len(parent.PartialKey)]
+		key = key[len(parent.PartialKey)+1:]
+		parent = parent.Children[childIndex]
+	}
+	return count
+}
+
+func Test_LazyTrie_Get(t *testing.T) {
+	t.Parallel()
+
+	const size = 1000
+	trie, keyValues := makeSeededTrie(t, size)
+
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	rootHash := trie.MustHash()
+
+	counter := &countingGetter{getter: db}
+	lazyTrie, err := NewLazyTrie(counter, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.reads)
+	assert.Equal(t, rootHash, lazyTrie.Hash())
+
+	generator := newGenerator()
+	key := pickKeys(keyValues, generator, 1)[0]
+
+	value, err := lazyTrie.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, keyValues[string(key)], value)
+
+	expectedReads := countStoredNodesOnPath(trie.root, codec.KeyLEToNibbles(key))
+	assert.Equal(t, expectedReads, counter.reads)
+
+	// Nodes on the path are cached so no further database read is done.
+	value, err = lazyTrie.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, keyValues[string(key)], value)
+	assert.Equal(t, expectedReads, counter.reads)
+
+	for keyString, expectedValue := range keyValues {
+		value, err := lazyTrie.Get([]byte(keyString))
+		require.NoError(t, err)
+		assert.Equal(t, expectedValue, value)
+	}
+
+	value, err = lazyTrie.Get([]byte{0xff, 0xff, 0xff, 0xff, 0xff})
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func Test_LazyTrie_emptyRoot(t *testing.T) {
+	t.Parallel()
+
+	counter := &countingGetter{getter: newTestDB(t)}
+	lazyTrie, err := NewLazyTrie(counter, EmptyHash)
+	require.NoError(t, err)
+
+	value, err := lazyTrie.Get([]byte{1})
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	assert.Zero(t, counter.reads)
+}
+
+func Test_LazyTrie_Materialize(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, _ := makeSeededTrie(t, size)
+
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	rootHash := trie.MustHash()
+
+	lazyTrie, err := NewLazyTrie(db, rootHash)
+	require.NoError(t, err)
+
+	materialized, err := lazyTrie.Materialize()
+	require.NoError(t, err)
+	assert.Equal(t, trie.String(), materialized.String())
+
+	err = materialized.Put([]byte{1, 2, 3}, []byte{4})
+	require.NoError(t, err)
+	assert.NotEqual(t, rootHash, materialized.MustHash())
+	assert.Equal(t, rootHash, lazyTrie.Hash())
+}
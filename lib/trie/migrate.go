@@ -0,0 +1,259 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// migrationMarkerPrefix is the database key prefix for migration
+// progress markers. Each marker maps the node hash of a node in the
+// legacy encoding to the Merkle value of the node in the new encoding.
+var migrationMarkerPrefix = []byte("trie_migration")
+
+// NodeDecoder decodes a node from its encoding.
+type NodeDecoder func(encoding []byte) (n *Node, err error)
+
+// NodeEncoder encodes a node. Children of the node given
+// only have their Merkle value set, which must be used to
+// encode the children in their parent node encoding.
+type NodeEncoder func(n *Node) (encoding []byte, err error)
+
+// MigrationDatabase is the database used to migrate stored trie nodes.
+type MigrationDatabase interface {
+	Getter
+	Putter
+	Deleter
+}
+
+// MigrateStoredTrie re-encodes all the nodes of the trie stored in the database
+// at the given root hash. Each node is decoded with decodeOld and its children
+// are migrated first, so the node can then be encoded with encodeNew using the
+// new Merkle values of its children. Each new encoding is written to the
+// database at its new node hash. It returns the new root hash of the trie.
+//
+// Once a node and all its descendants are migrated, a progress marker mapping
+// its old node hash to its new Merkle value is written to the database. This
+// way a migration interrupted, for example by a crash, can be resumed by calling
+// MigrateStoredTrie again. Once the migration completes, all the markers are
+// deleted except the marker of the root node, such that the new root hash can
+// be found with MigratedRoot.
+// Note the old node encodings are not removed from the database, and child tries
+// are not migrated since their root hashes are storage values of the trie.
+func MigrateStoredTrie(db MigrationDatabase, root common.Hash,
+	decodeOld NodeDecoder, encodeNew NodeEncoder) (newRoot common.Hash, err error) {
+	if root == EmptyHash {
+		return EmptyHash, nil
+	}
+
+	m := &migrator{
+		db:        db,
+		decodeOld: decodeOld,
+		encodeNew: encodeNew,
+	}
+
+	const isRoot = true
+	newRootMerkleValue, err := m.migrateStoredNode(root.ToBytes(), isRoot)
+	if err != nil {
+		return newRoot, err
+	}
+
+	// Note the markers are deleted even if the root marker was already
+	// present, in case a previous marker deletion was interrupted.
+	err = m.deleteChildrenMarkers(root.ToBytes())
+	if err != nil {
+		return newRoot, fmt.Errorf("deleting migration markers: %w", err)
+	}
+
+	return common.NewHash(newRootMerkleValue), nil
+}
+
+// MigratedRoot returns the new root hash of the trie migrated from
+// the given old root hash. It returns an error wrapping
+// chaindb.ErrKeyNotFound if the trie migration is not complete.
+func MigratedRoot(db Getter, oldRoot common.Hash) (newRoot common.Hash, err error) {
+	if oldRoot == EmptyHash {
+		return EmptyHash, nil
+	}
+
+	newRootBytes, err := db.Get(migrationMarkerKey(oldRoot.ToBytes()))
+	if err != nil {
+		return newRoot, fmt.Errorf("getting migration marker: %w", err)
+	}
+	return common.NewHash(newRootBytes), nil
+}
+
+func migrationMarkerKey(oldNodeHash []byte) (key []byte) {
+	return concatenateSlices(migrationMarkerPrefix, oldNodeHash)
+}
+
+type migrator struct {
+	db        MigrationDatabase
+	decodeOld NodeDecoder
+	encodeNew NodeEncoder
+}
+
+// migrateStoredNode migrates the node stored in the database at the given
+// old node hash, and returns its new Merkle value.
+func (m *migrator) migrateStoredNode(oldNodeHash []byte, isRoot bool) (
+	newMerkleValue []byte, err error) {
+	markerKey := migrationMarkerKey(oldNodeHash)
+	newMerkleValue, err = m.db.Get(markerKey)
+	if err == nil {
+		return newMerkleValue, nil
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, fmt.Errorf("getting migration marker for node hash 0x%x: %w",
+			oldNodeHash, err)
+	}
+
+	oldEncoding, err := m.db.Get(oldNodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting node with hash 0x%x from database: %w",
+			oldNodeHash, err)
+	}
+
+	decodedNode, err := m.decodeOld(oldEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("decoding node with hash 0x%x: %w", oldNodeHash, err)
+	}
+
+	newMerkleValue, err = m.migrateNode(decodedNode, isRoot)
+	if err != nil {
+		// Note: do not wrap error since this is called recursively.
+		return nil, err
+	}
+
+	err = m.db.Put(markerKey, newMerkleValue)
+	if err != nil {
+		return nil, fmt.Errorf("putting migration marker for node hash 0x%x: %w",
+			oldNodeHash, err)
+	}
+
+	return newMerkleValue, nil
+}
+
+// migrateNode migrates the children of the decoded node given, then
+// encodes the node with the new encoding and writes this encoding in
+// the database if the node is not inlined. It returns the new Merkle
+// value of the node.
+func (m *migrator) migrateNode(decodedNode *Node, isRoot bool) (
+	newMerkleValue []byte, err error) {
+	for i, child := range decodedNode.Children {
+		if child == nil {
+			continue
+		}
+
+		const childIsRoot = false
+		var childMerkleValue []byte
+		if isHashPointerStub(child) {
+			childMerkleValue, err = m.migrateStoredNode(child.MerkleValue, childIsRoot)
+		} else {
+			// inlined child node in the old encoding
+			childMerkleValue, err = m.migrateNode(child, childIsRoot)
+		}
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return nil, err
+		}
+
+		decodedNode.Children[i] = &Node{MerkleValue: childMerkleValue}
+	}
+
+	newEncoding, err := m.encodeNew(decodedNode)
+	if err != nil {
+		return nil, fmt.Errorf("encoding node with partial key 0x%x: %w",
+			decodedNode.PartialKey, err)
+	}
+
+	merkleValueBuffer := bytes.NewBuffer(nil)
+	if isRoot {
+		err = node.MerkleValueRoot(newEncoding, merkleValueBuffer)
+	} else {
+		err = node.MerkleValue(newEncoding, merkleValueBuffer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("computing Merkle value: %w", err)
+	}
+	newMerkleValue = merkleValueBuffer.Bytes()
+
+	if len(newMerkleValue) < 32 {
+		// Inlined node in its parent node encoding, no need to store it.
+		return newMerkleValue, nil
+	}
+
+	err = m.db.Put(newMerkleValue, newEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("putting node with hash 0x%x in database: %w",
+			newMerkleValue, err)
+	}
+
+	return newMerkleValue, nil
+}
+
+// deleteChildrenMarkers deletes the migration markers of the descendants of
+// the old node stored at the given node hash. The markers of the children of
+// a node are deleted before the marker of the node, so a node without marker
+// has no descendant with a marker and its descendants are not visited.
+func (m *migrator) deleteChildrenMarkers(oldNodeHash []byte) (err error) {
+	oldEncoding, err := m.db.Get(oldNodeHash)
+	if err != nil {
+		return fmt.Errorf("getting node with hash 0x%x from database: %w",
+			oldNodeHash, err)
+	}
+
+	decodedNode, err := m.decodeOld(oldEncoding)
+	if err != nil {
+		return fmt.Errorf("decoding node with hash 0x%x: %w", oldNodeHash, err)
+	}
+
+	return m.deleteDescendantsMarkers(decodedNode)
+}
+
+// deleteDescendantsMarkers deletes the migration markers of the stored
+// descendants of the decoded node given.
+func (m *migrator) deleteDescendantsMarkers(decodedNode *Node) (err error) {
+	for _, child := range decodedNode.Children {
+		if child == nil {
+			continue
+		}
+
+		if !isHashPointerStub(child) {
+			// inlined child node in the old encoding
+			err = m.deleteDescendantsMarkers(child)
+			if err != nil {
+				// Note: do not wrap error since this is called recursively.
+				return err
+			}
+			continue
+		}
+
+		markerKey := migrationMarkerKey(child.MerkleValue)
+		_, err = m.db.Get(markerKey)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("getting migration marker for node hash 0x%x: %w",
+				child.MerkleValue, err)
+		}
+
+		err = m.deleteChildrenMarkers(child.MerkleValue)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return err
+		}
+
+		err = m.db.Del(markerKey)
+		if err != nil {
+			return fmt.Errorf("deleting migration marker for node hash 0x%x: %w",
+				child.MerkleValue, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeLegacy encodes the node using a test only legacy encoding,
+// which is the current encoding with the storage value reversed.
+func encodeLegacy(n *Node) (encoding []byte, err error) {
+	legacyNode := n.Copy(node.DefaultCopySettings)
+	reverseBytes(legacyNode.StorageValue)
+	buffer := bytes.NewBuffer(nil)
+	err = legacyNode.Encode(buffer)
+	return buffer.Bytes(), err
+}
+
+func decodeLegacy(encoding []byte) (n *Node, err error) {
+	n, err = node.Decode(bytes.NewReader(encoding))
+	if err != nil {
+		return nil, err
+	}
+	reverseStorageValues(n)
+	return n, nil
+}
+
+// reverseStorageValues reverses the storage value of the node
+// and of its inlined descendant nodes.
+func reverseStorageValues(n *Node) {
+	reverseBytes(n.StorageValue)
+	for _, child := range n.Children {
+		if child != nil {
+			reverseStorageValues(child)
+		}
+	}
+}
+
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+func encodeCurrent(n *Node) (encoding []byte, err error) {
+	buffer := bytes.NewBuffer(nil)
+	err = n.Encode(buffer)
+	return buffer.Bytes(), err
+}
+
+func decodeCurrent(encoding []byte) (n *Node, err error) {
+	return node.Decode(bytes.NewReader(encoding))
+}
+
+// writeLegacyTrie writes the in-memory trie node given and all its
+// descendants to the database using the legacy test encoding,
+// and returns the legacy Merkle value of the node.
+func writeLegacyTrie(t *testing.T, db Putter, n *Node, isRoot bool) (merkleValue []byte) {
+	t.Helper()
+
+	stubbed := n.Copy(node.DefaultCopySettings)
+	for i, child := range n.Children {
+		if child == nil {
+			continue
+		}
+		const childIsRoot = false
+		stubbed.Children[i] = &Node{
+			MerkleValue: writeLegacyTrie(t, db, child, childIsRoot),
+		}
+	}
+
+	encoding, err := encodeLegacy(stubbed)
+	require.NoError(t, err)
+
+	buffer := bytes.NewBuffer(nil)
+	if isRoot {
+		err = node.MerkleValueRoot(encoding, buffer)
+	} else {
+		err = node.MerkleValue(encoding, buffer)
+	}
+	require.NoError(t, err)
+	merkleValue = buffer.Bytes()
+
+	if len(merkleValue) >= 32 {
+		err = db.Put(merkleValue, encoding)
+		require.NoError(t, err)
+	}
+	return merkleValue
+}
+
+// countingPutter counts the database writes and fails all
+// database writes after maxPuts writes if maxPuts is not zero.
+type countingPutter struct {
+	MigrationDatabase
+	puts    int
+	maxPuts int
+}
+
+var errTest = errors.New("test error")
+
+func (c *countingPutter) Put(key, value []byte) (err error) {
+	if c.maxPuts > 0 && c.puts == c.maxPuts {
+		return errTest
+	}
+	c.puts++
+	return c.MigrationDatabase.Put(key, value)
+}
+
+// markersDB tracks the migration markers present in the database.
+type markersDB struct {
+	MigrationDatabase
+	markers map[string]struct{}
+}
+
+func (m *markersDB) Put(key, value []byte) (err error) {
+	if bytes.HasPrefix(key, migrationMarkerPrefix) {
+		m.markers[string(key)] = struct{}{}
+	}
+	return m.MigrationDatabase.Put(key, value)
+}
+
+func (m *markersDB) Del(key []byte) (err error) {
+	delete(m.markers, string(key))
+	return m.MigrationDatabase.Del(key)
+}
+
+func Test_MigrateStoredTrie(t *testing.T) {
+	t.Parallel()
+
+	const size = 500
+	trie, keyValues := makeSeededTrie(t, size)
+	expectedRoot := trie.MustHash()
+
+	db := newTestDB(t)
+	const isRoot = true
+	legacyRoot := common.NewHash(writeLegacyTrie(t, db, trie.root, isRoot))
+	require.NotEqual(t, expectedRoot, legacyRoot)
+
+	_, err := MigratedRoot(db, legacyRoot)
+	require.Error(t, err)
+
+	markers := &markersDB{
+		MigrationDatabase: db,
+		markers:           map[string]struct{}{},
+	}
+	newRoot, err := MigrateStoredTrie(markers, legacyRoot, decodeLegacy, encodeCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRoot, newRoot)
+
+	// Only the root marker is left once the migration completes.
+	expectedMarkers := map[string]struct{}{
+		string(migrationMarkerKey(legacyRoot.ToBytes())): {},
+	}
+	assert.Equal(t, expectedMarkers, markers.markers)
+
+	migratedRoot, err := MigratedRoot(db, legacyRoot)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRoot, migratedRoot)
+
+	trieFromDB := NewEmptyTrie()
+	err = trieFromDB.Load(db, newRoot)
+	require.NoError(t, err)
+	assert.Equal(t, keyValues, trieFromDB.Entries())
+}
+
+func Test_MigrateStoredTrie_resume(t *testing.T) {
+	t.Parallel()
+
+	const size = 500
+	trie, _ := makeSeededTrie(t, size)
+	expectedRoot := trie.MustHash()
+
+	db := newTestDB(t)
+	const isRoot = true
+	legacyRoot := common.NewHash(writeLegacyTrie(t, db, trie.root, isRoot))
+
+	markers := &markersDB{
+		MigrationDatabase: db,
+		markers:           map[string]struct{}{},
+	}
+
+	const putsBeforeCrash = 50
+	failingDB := &countingPutter{
+		MigrationDatabase: markers,
+		maxPuts:           putsBeforeCrash,
+	}
+	_, err := MigrateStoredTrie(failingDB, legacyRoot, decodeLegacy, encodeCurrent)
+	require.ErrorIs(t, err, errTest)
+
+	countingDB := &countingPutter{MigrationDatabase: markers}
+	newRoot, err := MigrateStoredTrie(countingDB, legacyRoot, decodeLegacy, encodeCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRoot, newRoot)
+
+	// The markers written before the crash are deleted as well.
+	expectedMarkers := map[string]struct{}{
+		string(migrationMarkerKey(legacyRoot.ToBytes())): {},
+	}
+	assert.Equal(t, expectedMarkers, markers.markers)
+
+	// Migrate in a fresh database to count the total number of writes,
+	// and check the resumed migration did not redo the migrated nodes.
+	freshDB := newTestDB(t)
+	writeLegacyTrie(t, freshDB, trie.root, isRoot)
+	freshCountingDB := &countingPutter{MigrationDatabase: freshDB}
+	_, err = MigrateStoredTrie(freshCountingDB, legacyRoot, decodeLegacy, encodeCurrent)
+	require.NoError(t, err)
+	// Note a node written just before the crash without its progress
+	// marker is written again when resuming the migration.
+	assert.Less(t, countingDB.puts, freshCountingDB.puts)
+	assert.GreaterOrEqual(t, countingDB.puts, freshCountingDB.puts-putsBeforeCrash)
+
+	// Migrating an already migrated trie does not write to the database.
+	countingDB = &countingPutter{MigrationDatabase: db}
+	newRoot, err = MigrateStoredTrie(countingDB, legacyRoot, decodeLegacy, encodeCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRoot, newRoot)
+	assert.Zero(t, countingDB.puts)
+}
+
+func Test_MigrateStoredTrie_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, _ := makeSeededTrie(t, size)
+
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	root := trie.MustHash()
+
+	legacyRoot, err := MigrateStoredTrie(db, root, decodeCurrent, encodeLegacy)
+	require.NoError(t, err)
+
+	newRoot, err := MigrateStoredTrie(db, legacyRoot, decodeLegacy, encodeCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, root, newRoot)
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/internal/trie/codec"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+var (
+	ErrQueryLimitReached = errors.New("remote proof query limit reached")
+	ErrProofNodeMissing  = errors.New("proof node missing")
+)
+
+// RemoteProofFetcher fetches the encoded proof nodes proving the values,
+// or their absence, of the given little Endian keys in the remote trie.
+// This is typically a state_getReadProof call to a remote node.
+type RemoteProofFetcher func(keysLE [][]byte) (encodedProofNodes [][]byte, err error)
+
+// Mismatch is a key having a different value in the local and
+// remote tries. A nil value means the key is not in the trie.
+type Mismatch struct {
+	Key         []byte
+	LocalValue  []byte
+	RemoteValue []byte
+}
+
+// FindMismatch compares the local trie stored in the database at
+// localRoot with the remote trie at remoteRoot, and returns up to
+// maxMismatches keys having different values in both tries, in
+// lexicographic order of keys.
+// Remote nodes are obtained from storage proofs fetched with
+// fetchRemoteProof, and only for subtrees having a different node hash
+// in both tries. Each remote node is verified against the node hash
+// referencing it, starting from remoteRoot.
+// At most maxQueries calls to fetchRemoteProof are made: if this is not
+// enough, the mismatches found so far are returned with an error
+// wrapping ErrQueryLimitReached.
+func FindMismatch(db Getter, localRoot, remoteRoot common.Hash,
+	fetchRemoteProof RemoteProofFetcher, maxMismatches, maxQueries uint) (
+	mismatches []Mismatch, err error) {
+	if localRoot == remoteRoot || maxMismatches == 0 {
+		return nil, nil
+	}
+
+	remoteDB := newRemoteProofDatabase(fetchRemoteProof, remoteRoot, maxQueries)
+
+	localCursor, err := newDiffCursorFromRoot(db, localRoot)
+	if err != nil {
+		return nil, fmt.Errorf("loading local root node: %w", err)
+	}
+
+	remoteCursor, err := newDiffCursorFromRoot(remoteDB, remoteRoot)
+	if err != nil {
+		return nil, fmt.Errorf("loading remote root node: %w", err)
+	}
+
+	d := &differ{
+		oldDB: db,
+		newDB: remoteDB,
+		handleDifference: func(keyLE, localValue, remoteValue []byte) (stop bool) {
+			mismatches = append(mismatches, Mismatch{
+				Key:         keyLE,
+				LocalValue:  localValue,
+				RemoteValue: remoteValue,
+			})
+			return uint(len(mismatches)) == maxMismatches
+		},
+	}
+	err = d.diff(localCursor, remoteCursor, nil)
+	if err != nil {
+		return mismatches, err
+	}
+
+	return mismatches, nil
+}
+
+// remoteProofDatabase is a read only database of remote trie nodes,
+// fetching the proof for the path of a node when the node is not
+// yet known.
+type remoteProofDatabase struct {
+	fetchProof RemoteProofFetcher
+	maxQueries uint
+	queries    uint
+	// encodings maps node hashes to verified node encodings.
+	encodings map[common.Hash][]byte
+	// paths maps node hashes referenced by known nodes to the
+	// nibbles path of the node in the trie.
+	paths map[common.Hash][]byte
+}
+
+func newRemoteProofDatabase(fetchProof RemoteProofFetcher,
+	rootHash common.Hash, maxQueries uint) *remoteProofDatabase {
+	return &remoteProofDatabase{
+		fetchProof: fetchProof,
+		maxQueries: maxQueries,
+		encodings:  make(map[common.Hash][]byte),
+		paths: map[common.Hash][]byte{
+			rootHash: {},
+		},
+	}
+}
+
+// Get returns the encoding of the remote node with the given node hash.
+func (r *remoteProofDatabase) Get(key []byte) (encoding []byte, err error) {
+	nodeHash := common.BytesToHash(key)
+	path, known := r.paths[nodeHash]
+	if !known {
+		return nil, fmt.Errorf("%w: node hash 0x%x is not referenced by a verified node",
+			ErrProofNodeMissing, key)
+	}
+
+	encoding, ok := r.encodings[nodeHash]
+	if !ok {
+		err = r.fetch(path)
+		if err != nil {
+			return nil, err
+		}
+
+		encoding, ok = r.encodings[nodeHash]
+		if !ok {
+			return nil, fmt.Errorf("%w: for node hash 0x%x at path 0x%x",
+				ErrProofNodeMissing, key, path)
+		}
+	}
+
+	decoded, err := node.Decode(bytes.NewReader(encoding))
+	if err != nil {
+		return nil, fmt.Errorf("decoding remote node with hash 0x%x: %w", key, err)
+	}
+	r.registerChildrenPaths(decoded, path)
+
+	return encoding, nil
+}
+
+// fetch fetches the proof for the given nibbles path, and
+// stores the encoded proof nodes by their node hash.
+func (r *remoteProofDatabase) fetch(path []byte) (err error) {
+	if r.queries == r.maxQueries {
+		return fmt.Errorf("%w: after %d queries", ErrQueryLimitReached, r.queries)
+	}
+	r.queries++
+
+	if len(path)%2 == 1 {
+		// Any key having the path as prefix crosses the node at this path.
+		path = concatenateSlices(path, []byte{0})
+	}
+	keyLE := codec.NibblesToKeyLE(path)
+
+	encodedProofNodes, err := r.fetchProof([][]byte{keyLE})
+	if err != nil {
+		return fmt.Errorf("fetching remote proof for key 0x%x: %w", keyLE, err)
+	}
+
+	for _, encodedProofNode := range encodedProofNodes {
+		nodeHash, err := common.Blake2bHash(encodedProofNode)
+		if err != nil {
+			return fmt.Errorf("hashing proof node: %w", err)
+		}
+		r.encodings[nodeHash] = encodedProofNode
+	}
+
+	return nil
+}
+
+// registerChildrenPaths registers the paths of the children of the
+// node given, and of its inlined descendants, which are only referenced
+// by their node hash in the node encoding.
+func (r *remoteProofDatabase) registerChildrenPaths(n *Node, path []byte) {
+	if n.Kind() == node.Leaf {
+		return
+	}
+
+	branchPath := concatenateSlices(path, n.PartialKey)
+	for i, child := range n.Children {
+		if child == nil {
+			continue
+		}
+
+		childPath := concatenateSlices(branchPath, []byte{byte(i)})
+		if isHashPointerStub(child) {
+			r.paths[common.BytesToHash(child.MerkleValue)] = childPath
+			continue
+		}
+		r.registerChildrenPaths(child, childPath)
+	}
+}
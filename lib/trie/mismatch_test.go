@@ -0,0 +1,158 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/internal/trie/codec"
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+// newTestProofFetcher returns a remote proof fetcher generating proofs
+// from the trie given, as well as a pointer to the number of queries made.
+// Proofs contain the encoded nodes on the path to each key, such that the
+// absence of a key is proven as well.
+func newTestProofFetcher(t *testing.T, remote *Trie) (
+	fetcher RemoteProofFetcher, queries *int) {
+	t.Helper()
+
+	queries = new(int)
+	fetcher = func(keysLE [][]byte) (encodedProofNodes [][]byte, err error) {
+		*queries++
+		for _, keyLE := range keysLE {
+			const isRoot = true
+			encodedProofNodes = appendPathEncodings(t, remote.root,
+				codec.KeyLEToNibbles(keyLE), isRoot, encodedProofNodes)
+		}
+		return encodedProofNodes, nil
+	}
+	return fetcher, queries
+}
+
+func appendPathEncodings(t *testing.T, n *Node, key []byte, isRoot bool,
+	encodings [][]byte) (newEncodings [][]byte) {
+	t.Helper()
+
+	buffer := bytes.NewBuffer(nil)
+	err := n.Encode(buffer)
+	require.NoError(t, err)
+	if isRoot || buffer.Len() >= 32 {
+		encodings = append(encodings, buffer.Bytes())
+	}
+
+	if n.Kind() == node.Leaf {
+		return encodings
+	}
+
+	commonPrefixLength := lenCommonPrefix(n.PartialKey, key)
+	if commonPrefixLength < len(n.PartialKey) || commonPrefixLength == len(key) {
+		return encodings
+	}
+
+	child := n.Children[key[commonPrefixLength]]
+	if child == nil {
+		return encodings
+	}
+
+	const childIsRoot = false
+	return appendPathEncodings(t, child, key[commonPrefixLength+1:], childIsRoot, encodings)
+}
+
+func Test_FindMismatch(t *testing.T) {
+	t.Parallel()
+
+	const size = 1000
+	local, keyValues := makeSeededTrie(t, size)
+	db := newTestDB(t)
+	err := local.WriteDirty(db)
+	require.NoError(t, err)
+	localRoot := local.MustHash()
+
+	keys := maps.Keys(keyValues)
+	sort.Strings(keys)
+	changedKey, removedKey := []byte(keys[10]), []byte(keys[500])
+	addedKey := append([]byte(keys[900]), 0xff)
+
+	remote := local.DeepCopy()
+	err = remote.Put(changedKey, []byte("changed"))
+	require.NoError(t, err)
+	err = remote.Delete(removedKey)
+	require.NoError(t, err)
+	err = remote.Put(addedKey, []byte("added"))
+	require.NoError(t, err)
+	remoteRoot := remote.MustHash()
+
+	expectedMismatches := []Mismatch{
+		{Key: changedKey, LocalValue: keyValues[string(changedKey)], RemoteValue: []byte("changed")},
+		{Key: removedKey, LocalValue: keyValues[string(removedKey)]},
+		{Key: addedKey, RemoteValue: []byte("added")},
+	}
+
+	t.Run("same_roots", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher, queries := newTestProofFetcher(t, local)
+		mismatches, err := FindMismatch(db, localRoot, localRoot, fetcher, 10, 10)
+
+		require.NoError(t, err)
+		assert.Empty(t, mismatches)
+		assert.Zero(t, *queries)
+	})
+
+	t.Run("all_mismatches", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher, queries := newTestProofFetcher(t, remote)
+		mismatches, err := FindMismatch(db, localRoot, remoteRoot, fetcher, 10, 100)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedMismatches, mismatches)
+		// Only the nodes on the paths to the mismatches are queried.
+		assert.Less(t, *queries, 15)
+	})
+
+	t.Run("first_mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher, _ := newTestProofFetcher(t, remote)
+		mismatches, err := FindMismatch(db, localRoot, remoteRoot, fetcher, 1, 100)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedMismatches[:1], mismatches)
+	})
+
+	t.Run("query_limit_reached", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher, queries := newTestProofFetcher(t, remote)
+		_, err := FindMismatch(db, localRoot, remoteRoot, fetcher, 10, 2)
+
+		require.ErrorIs(t, err, ErrQueryLimitReached)
+		assert.Equal(t, 2, *queries)
+	})
+
+	t.Run("tampered_proof", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher, _ := newTestProofFetcher(t, remote)
+		tamperedFetcher := func(keysLE [][]byte) (encodedProofNodes [][]byte, err error) {
+			encodedProofNodes, err = fetcher(keysLE)
+			for i, encodedProofNode := range encodedProofNodes {
+				tampered := append([]byte{}, encodedProofNode...)
+				tampered[len(tampered)-1]++
+				encodedProofNodes[i] = tampered
+			}
+			return encodedProofNodes, err
+		}
+		_, err := FindMismatch(db, localRoot, remoteRoot, tamperedFetcher, 10, 100)
+
+		require.ErrorIs(t, err, ErrProofNodeMissing)
+	})
+}
@@ -46,9 +46,9 @@ func Test_Generate(t *testing.T) {
 			},
 			errWrapped: errTest,
 			errMessage: "loading trie: " +
-				"failed to find root key " +
-				"0x000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f: " +
-				"test error",
+				"loading root node: getting node with hash " +
+				"0x000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f " +
+				"from database: test error",
 		},
 		"walk_error": {
 			rootHash:        someHash,
@@ -125,6 +125,29 @@ func (t *Trie) registerDeletedNodeHash(node *Node,
 	return nil
 }
 
+// registerDeletedNodeHashes registers the hashes of the given
+// node and of all its descendants as deleted.
+func (t *Trie) registerDeletedNodeHashes(n *Node,
+	pendingDeltas DeltaRecorder) (err error) {
+	if n == nil {
+		return nil
+	}
+
+	err = t.registerDeletedNodeHash(n, pendingDeltas)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range n.Children {
+		err = t.registerDeletedNodeHashes(child, pendingDeltas)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DeepCopy deep copies the trie and returns
 // the copy. Note this method is meant to be used
 // in tests and should not be used in production
@@ -317,6 +340,37 @@ func findNextKeyChild(children []*Node, startIndex byte,
 	return nil
 }
 
+// GetKeysPaged returns up to limit keys in little Endian format
+// from the trie, in lexicographic order, having the given prefix and
+// being strictly after the start key given. The start key can be left
+// nil to start from the first key having the prefix.
+func (t *Trie) GetKeysPaged(prefixLE, startAfterLE []byte, limit uint) (keysLE [][]byte) {
+	if limit == 0 {
+		return nil
+	}
+
+	cursor := startAfterLE
+	if bytes.Compare(startAfterLE, prefixLE) < 0 {
+		// The start key is before all the keys having the prefix,
+		// so start from the prefix, which can be a key itself.
+		cursor = prefixLE
+		if len(prefixLE) > 0 && t.Get(prefixLE) != nil {
+			keysLE = append(keysLE, prefixLE)
+		}
+	}
+
+	for uint(len(keysLE)) < limit {
+		nextKey := t.NextKey(cursor)
+		if nextKey == nil || !bytes.HasPrefix(nextKey, prefixLE) {
+			break
+		}
+		keysLE = append(keysLE, nextKey)
+		cursor = nextKey
+	}
+
+	return keysLE
+}
+
 // Put inserts a value into the trie at the
 // key specified in little Endian format.
 func (t *Trie) Put(keyLE, value []byte) (err error) {
@@ -759,6 +759,120 @@ func Test_Trie_NextKey(t *testing.T) {
 	}
 }
 
+func Test_Trie_NextKey_sharedPrefixes(t *testing.T) {
+	t.Parallel()
+
+	sortedKeys := [][]byte{
+		{0x01},
+		{0x01, 0x00},
+		{0x01, 0x00, 0x00},
+		{0x01, 0x00, 0x01},
+		{0x01, 0x01},
+		{0x01, 0x10},
+		{0x01, 0x10, 0xff},
+		{0x10},
+		{0x10, 0x01},
+		{0x11},
+		{0xf0, 0x0f},
+		{0xff},
+	}
+
+	trie := NewEmptyTrie()
+	for _, key := range sortedKeys {
+		err := trie.Put(key, []byte{1})
+		require.NoError(t, err)
+	}
+
+	var enumeratedKeys [][]byte
+	for key := trie.NextKey(nil); key != nil; key = trie.NextKey(key) {
+		enumeratedKeys = append(enumeratedKeys, key)
+	}
+
+	assert.Equal(t, sortedKeys, enumeratedKeys)
+}
+
+func Test_Trie_GetKeysPaged(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	for _, key := range [][]byte{
+		{0x01},
+		{0x01, 0x00},
+		{0x01, 0x01},
+		{0x01, 0x10},
+		{0x01, 0x10, 0xff},
+		{0x02},
+		{0x02, 0x01},
+	} {
+		err := trie.Put(key, []byte{1})
+		require.NoError(t, err)
+	}
+
+	testCases := map[string]struct {
+		prefix     []byte
+		startAfter []byte
+		limit      uint
+		keys       [][]byte
+	}{
+		"zero_limit": {
+			limit: 0,
+		},
+		"all_keys": {
+			limit: 100,
+			keys: [][]byte{
+				{0x01}, {0x01, 0x00}, {0x01, 0x01}, {0x01, 0x10},
+				{0x01, 0x10, 0xff}, {0x02}, {0x02, 0x01},
+			},
+		},
+		"first_page": {
+			limit: 3,
+			keys:  [][]byte{{0x01}, {0x01, 0x00}, {0x01, 0x01}},
+		},
+		"next_page": {
+			startAfter: []byte{0x01, 0x01},
+			limit:      3,
+			keys:       [][]byte{{0x01, 0x10}, {0x01, 0x10, 0xff}, {0x02}},
+		},
+		"prefix_is_key": {
+			prefix: []byte{0x01},
+			limit:  2,
+			keys:   [][]byte{{0x01}, {0x01, 0x00}},
+		},
+		"prefix_with_start_key": {
+			prefix:     []byte{0x01},
+			startAfter: []byte{0x01, 0x01},
+			limit:      100,
+			keys:       [][]byte{{0x01, 0x10}, {0x01, 0x10, 0xff}},
+		},
+		"start_key_before_prefix": {
+			prefix:     []byte{0x02},
+			startAfter: []byte{0x01, 0x10},
+			limit:      100,
+			keys:       [][]byte{{0x02}, {0x02, 0x01}},
+		},
+		"start_key_after_last_key": {
+			startAfter: []byte{0xff},
+			limit:      100,
+		},
+		"prefix_without_keys": {
+			prefix: []byte{0x03},
+			limit:  100,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			keys := trie.GetKeysPaged(testCase.prefix,
+				testCase.startAfter, testCase.limit)
+
+			assert.Equal(t, testCase.keys, keys)
+		})
+	}
+}
+
 func Test_nextKey(t *testing.T) {
 	// Note this test is basically testing trie.NextKey without
 	// the headaches associated with converting nibbles and
@@ -0,0 +1,217 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/internal/trie/node"
+)
+
+var (
+	ErrInvalidNibble         = errors.New("invalid nibble in partial key")
+	ErrOddKeyLength          = errors.New("storage value at key with an odd number of nibbles")
+	ErrLeafWithoutValue      = errors.New("leaf has no storage value")
+	ErrInvalidChildrenLength = errors.New("invalid branch children slice length")
+	ErrBranchWithoutChildren = errors.New("branch has no child")
+	ErrBranchNotMerged       = errors.New("branch without storage value has a single child")
+	ErrDirtyChildOfCleanNode = errors.New("dirty child of a clean node")
+	ErrDescendantsMismatch   = errors.New("descendants count mismatch")
+	ErrNodeNotInDatabase     = errors.New("node not found in database")
+	ErrMerkleValueMismatch   = errors.New("cached Merkle value mismatch")
+)
+
+// ValidationSettings are settings to validate the trie.
+type ValidationSettings struct {
+	// Database, if not nil, is used to check each node not modified
+	// since it was last written is stored in the database.
+	Database Getter
+	// Strict can be set to true to check each cached Merkle value
+	// of the nodes not modified matches its recomputed Merkle value.
+	// This is as expensive as hashing the entire trie.
+	Strict bool
+}
+
+// Validate walks the trie and its child tries, and returns an error if
+// a structural invariant is not respected by a node of the trie.
+// See ValidateWithSettings to check nodes against the database and to
+// check cached Merkle values.
+func (t *Trie) Validate() (err error) {
+	return t.ValidateWithSettings(ValidationSettings{})
+}
+
+// ValidateWithSettings walks the trie and its child tries, and returns an
+// error if a structural invariant is not respected by a node of the trie.
+// The invariants checked are:
+//   - partial key nibbles are between 0 and 15
+//   - storage values are at keys with an even number of nibbles
+//   - leaves have a storage value
+//   - branches have 16 children slots and at least one child
+//   - branches without storage value have at least two children,
+//     since a single child should have been merged with its parent
+//   - parents of dirty nodes are dirty
+//   - the descendants count of branches is correct
+//
+// Nodes stored in the database and cached Merkle values can also be
+// checked depending on the settings given.
+func (t *Trie) ValidateWithSettings(settings ValidationSettings) (err error) {
+	if t.root != nil {
+		const isRoot = true
+		_, err = validateNode(t.root, nil, isRoot, settings)
+		if err != nil {
+			return err
+		}
+	}
+
+	for rootHash, childTrie := range t.childTries {
+		err = childTrie.ValidateWithSettings(settings)
+		if err != nil {
+			return fmt.Errorf("validating child trie with root hash %s: %w", rootHash, err)
+		}
+	}
+
+	return nil
+}
+
+// validateNode validates the node given and its descendants, where path
+// is the nibbles key of the node without its partial key. It returns the
+// number of descendant nodes of the node given.
+func validateNode(n *Node, path []byte, isRoot bool,
+	settings ValidationSettings) (descendants uint32, err error) {
+	for _, nibble := range n.PartialKey {
+		if nibble > 0xf {
+			return 0, fmt.Errorf("%w: partial key 0x%x at path %s",
+				ErrInvalidNibble, n.PartialKey, nibblesToString(path))
+		}
+	}
+	fullKey := concatenateSlices(path, n.PartialKey)
+
+	if n.StorageValue != nil && len(fullKey)%2 == 1 {
+		return 0, fmt.Errorf("%w: at path %s", ErrOddKeyLength, nibblesToString(fullKey))
+	}
+
+	if n.Kind() == node.Leaf {
+		if n.StorageValue == nil {
+			return 0, fmt.Errorf("%w: at path %s", ErrLeafWithoutValue, nibblesToString(fullKey))
+		}
+	} else {
+		descendants, err = validateBranchChildren(n, fullKey, settings)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return 0, err
+		}
+	}
+
+	if n.Descendants != descendants {
+		return 0, fmt.Errorf("%w: node at path %s has %d descendants instead of %d",
+			ErrDescendantsMismatch, nibblesToString(fullKey), n.Descendants, descendants)
+	}
+
+	err = validateCleanNode(n, isRoot, settings)
+	if err != nil {
+		return 0, fmt.Errorf("for node at path %s: %w", nibblesToString(fullKey), err)
+	}
+
+	return descendants, nil
+}
+
+// validateBranchChildren validates the children of the branch given
+// at the given full nibbles key, and returns the number of descendant
+// nodes of the branch.
+func validateBranchChildren(branch *Node, fullKey []byte,
+	settings ValidationSettings) (descendants uint32, err error) {
+	if len(branch.Children) != node.ChildrenCapacity {
+		return 0, fmt.Errorf("%w: %d for branch at path %s", ErrInvalidChildrenLength,
+			len(branch.Children), nibblesToString(fullKey))
+	}
+
+	childrenCount := 0
+	for i, child := range branch.Children {
+		if child == nil {
+			continue
+		}
+		childrenCount++
+
+		childPath := concatenateSlices(fullKey, []byte{byte(i)})
+		if child.Dirty && !branch.Dirty {
+			return 0, fmt.Errorf("%w: at path %s", ErrDirtyChildOfCleanNode,
+				nibblesToString(childPath))
+		}
+
+		const childIsRoot = false
+		childDescendants, err := validateNode(child, childPath, childIsRoot, settings)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return 0, err
+		}
+		descendants += 1 + childDescendants
+	}
+
+	switch {
+	case childrenCount == 0:
+		return 0, fmt.Errorf("%w: at path %s", ErrBranchWithoutChildren, nibblesToString(fullKey))
+	case childrenCount == 1 && branch.StorageValue == nil:
+		return 0, fmt.Errorf("%w: at path %s", ErrBranchNotMerged, nibblesToString(fullKey))
+	}
+
+	return descendants, nil
+}
+
+// validateCleanNode checks the node, if not modified since its Merkle value
+// was last computed, is in the database and has a correct Merkle value,
+// depending on the settings given.
+func validateCleanNode(n *Node, isRoot bool, settings ValidationSettings) (err error) {
+	if n.Dirty || n.MerkleValue == nil {
+		return nil
+	}
+
+	const hashLength = 32
+	if settings.Database != nil && len(n.MerkleValue) == hashLength {
+		_, err = settings.Database.Get(n.MerkleValue)
+		if err != nil {
+			return fmt.Errorf("%w: node hash 0x%x: %s", ErrNodeNotInDatabase, n.MerkleValue, err)
+		}
+	}
+
+	if !settings.Strict {
+		return nil
+	}
+
+	encoding := bytes.NewBuffer(nil)
+	err = n.Encode(encoding)
+	if err != nil {
+		return fmt.Errorf("encoding node: %w", err)
+	}
+
+	merkleValue := bytes.NewBuffer(nil)
+	if isRoot {
+		err = node.MerkleValueRoot(encoding.Bytes(), merkleValue)
+	} else {
+		err = node.MerkleValue(encoding.Bytes(), merkleValue)
+	}
+	if err != nil {
+		return fmt.Errorf("computing Merkle value: %w", err)
+	}
+
+	if !bytes.Equal(n.MerkleValue, merkleValue.Bytes()) {
+		return fmt.Errorf("%w: cached 0x%x but computed 0x%x",
+			ErrMerkleValueMismatch, n.MerkleValue, merkleValue.Bytes())
+	}
+
+	return nil
+}
+
+// nibblesToString returns the nibbles given as
+// a 0x prefixed hexadecimal string of nibbles.
+func nibblesToString(nibbles []byte) (s string) {
+	const hexDigits = "0123456789abcdef"
+	b := make([]byte, 2, 2+len(nibbles))
+	b[0], b[1] = '0', 'x'
+	for _, nibble := range nibbles {
+		b = append(b, hexDigits[nibble&0xf])
+	}
+	return string(b)
+}
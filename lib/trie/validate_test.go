@@ -0,0 +1,210 @@
+// Copyright 2023 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_Validate_validTries(t *testing.T) {
+	t.Parallel()
+
+	const size = 1000
+	trie, keyValues := makeSeededTrie(t, size)
+	err := trie.Validate()
+	require.NoError(t, err)
+
+	db := newTestDB(t)
+	err = trie.WriteDirty(db)
+	require.NoError(t, err)
+	settings := ValidationSettings{Database: db, Strict: true}
+	err = trie.ValidateWithSettings(settings)
+	require.NoError(t, err)
+
+	trieFromDB := NewEmptyTrie()
+	err = trieFromDB.Load(db, trie.MustHash())
+	require.NoError(t, err)
+	err = trieFromDB.ValidateWithSettings(settings)
+	require.NoError(t, err)
+
+	// Deleting keys, including the empty value keys to merge
+	// branches, must keep the trie structure valid.
+	generator := newGenerator()
+	for i, key := range pickKeys(keyValues, generator, size/2) {
+		if i%3 == 0 {
+			err = trieFromDB.Put(key, []byte{})
+		} else {
+			err = trieFromDB.ClearPrefix(key[:len(key)/2])
+		}
+		require.NoError(t, err)
+		err = trieFromDB.Delete(key)
+		require.NoError(t, err)
+	}
+	err = trieFromDB.Validate()
+	require.NoError(t, err)
+
+	err = trieFromDB.WriteDirty(db)
+	require.NoError(t, err)
+	err = trieFromDB.ValidateWithSettings(settings)
+	require.NoError(t, err)
+}
+
+func Test_Trie_ValidateWithSettings(t *testing.T) {
+	t.Parallel()
+
+	someHash := bytes.Repeat([]byte{1}, 32)
+
+	testCases := map[string]struct {
+		trie       *Trie
+		settings   ValidationSettings
+		errWrapped error
+		errMessage string
+	}{
+		"empty_trie": {
+			trie: NewEmptyTrie(),
+		},
+		"invalid_nibble": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 0x10},
+				StorageValue: []byte{1},
+			}),
+			errWrapped: ErrInvalidNibble,
+			errMessage: "invalid nibble in partial key: partial key 0x0110 at path 0x",
+		},
+		"odd_key_length": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1},
+				StorageValue: []byte{1},
+			}),
+			errWrapped: ErrOddKeyLength,
+			errMessage: "storage value at key with an odd number of nibbles: at path 0x1",
+		},
+		"leaf_without_value": {
+			trie: NewTrie(&Node{
+				PartialKey: []byte{1, 2},
+			}),
+			errWrapped: ErrLeafWithoutValue,
+			errMessage: "leaf has no storage value: at path 0x12",
+		},
+		"invalid_children_length": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{1},
+				Children:     []*Node{{StorageValue: []byte{1}}},
+			}),
+			errWrapped: ErrInvalidChildrenLength,
+			errMessage: "invalid branch children slice length: 1 for branch at path 0x12",
+		},
+		"branch_without_children": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{1},
+				Children:     padRightChildren(nil),
+			}),
+			errWrapped: ErrBranchWithoutChildren,
+			errMessage: "branch has no child: at path 0x12",
+		},
+		"branch_not_merged": {
+			trie: NewTrie(&Node{
+				PartialKey: []byte{1},
+				Children: padRightChildren([]*Node{
+					nil,
+					{StorageValue: []byte{1}},
+				}),
+				Descendants: 1,
+			}),
+			errWrapped: ErrBranchNotMerged,
+			errMessage: "branch without storage value has a single child: at path 0x1",
+		},
+		"dirty_child_of_clean_node": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{1},
+				Children: padRightChildren([]*Node{
+					{PartialKey: []byte{3}, StorageValue: []byte{1}},
+					{PartialKey: []byte{3}, StorageValue: []byte{2}, Dirty: true},
+				}),
+				Descendants: 2,
+			}),
+			errWrapped: ErrDirtyChildOfCleanNode,
+			errMessage: "dirty child of a clean node: at path 0x121",
+		},
+		"descendants_mismatch": {
+			trie: NewTrie(&Node{
+				PartialKey: []byte{1},
+				Children: padRightChildren([]*Node{
+					{StorageValue: []byte{1}},
+					{StorageValue: []byte{2}},
+				}),
+				Descendants: 1,
+			}),
+			errWrapped: ErrDescendantsMismatch,
+			errMessage: "descendants count mismatch: node at path 0x1 has 1 descendants instead of 2",
+		},
+		"node_not_in_database": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{1},
+				MerkleValue:  someHash,
+			}),
+			settings:   ValidationSettings{Database: newTestDB(t)},
+			errWrapped: ErrNodeNotInDatabase,
+			errMessage: "for node at path 0x12: node not found in database: " +
+				"node hash 0x0101010101010101010101010101010101010101010101010101010101010101: " +
+				"Key not found",
+		},
+		"dirty_node_not_in_database": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{1},
+				MerkleValue:  someHash,
+				Dirty:        true,
+			}),
+			settings: ValidationSettings{Database: newTestDB(t), Strict: true},
+		},
+		"cached_merkle_value_mismatch": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{1},
+				MerkleValue:  someHash,
+			}),
+			settings:   ValidationSettings{Strict: true},
+			errWrapped: ErrMerkleValueMismatch,
+			errMessage: "for node at path 0x12: cached Merkle value mismatch: " +
+				"cached 0x0101010101010101010101010101010101010101010101010101010101010101 " +
+				"but computed 0x81df6f41f22b756be5b3de256c9af4110b5d47fea97540608eefb37c359c9067",
+		},
+		"invalid_child_trie": {
+			trie: &Trie{
+				childTries: map[common.Hash]*Trie{
+					{1}: NewTrie(&Node{PartialKey: []byte{1, 2}}),
+				},
+			},
+			errWrapped: ErrLeafWithoutValue,
+			errMessage: "validating child trie with root hash " +
+				"0x0100000000000000000000000000000000000000000000000000000000000000: " +
+				"leaf has no storage value: at path 0x12",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.trie.ValidateWithSettings(testCase.settings)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
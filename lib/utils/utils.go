@@ -15,19 +15,26 @@
 	"testing"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/internal/database"
 	"github.com/dgraph-io/badger/v2"
+	badgerv4 "github.com/dgraph-io/badger/v4"
 	"github.com/stretchr/testify/require"
 )
 
 // DefaultDatabaseDir directory inside basepath where database contents are stored
 const DefaultDatabaseDir = "db"
 
-// SetupDatabase will return an instance of database based on basepath
-func SetupDatabase(basepath string, inMemory bool) (*chaindb.BadgerDB, error) {
-	return chaindb.NewBadgerDB(&chaindb.Config{
-		DataDir:  filepath.Join(basepath, DefaultDatabaseDir),
-		InMemory: inMemory,
-	})
+// SetupDatabase will return an instance of database based on basepath,
+// opened with the default database options.
+func SetupDatabase(basepath string, inMemory bool) (*database.BadgerDB, error) {
+	return SetupDatabaseWithOptions(basepath, inMemory, database.DefaultOptions())
+}
+
+// SetupDatabaseWithOptions will return an instance of database based on
+// basepath, opened with the given database options.
+func SetupDatabaseWithOptions(basepath string, inMemory bool, options database.Options) (
+	*database.BadgerDB, error) {
+	return database.New(filepath.Join(basepath, DefaultDatabaseDir), inMemory, options)
 }
 
 // PathExists returns true if the named file or directory exists, otherwise false
@@ -269,6 +276,26 @@ func LoadChainDB(basePath string) (*chaindb.BadgerDB, error) {
 	return db, nil
 }
 
+// ErrDatabaseLocked is returned when opening a database
+// locked by another process, such as a running node.
+var ErrDatabaseLocked = errors.New("database is locked by another process")
+
+// LoadReadOnlyBadgerDB loads the db at the given path in read-only mode.
+// It returns an error wrapping ErrDatabaseLocked if the database is
+// opened in read-write mode by another process.
+func LoadReadOnlyBadgerDB(basePath string) (*badgerv4.DB, error) {
+	opts := badgerv4.DefaultOptions(basePath).WithReadOnly(true).WithLogger(nil)
+	db, err := badgerv4.Open(opts)
+	if err != nil {
+		if strings.Contains(err.Error(), "Cannot acquire directory lock") {
+			return nil, fmt.Errorf("%w: %s", ErrDatabaseLocked, basePath)
+		}
+		return nil, err
+	}
+
+	return db, nil
+}
+
 // LoadBadgerDB load the db at the given path.
 func LoadBadgerDB(basePath string) (*badger.DB, error) {
 	opts := badger.DefaultOptions(basePath)
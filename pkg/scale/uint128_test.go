@@ -53,3 +53,14 @@ func TestUint128_Cmp(t *testing.T) {
 	require.Equal(t, 1, u0.Compare(u3))
 	require.Equal(t, -1, u3.Compare(u0))
 }
+
+func TestUint128_String(t *testing.T) {
+	u := &Uint128{Lower: 11939839815}
+	require.Equal(t, "11939839815", u.String())
+
+	bi, ok := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	require.True(t, ok)
+	u, err := NewUint128(bi)
+	require.NoError(t, err)
+	require.Equal(t, bi.String(), u.String())
+}
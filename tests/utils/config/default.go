@@ -38,11 +38,12 @@ func Default() cfg.Config {
 			Unlock: "",
 		},
 		Core: &cfg.CoreConfig{
-			Role:             4,
-			BabeAuthority:    true,
-			GrandpaAuthority: true,
-			GrandpaInterval:  1 * time.Second,
-			WasmInterpreter:  wasmer.Name,
+			Role:                   4,
+			BabeAuthority:          true,
+			GrandpaAuthority:       true,
+			GrandpaInterval:        1 * time.Second,
+			WasmInterpreter:        wasmer.Name,
+			ValidateBlockAnnounces: true,
 		},
 		Network: &cfg.NetworkConfig{
 			Bootnodes:         nil,